@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/reggo/reggo/supervised/nnet"
+)
+
+// activators are the hidden layer activation functions by name.
+var activators = map[string]nnet.Activator{
+	"tanh":       nnet.Tanh{},
+	"lineartanh": nnet.LinearTanh{},
+	"sigmoid":    nnet.Sigmoid{},
+	"relu":       relu{},
+	"linear":     nnet.Linear{},
+}
+
+// activatorNames returns the names of the activators in sorted order.
+func activatorNames() []string {
+	names := make([]string, 0, len(activators))
+	for name := range activators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// relu is the rectified linear activation function, out = max(0, sum). The
+// derivative at zero is taken to be zero.
+type relu struct{}
+
+func (relu) Activate(sum float64) float64 {
+	if sum > 0 {
+		return sum
+	}
+	return 0
+}
+
+func (relu) DActivateDCombination(sum, output float64) float64 {
+	if sum > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (relu) String() string {
+	return "ReLU"
+}
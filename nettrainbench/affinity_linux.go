@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// maxCPUs is the number of processors the affinity mask can hold.
+const maxCPUs = 1024
+
+// setAffinity pins every thread of the process to the processors. Threads
+// started later inherit the mask of the thread starting them, so it makes two
+// passes to catch any started during the first.
+func setAffinity(cpus []int) error {
+	var mask [maxCPUs / 64]uint64
+	for _, c := range cpus {
+		if c >= maxCPUs {
+			return fmt.Errorf("cpu %d exceeds the %d supported", c, maxCPUs)
+		}
+		mask[c/64] |= 1 << uint(c%64)
+	}
+	for pass := 0; pass < 2; pass++ {
+		tasks, err := os.ReadDir("/proc/self/task")
+		if err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			tid, err := strconv.Atoi(t.Name())
+			if err != nil {
+				continue
+			}
+			_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(tid), unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+			if errno != 0 && errno != syscall.ESRCH { // ESRCH if the thread has exited
+				return fmt.Errorf("pinning to cpus %v: %v", cpus, errno)
+			}
+		}
+	}
+	return nil
+}
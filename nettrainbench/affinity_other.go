@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"runtime"
+)
+
+func setAffinity(cpus []int) error {
+	return errors.New("pinning to cpus is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// readRecords reads the records of the finished runs of a JSON results file.
+func readRecords(filename string) ([]Record, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []Record
+	if err := json.NewDecoder(f).Decode(&records); err != nil {
+		return nil, fmt.Errorf("results %s: %v", filename, err)
+	}
+	return finished(records), nil
+}
+
+// meanTimes returns the mean ns/eval of the records by name, or ns/op if
+// perOp is set, and the names in order of first appearance.
+func meanTimes(records []Record, perOp bool) (map[string]float64, []string) {
+	sum := make(map[string]float64)
+	n := make(map[string]int)
+	var names []string
+	for _, r := range records {
+		if n[r.Name] == 0 {
+			names = append(names, r.Name)
+		}
+		if perOp {
+			sum[r.Name] += float64(r.NsPerOp)
+		} else {
+			sum[r.Name] += r.NsPerEval
+		}
+		n[r.Name]++
+	}
+	for name := range sum {
+		sum[name] /= float64(n[name])
+	}
+	return sum, names
+}
+
+// compareBaseline writes the change in mean time per evaluation of each case
+// relative to the baseline, and returns the names of the cases that are slower
+// by more than the threshold fraction. Time per run is compared instead if the
+// baseline predates ns/eval. Cases missing from the baseline are reported but
+// never regress. A note is written first if the baseline ran on a different
+// machine.
+func compareBaseline(w io.Writer, baseline, records []Record, threshold float64) (regressed []string, err error) {
+	if len(baseline) > 0 && len(records) > 0 {
+		old, cur := baseline[0].Env, records[0].Env
+		if old.Machine != "" && cur.Machine != "" && old.Machine != cur.Machine {
+			fmt.Fprintf(w, "note: baseline ran on machine %s (%s, %d cores, %s), not %s (%s, %d cores, %s)\n",
+				old.Machine, old.CPU, old.Cores, old.OS, cur.Machine, cur.CPU, cur.Cores, cur.OS)
+		}
+	}
+	unit, perOp := "ns/eval", false
+	for _, r := range baseline {
+		if r.NsPerEval == 0 {
+			unit, perOp = "ns/op", true
+		}
+	}
+	old, _ := meanTimes(baseline, perOp)
+	cur, names := meanTimes(records, perOp)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "case\tbaseline %s\t%s\tdelta\t\n", unit, unit)
+	for _, name := range names {
+		base, ok := old[name]
+		if !ok {
+			fmt.Fprintf(tw, "%s\t-\t%.0f\tnew\t\n", name, cur[name])
+			continue
+		}
+		delta := cur[name]/base - 1
+		mark := ""
+		if delta > threshold {
+			mark = "REGRESSION"
+			regressed = append(regressed, name)
+		}
+		fmt.Fprintf(tw, "%s\t%.0f\t%.0f\t%+.1f%%\t%s\n", name, base, cur[name], 100*delta, mark)
+	}
+	return regressed, tw.Flush()
+}
@@ -0,0 +1,161 @@
+// Package benchdata loads the datasets of the nettrainbench program and its
+// benchmarks, memoized so that the cases and benchmarks of a process that
+// share a dataset read, generate or scale it once. The returned matrices are
+// shared between callers and must not be modified; callers that scale or
+// reorder the data copy it first.
+package benchdata
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/btracey/gobench/nettrainbench/datagen"
+	"github.com/btracey/numcsv"
+	"github.com/btracey/numcsv/dataset"
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/scale"
+)
+
+// Exp4 is the turbulence modelling dataset of the benchmarks. The file is
+// expected in the working directory.
+var Exp4 = dataset.Info{
+	Name:    "exp4",
+	Source:  "data.txt",
+	SHA256:  "c283420d9149d4858034d2c33c6c62ab416737678c795414192d44673e861ee7",
+	Comma:   " ", // the file is space dilimeted (ish)
+	Rows:    1028787,
+	Cols:    4,
+	Inputs:  []string{"Col1", "Col2", "Col3"},
+	Targets: []string{"Col4"},
+}
+
+// Exp4Fallback generates data like Exp4 when its file is missing.
+var Exp4Fallback = datagen.Spec{Kind: datagen.Turbulence, N: 100000, Seed: 1}
+
+// fallbacks are the synthetic data of the registered datasets that
+// LoadDataset uses when their files are missing.
+var fallbacks = map[string]datagen.Spec{Exp4.Name: Exp4Fallback}
+
+func init() {
+	if err := dataset.Register(Exp4); err != nil {
+		panic(err)
+	}
+}
+
+// entry is a memoized result, computed once by the first caller.
+type entry struct {
+	once            sync.Once
+	data            *mat64.Dense
+	inputs, outputs *mat64.Dense
+	err             error
+}
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]*entry)
+)
+
+// memo returns the entry of the key, computing it with load the first time.
+func memo(key string, load func(e *entry)) *entry {
+	mu.Lock()
+	e, ok := cache[key]
+	if !ok {
+		e = &entry{}
+		cache[key] = e
+	}
+	mu.Unlock()
+	e.once.Do(func() { load(e) })
+	return e
+}
+
+// LoadFile returns the data of the named file, which has a row of column
+// headings above rows of numbers separated by comma.
+func LoadFile(filename, comma string) (*mat64.Dense, error) {
+	e := memo("file\x00"+filename+"\x00"+comma, func(e *entry) {
+		f, err := os.Open(filename)
+		if err != nil {
+			e.err = err
+			return
+		}
+		defer f.Close()
+		// numcsv is a wrapper I wrote over the normal go csv parser. The Go csv
+		// parser returns strings. This assumes that the data is numeric with possibly
+		// some column headings at the top, so it returns a matrix of data instead
+		// of strings.
+		r := numcsv.NewReader(f)
+		r.Comma = comma
+		if _, err := r.ReadHeading(); err != nil {
+			e.err = err
+			return
+		}
+		e.data, e.err = r.ReadAll()
+	})
+	return e.data, e.err
+}
+
+// Generate returns the synthetic data of the spec.
+func Generate(spec datagen.Spec) (*mat64.Dense, error) {
+	e := memo(fmt.Sprintf("synthetic\x00%+v", spec), func(e *entry) {
+		e.data, e.err = datagen.Generate(spec)
+	})
+	return e.data, e.err
+}
+
+// loadDataset returns the inputs and outputs of the dataset registered under
+// name with numcsv/dataset, checked against its checksum and shape and split
+// by its column roles, or of its fallback if its file is missing.
+func loadDataset(name string) (inputs, outputs *mat64.Dense, err error) {
+	e := memo("dataset\x00"+name, func(e *entry) {
+		d, err := dataset.Load(name)
+		if spec, ok := fallbacks[name]; ok && os.IsNotExist(err) {
+			info, _ := dataset.Lookup(name)
+			log.Printf("%s not found, using synthetic %s data", info.Source, spec.Kind)
+			var data *mat64.Dense
+			data, err = Generate(spec)
+			if err != nil {
+				e.err = err
+				return
+			}
+			_, c := data.Dims()
+			d = &numcsv.Dataset{Headings: datagen.Headings(c-1, 1), Data: data}
+			d.SetRoles(numcsv.Input, d.Headings[:c-1]...)
+			d.SetRoles(numcsv.Target, d.Headings[c-1])
+		} else if err != nil {
+			e.err = err
+			return
+		}
+		e.inputs, e.outputs, _, e.err = d.Split()
+	})
+	return e.inputs, e.outputs, e.err
+}
+
+// LoadDataset returns the first n samples of the dataset registered under
+// name with numcsv/dataset, such as Exp4, split into inputs and outputs by its
+// column roles and each scaled to mean zero and variance one. If the file of a
+// dataset with a fallback, such as Exp4, is missing, its synthetic data is
+// used in its place. The dataset is read once, and scaled once for each n.
+func LoadDataset(name string, n int) (inputs, outputs *mat64.Dense, err error) {
+	e := memo(fmt.Sprintf("scaled\x00%s\x00%d", name, n), func(e *entry) {
+		allInputs, allOutputs, err := loadDataset(name)
+		if err != nil {
+			e.err = err
+			return
+		}
+		rows, inputDim := allInputs.Dims()
+		_, outputDim := allOutputs.Dims()
+		if n > rows {
+			e.err = fmt.Errorf("benchdata: %d samples of dataset %q, which has %d", n, name, rows)
+			return
+		}
+		// Copy the samples from submatrices of the split data, then scale the
+		// copies. ScaleData alone does not set the scale, so the scalers are
+		// fit by ScaleTrainingData.
+		e.inputs, e.outputs = &mat64.Dense{}, &mat64.Dense{}
+		e.inputs.Submatrix(allInputs, 0, 0, n, inputDim)
+		e.outputs.Submatrix(allOutputs, 0, 0, n, outputDim)
+		e.err = scale.ScaleTrainingData(e.inputs, e.outputs, &scale.Normal{}, &scale.Normal{})
+	})
+	return e.inputs, e.outputs, e.err
+}
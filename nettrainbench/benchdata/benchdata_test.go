@@ -0,0 +1,83 @@
+package benchdata
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonum/blas/goblas"
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func init() {
+	mat64.Register(goblas.Blas{})
+}
+
+func TestLoadFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filename, []byte("a b\n1 2\n3 4\n5 6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadFile(filename, " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Equals(mat64.NewDense(3, 2, []float64{1, 2, 3, 4, 5, 6})) {
+		t.Errorf("read %v", d)
+	}
+	if again, _ := LoadFile(filename, " "); again != d {
+		t.Error("file read again")
+	}
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.txt"), " "); !os.IsNotExist(err) {
+		t.Errorf("missing file: error %v", err)
+	}
+}
+
+func TestLoadDataset(t *testing.T) {
+	// The test runs without data.txt, so exp4 falls back to synthetic data.
+	if _, err := os.Stat(Exp4.Source); err == nil {
+		t.Skipf("%s is present", Exp4.Source)
+	}
+	inputs, outputs, err := LoadDataset(Exp4.Name, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := inputs.Dims(); r != 1000 || c != 3 {
+		t.Fatalf("inputs %d×%d, want 1000×3", r, c)
+	}
+	col := make([]float64, 1000)
+	for _, m := range []*mat64.Dense{inputs, outputs} {
+		_, c := m.Dims()
+		for j := 0; j < c; j++ {
+			m.Col(col, j)
+			mean := floats.Sum(col) / 1000
+			if math.Abs(mean) > 1e-10 {
+				t.Errorf("column %d has mean %v after scaling", j, mean)
+			}
+		}
+	}
+	if again, _, _ := LoadDataset(Exp4.Name, 1000); again != inputs {
+		t.Error("dataset scaled again")
+	}
+	if _, _, err := LoadDataset(Exp4.Name, Exp4Fallback.N+1); err == nil {
+		t.Error("no error for more samples than the dataset has")
+	}
+	if _, _, err := LoadDataset("nonesuch", 10); err == nil {
+		t.Error("no error for an unregistered dataset")
+	}
+	d, err := Generate(Exp4Fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := Exp4Fallback
+	spec.Seed++
+	other, err := Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := Generate(Exp4Fallback); again != d || other == d {
+		t.Error("generated data not kept by spec")
+	}
+}
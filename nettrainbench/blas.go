@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gonum/blas"
+	"github.com/gonum/blas/dbw"
+	"github.com/gonum/blas/goblas"
+	"github.com/gonum/matrix/mat64"
+)
+
+// blasBackends holds the BLAS implementations available to -blas. Backends
+// using C libraries are added by files built with the matching build tag. The
+// tags are exclusive: openblas takes precedence over atlas, and either over
+// cblas.
+var blasBackends = map[string]blas.Float64{
+	"go": goblas.Blas{},
+}
+
+// blasLibraries holds the file name prefix of the shared library of each C
+// backend, to find the one loaded.
+var blasLibraries = map[string]string{}
+
+// blasBackend is the name of the BLAS implementation registered with mat64,
+// followed for C backends by the file of the library loaded, if it is found.
+var blasBackend string
+
+// registerBLAS registers the named BLAS implementation with mat64 and dbw.
+func registerBLAS(name string) error {
+	impl, ok := blasBackends[name]
+	if !ok {
+		names := make([]string, 0, len(blasBackends))
+		for n := range blasBackends {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("blas %q not available, have %s (C backends need the build tag of their name)", name, strings.Join(names, ","))
+	}
+	mat64.Register(impl)
+	dbw.Register(impl)
+	blasBackend = name
+	if lib := loadedLibrary(blasLibraries[name]); lib != "" {
+		blasBackend += " (" + lib + ")"
+	}
+	return nil
+}
+
+// loadedLibrary returns the file name of the shared library mapped by this
+// process whose name starts with prefix, such as libopenblasp-r0.3.21.so for
+// libopenblas, or "" if there is none or the mappings cannot be read, as
+// outside Linux.
+func loadedLibrary(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	maps, err := os.ReadFile("/proc/self/maps")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(maps), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		if base := filepath.Base(fields[5]); strings.HasPrefix(base, prefix) && strings.Contains(base, ".so") {
+			return base
+		}
+	}
+	return ""
+}
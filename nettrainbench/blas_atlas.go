@@ -0,0 +1,15 @@
+//go:build atlas && !openblas
+
+package main
+
+// #cgo LDFLAGS: -lsatlas
+import "C"
+
+import "github.com/gonum/blas/cblas"
+
+// With the atlas tag the cblas package links no library of its own, so its
+// routines are those of the libsatlas linked here, the serial ATLAS library.
+func init() {
+	blasBackends["atlas"] = cblas.Blas{}
+	blasLibraries["atlas"] = "libsatlas"
+}
@@ -0,0 +1,14 @@
+//go:build cblas && !openblas && !atlas
+
+package main
+
+import "github.com/gonum/blas/cblas"
+
+// The cblas package links against -lcblas, so the C library used is whichever
+// libcblas the linker finds, such as that of OpenBLAS or ATLAS; point
+// CGO_LDFLAGS at its directory to choose one, or build with the openblas or
+// atlas tag to link that library by name.
+func init() {
+	blasBackends["cblas"] = cblas.Blas{}
+	blasLibraries["cblas"] = "libcblas"
+}
@@ -0,0 +1,15 @@
+//go:build openblas
+
+package main
+
+// #cgo LDFLAGS: -lopenblas
+import "C"
+
+import "github.com/gonum/blas/cblas"
+
+// With the openblas tag the cblas package links no library of its own, so its
+// routines are those of the libopenblas linked here.
+func init() {
+	blasBackends["openblas"] = cblas.Blas{}
+	blasLibraries["openblas"] = "libopenblas"
+}
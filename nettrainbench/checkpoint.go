@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// checkpoint appends the records of each finished case to a file of JSON
+// lines, so that an interrupted benchmark can be resumed.
+type checkpoint struct {
+	f *os.File
+}
+
+// createCheckpoint starts the named checkpoint file, or, if resume is set,
+// opens it to append to and returns the records already in it by case name.
+func createCheckpoint(filename string, resume bool) (*checkpoint, map[string][]Record, error) {
+	if !resume {
+		f, err := os.Create(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &checkpoint{f}, nil, nil
+	}
+	done, size, err := readCheckpoint(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Drop a last line cut short, to append after the whole ones.
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return &checkpoint{f}, done, nil
+}
+
+// readCheckpoint reads the records of a checkpoint file by case name, and
+// returns the size of its whole lines. A missing file has none. A last line
+// cut short by an interruption is left out, so its case is run again.
+func readCheckpoint(filename string) (done map[string][]Record, size int64, err error) {
+	b, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return map[string][]Record{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	done = make(map[string][]Record)
+	for line := 1; len(b) > 0; line++ {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			break // cut short before its newline, which is written last
+		}
+		var records []Record
+		if err := json.Unmarshal(b[:i], &records); err != nil {
+			return nil, 0, fmt.Errorf("checkpoint %s line %d: %v", filename, line, err)
+		}
+		if len(records) > 0 {
+			done[records[0].Name] = records
+		}
+		size += int64(i + 1)
+		b = b[i+1:]
+	}
+	return done, size, nil
+}
+
+// write appends the records of a finished case as one line, so that a case
+// is either all in the file or, if writing is interrupted, on a last line
+// that is cut short, and syncs the file.
+func (c *checkpoint) write(records []Record) error {
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if _, err := c.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return c.f.Sync()
+}
+
+func (c *checkpoint) Close() error {
+	return c.f.Close()
+}
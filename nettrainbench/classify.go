@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Tasks of a case.
+const (
+	regression     = "regression"
+	classification = "classification"
+)
+
+// softmaxCrossEntropy is the cross-entropy of one-hot truth and the softmax
+// of the predictions, so a network with linear outputs predicts the log odds
+// of each class.
+type softmaxCrossEntropy struct{}
+
+func (softmaxCrossEntropy) Loss(prediction, truth []float64) float64 {
+	return softmaxCrossEntropy{}.LossDeriv(prediction, truth, nil)
+}
+
+// LossDeriv returns the loss, and puts the derivative in place into
+// derivative if it is not nil.
+func (softmaxCrossEntropy) LossDeriv(prediction, truth, derivative []float64) float64 {
+	if len(prediction) != len(truth) || (derivative != nil && len(derivative) != len(truth)) {
+		panic("length mismatch")
+	}
+	max := math.Inf(-1)
+	for _, v := range prediction {
+		max = math.Max(max, v)
+	}
+	var sum float64
+	for _, v := range prediction {
+		sum += math.Exp(v - max)
+	}
+	logSum := max + math.Log(sum)
+	var loss float64
+	for i, v := range prediction {
+		loss -= truth[i] * (v - logSum)
+		if derivative != nil {
+			derivative[i] = math.Exp(v-logSum) - truth[i]
+		}
+	}
+	return loss
+}
+
+// numClasses returns one more than the largest label of the single column
+// matrices, checking that the labels are non-negative integers.
+func numClasses(labels ...*mat64.Dense) (int, error) {
+	classes := 0
+	for _, m := range labels {
+		if m == nil {
+			continue
+		}
+		r, _ := m.Dims()
+		for i := 0; i < r; i++ {
+			v := m.At(i, 0)
+			if v < 0 || v != math.Floor(v) || math.IsInf(v, 0) {
+				return 0, fmt.Errorf("class label %v in row %d is not a non-negative integer", v, i)
+			}
+			if int(v) >= classes {
+				classes = int(v) + 1
+			}
+		}
+	}
+	if classes < 2 {
+		return 0, fmt.Errorf("%d classes, need at least 2", classes)
+	}
+	return classes, nil
+}
+
+// oneHot returns the labels as rows with a one in the column of the class.
+func oneHot(labels *mat64.Dense, classes int) *mat64.Dense {
+	r, _ := labels.Dims()
+	m := mat64.NewDense(r, classes, nil)
+	for i := 0; i < r; i++ {
+		m.Set(i, int(labels.At(i, 0)), 1)
+	}
+	return m
+}
+
+// classMetrics returns the accuracy and mean cross-entropy of the predicted
+// log odds against the labels.
+func classMetrics(pred, labels *mat64.Dense) Metrics {
+	r, classes := pred.Dims()
+	m := Metrics{N: r, Classes: classes}
+	if r == 0 {
+		return m
+	}
+	row := make([]float64, classes)
+	truth := make([]float64, classes)
+	var correct int
+	for i := 0; i < r; i++ {
+		pred.Row(row, i)
+		label := int(labels.At(i, 0))
+		best := 0
+		for j, v := range row {
+			if v > row[best] {
+				best = j
+			}
+		}
+		if best == label {
+			correct++
+		}
+		truth[label] = 1
+		m.CrossEntropy += softmaxCrossEntropy{}.Loss(row, truth)
+		truth[label] = 0
+	}
+	m.Accuracy = float64(correct) / float64(r)
+	m.CrossEntropy /= float64(r)
+	return m
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/btracey/gobench/nettrainbench/optimize"
+	"github.com/gonum/opt"
+)
+
+// expandOptimizers replaces each case with optimizer "all" by a case for
+// each optimization method.
+func expandOptimizers(cases []Case) []Case {
+	var expanded []Case
+	for _, c := range cases {
+		if c.Optimizer != "all" {
+			expanded = append(expanded, c)
+			continue
+		}
+		for _, name := range optimize.Names() {
+			c := c
+			c.Optimizer = name
+			c.Name += "_" + name
+			expanded = append(expanded, c)
+		}
+	}
+	return expanded
+}
+
+// expandActivations replaces each case with activation "all" by a case for
+// each hidden layer activation function.
+func expandActivations(cases []Case) []Case {
+	var expanded []Case
+	for _, c := range cases {
+		if c.Activation != "all" {
+			expanded = append(expanded, c)
+			continue
+		}
+		for _, name := range activatorNames() {
+			c := c
+			c.Activation = name
+			c.Name += "_" + name
+			expanded = append(expanded, c)
+		}
+	}
+	return expanded
+}
+
+// distinct returns the distinct values of key over the cases of the records.
+func distinct(records []Record, key func(Case) string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if k := key(r.Case); !seen[k] {
+			seen[k] = true
+			names = append(names, k)
+		}
+	}
+	return names
+}
+
+// compareBy writes a table of the mean evaluations, wall time and time per
+// evaluation of each case, with a column headed heading of the key of the
+// case, such as its optimizer.
+// Evaluations are only averaged over the runs that converged to the function
+// tolerance, so they are the evaluations needed to reach it.
+func compareBy(w io.Writer, records []Record, heading string, key func(Case) string) error {
+	type row struct {
+		name, key        string
+		runs, converged  int
+		evals, sec, loss float64
+		nsPerEval        float64
+	}
+	var rows []*row
+	byName := make(map[string]*row)
+	for _, r := range records {
+		rw, ok := byName[r.Name]
+		if !ok {
+			k := key(r.Case)
+			rw = &row{name: strings.TrimSuffix(r.Name, "_"+k), key: k}
+			byName[r.Name] = rw
+			rows = append(rows, rw)
+		}
+		rw.runs++
+		rw.sec += r.Seconds
+		rw.loss += r.Loss
+		rw.nsPerEval += r.NsPerEval
+		if r.Status == opt.FunctionAbsoluteConvergence.String() {
+			rw.converged++
+			rw.evals += float64(r.FunEvals + r.GradEvals + r.FunGradEvals)
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "case\t%s\tconverged\tevals to tolerance\tseconds\tns/eval\tloss\t\n", heading)
+	for _, rw := range rows {
+		evals := "-"
+		if rw.converged > 0 {
+			evals = fmt.Sprintf("%.0f", rw.evals/float64(rw.converged))
+		}
+		n := float64(rw.runs)
+		fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%s\t%.3g\t%.4g\t%.4g\t\n", rw.name, rw.key, rw.converged, rw.runs, evals, rw.sec/n, rw.nsPerEval/n, rw.loss/n)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/btracey/gobench/nettrainbench/datagen"
+)
+
+// Case is a single named benchmark case. Zero fields take their value from
+// the defaults of the config.
+type Case struct {
+	Name       string  `json:"name"`
+	Data       string  `json:"data"`       // data file, the last columns are the outputs
+	Comma      string  `json:"comma"`      // delimiter of the data file
+	NData      int     `json:"ndata"`      // number of samples to train on
+	Layers     int     `json:"layers"`     // number of hidden layers
+	Neurons    int     `json:"neurons"`    // neurons per hidden layer
+	Tolerance  float64 `json:"tolerance"`  // absolute function tolerance
+	MaxEvals   int     `json:"maxevals"`   // maximum function evaluations
+	Workers    int     `json:"workers"`    // objective workers, zero for GOMAXPROCS
+	Repeat     int     `json:"repeat"`     // number of runs with different seeds
+	Warmup     int     `json:"warmup"`     // number of runs discarded before the measured runs
+	Optimizer  string  `json:"optimizer"`  // optimization method, or "all" to compare them
+	Rate       float64 `json:"rate"`       // learning rate of sgd, nesterov and adam; zero for the method's default
+	Activation string  `json:"activation"` // hidden layer activation function, or "all" to compare them
+	Holdout    float64 `json:"holdout"`    // test samples after the training samples, as a fraction of NData; negative for none
+	BatchSize  int     `json:"batchsize"`  // samples per gradient evaluation, zero for full batch
+	Epochs     int     `json:"epochs"`     // passes over the data with mini-batches, replacing MaxEvals
+	Outputs    int     `json:"outputs"`    // number of output columns at the end of the data
+	Task       string  `json:"task"`       // regression, or classification of the integer labels in the last column
+	L1         float64 `json:"l1"`         // strength of the L1 penalty on the parameters
+	L2         float64 `json:"l2"`         // strength of the L2 penalty on the parameters
+	Weights    string  `json:"weights"`    // sample weights, "column" before the outputs or "importance"; "" for none
+	Folds      int     `json:"folds"`      // cross-validation folds of NData, replacing Holdout; zero for none
+	Stream     string  `json:"stream"`     // read mini-batches from Data while training, "sequential" or "shuffled"; "" to load it
+
+	// Scaling of the data for training, normal, minmax or none. Errors are
+	// reported in the units of the data whatever the scaling.
+	InputScaling  string `json:"inputscaling"`
+	OutputScaling string `json:"outputscaling"` // of regression outputs
+
+	// Further stopping criteria, each off if zero. GradTolerance is the
+	// gradient norm below which training stops, 1e-6 if zero and off if
+	// negative. RelTolerance stops training when the loss at a major iteration
+	// changes by no more than that fraction of the loss at the last one.
+	GradTolerance float64 `json:"gradtolerance"`
+	RelTolerance  float64 `json:"reltolerance"`
+	MaxIterations int     `json:"maxiterations"` // maximum major iterations
+	MaxSeconds    float64 `json:"maxseconds"`    // maximum wall time of the optimizer
+	Timeout       float64 `json:"timeout"`       // seconds after which a run is abandoned as failed, no limit if zero
+
+	// Method is the model trained, nnet for a neural net, or ols, knn or krr
+	// for the least squares, K nearest neighbor and kernel ridge baselines,
+	// or "all" to compare them. The baselines ignore the network and
+	// optimizer fields.
+	Method string `json:"method"`
+	K      int    `json:"k"` // neighbors averaged by knn, 5 if zero
+
+	// Kernel ridge regression, krr, fits Gaussian kernels exp(-Gamma |x-y|²)
+	// on the scaled inputs, Gamma one over the number of inputs if zero, with
+	// a Ridge penalty, 1e-3 if zero. It takes memory growing as NData² and
+	// time as NData³, so suits a few thousand samples.
+	Gamma float64 `json:"gamma"`
+	Ridge float64 `json:"ridge"`
+
+	// GOGC is the garbage collection target percentage during each run, as
+	// for the GOGC environment variable, negative to turn collection off or
+	// zero to leave it.
+	GOGC int `json:"gogc"`
+
+	// ParallelFolds trains the folds of a cross-validated case at once.
+	ParallelFolds bool `json:"parallelfolds"`
+
+	// Ensemble trains that many networks from different random parameters
+	// and averages their predictions, if more than one. ParallelEnsemble
+	// trains them at once with Workers/Ensemble objective workers each,
+	// rather than one after another with all of the Workers.
+	Ensemble         int  `json:"ensemble"`
+	ParallelEnsemble bool `json:"parallelensemble"`
+
+	// Synthetic generates the data instead of reading Data.
+	Synthetic *datagen.Spec `json:"synthetic,omitempty"`
+
+	model *Model     // the network to start training from, if not random
+	rand  *rand.Rand // the source of the random draws of the run
+}
+
+// Config is a list of benchmark cases run in sequence. If Sweep is set, each
+// case is expanded into a grid of cases, and if Search is set, into random
+// configurations of each of those. Suites name subsets of the cases to run
+// alone.
+type Config struct {
+	Defaults Case    `json:"defaults"`
+	Cases    []Case  `json:"cases"`
+	Sweep    *Sweep  `json:"sweep"`
+	Search   *Search `json:"search"`
+
+	Suites map[string]Suite `json:"suites"`
+}
+
+// Suite is a named subset of the cases of a config, such as the quick cases
+// run on every change or the slow ones run nightly.
+type Suite struct {
+	Cases   []string `json:"cases"`   // names of the cases, all of them if empty
+	Timeout float64  `json:"timeout"` // seconds the suite may run before the remaining cases are skipped, no limit if zero
+}
+
+// defaultCase is the case run when no config is given.
+var defaultCase = Case{
+	Name:       "default",
+	Data:       "data.txt", // Assumes exp4 is in the working directory
+	Comma:      " ",        // the file is space dilimeted (ish)
+	NData:      10000,
+	Layers:     2,
+	Neurons:    30, // I usually use more, but let's keep this example cheap
+	Tolerance:  1e-6,
+	MaxEvals:   100,
+	Repeat:     1,
+	Optimizer:  "bfgs",
+	Activation: "tanh",
+	Holdout:    0.2,
+	Task:       regression,
+	Outputs:    1,
+
+	InputScaling:  scaleNormal,
+	OutputScaling: scaleNormal,
+
+	Method: methodNet,
+}
+
+// readConfig reads a JSON config from the named file.
+func readConfig(filename string) (*Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c := &Config{}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("config %s: %v", filename, err)
+	}
+	if c.Search != nil {
+		if err := c.Search.validate(); err != nil {
+			return nil, fmt.Errorf("config %s: %v", filename, err)
+		}
+	}
+	if len(c.Cases) == 0 {
+		switch {
+		case c.Sweep != nil:
+			c.Cases = []Case{{Name: "sweep"}}
+		case c.Search != nil:
+			c.Cases = []Case{{Name: "search"}}
+		default:
+			return nil, fmt.Errorf("config %s: %v", filename, errors.New("no cases"))
+		}
+	}
+	for name, suite := range c.Suites {
+		if err := c.checkSuite(name, suite); err != nil {
+			return nil, fmt.Errorf("config %s: %v", filename, err)
+		}
+	}
+	return c, nil
+}
+
+// checkSuite returns an error if the suite names a case that is not in the
+// config, or more than once.
+func (c *Config) checkSuite(name string, suite Suite) error {
+	if suite.Timeout < 0 {
+		return fmt.Errorf("suite %s: negative timeout %g", name, suite.Timeout)
+	}
+	names := make(map[string]bool)
+	for _, cs := range c.Cases {
+		names[cs.Name] = true
+	}
+	seen := make(map[string]bool)
+	for _, n := range suite.Cases {
+		if !names[n] {
+			return fmt.Errorf("suite %s: no case %q", name, n)
+		}
+		if seen[n] {
+			return fmt.Errorf("suite %s: case %q listed twice", name, n)
+		}
+		seen[n] = true
+	}
+	return nil
+}
+
+// selectSuite keeps only the cases of the named suite, in the order they
+// are listed in the config, and returns the suite.
+func (c *Config) selectSuite(name string) (Suite, error) {
+	suite, ok := c.Suites[name]
+	if !ok {
+		var names []string
+		for n := range c.Suites {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Suite{}, fmt.Errorf("no suite %q, the config has %v", name, names)
+	}
+	if len(suite.Cases) == 0 {
+		return suite, nil
+	}
+	in := make(map[string]bool)
+	for _, n := range suite.Cases {
+		in[n] = true
+	}
+	var cases []Case
+	for _, cs := range c.Cases {
+		if in[cs.Name] {
+			cases = append(cases, cs)
+		}
+	}
+	c.Cases = cases
+	return suite, nil
+}
+
+// random returns the source of the random draws of the case, seeding one
+// from the time if the run has none.
+func (c *Case) random() *rand.Rand {
+	if c.rand == nil {
+		c.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return c.rand
+}
+
+// fill sets the zero fields of c from d.
+func (c *Case) fill(d Case) {
+	if c.Name == "" {
+		c.Name = d.Name
+	}
+	if c.Data == "" {
+		c.Data = d.Data
+	}
+	if c.Comma == "" {
+		c.Comma = d.Comma
+	}
+	if c.NData == 0 {
+		c.NData = d.NData
+	}
+	if c.Layers == 0 {
+		c.Layers = d.Layers
+	}
+	if c.Neurons == 0 {
+		c.Neurons = d.Neurons
+	}
+	if c.Tolerance == 0 {
+		c.Tolerance = d.Tolerance
+	}
+	if c.MaxEvals == 0 {
+		c.MaxEvals = d.MaxEvals
+	}
+	if c.Workers == 0 {
+		c.Workers = d.Workers
+	}
+	if c.Repeat == 0 {
+		c.Repeat = d.Repeat
+	}
+	if c.Warmup == 0 {
+		c.Warmup = d.Warmup
+	}
+	if c.Optimizer == "" {
+		c.Optimizer = d.Optimizer
+	}
+	if c.Rate == 0 {
+		c.Rate = d.Rate
+	}
+	if c.Activation == "" {
+		c.Activation = d.Activation
+	}
+	if c.Holdout == 0 {
+		c.Holdout = d.Holdout
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = d.BatchSize
+	}
+	if c.Epochs == 0 {
+		c.Epochs = d.Epochs
+	}
+	if c.Outputs == 0 {
+		c.Outputs = d.Outputs
+	}
+	if c.Task == "" {
+		c.Task = d.Task
+	}
+	if c.InputScaling == "" {
+		c.InputScaling = d.InputScaling
+	}
+	if c.OutputScaling == "" {
+		c.OutputScaling = d.OutputScaling
+	}
+	if c.L1 == 0 {
+		c.L1 = d.L1
+	}
+	if c.L2 == 0 {
+		c.L2 = d.L2
+	}
+	if c.Weights == "" {
+		c.Weights = d.Weights
+	}
+	if c.Folds == 0 {
+		c.Folds = d.Folds
+	}
+	if c.Stream == "" {
+		c.Stream = d.Stream
+	}
+	if c.GradTolerance == 0 {
+		c.GradTolerance = d.GradTolerance
+	}
+	if c.RelTolerance == 0 {
+		c.RelTolerance = d.RelTolerance
+	}
+	if c.MaxIterations == 0 {
+		c.MaxIterations = d.MaxIterations
+	}
+	if c.MaxSeconds == 0 {
+		c.MaxSeconds = d.MaxSeconds
+	}
+	if c.Timeout == 0 {
+		c.Timeout = d.Timeout
+	}
+	if c.GOGC == 0 {
+		c.GOGC = d.GOGC
+	}
+	if c.Method == "" {
+		c.Method = d.Method
+	}
+	if c.K == 0 {
+		c.K = d.K
+	}
+	if c.Gamma == 0 {
+		c.Gamma = d.Gamma
+	}
+	if c.Ridge == 0 {
+		c.Ridge = d.Ridge
+	}
+	if !c.ParallelFolds {
+		c.ParallelFolds = d.ParallelFolds
+	}
+	if c.Ensemble == 0 {
+		c.Ensemble = d.Ensemble
+	}
+	if !c.ParallelEnsemble {
+		c.ParallelEnsemble = d.ParallelEnsemble
+	}
+	if c.Synthetic == nil {
+		c.Synthetic = d.Synthetic
+	}
+}
+
+// resolve returns the cases of the config with defaults applied. Cases
+// without a name are numbered.
+func (c *Config) resolve() []Case {
+	defaults := c.Defaults
+	defaults.fill(defaultCase)
+	var cases []Case
+	for i, cs := range c.Cases {
+		if cs.Name == "" {
+			cs.Name = fmt.Sprintf("case%d", i)
+		}
+		cs.fill(defaults)
+		grid := []Case{cs}
+		if c.Sweep != nil {
+			grid = c.Sweep.cases(cs)
+		}
+		if c.Search != nil {
+			var drawn []Case
+			for _, g := range grid {
+				drawn = append(drawn, c.Search.cases(g)...)
+			}
+			grid = drawn
+		}
+		cases = append(cases, grid...)
+	}
+	return cases
+}
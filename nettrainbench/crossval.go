@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// CrossValidation summarizes the test errors over the folds of a
+// cross-validated case.
+type CrossValidation struct {
+	Folds []Metrics `json:"folds"` // test errors of each fold
+	RMSE  Summary   `json:"rmse"`
+	MAE   Summary   `json:"mae"`
+	R2    Summary   `json:"r2"`
+
+	Accuracy *Summary `json:"accuracy,omitempty"` // of classifiers
+}
+
+func (cv CrossValidation) String() string {
+	if cv.Accuracy != nil {
+		return fmt.Sprintf("%d folds, accuracy %.4f ± %.2g", len(cv.Folds), cv.Accuracy.Mean, cv.Accuracy.Std)
+	}
+	return fmt.Sprintf("%d folds, RMSE %.4g ± %.2g, MAE %.4g ± %.2g, R² %.4f ± %.2g",
+		len(cv.Folds), cv.RMSE.Mean, cv.RMSE.Std, cv.MAE.Mean, cv.MAE.Std, cv.R2.Mean, cv.R2.Std)
+}
+
+// crossValidate trains c.Folds networks on the first c.NData samples of
+// allData, each one tested on a contiguous fold of the samples and trained on
+// the rest. The folds run one after another, or all at once if c.ParallelFolds
+// is set, each drawing from a source of its own seeded from the case, so that
+// both start from the same parameters. The returned record has the mean
+// training loss and the total evaluations and phases over the folds, which are
+// CPU rather than wall time for parallel folds. The network of the first fold
+// is kept for timing prediction and saving.
+func crossValidate(c Case, allData *mat64.Dense, workerTiming bool) (*Record, error) {
+	nSamples, nDim := allData.Dims()
+	nIn, err := inputColumns(c, nDim)
+	if err != nil {
+		return nil, err
+	}
+	if c.NData > nSamples {
+		return nil, fmt.Errorf("ndata %d exceeds the %d samples in %s", c.NData, nSamples, c.Data)
+	}
+	if c.Folds < 2 || c.Folds > c.NData {
+		return nil, fmt.Errorf("folds %d not between 2 and ndata %d", c.Folds, c.NData)
+	}
+
+	sources := make([]*rand.Rand, c.Folds)
+	for f := range sources {
+		sources[f] = rand.New(rand.NewSource(c.random().Int63()))
+	}
+	folds := make([]*Record, c.Folds)
+	errs := make([]error, c.Folds)
+	run := func(f int) {
+		start := time.Now()
+		lo, hi := f*c.NData/c.Folds, (f+1)*c.NData/c.Folds
+		inputs, outputs, testInputs, testOutputs := foldData(allData, c.NData, nIn, lo, hi)
+		copyTime := time.Since(start).Seconds()
+		fold := c
+		fold.rand = sources[f]
+		folds[f], errs[f] = trainCase(fold, inputs, outputs, testInputs, testOutputs, nil, workerTiming)
+		if errs[f] == nil {
+			folds[f].Phases.Scale += copyTime
+		}
+	}
+	if c.ParallelFolds {
+		var wg sync.WaitGroup
+		for f := range folds {
+			wg.Add(1)
+			go func(f int) {
+				defer wg.Done()
+				run(f)
+			}(f)
+		}
+		wg.Wait()
+	} else {
+		for f := range folds {
+			run(f)
+		}
+	}
+	for f, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("fold %d: %v", f, err)
+		}
+	}
+
+	rec := &Record{
+		Name:    c.Name,
+		Case:    c,
+		NParams: folds[0].NParams,
+		CV:      &CrossValidation{},
+		inputs:  folds[0].inputs,
+		model:   folds[0].model,
+
+		predictor: folds[0].predictor,
+	}
+	var rmse, mae, r2, accuracy []float64
+	statuses := make(map[string]bool)
+	for _, fold := range folds {
+		rec.Loss += fold.Loss / float64(len(folds))
+		rec.Iterations += fold.Iterations
+		rec.FunEvals += fold.FunEvals
+		rec.GradEvals += fold.GradEvals
+		rec.FunGradEvals += fold.FunGradEvals
+		rec.Phases.add(fold.Phases)
+		statuses[fold.Status] = true
+		rec.CV.Folds = append(rec.CV.Folds, *fold.Test)
+		rmse = append(rmse, fold.Test.RMSE)
+		mae = append(mae, fold.Test.MAE)
+		r2 = append(r2, fold.Test.R2)
+		accuracy = append(accuracy, fold.Test.Accuracy)
+	}
+	rec.CV.RMSE = summarize(rmse)
+	rec.CV.MAE = summarize(mae)
+	rec.CV.R2 = summarize(r2)
+	if c.Task == classification {
+		acc := summarize(accuracy)
+		rec.CV.Accuracy = &acc
+	}
+	rec.Status = joinStatuses(statuses)
+	return rec, nil
+}
+
+// joinStatuses returns the sorted statuses separated by commas.
+func joinStatuses(statuses map[string]bool) string {
+	var s []string
+	for status := range statuses {
+		s = append(s, status)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+// foldData returns copies of the inputs and outputs of the first n rows of
+// data, split into the rows in [lo, hi) for testing and the rest for training.
+// The first nIn columns are the inputs and the rest the outputs.
+func foldData(data *mat64.Dense, n, nIn, lo, hi int) (inputs, outputs, testInputs, testOutputs *mat64.Dense) {
+	_, nDim := data.Dims()
+	nTest := hi - lo
+	inputs = mat64.NewDense(n-nTest, nIn, nil)
+	outputs = mat64.NewDense(n-nTest, nDim-nIn, nil)
+	testInputs = mat64.NewDense(nTest, nIn, nil)
+	testOutputs = mat64.NewDense(nTest, nDim-nIn, nil)
+	row := make([]float64, nDim)
+	for i := 0; i < n; i++ {
+		data.Row(row, i)
+		in, out, j := inputs, outputs, i
+		if i >= lo && i < hi {
+			in, out, j = testInputs, testOutputs, i-lo
+		} else if i >= hi {
+			j = i - nTest
+		}
+		in.SetRow(j, row[:nIn])
+		out.SetRow(j, row[nIn:])
+	}
+	return inputs, outputs, testInputs, testOutputs
+}
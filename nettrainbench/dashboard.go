@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dashboard is a web UI over a results store, listing the trend of each
+// benchmark, comparing two commits and drawing charts, and serves the
+// metrics of the last commit of each for Prometheus. The store is read
+// again for each page, so that it shows runs appended since it started.
+type dashboard struct {
+	store            string
+	commits          int // of each benchmark shown
+	alpha, threshold float64
+}
+
+// serveDashboard serves the dashboard on addr.
+func serveDashboard(addr string, d *dashboard) error {
+	log.Printf("dashboard of %s on %s", d.store, addr)
+	return http.ListenAndServe(addr, d.handler())
+}
+
+func (d *dashboard) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.index)
+	mux.HandleFunc("/benchmark", d.benchmark)
+	mux.HandleFunc("/compare", d.compare)
+	mux.HandleFunc("/trend.svg", d.trendChart)
+	mux.HandleFunc("/convergence.svg", d.convergenceChart)
+	mux.HandleFunc("/metrics", d.metrics)
+	return mux
+}
+
+// dashboardFilter selects the records of a page, by the store query fields
+// and the BLAS backend given as URL parameters.
+type dashboardFilter struct {
+	storeQuery
+	BLAS string
+}
+
+func parseDashboardFilter(v url.Values) (dashboardFilter, error) {
+	f := dashboardFilter{BLAS: v.Get("blas")}
+	f.Name, f.Config, f.Machine, f.Git = v.Get("name"), v.Get("config"), v.Get("machine"), v.Get("git")
+	if s := v.Get("since"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return f, fmt.Errorf("since %q is not a date like 2006-01-02", s)
+		}
+		f.Since = t
+	}
+	return f, nil
+}
+
+// records reads the records of the store matching the filter of the request,
+// replying with an error and returning false if it cannot.
+func (d *dashboard) records(w http.ResponseWriter, r *http.Request) (dashboardFilter, []Record, bool) {
+	f, err := parseDashboardFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return f, nil, false
+	}
+	all, err := readStore(d.store, f.storeQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return f, nil, false
+	}
+	var records []Record
+	for _, rec := range all {
+		if f.BLAS == "" || rec.Env.BLAS == f.BLAS {
+			records = append(records, rec)
+		}
+	}
+	return f, records, true
+}
+
+// backendSeries is the trend of a benchmark with a BLAS backend. Backends
+// are chosen by flag rather than in the case, so they share a config hash
+// and are told apart here.
+type backendSeries struct {
+	*trendSeries
+	BLAS string
+}
+
+// seriesByBackend returns the trends of the records with each backend.
+func (d *dashboard) seriesByBackend(records []Record) []backendSeries {
+	byBLAS := make(map[string][]Record)
+	for _, r := range records {
+		byBLAS[r.Env.BLAS] = append(byBLAS[r.Env.BLAS], r)
+	}
+	var series []backendSeries
+	for _, blas := range sortedKeys(byBLAS) {
+		for _, s := range trends(byBLAS[blas], d.commits, d.alpha, d.threshold) {
+			series = append(series, backendSeries{s, blas})
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series
+}
+
+func sortedKeys(m map[string][]Record) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// choices returns the distinct values of a field of the records, sorted, for
+// the menus of the filter form.
+func choices(records []Record, field func(Record) string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, r := range records {
+		if v := field(r); v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// commitsOf returns the commits of the records ordered by their first run.
+func commitsOf(records []Record) []string {
+	first := make(map[string]time.Time)
+	var commits []string
+	for _, r := range records {
+		t, ok := first[r.Env.GitSHA]
+		if !ok {
+			commits = append(commits, r.Env.GitSHA)
+		}
+		if !ok || r.Start.Before(t) {
+			first[r.Env.GitSHA] = r.Start
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return first[commits[i]].Before(first[commits[j]]) })
+	return commits
+}
+
+func (d *dashboard) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	f, records, ok := d.records(w, r)
+	if !ok {
+		return
+	}
+	d.render(w, "index", struct {
+		Filter             dashboardFilter
+		Machines, Backends []string
+		Commits            []string
+		Series             []backendSeries
+	}{
+		Filter:   f,
+		Machines: choices(records, func(r Record) string { return r.Env.Machine }),
+		Backends: choices(records, func(r Record) string { return r.Env.BLAS }),
+		Commits:  commitsOf(records),
+		Series:   d.seriesByBackend(records),
+	})
+}
+
+func (d *dashboard) benchmark(w http.ResponseWriter, r *http.Request) {
+	f, records, ok := d.records(w, r)
+	if !ok {
+		return
+	}
+	series := d.seriesByBackend(records)
+	if len(series) == 0 {
+		http.Error(w, "no runs of the benchmark", http.StatusNotFound)
+		return
+	}
+	converged := false
+	for _, rec := range records {
+		converged = converged || len(rec.Convergence) > 0
+	}
+	d.render(w, "benchmark", struct {
+		Filter    dashboardFilter
+		Series    []backendSeries
+		Converged bool
+	}{f, series, converged})
+}
+
+// comparison is the change of a benchmark with a backend from one commit to
+// another.
+type comparison struct {
+	Name, Config, Machine, BLAS string
+	A, B                        trendPoint
+}
+
+// compareCommits returns the comparison of each benchmark run at both
+// commits, given as prefixes, marking significant changes as for trends.
+func compareCommits(records []Record, a, b string, alpha, threshold float64) []*comparison {
+	type key struct{ name, config, machine, blas string }
+	byKey := make(map[key]*comparison)
+	var comps []*comparison
+	for _, r := range records {
+		inA := a != "" && strings.HasPrefix(r.Env.GitSHA, a)
+		if !inA && (b == "" || !strings.HasPrefix(r.Env.GitSHA, b)) {
+			continue
+		}
+		k := key{r.Name, r.ConfigHash, r.Env.Machine, r.Env.BLAS}
+		c, ok := byKey[k]
+		if !ok {
+			c = &comparison{Name: r.Name, Config: r.ConfigHash, Machine: r.Env.Machine, BLAS: r.Env.BLAS}
+			byKey[k] = c
+			comps = append(comps, c)
+		}
+		pt := &c.B
+		if inA {
+			pt = &c.A
+		}
+		pt.Git = r.Env.GitSHA
+		pt.Values = append(pt.Values, float64(r.NsPerOp))
+	}
+	var both []*comparison
+	for _, c := range comps {
+		if len(c.A.Values) == 0 || len(c.B.Values) == 0 {
+			continue
+		}
+		c.A.Median, c.B.Median = median(c.A.Values), median(c.B.Values)
+		c.B.Change = c.B.Median/c.A.Median - 1
+		c.B.P = mannWhitney(c.A.Values, c.B.Values)
+		if c.B.P < alpha && math.Abs(c.B.Change) > threshold {
+			c.B.Mark = trendFaster
+			if c.B.Change > 0 {
+				c.B.Mark = trendSlower
+			}
+		}
+		both = append(both, c)
+	}
+	sort.SliceStable(both, func(i, j int) bool { return both[i].Name < both[j].Name })
+	return both
+}
+
+func (d *dashboard) compare(w http.ResponseWriter, r *http.Request) {
+	f, records, ok := d.records(w, r)
+	if !ok {
+		return
+	}
+	a, b := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+	d.render(w, "compare", struct {
+		Filter      dashboardFilter
+		A, B        string
+		Commits     []string
+		Comparisons []*comparison
+	}{f, a, b, commitsOf(records), compareCommits(records, a, b, d.alpha, d.threshold)})
+}
+
+// trendChart draws the median time of each benchmark of the filter at its
+// last commits.
+func (d *dashboard) trendChart(w http.ResponseWriter, r *http.Request) {
+	_, records, ok := d.records(w, r)
+	if !ok {
+		return
+	}
+	ch := &chart{title: "Trend", xLabel: "commit, oldest first", yLabel: "median time"}
+	for _, s := range d.seriesByBackend(records) {
+		l := chartLine{name: s.Name + " " + s.BLAS + ", " + s.Unit}
+		for i, p := range s.Points {
+			l.x = append(l.x, float64(i+1))
+			l.y = append(l.y, p.Median)
+		}
+		ch.lines = append(ch.lines, l)
+	}
+	d.writeChart(w, ch)
+}
+
+// convergenceChart draws the convergence of the benchmarks of the filter at
+// their last commit.
+func (d *dashboard) convergenceChart(w http.ResponseWriter, r *http.Request) {
+	_, records, ok := d.records(w, r)
+	if !ok {
+		return
+	}
+	commits := commitsOf(records)
+	var last []Record
+	for _, rec := range records {
+		if len(commits) > 0 && rec.Env.GitSHA == commits[len(commits)-1] {
+			if rec.Env.BLAS != "" {
+				rec.Name += " " + rec.Env.BLAS
+			}
+			last = append(last, rec)
+		}
+	}
+	d.writeChart(w, convergenceChart(last))
+}
+
+// metrics serves the metrics of the last commit of each benchmark of the
+// filter, for Prometheus to scrape.
+func (d *dashboard) metrics(w http.ResponseWriter, r *http.Request) {
+	_, records, ok := d.records(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	if err := writeMetrics(w, latestRecords(records)); err != nil {
+		log.Print(err)
+	}
+}
+
+func (d *dashboard) writeChart(w http.ResponseWriter, ch *chart) {
+	if ch == nil || len(ch.lines) == 0 {
+		http.Error(w, "nothing to plot", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	if err := ch.write(w, "svg"); err != nil {
+		log.Print(err)
+	}
+}
+
+func (d *dashboard) render(w http.ResponseWriter, page string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardHTML.ExecuteTemplate(w, page, data); err != nil {
+		log.Print(err)
+	}
+}
+
+// dashboardHTML has the pages of the dashboard, with the functions of
+// trendHTML.
+var dashboardHTML = template.Must(template.Must(trendHTML.Clone()).Funcs(template.FuncMap{
+	"last": func(s backendSeries) *trendPoint { return s.Points[len(s.Points)-1] },
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>nettrainbench</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { padding: 2px 8px; text-align: right; border-bottom: 1px solid #ddd; }
+td:first-child { text-align: left; }
+tr.REGRESSION { background: #fbb; }
+tr.improvement { background: #bfb; }
+</style>
+</head>
+<body>
+<p><a href="/">benchmarks</a> | <a href="/compare">compare commits</a></p>
+{{end}}
+
+{{define "filter"}}<form>
+machine <select name="machine"><option value="">all</option>{{range .Machines}}<option{{if eq . $.Filter.Machine}} selected{{end}}>{{.}}</option>{{end}}</select>
+backend <select name="blas"><option value="">all</option>{{range .Backends}}<option{{if eq . $.Filter.BLAS}} selected{{end}}>{{.}}</option>{{end}}</select>
+name <input name="name" value="{{.Filter.Name}}">
+<input type="submit" value="filter">
+</form>
+{{end}}
+
+{{define "index"}}{{template "head"}}
+<h1>Benchmarks</h1>
+{{template "filter" .}}
+<table>
+<tr><th>case</th><th>backend</th><th>machine</th><th>config</th><th>commits</th><th>last commit</th><th>date</th><th>median</th><th>change</th><th></th></tr>
+{{range .Series}}{{$p := last .}}<tr class="{{$p.Mark}}"><td><a href="/benchmark?name={{.Name}}&amp;config={{.Config}}&amp;machine={{.Machine}}&amp;blas={{.BLAS}}">{{.Name}}</a></td><td>{{.BLAS}}</td><td>{{.Machine}}</td><td>{{.Config}}</td><td>{{len .Points}}</td><td>{{short $p.Git}}</td><td>{{date $p.First}}</td><td>{{num $p.Median}} {{.Unit}}</td><td>{{if gt (len .Points) 1}}{{percent $p.Change}}{{end}}</td><td>{{$p.Mark}}</td></tr>
+{{else}}<tr><td colspan="10">no runs</td></tr>
+{{end}}</table>
+</body>
+</html>
+{{end}}
+
+{{define "benchmark"}}{{template "head"}}
+{{with .Filter}}<h1>{{.Name}}</h1>
+<p><img src="/trend.svg?name={{.Name}}&amp;config={{.Config}}&amp;machine={{.Machine}}&amp;blas={{.BLAS}}" alt="trend"></p>
+{{end}}{{if .Converged}}{{with .Filter}}<p><img src="/convergence.svg?name={{.Name}}&amp;config={{.Config}}&amp;machine={{.Machine}}&amp;blas={{.BLAS}}" alt="convergence at the last commit"></p>
+{{end}}{{end}}{{range .Series}}
+<h2>{{.BLAS}} on {{.Machine}}, config {{.Config}}, median {{.Unit}}</h2>
+<table>
+<tr><th>git</th><th>date</th><th>runs</th><th>median</th><th>change</th><th>p</th><th></th></tr>
+{{range $i, $p := .Points}}<tr class="{{$p.Mark}}"><td>{{short $p.Git}}</td><td>{{date $p.First}}</td><td>{{len $p.Values}}</td><td>{{num $p.Median}}</td>{{if $i}}<td>{{percent $p.Change}}</td><td>{{num $p.P}}</td>{{else}}<td></td><td></td>{{end}}<td>{{$p.Mark}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "compare"}}{{template "head"}}
+<h1>Compare commits</h1>
+<form>
+<select name="a">{{range .Commits}}<option value="{{.}}"{{if eq . $.A}} selected{{end}}>{{short .}}</option>{{end}}</select>
+to <select name="b">{{range .Commits}}<option value="{{.}}"{{if eq . $.B}} selected{{end}}>{{short .}}</option>{{end}}</select>
+<input type="hidden" name="machine" value="{{.Filter.Machine}}">
+<input type="hidden" name="blas" value="{{.Filter.BLAS}}">
+<input type="submit" value="compare">
+</form>
+{{if .A}}<table>
+<tr><th>case</th><th>backend</th><th>machine</th><th>config</th><th>ns/op {{short .A}}</th><th>ns/op {{short .B}}</th><th>change</th><th>p</th><th></th></tr>
+{{range .Comparisons}}<tr class="{{.B.Mark}}"><td>{{.Name}}</td><td>{{.BLAS}}</td><td>{{.Machine}}</td><td>{{.Config}}</td><td>{{num .A.Median}}</td><td>{{num .B.Median}}</td><td>{{percent .B.Change}}</td><td>{{num .B.P}}</td><td>{{.B.Mark}}</td></tr>
+{{else}}<tr><td colspan="9">no benchmark was run at both commits</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+{{end}}
+`))
@@ -0,0 +1,206 @@
+// Package datagen generates reproducible synthetic regression and
+// classification datasets, so the benchmarks can run without the exp4 data
+// file.
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/btracey/numcsv"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Kind is a synthetic response function.
+type Kind string
+
+const (
+	// Friedman1 is y = 10 sin(π x1 x2) + 20 (x3 - 0.5)² + 10 x4 + 5 x5 with
+	// inputs uniform on [0, 1]. Inputs beyond the fifth do not affect y.
+	Friedman1 Kind = "friedman1"
+	// Sinusoid is y = Σ sin(2π x_i) with inputs uniform on [0, 1]. With
+	// several outputs, output k, counting from zero, is Σ sin(2π (k+1) x_i).
+	Sinusoid Kind = "sinusoid"
+	// Turbulence is the source term of the Spalart-Allmaras turbulence model
+	// as a function of the viscosity ratio, vorticity and wall distance, the
+	// kind of response in the exp4 data. The source is scaled by the wall
+	// destruction scale (χ/d)² to keep it order one. It has three inputs.
+	Turbulence Kind = "turbulence"
+	// Shells is a classification problem with inputs uniform on [0, 1]. The
+	// response is the class label 0, 1 or 2 of the distance r from the centre
+	// of the cube, with r < 0.35, 0.35 <= r < 0.5 and r >= 0.5 respectively.
+	// Noise is added to r rather than to the label.
+	Shells Kind = "shells"
+)
+
+var (
+	ErrKind = errors.New("datagen: unknown kind")
+	ErrDim  = errors.New("datagen: bad input dimension for kind")
+	ErrOut  = errors.New("datagen: bad output dimension for kind")
+)
+
+// Spec describes a dataset.
+type Spec struct {
+	Kind  Kind    `json:"kind"`
+	N     int     `json:"n"`     // number of samples
+	Dim   int     `json:"dim"`   // number of inputs, zero for the kind's default
+	Out   int     `json:"out"`   // number of outputs, zero for one; only Sinusoid has several
+	Noise float64 `json:"noise"` // standard deviation of the additive gaussian noise
+	Seed  int64   `json:"seed"`
+}
+
+// dims returns the default and minimum input dimensions of the kind.
+func (k Kind) dims() (def, min int, err error) {
+	switch k {
+	case Friedman1:
+		return 5, 5, nil
+	case Sinusoid:
+		return 3, 1, nil
+	case Turbulence:
+		return 3, 3, nil
+	case Shells:
+		return 2, 1, nil
+	}
+	return 0, 0, ErrKind
+}
+
+// Generate returns an N×(Dim+Out) matrix of samples. The last Out columns are
+// the responses. The same spec always produces the same data.
+func Generate(s Spec) (*mat64.Dense, error) {
+	def, min, err := s.Kind.dims()
+	if err != nil {
+		return nil, err
+	}
+	dim := s.Dim
+	if dim == 0 {
+		dim = def
+	}
+	if dim < min || (s.Kind == Turbulence && dim != min) {
+		return nil, fmt.Errorf("datagen: %s with %d inputs: %v", s.Kind, dim, ErrDim)
+	}
+	out := s.Out
+	if out == 0 {
+		out = 1
+	}
+	if out < 1 || (out > 1 && s.Kind != Sinusoid) {
+		return nil, fmt.Errorf("datagen: %s with %d outputs: %v", s.Kind, out, ErrOut)
+	}
+	rnd := rand.New(rand.NewSource(s.Seed))
+	data := mat64.NewDense(s.N, dim+out, nil)
+	x := make([]float64, dim)
+	for i := 0; i < s.N; i++ {
+		for j := range x {
+			x[j] = rnd.Float64()
+		}
+		var y float64
+		switch s.Kind {
+		case Friedman1:
+			y = 10*math.Sin(math.Pi*x[0]*x[1]) + 20*(x[2]-0.5)*(x[2]-0.5) + 10*x[3] + 5*x[4]
+		case Sinusoid:
+			for k := 1; k < out; k++ {
+				var yk float64
+				for _, v := range x {
+					yk += math.Sin(2 * math.Pi * float64(k+1) * v)
+				}
+				data.Set(i, dim+k, yk+s.Noise*rnd.NormFloat64())
+			}
+			for _, v := range x {
+				y += math.Sin(2 * math.Pi * v)
+			}
+		case Turbulence:
+			x[0] = 0.5 + 20*x[0]  // viscosity ratio
+			x[1] = 0.5 + 20*x[1]  // vorticity
+			x[2] = 0.5 + 4.5*x[2] // wall distance
+			y = saSource(x[0], x[1], x[2])
+		case Shells:
+			var r2 float64
+			for _, v := range x {
+				r2 += (v - 0.5) * (v - 0.5)
+			}
+			r := math.Sqrt(r2) + s.Noise*rnd.NormFloat64()
+			switch {
+			case r < 0.35:
+				y = 0
+			case r < 0.5:
+				y = 1
+			default:
+				y = 2
+			}
+		}
+		if s.Kind != Shells {
+			y += s.Noise * rnd.NormFloat64()
+		}
+		for j, v := range x {
+			data.Set(i, j, v)
+		}
+		data.Set(i, dim, y)
+	}
+	return data, nil
+}
+
+// Spalart-Allmaras model constants
+const (
+	saCb1   = 0.1355
+	saCb2   = 0.622
+	saSigma = 2.0 / 3
+	saKappa = 0.41
+	saCv1   = 7.1
+	saCw2   = 0.3
+	saCw3   = 2
+)
+
+var saCw1 = saCb1/(saKappa*saKappa) + (1+saCb2)/saSigma
+
+// saSource returns the production minus destruction of the Spalart-Allmaras
+// model for unit molecular viscosity, divided by (χ/d)².
+func saSource(chi, omega, d float64) float64 {
+	chi3 := chi * chi * chi
+	fv1 := chi3 / (chi3 + saCv1*saCv1*saCv1)
+	fv2 := 1 - chi/(1+chi*fv1)
+	kd2 := saKappa * saKappa * d * d
+	sTilde := math.Max(omega+chi/kd2*fv2, 0.3*omega)
+	r := 10.0
+	if sTilde > 0 {
+		r = math.Min(chi/(sTilde*kd2), 10)
+	}
+	g := r + saCw2*(math.Pow(r, 6)-r)
+	cw36 := math.Pow(saCw3, 6)
+	fw := g * math.Pow((1+cw36)/(math.Pow(g, 6)+cw36), 1.0/6)
+	return saCb1*sTilde*d*d/chi - saCw1*fw
+}
+
+// Headings returns the column headings of a generated dataset with dim
+// inputs and out outputs, x1 to xdim followed by y, or by y1 to yout if there
+// are several outputs.
+func Headings(dim, out int) []string {
+	h := make([]string, dim+out)
+	for i := 0; i < dim; i++ {
+		h[i] = fmt.Sprintf("x%d", i+1)
+	}
+	if out == 1 {
+		h[dim] = "y"
+		return h
+	}
+	for k := 0; k < out; k++ {
+		h[dim+k] = fmt.Sprintf("y%d", k+1)
+	}
+	return h
+}
+
+// Write generates the dataset and writes it to w as CSV with headings.
+func Write(w io.Writer, s Spec) error {
+	data, err := Generate(s)
+	if err != nil {
+		return err
+	}
+	out := s.Out
+	if out == 0 {
+		out = 1
+	}
+	_, c := data.Dims()
+	cw := numcsv.NewWriter(w)
+	return cw.WriteAll(Headings(c-out, out), data)
+}
@@ -0,0 +1,73 @@
+package datagen
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/btracey/numcsv"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, s := range []Spec{
+		{Kind: Friedman1, N: 100, Dim: 7, Noise: 0.1, Seed: 1},
+		{Kind: Sinusoid, N: 50, Seed: 2},
+		{Kind: Turbulence, N: 200, Seed: 3},
+		{Kind: Shells, N: 100, Noise: 0.05, Seed: 4},
+		{Kind: Sinusoid, N: 50, Dim: 2, Out: 3, Seed: 5},
+	} {
+		a, err := Generate(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s.Kind, err)
+		}
+		def, _, _ := s.Kind.dims()
+		if s.Dim != 0 {
+			def = s.Dim
+		}
+		out := s.Out
+		if out == 0 {
+			out = 1
+		}
+		if r, c := a.Dims(); r != s.N || c != def+out {
+			t.Errorf("%s: got %d×%d, want %d×%d", s.Kind, r, c, s.N, def+out)
+		}
+		for i := 0; i < s.N; i++ {
+			if y := a.At(i, def); math.IsNaN(y) || math.IsInf(y, 0) {
+				t.Fatalf("%s: non-finite response %v at row %d", s.Kind, y, i)
+			}
+		}
+		b, _ := Generate(s)
+		if !a.Equals(b) {
+			t.Errorf("%s: not reproducible", s.Kind)
+		}
+	}
+	if _, err := Generate(Spec{Kind: Friedman1, Dim: 3}); err == nil {
+		t.Error("no error for too few inputs")
+	}
+	if _, err := Generate(Spec{Kind: Friedman1, Out: 2}); err == nil {
+		t.Error("no error for several outputs of a single output kind")
+	}
+	if _, err := Generate(Spec{Kind: "foo"}); err != ErrKind {
+		t.Errorf("got error %v, want %v", err, ErrKind)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := Spec{Kind: Friedman1, N: 10, Seed: 1}
+	buf := &bytes.Buffer{}
+	if err := Write(buf, s); err != nil {
+		t.Fatal(err)
+	}
+	r := numcsv.NewReader(buf)
+	d, err := r.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Generate(s)
+	if !d.Data.EqualsApprox(want, 1e-12) {
+		t.Error("data mismatch after round trip")
+	}
+	if d.Headings[5] != "y" {
+		t.Errorf("got headings %v", d.Headings)
+	}
+}
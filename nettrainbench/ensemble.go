@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+	"github.com/reggo/reggo/scale"
+)
+
+// trainEnsemble trains c.Ensemble networks from different random initial
+// parameters on the inputs and outputs, and tests the mean of their
+// predictions on the test samples if they are not nil. The networks train one
+// after another with all of c.Workers, or at once with c.Workers/c.Ensemble
+// each if c.ParallelEnsemble is set, so that the two compare fine and coarse
+// grained parallelism over the same work. Each network draws from a source of
+// its own seeded from the case, so both start from the same parameters. The
+// returned record has the mean training loss and the total evaluations and
+// phases of the networks, as for crossValidate, and the test errors of each of
+// them. The trace, if any, follows the first network.
+func trainEnsemble(c Case, inputData, outputData, testInputs, testOutputs *mat64.Dense, trace *tracer, workerTiming bool) (*Record, error) {
+	if c.Method != methodNet {
+		return nil, fmt.Errorf("method %s cannot be an ensemble, only nnet", c.Method)
+	}
+	if c.model != nil {
+		return nil, errors.New("ensembles cannot start from a loaded network")
+	}
+	member := c
+	member.Ensemble = 0
+	if c.ParallelEnsemble {
+		member.Workers = c.Workers / c.Ensemble
+		if member.Workers < 1 {
+			member.Workers = 1
+		}
+	}
+	sources := make([]*rand.Rand, c.Ensemble)
+	for i := range sources {
+		sources[i] = rand.New(rand.NewSource(c.random().Int63()))
+	}
+	members := make([]*Record, c.Ensemble)
+	tests := make([]*mat64.Dense, c.Ensemble)
+	errs := make([]error, c.Ensemble)
+	run := func(i int) {
+		// Each network scales its own copy of the data.
+		start := time.Now()
+		inputs, outputs := mat64.DenseCopyOf(inputData), mat64.DenseCopyOf(outputData)
+		if testInputs != nil {
+			tests[i] = mat64.DenseCopyOf(testInputs)
+		}
+		copyTime := time.Since(start).Seconds()
+		tr := trace
+		if i > 0 {
+			tr = nil
+		}
+		m := member
+		m.rand = sources[i]
+		members[i], errs[i] = trainCase(m, inputs, outputs, tests[i], testOutputs, tr, workerTiming)
+		if errs[i] == nil {
+			members[i].Phases.Scale += copyTime
+		}
+	}
+	if c.ParallelEnsemble {
+		var wg sync.WaitGroup
+		for i := range members {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range members {
+			run(i)
+		}
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("network %d: %v", i, err)
+		}
+	}
+
+	ens := make(ensemble, len(members))
+	rec := &Record{
+		Name:      c.Name,
+		Case:      c,
+		predictor: ens,
+		inputs:    members[0].inputs,
+	}
+	statuses := make(map[string]bool)
+	for i, m := range members {
+		ens[i] = m.predictor
+		rec.Loss += m.Loss / float64(len(members))
+		rec.Iterations += m.Iterations
+		rec.FunEvals += m.FunEvals
+		rec.GradEvals += m.GradEvals
+		rec.FunGradEvals += m.FunGradEvals
+		rec.NParams += m.NParams
+		rec.Phases.add(m.Phases)
+		statuses[m.Status] = true
+		if m.Test != nil {
+			rec.Members = append(rec.Members, *m.Test)
+		}
+	}
+	rec.Status = joinStatuses(statuses)
+	if testInputs != nil {
+		// The networks have scaled their test inputs alike.
+		truth := testOutputs
+		if c.Weights == weightColumn {
+			_, truth = firstColumn(truth)
+		}
+		var outputScaling scale.Scaler = &scale.None{}
+		if s := members[0].model.OutputScaler; s != nil {
+			outputScaling = s.scaler()
+		}
+		var err error
+		rec.Test, err = predictionMetrics(ens, tests[0], truth, members[0].Test.Classes, outputScaling)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
+
+// memberSummary summarizes the test accuracy of classifiers, or RMSE, of the
+// networks of an ensemble.
+func memberSummary(members []Metrics) string {
+	var x []float64
+	for _, m := range members {
+		if m.Classes > 0 {
+			x = append(x, m.Accuracy)
+		} else {
+			x = append(x, m.RMSE)
+		}
+	}
+	if members[0].Classes > 0 {
+		return "test accuracy " + summarize(x).String()
+	}
+	return "test RMSE " + summarize(x).String()
+}
+
+// ensemble predicts the mean of the predictions of its members, which for
+// classifiers is the mean of their log odds.
+type ensemble []common.Predictor
+
+func (e ensemble) InputDim() int  { return e[0].InputDim() }
+func (e ensemble) OutputDim() int { return e[0].OutputDim() }
+
+func (e ensemble) Predict(input, output []float64) ([]float64, error) {
+	if output == nil {
+		output = make([]float64, e.OutputDim())
+	} else if len(output) != e.OutputDim() {
+		return nil, errors.New("output dimension mismatch")
+	}
+	for j := range output {
+		output[j] = 0
+	}
+	pred := make([]float64, len(output))
+	for _, p := range e {
+		if _, err := p.Predict(input, pred); err != nil {
+			return nil, err
+		}
+		floats.Add(output, pred)
+	}
+	floats.Scale(1/float64(len(e)), output)
+	return output, nil
+}
+
+// PredictBatch predicts with each member in turn, each of which may use
+// several goroutines.
+func (e ensemble) PredictBatch(inputs common.RowMatrix, outputs common.MutableRowMatrix) (common.MutableRowMatrix, error) {
+	n, _ := inputs.Dims()
+	dim := e.OutputDim()
+	if outputs == nil {
+		outputs = mat64.NewDense(n, dim, nil)
+	} else if r, c := outputs.Dims(); r != n || c != dim {
+		return outputs, errors.New("predict batch: output dimension mismatch")
+	}
+	sum := mat64.NewDense(n, dim, nil)
+	pred := mat64.NewDense(n, dim, nil)
+	for _, p := range e {
+		if _, err := p.PredictBatch(inputs, pred); err != nil {
+			return outputs, err
+		}
+		sum.Add(sum, pred)
+	}
+	sum.Scale(1/float64(len(e)), sum)
+	for i := 0; i < n; i++ {
+		outputs.SetRow(i, sum.RowView(i))
+	}
+	return outputs, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/loss"
+	"github.com/reggo/reggo/supervised/nnet"
+	"github.com/reggo/reggo/train"
+)
+
+const (
+	gradCheckSamples   = 200  // most samples of a case the gradient is checked on
+	gradCheckPoints    = 3    // random parameters the gradient is checked at
+	gradCheckParams    = 200  // most partial derivatives checked at each point
+	gradCheckStep      = 1e-5 // of the central differences, relative to the parameter
+	gradCheckFloor     = 1e-8 // least magnitude the relative error is taken against
+	gradCheckTolerance = 1e-4 // largest relative error of a correct gradient, allowing for rounding
+)
+
+// GradCheck is the largest relative error of the gradient of the training
+// objective of a case against central finite differences.
+type GradCheck struct {
+	Points    int     // random parameters checked
+	Params    int     // parameters of the network
+	Checked   int     // partial derivatives checked at each point
+	MaxRelErr float64 // largest relative error of a partial derivative
+	Param     int     // index of the parameter with that error
+	Analytic  float64
+	Numeric   float64
+}
+
+func (g GradCheck) String() string {
+	return fmt.Sprintf("max relative error %.3g at parameter %d of %d (analytic %.6g, numeric %.6g), %d derivatives at each of %d points",
+		g.MaxRelErr, g.Param, g.Params, g.Analytic, g.Numeric, g.Checked, g.Points)
+}
+
+// checkGradient compares the gradient of the training objective of the case,
+// with its loss, penalty and weights, against central finite differences at
+// points random initial parameters. The check evaluates the objective twice
+// for each partial derivative, so it is over the first gradCheckSamples of the
+// c.NData training samples of allData, and checks gradCheckParams random
+// partial derivatives of larger networks.
+func checkGradient(c Case, allData *mat64.Dense, points int) (*GradCheck, error) {
+	if c.Method != methodNet {
+		return nil, fmt.Errorf("method %s has no gradient to check", c.Method)
+	}
+	if allData == nil {
+		return nil, errors.New("streamed cases cannot be checked")
+	}
+	nSamples, nDim := allData.Dims()
+	nIn, err := inputColumns(c, nDim)
+	if err != nil {
+		return nil, err
+	}
+	n := c.NData
+	if n > gradCheckSamples {
+		n = gradCheckSamples
+	}
+	if n > nSamples {
+		return nil, fmt.Errorf("ndata %d exceeds the %d samples in %s", n, nSamples, c.Data)
+	}
+	inputs, outputs := &mat64.Dense{}, &mat64.Dense{}
+	inputs.Submatrix(allData, 0, 0, n, nIn)
+	outputs.Submatrix(allData, 0, nIn, n, nDim-nIn)
+	weights, outputs, _, classes, err := trainingOutputs(c, outputs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, _, err := scaleCase(c, inputs, outputs, classes); err != nil {
+		return nil, err
+	}
+	hiddenActivator, ok := activators[c.Activation]
+	if !ok {
+		return nil, fmt.Errorf("unknown activation %q", c.Activation)
+	}
+	_, outputDim := outputs.Dims()
+	algorithm, err := nnet.NewSimpleTrainer(nIn, outputDim, c.Layers, c.Neurons, hiddenActivator, nnet.Linear{})
+	if err != nil {
+		return nil, err
+	}
+	var losser loss.DerivLosser = loss.SquaredDistance{}
+	if classes > 0 {
+		losser = softmaxCrossEntropy{}
+	}
+	gradOpt := &train.GradOptimizable{
+		Trainable: algorithm,
+		Inputs:    inputs,
+		Outputs:   outputs,
+		Weights:   weights,
+
+		NumWorkers:  c.Workers,
+		Losser:      losser,
+		Regularizer: newRegularizer(c),
+	}
+	if err := gradOpt.Init(); err != nil {
+		return nil, err
+	}
+	defer gradOpt.Close()
+
+	check := &GradCheck{Points: points, Params: algorithm.NumParameters(), MaxRelErr: -1}
+	check.Checked = check.Params
+	if check.Checked > gradCheckParams {
+		check.Checked = gradCheckParams
+	}
+	grad := make([]float64, check.Params)
+	for p := 0; p < points; p++ {
+		randomizeParameters(algorithm, nIn, c.Layers, c.Neurons, outputDim, c.random())
+		x := algorithm.Parameters(nil)
+		gradOpt.FDf(x, grad)
+		for _, i := range c.random().Perm(check.Params)[:check.Checked] {
+			xi := x[i]
+			h := gradCheckStep * math.Max(1, math.Abs(xi))
+			x[i] = xi + h
+			plus, fPlus := x[i], gradOpt.F(x)
+			x[i] = xi - h
+			minus, fMinus := x[i], gradOpt.F(x)
+			x[i] = xi
+			// Divide by the step actually taken after rounding
+			numeric := (fPlus - fMinus) / (plus - minus)
+			rel := math.Abs(grad[i]-numeric) / math.Max(math.Max(math.Abs(grad[i]), math.Abs(numeric)), gradCheckFloor)
+			if rel > check.MaxRelErr {
+				check.MaxRelErr, check.Param, check.Analytic, check.Numeric = rel, i, grad[i], numeric
+			}
+		}
+	}
+	return check, nil
+}
+
+// checkGradients checks the gradient of each network case, writing the
+// results to w, and returns whether they were all within gradCheckTolerance.
+// Baselines are skipped.
+func checkGradients(w io.Writer, cases []Case, seed int64) (bool, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	ok := true
+	for _, c := range cases {
+		if c.Method != methodNet {
+			fmt.Fprintf(w, "%s: method %s has no gradient, skipped\n", c.Name, c.Method)
+			continue
+		}
+		allData, err := loadData(&c)
+		if err != nil {
+			return false, err
+		}
+		if c.Workers == 0 {
+			c.Workers = runtime.GOMAXPROCS(0)
+		}
+		c.rand = rnd
+		check, err := checkGradient(c, allData, gradCheckPoints)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", c.Name, err)
+		}
+		result := "ok"
+		if !(check.MaxRelErr <= gradCheckTolerance) {
+			result = "FAIL"
+			ok = false
+		}
+		fmt.Fprintf(w, "%s: gradient check %s: %v\n", c.Name, result, check)
+	}
+	return ok, nil
+}
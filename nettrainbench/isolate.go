@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Statuses of runs that did not finish.
+const (
+	statusFailed   = "Failed"   // the run returned an error
+	statusPanicked = "Panicked" // the run panicked
+	statusTimedOut = "TimedOut" // the run was abandoned after the case's timeout
+)
+
+// runFailure is the error of a run that did not finish, with the status it is
+// recorded with.
+type runFailure struct {
+	status string
+	msg    string
+}
+
+func (f *runFailure) Error() string { return f.msg }
+
+// isolate runs fit in its own goroutine, recovering from a panic and giving
+// up on it after timeout seconds if timeout is positive, so that one
+// diverging or hung case does not end the benchmark. The returned error of a
+// run that did not finish is a *runFailure. A goroutine can not be stopped,
+// so an abandoned run keeps its processors until it ends, slowing the runs
+// after it.
+func isolate(timeout float64, fit func() (*Record, error)) (*Record, error) {
+	type result struct {
+		rec *Record
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: &runFailure{statusPanicked, fmt.Sprintf("panic: %v\n%s", r, debug.Stack())}}
+			}
+		}()
+		rec, err := fit()
+		if err != nil {
+			err = &runFailure{statusFailed, err.Error()}
+		}
+		done <- result{rec, err}
+	}()
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case r := <-done:
+		return r.rec, r.err
+	case <-deadline:
+		return nil, &runFailure{statusTimedOut, fmt.Sprintf("timed out after %gs", timeout)}
+	}
+}
+
+// failedRecord returns the record of a run of the case that did not finish.
+func failedRecord(c Case, f *runFailure) Record {
+	return Record{Name: c.Name, Case: c, ConfigHash: configHash(c), Status: f.status, Error: f.msg}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"runtime"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+	predHelp "github.com/reggo/reggo/common/predict"
+)
+
+// kNearest predicts the mean of the outputs of the K training samples nearest
+// the input, by Euclidean distance, searching all of them.
+type kNearest struct {
+	K       int
+	Workers int // goroutines of PredictBatch, zero for GOMAXPROCS
+	Inputs  *mat64.Dense
+	Outputs *mat64.Dense
+}
+
+func (k *kNearest) InputDim() int {
+	_, c := k.Inputs.Dims()
+	return c
+}
+
+func (k *kNearest) OutputDim() int {
+	_, c := k.Outputs.Dims()
+	return c
+}
+
+func (k *kNearest) Predict(input, output []float64) ([]float64, error) {
+	if len(input) != k.InputDim() {
+		return nil, errors.New("input dimension mismatch")
+	}
+	if output == nil {
+		output = make([]float64, k.OutputDim())
+	} else if len(output) != k.OutputDim() {
+		return nil, errors.New("output dimension mismatch")
+	}
+	k.NewPredictor().Predict(input, output)
+	return output, nil
+}
+
+func (k *kNearest) PredictBatch(inputs common.RowMatrix, outputs common.MutableRowMatrix) (common.MutableRowMatrix, error) {
+	// Every prediction searches all of the samples, so split the rows evenly
+	// between the workers.
+	n, _ := inputs.Dims()
+	workers := k.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	grainSize := int(math.Ceil(float64(n) / float64(workers)))
+	if grainSize < 1 {
+		grainSize = 1
+	}
+	return predHelp.BatchPredict(k, inputs, outputs, k.InputDim(), k.OutputDim(), grainSize)
+}
+
+// NewPredictor returns a predictor with its own list of the nearest samples,
+// for predHelp.BatchPredict.
+func (k *kNearest) NewPredictor() predHelp.Predictor {
+	return &knnPredictor{
+		kNearest: k,
+		dist:     make([]float64, 0, k.K+1),
+		index:    make([]int, 0, k.K+1),
+	}
+}
+
+type knnPredictor struct {
+	*kNearest
+	dist  []float64 // squared distances of the nearest samples so far, ascending
+	index []int     // and their rows
+}
+
+func (p *knnPredictor) Predict(input, output []float64) {
+	p.dist, p.index = p.dist[:0], p.index[:0]
+	n, _ := p.Inputs.Dims()
+	for i := 0; i < n; i++ {
+		var d float64
+		for j, v := range p.Inputs.RowView(i) {
+			diff := v - input[j]
+			d += diff * diff
+		}
+		if len(p.dist) == p.K && d >= p.dist[p.K-1] {
+			continue
+		}
+		// Insert the sample in order, dropping the farthest if there are
+		// more than K.
+		j := len(p.dist)
+		if j < p.K {
+			p.dist, p.index = append(p.dist, 0), append(p.index, 0)
+		} else {
+			j--
+		}
+		for ; j > 0 && p.dist[j-1] > d; j-- {
+			p.dist[j], p.index[j] = p.dist[j-1], p.index[j-1]
+		}
+		p.dist[j], p.index[j] = d, i
+	}
+	for j := range output {
+		output[j] = 0
+	}
+	for _, i := range p.index {
+		for j, v := range p.Outputs.RowView(i) {
+			output[j] += v
+		}
+	}
+	for j := range output {
+		output[j] /= float64(len(p.index))
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/gonum/blas"
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+	predHelp "github.com/reggo/reggo/common/predict"
+)
+
+const (
+	defaultRidge  = 1e-3 // ridge of krr if the case does not set one
+	choleskyBlock = 64   // columns factored at a time by cholesky
+)
+
+// kernelRidge predicts the outputs as a weighted sum of Gaussian kernels
+// exp(-Gamma |x-x_i|²) centered on the training inputs x_i.
+type kernelRidge struct {
+	Gamma   float64
+	Workers int          // goroutines of PredictBatch, zero for GOMAXPROCS
+	Inputs  *mat64.Dense // the training inputs
+	Alpha   *mat64.Dense // a row of weights of each training input
+}
+
+// fitKernelRidge fits a kernelRidge to the inputs and outputs by solving
+// (K + ridge I) alpha = outputs, where K is the kernel matrix of the inputs.
+// The kernel matrix is formed with a matrix product and factored by cholesky,
+// so the fit is dominated by level 3 BLAS, and it needs memory for
+// len(inputs)² floats. It also returns the seconds taken to form the kernel
+// matrix.
+func fitKernelRidge(inputs, outputs *mat64.Dense, gamma, ridge float64) (*kernelRidge, float64, error) {
+	n, _ := inputs.Dims()
+	start := time.Now()
+	// |x_i-x_j|² = x_i.x_i + x_j.x_j - 2 x_i.x_j from the Gram matrix.
+	t := &mat64.Dense{}
+	t.TCopy(inputs)
+	a := &mat64.Dense{}
+	a.Mul(inputs, t)
+	sq := make([]float64, n)
+	for i := range sq {
+		sq[i] = a.At(i, i)
+	}
+	for i := 0; i < n; i++ {
+		row := a.RowView(i)
+		for j := 0; j <= i; j++ {
+			row[j] = math.Exp(-gamma * math.Max(sq[i]+sq[j]-2*row[j], 0))
+		}
+		row[i] += ridge
+	}
+	kernel := time.Since(start).Seconds()
+
+	if err := cholesky(a); err != nil {
+		return nil, 0, err
+	}
+	alpha := mat64.DenseCopyOf(outputs)
+	choleskySolve(a, alpha)
+	return &kernelRidge{Gamma: gamma, Inputs: inputs, Alpha: alpha}, kernel, nil
+}
+
+// cholesky overwrites the lower triangle of the symmetric positive definite
+// matrix a with L such that a = L Lᵀ, leaving Lᵀ in the upper triangle. The
+// upper triangle of a is not read. It factors choleskyBlock columns at a time
+// like LAPACK's dpotrf, using the triangular solves and matrix products of the
+// registered BLAS for all but the diagonal blocks. Only the left-sided
+// non-transposed Dtrsm is used, as the vendored goblas gets the others wrong.
+func cholesky(a *mat64.Dense) error {
+	n, _ := a.Dims()
+	raw := a.RawMatrix()
+	data, lda := raw.Data, raw.Stride
+	impl := mat64.Registered()
+	panel := make([]float64, choleskyBlock*n)
+	for k := 0; k < n; k += choleskyBlock {
+		b := choleskyBlock
+		if k+b > n {
+			b = n - k
+		}
+		// Factor the diagonal block, which the earlier blocks have updated.
+		for j := k; j < k+b; j++ {
+			rowj := data[j*lda : j*lda+n]
+			d := rowj[j]
+			for _, v := range rowj[k:j] {
+				d -= v * v
+			}
+			if !(d > 0) {
+				return fmt.Errorf("kernel matrix not positive definite at column %d, increase the ridge", j)
+			}
+			d = math.Sqrt(d)
+			rowj[j] = d
+			for i := j + 1; i < k+b; i++ {
+				rowi := data[i*lda : i*lda+n]
+				s := rowi[j]
+				for p := k; p < j; p++ {
+					s -= rowi[p] * rowj[p]
+				}
+				rowi[j] = s / d
+			}
+		}
+		m := n - k - b
+		if m == 0 {
+			break
+		}
+		// L21 = A21 L11⁻ᵀ, solved as L11 L21ᵀ = A21ᵀ in the b by m panel.
+		p := panel[:b*m]
+		for i := 0; i < m; i++ {
+			for j := 0; j < b; j++ {
+				p[j*m+i] = data[(k+b+i)*lda+k+j]
+			}
+		}
+		impl.Dtrsm(blas.Left, blas.Lower, blas.NoTrans, blas.NonUnit, b, m, 1, data[k*lda+k:], lda, p, m)
+		for i := 0; i < m; i++ {
+			for j := 0; j < b; j++ {
+				data[(k+b+i)*lda+k+j] = p[j*m+i]
+			}
+		}
+		// A22 -= L21 L21ᵀ, a block row at a time to update only the lower
+		// triangle.
+		for r := 0; r < m; r += choleskyBlock {
+			rb := choleskyBlock
+			if r+rb > m {
+				rb = m - r
+			}
+			impl.Dgemm(blas.NoTrans, blas.NoTrans, rb, r+rb, b,
+				-1, data[(k+b+r)*lda+k:], lda, p, m,
+				1, data[(k+b+r)*lda+k+b:], lda)
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			data[i*lda+j] = data[j*lda+i]
+		}
+	}
+	return nil
+}
+
+// choleskySolve overwrites b with the solution x of L Lᵀ x = b, where l was
+// factored by cholesky.
+func choleskySolve(l, b *mat64.Dense) {
+	n, _ := l.Dims()
+	_, nb := b.Dims()
+	lm, bm := l.RawMatrix(), b.RawMatrix()
+	impl := mat64.Registered()
+	impl.Dtrsm(blas.Left, blas.Lower, blas.NoTrans, blas.NonUnit, n, nb, 1, lm.Data, lm.Stride, bm.Data, bm.Stride)
+	impl.Dtrsm(blas.Left, blas.Upper, blas.NoTrans, blas.NonUnit, n, nb, 1, lm.Data, lm.Stride, bm.Data, bm.Stride)
+}
+
+func (k *kernelRidge) InputDim() int {
+	_, c := k.Inputs.Dims()
+	return c
+}
+
+func (k *kernelRidge) OutputDim() int {
+	_, c := k.Alpha.Dims()
+	return c
+}
+
+// numParameters returns the number of weights of the kernels.
+func (k *kernelRidge) numParameters() int {
+	r, c := k.Alpha.Dims()
+	return r * c
+}
+
+func (k *kernelRidge) Predict(input, output []float64) ([]float64, error) {
+	if len(input) != k.InputDim() {
+		return nil, errors.New("input dimension mismatch")
+	}
+	if output == nil {
+		output = make([]float64, k.OutputDim())
+	} else if len(output) != k.OutputDim() {
+		return nil, errors.New("output dimension mismatch")
+	}
+	k.predict(input, output)
+	return output, nil
+}
+
+func (k *kernelRidge) predict(input, output []float64) {
+	for j := range output {
+		output[j] = 0
+	}
+	n, _ := k.Inputs.Dims()
+	for i := 0; i < n; i++ {
+		var d float64
+		for j, v := range k.Inputs.RowView(i) {
+			diff := v - input[j]
+			d += diff * diff
+		}
+		w := math.Exp(-k.Gamma * d)
+		for j, a := range k.Alpha.RowView(i) {
+			output[j] += w * a
+		}
+	}
+}
+
+func (k *kernelRidge) PredictBatch(inputs common.RowMatrix, outputs common.MutableRowMatrix) (common.MutableRowMatrix, error) {
+	// Every prediction sums over all of the samples, so split the rows evenly
+	// between the workers, as for knn.
+	n, _ := inputs.Dims()
+	workers := k.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	grainSize := int(math.Ceil(float64(n) / float64(workers)))
+	if grainSize < 1 {
+		grainSize = 1
+	}
+	return predHelp.BatchPredict(k, inputs, outputs, k.InputDim(), k.OutputDim(), grainSize)
+}
+
+// NewPredictor returns a predictor of the model for predHelp.BatchPredict.
+// The model keeps no state while predicting, so they can share it.
+func (k *kernelRidge) NewPredictor() predHelp.Predictor {
+	return kernelPredictor{k}
+}
+
+type kernelPredictor struct {
+	*kernelRidge
+}
+
+func (p kernelPredictor) Predict(input, output []float64) {
+	p.predict(input, output)
+}
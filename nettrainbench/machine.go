@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// affinity is the list of processors the benchmark is pinned to, as given to
+// -cpus, or empty if it is not pinned, and pinned holds them.
+var (
+	affinity string
+	pinned   []int
+)
+
+// Modes of -governor for processors whose frequency governor is not
+// performance, which varies their clock speed with the load.
+const (
+	governorWarn    = "warn"    // log a warning
+	governorRequire = "require" // refuse to run
+	governorIgnore  = "ignore"  // do not check
+)
+
+// parseCPUList parses a list of processors such as "0-3,6" in the format of
+// taskset and /sys/devices/system/cpu/online.
+func parseCPUList(s string) ([]int, error) {
+	seen := make(map[int]bool)
+	var cpus []int
+	for _, field := range strings.Split(s, ",") {
+		lo, hi := field, field
+		if i := strings.Index(field, "-"); i >= 0 {
+			lo, hi = field[:i], field[i+1:]
+		}
+		first, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("cpu list %q: bad processor %q", s, lo)
+		}
+		last, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil {
+			return nil, fmt.Errorf("cpu list %q: bad processor %q", s, hi)
+		}
+		if first < 0 || last < first {
+			return nil, fmt.Errorf("cpu list %q: bad range %q", s, field)
+		}
+		for c := first; c <= last; c++ {
+			if !seen[c] {
+				seen[c] = true
+				cpus = append(cpus, c)
+			}
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// governors returns the distinct frequency governors of the processors, or
+// of all of them if cpus is nil, comma separated. It is empty if they are
+// not known, which is always so outside Linux.
+func governors(cpus []int) string {
+	var files []string
+	if cpus == nil {
+		files, _ = filepath.Glob("/sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_governor")
+	} else {
+		for _, c := range cpus {
+			files = append(files, fmt.Sprintf("/sys/devices/system/cpu/cpu%d/cpufreq/scaling_governor", c))
+		}
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		g := strings.TrimSpace(string(b))
+		if !seen[g] {
+			seen[g] = true
+			names = append(names, g)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// checkGovernor warns, or returns an error if mode is governorRequire, if the
+// processors are known to have a frequency governor other than performance.
+func checkGovernor(mode string, cpus []int) error {
+	switch mode {
+	case governorIgnore:
+		return nil
+	case governorWarn, governorRequire:
+	default:
+		return fmt.Errorf("unknown governor mode %q, want %s, %s or %s", mode, governorWarn, governorRequire, governorIgnore)
+	}
+	g := governors(cpus)
+	if g == "" || g == "performance" {
+		return nil
+	}
+	msg := fmt.Sprintf("cpu frequency governor is %s, not performance, so timings vary with the clock speed", g)
+	if mode == governorRequire {
+		return fmt.Errorf("%s; set it with cpupower frequency-set -g performance", msg)
+	}
+	log.Print(msg)
+	return nil
+}
+
+// setGOGC sets the garbage collection target percentage, as for GOGC, unless
+// it is zero, turning collection off if it is negative, and returns a function
+// restoring the last one.
+func setGOGC(percent int) (restore func()) {
+	if percent == 0 {
+		return func() {}
+	}
+	if percent < 0 {
+		percent = -1
+	}
+	old := debug.SetGCPercent(percent)
+	return func() { debug.SetGCPercent(old) }
+}
+
+// blasThreadVars are the environment variables setting the threads of the
+// C BLAS libraries.
+var blasThreadVars = []string{"OPENBLAS_NUM_THREADS", "GOTO_NUM_THREADS", "MKL_NUM_THREADS", "OMP_NUM_THREADS"}
+
+// blasThreads returns the blasThreadVars that are set, as NAME=value
+// separated by spaces.
+func blasThreads() string {
+	var set []string
+	for _, name := range blasThreadVars {
+		if v, ok := os.LookupEnv(name); ok {
+			set = append(set, name+"="+v)
+		}
+	}
+	return strings.Join(set, " ")
+}
+
+// physicalCores returns the number of distinct physical id and core id pairs
+// in /proc/cpuinfo, or zero if it has none.
+func physicalCores() int {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	cores := make(map[string]bool)
+	var physical string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		kv := strings.SplitN(s.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "physical id":
+			physical = strings.TrimSpace(kv[1])
+		case "core id":
+			cores[physical+"/"+strings.TrimSpace(kv[1])] = true
+		}
+	}
+	return len(cores)
+}
+
+// numaNodes returns the processors of each NUMA node from
+// /sys/devices/system/node, separated by semicolons.
+func numaNodes() string {
+	files, _ := filepath.Glob("/sys/devices/system/node/node[0-9]*/cpulist")
+	// Sort node10 after node9.
+	sort.Slice(files, func(i, j int) bool {
+		if len(files[i]) != len(files[j]) {
+			return len(files[i]) < len(files[j])
+		}
+		return files[i] < files[j]
+	})
+	var nodes []string
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		nodes = append(nodes, strings.TrimSpace(string(b)))
+	}
+	return strings.Join(nodes, ";")
+}
+
+// physicalMemory returns the MemTotal of /proc/meminfo in bytes.
+func physicalMemory() uint64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb << 10
+		}
+	}
+	return 0
+}
+
+// osRelease returns the name of the distribution from /etc/os-release and the
+// kernel release, or GOOS if neither is known.
+func osRelease() string {
+	var parts []string
+	if b, err := os.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(b), "\n") {
+			if v := strings.TrimPrefix(line, "PRETTY_NAME="); v != line {
+				parts = append(parts, strings.Trim(v, `"`))
+			}
+		}
+	}
+	if b, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		parts = append(parts, strings.TrimSpace(string(b)))
+	}
+	if len(parts) == 0 {
+		return runtime.GOOS
+	}
+	return strings.Join(parts, " ")
+}
+
+// machine returns a short hash of the hardware and OS of the environment,
+// leaving out the build and the settings of the run, such as the processors
+// it is pinned to.
+func (e Env) machine() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%d\x00%s\x00%s/%s", e.CPU, e.Cores, e.NUMA, e.Memory, e.OS, e.GOOS, e.GOARCH)
+	return fmt.Sprintf("%x", h.Sum(nil))[:12]
+}
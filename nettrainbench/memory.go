@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// heapSampleInterval is how often the live heap is sampled for its peak.
+const heapSampleInterval = 10 * time.Millisecond
+
+// heapObjects is the runtime/metrics name of the bytes in live and unswept
+// heap objects, which reading does not stop the world.
+const heapObjects = "/memory/classes/heap/objects:bytes"
+
+// Memory is the allocation and garbage collection of a run, from the changes
+// in runtime.MemStats over it.
+type Memory struct {
+	Mallocs      uint64  `json:"mallocs"`       // heap objects allocated
+	Bytes        uint64  `json:"bytes"`         // bytes allocated
+	NumGC        uint32  `json:"num_gc"`        // garbage collections
+	PauseSeconds float64 `json:"pause_seconds"` // total stop-the-world pauses of the collections
+	MaxPause     float64 `json:"max_pause"`     // longest of them in seconds
+	PeakHeap     uint64  `json:"peak_heap"`     // most bytes of heap objects, sampled every heapSampleInterval
+}
+
+func (m Memory) String() string {
+	return fmt.Sprintf("allocated %d objects, %.4g MB; %d GCs pausing %.3gs, at most %.3gs; peak heap %.4g MB",
+		m.Mallocs, float64(m.Bytes)/1e6, m.NumGC, m.PauseSeconds, m.MaxPause, float64(m.PeakHeap)/1e6)
+}
+
+// memoryMeter measures the Memory of a run from its start until
+// its stop method is called.
+type memoryMeter struct {
+	start runtime.MemStats
+	done  chan struct{}
+	peak  chan uint64
+}
+
+// startMemory starts measuring the memory of a run.
+func startMemory() *memoryMeter {
+	m := &memoryMeter{done: make(chan struct{}), peak: make(chan uint64)}
+	runtime.ReadMemStats(&m.start)
+	go func() {
+		sample := []metrics.Sample{{Name: heapObjects}}
+		ticker := time.NewTicker(heapSampleInterval)
+		defer ticker.Stop()
+		var peak uint64
+		for {
+			metrics.Read(sample)
+			if v := sample[0].Value; v.Kind() == metrics.KindUint64 && v.Uint64() > peak {
+				peak = v.Uint64()
+			}
+			select {
+			case <-ticker.C:
+			case <-m.done:
+				m.peak <- peak
+				return
+			}
+		}
+	}()
+	return m
+}
+
+// stop returns the memory of the run so far.
+func (m *memoryMeter) stop() *Memory {
+	var end runtime.MemStats
+	runtime.ReadMemStats(&end)
+	close(m.done)
+	peak := <-m.peak
+	if end.HeapAlloc > peak {
+		peak = end.HeapAlloc
+	}
+	mem := &Memory{
+		Mallocs:      end.Mallocs - m.start.Mallocs,
+		Bytes:        end.TotalAlloc - m.start.TotalAlloc,
+		NumGC:        end.NumGC - m.start.NumGC,
+		PauseSeconds: float64(end.PauseTotalNs-m.start.PauseTotalNs) / 1e9,
+		PeakHeap:     peak,
+	}
+	// PauseNs holds the pauses of the last 256 collections.
+	n := mem.NumGC
+	if n > uint32(len(end.PauseNs)) {
+		n = uint32(len(end.PauseNs))
+	}
+	for i := uint32(0); i < n; i++ {
+		p := float64(end.PauseNs[(end.NumGC-i+255)%256]) / 1e9
+		if p > mem.MaxPause {
+			mem.MaxPause = p
+		}
+	}
+	return mem
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+	"github.com/reggo/reggo/loss"
+)
+
+// Models a case can train. The baselines are fit in closed form or not at
+// all, so they give the accuracy and cost of the network some context.
+const (
+	methodNet = "nnet" // neural net trained by the optimizer
+	methodOLS = "ols"  // ordinary least squares with an intercept
+	methodKNN = "knn"  // mean of the K nearest training samples
+	methodKRR = "krr"  // kernel ridge regression with Gaussian kernels
+)
+
+// defaultK is the number of neighbors of knn if the case does not set one.
+const defaultK = 5
+
+// methodNames returns the names of the models in the order they are compared.
+func methodNames() []string {
+	return []string{methodNet, methodOLS, methodKNN, methodKRR}
+}
+
+// expandCases expands the cases with method, optimizer or activation "all"
+// into a case for each.
+func expandCases(cases []Case) []Case {
+	return expandActivations(expandOptimizers(expandMethods(cases)))
+}
+
+// expandMethods replaces each case with method "all" by a case for each
+// model.
+func expandMethods(cases []Case) []Case {
+	var expanded []Case
+	for _, c := range cases {
+		if c.Method != "all" {
+			expanded = append(expanded, c)
+			continue
+		}
+		for _, name := range methodNames() {
+			c := c
+			c.Method = name
+			c.Name += "_" + name
+			expanded = append(expanded, c)
+		}
+	}
+	return expanded
+}
+
+// trainBaseline fits the baseline model of the case to the inputs and
+// outputs, which are scaled in place as for a network, and tests it on the
+// test samples if they are not nil. The fit is timed as the optimizer, apart
+// from forming the kernel matrix of krr, which is timed as the setup. The loss
+// is the mean squared error on the scaled training outputs, as for a network.
+// Baselines only do unweighted regression.
+func trainBaseline(c Case, inputData, outputData, testInputs, testOutputs *mat64.Dense) (*Record, error) {
+	if c.Task != regression {
+		return nil, fmt.Errorf("method %s only does regression", c.Method)
+	}
+	if c.Weights != "" {
+		return nil, fmt.Errorf("method %s does not weight samples", c.Method)
+	}
+	if c.model != nil {
+		return nil, fmt.Errorf("method %s cannot start from a loaded network", c.Method)
+	}
+	var phases Phases
+	start := time.Now()
+	inputScaler, _, outputScaling, err := scaleCase(c, inputData, outputData, 0)
+	if err != nil {
+		return nil, err
+	}
+	phases.Scale = time.Since(start).Seconds()
+
+	start = time.Now()
+	var predictor common.Predictor
+	var nParams int
+	var status string
+	switch c.Method {
+	case methodOLS:
+		l, err := fitLinear(inputData, outputData)
+		if err != nil {
+			return nil, err
+		}
+		predictor, nParams, status = l, l.numParameters(), "Solved"
+	case methodKNN:
+		k := c.K
+		if k == 0 {
+			k = defaultK
+		}
+		n, _ := inputData.Dims()
+		if k < 1 || k > n {
+			return nil, fmt.Errorf("k %d not between 1 and the %d training samples", k, n)
+		}
+		predictor, status = &kNearest{K: k, Workers: c.Workers, Inputs: inputData, Outputs: outputData}, "Memorized"
+	case methodKRR:
+		_, inputDim := inputData.Dims()
+		gamma, ridge := c.Gamma, c.Ridge
+		if gamma == 0 {
+			gamma = 1 / float64(inputDim)
+		}
+		if ridge == 0 {
+			ridge = defaultRidge
+		}
+		if !(gamma > 0) || !(ridge > 0) {
+			return nil, fmt.Errorf("krr gamma %g and ridge %g must be positive", gamma, ridge)
+		}
+		k, kernel, err := fitKernelRidge(inputData, outputData, gamma, ridge)
+		if err != nil {
+			return nil, err
+		}
+		k.Workers = c.Workers
+		predictor, nParams, status = k, k.numParameters(), "Solved"
+		phases.Setup = kernel
+	default:
+		return nil, fmt.Errorf("unknown method %q", c.Method)
+	}
+	trainLoss, err := meanLoss(predictor, inputData, outputData)
+	if err != nil {
+		return nil, err
+	}
+	phases.Optimize = time.Since(start).Seconds() - phases.Setup
+
+	var test *Metrics
+	if testInputs != nil {
+		test, err = testMetrics(predictor, testInputs, testOutputs, 0, inputScaler.scaler(), outputScaling)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Record{
+		Name:      c.Name,
+		Case:      c,
+		Loss:      trainLoss,
+		NParams:   nParams,
+		Status:    status,
+		Phases:    phases,
+		Test:      test,
+		predictor: predictor,
+		inputs:    inputData,
+	}, nil
+}
+
+// meanLoss returns the mean squared distance between the predictions of the
+// inputs and the outputs.
+func meanLoss(p common.Predictor, inputs, outputs *mat64.Dense) (float64, error) {
+	n, nOut := outputs.Dims()
+	if n == 0 {
+		return 0, errors.New("no samples")
+	}
+	pred := mat64.NewDense(n, nOut, nil)
+	if _, err := p.PredictBatch(inputs, pred); err != nil {
+		return 0, err
+	}
+	var l float64
+	for i := 0; i < n; i++ {
+		l += loss.SquaredDistance{}.Loss(pred.RowView(i), outputs.RowView(i))
+	}
+	return l / float64(n), nil
+}
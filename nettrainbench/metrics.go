@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Metrics are the prediction errors of a trained network on a data set, in
+// the units of the unscaled outputs. Classifiers have the number of classes,
+// accuracy and cross-entropy in place of the regression errors.
+type Metrics struct {
+	N    int     `json:"n"` // number of samples
+	RMSE float64 `json:"rmse"`
+	MAE  float64 `json:"mae"`
+	R2   float64 `json:"r2"` // coefficient of determination, pooled over the outputs
+
+	Outputs []Metrics `json:"outputs,omitempty"` // errors of each output, if there are several
+
+	Classes      int     `json:"classes,omitempty"`
+	Accuracy     float64 `json:"accuracy,omitempty"`      // fraction of samples with the most likely class correct
+	CrossEntropy float64 `json:"cross_entropy,omitempty"` // mean over the samples
+}
+
+func (m Metrics) String() string {
+	if m.Classes > 0 {
+		return fmt.Sprintf("accuracy %.4f, cross-entropy %.4g (n=%d, %d classes)", m.Accuracy, m.CrossEntropy, m.N, m.Classes)
+	}
+	str := fmt.Sprintf("RMSE %.4g, MAE %.4g, R² %.4f (n=%d)", m.RMSE, m.MAE, m.R2, m.N)
+	for j, o := range m.Outputs {
+		str += fmt.Sprintf("; output %d RMSE %.4g, R² %.4f", j, o.RMSE, o.R2)
+	}
+	return str
+}
+
+// errorMetrics returns the metrics of the predictions against the truth. The
+// total variance of R² is taken about the mean of each output.
+func errorMetrics(pred, truth *mat64.Dense) Metrics {
+	r, c := truth.Dims()
+	m := pooledMetrics(pred, truth)
+	if r == 0 || c == 1 {
+		return m
+	}
+	m.Outputs = make([]Metrics, c)
+	p, t := &mat64.Dense{}, &mat64.Dense{}
+	for j := range m.Outputs {
+		p.Submatrix(pred, 0, j, r, 1)
+		t.Submatrix(truth, 0, j, r, 1)
+		m.Outputs[j] = pooledMetrics(p, t)
+	}
+	return m
+}
+
+// pooledMetrics returns the metrics over all of the outputs.
+func pooledMetrics(pred, truth *mat64.Dense) Metrics {
+	r, c := truth.Dims()
+	m := Metrics{N: r}
+	if r == 0 {
+		return m
+	}
+	var sse, sae, sst float64
+	for j := 0; j < c; j++ {
+		var mean float64
+		for i := 0; i < r; i++ {
+			mean += truth.At(i, j)
+		}
+		mean /= float64(r)
+		for i := 0; i < r; i++ {
+			y := truth.At(i, j)
+			e := pred.At(i, j) - y
+			sse += e * e
+			sae += math.Abs(e)
+			sst += (y - mean) * (y - mean)
+		}
+	}
+	n := float64(r * c)
+	m.RMSE = math.Sqrt(sse / n)
+	m.MAE = sae / n
+	m.R2 = 1 - sse/sst
+	return m
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsContentType is that of the Prometheus text format written by
+// writeMetrics, which Prometheus, its Pushgateway and OpenMetrics scrapers
+// read.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// exportedMetrics are the gauges written by writeMetrics for each benchmark,
+// named with the prefix nettrainbench_, each the mean over the runs that have
+// it.
+var exportedMetrics = []struct {
+	name, help string
+	value      func(r Record) (float64, bool)
+}{
+	{"seconds", "Wall time of a training run.", func(r Record) (float64, bool) { return r.Seconds, true }},
+	{"ns_per_eval", "Wall time per evaluation of the objective or its gradient.", func(r Record) (float64, bool) { return r.NsPerEval, r.NsPerEval > 0 }},
+	{"evals", "Evaluations of the objective or its gradient of a training run.", func(r Record) (float64, bool) { return float64(r.evals()), r.evals() > 0 }},
+	{"loss", "Final training loss, of the scaled outputs.", func(r Record) (float64, bool) { return r.Loss, true }},
+	{"test_rmse", "Root mean squared error of a regression on the held out samples or folds.", func(r Record) (float64, bool) {
+		switch {
+		case r.Test != nil && r.Test.Classes == 0:
+			return r.Test.RMSE, true
+		case r.CV != nil && r.CV.Accuracy == nil:
+			return r.CV.RMSE.Mean, true
+		}
+		return 0, false
+	}},
+	{"test_accuracy", "Accuracy of a classifier on the held out samples or folds.", func(r Record) (float64, bool) {
+		switch {
+		case r.Test != nil && r.Test.Classes > 0:
+			return r.Test.Accuracy, true
+		case r.CV != nil && r.CV.Accuracy != nil:
+			return r.CV.Accuracy.Mean, true
+		}
+		return 0, false
+	}},
+	{"bytes_per_op", "Bytes allocated by a training run.", func(r Record) (float64, bool) {
+		if r.Memory == nil {
+			return 0, false
+		}
+		return float64(r.Memory.Bytes), true
+	}},
+	{"allocs_per_op", "Heap objects allocated by a training run.", func(r Record) (float64, bool) {
+		if r.Memory == nil {
+			return 0, false
+		}
+		return float64(r.Memory.Mallocs), true
+	}},
+}
+
+// metricLabels returns the labels of the benchmark of a record: its case,
+// config hash, machine and backend, which identify it, and the settings most
+// often filtered on. The commit is left to the info metric, so that a
+// benchmark is one series across commits.
+func metricLabels(r Record) [][2]string {
+	c := r.Case
+	return [][2]string{
+		{"case", r.Name},
+		{"config", r.ConfigHash},
+		{"machine", r.Env.Machine},
+		{"blas", r.Env.BLAS},
+		{"method", c.Method},
+		{"optimizer", c.Optimizer},
+		{"activation", c.Activation},
+		{"neurons", strconv.Itoa(c.Neurons)},
+		{"layers", strconv.Itoa(c.Layers)},
+		{"ndata", strconv.Itoa(c.NData)},
+		{"workers", strconv.Itoa(c.Workers)},
+	}
+}
+
+func formatLabels(labels [][2]string) string {
+	s := make([]string, len(labels))
+	for i, l := range labels {
+		s[i] = l[0] + `="` + escapeLabel(l[1]) + `"`
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// writeMetrics writes the finished records in the Prometheus text format, as
+// the exportedMetrics of each benchmark, the runs of each and an info metric
+// with the commit and Go version of its runs, ordered by their labels.
+func writeMetrics(w io.Writer, records []Record) error {
+	groups := make(map[string][]Record)
+	var keys []string
+	for _, r := range finished(records) {
+		k := formatLabels(metricLabels(r))
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+	sort.Strings(keys)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# HELP nettrainbench_runs Runs of the benchmark.")
+	fmt.Fprintln(bw, "# TYPE nettrainbench_runs gauge")
+	for _, k := range keys {
+		fmt.Fprintf(bw, "nettrainbench_runs%s %d\n", k, len(groups[k]))
+	}
+	for _, m := range exportedMetrics {
+		fmt.Fprintf(bw, "# HELP nettrainbench_%s %s\n", m.name, m.help)
+		fmt.Fprintf(bw, "# TYPE nettrainbench_%s gauge\n", m.name)
+		for _, k := range keys {
+			var sum float64
+			var n int
+			for _, r := range groups[k] {
+				if v, ok := m.value(r); ok {
+					sum += v
+					n++
+				}
+			}
+			if n > 0 {
+				fmt.Fprintf(bw, "nettrainbench_%s%s %s\n", m.name, k, strconv.FormatFloat(sum/float64(n), 'g', -1, 64))
+			}
+		}
+	}
+	fmt.Fprintln(bw, "# HELP nettrainbench_info Commit and Go version of the runs of the benchmark.")
+	fmt.Fprintln(bw, "# TYPE nettrainbench_info gauge")
+	for _, k := range keys {
+		r := groups[k][len(groups[k])-1]
+		info := append(metricLabels(r), [2]string{"git", r.Env.GitSHA}, [2]string{"go", r.Env.GoVersion})
+		fmt.Fprintf(bw, "nettrainbench_info%s 1\n", formatLabels(info))
+	}
+	return bw.Flush()
+}
+
+// latestRecords returns the records of the last commit of each benchmark, a
+// case and config on a machine with a backend, by the start of their runs.
+func latestRecords(records []Record) []Record {
+	type key struct{ name, config, machine, blas string }
+	latest := make(map[key]Record)
+	for _, r := range records {
+		k := key{r.Name, r.ConfigHash, r.Env.Machine, r.Env.BLAS}
+		if l, ok := latest[k]; !ok || r.Start.After(l.Start) {
+			latest[k] = r
+		}
+	}
+	var recs []Record
+	for _, r := range records {
+		if r.Env.GitSHA == latest[key{r.Name, r.ConfigHash, r.Env.Machine, r.Env.BLAS}].Env.GitSHA {
+			recs = append(recs, r)
+		}
+	}
+	return recs
+}
+
+// pushMetrics replaces the metrics of the job nettrainbench of the Prometheus
+// Pushgateway at the URL with those of the records.
+func pushMetrics(gateway string, records []Record) error {
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, records); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSuffix(gateway, "/")+"/metrics/job/nettrainbench", &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", metricsContentType)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("push to %s: %s: %s", gateway, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Model is a trained network with the scalers of its training data, as saved
+// by -save and loaded by -load.
+type Model struct {
+	Case         Case      `json:"case"` // the case that trained it, giving the architecture
+	InputDim     int       `json:"input_dim"`
+	OutputDim    int       `json:"output_dim"`
+	Parameters   []float64 `json:"parameters"`
+	InputScaler  *Scaler   `json:"input_scaler"`
+	OutputScaler *Scaler   `json:"output_scaler"` // nil for classifiers
+}
+
+// architecture sets the fields of c that determine the shape of the network
+// to those of the model.
+func (m *Model) architecture(c *Case) {
+	c.Layers = m.Case.Layers
+	c.Neurons = m.Case.Neurons
+	c.Activation = m.Case.Activation
+	c.Task = m.Case.Task
+	c.Outputs = m.Case.Outputs
+	c.InputScaling = m.Case.InputScaling
+	c.OutputScaling = m.Case.OutputScaling
+}
+
+// saveModel writes the model to the named file, as JSON if the name ends in
+// ".json" and as gob otherwise.
+func saveModel(filename string, m *Model) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(filename) == ".json" {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(m)
+	} else {
+		err = gob.NewEncoder(f).Encode(m)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadModel reads a model written by saveModel.
+func loadModel(filename string) (*Model, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m := &Model{}
+	if filepath.Ext(filename) == ".json" {
+		err = json.NewDecoder(f).Decode(m)
+	} else {
+		err = gob.NewDecoder(f).Decode(m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("model %s: %v", filename, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationmonobolditalic provides the "LiberationMono BoldItalic" TrueType font
+// from the Liberation font family.
+package liberationmonobolditalic // import "codeberg.org/go-fonts/liberation/liberationmonobolditalic"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationMono BoldItalic" TrueType font.
+//
+//go:embed LiberationMono-BoldItalic.ttf
+var TTF []byte
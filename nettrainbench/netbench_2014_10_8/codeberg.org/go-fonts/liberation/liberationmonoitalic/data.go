@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationmonoitalic provides the "LiberationMono Italic" TrueType font
+// from the Liberation font family.
+package liberationmonoitalic // import "codeberg.org/go-fonts/liberation/liberationmonoitalic"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationMono Italic" TrueType font.
+//
+//go:embed LiberationMono-Italic.ttf
+var TTF []byte
@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationmonoregular provides the "LiberationMono Regular" TrueType font
+// from the Liberation font family.
+package liberationmonoregular // import "codeberg.org/go-fonts/liberation/liberationmonoregular"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationMono Regular" TrueType font.
+//
+//go:embed LiberationMono-Regular.ttf
+var TTF []byte
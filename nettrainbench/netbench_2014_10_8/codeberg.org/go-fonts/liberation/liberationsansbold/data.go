@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationsansbold provides the "LiberationSans Bold" TrueType font
+// from the Liberation font family.
+package liberationsansbold // import "codeberg.org/go-fonts/liberation/liberationsansbold"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSans Bold" TrueType font.
+//
+//go:embed LiberationSans-Bold.ttf
+var TTF []byte
@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationsansbolditalic provides the "LiberationSans BoldItalic" TrueType font
+// from the Liberation font family.
+package liberationsansbolditalic // import "codeberg.org/go-fonts/liberation/liberationsansbolditalic"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSans BoldItalic" TrueType font.
+//
+//go:embed LiberationSans-BoldItalic.ttf
+var TTF []byte
@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationsansitalic provides the "LiberationSans Italic" TrueType font
+// from the Liberation font family.
+package liberationsansitalic // import "codeberg.org/go-fonts/liberation/liberationsansitalic"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSans Italic" TrueType font.
+//
+//go:embed LiberationSans-Italic.ttf
+var TTF []byte
@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationserifbold provides the "LiberationSerif Bold" TrueType font
+// from the Liberation font family.
+package liberationserifbold // import "codeberg.org/go-fonts/liberation/liberationserifbold"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSerif Bold" TrueType font.
+//
+//go:embed LiberationSerif-Bold.ttf
+var TTF []byte
@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationserifbolditalic provides the "LiberationSerif BoldItalic" TrueType font
+// from the Liberation font family.
+package liberationserifbolditalic // import "codeberg.org/go-fonts/liberation/liberationserifbolditalic"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSerif BoldItalic" TrueType font.
+//
+//go:embed LiberationSerif-BoldItalic.ttf
+var TTF []byte
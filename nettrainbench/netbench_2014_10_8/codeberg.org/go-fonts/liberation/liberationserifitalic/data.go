@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationserifitalic provides the "LiberationSerif Italic" TrueType font
+// from the Liberation font family.
+package liberationserifitalic // import "codeberg.org/go-fonts/liberation/liberationserifitalic"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSerif Italic" TrueType font.
+//
+//go:embed LiberationSerif-Italic.ttf
+var TTF []byte
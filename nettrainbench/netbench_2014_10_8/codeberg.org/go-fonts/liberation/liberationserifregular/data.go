@@ -0,0 +1,12 @@
+// generated by go run gen-fonts.go; DO NOT EDIT
+
+// Package liberationserifregular provides the "LiberationSerif Regular" TrueType font
+// from the Liberation font family.
+package liberationserifregular // import "codeberg.org/go-fonts/liberation/liberationserifregular"
+
+import _ "embed"
+
+// TTF is the data for the "LiberationSerif Regular" TrueType font.
+//
+//go:embed LiberationSerif-Regular.ttf
+var TTF []byte
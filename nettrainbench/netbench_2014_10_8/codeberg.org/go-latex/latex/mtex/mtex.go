@@ -0,0 +1,6 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mtex provides tools to render LaTeX math expressions.
+package mtex
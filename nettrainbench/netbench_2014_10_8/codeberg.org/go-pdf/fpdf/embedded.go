@@ -0,0 +1,44 @@
+// Copyright ©2023 The go-pdf Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*
+ * Copyright (c) 2014 Kurt Jung (Gmail: kurt.w.jung)
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package fpdf
+
+// Embedded standard fonts
+
+import (
+	"embed"
+	"io"
+	"strings"
+)
+
+//go:embed font_embed/*.json font_embed/*.map
+var embFS embed.FS
+
+func (f *Fpdf) coreFontReader(familyStr, styleStr string) (r io.ReadCloser) {
+	key := familyStr + styleStr
+	key = strings.ToLower(key)
+	emb, err := embFS.Open("font_embed/" + key + ".json")
+	if err == nil {
+		r = emb
+	} else {
+		f.SetErrorf("could not locate \"%s\" among embedded core font definition files", key)
+	}
+	return
+}
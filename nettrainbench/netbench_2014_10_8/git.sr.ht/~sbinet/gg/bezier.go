@@ -0,0 +1,63 @@
+// Copyright ©2022 The gg Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import "math"
+
+func quadratic(x0, y0, x1, y1, x2, y2, t float64) (x, y float64) {
+	u := 1 - t
+	a := u * u
+	b := 2 * u * t
+	c := t * t
+	x = a*x0 + b*x1 + c*x2
+	y = a*y0 + b*y1 + c*y2
+	return
+}
+
+func QuadraticBezier(x0, y0, x1, y1, x2, y2 float64) []Point {
+	l := (math.Hypot(x1-x0, y1-y0) +
+		math.Hypot(x2-x1, y2-y1))
+	n := int(l + 0.5)
+	if n < 4 {
+		n = 4
+	}
+	d := float64(n) - 1
+	result := make([]Point, n)
+	for i := 0; i < n; i++ {
+		t := float64(i) / d
+		x, y := quadratic(x0, y0, x1, y1, x2, y2, t)
+		result[i] = Point{x, y}
+	}
+	return result
+}
+
+func cubic(x0, y0, x1, y1, x2, y2, x3, y3, t float64) (x, y float64) {
+	u := 1 - t
+	a := u * u * u
+	b := 3 * u * u * t
+	c := 3 * u * t * t
+	d := t * t * t
+	x = a*x0 + b*x1 + c*x2 + d*x3
+	y = a*y0 + b*y1 + c*y2 + d*y3
+	return
+}
+
+func CubicBezier(x0, y0, x1, y1, x2, y2, x3, y3 float64) []Point {
+	l := (math.Hypot(x1-x0, y1-y0) +
+		math.Hypot(x2-x1, y2-y1) +
+		math.Hypot(x3-x2, y3-y2))
+	n := int(l + 0.5)
+	if n < 4 {
+		n = 4
+	}
+	d := float64(n) - 1
+	result := make([]Point, n)
+	for i := 0; i < n; i++ {
+		t := float64(i) / d
+		x, y := cubic(x0, y0, x1, y1, x2, y2, x3, y3, t)
+		result[i] = Point{x, y}
+	}
+	return result
+}
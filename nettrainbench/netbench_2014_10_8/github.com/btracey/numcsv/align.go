@@ -0,0 +1,79 @@
+package numcsv
+
+import (
+	"bytes"
+	"io"
+)
+
+// aligner sits below the Writer's buffer. When Writer.Align is set it holds
+// the output until Flush, then pads the fields of each line to the width of
+// their column.
+type aligner struct {
+	w       *Writer
+	dst     io.Writer
+	pending []byte
+}
+
+func (a *aligner) Write(p []byte) (int, error) {
+	if !a.w.Align {
+		return a.dst.Write(p)
+	}
+	a.pending = append(a.pending, p...)
+	return len(p), nil
+}
+
+// flush writes the pending lines with their fields right-aligned. Comment
+// lines are written unchanged.
+func (a *aligner) flush() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	comma := []byte(a.w.separator())
+	var comment []byte
+	if a.w.Comment != "" {
+		comment = []byte(a.w.Comment)
+	}
+	lines := bytes.SplitAfter(a.pending, []byte{'\n'})
+	fields := make([][][]byte, len(lines))
+	var widths []int
+	for i, line := range lines {
+		if comment != nil && bytes.HasPrefix(line, comment) {
+			continue
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		fields[i] = bytes.Split(line, comma)
+		for j, f := range fields[i] {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(f) > widths[j] {
+				widths[j] = len(f)
+			}
+		}
+	}
+
+	var out []byte
+	for i, line := range lines {
+		if fields[i] == nil {
+			out = append(out, line...)
+			continue
+		}
+		for j, f := range fields[i] {
+			if j > 0 {
+				out = append(out, comma...)
+			}
+			for k := len(f); k < widths[j]; k++ {
+				out = append(out, ' ')
+			}
+			out = append(out, f...)
+		}
+		trimmed := bytes.TrimRight(line, "\r\n")
+		out = append(out, line[len(trimmed):]...)
+	}
+	a.pending = a.pending[:0]
+	_, err := a.dst.Write(out)
+	return err
+}
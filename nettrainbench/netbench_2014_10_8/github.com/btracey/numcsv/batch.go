@@ -0,0 +1,141 @@
+package numcsv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+var ErrNotReaderAt = errors.New("numcsv: shuffled batches need an io.ReaderAt")
+
+// Batches returns a function yielding successive batches of batchSize records.
+// The final batch holds the remaining records and may be smaller; after it the
+// function returns io.EOF (or nil, nil if NilAtEOF is set). Each batch is a
+// new matrix, so it may be retained.
+//
+// If r.Shuffle is set, Batches first makes a pass over the file recording where
+// each record starts, and the batches then read the records from disk in a
+// random order. ReadHeading must be called first if there are headings, and no
+// records may have been read.
+func (r *Reader) Batches(batchSize int) func() (*mat64.Dense, error) {
+	if batchSize <= 0 {
+		panic("numcsv: non-positive batch size")
+	}
+	if r.Shuffle == nil {
+		return func() (*mat64.Dense, error) {
+			rows := make([][]float64, 0, batchSize)
+			for len(rows) < batchSize {
+				record, err := r.read()
+				if err != nil {
+					return nil, err
+				}
+				if record == nil {
+					break
+				}
+				rows = append(rows, record)
+			}
+			if len(rows) == 0 {
+				return nil, r.eof()
+			}
+			return denseFromRows(rows, r.recordLen()), nil
+		}
+	}
+
+	var (
+		lines []lineSpan
+		perm  []int
+		err   error
+	)
+	ra, ok := r.reader.r.(io.ReaderAt)
+	if !ok {
+		err = ErrNotReaderAt
+	} else {
+		lines, err = r.indexLines(ra)
+		perm = r.Shuffle.Perm(len(lines))
+	}
+	var buf []byte
+	return func() (*mat64.Dense, error) {
+		if err != nil {
+			return nil, err
+		}
+		if len(perm) == 0 {
+			return nil, r.eof()
+		}
+		n := batchSize
+		if n > len(perm) {
+			n = len(perm)
+		}
+		rows := make([][]float64, 0, n)
+		for _, idx := range perm[:n] {
+			span := lines[idx]
+			if cap(buf) < span.n {
+				buf = make([]byte, span.n)
+			}
+			buf = buf[:span.n]
+			if _, err := ra.ReadAt(buf, span.off); err != nil {
+				return nil, err
+			}
+			strs, err := r.splitFields(strings.TrimSuffix(string(buf), "\r"))
+			if err != nil {
+				return nil, err
+			}
+			record, err := r.parseFields(strs)
+			if err == errSkip {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, record)
+		}
+		perm = perm[n:]
+		return denseFromRows(rows, r.recordLen()), nil
+	}
+}
+
+// lineSpan is the location of a line in a file, excluding the newline.
+type lineSpan struct {
+	off int64
+	n   int
+}
+
+// indexLines returns the locations of the records in ra, skipping comments
+// and the heading if one was read.
+func (r *Reader) indexLines(ra io.ReaderAt) ([]lineSpan, error) {
+	br := bufio.NewReaderSize(io.NewSectionReader(ra, 0, 1<<62), largeBuffer)
+	var lines []lineSpan
+	skipHeading := r.headingRead
+	var off int64
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			return nil, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return lines, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		n := len(line)
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		text := strings.TrimSuffix(string(line), "\r")
+		isComment := r.Comment != "" && strings.HasPrefix(text, r.Comment)
+		switch {
+		case skipHeading:
+			if text != "" && !isComment {
+				skipHeading = false
+			}
+		case !isComment:
+			lines = append(lines, lineSpan{off: off, n: len(line)})
+		}
+		off += int64(n)
+		if err == io.EOF {
+			return lines, nil
+		}
+	}
+}
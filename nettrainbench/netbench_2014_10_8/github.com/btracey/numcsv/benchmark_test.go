@@ -0,0 +1,117 @@
+package numcsv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func benchmarkWrite(b *testing.B, rows, cols int) {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([][]float64, rows)
+	for i := range data {
+		data[i] = make([]float64, cols)
+		for j := range data[i] {
+			data[i][j] = rnd.NormFloat64()
+		}
+	}
+	counter := &countingWriter{w: ioutil.Discard}
+	w := NewWriter(counter)
+	for _, record := range data {
+		w.Write(record)
+	}
+	w.w.Flush()
+	b.SetBytes(counter.n)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, record := range data {
+			if err := w.Write(record); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	w.w.Flush()
+}
+
+func BenchmarkWriteNarrow(b *testing.B) { benchmarkWrite(b, 10000, 4) }
+func BenchmarkWriteWide(b *testing.B)   { benchmarkWrite(b, 100, 1000) }
+
+// syntheticData returns a matrix of normal random numbers.
+func syntheticData(rows, cols int) *mat64.Dense {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = rnd.NormFloat64()
+	}
+	return mat64.NewDense(rows, cols, data)
+}
+
+// syntheticCSV returns a file with headings holding syntheticData, formatted
+// with the same precision as nettrainbench's data.txt.
+func syntheticCSV(rows, cols int) []byte {
+	headings := make([]string, cols)
+	for j := range headings {
+		headings[j] = "Col" + strconv.Itoa(j+1)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.FloatFmt = 'f'
+	w.Prec = 8
+	if err := w.WriteAll(headings, syntheticData(rows, cols)); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func benchmarkReadAll(b *testing.B, rows, cols, parallelism int) {
+	file := syntheticCSV(rows, cols)
+	b.SetBytes(int64(len(file)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(bytes.NewReader(file))
+		r.Parallelism = parallelism
+		if _, err := r.ReadHeading(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := r.ReadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadAll_small(b *testing.B) { benchmarkReadAll(b, 100, 4, 1) }
+func BenchmarkReadAll_wide(b *testing.B)  { benchmarkReadAll(b, 100, 1000, 1) }
+func BenchmarkReadAll_long(b *testing.B)  { benchmarkReadAll(b, 100000, 4, 1) }
+
+func BenchmarkReadAll_wideParallel(b *testing.B) {
+	benchmarkReadAll(b, 100, 1000, runtime.GOMAXPROCS(0))
+}
+func BenchmarkReadAll_longParallel(b *testing.B) {
+	benchmarkReadAll(b, 100000, 4, runtime.GOMAXPROCS(0))
+}
+
+func benchmarkWriteAll(b *testing.B, rows, cols int) {
+	data := syntheticData(rows, cols)
+	counter := &countingWriter{w: ioutil.Discard}
+	if err := NewWriter(counter).WriteAll(nil, data); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(counter.n)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewWriter(ioutil.Discard).WriteAll(nil, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteAll_long(b *testing.B) { benchmarkWriteAll(b, 100000, 4) }
+func BenchmarkWriteAll_wide(b *testing.B) { benchmarkWriteAll(b, 100, 1000) }
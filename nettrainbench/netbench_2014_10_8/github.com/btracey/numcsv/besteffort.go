@@ -0,0 +1,18 @@
+package numcsv
+
+import "fmt"
+
+// Diagnostic is a problem found while reading in BestEffort mode.
+type Diagnostic struct {
+	Line int64 // Line number, counting from one
+	Err  error
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("line %d: %v", d.Line, d.Err)
+}
+
+// Diagnostics returns the problems skipped by ReadAll in BestEffort mode.
+func (r *Reader) Diagnostics() []Diagnostic {
+	return r.diagnostics
+}
@@ -0,0 +1,37 @@
+package numcsv
+
+import "strings"
+
+// NumberCleaning describes decorations removed from numeric fields before
+// parsing, as found in data exported from spreadsheets. For example
+//
+//	&NumberCleaning{ThousandsSep: ".", DecimalSep: ",", Suffixes: []string{"kg"}}
+//
+// parses "1.234,5 kg" as 1234.5. The Reader delimiter must differ from the
+// separators.
+type NumberCleaning struct {
+	ThousandsSep string   // Removed from the field if non-empty
+	DecimalSep   string   // Replaced with "." if non-empty
+	Percent      bool     // Strip a trailing '%'. The value is not rescaled
+	Suffixes     []string // Unit suffixes stripped from the end of the field, tried in order
+}
+
+// clean returns the field with the decorations removed.
+func (c *NumberCleaning) clean(str string) string {
+	for _, suffix := range c.Suffixes {
+		if strings.HasSuffix(str, suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, suffix))
+			break
+		}
+	}
+	if c.Percent {
+		str = strings.TrimSpace(strings.TrimSuffix(str, "%"))
+	}
+	if c.ThousandsSep != "" {
+		str = strings.Replace(str, c.ThousandsSep, "", -1)
+	}
+	if c.DecimalSep != "" && c.DecimalSep != "." {
+		str = strings.Replace(str, c.DecimalSep, ".", -1)
+	}
+	return str
+}
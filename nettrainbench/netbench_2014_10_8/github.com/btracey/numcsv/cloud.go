@@ -0,0 +1,168 @@
+//go:build cloud
+
+package numcsv
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// The object-store backends use the services' plain HTTPS APIs. Credentials
+// are taken from the environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN and AWS_REGION (or AWS_DEFAULT_REGION) for S3, and
+// GOOGLE_OAUTH_ACCESS_TOKEN for GCS. With credentials, S3 requests are signed
+// with AWS Signature Version 4 by signS3 and GCS requests carry the token, so
+// private objects can be read. Without them the request is anonymous, which
+// works for public buckets. AWS_ENDPOINT_URL may point S3 at
+// a compatible service, addressed path-style, and is https if it has no
+// scheme.
+
+func init() {
+	ObjectStores["s3"] = openS3
+	ObjectStores["gs"] = openGCS
+}
+
+// splitBucket splits scheme://bucket/key.
+func splitBucket(name string) (bucket, key string, err error) {
+	_, rest, ok := strings.Cut(name, "://")
+	i := strings.IndexByte(rest, '/')
+	if !ok || i <= 0 || i == len(rest)-1 {
+		return "", "", fmt.Errorf("numcsv: %s: missing bucket or key", name)
+	}
+	return rest[:i], rest[i+1:], nil
+}
+
+// endpointURL parses an S3 endpoint, such as http://localhost:9000 or
+// minio.example.com, which is taken to be https if it has no scheme.
+func endpointURL(endpoint string) (*url.URL, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("numcsv: S3 endpoint %s has no host", endpoint)
+	}
+	return u, nil
+}
+
+func openS3(name string) (io.ReadCloser, error) {
+	bucket, key, err := splitBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	host := bucket + ".s3." + region + ".amazonaws.com"
+	path := "/" + awsEscape(key)
+	scheme := "https"
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		u, err := endpointURL(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		scheme, host = u.Scheme, u.Host
+		path = strings.TrimSuffix(u.EscapedPath(), "/") + "/" + awsEscape(bucket) + path
+	}
+	req, err := http.NewRequest("GET", scheme+"://"+host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		signS3(req, host, path, region, id, os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"), time.Now().UTC())
+	}
+	return getObject(req, name)
+}
+
+func openGCS(name string) (io.ReadCloser, error) {
+	bucket, key, err := splitBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", "https://storage.googleapis.com/"+bucket+"/"+awsEscape(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return getObject(req, name)
+}
+
+// getObject performs the request, returning the body for streaming.
+func getObject(req *http.Request, name string) (io.ReadCloser, error) {
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("numcsv: fetching %s: %s", name, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// signS3 adds an AWS Signature Version 4 to a GET request with no body.
+func signS3(req *http.Request, host, path, region, id, secret, token string, now time.Time) {
+	const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	date := now.Format("20060102")
+	stamp := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", emptyHash)
+	headers := "host:" + host + "\nx-amz-content-sha256:" + emptyHash + "\nx-amz-date:" + stamp + "\n"
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+		headers += "x-amz-security-token:" + token + "\n"
+		signed += ";x-amz-security-token"
+	}
+	canonical := "GET\n" + path + "\n\n" + headers + "\n" + signed + "\n" + emptyHash
+	scope := date + "/" + region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + secret)
+	for _, s := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+id+"/"+scope+", SignedHeaders="+signed+", Signature="+sig)
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// awsEscape percent-encodes everything but unreserved characters and '/'.
+func awsEscape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
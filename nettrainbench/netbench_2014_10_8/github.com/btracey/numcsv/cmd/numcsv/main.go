@@ -0,0 +1,394 @@
+// The numcsv command inspects and converts numeric csv files.
+//
+// Usage:
+//
+//	numcsv head [-n rows] [-comma c] file
+//	numcsv describe [-comma c] file
+//	numcsv convert [-comma c] [-outcomma c] [-format csv|markdown|latex] [-fmt e] [-prec 16] [-gzip] in out
+//	numcsv select [-comma c] [-cols a,b] [-rows start:end] in out
+//	numcsv compare [-comma c] [-abs tol] [-rel tol] a b
+//
+// Inputs may be local files, URLs, or "-" for standard input, and are
+// decompressed if their names end in ".gz". If -comma is not given the
+// delimiter is detected. An output of "-" is standard output, and outputs
+// ending in ".gz" are compressed. compare reports the deviation of each column
+// and exits with status 1 if any value is outside tolerance.
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/btracey/numcsv"
+	"github.com/gonum/matrix/mat64"
+)
+
+var commands = map[string]func(args []string) error{
+	"head":     head,
+	"describe": describe,
+	"convert":  convert,
+	"select":   selectCmd,
+	"compare":  compare,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: numcsv head|describe|convert|select|compare [flags] file...")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "numcsv:", err)
+		os.Exit(1)
+	}
+}
+
+// input is an opened input file.
+type input struct {
+	*numcsv.Reader
+	io.Closer
+	headings []string
+}
+
+// open opens the named input and reads its heading. If comma is empty the
+// delimiter is detected.
+func open(name, comma string) (*input, error) {
+	var rc io.ReadCloser = os.Stdin
+	if name != "-" {
+		if comma == "" {
+			probe, err := numcsv.OpenStream(name)
+			if err != nil {
+				return nil, err
+			}
+			shape, err := numcsv.Probe(probe)
+			probe.Close()
+			if err != nil {
+				return nil, err
+			}
+			comma = shape.Comma
+		}
+		var err error
+		if rc, err = numcsv.OpenStream(name); err != nil {
+			return nil, err
+		}
+	}
+	if comma == "" {
+		comma = ","
+	}
+	in := &input{Reader: numcsv.NewReader(rc), Closer: rc}
+	in.Comma = comma
+	headings, err := in.ReadHeading()
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	in.headings = headings
+	return in, nil
+}
+
+// create opens the named output, compressing it if the name ends in ".gz" or
+// compress is set.
+func create(name string, compress bool) (io.WriteCloser, error) {
+	var f io.WriteCloser = nopCloser{os.Stdout}
+	if name != "-" {
+		var err error
+		if f, err = os.Create(name); err != nil {
+			return nil, err
+		}
+	}
+	if compress || strings.HasSuffix(name, ".gz") {
+		return &gzipFile{gzip.NewWriter(f), f}, nil
+	}
+	return f, nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+type gzipFile struct {
+	*gzip.Writer
+	f io.Closer
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Writer.Close()
+	if cerr := g.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func head(args []string) error {
+	fs := flag.NewFlagSet("head", flag.ExitOnError)
+	n := fs.Int("n", 10, "number of rows")
+	comma := fs.String("comma", "", "input delimiter")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("head: need one file")
+	}
+	in, err := open(fs.Arg(0), *comma)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	w := numcsv.NewWriter(os.Stdout)
+	w.Comma = " "
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	w.Align = true
+	w.WriteHeading(in.headings)
+	for i := 0; i < *n; i++ {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		w.Write(record)
+	}
+	return w.Flush()
+}
+
+func describe(args []string) error {
+	fs := flag.NewFlagSet("describe", flag.ExitOnError)
+	comma := fs.String("comma", "", "input delimiter")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("describe: need one file")
+	}
+	in, err := open(fs.Arg(0), *comma)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	data, err := in.ReadAll()
+	if err != nil {
+		return err
+	}
+	rows, _ := data.Dims()
+	fmt.Printf("%d rows\n", rows)
+	fmt.Print(numcsv.Describe(in.headings, data))
+	return nil
+}
+
+// outputFlags are the flags setting the output format.
+type outputFlags struct {
+	comma    *string
+	format   *string
+	floatFmt *string
+	prec     *int
+	compress *bool
+}
+
+func addOutputFlags(fs *flag.FlagSet) outputFlags {
+	return outputFlags{
+		comma:    fs.String("outcomma", ",", "output delimiter"),
+		format:   fs.String("format", "csv", "output format: csv, markdown or latex"),
+		floatFmt: fs.String("fmt", "e", "float format, as in strconv.FormatFloat"),
+		prec:     fs.Int("prec", 16, "float precision, as in strconv.FormatFloat"),
+		compress: fs.Bool("gzip", false, "compress the output"),
+	}
+}
+
+// writer creates the output with the flags' settings.
+func (o outputFlags) writer(name string) (*numcsv.Writer, io.Closer, error) {
+	f, err := create(name, *o.compress)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := numcsv.NewWriter(f)
+	w.Comma = *o.comma
+	w.Prec = *o.prec
+	if len(*o.floatFmt) != 1 {
+		f.Close()
+		return nil, nil, fmt.Errorf("bad float format %q", *o.floatFmt)
+	}
+	w.FloatFmt = (*o.floatFmt)[0]
+	switch *o.format {
+	case "csv":
+	case "markdown":
+		w.Format = numcsv.Markdown
+	case "latex":
+		w.Format = numcsv.LaTeX
+	default:
+		f.Close()
+		return nil, nil, fmt.Errorf("unknown format %q", *o.format)
+	}
+	return w, f, nil
+}
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	comma := fs.String("comma", "", "input delimiter")
+	out := addOutputFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("convert: need input and output files")
+	}
+	in, err := open(fs.Arg(0), *comma)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	w, f, err := out.writer(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := w.WriteHeading(in.headings); err != nil {
+		f.Close()
+		return err
+	}
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = w.Write(record)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func selectCmd(args []string) error {
+	fs := flag.NewFlagSet("select", flag.ExitOnError)
+	comma := fs.String("comma", "", "input delimiter")
+	cols := fs.String("cols", "", "comma separated columns to keep, in order (default all)")
+	rows := fs.String("rows", "", "range of rows start:end to keep, counting from zero (default all)")
+	out := addOutputFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("select: need input and output files")
+	}
+	start, end, err := parseRange(*rows)
+	if err != nil {
+		return err
+	}
+	in, err := open(fs.Arg(0), *comma)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	headings := in.headings
+	if *cols != "" {
+		headings = strings.Split(*cols, ",")
+		if err := in.ReorderTo(headings); err != nil {
+			return err
+		}
+	}
+	w, f, err := out.writer(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := w.WriteHeading(headings); err != nil {
+		f.Close()
+		return err
+	}
+	for i := 0; end < 0 || i < end; i++ {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && i >= start {
+			err = w.Write(record)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseRange parses "start:end", where either may be omitted. end is -1 if
+// unbounded.
+func parseRange(s string) (start, end int, err error) {
+	end = -1
+	if s == "" {
+		return 0, -1, nil
+	}
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("bad row range %q", s)
+	}
+	if i > 0 {
+		if start, err = strconv.Atoi(s[:i]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if i < len(s)-1 {
+		if end, err = strconv.Atoi(s[i+1:]); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, end, nil
+}
+
+// errDiffer is returned by compare when the files differ.
+var errDiffer = errors.New("files differ")
+
+func compare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	comma := fs.String("comma", "", "input delimiter")
+	absTol := fs.Float64("abs", 0, "absolute tolerance")
+	relTol := fs.Float64("rel", 1e-12, "relative tolerance")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("compare: need two files")
+	}
+	var headings []string
+	var data [2]*mat64.Dense
+	for i := range data {
+		in, err := open(fs.Arg(i), *comma)
+		if err != nil {
+			return err
+		}
+		data[i], err = in.ReadAll()
+		in.Close()
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			headings = in.headings
+		} else if !reflect.DeepEqual(headings, in.headings) {
+			fmt.Printf("headings differ: %v != %v\n", headings, in.headings)
+		}
+	}
+	c, err := numcsv.Compare(data[0], data[1], *absTol, *relTol)
+	if err != nil {
+		return err
+	}
+	fmt.Print(c.Report(headings))
+	if !c.Equal() {
+		return errDiffer
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package numcsv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"text/tabwriter"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+var ErrShapeMismatch = errors.New("numcsv: matrices have different shapes")
+
+// Cell is a location in a matrix with the values compared there.
+type Cell struct {
+	Row, Col int
+	A, B     float64
+}
+
+// Comparison is the result of Compare. The deviations are per column.
+type Comparison struct {
+	MaxAbs  []float64 // Largest absolute difference
+	MeanAbs []float64 // Mean absolute difference
+	MaxRel  []float64 // Largest difference relative to the larger magnitude
+	Differ  int       // Number of cells outside tolerance
+	First   *Cell     // First cell outside tolerance in row-major order, or nil
+}
+
+// Equal reports whether every cell was within tolerance.
+func (c *Comparison) Equal() bool { return c.Differ == 0 }
+
+// Compare compares a and b cell by cell. Values are within tolerance if they
+// differ by at most absTol, or by at most relTol times the larger of their
+// magnitudes. NaNs compare equal to each other.
+func Compare(a, b *mat64.Dense, absTol, relTol float64) (*Comparison, error) {
+	ra, ca := a.Dims()
+	rb, cb := b.Dims()
+	if ra != rb || ca != cb {
+		return nil, ErrShapeMismatch
+	}
+	c := &Comparison{
+		MaxAbs:  make([]float64, ca),
+		MeanAbs: make([]float64, ca),
+		MaxRel:  make([]float64, ca),
+	}
+	for i := 0; i < ra; i++ {
+		for j := 0; j < ca; j++ {
+			x, y := a.At(i, j), b.At(i, j)
+			var diff, rel float64
+			switch {
+			case x == y || (math.IsNaN(x) && math.IsNaN(y)):
+			default:
+				diff = math.Abs(x - y)
+				if math.IsNaN(diff) {
+					diff = math.Inf(1)
+				}
+				rel = diff / math.Max(math.Abs(x), math.Abs(y))
+			}
+			c.MaxAbs[j] = math.Max(c.MaxAbs[j], diff)
+			c.MaxRel[j] = math.Max(c.MaxRel[j], rel)
+			c.MeanAbs[j] += diff
+			if diff > absTol && !(rel <= relTol) {
+				c.Differ++
+				if c.First == nil {
+					c.First = &Cell{Row: i, Col: j, A: x, B: y}
+				}
+			}
+		}
+	}
+	if ra > 0 {
+		for j := range c.MeanAbs {
+			c.MeanAbs[j] /= float64(ra)
+		}
+	}
+	return c, nil
+}
+
+// Report formats the comparison as a table, naming the columns by headings if
+// non-nil.
+func (c *Comparison) Report(headings []string) string {
+	name := func(j int) string {
+		if headings != nil {
+			return headings[j]
+		}
+		return fmt.Sprint(j)
+	}
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "column\tmax abs\tmean abs\tmax rel\t")
+	for j := range c.MaxAbs {
+		fmt.Fprintf(w, "%s\t%.3g\t%.3g\t%.3g\t\n", name(j), c.MaxAbs[j], c.MeanAbs[j], c.MaxRel[j])
+	}
+	w.Flush()
+	if c.First == nil {
+		buf.WriteString("all values within tolerance\n")
+	} else {
+		fmt.Fprintf(buf, "%d values differ, first at row %d column %s: %v != %v\n",
+			c.Differ, c.First.Row, name(c.First.Col), c.First.A, c.First.B)
+	}
+	return buf.String()
+}
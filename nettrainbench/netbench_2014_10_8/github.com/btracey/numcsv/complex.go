@@ -0,0 +1,165 @@
+package numcsv
+
+import (
+	"errors"
+	"math/cmplx"
+	"strconv"
+)
+
+var ErrOddPairs = errors.New("numcsv: odd number of fields for real/imaginary column pairs")
+
+// ComplexMatrix is a row-major complex matrix, laid out like mat64.RawMatrix so
+// the data can be passed to the complex BLAS routines.
+type ComplexMatrix struct {
+	Rows, Cols int
+	Stride     int
+	Data       []complex128
+}
+
+// NewComplexMatrix creates a new ComplexMatrix with the given size. If data is
+// nil a new slice is allocated, otherwise len(data) must equal r*c.
+func NewComplexMatrix(r, c int, data []complex128) *ComplexMatrix {
+	if data != nil && r*c != len(data) {
+		panic("numcsv: complex matrix shape mismatch")
+	}
+	if data == nil {
+		data = make([]complex128, r*c)
+	}
+	return &ComplexMatrix{Rows: r, Cols: c, Stride: c, Data: data}
+}
+
+// Dims returns the number of rows and columns.
+func (m *ComplexMatrix) Dims() (r, c int) { return m.Rows, m.Cols }
+
+// At returns the element at row r and column c.
+func (m *ComplexMatrix) At(r, c int) complex128 { return m.Data[r*m.Stride+c] }
+
+// Set sets the element at row r and column c.
+func (m *ComplexMatrix) Set(r, c int, v complex128) { m.Data[r*m.Stride+c] = v }
+
+// RowView returns a slice of the data in row r.
+func (m *ComplexMatrix) RowView(r int) []complex128 {
+	return m.Data[r*m.Stride : r*m.Stride+m.Cols]
+}
+
+// ReadComplex reads a single record of complex values. Fields are of the form
+// "1.2+3.4i" (optionally parenthesized), or if ComplexPairs is set, adjacent
+// fields hold the real and imaginary parts. At the end of the input it returns
+// io.EOF, or nil, nil if NilAtEOF is set.
+func (r *Reader) ReadComplex() ([]complex128, error) {
+	data, err := r.readComplex()
+	if data == nil && err == nil {
+		return nil, r.eof()
+	}
+	return data, err
+}
+
+func (r *Reader) readComplex() ([]complex128, error) {
+	strs, err := r.readFields()
+	if strs == nil || err != nil {
+		return nil, err
+	}
+	if r.ComplexPairs {
+		if len(strs)%2 != 0 {
+			return nil, ErrOddPairs
+		}
+		data := make([]complex128, len(strs)/2)
+		for i := range data {
+			re, err := strconv.ParseFloat(r.field(strs[2*i]), 64)
+			if err != nil {
+				return nil, err
+			}
+			im, err := strconv.ParseFloat(r.field(strs[2*i+1]), 64)
+			if err != nil {
+				return nil, err
+			}
+			data[i] = complex(re, im)
+		}
+		return data, nil
+	}
+	data := make([]complex128, len(strs))
+	for i, str := range strs {
+		data[i], err = strconv.ParseComplex(r.field(str), 128)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// ReadAllComplex reads all of the records as complex values. ReadHeading must
+// be called first if there are headings.
+func (r *Reader) ReadAllComplex() (*ComplexMatrix, error) {
+	var data []complex128
+	var rows, cols int
+	for {
+		record, err := r.readComplex()
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			break
+		}
+		cols = len(record)
+		data = append(data, record...)
+		rows++
+	}
+	if data == nil {
+		data = []complex128{}
+	}
+	return NewComplexMatrix(rows, cols, data), nil
+}
+
+// WriteComplex writes a single record of complex values as "re+imi" fields, or
+// as adjacent real and imaginary fields if ComplexPairs is set, with the
+// Writer's FloatFmt and Prec. A value with a NaN part is written as the NaN
+// token if it is set, or with ComplexPairs each NaN part is. In a Markdown or
+// LaTeX table a value is one column, or two with ComplexPairs.
+func (w *Writer) WriteComplex(record []complex128) error {
+	cols := len(record)
+	if w.ComplexPairs {
+		cols *= 2
+	}
+	dst := w.appendRowStart(w.scratch[:0], cols)
+	sep := w.separator()
+	for n, field := range record {
+		if n > 0 {
+			dst = append(dst, sep...)
+		}
+		switch {
+		case w.ComplexPairs:
+			dst = w.appendFloat(dst, real(field), 64)
+			dst = append(dst, sep...)
+			dst = w.appendFloat(dst, imag(field), 64)
+		case w.NaN != "" && cmplx.IsNaN(field):
+			dst = append(dst, w.NaN...)
+		default:
+			dst = strconv.AppendFloat(dst, real(field), w.FloatFmt, w.Prec, 64)
+			im := strconv.FormatFloat(imag(field), w.FloatFmt, w.Prec, 64)
+			if im[0] != '-' && im[0] != '+' {
+				dst = append(dst, '+')
+			}
+			dst = append(dst, im...)
+			dst = append(dst, 'i')
+		}
+	}
+	w.scratch = w.appendRowEnd(dst)
+	_, err := w.w.Write(w.scratch)
+	return err
+}
+
+// WriteAllComplex writes the headings (if non-nil) and all of the rows of data,
+// and flushes the Writer.
+func (w *Writer) WriteAllComplex(headings []string, data *ComplexMatrix) error {
+	if headings != nil {
+		if err := w.WriteHeading(headings); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < data.Rows; i++ {
+		if err := w.WriteComplex(data.RowView(i)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,36 @@
+package numcsv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// contextInterval is the number of rows between checks of the context.
+const contextInterval = 256
+
+// PartialError is returned when a read or write is stopped by its context
+// before completing. Rows is the number of complete records processed and Bytes
+// the number of bytes consumed from the input (reads only).
+type PartialError struct {
+	Rows  int64
+	Bytes int64
+	Err   error
+}
+
+func (p *PartialError) Error() string {
+	return fmt.Sprintf("numcsv: stopped after %d rows: %v", p.Rows, p.Err)
+}
+
+// ReadAllContext is like ReadAll, but aborts once ctx is done. In that case the
+// records read so far are returned along with a *PartialError.
+func (r *Reader) ReadAllContext(ctx context.Context) (*mat64.Dense, error) {
+	return r.readAll(ctx)
+}
+
+// WriteAllContext is like WriteAll, but aborts once ctx is done, returning a
+// *PartialError. The rows already written are flushed.
+func (w *Writer) WriteAllContext(ctx context.Context, headings []string, data *mat64.Dense) error {
+	return w.writeAll(ctx, headings, data)
+}
@@ -0,0 +1,73 @@
+package numcsv
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Correlation returns the matrix of Pearson correlation coefficients between
+// the columns of data. Entries involving a constant column are NaN.
+func Correlation(data *mat64.Dense) *mat64.Dense {
+	rows, cols := data.Dims()
+	centered := mat64.NewDense(rows, cols, nil)
+	norms := make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		var mean float64
+		for i := 0; i < rows; i++ {
+			mean += data.At(i, j)
+		}
+		mean /= float64(rows)
+		for i := 0; i < rows; i++ {
+			v := data.At(i, j) - mean
+			centered.Set(i, j, v)
+			norms[j] += v * v
+		}
+		norms[j] = math.Sqrt(norms[j])
+	}
+	corr := mat64.NewDense(cols, cols, nil)
+	for j := 0; j < cols; j++ {
+		for k := j; k < cols; k++ {
+			var dot float64
+			for i := 0; i < rows; i++ {
+				dot += centered.At(i, j) * centered.At(i, k)
+			}
+			c := math.NaN()
+			if norms[j] != 0 && norms[k] != 0 {
+				c = dot / (norms[j] * norms[k])
+			}
+			if j == k && !math.IsNaN(c) {
+				c = 1
+			}
+			corr.Set(j, k, c)
+			corr.Set(k, j, c)
+		}
+	}
+	return corr
+}
+
+// Correlation returns the correlation matrix of the dataset's columns, with
+// the same headings, ready to be written as a CSV with WriteTo.
+func (d *Dataset) Correlation() *Dataset {
+	return &Dataset{Headings: d.Headings, Data: Correlation(d.Data)}
+}
+
+// TargetCorrelation returns a single-record dataset holding the correlation
+// of each of the other columns with the named target column.
+func (d *Dataset) TargetCorrelation(target string) (*Dataset, error) {
+	t, err := columnIndex(d.Headings, target)
+	if err != nil {
+		return nil, err
+	}
+	corr := Correlation(d.Data)
+	_, cols := d.Dims()
+	out := &Dataset{Data: mat64.NewDense(1, cols-1, nil)}
+	for j := 0; j < cols; j++ {
+		if j == t {
+			continue
+		}
+		out.Data.Set(0, len(out.Headings), corr.At(j, t))
+		out.Headings = append(out.Headings, d.Headings[j])
+	}
+	return out, nil
+}
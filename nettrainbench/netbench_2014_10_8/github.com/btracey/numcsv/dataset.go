@@ -0,0 +1,154 @@
+package numcsv
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Dataset is a numeric table along with its column metadata. Headings and
+// Units may be nil, but if set must have one entry per column of Data. A nil
+// Data has no rows, with the columns given by the metadata.
+type Dataset struct {
+	Headings []string
+	Units    []string
+	Data     *mat64.Dense
+	Roles    map[string]Role // Column roles by heading, used by Split
+}
+
+var ErrDatasetShape = errors.New("numcsv: dataset metadata does not match the number of columns")
+
+// datasetGob is the wire form of a Dataset. Dense is stored as a contiguous
+// row-major slice so decoding is a single allocation.
+type datasetGob struct {
+	Headings   []string
+	Units      []string
+	Roles      map[string]Role
+	Rows, Cols int
+	Data       []float64
+}
+
+// ReadDataset reads the heading (unless NoHeading is set) and all of the
+// records into a Dataset.
+func (r *Reader) ReadDataset() (*Dataset, error) {
+	d := &Dataset{}
+	if !r.NoHeading {
+		headings, err := r.ReadHeading()
+		if err != nil {
+			return nil, err
+		}
+		d.Headings = headings
+	}
+	data, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	d.Data = data
+	d.Roles = r.Roles
+	if r.RemoveDuplicates {
+		d.Data, r.droppedRows = RemoveDuplicateRows(d.Data)
+	}
+	if r.DropConstant {
+		d.Headings, d.Data, r.droppedCols = DropConstantColumns(d.Headings, d.Data)
+	}
+	if r.Transforms != nil {
+		if err := applyTransforms(r.Transforms, d.Headings, d.Data); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Dropped returns the indices of the rows and columns removed by the
+// RemoveDuplicates and DropConstant options during ReadDataset.
+func (r *Reader) Dropped() (rows, cols []int) {
+	return r.droppedRows, r.droppedCols
+}
+
+// Dims returns the size of the data.
+func (d *Dataset) Dims() (r, c int) {
+	if d.Data == nil {
+		return 0, 0
+	}
+	return d.Data.Dims()
+}
+
+// check verifies that the metadata matches the data.
+func (d *Dataset) check() error {
+	if d.Data == nil {
+		return nil
+	}
+	_, c := d.Dims()
+	if d.Headings != nil && len(d.Headings) != c {
+		return ErrDatasetShape
+	}
+	if d.Units != nil && len(d.Units) != c {
+		return ErrDatasetShape
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface.
+func (d *Dataset) GobEncode() ([]byte, error) {
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	rows, cols := d.Dims()
+	g := datasetGob{
+		Headings: d.Headings,
+		Units:    d.Units,
+		Roles:    d.Roles,
+		Rows:     rows,
+		Cols:     cols,
+	}
+	if d.Data != nil {
+		raw := d.Data.RawMatrix()
+		if raw.Stride == cols {
+			g.Data = raw.Data[:rows*cols]
+		} else {
+			g.Data = make([]float64, 0, rows*cols)
+			for i := 0; i < rows; i++ {
+				g.Data = append(g.Data, d.Data.RowView(i)...)
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	err := gob.NewEncoder(buf).Encode(g)
+	return buf.Bytes(), err
+}
+
+// GobDecode implements the gob.GobDecoder interface.
+func (d *Dataset) GobDecode(b []byte) error {
+	var g datasetGob
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&g); err != nil {
+		return err
+	}
+	if len(g.Data) != g.Rows*g.Cols {
+		return ErrDatasetShape
+	}
+	d.Headings = g.Headings
+	d.Units = g.Units
+	d.Roles = g.Roles
+	d.Data = nil
+	if g.Rows*g.Cols > 0 {
+		d.Data = mat64.NewDense(g.Rows, g.Cols, g.Data)
+	}
+	return d.check()
+}
+
+// Encode writes the dataset to w in gob format.
+func (d *Dataset) Encode(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(d)
+}
+
+// DecodeDataset reads a dataset written by Encode.
+func DecodeDataset(r io.Reader) (*Dataset, error) {
+	d := &Dataset{}
+	if err := gob.NewDecoder(r).Decode(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,136 @@
+// package dataset is a registry of named numeric datasets. Each entry records
+// where the data lives, its checksum and its expected shape, so that programs
+// can refer to a dataset by name and have it fetched and validated on demand.
+package dataset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/btracey/numcsv"
+)
+
+// Info describes a registered dataset.
+type Info struct {
+	Name   string // Name under which the dataset is registered
+	Source string // File name or URL, as accepted by numcsv.Open
+	SHA256 string // If non-empty, the hex sha256 of the (uncompressed) file contents
+	Comma  string // Field delimiter. If "", ","
+	Rows   int    // If non-zero, the expected number of records
+	Cols   int    // If non-zero, the expected number of columns
+
+	// Column roles, by heading, set on the loaded Dataset for use with
+	// Split. Columns not listed have no role.
+	Inputs  []string
+	Targets []string
+	Weights []string
+}
+
+var (
+	ErrExists   = errors.New("dataset: already registered")
+	ErrUnknown  = errors.New("dataset: not registered")
+	ErrChecksum = errors.New("dataset: checksum mismatch")
+	ErrShape    = errors.New("dataset: unexpected shape")
+)
+
+var (
+	mu       sync.Mutex
+	registry = make(map[string]Info)
+)
+
+// Register adds a dataset to the registry.
+func Register(info Info) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[info.Name]; ok {
+		return fmt.Errorf("dataset %q: %v", info.Name, ErrExists)
+	}
+	registry[info.Name] = info
+	return nil
+}
+
+// Lookup returns the registered dataset with the given name.
+func Lookup(name string) (Info, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	info, ok := registry[name]
+	if !ok {
+		return Info{}, fmt.Errorf("dataset %q: %v", name, ErrUnknown)
+	}
+	return info, nil
+}
+
+// Names returns the names of the registered datasets in sorted order.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load fetches and reads the named dataset, checking its checksum and shape.
+func Load(name string) (*numcsv.Dataset, error) {
+	info, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return info.Load()
+}
+
+// Load fetches and reads the dataset, checking its checksum and shape.
+func (info Info) Load() (*numcsv.Dataset, error) {
+	rc, err := numcsv.OpenStream(info.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	r := numcsv.NewReader(io.TeeReader(rc, h))
+	if info.Comma != "" {
+		r.Comma = info.Comma
+	}
+	d, err := r.ReadDataset()
+	if err != nil {
+		return nil, fmt.Errorf("dataset %q: %v", info.Name, err)
+	}
+	io.Copy(h, rc) // hash anything after the last record
+	if info.SHA256 != "" {
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != info.SHA256 {
+			return nil, fmt.Errorf("dataset %q: sha256 %s, want %s: %v", info.Name, sum, info.SHA256, ErrChecksum)
+		}
+	}
+	rows, cols := d.Dims()
+	if (info.Rows != 0 && rows != info.Rows) || (info.Cols != 0 && cols != info.Cols) {
+		return nil, fmt.Errorf("dataset %q: %d×%d, want %d×%d: %v", info.Name, rows, cols, info.Rows, info.Cols, ErrShape)
+	}
+	for _, names := range [][]string{info.Inputs, info.Targets, info.Weights} {
+		for _, name := range names {
+			if !contains(d.Headings, name) {
+				return nil, fmt.Errorf("dataset %q: no column %q", info.Name, name)
+			}
+		}
+	}
+	d.SetRoles(numcsv.Input, info.Inputs...)
+	d.SetRoles(numcsv.Target, info.Targets...)
+	d.SetRoles(numcsv.Weight, info.Weights...)
+	return d, nil
+}
+
+func contains(s []string, v string) bool {
+	for _, str := range s {
+		if str == v {
+			return true
+		}
+	}
+	return false
+}
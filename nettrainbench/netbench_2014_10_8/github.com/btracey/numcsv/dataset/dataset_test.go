@@ -0,0 +1,64 @@
+package dataset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	const text = "x y\n1 2\n3 4\n5 6\n"
+	f, err := ioutil.TempFile("", "dataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(text)
+	f.Close()
+	sum := sha256.Sum256([]byte(text))
+
+	info := Info{
+		Name:    "test",
+		Source:  f.Name(),
+		SHA256:  hex.EncodeToString(sum[:]),
+		Comma:   " ",
+		Rows:    3,
+		Cols:    2,
+		Inputs:  []string{"x"},
+		Targets: []string{"y"},
+	}
+	if err := Register(info); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(info); err == nil {
+		t.Error("no error registering a duplicate")
+	}
+	d, err := Load("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows, cols := d.Dims(); rows != 3 || cols != 2 || d.Data.At(2, 1) != 6 {
+		t.Errorf("got %v", d.Data)
+	}
+
+	bad := info
+	bad.SHA256 = "00"
+	if _, err := bad.Load(); err == nil {
+		t.Error("no error for a bad checksum")
+	}
+	bad = info
+	bad.Rows = 4
+	if _, err := bad.Load(); err == nil {
+		t.Error("no error for a bad shape")
+	}
+	bad = info
+	bad.Targets = []string{"z"}
+	if _, err := bad.Load(); err == nil {
+		t.Error("no error for a missing role column")
+	}
+	if _, err := Load("missing"); err == nil {
+		t.Error("no error for an unregistered name")
+	}
+}
@@ -0,0 +1,48 @@
+package numcsv
+
+import "math"
+
+// Aggregation is how Decimate combines a group of records.
+type Aggregation int
+
+const (
+	AggFirst Aggregation = iota // Keep the first record of the group
+	AggMean
+	AggMin
+	AggMax
+)
+
+// readDecimated reads a group of Decimate records and combines them.
+func (r *Reader) readDecimated() ([]float64, error) {
+	var out []float64
+	n := 0
+	for ; n < r.Decimate; n++ {
+		record, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			break
+		}
+		if out == nil {
+			out = record
+			continue
+		}
+		for j, v := range record {
+			switch r.Aggregate {
+			case AggMean:
+				out[j] += v
+			case AggMin:
+				out[j] = math.Min(out[j], v)
+			case AggMax:
+				out[j] = math.Max(out[j], v)
+			}
+		}
+	}
+	if r.Aggregate == AggMean {
+		for j := range out {
+			out[j] /= float64(n)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package numcsv
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// RemoveDuplicateRows returns a copy of data with the rows that exactly repeat
+// an earlier row removed, along with the indices of the dropped rows.
+func RemoveDuplicateRows(data *mat64.Dense) (*mat64.Dense, []int) {
+	rows, cols := data.Dims()
+	seen := make(map[string]struct{}, rows)
+	key := make([]byte, 8*cols)
+	var keep, dropped []int
+	for i := 0; i < rows; i++ {
+		for j, v := range data.RowView(i) {
+			binary.LittleEndian.PutUint64(key[8*j:], math.Float64bits(v))
+		}
+		if _, ok := seen[string(key)]; ok {
+			dropped = append(dropped, i)
+			continue
+		}
+		seen[string(key)] = struct{}{}
+		keep = append(keep, i)
+	}
+	m := mat64.NewDense(len(keep), cols, nil)
+	for i, idx := range keep {
+		copy(m.RowView(i), data.RowView(idx))
+	}
+	return m, dropped
+}
+
+// DropConstantColumns returns a copy of data without the columns in which every
+// value is the same (zero variance columns make normal scaling divide by zero).
+// If headings is non-nil, the headings of the kept columns are also returned.
+// The indices of the dropped columns are returned last.
+func DropConstantColumns(headings []string, data *mat64.Dense) ([]string, *mat64.Dense, []int) {
+	rows, cols := data.Dims()
+	var keep, dropped []int
+	for j := 0; j < cols; j++ {
+		if isConstantColumn(data, j) {
+			dropped = append(dropped, j)
+			continue
+		}
+		keep = append(keep, j)
+	}
+	var kept []string
+	if headings != nil {
+		kept = make([]string, len(keep))
+		for i, j := range keep {
+			kept[i] = headings[j]
+		}
+	}
+	m := mat64.NewDense(rows, len(keep), nil)
+	for i := 0; i < rows; i++ {
+		for k, j := range keep {
+			m.Set(i, k, data.At(i, j))
+		}
+	}
+	return kept, m, dropped
+}
+
+func isConstantColumn(data *mat64.Dense, j int) bool {
+	rows, _ := data.Dims()
+	if rows == 0 {
+		return false
+	}
+	first := data.At(0, j)
+	for i := 1; i < rows; i++ {
+		v := data.At(i, j)
+		if v != first && !(math.IsNaN(v) && math.IsNaN(first)) {
+			return false
+		}
+	}
+	return true
+}
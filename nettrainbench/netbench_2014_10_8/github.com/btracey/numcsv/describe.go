@@ -0,0 +1,98 @@
+package numcsv
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ColumnSummary holds summary statistics of a column. NaN values are counted
+// and otherwise ignored.
+type ColumnSummary struct {
+	Name  string
+	Count int // Number of non-NaN values
+	NaN   int
+	Mean  float64
+	Std   float64 // Sample standard deviation
+	Min   float64
+	Q1    float64 // 25th percentile
+	Med   float64
+	Q3    float64 // 75th percentile
+	Max   float64
+}
+
+// Summary is the description of a dataset, one entry per column.
+type Summary []ColumnSummary
+
+// Describe computes summary statistics of each column of data. Percentiles
+// interpolate linearly between the closest ranks. headings may be nil, in
+// which case the columns are named by index.
+func Describe(headings []string, data *mat64.Dense) Summary {
+	rows, cols := data.Dims()
+	s := make(Summary, cols)
+	col := make([]float64, 0, rows)
+	for j := range s {
+		c := &s[j]
+		if headings != nil {
+			c.Name = headings[j]
+		} else {
+			c.Name = fmt.Sprint(j)
+		}
+		col = col[:0]
+		for i := 0; i < rows; i++ {
+			v := data.At(i, j)
+			if math.IsNaN(v) {
+				c.NaN++
+				continue
+			}
+			col = append(col, v)
+		}
+		c.Count = len(col)
+		if c.Count == 0 {
+			c.Mean, c.Std, c.Min, c.Q1, c.Med, c.Q3, c.Max = nan(), nan(), nan(), nan(), nan(), nan(), nan()
+			continue
+		}
+		for _, v := range col {
+			c.Mean += v
+		}
+		c.Mean /= float64(c.Count)
+		for _, v := range col {
+			c.Std += (v - c.Mean) * (v - c.Mean)
+		}
+		c.Std = math.Sqrt(c.Std / float64(c.Count-1))
+		sort.Float64s(col)
+		c.Min, c.Max = col[0], col[c.Count-1]
+		c.Q1, c.Med, c.Q3 = percentile(col, 0.25), percentile(col, 0.5), percentile(col, 0.75)
+	}
+	return s
+}
+
+func nan() float64 { return math.NaN() }
+
+// percentile returns the p quantile of sorted data.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
+
+// String formats the summary as an aligned table with a row per column.
+func (s Summary) String() string {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "column\tcount\tnan\tmean\tstd\tmin\t25%\t50%\t75%\tmax\t")
+	for _, c := range s {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t\n",
+			c.Name, c.Count, c.NaN, c.Mean, c.Std, c.Min, c.Q1, c.Med, c.Q3, c.Max)
+	}
+	w.Flush()
+	return buf.String()
+}
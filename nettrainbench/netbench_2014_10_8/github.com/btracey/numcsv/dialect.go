@@ -0,0 +1,98 @@
+package numcsv
+
+import "io"
+
+// Dialect is the set of formatting options shared by Reader and Writer. Data
+// written by a Writer with a given Dialect is read back with the same values
+// (bit-for-bit, including NaN and ±Inf) by a Reader with that Dialect, provided
+// FloatFmt is 'e' with Prec of at least 16, or Prec is -1.
+type Dialect struct {
+	Comma        string // Field delimiter
+	QuoteHeading bool   // Write quotes around headings. Quotes are always stripped on read
+	Comment      string // Prefix of comment lines, skipped on read
+	UseCRLF      bool   // Write lines ending in "\r\n". Both endings are accepted on read
+	FloatFmt     byte   // Format passed to strconv.FormatFloat
+	Prec         int    // Precision passed to strconv.FormatFloat
+	NaN          string // Token used for NaN. If "", "NaN" is used
+}
+
+// DefaultDialect returns the Dialect used by NewReader and NewWriter.
+func DefaultDialect() Dialect {
+	return Dialect{
+		Comma:    ",",
+		FloatFmt: 'e',
+		Prec:     16,
+	}
+}
+
+// NewDialectReader returns a new Reader using the given dialect.
+func NewDialectReader(r io.Reader, d Dialect) *Reader {
+	reader := NewReader(r)
+	reader.SetDialect(d)
+	return reader
+}
+
+// NewDialectWriter returns a new Writer using the given dialect.
+func NewDialectWriter(w io.Writer, d Dialect) *Writer {
+	writer := NewWriter(w)
+	writer.SetDialect(d)
+	return writer
+}
+
+// SetDialect sets the formatting options of the Reader. The write-only options
+// of the Dialect have no effect.
+func (r *Reader) SetDialect(d Dialect) {
+	r.Comma = d.Comma
+	r.HeadingComma = ""
+	r.Comment = d.Comment
+	r.NaN = d.NaN
+}
+
+// Dialect returns the formatting options of the Reader.
+func (r *Reader) Dialect() Dialect {
+	d := DefaultDialect()
+	d.Comma = r.Comma
+	d.Comment = r.Comment
+	d.NaN = r.NaN
+	return d
+}
+
+// SetDialect sets the formatting options of the Writer.
+func (w *Writer) SetDialect(d Dialect) {
+	w.Comma = d.Comma
+	w.QuoteHeading = d.QuoteHeading
+	w.Comment = d.Comment
+	w.UseCRLF = d.UseCRLF
+	w.FloatFmt = d.FloatFmt
+	w.Prec = d.Prec
+	w.NaN = d.NaN
+}
+
+// Dialect returns the formatting options of the Writer.
+func (w *Writer) Dialect() Dialect {
+	return Dialect{
+		Comma:        w.Comma,
+		QuoteHeading: w.QuoteHeading,
+		Comment:      w.Comment,
+		UseCRLF:      w.UseCRLF,
+		FloatFmt:     w.FloatFmt,
+		Prec:         w.Prec,
+		NaN:          w.NaN,
+	}
+}
+
+// WriteComment writes a comment line starting with the Writer's Comment prefix.
+func (w *Writer) WriteComment(text string) error {
+	if _, err := w.w.WriteString(w.Comment + text); err != nil {
+		return err
+	}
+	return w.endLine()
+}
+
+func (w *Writer) endLine() error {
+	if w.UseCRLF {
+		_, err := w.w.WriteString("\r\n")
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
@@ -0,0 +1,201 @@
+package numcsv
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of the input.
+type Encoding int
+
+const (
+	// DetectEncoding uses a byte order mark if present. Otherwise input with
+	// NUL bytes alternating with text is read as UTF-16, and input that is not
+	// valid UTF-8 as Windows-1252.
+	DetectEncoding Encoding = iota
+	UTF8
+	UTF16LE
+	UTF16BE
+	Windows1252
+)
+
+// sniffLen is the amount of input examined by DetectEncoding.
+const sniffLen = 4096
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// detectEncoding returns the encoding of the sample and the length of its
+// byte order mark. If enc is not DetectEncoding only a matching mark is
+// recognized.
+func detectEncoding(sample []byte, enc Encoding) (Encoding, int) {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8) && (enc == DetectEncoding || enc == UTF8):
+		return UTF8, len(bomUTF8)
+	case bytes.HasPrefix(sample, bomUTF16LE) && (enc == DetectEncoding || enc == UTF16LE):
+		return UTF16LE, len(bomUTF16LE)
+	case bytes.HasPrefix(sample, bomUTF16BE) && (enc == DetectEncoding || enc == UTF16BE):
+		return UTF16BE, len(bomUTF16BE)
+	}
+	if enc != DetectEncoding {
+		return enc, 0
+	}
+	var even, odd int
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	half := len(sample) / 4 // a quarter of the bytes, half of one parity
+	switch {
+	case len(sample) >= 2 && odd > half && even == 0:
+		return UTF16LE, 0
+	case len(sample) >= 2 && even > half && odd == 0:
+		return UTF16BE, 0
+	}
+	// Ignore a rune cut off at the end of the sample.
+	valid := sample
+	for i := 1; i < utf8.UTFMax && i <= len(valid); i++ {
+		if utf8.RuneStart(valid[len(valid)-i]) {
+			if !utf8.FullRune(valid[len(valid)-i:]) {
+				valid = valid[:len(valid)-i]
+			}
+			break
+		}
+	}
+	if !utf8.Valid(valid) {
+		return Windows1252, 0
+	}
+	return UTF8, 0
+}
+
+// cp1252 maps the bytes 0x80 to 0x9f of Windows-1252 to runes. The other
+// bytes are the same as in Latin-1. Undefined bytes map to U+FFFD.
+var cp1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// transcode appends the UTF-8 encoding of src to dst, returning the number of
+// bytes of src consumed. Trailing bytes that may be part of an incomplete
+// character are left unless final is set.
+func transcode(dst, src []byte, enc Encoding, final bool) ([]byte, int) {
+	switch enc {
+	case Windows1252:
+		for _, b := range src {
+			switch {
+			case b < 0x80:
+				dst = append(dst, b)
+			case b < 0xa0:
+				dst = utf8.AppendRune(dst, cp1252[b-0x80])
+			default:
+				dst = utf8.AppendRune(dst, rune(b))
+			}
+		}
+		return dst, len(src)
+	case UTF16LE, UTF16BE:
+		i := 0
+		for ; i+1 < len(src); i += 2 {
+			u := uint16(src[i]) | uint16(src[i+1])<<8
+			if enc == UTF16BE {
+				u = u>>8 | u<<8
+			}
+			r := rune(u)
+			if utf16.IsSurrogate(r) {
+				if i+3 >= len(src) {
+					if !final {
+						break
+					}
+					r = utf8.RuneError
+				} else {
+					u2 := uint16(src[i+2]) | uint16(src[i+3])<<8
+					if enc == UTF16BE {
+						u2 = u2>>8 | u2<<8
+					}
+					if r = utf16.DecodeRune(r, rune(u2)); r != utf8.RuneError {
+						i += 2
+					}
+				}
+			}
+			dst = utf8.AppendRune(dst, r)
+		}
+		if final && i < len(src) {
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i = len(src)
+		}
+		return dst, i
+	}
+	return append(dst, src...), len(src)
+}
+
+// decodingReader converts the input to UTF-8. The encoding is decided on the
+// first Read, so that Reader.Encoding may be set after NewReader.
+type decodingReader struct {
+	r       io.Reader
+	rd      *Reader
+	enc     Encoding
+	started bool
+	in      []byte // undecoded input
+	out     []byte // decoded output not yet returned
+	eof     bool
+	err     error
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		d.in = make([]byte, 0, sniffLen)
+		for len(d.in) < sniffLen && d.err == nil {
+			var n int
+			n, d.err = d.r.Read(d.in[len(d.in):cap(d.in)])
+			d.in = d.in[:len(d.in)+n]
+		}
+		var bom int
+		d.enc, bom = detectEncoding(d.in, d.rd.Encoding)
+		d.in = d.in[bom:]
+		if d.enc == UTF8 {
+			d.out, d.in = d.in, nil
+		}
+	}
+	for len(d.out) == 0 {
+		if d.err != nil {
+			if len(d.in) > 0 {
+				d.out, _ = transcode(d.out[:0], d.in, d.enc, true)
+				d.in = d.in[:0]
+				continue
+			}
+			return 0, d.err
+		}
+		if d.enc == UTF8 {
+			return d.r.Read(p)
+		}
+		if len(d.in) == 0 {
+			buf := make([]byte, sniffLen)
+			var n int
+			n, d.err = d.r.Read(buf)
+			d.in = buf[:n]
+		}
+		var n int
+		d.out, n = transcode(d.out[:0], d.in, d.enc, false)
+		d.in = d.in[n:]
+		if n == 0 && d.err == nil {
+			// An incomplete character; read more.
+			buf := make([]byte, sniffLen)
+			var m int
+			m, d.err = d.r.Read(buf)
+			d.in = append(d.in, buf[:m]...)
+		}
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
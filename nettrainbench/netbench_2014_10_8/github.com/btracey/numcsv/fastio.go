@@ -0,0 +1,76 @@
+package numcsv
+
+import (
+	"bufio"
+	"io"
+)
+
+// largeBuffer is the buffer size used by the bulk WriterTo and ReaderFrom paths.
+const largeBuffer = 1 << 20
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo implements io.WriterTo, writing the dataset as CSV in the default
+// dialect. The headings are written if non-nil.
+func (d *Dataset) WriteTo(w io.Writer) (int64, error) {
+	if err := d.check(); err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: w}
+	nw := NewWriter(cw)
+	nw.w = bufio.NewWriterSize(cw, largeBuffer)
+	if d.Headings != nil {
+		if err := nw.WriteHeading(d.Headings); err != nil {
+			return cw.n, err
+		}
+	}
+	rows, _ := d.Dims()
+	for i := 0; i < rows; i++ {
+		if err := nw.Write(d.Data.RowView(i)); err != nil {
+			return cw.n, err
+		}
+	}
+	err := nw.w.Flush()
+	return cw.n, err
+}
+
+// ReadFrom implements io.ReaderFrom. It reads a CSV with headings in the
+// default dialect from r and writes it with the Writer's options, returning the
+// number of bytes read. It is an efficient way to change the delimiter or float
+// format of a file.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	rd := NewReader(r)
+	rd.scanner.(*bufio.Scanner).Buffer(make([]byte, 0, largeBuffer), bufio.MaxScanTokenSize*16)
+	headings, err := rd.ReadHeading()
+	if err != nil {
+		return rd.reader.n, err
+	}
+	if len(headings) != 0 {
+		if err := w.WriteHeading(headings); err != nil {
+			return rd.reader.n, err
+		}
+	}
+	for {
+		record, err := rd.read()
+		if err != nil {
+			return rd.reader.n, err
+		}
+		if record == nil {
+			break
+		}
+		if err := w.Write(record); err != nil {
+			return rd.reader.n, err
+		}
+	}
+	return rd.reader.n, w.Flush()
+}
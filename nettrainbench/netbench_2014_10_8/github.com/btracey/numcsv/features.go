@@ -0,0 +1,110 @@
+package numcsv
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Feature configures the derived columns AddFeatures appends for a column.
+// For each lag k a column "name_lagk" holds the value k rows earlier, and for
+// each window w the columns "name_meanw" and "name_stdw" hold the mean and
+// sample standard deviation of the w rows ending at the current one. Values
+// without enough history are NaN.
+type Feature struct {
+	Column  string
+	Lags    []int
+	Windows []int
+}
+
+// AddFeatures returns a copy of the dataset with the derived columns of the
+// features appended, in order. The rows are taken to be in time order.
+func (d *Dataset) AddFeatures(features ...Feature) (*Dataset, error) {
+	rows, cols := d.Dims()
+	var derived [][]float64
+	headings := append([]string(nil), d.Headings...)
+	var units []string
+	if d.Units != nil {
+		units = append(units, d.Units...)
+	}
+	col := make([]float64, rows)
+	for _, f := range features {
+		j, err := columnIndex(d.Headings, f.Column)
+		if err != nil {
+			return nil, err
+		}
+		for i := range col {
+			col[i] = d.Data.At(i, j)
+		}
+		add := func(suffix string, v []float64) {
+			derived = append(derived, v)
+			headings = append(headings, f.Column+suffix)
+			if units != nil {
+				units = append(units, d.Units[j])
+			}
+		}
+		for _, k := range f.Lags {
+			add("_lag"+strconv.Itoa(k), lag(col, k))
+		}
+		for _, w := range f.Windows {
+			mean, std := rolling(col, w)
+			add("_mean"+strconv.Itoa(w), mean)
+			add("_std"+strconv.Itoa(w), std)
+		}
+	}
+
+	out := &Dataset{Headings: headings, Units: units, Roles: d.Roles}
+	if rows == 0 {
+		return out, nil
+	}
+	out.Data = mat64.NewDense(rows, cols+len(derived), nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			out.Data.Set(i, j, d.Data.At(i, j))
+		}
+		for k, v := range derived {
+			out.Data.Set(i, cols+k, v[i])
+		}
+	}
+	return out, nil
+}
+
+// lag returns col shifted down by k rows.
+func lag(col []float64, k int) []float64 {
+	v := make([]float64, len(col))
+	for i := range v {
+		if i-k < 0 || i-k >= len(col) {
+			v[i] = math.NaN()
+			continue
+		}
+		v[i] = col[i-k]
+	}
+	return v
+}
+
+// rolling returns the mean and sample standard deviation over trailing
+// windows of w rows.
+func rolling(col []float64, w int) (mean, std []float64) {
+	mean = make([]float64, len(col))
+	std = make([]float64, len(col))
+	for i := range col {
+		if w <= 0 || i+1 < w {
+			mean[i], std[i] = math.NaN(), math.NaN()
+			continue
+		}
+		window := col[i+1-w : i+1]
+		var m float64
+		for _, v := range window {
+			m += v
+		}
+		m /= float64(w)
+		var ss float64
+		for _, v := range window {
+			ss += (v - m) * (v - m)
+		}
+		mean[i] = m
+		std[i] = math.Sqrt(ss / float64(w-1))
+	}
+	return mean, std
+}
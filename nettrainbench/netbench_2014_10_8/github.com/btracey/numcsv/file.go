@@ -0,0 +1,127 @@
+package numcsv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// FileReader is a Reader of a named file or stream. It must be closed when
+// done.
+type FileReader struct {
+	*Reader
+	c     io.Closer
+	unmap func() error
+}
+
+// NewFileReader opens the named file for reading. If useMmap is true and the
+// platform supports it, the file is memory mapped and lines are scanned
+// directly from the mapping, avoiding the copy through bufio and the per-line
+// string allocation; the records returned are unaffected. On platforms without
+// mmap support the file is read normally.
+func NewFileReader(filename string, useMmap bool) (*FileReader, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	fr := &FileReader{Reader: NewReader(f), c: f}
+	if !useMmap {
+		return fr, nil
+	}
+	data, unmap, err := mmapFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if data == nil {
+		return fr, nil // mmap not supported or empty file
+	}
+	fr.unmap = unmap
+	fr.scanner = &mmapScanner{data: data, counter: fr.reader, rd: fr.Reader}
+	return fr, nil
+}
+
+// Close releases the mapping, if any, and closes the file or stream. Rows returned
+// before Close remain valid.
+func (f *FileReader) Close() error {
+	var err error
+	if f.unmap != nil {
+		err = f.unmap()
+		f.unmap = nil
+	}
+	if cerr := f.c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// mmapScanner splits memory-mapped data into lines like bufio.ScanLines. Text
+// returns strings aliasing the mapping, so they must not be retained. Input
+// that is not UTF-8 is transcoded into memory on the first Scan.
+type mmapScanner struct {
+	data    []byte
+	pos     int
+	line    string
+	err     error
+	counter *countingReader
+	rd      *Reader
+	started bool
+	scale   float64 // ratio of the mapped size to the size of data
+}
+
+func (m *mmapScanner) Scan() bool {
+	if !m.started {
+		m.started = true
+		m.scale = 1
+		sample := m.data
+		if len(sample) > sniffLen {
+			sample = sample[:sniffLen]
+		}
+		enc, bom := detectEncoding(sample, m.rd.Encoding)
+		m.pos = bom
+		if enc != UTF8 {
+			size := len(m.data)
+			m.data, _ = transcode(nil, m.data[bom:], enc, true)
+			m.pos = 0
+			if len(m.data) > 0 {
+				m.scale = float64(size) / float64(len(m.data))
+			}
+		}
+	}
+	if m.counter.ctx != nil {
+		if err := m.counter.ctx.Err(); err != nil {
+			m.err = err
+			return false
+		}
+	}
+	if m.pos >= len(m.data) {
+		return false
+	}
+	rest := m.data[m.pos:]
+	end := bytes.IndexByte(rest, '\n')
+	var line []byte
+	if end < 0 {
+		line = rest
+		m.pos = len(m.data)
+	} else {
+		line = rest[:end]
+		m.pos += end + 1
+	}
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	m.counter.n = int64(float64(m.pos) * m.scale)
+	if len(line) == 0 {
+		m.line = ""
+	} else {
+		m.line = unsafe.String(&line[0], len(line))
+	}
+	return true
+}
+
+func (m *mmapScanner) Text() string { return m.line }
+func (m *mmapScanner) Err() error   { return m.err }
+
+var _ lineScanner = (*bufio.Scanner)(nil)
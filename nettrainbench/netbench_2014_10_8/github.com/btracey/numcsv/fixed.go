@@ -0,0 +1,59 @@
+package numcsv
+
+import (
+	"strings"
+	"unicode"
+)
+
+// InferWidths returns the column widths of a fixed-width file from its heading
+// line, assuming the values are right-aligned under their headings as written
+// by Fortran formatted output. Each column ends at the last character of its
+// heading, and the final column extends to the end of the line (width 0).
+func InferWidths(heading string) []int {
+	var widths []int
+	start := 0
+	inToken := false
+	for i, r := range heading {
+		space := unicode.IsSpace(r)
+		if inToken && space {
+			widths = append(widths, i-start)
+			start = i
+		}
+		inToken = !space
+	}
+	if inToken || len(widths) == 0 {
+		widths = append(widths, 0)
+	} else {
+		widths[len(widths)-1] = 0
+	}
+	return widths
+}
+
+// splitFixed cuts the line into fields of the given widths. A width of zero
+// (allowed only last) takes the remainder of the line. Fields past the end of
+// a short line are empty.
+func splitFixed(line string, widths []int) []string {
+	strs := make([]string, len(widths))
+	start := 0
+	for i, w := range widths {
+		if start >= len(line) {
+			break
+		}
+		end := start + w
+		if w == 0 || end > len(line) {
+			end = len(line)
+		}
+		strs[i] = line[start:end]
+		start = end
+	}
+	return strs
+}
+
+// fortranExponent converts a Fortran double precision exponent ("1.5D+03")
+// into one understood by strconv.
+func fortranExponent(str string) string {
+	if strings.IndexAny(str, "dD") < 0 {
+		return str
+	}
+	return strings.NewReplacer("D", "e", "d", "e").Replace(str)
+}
@@ -0,0 +1,149 @@
+package numcsv
+
+import (
+	"math"
+	"strconv"
+)
+
+// Matrix32 is a row-major single-precision matrix. It mirrors the layout of
+// mat64.RawMatrix so the data can be handed directly to float32 BLAS routines.
+type Matrix32 struct {
+	Rows, Cols int
+	Stride     int
+	Data       []float32
+}
+
+// NewMatrix32 creates a new Matrix32 with the given size. If data is nil a new
+// slice is allocated, otherwise len(data) must equal r*c.
+func NewMatrix32(r, c int, data []float32) *Matrix32 {
+	if data != nil && r*c != len(data) {
+		panic("numcsv: matrix32 shape mismatch")
+	}
+	if data == nil {
+		data = make([]float32, r*c)
+	}
+	return &Matrix32{Rows: r, Cols: c, Stride: c, Data: data}
+}
+
+// Dims returns the number of rows and columns.
+func (m *Matrix32) Dims() (r, c int) { return m.Rows, m.Cols }
+
+// At returns the element at row r and column c.
+func (m *Matrix32) At(r, c int) float32 { return m.Data[r*m.Stride+c] }
+
+// Set sets the element at row r and column c.
+func (m *Matrix32) Set(r, c int, v float32) { m.Data[r*m.Stride+c] = v }
+
+// RowView returns a slice of the data in row r.
+func (m *Matrix32) RowView(r int) []float32 {
+	return m.Data[r*m.Stride : r*m.Stride+m.Cols]
+}
+
+// Read32 reads a single record as single precision values. The fields are
+// parsed directly as float32 to avoid double rounding. The value policies
+// apply as in Read, with infinities clamped to ±math.MaxFloat32. At the end of the input
+// it returns io.EOF, or nil, nil if NilAtEOF is set.
+func (r *Reader) Read32() ([]float32, error) {
+	data, err := r.read32()
+	if data == nil && err == nil {
+		return nil, r.eof()
+	}
+	return data, err
+}
+
+// read32 reads the next record that is not skipped by a value policy.
+func (r *Reader) read32() ([]float32, error) {
+	for {
+		strs, err := r.readFields()
+		if strs == nil || err != nil {
+			return nil, err
+		}
+		data, err := r.parseFields32(strs)
+		if err != errSkip {
+			return data, err
+		}
+	}
+}
+
+// parseFields32 parses the fields of a record as single precision values and
+// applies the value policies, clamping to ±math.MaxFloat32.
+func (r *Reader) parseFields32(strs []string) ([]float32, error) {
+	data := make([]float32, len(strs))
+	for i, str := range strs {
+		v, err := strconv.ParseFloat(r.field(str), 32)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = float32(v)
+	}
+	if r.NonFinite == Keep && r.OutOfRange == Keep {
+		return data, nil
+	}
+	values := make([]float64, len(data))
+	for i, v := range data {
+		values[i] = float64(v)
+	}
+	if err := r.checkValues(values, strs, math.MaxFloat32); err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		data[i] = float32(v)
+	}
+	return data, nil
+}
+
+// ReadAll32 reads all of the records as single precision values. ReadHeading
+// must be called first if there are headings.
+func (r *Reader) ReadAll32() (*Matrix32, error) {
+	var data []float32
+	var rows int
+	for {
+		record, err := r.read32()
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			break
+		}
+		data = append(data, record...)
+		rows++
+	}
+	if data == nil {
+		data = []float32{}
+	}
+	return NewMatrix32(rows, r.recordLen(), data), nil
+}
+
+// Write32 writes a single record of single precision values with the Writer's
+// FloatFmt, Prec and NaN, as Write. The default precision is enough for the
+// values to be recovered exactly by ParseFloat(s, 32), as is a Prec of -1,
+// which writes the fewest digits that do.
+func (w *Writer) Write32(record []float32) error {
+	dst := w.appendRowStart(w.scratch[:0], len(record))
+	sep := w.separator()
+	for n, field := range record {
+		if n > 0 {
+			dst = append(dst, sep...)
+		}
+		dst = w.appendFloat(dst, float64(field), 32)
+	}
+	w.scratch = w.appendRowEnd(dst)
+	_, err := w.w.Write(w.scratch)
+	return err
+}
+
+// WriteAll32 writes the headings (if non-nil) and all of the rows of data,
+// and flushes the Writer.
+func (w *Writer) WriteAll32(headings []string, data *Matrix32) error {
+	if headings != nil {
+		if err := w.WriteHeading(headings); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < data.Rows; i++ {
+		if err := w.Write32(data.RowView(i)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
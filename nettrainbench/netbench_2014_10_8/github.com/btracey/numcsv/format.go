@@ -0,0 +1,113 @@
+package numcsv
+
+import "strings"
+
+// Format is the syntax of the Writer's output.
+type Format int
+
+const (
+	CSV      Format = iota
+	Markdown        // A GitHub-flavored Markdown table
+	LaTeX           // A tabular environment with right-aligned columns
+)
+
+// separator returns the text between fields.
+func (w *Writer) separator() string {
+	switch w.Format {
+	case Markdown:
+		return " | "
+	case LaTeX:
+		return " & "
+	}
+	return w.Comma
+}
+
+// appendRowStart appends what precedes the fields of a row, beginning a LaTeX
+// table if needed.
+func (w *Writer) appendRowStart(dst []byte, cols int) []byte {
+	switch w.Format {
+	case Markdown:
+		return append(dst, "| "...)
+	case LaTeX:
+		if !w.tableOpen {
+			w.tableOpen = true
+			dst = append(dst, `\begin{tabular}{`...)
+			for i := 0; i < cols; i++ {
+				dst = append(dst, 'r')
+			}
+			dst = append(dst, '}')
+			dst = w.appendNewline(dst)
+			dst = append(dst, `\hline`...)
+			dst = w.appendNewline(dst)
+		}
+	}
+	return dst
+}
+
+// appendRowEnd appends what follows the fields of a row, including the line
+// ending.
+func (w *Writer) appendRowEnd(dst []byte) []byte {
+	switch w.Format {
+	case Markdown:
+		dst = append(dst, " |"...)
+	case LaTeX:
+		dst = append(dst, ` \\`...)
+	}
+	return w.appendNewline(dst)
+}
+
+func (w *Writer) appendNewline(dst []byte) []byte {
+	if w.UseCRLF {
+		return append(dst, '\r', '\n')
+	}
+	return append(dst, '\n')
+}
+
+var (
+	markdownEscaper = strings.NewReplacer("|", `\|`)
+	latexEscaper    = strings.NewReplacer(
+		`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`,
+		"_", `\_`, "{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+	)
+)
+
+// escape quotes the characters of a string field special to the table format.
+func (w *Writer) escape(s string) string {
+	switch w.Format {
+	case Markdown:
+		return markdownEscaper.Replace(s)
+	case LaTeX:
+		return latexEscaper.Replace(s)
+	}
+	return s
+}
+
+// writeTableHeading writes the heading row of a Markdown or LaTeX table.
+func (w *Writer) writeTableHeading(heading []string) error {
+	dst := w.appendRowStart(w.scratch[:0], len(heading))
+	for n, field := range heading {
+		if n > 0 {
+			dst = append(dst, w.separator()...)
+		}
+		dst = append(dst, w.escape(field)...)
+	}
+	dst = w.appendRowEnd(dst)
+	switch w.Format {
+	case Markdown:
+		dst = append(dst, "| "...)
+		for n := range heading {
+			if n > 0 {
+				dst = append(dst, " | "...)
+			}
+			dst = append(dst, "---:"...)
+		}
+		dst = append(dst, " |"...)
+		dst = w.appendNewline(dst)
+	case LaTeX:
+		dst = append(dst, `\hline`...)
+		dst = w.appendNewline(dst)
+	}
+	w.scratch = dst
+	_, err := w.w.Write(dst)
+	return err
+}
@@ -0,0 +1,49 @@
+package numcsv
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzReader checks that the Reader never panics and that in BestEffort mode
+// ReadAll always succeeds, whatever the input.
+func FuzzReader(f *testing.F) {
+	for _, seed := range []string{
+		"a,b\n1,2\n3,4\n",
+		"\"x\"  \"y\"\n 1.5  2e3\n",
+		"# c\nx y\n1 2 3\n\n4,5\n",
+		"  A    B\n1.0D+00 2\n",
+		"\xff\xfea\x00,\x00b\x00\n\x001\x00,\x002\x00",
+		"1;2;3\nNaN;Inf;-0\n",
+	} {
+		f.Add([]byte(seed), uint8(0))
+	}
+	f.Fuzz(func(t *testing.T, data []byte, mode uint8) {
+		r := NewReader(bytes.NewReader(data))
+		r.BestEffort = true
+		r.Comment = "#"
+		switch mode % 4 {
+		case 1:
+			r.Comma = " "
+		case 2:
+			r.InferWidths = true
+		case 3:
+			r.NoHeading = true
+			r.NaN = "NA"
+			r.Cleaning = &NumberCleaning{ThousandsSep: ",", DecimalSep: ".", Percent: true}
+		}
+		if !r.NoHeading {
+			if _, err := r.ReadHeading(); err != nil {
+				return
+			}
+		}
+		m, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("BestEffort ReadAll failed: %v", err)
+		}
+		rows, cols := m.Dims()
+		if rows > 0 && cols != r.FieldsPerRecord {
+			t.Fatalf("got %d columns, want %d", cols, r.FieldsPerRecord)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package numcsv
+
+import (
+	"errors"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ErrNoHDF5 is returned by ReadHDF5 when numcsv was built without the hdf5
+// build tag.
+var ErrNoHDF5 = errors.New("numcsv: HDF5 support not built (use -tags hdf5)")
+
+// Hyperslab selects a contiguous block of rows and columns out of a 2-D dataset.
+// The ranges are half-open, [RowStart, RowEnd). An end of zero selects through
+// the last row (or column) of the dataset.
+type Hyperslab struct {
+	RowStart, RowEnd int
+	ColStart, ColEnd int
+}
+
+// ReadHDF5 reads the two-dimensional double dataset at path (for example
+// "/flow/training") in the named HDF5 file. If slab is non-nil only the selected
+// block is read from disk. HDF5 support requires the C library and building
+// with -tags hdf5; otherwise ErrNoHDF5 is returned.
+func ReadHDF5(filename, path string, slab *Hyperslab) (*mat64.Dense, error) {
+	return readHDF5(filename, path, slab)
+}
+
+// bounds resolves the selection against a dataset of the given size.
+func (h *Hyperslab) bounds(rows, cols int) (r0, r1, c0, c1 int, err error) {
+	if h == nil {
+		return 0, rows, 0, cols, nil
+	}
+	r0, r1, c0, c1 = h.RowStart, h.RowEnd, h.ColStart, h.ColEnd
+	if r1 == 0 {
+		r1 = rows
+	}
+	if c1 == 0 {
+		c1 = cols
+	}
+	if r0 < 0 || c0 < 0 || r1 > rows || c1 > cols || r0 >= r1 || c0 >= c1 {
+		return 0, 0, 0, 0, errors.New("numcsv: hyperslab out of range")
+	}
+	return r0, r1, c0, c1, nil
+}
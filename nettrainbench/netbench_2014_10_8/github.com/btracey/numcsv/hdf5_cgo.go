@@ -0,0 +1,58 @@
+//go:build hdf5
+
+package numcsv
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/sbinet/go-hdf5"
+)
+
+func readHDF5(filename, path string, slab *Hyperslab) (*mat64.Dense, error) {
+	f, err := hdf5.OpenFile(filename, hdf5.F_ACC_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dset, err := f.OpenDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	defer dset.Close()
+
+	filespace := dset.Space()
+	defer filespace.Close()
+	dims, _, err := filespace.SimpleExtentDims()
+	if err != nil {
+		return nil, err
+	}
+	if len(dims) != 2 {
+		return nil, fmt.Errorf("numcsv: HDF5 dataset %s has %d dimensions, need 2", path, len(dims))
+	}
+
+	r0, r1, c0, c1, err := slab.bounds(int(dims[0]), int(dims[1]))
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := r1-r0, c1-c0
+
+	offset := []uint{uint(r0), uint(c0)}
+	count := []uint{uint(rows), uint(cols)}
+	if err := filespace.SelectHyperslab(offset, nil, count, nil); err != nil {
+		return nil, err
+	}
+	memspace, err := hdf5.CreateSimpleDataspace(count, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer memspace.Close()
+
+	// HDF5 datasets are row-major, the same as mat64.Dense.
+	data := make([]float64, rows*cols)
+	if err := dset.ReadSubset(&data, memspace, filespace); err != nil {
+		return nil, err
+	}
+	return mat64.NewDense(rows, cols, data), nil
+}
@@ -0,0 +1,9 @@
+//go:build !hdf5
+
+package numcsv
+
+import "github.com/gonum/matrix/mat64"
+
+func readHDF5(filename, path string, slab *Hyperslab) (*mat64.Dense, error) {
+	return nil, ErrNoHDF5
+}
@@ -0,0 +1,80 @@
+package numcsv
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HeadingNormalization describes how headings are rewritten so they can be used
+// reliably as map keys or struct tags.
+type HeadingNormalization struct {
+	Lower bool // Convert to lower case
+	// If non-empty, each run of characters other than letters, digits and
+	// underscore is replaced with Replacement, and leading and trailing
+	// replacements are trimmed.
+	Replacement string
+	// Rename repeated headings by appending Replacement (or "_" if empty) and
+	// a count, so "x", "x" becomes "x", "x_2".
+	Dedupe bool
+}
+
+// NormalizeHeadings returns the normalized headings along with a map from each
+// normalized heading to its original.
+func NormalizeHeadings(headings []string, n HeadingNormalization) ([]string, map[string]string) {
+	normalized := make([]string, len(headings))
+	original := make(map[string]string, len(headings))
+	sep := n.Replacement
+	if sep == "" {
+		sep = "_"
+	}
+	for i, h := range headings {
+		str := strings.TrimSpace(h)
+		if n.Lower {
+			str = strings.ToLower(str)
+		}
+		if n.Replacement != "" {
+			str = replaceIllegal(str, n.Replacement)
+		}
+		if str == "" {
+			str = "col" + sep + strconv.Itoa(i)
+		}
+		if _, taken := original[str]; taken && n.Dedupe {
+			base := str
+			for c := 2; taken; c++ {
+				str = base + sep + strconv.Itoa(c)
+				_, taken = original[str]
+			}
+		}
+		normalized[i] = str
+		if _, ok := original[str]; !ok {
+			original[str] = h
+		}
+	}
+	return normalized, original
+}
+
+// replaceIllegal replaces runs of characters that are not allowed in an
+// identifier.
+func replaceIllegal(str, replacement string) string {
+	var b []rune
+	inRun := false
+	for _, r := range str {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b = append(b, r)
+			inRun = false
+			continue
+		}
+		if !inRun {
+			b = append(b, []rune(replacement)...)
+			inRun = true
+		}
+	}
+	return strings.Trim(string(b), replacement)
+}
+
+// OriginalHeadings returns the mapping from the normalized headings to the
+// headings as they appear in the file, if r.Normalize was set during ReadHeading.
+func (r *Reader) OriginalHeadings() map[string]string {
+	return r.originalHeadings
+}
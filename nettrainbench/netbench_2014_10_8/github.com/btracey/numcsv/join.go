@@ -0,0 +1,122 @@
+package numcsv
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// JoinKind selects which rows Join keeps.
+type JoinKind int
+
+const (
+	InnerJoin JoinKind = iota // Keys present in both datasets
+	LeftJoin                  // All rows of a
+	OuterJoin                 // All rows of both
+)
+
+// Join combines the columns of a and b, matching rows by the value of the key
+// column. The result has the key column followed by the other columns of a and
+// then of b; repeated headings have a count appended as by NormalizeHeadings
+// with Dedupe. Rows are in the order of a, followed for OuterJoin by the
+// unmatched rows of b. A key repeated in both gives every pairing. Values
+// missing in an unmatched row are NaN. If no rows are kept, Data is nil.
+func Join(a, b *Dataset, key string, how JoinKind) (*Dataset, error) {
+	ka, err := columnIndex(a.Headings, key)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := columnIndex(b.Headings, key)
+	if err != nil {
+		return nil, err
+	}
+	ra, ca := a.Dims()
+	rb, cb := b.Dims()
+
+	bRows := make(map[float64][]int)
+	for i := 0; i < rb; i++ {
+		k := b.Data.At(i, kb)
+		bRows[k] = append(bRows[k], i)
+	}
+
+	// Pairs of matched row indices; -1 marks a missing side.
+	var pairs [][2]int
+	matched := make([]bool, rb)
+	for i := 0; i < ra; i++ {
+		js := bRows[a.Data.At(i, ka)]
+		for _, j := range js {
+			pairs = append(pairs, [2]int{i, j})
+			matched[j] = true
+		}
+		if len(js) == 0 && how != InnerJoin {
+			pairs = append(pairs, [2]int{i, -1})
+		}
+	}
+	if how == OuterJoin {
+		for j, ok := range matched {
+			if !ok {
+				pairs = append(pairs, [2]int{-1, j})
+			}
+		}
+	}
+
+	headings := []string{key}
+	var units []string
+	if a.Units != nil && b.Units != nil {
+		units = []string{a.Units[ka]}
+	}
+	for j := 0; j < ca; j++ {
+		if j != ka {
+			headings = append(headings, a.Headings[j])
+			if units != nil {
+				units = append(units, a.Units[j])
+			}
+		}
+	}
+	for j := 0; j < cb; j++ {
+		if j != kb {
+			headings = append(headings, b.Headings[j])
+			if units != nil {
+				units = append(units, b.Units[j])
+			}
+		}
+	}
+	headings, _ = NormalizeHeadings(headings, HeadingNormalization{Dedupe: true})
+
+	out := &Dataset{Headings: headings, Units: units}
+	if len(pairs) == 0 {
+		return out, nil
+	}
+	out.Data = mat64.NewDense(len(pairs), len(headings), nil)
+	for r, p := range pairs {
+		if p[0] >= 0 {
+			out.Data.Set(r, 0, a.Data.At(p[0], ka))
+		} else {
+			out.Data.Set(r, 0, b.Data.At(p[1], kb))
+		}
+		c := 1
+		for j := 0; j < ca; j++ {
+			if j == ka {
+				continue
+			}
+			v := math.NaN()
+			if p[0] >= 0 {
+				v = a.Data.At(p[0], j)
+			}
+			out.Data.Set(r, c, v)
+			c++
+		}
+		for j := 0; j < cb; j++ {
+			if j == kb {
+				continue
+			}
+			v := math.NaN()
+			if p[1] >= 0 {
+				v = b.Data.At(p[1], j)
+			}
+			out.Data.Set(r, c, v)
+			c++
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,329 @@
+package numcsv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// MAT-file (level 5) data types
+const (
+	miINT8       = 1
+	miUINT8      = 2
+	miINT16      = 3
+	miUINT16     = 4
+	miINT32      = 5
+	miUINT32     = 6
+	miSINGLE     = 7
+	miDOUBLE     = 9
+	miINT64      = 12
+	miUINT64     = 13
+	miMATRIX     = 14
+	miCOMPRESSED = 15
+)
+
+// MAT-file array classes
+const (
+	mxDoubleClass = 6
+	mxUint64Class = 15
+)
+
+const (
+	matHeaderLen = 128
+	matTextLen   = 116
+	matComplex   = 0x0800 // complex bit in the array flags
+)
+
+var (
+	ErrMATHeader      = errors.New("numcsv: not a level 5 MAT-file")
+	ErrMATFormat      = errors.New("numcsv: malformed MAT-file element")
+	ErrMATUnsupported = errors.New("numcsv: unsupported MAT-file array")
+)
+
+// ReadMAT reads all of the numeric two-dimensional real arrays stored in a
+// level 5 MAT-file (the default format of save in MATLAB since v5, including
+// the compressed v7 variant). Arrays of other classes (cell, struct, char, sparse)
+// are skipped. The returned map is keyed by variable name.
+func ReadMAT(r io.Reader) (map[string]*mat64.Dense, error) {
+	header := make([]byte, matHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrMATHeader
+	}
+	var order binary.ByteOrder
+	switch string(header[126:128]) {
+	case "IM":
+		order = binary.LittleEndian
+	case "MI":
+		order = binary.BigEndian
+	default:
+		return nil, ErrMATHeader
+	}
+
+	vars := make(map[string]*mat64.Dense)
+	for {
+		typ, data, err := readMATElement(r, order)
+		if err == io.EOF {
+			return vars, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if typ == miCOMPRESSED {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			typ, data, err = readMATElement(zr, order)
+			zr.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if typ != miMATRIX {
+			continue
+		}
+		name, m, err := decodeMATMatrix(data, order)
+		if err == errMATSkip {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = m
+	}
+}
+
+var errMATSkip = errors.New("skip")
+
+// readMATElement reads the next data element, returning its type and contents
+// with any padding removed.
+func readMATElement(r io.Reader, order binary.ByteOrder) (typ uint32, data []byte, err error) {
+	var tag [8]byte
+	n, err := io.ReadFull(r, tag[:])
+	if n == 0 && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+		return 0, nil, io.EOF
+	}
+	if err != nil {
+		return 0, nil, ErrMATFormat
+	}
+	first := order.Uint32(tag[0:4])
+	if first>>16 != 0 {
+		// Small data element format, the data is packed into the tag.
+		nBytes := first >> 16
+		if nBytes > 4 {
+			return 0, nil, ErrMATFormat
+		}
+		return first & 0xffff, tag[4 : 4+nBytes], nil
+	}
+	typ = first
+	nBytes := order.Uint32(tag[4:8])
+	size := int64(nBytes)
+	if typ != miCOMPRESSED {
+		size = padMAT(size)
+	}
+	data = make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if typ == miMATRIX && err == io.ErrUnexpectedEOF {
+			// Some writers omit the padding of the final element.
+			return typ, data[:nBytes], nil
+		}
+		return 0, nil, ErrMATFormat
+	}
+	return typ, data[:nBytes], nil
+}
+
+// padMAT rounds n up to the 8-byte boundary of MAT-file elements.
+func padMAT(n int64) int64 {
+	return (n + 7) &^ 7
+}
+
+// decodeMATMatrix decodes the subelements of an miMATRIX element.
+func decodeMATMatrix(data []byte, order binary.ByteOrder) (string, *mat64.Dense, error) {
+	r := bytes.NewReader(data)
+
+	typ, flags, err := readMATElement(r, order)
+	if err != nil || typ != miUINT32 || len(flags) < 8 {
+		return "", nil, ErrMATFormat
+	}
+	flagWord := order.Uint32(flags[0:4])
+	class := flagWord & 0xff
+	if class < mxDoubleClass || class > mxUint64Class {
+		return "", nil, errMATSkip
+	}
+
+	typ, dimData, err := readMATElement(r, order)
+	if err != nil || typ != miINT32 {
+		return "", nil, ErrMATFormat
+	}
+	dims, err := decodeMATNumeric(typ, dimData, order)
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, nameData, err := readMATElement(r, order)
+	if err != nil {
+		return "", nil, ErrMATFormat
+	}
+	name := string(nameData)
+
+	if len(dims) != 2 {
+		return "", nil, fmt.Errorf("MAT variable %q has %d dimensions: %w", name, len(dims), ErrMATUnsupported)
+	}
+	if flagWord&matComplex != 0 {
+		return "", nil, fmt.Errorf("MAT variable %q is complex: %w", name, ErrMATUnsupported)
+	}
+	rows, cols := int(dims[0]), int(dims[1])
+
+	typ, realData, err := readMATElement(r, order)
+	if err != nil {
+		return "", nil, ErrMATFormat
+	}
+	colMajor, err := decodeMATNumeric(typ, realData, order)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(colMajor) != rows*cols {
+		return "", nil, ErrMATFormat
+	}
+	if rows == 0 || cols == 0 {
+		return name, &mat64.Dense{}, nil
+	}
+	m := mat64.NewDense(rows, cols, nil)
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			m.Set(i, j, colMajor[j*rows+i])
+		}
+	}
+	return name, m, nil
+}
+
+// decodeMATNumeric converts the contents of a numeric element to float64.
+func decodeMATNumeric(typ uint32, data []byte, order binary.ByteOrder) ([]float64, error) {
+	var size int
+	switch typ {
+	case miINT8, miUINT8:
+		size = 1
+	case miINT16, miUINT16:
+		size = 2
+	case miINT32, miUINT32, miSINGLE:
+		size = 4
+	case miDOUBLE, miINT64, miUINT64:
+		size = 8
+	default:
+		return nil, ErrMATFormat
+	}
+	if len(data)%size != 0 {
+		return nil, ErrMATFormat
+	}
+	v := make([]float64, len(data)/size)
+	for i := range v {
+		b := data[i*size : (i+1)*size]
+		switch typ {
+		case miINT8:
+			v[i] = float64(int8(b[0]))
+		case miUINT8:
+			v[i] = float64(b[0])
+		case miINT16:
+			v[i] = float64(int16(order.Uint16(b)))
+		case miUINT16:
+			v[i] = float64(order.Uint16(b))
+		case miINT32:
+			v[i] = float64(int32(order.Uint32(b)))
+		case miUINT32:
+			v[i] = float64(order.Uint32(b))
+		case miSINGLE:
+			v[i] = float64(math.Float32frombits(order.Uint32(b)))
+		case miDOUBLE:
+			v[i] = math.Float64frombits(order.Uint64(b))
+		case miINT64:
+			v[i] = float64(int64(order.Uint64(b)))
+		case miUINT64:
+			v[i] = float64(order.Uint64(b))
+		}
+	}
+	return v, nil
+}
+
+// WriteMAT writes the matrices as double arrays in an uncompressed level 5
+// MAT-file readable by MATLAB's load. Variables are written in sorted order of
+// their names.
+func WriteMAT(w io.Writer, vars map[string]*mat64.Dense) error {
+	header := bytes.Repeat([]byte{' '}, matHeaderLen)
+	text := "MATLAB 5.0 MAT-file, Platform: GOLANG, Created on: " + time.Now().Format(time.ANSIC)
+	copy(header[:matTextLen], text)
+	for i := matTextLen; i < matTextLen+8; i++ {
+		header[i] = 0 // subsystem data offset
+	}
+	binary.LittleEndian.PutUint16(header[124:126], 0x0100)
+	header[126], header[127] = 'I', 'M'
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeMATElement(w, miMATRIX, encodeMATMatrix(name, vars[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMATMatrix returns the contents of an miMATRIX element for a double array.
+func encodeMATMatrix(name string, m *mat64.Dense) []byte {
+	var rows, cols int
+	if m != nil {
+		rows, cols = m.Dims()
+	}
+	buf := &bytes.Buffer{}
+	tmp := make([]byte, 8)
+
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags[0:4], mxDoubleClass)
+	writeMATElement(buf, miUINT32, flags)
+
+	dims := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dims[0:4], uint32(rows))
+	binary.LittleEndian.PutUint32(dims[4:8], uint32(cols))
+	writeMATElement(buf, miINT32, dims)
+
+	writeMATElement(buf, miINT8, []byte(name))
+
+	values := make([]byte, 0, 8*rows*cols)
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			binary.LittleEndian.PutUint64(tmp, math.Float64bits(m.At(i, j)))
+			values = append(values, tmp...)
+		}
+	}
+	writeMATElement(buf, miDOUBLE, values)
+	return buf.Bytes()
+}
+
+// writeMATElement writes a tagged element padded to an 8-byte boundary.
+func writeMATElement(w io.Writer, typ uint32, data []byte) error {
+	var tag [8]byte
+	binary.LittleEndian.PutUint32(tag[0:4], typ)
+	binary.LittleEndian.PutUint32(tag[4:8], uint32(len(data)))
+	if _, err := w.Write(tag[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	pad := padMAT(int64(len(data))) - int64(len(data))
+	_, err := w.Write(make([]byte, pad))
+	return err
+}
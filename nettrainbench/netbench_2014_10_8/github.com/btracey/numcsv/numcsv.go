@@ -6,10 +6,14 @@ package numcsv
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"io"
+	"math"
+	"math/rand"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/gonum/matrix/mat64"
 )
@@ -17,22 +21,120 @@ import (
 type Reader struct {
 	Comma        string // field delimiter (set to ',' by NewReader)
 	HeadingComma string // delimiter for the headings. If "", set to the same value as Comma
-	// AllowEndingComma bool   // Allows there to be a single comma at the end of the field
-	Comment         string // comment character for start of line
-	FieldsPerRecord int    // If preset, the number of expected fields. Set otherwise
-	NoHeading       bool
-	hasEndingComma  bool
-	reader          io.Reader
-	scanner         *bufio.Scanner
-	lineRead        bool // signifier that some of the
+	// AllowEndingComma allows a single delimiter at the end of each line. Since
+	// the trailing delimiter is accounted for, empty fields elsewhere are then
+	// kept (and fail to parse) rather than ignored, so short rows are detected.
+	// It has no effect if Comma is whitespace.
+	AllowEndingComma bool
+	Comment          string // comment character for start of line
+	NaN              string // If non-empty, fields equal to NaN are read as math.NaN()
+	FieldsPerRecord  int    // If preset, the number of expected fields. Set otherwise
+	NoHeading        bool
+	NilAtEOF         bool                  // Read and its variants return nil, nil at EOF instead of io.EOF, as in earlier versions
+	Encoding         Encoding              // Character encoding of the input. By default it is detected
+	Normalize        *HeadingNormalization // If non-nil, used to normalize the headings in ReadHeading
+	Widths           []int                 // If non-nil, fields are fixed-width columns of these widths instead of delimited
+	InferWidths      bool                  // Set Widths in ReadHeading from the alignment of the headings
+	Kinds            map[int]Kind          // Column types used by ReadAllTyped. Missing columns are Float
+	BigPrec          uint                  // Precision of BigFloat columns. If 0, DefaultBigPrec is used
+	Cleaning         *NumberCleaning       // If non-nil, decorations to strip from fields before parsing
+	ComplexPairs     bool                  // In ReadComplex, adjacent fields are the real and imaginary parts
+	RemoveDuplicates bool                  // In ReadDataset, drop rows repeating an earlier row
+	DropConstant     bool                  // In ReadDataset, drop columns where every value is equal
+	Transforms       map[string]Transform  // In ReadDataset, transforms applied to the named columns
+	Roles            map[string]Role       // In ReadDataset, the column roles of the Dataset
+
+	// Progress, if non-nil, is called every ProgressInterval rows during ReadAll
+	// and once at the end with the number of bytes consumed from the
+	// underlying reader and the number of records read.
+	Progress         func(bytesRead, rowsRead int64)
+	ProgressInterval int64 // If 0, DefaultProgressInterval is used
+
+	// Bounds gives the allowed range of values of the columns by index.
+	// NonFinite and OutOfRange set the handling of ±Inf and NaN, apart from
+	// the NaN token, and of values outside the bounds by Read, ReadAll,
+	// ReadAll32 and ReadAllTyped, and ValueCounts reports how often they
+	// applied. If a value is both, NonFinite is used.
+	// ReadComplex and Unmarshal do not apply them.
+	Bounds      map[int]Bounds
+	NonFinite   ValuePolicy
+	OutOfRange  ValuePolicy
+	Replacement float64 // Value used by the Replace policy
+	counts      ValueCounts
+
+	// Shuffle, if non-nil, makes Batches yield the records in a random order.
+	// The underlying reader must implement io.ReaderAt, as os.File does, and
+	// hold UTF-8.
+	Shuffle *rand.Rand
+
+	// Decimate, if greater than one, makes Read return one record for each
+	// group of Decimate records in the file, combined as set by Aggregate.
+	// The final group may be smaller. Parallelism is then ignored.
+	Decimate  int
+	Aggregate Aggregation
+
+	// BestEffort makes ReadAll skip records that cannot be parsed rather than
+	// fail, and stop at an input error returning the records read so far. The
+	// problems are reported by Diagnostics. Parallelism is ignored.
+	BestEffort bool
+
+	// Parallelism, if greater than one, is the number of goroutines parsing
+	// fields in ReadAll while another reads lines. Works on any io.Reader.
+	Parallelism int
+
+	droppedRows      []int
+	originalHeadings map[string]string
+	droppedCols      []int
+	reader           *countingReader
+	scanner          lineScanner
+	lineRead         bool // signifier that some of the
+	headingRead      bool
+	headings         []string // as read, before reordering
+	reorderRef       []string
+	order            []int // file column of each output column
+	line             int64 // number of lines scanned
+	diagnostics      []Diagnostic
 }
 
 func NewReader(r io.Reader) *Reader {
-	return &Reader{
+	c := &countingReader{r: r}
+	d := &decodingReader{r: c}
+	d.rd = &Reader{
 		Comma:   ",",
-		reader:  r,
-		scanner: bufio.NewScanner(r),
+		reader:  c,
+		scanner: bufio.NewScanner(d),
+	}
+	return d.rd
+}
+
+// lineScanner is the subset of bufio.Scanner used by Reader, so that lines can
+// also come from a memory-mapped file.
+type lineScanner interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+// DefaultProgressInterval is the number of rows between calls to Reader.Progress.
+const DefaultProgressInterval = 100000
+
+// countingReader counts the bytes read through it. If ctx is set, reads fail
+// once it is done.
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	ctx context.Context
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if c.ctx != nil {
+		if err := c.ctx.Err(); err != nil {
+			return 0, err
+		}
 	}
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 var (
@@ -45,6 +147,7 @@ func (r *Reader) ReadHeading() (headings []string, err error) {
 	// Read until prefix isn't comment
 	var line string
 	for b := r.scanner.Scan(); b; b = r.scanner.Scan() {
+		r.line++
 		line = r.scanner.Text()
 		if line == "" {
 			continue
@@ -61,7 +164,16 @@ func (r *Reader) ReadHeading() (headings []string, err error) {
 	if comma == "" {
 		comma = r.Comma
 	}
-	strs := strings.Split(line, r.Comma)
+	if r.InferWidths {
+		r.Widths = InferWidths(line)
+	}
+	var strs []string
+	if r.Widths != nil {
+		strs = splitFixed(line, r.Widths)
+	} else {
+		line, _ = r.trimEndingComma(line, comma)
+		strs = strings.Split(line, comma)
+	}
 	for _, str := range strs {
 		str = strings.TrimSpace(str)
 		if len(str) != 0 {
@@ -74,31 +186,154 @@ func (r *Reader) ReadHeading() (headings []string, err error) {
 	}
 	r.FieldsPerRecord = len(headings)
 
-	// Remove the quotations
+	// Remove the quotations. The headings are copied since the line may refer
+	// to memory-mapped data.
 	for i, str := range headings {
 		str = strings.TrimSuffix(str, "\"")
 		str = strings.TrimPrefix(str, "\"")
-		headings[i] = str
+		headings[i] = strings.Clone(str)
+	}
+	if r.Normalize != nil {
+		headings, r.originalHeadings = NormalizeHeadings(headings, *r.Normalize)
 	}
 	r.lineRead = true
+	r.headingRead = true
+	r.headings = headings
+	if r.reorderRef != nil {
+		if err := r.setOrder(); err != nil {
+			return nil, err
+		}
+		headings = append([]string(nil), r.reorderRef...)
+	}
 	return headings, nil
 }
 
 // Read reads a single record from the CSV. ReadHeading must be called first if
-// there are headings. Returns nil if EOF reached.
+// there are headings. At the end of the input Read returns io.EOF, or nil, nil
+// if NilAtEOF is set.
 func (r *Reader) Read() ([]float64, error) {
-	b := r.scanner.Scan()
-	if !b {
-		return nil, r.scanner.Err()
+	data, err := r.read()
+	if data == nil && err == nil {
+		return nil, r.eof()
+	}
+	return data, err
+}
+
+// eof returns the error for the end of the input.
+func (r *Reader) eof() error {
+	if r.NilAtEOF {
+		return nil
+	}
+	return io.EOF
+}
+
+// read is Read returning nil, nil at EOF.
+func (r *Reader) read() ([]float64, error) {
+	if r.Decimate > 1 {
+		return r.readDecimated()
+	}
+	return r.readRecord()
+}
+
+// readRecord reads the next record that is not skipped by a value policy.
+func (r *Reader) readRecord() ([]float64, error) {
+	for {
+		strs, err := r.readFields()
+		if strs == nil || err != nil {
+			return nil, err
+		}
+		data, err := r.parseFields(strs)
+		if err != errSkip {
+			return data, err
+		}
+	}
+}
+
+// parseFields parses the fields of a record.
+func (r *Reader) parseFields(strs []string) ([]float64, error) {
+	var err error
+	data := make([]float64, len(strs))
+	for i, str := range strs {
+		data[i], err = strconv.ParseFloat(r.field(str), 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := r.checkValues(data, strs, math.MaxFloat64); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// field returns the field to parse for str, "NaN" if it is the NaN token.
+func (r *Reader) field(str string) string {
+	if r.NaN != "" && str == r.NaN {
+		return "NaN"
+	}
+	return str
+}
+
+// readFields reads the next line and splits it into its non-empty fields,
+// checking the number of fields. Returns nil if EOF reached.
+func (r *Reader) readFields() ([]string, error) {
+	line, ok, err := r.readLine()
+	if !ok {
+		return nil, err
+	}
+	return r.splitFields(line)
+}
+
+// trimEndingComma removes a trailing delimiter from the line if
+// AllowEndingComma is set. strict reports whether empty fields should be kept.
+func (r *Reader) trimEndingComma(line, comma string) (trimmed string, strict bool) {
+	if !r.AllowEndingComma || strings.TrimSpace(comma) == "" {
+		return line, false
+	}
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	return strings.TrimSuffix(line, comma), true
+}
+
+// readLine returns the next line that is not a comment. ok is false at EOF or
+// on error.
+func (r *Reader) readLine() (line string, ok bool, err error) {
+	for {
+		if !r.scanner.Scan() {
+			return "", false, r.scanner.Err()
+		}
+		r.line++
+		line = r.scanner.Text()
+		if r.Comment == "" || !strings.HasPrefix(line, r.Comment) {
+			return line, true, nil
+		}
+	}
+}
+
+// splitFields splits a line into its fields, checks their number and puts them
+// in the order set by ReorderTo. It only modifies r for the first record, so
+// it is safe for concurrent use after that.
+func (r *Reader) splitFields(line string) ([]string, error) {
+	var allStrs []string
+	fixed := r.Widths != nil
+	strict := false
+	if fixed {
+		allStrs = splitFixed(line, r.Widths)
+	} else {
+		line, strict = r.trimEndingComma(line, r.Comma)
+		allStrs = strings.Split(line, r.Comma)
 	}
-	line := r.scanner.Text()
-	allStrs := strings.Split(line, r.Comma)
 
 	strs := make([]string, 0, len(allStrs))
-	// Eliminate fields that are only whitespace
+	// Eliminate fields that are only whitespace. Fixed-width fields keep their
+	// position, so blank fields are kept and fail to parse.
 	for _, str := range allStrs {
 		str = strings.TrimSpace(str)
-		if len(str) != 0 {
+		if len(str) != 0 || fixed || strict {
+			if fixed {
+				str = fortranExponent(str)
+			}
+			if r.Cleaning != nil {
+				str = r.Cleaning.clean(str)
+			}
 			strs = append(strs, str)
 		}
 	}
@@ -113,42 +348,69 @@ func (r *Reader) Read() ([]float64, error) {
 	if len(strs) != r.FieldsPerRecord {
 		return nil, ErrFieldCount
 	}
-
-	// Parse all of the data
-	data := make([]float64, r.FieldsPerRecord)
-	var err error
-	for i, str := range strs {
-		data[i], err = strconv.ParseFloat(str, 64)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return data, nil
+	return r.reorder(strs), nil
 }
 
 // ReadAll reads all of the numeric records from the CSV. ReadHeading must be called first if
 // there are headings
 func (r *Reader) ReadAll() (*mat64.Dense, error) {
+	return r.readAll(context.Background())
+}
+
+func (r *Reader) readAll(ctx context.Context) (*mat64.Dense, error) {
 	alldata := make([][]float64, 0)
 	count := 0
+	interval := r.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	r.reader.ctx = ctx
+	defer func() { r.reader.ctx = nil }()
+	if r.Parallelism > 1 && !r.BestEffort && r.Decimate <= 1 {
+		return r.readAllParallel(ctx, interval)
+	}
 	for {
-		data, err := r.Read()
+		data, err := r.read()
+		if err == nil && count%contextInterval == 0 {
+			err = ctx.Err()
+		}
 		if err != nil {
-			return nil, err
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return denseFromRows(alldata, r.recordLen()), &PartialError{Rows: int64(count), Bytes: r.reader.n, Err: ctxErr}
+			}
+			if !r.BestEffort {
+				return nil, err
+			}
+			r.diagnostics = append(r.diagnostics, Diagnostic{Line: r.line, Err: err})
+			if r.scanner.Err() != nil {
+				break
+			}
+			continue
 		}
 		if data == nil {
 			break
 		}
 		alldata = append(alldata, data)
 		count++
+		if r.Progress != nil && int64(count)%interval == 0 {
+			r.Progress(r.reader.n, int64(count))
+		}
+	}
+	if r.Progress != nil {
+		r.Progress(r.reader.n, int64(count))
 	}
-	mat := mat64.NewDense(len(alldata), r.FieldsPerRecord, nil)
+	return denseFromRows(alldata, r.recordLen()), nil
+}
+
+// denseFromRows copies the records into a new matrix.
+func denseFromRows(alldata [][]float64, cols int) *mat64.Dense {
+	mat := mat64.NewDense(len(alldata), cols, nil)
 	for i, record := range alldata {
 		for j, v := range record {
 			mat.Set(i, j, v)
 		}
 	}
-	return mat, nil
+	return mat
 }
 
 type Writer struct {
@@ -156,18 +418,33 @@ type Writer struct {
 	UseCRLF      bool
 	QuoteHeading bool // Put quotes around heading strings
 	FloatFmt     byte
+	Prec         int    // Precision passed to strconv.FormatFloat (set to 16 by NewWriter)
+	NaN          string // If non-empty, written in place of NaN values
+	Comment      string // Prefix used by WriteComment
+	ComplexPairs bool   // In WriteComplex, write the real and imaginary parts as separate fields
+	Align        bool   // Pad fields to the width of their column. The output is held until Flush
+	Format       Format // Output syntax. Markdown and LaTeX tables ignore Comma
 	w            *bufio.Writer
+	al           *aligner
+	tableOpen    bool // a LaTeX tabular has been started
+	scratch      []byte
 }
 
 func NewWriter(w io.Writer) *Writer {
-	return &Writer{
+	nw := &Writer{
 		Comma:    ",",
-		w:        bufio.NewWriter(w),
 		FloatFmt: 'e',
+		Prec:     16,
 	}
+	nw.al = &aligner{w: nw, dst: w}
+	nw.w = bufio.NewWriter(nw.al)
+	return nw
 }
 
 func (w *Writer) WriteHeading(heading []string) (err error) {
+	if w.Format != CSV {
+		return w.writeTableHeading(heading)
+	}
 	for n, field := range heading {
 		if n > 0 {
 			if _, err = w.w.WriteString(w.Comma); err != nil {
@@ -181,36 +458,44 @@ func (w *Writer) WriteHeading(heading []string) (err error) {
 			return
 		}
 	}
-	if w.UseCRLF {
-		_, err = w.w.WriteString("\r\n")
-	} else {
-		err = w.w.WriteByte('\n')
-	}
-	return err
+	return w.endLine()
 }
 
+// Write writes a single record. The line is formatted into a reused buffer
+// and written at once, so Write does not allocate.
 func (w *Writer) Write(record []float64) error {
+	w.scratch = w.appendRecord(w.scratch[:0], record)
+	_, err := w.w.Write(w.scratch)
+	return err
+}
+
+// appendRecord appends the formatted record, including the line ending, to dst.
+func (w *Writer) appendRecord(dst []byte, record []float64) []byte {
+	dst = w.appendRowStart(dst, len(record))
+	sep := w.separator()
 	for n, field := range record {
 		if n > 0 {
-			if _, err := w.w.WriteString(w.Comma); err != nil {
-				return err
-			}
-		}
-		str := strconv.FormatFloat(field, w.FloatFmt, 16, 64)
-		if _, err := w.w.WriteString(str); err != nil {
-			return err
+			dst = append(dst, sep...)
 		}
+		dst = w.appendFloat(dst, field, 64)
 	}
-	var err error
-	if w.UseCRLF {
-		_, err = w.w.WriteString("\r\n")
-	} else {
-		err = w.w.WriteByte('\n')
+	return w.appendRowEnd(dst)
+}
+
+// appendFloat appends a value of the bit size with the Writer's format,
+// precision and NaN token.
+func (w *Writer) appendFloat(dst []byte, v float64, bitSize int) []byte {
+	if w.NaN != "" && math.IsNaN(v) {
+		return append(dst, w.NaN...)
 	}
-	return err
+	return strconv.AppendFloat(dst, v, w.FloatFmt, w.Prec, bitSize)
 }
 
 func (w *Writer) WriteAll(headings []string, data *mat64.Dense) error {
+	return w.writeAll(context.Background(), headings, data)
+}
+
+func (w *Writer) writeAll(ctx context.Context, headings []string, data *mat64.Dense) error {
 	if headings != nil {
 		if err := w.WriteHeading(headings); err != nil {
 			return err
@@ -218,10 +503,16 @@ func (w *Writer) WriteAll(headings []string, data *mat64.Dense) error {
 	}
 	r, _ := data.Dims()
 	for i := 0; i < r; i++ {
+		if i%contextInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				w.Flush()
+				return &PartialError{Rows: int64(i), Err: err}
+			}
+		}
 		err := w.Write(data.RowView(i))
 		if err != nil {
 			return err
 		}
 	}
-	return w.w.Flush()
+	return w.Flush()
 }
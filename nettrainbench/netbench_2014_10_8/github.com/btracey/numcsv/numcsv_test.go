@@ -0,0 +1,1627 @@
+package numcsv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestMATRoundTrip(t *testing.T) {
+	vars := map[string]*mat64.Dense{
+		"a":         mat64.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}),
+		"reference": mat64.NewDense(3, 1, []float64{-1.5, 0, 1e300}),
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteMAT(buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len()%8 != 0 {
+		t.Errorf("MAT-file length %v not 8-byte aligned", buf.Len())
+	}
+	got, err := ReadMAT(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(vars) {
+		t.Fatalf("wrong number of variables: got %v, want %v", len(got), len(vars))
+	}
+	for name, want := range vars {
+		if !want.Equals(got[name]) {
+			t.Errorf("variable %v mismatch: got %v, want %v", name, got[name], want)
+		}
+	}
+}
+
+func TestMATCompressed(t *testing.T) {
+	// Build a compressed element by hand with an int32 payload and a small
+	// format name element, as written by MATLAB's default save.
+	inner := &bytes.Buffer{}
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags, 12) // int32 class
+	writeMATElement(inner, miUINT32, flags)
+	dims := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dims[0:4], 1)
+	binary.LittleEndian.PutUint32(dims[4:8], 2)
+	writeMATElement(inner, miINT32, dims)
+	small := make([]byte, 8)
+	binary.LittleEndian.PutUint32(small[0:4], 1<<16|miINT8)
+	small[4] = 'x'
+	inner.Write(small)
+	values := make([]byte, 8)
+	binary.LittleEndian.PutUint32(values[0:4], uint32(7))
+	binary.LittleEndian.PutUint32(values[4:8], uint32(0xfffffffe)) // -2
+	writeMATElement(inner, miINT32, values)
+
+	element := &bytes.Buffer{}
+	writeMATElement(element, miMATRIX, inner.Bytes())
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	zw.Write(element.Bytes())
+	zw.Close()
+
+	file := &bytes.Buffer{}
+	if err := WriteMAT(file, nil); err != nil {
+		t.Fatal(err)
+	}
+	var tag [8]byte
+	binary.LittleEndian.PutUint32(tag[0:4], miCOMPRESSED)
+	binary.LittleEndian.PutUint32(tag[4:8], uint32(compressed.Len()))
+	file.Write(tag[:])
+	file.Write(compressed.Bytes())
+
+	got, err := ReadMAT(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mat64.NewDense(1, 2, []float64{7, -2})
+	if !want.Equals(got["x"]) {
+		t.Errorf("compressed variable mismatch: got %v, want %v", got["x"], want)
+	}
+}
+
+func TestMATUnsupported(t *testing.T) {
+	// A complex double array, whose imaginary part is not read.
+	inner := &bytes.Buffer{}
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags, matComplex|mxDoubleClass)
+	writeMATElement(inner, miUINT32, flags)
+	dims := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dims[0:4], 1)
+	binary.LittleEndian.PutUint32(dims[4:8], 1)
+	writeMATElement(inner, miINT32, dims)
+	writeMATElement(inner, miINT8, []byte("z"))
+	_, _, err := decodeMATMatrix(inner.Bytes(), binary.LittleEndian)
+	if !errors.Is(err, ErrMATUnsupported) || err.Error() != `MAT variable "z" is complex: numcsv: unsupported MAT-file array` {
+		t.Errorf("got error %v for a complex array", err)
+	}
+}
+
+func TestHyperslabBounds(t *testing.T) {
+	for i, test := range []struct {
+		slab           *Hyperslab
+		r0, r1, c0, c1 int
+		err            bool
+	}{
+		{nil, 0, 10, 0, 4, false},
+		{&Hyperslab{}, 0, 10, 0, 4, false},
+		{&Hyperslab{RowStart: 2, RowEnd: 5, ColEnd: 3}, 2, 5, 0, 3, false},
+		{&Hyperslab{RowEnd: 11}, 0, 0, 0, 0, true},
+		{&Hyperslab{ColStart: 4}, 0, 0, 0, 0, true},
+	} {
+		r0, r1, c0, c1, err := test.slab.bounds(10, 4)
+		if (err != nil) != test.err {
+			t.Errorf("case %d: unexpected error state %v", i, err)
+			continue
+		}
+		if r0 != test.r0 || r1 != test.r1 || c0 != test.c0 || c1 != test.c1 {
+			t.Errorf("case %d: got [%d,%d)x[%d,%d)", i, r0, r1, c0, c1)
+		}
+	}
+}
+
+func TestDatasetGob(t *testing.T) {
+	all := mat64.NewDense(3, 3, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	view := &mat64.Dense{}
+	view.View(all, 1, 1, 2, 2) // non-contiguous data
+	for _, d := range []*Dataset{
+		{Headings: []string{"a", "b", "c"}, Units: []string{"m", "s", "kg"}, Data: all},
+		{Headings: []string{"e", "i"}, Data: view},
+	} {
+		buf := &bytes.Buffer{}
+		if err := d.Encode(buf); err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeDataset(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Headings, d.Headings) || !reflect.DeepEqual(got.Units, d.Units) {
+			t.Errorf("metadata mismatch: got %v %v", got.Headings, got.Units)
+		}
+		if !got.Data.Equals(d.Data) {
+			t.Errorf("data mismatch: got %v, want %v", got.Data, d.Data)
+		}
+	}
+
+	bad := &Dataset{Headings: []string{"a"}, Data: all}
+	if err := bad.Encode(&bytes.Buffer{}); err == nil {
+		t.Errorf("no error for heading mismatch")
+	}
+}
+
+func TestFloat32RoundTrip(t *testing.T) {
+	want := NewMatrix32(2, 3, []float32{1.1, -2.5e-30, 3.4028235e38, 0, 1.0 / 3, 7})
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	if err := w.WriteAll32([]string{"a", "b", "c"}, want); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(buf)
+	if _, err := r.ReadHeading(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.ReadAll32()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Rows != want.Rows || got.Cols != want.Cols {
+		t.Fatalf("dimension mismatch: got %v×%v", got.Rows, got.Cols)
+	}
+	if !reflect.DeepEqual(got.Data, want.Data) {
+		t.Errorf("data mismatch: got %v, want %v", got.Data, want.Data)
+	}
+
+	buf.Reset()
+	w = NewWriter(buf)
+	w.Prec = -1
+	w.NaN = "NA"
+	if err := w.Write32([]float32{1.1, float32(math.NaN())}); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if buf.String() != "1.1e+00,NA\n" {
+		t.Errorf("shortest precision and NaN: got %q", buf.String())
+	}
+}
+
+func TestReadAllTyped(t *testing.T) {
+	const text = `id value count
+9007199254740993 1.5 3
+-12 0.1000000000000000000000001 4
+`
+	r := NewReader(strings.NewReader(text))
+	r.Comma = " "
+	r.Kinds = map[int]Kind{0: Int, 1: BigFloat, 2: Int}
+	if _, err := r.ReadHeading(); err != nil {
+		t.Fatal(err)
+	}
+	data, typed, err := r.ReadAllTyped()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows, cols := data.Dims(); rows != 2 || cols != 3 {
+		t.Fatalf("wrong size %v×%v", rows, cols)
+	}
+	if !reflect.DeepEqual(typed.Ints[0], []int64{9007199254740993, -12}) {
+		t.Errorf("id column mismatch: %v", typed.Ints[0])
+	}
+	if !reflect.DeepEqual(typed.Ints[2], []int64{3, 4}) {
+		t.Errorf("count column mismatch: %v", typed.Ints[2])
+	}
+	if got := typed.Bigs[1][1].Text('g', 25); got != "0.1000000000000000000000001" {
+		t.Errorf("big column lost precision: %v", got)
+	}
+	if data.At(1, 2) != 4 {
+		t.Errorf("int column not in matrix")
+	}
+
+	r = NewReader(strings.NewReader("1.5\n"))
+	r.Kinds = map[int]Kind{0: Int}
+	if _, _, err := r.ReadAllTyped(); err == nil {
+		t.Errorf("no error parsing 1.5 as an integer")
+	}
+}
+
+func TestNumberCleaning(t *testing.T) {
+	for i, test := range []struct {
+		cleaning *NumberCleaning
+		comma    string
+		text     string
+		want     []float64
+	}{
+		{
+			cleaning: &NumberCleaning{ThousandsSep: ","},
+			comma:    ";",
+			text:     "1,234.5; 12; -1,000,000\n",
+			want:     []float64{1234.5, 12, -1e6},
+		},
+		{
+			cleaning: &NumberCleaning{ThousandsSep: ".", DecimalSep: ","},
+			comma:    ";",
+			text:     "1.234,5;0,25;7\n",
+			want:     []float64{1234.5, 0.25, 7},
+		},
+		{
+			cleaning: &NumberCleaning{Percent: true, Suffixes: []string{"m/s", "kg"}},
+			comma:    ",",
+			text:     "45%, 3.5 m/s, 12kg\n",
+			want:     []float64{45, 3.5, 12},
+		},
+	} {
+		r := NewReader(strings.NewReader(test.text))
+		r.Comma = test.comma
+		r.Cleaning = test.cleaning
+		got, err := r.Read()
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("case %d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestComplexRoundTrip(t *testing.T) {
+	want := NewComplexMatrix(2, 2, []complex128{1.2 + 3.4i, -1 - 1e-20i, 0, complex(5, -0.5)})
+	for _, pairs := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		w := NewWriter(buf)
+		w.ComplexPairs = pairs
+		if err := w.WriteAllComplex(nil, want); err != nil {
+			t.Fatal(err)
+		}
+		r := NewReader(buf)
+		r.ComplexPairs = pairs
+		got, err := r.ReadAllComplex()
+		if err != nil {
+			t.Fatalf("pairs %v: %v", pairs, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("pairs %v: got %v, want %v", pairs, got, want)
+		}
+	}
+
+	r := NewReader(strings.NewReader("(1+2i), 3-4i, 5i\n"))
+	got, err := r.ReadComplex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []complex128{1 + 2i, 3 - 4i, 5i}) {
+		t.Errorf("parse mismatch: got %v", got)
+	}
+
+	nan := complex(math.NaN(), 1)
+	for _, test := range []struct {
+		pairs bool
+		want  string
+	}{
+		{false, "\\begin{tabular}{rr}\n\\hline\nz & w \\\\\n\\hline\n1+2i & - \\\\\n\\hline\n\\end{tabular}\n"},
+		{true, "\\begin{tabular}{rrrr}\n\\hline\nre & im & re & im \\\\\n\\hline\n1 & 2 & - & 1 \\\\\n\\hline\n\\end{tabular}\n"},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.Format = LaTeX
+		w.FloatFmt = 'g'
+		w.Prec = 3
+		w.NaN = "-"
+		w.ComplexPairs = test.pairs
+		headings := []string{"z", "w"}
+		if test.pairs {
+			headings = []string{"re", "im", "re", "im"}
+		}
+		if err := w.WriteAllComplex(headings, NewComplexMatrix(1, 2, []complex128{1 + 2i, nan})); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("pairs %v: got\n%s\nwant\n%s", test.pairs, buf.String(), test.want)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	text := "a,b\n" + strings.Repeat("1,2\n", 25)
+	r := NewReader(strings.NewReader(text))
+	r.ProgressInterval = 10
+	var rows, bytesRead []int64
+	r.Progress = func(b, n int64) {
+		bytesRead = append(bytesRead, b)
+		rows = append(rows, n)
+	}
+	if _, err := r.ReadHeading(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, []int64{10, 20, 25}) {
+		t.Errorf("progress rows mismatch: %v", rows)
+	}
+	if bytesRead[len(bytesRead)-1] != int64(len(text)) {
+		t.Errorf("final bytes read %v, want %v", bytesRead[len(bytesRead)-1], len(text))
+	}
+}
+
+func TestReadAllContext(t *testing.T) {
+	text := strings.Repeat("1,2\n", 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReader(strings.NewReader(text))
+	r.ProgressInterval = 300
+	r.Progress = func(b, n int64) {
+		if n == 600 {
+			cancel()
+		}
+	}
+	data, err := r.ReadAllContext(ctx)
+	perr, ok := err.(*PartialError)
+	if !ok {
+		t.Fatalf("expected *PartialError, got %v", err)
+	}
+	if perr.Err != context.Canceled {
+		t.Errorf("wrong cause %v", perr.Err)
+	}
+	rows, _ := data.Dims()
+	if int64(rows) != perr.Rows || rows < 600 || rows >= 1000 {
+		t.Errorf("partial rows %v, reported %v", rows, perr.Rows)
+	}
+
+	buf := &bytes.Buffer{}
+	err = NewWriter(buf).WriteAllContext(ctx, nil, mat64.NewDense(2, 1, nil))
+	if _, ok := err.(*PartialError); !ok {
+		t.Errorf("expected *PartialError from write, got %v", err)
+	}
+}
+
+func TestDropRedundant(t *testing.T) {
+	const text = `x, c, y
+1, 5, 2
+3, 5, 4
+1, 5, 2
+0, 5, 2
+`
+	r := NewReader(strings.NewReader(text))
+	r.RemoveDuplicates = true
+	r.DropConstant = true
+	d, err := r.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, cols := r.Dropped()
+	if !reflect.DeepEqual(rows, []int{2}) || !reflect.DeepEqual(cols, []int{1}) {
+		t.Errorf("dropped mismatch: rows %v cols %v", rows, cols)
+	}
+	if !reflect.DeepEqual(d.Headings, []string{"x", "y"}) {
+		t.Errorf("headings mismatch: %v", d.Headings)
+	}
+	want := mat64.NewDense(3, 2, []float64{1, 2, 3, 4, 0, 2})
+	if !want.Equals(d.Data) {
+		t.Errorf("data mismatch: got %v", d.Data)
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	const text = `a, b, c
+1, -5, 1
+2, 0, 2.718281828459045
+3, 5, 1
+`
+	r := NewReader(strings.NewReader(text))
+	r.Transforms = map[string]Transform{
+		"a": Standardize,
+		"b": Clip(-1, 2),
+		"c": Chain(Log, func(col []float64) {
+			for i := range col {
+				col[i] *= 2
+			}
+		}),
+	}
+	d, err := r.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := math.Sqrt(2.0 / 3)
+	want := mat64.NewDense(3, 3, []float64{-1 / s, -1, 0, 0, 0, 2, 1 / s, 2, 0})
+	if !want.EqualsApprox(d.Data, 1e-14) {
+		t.Errorf("transform mismatch: got %v, want %v", d.Data, want)
+	}
+
+	r = NewReader(strings.NewReader(text))
+	r.Transforms = map[string]Transform{"missing": Log}
+	if _, err := r.ReadDataset(); err == nil {
+		t.Errorf("no error for missing column")
+	}
+}
+
+func TestDialectRoundTrip(t *testing.T) {
+	headings := []string{"alpha", "mach", "cl"}
+	data := mat64.NewDense(4, 3, []float64{
+		0, 0.3, 1.0 / 3,
+		-2.5, math.NaN(), math.Inf(1),
+		1e-300, 5e300, math.Inf(-1),
+		math.SmallestNonzeroFloat64, math.MaxFloat64, -0.1,
+	})
+	for i, d := range []Dialect{
+		DefaultDialect(),
+		{Comma: " ", FloatFmt: 'g', Prec: -1, NaN: "NA", Comment: "#"},
+		{Comma: "\t", QuoteHeading: true, UseCRLF: true, FloatFmt: 'e', Prec: 16, NaN: "nan"},
+		{Comma: ";", FloatFmt: 'f', Prec: -1, Comment: "%"},
+	} {
+		buf := &bytes.Buffer{}
+		w := NewDialectWriter(buf, d)
+		if d.Comment != "" {
+			if err := w.WriteComment(" generated by numcsv"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.WriteAll(headings, data); err != nil {
+			t.Fatal(err)
+		}
+		if w.Dialect() != d {
+			t.Errorf("case %d: writer dialect mismatch", i)
+		}
+
+		r := NewDialectReader(buf, d)
+		gotHeadings, err := r.ReadHeading()
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		got, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(gotHeadings, headings) {
+			t.Errorf("case %d: heading mismatch %v", i, gotHeadings)
+		}
+		rows, cols := data.Dims()
+		for m := 0; m < rows; m++ {
+			for n := 0; n < cols; n++ {
+				want, v := data.At(m, n), got.At(m, n)
+				if math.Float64bits(want) != math.Float64bits(v) && !(math.IsNaN(want) && math.IsNaN(v)) {
+					t.Errorf("case %d: element %d,%d mismatch: got %v, want %v", i, m, n, v, want)
+				}
+			}
+		}
+	}
+}
+
+func TestNormalizeHeadings(t *testing.T) {
+	r := NewReader(strings.NewReader(`"Mach Number", Re (x10^6), cl, CL, cl_2, ,
+1,2,3,4,5
+`))
+	r.Normalize = &HeadingNormalization{Lower: true, Replacement: "_", Dedupe: true}
+	headings, err := r.ReadHeading()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"mach_number", "re_x10_6", "cl", "cl_2", "cl_2_2"}
+	if !reflect.DeepEqual(headings, want) {
+		t.Errorf("normalized mismatch: got %v, want %v", headings, want)
+	}
+	orig := r.OriginalHeadings()
+	if orig["re_x10_6"] != "Re (x10^6)" || orig["cl_2"] != "CL" || orig["cl_2_2"] != "cl_2" {
+		t.Errorf("original mapping mismatch: %v", orig)
+	}
+	if _, err := r.Read(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type sample struct {
+		ID    int     `csv:"id"`
+		Alpha float64 `csv:"alpha"`
+		Cl    float32
+		Count uint8  `csv:"n"`
+		Note  string `csv:"-"`
+		skip  int
+	}
+	const text = `id, alpha, Cl, n, extra
+1, 0.5, 0.25, 3, 9
+2.0, -1e-3, 1, 1e2, 9
+`
+	var got []sample
+	if err := Unmarshal(strings.NewReader(text), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []sample{
+		{ID: 1, Alpha: 0.5, Cl: 0.25, Count: 3},
+		{ID: 2, Alpha: -1e-3, Cl: 1, Count: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	var bad []sample
+	if err := Unmarshal(strings.NewReader("id, alpha, Cl, n\n1.5, 0, 0, 0\n"), &bad); err == nil {
+		t.Errorf("no error for fractional integer")
+	}
+	type missing struct {
+		X float64 `csv:"x"`
+	}
+	if err := Unmarshal(strings.NewReader(text), &[]missing{}); err == nil {
+		t.Errorf("no error for missing tagged column")
+	}
+	if err := Unmarshal(strings.NewReader(text), []sample{}); err != ErrUnmarshalType {
+		t.Errorf("wrong error for non-pointer: %v", err)
+	}
+}
+
+func TestFixedWidth(t *testing.T) {
+	const text = `      ALPHA         CL        CD
+ -2.000E+00 1.2345D-01-5.000E-03
+  1.000E+01 9.9000D-01 1.200E-02
+`
+	r := NewReader(strings.NewReader(text))
+	r.InferWidths = true
+	headings, err := r.ReadHeading()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(headings, []string{"ALPHA", "CL", "CD"}) {
+		t.Errorf("heading mismatch: %v", headings)
+	}
+	if !reflect.DeepEqual(r.Widths, []int{11, 11, 0}) {
+		t.Errorf("inferred widths %v", r.Widths)
+	}
+	data, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mat64.NewDense(2, 3, []float64{-2, 0.12345, -5e-3, 10, 0.99, 0.012})
+	if !want.Equals(data) {
+		t.Errorf("data mismatch: got %v", data)
+	}
+
+	r = NewReader(strings.NewReader("123456\n"))
+	r.Widths = []int{2, 3, 1}
+	got, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []float64{12, 345, 6}) {
+		t.Errorf("explicit widths mismatch: %v", got)
+	}
+}
+
+func TestWriterToReaderFrom(t *testing.T) {
+	d := &Dataset{
+		Headings: []string{"x", "y"},
+		Data:     mat64.NewDense(3, 2, []float64{1, 2, 3.5, -4, 1e-10, math.NaN()}),
+	}
+	buf := &bytes.Buffer{}
+	n, err := d.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %v bytes, wrote %v", n, buf.Len())
+	}
+
+	// Check against the regular Writer.
+	want := &bytes.Buffer{}
+	if err := NewWriter(want).WriteAll(d.Headings, d.Data); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want.String() {
+		t.Errorf("WriteTo mismatch:\n%s\nwant:\n%s", buf.String(), want.String())
+	}
+
+	total := int64(buf.Len())
+	out := &bytes.Buffer{}
+	w := NewDialectWriter(out, Dialect{Comma: " ", FloatFmt: 'g', Prec: -1, NaN: "NA"})
+	n, err = w.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != total {
+		t.Errorf("ReadFrom reported %v bytes, want %v", n, total)
+	}
+	if got := out.String(); got != "x y\n1 2\n3.5 -4\n1e-10 NA\n" {
+		t.Errorf("ReadFrom output mismatch:\n%s", got)
+	}
+}
+
+func TestFileReaderMmap(t *testing.T) {
+	const text = "\"a\" b c\n1 2 3\r\n# note\n4 5 6"
+	f, err := ioutil.TempFile("", "numcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(text)
+	f.Close()
+
+	for _, useMmap := range []bool{false, true} {
+		r, err := NewFileReader(f.Name(), useMmap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Comma = " "
+		r.Comment = "#"
+		headings, err := r.ReadHeading()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(headings, []string{"a", "b", "c"}) {
+			t.Errorf("mmap %v: headings %v", useMmap, headings)
+		}
+		want := mat64.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+		if !want.Equals(data) {
+			t.Errorf("mmap %v: data %v", useMmap, data)
+		}
+	}
+}
+
+func TestReadAllParallel(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("a,b,c\n")
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&buf, "%d,%g,%g\n", i, rand.Float64(), rand.NormFloat64())
+	}
+	text := buf.String()
+
+	r := NewReader(strings.NewReader(text))
+	r.ReadHeading()
+	want, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []int{2, 4, 7} {
+		r := NewReader(strings.NewReader(text))
+		r.Parallelism = p
+		r.ReadHeading()
+		got, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !want.Equals(got) {
+			t.Errorf("parallelism %d: data does not match serial read", p)
+		}
+	}
+
+	r = NewReader(strings.NewReader(text + "1,2\n"))
+	r.Parallelism = 3
+	r.ReadHeading()
+	if _, err := r.ReadAll(); err != ErrFieldCount {
+		t.Errorf("got error %v, want %v", err, ErrFieldCount)
+	}
+}
+
+func TestOpenHTTP(t *testing.T) {
+	const body = "x,y\n1,2\n3,4\n"
+	var gets, notModified int
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		gets++
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "numcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(old string) { CacheDir = old }(CacheDir)
+	CacheDir = dir
+
+	// The last read is of the cached copy while the server is failing.
+	for i := 0; i < 3; i++ {
+		down = i == 2
+		r, err := Open(srv.URL + "/data.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		headings, err := r.ReadHeading()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := r.ReadAll()
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(headings, []string{"x", "y"}) || !mat64.NewDense(2, 2, []float64{1, 2, 3, 4}).Equals(data) {
+			t.Errorf("read %d: got %v %v", i, headings, data)
+		}
+	}
+	if gets != 1 || notModified != 1 {
+		t.Errorf("got %d downloads and %d revalidations, want 1 and 1", gets, notModified)
+	}
+	if _, err := Open(srv.URL + "/other.csv"); err == nil {
+		t.Error("no error for an uncached file while the server is failing")
+	}
+}
+
+func TestOpenGzip(t *testing.T) {
+	f, err := ioutil.TempFile("", "numcsv*.csv.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	zw := gzip.NewWriter(f)
+	io.WriteString(zw, "a,b\n1,2\n3,4\n")
+	zw.Close()
+	f.Close()
+
+	r, err := Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.ReadHeading()
+	data, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat64.NewDense(2, 2, []float64{1, 2, 3, 4}).Equals(data) {
+		t.Errorf("got %v", data)
+	}
+	if _, err := Open("ftp://host/data.csv"); !errors.Is(err, ErrScheme) || err.Error() != "ftp://host/data.csv: numcsv: unsupported URL scheme" {
+		t.Errorf("got error %v for unknown scheme", err)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("# comment\nx,y\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&buf, "%d,%d\n", i, 2*i)
+	}
+	f, err := ioutil.TempFile("", "numcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(buf.Bytes())
+	f.Close()
+
+	for _, shuffle := range []bool{false, true} {
+		r, err := NewFileReader(f.Name(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Comment = "#"
+		if shuffle {
+			r.Shuffle = rand.New(rand.NewSource(1))
+		}
+		r.ReadHeading()
+		next := r.Batches(4)
+		var sizes []int
+		seen := make(map[float64]bool)
+		inOrder := true
+		for {
+			batch, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, _ := batch.Dims()
+			sizes = append(sizes, rows)
+			for i := 0; i < rows; i++ {
+				x := batch.At(i, 0)
+				if batch.At(i, 1) != 2*x {
+					t.Errorf("shuffle %v: bad row %v", shuffle, batch.RowView(i))
+				}
+				if x != float64(len(seen)) {
+					inOrder = false
+				}
+				seen[x] = true
+			}
+		}
+		r.Close()
+		if !reflect.DeepEqual(sizes, []int{4, 4, 2}) || len(seen) != 10 {
+			t.Errorf("shuffle %v: batch sizes %v, %d distinct rows", shuffle, sizes, len(seen))
+		}
+		if inOrder == shuffle {
+			t.Errorf("shuffle %v: records in order is %v", shuffle, inOrder)
+		}
+	}
+}
+
+func TestRolesSplit(t *testing.T) {
+	r := NewReader(strings.NewReader("w,y,a,b\n1,10,2,3\n0.5,20,4,5\n"))
+	r.Roles = map[string]Role{"a": Input, "b": Input, "y": Target, "w": Weight}
+	d, err := r.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, targets, weights, err := d.Split()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat64.NewDense(2, 2, []float64{2, 3, 4, 5}).Equals(inputs) {
+		t.Errorf("inputs %v", inputs)
+	}
+	if !mat64.NewDense(2, 1, []float64{10, 20}).Equals(targets) {
+		t.Errorf("targets %v", targets)
+	}
+	if !reflect.DeepEqual(weights, []float64{1, 0.5}) {
+		t.Errorf("weights %v", weights)
+	}
+
+	d.SetRoles(Weight, "a")
+	if _, _, _, err := d.Split(); err != ErrWeightColumns {
+		t.Errorf("got error %v, want %v", err, ErrWeightColumns)
+	}
+	d.SetRoles(Input, "missing")
+	if _, _, _, err := d.Split(); err == nil {
+		t.Error("no error for an unknown column")
+	}
+}
+
+func TestValuePolicies(t *testing.T) {
+	const text = "1,2\nInf,3\n4,100\n-Inf,-5\n"
+	for _, test := range []struct {
+		nonFinite, outOfRange ValuePolicy
+		want                  []float64
+		counts                ValueCounts
+		fail                  string // the error message, if the read fails
+	}{
+		{Keep, Keep, []float64{1, 2, math.Inf(1), 3, 4, 100, math.Inf(-1), -5}, ValueCounts{}, ""},
+		{Skip, Skip, []float64{1, 2}, ValueCounts{2, 1, 3}, ""},
+		{Clamp, Clamp, []float64{1, 2, math.MaxFloat64, 3, 4, 10, -math.MaxFloat64, 0}, ValueCounts{2, 2, 0}, ""},
+		{Replace, Keep, []float64{1, 2, -1, 3, 4, 100, -1, -5}, ValueCounts{2, 2, 0}, ""},
+		{Fail, Keep, nil, ValueCounts{1, 0, 0}, "numcsv: non-finite value +Inf in column 0"},
+		{Keep, Fail, nil, ValueCounts{1, 1, 0}, "numcsv: value 100 in column 1 out of range"},
+	} {
+		for _, p := range []int{0, 2} {
+			r := NewReader(strings.NewReader(text))
+			r.NoHeading = true
+			r.Parallelism = p
+			r.Bounds = map[int]Bounds{1: {0, 10}}
+			r.NonFinite = test.nonFinite
+			r.OutOfRange = test.outOfRange
+			r.Replacement = -1
+			data, err := r.ReadAll()
+			if test.fail != "" {
+				if _, ok := err.(*ValueError); !ok || err.Error() != test.fail {
+					t.Errorf("policies %v, %v: got error %v, want a ValueError %q", test.nonFinite, test.outOfRange, err, test.fail)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !mat64.NewDense(len(test.want)/2, 2, test.want).Equals(data) {
+				t.Errorf("policies %v, %v: got %v", test.nonFinite, test.outOfRange, data)
+			}
+			if got := r.ValueCounts(); got != test.counts {
+				t.Errorf("policies %v, %v: got counts %+v, want %+v", test.nonFinite, test.outOfRange, got, test.counts)
+			}
+		}
+	}
+
+	// The float32 and typed readers apply the policies too, clamping float32
+	// infinities to the largest float32.
+	r := NewReader(strings.NewReader(text))
+	r.NoHeading = true
+	r.Bounds = map[int]Bounds{1: {0, 10}}
+	r.NonFinite, r.OutOfRange = Clamp, Clamp
+	m32, err := r.ReadAll32()
+	if want := []float32{1, 2, math.MaxFloat32, 3, 4, 10, -math.MaxFloat32, 0}; err != nil || !reflect.DeepEqual(m32.Data, want) {
+		t.Errorf("ReadAll32: got %v, %v, want %v", m32, err, want)
+	}
+	r = NewReader(strings.NewReader(text))
+	r.NoHeading = true
+	r.Bounds = map[int]Bounds{1: {0, 10}}
+	r.NonFinite, r.OutOfRange = Skip, Skip
+	r.Kinds = map[int]Kind{1: Int}
+	data, typed, err := r.ReadAllTyped()
+	if err != nil || !mat64.NewDense(1, 2, []float64{1, 2}).Equals(data) || !reflect.DeepEqual(typed.Ints[1], []int64{2}) {
+		t.Errorf("ReadAllTyped: got %v %v, %v", data, typed, err)
+	}
+	r = NewReader(strings.NewReader(text))
+	r.NoHeading = true
+	r.NonFinite = Fail
+	if _, err := r.ReadAll32(); err == nil || err.Error() != "numcsv: non-finite value +Inf in column 0" {
+		t.Errorf("ReadAll32: got error %v", err)
+	}
+
+	// NaN is non-finite too, but the NaN token is a missing value.
+	const nans = "1,NaN\nNA,2\n"
+	r = NewReader(strings.NewReader(nans))
+	r.NoHeading = true
+	r.NaN = "NA"
+	r.Bounds = map[int]Bounds{1: {0, 10}}
+	r.NonFinite, r.OutOfRange = Skip, Fail
+	data, err = r.ReadAll()
+	if err != nil || data.At(0, 1) != 2 || !math.IsNaN(data.At(0, 0)) {
+		t.Errorf("NaN skipped: got %v, %v", data, err)
+	}
+	if got, want := r.ValueCounts(), (ValueCounts{1, 0, 1}); got != want {
+		t.Errorf("NaN skipped: got counts %+v, want %+v", got, want)
+	}
+	r = NewReader(strings.NewReader(nans))
+	r.NoHeading = true
+	r.NonFinite = Fail
+	if _, err := r.ReadAll(); err == nil || err.Error() != "numcsv: non-finite value NaN in column 1" {
+		t.Errorf("NaN failed: got error %v", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	data := mat64.NewDense(5, 2, []float64{
+		1, math.NaN(),
+		2, math.NaN(),
+		3, math.NaN(),
+		4, math.NaN(),
+		5, math.NaN(),
+	})
+	s := Describe([]string{"x", "missing"}, data)
+	want := ColumnSummary{Name: "x", Count: 5, Mean: 3, Std: math.Sqrt(2.5), Min: 1, Q1: 2, Med: 3, Q3: 4, Max: 5}
+	if s[0] != want {
+		t.Errorf("got %+v, want %+v", s[0], want)
+	}
+	if s[1].Count != 0 || s[1].NaN != 5 || !math.IsNaN(s[1].Mean) {
+		t.Errorf("got %+v for a column of NaN", s[1])
+	}
+	if str := s.String(); !strings.Contains(str, "missing") || strings.Count(str, "\n") != 3 {
+		t.Errorf("unexpected table:\n%s", str)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	d := &Dataset{
+		Headings: []string{"x", "neg", "c", "y"},
+		Data: mat64.NewDense(4, 4, []float64{
+			1, -2, 7, 1,
+			2, -4, 7, 3,
+			3, -6, 7, 2,
+			4, -8, 7, 4,
+		}),
+	}
+	corr := d.Correlation()
+	if corr.Data.At(0, 0) != 1 || math.Abs(corr.Data.At(0, 1)+1) > 1e-14 || !math.IsNaN(corr.Data.At(2, 0)) {
+		t.Errorf("unexpected correlation matrix %v", corr.Data)
+	}
+	if corr.Data.At(0, 3) != corr.Data.At(3, 0) {
+		t.Error("correlation matrix not symmetric")
+	}
+
+	report, err := d.TargetCorrelation("y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Headings, []string{"x", "neg", "c"}) {
+		t.Errorf("got headings %v", report.Headings)
+	}
+	if math.Abs(report.Data.At(0, 0)-0.8) > 1e-14 || math.Abs(report.Data.At(0, 1)+0.8) > 1e-14 {
+		t.Errorf("got target correlations %v", report.Data)
+	}
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.TargetCorrelation("z"); err == nil {
+		t.Error("no error for an unknown target")
+	}
+}
+
+func TestWriteRecord(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	if err := w.WriteRecord([]interface{}{3, uint8(4), int64(-5), true, 0.5, float32(0.25), "a,b", "plain"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRecord([]interface{}{struct{}{}}); err != ErrFieldType {
+		t.Errorf("got error %v, want %v", err, ErrFieldType)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "3,4,-5,true,0.5,0.25,\"a,b\",plain\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriterAlign(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Comma = " "
+	w.Comment = "#"
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	w.Align = true
+	w.WriteComment(" results")
+	w.WriteRecord([]interface{}{"case", "evals", "loss"})
+	w.WriteRecord([]interface{}{"five", 50, 0.125})
+	w.WriteRecord([]interface{}{"hundred", 1000, 3.5})
+	if buf.Len() != 0 {
+		t.Error("aligned output written before Flush")
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "# results\n   case evals  loss\n   five    50 0.125\nhundred  1000   3.5\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf)
+	w.Comma = " "
+	w.Align = true
+	data := mat64.NewDense(2, 2, []float64{1, -2, 300, 4})
+	if err := w.WriteAll([]string{"a", "b"}, data); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(&buf)
+	r.Comma = " "
+	r.ReadHeading()
+	got, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.Equals(got) {
+		t.Errorf("aligned output read back as %v", got)
+	}
+}
+
+func TestEncodingDetection(t *testing.T) {
+	utf16le := func(s string, bom bool) []byte {
+		var b []byte
+		if bom {
+			b = append(b, 0xff, 0xfe)
+		}
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+		return b
+	}
+	utf16be := func(s string) []byte {
+		b := []byte{0xfe, 0xff}
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(u>>8), byte(u))
+		}
+		return b
+	}
+	const text = "T (°C),µ 𝜎\n1,2\n3,4\n"
+	for _, test := range []struct {
+		name string
+		in   []byte
+	}{
+		{"utf8", []byte(text)},
+		{"utf8 bom", append([]byte{0xef, 0xbb, 0xbf}, text...)},
+		{"utf16le", utf16le(text, true)},
+		{"utf16le no bom", utf16le(text, false)},
+		{"utf16be", utf16be(text)},
+	} {
+		for _, mmap := range []bool{false, true} {
+			var r *Reader
+			if mmap {
+				f, err := ioutil.TempFile("", "numcsv")
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.Write(test.in)
+				f.Close()
+				defer os.Remove(f.Name())
+				fr, err := NewFileReader(f.Name(), true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer fr.Close()
+				r = fr.Reader
+			} else {
+				// Deliver one byte at a time to exercise partial characters.
+				r = NewReader(iotest.OneByteReader(bytes.NewReader(test.in)))
+			}
+			headings, err := r.ReadHeading()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := r.ReadAll()
+			if err != nil {
+				t.Errorf("%s (mmap %v): %v", test.name, mmap, err)
+				continue
+			}
+			if !reflect.DeepEqual(headings, []string{"T (°C)", "µ 𝜎"}) || !mat64.NewDense(2, 2, []float64{1, 2, 3, 4}).Equals(data) {
+				t.Errorf("%s (mmap %v): got %q %v", test.name, mmap, headings, data)
+			}
+		}
+	}
+
+	// Windows-1252, as written by Excel.
+	r := NewReader(bytes.NewReader([]byte("\x80 price,caf\xe9\n1,2\n")))
+	headings, err := r.ReadHeading()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(headings, []string{"€ price", "café"}) {
+		t.Errorf("got headings %q", headings)
+	}
+}
+
+func TestBestEffort(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n1,2\n3\nx,4\n5,6\n"))
+	r.BestEffort = true
+	r.ReadHeading()
+	data, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat64.NewDense(2, 2, []float64{1, 2, 5, 6}).Equals(data) {
+		t.Errorf("got %v", data)
+	}
+	diags := r.Diagnostics()
+	if len(diags) != 2 || diags[0].Line != 3 || diags[0].Err != ErrFieldCount || diags[1].Line != 4 {
+		t.Errorf("got diagnostics %v", diags)
+	}
+
+	// An overlong line stops the scan but keeps the earlier records.
+	r = NewReader(strings.NewReader("1,2\n" + strings.Repeat("9", bufio.MaxScanTokenSize+1) + "\n"))
+	r.NoHeading = true
+	r.BestEffort = true
+	data, err = r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ := data.Dims(); rows != 1 || len(r.Diagnostics()) != 1 {
+		t.Errorf("got %d rows and diagnostics %v", rows, r.Diagnostics())
+	}
+}
+
+func TestReadEOF(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n1,2\n"))
+	r.ReadHeading()
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if record, err := r.Read(); record != nil || err != io.EOF {
+		t.Errorf("got %v, %v at EOF, want nil, io.EOF", record, err)
+	}
+
+	r = NewReader(strings.NewReader("1,2\n"))
+	r.NoHeading = true
+	r.NilAtEOF = true
+	r.Read()
+	if record, err := r.Read(); record != nil || err != nil {
+		t.Errorf("got %v, %v at EOF with NilAtEOF, want nil, nil", record, err)
+	}
+
+	// Scanner errors are not mistaken for EOF.
+	r = NewReader(iotest.TimeoutReader(strings.NewReader("1,2\n3,4\n")))
+	r.NoHeading = true
+	if _, err := r.ReadAll(); err != iotest.ErrTimeout {
+		t.Errorf("got error %v, want %v", err, iotest.ErrTimeout)
+	}
+}
+
+func TestAllowEndingComma(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b,\n1,2,\n3,4 , \n"))
+	r.AllowEndingComma = true
+	headings, err := r.ReadHeading()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(headings, []string{"a", "b"}) || !mat64.NewDense(2, 2, []float64{1, 2, 3, 4}).Equals(data) {
+		t.Errorf("got %v %v", headings, data)
+	}
+
+	for _, line := range []string{"1,\n", "1,2,3,\n", "1,2,,\n", "1,,\n"} {
+		r := NewReader(strings.NewReader("a,b,\n" + line))
+		r.AllowEndingComma = true
+		r.ReadHeading()
+		if _, err := r.Read(); err == nil {
+			t.Errorf("no error for short or long row %q", line)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		shape Shape
+	}{
+		{"a,b,c\n1,2,3\n4,5,6\n", Shape{Headings: []string{"a", "b", "c"}, Comma: ",", Cols: 3, Rows: 2, Exact: true}},
+		{"\"x\"  \"y\"\n 1.5  2\n", Shape{Headings: []string{"x", "y"}, Comma: " ", Cols: 2, Rows: 1, Exact: true}},
+		{"1;2\r\n3;4\r\n5;6", Shape{Comma: ";", Cols: 2, Rows: 3, Exact: true}},
+		{"t\tv\n0\t1.5e3\n", Shape{Headings: []string{"t", "v"}, Comma: "\t", Cols: 2, Rows: 1, Exact: true}},
+	} {
+		got, err := Probe(strings.NewReader(test.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*got, test.shape) {
+			t.Errorf("%q: got %+v, want %+v", test.text, *got, test.shape)
+		}
+	}
+
+	// A file larger than the sample gives an estimate and keeps its offset.
+	var buf bytes.Buffer
+	buf.WriteString("a,b\n")
+	for i := 0; i < 20000; i++ {
+		buf.WriteString("1.25,2.5\n")
+	}
+	rs := bytes.NewReader(buf.Bytes())
+	got, err := Probe(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Exact || got.Rows < 19000 || got.Rows > 21000 || got.Cols != 2 {
+		t.Errorf("got %+v for 20000 rows", *got)
+	}
+	if off, _ := rs.Seek(0, io.SeekCurrent); off != 0 {
+		t.Errorf("offset %d after Probe", off)
+	}
+}
+
+func TestReorderTo(t *testing.T) {
+	ref := []string{"x", "y", "z"}
+	want := mat64.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	for _, text := range []string{
+		"x,y,z\n1,2,3\n4,5,6\n",
+		"z,extra,x,y\n3,0,1,2\n6,0,4,5\n",
+	} {
+		r := NewReader(strings.NewReader(text))
+		if err := r.ReorderTo(ref); err != nil {
+			t.Fatal(err)
+		}
+		d, err := r.ReadDataset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(d.Headings, ref) || !want.Equals(d.Data) {
+			t.Errorf("%q: got %v %v", text, d.Headings, d.Data)
+		}
+	}
+
+	// The typed readers and Unmarshal reorder the columns as ReadAll does.
+	const text = "z,extra,x,y\n3,0,1,2\n6,0,4,5\n"
+	open := func() *Reader {
+		r := NewReader(strings.NewReader(text))
+		if err := r.ReorderTo(ref); err != nil {
+			t.Fatal(err)
+		}
+		if headings, err := r.ReadHeading(); err != nil || !reflect.DeepEqual(headings, ref) {
+			t.Fatalf("headings %v, %v", headings, err)
+		}
+		return r
+	}
+	m32, err := open().ReadAll32()
+	if err != nil || !reflect.DeepEqual(m32.Data, []float32{1, 2, 3, 4, 5, 6}) || m32.Cols != 3 {
+		t.Errorf("ReadAll32: got %v, %v", m32, err)
+	}
+	mc, err := open().ReadAllComplex()
+	if err != nil || !reflect.DeepEqual(mc.Data, []complex128{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("ReadAllComplex: got %v, %v", mc, err)
+	}
+	r := open()
+	r.Kinds = map[int]Kind{0: Int}
+	data, typed, err := r.ReadAllTyped()
+	if err != nil || !want.Equals(data) || !reflect.DeepEqual(typed.Ints[0], []int64{1, 4}) {
+		t.Errorf("ReadAllTyped: got %v %v, %v", data, typed, err)
+	}
+	type row struct {
+		X int `csv:"x"`
+		Y int `csv:"y"`
+		Z int `csv:"z"`
+	}
+	var rows []row
+	r = NewReader(strings.NewReader(text))
+	r.ReorderTo(ref)
+	if err := r.Unmarshal(&rows); err != nil || !reflect.DeepEqual(rows, []row{{1, 2, 3}, {4, 5, 6}}) {
+		t.Errorf("Unmarshal: got %v, %v", rows, err)
+	}
+
+	r = NewReader(strings.NewReader("x,z\n1,3\n"))
+	r.ReadHeading()
+	if err := r.ReorderTo(ref); err == nil {
+		t.Error("no error for a missing column")
+	} else if _, ok := err.(*ColumnError); !ok {
+		t.Errorf("got error %v, want a *ColumnError", err)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	features := &Dataset{
+		Headings: []string{"id", "x", "v"},
+		Data:     mat64.NewDense(3, 3, []float64{1, 10, 0.1, 2, 20, 0.2, 3, 30, 0.3}),
+	}
+	labels := &Dataset{
+		Headings: []string{"v", "id"},
+		Data:     mat64.NewDense(3, 2, []float64{-1, 3, -2, 1, -4, 4}),
+	}
+	nan := math.NaN()
+	for _, test := range []struct {
+		how  JoinKind
+		want []float64
+	}{
+		{InnerJoin, []float64{1, 10, 0.1, -2, 3, 30, 0.3, -1}},
+		{LeftJoin, []float64{1, 10, 0.1, -2, 2, 20, 0.2, nan, 3, 30, 0.3, -1}},
+		{OuterJoin, []float64{1, 10, 0.1, -2, 2, 20, 0.2, nan, 3, 30, 0.3, -1, 4, nan, nan, -4}},
+	} {
+		got, err := Join(features, labels, "id", test.how)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Headings, []string{"id", "x", "v", "v_2"}) {
+			t.Errorf("join %v: got headings %v", test.how, got.Headings)
+		}
+		want := mat64.NewDense(len(test.want)/4, 4, test.want)
+		if !equalNaN(want, got.Data) {
+			t.Errorf("join %v: got %v, want %v", test.how, got.Data, want)
+		}
+	}
+	if _, err := Join(features, labels, "missing", InnerJoin); err == nil {
+		t.Error("no error for a missing key column")
+	}
+
+	// An inner join with no matches has the headings and no rows, and
+	// round-trips through CSV and gob.
+	other := &Dataset{Headings: []string{"id", "w"}, Data: mat64.NewDense(1, 2, []float64{9, 1})}
+	empty, err := Join(features, other, "id", InnerJoin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Data != nil || !reflect.DeepEqual(empty.Headings, []string{"id", "x", "v", "w"}) {
+		t.Fatalf("empty join: got headings %v, data %v", empty.Headings, empty.Data)
+	}
+	var buf bytes.Buffer
+	if _, err := empty.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "id,x,v,w\n" {
+		t.Errorf("empty join written as %q", buf.String())
+	}
+	buf.Reset()
+	if err := empty.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeDataset(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Data != nil || !reflect.DeepEqual(decoded.Headings, empty.Headings) {
+		t.Errorf("empty join decoded as headings %v, data %v", decoded.Headings, decoded.Data)
+	}
+}
+
+// equalNaN reports whether the matrices are equal, treating NaNs as equal.
+func equalNaN(a, b *mat64.Dense) bool {
+	ra, ca := a.Dims()
+	rb, cb := b.Dims()
+	if ra != rb || ca != cb {
+		return false
+	}
+	for i := 0; i < ra; i++ {
+		for j := 0; j < ca; j++ {
+			x, y := a.At(i, j), b.At(i, j)
+			if x != y && !(math.IsNaN(x) && math.IsNaN(y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestAddFeatures(t *testing.T) {
+	d := &Dataset{
+		Headings: []string{"t", "x"},
+		Data:     mat64.NewDense(4, 2, []float64{0, 1, 1, 2, 2, 4, 3, 8}),
+	}
+	got, err := d.AddFeatures(Feature{Column: "x", Lags: []int{1}, Windows: []int{2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Headings, []string{"t", "x", "x_lag1", "x_mean2", "x_std2"}) {
+		t.Errorf("got headings %v", got.Headings)
+	}
+	nan := math.NaN()
+	want := mat64.NewDense(4, 5, []float64{
+		0, 1, nan, nan, nan,
+		1, 2, 1, 1.5, math.Sqrt(0.5),
+		2, 4, 2, 3, math.Sqrt(2),
+		3, 8, 4, 6, math.Sqrt(8),
+	})
+	if !equalNaN(want, got.Data) {
+		t.Errorf("got %v, want %v", got.Data, want)
+	}
+	if _, err := d.AddFeatures(Feature{Column: "y", Lags: []int{1}}); err == nil {
+		t.Error("no error for an unknown column")
+	}
+}
+
+func TestWriterFormat(t *testing.T) {
+	data := mat64.NewDense(2, 2, []float64{1, 0.5, 2, math.NaN()})
+	for _, test := range []struct {
+		format Format
+		want   string
+	}{
+		{Markdown, "| n | loss_avg |\n| ---: | ---: |\n| 1 | 0.5 |\n| 2 | - |\n"},
+		{LaTeX, "\\begin{tabular}{rr}\n\\hline\nn & loss\\_avg \\\\\n\\hline\n1 & 0.5 \\\\\n2 & - \\\\\n\\hline\n\\end{tabular}\n"},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.Format = test.format
+		w.FloatFmt = 'g'
+		w.Prec = 3
+		w.NaN = "-"
+		if err := w.WriteAll([]string{"n", "loss_avg"}, data); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("format %v: got\n%s\nwant\n%s", test.format, buf.String(), test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Format = Markdown
+	w.FloatFmt = 'g'
+	w.Prec = 3
+	if err := w.WriteAll32([]string{"a", "b"}, NewMatrix32(1, 2, []float32{1, 0.5})); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteAllComplex(nil, NewComplexMatrix(1, 2, []complex128{1 + 2i, 0.5 - 1i})); err != nil {
+		t.Fatal(err)
+	}
+	want := "| a | b |\n| ---: | ---: |\n| 1 | 0.5 |\n| 1+2i | 0.5-1i |\n"
+	if buf.String() != want {
+		t.Errorf("float32 and complex rows: got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDecimate(t *testing.T) {
+	const text = "1,10\n2,30\n3,20\n4,0\n5,5\n"
+	for _, test := range []struct {
+		agg  Aggregation
+		want []float64
+	}{
+		{AggFirst, []float64{1, 10, 3, 20, 5, 5}},
+		{AggMean, []float64{1.5, 20, 3.5, 10, 5, 5}},
+		{AggMin, []float64{1, 10, 3, 0, 5, 5}},
+		{AggMax, []float64{2, 30, 4, 20, 5, 5}},
+	} {
+		r := NewReader(strings.NewReader(text))
+		r.NoHeading = true
+		r.Decimate = 2
+		r.Aggregate = test.agg
+		r.Parallelism = 4
+		got, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := mat64.NewDense(3, 2, test.want); !want.Equals(got) {
+			t.Errorf("aggregation %v: got %v, want %v", test.agg, got, want)
+		}
+	}
+}
+
+func TestWriteColumns(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	if err := w.WriteColumns([]string{"a", "b"}, [][]float64{{1, 2, 3}, {4, 5, 6}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,b\n1,4\n2,5\n3,6\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+	if err := w.WriteColumns(nil, [][]float64{{1, 2}, {3}}); err != ErrColumnLength {
+		t.Errorf("got error %v, want %v", err, ErrColumnLength)
+	}
+	if err := w.WriteColumns([]string{"a"}, [][]float64{{1}, {2}}); err != ErrDatasetShape {
+		t.Errorf("got error %v, want %v", err, ErrDatasetShape)
+	}
+}
+
+func TestSafeWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	s := NewSafeWriter(w)
+	s.WriteHeading([]string{"worker", "i", "check"})
+	const workers, rows = 8, 500
+	var wg sync.WaitGroup
+	for g := 0; g < workers; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			var b *RowBuffer
+			if g%2 == 1 {
+				b = s.NewRowBuffer(7)
+			}
+			for i := 0; i < rows; i++ {
+				record := []float64{float64(g), float64(i), float64(g*rows + i)}
+				if b != nil {
+					b.Write(record)
+				} else {
+					s.Write(record)
+				}
+			}
+			if b != nil {
+				b.Flush()
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(&buf)
+	r.ReadHeading()
+	data, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := data.Dims()
+	if n != workers*rows {
+		t.Fatalf("got %d rows, want %d", n, workers*rows)
+	}
+	for i := 0; i < n; i++ {
+		if data.At(i, 0)*rows+data.At(i, 1) != data.At(i, 2) {
+			t.Fatalf("corrupted row %v", data.RowView(i))
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := mat64.NewDense(2, 2, []float64{1, 100, math.NaN(), 4})
+	b := mat64.NewDense(2, 2, []float64{1.001, 100.5, math.NaN(), 4})
+	c, err := Compare(a, b, 0.01, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Equal() || c.Differ != 1 || *c.First != (Cell{Row: 0, Col: 1, A: 100, B: 100.5}) {
+		t.Errorf("got %+v, first %+v", c, c.First)
+	}
+	if math.Abs(c.MaxAbs[0]-0.001) > 1e-12 || math.Abs(c.MeanAbs[1]-0.25) > 1e-12 {
+		t.Errorf("got deviations %v %v", c.MaxAbs, c.MeanAbs)
+	}
+	if c, _ := Compare(a, b, 0.01, 0.01); !c.Equal() {
+		t.Errorf("values not within relative tolerance: %s", c.Report([]string{"x", "y"}))
+	}
+	if _, err := Compare(a, mat64.NewDense(1, 2, nil), 0, 0); err != ErrShapeMismatch {
+		t.Errorf("got error %v, want %v", err, ErrShapeMismatch)
+	}
+}
@@ -0,0 +1,164 @@
+package numcsv
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// parallelBatch is the number of lines handed to a parsing goroutine at once.
+const parallelBatch = 512
+
+// lineBatch is a run of consecutive lines. bytes is the number of bytes
+// consumed from the underlying reader once the batch was read.
+type lineBatch struct {
+	seq   int
+	lines []string
+	bytes int64
+}
+
+type parsedBatch struct {
+	seq   int
+	rows  [][]float64
+	bytes int64
+	err   error
+}
+
+// readAllParallel is ReadAll with one goroutine reading lines and r.Parallelism
+// goroutines parsing them. The batches are reassembled in the order read, and
+// errors are reported for the earliest bad record as in the serial case.
+func (r *Reader) readAllParallel(ctx context.Context, interval int64) (*mat64.Dense, error) {
+	// The first record is read here since it sets FieldsPerRecord.
+	first, err := r.read()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return denseFromRows(nil, r.recordLen()), &PartialError{Bytes: r.reader.n, Err: ctxErr}
+		}
+		return nil, err
+	}
+	if first == nil {
+		return denseFromRows(nil, r.recordLen()), nil
+	}
+
+	bytes := r.reader.n
+	done := make(chan struct{})
+	jobs := make(chan lineBatch, r.Parallelism)
+	results := make(chan parsedBatch, r.Parallelism)
+	var readErr error
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			lines := make([]string, 0, parallelBatch)
+			for len(lines) < parallelBatch {
+				line, ok, err := r.readLine()
+				if !ok {
+					readErr = err
+					break
+				}
+				lines = append(lines, line)
+			}
+			if len(lines) == 0 {
+				return
+			}
+			select {
+			case jobs <- lineBatch{seq: seq, lines: lines, bytes: r.reader.n}:
+			case <-done:
+				return
+			}
+			if len(lines) < parallelBatch {
+				return
+			}
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for i := 0; i < r.Parallelism; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				res := parsedBatch{seq: job.seq, bytes: job.bytes, rows: make([][]float64, 0, len(job.lines))}
+				for _, line := range job.lines {
+					var row []float64
+					strs, err := r.splitFields(line)
+					if err == nil {
+						row, err = r.parseFields(strs)
+					}
+					if err == errSkip {
+						continue
+					}
+					if err != nil {
+						res.err = err
+						break
+					}
+					res.rows = append(res.rows, row)
+				}
+				select {
+				case results <- res:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	alldata := [][]float64{first}
+	count := int64(1)
+	pending := make(map[int]parsedBatch)
+	next := 0
+	err = nil
+loop:
+	for res := range results {
+		pending[res.seq] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if ctx.Err() != nil {
+				break loop
+			}
+			for _, row := range res.rows {
+				alldata = append(alldata, row)
+				count++
+				if r.Progress != nil && count%interval == 0 {
+					r.Progress(res.bytes, count)
+				}
+			}
+			bytes = res.bytes
+			if res.err != nil {
+				err = res.err
+				break loop
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+	for range results {
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return denseFromRows(alldata, r.recordLen()), &PartialError{Rows: count, Bytes: bytes, Err: ctxErr}
+	}
+	if err == nil {
+		err = readErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Progress != nil {
+		r.Progress(r.reader.n, count)
+	}
+	return denseFromRows(alldata, r.recordLen()), nil
+}
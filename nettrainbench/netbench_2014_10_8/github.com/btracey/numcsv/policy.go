@@ -0,0 +1,97 @@
+package numcsv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// ValuePolicy is the handling of a value that is non-finite or out of bounds.
+type ValuePolicy int
+
+const (
+	Keep    ValuePolicy = iota // Use the value as read
+	Fail                       // Return a *ValueError
+	Skip                       // Drop the record
+	Clamp                      // Limit the value to the bounds, or ±math.MaxFloat64. A NaN is kept
+	Replace                    // Use Reader.Replacement instead
+)
+
+// Bounds is the closed range of allowed values of a column.
+type Bounds struct {
+	Min, Max float64
+}
+
+// ValueCounts is the number of values to which the NonFinite and OutOfRange
+// policies applied, and the number of records skipped because of them.
+type ValueCounts struct {
+	NonFinite  int64
+	OutOfRange int64
+	Skipped    int64
+}
+
+// ValueError is returned for a value rejected by the Fail policy.
+type ValueError struct {
+	Col   int
+	Value float64
+}
+
+func (v *ValueError) Error() string {
+	if math.IsInf(v.Value, 0) || math.IsNaN(v.Value) {
+		return fmt.Sprintf("numcsv: non-finite value %v in column %d", v.Value, v.Col)
+	}
+	return fmt.Sprintf("numcsv: value %v in column %d out of range", v.Value, v.Col)
+}
+
+// errSkip is returned by parseFields for a record dropped by the Skip policy.
+var errSkip = errors.New("numcsv: skip record")
+
+// ValueCounts returns the counts of the values handled by the NonFinite and
+// OutOfRange policies so far. Nothing is counted if both policies are Keep.
+func (r *Reader) ValueCounts() ValueCounts {
+	return ValueCounts{
+		NonFinite:  atomic.LoadInt64(&r.counts.NonFinite),
+		OutOfRange: atomic.LoadInt64(&r.counts.OutOfRange),
+		Skipped:    atomic.LoadInt64(&r.counts.Skipped),
+	}
+}
+
+// checkValues applies the value policies to a record in place, given the
+// fields it was parsed from, clamping unbounded infinities to ±largest. A NaN
+// read from the NaN token is a missing value rather than non-finite, and a
+// NaN cannot be clamped, so Clamp keeps it. It is safe for concurrent use.
+func (r *Reader) checkValues(data []float64, strs []string, largest float64) error {
+	if r.NonFinite == Keep && r.OutOfRange == Keep {
+		return nil
+	}
+	for j, v := range data {
+		b, bounded := r.Bounds[j]
+		var policy ValuePolicy
+		switch {
+		case math.IsNaN(v) && (r.NaN == "" || strs[j] != r.NaN), math.IsInf(v, 0):
+			atomic.AddInt64(&r.counts.NonFinite, 1)
+			policy = r.NonFinite
+			if !bounded {
+				b = Bounds{-largest, largest}
+			}
+		case bounded && (v < b.Min || v > b.Max):
+			atomic.AddInt64(&r.counts.OutOfRange, 1)
+			policy = r.OutOfRange
+		default:
+			continue
+		}
+		switch policy {
+		case Fail:
+			return &ValueError{Col: j, Value: v}
+		case Skip:
+			atomic.AddInt64(&r.counts.Skipped, 1)
+			return errSkip
+		case Clamp:
+			data[j] = math.Max(b.Min, math.Min(b.Max, v))
+		case Replace:
+			data[j] = r.Replacement
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package numcsv
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// probeLen is the amount of input read by Probe.
+const probeLen = 64 << 10
+
+// Shape is the layout of a file as found by Probe.
+type Shape struct {
+	Headings []string // nil if the first line is numeric
+	Comma    string   // Detected delimiter
+	Cols     int
+	Rows     int64 // Number of records, estimated unless Exact
+	Exact    bool  // Rows is exact since the whole input was read
+}
+
+// probeCommas are the delimiters tried by Probe in order of preference.
+var probeCommas = []string{",", "\t", ";", "|", " "}
+
+// Probe reads the start of a file to find its delimiter, headings and number
+// of columns without loading the data. If the whole input fits in the sample
+// the number of records is exact. Otherwise it is estimated from the average
+// line length, which needs r to be an io.Seeker (as os.File is) to find the
+// size; r is left at its original offset. Without a size Rows is zero.
+func Probe(r io.Reader) (*Shape, error) {
+	size := int64(-1)
+	if s, ok := r.(io.Seeker); ok {
+		cur, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			if end, err := s.Seek(0, io.SeekEnd); err == nil {
+				size = end - cur
+			}
+			if _, err := s.Seek(cur, io.SeekStart); err != nil {
+				return nil, err
+			}
+			defer s.Seek(cur, io.SeekStart)
+		}
+	}
+	sample := make([]byte, probeLen)
+	n, err := io.ReadFull(r, sample)
+	sample = sample[:n]
+	complete := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !complete {
+		return nil, err
+	}
+
+	lines := strings.Split(string(sample), "\n")
+	if !complete || lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1] // drop the partial or empty last line
+	}
+	var content []string
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) != "" {
+			content = append(content, line)
+		}
+	}
+	shape := &Shape{Comma: ","}
+	if len(content) == 0 {
+		shape.Exact = complete
+		return shape, nil
+	}
+
+	shape.Comma = detectComma(content)
+	fields := probeFields(content[0], shape.Comma)
+	shape.Cols = len(fields)
+	numeric := true
+	for _, f := range fields {
+		if _, err := strconv.ParseFloat(f, 64); err != nil {
+			numeric = false
+			break
+		}
+	}
+	records := int64(len(content))
+	if !numeric {
+		shape.Headings = make([]string, len(fields))
+		for i, f := range fields {
+			shape.Headings[i] = strings.Trim(f, "\"")
+		}
+		records--
+	}
+
+	switch {
+	case complete:
+		shape.Rows, shape.Exact = records, true
+	case size > 0:
+		used := 0
+		for _, line := range lines {
+			used += len(line) + 1
+		}
+		shape.Rows = int64(float64(records) * float64(size) / float64(used))
+	}
+	return shape, nil
+}
+
+// detectComma returns the delimiter splitting the lines into the same number
+// of fields, preferring the one giving the most.
+func detectComma(lines []string) string {
+	best, bestCols := ",", 1
+	for _, comma := range probeCommas {
+		cols := len(probeFields(lines[0], comma))
+		if cols <= bestCols {
+			continue
+		}
+		consistent := true
+		for _, line := range lines[1:] {
+			if len(probeFields(line, comma)) != cols {
+				consistent = false
+				break
+			}
+		}
+		if consistent {
+			best, bestCols = comma, cols
+		}
+	}
+	return best
+}
+
+// probeFields splits the line like the Reader, dropping empty fields.
+func probeFields(line, comma string) []string {
+	var fields []string
+	for _, f := range strings.Split(line, comma) {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
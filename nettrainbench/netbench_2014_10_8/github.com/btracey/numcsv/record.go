@@ -0,0 +1,130 @@
+package numcsv
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFieldType    = errors.New("numcsv: unsupported field type")
+	ErrColumnLength = errors.New("numcsv: columns have different lengths")
+)
+
+// WriteRecord writes a record of mixed fields. Floats are formatted as in
+// Write, integers and booleans with strconv, and strings as they are, quoted
+// if they contain the delimiter, a quote or a line break. Other types give
+// ErrFieldType.
+func (w *Writer) WriteRecord(fields []interface{}) error {
+	dst := w.appendRowStart(w.scratch[:0], len(fields))
+	sep := w.separator()
+	for n, field := range fields {
+		if n > 0 {
+			dst = append(dst, sep...)
+		}
+		switch v := field.(type) {
+		case float64:
+			if w.NaN != "" && math.IsNaN(v) {
+				dst = append(dst, w.NaN...)
+				continue
+			}
+			dst = strconv.AppendFloat(dst, v, w.FloatFmt, w.Prec, 64)
+		case float32:
+			if w.NaN != "" && v != v {
+				dst = append(dst, w.NaN...)
+				continue
+			}
+			dst = strconv.AppendFloat(dst, float64(v), w.FloatFmt, 8, 32)
+		case int:
+			dst = strconv.AppendInt(dst, int64(v), 10)
+		case int8:
+			dst = strconv.AppendInt(dst, int64(v), 10)
+		case int16:
+			dst = strconv.AppendInt(dst, int64(v), 10)
+		case int32:
+			dst = strconv.AppendInt(dst, int64(v), 10)
+		case int64:
+			dst = strconv.AppendInt(dst, v, 10)
+		case uint:
+			dst = strconv.AppendUint(dst, uint64(v), 10)
+		case uint8:
+			dst = strconv.AppendUint(dst, uint64(v), 10)
+		case uint16:
+			dst = strconv.AppendUint(dst, uint64(v), 10)
+		case uint32:
+			dst = strconv.AppendUint(dst, uint64(v), 10)
+		case uint64:
+			dst = strconv.AppendUint(dst, v, 10)
+		case bool:
+			dst = strconv.AppendBool(dst, v)
+		case string:
+			if w.Format != CSV {
+				dst = append(dst, w.escape(v)...)
+				continue
+			}
+			if strings.Contains(v, w.Comma) || strings.ContainsAny(v, "\"\r\n") {
+				dst = append(dst, '"')
+				dst = append(dst, strings.Replace(v, "\"", "\"\"", -1)...)
+				dst = append(dst, '"')
+				continue
+			}
+			dst = append(dst, v...)
+		default:
+			w.scratch = dst
+			return ErrFieldType
+		}
+	}
+	dst = w.appendRowEnd(dst)
+	w.scratch = dst
+	_, err := w.w.Write(dst)
+	return err
+}
+
+// Flush writes any buffered data to the underlying writer. The WriteAll
+// methods flush when done, but after Write or WriteRecord it must be called.
+// For the LaTeX format Flush ends the table.
+func (w *Writer) Flush() error {
+	if w.tableOpen {
+		w.tableOpen = false
+		w.w.WriteString(`\hline`)
+		w.endLine()
+		w.w.WriteString(`\end{tabular}`)
+		w.endLine()
+	}
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return w.al.flush()
+}
+
+// WriteColumns writes the headings (if non-nil) and the rows formed by the
+// columns, and flushes the Writer. The columns must have equal lengths.
+func (w *Writer) WriteColumns(headings []string, cols [][]float64) error {
+	if headings != nil && len(headings) != len(cols) {
+		return ErrDatasetShape
+	}
+	var rows int
+	for j, col := range cols {
+		if j == 0 {
+			rows = len(col)
+		} else if len(col) != rows {
+			return ErrColumnLength
+		}
+	}
+	if headings != nil {
+		if err := w.WriteHeading(headings); err != nil {
+			return err
+		}
+	}
+	record := make([]float64, len(cols))
+	for i := 0; i < rows; i++ {
+		for j, col := range cols {
+			record[j] = col[i]
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,204 @@
+package numcsv
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CacheDir is the directory in which Open caches remote files. If "", a
+// numcsv directory in os.UserCacheDir is used.
+var CacheDir string
+
+// HTTPClient is the client used by Open to fetch remote files.
+var HTTPClient = http.DefaultClient
+
+// cacheMeta records the validators of a cached download.
+type cacheMeta struct {
+	URL  string
+	ETag string
+	Size int64
+}
+
+// Open returns a Reader of the named file or URL, as opened by OpenStream.
+func Open(name string) (*FileReader, error) {
+	rc, err := OpenStream(name)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReader{Reader: NewReader(rc), c: rc}, nil
+}
+
+// OpenStream opens the named file for reading. Names with an http:// or
+// https:// scheme are downloaded into CacheDir on first use; later calls
+// revalidate the cached copy with the server using its ETag, or its size if
+// the server gives no ETag, and only download it again if it has changed. If
+// the server cannot be reached or responds with an error the cached copy is
+// used. Names with a scheme registered in ObjectStores, such as s3:// and
+// gs:// when built with the cloud tag, are streamed without a local copy.
+// Other names are opened as local files. Files whose names end in ".gz" are
+// decompressed as they are read.
+func OpenStream(name string) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	var err error
+	switch scheme := urlScheme(name); {
+	case scheme == "http" || scheme == "https":
+		path, err := fetch(name)
+		if err != nil {
+			return nil, err
+		}
+		if rc, err = os.Open(path); err != nil {
+			return nil, err
+		}
+	case scheme != "":
+		open, ok := ObjectStores[scheme]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", name, ErrScheme)
+		}
+		if rc, err = open(name); err != nil {
+			return nil, err
+		}
+	default:
+		if rc, err = os.Open(name); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(rc)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		rc = &gzipCloser{zr, rc}
+	}
+	return rc, nil
+}
+
+// ErrScheme is wrapped by the error of Open for a URL scheme with no
+// registered store.
+var ErrScheme = errors.New("numcsv: unsupported URL scheme")
+
+// ObjectStores maps URL schemes to functions opening objects for streaming.
+// Building with the cloud tag registers "s3" and "gs".
+var ObjectStores = map[string]func(url string) (io.ReadCloser, error){}
+
+// urlScheme returns the scheme of name, or "" if it is not a URL.
+func urlScheme(name string) string {
+	i := strings.Index(name, "://")
+	if i <= 0 {
+		return ""
+	}
+	return name[:i]
+}
+
+// gzipCloser closes both the decompressor and the underlying stream.
+type gzipCloser struct {
+	*gzip.Reader
+	rc io.Closer
+}
+
+func (g *gzipCloser) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.rc.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// fetch returns the path of an up-to-date cached copy of url.
+func fetch(url string) (string, error) {
+	dir := CacheDir
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, "numcsv")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:]))
+	metaPath := path + ".json"
+
+	var meta cacheMeta
+	cached := false
+	if b, err := ioutil.ReadFile(metaPath); err == nil && json.Unmarshal(b, &meta) == nil {
+		if info, err := os.Stat(path); err == nil && info.Size() == meta.Size {
+			cached = true
+		}
+	}
+
+	if cached && meta.ETag == "" {
+		// Revalidate by size.
+		resp, err := HTTPClient.Head(url)
+		if err != nil {
+			return path, nil
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK && resp.ContentLength == meta.Size {
+			return path, nil
+		}
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	if cached && meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		if cached {
+			return path, nil
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return path, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		if cached {
+			return path, nil
+		}
+		return "", fmt.Errorf("numcsv: fetching %s: %s", url, resp.Status)
+	}
+
+	// Download to a temporary file so that an interrupted fetch does not
+	// leave a truncated file in the cache.
+	tmp, err := ioutil.TempFile(dir, "download")
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(tmp, resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && resp.ContentLength >= 0 && n != resp.ContentLength {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	b, err := json.Marshal(cacheMeta{URL: url, ETag: resp.Header.Get("ETag"), Size: n})
+	if err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(metaPath, b, 0644)
+}
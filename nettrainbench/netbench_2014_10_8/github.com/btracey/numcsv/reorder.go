@@ -0,0 +1,51 @@
+package numcsv
+
+// ReorderTo makes every read of records, such as Read, ReadAll, ReadAll32,
+// ReadAllComplex, ReadAllTyped and Unmarshal, return the columns in the order
+// of the reference headings, so files with the same columns in different
+// orders give the same layout. Columns of the file not in ref are dropped. The
+// returned headings are ref, and Bounds and Kinds refer to the reordered
+// columns. A
+// *ColumnError is returned, here or by ReadHeading, if the file is missing a
+// column of ref.
+func (r *Reader) ReorderTo(ref []string) error {
+	r.reorderRef = append([]string(nil), ref...)
+	if !r.headingRead {
+		return nil
+	}
+	return r.setOrder()
+}
+
+// setOrder finds the file column of each reference heading.
+func (r *Reader) setOrder() error {
+	order := make([]int, len(r.reorderRef))
+	for k, name := range r.reorderRef {
+		j, err := columnIndex(r.headings, name)
+		if err != nil {
+			return err
+		}
+		order[k] = j
+	}
+	r.order = order
+	return nil
+}
+
+// reorder returns the fields of a record in the order set by ReorderTo.
+func (r *Reader) reorder(strs []string) []string {
+	if r.order == nil {
+		return strs
+	}
+	ordered := make([]string, len(r.order))
+	for k, j := range r.order {
+		ordered[k] = strs[j]
+	}
+	return ordered
+}
+
+// recordLen returns the number of values in the records returned by Read.
+func (r *Reader) recordLen() int {
+	if r.order != nil {
+		return len(r.order)
+	}
+	return r.FieldsPerRecord
+}
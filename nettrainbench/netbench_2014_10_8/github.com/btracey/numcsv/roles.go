@@ -0,0 +1,84 @@
+package numcsv
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Role is the part a column plays in a supervised learning problem.
+type Role int
+
+const (
+	NoRole Role = iota
+	Input
+	Target
+	Weight // Sample weights. At most one column may have this role.
+)
+
+var ErrWeightColumns = errors.New("numcsv: more than one weight column")
+
+// SetRoles gives the named columns the role.
+func (d *Dataset) SetRoles(role Role, names ...string) {
+	if d.Roles == nil {
+		d.Roles = make(map[string]Role)
+	}
+	for _, name := range names {
+		d.Roles[name] = role
+	}
+}
+
+// Split returns the input and target columns as separate matrices, in heading
+// order, and the sample weights. A return value is nil if no column has that
+// role.
+func (d *Dataset) Split() (inputs, targets *mat64.Dense, weights []float64, err error) {
+	var in, out []int
+	weight := -1
+	for name, role := range d.Roles {
+		if role == NoRole {
+			continue
+		}
+		j, err := columnIndex(d.Headings, name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		switch role {
+		case Input:
+			in = append(in, j)
+		case Target:
+			out = append(out, j)
+		case Weight:
+			if weight >= 0 {
+				return nil, nil, nil, ErrWeightColumns
+			}
+			weight = j
+		}
+	}
+	inputs = selectColumns(d.Data, in)
+	targets = selectColumns(d.Data, out)
+	if weight >= 0 {
+		rows, _ := d.Dims()
+		weights = make([]float64, rows)
+		for i := range weights {
+			weights[i] = d.Data.At(i, weight)
+		}
+	}
+	return inputs, targets, weights, nil
+}
+
+// selectColumns copies the columns of data, sorted by index, into a new matrix.
+func selectColumns(data *mat64.Dense, cols []int) *mat64.Dense {
+	if len(cols) == 0 {
+		return nil
+	}
+	sort.Ints(cols)
+	rows, _ := data.Dims()
+	m := mat64.NewDense(rows, len(cols), nil)
+	for i := 0; i < rows; i++ {
+		for k, j := range cols {
+			m.Set(i, k, data.At(i, j))
+		}
+	}
+	return m
+}
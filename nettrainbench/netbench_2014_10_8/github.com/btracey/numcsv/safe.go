@@ -0,0 +1,81 @@
+package numcsv
+
+import "sync"
+
+// SafeWriter is a Writer that may be used from several goroutines. Each record
+// is written whole, so rows from different goroutines never interleave.
+type SafeWriter struct {
+	mu sync.Mutex
+	w  *Writer
+}
+
+// NewSafeWriter returns a SafeWriter writing through w. w must not be used
+// directly while the SafeWriter is in use.
+func NewSafeWriter(w *Writer) *SafeWriter {
+	return &SafeWriter{w: w}
+}
+
+func (s *SafeWriter) WriteHeading(heading []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.WriteHeading(heading)
+}
+
+func (s *SafeWriter) Write(record []float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(record)
+}
+
+func (s *SafeWriter) WriteRecord(fields []interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.WriteRecord(fields)
+}
+
+// Flush writes the buffered data of the Writer. Rows held by RowBuffers are
+// not included.
+func (s *SafeWriter) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Flush()
+}
+
+// RowBuffer collects rows formatted by one goroutine and passes them to the
+// SafeWriter in groups, so the lock is taken once per group rather than once
+// per row. A RowBuffer must not be shared between goroutines. For the LaTeX
+// format the heading must be written before rows are buffered.
+type RowBuffer struct {
+	s    *SafeWriter
+	rows int
+	n    int
+	buf  []byte
+}
+
+// NewRowBuffer returns a buffer passing rows to s every rows rows.
+func (s *SafeWriter) NewRowBuffer(rows int) *RowBuffer {
+	return &RowBuffer{s: s, rows: rows}
+}
+
+// Write adds a record to the buffer.
+func (b *RowBuffer) Write(record []float64) error {
+	b.buf = b.s.w.appendRecord(b.buf, record)
+	b.n++
+	if b.n >= b.rows {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush passes the buffered rows to the SafeWriter.
+func (b *RowBuffer) Flush() error {
+	if b.n == 0 {
+		return nil
+	}
+	b.s.mu.Lock()
+	_, err := b.s.w.w.Write(b.buf)
+	b.s.mu.Unlock()
+	b.buf = b.buf[:0]
+	b.n = 0
+	return err
+}
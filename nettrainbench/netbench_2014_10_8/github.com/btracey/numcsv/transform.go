@@ -0,0 +1,101 @@
+package numcsv
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ColumnError is returned when a column is referenced by a name that is not
+// in the headings.
+type ColumnError struct {
+	Name string
+}
+
+func (c *ColumnError) Error() string {
+	return "numcsv: no column named " + strconv.Quote(c.Name)
+}
+
+// columnIndex returns the index of the named column.
+func columnIndex(headings []string, name string) (int, error) {
+	for i, h := range headings {
+		if h == name {
+			return i, nil
+		}
+	}
+	return -1, &ColumnError{Name: name}
+}
+
+// A Transform modifies a column of data in place.
+type Transform func(col []float64)
+
+// Chain returns a Transform applying each of the transforms in order.
+func Chain(ts ...Transform) Transform {
+	return func(col []float64) {
+		for _, t := range ts {
+			t(col)
+		}
+	}
+}
+
+// Log replaces each value with its natural logarithm.
+func Log(col []float64) {
+	for i, v := range col {
+		col[i] = math.Log(v)
+	}
+}
+
+// Clip returns a Transform limiting the values to [min, max].
+func Clip(min, max float64) Transform {
+	return func(col []float64) {
+		for i, v := range col {
+			col[i] = math.Max(min, math.Min(max, v))
+		}
+	}
+}
+
+// Standardize shifts and scales the column to have mean zero and variance one,
+// using the same (population) variance as the reggo Normal scaler. A constant
+// column is only shifted.
+func Standardize(col []float64) {
+	if len(col) == 0 {
+		return
+	}
+	var mean float64
+	for _, v := range col {
+		mean += v
+	}
+	mean /= float64(len(col))
+	var variance float64
+	for _, v := range col {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(col)))
+	if std == 0 {
+		std = 1
+	}
+	for i, v := range col {
+		col[i] = (v - mean) / std
+	}
+}
+
+// applyTransforms runs the transforms on the named columns of data.
+func applyTransforms(transforms map[string]Transform, headings []string, data *mat64.Dense) error {
+	rows, _ := data.Dims()
+	col := make([]float64, rows)
+	for name, t := range transforms {
+		j, err := columnIndex(headings, name)
+		if err != nil {
+			return err
+		}
+		for i := range col {
+			col[i] = data.At(i, j)
+		}
+		t(col)
+		for i, v := range col {
+			data.Set(i, j, v)
+		}
+	}
+	return nil
+}
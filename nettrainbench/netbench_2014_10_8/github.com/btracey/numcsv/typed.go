@@ -0,0 +1,102 @@
+package numcsv
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Kind is the type a column is parsed as.
+type Kind int
+
+const (
+	Float    Kind = iota // float64, the default
+	Int                  // int64
+	BigFloat             // *big.Float with precision BigPrec
+)
+
+// DefaultBigPrec is the mantissa precision in bits used for BigFloat columns
+// when Reader.BigPrec is zero.
+const DefaultBigPrec = 256
+
+// Typed holds the exact values of the non-Float columns read by ReadAllTyped,
+// keyed by column index. Each slice has one entry per row.
+type Typed struct {
+	Ints map[int][]int64
+	Bigs map[int][]*big.Float
+}
+
+// ReadAllTyped reads all of the records like ReadAll, additionally parsing the
+// columns declared in r.Kinds with their exact type. The returned matrix
+// contains every column (Int and BigFloat columns rounded to float64) so it can
+// be used as usual, while the Typed values keep IDs, counters, and high
+// precision values intact. The value policies apply as in ReadAll: a skipped
+// record is dropped from the Typed values too, while Clamp and Replace change
+// only the matrix.
+func (r *Reader) ReadAllTyped() (*mat64.Dense, *Typed, error) {
+	prec := r.BigPrec
+	if prec == 0 {
+		prec = DefaultBigPrec
+	}
+	typed := &Typed{
+		Ints: make(map[int][]int64),
+		Bigs: make(map[int][]*big.Float),
+	}
+	var data []float64
+	var rows int
+	for {
+		strs, err := r.readFields()
+		if err != nil {
+			return nil, nil, err
+		}
+		if strs == nil {
+			break
+		}
+		row := make([]float64, len(strs))
+		for j, str := range strs {
+			str = r.field(str)
+			var v float64
+			switch r.Kinds[j] {
+			case Int:
+				i, err := strconv.ParseInt(str, 10, 64)
+				if err != nil {
+					return nil, nil, err
+				}
+				typed.Ints[j] = append(typed.Ints[j], i)
+				v = float64(i)
+			case BigFloat:
+				b, _, err := big.ParseFloat(str, 10, prec, big.ToNearestEven)
+				if err != nil {
+					return nil, nil, err
+				}
+				typed.Bigs[j] = append(typed.Bigs[j], b)
+				v, _ = b.Float64()
+			default:
+				v, err = strconv.ParseFloat(str, 64)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			row[j] = v
+		}
+		if err := r.checkValues(row, strs, math.MaxFloat64); err == errSkip {
+			for j := range typed.Ints {
+				typed.Ints[j] = typed.Ints[j][:rows]
+			}
+			for j := range typed.Bigs {
+				typed.Bigs[j] = typed.Bigs[j][:rows]
+			}
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, row...)
+		rows++
+	}
+	if data == nil {
+		data = []float64{}
+	}
+	return mat64.NewDense(rows, r.recordLen(), data), typed, nil
+}
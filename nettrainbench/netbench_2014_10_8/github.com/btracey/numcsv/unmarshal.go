@@ -0,0 +1,143 @@
+package numcsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+var ErrUnmarshalType = errors.New("numcsv: Unmarshal destination must be a pointer to a slice of structs")
+
+// Unmarshal reads a CSV with headings from r and appends one element per record
+// to the slice pointed to by dst. See Reader.Unmarshal.
+func Unmarshal(r io.Reader, dst interface{}) error {
+	return NewReader(r).Unmarshal(dst)
+}
+
+// Unmarshal reads the headings and all of the records, decoding each record
+// into a new element of the slice of structs pointed to by dst. Fields are
+// matched to columns by their `csv:"colname"` tag, or by the field name if
+// there is no tag. A tag of "-" skips the field. Fields may be float64, float32,
+// or any integer kind; integer fields accept any integral number, such as
+// "3.0" or "1e3". A tagged field with no matching column is an error, while
+// columns not matching any field are ignored.
+func (r *Reader) Unmarshal(dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice || ptr.Elem().Type().Elem().Kind() != reflect.Struct {
+		return ErrUnmarshalType
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+
+	headings, err := r.ReadHeading()
+	if err != nil {
+		return err
+	}
+
+	// columns[j] is the field index for column j, or nil.
+	columns := make([][]int, len(headings))
+	for i := 0; i < elemType.NumField(); i++ {
+		f := elemType.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name, tagged := f.Tag.Lookup("csv")
+		if name == "-" {
+			continue
+		}
+		if !tagged {
+			name = f.Name
+		}
+		switch f.Type.Kind() {
+		case reflect.Float64, reflect.Float32,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return fmt.Errorf("numcsv: cannot unmarshal into field %s of type %v", f.Name, f.Type)
+		}
+		j, err := columnIndex(headings, name)
+		if err != nil {
+			if tagged {
+				return err
+			}
+			continue
+		}
+		columns[j] = f.Index
+	}
+
+	for line := 1; ; line++ {
+		strs, err := r.readFields()
+		if err != nil {
+			return fmt.Errorf("numcsv: record %d: %v", line, err)
+		}
+		if strs == nil {
+			return nil
+		}
+		elem := reflect.New(elemType).Elem()
+		for j, str := range strs {
+			if columns[j] == nil {
+				continue
+			}
+			if err := setNumeric(elem.FieldByIndex(columns[j]), r.field(str)); err != nil {
+				return fmt.Errorf("numcsv: record %d, column %s: %v", line, headings[j], err)
+			}
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+}
+
+// setNumeric parses str into the numeric field v.
+func setNumeric(v reflect.Value, str string) error {
+	switch v.Kind() {
+	case reflect.Float64, reflect.Float32:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 10, v.Type().Bits())
+		if err != nil {
+			f, ferr := parseIntegral(str)
+			if ferr != nil {
+				return err
+			}
+			i = int64(f)
+			if v.OverflowInt(i) || f != float64(i) {
+				return err
+			}
+		}
+		v.SetInt(i)
+		return nil
+	default:
+		u, err := strconv.ParseUint(str, 10, v.Type().Bits())
+		if err != nil {
+			f, ferr := parseIntegral(str)
+			if ferr != nil || f < 0 {
+				return err
+			}
+			u = uint64(f)
+			if v.OverflowUint(u) || f != float64(u) {
+				return err
+			}
+		}
+		v.SetUint(u)
+		return nil
+	}
+}
+
+// parseIntegral parses a float and checks that it has no fractional part.
+func parseIntegral(str string) (float64, error) {
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || math.IsInf(f, 0) {
+		return 0, errors.New("not integral")
+	}
+	return f, nil
+}
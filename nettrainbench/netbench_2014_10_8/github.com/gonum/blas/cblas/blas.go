@@ -9,7 +9,7 @@ package cblas
 
 /*
 #cgo CFLAGS: -g -O2
-#cgo linux LDFLAGS: -lcblas
+#cgo linux,!openblas,!atlas LDFLAGS: -lcblas
 //#cgo linux LDFLAGS: -lmkl_rt
 //#cgo linux LDFLAGS: -L/path/to/OpenBLAS -lopenblas
 #cgo darwin LDFLAGS: -DYA_BLAS -DYA_LAPACK -DYA_BLASMULT -framework vecLib
@@ -50,7 +50,7 @@ package cblas
 
 /*
 #cgo CFLAGS: -g -O2
-#cgo linux LDFLAGS: -L/usr/lib/ -lcblas
+#cgo linux,!openblas,!atlas LDFLAGS: -L/usr/lib/ -lcblas
 #cgo darwin LDFLAGS: -DYA_BLAS -DYA_LAPACK -DYA_BLASMULT -framework vecLib
 #include "${cblasHeader}"
 */
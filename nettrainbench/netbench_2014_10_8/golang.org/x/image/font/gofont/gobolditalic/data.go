@@ -0,0 +1,9979 @@
+// generated by go run gen.go; DO NOT EDIT
+
+// Package gobolditalic provides the "Go Bold Italic" TrueType font
+// from the Go font family. It is a proportional-width, sans-serif font.
+//
+// See https://blog.golang.org/go-fonts for details.
+package gobolditalic
+
+// TTF is the data for the "Go Bold Italic" TrueType font.
+var TTF = []byte{
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x80, 0x00, 0x03, 0x00, 0x60, 0x4f, 0x53, 0x2f, 0x32,
+	0xc6, 0x75, 0x39, 0xeb, 0x00, 0x00, 0x00, 0xec, 0x00, 0x00, 0x00, 0x60, 0x63, 0x6d, 0x61, 0x70,
+	0xbe, 0x92, 0x2d, 0x51, 0x00, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x05, 0x3e, 0x63, 0x76, 0x74, 0x20,
+	0x4f, 0x65, 0x17, 0x06, 0x00, 0x02, 0x5f, 0x64, 0x00, 0x00, 0x00, 0xb0, 0x66, 0x70, 0x67, 0x6d,
+	0x62, 0x2f, 0x03, 0x7f, 0x00, 0x02, 0x60, 0x14, 0x00, 0x00, 0x0e, 0x0c, 0x67, 0x61, 0x73, 0x70,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x02, 0x5f, 0x5c, 0x00, 0x00, 0x00, 0x08, 0x67, 0x6c, 0x79, 0x66,
+	0xf8, 0x46, 0xb0, 0x7e, 0x00, 0x00, 0x06, 0x8c, 0x00, 0x02, 0x11, 0xea, 0x68, 0x65, 0x61, 0x64,
+	0x19, 0x6c, 0x52, 0xf8, 0x00, 0x02, 0x18, 0x78, 0x00, 0x00, 0x00, 0x36, 0x68, 0x68, 0x65, 0x61,
+	0x10, 0x51, 0x0f, 0x0d, 0x00, 0x02, 0x18, 0xb0, 0x00, 0x00, 0x00, 0x24, 0x68, 0x6d, 0x74, 0x78,
+	0x7c, 0x64, 0x9b, 0x1c, 0x00, 0x02, 0x18, 0xd4, 0x00, 0x00, 0x0b, 0x1e, 0x6c, 0x6f, 0x63, 0x61,
+	0x02, 0xde, 0x5f, 0xae, 0x00, 0x02, 0x23, 0xf4, 0x00, 0x00, 0x0b, 0x24, 0x6d, 0x61, 0x78, 0x70,
+	0x06, 0x46, 0x18, 0x26, 0x00, 0x02, 0x2f, 0x18, 0x00, 0x00, 0x00, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+	0x85, 0xd9, 0x85, 0x7d, 0x00, 0x02, 0x2f, 0x38, 0x00, 0x00, 0x1b, 0x71, 0x70, 0x6f, 0x73, 0x74,
+	0xfc, 0x7b, 0x10, 0xd7, 0x00, 0x02, 0x4a, 0xac, 0x00, 0x00, 0x14, 0xb0, 0x70, 0x72, 0x65, 0x70,
+	0x8e, 0xd0, 0xa0, 0x76, 0x00, 0x02, 0x6e, 0x20, 0x00, 0x00, 0x00, 0xd6, 0x00, 0x03, 0x04, 0xe4,
+	0x02, 0x58, 0x00, 0x05, 0x00, 0x00, 0x05, 0x9a, 0x05, 0x33, 0x00, 0x00, 0x01, 0x1b, 0x05, 0x9a,
+	0x05, 0x33, 0x00, 0x00, 0x03, 0xd1, 0x00, 0x66, 0x02, 0x00, 0x08, 0x02, 0x02, 0x0b, 0x07, 0x03,
+	0x05, 0x00, 0x00, 0x00, 0x00, 0x04, 0xa0, 0x00, 0x02, 0xaf, 0x50, 0x00, 0x78, 0xfb, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x20, 0x20, 0x20, 0x00, 0x21, 0x00, 0x00, 0xff, 0xfd,
+	0x06, 0x2b, 0xfe, 0x75, 0x01, 0x89, 0x07, 0x8f, 0x01, 0xb0, 0x20, 0x00, 0x00, 0x9f, 0xdf, 0xd7,
+	0x00, 0x00, 0x04, 0x4a, 0x05, 0xc8, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03, 0x34,
+	0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x04, 0x03, 0x18, 0x00, 0x00, 0x00, 0xc2,
+	0x00, 0x80, 0x00, 0x06, 0x00, 0x42, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x7e, 0x01, 0x7f, 0x01, 0x92,
+	0x01, 0xdc, 0x01, 0xff, 0x02, 0x1b, 0x02, 0xc7, 0x02, 0xc9, 0x02, 0xdd, 0x03, 0x7e, 0x03, 0x8a,
+	0x03, 0x8c, 0x03, 0xa1, 0x03, 0xce, 0x04, 0x5f, 0x04, 0x91, 0x1e, 0x85, 0x1e, 0xf3, 0x20, 0x15,
+	0x20, 0x1e, 0x20, 0x22, 0x20, 0x26, 0x20, 0x30, 0x20, 0x33, 0x20, 0x3a, 0x20, 0x3c, 0x20, 0x3e,
+	0x20, 0x44, 0x20, 0x70, 0x20, 0x8e, 0x20, 0x99, 0x20, 0xa4, 0x20, 0xa7, 0x20, 0xac, 0x21, 0x05,
+	0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5e, 0x21, 0x95, 0x21, 0xa8,
+	0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x12, 0x22, 0x15, 0x22, 0x1a, 0x22, 0x1f, 0x22, 0x2b,
+	0x22, 0x48, 0x22, 0x61, 0x22, 0x65, 0x23, 0x02, 0x23, 0x10, 0x23, 0x21, 0x25, 0x00, 0x25, 0x02,
+	0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24, 0x25, 0x2c, 0x25, 0x34,
+	0x25, 0x3c, 0x25, 0x6c, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c, 0x25, 0x93, 0x25, 0xa1,
+	0x25, 0xac, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xcb, 0x25, 0xcf, 0x25, 0xd9,
+	0x25, 0xe6, 0x26, 0x3c, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63, 0x26, 0x66, 0x26, 0x6b,
+	0xf8, 0x00, 0xfb, 0x02, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x20,
+	0x00, 0xa0, 0x01, 0x92, 0x01, 0xcd, 0x01, 0xfa, 0x02, 0x18, 0x02, 0xc6, 0x02, 0xc9, 0x02, 0xd8,
+	0x03, 0x7e, 0x03, 0x84, 0x03, 0x8c, 0x03, 0x8e, 0x03, 0xa3, 0x04, 0x00, 0x04, 0x90, 0x1e, 0x80,
+	0x1e, 0xf2, 0x20, 0x13, 0x20, 0x17, 0x20, 0x20, 0x20, 0x26, 0x20, 0x30, 0x20, 0x32, 0x20, 0x39,
+	0x20, 0x3c, 0x20, 0x3e, 0x20, 0x44, 0x20, 0x70, 0x20, 0x74, 0x20, 0x99, 0x20, 0xa3, 0x20, 0xa7,
+	0x20, 0xac, 0x21, 0x05, 0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5b,
+	0x21, 0x90, 0x21, 0xa8, 0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x11, 0x22, 0x15, 0x22, 0x19,
+	0x22, 0x1e, 0x22, 0x29, 0x22, 0x48, 0x22, 0x60, 0x22, 0x64, 0x23, 0x02, 0x23, 0x10, 0x23, 0x20,
+	0x25, 0x00, 0x25, 0x02, 0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24,
+	0x25, 0x2c, 0x25, 0x34, 0x25, 0x3c, 0x25, 0x50, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c,
+	0x25, 0x90, 0x25, 0xa0, 0x25, 0xaa, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xca,
+	0x25, 0xcf, 0x25, 0xd8, 0x25, 0xe6, 0x26, 0x3a, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63,
+	0x26, 0x65, 0x26, 0x6a, 0xf8, 0x00, 0xfb, 0x01, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x01, 0xff, 0xf5,
+	0xff, 0xe3, 0xff, 0xc2, 0xff, 0xb0, 0xff, 0x76, 0xff, 0x59, 0xff, 0x41, 0xfe, 0x97, 0xfe, 0x96,
+	0xfe, 0x88, 0xfd, 0xe8, 0xfd, 0xe3, 0xfd, 0xe2, 0xfd, 0xe1, 0xfd, 0xe0, 0xfd, 0xaf, 0xfd, 0x7f,
+	0xe3, 0x91, 0xe3, 0x25, 0xe2, 0x06, 0xe2, 0x05, 0xe2, 0x04, 0xe2, 0x01, 0xe1, 0xf8, 0xe1, 0xf7,
+	0xe1, 0xf2, 0xe1, 0xf1, 0xe1, 0xf0, 0xe1, 0xeb, 0xe1, 0xc0, 0xe1, 0xbd, 0xe1, 0xb3, 0xe1, 0xaa,
+	0xe1, 0xa8, 0xe1, 0xa4, 0xe1, 0x4c, 0xe1, 0x3f, 0xe1, 0x3d, 0xe1, 0x32, 0xe1, 0x2f, 0xe1, 0x28,
+	0xe0, 0xfc, 0xe0, 0xcb, 0xe0, 0xb9, 0xe0, 0x60, 0xe0, 0x5d, 0xe0, 0x55, 0xe0, 0x54, 0xe0, 0x52,
+	0xe0, 0x4f, 0xe0, 0x4c, 0xe0, 0x43, 0xe0, 0x27, 0xe0, 0x10, 0xe0, 0x0e, 0xdf, 0x72, 0xdf, 0x65,
+	0xdf, 0x56, 0xdd, 0x78, 0xdd, 0x77, 0xdd, 0x6e, 0xdd, 0x6b, 0xdd, 0x68, 0xdd, 0x65, 0xdd, 0x62,
+	0xdd, 0x5b, 0xdd, 0x54, 0xdd, 0x4d, 0xdd, 0x46, 0xdd, 0x33, 0xdd, 0x20, 0xdd, 0x1d, 0xdd, 0x1a,
+	0xdd, 0x17, 0xdd, 0x14, 0xdd, 0x08, 0xdd, 0x00, 0xdc, 0xfb, 0xdc, 0xf4, 0xdc, 0xf3, 0xdc, 0xec,
+	0xdc, 0xe7, 0xdc, 0xe4, 0xdc, 0xdc, 0xdc, 0xd0, 0xdc, 0x7d, 0xdc, 0x7a, 0xdc, 0x79, 0xdc, 0x5c,
+	0xdc, 0x5a, 0xdc, 0x59, 0xdc, 0x56, 0x0a, 0xc2, 0x07, 0xc2, 0x02, 0xc8, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x02, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05,
+	0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d,
+	0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15,
+	0x00, 0x16, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d,
+	0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24, 0x00, 0x25,
+	0x00, 0x26, 0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d,
+	0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35,
+	0x00, 0x36, 0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d,
+	0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44, 0x00, 0x45,
+	0x00, 0x46, 0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d,
+	0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54, 0x00, 0x55,
+	0x00, 0x56, 0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d,
+	0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x00, 0x00, 0x00, 0x86, 0x00, 0x87, 0x00, 0x89,
+	0x00, 0x8b, 0x00, 0x93, 0x00, 0x98, 0x00, 0x9e, 0x00, 0xa3, 0x00, 0xa2, 0x00, 0xa4, 0x00, 0xa6,
+	0x00, 0xa5, 0x00, 0xa7, 0x00, 0xa9, 0x00, 0xab, 0x00, 0xaa, 0x00, 0xac, 0x00, 0xad, 0x00, 0xaf,
+	0x00, 0xae, 0x00, 0xb0, 0x00, 0xb1, 0x00, 0xb3, 0x00, 0xb5, 0x00, 0xb4, 0x00, 0xb6, 0x00, 0xb8,
+	0x00, 0xb7, 0x00, 0xbc, 0x00, 0xbb, 0x00, 0xbd, 0x00, 0xbe, 0x02, 0x24, 0x00, 0x72, 0x00, 0x64,
+	0x00, 0x65, 0x00, 0x69, 0x02, 0x26, 0x00, 0x78, 0x00, 0xa1, 0x00, 0x70, 0x00, 0x6b, 0x02, 0x54,
+	0x00, 0x76, 0x00, 0x6a, 0x02, 0x70, 0x00, 0x88, 0x00, 0x9a, 0x02, 0x6a, 0x00, 0x73, 0x02, 0x72,
+	0x02, 0x73, 0x00, 0x67, 0x00, 0x77, 0x02, 0x62, 0x02, 0x65, 0x02, 0x64, 0x01, 0xa0, 0x02, 0x6e,
+	0x00, 0x6c, 0x00, 0x7c, 0x02, 0x55, 0x00, 0xa8, 0x00, 0xba, 0x00, 0x81, 0x00, 0x63, 0x00, 0x6e,
+	0x02, 0x69, 0x01, 0x42, 0x02, 0x6f, 0x02, 0x63, 0x00, 0x6d, 0x00, 0x7d, 0x02, 0x27, 0x00, 0x03,
+	0x00, 0x82, 0x00, 0x85, 0x00, 0x97, 0x01, 0x14, 0x01, 0x15, 0x02, 0x19, 0x02, 0x1a, 0x02, 0x21,
+	0x02, 0x22, 0x02, 0x1d, 0x02, 0x1e, 0x00, 0xb9, 0x02, 0xb1, 0x00, 0xc1, 0x01, 0x3a, 0x02, 0x2f,
+	0x02, 0x50, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0xc3, 0x02, 0xc4, 0x02, 0x25, 0x00, 0x79, 0x02, 0x1f,
+	0x02, 0x23, 0x02, 0x28, 0x00, 0x84, 0x00, 0x8c, 0x00, 0x83, 0x00, 0x8d, 0x00, 0x8a, 0x00, 0x8f,
+	0x00, 0x90, 0x00, 0x91, 0x00, 0x8e, 0x00, 0x95, 0x00, 0x96, 0x00, 0x00, 0x00, 0x94, 0x00, 0x9c,
+	0x00, 0x9d, 0x00, 0x9b, 0x00, 0xf3, 0x01, 0x5d, 0x01, 0x64, 0x00, 0x71, 0x01, 0x60, 0x01, 0x61,
+	0x01, 0x62, 0x00, 0x7a, 0x01, 0x65, 0x01, 0x63, 0x01, 0x5e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x21, 0x11, 0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x01, 0x00,
+	0x04, 0x00, 0xfc, 0x40, 0x03, 0x80, 0xfc, 0x80, 0x05, 0x00, 0xfb, 0x00, 0x40, 0x04, 0x80, 0x00,
+	0x00, 0x02, 0x00, 0xcb, 0x00, 0x00, 0x03, 0x24, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x09, 0x04,
+	0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03,
+	0x03, 0x13, 0x13, 0x21, 0x03, 0x03, 0xcb, 0x33, 0x01, 0x3c, 0x33, 0xab, 0x65, 0x3b, 0x01, 0x28,
+	0x3b, 0xc7, 0x01, 0x01, 0xfe, 0xff, 0x01, 0xb0, 0x02, 0xf0, 0x01, 0x28, 0xfe, 0xd8, 0xfd, 0x10,
+	0x00, 0x02, 0x01, 0x61, 0x03, 0xb8, 0x04, 0x94, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x24,
+	0x40, 0x21, 0x05, 0x03, 0x04, 0x03, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x01,
+	0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x06, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x01, 0x61, 0x4c, 0x01,
+	0x28, 0xbb, 0x01, 0x06, 0x4c, 0x01, 0x28, 0xba, 0x03, 0xb8, 0x02, 0x73, 0xfd, 0x8d, 0x02, 0x73,
+	0xfd, 0x8d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6e, 0x00, 0x00, 0x05, 0x2c, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0xa9, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x28, 0x0e, 0x09, 0x02, 0x01, 0x0c,
+	0x0a, 0x02, 0x00, 0x0b, 0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0f, 0x08, 0x02,
+	0x02, 0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x03, 0x3b, 0x4d, 0x10, 0x0d, 0x02, 0x0b, 0x0b,
+	0x39, 0x0b, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x07, 0x05, 0x02, 0x03, 0x0f,
+	0x08, 0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b,
+	0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x39, 0x0b,
+	0x4e, 0x1b, 0x40, 0x26, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x05, 0x02, 0x03, 0x0f, 0x08,
+	0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b, 0x01,
+	0x00, 0x67, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x59, 0x40, 0x1e, 0x00, 0x00,
+	0x1f, 0x1e, 0x1d, 0x1c, 0x00, 0x1b, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x17, 0x16, 0x15, 0x14, 0x13,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x1f, 0x2b, 0x33, 0x13, 0x23,
+	0x37, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x33, 0x03, 0x33, 0x13, 0x33, 0x03, 0x33, 0x07, 0x23,
+	0x03, 0x33, 0x07, 0x23, 0x03, 0x23, 0x13, 0x23, 0x03, 0x01, 0x33, 0x13, 0x23, 0x7d, 0xbf, 0xce,
+	0x3a, 0xd6, 0x97, 0xe8, 0x3b, 0xef, 0xbf, 0x99, 0xc0, 0xd5, 0xc0, 0x98, 0xbf, 0xcf, 0x3b, 0xd6,
+	0x96, 0xe7, 0x3a, 0xef, 0xc0, 0x98, 0xbf, 0xd5, 0xbf, 0x01, 0x01, 0xd5, 0x96, 0xd5, 0x01, 0xaa,
+	0x94, 0x01, 0x4d, 0x94, 0x01, 0xa9, 0xfe, 0x57, 0x01, 0xa9, 0xfe, 0x57, 0x94, 0xfe, 0xb3, 0x94,
+	0xfe, 0x56, 0x01, 0xaa, 0xfe, 0x56, 0x02, 0x3e, 0x01, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x00, 0x72,
+	0xff, 0x60, 0x04, 0xc0, 0x06, 0x69, 0x00, 0x26, 0x00, 0x2b, 0x00, 0x30, 0x00, 0x9a, 0x40, 0x13,
+	0x19, 0x01, 0x04, 0x03, 0x2d, 0x2b, 0x1d, 0x1a, 0x09, 0x06, 0x06, 0x01, 0x04, 0x05, 0x01, 0x00,
+	0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x22, 0x00, 0x02, 0x03, 0x03, 0x02, 0x70,
+	0x06, 0x01, 0x05, 0x00, 0x00, 0x05, 0x71, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38,
+	0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x00,
+	0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x01, 0x05, 0x00,
+	0x05, 0x86, 0x00, 0x03, 0x00, 0x04, 0x01, 0x03, 0x04, 0x6a, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x26, 0x00, 0x26, 0x13,
+	0x11, 0x1d, 0x15, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x05, 0x37, 0x06, 0x26, 0x27, 0x27, 0x37, 0x16,
+	0x17, 0x13, 0x27, 0x2e, 0x03, 0x37, 0x3e, 0x03, 0x37, 0x37, 0x33, 0x07, 0x16, 0x17, 0x07, 0x26,
+	0x23, 0x03, 0x17, 0x04, 0x07, 0x0e, 0x03, 0x07, 0x07, 0x13, 0x36, 0x37, 0x36, 0x27, 0x03, 0x13,
+	0x06, 0x07, 0x06, 0x01, 0xc0, 0x20, 0x45, 0xa0, 0x5d, 0x2c, 0x2c, 0xa2, 0xc4, 0x61, 0x4a, 0x4f,
+	0x5d, 0x2d, 0x03, 0x0e, 0x0f, 0x47, 0x6b, 0x89, 0x70, 0x20, 0xa0, 0x20, 0x98, 0x81, 0x28, 0xae,
+	0x66, 0x5b, 0x31, 0x01, 0x1a, 0x2e, 0x0f, 0x50, 0x73, 0x8c, 0x68, 0x20, 0x28, 0xb0, 0x1d, 0x17,
+	0x97, 0x1d, 0x49, 0xaa, 0x1b, 0x1a, 0xa0, 0xa4, 0x01, 0x20, 0x1d, 0x0e, 0xda, 0x65, 0x0a, 0x01,
+	0xe4, 0x25, 0x30, 0x5d, 0x5d, 0x67, 0x45, 0x4d, 0x7f, 0x5e, 0x3b, 0x0a, 0xa2, 0xa2, 0x08, 0x37,
+	0xc9, 0x5b, 0xfe, 0x36, 0x1e, 0xb4, 0xe6, 0x4a, 0x8a, 0x6d, 0x4a, 0x0a, 0xa3, 0x01, 0x65, 0x24,
+	0x8f, 0x76, 0x5a, 0x01, 0x5c, 0x01, 0x6e, 0x1d, 0x88, 0x83, 0x00, 0x00, 0x00, 0x05, 0x00, 0xdc,
+	0xff, 0xdb, 0x07, 0x67, 0x05, 0xed, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2b,
+	0x01, 0x10, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x2c, 0x0c, 0x01, 0x04, 0x0b, 0x01, 0x02, 0x07,
+	0x04, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x00, 0x05, 0x05, 0x00, 0x61,
+	0x03, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x0e, 0x01, 0x08, 0x08, 0x01, 0x61, 0x0d, 0x06, 0x0a, 0x03,
+	0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x34, 0x0c, 0x01, 0x04,
+	0x0b, 0x01, 0x02, 0x07, 0x04, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x0e, 0x01,
+	0x08, 0x08, 0x06, 0x61, 0x0d, 0x01, 0x06, 0x06, 0x39, 0x4d, 0x0a, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x34, 0x00, 0x00, 0x03, 0x00, 0x85, 0x0a, 0x01,
+	0x01, 0x06, 0x01, 0x86, 0x0c, 0x01, 0x04, 0x0b, 0x01, 0x02, 0x07, 0x04, 0x02, 0x69, 0x00, 0x07,
+	0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d,
+	0x0e, 0x01, 0x08, 0x08, 0x06, 0x61, 0x0d, 0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x32,
+	0x00, 0x00, 0x03, 0x00, 0x85, 0x0a, 0x01, 0x01, 0x06, 0x01, 0x86, 0x00, 0x03, 0x00, 0x05, 0x04,
+	0x03, 0x05, 0x69, 0x0c, 0x01, 0x04, 0x0b, 0x01, 0x02, 0x07, 0x04, 0x02, 0x69, 0x00, 0x07, 0x00,
+	0x09, 0x08, 0x07, 0x09, 0x69, 0x0e, 0x01, 0x08, 0x08, 0x06, 0x61, 0x0d, 0x01, 0x06, 0x06, 0x3c,
+	0x06, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x2a, 0x25, 0x24, 0x19, 0x18, 0x11, 0x10, 0x05, 0x04, 0x00,
+	0x00, 0x29, 0x27, 0x24, 0x2b, 0x25, 0x2b, 0x1f, 0x1d, 0x18, 0x23, 0x19, 0x23, 0x15, 0x13, 0x10,
+	0x17, 0x11, 0x17, 0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0f, 0x09,
+	0x17, 0x2b, 0x17, 0x01, 0x33, 0x01, 0x13, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36,
+	0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0xdc, 0x05,
+	0xc3, 0xc8, 0xfa, 0x3e, 0x9d, 0xa1, 0x92, 0x22, 0x23, 0xe2, 0xa4, 0xa4, 0x95, 0x23, 0x22, 0xe4,
+	0x88, 0x84, 0x2c, 0x2d, 0x82, 0x81, 0x2d, 0x2c, 0x03, 0x82, 0xa2, 0x92, 0x23, 0x22, 0xe4, 0xa2,
+	0xa4, 0x95, 0x22, 0x23, 0xe3, 0x89, 0x84, 0x2d, 0x2c, 0x82, 0x81, 0x2d, 0x2c, 0x25, 0x06, 0x12,
+	0xf9, 0xee, 0x03, 0x09, 0xc7, 0xab, 0xad, 0xc5, 0xc5, 0xac, 0xae, 0xc5, 0x94, 0xdf, 0xdd, 0xde,
+	0xde, 0xfc, 0x88, 0xc8, 0xaf, 0xa9, 0xc4, 0xc5, 0xac, 0xaf, 0xc4, 0x94, 0xdf, 0xdd, 0xde, 0xde,
+	0x00, 0x03, 0x00, 0x5b, 0xff, 0xdb, 0x06, 0x07, 0x05, 0xee, 0x00, 0x1c, 0x00, 0x26, 0x00, 0x2e,
+	0x00, 0x90, 0x40, 0x11, 0x13, 0x09, 0x02, 0x03, 0x05, 0x24, 0x1b, 0x15, 0x03, 0x04, 0x03, 0x02,
+	0x01, 0x00, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39,
+	0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x05, 0x03, 0x02, 0x05, 0x69, 0x00,
+	0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x27, 0x28, 0x19, 0x28, 0x22, 0x10, 0x06, 0x09,
+	0x1c, 0x2b, 0x21, 0x21, 0x27, 0x06, 0x23, 0x22, 0x00, 0x37, 0x12, 0x25, 0x26, 0x37, 0x36, 0x24,
+	0x33, 0x32, 0x16, 0x07, 0x06, 0x05, 0x16, 0x17, 0x36, 0x37, 0x37, 0x33, 0x06, 0x05, 0x16, 0x01,
+	0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x37, 0x26, 0x27, 0x13, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07,
+	0x06, 0x05, 0x72, 0xfe, 0xa4, 0x33, 0xc5, 0xd3, 0xf0, 0xff, 0x00, 0x29, 0x3d, 0x01, 0x90, 0x45,
+	0x17, 0x1b, 0x01, 0x11, 0xad, 0xa6, 0xb5, 0x1b, 0x2a, 0xfe, 0x9a, 0x51, 0x82, 0x6f, 0x1b, 0x05,
+	0xf9, 0x29, 0xfe, 0xf6, 0x48, 0xfd, 0x7b, 0xc8, 0x21, 0x1d, 0x90, 0x85, 0x73, 0x71, 0x7b, 0x60,
+	0xbc, 0xbf, 0x1a, 0x1b, 0x84, 0x84, 0x1a, 0x12, 0x4f, 0x74, 0x01, 0x0c, 0xce, 0x01, 0x32, 0x98,
+	0xba, 0x76, 0x87, 0xb8, 0xb1, 0x89, 0xd5, 0x98, 0xec, 0xd0, 0x92, 0x89, 0x19, 0xcd, 0xfc, 0x80,
+	0x02, 0x70, 0x52, 0xa9, 0x8d, 0xc4, 0x46, 0xd2, 0xf7, 0x01, 0x28, 0x5c, 0x81, 0x86, 0x81, 0x57,
+	0x00, 0x01, 0x01, 0x4f, 0x03, 0xb8, 0x02, 0xcf, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16,
+	0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x03, 0x01, 0x4f, 0x3f, 0x01, 0x41,
+	0xc7, 0x03, 0xb8, 0x02, 0x73, 0xfd, 0x8d, 0x00, 0x00, 0x01, 0x00, 0x98, 0xfe, 0xcc, 0x03, 0xaa,
+	0x06, 0x37, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x07, 0x00, 0x03,
+	0x02, 0x13, 0x07, 0x26, 0x02, 0x13, 0x12, 0x00, 0x03, 0xaa, 0x26, 0xfe, 0xc1, 0x62, 0x62, 0xd4,
+	0x26, 0xd9, 0xbe, 0x3c, 0x3c, 0x01, 0x87, 0x06, 0x37, 0xbf, 0xfe, 0xf4, 0xfe, 0x15, 0xfe, 0x17,
+	0xfe, 0xf3, 0xbf, 0x83, 0x02, 0x09, 0x01, 0x2a, 0x01, 0x2b, 0x02, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0xcc, 0x03, 0x12, 0x06, 0x37, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b,
+	0x11, 0x37, 0x00, 0x13, 0x12, 0x03, 0x37, 0x16, 0x12, 0x03, 0x02, 0x00, 0x25, 0x01, 0x3f, 0x61,
+	0x63, 0xd4, 0x26, 0xd9, 0xbf, 0x3c, 0x3c, 0xfe, 0x78, 0xfe, 0xcc, 0xbf, 0x01, 0x0d, 0x01, 0xe7,
+	0x01, 0xed, 0x01, 0x0c, 0xbf, 0x83, 0xfd, 0xf9, 0xfe, 0xd4, 0xfe, 0xd6, 0xfd, 0xff, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0xee, 0x01, 0x17, 0x04, 0xb7, 0x04, 0xb2, 0x00, 0x06, 0x00, 0x0b, 0x00, 0x10,
+	0x00, 0x17, 0x00, 0x1e, 0x00, 0x31, 0x40, 0x2e, 0x13, 0x01, 0x02, 0x01, 0x00, 0x01, 0x4c, 0x1e,
+	0x1a, 0x14, 0x12, 0x11, 0x0f, 0x0e, 0x0d, 0x0a, 0x09, 0x08, 0x03, 0x02, 0x0d, 0x01, 0x49, 0x00,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x00, 0x01, 0x51, 0x1d,
+	0x1b, 0x19, 0x18, 0x02, 0x09, 0x16, 0x2b, 0x01, 0x25, 0x13, 0x05, 0x36, 0x37, 0x36, 0x07, 0x17,
+	0x05, 0x03, 0x36, 0x07, 0x03, 0x27, 0x25, 0x16, 0x27, 0x25, 0x13, 0x05, 0x06, 0x07, 0x06, 0x13,
+	0x21, 0x03, 0x26, 0x23, 0x22, 0x07, 0x03, 0x32, 0x01, 0x61, 0x24, 0xfe, 0x8a, 0x05, 0x02, 0x0a,
+	0x1d, 0xe1, 0xfe, 0xfc, 0x51, 0x47, 0x5f, 0xd7, 0xc2, 0x01, 0x42, 0x15, 0x18, 0xfe, 0x9f, 0x8b,
+	0x01, 0x15, 0x31, 0x0a, 0x01, 0x4d, 0x01, 0x17, 0x94, 0x1e, 0x1f, 0x20, 0x21, 0x03, 0x3e, 0xc1,
+	0xfe, 0xf7, 0x2f, 0x0f, 0x0a, 0x34, 0x64, 0xf3, 0xa6, 0x01, 0x4f, 0x0f, 0x0e, 0xfe, 0xb1, 0xa4,
+	0xf4, 0x3b, 0x52, 0x2e, 0x01, 0x0a, 0xc0, 0x2c, 0x33, 0x0a, 0x01, 0xdc, 0xfe, 0x9a, 0x10, 0x10,
+	0x00, 0x01, 0x00, 0xca, 0x00, 0x63, 0x04, 0xcc, 0x04, 0x3e, 0x00, 0x0b, 0x00, 0x27, 0x40, 0x24,
+	0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68,
+	0x00, 0x02, 0x02, 0x3b, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x13, 0x21, 0x37, 0x21, 0x13, 0x33, 0x03, 0x21, 0x07, 0x21,
+	0x03, 0x02, 0x07, 0x4f, 0xfe, 0x74, 0x27, 0x01, 0x8c, 0x50, 0xc3, 0x50, 0x01, 0x8c, 0x27, 0xfe,
+	0x74, 0x4f, 0x63, 0x01, 0x8c, 0xc3, 0x01, 0x8c, 0xfe, 0x74, 0xc3, 0xfe, 0x74, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x36, 0xfe, 0xa2, 0x01, 0xfd, 0x01, 0x41, 0x00, 0x09, 0x00, 0x56, 0x4b, 0xb0,
+	0x17, 0x50, 0x58, 0x40, 0x15, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00,
+	0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10,
+	0x04, 0x09, 0x1a, 0x2b, 0x33, 0x23, 0x13, 0x21, 0x07, 0x02, 0x21, 0x37, 0x32, 0x37, 0xf7, 0x7b,
+	0x40, 0x01, 0x41, 0x32, 0x54, 0xfe, 0xbf, 0x16, 0x7b, 0x29, 0x01, 0x41, 0xf9, 0xfe, 0x5a, 0x6f,
+	0xcf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xca, 0x01, 0xef, 0x04, 0xcd, 0x02, 0xb2, 0x00, 0x03,
+	0x00, 0x1f, 0x40, 0x1c, 0x02, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x02, 0x01, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17,
+	0x2b, 0x01, 0x07, 0x21, 0x37, 0x04, 0xcd, 0x27, 0xfc, 0x24, 0x27, 0x02, 0xb2, 0xc3, 0xc3, 0x00,
+	0x00, 0x01, 0x00, 0x7c, 0x00, 0x00, 0x01, 0xfd, 0x01, 0x41, 0x00, 0x03, 0x00, 0x30, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x33, 0x13,
+	0x21, 0x03, 0x7c, 0x40, 0x01, 0x41, 0x40, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x01, 0xff, 0xe8,
+	0xff, 0x85, 0x03, 0x51, 0x05, 0x7e, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17,
+	0x2b, 0x07, 0x01, 0x33, 0x01, 0x18, 0x02, 0xa1, 0xc8, 0xfd, 0x5e, 0x7b, 0x05, 0xf9, 0xfa, 0x07,
+	0x00, 0x03, 0x00, 0x9e, 0xff, 0xdb, 0x04, 0xfe, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19,
+	0x00, 0x5e, 0x40, 0x09, 0x18, 0x17, 0x11, 0x10, 0x04, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x18, 0x06, 0x01, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x05,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x01, 0x06, 0x01, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x14, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x19,
+	0x14, 0x19, 0x0c, 0x12, 0x0d, 0x12, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x07, 0x09, 0x16, 0x2b,
+	0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x13, 0x36,
+	0x37, 0x01, 0x02, 0x01, 0x22, 0x03, 0x06, 0x07, 0x01, 0x12, 0x02, 0x32, 0xdf, 0xb5, 0x46, 0x46,
+	0x01, 0x61, 0xde, 0xdd, 0xb8, 0x46, 0x47, 0xfe, 0x9f, 0xb9, 0xd2, 0x77, 0x10, 0x09, 0xfe, 0x1a,
+	0x14, 0x01, 0x85, 0xd2, 0x76, 0x10, 0x0b, 0x01, 0xe6, 0x14, 0x25, 0x01, 0xac, 0x01, 0x5e, 0x01,
+	0x60, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0x9f, 0xfe, 0x9d, 0xfe, 0x59, 0xb9, 0x02, 0x51, 0x50, 0x45,
+	0xfe, 0x4f, 0xfe, 0xcb, 0x04, 0xa0, 0xfd, 0xb1, 0x50, 0x45, 0x01, 0xb1, 0x01, 0x33, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xb6, 0x00, 0x00, 0x04, 0x50, 0x05, 0xed, 0x00, 0x09, 0x00, 0x3a, 0xb5, 0x06,
+	0x04, 0x03, 0x03, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x09,
+	0x00, 0x09, 0x15, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x37, 0x21, 0x13, 0x05, 0x37, 0x25, 0x01,
+	0x21, 0x07, 0xb6, 0x22, 0x01, 0x28, 0xda, 0xfe, 0xc9, 0x24, 0x02, 0x6e, 0xfe, 0xf3, 0x01, 0x28,
+	0x22, 0xad, 0x04, 0x44, 0x4a, 0xb2, 0x94, 0xfa, 0xc0, 0xad, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4d,
+	0x00, 0x00, 0x04, 0xf4, 0x05, 0xed, 0x00, 0x1a, 0x00, 0x4b, 0xb5, 0x0c, 0x01, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40,
+	0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01,
+	0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x18, 0x23,
+	0x29, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x36, 0x37, 0x36, 0x23, 0x22,
+	0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x07, 0x4d,
+	0x2e, 0x6e, 0x8e, 0x7b, 0x8e, 0x95, 0x67, 0x15, 0x2e, 0xdc, 0x8f, 0xef, 0x2c, 0xf3, 0xb7, 0xe3,
+	0xd6, 0x27, 0x1a, 0xa5, 0xbe, 0x74, 0xe0, 0x32, 0x02, 0x51, 0x2e, 0xea, 0x8f, 0x79, 0x69, 0x78,
+	0x7f, 0x8b, 0x6a, 0xe7, 0x6e, 0xd9, 0x54, 0xdf, 0xc4, 0x80, 0xcc, 0x8b, 0x53, 0xa3, 0x93, 0xea,
+	0x00, 0x01, 0x00, 0x8b, 0xff, 0xdb, 0x04, 0xd7, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x5f, 0x40, 0x0e,
+	0x11, 0x01, 0x02, 0x03, 0x19, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f,
+	0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00,
+	0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e,
+	0x59, 0x40, 0x09, 0x28, 0x23, 0x23, 0x11, 0x23, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x16,
+	0x33, 0x32, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x32, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x02, 0x05, 0x04, 0x03, 0x06, 0x04, 0x23, 0x22, 0x8b, 0x2c, 0xcd,
+	0x6a, 0xf8, 0x30, 0x22, 0x93, 0xd7, 0x33, 0x24, 0xe0, 0xc4, 0x1d, 0x2a, 0xd7, 0x9f, 0x9e, 0x28,
+	0xb1, 0xc6, 0xd9, 0xc9, 0x21, 0x34, 0xfe, 0xa3, 0x01, 0x65, 0x3f, 0x27, 0xfe, 0xab, 0xf1, 0xa5,
+	0x0b, 0xde, 0x55, 0xf1, 0xa7, 0x86, 0xb1, 0x70, 0x90, 0xd2, 0x54, 0xca, 0x42, 0xba, 0xa9, 0xfe,
+	0xfc, 0x6c, 0x56, 0xfe, 0xc6, 0xc2, 0xed, 0x00, 0x00, 0x02, 0x00, 0x6d, 0x00, 0x00, 0x04, 0xcb,
+	0x05, 0xc8, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x50, 0xb5, 0x0d, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x05, 0x01, 0x01, 0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02,
+	0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x00, 0x01, 0x00, 0x85, 0x05, 0x01, 0x01, 0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00,
+	0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a,
+	0x11, 0x11, 0x11, 0x12, 0x07, 0x09, 0x1a, 0x2b, 0x13, 0x37, 0x01, 0x21, 0x03, 0x33, 0x07, 0x23,
+	0x03, 0x21, 0x13, 0x25, 0x21, 0x13, 0x6d, 0x2d, 0x03, 0x22, 0x01, 0x0f, 0xac, 0x88, 0x2d, 0x88,
+	0x4e, 0xfe, 0xfd, 0x4e, 0xfe, 0x99, 0x01, 0x9a, 0x74, 0x01, 0x8b, 0xde, 0x03, 0x5f, 0xfc, 0xa1,
+	0xde, 0xfe, 0x75, 0x01, 0x8b, 0xde, 0x02, 0x44, 0x00, 0x01, 0x00, 0x91, 0xff, 0xdb, 0x05, 0x01,
+	0x05, 0xc8, 0x00, 0x21, 0x00, 0x64, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x03, 0x01, 0x03, 0x04, 0x01, 0x80, 0x00, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x7e, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x04, 0x03, 0x01, 0x03,
+	0x04, 0x01, 0x80, 0x00, 0x01, 0x00, 0x03, 0x01, 0x00, 0x7e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x09,
+	0x28, 0x21, 0x11, 0x11, 0x28, 0x23, 0x06, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x16, 0x33, 0x32,
+	0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x13, 0x21, 0x07, 0x21, 0x03, 0x33, 0x32, 0x1e,
+	0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x91, 0x2a, 0x44, 0x88, 0x4f, 0x3c, 0x69, 0x53, 0x37,
+	0x0b, 0x11, 0x1a, 0x56, 0x93, 0x6a, 0x7a, 0x97, 0x03, 0x30, 0x2f, 0xfd, 0xa3, 0x44, 0x1f, 0x7e,
+	0xd3, 0x8d, 0x3c, 0x1a, 0x18, 0x7f, 0xb2, 0xd1, 0x69, 0x42, 0x9d, 0x06, 0xd6, 0x24, 0x24, 0x2c,
+	0x4b, 0x62, 0x37, 0x52, 0x73, 0x49, 0x22, 0x02, 0xf4, 0xea, 0xfe, 0xab, 0x30, 0x6d, 0xb1, 0x81,
+	0x75, 0xb3, 0x79, 0x3e, 0x14, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x79, 0xff, 0xdb, 0x05, 0x02,
+	0x05, 0xed, 0x00, 0x16, 0x00, 0x20, 0x00, 0x5b, 0x40, 0x0a, 0x01, 0x01, 0x01, 0x00, 0x07, 0x01,
+	0x04, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x01, 0x00, 0x04, 0x05,
+	0x01, 0x04, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3e, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x01,
+	0x03, 0x00, 0x69, 0x00, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x69, 0x00, 0x05, 0x05, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06,
+	0x09, 0x1c, 0x2b, 0x01, 0x07, 0x26, 0x23, 0x22, 0x02, 0x07, 0x07, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x00, 0x23, 0x22, 0x02, 0x13, 0x12, 0x00, 0x21, 0x32, 0x03, 0x12, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x16, 0x33, 0x32, 0x05, 0x02, 0x2b, 0xb0, 0x5e, 0xa1, 0xe9, 0x2f, 0x04, 0x98, 0xa7, 0xbc,
+	0xaa, 0x2a, 0x33, 0xfe, 0xd4, 0xe5, 0xfc, 0xce, 0x45, 0x4c, 0x01, 0xb0, 0x01, 0x23, 0x7f, 0xec,
+	0x3f, 0xc0, 0x64, 0x9a, 0x1c, 0x1d, 0x53, 0x64, 0xc3, 0x05, 0xbf, 0xd8, 0x4e, 0xfe, 0xf8, 0xed,
+	0x18, 0x91, 0xf8, 0xd3, 0xff, 0xfe, 0xec, 0x01, 0x83, 0x01, 0x59, 0x01, 0x79, 0x01, 0xbd, 0xfb,
+	0xdf, 0x01, 0x37, 0xa8, 0x8b, 0x92, 0xaa, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x05, 0x42,
+	0x05, 0xc8, 0x00, 0x0a, 0x00, 0x39, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x0f, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x09, 0x18, 0x2b, 0x33,
+	0x36, 0x12, 0x01, 0x01, 0x21, 0x37, 0x21, 0x07, 0x00, 0x03, 0xb0, 0x33, 0xe7, 0x01, 0x21, 0x01,
+	0x35, 0xfd, 0x48, 0x30, 0x03, 0xaa, 0x30, 0xfd, 0x61, 0x7a, 0xa0, 0x01, 0x5c, 0x01, 0x61, 0x01,
+	0x7b, 0xf0, 0xf0, 0xfd, 0x1e, 0xfe, 0x0a, 0x00, 0x00, 0x03, 0x00, 0x79, 0xff, 0xdb, 0x04, 0xfd,
+	0x05, 0xed, 0x00, 0x16, 0x00, 0x20, 0x00, 0x2b, 0x00, 0x43, 0xb5, 0x0b, 0x01, 0x03, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x13,
+	0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x02, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x42, 0x01, 0x4e, 0x59, 0xb6, 0x28, 0x28, 0x29, 0x25, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x26, 0x26,
+	0x37, 0x36, 0x24, 0x33, 0x32, 0x16, 0x07, 0x06, 0x05, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22,
+	0x26, 0x37, 0x36, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x17, 0x16, 0x07, 0x06,
+	0x07, 0x06, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x27, 0x02, 0x08, 0x60, 0x33, 0x16, 0x23, 0x01,
+	0x11, 0xcb, 0xbb, 0xb8, 0x1f, 0x2a, 0xff, 0x00, 0x91, 0x5b, 0x19, 0x28, 0xfe, 0xb2, 0xe3, 0xdd,
+	0xd8, 0x25, 0x1b, 0x9f, 0x01, 0xd3, 0x8e, 0x1e, 0x26, 0xa3, 0xa8, 0x21, 0x16, 0x83, 0x10, 0x86,
+	0xa8, 0x23, 0x32, 0xe5, 0x5e, 0x8f, 0x12, 0x10, 0x30, 0x6b, 0x03, 0x1d, 0x5f, 0x89, 0x6e, 0xb0,
+	0xca, 0xb6, 0x9a, 0xd4, 0x9c, 0x6c, 0xae, 0x7d, 0xc4, 0xf7, 0xd8, 0xb9, 0x84, 0xbe, 0xd3, 0x5e,
+	0x99, 0xbc, 0xa3, 0x6f, 0x76, 0x0e, 0xe3, 0x85, 0xad, 0xf8, 0x71, 0x58, 0x51, 0x5c, 0x61, 0x00,
+	0x00, 0x02, 0x00, 0x98, 0xff, 0xdb, 0x05, 0x21, 0x05, 0xed, 0x00, 0x16, 0x00, 0x20, 0x00, 0x5b,
+	0x40, 0x0a, 0x07, 0x01, 0x01, 0x04, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1d, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x05, 0x05, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e,
+	0x1b, 0x40, 0x1b, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x69, 0x00, 0x04, 0x00, 0x01, 0x00,
+	0x04, 0x01, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40,
+	0x09, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32,
+	0x12, 0x37, 0x37, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00,
+	0x21, 0x22, 0x13, 0x02, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x98, 0x2c, 0xb1, 0x5d,
+	0xa2, 0xe8, 0x2f, 0x05, 0x99, 0xa7, 0xbc, 0xab, 0x2b, 0x33, 0x01, 0x2d, 0xe4, 0xfc, 0xcd, 0x44,
+	0x4c, 0xfe, 0x50, 0xfe, 0xde, 0x80, 0xec, 0x3f, 0xc0, 0x64, 0x9b, 0x1c, 0x1d, 0x54, 0x64, 0xc3,
+	0x09, 0xd9, 0x4e, 0x01, 0x07, 0xed, 0x18, 0x91, 0xf8, 0xd4, 0xff, 0x01, 0x13, 0xfe, 0x7d, 0xfe,
+	0xa8, 0xfe, 0x87, 0xfe, 0x42, 0x04, 0x22, 0xfe, 0xc8, 0xa9, 0x8b, 0x91, 0xab, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xd6, 0x00, 0x00, 0x02, 0xf7, 0x04, 0x63, 0x00, 0x03, 0x00, 0x07, 0x00, 0x6a,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x17, 0x04, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x00, 0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x03, 0x01, 0x13,
+	0x21, 0x03, 0x01, 0x76, 0x40, 0x01, 0x41, 0x40, 0xfe, 0x1f, 0x40, 0x01, 0x41, 0x40, 0x03, 0x22,
+	0x01, 0x41, 0xfe, 0xbf, 0xfc, 0xde, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x90,
+	0xfe, 0xa2, 0x02, 0xf7, 0x04, 0x63, 0x00, 0x03, 0x00, 0x0d, 0x00, 0xa5, 0x4b, 0xb0, 0x17, 0x50,
+	0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x04, 0x05,
+	0x04, 0x65, 0x06, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x5f, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x00, 0x06, 0x01, 0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00,
+	0x06, 0x01, 0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00,
+	0x0c, 0x0b, 0x0a, 0x09, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17,
+	0x2b, 0x01, 0x13, 0x21, 0x03, 0x01, 0x23, 0x13, 0x21, 0x07, 0x02, 0x21, 0x37, 0x32, 0x37, 0x01,
+	0x76, 0x40, 0x01, 0x41, 0x40, 0xfe, 0x9a, 0x7b, 0x40, 0x01, 0x41, 0x32, 0x54, 0xfe, 0xbf, 0x16,
+	0x7b, 0x29, 0x03, 0x22, 0x01, 0x41, 0xfe, 0xbf, 0xfc, 0xde, 0x01, 0x41, 0xf9, 0xfe, 0x5a, 0x6f,
+	0xcf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xde, 0x00, 0x63, 0x05, 0x1c, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x25, 0x01, 0x01, 0x07, 0x01, 0x15, 0x01, 0x04,
+	0x56, 0xfc, 0x88, 0x04, 0x3e, 0x2c, 0xfd, 0xa4, 0x01, 0xee, 0x63, 0x01, 0xed, 0x01, 0xee, 0xda,
+	0xfe, 0xed, 0x02, 0xfe, 0xee, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9d, 0x01, 0x0d, 0x04, 0xf6,
+	0x03, 0x82, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x9d,
+	0x2b, 0x03, 0xdb, 0x2b, 0xfc, 0x7c, 0x27, 0x03, 0xdb, 0x27, 0x01, 0x0d, 0xd4, 0xd4, 0x01, 0xb2,
+	0xc3, 0xc3, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7c, 0x00, 0x63, 0x04, 0xba, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x06, 0xb3, 0x06, 0x04, 0x01, 0x32, 0x2b, 0x13, 0x01, 0x35, 0x01, 0x37, 0x01, 0x01, 0xa8,
+	0x02, 0x5c, 0xfe, 0x12, 0x2c, 0x03, 0x78, 0xfb, 0xc2, 0x01, 0x3d, 0x01, 0x12, 0x02, 0x01, 0x13,
+	0xda, 0xfe, 0x12, 0xfe, 0x13, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x60, 0x00, 0x00, 0x05, 0x8e,
+	0x05, 0xed, 0x00, 0x03, 0x00, 0x1c, 0x00, 0x5f, 0xb5, 0x10, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00,
+	0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01,
+	0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x14, 0x12, 0x0f, 0x0d, 0x05, 0x04, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x21, 0x37, 0x21, 0x07, 0x13, 0x21, 0x37, 0x36, 0x36,
+	0x37, 0x37, 0x36, 0x37, 0x36, 0x21, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x03, 0x06, 0x06, 0x07,
+	0x07, 0x06, 0x06, 0x07, 0x01, 0x60, 0x31, 0x01, 0x3c, 0x31, 0x4c, 0xfe, 0xd8, 0x03, 0x19, 0x75,
+	0x83, 0x73, 0x93, 0x1b, 0x26, 0xfe, 0xf9, 0xd8, 0xb9, 0x2d, 0xce, 0xdc, 0x02, 0x34, 0x46, 0x15,
+	0x7b, 0xa5, 0x5d, 0x5a, 0x4c, 0x1a, 0xf7, 0xf7, 0x01, 0xb0, 0x12, 0x79, 0x9f, 0x55, 0x4a, 0x66,
+	0x8c, 0xbd, 0x53, 0xe2, 0x36, 0xfe, 0xa5, 0x69, 0x80, 0x58, 0x32, 0x30, 0x75, 0x83, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xfc, 0xff, 0xdb, 0x07, 0xdd, 0x05, 0xed, 0x00, 0x33, 0x00, 0x3e, 0x01, 0xc8,
+	0x40, 0x0a, 0x35, 0x01, 0x03, 0x0a, 0x33, 0x01, 0x09, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50,
+	0x58, 0x40, 0x32, 0x00, 0x03, 0x0a, 0x07, 0x0a, 0x03, 0x07, 0x80, 0x0b, 0x01, 0x07, 0x04, 0x01,
+	0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00,
+	0x0a, 0x0a, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x30, 0x00, 0x03, 0x0a,
+	0x07, 0x0a, 0x03, 0x07, 0x80, 0x06, 0x01, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69, 0x0b, 0x01,
+	0x07, 0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x32, 0x00, 0x03, 0x0a, 0x07, 0x0a, 0x03, 0x07, 0x80, 0x0b, 0x01, 0x07,
+	0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x00, 0x0a, 0x0a, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x30, 0x00,
+	0x03, 0x0a, 0x07, 0x0a, 0x03, 0x07, 0x80, 0x06, 0x01, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69,
+	0x0b, 0x01, 0x07, 0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x35, 0x00, 0x03, 0x0a, 0x0b, 0x0a, 0x03, 0x0b, 0x80, 0x06,
+	0x01, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69, 0x00, 0x0b, 0x07, 0x02, 0x0b, 0x59, 0x00, 0x07,
+	0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x3c, 0x00, 0x06, 0x05, 0x0a, 0x05, 0x06, 0x0a, 0x80, 0x00, 0x03, 0x0a, 0x0b,
+	0x0a, 0x03, 0x0b, 0x80, 0x00, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69, 0x00, 0x0b, 0x07, 0x02,
+	0x0b, 0x59, 0x00, 0x07, 0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e,
+	0x1b, 0x40, 0x3a, 0x00, 0x06, 0x05, 0x0a, 0x05, 0x06, 0x0a, 0x80, 0x00, 0x03, 0x0a, 0x0b, 0x0a,
+	0x03, 0x0b, 0x80, 0x00, 0x01, 0x00, 0x08, 0x05, 0x01, 0x08, 0x69, 0x00, 0x05, 0x00, 0x0a, 0x03,
+	0x05, 0x0a, 0x69, 0x00, 0x0b, 0x07, 0x02, 0x0b, 0x59, 0x00, 0x07, 0x04, 0x01, 0x02, 0x09, 0x07,
+	0x02, 0x6a, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x40, 0x12, 0x3d, 0x3b, 0x38, 0x36, 0x32, 0x30, 0x24, 0x24, 0x22, 0x23, 0x22,
+	0x13, 0x24, 0x24, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x00,
+	0x21, 0x20, 0x00, 0x03, 0x06, 0x00, 0x23, 0x22, 0x37, 0x36, 0x37, 0x23, 0x06, 0x04, 0x23, 0x22,
+	0x37, 0x36, 0x00, 0x33, 0x32, 0x17, 0x16, 0x33, 0x33, 0x03, 0x06, 0x07, 0x06, 0x33, 0x32, 0x12,
+	0x37, 0x36, 0x02, 0x23, 0x20, 0x00, 0x03, 0x06, 0x12, 0x33, 0x32, 0x37, 0x13, 0x37, 0x26, 0x23,
+	0x22, 0x02, 0x07, 0x06, 0x33, 0x32, 0x36, 0x04, 0xb0, 0xbf, 0xae, 0xfe, 0xe3, 0xfe, 0xd6, 0x36,
+	0x4a, 0x02, 0xa6, 0x01, 0x72, 0x01, 0x19, 0x01, 0x30, 0x35, 0x33, 0xfe, 0x8b, 0xe1, 0xa3, 0x15,
+	0x0a, 0x5c, 0x15, 0x6d, 0xfe, 0xfc, 0x63, 0xb3, 0x2e, 0x2f, 0x01, 0x95, 0xbc, 0x17, 0x28, 0x3a,
+	0x48, 0x86, 0xdf, 0x14, 0x09, 0x0e, 0x4c, 0x77, 0xfa, 0x22, 0x2b, 0xf7, 0xe0, 0xfe, 0xc4, 0xfd,
+	0xb6, 0x3f, 0x2b, 0xee, 0xe3, 0x9b, 0xa8, 0x7d, 0x4b, 0x48, 0x3e, 0x81, 0xf2, 0x27, 0x19, 0x47,
+	0x36, 0xfc, 0x2d, 0x52, 0x01, 0x5b, 0x01, 0x0c, 0x01, 0x74, 0x02, 0x37, 0xfe, 0x9b, 0xfe, 0xf4,
+	0xfc, 0xfe, 0xa8, 0x6d, 0x2e, 0xb8, 0x96, 0xbd, 0xe7, 0xec, 0x01, 0x99, 0x06, 0x08, 0xfd, 0xd2,
+	0x34, 0x2d, 0x44, 0x01, 0x15, 0xab, 0xd6, 0x01, 0x23, 0xfe, 0x17, 0xfe, 0xc2, 0xd6, 0xfe, 0xed,
+	0x48, 0x02, 0x94, 0xba, 0x25, 0xfe, 0xe8, 0xc2, 0x7b, 0xdf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21,
+	0x03, 0x01, 0x21, 0x03, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c,
+	0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50,
+	0x02, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x68, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x14, 0x00, 0x1d, 0x00, 0x61, 0xb5, 0x06, 0x01, 0x05, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x00,
+	0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01,
+	0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1d, 0x1b, 0x17, 0x15, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b,
+	0x00, 0x0a, 0x21, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x04, 0x03,
+	0x06, 0x04, 0x23, 0x01, 0x21, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21, 0x03, 0x21, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x21, 0xad, 0x01, 0x27, 0x02, 0xcc, 0x01, 0xc8, 0x42, 0x35, 0xfe, 0x87,
+	0x01, 0x8c, 0x3d, 0x24, 0xfe, 0xe8, 0xe4, 0xfe, 0xd4, 0x01, 0x1e, 0x82, 0xb3, 0x16, 0x14, 0x6c,
+	0xab, 0xfe, 0xed, 0xd6, 0x01, 0x17, 0xc2, 0xa4, 0x16, 0x17, 0xa7, 0x96, 0xfe, 0xef, 0x05, 0xc8,
+	0xfe, 0xb7, 0xfe, 0xf5, 0x6f, 0x64, 0xfe, 0xcd, 0xb1, 0xbd, 0x03, 0x60, 0x81, 0x6d, 0x65, 0x4a,
+	0xfb, 0xd5, 0x53, 0x6d, 0x72, 0x96, 0x00, 0x00, 0x00, 0x01, 0x00, 0x99, 0xff, 0xdb, 0x06, 0x94,
+	0x05, 0xed, 0x00, 0x13, 0x00, 0x48, 0x40, 0x0a, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0xb6, 0x22, 0x23, 0x24, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x07,
+	0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x02,
+	0x21, 0x32, 0x05, 0xb7, 0x2e, 0xea, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0xb7, 0x4a, 0x4d, 0x01, 0xed,
+	0x01, 0x8f, 0x01, 0x03, 0xe5, 0x30, 0xfe, 0xc8, 0xfd, 0xff, 0x72, 0x71, 0x02, 0x1e, 0xeb, 0x01,
+	0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6,
+	0xfd, 0xc8, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x55, 0x05, 0xc8, 0x00, 0x08,
+	0x00, 0x11, 0x00, 0x46, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x03, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x11, 0x0f, 0x0b,
+	0x09, 0x00, 0x08, 0x00, 0x07, 0x21, 0x05, 0x09, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x00, 0x03,
+	0x02, 0x00, 0x21, 0x27, 0x33, 0x32, 0x00, 0x13, 0x36, 0x02, 0x23, 0x23, 0xad, 0x01, 0x27, 0x02,
+	0x03, 0x01, 0x58, 0x01, 0x26, 0x44, 0x4a, 0xfe, 0x2c, 0xfe, 0xa2, 0x8b, 0x6d, 0xf3, 0x01, 0x25,
+	0x37, 0x31, 0xb8, 0xd3, 0x8c, 0x05, 0xc8, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2,
+	0x01, 0x0d, 0x01, 0x12, 0xf5, 0x01, 0x17, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0xad, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6,
+	0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6,
+	0xfe, 0x38, 0xd2, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xdc, 0x05, 0xc8, 0x00, 0x09,
+	0x00, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39,
+	0x04, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00,
+	0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x21,
+	0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0xad, 0x01, 0x27, 0x04, 0x08, 0x28, 0xfd, 0x2c, 0x5a,
+	0x02, 0x65, 0x29, 0xfd, 0x9b, 0x7c, 0x05, 0xc8, 0xcb, 0xfe, 0x3e, 0xcc, 0xfd, 0x91, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x9c, 0xff, 0xdb, 0x06, 0xc7, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x62, 0x40, 0x0a,
+	0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1e, 0x06, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x06, 0x01, 0x05, 0x00, 0x04, 0x03,
+	0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x07, 0x09, 0x1b, 0x2b,
+	0x01, 0x03, 0x04, 0x23, 0x22, 0x24, 0x27, 0x26, 0x13, 0x12, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17,
+	0x07, 0x24, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37, 0x06, 0x34,
+	0x89, 0xfe, 0xd9, 0xe8, 0xf9, 0xfe, 0xec, 0x55, 0x9e, 0x48, 0x45, 0xe4, 0x84, 0x01, 0x3d, 0xf2,
+	0x01, 0x22, 0xe5, 0x30, 0xfe, 0xe3, 0xdf, 0xfa, 0xfe, 0xc2, 0x39, 0x37, 0xdd, 0x01, 0x04, 0x47,
+	0x7a, 0x43, 0xfa, 0x28, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72, 0xd4, 0x01, 0x67, 0x01, 0x58,
+	0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01,
+	0x4b, 0xcb, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x41, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13,
+	0x21, 0x01, 0x21, 0x13, 0x21, 0x03, 0xad, 0x01, 0x27, 0x01, 0x34, 0x78, 0x02, 0x05, 0x78, 0x01,
+	0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0x86, 0xfd, 0xfb, 0x86, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa,
+	0x38, 0x02, 0xa3, 0xfd, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x04, 0x63,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b,
+	0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x64, 0x29, 0xd2, 0xd4,
+	0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc,
+	0xd2, 0x00, 0x00, 0x00, 0x00, 0x01, 0xff, 0xce, 0xfe, 0xd8, 0x04, 0xc8, 0x05, 0xc8, 0x00, 0x0f,
+	0x00, 0x45, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38,
+	0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x03,
+	0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x23,
+	0x11, 0x13, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x07, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x21,
+	0x37, 0x21, 0x03, 0x02, 0x04, 0x21, 0x22, 0x32, 0x2c, 0xae, 0xa9, 0x97, 0x8a, 0x1f, 0xd9, 0xfe,
+	0xfc, 0x2a, 0x02, 0x38, 0xfc, 0x36, 0xfe, 0xc3, 0xfe, 0xd9, 0xae, 0xfc, 0xdd, 0x38, 0x75, 0x9a,
+	0x04, 0x3e, 0xd2, 0xfb, 0x11, 0xfe, 0xf3, 0xf4, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x63,
+	0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x04, 0x03, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12,
+	0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x03,
+	0xad, 0x01, 0x27, 0x01, 0x28, 0x8f, 0x02, 0xf7, 0xff, 0xfd, 0x4a, 0x02, 0x0b, 0xfe, 0x7f, 0xfe,
+	0x2f, 0x91, 0x05, 0xc8, 0xfd, 0x32, 0x02, 0xce, 0xfd, 0x68, 0xfc, 0xd0, 0x02, 0xd8, 0xfd, 0x28,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x04, 0xfa, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00,
+	0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x07, 0xad,
+	0x01, 0x27, 0x01, 0x34, 0xfe, 0x02, 0xf0, 0x29, 0x05, 0xc8, 0xfb, 0x0a, 0xd2, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x07, 0x25, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4b, 0xb7, 0x0b,
+	0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03,
+	0x03, 0x00, 0x5f, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x40, 0x14, 0x00, 0x03, 0x02, 0x00, 0x03, 0x57, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00,
+	0x0c, 0x12, 0x11, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01,
+	0x21, 0x13, 0x01, 0x23, 0x03, 0x03, 0xad, 0x01, 0x27, 0x01, 0x98, 0x54, 0x01, 0xff, 0x01, 0x66,
+	0xfe, 0xd9, 0xfe, 0xe4, 0xdf, 0xfe, 0x0b, 0xf8, 0x53, 0xe2, 0x05, 0xc8, 0xfb, 0xef, 0x04, 0x11,
+	0xfa, 0x38, 0x04, 0x5d, 0xfc, 0x06, 0x04, 0x09, 0xfb, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x41, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00,
+	0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x13, 0x33, 0x01, 0x21,
+	0x01, 0x03, 0xad, 0x01, 0x27, 0x01, 0x0f, 0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0xfe, 0xed, 0xfe,
+	0x67, 0xca, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01,
+	0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36,
+	0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01,
+	0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d,
+	0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x16, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00,
+	0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00,
+	0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x32, 0x16, 0x17, 0x16,
+	0x07, 0x02, 0x21, 0x23, 0x03, 0x13, 0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x23, 0xad, 0x01, 0x27,
+	0x02, 0x5a, 0xbd, 0xb1, 0x33, 0x47, 0x23, 0x66, 0xfd, 0x97, 0xd6, 0x73, 0x9c, 0x92, 0x01, 0x72,
+	0x37, 0x18, 0x7e, 0xa5, 0xcd, 0x05, 0xc8, 0x2f, 0x46, 0x61, 0xb3, 0xfe, 0x05, 0xfd, 0xbc, 0x03,
+	0x0f, 0x01, 0x12, 0x7a, 0x62, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9c, 0xfe, 0xd8, 0x06, 0xc5,
+	0x05, 0xed, 0x00, 0x11, 0x00, 0x1d, 0x00, 0x42, 0xb4, 0x03, 0x02, 0x02, 0x00, 0x49, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0xb6, 0x24, 0x26, 0x24, 0x35, 0x04, 0x09, 0x1a, 0x2b, 0x25, 0x04, 0x05, 0x07, 0x24, 0x27,
+	0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x01, 0x02, 0x12, 0x33,
+	0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x04, 0x86, 0x01, 0x0d, 0x01, 0x27, 0xf0, 0xfe,
+	0x87, 0xda, 0x53, 0x28, 0xfe, 0xc4, 0xfe, 0xdc, 0x47, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f,
+	0x01, 0x2a, 0x48, 0x61, 0xfc, 0x10, 0x38, 0x8f, 0xbb, 0xb9, 0x01, 0x08, 0x36, 0x37, 0x91, 0xb8,
+	0xb8, 0xfe, 0xf6, 0x22, 0x64, 0x20, 0xc6, 0x69, 0x9f, 0x05, 0x01, 0xa6, 0x01, 0x63, 0x01, 0x6d,
+	0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x95, 0xfe, 0x1c, 0x01, 0xeb, 0xfe, 0xe9, 0xfe, 0xd1, 0x01, 0x2d,
+	0x01, 0x10, 0x01, 0x10, 0x01, 0x2e, 0xfe, 0xd4, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x1c,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x57, 0xb5, 0x06, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05,
+	0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e,
+	0x1b, 0x40, 0x18, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x67, 0x06, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00,
+	0x12, 0x10, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x07, 0x09, 0x19, 0x2b, 0x33,
+	0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x01, 0x21, 0x01, 0x23, 0x03, 0x13, 0x33, 0x20, 0x13, 0x36,
+	0x21, 0x23, 0xad, 0x01, 0x27, 0x02, 0x85, 0x01, 0xc3, 0x49, 0x3b, 0xfe, 0xc8, 0x01, 0x5a, 0xfe,
+	0xa6, 0xfe, 0xd8, 0xf1, 0x78, 0xa1, 0xa2, 0x01, 0x4f, 0x36, 0x28, 0xfe, 0xd5, 0xc6, 0x05, 0xc8,
+	0xfe, 0x91, 0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd, 0xa3, 0x03, 0x28, 0x01, 0x0f, 0xc6,
+	0x00, 0x01, 0x00, 0x68, 0xff, 0xda, 0x05, 0xc6, 0x05, 0xed, 0x00, 0x23, 0x00, 0x49, 0x40, 0x0b,
+	0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01,
+	0x02, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6, 0x2c,
+	0x23, 0x29, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02,
+	0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f,
+	0x02, 0x16, 0x16, 0x07, 0x06, 0x04, 0x21, 0x22, 0x27, 0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54,
+	0x27, 0x1a, 0x76, 0x7f, 0x97, 0xf1, 0x8c, 0x21, 0x53, 0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf,
+	0xb5, 0x9b, 0x14, 0x0c, 0x36, 0x5a, 0x69, 0x9d, 0xe5, 0x96, 0x21, 0x2f, 0xfe, 0x7c, 0xfe, 0x8d,
+	0x8b, 0xa9, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33,
+	0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x00,
+	0x00, 0x01, 0x01, 0x25, 0x00, 0x00, 0x05, 0xe3, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07,
+	0x21, 0x03, 0x01, 0xd8, 0xfd, 0xfe, 0x50, 0x2a, 0x04, 0x94, 0x2a, 0xfe, 0x50, 0xfd, 0x04, 0xf3,
+	0xd5, 0xd5, 0xfb, 0x0d, 0x00, 0x01, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x05, 0xc8, 0x00, 0x14,
+	0x00, 0x36, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x11, 0x02, 0x01, 0x00,
+	0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6,
+	0x25, 0x12, 0x23, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13,
+	0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0xc7, 0x01,
+	0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3,
+	0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2,
+	0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x00, 0x00, 0x00, 0x01, 0x01, 0x40,
+	0x00, 0x00, 0x06, 0x65, 0x05, 0xc8, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x03, 0x01,
+	0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x11,
+	0x04, 0x09, 0x18, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x02, 0x1b, 0xdb, 0x01, 0x49,
+	0xa7, 0x02, 0x51, 0xe4, 0xfc, 0xea, 0x05, 0xc8, 0xfb, 0xaf, 0x04, 0x51, 0xfa, 0x38, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x40, 0x00, 0x00, 0x08, 0x9c, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x42, 0xb7, 0x0b,
+	0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0f, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x0f,
+	0x02, 0x01, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x05, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b,
+	0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x01, 0x95, 0x55,
+	0x01, 0x23, 0x41, 0x01, 0xf0, 0x01, 0x01, 0x26, 0x02, 0x06, 0xdb, 0xfd, 0x3e, 0xfe, 0xd9, 0x26,
+	0xfe, 0x2e, 0x05, 0xc8, 0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7,
+	0xfc, 0x09, 0x00, 0x00, 0x00, 0x01, 0x00, 0x31, 0x00, 0x00, 0x06, 0x40, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01, 0x21, 0x03, 0x01, 0x31,
+	0x02, 0x6b, 0xfe, 0xd1, 0x01, 0x67, 0xca, 0x01, 0xa9, 0xf9, 0xfd, 0xaf, 0x01, 0x3a, 0xfe, 0x9a,
+	0xd8, 0xfe, 0x3f, 0x02, 0xd9, 0x02, 0xef, 0xfe, 0x0e, 0x01, 0xf2, 0xfd, 0x46, 0xfc, 0xf2, 0x02,
+	0x11, 0xfd, 0xef, 0x00, 0x00, 0x01, 0x01, 0x43, 0x00, 0x00, 0x06, 0x62, 0x05, 0xc8, 0x00, 0x08,
+	0x00, 0x3b, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x0d, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x0d, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x0b, 0x00, 0x00, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x13, 0x01,
+	0x21, 0x13, 0x01, 0x33, 0x01, 0x03, 0x02, 0x07, 0x7b, 0xfe, 0xc1, 0x01, 0x55, 0xe5, 0x01, 0xf1,
+	0xf4, 0xfd, 0x55, 0x7c, 0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92,
+	0x00, 0x01, 0x00, 0x5e, 0x00, 0x00, 0x05, 0xad, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x44, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01,
+	0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c,
+	0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09,
+	0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x5e, 0x29, 0x03, 0x98,
+	0xfd, 0x69, 0x28, 0x03, 0xfd, 0x28, 0xfc, 0x68, 0x02, 0xc2, 0x29, 0xd2, 0x04, 0x2b, 0xcb, 0xcb,
+	0xfb, 0xd5, 0xd2, 0x00, 0x00, 0x01, 0x00, 0x63, 0xfe, 0xd8, 0x03, 0xa9, 0x06, 0x2b, 0x00, 0x07,
+	0x00, 0x22, 0x40, 0x1f, 0x00, 0x02, 0x04, 0x01, 0x03, 0x02, 0x03, 0x63, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x05, 0x09, 0x19, 0x2b, 0x13, 0x01, 0x21, 0x07, 0x23, 0x01, 0x33, 0x07, 0x63, 0x01, 0x77, 0x01,
+	0xcf, 0x23, 0xd8, 0xfe, 0xcf, 0xd8, 0x23, 0xfe, 0xd8, 0x07, 0x53, 0xad, 0xfa, 0x07, 0xad, 0x00,
+	0x00, 0x01, 0x01, 0x27, 0xff, 0x85, 0x02, 0x20, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x35, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x0c, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x01,
+	0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x05, 0x03, 0x33, 0x13, 0x01, 0x58, 0x31, 0xc8, 0x31, 0x7b, 0x06, 0x43, 0xf9,
+	0xbd, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0xd8, 0x03, 0x46, 0x06, 0x2b, 0x00, 0x07,
+	0x00, 0x1c, 0x40, 0x19, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x63, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x00, 0x03, 0x03, 0x3a, 0x02, 0x4e, 0x11, 0x11, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x21,
+	0x37, 0x33, 0x01, 0x23, 0x37, 0x21, 0x01, 0xcf, 0xfe, 0x31, 0x23, 0xd8, 0x01, 0x31, 0xd8, 0x23,
+	0x01, 0xcf, 0xfe, 0xd8, 0xad, 0x05, 0xf9, 0xad, 0x00, 0x01, 0x00, 0xf4, 0x02, 0xbf, 0x04, 0xd0,
+	0x05, 0xc8, 0x00, 0x06, 0x00, 0x20, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x15, 0x04, 0x01, 0x02, 0x00,
+	0x4a, 0x02, 0x01, 0x02, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x03, 0x09,
+	0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x01, 0x01, 0x23, 0x03, 0x23, 0x01, 0xf4, 0x02, 0x89,
+	0x01, 0x53, 0xcf, 0xc4, 0x02, 0xfe, 0x88, 0x02, 0xbf, 0x03, 0x09, 0xfc, 0xf7, 0x01, 0xc4, 0xfe,
+	0x3c, 0x00, 0x00, 0x00, 0x00, 0x01, 0xff, 0xde, 0xff, 0x53, 0x04, 0x73, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x07, 0x37, 0x21, 0x07, 0x22, 0x22, 0x04, 0x73,
+	0x23, 0xad, 0xad, 0xad, 0x00, 0x01, 0x01, 0x8b, 0x05, 0x03, 0x03, 0x55, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x20, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x15, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x11, 0x10, 0x02, 0x09, 0x18, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x01, 0x23, 0x01, 0x21, 0x03, 0x55, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x05, 0x03, 0x01,
+	0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x16, 0x00, 0x75, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00,
+	0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x02, 0x02, 0x3b,
+	0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x22,
+	0x22, 0x11, 0x11, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37,
+	0x03, 0x4b, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb,
+	0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0xb6, 0xcf, 0x01, 0x2b, 0xf5,
+	0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x05, 0x38, 0x06, 0x2b, 0x00, 0x0d, 0x00, 0x16, 0x00, 0x80,
+	0xb5, 0x04, 0x01, 0x05, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x01,
+	0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04,
+	0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d,
+	0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x41, 0x4d, 0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42,
+	0x03, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x22, 0x22, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b,
+	0x21, 0x21, 0x01, 0x21, 0x03, 0x36, 0x33, 0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x16,
+	0x33, 0x32, 0x13, 0x12, 0x23, 0x22, 0x07, 0x01, 0xbc, 0xfe, 0xd8, 0x01, 0x3b, 0x01, 0x28, 0x84,
+	0xc6, 0xbc, 0xac, 0x97, 0x31, 0x39, 0xfe, 0xb0, 0xf3, 0x51, 0x5b, 0x6c, 0x37, 0xf6, 0x4c, 0x47,
+	0xb3, 0x78, 0x95, 0x06, 0x2b, 0xfd, 0x69, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe, 0xe4, 0xfe, 0xc0, 0xc9,
+	0x13, 0x01, 0x7d, 0x01, 0x61, 0xaf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x81, 0xff, 0xe7, 0x04, 0xee,
+	0x04, 0x63, 0x00, 0x13, 0x00, 0x29, 0x40, 0x26, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x03, 0x02,
+	0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x23, 0x23, 0x23, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x25,
+	0x07, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x04, 0x4d, 0x29, 0xdd, 0xa3, 0xfe, 0xde, 0xfe, 0xff, 0x36, 0x73, 0x02, 0x75,
+	0xae, 0xa1, 0x2a, 0xc6, 0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0xe5, 0xcd, 0x31, 0x01,
+	0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x00, 0x02, 0x00, 0x86,
+	0xff, 0xe7, 0x05, 0x8a, 0x06, 0x2b, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x80, 0xb5, 0x0a, 0x01, 0x04,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00,
+	0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x02, 0x02,
+	0x3a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x39,
+	0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59,
+	0x40, 0x09, 0x22, 0x22, 0x11, 0x12, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22,
+	0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x13, 0x21, 0x01, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03,
+	0x02, 0x33, 0x32, 0x37, 0x03, 0x4b, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51,
+	0x7d, 0x60, 0x01, 0x28, 0xfe, 0xc5, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78,
+	0x94, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0x01, 0xe1, 0xf9, 0xd5, 0x03,
+	0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x04, 0xaa,
+	0x04, 0x63, 0x00, 0x10, 0x00, 0x15, 0x00, 0x29, 0x40, 0x26, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04,
+	0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x06, 0x09, 0x1c,
+	0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02,
+	0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37,
+	0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01,
+	0x65, 0x38, 0x9f, 0xa8, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1,
+	0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x00, 0x01, 0x00, 0xa6, 0x00, 0x00, 0x04, 0x1b,
+	0x06, 0x44, 0x00, 0x13, 0x00, 0x85, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3a, 0x4d, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07,
+	0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02,
+	0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x07, 0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x02, 0x00, 0x03,
+	0x01, 0x02, 0x03, 0x69, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x07, 0x01, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x13, 0x00,
+	0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x08, 0x09, 0x1c, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33,
+	0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x07, 0x33, 0x07, 0x23, 0x03, 0xa6,
+	0xb6, 0x72, 0x25, 0x72, 0x10, 0x55, 0x01, 0x86, 0x54, 0x5b, 0x27, 0x4b, 0x41, 0x7f, 0x2e, 0x12,
+	0xb9, 0x25, 0xb9, 0xb6, 0x03, 0x91, 0xb9, 0x4f, 0x01, 0xab, 0x1a, 0xc0, 0x21, 0xe7, 0x5a, 0xb9,
+	0xfc, 0x6f, 0x00, 0x00, 0x00, 0x02, 0x00, 0x37, 0xfe, 0x5c, 0x05, 0x2a, 0x04, 0x63, 0x00, 0x08,
+	0x00, 0x22, 0x00, 0x95, 0x40, 0x0a, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x02, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d, 0x00,
+	0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0a, 0x23, 0x25,
+	0x11, 0x24, 0x23, 0x22, 0x21, 0x07, 0x09, 0x1d, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33,
+	0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x06,
+	0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x03, 0xdf, 0x6b, 0x37,
+	0xf6, 0x4a, 0x44, 0xb3, 0x78, 0x94, 0x28, 0xc5, 0xbc, 0xaa, 0x9a, 0x2f, 0x37, 0x01, 0x53, 0xf0,
+	0x51, 0x7d, 0x01, 0x28, 0xa8, 0x32, 0x68, 0x69, 0xad, 0xfe, 0xf4, 0xc1, 0xce, 0x2c, 0xc8, 0x9d,
+	0xa3, 0xae, 0x1f, 0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec,
+	0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d,
+	0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x05, 0x3c, 0x06, 0x2b, 0x00, 0x10, 0x00, 0x50, 0xb5, 0x03,
+	0x01, 0x03, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3a,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01,
+	0x21, 0x03, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x94,
+	0x01, 0x3b, 0x01, 0x28, 0x84, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x15, 0x23,
+	0x44, 0x78, 0xab, 0x8f, 0x06, 0x2b, 0xfd, 0x69, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b,
+	0x50, 0xae, 0xfd, 0x34, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0x01, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x4e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09,
+	0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x03, 0x13, 0x21, 0x03, 0x94, 0xdb, 0x01, 0x28, 0xdb, 0x2f,
+	0x38, 0x01, 0x3c, 0x38, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x12, 0x01, 0x19, 0xfe, 0xe7, 0x00, 0x00,
+	0x00, 0x02, 0xff, 0x25, 0xfe, 0x5d, 0x03, 0x01, 0x06, 0x2b, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x31,
+	0x40, 0x2e, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03,
+	0x03, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x43, 0x02, 0x4e, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06, 0x09, 0x1a,
+	0x2b, 0x03, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x21, 0x03, 0x02, 0x21, 0x22, 0x01, 0x13,
+	0x21, 0x03, 0xdb, 0x27, 0x5f, 0x33, 0x4d, 0x4e, 0x1b, 0xdb, 0x01, 0x28, 0xd8, 0x57, 0xfe, 0x7b,
+	0x57, 0x02, 0x01, 0x38, 0x01, 0x3c, 0x38, 0xfe, 0x85, 0xc6, 0x35, 0x64, 0x86, 0x04, 0x4a, 0xfb,
+	0xc9, 0xfe, 0x4a, 0x06, 0xb5, 0x01, 0x19, 0xfe, 0xe7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x04, 0xf8, 0x06, 0x2b, 0x00, 0x0c, 0x00, 0x47, 0xb7, 0x0a, 0x07, 0x03, 0x03, 0x02,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x12, 0x00,
+	0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x13, 0x11, 0x05, 0x09, 0x19,
+	0x2b, 0x33, 0x01, 0x21, 0x03, 0x33, 0x01, 0x33, 0x01, 0x01, 0x21, 0x03, 0x23, 0x03, 0x94, 0x01,
+	0x3b, 0x01, 0x28, 0xc6, 0x13, 0x01, 0xbf, 0xf5, 0xfe, 0x61, 0x01, 0x11, 0xfe, 0xc4, 0xf2, 0x13,
+	0x6d, 0x06, 0x2b, 0xfc, 0x1f, 0x02, 0x00, 0xfe, 0x23, 0xfd, 0x93, 0x02, 0x25, 0xfd, 0xdb, 0x00,
+	0x00, 0x01, 0x00, 0x82, 0xff, 0xe7, 0x02, 0xea, 0x06, 0x2b, 0x00, 0x0c, 0x00, 0x1f, 0x40, 0x1c,
+	0x00, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x23, 0x12, 0x22, 0x03, 0x09, 0x19, 0x2b, 0x25, 0x07, 0x06,
+	0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x02, 0x73, 0x24, 0x48, 0x4c, 0xfe,
+	0xc7, 0x47, 0xf9, 0x01, 0x28, 0xf1, 0x19, 0x1b, 0x42, 0x1b, 0xb6, 0xb6, 0x19, 0x01, 0x68, 0x04,
+	0xdc, 0xfb, 0x4b, 0x7c, 0x4d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x07, 0x75,
+	0x04, 0x63, 0x00, 0x1c, 0x00, 0x79, 0xb6, 0x07, 0x03, 0x02, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x16, 0x06, 0x01, 0x04, 0x04, 0x00, 0x61, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x3b, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x01, 0x41, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00,
+	0x00, 0x1c, 0x00, 0x1c, 0x23, 0x12, 0x23, 0x12, 0x22, 0x22, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x33,
+	0x13, 0x21, 0x07, 0x36, 0x33, 0x32, 0x17, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26,
+	0x23, 0x22, 0x07, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x94, 0xdb, 0x01, 0x28,
+	0x24, 0xaf, 0xcb, 0xdb, 0x17, 0xa4, 0xd7, 0x01, 0x1b, 0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x16, 0x1a,
+	0x3c, 0x7f, 0x82, 0x8f, 0xfe, 0xd8, 0x8c, 0x16, 0x1b, 0x3b, 0x7f, 0x83, 0x8f, 0x04, 0x4a, 0xb6,
+	0xcf, 0xd2, 0xd2, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6e, 0x4d, 0xae, 0xfd, 0x34, 0x02, 0xbf,
+	0x6e, 0x4d, 0xae, 0xfd, 0x34, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x05, 0x3c,
+	0x04, 0x63, 0x00, 0x10, 0x00, 0x6c, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x05,
+	0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04,
+	0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x09,
+	0x1a, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23,
+	0x22, 0x07, 0x03, 0x94, 0xdb, 0x01, 0x28, 0x24, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b, 0xfe, 0xd8,
+	0x8c, 0x15, 0x23, 0x44, 0x78, 0xab, 0x8f, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02,
+	0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x00, 0x00, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b,
+	0x04, 0x63, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x02, 0x66, 0xf6,
+	0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25,
+	0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01,
+	0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3,
+	0xb1, 0xd4, 0x00, 0x00, 0x00, 0x02, 0x00, 0x45, 0xfe, 0x75, 0x05, 0x38, 0x04, 0x63, 0x00, 0x0e,
+	0x00, 0x17, 0x00, 0x5d, 0x40, 0x0a, 0x04, 0x01, 0x05, 0x01, 0x0e, 0x01, 0x03, 0x04, 0x02, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x3d,
+	0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00,
+	0x00, 0x3d, 0x00, 0x4e, 0x59, 0x40, 0x09, 0x22, 0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09, 0x1c,
+	0x2b, 0x01, 0x21, 0x01, 0x21, 0x07, 0x36, 0x33, 0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27,
+	0x37, 0x16, 0x33, 0x32, 0x13, 0x12, 0x23, 0x22, 0x07, 0x01, 0x6d, 0xfe, 0xd8, 0x01, 0x2a, 0x01,
+	0x28, 0x24, 0xc6, 0xbc, 0xac, 0x97, 0x31, 0x39, 0xfe, 0xb0, 0xf3, 0x51, 0x7e, 0x23, 0x6c, 0x37,
+	0xf6, 0x4c, 0x47, 0xb3, 0x78, 0x95, 0xfe, 0x75, 0x05, 0xd5, 0xb6, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe,
+	0xe4, 0xfe, 0xc0, 0x19, 0xb0, 0x13, 0x01, 0x7d, 0x01, 0x61, 0xaf, 0x00, 0x00, 0x02, 0x00, 0x86,
+	0xfe, 0x75, 0x05, 0x2a, 0x04, 0x63, 0x00, 0x0d, 0x00, 0x16, 0x00, 0x51, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x3d, 0x03, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x3d, 0x03, 0x4e,
+	0x59, 0x40, 0x09, 0x22, 0x22, 0x11, 0x11, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23,
+	0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x01, 0x21, 0x01, 0x26, 0x23, 0x22, 0x03,
+	0x02, 0x33, 0x32, 0x37, 0x03, 0x4b, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51,
+	0x7d, 0x01, 0x28, 0xfe, 0xd6, 0xfe, 0xd8, 0x01, 0x07, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78,
+	0x94, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfa, 0x2b, 0x05, 0x25, 0x13,
+	0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x03, 0xdc,
+	0x04, 0x63, 0x00, 0x0d, 0x00, 0x88, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0e, 0x03, 0x01, 0x02,
+	0x00, 0x08, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x07, 0x01, 0x00, 0x4a, 0x1b, 0x40, 0x0e, 0x07, 0x01,
+	0x00, 0x01, 0x03, 0x01, 0x02, 0x00, 0x08, 0x01, 0x03, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x13,
+	0x21, 0x07, 0x36, 0x33, 0x32, 0x17, 0x03, 0x26, 0x23, 0x22, 0x07, 0x03, 0xad, 0xdb, 0x01, 0x28,
+	0x24, 0x7c, 0xa3, 0x17, 0x1a, 0x35, 0x33, 0x26, 0x77, 0x72, 0x90, 0x04, 0x4a, 0xb6, 0xcf, 0x06,
+	0xfe, 0xf8, 0x17, 0x9a, 0xfd, 0x2e, 0x00, 0x00, 0x00, 0x01, 0x00, 0x82, 0xff, 0xe7, 0x04, 0x8d,
+	0x04, 0x63, 0x00, 0x1e, 0x00, 0x2a, 0x40, 0x27, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00,
+	0x02, 0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x29, 0x23, 0x28, 0x22, 0x04, 0x09, 0x1a, 0x2b,
+	0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17,
+	0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x82,
+	0x2b, 0xd3, 0x9d, 0xdd, 0x18, 0x0f, 0xa1, 0x5c, 0xbc, 0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0,
+	0x28, 0xd1, 0x66, 0xcf, 0x16, 0x0e, 0x95, 0x4f, 0xcc, 0x78, 0x18, 0x20, 0xfe, 0xc9, 0xe8, 0xcc,
+	0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70,
+	0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x00, 0x01, 0x00, 0x9d, 0xff, 0xe7, 0x03, 0x77,
+	0x05, 0x43, 0x00, 0x14, 0x00, 0x27, 0x40, 0x24, 0x0b, 0x01, 0x02, 0x4a, 0x04, 0x01, 0x01, 0x01,
+	0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x23, 0x11, 0x13, 0x11, 0x12, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x07, 0x06,
+	0x23, 0x20, 0x13, 0x13, 0x23, 0x37, 0x33, 0x37, 0x25, 0x07, 0x33, 0x07, 0x23, 0x03, 0x06, 0x16,
+	0x33, 0x32, 0x02, 0xbe, 0x25, 0x77, 0x4c, 0xfe, 0xc7, 0x47, 0x74, 0x78, 0x25, 0x78, 0x2b, 0x01,
+	0x2f, 0x32, 0xd2, 0x25, 0xd2, 0x6c, 0x19, 0x1b, 0x42, 0x28, 0xba, 0xb9, 0x1a, 0x01, 0x68, 0x02,
+	0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xfd, 0xe5, 0x7c, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x00, 0x83,
+	0xff, 0xe7, 0x05, 0x2b, 0x04, 0x4a, 0x00, 0x10, 0x00, 0x7a, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5,
+	0x01, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x13, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x05, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x17, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x17, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22,
+	0x06, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0x37, 0x13, 0x21, 0x03, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28,
+	0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08,
+	0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xf4,
+	0x00, 0x00, 0x05, 0x34, 0x04, 0x4a, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x03, 0x01,
+	0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x03, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x11,
+	0x04, 0x09, 0x18, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01, 0xa3, 0xaf, 0x01, 0x38,
+	0x7a, 0x01, 0xb2, 0xdc, 0xfd, 0x97, 0x04, 0x4a, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x19, 0x00, 0x00, 0x06, 0xd7, 0x04, 0x4a, 0x00, 0x0c, 0x00, 0x42, 0xb7, 0x0b,
+	0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0f, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x0f,
+	0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b,
+	0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x01, 0x48, 0x2f,
+	0x01, 0x0b, 0x1f, 0x01, 0x5b, 0x01, 0x00, 0x0f, 0x01, 0x63, 0xc7, 0xfe, 0x07, 0xfe, 0xe5, 0x0e,
+	0xfe, 0xaf, 0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1,
+	0xfd, 0x0f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x30, 0x00, 0x00, 0x05, 0x04, 0x04, 0x4a, 0x00, 0x0b,
+	0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x13, 0x21, 0x03, 0x01, 0x30,
+	0x01, 0xd4, 0xe9, 0x01, 0x51, 0x93, 0x01, 0x15, 0xf0, 0xfe, 0x4f, 0xef, 0xfe, 0xaf, 0x9b, 0xfe,
+	0xcf, 0x02, 0x27, 0x02, 0x23, 0xfe, 0xa4, 0x01, 0x5c, 0xfd, 0xe4, 0xfd, 0xd2, 0x01, 0x6b, 0xfe,
+	0x95, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x5c, 0xfe, 0x75, 0x05, 0x34, 0x04, 0x4a, 0x00, 0x07,
+	0x00, 0x1b, 0x40, 0x18, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x11, 0x12, 0x11, 0x03, 0x09, 0x19, 0x2b, 0x21, 0x03, 0x21,
+	0x13, 0x01, 0x33, 0x01, 0x21, 0x01, 0xa3, 0xaf, 0x01, 0x38, 0x70, 0x01, 0xbc, 0xdc, 0xfc, 0x56,
+	0xfe, 0xd2, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6f,
+	0x00, 0x00, 0x04, 0x6c, 0x04, 0x4a, 0x00, 0x09, 0x00, 0x46, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b,
+	0x33, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x6f, 0x27, 0x02, 0x66, 0xfe, 0x45,
+	0x25, 0x03, 0x06, 0x25, 0xfd, 0x9a, 0x01, 0xe3, 0x27, 0xc5, 0x02, 0xcc, 0xb9, 0xb9, 0xfd, 0x34,
+	0xc5, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xaf, 0xfe, 0xd8, 0x03, 0xdc, 0x06, 0x2b, 0x00, 0x28,
+	0x00, 0x2f, 0x40, 0x2c, 0x14, 0x01, 0x05, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x05, 0x03, 0x00,
+	0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3a, 0x02, 0x4e, 0x28, 0x26, 0x1f, 0x1e, 0x1d, 0x1c, 0x11, 0x17, 0x20, 0x06, 0x09, 0x19,
+	0x2b, 0x13, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x12, 0x21, 0x07, 0x22, 0x07, 0x06,
+	0x07, 0x07, 0x06, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x33, 0x07,
+	0x20, 0x13, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36, 0x23, 0x23, 0xf6, 0x3e, 0x8a, 0x1b, 0x0d, 0x04,
+	0x05, 0x04, 0x10, 0x3d, 0x01, 0xb6, 0x23, 0xbe, 0x19, 0x06, 0x02, 0x02, 0x03, 0x0e, 0x29, 0xdc,
+	0xae, 0x29, 0x0f, 0x19, 0x20, 0x16, 0x06, 0x19, 0xbe, 0x23, 0xfe, 0x4a, 0x3d, 0x10, 0x28, 0x29,
+	0x22, 0x0e, 0x1a, 0x8a, 0x3e, 0x02, 0xe4, 0x83, 0x45, 0x49, 0x5c, 0x58, 0x53, 0x01, 0x2f, 0xad,
+	0x80, 0x1d, 0x3d, 0x56, 0x44, 0x49, 0xcc, 0x73, 0x74, 0xcc, 0x49, 0x45, 0x55, 0x3d, 0x1d, 0x80,
+	0xad, 0x01, 0x2f, 0x53, 0x58, 0x5c, 0x49, 0x46, 0x82, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x75,
+	0xfe, 0xd8, 0x02, 0xc8, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x00,
+	0x01, 0x86, 0x00, 0x00, 0x00, 0x3a, 0x00, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x13, 0x01, 0x33, 0x01, 0x75, 0x01, 0x77, 0xdc, 0xfe, 0x89, 0xfe, 0xd8, 0x07,
+	0x53, 0xf8, 0xad, 0x00, 0x00, 0x01, 0x00, 0x3f, 0xfe, 0xd8, 0x03, 0x6c, 0x06, 0x2b, 0x00, 0x28,
+	0x00, 0x2f, 0x40, 0x2c, 0x14, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x00, 0x05, 0x00, 0x00, 0x02, 0x05,
+	0x00, 0x69, 0x00, 0x02, 0x00, 0x01, 0x02, 0x01, 0x65, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04,
+	0x04, 0x3a, 0x03, 0x4e, 0x28, 0x26, 0x1f, 0x1e, 0x1d, 0x1c, 0x11, 0x17, 0x20, 0x06, 0x09, 0x19,
+	0x2b, 0x01, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x16, 0x07, 0x02, 0x21, 0x37, 0x32, 0x37, 0x36,
+	0x37, 0x37, 0x36, 0x37, 0x36, 0x37, 0x26, 0x37, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36, 0x23, 0x37,
+	0x20, 0x03, 0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x33, 0x33, 0x03, 0x25, 0x3e, 0x8a, 0x1a, 0x0e,
+	0x04, 0x05, 0x04, 0x10, 0x3d, 0xfe, 0x4a, 0x23, 0xbe, 0x19, 0x06, 0x02, 0x04, 0x01, 0x0f, 0x29,
+	0xdc, 0xae, 0x29, 0x0e, 0x1a, 0x1f, 0x16, 0x06, 0x19, 0xbe, 0x23, 0x01, 0xb6, 0x3d, 0x10, 0x28,
+	0x29, 0x22, 0x0e, 0x1a, 0x8a, 0x3e, 0x02, 0x1f, 0x83, 0x45, 0x49, 0x5c, 0x58, 0x53, 0xfe, 0xd1,
+	0xad, 0x80, 0x1d, 0x3d, 0x56, 0x44, 0x49, 0xcc, 0x74, 0x73, 0xcc, 0x49, 0x45, 0x55, 0x3e, 0x1c,
+	0x80, 0xad, 0xfe, 0xd1, 0x53, 0x58, 0x5c, 0x49, 0x46, 0x82, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa8,
+	0x01, 0x8a, 0x04, 0xef, 0x03, 0x17, 0x00, 0x15, 0x00, 0x34, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x29,
+	0x0c, 0x01, 0x03, 0x00, 0x01, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00,
+	0x03, 0x69, 0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x01,
+	0x02, 0x51, 0x23, 0x24, 0x23, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x23,
+	0x12, 0x21, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x35, 0x33, 0x02, 0x21, 0x22, 0x27, 0x27,
+	0x26, 0x23, 0x22, 0x07, 0x01, 0x19, 0x71, 0x46, 0x01, 0x25, 0x76, 0x5b, 0x45, 0x4e, 0x5a, 0x90,
+	0x1d, 0x71, 0x45, 0xfe, 0xdb, 0x76, 0x5b, 0x46, 0x4d, 0x5a, 0x8f, 0x1d, 0x01, 0xbc, 0x01, 0x5b,
+	0x4e, 0x3b, 0x43, 0x90, 0x09, 0xfe, 0xa6, 0x4d, 0x3b, 0x43, 0x90, 0x00, 0x00, 0x02, 0x00, 0x6a,
+	0xfe, 0x82, 0x02, 0xba, 0x04, 0x4a, 0x00, 0x03, 0x00, 0x09, 0x00, 0x2c, 0x40, 0x29, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x3d, 0x02, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x09, 0x04, 0x09, 0x07, 0x06, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x03, 0x21, 0x13, 0x13, 0x03, 0x03, 0x21,
+	0x13, 0x13, 0x02, 0xba, 0x33, 0xfe, 0xd8, 0x33, 0xa3, 0x68, 0x3b, 0xfe, 0xd8, 0x3b, 0xca, 0x04,
+	0x4a, 0xff, 0x00, 0x01, 0x00, 0xfe, 0x5d, 0xfd, 0x03, 0xfe, 0xd8, 0x01, 0x28, 0x02, 0xfd, 0x00,
+	0x00, 0x02, 0x00, 0xf7, 0x00, 0x00, 0x04, 0xff, 0x05, 0xc8, 0x00, 0x16, 0x00, 0x1b, 0x00, 0x70,
+	0x40, 0x0c, 0x18, 0x0c, 0x02, 0x03, 0x02, 0x12, 0x0d, 0x02, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x00, 0x05,
+	0x03, 0x00, 0x05, 0x7e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a, 0x00, 0x01, 0x01, 0x38,
+	0x4d, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x01, 0x02, 0x01, 0x85,
+	0x00, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x00, 0x05, 0x03, 0x00, 0x05, 0x7e, 0x00,
+	0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x16, 0x00, 0x16, 0x11, 0x13, 0x11, 0x16, 0x11, 0x07, 0x09, 0x1b, 0x2b,
+	0x21, 0x37, 0x26, 0x02, 0x13, 0x12, 0x00, 0x37, 0x37, 0x33, 0x07, 0x16, 0x17, 0x07, 0x26, 0x27,
+	0x03, 0x36, 0x37, 0x07, 0x06, 0x07, 0x07, 0x03, 0x13, 0x06, 0x03, 0x02, 0x02, 0x83, 0x23, 0xe6,
+	0xc9, 0x33, 0x34, 0x01, 0x38, 0xf6, 0x1e, 0x94, 0x1f, 0x76, 0x6a, 0x2b, 0x72, 0x69, 0x98, 0x77,
+	0x7c, 0x28, 0x7a, 0x78, 0x23, 0x46, 0x93, 0xe0, 0x3b, 0x3c, 0xb0, 0x0e, 0x01, 0x32, 0x01, 0x01,
+	0x01, 0x02, 0x01, 0x26, 0x16, 0x99, 0x9b, 0x08, 0x20, 0xd8, 0x3a, 0x07, 0xfd, 0x08, 0x08, 0x2f,
+	0xc9, 0x27, 0x09, 0xb4, 0x01, 0x87, 0x02, 0xe4, 0x42, 0xfe, 0xd6, 0xfe, 0xd6, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x66, 0x00, 0x00, 0x05, 0x21, 0x05, 0xed, 0x00, 0x1a, 0x00, 0x68, 0x40, 0x0a,
+	0x0c, 0x01, 0x03, 0x02, 0x0d, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x20, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07,
+	0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x05,
+	0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x3c,
+	0x07, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x13, 0x11, 0x12, 0x23, 0x22,
+	0x11, 0x14, 0x09, 0x09, 0x1d, 0x2b, 0x33, 0x37, 0x36, 0x37, 0x37, 0x23, 0x37, 0x33, 0x37, 0x12,
+	0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x07, 0x33, 0x07, 0x23, 0x06, 0x06, 0x07, 0x21,
+	0x07, 0x66, 0x2e, 0xce, 0x30, 0x24, 0xa3, 0x25, 0xa3, 0x22, 0x5d, 0x01, 0xc1, 0x79, 0x8d, 0x29,
+	0x6e, 0x70, 0xbd, 0x2b, 0x2f, 0xc3, 0x25, 0xc3, 0x28, 0x75, 0x98, 0x02, 0x7c, 0x2e, 0xea, 0x2e,
+	0xec, 0xb5, 0xb9, 0xaa, 0x01, 0xd1, 0x17, 0xcb, 0x29, 0xd6, 0xec, 0xb9, 0xc5, 0xb0, 0x5a, 0xea,
+	0x00, 0x02, 0x00, 0x3c, 0x00, 0xad, 0x05, 0x5c, 0x05, 0x1b, 0x00, 0x1d, 0x00, 0x29, 0x00, 0x46,
+	0x40, 0x43, 0x1a, 0x17, 0x03, 0x03, 0x02, 0x01, 0x13, 0x0f, 0x0a, 0x07, 0x04, 0x00, 0x03, 0x02,
+	0x4c, 0x19, 0x18, 0x02, 0x01, 0x04, 0x01, 0x4a, 0x12, 0x11, 0x09, 0x08, 0x04, 0x00, 0x49, 0x00,
+	0x01, 0x04, 0x01, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x00, 0x00, 0x03, 0x59, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x03, 0x00, 0x51, 0x1f, 0x1e, 0x25, 0x23, 0x1e, 0x29, 0x1f, 0x29,
+	0x2d, 0x2c, 0x05, 0x09, 0x18, 0x2b, 0x01, 0x37, 0x17, 0x07, 0x16, 0x07, 0x06, 0x07, 0x17, 0x07,
+	0x27, 0x31, 0x06, 0x23, 0x22, 0x27, 0x31, 0x07, 0x27, 0x37, 0x26, 0x37, 0x36, 0x37, 0x27, 0x37,
+	0x17, 0x36, 0x33, 0x32, 0x07, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26,
+	0x04, 0x0c, 0xef, 0x61, 0xee, 0x34, 0x1a, 0x19, 0x64, 0x9e, 0x92, 0x9e, 0x87, 0x7e, 0x7f, 0x69,
+	0xee, 0x62, 0xee, 0x33, 0x19, 0x1a, 0x63, 0x9f, 0x93, 0x9e, 0x87, 0x7f, 0x7e, 0xa8, 0x5e, 0x9d,
+	0x13, 0x12, 0x69, 0x5c, 0x5b, 0x9d, 0x12, 0x13, 0x68, 0x04, 0x55, 0xc6, 0x7a, 0xc6, 0x77, 0x80,
+	0x80, 0x76, 0xc7, 0x7a, 0xc6, 0x4b, 0x4b, 0xc6, 0x7a, 0xc7, 0x76, 0x80, 0x81, 0x76, 0xc6, 0x7a,
+	0xc6, 0x4b, 0xde, 0x82, 0x5d, 0x5b, 0x82, 0x82, 0x5c, 0x5b, 0x83, 0x00, 0x00, 0x01, 0x00, 0xeb,
+	0x00, 0x00, 0x05, 0x9a, 0x05, 0xc8, 0x00, 0x16, 0x00, 0x6b, 0xb5, 0x0b, 0x01, 0x03, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03,
+	0x02, 0x68, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x05, 0x01, 0x04, 0x04,
+	0x38, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x39, 0x0a, 0x4e, 0x1b, 0x40, 0x21, 0x05, 0x01, 0x04, 0x03,
+	0x04, 0x85, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x08, 0x01, 0x01, 0x09,
+	0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x0b, 0x01, 0x0a, 0x0a, 0x3c, 0x0a, 0x4e, 0x59, 0x40, 0x14,
+	0x00, 0x00, 0x00, 0x16, 0x00, 0x16, 0x15, 0x14, 0x11, 0x11, 0x11, 0x12, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x0c, 0x09, 0x1f, 0x2b, 0x21, 0x13, 0x23, 0x37, 0x33, 0x37, 0x23, 0x37, 0x33, 0x01, 0x21,
+	0x13, 0x01, 0x33, 0x01, 0x33, 0x07, 0x23, 0x07, 0x33, 0x07, 0x23, 0x03, 0x01, 0xa6, 0x3c, 0xf7,
+	0x1d, 0xf7, 0x1e, 0xf7, 0x1e, 0xf7, 0xfe, 0xec, 0x01, 0x57, 0xbb, 0x01, 0x81, 0xe0, 0xfd, 0xc9,
+	0xf7, 0x1e, 0xf7, 0x1e, 0xf7, 0x1d, 0xf7, 0x3c, 0x01, 0x2e, 0x94, 0x94, 0x94, 0x02, 0xde, 0xfe,
+	0x0d, 0x01, 0xf3, 0xfd, 0x22, 0x94, 0x94, 0x94, 0xfe, 0xd2, 0x00, 0x00, 0x00, 0x02, 0x00, 0x75,
+	0xfe, 0xd8, 0x02, 0xc8, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x29, 0x40, 0x26, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x00, 0x01, 0x63, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a,
+	0x03, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x13, 0x33, 0x03, 0x13, 0x13, 0x33, 0x03, 0x75, 0x94, 0xdc,
+	0x94, 0x07, 0x94, 0xdc, 0x94, 0xfe, 0xd8, 0x02, 0xe4, 0xfd, 0x1c, 0x04, 0x6f, 0x02, 0xe4, 0xfd,
+	0x1c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5e, 0xfe, 0xb2, 0x04, 0xcf, 0x05, 0xee, 0x00, 0x26,
+	0x00, 0x31, 0x00, 0x4e, 0x40, 0x0e, 0x14, 0x01, 0x02, 0x01, 0x2d, 0x21, 0x15, 0x0d, 0x01, 0x05,
+	0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03, 0x00,
+	0x03, 0x65, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x02, 0x4e, 0x1b, 0x40, 0x18,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x2c, 0x23, 0x2d, 0x22, 0x04, 0x09,
+	0x1a, 0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x36, 0x37,
+	0x26, 0x37, 0x36, 0x24, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x16,
+	0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x02, 0x21, 0x22, 0x01, 0x36, 0x37, 0x36, 0x26, 0x27, 0x27,
+	0x06, 0x07, 0x06, 0x17, 0x5e, 0x2c, 0xd1, 0x95, 0xe1, 0x1c, 0x11, 0x94, 0x7d, 0x94, 0x69, 0x19,
+	0x1f, 0xa5, 0x79, 0x1f, 0x21, 0x01, 0x1c, 0xc8, 0xa7, 0xb0, 0x29, 0xa6, 0x94, 0xde, 0x1c, 0x10,
+	0x82, 0x6e, 0xa8, 0x70, 0x1b, 0x1e, 0xa1, 0x7c, 0x22, 0x47, 0xfe, 0x0d, 0x93, 0x02, 0x06, 0x44,
+	0x0e, 0x0d, 0x3a, 0x60, 0xaf, 0x43, 0x0d, 0x18, 0xaa, 0xfe, 0xea, 0xdb, 0x59, 0x8c, 0x55, 0x4f,
+	0x42, 0x4f, 0xa9, 0x7a, 0x9b, 0x95, 0x65, 0x9c, 0xa4, 0xc9, 0x29, 0xcd, 0x3c, 0x88, 0x54, 0x41,
+	0x37, 0x54, 0xab, 0x84, 0x96, 0x9d, 0x68, 0xac, 0xfe, 0x9c, 0x02, 0xc5, 0x4f, 0x43, 0x41, 0x52,
+	0x35, 0x61, 0x49, 0x43, 0x76, 0x5c, 0x00, 0x00, 0x00, 0x02, 0x01, 0x14, 0x05, 0x03, 0x03, 0xc2,
+	0x05, 0xe1, 0x00, 0x03, 0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01,
+	0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37,
+	0x33, 0x07, 0x01, 0x14, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c, 0x05, 0x03, 0xde, 0xde, 0xde,
+	0xde, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x63, 0x00, 0x00, 0x06, 0xa5, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x2d, 0x00, 0x5c, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x51, 0x22, 0x01, 0x06, 0x05,
+	0x2d, 0x23, 0x02, 0x07, 0x06, 0x02, 0x4c, 0x00, 0x01, 0x00, 0x03, 0x05, 0x01, 0x03, 0x69, 0x00,
+	0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00, 0x07, 0x00, 0x04, 0x02, 0x07, 0x04, 0x69, 0x09,
+	0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x02,
+	0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x2c, 0x2a, 0x26, 0x24, 0x21, 0x1f, 0x1b, 0x19, 0x13, 0x11,
+	0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x20,
+	0x00, 0x13, 0x36, 0x00, 0x23, 0x22, 0x00, 0x07, 0x06, 0x00, 0x25, 0x06, 0x23, 0x22, 0x26, 0x37,
+	0x36, 0x24, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x37,
+	0x02, 0xe7, 0xfe, 0xd5, 0xfe, 0xa7, 0x3c, 0x3e, 0x02, 0x08, 0x01, 0x32, 0x01, 0x32, 0x01, 0x5c,
+	0x3d, 0x3e, 0xfd, 0xf6, 0xfe, 0xe0, 0x01, 0x05, 0x01, 0xb4, 0x34, 0x32, 0xfe, 0xde, 0xfe, 0xff,
+	0xfe, 0x4d, 0x33, 0x32, 0x01, 0x1f, 0x02, 0x5f, 0x96, 0x7b, 0xc5, 0xc1, 0x26, 0x26, 0x01, 0x15,
+	0xc4, 0x80, 0x8b, 0x19, 0x7f, 0x75, 0x78, 0xb9, 0x1c, 0x1c, 0x84, 0x86, 0x85, 0x68, 0x01, 0xb5,
+	0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe, 0xcf, 0xfe, 0xc9, 0xfe, 0x51, 0x7b, 0x01,
+	0x68, 0x01, 0x02, 0xfe, 0x01, 0x6a, 0xfe, 0x96, 0xff, 0xfc, 0xfe, 0x93, 0xed, 0x2a, 0xeb, 0xbf,
+	0xbf, 0xe2, 0x23, 0x7f, 0x38, 0xae, 0x8b, 0x89, 0xa9, 0x32, 0x00, 0x00, 0x00, 0x02, 0x00, 0xeb,
+	0x03, 0x37, 0x03, 0xb9, 0x05, 0xed, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x3b, 0x40, 0x38, 0x0d, 0x01,
+	0x01, 0x02, 0x17, 0x01, 0x04, 0x06, 0x02, 0x4c, 0x00, 0x03, 0x00, 0x02, 0x01, 0x03, 0x02, 0x69,
+	0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x07, 0x01, 0x04, 0x00, 0x00, 0x04, 0x59, 0x07,
+	0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x04, 0x00, 0x51, 0x22, 0x23, 0x24, 0x13, 0x23,
+	0x22, 0x23, 0x21, 0x08, 0x0b, 0x1e, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x21, 0x33,
+	0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x07, 0x03, 0x06, 0x33, 0x32, 0x37, 0x07,
+	0x06, 0x23, 0x22, 0x27, 0x37, 0x37, 0x23, 0x22, 0x07, 0x06, 0x33, 0x32, 0x02, 0x61, 0x5b, 0x61,
+	0x5b, 0x5f, 0x12, 0x2e, 0x01, 0x58, 0x30, 0x09, 0x13, 0x76, 0x70, 0x73, 0x1c, 0x81, 0x80, 0x01,
+	0x26, 0x2a, 0x3a, 0x0b, 0x28, 0x0b, 0x0d, 0x19, 0x42, 0x30, 0x77, 0x1a, 0x16, 0x17, 0x2e, 0x89,
+	0x13, 0x0f, 0x4b, 0x37, 0x03, 0x84, 0x4d, 0x70, 0x57, 0xe6, 0x2f, 0x5e, 0x3c, 0x8d, 0x2b, 0xcf,
+	0xfe, 0xde, 0x3b, 0x03, 0x7e, 0x0f, 0x4d, 0x77, 0x71, 0x5d, 0x4d, 0x00, 0x00, 0x02, 0x00, 0xae,
+	0x00, 0x69, 0x04, 0xe6, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x08, 0xb5, 0x0b, 0x09, 0x05,
+	0x03, 0x02, 0x32, 0x2b, 0x01, 0x01, 0x13, 0x07, 0x01, 0x01, 0x05, 0x01, 0x13, 0x07, 0x01, 0x01,
+	0x04, 0xe6, 0xfe, 0xb5, 0xc3, 0xa0, 0xfe, 0xb8, 0x01, 0xfa, 0xfe, 0xac, 0xfe, 0xb7, 0xc2, 0xa0,
+	0xfe, 0xb9, 0x01, 0xf9, 0x03, 0x78, 0xfe, 0xad, 0xfe, 0xad, 0x69, 0x01, 0xbc, 0x01, 0xbc, 0x6c,
+	0xfe, 0xb0, 0xfe, 0xad, 0x69, 0x01, 0xbc, 0x01, 0xbc, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xf7,
+	0x01, 0x28, 0x04, 0xf4, 0x03, 0x78, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x02, 0x01,
+	0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x13, 0x37,
+	0x21, 0x03, 0x23, 0x13, 0xf7, 0x22, 0x03, 0xdb, 0x76, 0xad, 0x54, 0x02, 0xcc, 0xac, 0xfd, 0xb0,
+	0x01, 0xa4, 0x00, 0x00, 0x00, 0x01, 0x00, 0xb6, 0x02, 0x1f, 0x02, 0xf1, 0x02, 0xd8, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x13, 0x37, 0x21, 0x07, 0xb6, 0x25, 0x02, 0x16, 0x25, 0x02, 0x1f, 0xb9, 0xb9, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x65, 0x00, 0x00, 0x06, 0xa7, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x2a, 0x00, 0x69, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x5e, 0x1e, 0x01, 0x06, 0x08, 0x01, 0x4c,
+	0x0c, 0x07, 0x02, 0x05, 0x06, 0x02, 0x06, 0x05, 0x02, 0x80, 0x00, 0x01, 0x00, 0x03, 0x04, 0x01,
+	0x03, 0x69, 0x00, 0x04, 0x00, 0x09, 0x08, 0x04, 0x09, 0x69, 0x00, 0x08, 0x00, 0x06, 0x05, 0x08,
+	0x06, 0x67, 0x0b, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x2a, 0x28, 0x26, 0x24, 0x18,
+	0x23, 0x18, 0x23, 0x22, 0x21, 0x20, 0x1f, 0x1b, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0d, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x20, 0x00, 0x13, 0x36, 0x00, 0x23,
+	0x22, 0x00, 0x07, 0x06, 0x00, 0x37, 0x13, 0x21, 0x32, 0x07, 0x06, 0x07, 0x13, 0x23, 0x03, 0x23,
+	0x03, 0x13, 0x33, 0x32, 0x37, 0x36, 0x23, 0x23, 0x02, 0xe9, 0xfe, 0xd5, 0xfe, 0xa7, 0x3c, 0x3e,
+	0x02, 0x08, 0x01, 0x32, 0x01, 0x32, 0x01, 0x5c, 0x3d, 0x3e, 0xfd, 0xf6, 0xfe, 0xe0, 0x01, 0x05,
+	0x01, 0xb4, 0x34, 0x32, 0xfe, 0xde, 0xfe, 0xff, 0xfe, 0x4d, 0x33, 0x32, 0x01, 0x1f, 0x18, 0xa0,
+	0x01, 0x5d, 0xf1, 0x28, 0x1f, 0xa6, 0x97, 0xbb, 0x7c, 0x83, 0x41, 0x57, 0x58, 0xb6, 0x1e, 0x15,
+	0xa3, 0x6b, 0x01, 0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe, 0xcf, 0xfe, 0xc9,
+	0xfe, 0x51, 0x7b, 0x01, 0x68, 0x01, 0x02, 0xfe, 0x01, 0x6a, 0xfe, 0x96, 0xff, 0xfc, 0xfe, 0x93,
+	0xdb, 0x03, 0x22, 0xc7, 0x9f, 0x46, 0xfe, 0x8a, 0x01, 0x47, 0xfe, 0xb9, 0x01, 0xb6, 0x93, 0x6b,
+	0x00, 0x01, 0x01, 0x6e, 0x05, 0xa3, 0x05, 0x65, 0x06, 0x44, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x21, 0x07, 0x01, 0x6e, 0x20, 0x03, 0xd7, 0x20, 0x05, 0xa3,
+	0xa1, 0xa1, 0x00, 0x00, 0x00, 0x02, 0x01, 0x5f, 0x03, 0xf4, 0x03, 0xdf, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x39, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x22, 0x26,
+	0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x06, 0x07, 0x06, 0x16, 0x02, 0x61, 0x78, 0x8a, 0x18, 0x18, 0xd1, 0x7a, 0x7a, 0x8b, 0x18,
+	0x19, 0xd0, 0x5f, 0x3f, 0x68, 0x0d, 0x0c, 0x46, 0x3d, 0x3d, 0x68, 0x0c, 0x0c, 0x45, 0x03, 0xf4,
+	0xaf, 0x79, 0x7a, 0xae, 0xae, 0x7a, 0x7c, 0xac, 0x94, 0x56, 0x3e, 0x3d, 0x57, 0x57, 0x3d, 0x3d,
+	0x57, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x68, 0x00, 0x00, 0x04, 0xe8, 0x04, 0xa0, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x70, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x03, 0x04, 0x85,
+	0x09, 0x01, 0x07, 0x02, 0x00, 0x02, 0x07, 0x00, 0x80, 0x05, 0x01, 0x03, 0x06, 0x01, 0x02, 0x07,
+	0x03, 0x02, 0x68, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x24, 0x00, 0x04, 0x03, 0x04, 0x85, 0x09, 0x01, 0x07, 0x02, 0x00, 0x02, 0x07, 0x00, 0x80,
+	0x05, 0x01, 0x03, 0x06, 0x01, 0x02, 0x07, 0x03, 0x02, 0x68, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x08,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04,
+	0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x09, 0x17, 0x2b, 0x33, 0x37, 0x21, 0x07, 0x01, 0x13, 0x21, 0x37, 0x21, 0x13, 0x33, 0x03,
+	0x21, 0x07, 0x21, 0x03, 0x68, 0x22, 0x03, 0xdb, 0x22, 0xfd, 0xf7, 0x47, 0xfe, 0x69, 0x23, 0x01,
+	0x97, 0x47, 0xad, 0x47, 0x01, 0x97, 0x23, 0xfe, 0x69, 0x47, 0xad, 0xad, 0x01, 0x28, 0x01, 0x66,
+	0xad, 0x01, 0x65, 0xfe, 0x9b, 0xad, 0xfe, 0x9a, 0x00, 0x01, 0x00, 0xf6, 0x02, 0xb5, 0x04, 0x74,
+	0x06, 0x43, 0x00, 0x1a, 0x00, 0x2b, 0x40, 0x28, 0x0c, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x00, 0x00,
+	0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03,
+	0x03, 0x55, 0x03, 0x4e, 0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x18, 0x23, 0x29, 0x05, 0x0b, 0x19,
+	0x2b, 0x13, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33,
+	0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x07, 0xf6, 0x23, 0x52, 0x6b, 0x5c,
+	0x6b, 0x6f, 0x4e, 0x0f, 0x23, 0xa5, 0x6b, 0xb4, 0x21, 0xb7, 0x89, 0xaa, 0xa1, 0x1e, 0x13, 0x7c,
+	0x8e, 0x57, 0xa8, 0x26, 0x01, 0xbd, 0x23, 0x02, 0xb5, 0x8c, 0x56, 0x48, 0x3f, 0x48, 0x4d, 0x53,
+	0x40, 0x8a, 0x42, 0x82, 0x33, 0x86, 0x76, 0x4c, 0x7b, 0x53, 0x32, 0x62, 0x58, 0x8c, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x25, 0x02, 0x9f, 0x04, 0x5e, 0x06, 0x43, 0x00, 0x1f, 0x00, 0x37, 0x40, 0x34,
+	0x11, 0x01, 0x02, 0x03, 0x19, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x56, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x28, 0x23, 0x23, 0x11, 0x23,
+	0x22, 0x06, 0x0b, 0x1c, 0x2b, 0x01, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37,
+	0x32, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x05, 0x04,
+	0x07, 0x06, 0x04, 0x23, 0x22, 0x01, 0x25, 0x21, 0x9a, 0x4f, 0xba, 0x24, 0x1a, 0x6f, 0xa1, 0x26,
+	0x1b, 0xa8, 0x93, 0x16, 0x1f, 0xa1, 0x77, 0x77, 0x1e, 0x85, 0x94, 0xa3, 0x97, 0x19, 0x27, 0xfe,
+	0xfa, 0x01, 0x0c, 0x2f, 0x1d, 0xff, 0x00, 0xb5, 0x7c, 0x02, 0xbb, 0x85, 0x33, 0x91, 0x64, 0x51,
+	0x6a, 0x43, 0x56, 0x7e, 0x32, 0x79, 0x28, 0x70, 0x65, 0x9c, 0x41, 0x34, 0xbc, 0x74, 0x8e, 0x00,
+	0x00, 0x01, 0x01, 0x55, 0x05, 0x03, 0x03, 0x9f, 0x06, 0x44, 0x00, 0x03, 0x00, 0x1f, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x01, 0x21,
+	0x01, 0x01, 0x55, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x01, 0x00, 0x45, 0xfe, 0x75, 0x05, 0x31, 0x04, 0x4a, 0x00, 0x14, 0x00, 0x7d, 0xb6, 0x13,
+	0x0f, 0x02, 0x03, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x04, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x06, 0x01,
+	0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x02, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04,
+	0x42, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04, 0x42,
+	0x4d, 0x06, 0x01, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x14,
+	0x00, 0x14, 0x23, 0x13, 0x12, 0x22, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x13, 0x01, 0x21, 0x03, 0x06,
+	0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x06, 0x17, 0x21, 0x26, 0x37, 0x06, 0x23, 0x22, 0x27, 0x03,
+	0x45, 0x01, 0x2a, 0x01, 0x28, 0x88, 0x28, 0x89, 0x74, 0x9b, 0x8a, 0x01, 0x28, 0x9a, 0x26, 0x23,
+	0xfe, 0xc0, 0x07, 0x0a, 0x7d, 0xac, 0x46, 0x2a, 0x51, 0xfe, 0x75, 0x05, 0xd5, 0xfd, 0x5a, 0xcc,
+	0xbf, 0x02, 0xb3, 0xfc, 0xfe, 0xc0, 0x88, 0x4c, 0x83, 0xe2, 0x1f, 0xfe, 0x69, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xec, 0xfe, 0xd8, 0x04, 0xd5, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4a, 0xb5, 0x01,
+	0x01, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x04, 0x03, 0x02, 0x01,
+	0x02, 0x01, 0x86, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x02, 0x4e, 0x1b, 0x40,
+	0x17, 0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x02, 0x5f, 0x00, 0x02, 0x00, 0x02, 0x4f, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0c, 0x00,
+	0x0c, 0x11, 0x11, 0x25, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x13, 0x24, 0x13, 0x36, 0x36, 0x33, 0x21,
+	0x01, 0x23, 0x01, 0x23, 0x01, 0x01, 0x9d, 0xcf, 0xfe, 0x80, 0x47, 0x24, 0xde, 0xdd, 0x01, 0xc3,
+	0xfe, 0x9d, 0xa1, 0x01, 0x48, 0x94, 0xfe, 0xb8, 0xfe, 0xd8, 0x04, 0x0c, 0x35, 0x01, 0x64, 0xb2,
+	0x99, 0xf9, 0x10, 0x06, 0x69, 0xf9, 0x97, 0x00, 0x00, 0x01, 0x01, 0x16, 0x03, 0x0a, 0x02, 0x97,
+	0x04, 0x4a, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3b, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x01,
+	0x13, 0x21, 0x03, 0x01, 0x16, 0x40, 0x01, 0x41, 0x40, 0x03, 0x0a, 0x01, 0x40, 0xfe, 0xc0, 0x00,
+	0x00, 0x01, 0x00, 0x2a, 0xfe, 0x50, 0x02, 0x14, 0x00, 0x00, 0x00, 0x11, 0x00, 0x38, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x2d, 0x02, 0x01, 0x03, 0x00, 0x0a, 0x01, 0x02, 0x03, 0x09, 0x01, 0x01, 0x02,
+	0x03, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x02, 0x01, 0x01, 0x02, 0x59,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x02, 0x01, 0x51, 0x22, 0x23, 0x25, 0x10, 0x04, 0x09,
+	0x1a, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27,
+	0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x23, 0x01, 0x0c, 0x88, 0x63, 0xe3, 0x1a, 0x0e, 0xa3,
+	0x69, 0x52, 0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x71, 0x19, 0x83, 0x45, 0x5e,
+	0x1e, 0x5b, 0x0f, 0x3c, 0x54, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x45, 0x02, 0xb5, 0x03, 0xf9,
+	0x06, 0x43, 0x00, 0x09, 0x00, 0x21, 0x40, 0x1e, 0x06, 0x04, 0x03, 0x03, 0x00, 0x4a, 0x01, 0x01,
+	0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x15, 0x11, 0x04, 0x0b, 0x18, 0x2b, 0x01, 0x37, 0x33, 0x13, 0x07, 0x37, 0x25, 0x03, 0x33,
+	0x07, 0x01, 0x45, 0x1a, 0xde, 0xa3, 0xe9, 0x1b, 0x01, 0xd2, 0xc9, 0xde, 0x1a, 0x02, 0xb5, 0x67,
+	0x02, 0x90, 0x2d, 0x6b, 0x59, 0xfc, 0xd9, 0x67, 0x00, 0x02, 0x00, 0xf1, 0x03, 0x37, 0x03, 0xdd,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x0b, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x02, 0x1d, 0x9b,
+	0x91, 0x20, 0x21, 0xdb, 0x9f, 0x9f, 0x92, 0x21, 0x20, 0xdc, 0x86, 0x79, 0x2c, 0x2b, 0x78, 0x77,
+	0x2c, 0x2b, 0x03, 0x37, 0xbb, 0xa0, 0xa2, 0xb9, 0xb9, 0xa1, 0xa3, 0xb9, 0x80, 0xdd, 0xda, 0xdb,
+	0xdc, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x67, 0x00, 0x69, 0x04, 0x9f, 0x03, 0xe1, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x08, 0xb5, 0x0b, 0x09, 0x05, 0x03, 0x02, 0x32, 0x2b, 0x37, 0x01, 0x03, 0x37,
+	0x01, 0x01, 0x25, 0x01, 0x03, 0x37, 0x01, 0x01, 0x67, 0x01, 0x4a, 0xc2, 0xa0, 0x01, 0x47, 0xfe,
+	0x07, 0x01, 0x53, 0x01, 0x4a, 0xc3, 0xa0, 0x01, 0x48, 0xfe, 0x06, 0xd2, 0x01, 0x53, 0x01, 0x53,
+	0x69, 0xfe, 0x44, 0xfe, 0x44, 0x6c, 0x01, 0x50, 0x01, 0x53, 0x69, 0xfe, 0x44, 0xfe, 0x44, 0x00,
+	0x00, 0x04, 0x00, 0x9f, 0xff, 0xdb, 0x06, 0xc1, 0x05, 0xed, 0x00, 0x05, 0x00, 0x09, 0x00, 0x14,
+	0x00, 0x17, 0x00, 0xd9, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x04, 0x01, 0x17,
+	0x01, 0x00, 0x04, 0x02, 0x4c, 0x04, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x2c,
+	0x00, 0x01, 0x04, 0x01, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x09, 0x01, 0x00, 0x05, 0x00, 0x85,
+	0x0b, 0x07, 0x0a, 0x03, 0x02, 0x03, 0x03, 0x02, 0x71, 0x08, 0x01, 0x05, 0x03, 0x03, 0x05, 0x57,
+	0x08, 0x01, 0x05, 0x05, 0x03, 0x60, 0x06, 0x01, 0x03, 0x05, 0x03, 0x50, 0x1b, 0x4b, 0xb0, 0x0e,
+	0x50, 0x58, 0x40, 0x2b, 0x00, 0x01, 0x04, 0x01, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x09, 0x01,
+	0x00, 0x05, 0x00, 0x85, 0x0b, 0x07, 0x0a, 0x03, 0x02, 0x03, 0x02, 0x86, 0x08, 0x01, 0x05, 0x03,
+	0x03, 0x05, 0x57, 0x08, 0x01, 0x05, 0x05, 0x03, 0x60, 0x06, 0x01, 0x03, 0x05, 0x03, 0x50, 0x1b,
+	0x40, 0x31, 0x00, 0x01, 0x04, 0x01, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x09, 0x01, 0x00, 0x05,
+	0x00, 0x85, 0x0b, 0x01, 0x07, 0x03, 0x02, 0x03, 0x07, 0x02, 0x80, 0x0a, 0x01, 0x02, 0x02, 0x84,
+	0x08, 0x01, 0x05, 0x03, 0x03, 0x05, 0x57, 0x08, 0x01, 0x05, 0x05, 0x03, 0x60, 0x06, 0x01, 0x03,
+	0x05, 0x03, 0x50, 0x59, 0x59, 0x40, 0x21, 0x0a, 0x0a, 0x06, 0x06, 0x00, 0x00, 0x16, 0x15, 0x0a,
+	0x14, 0x0a, 0x14, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0c, 0x0b, 0x06, 0x09, 0x06, 0x09, 0x08,
+	0x07, 0x00, 0x05, 0x00, 0x05, 0x0c, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x13, 0x07,
+	0x37, 0x25, 0x03, 0x01, 0x01, 0x33, 0x01, 0x25, 0x37, 0x21, 0x37, 0x01, 0x33, 0x03, 0x33, 0x07,
+	0x23, 0x07, 0x01, 0x33, 0x13, 0x01, 0x7d, 0x8f, 0xe9, 0x1a, 0x01, 0xd3, 0xb5, 0xfe, 0x44, 0x05,
+	0x8a, 0x98, 0xfa, 0x75, 0x03, 0xcf, 0x2d, 0xfe, 0x5c, 0x21, 0x02, 0x00, 0xca, 0x63, 0x5c, 0x21,
+	0x5c, 0x2d, 0xfe, 0x8e, 0xfe, 0x3d, 0x02, 0x67, 0x02, 0xc9, 0x37, 0x85, 0x6f, 0xfc, 0x7a, 0xfd,
+	0x74, 0x06, 0x12, 0xf9, 0xee, 0x25, 0xe2, 0xa6, 0x01, 0xf0, 0xfe, 0x10, 0xa6, 0xe2, 0x01, 0x88,
+	0x01, 0x30, 0x00, 0x00, 0x00, 0x03, 0x00, 0x7a, 0xff, 0xdb, 0x07, 0x01, 0x05, 0xed, 0x00, 0x05,
+	0x00, 0x09, 0x00, 0x23, 0x00, 0x9a, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x04,
+	0x01, 0x15, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x04, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x0e, 0x50, 0x58,
+	0x40, 0x29, 0x00, 0x01, 0x04, 0x01, 0x85, 0x07, 0x01, 0x00, 0x03, 0x05, 0x03, 0x00, 0x05, 0x80,
+	0x00, 0x04, 0x00, 0x03, 0x00, 0x04, 0x03, 0x6a, 0x00, 0x05, 0x02, 0x02, 0x05, 0x57, 0x00, 0x05,
+	0x05, 0x02, 0x5f, 0x09, 0x06, 0x08, 0x03, 0x02, 0x05, 0x02, 0x4f, 0x1b, 0x40, 0x2d, 0x00, 0x01,
+	0x04, 0x01, 0x85, 0x07, 0x01, 0x00, 0x03, 0x05, 0x03, 0x00, 0x05, 0x80, 0x08, 0x01, 0x02, 0x06,
+	0x02, 0x86, 0x00, 0x04, 0x00, 0x03, 0x00, 0x04, 0x03, 0x6a, 0x00, 0x05, 0x06, 0x06, 0x05, 0x57,
+	0x00, 0x05, 0x05, 0x06, 0x5f, 0x09, 0x01, 0x06, 0x05, 0x06, 0x4f, 0x59, 0x40, 0x1d, 0x0a, 0x0a,
+	0x06, 0x06, 0x00, 0x00, 0x0a, 0x23, 0x0a, 0x23, 0x22, 0x21, 0x19, 0x17, 0x14, 0x12, 0x06, 0x09,
+	0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x0a, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44,
+	0x01, 0x13, 0x07, 0x37, 0x25, 0x03, 0x01, 0x01, 0x33, 0x01, 0x25, 0x37, 0x36, 0x37, 0x37, 0x36,
+	0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x07,
+	0x06, 0x07, 0x21, 0x07, 0x01, 0x7d, 0x8f, 0xe9, 0x1a, 0x01, 0xd3, 0xb5, 0xfe, 0x1f, 0x05, 0x8a,
+	0x98, 0xfa, 0x75, 0x02, 0xe2, 0x21, 0x46, 0x33, 0x5a, 0xa1, 0x67, 0x0e, 0x19, 0x99, 0x64, 0x92,
+	0x1e, 0x9e, 0x8f, 0x94, 0x9b, 0x16, 0x0f, 0x64, 0x87, 0x4e, 0x8a, 0x1d, 0x01, 0x8d, 0x21, 0x02,
+	0x67, 0x02, 0xc9, 0x37, 0x85, 0x6f, 0xfc, 0x7a, 0xfd, 0x74, 0x06, 0x12, 0xf9, 0xee, 0x25, 0xa9,
+	0x40, 0x28, 0x45, 0x7c, 0x73, 0x49, 0x7a, 0x3e, 0x96, 0x2e, 0x87, 0x6f, 0x4d, 0x73, 0x5f, 0x37,
+	0x61, 0x38, 0xa9, 0x00, 0x00, 0x04, 0x00, 0xdd, 0xff, 0xdb, 0x07, 0x1d, 0x05, 0xed, 0x00, 0x1d,
+	0x00, 0x21, 0x00, 0x2c, 0x00, 0x2f, 0x01, 0x0e, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x12, 0x10, 0x01,
+	0x02, 0x03, 0x17, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x09, 0x2f, 0x01, 0x05, 0x00, 0x04, 0x4c,
+	0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x3d, 0x00, 0x09, 0x01, 0x00, 0x01, 0x09, 0x00, 0x80, 0x0f,
+	0x0c, 0x0e, 0x03, 0x07, 0x08, 0x08, 0x07, 0x71, 0x06, 0x01, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x09, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x0a, 0x00, 0x05,
+	0x69, 0x0d, 0x01, 0x0a, 0x08, 0x08, 0x0a, 0x57, 0x0d, 0x01, 0x0a, 0x0a, 0x08, 0x60, 0x0b, 0x01,
+	0x08, 0x0a, 0x08, 0x50, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x3c, 0x00, 0x09, 0x01, 0x00,
+	0x01, 0x09, 0x00, 0x80, 0x0f, 0x0c, 0x0e, 0x03, 0x07, 0x08, 0x07, 0x86, 0x06, 0x01, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x09, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00,
+	0x05, 0x0a, 0x00, 0x05, 0x69, 0x0d, 0x01, 0x0a, 0x08, 0x08, 0x0a, 0x57, 0x0d, 0x01, 0x0a, 0x0a,
+	0x08, 0x60, 0x0b, 0x01, 0x08, 0x0a, 0x08, 0x50, 0x1b, 0x40, 0x42, 0x00, 0x09, 0x01, 0x00, 0x01,
+	0x09, 0x00, 0x80, 0x0f, 0x01, 0x0c, 0x08, 0x07, 0x08, 0x0c, 0x07, 0x80, 0x0e, 0x01, 0x07, 0x07,
+	0x84, 0x06, 0x01, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x09, 0x02,
+	0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x69, 0x0d, 0x01, 0x0a, 0x08, 0x08, 0x0a,
+	0x57, 0x0d, 0x01, 0x0a, 0x0a, 0x08, 0x60, 0x0b, 0x01, 0x08, 0x0a, 0x08, 0x50, 0x59, 0x59, 0x40,
+	0x20, 0x22, 0x22, 0x1e, 0x1e, 0x2e, 0x2d, 0x22, 0x2c, 0x22, 0x2c, 0x2b, 0x2a, 0x29, 0x28, 0x27,
+	0x26, 0x24, 0x23, 0x1e, 0x21, 0x1e, 0x21, 0x12, 0x27, 0x23, 0x22, 0x21, 0x22, 0x22, 0x10, 0x09,
+	0x1d, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x23, 0x37,
+	0x33, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x07, 0x06, 0x07, 0x16, 0x07,
+	0x06, 0x06, 0x23, 0x22, 0x03, 0x01, 0x33, 0x01, 0x25, 0x37, 0x21, 0x37, 0x01, 0x33, 0x03, 0x33,
+	0x07, 0x23, 0x07, 0x01, 0x33, 0x13, 0xdd, 0x1e, 0x7d, 0x64, 0xa2, 0x19, 0x22, 0xff, 0x3c, 0x19,
+	0x2d, 0xf3, 0x1d, 0x16, 0x8a, 0x6c, 0x7a, 0x1b, 0x9a, 0x7a, 0x01, 0x40, 0x2b, 0x20, 0xda, 0xde,
+	0x26, 0x18, 0xdd, 0xad, 0x7e, 0x61, 0x05, 0x8b, 0x97, 0xfa, 0x76, 0x03, 0x98, 0x2d, 0xfe, 0x5c,
+	0x21, 0x01, 0xff, 0xca, 0x63, 0x5d, 0x21, 0x5d, 0x2d, 0xfe, 0x8e, 0xff, 0x3d, 0x02, 0x66, 0x96,
+	0x34, 0x80, 0xa8, 0x7f, 0x92, 0x6d, 0x33, 0x87, 0x2b, 0xd7, 0xa0, 0x3e, 0x35, 0xbd, 0x78, 0x85,
+	0xfd, 0x92, 0x06, 0x12, 0xf9, 0xee, 0x25, 0xe2, 0xa6, 0x01, 0xf0, 0xfe, 0x10, 0xa6, 0xe2, 0x01,
+	0x88, 0x01, 0x30, 0x00, 0x00, 0x02, 0x00, 0x35, 0xfe, 0x75, 0x04, 0x5e, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x1c, 0x00, 0x37, 0x40, 0x34, 0x10, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02, 0x00, 0x03,
+	0x00, 0x02, 0x03, 0x80, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x03, 0x03, 0x04, 0x62, 0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x00, 0x00, 0x14, 0x12, 0x0f, 0x0d,
+	0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x07, 0x21, 0x37, 0x03,
+	0x21, 0x07, 0x06, 0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x21, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20,
+	0x13, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x37, 0x04, 0x5e, 0x31, 0xfe, 0xc4, 0x31, 0x4c, 0x01,
+	0x28, 0x04, 0x13, 0x76, 0x83, 0x73, 0x92, 0x1c, 0x26, 0x01, 0x07, 0xd8, 0xba, 0x2e, 0xcd, 0xdc,
+	0xfd, 0xcc, 0x45, 0x15, 0x7b, 0xa5, 0x5d, 0x5b, 0x4b, 0x1a, 0x04, 0x4a, 0xf7, 0xf7, 0xfe, 0x50,
+	0x12, 0x61, 0x9f, 0x55, 0x4a, 0x66, 0x8c, 0xbd, 0x53, 0xe2, 0x36, 0x01, 0x5b, 0x69, 0x80, 0x58,
+	0x32, 0x30, 0x75, 0x83, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x64, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1d, 0x00, 0x06, 0x00, 0x05, 0x00, 0x06, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02,
+	0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x05, 0x04, 0x05, 0x00, 0x04, 0x80, 0x00, 0x06, 0x00, 0x05,
+	0x00, 0x06, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03,
+	0x21, 0x03, 0x01, 0x21, 0x03, 0x01, 0x23, 0x01, 0x21, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15,
+	0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x01, 0x37, 0xc9, 0xfe, 0xff,
+	0x01, 0x19, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xb0,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6b, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00,
+	0x06, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e,
+	0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19,
+	0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x03, 0x01, 0x21, 0x01,
+	0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59, 0x01,
+	0xcc, 0x70, 0x47, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe,
+	0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xb0, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x12, 0x00, 0x74, 0x40, 0x0a,
+	0x10, 0x01, 0x06, 0x05, 0x0a, 0x01, 0x04, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x08, 0x03,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x12, 0x0b,
+	0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09,
+	0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x01, 0x01, 0x21,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd,
+	0x9c, 0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0xfe, 0xfe, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e,
+	0x03, 0xec, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xb0,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x21, 0x00, 0x80, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05, 0x06, 0x0a,
+	0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x00, 0x07, 0x05, 0x6a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0b, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x2a, 0x00, 0x00, 0x05, 0x04, 0x05, 0x00, 0x04, 0x80, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05,
+	0x06, 0x0a, 0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x00, 0x07, 0x05, 0x6a, 0x00, 0x04, 0x00, 0x02,
+	0x01, 0x04, 0x02, 0x68, 0x0b, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x00,
+	0x00, 0x21, 0x1f, 0x1a, 0x18, 0x17, 0x16, 0x15, 0x13, 0x0f, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03,
+	0x21, 0x03, 0x01, 0x21, 0x03, 0x03, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x37,
+	0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x0c, 0x03, 0x65, 0x01, 0x34,
+	0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x54, 0x94, 0x40,
+	0xca, 0x40, 0x36, 0x20, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x35, 0x22, 0x14,
+	0x07, 0x34, 0x1d, 0x44, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e,
+	0x01, 0xb0, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d,
+	0x00, 0x04, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x40, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e,
+	0x00, 0x12, 0x00, 0x78, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x21, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x06, 0x04, 0x06, 0x00, 0x04, 0x80, 0x07, 0x01,
+	0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1e, 0x0f, 0x0f, 0x0b,
+	0x0b, 0x00, 0x00, 0x0f, 0x12, 0x0f, 0x12, 0x11, 0x10, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09,
+	0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01,
+	0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x0c,
+	0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59, 0x01, 0xcc,
+	0x70, 0xdd, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe,
+	0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xc4, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x03, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x16, 0x00, 0x19, 0x00, 0x25, 0x00, 0x75, 0xb5, 0x19,
+	0x01, 0x06, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x01, 0x00, 0x08,
+	0x07, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x04, 0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07,
+	0x3a, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x24, 0x00, 0x01, 0x00, 0x08, 0x07, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x04, 0x03,
+	0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x09,
+	0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18, 0x1b, 0x1a, 0x00, 0x00, 0x21, 0x1f,
+	0x1a, 0x25, 0x1b, 0x25, 0x18, 0x17, 0x00, 0x16, 0x00, 0x16, 0x11, 0x11, 0x16, 0x26, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x26, 0x27, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x07, 0x06, 0x07, 0x33, 0x01, 0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x13, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x0c, 0x03, 0x65, 0x49, 0x2a, 0x1e, 0x35,
+	0x13, 0x14, 0xa3, 0x62, 0x61, 0x6e, 0x14, 0x13, 0x53, 0x2c, 0x33, 0x46, 0x01, 0x15, 0xfe, 0xc5,
+	0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x7e, 0x35, 0x56, 0x0a, 0x0b, 0x3a, 0x33,
+	0x33, 0x56, 0x0b, 0x0a, 0x39, 0x05, 0xc8, 0x11, 0x26, 0x45, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63,
+	0x44, 0x25, 0x11, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0x8b, 0x48,
+	0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x02, 0x00, 0x0c, 0x00, 0x00, 0x08, 0xba,
+	0x05, 0xc8, 0x00, 0x0f, 0x00, 0x12, 0x00, 0x73, 0xb5, 0x12, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x02, 0x00, 0x03, 0x08, 0x02, 0x03, 0x67, 0x00, 0x08,
+	0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x25,
+	0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x08, 0x02, 0x03, 0x67,
+	0x00, 0x08, 0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x07, 0x02,
+	0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x11, 0x10, 0x00, 0x0f, 0x00, 0x0f,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1d, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21,
+	0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x13, 0x21, 0x01, 0x01, 0x21, 0x13, 0x0c, 0x04,
+	0xa7, 0x04, 0x07, 0x28, 0xfd, 0x59, 0x53, 0x02, 0x38, 0x29, 0xfd, 0xc8, 0x5a, 0x02, 0xd6, 0x29,
+	0xfc, 0x02, 0x4c, 0xfe, 0x24, 0xfe, 0xcd, 0x01, 0xcd, 0x01, 0x68, 0x77, 0x05, 0xc8, 0xcb, 0xfe,
+	0x63, 0xcc, 0xfe, 0x3e, 0xd2, 0x01, 0x7e, 0xfe, 0x82, 0x02, 0x3e, 0x02, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x99, 0xfe, 0x50, 0x06, 0x94, 0x05, 0xed, 0x00, 0x25, 0x00, 0xaf, 0x40, 0x1a,
+	0x1d, 0x01, 0x05, 0x04, 0x1e, 0x01, 0x06, 0x05, 0x14, 0x01, 0x00, 0x06, 0x04, 0x01, 0x03, 0x00,
+	0x0c, 0x01, 0x02, 0x03, 0x0b, 0x01, 0x01, 0x02, 0x06, 0x4c, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40,
+	0x26, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x72, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x3e, 0x4d, 0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e,
+	0x4d, 0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02,
+	0x80, 0x00, 0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x69, 0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x0a, 0x22, 0x23, 0x27, 0x22, 0x23, 0x25, 0x12, 0x07, 0x09, 0x1d, 0x2b, 0x01, 0x07, 0x06,
+	0x05, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23,
+	0x23, 0x37, 0x24, 0x27, 0x26, 0x13, 0x12, 0x00, 0x21, 0x20, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03,
+	0x02, 0x21, 0x32, 0x05, 0xb7, 0x2e, 0xe5, 0xfe, 0xc6, 0x42, 0xe3, 0x1a, 0x0e, 0xa3, 0x69, 0x52,
+	0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x82, 0xfe, 0xe0, 0x8a, 0xa4, 0x4a, 0x4d,
+	0x01, 0xed, 0x01, 0x8f, 0x01, 0x03, 0xe5, 0x30, 0xfe, 0xc8, 0xfd, 0xff, 0x72, 0x71, 0x02, 0x1e,
+	0xeb, 0x01, 0x1e, 0xe3, 0x5e, 0x02, 0x4c, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54,
+	0x97, 0x1b, 0xa8, 0xca, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6, 0xfd,
+	0xc8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x6a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x07, 0x00, 0x06, 0x00,
+	0x07, 0x06, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05,
+	0x4e, 0x1b, 0x40, 0x24, 0x00, 0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x67, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1b, 0x2b, 0x33, 0x01,
+	0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x03, 0x23, 0x01, 0x21, 0xad, 0x01,
+	0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29,
+	0x65, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06,
+	0x4e, 0x01, 0x41, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x74, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00,
+	0x07, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x07, 0x01, 0x01, 0x21, 0x01, 0xad, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6,
+	0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xfe, 0x47, 0x01, 0x31, 0x01, 0x19,
+	0xfe, 0x7f, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x7f, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x00,
+	0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13,
+	0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x01,
+	0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0xad, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53,
+	0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xfd, 0x7c, 0x01, 0x31, 0x01, 0x11, 0xb1,
+	0xb3, 0x9e, 0x03, 0xec, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x4e, 0x01,
+	0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12,
+	0x07, 0x40, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x08, 0x01,
+	0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10,
+	0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x07, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0xad, 0x01, 0x27, 0x04,
+	0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xfd, 0x93,
+	0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2,
+	0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0x63,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x5c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00,
+	0x01, 0x00, 0x00, 0x04, 0x01, 0x00, 0x67, 0x05, 0x01, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x04,
+	0x38, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b,
+	0x40, 0x1e, 0x00, 0x01, 0x00, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x04, 0x05, 0x01, 0x03, 0x02,
+	0x04, 0x03, 0x67, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e,
+	0x59, 0x40, 0x10, 0x04, 0x04, 0x04, 0x0f, 0x04, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x12, 0x11, 0x10,
+	0x09, 0x09, 0x1d, 0x2b, 0x01, 0x23, 0x01, 0x21, 0x01, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x03, 0xc5, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0xfd, 0x50, 0x29, 0xd2, 0xd4,
+	0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06, 0x4e, 0x01, 0x41, 0xf8, 0x71, 0xd2,
+	0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0x91,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x6c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00,
+	0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x04, 0x01, 0x85, 0x05, 0x01, 0x03, 0x03, 0x04, 0x5f,
+	0x00, 0x04, 0x04, 0x38, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x39,
+	0x07, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x04, 0x01, 0x85,
+	0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03, 0x68, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09,
+	0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04,
+	0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x09, 0x17, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x01, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x02, 0x47, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xfd, 0x54, 0x29, 0xd2,
+	0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf,
+	0xf9, 0xb2, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64,
+	0x00, 0x00, 0x04, 0x7f, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0xb5, 0x05, 0x01, 0x01,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09,
+	0x02, 0x02, 0x01, 0x05, 0x01, 0x85, 0x06, 0x01, 0x04, 0x04, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38,
+	0x4d, 0x07, 0x01, 0x03, 0x03, 0x08, 0x5f, 0x0a, 0x01, 0x08, 0x08, 0x39, 0x08, 0x4e, 0x1b, 0x40,
+	0x22, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x02, 0x02, 0x01, 0x05, 0x01, 0x85, 0x00, 0x05, 0x06,
+	0x01, 0x04, 0x03, 0x05, 0x04, 0x68, 0x07, 0x01, 0x03, 0x03, 0x08, 0x5f, 0x0a, 0x01, 0x08, 0x08,
+	0x3c, 0x08, 0x4e, 0x59, 0x40, 0x1b, 0x08, 0x08, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12, 0x11,
+	0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x0b, 0x09,
+	0x18, 0x2b, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x37, 0x33, 0x13, 0x23, 0x37,
+	0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0x8c, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03,
+	0xec, 0xfe, 0x25, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0xf9, 0xb2, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc,
+	0xd2, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x64, 0x00, 0x00, 0x04, 0x78, 0x07, 0x2c, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x02, 0x01, 0x00,
+	0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x38, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x39, 0x09,
+	0x4e, 0x1b, 0x40, 0x22, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67,
+	0x00, 0x06, 0x07, 0x01, 0x05, 0x04, 0x06, 0x05, 0x67, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c,
+	0x01, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x22, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08,
+	0x13, 0x08, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07,
+	0x33, 0x37, 0x33, 0x07, 0x01, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07,
+	0x01, 0xb6, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0xfc, 0x18, 0x29, 0xd2, 0xd4, 0xd2, 0x2a,
+	0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06, 0x4e, 0xde, 0xde, 0xde, 0xde, 0xf9, 0xb2, 0xd2,
+	0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x02, 0x00, 0x83, 0x00, 0x00, 0x06, 0x55,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x19, 0x00, 0x60, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x06,
+	0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x01, 0x02, 0x05, 0x69, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00,
+	0x04, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e,
+	0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x18, 0x17, 0x16, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00,
+	0x0b, 0x21, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x21, 0x20,
+	0x00, 0x03, 0x02, 0x00, 0x21, 0x27, 0x33, 0x32, 0x00, 0x13, 0x36, 0x02, 0x23, 0x23, 0x03, 0x33,
+	0x07, 0x23, 0xad, 0x83, 0xad, 0x25, 0xad, 0x7f, 0x02, 0x03, 0x01, 0x58, 0x01, 0x26, 0x44, 0x4a,
+	0xfe, 0x2c, 0xfe, 0xa2, 0x8b, 0x6d, 0xf3, 0x01, 0x25, 0x37, 0x31, 0xb8, 0xd3, 0x8c, 0x57, 0xd2,
+	0x25, 0xd2, 0x02, 0x91, 0xb9, 0x02, 0x7e, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2,
+	0x01, 0x0d, 0x01, 0x12, 0xf5, 0x01, 0x17, 0xfe, 0x4d, 0xb9, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x41, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x20, 0x00, 0x71, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x07, 0x01, 0x05, 0x00, 0x09,
+	0x04, 0x05, 0x09, 0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a, 0x01, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x0a, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x23, 0x01, 0x01,
+	0x00, 0x04, 0x02, 0x04, 0x00, 0x02, 0x80, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69,
+	0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a, 0x0a, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x18, 0x00, 0x00, 0x20, 0x1e, 0x19, 0x17, 0x16, 0x15, 0x14, 0x12, 0x0e, 0x0c,
+	0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21,
+	0x01, 0x13, 0x33, 0x01, 0x21, 0x01, 0x03, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0xad, 0x01, 0x27,
+	0x01, 0x0f, 0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0xfe, 0xed, 0xfe, 0x67, 0xca, 0x01, 0xbc, 0x94,
+	0x40, 0xca, 0x40, 0x36, 0x20, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x35, 0x22,
+	0x14, 0x07, 0x34, 0x1d, 0x44, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc,
+	0x0d, 0x06, 0x4e, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06,
+	0x2d, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x61, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x00,
+	0x04, 0x01, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1d, 0x00,
+	0x05, 0x00, 0x04, 0x01, 0x05, 0x04, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x07,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x0d,
+	0x0c, 0x01, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00,
+	0x0b, 0x01, 0x0b, 0x08, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00,
+	0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01,
+	0x23, 0x01, 0x21, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01,
+	0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8,
+	0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x02, 0x98, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x25, 0x01, 0xa1,
+	0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01,
+	0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05,
+	0xa7, 0x01, 0x41, 0x00, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x6b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x04, 0x05,
+	0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x6a, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a,
+	0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00,
+	0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x01, 0x21, 0x01, 0x03, 0x0a, 0xfe,
+	0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe,
+	0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f,
+	0x01, 0x05, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01,
+	0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d,
+	0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f,
+	0x00, 0x76, 0xb5, 0x1d, 0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01,
+	0x05, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61,
+	0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00,
+	0x18, 0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05,
+	0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20,
+	0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12,
+	0x13, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49,
+	0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01,
+	0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x55, 0x01, 0x31, 0x01, 0x11,
+	0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64,
+	0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe,
+	0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2e,
+	0x00, 0x7d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05,
+	0x09, 0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x01, 0x06, 0x04, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x27, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x00, 0x06,
+	0x08, 0x01, 0x04, 0x01, 0x06, 0x04, 0x6a, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1f, 0x0d,
+	0x0c, 0x01, 0x00, 0x2e, 0x2c, 0x27, 0x25, 0x24, 0x23, 0x22, 0x20, 0x1c, 0x1a, 0x19, 0x18, 0x13,
+	0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05,
+	0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12,
+	0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x03, 0x0a, 0xfe,
+	0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe,
+	0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f,
+	0x01, 0x03, 0x94, 0x40, 0xca, 0x40, 0x36, 0x20, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9,
+	0x40, 0x35, 0x22, 0x14, 0x07, 0x34, 0x1d, 0x44, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01,
+	0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d,
+	0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0x2b, 0x1a,
+	0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x00, 0x00, 0x04, 0x00, 0x9b,
+	0xff, 0xdb, 0x06, 0xc5, 0x07, 0x40, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x75,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01,
+	0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x06, 0x01, 0x04,
+	0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02,
+	0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01,
+	0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36,
+	0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x84, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c,
+	0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe,
+	0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3,
+	0xfe, 0xd0, 0x05, 0xbb, 0xde, 0xde, 0xde, 0xde, 0x00, 0x01, 0x00, 0x8e, 0x00, 0x5e, 0x05, 0x09,
+	0x04, 0x43, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x09, 0x03, 0x01, 0x32, 0x2b, 0x37, 0x01, 0x01, 0x37,
+	0x01, 0x01, 0x17, 0x01, 0x01, 0x07, 0x01, 0x01, 0x8e, 0x01, 0xc3, 0xfe, 0xd3, 0x93, 0x01, 0x2c,
+	0x01, 0xc5, 0x61, 0xfe, 0x3c, 0x01, 0x2e, 0x93, 0xfe, 0xd2, 0xfe, 0x3d, 0xd8, 0x01, 0x78, 0x01,
+	0x79, 0x7a, 0xfe, 0x88, 0x01, 0x78, 0x7a, 0xfe, 0x87, 0xfe, 0x88, 0x7a, 0x01, 0x78, 0xfe, 0x88,
+	0x00, 0x03, 0x00, 0x48, 0xff, 0xdb, 0x07, 0x18, 0x05, 0xed, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x23,
+	0x00, 0x5d, 0x40, 0x11, 0x18, 0x01, 0x00, 0x02, 0x1b, 0x11, 0x0f, 0x07, 0x04, 0x01, 0x00, 0x22,
+	0x01, 0x04, 0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x00, 0x00, 0x02,
+	0x61, 0x03, 0x01, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x06, 0x05, 0x02, 0x04,
+	0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x40, 0x16, 0x03, 0x01, 0x02, 0x00, 0x00, 0x01, 0x02, 0x00, 0x69,
+	0x00, 0x01, 0x01, 0x04, 0x61, 0x06, 0x05, 0x02, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x40, 0x0e,
+	0x10, 0x10, 0x10, 0x23, 0x10, 0x23, 0x25, 0x12, 0x2a, 0x26, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0x01,
+	0x26, 0x23, 0x22, 0x00, 0x03, 0x06, 0x17, 0x17, 0x16, 0x33, 0x32, 0x00, 0x13, 0x36, 0x27, 0x01,
+	0x37, 0x26, 0x13, 0x12, 0x00, 0x21, 0x20, 0x17, 0x37, 0x33, 0x07, 0x16, 0x03, 0x02, 0x00, 0x21,
+	0x20, 0x27, 0x07, 0x05, 0x13, 0x48, 0xa9, 0xb8, 0xfe, 0xf7, 0x36, 0x21, 0x13, 0x2f, 0x4a, 0xa7,
+	0xb9, 0x01, 0x09, 0x36, 0x21, 0x13, 0xfb, 0x05, 0xdf, 0x85, 0x41, 0x4a, 0x01, 0xcf, 0x01, 0x53,
+	0x01, 0x07, 0x8d, 0x77, 0xbe, 0xdf, 0x86, 0x43, 0x49, 0xfe, 0x30, 0xfe, 0xae, 0xfe, 0xfa, 0x8e,
+	0x77, 0x04, 0xa6, 0x7c, 0xfe, 0xd3, 0xfe, 0xf0, 0xa5, 0x90, 0x8e, 0x7b, 0x01, 0x2c, 0x01, 0x0f,
+	0xa5, 0x92, 0xfb, 0xc2, 0xdf, 0xe2, 0x01, 0x48, 0x01, 0x6e, 0x01, 0x9b, 0x77, 0x77, 0xdf, 0xdf,
+	0xfe, 0xb5, 0xfe, 0x92, 0xfe, 0x65, 0x78, 0x78, 0x00, 0x02, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d,
+	0x07, 0x8f, 0x00, 0x14, 0x00, 0x18, 0x00, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x05, 0x00, 0x04, 0x00, 0x05, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01,
+	0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x04, 0x01,
+	0x04, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x04, 0x00, 0x05, 0x04, 0x67, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x09, 0x11, 0x15, 0x25, 0x12, 0x23, 0x10,
+	0x06, 0x09, 0x1c, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06,
+	0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x23, 0x01, 0x21, 0x01, 0xc7, 0x01,
+	0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3,
+	0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x03, 0xd0, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x05, 0xc8, 0xfc,
+	0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb,
+	0xc4, 0x04, 0x10, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d,
+	0x07, 0x8f, 0x00, 0x14, 0x00, 0x18, 0x00, 0x54, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x15, 0x15, 0x15,
+	0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06,
+	0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26,
+	0x37, 0x01, 0x01, 0x21, 0x01, 0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c,
+	0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x02, 0x52,
+	0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2,
+	0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x5e,
+	0xb5, 0x1a, 0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85,
+	0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x15, 0x15,
+	0x15, 0x1c, 0x15, 0x1c, 0x11, 0x16, 0x25, 0x12, 0x23, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x21,
+	0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27,
+	0x26, 0x26, 0x37, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0xc7, 0x01, 0x34, 0xb5,
+	0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc,
+	0x84, 0x5b, 0x29, 0x27, 0x01, 0x98, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05,
+	0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74,
+	0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x03, 0x00, 0xeb,
+	0xff, 0xdb, 0x06, 0x4d, 0x07, 0x40, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0x61, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05,
+	0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01,
+	0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x19, 0x19, 0x15, 0x15, 0x19, 0x1c, 0x19, 0x1c,
+	0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0a, 0x09, 0x1b, 0x2b, 0x01,
+	0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22,
+	0x27, 0x26, 0x26, 0x37, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0xc7, 0x01, 0x34,
+	0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed,
+	0xfc, 0x84, 0x5b, 0x29, 0x27, 0x01, 0xc7, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0x05, 0xc8,
+	0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50,
+	0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde, 0x00, 0x02, 0x01, 0x43, 0x00, 0x00, 0x06, 0x62,
+	0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x59, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00,
+	0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00,
+	0x00, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x09, 0x09, 0x00,
+	0x00, 0x09, 0x0c, 0x09, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x07, 0x09, 0x18,
+	0x2b, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x03, 0x13, 0x01, 0x21, 0x01, 0x02, 0x07,
+	0x7b, 0xfe, 0xc1, 0x01, 0x55, 0xe5, 0x01, 0xf1, 0xf4, 0xfd, 0x55, 0x7c, 0x18, 0x01, 0x31, 0x01,
+	0x19, 0xfe, 0x7f, 0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0xe9,
+	0x05, 0xc8, 0x00, 0x0d, 0x00, 0x15, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00,
+	0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00,
+	0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x03,
+	0x04, 0x02, 0x67, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x15,
+	0x13, 0x10, 0x0e, 0x00, 0x0d, 0x00, 0x0d, 0x25, 0x21, 0x11, 0x07, 0x09, 0x19, 0x2b, 0x33, 0x01,
+	0x21, 0x03, 0x21, 0x32, 0x16, 0x17, 0x16, 0x07, 0x02, 0x21, 0x23, 0x03, 0x13, 0x33, 0x20, 0x13,
+	0x36, 0x26, 0x23, 0x23, 0xad, 0x01, 0x27, 0x01, 0x2e, 0x38, 0x01, 0x24, 0xd0, 0xb0, 0x33, 0x48,
+	0x24, 0x65, 0xfd, 0x84, 0xce, 0x3b, 0x63, 0x8a, 0x01, 0x85, 0x37, 0x19, 0x7f, 0xb8, 0xc5, 0x05,
+	0xc8, 0xfe, 0xe5, 0x30, 0x45, 0x62, 0xb3, 0xfe, 0x05, 0xfe, 0xd8, 0x01, 0xf4, 0x01, 0x11, 0x7b,
+	0x61, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94, 0xff, 0xe7, 0x05, 0x1c, 0x06, 0x44, 0x00, 0x2b,
+	0x00, 0xb0, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x16, 0x01, 0x02, 0x03, 0x15, 0x01, 0x01,
+	0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x16, 0x01, 0x02, 0x03, 0x15, 0x01, 0x04, 0x02, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x05, 0x04, 0x02, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3a,
+	0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42,
+	0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x69, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x42, 0x01, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x05, 0x01,
+	0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x2b, 0x00, 0x2b, 0x2e, 0x23, 0x2e, 0x22, 0x06, 0x09,
+	0x1a, 0x2b, 0x33, 0x13, 0x12, 0x21, 0x32, 0x16, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x17,
+	0x17, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x2f, 0x02,
+	0x26, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x03, 0x94, 0xe1, 0x5f, 0x01,
+	0xbe, 0xc3, 0xc7, 0x18, 0x14, 0x53, 0x4e, 0x59, 0x09, 0x09, 0x4c, 0x40, 0x84, 0x1e, 0x1e, 0xf7,
+	0xb1, 0x60, 0x77, 0x26, 0x72, 0x46, 0x8c, 0x1a, 0x0c, 0x41, 0x4b, 0x3c, 0x2d, 0x13, 0x0f, 0x35,
+	0x3f, 0x42, 0x3a, 0x0e, 0x1c, 0x9c, 0x9c, 0x2b, 0xf1, 0x04, 0x6b, 0x01, 0xd9, 0x8d, 0x78, 0x61,
+	0x5b, 0x56, 0x62, 0x2a, 0x2c, 0x77, 0x65, 0xcf, 0x96, 0x98, 0xb5, 0x20, 0xc1, 0x28, 0x7e, 0x3c,
+	0x65, 0x74, 0x5b, 0x4d, 0x63, 0x47, 0x47, 0x51, 0x57, 0x4a, 0x46, 0x8a, 0xd5, 0xfb, 0x47, 0x00,
+	0x00, 0x03, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x06, 0x44, 0x00, 0x03, 0x00, 0x11, 0x00, 0x1a,
+	0x00, 0xc3, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x3a, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07,
+	0x07, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x29, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b,
+	0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x01, 0x00, 0x00, 0x03, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x3b, 0x4d,
+	0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00,
+	0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x01, 0x00,
+	0x00, 0x03, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0b, 0x22, 0x22, 0x11, 0x11, 0x24, 0x22, 0x11,
+	0x10, 0x08, 0x09, 0x1e, 0x2b, 0x01, 0x23, 0x01, 0x21, 0x03, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37,
+	0x04, 0x3b, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x3f, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51,
+	0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78,
+	0x94, 0x05, 0x03, 0x01, 0x41, 0xfa, 0x72, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19,
+	0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00, 0x00, 0x03, 0x00, 0x86,
+	0xff, 0xe7, 0x05, 0x2a, 0x06, 0x44, 0x00, 0x03, 0x00, 0x11, 0x00, 0x1a, 0x00, 0xdc, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x01, 0x00, 0x03, 0x00, 0x01, 0x03, 0x80, 0x00, 0x00,
+	0x00, 0x3a, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07,
+	0x07, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x2d, 0x08, 0x01, 0x01, 0x00, 0x03, 0x00, 0x01, 0x03, 0x80, 0x00, 0x00, 0x00, 0x3a, 0x4d,
+	0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x39, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03,
+	0x01, 0x85, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05,
+	0x05, 0x3c, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59,
+	0x59, 0x40, 0x16, 0x00, 0x00, 0x19, 0x17, 0x15, 0x13, 0x11, 0x10, 0x0f, 0x0e, 0x0d, 0x0b, 0x07,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x03, 0x06,
+	0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22,
+	0x03, 0x02, 0x33, 0x32, 0x37, 0x02, 0xa9, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x27, 0xc5, 0xbc,
+	0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b,
+	0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xfb, 0xb3, 0xcf,
+	0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe,
+	0x9f, 0xaf, 0x00, 0x00, 0x00, 0x03, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x06, 0x44, 0x00, 0x07,
+	0x00, 0x15, 0x00, 0x1e, 0x00, 0xe8, 0xb5, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x26, 0x09, 0x02, 0x02, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x80, 0x00, 0x00,
+	0x00, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x04, 0x61, 0x05, 0x01, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x08,
+	0x08, 0x03, 0x61, 0x06, 0x01, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x2e, 0x09, 0x02, 0x02, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x80, 0x00, 0x00, 0x00, 0x3a,
+	0x4d, 0x00, 0x05, 0x05, 0x3b, 0x4d, 0x00, 0x07, 0x07, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d,
+	0x00, 0x06, 0x06, 0x39, 0x4d, 0x00, 0x08, 0x08, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x02, 0x02,
+	0x01, 0x04, 0x01, 0x85, 0x00, 0x05, 0x05, 0x3b, 0x4d, 0x00, 0x07, 0x07, 0x04, 0x61, 0x00, 0x04,
+	0x04, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x39, 0x4d, 0x00, 0x08, 0x08, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x02, 0x02, 0x01, 0x04,
+	0x01, 0x85, 0x00, 0x05, 0x05, 0x3b, 0x4d, 0x00, 0x07, 0x07, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41,
+	0x4d, 0x00, 0x06, 0x06, 0x3c, 0x4d, 0x00, 0x08, 0x08, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x00, 0x00, 0x1d, 0x1b, 0x19, 0x17, 0x15, 0x14, 0x13, 0x12,
+	0x11, 0x0f, 0x0b, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x0a, 0x09, 0x18, 0x2b, 0x01, 0x01,
+	0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x13, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32,
+	0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x02, 0x0f, 0x01,
+	0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x89, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01,
+	0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3,
+	0x78, 0x94, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0xfb, 0xb3, 0xcf, 0x01, 0x2b, 0xf5,
+	0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00,
+	0x00, 0x03, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x06, 0x4e, 0x00, 0x16, 0x00, 0x24, 0x00, 0x2d,
+	0x00, 0xf8, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x01,
+	0x01, 0x01, 0x3a, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x0a, 0x0a, 0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x06, 0x61, 0x09,
+	0x01, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x35, 0x00, 0x05,
+	0x05, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x3a, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x0a, 0x0a, 0x07, 0x61, 0x00, 0x07,
+	0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x0b, 0x0b, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x42, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x33, 0x03, 0x01, 0x01, 0x00, 0x05,
+	0x00, 0x01, 0x05, 0x69, 0x04, 0x01, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x08, 0x08, 0x3b, 0x4d, 0x00, 0x0a, 0x0a, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09,
+	0x09, 0x39, 0x4d, 0x00, 0x0b, 0x0b, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x40,
+	0x31, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69, 0x00, 0x02, 0x04, 0x01, 0x00, 0x07,
+	0x02, 0x00, 0x6a, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x0a, 0x0a, 0x07, 0x61, 0x00, 0x07, 0x07,
+	0x41, 0x4d, 0x00, 0x09, 0x09, 0x3c, 0x4d, 0x00, 0x0b, 0x0b, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42,
+	0x06, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x2c, 0x2a, 0x28, 0x26, 0x24, 0x23, 0x11, 0x24, 0x22,
+	0x25, 0x21, 0x11, 0x24, 0x21, 0x10, 0x0c, 0x09, 0x1f, 0x2b, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f,
+	0x02, 0x16, 0x33, 0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22,
+	0x13, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26,
+	0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x02, 0xae, 0x94, 0x40, 0xca, 0x40, 0x35, 0x21, 0x1b,
+	0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x36, 0x21, 0x14, 0x07, 0x34, 0x1d, 0x44, 0x82,
+	0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8,
+	0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x05, 0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16,
+	0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0xfb, 0x21, 0xcf, 0x01, 0x2b, 0xf5, 0x01,
+	0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x05, 0xeb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x15,
+	0x00, 0x1e, 0x00, 0xe6, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x03, 0x0a, 0x03, 0x01,
+	0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x05, 0x61, 0x06, 0x01,
+	0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x07, 0x01, 0x04, 0x04, 0x42, 0x04, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2d, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x01, 0x00, 0x5f,
+	0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x02, 0x01, 0x00,
+	0x0b, 0x03, 0x0a, 0x03, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08,
+	0x08, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09, 0x09,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x40, 0x2b, 0x02, 0x01, 0x00, 0x0b, 0x03,
+	0x0a, 0x03, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1e, 0x04, 0x04, 0x00, 0x00, 0x1d,
+	0x1b, 0x19, 0x17, 0x15, 0x14, 0x13, 0x12, 0x11, 0x0f, 0x0b, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0c, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37,
+	0x33, 0x07, 0x01, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21,
+	0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x02, 0x39, 0x2c, 0xde, 0x2c, 0xc5, 0x2c,
+	0xdf, 0x2c, 0xfe, 0x90, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01,
+	0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x05, 0x0d, 0xde,
+	0xde, 0xde, 0xde, 0xfb, 0xa9, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6,
+	0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x04, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a,
+	0x06, 0xd8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x25, 0x00, 0x2e, 0x00, 0xc1, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a, 0x01,
+	0x00, 0x05, 0x02, 0x00, 0x69, 0x00, 0x08, 0x08, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d,
+	0x00, 0x09, 0x09, 0x04, 0x61, 0x07, 0x01, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x31, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a,
+	0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x40, 0x31, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69,
+	0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00,
+	0x08, 0x08, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x09,
+	0x09, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x1f, 0x0d, 0x0c, 0x01,
+	0x00, 0x2d, 0x2b, 0x29, 0x27, 0x25, 0x24, 0x23, 0x22, 0x21, 0x1f, 0x1b, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26,
+	0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x06, 0x07, 0x06, 0x16, 0x03, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x03, 0x7c, 0x60, 0x6c,
+	0x14, 0x13, 0xa4, 0x62, 0x61, 0x6d, 0x13, 0x14, 0xa4, 0x4d, 0x35, 0x56, 0x0b, 0x0a, 0x3a, 0x33,
+	0x33, 0x56, 0x0a, 0x0a, 0x38, 0x16, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51,
+	0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x05,
+	0x03, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33,
+	0x33, 0x49, 0xfb, 0x44, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03,
+	0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00, 0x03, 0x00, 0x62, 0xff, 0xe7, 0x07, 0x52,
+	0x04, 0x63, 0x00, 0x21, 0x00, 0x2a, 0x00, 0x2f, 0x00, 0x7b, 0x40, 0x0e, 0x13, 0x01, 0x02, 0x03,
+	0x0e, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x06, 0x05, 0x03, 0x4c, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40,
+	0x23, 0x0a, 0x01, 0x01, 0x08, 0x01, 0x05, 0x06, 0x01, 0x05, 0x69, 0x0b, 0x01, 0x02, 0x02, 0x03,
+	0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x07, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x08, 0x05, 0x01, 0x08, 0x59, 0x0a, 0x01, 0x01,
+	0x00, 0x05, 0x06, 0x01, 0x05, 0x67, 0x0b, 0x01, 0x02, 0x02, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x41, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x12, 0x2f, 0x2d, 0x2c, 0x2b, 0x2a, 0x28, 0x22, 0x23, 0x21, 0x12, 0x22, 0x23, 0x22, 0x24,
+	0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x24, 0x21, 0x33, 0x37,
+	0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x17, 0x36, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02,
+	0x21, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x03, 0x37, 0x23, 0x20, 0x07, 0x06, 0x16, 0x33, 0x32,
+	0x01, 0x21, 0x12, 0x23, 0x22, 0x03, 0x4a, 0xc7, 0xf1, 0x98, 0x98, 0x1d, 0x24, 0x01, 0x50, 0x01,
+	0x16, 0x54, 0x14, 0x23, 0xca, 0xb2, 0xc9, 0x29, 0xdf, 0xc1, 0xb0, 0x8d, 0xb2, 0xb8, 0xef, 0xa4,
+	0x41, 0xfd, 0x47, 0x1e, 0x01, 0x41, 0x99, 0xcc, 0x29, 0xe2, 0xd6, 0xfe, 0xcc, 0xbc, 0x2c, 0x4b,
+	0xfe, 0xd4, 0x23, 0x0d, 0x49, 0x43, 0x6b, 0x01, 0xec, 0x01, 0x99, 0x39, 0xbd, 0xbf, 0xc0, 0xd9,
+	0xae, 0x8e, 0xb5, 0xc2, 0x68, 0xab, 0x62, 0xcc, 0x4c, 0x79, 0x79, 0xfe, 0xcc, 0xfe, 0xbb, 0xfe,
+	0xc6, 0x45, 0xd0, 0x3e, 0x01, 0x2e, 0xdf, 0xb3, 0x3f, 0x52, 0x01, 0xe1, 0x01, 0x1c, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x81, 0xfe, 0x50, 0x04, 0xee, 0x04, 0x63, 0x00, 0x25, 0x00, 0x81, 0x40, 0x1a,
+	0x1c, 0x01, 0x05, 0x04, 0x1d, 0x01, 0x06, 0x05, 0x14, 0x01, 0x00, 0x06, 0x04, 0x01, 0x03, 0x00,
+	0x0c, 0x01, 0x02, 0x03, 0x0b, 0x01, 0x01, 0x02, 0x06, 0x4c, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40,
+	0x26, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x72, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x41, 0x4d, 0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03,
+	0x02, 0x80, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01,
+	0x4e, 0x59, 0x40, 0x0a, 0x23, 0x23, 0x26, 0x22, 0x23, 0x25, 0x12, 0x07, 0x09, 0x1d, 0x2b, 0x25,
+	0x07, 0x06, 0x07, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37,
+	0x36, 0x23, 0x23, 0x37, 0x26, 0x27, 0x26, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x04, 0x4d, 0x29, 0xd7, 0xa0, 0x4d, 0xe3, 0x1a, 0x0e, 0xa3, 0x69,
+	0x52, 0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x8e, 0xc6, 0x65, 0x7f, 0x36, 0x73,
+	0x02, 0x75, 0xae, 0xa1, 0x2a, 0xc6, 0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0xe5, 0xcd,
+	0x2f, 0x02, 0x58, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54, 0xa4, 0x1a, 0x75, 0x97,
+	0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x00, 0x00, 0x03, 0x00, 0x83,
+	0xff, 0xe7, 0x04, 0xaa, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x65, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x27, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x06, 0x06,
+	0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00,
+	0x07, 0x00, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0b, 0x11, 0x11, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x08,
+	0x09, 0x1e, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03,
+	0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x01, 0x23, 0x01, 0x21, 0x04, 0x37, 0x29,
+	0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f,
+	0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0x01, 0xb2, 0xc9, 0xfe, 0xff, 0x01,
+	0x19, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe,
+	0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x59, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x83,
+	0xff, 0xe7, 0x04, 0xcb, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x71, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x2b, 0x08, 0x01, 0x07, 0x06, 0x01, 0x06, 0x07, 0x01, 0x80, 0x00, 0x04,
+	0x00, 0x02, 0x03, 0x04, 0x02, 0x68, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x28, 0x00, 0x06, 0x07, 0x06, 0x85, 0x08, 0x01, 0x07, 0x01, 0x07, 0x85, 0x00, 0x04,
+	0x00, 0x02, 0x03, 0x04, 0x02, 0x68, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x10, 0x16, 0x16,
+	0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x09, 0x09, 0x1d, 0x2b, 0x25,
+	0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32,
+	0x01, 0x21, 0x12, 0x23, 0x22, 0x13, 0x01, 0x21, 0x01, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed,
+	0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe,
+	0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0x29, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xf5, 0xd0, 0x3e,
+	0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01,
+	0x19, 0x01, 0x59, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x04, 0xc4,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x7b, 0xb5, 0x1b, 0x01, 0x07, 0x06, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2c, 0x09, 0x08, 0x02, 0x07, 0x06, 0x01, 0x06, 0x07,
+	0x01, 0x80, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x08, 0x02, 0x07,
+	0x01, 0x07, 0x85, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x11, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d, 0x11, 0x12, 0x21, 0x11, 0x21, 0x12, 0x24,
+	0x22, 0x0a, 0x09, 0x1e, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32,
+	0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x03, 0x01, 0x21, 0x13, 0x23,
+	0x27, 0x23, 0x07, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4,
+	0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8,
+	0x87, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01,
+	0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x59,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x04, 0xad,
+	0x05, 0xeb, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x7a, 0x4b, 0xb0, 0x1d, 0x50,
+	0x58, 0x40, 0x2b, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x0b, 0x09, 0x0a, 0x03, 0x07,
+	0x07, 0x06, 0x5f, 0x08, 0x01, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x29, 0x08, 0x01, 0x06, 0x0b, 0x09, 0x0a, 0x03, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00,
+	0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x18, 0x1a, 0x1a, 0x16,
+	0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12,
+	0x24, 0x22, 0x0c, 0x09, 0x1d, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33,
+	0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x03, 0x37, 0x33, 0x07,
+	0x33, 0x37, 0x33, 0x07, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50,
+	0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f,
+	0xa8, 0x59, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12,
+	0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x63, 0xde,
+	0xde, 0xde, 0xde, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0x0a, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x63, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x16, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x14, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02,
+	0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x14,
+	0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x05, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x01, 0x23, 0x01, 0x21, 0x94,
+	0xdb, 0x01, 0x28, 0xdb, 0x01, 0x4e, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x04, 0x4a, 0xfb, 0xb6, 0x05,
+	0x03, 0x01, 0x41, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0x90, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x71, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x02, 0x00,
+	0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x02, 0x03,
+	0x02, 0x85, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x00,
+	0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x03, 0x01, 0x21, 0x01, 0x94, 0xdb, 0x01,
+	0x28, 0xdb, 0x76, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x03, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0xa1, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x1b, 0x06, 0x04, 0x02, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18,
+	0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17,
+	0x2b, 0x33, 0x13, 0x21, 0x03, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x94, 0xdb, 0x01,
+	0x28, 0xdb, 0xfe, 0xf2, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x04, 0x4a, 0xfb,
+	0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x94,
+	0x00, 0x00, 0x03, 0x8b, 0x05, 0xeb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x7b, 0x4b, 0xb0,
+	0x1d, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x05, 0x07, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02,
+	0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x08, 0x08,
+	0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x03, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x94, 0xdb, 0x01, 0x28, 0xdb, 0xdf, 0x2c, 0xde, 0x2c, 0xc5, 0x2c,
+	0xdf, 0x2c, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x00, 0x02, 0x00, 0x81,
+	0xff, 0xe7, 0x05, 0x44, 0x06, 0x9c, 0x00, 0x1b, 0x00, 0x26, 0x00, 0x73, 0x40, 0x16, 0x0b, 0x08,
+	0x02, 0x00, 0x01, 0x1b, 0x02, 0x01, 0x03, 0x03, 0x00, 0x19, 0x01, 0x05, 0x03, 0x03, 0x4c, 0x0a,
+	0x09, 0x02, 0x01, 0x4a, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x06,
+	0x01, 0x04, 0x04, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x03,
+	0x00, 0x05, 0x04, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3a, 0x4d,
+	0x06, 0x01, 0x04, 0x04, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x1d,
+	0x1c, 0x22, 0x20, 0x1c, 0x26, 0x1d, 0x26, 0x24, 0x29, 0x11, 0x23, 0x07, 0x09, 0x1a, 0x2b, 0x01,
+	0x27, 0x37, 0x26, 0x23, 0x23, 0x37, 0x32, 0x17, 0x37, 0x17, 0x07, 0x16, 0x12, 0x03, 0x02, 0x00,
+	0x23, 0x22, 0x02, 0x37, 0x36, 0x00, 0x33, 0x32, 0x17, 0x26, 0x27, 0x03, 0x32, 0x36, 0x37, 0x12,
+	0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x01, 0xd6, 0x42, 0xdf, 0x81, 0x71, 0x21, 0x25, 0xf3, 0xc6,
+	0xff, 0x44, 0xca, 0xe5, 0xa8, 0x35, 0x37, 0xfe, 0x89, 0xf8, 0xf5, 0xf3, 0x32, 0x2f, 0x01, 0x61,
+	0xde, 0x4f, 0x57, 0x33, 0x9c, 0x64, 0x74, 0xb2, 0x24, 0x44, 0xf6, 0x74, 0xb0, 0x22, 0x21, 0x5f,
+	0x04, 0x46, 0x66, 0x9f, 0x26, 0xba, 0x4b, 0xbc, 0x68, 0x93, 0x91, 0xfe, 0x48, 0xfe, 0xf9, 0xfe,
+	0xeb, 0xfe, 0xab, 0x01, 0x31, 0xf6, 0xed, 0x01, 0x36, 0x1a, 0x96, 0x69, 0xfb, 0x8a, 0xd0, 0xb2,
+	0x01, 0x57, 0xc6, 0xa8, 0xa5, 0xc6, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x05, 0x3c,
+	0x06, 0x4e, 0x00, 0x10, 0x00, 0x27, 0x00, 0xea, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x29, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08, 0x01, 0x06, 0x06, 0x3a,
+	0x4d, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08, 0x01, 0x06, 0x06,
+	0x3a, 0x4d, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x08, 0x01, 0x06, 0x00,
+	0x0a, 0x05, 0x06, 0x0a, 0x69, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b,
+	0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05,
+	0x06, 0x0a, 0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x01, 0x07, 0x05, 0x6a, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x19, 0x00, 0x00, 0x27, 0x25, 0x20, 0x1e, 0x1d, 0x1c,
+	0x1b, 0x19, 0x15, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0c, 0x09,
+	0x1a, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23,
+	0x22, 0x07, 0x03, 0x13, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x37, 0x33, 0x02,
+	0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x94, 0xdb, 0x01, 0x28, 0x24, 0xd2, 0xcc,
+	0x01, 0x2b, 0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x15, 0x23, 0x44, 0x78, 0xab, 0x8f, 0xea, 0x94, 0x40,
+	0xca, 0x40, 0x35, 0x21, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x36, 0x21, 0x14,
+	0x07, 0x34, 0x1d, 0x44, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50,
+	0xae, 0xfd, 0x34, 0x05, 0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a,
+	0x10, 0x06, 0x2d, 0x00, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x65, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x04,
+	0x05, 0x5f, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x05, 0x00, 0x04, 0x01, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d,
+	0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x08, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12,
+	0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06,
+	0x07, 0x06, 0x16, 0x01, 0x23, 0x01, 0x21, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb,
+	0xfb, 0xef, 0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24,
+	0x23, 0x55, 0x02, 0x17, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06,
+	0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2,
+	0xb3, 0xb1, 0xd4, 0x04, 0x63, 0x01, 0x41, 0x00, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x70, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x25, 0x08, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01,
+	0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18,
+	0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17,
+	0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00,
+	0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x16, 0x13, 0x01, 0x21, 0x01, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb,
+	0xef, 0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23,
+	0x55, 0x85, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01,
+	0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3,
+	0xb1, 0xd4, 0x04, 0x63, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x7b, 0xb5, 0x1d, 0x01, 0x05, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26, 0x09, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05,
+	0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18,
+	0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x03, 0x01, 0x21,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef,
+	0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55,
+	0x2b, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01,
+	0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2,
+	0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x63, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x03, 0x00, 0x83,
+	0xff, 0xe7, 0x05, 0x3b, 0x06, 0x4e, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2e, 0x00, 0xb7, 0x4b, 0xb0,
+	0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d,
+	0x08, 0x01, 0x04, 0x04, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04,
+	0x05, 0x09, 0x69, 0x08, 0x01, 0x04, 0x04, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09,
+	0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x01, 0x06, 0x04, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x40, 0x1f, 0x0d, 0x0c, 0x01, 0x00, 0x2e, 0x2c, 0x27, 0x25, 0x24, 0x23, 0x22,
+	0x20, 0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x13, 0x23, 0x12,
+	0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27,
+	0x26, 0x23, 0x22, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35,
+	0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x79, 0x94,
+	0x40, 0xca, 0x40, 0x35, 0x21, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x36, 0x21,
+	0x14, 0x07, 0x34, 0x1d, 0x44, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8,
+	0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04,
+	0x6d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00,
+	0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b, 0x05, 0xeb, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x79, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05,
+	0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01,
+	0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x06, 0x07, 0x06, 0x16, 0x13, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x02, 0x66, 0xf6,
+	0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25,
+	0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x04, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c,
+	0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe,
+	0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x6d, 0xde, 0xde, 0xde, 0xde,
+	0x00, 0x03, 0x00, 0xcd, 0x00, 0x25, 0x04, 0xca, 0x04, 0x7b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0xe2, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04,
+	0x05, 0x67, 0x06, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x00, 0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02,
+	0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67,
+	0x06, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x00, 0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05,
+	0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x23,
+	0x00, 0x00, 0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04,
+	0x05, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03,
+	0x02, 0x03, 0x4f, 0x59, 0x59, 0x59, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08,
+	0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x09, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x01, 0x37, 0x33, 0x07, 0x01, 0x37, 0x21, 0x07,
+	0x02, 0x8e, 0x31, 0xf7, 0x31, 0xfe, 0x5c, 0x31, 0xf7, 0x31, 0xfd, 0xf5, 0x22, 0x03, 0xdb, 0x22,
+	0x03, 0x85, 0xf6, 0xf6, 0xfc, 0xa0, 0xf7, 0xf7, 0x01, 0xd5, 0xad, 0xad, 0x00, 0x03, 0x00, 0x45,
+	0xff, 0xe7, 0x05, 0x79, 0x04, 0x63, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x4b, 0x40, 0x48,
+	0x0f, 0x0c, 0x02, 0x05, 0x02, 0x22, 0x21, 0x1a, 0x19, 0x04, 0x04, 0x05, 0x05, 0x02, 0x02, 0x00,
+	0x04, 0x03, 0x4c, 0x08, 0x01, 0x05, 0x05, 0x02, 0x61, 0x03, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x07,
+	0x01, 0x04, 0x04, 0x00, 0x61, 0x01, 0x06, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1d, 0x1c, 0x15,
+	0x14, 0x01, 0x00, 0x1c, 0x23, 0x1d, 0x23, 0x14, 0x1b, 0x15, 0x1b, 0x0e, 0x0d, 0x0b, 0x09, 0x04,
+	0x03, 0x00, 0x13, 0x01, 0x13, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x27, 0x07, 0x23, 0x37, 0x26,
+	0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x07, 0x02, 0x00, 0x27, 0x32, 0x36,
+	0x37, 0x36, 0x27, 0x01, 0x16, 0x01, 0x22, 0x06, 0x07, 0x06, 0x17, 0x01, 0x26, 0x02, 0x66, 0xb1,
+	0x6f, 0x52, 0xaf, 0xaa, 0x6a, 0x32, 0x35, 0x01, 0x6a, 0xfb, 0xb6, 0x71, 0x52, 0xaf, 0xac, 0x6b,
+	0x31, 0x35, 0xfe, 0x95, 0xd9, 0x7c, 0xb8, 0x25, 0x15, 0x0a, 0xfe, 0x06, 0x33, 0x01, 0x01, 0x79,
+	0xb8, 0x24, 0x15, 0x0a, 0x01, 0xfa, 0x36, 0x19, 0x51, 0x51, 0xaa, 0x9b, 0xf9, 0x01, 0x06, 0x01,
+	0x38, 0x52, 0x52, 0xaa, 0x9a, 0xf8, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0x65, 0x53, 0xfe,
+	0x0b, 0x4a, 0x03, 0x0a, 0xd2, 0xb3, 0x66, 0x55, 0x01, 0xf6, 0x4a, 0x00, 0x00, 0x02, 0x00, 0x83,
+	0xff, 0xe7, 0x05, 0x2b, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xc2, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0xb5, 0x01, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c,
+	0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06,
+	0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x05,
+	0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x00, 0x05, 0x01, 0x06, 0x05, 0x67, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x06, 0x00, 0x05, 0x01, 0x06, 0x05, 0x67,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x14, 0x13,
+	0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x08, 0x09, 0x1a, 0x2b, 0x21, 0x37,
+	0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x03,
+	0x23, 0x01, 0x21, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15,
+	0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0x25, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0xb6, 0xcf,
+	0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01,
+	0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0xd4, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01, 0x00, 0x02, 0x01, 0x4c,
+	0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21,
+	0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06,
+	0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06,
+	0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85,
+	0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04,
+	0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x15, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10,
+	0x12, 0x23, 0x12, 0x22, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x01, 0x21, 0x01, 0x03, 0x28, 0x24,
+	0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01,
+	0x28, 0xdb, 0xfe, 0x5d, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08,
+	0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xe4,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x16, 0x01, 0x06, 0x05, 0x01, 0x01, 0x00, 0x02, 0x02,
+	0x4c, 0x1b, 0x40, 0x0a, 0x16, 0x01, 0x06, 0x05, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x09, 0x07, 0x02, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80,
+	0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x08, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26,
+	0x09, 0x07, 0x02, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05,
+	0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x01, 0x06, 0x85,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00,
+	0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22,
+	0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x28, 0x24,
+	0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01,
+	0x28, 0xdb, 0xfd, 0xa2, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0xb6, 0xcf, 0x01,
+	0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41,
+	0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b, 0x05, 0xeb, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0xde, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01, 0x00, 0x02,
+	0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x21, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x06,
+	0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09,
+	0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06,
+	0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x07, 0x01,
+	0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x1d, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18,
+	0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22,
+	0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03, 0x28, 0x24,
+	0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01,
+	0x28, 0xdb, 0xfd, 0xbd, 0x2c, 0xde, 0x2c, 0xed, 0x2c, 0xdf, 0x2c, 0xb6, 0xcf, 0x01, 0x5b, 0x03,
+	0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde,
+	0x00, 0x02, 0x00, 0x5c, 0xfe, 0x75, 0x05, 0x34, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x53,
+	0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01,
+	0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x03, 0x04, 0x03, 0x85,
+	0x05, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x08, 0x08, 0x08, 0x0b, 0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x06,
+	0x09, 0x1a, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x01, 0x01, 0x21, 0x01, 0x01,
+	0xa3, 0xaf, 0x01, 0x38, 0x70, 0x01, 0xbc, 0xdc, 0xfc, 0x56, 0xfe, 0xd2, 0x02, 0x34, 0x01, 0x31,
+	0x01, 0x19, 0xfe, 0x7f, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x8e, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x45, 0xfe, 0x75, 0x05, 0x38, 0x06, 0x2b, 0x00, 0x0e,
+	0x00, 0x17, 0x00, 0x35, 0x40, 0x32, 0x04, 0x01, 0x05, 0x02, 0x0e, 0x01, 0x03, 0x04, 0x02, 0x4c,
+	0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x4e, 0x22,
+	0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x21, 0x01, 0x21, 0x03, 0x36, 0x33,
+	0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x13, 0x12, 0x23, 0x22,
+	0x07, 0x01, 0x6d, 0xfe, 0xd8, 0x01, 0x8a, 0x01, 0x28, 0x84, 0xc6, 0xbc, 0xac, 0x97, 0x31, 0x39,
+	0xfe, 0xb0, 0xf3, 0x51, 0x7e, 0x23, 0x6c, 0x37, 0xf6, 0x4c, 0x47, 0xb3, 0x78, 0x95, 0xfe, 0x75,
+	0x07, 0xb6, 0xfd, 0x69, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe, 0xe4, 0xfe, 0xc0, 0x19, 0xb0, 0x13, 0x01,
+	0x7d, 0x01, 0x61, 0xaf, 0x00, 0x03, 0x00, 0x5c, 0xfe, 0x75, 0x05, 0x34, 0x05, 0xeb, 0x00, 0x07,
+	0x00, 0x0b, 0x00, 0x0f, 0x00, 0x5c, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x1d,
+	0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x07, 0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03,
+	0x38, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40,
+	0x18, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0x40, 0x15, 0x0c, 0x0c, 0x08, 0x08,
+	0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b, 0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x09, 0x09,
+	0x1a, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37,
+	0x33, 0x07, 0x01, 0xa3, 0xaf, 0x01, 0x38, 0x70, 0x01, 0xbc, 0xdc, 0xfc, 0x56, 0xfe, 0xd2, 0x01,
+	0x9e, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b,
+	0x06, 0x98, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba,
+	0x07, 0x19, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6a, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x05, 0x08, 0x01, 0x06, 0x00, 0x05, 0x06,
+	0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03,
+	0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x00, 0x06, 0x04, 0x06, 0x00, 0x04,
+	0x80, 0x00, 0x05, 0x08, 0x01, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00,
+	0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x03,
+	0x37, 0x21, 0x07, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5,
+	0x01, 0x59, 0x01, 0xcc, 0x70, 0xfa, 0x23, 0x02, 0xe4, 0x23, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b,
+	0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xce, 0xad, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0x86,
+	0xff, 0xe7, 0x05, 0x2f, 0x05, 0xc4, 0x00, 0x03, 0x00, 0x11, 0x00, 0x1a, 0x00, 0xa1, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x06, 0x06, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61,
+	0x05, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x08,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x07,
+	0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x00, 0x08, 0x01,
+	0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00, 0x19, 0x17, 0x15, 0x13, 0x11, 0x10,
+	0x0f, 0x0e, 0x0d, 0x0b, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x01,
+	0x37, 0x21, 0x07, 0x01, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03,
+	0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x02, 0x29, 0x22, 0x02, 0xe4, 0x22,
+	0xfe, 0x3e, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb,
+	0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x05, 0x17, 0xad, 0xad, 0xfb,
+	0x9f, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe,
+	0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x16, 0x00, 0x74, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06,
+	0x08, 0x69, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x26, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85,
+	0x00, 0x00, 0x08, 0x04, 0x08, 0x00, 0x04, 0x80, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06, 0x08, 0x69,
+	0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x40, 0x16, 0x00, 0x00, 0x15, 0x13, 0x11, 0x10, 0x0f, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03,
+	0x21, 0x03, 0x01, 0x21, 0x03, 0x03, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22,
+	0x26, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59,
+	0x01, 0xcc, 0x70, 0xb0, 0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9d, 0x05,
+	0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x02, 0xf1, 0x8e, 0x8e, 0x93,
+	0xae, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x19, 0x00, 0x22, 0x00, 0xe0, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x27, 0x02, 0x01, 0x00,
+	0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x07, 0x01, 0x04,
+	0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2f, 0x02, 0x01, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x3b,
+	0x4d, 0x00, 0x08, 0x08, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d,
+	0x00, 0x09, 0x09, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x2f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42,
+	0x04, 0x4e, 0x1b, 0x40, 0x2d, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x03, 0x05,
+	0x01, 0x03, 0x69, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42,
+	0x04, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0e, 0x21, 0x1f, 0x22, 0x11, 0x11, 0x24, 0x23, 0x22, 0x11,
+	0x21, 0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x13, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21,
+	0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x02, 0x5a, 0x94, 0x0d, 0xa5, 0xa3, 0x46,
+	0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9e, 0xfe, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3,
+	0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94,
+	0x06, 0x44, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0xfb, 0x06, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01,
+	0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x02, 0x00, 0x0c,
+	0xfe, 0x8e, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x14, 0x00, 0x17, 0x00, 0x8f, 0x40, 0x0f, 0x17, 0x01,
+	0x06, 0x00, 0x0a, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x11, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x07, 0x05, 0x02, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x3d, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x06, 0x00, 0x04, 0x01,
+	0x06, 0x04, 0x68, 0x00, 0x02, 0x00, 0x03, 0x02, 0x03, 0x65, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07,
+	0x05, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00,
+	0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00, 0x02, 0x00, 0x03, 0x02, 0x03, 0x65, 0x07, 0x05,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x16, 0x15, 0x00, 0x14,
+	0x00, 0x14, 0x14, 0x23, 0x23, 0x11, 0x11, 0x08, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x23,
+	0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x37, 0x03, 0x21, 0x03,
+	0x01, 0x21, 0x03, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0x9d, 0xcc, 0x12, 0x13, 0xa2, 0x55,
+	0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f, 0x18, 0xf4, 0x49, 0xfd, 0x9c, 0xe5, 0x01, 0x59, 0x01,
+	0xcc, 0x70, 0x05, 0xc8, 0xfa, 0x38, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x5d, 0x01,
+	0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x00, 0x00, 0x02, 0x00, 0x86, 0xfe, 0x8e, 0x05, 0x2a,
+	0x04, 0x63, 0x00, 0x1b, 0x00, 0x24, 0x00, 0xcd, 0xb5, 0x13, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x00, 0x07, 0x07, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x08, 0x08, 0x00, 0x5f, 0x06, 0x03, 0x02, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x04, 0x04,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2a,
+	0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06,
+	0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x08, 0x08, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x27, 0x00, 0x04, 0x00, 0x05, 0x04, 0x05, 0x65, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x07,
+	0x07, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x08,
+	0x08, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x04, 0x00, 0x05,
+	0x04, 0x05, 0x65, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x08, 0x08, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0c, 0x22, 0x22, 0x13, 0x23, 0x23, 0x11, 0x11, 0x24,
+	0x21, 0x09, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x21, 0x03, 0x23, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x37,
+	0x23, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x03, 0x4b, 0xc5, 0xbc, 0xac, 0x98,
+	0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0x1e, 0xcc, 0x12, 0x13, 0xa2, 0x55,
+	0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f, 0x18, 0xf4, 0x6c, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46,
+	0xb3, 0x78, 0x94, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x56,
+	0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x5d, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf,
+	0x00, 0x02, 0x00, 0x99, 0xff, 0xdb, 0x06, 0x94, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x66,
+	0x40, 0x0a, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05,
+	0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17, 0x14, 0x17, 0x12, 0x22, 0x23,
+	0x24, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x07, 0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21,
+	0x20, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x02, 0x21, 0x32, 0x03, 0x01, 0x21, 0x01, 0x05, 0xb7,
+	0x2e, 0xea, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0xb7, 0x4a, 0x4d, 0x01, 0xed, 0x01, 0x8f, 0x01, 0x03,
+	0xe5, 0x30, 0xfe, 0xc8, 0xfd, 0xff, 0x72, 0x71, 0x02, 0x1e, 0xeb, 0xce, 0x01, 0x31, 0x01, 0x19,
+	0xfe, 0x7f, 0x01, 0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1,
+	0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x05, 0x9e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x81,
+	0xff, 0xe7, 0x05, 0x39, 0x06, 0x44, 0x00, 0x13, 0x00, 0x17, 0x00, 0x6b, 0x40, 0x0a, 0x0a, 0x01,
+	0x02, 0x01, 0x0b, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23, 0x06,
+	0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17, 0x14, 0x17, 0x12, 0x23,
+	0x23, 0x23, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x21,
+	0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x03, 0x01, 0x21, 0x01, 0x04,
+	0x4d, 0x29, 0xdd, 0xa3, 0xfe, 0xde, 0xfe, 0xff, 0x36, 0x73, 0x02, 0x75, 0xae, 0xa1, 0x2a, 0xc6,
+	0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0x9b, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xe5,
+	0xcd, 0x31, 0x01, 0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x04,
+	0x58, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x99, 0xff, 0xdb, 0x06, 0x94,
+	0x07, 0x8f, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x6d, 0x40, 0x0e, 0x19, 0x01, 0x05, 0x04, 0x0b, 0x01,
+	0x02, 0x01, 0x0c, 0x01, 0x03, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e,
+	0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b, 0x11, 0x12, 0x22, 0x23, 0x24,
+	0x22, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x07, 0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20,
+	0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x02, 0x21, 0x32, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23,
+	0x07, 0x05, 0xb7, 0x2e, 0xea, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0xb7, 0x4a, 0x4d, 0x01, 0xed, 0x01,
+	0x8f, 0x01, 0x03, 0xe5, 0x30, 0xfe, 0xc8, 0xfd, 0xff, 0x72, 0x71, 0x02, 0x1e, 0xeb, 0xfe, 0x6a,
+	0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x01, 0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01,
+	0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x05, 0x9e, 0x01, 0x41,
+	0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x81, 0xff, 0xe7, 0x05, 0x1a, 0x06, 0x44, 0x00, 0x13,
+	0x00, 0x1b, 0x00, 0x72, 0x40, 0x0e, 0x19, 0x01, 0x05, 0x04, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01,
+	0x03, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05, 0x04,
+	0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b, 0x11, 0x12, 0x23, 0x23,
+	0x23, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x21, 0x32,
+	0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x04, 0x4d, 0x29, 0xdd, 0xa3, 0xfe, 0xde, 0xfe, 0xff, 0x36, 0x73, 0x02, 0x75, 0xae,
+	0xa1, 0x2a, 0xc6, 0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0xfe, 0x9d, 0x01, 0x31, 0x01,
+	0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0xe5, 0xcd, 0x31, 0x01, 0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b,
+	0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x04, 0x58, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x99, 0xff, 0xdb, 0x06, 0x94, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x62,
+	0x40, 0x0a, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1e, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00,
+	0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00,
+	0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17, 0x14, 0x17, 0x12, 0x22, 0x23, 0x24, 0x22, 0x07, 0x09,
+	0x1b, 0x2b, 0x01, 0x07, 0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x17, 0x07, 0x26,
+	0x23, 0x20, 0x03, 0x02, 0x21, 0x32, 0x03, 0x13, 0x21, 0x03, 0x05, 0xb7, 0x2e, 0xea, 0xfe, 0xc0,
+	0xfe, 0x83, 0xfe, 0xb7, 0x4a, 0x4d, 0x01, 0xed, 0x01, 0x8f, 0x01, 0x03, 0xe5, 0x30, 0xfe, 0xc8,
+	0xfd, 0xff, 0x72, 0x71, 0x02, 0x1e, 0xeb, 0xa9, 0x3b, 0x01, 0x28, 0x3b, 0x01, 0x1e, 0xe3, 0x60,
+	0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x05,
+	0xb7, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x81, 0xff, 0xe7, 0x04, 0xee,
+	0x06, 0x3f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x66, 0x40, 0x0a, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01,
+	0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x05, 0x05, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04,
+	0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x14,
+	0x14, 0x14, 0x17, 0x14, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x07,
+	0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16,
+	0x33, 0x32, 0x03, 0x13, 0x21, 0x03, 0x04, 0x4d, 0x29, 0xdd, 0xa3, 0xfe, 0xde, 0xfe, 0xff, 0x36,
+	0x73, 0x02, 0x75, 0xae, 0xa1, 0x2a, 0xc6, 0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0x90,
+	0x3b, 0x01, 0x28, 0x3b, 0xe5, 0xcd, 0x31, 0x01, 0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b,
+	0xfe, 0x8a, 0xb2, 0xca, 0x04, 0x6c, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00, 0x02, 0x00, 0x99,
+	0xff, 0xdb, 0x06, 0x94, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x6d, 0x40, 0x0e, 0x19, 0x01,
+	0x04, 0x05, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b, 0x11,
+	0x12, 0x22, 0x23, 0x24, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x07, 0x06, 0x21, 0x20, 0x00, 0x13,
+	0x12, 0x00, 0x21, 0x20, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x02, 0x21, 0x32, 0x01, 0x01, 0x21,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x05, 0xb7, 0x2e, 0xea, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0xb7, 0x4a,
+	0x4d, 0x01, 0xed, 0x01, 0x8f, 0x01, 0x03, 0xe5, 0x30, 0xfe, 0xc8, 0xfd, 0xff, 0x72, 0x71, 0x02,
+	0x1e, 0xeb, 0x01, 0x9b, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0x01, 0x1e, 0xe3,
+	0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6, 0xfd, 0xc8,
+	0x06, 0xdf, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0x81, 0xff, 0xe7, 0x05, 0x50,
+	0x06, 0x44, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x72, 0x40, 0x0e, 0x19, 0x01, 0x04, 0x05, 0x0a, 0x01,
+	0x02, 0x01, 0x0b, 0x01, 0x03, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24, 0x00,
+	0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x07, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b,
+	0x11, 0x12, 0x23, 0x23, 0x23, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x00,
+	0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x01, 0x01,
+	0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x04, 0x4d, 0x29, 0xdd, 0xa3, 0xfe, 0xde, 0xfe, 0xff, 0x36,
+	0x73, 0x02, 0x75, 0xae, 0xa1, 0x2a, 0xc6, 0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0x01,
+	0xc6, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0xe5, 0xcd, 0x31, 0x01, 0x2d, 0x01,
+	0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x05, 0x99, 0xfe, 0xbf, 0x01, 0x41,
+	0xc6, 0xc6, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x55, 0x07, 0x8f, 0x00, 0x08,
+	0x00, 0x11, 0x00, 0x19, 0x00, 0x6f, 0xb5, 0x17, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85,
+	0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x07,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85,
+	0x00, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x6a, 0x00, 0x02, 0x02,
+	0x01, 0x5f, 0x07, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x12, 0x12, 0x00, 0x00,
+	0x12, 0x19, 0x12, 0x19, 0x16, 0x15, 0x14, 0x13, 0x11, 0x0f, 0x0b, 0x09, 0x00, 0x08, 0x00, 0x07,
+	0x21, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x21, 0x27, 0x33,
+	0x32, 0x00, 0x13, 0x36, 0x02, 0x23, 0x23, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xad,
+	0x01, 0x27, 0x02, 0x03, 0x01, 0x58, 0x01, 0x26, 0x44, 0x4a, 0xfe, 0x2c, 0xfe, 0xa2, 0x8b, 0x6d,
+	0xf3, 0x01, 0x25, 0x37, 0x31, 0xb8, 0xd3, 0x8c, 0x02, 0xb5, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3,
+	0x9d, 0x03, 0xed, 0x05, 0xc8, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2, 0x01, 0x0d,
+	0x01, 0x12, 0xf5, 0x01, 0x17, 0x02, 0x92, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x86, 0xff, 0xe7, 0x06, 0xfc, 0x06, 0x2b, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x21,
+	0x00, 0xe3, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0xb6, 0x1e, 0x0a, 0x02, 0x04, 0x01, 0x01, 0x4c, 0x1b,
+	0x40, 0x0a, 0x0a, 0x01, 0x08, 0x01, 0x1e, 0x01, 0x04, 0x08, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x10,
+	0x50, 0x58, 0x40, 0x22, 0x00, 0x06, 0x06, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00,
+	0x04, 0x04, 0x01, 0x61, 0x08, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x26, 0x00, 0x06,
+	0x06, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04,
+	0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x06, 0x02,
+	0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x06, 0x06, 0x02, 0x5f, 0x07, 0x01,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0c, 0x14, 0x11, 0x12, 0x22, 0x22, 0x11, 0x12, 0x24,
+	0x21, 0x09, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x13, 0x21, 0x01, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x01, 0x23, 0x13,
+	0x21, 0x07, 0x02, 0x05, 0x37, 0x32, 0x37, 0x03, 0x4b, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01,
+	0x51, 0xf3, 0x51, 0x7d, 0x60, 0x01, 0x28, 0xfe, 0xc5, 0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d,
+	0x46, 0xb3, 0x78, 0x94, 0x02, 0xbd, 0x72, 0x3b, 0x01, 0x03, 0x2e, 0x44, 0xfe, 0xf9, 0x14, 0x72,
+	0x21, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0x01, 0xe1, 0xf9, 0xd5, 0x03,
+	0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x03, 0x85, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66,
+	0xa5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x00, 0x06, 0x55, 0x05, 0xc8, 0x00, 0x0c,
+	0x00, 0x19, 0x00, 0x60, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01,
+	0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02,
+	0x00, 0x05, 0x01, 0x02, 0x05, 0x69, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67,
+	0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x19, 0x18, 0x17, 0x16, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0b, 0x21, 0x11, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00,
+	0x21, 0x27, 0x33, 0x32, 0x00, 0x13, 0x36, 0x02, 0x23, 0x23, 0x03, 0x33, 0x07, 0x23, 0xad, 0x85,
+	0xad, 0x23, 0xad, 0x7f, 0x02, 0x03, 0x01, 0x58, 0x01, 0x26, 0x44, 0x4a, 0xfe, 0x2c, 0xfe, 0xa2,
+	0x8b, 0x6d, 0xf3, 0x01, 0x25, 0x37, 0x31, 0xb8, 0xd3, 0x8c, 0x57, 0xd2, 0x23, 0xd2, 0x02, 0x9d,
+	0xad, 0x02, 0x7e, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2, 0x01, 0x0d, 0x01, 0x12,
+	0xf5, 0x01, 0x17, 0xfe, 0x4d, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86, 0xff, 0xe7, 0x05, 0xfb,
+	0x06, 0x2b, 0x00, 0x16, 0x00, 0x1f, 0x00, 0xa8, 0x40, 0x0a, 0x0c, 0x01, 0x08, 0x02, 0x02, 0x01,
+	0x00, 0x09, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x06, 0x01, 0x04, 0x07, 0x01,
+	0x03, 0x02, 0x04, 0x03, 0x68, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04,
+	0x03, 0x68, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01,
+	0x4e, 0x1b, 0x40, 0x29, 0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x68, 0x00, 0x05,
+	0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x3c, 0x4d, 0x00, 0x09, 0x09, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40,
+	0x0e, 0x1e, 0x1c, 0x22, 0x11, 0x11, 0x11, 0x11, 0x12, 0x24, 0x22, 0x10, 0x0a, 0x09, 0x1f, 0x2b,
+	0x21, 0x21, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x37, 0x21, 0x37,
+	0x21, 0x37, 0x21, 0x07, 0x33, 0x07, 0x23, 0x01, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37,
+	0x04, 0x4f, 0xfe, 0xd8, 0x24, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d,
+	0x1b, 0xfe, 0xfd, 0x22, 0x01, 0x03, 0x23, 0x01, 0x28, 0x23, 0x94, 0x22, 0x94, 0xfe, 0x9a, 0x6b,
+	0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40,
+	0x19, 0x88, 0xac, 0xad, 0xad, 0xac, 0xfe, 0xc8, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x19, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x70,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40,
+	0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c,
+	0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b,
+	0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07,
+	0xad, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03,
+	0x39, 0x29, 0xfd, 0xa0, 0x23, 0x02, 0xe4, 0x23, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38,
+	0xd2, 0x06, 0x6c, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x04, 0xe2,
+	0x05, 0xc4, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x6c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x28, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x08, 0x01, 0x07, 0x07, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x06, 0x08, 0x01,
+	0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x10, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12,
+	0x24, 0x22, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33,
+	0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x03, 0x37, 0x21, 0x07,
+	0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42,
+	0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0x7c, 0x22, 0x02,
+	0xe4, 0x22, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6,
+	0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x6d, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x7a, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09,
+	0x69, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b,
+	0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09, 0x69,
+	0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x16, 0x14, 0x12, 0x11, 0x10, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03,
+	0x21, 0x07, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xad, 0x01,
+	0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29,
+	0xfd, 0xe5, 0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9d, 0x05, 0xc8, 0xcb,
+	0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x07, 0x8f, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x04, 0xe2, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x21,
+	0x00, 0xaa, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02,
+	0x67, 0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x09, 0x09, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38,
+	0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2d, 0x08, 0x01,
+	0x06, 0x07, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x09, 0x09, 0x07,
+	0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2b, 0x08, 0x01,
+	0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69, 0x00, 0x04, 0x00, 0x02,
+	0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x20, 0x1e, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x07, 0x06, 0x23,
+	0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12,
+	0x23, 0x22, 0x03, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x04, 0x37,
+	0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b,
+	0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0x39, 0x94, 0x0d, 0xa5, 0xa3,
+	0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9e, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01,
+	0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x02, 0x9a, 0x8e, 0x8e, 0x93,
+	0xae, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x70, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x13, 0x21, 0x03, 0xad, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27,
+	0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xfe, 0x60, 0x3b, 0x01, 0x28, 0x3b, 0x05, 0xc8, 0xcb, 0xfe,
+	0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x67, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x03, 0x00, 0x83,
+	0xff, 0xe7, 0x04, 0xaa, 0x06, 0x3f, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x6c, 0x4b, 0xb0,
+	0x32, 0x50, 0x58, 0x40, 0x28, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x08, 0x01, 0x07,
+	0x07, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x26,
+	0x00, 0x06, 0x08, 0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02,
+	0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x10, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x12,
+	0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02,
+	0x13, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22,
+	0x13, 0x13, 0x21, 0x03, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50,
+	0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f,
+	0xa8, 0x54, 0x3b, 0x01, 0x28, 0x3b, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31,
+	0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x6d, 0x01, 0x28, 0xfe, 0xd8,
+	0x00, 0x01, 0x00, 0xad, 0xfe, 0x8e, 0x06, 0x12, 0x05, 0xc8, 0x00, 0x19, 0x00, 0xa2, 0xb5, 0x12,
+	0x01, 0x06, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x29, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00,
+	0x07, 0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05,
+	0x39, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x04, 0x04,
+	0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00,
+	0x00, 0x19, 0x00, 0x19, 0x23, 0x23, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b,
+	0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x23, 0x06, 0x07, 0x06,
+	0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x37, 0xad, 0x01, 0x27, 0x04, 0x3e, 0x28,
+	0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xa1, 0xcc, 0x12, 0x13,
+	0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f, 0x18, 0xf4, 0x05, 0xc8, 0xcb, 0xfe, 0x63,
+	0xc6, 0xfe, 0x38, 0xd2, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x5d, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x83, 0xfe, 0x8e, 0x04, 0xaa, 0x04, 0x63, 0x00, 0x1e, 0x00, 0x23, 0x00, 0x6b,
+	0xb5, 0x09, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06,
+	0x00, 0x04, 0x05, 0x06, 0x04, 0x67, 0x00, 0x07, 0x07, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x06, 0x00, 0x04, 0x05, 0x06, 0x04, 0x67,
+	0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x65, 0x00, 0x07, 0x07, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x21,
+	0x11, 0x21, 0x12, 0x25, 0x13, 0x23, 0x26, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x07, 0x06, 0x07, 0x06,
+	0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x37, 0x24, 0x27, 0x26, 0x13,
+	0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x04,
+	0x37, 0x29, 0x68, 0x64, 0xb2, 0x11, 0x13, 0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f,
+	0x15, 0xba, 0xfe, 0xfa, 0x7b, 0x7e, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b,
+	0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0xf5, 0xd0, 0x21, 0x0f, 0x51,
+	0x58, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x67, 0x53, 0x05, 0x98, 0x9e, 0x01, 0x12, 0xfe, 0x01, 0x31,
+	0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x7f, 0xb5, 0x11, 0x01, 0x06,
+	0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07,
+	0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06,
+	0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04,
+	0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b,
+	0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21,
+	0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x13, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xad,
+	0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39,
+	0x29, 0xa3, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05, 0xc8, 0xcb, 0xfe, 0x63,
+	0xc6, 0xfe, 0x38, 0xd2, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x03, 0x00, 0x83,
+	0xff, 0xe7, 0x05, 0x04, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x7b, 0xb5, 0x1b,
+	0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x06, 0x07, 0x01,
+	0x07, 0x06, 0x01, 0x80, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x09, 0x08, 0x02, 0x07,
+	0x07, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x09, 0x08, 0x02, 0x07, 0x06,
+	0x07, 0x85, 0x00, 0x06, 0x01, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x11, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d, 0x11, 0x12, 0x21,
+	0x11, 0x21, 0x12, 0x24, 0x22, 0x0a, 0x09, 0x1e, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13,
+	0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x01,
+	0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37,
+	0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01,
+	0x65, 0x38, 0x9f, 0xa8, 0x02, 0xac, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0xf5,
+	0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01,
+	0xe1, 0x01, 0x19, 0x02, 0x9a, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x02, 0x00, 0x9c,
+	0xff, 0xdb, 0x06, 0xc7, 0x07, 0x8f, 0x00, 0x1d, 0x00, 0x25, 0x00, 0x88, 0x40, 0x0e, 0x23, 0x01,
+	0x07, 0x06, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85, 0x09,
+	0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x28,
+	0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85, 0x00, 0x01, 0x00, 0x02,
+	0x05, 0x01, 0x02, 0x6a, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x18, 0x1e, 0x1e, 0x00, 0x00, 0x1e,
+	0x25, 0x1e, 0x25, 0x22, 0x21, 0x20, 0x1f, 0x00, 0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22,
+	0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x03, 0x04, 0x23, 0x22, 0x24, 0x27, 0x26, 0x13, 0x12, 0x37, 0x36,
+	0x24, 0x33, 0x20, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13,
+	0x23, 0x37, 0x03, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x06, 0x34, 0x89, 0xfe, 0xd9, 0xe8,
+	0xf9, 0xfe, 0xec, 0x55, 0x9e, 0x48, 0x45, 0xe4, 0x84, 0x01, 0x3d, 0xf2, 0x01, 0x22, 0xe5, 0x30,
+	0xfe, 0xe3, 0xdf, 0xfa, 0xfe, 0xc2, 0x39, 0x37, 0xdd, 0x01, 0x04, 0x47, 0x7a, 0x43, 0xfa, 0x28,
+	0xdf, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e,
+	0x72, 0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6,
+	0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x03, 0x7f, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5,
+	0x00, 0x03, 0x00, 0x37, 0xfe, 0x5c, 0x05, 0x2a, 0x06, 0x44, 0x00, 0x08, 0x00, 0x22, 0x00, 0x2a,
+	0x01, 0x04, 0x40, 0x0e, 0x28, 0x01, 0x08, 0x07, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06,
+	0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2f, 0x0a, 0x09, 0x02, 0x08, 0x07, 0x03, 0x07,
+	0x08, 0x03, 0x80, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x33,
+	0x0a, 0x09, 0x02, 0x08, 0x07, 0x03, 0x07, 0x08, 0x03, 0x80, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x30, 0x00, 0x07, 0x08, 0x07, 0x85,
+	0x0a, 0x09, 0x02, 0x08, 0x03, 0x08, 0x85, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x30, 0x00, 0x07,
+	0x08, 0x07, 0x85, 0x0a, 0x09, 0x02, 0x08, 0x03, 0x08, 0x85, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59,
+	0x59, 0x40, 0x12, 0x23, 0x23, 0x23, 0x2a, 0x23, 0x2a, 0x11, 0x14, 0x23, 0x25, 0x11, 0x24, 0x23,
+	0x22, 0x21, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x07,
+	0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06,
+	0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23,
+	0x07, 0x03, 0xdf, 0x6b, 0x37, 0xf6, 0x4a, 0x44, 0xb3, 0x78, 0x94, 0x28, 0xc5, 0xbc, 0xaa, 0x9a,
+	0x2f, 0x37, 0x01, 0x53, 0xf0, 0x51, 0x7d, 0x01, 0x28, 0xa8, 0x32, 0x68, 0x69, 0xad, 0xfe, 0xf4,
+	0xc1, 0xce, 0x2c, 0xc8, 0x9d, 0xa3, 0xae, 0x1f, 0xfe, 0xc2, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3,
+	0x9e, 0x03, 0xec, 0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec,
+	0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d,
+	0x04, 0xac, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x9c, 0xff, 0xdb, 0x06, 0xc7,
+	0x07, 0x8f, 0x00, 0x1d, 0x00, 0x29, 0x00, 0x86, 0x40, 0x0a, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x05, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06,
+	0x85, 0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05,
+	0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85,
+	0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x6a,
+	0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x28, 0x26, 0x24, 0x23, 0x22, 0x20, 0x1f,
+	0x1e, 0x00, 0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x03,
+	0x04, 0x23, 0x22, 0x24, 0x27, 0x26, 0x13, 0x12, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17, 0x07, 0x24,
+	0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37, 0x03, 0x33, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x06, 0x34, 0x89, 0xfe, 0xd9, 0xe8, 0xf9, 0xfe,
+	0xec, 0x55, 0x9e, 0x48, 0x45, 0xe4, 0x84, 0x01, 0x3d, 0xf2, 0x01, 0x22, 0xe5, 0x30, 0xfe, 0xe3,
+	0xdf, 0xfa, 0xfe, 0xc2, 0x39, 0x37, 0xdd, 0x01, 0x04, 0x47, 0x7a, 0x43, 0xfa, 0x28, 0x7e, 0x94,
+	0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9d, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e,
+	0x72, 0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6,
+	0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x04, 0xc0, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00,
+	0x00, 0x03, 0x00, 0x37, 0xfe, 0x5c, 0x05, 0x2a, 0x06, 0x44, 0x00, 0x08, 0x00, 0x22, 0x00, 0x2e,
+	0x01, 0x06, 0x40, 0x0a, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x02, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x30, 0x09, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x0a, 0x0a, 0x08, 0x61,
+	0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x34, 0x09, 0x01, 0x07,
+	0x07, 0x3a, 0x4d, 0x00, 0x0a, 0x0a, 0x08, 0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x04, 0x04,
+	0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x34, 0x09, 0x01, 0x07, 0x08, 0x07, 0x85, 0x00,
+	0x0a, 0x0a, 0x08, 0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x32,
+	0x09, 0x01, 0x07, 0x08, 0x07, 0x85, 0x00, 0x08, 0x00, 0x0a, 0x03, 0x08, 0x0a, 0x69, 0x00, 0x04,
+	0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43,
+	0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x10, 0x2d, 0x2b, 0x29, 0x28, 0x21, 0x13, 0x23, 0x25, 0x11,
+	0x24, 0x23, 0x22, 0x21, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32,
+	0x37, 0x07, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x06, 0x06,
+	0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x03, 0x33, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0xdf, 0x6b, 0x37, 0xf6, 0x4a, 0x44, 0xb3, 0x78,
+	0x94, 0x28, 0xc5, 0xbc, 0xaa, 0x9a, 0x2f, 0x37, 0x01, 0x53, 0xf0, 0x51, 0x7d, 0x01, 0x28, 0xa8,
+	0x32, 0x68, 0x69, 0xad, 0xfe, 0xf4, 0xc1, 0xce, 0x2c, 0xc8, 0x9d, 0xa3, 0xae, 0x1f, 0xee, 0x94,
+	0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9e, 0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe,
+	0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec, 0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde,
+	0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d, 0x05, 0xed, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9c, 0xff, 0xdb, 0x06, 0xc7, 0x07, 0x8f, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x7c,
+	0x40, 0x0a, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x08, 0x01, 0x05, 0x00,
+	0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09,
+	0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x08, 0x01,
+	0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x16, 0x1e, 0x1e, 0x00, 0x00, 0x1e, 0x21, 0x1e, 0x21, 0x20, 0x1f, 0x00,
+	0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x03, 0x04, 0x23,
+	0x22, 0x24, 0x27, 0x26, 0x13, 0x12, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17, 0x07, 0x24, 0x23, 0x22,
+	0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37, 0x13, 0x13, 0x21, 0x03, 0x06, 0x34,
+	0x89, 0xfe, 0xd9, 0xe8, 0xf9, 0xfe, 0xec, 0x55, 0x9e, 0x48, 0x45, 0xe4, 0x84, 0x01, 0x3d, 0xf2,
+	0x01, 0x22, 0xe5, 0x30, 0xfe, 0xe3, 0xdf, 0xfa, 0xfe, 0xc2, 0x39, 0x37, 0xdd, 0x01, 0x04, 0x47,
+	0x7a, 0x43, 0xfa, 0x28, 0x2e, 0x3b, 0x01, 0x28, 0x3b, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72,
+	0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe,
+	0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x03, 0x98, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x37, 0xfe, 0x5c, 0x05, 0x2a, 0x06, 0x3f, 0x00, 0x08, 0x00, 0x22, 0x00, 0x26,
+	0x00, 0xf3, 0x40, 0x0a, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x02, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x2b, 0x09, 0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2f, 0x09, 0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07,
+	0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40, 0x2f, 0x09,
+	0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40,
+	0x2d, 0x00, 0x07, 0x09, 0x01, 0x08, 0x03, 0x07, 0x08, 0x67, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59,
+	0x59, 0x40, 0x11, 0x23, 0x23, 0x23, 0x26, 0x23, 0x26, 0x14, 0x23, 0x25, 0x11, 0x24, 0x23, 0x22,
+	0x21, 0x0a, 0x09, 0x1e, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x07, 0x06,
+	0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x21,
+	0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x03, 0x13, 0x21, 0x03, 0x03, 0xdf, 0x6b, 0x37,
+	0xf6, 0x4a, 0x44, 0xb3, 0x78, 0x94, 0x28, 0xc5, 0xbc, 0xaa, 0x9a, 0x2f, 0x37, 0x01, 0x53, 0xf0,
+	0x51, 0x7d, 0x01, 0x28, 0xa8, 0x32, 0x68, 0x69, 0xad, 0xfe, 0xf4, 0xc1, 0xce, 0x2c, 0xc8, 0x9d,
+	0xa3, 0xae, 0x1f, 0x4d, 0x3b, 0x01, 0x28, 0x3b, 0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0,
+	0xc8, 0xcf, 0x01, 0x28, 0xec, 0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81,
+	0x4f, 0xda, 0x57, 0x8c, 0x9d, 0x04, 0xc0, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x02, 0x00, 0x9c,
+	0xfe, 0x50, 0x06, 0xc7, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x2c, 0x00, 0x96, 0x40, 0x12, 0x0f, 0x01,
+	0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x26, 0x01, 0x08, 0x09, 0x25, 0x01, 0x07, 0x08, 0x04, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x30, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67,
+	0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x08, 0x08, 0x07,
+	0x61, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01,
+	0x02, 0x69, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x06, 0x00, 0x09, 0x08,
+	0x06, 0x09, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x08, 0x08,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x2c, 0x2b, 0x29,
+	0x27, 0x24, 0x22, 0x1f, 0x1e, 0x00, 0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0b, 0x09,
+	0x1b, 0x2b, 0x01, 0x03, 0x04, 0x23, 0x22, 0x24, 0x27, 0x26, 0x13, 0x12, 0x37, 0x36, 0x24, 0x33,
+	0x20, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37,
+	0x01, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x06,
+	0x34, 0x89, 0xfe, 0xd9, 0xe8, 0xf9, 0xfe, 0xec, 0x55, 0x9e, 0x48, 0x45, 0xe4, 0x84, 0x01, 0x3d,
+	0xf2, 0x01, 0x22, 0xe5, 0x30, 0xfe, 0xe3, 0xdf, 0xfa, 0xfe, 0xc2, 0x39, 0x37, 0xdd, 0x01, 0x04,
+	0x47, 0x7a, 0x43, 0xfa, 0x28, 0xfe, 0xa3, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13,
+	0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72, 0xd4, 0x01, 0x67,
+	0x01, 0x58, 0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe, 0xee, 0xfe, 0xda,
+	0x0e, 0x01, 0x4b, 0xcb, 0xfc, 0xd0, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00,
+	0x00, 0x03, 0x00, 0x37, 0xfe, 0x5c, 0x05, 0x2a, 0x07, 0x68, 0x00, 0x08, 0x00, 0x22, 0x00, 0x2c,
+	0x01, 0x0e, 0x40, 0x0a, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x02, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x32, 0x00, 0x09, 0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x08, 0x08,
+	0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x36, 0x00,
+	0x09, 0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a,
+	0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40, 0x36, 0x00, 0x09, 0x00,
+	0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x1b, 0x40, 0x34, 0x00, 0x09, 0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x07,
+	0x00, 0x08, 0x03, 0x07, 0x08, 0x67, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d, 0x00,
+	0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x10, 0x2b,
+	0x2a, 0x29, 0x28, 0x11, 0x13, 0x23, 0x25, 0x11, 0x24, 0x23, 0x22, 0x21, 0x0b, 0x09, 0x1f, 0x2b,
+	0x01, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33,
+	0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x21, 0x37, 0x12, 0x21, 0x07, 0x22, 0x07, 0x03, 0xdf, 0x6b,
+	0x37, 0xf6, 0x4a, 0x44, 0xb3, 0x78, 0x94, 0x28, 0xc5, 0xbc, 0xaa, 0x9a, 0x2f, 0x37, 0x01, 0x53,
+	0xf0, 0x51, 0x7d, 0x01, 0x28, 0xa8, 0x32, 0x68, 0x69, 0xad, 0xfe, 0xf4, 0xc1, 0xce, 0x2c, 0xc8,
+	0x9d, 0xa3, 0xae, 0x1f, 0xa4, 0x72, 0x3b, 0xfe, 0xd8, 0x2e, 0x48, 0x01, 0x28, 0x14, 0x72, 0x21,
+	0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec, 0x01, 0x12, 0x01,
+	0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d, 0x05, 0xe8, 0xfe,
+	0xd8, 0xe6, 0x01, 0x6b, 0x67, 0xa4, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x41,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x71, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00,
+	0x07, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x09, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x68, 0x09, 0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18,
+	0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21,
+	0x01, 0x21, 0x13, 0x21, 0x03, 0x13, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0xad, 0x01, 0x27,
+	0x01, 0x34, 0x78, 0x02, 0x05, 0x78, 0x01, 0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0x86, 0xfd, 0xfb, 0x86,
+	0xbf, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59,
+	0xfa, 0x38, 0x02, 0xa3, 0xfd, 0x5d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x05, 0x3c, 0x07, 0xcf, 0x00, 0x10, 0x00, 0x18, 0x00, 0x77,
+	0x40, 0x0a, 0x16, 0x01, 0x06, 0x05, 0x03, 0x01, 0x03, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00,
+	0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04,
+	0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07,
+	0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x11,
+	0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23,
+	0x12, 0x22, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x36, 0x33, 0x20, 0x03, 0x03,
+	0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x13, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07,
+	0x94, 0x01, 0x3b, 0x01, 0x28, 0x84, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x15,
+	0x23, 0x44, 0x78, 0xab, 0x8f, 0x84, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x06,
+	0x2b, 0xfd, 0x69, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x06,
+	0x8e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0xa6,
+	0x05, 0xc8, 0x00, 0x03, 0x00, 0x17, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x07,
+	0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01, 0x00, 0x03, 0x01, 0x68, 0x00, 0x00, 0x00, 0x0a, 0x09,
+	0x00, 0x0a, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0c, 0x0b, 0x02, 0x09, 0x09, 0x39, 0x09,
+	0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x05, 0x02, 0x03, 0x08, 0x02,
+	0x02, 0x01, 0x00, 0x03, 0x01, 0x68, 0x00, 0x00, 0x00, 0x0a, 0x09, 0x00, 0x0a, 0x67, 0x0c, 0x0b,
+	0x02, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x16, 0x04, 0x04, 0x04, 0x17, 0x04, 0x17, 0x16,
+	0x15, 0x14, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x11, 0x10, 0x0d, 0x09, 0x1f, 0x2b,
+	0x01, 0x21, 0x37, 0x21, 0x01, 0x13, 0x23, 0x37, 0x33, 0x37, 0x21, 0x07, 0x21, 0x37, 0x21, 0x07,
+	0x33, 0x07, 0x23, 0x03, 0x21, 0x13, 0x21, 0x03, 0x02, 0x90, 0x02, 0x05, 0x2c, 0xfd, 0xfb, 0xfd,
+	0xf1, 0xdb, 0x94, 0x1d, 0x94, 0x2f, 0x01, 0x34, 0x2f, 0x02, 0x05, 0x2f, 0x01, 0x34, 0x2f, 0x94,
+	0x1d, 0x94, 0xdb, 0xfe, 0xcc, 0x86, 0xfd, 0xfb, 0x86, 0x03, 0x6f, 0xdb, 0xfb, 0xb6, 0x04, 0x4a,
+	0x94, 0xea, 0xea, 0xea, 0xea, 0x94, 0xfb, 0xb6, 0x02, 0xa3, 0xfd, 0x5d, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x05, 0x3c, 0x06, 0x2b, 0x00, 0x18, 0x00, 0x68, 0xb5, 0x0b, 0x01, 0x07, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01,
+	0x00, 0x68, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41,
+	0x4d, 0x09, 0x08, 0x02, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01, 0x04,
+	0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x41, 0x4d, 0x09, 0x08, 0x02, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x11,
+	0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x23, 0x12, 0x22, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09,
+	0x1e, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37, 0x21, 0x07, 0x21, 0x07, 0x21, 0x03, 0x36, 0x33,
+	0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x94, 0xfb, 0x7b, 0x1d, 0x7b,
+	0x23, 0x01, 0x28, 0x23, 0x01, 0x28, 0x1d, 0xfe, 0xd8, 0x44, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b,
+	0xfe, 0xd8, 0x8c, 0x15, 0x23, 0x44, 0x78, 0xab, 0x8f, 0x04, 0xea, 0x94, 0xad, 0xad, 0x94, 0xfe,
+	0xaa, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0xa5, 0x07, 0x8f, 0x00, 0x16, 0x00, 0x22, 0x00, 0x76,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69,
+	0x00, 0x02, 0x04, 0x01, 0x00, 0x08, 0x02, 0x00, 0x6a, 0x09, 0x01, 0x07, 0x07, 0x08, 0x5f, 0x00,
+	0x08, 0x08, 0x38, 0x4d, 0x0a, 0x01, 0x06, 0x06, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b, 0x39, 0x0b,
+	0x4e, 0x1b, 0x40, 0x28, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69, 0x00, 0x02, 0x04,
+	0x01, 0x00, 0x08, 0x02, 0x00, 0x6a, 0x00, 0x08, 0x09, 0x01, 0x07, 0x06, 0x08, 0x07, 0x67, 0x0a,
+	0x01, 0x06, 0x06, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x40, 0x16, 0x17,
+	0x17, 0x17, 0x22, 0x17, 0x22, 0x21, 0x20, 0x1f, 0x1e, 0x11, 0x11, 0x12, 0x25, 0x21, 0x11, 0x24,
+	0x21, 0x10, 0x0d, 0x09, 0x1f, 0x2b, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x01, 0x37, 0x33, 0x13,
+	0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x02, 0x3a, 0x94, 0x40, 0xca, 0x40, 0x36, 0x20,
+	0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x35, 0x22, 0x14, 0x07, 0x34, 0x1d, 0x44,
+	0xfe, 0x0f, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06, 0x4e,
+	0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0xf9, 0x2a,
+	0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0xc7,
+	0x06, 0x4e, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x8d, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x22, 0x00,
+	0x07, 0x07, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03, 0x3a, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x05, 0x01, 0x03, 0x00, 0x07, 0x02, 0x03,
+	0x07, 0x69, 0x06, 0x01, 0x02, 0x02, 0x04, 0x61, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x08, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x05, 0x01, 0x03, 0x00,
+	0x07, 0x02, 0x03, 0x07, 0x69, 0x00, 0x04, 0x06, 0x01, 0x02, 0x00, 0x04, 0x02, 0x6a, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x08, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00,
+	0x1a, 0x18, 0x13, 0x11, 0x10, 0x0f, 0x0e, 0x0c, 0x08, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x03, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02,
+	0x16, 0x33, 0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x94,
+	0xdb, 0x01, 0x28, 0xdb, 0x60, 0x94, 0x40, 0xca, 0x40, 0x35, 0x21, 0x1b, 0x37, 0x1b, 0x43, 0x1b,
+	0x94, 0x40, 0xc9, 0x40, 0x36, 0x21, 0x14, 0x07, 0x34, 0x1d, 0x44, 0x04, 0x4a, 0xfb, 0xb6, 0x05,
+	0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0xad, 0x07, 0x19, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x68,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x00, 0x08, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67,
+	0x05, 0x01, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x07,
+	0x5f, 0x09, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x08, 0x01, 0x01,
+	0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03, 0x67, 0x06, 0x01, 0x02,
+	0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x0a, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x21, 0x07, 0x01, 0x37, 0x33, 0x13,
+	0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0xa6, 0x23, 0x02, 0xe4, 0x23, 0xfb, 0xda,
+	0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06, 0x6c, 0xad, 0xad,
+	0xf9, 0x94, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x03, 0xc0, 0x05, 0xc4, 0x00, 0x03, 0x00, 0x07, 0x00, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x05,
+	0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x01, 0x37, 0x21, 0x07,
+	0x94, 0xdb, 0x01, 0x28, 0xdb, 0xfe, 0xfe, 0x22, 0x02, 0xe4, 0x22, 0x04, 0x4a, 0xfb, 0xb6, 0x05,
+	0x17, 0xad, 0xad, 0x00, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0xb1, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x6a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x02, 0x01, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x00, 0x03, 0x06, 0x01, 0x03, 0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x38, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09, 0x39, 0x09,
+	0x4e, 0x1b, 0x40, 0x24, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x03, 0x06, 0x01,
+	0x03, 0x69, 0x00, 0x06, 0x07, 0x01, 0x05, 0x04, 0x06, 0x05, 0x68, 0x08, 0x01, 0x04, 0x04, 0x09,
+	0x5f, 0x0a, 0x01, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x12, 0x0c, 0x0c, 0x0c, 0x17, 0x0c,
+	0x17, 0x11, 0x11, 0x11, 0x11, 0x13, 0x22, 0x11, 0x21, 0x10, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x33,
+	0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x01, 0x37, 0x33, 0x13, 0x23, 0x37,
+	0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0xee, 0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3,
+	0x91, 0x91, 0x9d, 0xfe, 0x82, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2,
+	0x29, 0x07, 0x8f, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0xf9, 0x05, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb,
+	0xdc, 0xd2, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0xc9, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x7b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1c, 0x04, 0x01, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x04,
+	0x01, 0x02, 0x03, 0x02, 0x85, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1a, 0x04, 0x01,
+	0x02, 0x03, 0x02, 0x85, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0c,
+	0x0a, 0x09, 0x08, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x33,
+	0x13, 0x21, 0x03, 0x03, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x94,
+	0xdb, 0x01, 0x28, 0xdb, 0xb6, 0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9e,
+	0x04, 0x4a, 0xfb, 0xb6, 0x06, 0x44, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x01, 0x00, 0x64,
+	0xfe, 0x8e, 0x04, 0x63, 0x05, 0xc8, 0x00, 0x19, 0x00, 0x90, 0xb5, 0x12, 0x01, 0x06, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x20, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f,
+	0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05,
+	0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00,
+	0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05,
+	0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x23, 0x23,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37,
+	0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x23, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23,
+	0x20, 0x37, 0x36, 0x37, 0x64, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2,
+	0x29, 0xe3, 0xcc, 0x12, 0x13, 0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f, 0x18, 0xf4,
+	0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76,
+	0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xf4, 0xfe, 0x8e, 0x03, 0x01, 0x06, 0x2b, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x86, 0xb5, 0x06, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x20, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x62, 0x00, 0x01, 0x01, 0x3d,
+	0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01,
+	0x66, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01,
+	0x66, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11,
+	0x14, 0x12, 0x11, 0x13, 0x23, 0x23, 0x07, 0x09, 0x1b, 0x2b, 0x21, 0x06, 0x07, 0x06, 0x33, 0x32,
+	0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x37, 0x23, 0x13, 0x21, 0x25, 0x13, 0x21, 0x03, 0x01,
+	0xbc, 0xcc, 0x12, 0x13, 0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xda, 0x1e, 0x18, 0xf4, 0x8a,
+	0xdb, 0x01, 0x28, 0xfe, 0xf5, 0x39, 0x01, 0x3c, 0x39, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f,
+	0x76, 0x5d, 0x04, 0x4a, 0xc3, 0x01, 0x1e, 0xfe, 0xe2, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64,
+	0x00, 0x00, 0x04, 0x63, 0x07, 0x8e, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x21, 0x00, 0x00, 0x08, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x05, 0x01, 0x03, 0x03,
+	0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07,
+	0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x08, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67,
+	0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03, 0x67, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09,
+	0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04,
+	0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x03, 0x01, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x02, 0x7e, 0x3a, 0x01, 0x34, 0x3a, 0xfc, 0xb2, 0x29, 0xd2, 0xd4, 0xd2,
+	0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06, 0x6c, 0x01, 0x22, 0xfe, 0xde, 0xf9, 0x94,
+	0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x02, 0x97,
+	0x04, 0x4a, 0x00, 0x03, 0x00, 0x30, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x02, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x94, 0xdb, 0x01, 0x28, 0xdb, 0x04,
+	0x4a, 0xfb, 0xb6, 0x00, 0x00, 0x02, 0x00, 0x64, 0xfe, 0xd8, 0x07, 0x50, 0x05, 0xc8, 0x00, 0x0f,
+	0x00, 0x1b, 0x00, 0x67, 0xb5, 0x01, 0x01, 0x00, 0x09, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x21, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x07, 0x05, 0x02, 0x01, 0x01, 0x02, 0x5f,
+	0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09,
+	0x39, 0x09, 0x4e, 0x1b, 0x40, 0x1f, 0x06, 0x01, 0x02, 0x07, 0x05, 0x02, 0x01, 0x04, 0x02, 0x01,
+	0x67, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0a, 0x01,
+	0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x12, 0x10, 0x10, 0x10, 0x1b, 0x10, 0x1b, 0x11, 0x11,
+	0x11, 0x11, 0x12, 0x23, 0x11, 0x13, 0x22, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x37, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x23, 0x37, 0x21, 0x03, 0x02, 0x04, 0x23, 0x22, 0x01, 0x37, 0x33, 0x13, 0x23,
+	0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x03, 0x38, 0x2b, 0x81, 0x44, 0x52, 0x7f, 0x1f, 0xd9,
+	0xd2, 0x2a, 0x02, 0x07, 0xfc, 0x36, 0xfe, 0xcd, 0xe1, 0x4a, 0xfc, 0xa4, 0x29, 0xd2, 0xd4, 0xd2,
+	0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0xfe, 0xf7, 0xd8, 0x26, 0x75, 0x9a, 0x04, 0x3e,
+	0xd2, 0xfb, 0x11, 0xfe, 0xf3, 0xf4, 0x01, 0x28, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2,
+	0x00, 0x04, 0x00, 0x94, 0xfe, 0x5d, 0x05, 0x31, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0x7f, 0xb5, 0x09, 0x01, 0x04, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d,
+	0x05, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x01, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06,
+	0x61, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x03, 0x03,
+	0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x05, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x01,
+	0x01, 0x01, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x61, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59,
+	0x40, 0x20, 0x15, 0x15, 0x04, 0x04, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x14, 0x12,
+	0x10, 0x0f, 0x0c, 0x0a, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0c,
+	0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x03, 0x13, 0x21, 0x03, 0x01, 0x37, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x21, 0x03, 0x02, 0x21, 0x22, 0x01, 0x13, 0x21, 0x03, 0x94, 0xdb, 0x01, 0x28,
+	0xdb, 0x25, 0x38, 0x01, 0x28, 0x38, 0xfe, 0x9e, 0x27, 0x60, 0x33, 0x4d, 0x4e, 0x1b, 0xdb, 0x01,
+	0x29, 0xd8, 0x57, 0xfe, 0x7a, 0x57, 0x02, 0x0b, 0x38, 0x01, 0x29, 0x38, 0x04, 0x4a, 0xfb, 0xb6,
+	0x05, 0x12, 0x01, 0x19, 0xfe, 0xe7, 0xf9, 0x73, 0xc6, 0x35, 0x64, 0x86, 0x04, 0x4a, 0xfb, 0xc9,
+	0xfe, 0x4a, 0x06, 0xb5, 0x01, 0x19, 0xfe, 0xe7, 0x00, 0x02, 0xff, 0xce, 0xfe, 0xd8, 0x05, 0x44,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x17, 0x00, 0x6f, 0x40, 0x0a, 0x05, 0x01, 0x01, 0x00, 0x09, 0x01,
+	0x03, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x00, 0x01, 0x00, 0x85,
+	0x07, 0x02, 0x02, 0x01, 0x05, 0x01, 0x85, 0x00, 0x03, 0x00, 0x06, 0x03, 0x06, 0x65, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38, 0x04, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x07, 0x02, 0x02, 0x01, 0x05, 0x01, 0x85, 0x00, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67,
+	0x00, 0x03, 0x06, 0x06, 0x03, 0x59, 0x00, 0x03, 0x03, 0x06, 0x61, 0x00, 0x06, 0x03, 0x06, 0x51,
+	0x59, 0x40, 0x13, 0x00, 0x00, 0x17, 0x15, 0x12, 0x11, 0x10, 0x0f, 0x0c, 0x0a, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x08, 0x09, 0x18, 0x2b, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01,
+	0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x21, 0x37, 0x21, 0x03, 0x02, 0x04, 0x21, 0x22, 0x02,
+	0x51, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0xfc, 0xca, 0x2c, 0xae, 0xa9, 0x97,
+	0x8a, 0x1f, 0xd9, 0xfe, 0xfc, 0x2a, 0x02, 0x38, 0xfc, 0x36, 0xfe, 0xc3, 0xfe, 0xd9, 0xae, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0xf8, 0xb6, 0xdd, 0x38, 0x75, 0x9a, 0x04, 0x3e, 0xd2,
+	0xfb, 0x11, 0xfe, 0xf3, 0xf4, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x25, 0xfe, 0x5d, 0x03, 0x98,
+	0x06, 0x44, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x63, 0x40, 0x0a, 0x12, 0x01, 0x04, 0x03, 0x01, 0x01,
+	0x00, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x05, 0x02, 0x04, 0x03,
+	0x01, 0x03, 0x04, 0x01, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04,
+	0x03, 0x85, 0x06, 0x05, 0x02, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x0d, 0x0d, 0x0d, 0x14,
+	0x0d, 0x14, 0x11, 0x12, 0x22, 0x13, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x03, 0x37, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x21, 0x03, 0x02, 0x21, 0x22, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07,
+	0xdb, 0x27, 0x5f, 0x33, 0x4d, 0x4e, 0x1b, 0xdb, 0x01, 0x28, 0xd8, 0x57, 0xfe, 0x7b, 0x57, 0x01,
+	0x19, 0x01, 0x31, 0x01, 0x12, 0xb0, 0xb3, 0x9e, 0x02, 0xed, 0xfe, 0x85, 0xc6, 0x35, 0x64, 0x86,
+	0x04, 0x4a, 0xfb, 0xc9, 0xfe, 0x4a, 0x06, 0xa6, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0xfe, 0x50, 0x06, 0x63, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x19, 0x00, 0x74,
+	0x40, 0x10, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00, 0x13, 0x01, 0x06, 0x07, 0x12, 0x01, 0x05, 0x06,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x6a, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06,
+	0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07,
+	0x06, 0x04, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x19, 0x18, 0x16, 0x14, 0x11, 0x0f, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x03, 0x17,
+	0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0xad, 0x01,
+	0x27, 0x01, 0x28, 0x8f, 0x02, 0xf7, 0xff, 0xfd, 0x4a, 0x02, 0x0b, 0xfe, 0x7f, 0xfe, 0x2f, 0x91,
+	0x67, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4,
+	0x05, 0xc8, 0xfd, 0x32, 0x02, 0xce, 0xfd, 0x68, 0xfc, 0xd0, 0x02, 0xd8, 0xfd, 0x28, 0x61, 0xab,
+	0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x02, 0x00, 0x94, 0xfe, 0x50, 0x04, 0xf8,
+	0x06, 0x2b, 0x00, 0x0c, 0x00, 0x1b, 0x00, 0x7c, 0x40, 0x10, 0x0a, 0x07, 0x03, 0x03, 0x02, 0x01,
+	0x15, 0x01, 0x06, 0x07, 0x14, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x6a, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01,
+	0x01, 0x3b, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x6a,
+	0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x1b, 0x1a, 0x18, 0x16, 0x13, 0x11, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x13, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x33, 0x01, 0x33, 0x01, 0x01, 0x21, 0x03, 0x23,
+	0x03, 0x17, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27,
+	0x94, 0x01, 0x3b, 0x01, 0x28, 0xc6, 0x13, 0x01, 0xbf, 0xf5, 0xfe, 0x61, 0x01, 0x11, 0xfe, 0xc4,
+	0xf2, 0x13, 0x6d, 0x36, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80,
+	0x0d, 0x0c, 0xa4, 0x06, 0x2b, 0xfc, 0x1f, 0x02, 0x00, 0xfe, 0x23, 0xfd, 0x93, 0x02, 0x25, 0xfd,
+	0xdb, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x04, 0xf8, 0x04, 0x4a, 0x00, 0x0c, 0x00, 0x3f, 0xb7, 0x0a, 0x07, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0c,
+	0x00, 0x0c, 0x12, 0x13, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x33, 0x01, 0x33,
+	0x01, 0x01, 0x21, 0x03, 0x23, 0x03, 0x94, 0xdb, 0x01, 0x28, 0x66, 0x13, 0x01, 0xbf, 0xf5, 0xfe,
+	0x61, 0x01, 0x11, 0xfe, 0xc4, 0xf2, 0x13, 0x6d, 0x04, 0x4a, 0xfe, 0x00, 0x02, 0x00, 0xfe, 0x23,
+	0xfd, 0x93, 0x02, 0x25, 0xfd, 0xdb, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x04, 0xfa,
+	0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00,
+	0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03,
+	0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00,
+	0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18,
+	0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x07, 0x01, 0x01, 0x21, 0x01, 0xad, 0x01, 0x27, 0x01, 0x34,
+	0xfe, 0x02, 0xf0, 0x29, 0xfd, 0x89, 0x01, 0x32, 0x01, 0x19, 0xfe, 0x7e, 0x05, 0xc8, 0xfb, 0x0a,
+	0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x82, 0xff, 0xe7, 0x03, 0xf1,
+	0x07, 0xcf, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01, 0x02, 0x01, 0x01, 0x4c,
+	0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x3a, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x0d, 0x0d, 0x0d, 0x10, 0x0d,
+	0x10, 0x12, 0x23, 0x12, 0x22, 0x06, 0x09, 0x1a, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x13, 0x13,
+	0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x03, 0x01, 0x21, 0x01, 0x02, 0x73, 0x24, 0x48, 0x4c, 0xfe,
+	0xc7, 0x47, 0xf9, 0x01, 0x28, 0xf1, 0x19, 0x1b, 0x42, 0x1b, 0xa7, 0x01, 0x31, 0x01, 0x0f, 0xfe,
+	0x7f, 0xb6, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c, 0x4d, 0x05, 0xe1, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0xfe, 0x50, 0x04, 0xfa, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x14, 0x00, 0x73, 0x40, 0x0a, 0x0e, 0x01, 0x05, 0x06, 0x0d, 0x01, 0x04, 0x05, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x69, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x07, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00,
+	0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x43, 0x04, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x00, 0x01,
+	0x00, 0x85, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x69, 0x00, 0x01, 0x01, 0x02, 0x60, 0x07,
+	0x01, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x43, 0x04, 0x4e,
+	0x59, 0x40, 0x13, 0x00, 0x00, 0x14, 0x13, 0x11, 0x0f, 0x0c, 0x0a, 0x07, 0x06, 0x00, 0x05, 0x00,
+	0x05, 0x11, 0x11, 0x08, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x07, 0x05, 0x20, 0x07,
+	0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0xad, 0x01, 0x27, 0x01,
+	0x34, 0xfe, 0x02, 0xf0, 0x29, 0xfd, 0x6b, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13,
+	0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0x05, 0xc8, 0xfb, 0x0a, 0xd2, 0x61, 0xab, 0x44, 0x60, 0x0d,
+	0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x36, 0xfe, 0x50, 0x02, 0xea,
+	0x06, 0x2b, 0x00, 0x0e, 0x00, 0x1b, 0x00, 0x3d, 0x40, 0x3a, 0x0f, 0x01, 0x06, 0x05, 0x08, 0x01,
+	0x02, 0x03, 0x07, 0x01, 0x01, 0x02, 0x03, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69,
+	0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x06, 0x06, 0x04, 0x62, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x23, 0x12, 0x23, 0x12, 0x23, 0x23,
+	0x10, 0x07, 0x09, 0x1d, 0x2b, 0x17, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0x01, 0x07, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0xc0, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80, 0x0d, 0x0c,
+	0xa4, 0x01, 0xc7, 0x24, 0x48, 0x4c, 0xfe, 0xc7, 0x47, 0xf9, 0x01, 0x28, 0xf1, 0x19, 0x1b, 0x42,
+	0x1b, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x01, 0x7a, 0xb6, 0x19, 0x01,
+	0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c, 0x4d, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x4c,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x0f, 0x00, 0x62, 0xb5, 0x0c, 0x01, 0x01, 0x05, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x03, 0x01, 0x03, 0x05, 0x01, 0x80, 0x00, 0x03,
+	0x03, 0x00, 0x5f, 0x04, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01,
+	0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x05, 0x03, 0x01, 0x03, 0x05, 0x01, 0x80,
+	0x04, 0x01, 0x00, 0x00, 0x03, 0x05, 0x00, 0x03, 0x67, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x0e, 0x0d, 0x09, 0x08, 0x07, 0x06,
+	0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x07,
+	0x03, 0x23, 0x13, 0x21, 0x07, 0x02, 0x05, 0x37, 0x32, 0x37, 0xad, 0x01, 0x27, 0x01, 0x34, 0xfe,
+	0x02, 0xf0, 0x29, 0x51, 0x72, 0x3b, 0x01, 0x03, 0x2e, 0x44, 0xfe, 0xf9, 0x14, 0x72, 0x21, 0x05,
+	0xc8, 0xfb, 0x0a, 0xd2, 0x04, 0xa0, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x82, 0xff, 0xe7, 0x04, 0x7c, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x16, 0x00, 0x2e,
+	0x40, 0x2b, 0x0a, 0x06, 0x02, 0x05, 0x02, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x23, 0x12, 0x24, 0x14, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x23,
+	0x13, 0x21, 0x07, 0x02, 0x05, 0x37, 0x32, 0x37, 0x01, 0x07, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x03, 0xb0, 0x72, 0x3b, 0x01, 0x03, 0x2e, 0x44, 0xfe, 0xf9, 0x14,
+	0x72, 0x21, 0xfe, 0xc9, 0x24, 0x48, 0x4c, 0xfe, 0xc7, 0x47, 0xf9, 0x01, 0x28, 0xf1, 0x19, 0x1b,
+	0x42, 0x1b, 0x05, 0x03, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5, 0xfb, 0xd0, 0xb6, 0x19,
+	0x01, 0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c, 0x4d, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x04, 0xfb,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x09, 0x00, 0x55, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00,
+	0x03, 0x06, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01,
+	0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x00, 0x03, 0x00,
+	0x85, 0x00, 0x03, 0x06, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06,
+	0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21,
+	0x03, 0x21, 0x07, 0x01, 0x13, 0x21, 0x03, 0xad, 0x01, 0x27, 0x01, 0x34, 0xfe, 0x02, 0xf0, 0x29,
+	0xfe, 0xc7, 0x3b, 0x01, 0x28, 0x3b, 0x05, 0xc8, 0xfb, 0x0a, 0xd2, 0x02, 0x8e, 0x01, 0x28, 0xfe,
+	0xd8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x82, 0xff, 0xe7, 0x04, 0x59, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x10, 0x00, 0x32, 0x40, 0x2f, 0x04, 0x01, 0x04, 0x01, 0x01, 0x4c, 0x00, 0x00, 0x05, 0x01,
+	0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x00, 0x00, 0x10, 0x0e, 0x0b, 0x0a, 0x08, 0x06, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x03, 0x01, 0x07, 0x06, 0x23, 0x20, 0x13,
+	0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x02, 0xf5, 0x3b, 0x01, 0x29, 0x3b, 0xfe, 0x55, 0x24,
+	0x48, 0x4c, 0xfe, 0xc7, 0x47, 0xf9, 0x01, 0x28, 0xf1, 0x19, 0x1b, 0x42, 0x1b, 0x02, 0x8e, 0x01,
+	0x28, 0xfe, 0xd8, 0xfe, 0x28, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c, 0x4d, 0x00,
+	0x00, 0x01, 0x00, 0x65, 0x00, 0x00, 0x04, 0xfa, 0x05, 0xc8, 0x00, 0x0d, 0x00, 0x46, 0x40, 0x09,
+	0x08, 0x07, 0x02, 0x01, 0x04, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x15, 0x15,
+	0x04, 0x09, 0x18, 0x2b, 0x33, 0x13, 0x07, 0x37, 0x37, 0x13, 0x21, 0x03, 0x25, 0x07, 0x05, 0x03,
+	0x21, 0x07, 0xad, 0x77, 0xbf, 0x26, 0xbf, 0x8a, 0x01, 0x34, 0x68, 0x01, 0x11, 0x27, 0xfe, 0xee,
+	0x6e, 0x02, 0xf0, 0x29, 0x02, 0x54, 0x5a, 0xc1, 0x5b, 0x02, 0xb2, 0xfd, 0xf4, 0x85, 0xc5, 0x84,
+	0xfd, 0xda, 0xd2, 0x00, 0x00, 0x01, 0x00, 0x72, 0xff, 0xe7, 0x03, 0x45, 0x06, 0x2b, 0x00, 0x18,
+	0x00, 0x23, 0x40, 0x20, 0x11, 0x10, 0x0a, 0x08, 0x00, 0x05, 0x02, 0x01, 0x01, 0x4c, 0x00, 0x01,
+	0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x27, 0x1a,
+	0x22, 0x03, 0x09, 0x19, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x13, 0x36, 0x37, 0x13, 0x31, 0x07,
+	0x37, 0x37, 0x15, 0x13, 0x21, 0x03, 0x37, 0x07, 0x07, 0x03, 0x06, 0x16, 0x33, 0x32, 0x02, 0x76,
+	0x24, 0x48, 0x4c, 0xfe, 0xe1, 0x24, 0x03, 0x06, 0x3b, 0x95, 0x27, 0x95, 0x97, 0x01, 0x28, 0x76,
+	0xce, 0x27, 0xce, 0x54, 0x19, 0x1b, 0x42, 0x1b, 0xb6, 0xb6, 0x19, 0x01, 0x2d, 0x1a, 0x21, 0x01,
+	0x27, 0x48, 0xc3, 0x4c, 0x04, 0x02, 0xf2, 0xfd, 0xaf, 0x64, 0xc3, 0x64, 0xfe, 0x5f, 0x7c, 0x4d,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x41, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x64,
+	0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x07,
+	0x01, 0x05, 0x04, 0x00, 0x04, 0x05, 0x00, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04,
+	0x04, 0x02, 0x5f, 0x06, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1e, 0x07, 0x01,
+	0x05, 0x04, 0x00, 0x04, 0x05, 0x00, 0x80, 0x01, 0x01, 0x00, 0x02, 0x04, 0x00, 0x02, 0x7e, 0x00,
+	0x04, 0x04, 0x02, 0x5f, 0x06, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x14, 0x0a,
+	0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11,
+	0x08, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x13, 0x33, 0x01, 0x21, 0x01, 0x03, 0x01, 0x01,
+	0x21, 0x01, 0xad, 0x01, 0x27, 0x01, 0x0f, 0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0xfe, 0xed, 0xfe,
+	0x67, 0xca, 0x01, 0xf4, 0x01, 0x32, 0x01, 0x19, 0xfe, 0x7e, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3,
+	0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x05, 0x3c, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xc6, 0xb5, 0x03, 0x01, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05,
+	0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x25, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22,
+	0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07,
+	0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00,
+	0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x09, 0x09,
+	0x1a, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23,
+	0x22, 0x07, 0x03, 0x01, 0x01, 0x21, 0x01, 0x94, 0xdb, 0x01, 0x28, 0x24, 0xd2, 0xcc, 0x01, 0x2b,
+	0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x15, 0x23, 0x44, 0x78, 0xab, 0x8f, 0x01, 0x00, 0x01, 0x31, 0x01,
+	0x19, 0xfe, 0x7f, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae,
+	0xfd, 0x34, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xad, 0xfe, 0x50, 0x06, 0x41,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x18, 0x00, 0x73, 0x40, 0x0f, 0x08, 0x03, 0x02, 0x02, 0x00, 0x12,
+	0x01, 0x06, 0x07, 0x11, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20,
+	0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03,
+	0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x1b, 0x40, 0x20, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x6a, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x18, 0x17, 0x15, 0x13, 0x10, 0x0e, 0x0b, 0x0a,
+	0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x13,
+	0x33, 0x01, 0x21, 0x01, 0x03, 0x17, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0xad, 0x01, 0x27, 0x01, 0x0f, 0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0xfe,
+	0xed, 0xfe, 0x67, 0xca, 0x98, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d,
+	0x80, 0x0d, 0x0c, 0xa4, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d,
+	0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0xfe, 0x50, 0x05, 0x3c, 0x04, 0x63, 0x00, 0x10, 0x00, 0x1f, 0x00, 0xb3, 0x40, 0x0e, 0x03, 0x01,
+	0x03, 0x00, 0x19, 0x01, 0x07, 0x08, 0x18, 0x01, 0x06, 0x07, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x25, 0x00, 0x05, 0x00, 0x08, 0x07, 0x05, 0x08, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x07, 0x07,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29,
+	0x00, 0x05, 0x00, 0x08, 0x07, 0x05, 0x08, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x07,
+	0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x05, 0x00, 0x08,
+	0x07, 0x05, 0x08, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x09, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x00, 0x00, 0x1f, 0x1e, 0x1c, 0x1a, 0x17,
+	0x15, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33,
+	0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03,
+	0x17, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x94,
+	0xdb, 0x01, 0x28, 0x24, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x15, 0x23, 0x44,
+	0x78, 0xab, 0x8f, 0x36, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80,
+	0x0d, 0x0c, 0xa4, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae,
+	0xfd, 0x34, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x41, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x65, 0x40, 0x0b, 0x0f, 0x01,
+	0x04, 0x05, 0x08, 0x03, 0x02, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a,
+	0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1a, 0x08, 0x06, 0x02,
+	0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x07,
+	0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11,
+	0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x09, 0x19,
+	0x2b, 0x33, 0x01, 0x21, 0x01, 0x13, 0x33, 0x01, 0x21, 0x01, 0x03, 0x01, 0x01, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0xad, 0x01, 0x27, 0x01, 0x0f, 0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0xfe, 0xed,
+	0xfe, 0x67, 0xca, 0x04, 0x39, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05, 0xc8,
+	0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41,
+	0xc6, 0xc6, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x05, 0x3c, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x18, 0x00, 0xd1, 0x40, 0x0a, 0x16, 0x01, 0x05, 0x06, 0x03, 0x01, 0x03, 0x00, 0x02, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x09,
+	0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x26, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08,
+	0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x09,
+	0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00,
+	0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22,
+	0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x21, 0x13,
+	0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x94, 0xdb,
+	0x01, 0x28, 0x24, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b, 0xfe, 0xd8, 0x8c, 0x15, 0x23, 0x44, 0x78,
+	0xab, 0x8f, 0x03, 0x5b, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0x04, 0x4a, 0xb6,
+	0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x06, 0x44, 0xfe, 0xbf,
+	0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0xd3, 0x00, 0x00, 0x06, 0x03, 0x06, 0x2b, 0x00, 0x10,
+	0x00, 0x1a, 0x00, 0x94, 0xb6, 0x17, 0x03, 0x02, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x1e, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x07, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06,
+	0x06, 0x3a, 0x4d, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05,
+	0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x13, 0x00, 0x00, 0x19, 0x18, 0x14, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10,
+	0x23, 0x12, 0x22, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03,
+	0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x23, 0x13, 0x21, 0x07, 0x02, 0x05,
+	0x37, 0x32, 0x37, 0x01, 0x5b, 0xdb, 0x01, 0x28, 0x24, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0x9b, 0xfe,
+	0xd8, 0x8c, 0x15, 0x23, 0x44, 0x78, 0xab, 0x8f, 0xfe, 0xfd, 0x72, 0x3b, 0x01, 0x03, 0x2e, 0x44,
+	0xfe, 0xf9, 0x14, 0x72, 0x21, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b,
+	0x50, 0xae, 0xfd, 0x34, 0x05, 0x03, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0xfe, 0x5c, 0x06, 0x41, 0x05, 0xc8, 0x00, 0x12, 0x00, 0x5a, 0x40, 0x0f,
+	0x11, 0x03, 0x02, 0x04, 0x00, 0x0c, 0x01, 0x03, 0x04, 0x0b, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04, 0x04,
+	0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x17,
+	0x01, 0x01, 0x00, 0x04, 0x00, 0x85, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x03, 0x03, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12,
+	0x23, 0x23, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x13, 0x33, 0x01, 0x07,
+	0x02, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x01, 0x03, 0xad, 0x01, 0x27, 0x01, 0x0f,
+	0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0x0a, 0x4a, 0xfe, 0x94, 0x5d, 0x56, 0x28, 0x3e, 0x4b, 0x9b,
+	0x24, 0xfe, 0x57, 0xca, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x2e, 0xfe, 0x8a, 0x18,
+	0xc7, 0x19, 0xb3, 0x04, 0x1e, 0xfc, 0x0d, 0x00, 0x00, 0x01, 0x00, 0x94, 0xfe, 0x5c, 0x05, 0x3c,
+	0x04, 0x63, 0x00, 0x19, 0x00, 0x91, 0x40, 0x0e, 0x03, 0x01, 0x04, 0x00, 0x0d, 0x01, 0x03, 0x05,
+	0x0c, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x04, 0x04,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x03,
+	0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x20, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x06, 0x01, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02,
+	0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x43, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x25, 0x23,
+	0x23, 0x22, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03,
+	0x02, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07,
+	0x03, 0x94, 0xdb, 0x01, 0x28, 0x24, 0xd2, 0xcc, 0x01, 0x2b, 0x45, 0xa6, 0x49, 0xfe, 0x9b, 0x4d,
+	0x65, 0x27, 0x3d, 0x36, 0x47, 0x46, 0x16, 0x92, 0x15, 0x23, 0x44, 0x78, 0xab, 0x8f, 0x04, 0x4a,
+	0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xc2, 0xfe, 0x92, 0x17, 0xc4, 0x15, 0x53, 0x70, 0x02, 0xda, 0x6b,
+	0x50, 0xae, 0xfd, 0x34, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x19, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x67, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08,
+	0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d,
+	0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e,
+	0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x37, 0x21, 0x07, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48,
+	0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe,
+	0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x6e, 0x23, 0x02, 0xe4,
+	0x23, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e,
+	0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe,
+	0xf3, 0xfe, 0xd0, 0x05, 0xc5, 0xad, 0xad, 0x00, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b,
+	0x05, 0xc4, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x6b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x22, 0x08, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x03, 0x37, 0x21,
+	0x07, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35, 0xfe, 0x95,
+	0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x2a, 0x22, 0x02, 0xe4,
+	0x22, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7,
+	0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x77, 0xad, 0xad, 0x00,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x71, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00,
+	0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00,
+	0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x22, 0x20, 0x1e, 0x1d, 0x1c,
+	0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a,
+	0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25,
+	0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x33, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01,
+	0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09,
+	0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0xad, 0x94, 0x0d, 0xa5, 0xa3, 0x46,
+	0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9d, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe,
+	0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d,
+	0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x06, 0xe8, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00,
+	0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0xa5, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x27, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00,
+	0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x07,
+	0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61,
+	0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x22,
+	0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00,
+	0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12,
+	0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x13,
+	0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x02, 0x66, 0xf6, 0xed, 0x34,
+	0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57,
+	0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x2d, 0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91,
+	0x91, 0x9e, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe,
+	0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x05, 0xa4, 0x8e, 0x8e,
+	0x93, 0xae, 0xad, 0x00, 0x00, 0x04, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x75, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x06,
+	0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x21, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05,
+	0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01,
+	0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33, 0x01, 0x03, 0x0a, 0xfe,
+	0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe,
+	0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f,
+	0x9e, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xe5, 0x01, 0x30, 0xe5, 0xfe, 0x7f, 0x25, 0x01, 0xa1, 0x01,
+	0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b,
+	0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7,
+	0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x05, 0xc4,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x79, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07,
+	0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x13, 0x01, 0x33,
+	0x01, 0x33, 0x01, 0x33, 0x01, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef,
+	0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55,
+	0x13, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xe5, 0x01, 0x30, 0xe5, 0xfe, 0x7f, 0x19, 0x01, 0x3b, 0x01,
+	0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6,
+	0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x63, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x9b, 0xff, 0xdb, 0x08, 0xbc, 0x05, 0xed, 0x00, 0x14, 0x00, 0x1f, 0x00, 0xb5,
+	0xb5, 0x16, 0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x05,
+	0x00, 0x06, 0x07, 0x05, 0x06, 0x67, 0x08, 0x01, 0x04, 0x04, 0x02, 0x61, 0x03, 0x01, 0x02, 0x02,
+	0x3e, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x09, 0x09,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x31, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x67, 0x00, 0x08, 0x08, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3e, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x07, 0x07,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3f,
+	0x01, 0x4e, 0x1b, 0x40, 0x2d, 0x00, 0x02, 0x00, 0x08, 0x04, 0x02, 0x08, 0x69, 0x00, 0x03, 0x00,
+	0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x67, 0x00, 0x07, 0x07,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42,
+	0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x1f, 0x1d, 0x23, 0x11, 0x11, 0x11, 0x11, 0x11, 0x24, 0x21,
+	0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x21, 0x21, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x32,
+	0x17, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x25, 0x13, 0x26, 0x23, 0x22, 0x00,
+	0x03, 0x02, 0x12, 0x33, 0x32, 0x07, 0xc3, 0xfc, 0x6a, 0x82, 0x9b, 0xfe, 0xb4, 0xfe, 0xd7, 0x48,
+	0x49, 0x01, 0xce, 0x01, 0x4c, 0x9a, 0x74, 0x03, 0x68, 0x28, 0xfd, 0x99, 0x53, 0x01, 0xf8, 0x27,
+	0xfe, 0x08, 0x5c, 0x02, 0x95, 0xfc, 0x3e, 0xc7, 0x58, 0x7e, 0xb3, 0xfe, 0xf8, 0x36, 0x35, 0x8e,
+	0xb3, 0x7e, 0x25, 0x01, 0x9e, 0x01, 0x6b, 0x01, 0x6b, 0x01, 0x9e, 0x25, 0xcb, 0xfe, 0x63, 0xc6,
+	0xfe, 0x38, 0x20, 0x03, 0xe5, 0x4b, 0xfe, 0xcf, 0xfe, 0xf3, 0xfe, 0xf4, 0xfe, 0xcf, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x07, 0xc4, 0x04, 0x63, 0x00, 0x04, 0x00, 0x1d, 0x00, 0x29,
+	0x00, 0x8e, 0x4b, 0xb0, 0x20, 0x50, 0x58, 0x40, 0x0a, 0x0f, 0x01, 0x04, 0x03, 0x14, 0x01, 0x05,
+	0x04, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0f, 0x01, 0x04, 0x03, 0x14, 0x01, 0x05, 0x08, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x20, 0x50, 0x58, 0x40, 0x22, 0x00, 0x00, 0x00, 0x03, 0x04, 0x00, 0x03, 0x67,
+	0x09, 0x01, 0x01, 0x01, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x0a, 0x08, 0x02, 0x04,
+	0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x2c, 0x00, 0x00, 0x00,
+	0x03, 0x04, 0x00, 0x03, 0x67, 0x09, 0x01, 0x01, 0x01, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x41,
+	0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x4d, 0x0a, 0x01, 0x08, 0x08,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x13, 0x1f, 0x1e, 0x25, 0x23,
+	0x1e, 0x29, 0x1f, 0x29, 0x24, 0x22, 0x23, 0x21, 0x12, 0x22, 0x21, 0x10, 0x0b, 0x09, 0x1e, 0x2b,
+	0x01, 0x21, 0x12, 0x23, 0x22, 0x27, 0x36, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x37,
+	0x07, 0x06, 0x23, 0x20, 0x27, 0x06, 0x23, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x01, 0x32,
+	0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x05, 0x1c, 0x01, 0x65, 0x38, 0x9f,
+	0xa8, 0xb8, 0xa6, 0xdb, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xbc, 0x29, 0xc3,
+	0xb7, 0xfe, 0xf6, 0x89, 0xb9, 0xf5, 0xfc, 0xee, 0x34, 0x34, 0x01, 0x6b, 0xfb, 0xf6, 0xfe, 0x47,
+	0x70, 0xaa, 0x25, 0x23, 0x57, 0x6c, 0x6d, 0xaa, 0x24, 0x23, 0x54, 0x02, 0x91, 0x01, 0x19, 0x2a,
+	0x8f, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x45, 0xd0, 0x3e, 0x9a, 0x9a, 0x01, 0x39, 0x01, 0x05,
+	0x01, 0x05, 0x01, 0x39, 0xfc, 0x3d, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x1c, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x16,
+	0x00, 0x75, 0xb5, 0x06, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25,
+	0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07,
+	0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x67, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x13, 0x13,
+	0x00, 0x00, 0x13, 0x16, 0x13, 0x16, 0x15, 0x14, 0x12, 0x10, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x14, 0x21, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x01, 0x21,
+	0x01, 0x23, 0x03, 0x13, 0x33, 0x20, 0x13, 0x36, 0x21, 0x23, 0x13, 0x01, 0x21, 0x01, 0xad, 0x01,
+	0x27, 0x02, 0x85, 0x01, 0xc3, 0x49, 0x3b, 0xfe, 0xc8, 0x01, 0x5a, 0xfe, 0xa6, 0xfe, 0xd8, 0xf1,
+	0x78, 0xa1, 0xa2, 0x01, 0x4f, 0x36, 0x28, 0xfe, 0xd5, 0xc6, 0x6a, 0x01, 0x32, 0x01, 0x19, 0xfe,
+	0x7e, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd, 0xa3, 0x03, 0x28,
+	0x01, 0x0f, 0xc6, 0x01, 0x51, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x04, 0x28, 0x06, 0x44, 0x00, 0x0d, 0x00, 0x11, 0x00, 0xe1, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x0e, 0x07, 0x01, 0x00, 0x05, 0x03, 0x01, 0x02, 0x00, 0x08, 0x01, 0x03, 0x02, 0x03,
+	0x4c, 0x1b, 0x40, 0x0e, 0x07, 0x01, 0x00, 0x01, 0x03, 0x01, 0x02, 0x00, 0x08, 0x01, 0x03, 0x02,
+	0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x20, 0x07, 0x01, 0x05, 0x04, 0x00, 0x04,
+	0x05, 0x00, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x24, 0x07, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06,
+	0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x14, 0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x11, 0x0e, 0x11,
+	0x10, 0x0f, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x21,
+	0x07, 0x36, 0x33, 0x32, 0x17, 0x03, 0x26, 0x23, 0x22, 0x07, 0x03, 0x13, 0x01, 0x21, 0x01, 0xad,
+	0xdb, 0x01, 0x28, 0x24, 0x7c, 0xa3, 0x17, 0x1a, 0x35, 0x33, 0x26, 0x77, 0x72, 0x90, 0x09, 0x01,
+	0x31, 0x01, 0x19, 0xfe, 0x7f, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe, 0xf8, 0x17, 0x9a, 0xfd, 0x2e,
+	0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0xfe, 0x50, 0x06, 0x1c,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x21, 0x00, 0x8c, 0x40, 0x0e, 0x06, 0x01, 0x02, 0x04,
+	0x1b, 0x01, 0x08, 0x09, 0x1a, 0x01, 0x07, 0x08, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x2c, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09,
+	0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0a, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x1b, 0x40, 0x2a,
+	0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67,
+	0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69, 0x0a, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x4d, 0x00,
+	0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x59, 0x40, 0x18, 0x00, 0x00, 0x21,
+	0x20, 0x1e, 0x1c, 0x19, 0x17, 0x14, 0x13, 0x12, 0x10, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x14, 0x21, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x01, 0x21, 0x01,
+	0x23, 0x03, 0x13, 0x33, 0x20, 0x13, 0x36, 0x21, 0x23, 0x03, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22,
+	0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0xad, 0x01, 0x27, 0x02, 0x85, 0x01, 0xc3, 0x49,
+	0x3b, 0xfe, 0xc8, 0x01, 0x5a, 0xfe, 0xa6, 0xfe, 0xd8, 0xf1, 0x78, 0xa1, 0xa2, 0x01, 0x4f, 0x36,
+	0x28, 0xfe, 0xd5, 0xc6, 0x7f, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d,
+	0x80, 0x0d, 0x0c, 0xa4, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd,
+	0xa3, 0x03, 0x28, 0x01, 0x0f, 0xc6, 0xfa, 0xa2, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a,
+	0x08, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x72, 0xfe, 0x50, 0x03, 0xdc, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x1c, 0x00, 0xd6, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x16, 0x03, 0x01, 0x02, 0x00, 0x08,
+	0x01, 0x03, 0x02, 0x16, 0x01, 0x06, 0x07, 0x15, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x07, 0x01, 0x00,
+	0x4a, 0x1b, 0x40, 0x16, 0x07, 0x01, 0x00, 0x01, 0x03, 0x01, 0x02, 0x00, 0x08, 0x01, 0x03, 0x02,
+	0x16, 0x01, 0x06, 0x07, 0x15, 0x01, 0x05, 0x06, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01,
+	0x01, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x28, 0x00, 0x04,
+	0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x08, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05,
+	0x4e, 0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x1c, 0x1b, 0x19, 0x17, 0x14, 0x12, 0x0f, 0x0e, 0x00,
+	0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33,
+	0x32, 0x17, 0x03, 0x26, 0x23, 0x22, 0x07, 0x03, 0x07, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27,
+	0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0xad, 0xdb, 0x01, 0x28, 0x24, 0x7c, 0xa3, 0x17, 0x1a,
+	0x35, 0x33, 0x26, 0x77, 0x72, 0x90, 0xd9, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13,
+	0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe, 0xf8, 0x17, 0x9a, 0xfd,
+	0x2e, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x03, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x1c, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x1a, 0x00, 0x7e, 0x40, 0x0a,
+	0x18, 0x01, 0x06, 0x07, 0x06, 0x01, 0x02, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x26, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07,
+	0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x68, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x1a, 0x13, 0x13, 0x00, 0x00, 0x13, 0x1a, 0x13, 0x1a, 0x17, 0x16, 0x15, 0x14, 0x12, 0x10, 0x0e,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x20,
+	0x03, 0x02, 0x05, 0x01, 0x21, 0x01, 0x23, 0x03, 0x13, 0x33, 0x20, 0x13, 0x36, 0x21, 0x23, 0x01,
+	0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xad, 0x01, 0x27, 0x02, 0x85, 0x01, 0xc3, 0x49, 0x3b,
+	0xfe, 0xc8, 0x01, 0x5a, 0xfe, 0xa6, 0xfe, 0xd8, 0xf1, 0x78, 0xa1, 0xa2, 0x01, 0x4f, 0x36, 0x28,
+	0xfe, 0xd5, 0xc6, 0x02, 0xd0, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05, 0xc8,
+	0xfe, 0x91, 0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd, 0xa3, 0x03, 0x28, 0x01, 0x0f, 0xc6,
+	0x02, 0x92, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x04, 0x48,
+	0x06, 0x44, 0x00, 0x0d, 0x00, 0x15, 0x00, 0xef, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x12, 0x13,
+	0x01, 0x04, 0x05, 0x07, 0x01, 0x00, 0x04, 0x03, 0x01, 0x02, 0x00, 0x08, 0x01, 0x03, 0x02, 0x04,
+	0x4c, 0x1b, 0x40, 0x12, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01, 0x00, 0x01, 0x03, 0x01, 0x02, 0x00,
+	0x08, 0x01, 0x03, 0x02, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04,
+	0x05, 0x00, 0x05, 0x04, 0x00, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x08,
+	0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07,
+	0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85,
+	0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x16,
+	0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x15, 0x0e, 0x15, 0x12, 0x11, 0x10, 0x0f, 0x00, 0x0d, 0x00, 0x0d,
+	0x23, 0x22, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x36, 0x33, 0x32, 0x17, 0x03,
+	0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xad, 0xdb, 0x01,
+	0x28, 0x24, 0x7c, 0xa3, 0x17, 0x1a, 0x35, 0x33, 0x26, 0x77, 0x72, 0x90, 0x02, 0x73, 0xfe, 0xcf,
+	0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe, 0xf8, 0x17, 0x9a,
+	0xfd, 0x2e, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x02, 0x00, 0x68,
+	0xff, 0xda, 0x05, 0xc6, 0x07, 0x8f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x67, 0x40, 0x0b, 0x10, 0x01,
+	0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x0e, 0x24, 0x24, 0x24, 0x27, 0x24, 0x27, 0x13, 0x2c, 0x23, 0x29, 0x22, 0x07,
+	0x09, 0x1b, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02, 0x26, 0x26, 0x37, 0x12,
+	0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x07,
+	0x06, 0x04, 0x21, 0x22, 0x27, 0x01, 0x01, 0x21, 0x01, 0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54,
+	0x27, 0x1a, 0x76, 0x7f, 0x97, 0xf1, 0x8c, 0x21, 0x53, 0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf,
+	0xb5, 0x9b, 0x14, 0x0c, 0x36, 0x5a, 0x69, 0x9d, 0xe5, 0x96, 0x21, 0x2f, 0xfe, 0x7c, 0xfe, 0x8d,
+	0x8b, 0xa9, 0x02, 0x32, 0x01, 0x32, 0x01, 0x19, 0xfe, 0x7e, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37,
+	0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c,
+	0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x06, 0x59, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x82, 0xff, 0xe7, 0x04, 0xe4, 0x06, 0x44, 0x00, 0x1e, 0x00, 0x22, 0x00, 0x6c,
+	0x40, 0x0b, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06,
+	0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x1f, 0x1f, 0x1f,
+	0x22, 0x1f, 0x22, 0x12, 0x29, 0x23, 0x28, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x37, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x01, 0x01, 0x21, 0x01, 0x82,
+	0x2b, 0xd3, 0x9d, 0xdd, 0x18, 0x0f, 0xa1, 0x5c, 0xbc, 0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0,
+	0x28, 0xd1, 0x66, 0xcf, 0x16, 0x0e, 0x95, 0x4f, 0xcc, 0x78, 0x18, 0x20, 0xfe, 0xc9, 0xe8, 0xcc,
+	0x01, 0x5a, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53,
+	0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9,
+	0x05, 0x1c, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x68, 0xff, 0xda, 0x05, 0xc6,
+	0x07, 0x8f, 0x00, 0x23, 0x00, 0x2b, 0x00, 0x6e, 0x40, 0x0f, 0x29, 0x01, 0x05, 0x04, 0x10, 0x01,
+	0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x24, 0x24, 0x24, 0x2b, 0x24, 0x2b, 0x11, 0x13, 0x2c, 0x23,
+	0x29, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02, 0x26,
+	0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f, 0x02,
+	0x16, 0x16, 0x07, 0x06, 0x04, 0x21, 0x22, 0x27, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07,
+	0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54, 0x27, 0x1a, 0x76, 0x7f, 0x97, 0xf1, 0x8c, 0x21, 0x53,
+	0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf, 0xb5, 0x9b, 0x14, 0x0c, 0x36, 0x5a, 0x69, 0x9d, 0xe5,
+	0x96, 0x21, 0x2f, 0xfe, 0x7c, 0xfe, 0x8d, 0x8b, 0xa9, 0x01, 0x83, 0x01, 0x31, 0x01, 0x11, 0xb1,
+	0xb3, 0x9e, 0x03, 0xec, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01,
+	0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9,
+	0x1b, 0x06, 0x59, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x82,
+	0xff, 0xe7, 0x04, 0xc1, 0x06, 0x44, 0x00, 0x1e, 0x00, 0x26, 0x00, 0x73, 0x40, 0x0f, 0x24, 0x01,
+	0x05, 0x04, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1f,
+	0x1f, 0x1f, 0x26, 0x1f, 0x26, 0x11, 0x12, 0x29, 0x23, 0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37,
+	0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x13, 0x01,
+	0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x82, 0x2b, 0xd3, 0x9d, 0xdd, 0x18, 0x0f, 0xa1, 0x5c, 0xbc,
+	0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0, 0x28, 0xd1, 0x66, 0xcf, 0x16, 0x0e, 0x95, 0x4f, 0xcc,
+	0x78, 0x18, 0x20, 0xfe, 0xc9, 0xe8, 0xcc, 0x8e, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03,
+	0xec, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39,
+	0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x05, 0x1c, 0x01, 0x41, 0xfe, 0xbf, 0xc5,
+	0xc5, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x68, 0xfe, 0x50, 0x05, 0xc6, 0x05, 0xed, 0x00, 0x36,
+	0x00, 0xb0, 0x40, 0x17, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x23, 0x01, 0x05,
+	0x06, 0x2b, 0x01, 0x04, 0x05, 0x2a, 0x01, 0x03, 0x04, 0x05, 0x4c, 0x4b, 0xb0, 0x12, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05, 0x72, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3f, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27,
+	0x00, 0x05, 0x06, 0x04, 0x06, 0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3f, 0x4d, 0x00, 0x04, 0x04, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05,
+	0x04, 0x80, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x59,
+	0x59, 0x40, 0x0e, 0x35, 0x33, 0x32, 0x30, 0x2e, 0x2c, 0x29, 0x27, 0x23, 0x29, 0x22, 0x07, 0x09,
+	0x19, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02, 0x26, 0x26, 0x37, 0x12, 0x21,
+	0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x07, 0x06,
+	0x07, 0x06, 0x07, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37,
+	0x36, 0x23, 0x23, 0x37, 0x23, 0x22, 0x27, 0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54, 0x27, 0x1a,
+	0x76, 0x7f, 0x97, 0xf1, 0x8c, 0x21, 0x53, 0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf, 0xb5, 0x9b,
+	0x14, 0x0c, 0x36, 0x5a, 0x69, 0x9d, 0xe5, 0x96, 0x21, 0x2f, 0xc3, 0x93, 0xfb, 0x46, 0xe3, 0x1a,
+	0x0e, 0xa3, 0x69, 0x52, 0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x7d, 0x1a, 0x8b,
+	0xa9, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea,
+	0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0x6d, 0x52, 0x13, 0x52,
+	0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54, 0x90, 0x1b, 0x00, 0x00, 0x01, 0x00, 0x82,
+	0xfe, 0x50, 0x04, 0x8d, 0x04, 0x63, 0x00, 0x30, 0x00, 0x82, 0x40, 0x17, 0x0f, 0x01, 0x02, 0x01,
+	0x10, 0x01, 0x02, 0x00, 0x02, 0x1f, 0x01, 0x05, 0x06, 0x27, 0x01, 0x04, 0x05, 0x26, 0x01, 0x03,
+	0x04, 0x05, 0x4c, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05,
+	0x72, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x30, 0x2f, 0x2e,
+	0x2c, 0x2a, 0x28, 0x25, 0x23, 0x23, 0x28, 0x22, 0x07, 0x09, 0x19, 0x2b, 0x37, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x07, 0x06, 0x07, 0x07, 0x16, 0x07, 0x06, 0x06,
+	0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x23, 0x37, 0x26, 0x82, 0x2b, 0xd3,
+	0x9d, 0xdd, 0x18, 0x0f, 0xa1, 0x5c, 0xbc, 0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0, 0x28, 0xd1,
+	0x66, 0xcf, 0x16, 0x0e, 0x95, 0x4f, 0xcc, 0x78, 0x18, 0x20, 0x9c, 0x77, 0xa4, 0x51, 0xe3, 0x1a,
+	0x0e, 0xa3, 0x69, 0x52, 0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x88, 0xb9, 0x24,
+	0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44,
+	0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0x5d, 0x46, 0x11, 0x5d, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b,
+	0x0f, 0x3c, 0x54, 0x9e, 0x05, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x68, 0xff, 0xda, 0x05, 0xc6,
+	0x07, 0x8f, 0x00, 0x23, 0x00, 0x2b, 0x00, 0x6e, 0x40, 0x0f, 0x29, 0x01, 0x04, 0x05, 0x10, 0x01,
+	0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21,
+	0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x24, 0x24, 0x24, 0x2b, 0x24, 0x2b, 0x11, 0x13, 0x2c, 0x23,
+	0x29, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02, 0x26,
+	0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f, 0x02,
+	0x16, 0x16, 0x07, 0x06, 0x04, 0x21, 0x22, 0x27, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54, 0x27, 0x1a, 0x76, 0x7f, 0x97, 0xf1, 0x8c, 0x21, 0x53,
+	0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf, 0xb5, 0x9b, 0x14, 0x0c, 0x36, 0x5a, 0x69, 0x9d, 0xe5,
+	0x96, 0x21, 0x2f, 0xfe, 0x7c, 0xfe, 0x8d, 0x8b, 0xa9, 0x04, 0x9f, 0xfe, 0xcf, 0xfe, 0xef, 0xb1,
+	0xb3, 0x9e, 0x03, 0xec, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01,
+	0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9,
+	0x1b, 0x07, 0x9a, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x82,
+	0xff, 0xe7, 0x04, 0xfa, 0x06, 0x44, 0x00, 0x1e, 0x00, 0x26, 0x00, 0x73, 0x40, 0x0f, 0x24, 0x01,
+	0x04, 0x05, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x07, 0x06, 0x02, 0x05,
+	0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04,
+	0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1f,
+	0x1f, 0x1f, 0x26, 0x1f, 0x26, 0x11, 0x12, 0x29, 0x23, 0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37,
+	0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x01, 0x01,
+	0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x82, 0x2b, 0xd3, 0x9d, 0xdd, 0x18, 0x0f, 0xa1, 0x5c, 0xbc,
+	0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0, 0x28, 0xd1, 0x66, 0xcf, 0x16, 0x0e, 0x95, 0x4f, 0xcc,
+	0x78, 0x18, 0x20, 0xfe, 0xc9, 0xe8, 0xcc, 0x03, 0xba, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d,
+	0x03, 0xed, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb,
+	0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x06, 0x5d, 0xfe, 0xbf, 0x01, 0x41,
+	0xc6, 0xc6, 0x00, 0x00, 0x00, 0x01, 0x01, 0x27, 0xfe, 0x50, 0x05, 0xe3, 0x05, 0xc8, 0x00, 0x19,
+	0x00, 0x76, 0x40, 0x0e, 0x09, 0x01, 0x06, 0x03, 0x11, 0x01, 0x05, 0x06, 0x10, 0x01, 0x04, 0x05,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x03, 0x05, 0x03, 0x06, 0x05,
+	0x80, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x03,
+	0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x43, 0x04, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x06, 0x03, 0x05, 0x03, 0x06, 0x05, 0x80, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01,
+	0x00, 0x67, 0x08, 0x07, 0x02, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04,
+	0x04, 0x43, 0x04, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x22, 0x23, 0x25,
+	0x11, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03,
+	0x23, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23,
+	0x23, 0x37, 0x01, 0xd8, 0xff, 0xfe, 0x50, 0x28, 0x04, 0x94, 0x28, 0xfe, 0x50, 0xff, 0x4f, 0x63,
+	0xe3, 0x1a, 0x0e, 0xa3, 0x69, 0x52, 0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x9e,
+	0x04, 0xfd, 0xcb, 0xcb, 0xfb, 0x03, 0x71, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54,
+	0xb6, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x87, 0xfe, 0x50, 0x03, 0x77, 0x05, 0x43, 0x00, 0x25,
+	0x00, 0x4e, 0x40, 0x4b, 0x14, 0x01, 0x00, 0x08, 0x04, 0x01, 0x03, 0x00, 0x0c, 0x01, 0x02, 0x03,
+	0x0b, 0x01, 0x01, 0x02, 0x04, 0x4c, 0x1c, 0x01, 0x05, 0x4a, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03,
+	0x02, 0x80, 0x07, 0x01, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x3b, 0x4d, 0x00, 0x08,
+	0x08, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x43, 0x01, 0x4e, 0x23, 0x11, 0x13, 0x11, 0x14, 0x22, 0x23, 0x25, 0x12, 0x09, 0x09, 0x1f, 0x2b,
+	0x25, 0x07, 0x06, 0x07, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32,
+	0x37, 0x36, 0x23, 0x23, 0x37, 0x26, 0x13, 0x13, 0x23, 0x37, 0x33, 0x37, 0x25, 0x07, 0x33, 0x07,
+	0x23, 0x03, 0x06, 0x16, 0x33, 0x32, 0x02, 0xbe, 0x25, 0x74, 0x4a, 0x4d, 0xe3, 0x1a, 0x0e, 0xa3,
+	0x69, 0x52, 0x64, 0x12, 0x44, 0x2f, 0x79, 0x0c, 0x11, 0xc3, 0x14, 0x96, 0xb5, 0x38, 0x74, 0x78,
+	0x25, 0x78, 0x2b, 0x01, 0x2f, 0x32, 0xd2, 0x25, 0xd2, 0x6c, 0x19, 0x1b, 0x42, 0x28, 0xba, 0xb9,
+	0x19, 0x01, 0x58, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54, 0xae, 0x3c, 0x01, 0x1b,
+	0x02, 0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xfd, 0xe5, 0x7c, 0x4d, 0x00, 0x00, 0x02, 0x01, 0x27,
+	0x00, 0x00, 0x05, 0xe3, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x65, 0xb5, 0x0d, 0x01, 0x04,
+	0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05,
+	0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38,
+	0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x08, 0x06, 0x02, 0x05, 0x04,
+	0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x68,
+	0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x08, 0x08, 0x00, 0x00, 0x08, 0x0f,
+	0x08, 0x0f, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19,
+	0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33,
+	0x37, 0x01, 0xd8, 0xff, 0xfe, 0x50, 0x28, 0x04, 0x94, 0x28, 0xfe, 0x50, 0xff, 0x02, 0x56, 0xfe,
+	0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x04, 0xfd, 0xcb, 0xcb, 0xfb, 0x03, 0x07, 0x8f,
+	0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9d, 0xff, 0xe7, 0x05, 0x05,
+	0x06, 0xbf, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x40, 0x40, 0x3d, 0x0b, 0x01, 0x08, 0x06, 0x1b, 0x01,
+	0x02, 0x08, 0x02, 0x4c, 0x00, 0x08, 0x06, 0x02, 0x06, 0x08, 0x02, 0x80, 0x00, 0x07, 0x00, 0x06,
+	0x08, 0x07, 0x06, 0x67, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x14, 0x11, 0x11, 0x23, 0x11,
+	0x13, 0x11, 0x12, 0x22, 0x09, 0x09, 0x1f, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x13, 0x13, 0x23,
+	0x37, 0x33, 0x37, 0x25, 0x07, 0x33, 0x07, 0x23, 0x03, 0x06, 0x16, 0x33, 0x32, 0x01, 0x23, 0x13,
+	0x21, 0x07, 0x02, 0x05, 0x37, 0x32, 0x37, 0x02, 0xbe, 0x25, 0x77, 0x4c, 0xfe, 0xc7, 0x47, 0x74,
+	0x78, 0x25, 0x78, 0x2b, 0x01, 0x2f, 0x32, 0xdc, 0x25, 0xdc, 0x6c, 0x19, 0x1b, 0x42, 0x28, 0x01,
+	0xb8, 0x72, 0x3c, 0x01, 0x03, 0x2e, 0x45, 0xfe, 0xf9, 0x15, 0x72, 0x21, 0xba, 0xb9, 0x1a, 0x01,
+	0x68, 0x02, 0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xfd, 0xe5, 0x7c, 0x4d, 0x04, 0xea, 0x01, 0x28,
+	0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5, 0x00, 0x00, 0x00, 0x01, 0x01, 0x27, 0x00, 0x00, 0x05, 0xe3,
+	0x05, 0xc8, 0x00, 0x0f, 0x00, 0x54, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x05, 0x01, 0x01,
+	0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x04, 0x01, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x03,
+	0x38, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x03, 0x04, 0x01,
+	0x02, 0x01, 0x03, 0x02, 0x67, 0x05, 0x01, 0x01, 0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x08,
+	0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x13,
+	0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x01, 0xd8, 0x8c, 0xfe, 0xcb, 0x23,
+	0x01, 0x35, 0x50, 0xfe, 0x50, 0x28, 0x04, 0x94, 0x28, 0xfe, 0x50, 0x50, 0x01, 0x35, 0x23, 0xfe,
+	0xcb, 0x8c, 0x02, 0xbf, 0xad, 0x01, 0x91, 0xcb, 0xcb, 0xfe, 0x6f, 0xad, 0xfd, 0x41, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x9d, 0xff, 0xe7, 0x03, 0x77, 0x05, 0x43, 0x00, 0x1c, 0x00, 0x41, 0x40, 0x3e,
+	0x16, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x08, 0x01, 0x02, 0x4a, 0x05, 0x01, 0x00, 0x0a, 0x09, 0x02,
+	0x06, 0x07, 0x00, 0x06, 0x67, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b,
+	0x4d, 0x00, 0x07, 0x07, 0x08, 0x61, 0x00, 0x08, 0x08, 0x42, 0x08, 0x4e, 0x00, 0x00, 0x00, 0x1c,
+	0x00, 0x1c, 0x23, 0x23, 0x11, 0x11, 0x11, 0x13, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1f, 0x2b, 0x13,
+	0x37, 0x33, 0x37, 0x23, 0x37, 0x33, 0x37, 0x25, 0x07, 0x33, 0x07, 0x23, 0x07, 0x33, 0x07, 0x23,
+	0x07, 0x06, 0x16, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x13, 0x37, 0xae, 0x1e, 0x62, 0x2a,
+	0x78, 0x25, 0x78, 0x2b, 0x01, 0x2f, 0x32, 0xd2, 0x25, 0xd2, 0x2a, 0xc6, 0x1e, 0xc6, 0x24, 0x19,
+	0x1b, 0x42, 0x28, 0x3e, 0x25, 0x77, 0x4c, 0xfe, 0xc7, 0x47, 0x2c, 0x02, 0x2b, 0x94, 0xd2, 0xb9,
+	0xd7, 0x22, 0xf9, 0xb9, 0xd2, 0x94, 0xb5, 0x7c, 0x4d, 0x0d, 0xb9, 0x1a, 0x01, 0x68, 0xdc, 0x00,
+	0x00, 0x02, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x2b, 0x00, 0x65,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69,
+	0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x26, 0x02, 0x01, 0x00,
+	0x04, 0x01, 0x04, 0x00, 0x01, 0x80, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x00,
+	0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x2b, 0x29, 0x21, 0x11, 0x24, 0x21, 0x15, 0x25, 0x12, 0x23,
+	0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03,
+	0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f,
+	0x02, 0x16, 0x33, 0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22,
+	0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29,
+	0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x02, 0x50, 0x94, 0x40, 0xca, 0x40, 0x36,
+	0x20, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x35, 0x22, 0x14, 0x07, 0x34, 0x1d,
+	0x44, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f,
+	0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf,
+	0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b,
+	0x06, 0x4e, 0x00, 0x10, 0x00, 0x27, 0x00, 0xf8, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x29, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x09,
+	0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d,
+	0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05,
+	0x06, 0x0a, 0x69, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05, 0x06, 0x0a,
+	0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x01, 0x07, 0x05, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x19, 0x00, 0x00, 0x27, 0x25, 0x20, 0x1e, 0x1d, 0x1c, 0x1b, 0x19,
+	0x15, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b,
+	0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21,
+	0x03, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x37, 0x33, 0x02, 0x23, 0x22,
+	0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b,
+	0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xfe, 0x46, 0x94, 0x40,
+	0xca, 0x40, 0x35, 0x21, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x36, 0x21, 0x14,
+	0x07, 0x34, 0x1d, 0x44, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02,
+	0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a,
+	0x10, 0x06, 0x2d, 0x00, 0x00, 0x02, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x07, 0x19, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0x53, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x04, 0x06, 0x01, 0x05,
+	0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01,
+	0x80, 0x00, 0x04, 0x06, 0x01, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x15, 0x15, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25,
+	0x12, 0x23, 0x10, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13,
+	0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x37, 0x21, 0x07,
+	0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29,
+	0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x01, 0xad, 0x23, 0x02, 0xe4, 0x23, 0x05,
+	0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74,
+	0x50, 0xdb, 0xc4, 0x04, 0x2e, 0xad, 0xad, 0x00, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b,
+	0x05, 0xc4, 0x00, 0x10, 0x00, 0x14, 0x00, 0xa1, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x1e, 0x08, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x06, 0x06, 0x05, 0x5f,
+	0x00, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00,
+	0x05, 0x08, 0x01, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01,
+	0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00,
+	0x10, 0x12, 0x23, 0x12, 0x22, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13,
+	0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x37, 0x21, 0x07, 0x03, 0x28,
+	0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f,
+	0x01, 0x28, 0xdb, 0xfd, 0xae, 0x22, 0x02, 0xe4, 0x22, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd,
+	0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x17, 0xad, 0xad, 0x00, 0x02, 0x00, 0xeb,
+	0xff, 0xdb, 0x06, 0x4d, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x20, 0x00, 0x5a, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07,
+	0x69, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x02, 0x01, 0x00, 0x07, 0x01,
+	0x07, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x69, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0b, 0x22, 0x11, 0x21, 0x15, 0x25, 0x12,
+	0x23, 0x10, 0x08, 0x09, 0x1e, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21,
+	0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x33, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01,
+	0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27,
+	0x01, 0xfa, 0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9d, 0x05, 0xc8, 0xfc,
+	0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb,
+	0xc4, 0x05, 0x51, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x1c, 0x00, 0xe0, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09,
+	0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x27, 0x07,
+	0x01, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x07, 0x01,
+	0x05, 0x06, 0x05, 0x85, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x06,
+	0x00, 0x08, 0x01, 0x06, 0x08, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04,
+	0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x15, 0x00, 0x00, 0x1b, 0x19, 0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10,
+	0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28,
+	0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xfd, 0xf0, 0x94, 0x0d, 0xa5, 0xa3,
+	0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9e, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b,
+	0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x06, 0x44, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x08, 0x19, 0x00, 0x14, 0x00, 0x20, 0x00, 0x2c,
+	0x00, 0x6e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x00, 0x07, 0x06, 0x05, 0x07,
+	0x69, 0x09, 0x01, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x26, 0x02,
+	0x01, 0x00, 0x04, 0x01, 0x04, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07, 0x06, 0x05, 0x07, 0x69,
+	0x09, 0x01, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x69, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x17, 0x22, 0x21, 0x16, 0x15, 0x28, 0x26, 0x21, 0x2c,
+	0x22, 0x2c, 0x1c, 0x1a, 0x15, 0x20, 0x16, 0x20, 0x25, 0x12, 0x23, 0x10, 0x0a, 0x09, 0x1a, 0x2b,
+	0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23,
+	0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06,
+	0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x01, 0xc7, 0x01,
+	0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3,
+	0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x03, 0x18, 0x60, 0x6b, 0x13, 0x13, 0xa4, 0x62, 0x61, 0x6d,
+	0x13, 0x14, 0xa4, 0x4d, 0x35, 0x57, 0x0a, 0x0a, 0x39, 0x33, 0x33, 0x57, 0x0a, 0x0a, 0x38, 0x05,
+	0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74,
+	0x50, 0xdb, 0xc4, 0x04, 0x06, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34,
+	0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b,
+	0x06, 0xd8, 0x00, 0x10, 0x00, 0x1c, 0x00, 0x28, 0x00, 0xc0, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5,
+	0x01, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01,
+	0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x29, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01,
+	0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00,
+	0x06, 0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05,
+	0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1d, 0x1e, 0x1d, 0x12, 0x11,
+	0x00, 0x00, 0x24, 0x22, 0x1d, 0x28, 0x1e, 0x28, 0x18, 0x16, 0x11, 0x1c, 0x12, 0x1c, 0x00, 0x10,
+	0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13,
+	0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x03, 0x22, 0x26, 0x37, 0x36,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06,
+	0x07, 0x06, 0x16, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15,
+	0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xf2, 0x60, 0x6c, 0x14, 0x13, 0xa4, 0x62, 0x61,
+	0x6d, 0x13, 0x14, 0xa4, 0x4d, 0x35, 0x56, 0x0b, 0x0a, 0x3a, 0x33, 0x33, 0x56, 0x0a, 0x0a, 0x38,
+	0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05,
+	0x03, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33,
+	0x33, 0x49, 0x00, 0x00, 0x00, 0x03, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x80, 0x07, 0x8f, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0x1c, 0x00, 0x61, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04,
+	0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00,
+	0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16,
+	0x19, 0x19, 0x15, 0x15, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25,
+	0x12, 0x23, 0x10, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13,
+	0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x01, 0x33, 0x01,
+	0x33, 0x01, 0x33, 0x01, 0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba,
+	0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x01, 0xe0, 0x01,
+	0x31, 0xe4, 0xfe, 0x7f, 0xe5, 0x01, 0x30, 0xe5, 0xfe, 0x7f, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0,
+	0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10,
+	0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0xaa,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0xde, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5,
+	0x01, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01,
+	0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09,
+	0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x0b,
+	0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b,
+	0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1d, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00,
+	0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10,
+	0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33,
+	0x01, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45,
+	0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xfd, 0xcc, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xe5, 0x01, 0x30,
+	0xe5, 0xfe, 0x7f, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc,
+	0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0xeb,
+	0xfe, 0x8e, 0x06, 0x4d, 0x05, 0xc8, 0x00, 0x21, 0x00, 0x72, 0xb5, 0x15, 0x01, 0x03, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1b, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x05, 0x62, 0x00, 0x05, 0x05, 0x3f, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x00, 0x04, 0x03,
+	0x04, 0x65, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x62, 0x00, 0x05, 0x05,
+	0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x00, 0x04,
+	0x03, 0x04, 0x65, 0x00, 0x01, 0x01, 0x05, 0x62, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x59,
+	0x40, 0x09, 0x13, 0x23, 0x29, 0x12, 0x23, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x21, 0x03, 0x06,
+	0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x07, 0x06, 0x07, 0x06, 0x33,
+	0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x37, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0xc7,
+	0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77,
+	0x4f, 0x5e, 0xba, 0x12, 0x13, 0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f, 0x13, 0xa2,
+	0xfc, 0x84, 0x5b, 0x29, 0x27, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc,
+	0x73, 0xcd, 0xd7, 0x4f, 0x34, 0x1b, 0x53, 0x5a, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x5f, 0x4f, 0x74,
+	0x50, 0xdb, 0xc4, 0x00, 0x00, 0x01, 0x00, 0x83, 0xfe, 0x8e, 0x05, 0x2b, 0x04, 0x4a, 0x00, 0x1d,
+	0x00, 0xbf, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x01, 0x01, 0x00, 0x02, 0x17, 0x01, 0x05,
+	0x00, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x01, 0x01, 0x04, 0x02, 0x17, 0x01, 0x05, 0x00, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x04, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06,
+	0x06, 0x3d, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x20, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3d, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x0a, 0x23, 0x23, 0x11, 0x12, 0x23, 0x12, 0x22, 0x07, 0x09, 0x1d,
+	0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13,
+	0x21, 0x03, 0x23, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37, 0x36, 0x03,
+	0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad,
+	0x8f, 0x01, 0x28, 0xdb, 0x8a, 0xcc, 0x12, 0x13, 0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9,
+	0x1f, 0x18, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb,
+	0xb6, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x40,
+	0x00, 0x00, 0x08, 0x9c, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x69, 0x40, 0x0c, 0x12, 0x01,
+	0x06, 0x05, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02,
+	0x00, 0x00, 0x38, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00,
+	0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x03, 0x60, 0x08, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00,
+	0x00, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12,
+	0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21,
+	0x03, 0x01, 0x13, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x95, 0x55, 0x01, 0x23, 0x41,
+	0x01, 0xf0, 0x01, 0x01, 0x26, 0x02, 0x06, 0xdb, 0xfd, 0x3e, 0xfe, 0xd9, 0x26, 0xfe, 0x2e, 0xf9,
+	0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05, 0xc8, 0xfb, 0xc5, 0x04, 0x3b, 0xfb,
+	0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc, 0x09, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5,
+	0xc5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x19, 0x00, 0x00, 0x06, 0xd7, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x14, 0x00, 0x90, 0x40, 0x0c, 0x12, 0x01, 0x06, 0x05, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00,
+	0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1e, 0x09, 0x07, 0x02, 0x06, 0x05, 0x00, 0x05,
+	0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08,
+	0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00,
+	0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d,
+	0x08, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00, 0x00,
+	0x0d, 0x14, 0x0d, 0x14, 0x11, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11,
+	0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03,
+	0x01, 0x13, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x48, 0x2f, 0x01, 0x0b, 0x1f, 0x01,
+	0x5b, 0x01, 0x00, 0x0f, 0x01, 0x63, 0xc7, 0xfe, 0x07, 0xfe, 0xe5, 0x0e, 0xfe, 0xaf, 0x66, 0x01,
+	0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb,
+	0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5,
+	0x00, 0x02, 0x01, 0x43, 0x00, 0x00, 0x06, 0x62, 0x07, 0x8f, 0x00, 0x08, 0x00, 0x10, 0x00, 0x62,
+	0x40, 0x0b, 0x0e, 0x01, 0x04, 0x03, 0x04, 0x01, 0x02, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x07, 0x05, 0x02, 0x04, 0x00, 0x04, 0x85,
+	0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x19,
+	0x00, 0x03, 0x04, 0x03, 0x85, 0x07, 0x05, 0x02, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00,
+	0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x15, 0x09, 0x09, 0x00, 0x00,
+	0x09, 0x10, 0x09, 0x10, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x08, 0x09,
+	0x18, 0x2b, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x03, 0x03, 0x01, 0x21, 0x13, 0x23,
+	0x27, 0x23, 0x07, 0x02, 0x07, 0x7b, 0xfe, 0xc1, 0x01, 0x55, 0xe5, 0x01, 0xf1, 0xf4, 0xfd, 0x55,
+	0x7c, 0xa3, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x02, 0x6c, 0x03, 0x5c, 0xfd,
+	0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00,
+	0x00, 0x02, 0x00, 0x5c, 0xfe, 0x75, 0x05, 0x34, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x5b,
+	0x40, 0x0a, 0x0d, 0x01, 0x04, 0x03, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x1b, 0x06, 0x05, 0x02, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x03, 0x03,
+	0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40,
+	0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x05, 0x02, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x08, 0x08, 0x08, 0x0f,
+	0x08, 0x0f, 0x11, 0x12, 0x11, 0x12, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01,
+	0x33, 0x01, 0x21, 0x01, 0x01, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0xa3, 0xaf, 0x01, 0x38,
+	0x70, 0x01, 0xbc, 0xdc, 0xfc, 0x56, 0xfe, 0xd2, 0x01, 0x92, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3,
+	0x9e, 0x03, 0xec, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x8e, 0x01, 0x41, 0xfe,
+	0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x03, 0x01, 0x43, 0x00, 0x00, 0x06, 0x62, 0x07, 0x40, 0x00, 0x08,
+	0x00, 0x0c, 0x00, 0x10, 0x00, 0x63, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x19, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x00, 0x03, 0x04,
+	0x67, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x19, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x01, 0x01, 0x00,
+	0x00, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x1b, 0x0d, 0x0d, 0x09,
+	0x09, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x09, 0x0c, 0x09, 0x0c, 0x0b, 0x0a, 0x00,
+	0x08, 0x00, 0x08, 0x12, 0x12, 0x0a, 0x09, 0x18, 0x2b, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33,
+	0x01, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x02, 0x07, 0x7b, 0xfe, 0xc1, 0x01,
+	0x55, 0xe5, 0x01, 0xf1, 0xf4, 0xfd, 0x55, 0x7c, 0x6a, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c,
+	0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x62, 0xde, 0xde,
+	0xde, 0xde, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5e, 0x00, 0x00, 0x05, 0xad, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x62, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d,
+	0x00, 0x02, 0x02, 0x03, 0x60, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01,
+	0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x60, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40,
+	0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12,
+	0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07,
+	0x01, 0x01, 0x21, 0x01, 0x5e, 0x29, 0x03, 0x98, 0xfd, 0x69, 0x28, 0x03, 0xfd, 0x28, 0xfc, 0x68,
+	0x02, 0xc2, 0x29, 0xfe, 0x6d, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xd2, 0x04, 0x2b, 0xcb, 0xcb,
+	0xfb, 0xd5, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6f,
+	0x00, 0x00, 0x04, 0x91, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x91, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x24, 0x07, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x60,
+	0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x60, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x60, 0x06, 0x01, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d,
+	0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01,
+	0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01, 0x01, 0x21, 0x01, 0x6f, 0x27, 0x02, 0x66, 0xfe,
+	0x45, 0x25, 0x03, 0x06, 0x25, 0xfd, 0x9a, 0x01, 0xe3, 0x27, 0xfe, 0xaa, 0x01, 0x31, 0x01, 0x19,
+	0xfe, 0x7f, 0xc5, 0x02, 0xcc, 0xb9, 0xb9, 0xfd, 0x34, 0xc5, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x5e, 0x00, 0x00, 0x05, 0xad, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x5e,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06,
+	0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a,
+	0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37,
+	0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01, 0x13, 0x21, 0x03, 0x5e, 0x29, 0x03, 0x98,
+	0xfd, 0x69, 0x28, 0x03, 0xfd, 0x28, 0xfc, 0x68, 0x02, 0xc2, 0x29, 0xfe, 0xa9, 0x3b, 0x01, 0x28,
+	0x3b, 0xd2, 0x04, 0x2b, 0xcb, 0xcb, 0xfb, 0xd5, 0xd2, 0x06, 0x67, 0x01, 0x28, 0xfe, 0xd8, 0x00,
+	0x00, 0x02, 0x00, 0x6f, 0x00, 0x00, 0x04, 0x6c, 0x06, 0x3f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x8c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40, 0x21,
+	0x07, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c,
+	0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21,
+	0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01, 0x13, 0x21, 0x03, 0x6f, 0x27, 0x02, 0x66, 0xfe, 0x45,
+	0x25, 0x03, 0x06, 0x25, 0xfd, 0x9a, 0x01, 0xe3, 0x27, 0xfe, 0xd3, 0x3b, 0x01, 0x28, 0x3b, 0xc5,
+	0x02, 0xcc, 0xb9, 0xb9, 0xfd, 0x34, 0xc5, 0x05, 0x17, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x5e, 0x00, 0x00, 0x05, 0xad, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x6d,
+	0xb5, 0x0f, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x20, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e, 0x0d,
+	0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01,
+	0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x13, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x5e,
+	0x29, 0x03, 0x98, 0xfd, 0x69, 0x28, 0x03, 0xfd, 0x28, 0xfc, 0x68, 0x02, 0xc2, 0x29, 0xf1, 0xfe,
+	0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0xd2, 0x04, 0x2b, 0xcb, 0xcb, 0xfb, 0xd5, 0xd2,
+	0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0x6f, 0x00, 0x00, 0x04, 0xc8,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x11, 0x00, 0x9d, 0xb5, 0x0f, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b,
+	0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x08, 0x06,
+	0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x0a, 0x0a,
+	0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11,
+	0x12, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01,
+	0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x6f, 0x27, 0x02, 0x66, 0xfe, 0x45, 0x25, 0x03, 0x06,
+	0x25, 0xfd, 0x9a, 0x01, 0xe3, 0x27, 0x01, 0x2b, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03,
+	0xed, 0xc5, 0x02, 0xcc, 0xb9, 0xb9, 0xfd, 0x34, 0xc5, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6,
+	0xc6, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa6, 0x00, 0x00, 0x04, 0x1b, 0x06, 0x44, 0x00, 0x0f,
+	0x00, 0x7d, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04,
+	0x4e, 0x1b, 0x40, 0x19, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x23, 0x22, 0x11, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33,
+	0x13, 0x23, 0x37, 0x33, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa6,
+	0xb6, 0x72, 0x25, 0x72, 0x10, 0x55, 0x01, 0x86, 0x54, 0x5b, 0x27, 0x4b, 0x41, 0x7f, 0x2e, 0xed,
+	0x03, 0x91, 0xb9, 0x4f, 0x01, 0xab, 0x1a, 0xc0, 0x21, 0xe7, 0xfb, 0x5c, 0x00, 0x01, 0xff, 0xf6,
+	0xfe, 0xd8, 0x05, 0x6b, 0x05, 0xed, 0x00, 0x13, 0x00, 0x65, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02,
+	0x0a, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x07, 0x01, 0x06,
+	0x00, 0x06, 0x86, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x03, 0x03,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x01, 0x06, 0x00, 0x06,
+	0x86, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57,
+	0x04, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x05, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x08, 0x09, 0x1c, 0x2b, 0x03,
+	0x01, 0x23, 0x37, 0x33, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x03, 0x07, 0x33,
+	0x07, 0x23, 0x01, 0x0a, 0x01, 0x8c, 0x95, 0x25, 0xb9, 0x26, 0xe2, 0x01, 0xc8, 0x75, 0x5b, 0x29,
+	0x73, 0x5d, 0xc9, 0x6b, 0x45, 0xb1, 0x25, 0xd6, 0xfe, 0x74, 0xfe, 0xd8, 0x03, 0xe1, 0xb9, 0x5a,
+	0x02, 0x21, 0x12, 0xcc, 0x26, 0xfe, 0xee, 0xb1, 0xb9, 0xfc, 0x1f, 0x00, 0x00, 0x03, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xdf, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x12, 0x00, 0x74, 0x40, 0x0a,
+	0x10, 0x01, 0x05, 0x06, 0x0a, 0x01, 0x04, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x21, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05,
+	0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x08, 0x03,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x12, 0x0b,
+	0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09,
+	0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x01, 0x01, 0x21,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd,
+	0x9c, 0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x02, 0x3d, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e,
+	0x03, 0xec, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x02, 0xf1,
+	0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x03, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a,
+	0x06, 0x44, 0x00, 0x0d, 0x00, 0x16, 0x00, 0x1e, 0x00, 0xe2, 0xb5, 0x1c, 0x01, 0x06, 0x07, 0x01,
+	0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x26, 0x00, 0x06, 0x07, 0x01, 0x07, 0x06, 0x01, 0x80,
+	0x09, 0x08, 0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x29, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x06, 0x07, 0x01, 0x07, 0x06, 0x01, 0x80, 0x09, 0x08,
+	0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x09, 0x08, 0x02, 0x07,
+	0x06, 0x07, 0x85, 0x00, 0x06, 0x01, 0x06, 0x85, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2b, 0x09, 0x08, 0x02, 0x07, 0x06, 0x07,
+	0x85, 0x00, 0x06, 0x01, 0x06, 0x85, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11, 0x17, 0x17, 0x17, 0x1e, 0x17, 0x1e,
+	0x11, 0x13, 0x22, 0x22, 0x11, 0x11, 0x24, 0x21, 0x0a, 0x09, 0x1e, 0x2b, 0x25, 0x06, 0x23, 0x22,
+	0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02,
+	0x33, 0x32, 0x37, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0x4b, 0xc5, 0xbc, 0xac,
+	0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0xb8, 0x6b, 0x37,
+	0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x01, 0x96, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03,
+	0xed, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13,
+	0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x04, 0xc6, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0xcb, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x73,
+	0xb5, 0x11, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x0a, 0x08,
+	0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f,
+	0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x22, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06,
+	0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x68, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f,
+	0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13,
+	0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01,
+	0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x64, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a,
+	0xd2, 0xd4, 0xd2, 0x29, 0x01, 0x8f, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0xd2,
+	0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0xe2, 0x06, 0x44, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x7d,
+	0xb5, 0x09, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02,
+	0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x06, 0x04, 0x02, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x18, 0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x06, 0x04, 0x02, 0x03,
+	0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b,
+	0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21,
+	0x03, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x94, 0xdb, 0x01, 0x28, 0xdb, 0x02, 0x26,
+	0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0x04, 0x4a, 0xfb, 0xb6, 0x06, 0x44, 0xfe,
+	0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d, 0x01, 0x04, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x08, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19,
+	0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b,
+	0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13,
+	0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a,
+	0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7,
+	0x37, 0x35, 0x8f, 0x03, 0x94, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x25, 0x01,
+	0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc,
+	0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0,
+	0x06, 0xe8, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x7b, 0xb5, 0x1d, 0x01, 0x04, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80,
+	0x09, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18,
+	0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x01, 0x01, 0x21,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef,
+	0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55,
+	0x03, 0x08, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0x19, 0x01, 0x3b, 0x01, 0x03,
+	0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1,
+	0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x05, 0xa4, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x5e,
+	0xb5, 0x1a, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x07, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x07, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85,
+	0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x15, 0x15,
+	0x15, 0x1c, 0x15, 0x1c, 0x11, 0x16, 0x25, 0x12, 0x23, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x21,
+	0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27,
+	0x26, 0x26, 0x37, 0x01, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x01, 0xc7, 0x01, 0x34, 0xb5,
+	0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc,
+	0x84, 0x5b, 0x29, 0x27, 0x04, 0xcc, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05,
+	0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74,
+	0x50, 0xdb, 0xc4, 0x05, 0x51, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x02, 0x00, 0x83,
+	0xff, 0xe7, 0x05, 0x2b, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xe4, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x0a, 0x16, 0x01, 0x05, 0x06, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a,
+	0x16, 0x01, 0x05, 0x06, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x22, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04, 0x02, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x01,
+	0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06,
+	0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23,
+	0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18,
+	0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b,
+	0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21,
+	0x03, 0x13, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6,
+	0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xd6, 0xfe,
+	0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41,
+	0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00,
+	0x00, 0x04, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x08, 0x7d, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c,
+	0x00, 0x20, 0x00, 0x7b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x08, 0x0c, 0x01, 0x09,
+	0x04, 0x08, 0x09, 0x67, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67,
+	0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x29, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x08, 0x0c,
+	0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e,
+	0x1d, 0x1d, 0x19, 0x19, 0x15, 0x15, 0x1d, 0x20, 0x1d, 0x20, 0x1f, 0x1e, 0x19, 0x1c, 0x19, 0x1c,
+	0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x01,
+	0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22,
+	0x27, 0x26, 0x26, 0x37, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x37, 0x21, 0x07,
+	0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29,
+	0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x01, 0xc7, 0x2c, 0xde, 0x2c, 0xd9, 0x2c,
+	0xdf, 0x2c, 0xfd, 0x91, 0x23, 0x02, 0xe4, 0x23, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f,
+	0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde,
+	0xde, 0xde, 0x01, 0x6e, 0xad, 0xad, 0x00, 0x00, 0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x4b,
+	0x07, 0x28, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x01, 0x0a, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0xb5, 0x01, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c,
+	0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a,
+	0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05,
+	0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2c,
+	0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03,
+	0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2c, 0x00,
+	0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x25,
+	0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18,
+	0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23,
+	0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01,
+	0x37, 0x21, 0x07, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15,
+	0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xfd, 0xbd, 0x2c, 0xde, 0x2c, 0xed, 0x2c, 0xdf,
+	0x2c, 0xfd, 0x8e, 0x22, 0x02, 0xe4, 0x22, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b,
+	0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x01, 0x6e, 0xad, 0xad,
+	0x00, 0x04, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x52, 0x08, 0xf3, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c,
+	0x00, 0x20, 0x00, 0x7f, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x28, 0x00, 0x08, 0x09, 0x08, 0x85,
+	0x0c, 0x01, 0x09, 0x04, 0x09, 0x85, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04,
+	0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03,
+	0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x09,
+	0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a,
+	0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x1e, 0x1d, 0x1d, 0x19, 0x19, 0x15, 0x15, 0x1d, 0x20, 0x1d, 0x20, 0x1f, 0x1e,
+	0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0d,
+	0x09, 0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06,
+	0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0x01, 0x01, 0x21, 0x01, 0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba,
+	0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x01, 0xc7, 0x2c,
+	0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0xfe, 0x99, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x05, 0xc8,
+	0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50,
+	0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde, 0x01, 0x50, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x99, 0x07, 0xa8, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18,
+	0x00, 0x1c, 0x01, 0x12, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01, 0x00, 0x02, 0x01, 0x4c,
+	0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2c,
+	0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d,
+	0x50, 0x58, 0x40, 0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x0d,
+	0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x09, 0x0a, 0x09,
+	0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01,
+	0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x09, 0x0a,
+	0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x25,
+	0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18,
+	0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23,
+	0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01,
+	0x01, 0x21, 0x01, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15,
+	0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xfd, 0xbd, 0x2c, 0xde, 0x2c, 0xed, 0x2c, 0xdf,
+	0x2c, 0xfe, 0x98, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd,
+	0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x01, 0x5a,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x04, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x08, 0xf3, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x8a, 0xb5, 0x22, 0x01, 0x08, 0x09, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x0d, 0x0a, 0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04,
+	0x08, 0x85, 0x06, 0x01, 0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b,
+	0x40, 0x2c, 0x0d, 0x0a, 0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x02, 0x01,
+	0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00,
+	0x04, 0x05, 0x68, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40,
+	0x20, 0x1d, 0x1d, 0x19, 0x19, 0x15, 0x15, 0x1d, 0x24, 0x1d, 0x24, 0x21, 0x20, 0x1f, 0x1e, 0x19,
+	0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0e, 0x09,
+	0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07,
+	0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x13,
+	0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x01, 0xc7, 0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01,
+	0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77, 0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27,
+	0x01, 0xc7, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0xb7, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3,
+	0x9e, 0x03, 0xec, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd,
+	0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde, 0x02, 0x91, 0xfe,
+	0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x6d,
+	0x07, 0xa8, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0x20, 0x01, 0x22, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x0a, 0x1e, 0x01, 0x09, 0x0a, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a,
+	0x1e, 0x01, 0x09, 0x0a, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x2d, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0e, 0x08,
+	0x0d, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0c, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x31, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09,
+	0x05, 0x09, 0x85, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0c, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2f,
+	0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x07, 0x01, 0x05, 0x0e,
+	0x08, 0x0d, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0c, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x2f, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x07, 0x01,
+	0x05, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x0c, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x27, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x20,
+	0x19, 0x20, 0x1d, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14,
+	0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x10, 0x09, 0x1a, 0x2b, 0x21, 0x37,
+	0x06, 0x23, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01,
+	0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x13, 0x01, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03,
+	0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b, 0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad,
+	0x8f, 0x01, 0x28, 0xdb, 0xfd, 0xbd, 0x2c, 0xde, 0x2c, 0xed, 0x2c, 0xdf, 0x2c, 0xb6, 0xfe, 0xcf,
+	0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b,
+	0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x02, 0x9b, 0xfe, 0xbf,
+	0x01, 0x41, 0xc6, 0xc6, 0x00, 0x04, 0x00, 0xeb, 0xff, 0xdb, 0x06, 0x4d, 0x08, 0xf3, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0x1c, 0x00, 0x20, 0x00, 0x75, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00,
+	0x09, 0x00, 0x08, 0x04, 0x09, 0x08, 0x67, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00,
+	0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x28, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80,
+	0x00, 0x09, 0x00, 0x08, 0x04, 0x09, 0x08, 0x67, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05,
+	0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59,
+	0x40, 0x1a, 0x19, 0x19, 0x15, 0x15, 0x20, 0x1f, 0x1e, 0x1d, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a,
+	0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0c, 0x09, 0x1b, 0x2b, 0x01, 0x21, 0x03,
+	0x06, 0x16, 0x33, 0x20, 0x13, 0x13, 0x21, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26,
+	0x26, 0x37, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03, 0x23, 0x01, 0x21, 0x01, 0xc7,
+	0x01, 0x34, 0xb5, 0x2b, 0x67, 0x9d, 0x01, 0x1c, 0x4c, 0xba, 0x01, 0x0c, 0xb5, 0x29, 0x79, 0x77,
+	0xa3, 0xed, 0xfc, 0x84, 0x5b, 0x29, 0x27, 0x01, 0xc7, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c,
+	0x9e, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2,
+	0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde,
+	0x01, 0x50, 0x01, 0x41, 0x00, 0x04, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x2b, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x01, 0x02, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0xb5, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x4c, 0x1b, 0xb5, 0x01, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x29, 0x00, 0x0a, 0x00, 0x09, 0x05, 0x0a, 0x09, 0x67, 0x0d, 0x08, 0x0c, 0x03,
+	0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x0a, 0x00, 0x09, 0x05, 0x0a, 0x09, 0x67, 0x0d, 0x08, 0x0c,
+	0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x0a, 0x00, 0x09, 0x05, 0x0a,
+	0x09, 0x67, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x0a, 0x00, 0x09, 0x05, 0x0a, 0x09, 0x67,
+	0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x21, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x1c, 0x1b,
+	0x1a, 0x19, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10,
+	0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0e, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13,
+	0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x01, 0x37, 0x33, 0x07, 0x33,
+	0x37, 0x33, 0x07, 0x03, 0x23, 0x01, 0x21, 0x03, 0x28, 0x24, 0xd2, 0xcd, 0xfe, 0xd6, 0x45, 0x9b,
+	0x01, 0x28, 0x8d, 0x15, 0x22, 0x45, 0x77, 0xad, 0x8f, 0x01, 0x28, 0xdb, 0xfd, 0xbd, 0x2c, 0xde,
+	0x2c, 0xed, 0x2c, 0xdf, 0x2c, 0x9f, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0xb6, 0xcf, 0x01, 0x5b, 0x03,
+	0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde,
+	0x01, 0x5a, 0x01, 0x41, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x06, 0x3b, 0x08, 0x91, 0x00, 0x1a,
+	0x00, 0x1d, 0x00, 0x29, 0x00, 0x69, 0x40, 0x0b, 0x03, 0x01, 0x06, 0x00, 0x1d, 0x0b, 0x02, 0x04,
+	0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00,
+	0x06, 0x05, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x01, 0x05, 0x05,
+	0x3e, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00,
+	0x85, 0x00, 0x06, 0x05, 0x06, 0x85, 0x07, 0x01, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02,
+	0x01, 0x04, 0x02, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x1f, 0x1e,
+	0x25, 0x23, 0x1e, 0x29, 0x1f, 0x29, 0x1a, 0x11, 0x11, 0x1a, 0x11, 0x08, 0x09, 0x1b, 0x2b, 0x01,
+	0x01, 0x21, 0x01, 0x23, 0x16, 0x17, 0x16, 0x07, 0x06, 0x07, 0x07, 0x01, 0x21, 0x03, 0x21, 0x03,
+	0x23, 0x01, 0x26, 0x27, 0x26, 0x37, 0x36, 0x37, 0x36, 0x37, 0x01, 0x21, 0x03, 0x13, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x03, 0xfb, 0x01, 0x31, 0x01, 0x0f, 0xfe,
+	0x7f, 0x01, 0x23, 0x1a, 0x37, 0x13, 0x14, 0x53, 0x0e, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c,
+	0xe5, 0xe1, 0x03, 0x65, 0x05, 0x05, 0x35, 0x13, 0x14, 0x52, 0x26, 0x2b, 0xfe, 0x4a, 0x01, 0xcc,
+	0x70, 0x73, 0x35, 0x56, 0x0b, 0x0a, 0x3a, 0x33, 0x33, 0x56, 0x0a, 0x0b, 0x39, 0x07, 0x50, 0x01,
+	0x41, 0xfe, 0xbf, 0x11, 0x21, 0x44, 0x61, 0x63, 0x44, 0x0c, 0xfa, 0x3a, 0x01, 0x8b, 0xfe, 0x75,
+	0x05, 0xc8, 0x05, 0x06, 0x45, 0x60, 0x62, 0x44, 0x21, 0x11, 0xfb, 0x00, 0x02, 0x4e, 0x01, 0x5f,
+	0x48, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x04, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x47,
+	0x07, 0x8f, 0x00, 0x10, 0x00, 0x1c, 0x00, 0x2a, 0x00, 0x33, 0x00, 0xce, 0xb5, 0x0a, 0x01, 0x03,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x01, 0x03, 0x01, 0x85, 0x00,
+	0x03, 0x02, 0x03, 0x85, 0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x00, 0x08,
+	0x08, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x07, 0x01,
+	0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x33, 0x00, 0x01, 0x03,
+	0x01, 0x85, 0x00, 0x03, 0x02, 0x03, 0x85, 0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x05, 0x02, 0x00,
+	0x69, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d,
+	0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e,
+	0x1b, 0x40, 0x33, 0x00, 0x01, 0x03, 0x01, 0x85, 0x00, 0x03, 0x02, 0x03, 0x85, 0x0b, 0x01, 0x02,
+	0x0a, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x1f, 0x12, 0x11, 0x01, 0x00, 0x32, 0x30,
+	0x2e, 0x2c, 0x2a, 0x29, 0x28, 0x27, 0x26, 0x24, 0x20, 0x1e, 0x18, 0x16, 0x11, 0x1c, 0x12, 0x1c,
+	0x09, 0x08, 0x00, 0x10, 0x01, 0x10, 0x0c, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x37,
+	0x36, 0x37, 0x01, 0x21, 0x01, 0x16, 0x17, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36,
+	0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x13, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33,
+	0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37, 0x03, 0x0a,
+	0x60, 0x6b, 0x13, 0x14, 0x51, 0x27, 0x29, 0x01, 0x31, 0x01, 0x0f, 0xfe, 0x7f, 0x23, 0x19, 0x38,
+	0x14, 0x14, 0xa4, 0x4d, 0x35, 0x57, 0x0a, 0x0a, 0x39, 0x33, 0x33, 0x57, 0x0a, 0x0a, 0x38, 0x5c,
+	0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb, 0xfe, 0xd8,
+	0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0x04, 0x8d, 0x8a, 0x60, 0x62, 0x44, 0x20,
+	0x12, 0x01, 0x40, 0xfe, 0xbf, 0x11, 0x20, 0x44, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48,
+	0x48, 0x33, 0x33, 0x49, 0xfb, 0xba, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb,
+	0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c,
+	0x00, 0x00, 0x08, 0xba, 0x07, 0x8f, 0x00, 0x0f, 0x00, 0x12, 0x00, 0x16, 0x00, 0x91, 0xb5, 0x12,
+	0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x32, 0x00, 0x09, 0x0a, 0x09,
+	0x85, 0x0c, 0x01, 0x0a, 0x00, 0x0a, 0x85, 0x00, 0x02, 0x00, 0x03, 0x08, 0x02, 0x03, 0x67, 0x00,
+	0x08, 0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0b, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40,
+	0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0c, 0x01, 0x0a, 0x00, 0x0a, 0x85, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x08, 0x02, 0x03, 0x67, 0x00, 0x08, 0x00, 0x06,
+	0x04, 0x08, 0x06, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0b, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05,
+	0x4e, 0x59, 0x40, 0x1a, 0x13, 0x13, 0x00, 0x00, 0x13, 0x16, 0x13, 0x16, 0x15, 0x14, 0x11, 0x10,
+	0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1d, 0x2b, 0x33,
+	0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x13, 0x21, 0x01, 0x01,
+	0x21, 0x13, 0x13, 0x01, 0x21, 0x01, 0x0c, 0x04, 0xa7, 0x04, 0x07, 0x28, 0xfd, 0x59, 0x53, 0x02,
+	0x38, 0x29, 0xfd, 0xc8, 0x5a, 0x02, 0xd6, 0x29, 0xfc, 0x02, 0x4c, 0xfe, 0x24, 0xfe, 0xcd, 0x01,
+	0xcd, 0x01, 0x68, 0x77, 0xc2, 0x01, 0x32, 0x01, 0x19, 0xfe, 0x7e, 0x05, 0xc8, 0xcb, 0xfe, 0x63,
+	0xcc, 0xfe, 0x3e, 0xd2, 0x01, 0x7e, 0xfe, 0x82, 0x02, 0x3e, 0x02, 0x53, 0x01, 0xbd, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x04, 0x00, 0x62, 0xff, 0xe7, 0x07, 0x52, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x25, 0x00, 0x2e, 0x00, 0x33, 0x00, 0xdb, 0x40, 0x0e, 0x17, 0x01, 0x04, 0x05, 0x12, 0x01,
+	0x03, 0x04, 0x21, 0x01, 0x08, 0x07, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x31, 0x0e,
+	0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x80, 0x0c, 0x01, 0x03, 0x0a, 0x01, 0x07, 0x08, 0x03,
+	0x07, 0x69, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x0d, 0x01, 0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05,
+	0x05, 0x41, 0x4d, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x0e, 0x01, 0x01,
+	0x05, 0x01, 0x85, 0x0c, 0x01, 0x03, 0x0a, 0x01, 0x07, 0x08, 0x03, 0x07, 0x69, 0x0d, 0x01, 0x04,
+	0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09,
+	0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x33, 0x00, 0x00, 0x01, 0x00, 0x85, 0x0e, 0x01,
+	0x01, 0x05, 0x01, 0x85, 0x00, 0x0a, 0x07, 0x03, 0x0a, 0x59, 0x0c, 0x01, 0x03, 0x00, 0x07, 0x08,
+	0x03, 0x07, 0x67, 0x0d, 0x01, 0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x0b,
+	0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x22,
+	0x00, 0x00, 0x33, 0x31, 0x30, 0x2f, 0x2e, 0x2c, 0x29, 0x27, 0x25, 0x23, 0x20, 0x1e, 0x1d, 0x1c,
+	0x1a, 0x18, 0x16, 0x14, 0x11, 0x0f, 0x0d, 0x0b, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0f,
+	0x09, 0x17, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x24, 0x21,
+	0x33, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x17, 0x36, 0x33, 0x32, 0x12, 0x03,
+	0x21, 0x02, 0x21, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x03, 0x37, 0x23, 0x20, 0x07, 0x06, 0x16,
+	0x33, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x03, 0xce, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0xfe,
+	0xb3, 0xc7, 0xf1, 0x98, 0x98, 0x1d, 0x24, 0x01, 0x50, 0x01, 0x16, 0x54, 0x14, 0x23, 0xca, 0xb2,
+	0xc9, 0x29, 0xdf, 0xc1, 0xb0, 0x8d, 0xb2, 0xb8, 0xef, 0xa4, 0x41, 0xfd, 0x47, 0x1e, 0x01, 0x41,
+	0x99, 0xcc, 0x29, 0xe2, 0xd6, 0xfe, 0xcc, 0xbc, 0x2c, 0x4b, 0xfe, 0xd4, 0x23, 0x0d, 0x49, 0x43,
+	0x6b, 0x01, 0xec, 0x01, 0x99, 0x39, 0xbd, 0xbf, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xfb, 0xbd,
+	0xd9, 0xae, 0x8e, 0xb5, 0xc2, 0x68, 0xab, 0x62, 0xcc, 0x4c, 0x79, 0x79, 0xfe, 0xcc, 0xfe, 0xbb,
+	0xfe, 0xc6, 0x45, 0xd0, 0x3e, 0x01, 0x2e, 0xdf, 0xb3, 0x3f, 0x52, 0x01, 0xe1, 0x01, 0x1c, 0x00,
+	0x00, 0x04, 0x00, 0x48, 0xff, 0xdb, 0x07, 0x18, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x23,
+	0x00, 0x27, 0x00, 0x7b, 0x40, 0x11, 0x18, 0x01, 0x00, 0x02, 0x1b, 0x11, 0x0f, 0x07, 0x04, 0x01,
+	0x00, 0x22, 0x01, 0x04, 0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x06,
+	0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x07, 0x85, 0x00, 0x00, 0x00, 0x02, 0x61, 0x03, 0x01,
+	0x02, 0x02, 0x3e, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x08, 0x05, 0x02, 0x04, 0x04, 0x3f, 0x04,
+	0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x07, 0x85, 0x03,
+	0x01, 0x02, 0x00, 0x00, 0x01, 0x02, 0x00, 0x69, 0x00, 0x01, 0x01, 0x04, 0x61, 0x08, 0x05, 0x02,
+	0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x40, 0x16, 0x24, 0x24, 0x10, 0x10, 0x24, 0x27, 0x24, 0x27,
+	0x26, 0x25, 0x10, 0x23, 0x10, 0x23, 0x25, 0x12, 0x2a, 0x26, 0x21, 0x0a, 0x09, 0x1b, 0x2b, 0x01,
+	0x26, 0x23, 0x22, 0x00, 0x03, 0x06, 0x17, 0x17, 0x16, 0x33, 0x32, 0x00, 0x13, 0x36, 0x27, 0x01,
+	0x37, 0x26, 0x13, 0x12, 0x00, 0x21, 0x20, 0x17, 0x37, 0x33, 0x07, 0x16, 0x03, 0x02, 0x00, 0x21,
+	0x20, 0x27, 0x07, 0x01, 0x01, 0x21, 0x01, 0x05, 0x13, 0x48, 0xa9, 0xb8, 0xfe, 0xf7, 0x36, 0x21,
+	0x13, 0x2f, 0x4a, 0xa7, 0xb9, 0x01, 0x09, 0x36, 0x21, 0x13, 0xfb, 0x05, 0xdf, 0x85, 0x41, 0x4a,
+	0x01, 0xcf, 0x01, 0x53, 0x01, 0x07, 0x8d, 0x77, 0xbe, 0xdf, 0x86, 0x43, 0x49, 0xfe, 0x30, 0xfe,
+	0xae, 0xfe, 0xfa, 0x8e, 0x77, 0x02, 0x8e, 0x01, 0x32, 0x01, 0x19, 0xfe, 0x7e, 0x04, 0xa6, 0x7c,
+	0xfe, 0xd3, 0xfe, 0xf0, 0xa5, 0x90, 0x8e, 0x7b, 0x01, 0x2c, 0x01, 0x0f, 0xa5, 0x92, 0xfb, 0xc2,
+	0xdf, 0xe2, 0x01, 0x48, 0x01, 0x6e, 0x01, 0x9b, 0x77, 0x77, 0xdf, 0xdf, 0xfe, 0xb5, 0xfe, 0x92,
+	0xfe, 0x65, 0x78, 0x78, 0x06, 0x73, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x04, 0x00, 0x45,
+	0xff, 0xe7, 0x05, 0x79, 0x06, 0x44, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x27, 0x00, 0x93,
+	0x40, 0x13, 0x0f, 0x0c, 0x02, 0x05, 0x02, 0x22, 0x21, 0x1a, 0x19, 0x04, 0x04, 0x05, 0x05, 0x02,
+	0x02, 0x00, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x28, 0x0b, 0x01, 0x07, 0x06,
+	0x02, 0x06, 0x07, 0x02, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x02, 0x61,
+	0x03, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x00, 0x61, 0x01, 0x08, 0x02, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0b, 0x01, 0x07, 0x02,
+	0x07, 0x85, 0x0a, 0x01, 0x05, 0x05, 0x02, 0x61, 0x03, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x09, 0x01,
+	0x04, 0x04, 0x00, 0x61, 0x01, 0x08, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x24,
+	0x24, 0x1d, 0x1c, 0x15, 0x14, 0x01, 0x00, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25, 0x1c, 0x23, 0x1d,
+	0x23, 0x14, 0x1b, 0x15, 0x1b, 0x0e, 0x0d, 0x0b, 0x09, 0x04, 0x03, 0x00, 0x13, 0x01, 0x13, 0x0c,
+	0x09, 0x16, 0x2b, 0x05, 0x22, 0x27, 0x07, 0x23, 0x37, 0x26, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x37, 0x33, 0x07, 0x16, 0x07, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x27, 0x01, 0x16, 0x01,
+	0x22, 0x06, 0x07, 0x06, 0x17, 0x01, 0x26, 0x03, 0x01, 0x21, 0x01, 0x02, 0x66, 0xb1, 0x6f, 0x52,
+	0xaf, 0xaa, 0x6a, 0x32, 0x35, 0x01, 0x6a, 0xfb, 0xb6, 0x71, 0x52, 0xaf, 0xac, 0x6b, 0x31, 0x35,
+	0xfe, 0x95, 0xd9, 0x7c, 0xb8, 0x25, 0x15, 0x0a, 0xfe, 0x06, 0x33, 0x01, 0x01, 0x79, 0xb8, 0x24,
+	0x15, 0x0a, 0x01, 0xfa, 0x36, 0xe8, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x19, 0x51, 0x51, 0xaa,
+	0x9b, 0xf9, 0x01, 0x06, 0x01, 0x38, 0x52, 0x52, 0xaa, 0x9a, 0xf8, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9,
+	0xd1, 0xb6, 0x65, 0x53, 0xfe, 0x0b, 0x4a, 0x03, 0x0a, 0xd2, 0xb3, 0x66, 0x55, 0x01, 0xf6, 0x4a,
+	0x01, 0x59, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x68, 0xfe, 0x50, 0x05, 0xc6,
+	0x05, 0xed, 0x00, 0x23, 0x00, 0x32, 0x00, 0x7a, 0x40, 0x13, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01,
+	0x02, 0x00, 0x02, 0x2c, 0x01, 0x06, 0x07, 0x2b, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x27, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x01,
+	0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x59, 0x40, 0x0b, 0x12, 0x23, 0x23, 0x12, 0x2c, 0x23, 0x29, 0x22, 0x08, 0x09,
+	0x1e, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02, 0x26, 0x26, 0x37, 0x12, 0x21,
+	0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x07, 0x06,
+	0x04, 0x21, 0x22, 0x27, 0x05, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32,
+	0x37, 0x36, 0x27, 0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54, 0x27, 0x1a, 0x76, 0x7f, 0x97, 0xf1,
+	0x8c, 0x21, 0x53, 0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf, 0xb5, 0x9b, 0x14, 0x0c, 0x36, 0x5a,
+	0x69, 0x9d, 0xe5, 0x96, 0x21, 0x2f, 0xfe, 0x7c, 0xfe, 0x8d, 0x8b, 0xa9, 0x01, 0x2e, 0x01, 0x6b,
+	0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0x0d, 0xfc, 0x63,
+	0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e,
+	0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x56, 0xab, 0x44, 0x60, 0x0d, 0x62,
+	0x06, 0x41, 0x3a, 0x08, 0x00, 0x02, 0x00, 0x82, 0xfe, 0x50, 0x04, 0x8d, 0x04, 0x63, 0x00, 0x1e,
+	0x00, 0x2d, 0x00, 0x48, 0x40, 0x45, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x27,
+	0x01, 0x06, 0x07, 0x26, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x12, 0x23, 0x23, 0x11, 0x29, 0x23, 0x28, 0x22, 0x08, 0x09, 0x1e, 0x2b, 0x37, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x17, 0x20, 0x07, 0x06, 0x06,
+	0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x82, 0x2b, 0xd3, 0x9d, 0xdd, 0x18,
+	0x0f, 0xa1, 0x5c, 0xbc, 0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0, 0x28, 0xd1, 0x66, 0xcf, 0x16,
+	0x0e, 0x95, 0x4f, 0xcc, 0x78, 0x18, 0x20, 0xfe, 0xc9, 0xe8, 0xcc, 0x74, 0x01, 0x6b, 0x22, 0x0e,
+	0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0x24, 0xd8, 0x5c, 0x78, 0x49,
+	0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d,
+	0x7c, 0x9c, 0xb9, 0x48, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x27, 0xfe, 0x50, 0x05, 0xe3, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x16, 0x00, 0x74,
+	0x40, 0x0a, 0x10, 0x01, 0x06, 0x07, 0x0f, 0x01, 0x05, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x02, 0x01, 0x00, 0x00, 0x01,
+	0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x67, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x08, 0x01, 0x03, 0x03, 0x3c,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x16, 0x15, 0x13, 0x11, 0x0e, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x05, 0x20, 0x07, 0x06,
+	0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x01, 0xd8, 0xff, 0xfe, 0x50,
+	0x28, 0x04, 0x94, 0x28, 0xfe, 0x50, 0xff, 0xfe, 0xd0, 0x01, 0x6b, 0x22, 0x0e, 0xa0, 0x64, 0x52,
+	0x6f, 0x13, 0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0x04, 0xfd, 0xcb, 0xcb, 0xfb, 0x03, 0x61, 0xab,
+	0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x02, 0x00, 0x80, 0xfe, 0x50, 0x03, 0x77,
+	0x05, 0x43, 0x00, 0x14, 0x00, 0x23, 0x00, 0x48, 0x40, 0x45, 0x1d, 0x01, 0x08, 0x09, 0x1c, 0x01,
+	0x07, 0x08, 0x02, 0x4c, 0x0b, 0x01, 0x02, 0x4a, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69,
+	0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x43, 0x07,
+	0x4e, 0x23, 0x22, 0x23, 0x23, 0x11, 0x23, 0x11, 0x13, 0x11, 0x12, 0x22, 0x0a, 0x09, 0x1f, 0x2b,
+	0x25, 0x07, 0x06, 0x23, 0x20, 0x13, 0x13, 0x23, 0x37, 0x33, 0x37, 0x25, 0x07, 0x33, 0x07, 0x23,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x01, 0x20, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0x02, 0xbe, 0x25, 0x77, 0x4c, 0xfe, 0xc7, 0x47, 0x74, 0x78, 0x25, 0x78,
+	0x2b, 0x01, 0x2f, 0x32, 0xd2, 0x25, 0xd2, 0x6c, 0x19, 0x1b, 0x42, 0x28, 0xfe, 0x8a, 0x01, 0x6b,
+	0x22, 0x0e, 0xa0, 0x64, 0x52, 0x6f, 0x13, 0x41, 0x2d, 0x80, 0x0d, 0x0c, 0xa4, 0xba, 0xb9, 0x1a,
+	0x01, 0x68, 0x02, 0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xfd, 0xe5, 0x7c, 0x4d, 0xfe, 0xf2, 0xab,
+	0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x01, 0x00, 0xdc, 0x05, 0x03, 0x03, 0xcf,
+	0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1c, 0x05, 0x01, 0x01, 0x00,
+	0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x03, 0x02, 0x02, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x01, 0x21,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0xdc, 0x01, 0x31, 0x01, 0x11, 0xb1, 0xb3, 0x9e, 0x03, 0xec, 0x05,
+	0x03, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x01, 0x01, 0x1c, 0x05, 0x03, 0x04, 0x0f,
+	0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1c, 0x05, 0x01, 0x00, 0x01,
+	0x01, 0x4c, 0x03, 0x02, 0x02, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x01, 0x21,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x04, 0x0f, 0xfe, 0xcf, 0xfe, 0xef, 0xb1, 0xb3, 0x9d, 0x03, 0xed,
+	0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x01, 0x00, 0xd9, 0x05, 0x17, 0x03, 0xdf,
+	0x05, 0xc4, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x37, 0x21, 0x07,
+	0xd9, 0x22, 0x02, 0xe4, 0x22, 0x05, 0x17, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x26,
+	0x05, 0x03, 0x03, 0xf6, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x28, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1d,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x01, 0x03, 0x51, 0x22, 0x11, 0x21, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x01, 0x33,
+	0x94, 0x0d, 0xa5, 0xa3, 0x46, 0x94, 0x2d, 0xe3, 0x91, 0x91, 0x9e, 0x06, 0x44, 0x8e, 0x8e, 0x93,
+	0xae, 0xad, 0x00, 0x00, 0x00, 0x01, 0x01, 0xb7, 0x05, 0x17, 0x03, 0x1a, 0x06, 0x3f, 0x00, 0x03,
+	0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x13, 0x21, 0x03, 0x01, 0xb7, 0x3b, 0x01,
+	0x28, 0x3b, 0x05, 0x17, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x02, 0x01, 0x86, 0x05, 0x03, 0x03, 0x81,
+	0x06, 0xd8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x39, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x02, 0x52, 0x60, 0x6c, 0x14, 0x13, 0xa4,
+	0x62, 0x61, 0x6d, 0x13, 0x14, 0xa4, 0x4d, 0x35, 0x56, 0x0b, 0x0a, 0x3a, 0x33, 0x33, 0x56, 0x0a,
+	0x0a, 0x38, 0x05, 0x03, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33,
+	0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x14, 0xfe, 0x8e, 0x02, 0x18,
+	0x00, 0x00, 0x00, 0x0d, 0x00, 0x4d, 0xb1, 0x06, 0x64, 0x44, 0xb5, 0x07, 0x01, 0x01, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x01, 0x01, 0x00, 0x70, 0x00, 0x01,
+	0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x1b, 0x40,
+	0x15, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02,
+	0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x59, 0xb5, 0x23, 0x23, 0x10, 0x03, 0x09, 0x19, 0x2b, 0xb1,
+	0x06, 0x00, 0x44, 0x21, 0x33, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x37,
+	0x36, 0x01, 0x3f, 0x9e, 0xcc, 0x12, 0x13, 0xa2, 0x55, 0x35, 0x11, 0x5c, 0x70, 0xfe, 0xd9, 0x1f,
+	0x18, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xeb,
+	0x05, 0x0d, 0x03, 0xea, 0x06, 0x4e, 0x00, 0x16, 0x00, 0x2e, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x23,
+	0x00, 0x02, 0x05, 0x00, 0x02, 0x59, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x04, 0x01, 0x00, 0x02, 0x00, 0x52, 0x25, 0x21, 0x11, 0x24, 0x21, 0x10,
+	0x06, 0x09, 0x1c, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x23, 0x12, 0x33, 0x32, 0x1f, 0x02, 0x16,
+	0x33, 0x32, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x01, 0x7f,
+	0x94, 0x40, 0xca, 0x40, 0x35, 0x21, 0x1b, 0x37, 0x1b, 0x43, 0x1b, 0x94, 0x40, 0xc9, 0x40, 0x36,
+	0x21, 0x14, 0x07, 0x34, 0x1d, 0x44, 0x05, 0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe,
+	0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x00, 0x00, 0x02, 0x00, 0xae, 0x05, 0x03, 0x04, 0x3c,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01,
+	0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x01, 0x33, 0x01, 0x33, 0x01,
+	0x33, 0x01, 0xae, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xe5, 0x01, 0x30, 0xe5, 0xfe, 0x7f, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x90, 0xfe, 0xa2, 0x02, 0xf7,
+	0x04, 0x63, 0x00, 0x03, 0x00, 0x0d, 0x00, 0xa5, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x20, 0x06,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x29, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x2d, 0x04, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x06, 0x01,
+	0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x29, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x06, 0x01,
+	0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00, 0x06, 0x01, 0x01, 0x03,
+	0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0c, 0x0b, 0x0a, 0x09,
+	0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x08, 0x17, 0x2b, 0x01, 0x13, 0x21,
+	0x03, 0x01, 0x23, 0x13, 0x21, 0x07, 0x02, 0x21, 0x37, 0x32, 0x37, 0x01, 0x76, 0x40, 0x01, 0x41,
+	0x40, 0xfe, 0x9a, 0x7b, 0x40, 0x01, 0x41, 0x32, 0x54, 0xfe, 0xbf, 0x16, 0x7b, 0x29, 0x03, 0x22,
+	0x01, 0x41, 0xfe, 0xbf, 0xfc, 0xde, 0x01, 0x41, 0xf9, 0xfe, 0x5a, 0x6f, 0xcf, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x76, 0x05, 0x03, 0x03, 0xae, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x1f, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x08, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x01, 0x21,
+	0x01, 0x01, 0x76, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00,
+	0x00, 0x03, 0x01, 0x0f, 0x05, 0x0d, 0x04, 0xc0, 0x07, 0x1f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x48, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x3d, 0x00, 0x04, 0x00, 0x04, 0x85, 0x08, 0x01, 0x05,
+	0x00, 0x01, 0x00, 0x05, 0x01, 0x80, 0x02, 0x01, 0x00, 0x05, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x60, 0x07, 0x03, 0x06, 0x03, 0x01, 0x00, 0x01, 0x50, 0x08, 0x08, 0x04, 0x04, 0x00,
+	0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x33, 0x07, 0x21, 0x37,
+	0x33, 0x07, 0x25, 0x01, 0x21, 0x01, 0x01, 0x0f, 0x2c, 0xde, 0x2c, 0x01, 0xc9, 0x2c, 0xde, 0x2c,
+	0xfd, 0xd2, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x6f, 0x01,
+	0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x06, 0xa6, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6e, 0xb5, 0x0a, 0x01, 0x04, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x00, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x04, 0x00, 0x06,
+	0x04, 0x80, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00,
+	0x00, 0x06, 0x00, 0x85, 0x08, 0x01, 0x06, 0x04, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00,
+	0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x09, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x25,
+	0x01, 0x21, 0x01, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c, 0xe5,
+	0x01, 0x59, 0x01, 0xcc, 0x70, 0xfd, 0x68, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x05, 0xc8, 0xfa,
+	0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x65, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x4f, 0x03, 0x09, 0x02, 0xd0, 0x04, 0x4a, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16,
+	0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x03, 0x08, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x03, 0x01, 0x4f, 0x40, 0x01, 0x41,
+	0x40, 0x03, 0x09, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x01, 0x0a, 0x00, 0x00, 0x07, 0x8f,
+	0x06, 0xa6, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00,
+	0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x09, 0x07, 0x02, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x60, 0x08, 0x01, 0x05,
+	0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x09, 0x07,
+	0x02, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x60, 0x08, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x01, 0x21, 0x01, 0x02, 0x2a, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b,
+	0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xfa, 0x73, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x05,
+	0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x0a, 0x00, 0x00, 0x07, 0xb9, 0x06, 0xa6, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x69,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x06, 0x00, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00,
+	0x01, 0x00, 0x07, 0x01, 0x80, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00,
+	0x00, 0x28, 0x4d, 0x08, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06,
+	0x00, 0x06, 0x85, 0x02, 0x01, 0x00, 0x07, 0x00, 0x85, 0x09, 0x01, 0x07, 0x01, 0x07, 0x85, 0x00,
+	0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x08, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59,
+	0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21,
+	0x01, 0x21, 0x13, 0x21, 0x03, 0x01, 0x01, 0x21, 0x01, 0x02, 0x25, 0x01, 0x27, 0x01, 0x34, 0x78,
+	0x02, 0x05, 0x78, 0x01, 0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0x86, 0xfd, 0xfb, 0x86, 0xfd, 0xb1, 0x01,
+	0x26, 0x01, 0x12, 0xfe, 0x5c, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02, 0xa3, 0xfd,
+	0x5d, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x02, 0x00, 0x6a, 0x00, 0x00, 0x05, 0x45,
+	0x06, 0xa6, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x96, 0x4b, 0xb0, 0x26, 0x50, 0x58, 0x40, 0x1f, 0x00,
+	0x06, 0x02, 0x06, 0x85, 0x09, 0x07, 0x03, 0x03, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28,
+	0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x06, 0x02, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x01,
+	0x02, 0x07, 0x01, 0x80, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00,
+	0x06, 0x02, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x01, 0x02, 0x07, 0x01, 0x80, 0x00, 0x02, 0x03,
+	0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05,
+	0x2c, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e,
+	0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x37,
+	0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0x01, 0x21, 0x01, 0x01, 0x46,
+	0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0xfc, 0x4c, 0x01, 0x26,
+	0x01, 0x12, 0xfe, 0x5c, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x05, 0x03, 0x01, 0xa3,
+	0xfe, 0x5d, 0x00, 0x00, 0x00, 0x03, 0x00, 0xfb, 0xff, 0xdb, 0x07, 0x25, 0x06, 0xa6, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x98, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x01,
+	0x04, 0x85, 0x08, 0x05, 0x02, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x07, 0x01,
+	0x02, 0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x25, 0x00, 0x04, 0x01, 0x04, 0x85, 0x08, 0x01, 0x05, 0x03, 0x02, 0x03, 0x05, 0x02,
+	0x80, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00,
+	0x62, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x08, 0x01, 0x05, 0x03, 0x02, 0x03, 0x05, 0x02, 0x80, 0x00, 0x01, 0x00, 0x03, 0x05, 0x01, 0x03,
+	0x69, 0x07, 0x01, 0x02, 0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x59,
+	0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11,
+	0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x20,
+	0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02,
+	0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x01, 0x21, 0x01, 0x03, 0x6a, 0xfe, 0xb8, 0xfe, 0xd9,
+	0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5,
+	0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0xfe, 0x1c, 0x01,
+	0x26, 0x01, 0x12, 0xfe, 0x5c, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64,
+	0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe,
+	0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x04, 0x5c, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x14, 0x00, 0x00, 0x08, 0x7e, 0x06, 0xa6, 0x00, 0x10, 0x00, 0x14, 0x00, 0xad,
+	0x40, 0x0b, 0x08, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x0b, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x0c,
+	0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x01, 0x01, 0x03, 0x70, 0x06, 0x04, 0x02, 0x00, 0x00, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x01, 0x03, 0x85, 0x06, 0x04, 0x02, 0x00, 0x00, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x03, 0x01, 0x03, 0x85, 0x06, 0x01, 0x04, 0x01, 0x00, 0x01,
+	0x04, 0x00, 0x80, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x05, 0x01, 0x02,
+	0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x03, 0x01, 0x03, 0x85, 0x06, 0x01, 0x04, 0x01,
+	0x00, 0x01, 0x04, 0x00, 0x80, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x6a, 0x05, 0x01, 0x02,
+	0x02, 0x2c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x13, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14, 0x11,
+	0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x11, 0x13, 0x07, 0x08, 0x18, 0x2b, 0x21, 0x13, 0x12,
+	0x02, 0x23, 0x37, 0x20, 0x00, 0x13, 0x12, 0x00, 0x37, 0x07, 0x06, 0x00, 0x03, 0x03, 0x01, 0x01,
+	0x21, 0x01, 0x04, 0x18, 0x57, 0x43, 0xc3, 0xc9, 0x2a, 0x01, 0x24, 0x01, 0x15, 0x0d, 0x90, 0x01,
+	0x91, 0xc7, 0x25, 0xd9, 0xfe, 0x5d, 0x3d, 0x53, 0xfb, 0xc7, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c,
+	0x01, 0xb4, 0x01, 0x53, 0x01, 0xf0, 0xd1, 0xfe, 0xdd, 0xfe, 0xbc, 0x01, 0x0a, 0x01, 0x49, 0x14,
+	0xb9, 0x31, 0xfd, 0xf4, 0xfe, 0xd2, 0xfe, 0x5c, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa9, 0x00, 0x00, 0x07, 0x1b, 0x06, 0xa6, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x9c,
+	0xb4, 0x1e, 0x01, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x01,
+	0x06, 0x85, 0x09, 0x07, 0x02, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01,
+	0x00, 0x00, 0x03, 0x60, 0x08, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x26, 0x00, 0x06, 0x01, 0x06, 0x85, 0x09, 0x01, 0x07, 0x04, 0x00, 0x04, 0x07,
+	0x00, 0x80, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00, 0x00,
+	0x03, 0x60, 0x08, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x06, 0x01,
+	0x06, 0x85, 0x09, 0x01, 0x07, 0x04, 0x00, 0x04, 0x07, 0x00, 0x80, 0x00, 0x01, 0x00, 0x04, 0x07,
+	0x01, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x03, 0x60, 0x08, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03,
+	0x4e, 0x59, 0x59, 0x40, 0x16, 0x20, 0x20, 0x00, 0x00, 0x20, 0x23, 0x20, 0x23, 0x22, 0x21, 0x00,
+	0x1f, 0x00, 0x1f, 0x26, 0x11, 0x15, 0x25, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x21, 0x26,
+	0x02, 0x37, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x06, 0x02, 0x07, 0x21, 0x07, 0x21, 0x37, 0x36,
+	0x12, 0x37, 0x36, 0x02, 0x23, 0x22, 0x00, 0x07, 0x06, 0x12, 0x17, 0x07, 0x01, 0x01, 0x21, 0x01,
+	0xa9, 0x2a, 0x01, 0x76, 0x90, 0x6e, 0x26, 0x3b, 0x01, 0xcd, 0x01, 0x35, 0x01, 0x34, 0x01, 0x39,
+	0x3b, 0x26, 0xea, 0xc8, 0x01, 0x76, 0x2a, 0xfd, 0x95, 0x2a, 0x9a, 0xc9, 0x28, 0x2d, 0x9c, 0xaa,
+	0xab, 0xfe, 0xfc, 0x2d, 0x28, 0x51, 0x6c, 0x2a, 0xfd, 0xec, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c,
+	0xd7, 0x88, 0x01, 0x39, 0xbc, 0x01, 0x27, 0x01, 0x72, 0xfe, 0x8e, 0xfe, 0xd9, 0xbb, 0xfe, 0xc6,
+	0x88, 0xd7, 0xd7, 0x70, 0x01, 0x2e, 0xc9, 0xe1, 0x01, 0x03, 0xfe, 0xfc, 0xe1, 0xc9, 0xfe, 0xd3,
+	0x70, 0xd7, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x04, 0x00, 0xbe, 0xff, 0xe7, 0x04, 0x6f,
+	0x07, 0x1f, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x88, 0xb5, 0x0f, 0x01, 0x02,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x07, 0x03, 0x07, 0x85, 0x0b,
+	0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x0a, 0x06, 0x09, 0x03, 0x04, 0x04, 0x03, 0x5f,
+	0x05, 0x01, 0x03, 0x03, 0x28, 0x4d, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x07, 0x03, 0x07, 0x85, 0x0b, 0x01,
+	0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x05, 0x01, 0x03, 0x0a, 0x06, 0x09, 0x03, 0x04, 0x01,
+	0x03, 0x04, 0x68, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x14, 0x14, 0x10, 0x10, 0x18, 0x1b, 0x18, 0x1b,
+	0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10, 0x13, 0x13, 0x23, 0x15, 0x21,
+	0x0c, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x13, 0x21, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x01, 0x37, 0x33, 0x07, 0x21, 0x37, 0x33, 0x07, 0x25, 0x01, 0x21, 0x01,
+	0x02, 0xe5, 0x80, 0x7a, 0xa0, 0x48, 0x34, 0x0a, 0x24, 0x83, 0x01, 0x28, 0x87, 0x1c, 0x2a, 0x4e,
+	0x42, 0x60, 0xfd, 0xb3, 0x2c, 0xde, 0x2c, 0x01, 0xc9, 0x2c, 0xde, 0x2c, 0xfd, 0xd2, 0x01, 0x26,
+	0x01, 0x12, 0xfe, 0x5c, 0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73,
+	0x2a, 0x04, 0x3a, 0xde, 0xde, 0xde, 0xde, 0x6f, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21,
+	0x03, 0x01, 0x21, 0x03, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c,
+	0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50,
+	0x02, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x68, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x14, 0x00, 0x1d, 0x00, 0x61, 0xb5, 0x06, 0x01, 0x05, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x29,
+	0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x00,
+	0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x2c, 0x01,
+	0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1d, 0x1b, 0x17, 0x15, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b,
+	0x00, 0x0a, 0x21, 0x07, 0x08, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x04, 0x03,
+	0x06, 0x04, 0x23, 0x01, 0x21, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21, 0x03, 0x21, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x21, 0xad, 0x01, 0x27, 0x02, 0xcc, 0x01, 0xc8, 0x42, 0x35, 0xfe, 0x87,
+	0x01, 0x8c, 0x3d, 0x24, 0xfe, 0xe8, 0xe4, 0xfe, 0xd4, 0x01, 0x1e, 0x82, 0xb3, 0x16, 0x14, 0x6c,
+	0xab, 0xfe, 0xed, 0xd6, 0x01, 0x17, 0xc2, 0xa4, 0x16, 0x17, 0xa7, 0x96, 0xfe, 0xef, 0x05, 0xc8,
+	0xfe, 0xb7, 0xfe, 0xf5, 0x6f, 0x64, 0xfe, 0xcd, 0xb1, 0xbd, 0x03, 0x60, 0x81, 0x6d, 0x65, 0x4a,
+	0xfb, 0xd5, 0x53, 0x6d, 0x72, 0x96, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xe2,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x31, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x02,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x00, 0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b, 0x40, 0x0e,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x67, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x4e, 0x59, 0xb5,
+	0x11, 0x11, 0x10, 0x03, 0x08, 0x19, 0x2b, 0x21, 0x21, 0x01, 0x21, 0x07, 0x21, 0x01, 0xe1, 0xfe,
+	0xcc, 0x01, 0x27, 0x04, 0x0e, 0x2c, 0xfd, 0x26, 0x05, 0xc8, 0xdf, 0x00, 0x00, 0x02, 0x00, 0x1e,
+	0x00, 0x00, 0x05, 0xd3, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x08, 0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x60, 0x03, 0x01, 0x01,
+	0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x60, 0x03, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x08, 0x07, 0x00,
+	0x05, 0x00, 0x05, 0x12, 0x04, 0x08, 0x17, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x01, 0x07, 0x01, 0x01,
+	0x21, 0x1e, 0x31, 0x03, 0x34, 0x01, 0x06, 0x01, 0x4a, 0x31, 0xfd, 0xf2, 0xfd, 0xa8, 0x03, 0x48,
+	0xf7, 0x04, 0xd1, 0xfb, 0x2f, 0xf7, 0x04, 0x84, 0xfc, 0x73, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x12, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b,
+	0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0xad, 0x01, 0x27, 0x04,
+	0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0x05, 0xc8,
+	0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x00, 0x00, 0x01, 0x00, 0x5e, 0x00, 0x00, 0x05, 0xad,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x44, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21,
+	0x07, 0x01, 0x21, 0x07, 0x5e, 0x29, 0x03, 0x98, 0xfd, 0x69, 0x28, 0x03, 0xfd, 0x28, 0xfc, 0x68,
+	0x02, 0xc2, 0x29, 0xd2, 0x04, 0x2b, 0xcb, 0xcb, 0xfb, 0xd5, 0xd2, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x41, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x06,
+	0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85,
+	0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08,
+	0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21, 0x01, 0x21, 0x13, 0x21, 0x03, 0xad, 0x01,
+	0x27, 0x01, 0x34, 0x78, 0x02, 0x05, 0x78, 0x01, 0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0x86, 0xfd, 0xfb,
+	0x86, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02, 0xa3, 0xfd, 0x5d, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x67, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04,
+	0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x07, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x01, 0x00, 0x03,
+	0x04, 0x01, 0x03, 0x69, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d,
+	0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21,
+	0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02,
+	0x12, 0x13, 0x37, 0x21, 0x07, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01,
+	0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36,
+	0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x38, 0x28, 0x01, 0xcc, 0x28, 0x25, 0x01, 0xa1,
+	0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01,
+	0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x01,
+	0xf3, 0xcc, 0xcc, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x04, 0x63, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05,
+	0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13,
+	0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x64, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8,
+	0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x63, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09,
+	0x06, 0x03, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01,
+	0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01,
+	0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c,
+	0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21,
+	0x03, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x03, 0xad, 0x01, 0x27, 0x01, 0x28, 0x8f, 0x02, 0xf7,
+	0xff, 0xfd, 0x4a, 0x02, 0x0b, 0xfe, 0x7f, 0xfe, 0x2f, 0x91, 0x05, 0xc8, 0xfd, 0x32, 0x02, 0xce,
+	0xfd, 0x68, 0xfc, 0xd0, 0x02, 0xd8, 0xfd, 0x28, 0x00, 0x01, 0x00, 0x0e, 0x00, 0x00, 0x05, 0x48,
+	0x05, 0xc8, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x0d, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x03, 0x02, 0x02, 0x00, 0x00, 0x29, 0x00,
+	0x4e, 0x1b, 0x40, 0x0d, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x03, 0x02, 0x02, 0x00, 0x00, 0x2c, 0x00,
+	0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x11, 0x12, 0x04, 0x08, 0x18, 0x2b,
+	0x21, 0x03, 0x01, 0x23, 0x01, 0x21, 0x13, 0x04, 0x03, 0xa3, 0xfd, 0x9b, 0xed, 0x03, 0x29, 0x01,
+	0x3a, 0xd7, 0x04, 0x6e, 0xfb, 0x92, 0x05, 0xc8, 0xfa, 0x38, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x07, 0x25, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4b, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x01,
+	0x01, 0x00, 0x00, 0x28, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x14,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x57, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02,
+	0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12,
+	0x11, 0x06, 0x08, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x21, 0x13, 0x01, 0x23,
+	0x03, 0x03, 0xad, 0x01, 0x27, 0x01, 0x98, 0x54, 0x01, 0xff, 0x01, 0x66, 0xfe, 0xd9, 0xfe, 0xe4,
+	0xdf, 0xfe, 0x0b, 0xf8, 0x53, 0xe2, 0x05, 0xc8, 0xfb, 0xef, 0x04, 0x11, 0xfa, 0x38, 0x04, 0x5d,
+	0xfc, 0x06, 0x04, 0x09, 0xfb, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x41,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02,
+	0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11,
+	0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x13, 0x33, 0x01, 0x21, 0x01, 0x03, 0xad, 0x01,
+	0x27, 0x01, 0x0f, 0x01, 0x9d, 0xca, 0xf7, 0xfe, 0xd9, 0xfe, 0xed, 0xfe, 0x67, 0xca, 0x05, 0xc8,
+	0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x00, 0x00, 0x00, 0x03, 0x00, 0x28,
+	0x00, 0x00, 0x05, 0xed, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x66, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x08, 0x01,
+	0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x28, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01,
+	0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05,
+	0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06,
+	0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08,
+	0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x09, 0x08, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x01, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07,
+	0x28, 0x33, 0x04, 0xd6, 0x33, 0xfc, 0x44, 0x30, 0x03, 0xa2, 0x30, 0xfc, 0x71, 0x32, 0x04, 0x66,
+	0x32, 0x01, 0x04, 0xfe, 0xfc, 0x02, 0x82, 0xf0, 0xf0, 0x02, 0x4c, 0xfa, 0xfa, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x03, 0x0a, 0xfe, 0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01,
+	0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe, 0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36,
+	0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01,
+	0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d,
+	0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x41, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01,
+	0x29, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03,
+	0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x13, 0x21, 0x03, 0xad, 0x01,
+	0x27, 0x04, 0x6d, 0xfe, 0xd9, 0xfe, 0xcc, 0xff, 0xfd, 0xfb, 0xff, 0x05, 0xc8, 0xfa, 0x38, 0x04,
+	0xfd, 0xfb, 0x03, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x16, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02,
+	0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x05, 0x01, 0x02,
+	0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00,
+	0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40,
+	0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x08, 0x18,
+	0x2b, 0x33, 0x01, 0x21, 0x32, 0x16, 0x17, 0x16, 0x07, 0x02, 0x21, 0x23, 0x03, 0x13, 0x33, 0x20,
+	0x13, 0x36, 0x26, 0x23, 0x23, 0xad, 0x01, 0x27, 0x02, 0x5a, 0xbd, 0xb1, 0x33, 0x47, 0x23, 0x66,
+	0xfd, 0x97, 0xd6, 0x73, 0x9c, 0x92, 0x01, 0x72, 0x37, 0x18, 0x7e, 0xa5, 0xcd, 0x05, 0xc8, 0x2f,
+	0x46, 0x61, 0xb3, 0xfe, 0x05, 0xfd, 0xbc, 0x03, 0x0f, 0x01, 0x12, 0x7a, 0x62, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x46, 0x00, 0x00, 0x05, 0xb8, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x52, 0x40, 0x0c,
+	0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x03, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x16, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x08, 0x19, 0x2b,
+	0x33, 0x37, 0x01, 0x01, 0x37, 0x21, 0x07, 0x21, 0x01, 0x01, 0x21, 0x07, 0x46, 0x30, 0x02, 0x3c,
+	0xfe, 0xc4, 0x28, 0x04, 0x1a, 0x28, 0xfd, 0x6e, 0x01, 0x21, 0xfd, 0x8f, 0x03, 0x1e, 0x31, 0xf4,
+	0x01, 0xe3, 0x02, 0x26, 0xcb, 0xcb, 0xfe, 0x06, 0xfd, 0xf4, 0xf7, 0x00, 0x00, 0x01, 0x01, 0x25,
+	0x00, 0x00, 0x05, 0xe3, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04,
+	0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x01, 0xd8,
+	0xfd, 0xfe, 0x50, 0x2a, 0x04, 0x94, 0x2a, 0xfe, 0x50, 0xfd, 0x04, 0xf3, 0xd5, 0xd5, 0xfb, 0x0d,
+	0x00, 0x01, 0x01, 0x11, 0x00, 0x00, 0x06, 0x69, 0x05, 0xc8, 0x00, 0x10, 0x00, 0x45, 0x40, 0x0a,
+	0x08, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x0b, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x11, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29,
+	0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x03, 0x01, 0x02,
+	0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x11, 0x13, 0x04,
+	0x08, 0x18, 0x2b, 0x21, 0x13, 0x12, 0x02, 0x23, 0x37, 0x20, 0x00, 0x13, 0x12, 0x00, 0x37, 0x07,
+	0x06, 0x00, 0x03, 0x03, 0x02, 0x03, 0x57, 0x43, 0xc3, 0xc9, 0x2a, 0x01, 0x24, 0x01, 0x15, 0x0d,
+	0x90, 0x01, 0x91, 0xc7, 0x25, 0xd9, 0xfe, 0x5d, 0x3d, 0x53, 0x01, 0xb4, 0x01, 0x53, 0x01, 0xf0,
+	0xd1, 0xfe, 0xdd, 0xfe, 0xbc, 0x01, 0x0a, 0x01, 0x49, 0x14, 0xb9, 0x31, 0xfd, 0xf4, 0xfe, 0xd2,
+	0xfe, 0x5c, 0x00, 0x00, 0x00, 0x03, 0x00, 0xce, 0x00, 0x00, 0x06, 0xea, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x18, 0x00, 0x1f, 0x00, 0x50, 0xb6, 0x1a, 0x18, 0x02, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x16, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x6a, 0x00,
+	0x02, 0x02, 0x28, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02,
+	0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x6a, 0x06, 0x01, 0x05,
+	0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x14, 0x11, 0x11,
+	0x14, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x21, 0x37, 0x24, 0x00, 0x37, 0x36, 0x00, 0x25, 0x37, 0x21,
+	0x07, 0x04, 0x00, 0x07, 0x06, 0x00, 0x05, 0x07, 0x03, 0x06, 0x06, 0x07, 0x06, 0x16, 0x17, 0x01,
+	0x03, 0x36, 0x36, 0x37, 0x36, 0x26, 0x02, 0xc2, 0x28, 0xfe, 0xec, 0xfe, 0xf8, 0x2e, 0x2f, 0x01,
+	0x7d, 0x01, 0x19, 0x28, 0x01, 0x0e, 0x28, 0x01, 0x09, 0x01, 0x12, 0x2f, 0x2e, 0xfe, 0x83, 0xfe,
+	0xe8, 0x28, 0x38, 0xa8, 0xc8, 0x1e, 0x1e, 0x83, 0xa4, 0x01, 0x93, 0x85, 0xa8, 0xc6, 0x1e, 0x1e,
+	0x81, 0xca, 0x0c, 0x01, 0x26, 0xe8, 0xe9, 0x01, 0x25, 0x0c, 0xca, 0xca, 0x0c, 0xfe, 0xdb, 0xe9,
+	0xe8, 0xfe, 0xda, 0x0c, 0xca, 0x04, 0x33, 0x0d, 0xad, 0x95, 0x96, 0xac, 0x0c, 0x02, 0x9d, 0xfd,
+	0x63, 0x0c, 0xac, 0x96, 0x95, 0xad, 0x00, 0x00, 0x00, 0x01, 0x00, 0x31, 0x00, 0x00, 0x06, 0x40,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03,
+	0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x12, 0x12, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01,
+	0x21, 0x03, 0x01, 0x31, 0x02, 0x6b, 0xfe, 0xd1, 0x01, 0x67, 0xca, 0x01, 0xa9, 0xf9, 0xfd, 0xaf,
+	0x01, 0x3a, 0xfe, 0x9a, 0xd8, 0xfe, 0x3f, 0x02, 0xd9, 0x02, 0xef, 0xfe, 0x0e, 0x01, 0xf2, 0xfd,
+	0x46, 0xfc, 0xf2, 0x02, 0x11, 0xfd, 0xef, 0x00, 0x00, 0x01, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x50,
+	0x05, 0xc8, 0x00, 0x27, 0x00, 0x4e, 0xb7, 0x15, 0x12, 0x01, 0x03, 0x05, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x14, 0x04, 0x01, 0x00, 0x00, 0x01, 0x61, 0x03, 0x02, 0x02, 0x01,
+	0x01, 0x28, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x15, 0x04, 0x01, 0x00,
+	0x05, 0x01, 0x00, 0x59, 0x03, 0x02, 0x02, 0x01, 0x01, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c,
+	0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x27, 0x00, 0x27, 0x22, 0x17, 0x17, 0x22, 0x17,
+	0x07, 0x08, 0x1b, 0x2b, 0x21, 0x13, 0x26, 0x26, 0x37, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33,
+	0x32, 0x16, 0x07, 0x07, 0x06, 0x16, 0x17, 0x13, 0x21, 0x03, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36,
+	0x33, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x03, 0x02, 0xa2, 0x72, 0xd1,
+	0xa1, 0x0b, 0x04, 0x06, 0x25, 0x36, 0x0d, 0x28, 0x13, 0xb3, 0x85, 0x04, 0x01, 0x03, 0x31, 0x50,
+	0x8e, 0x01, 0x35, 0x8e, 0x54, 0x5d, 0x39, 0x25, 0x50, 0xbb, 0xb3, 0x13, 0x28, 0x0d, 0x36, 0x43,
+	0x2a, 0x20, 0x4b, 0xea, 0xda, 0x72, 0x02, 0x3f, 0x17, 0xb5, 0xd5, 0x5b, 0x78, 0x4a, 0xcb, 0x89,
+	0xd1, 0x60, 0x95, 0x71, 0x0a, 0x02, 0xca, 0xfd, 0x36, 0x0a, 0x71, 0x95, 0x60, 0xd1, 0x89, 0xcb,
+	0x4a, 0x78, 0x5b, 0xd5, 0xb5, 0x17, 0xfd, 0xc1, 0x00, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x06, 0xd1,
+	0x05, 0xed, 0x00, 0x1f, 0x00, 0x50, 0xb4, 0x1e, 0x01, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x18, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00,
+	0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01,
+	0x00, 0x04, 0x00, 0x01, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03,
+	0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f, 0x26, 0x11, 0x15,
+	0x25, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x21, 0x26, 0x02, 0x37, 0x12, 0x00, 0x21, 0x20,
+	0x00, 0x03, 0x06, 0x02, 0x07, 0x21, 0x07, 0x21, 0x37, 0x36, 0x12, 0x37, 0x36, 0x02, 0x23, 0x22,
+	0x00, 0x07, 0x06, 0x12, 0x17, 0x07, 0x5f, 0x2a, 0x01, 0x76, 0x90, 0x6e, 0x26, 0x3b, 0x01, 0xcd,
+	0x01, 0x35, 0x01, 0x34, 0x01, 0x39, 0x3b, 0x26, 0xea, 0xc8, 0x01, 0x76, 0x2a, 0xfd, 0x95, 0x2a,
+	0x9a, 0xc9, 0x28, 0x2d, 0x9c, 0xaa, 0xab, 0xfe, 0xfc, 0x2d, 0x28, 0x51, 0x6c, 0x2a, 0xd7, 0x88,
+	0x01, 0x39, 0xbc, 0x01, 0x27, 0x01, 0x72, 0xfe, 0x8e, 0xfe, 0xd9, 0xbb, 0xfe, 0xc6, 0x88, 0xd7,
+	0xd7, 0x70, 0x01, 0x2e, 0xc9, 0xe1, 0x01, 0x03, 0xfe, 0xfc, 0xe1, 0xc9, 0xfe, 0xd3, 0x70, 0xd7,
+	0x00, 0x03, 0x00, 0x64, 0x00, 0x00, 0x04, 0x78, 0x07, 0x40, 0x00, 0x03, 0x00, 0x07, 0x00, 0x13,
+	0x00, 0x76, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03,
+	0x01, 0x06, 0x00, 0x01, 0x67, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x28, 0x4d,
+	0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x29, 0x09, 0x4e, 0x1b, 0x40, 0x22,
+	0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67, 0x00, 0x06, 0x07, 0x01,
+	0x05, 0x04, 0x06, 0x05, 0x67, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x2c,
+	0x09, 0x4e, 0x59, 0x40, 0x22, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12,
+	0x11, 0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x0d, 0x08, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0x01, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0xb6, 0x2c, 0xde,
+	0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0xfc, 0x18, 0x27, 0xd2, 0xd6, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2,
+	0xd6, 0xd2, 0x27, 0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0xf9, 0x9e, 0xc8, 0x04, 0x2e, 0xd2, 0xd2,
+	0xfb, 0xd2, 0xc8, 0x00, 0x00, 0x03, 0x01, 0x11, 0x00, 0x00, 0x06, 0x69, 0x07, 0x40, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x6e, 0x40, 0x0b, 0x08, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x0b, 0x01,
+	0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08,
+	0x03, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d,
+	0x07, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08,
+	0x03, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x07, 0x01,
+	0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18,
+	0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x11, 0x13,
+	0x0a, 0x08, 0x18, 0x2b, 0x21, 0x13, 0x12, 0x02, 0x23, 0x37, 0x20, 0x00, 0x13, 0x12, 0x00, 0x37,
+	0x07, 0x06, 0x00, 0x0b, 0x02, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x02, 0x03, 0x57, 0x43,
+	0xc3, 0xc9, 0x2a, 0x01, 0x24, 0x01, 0x15, 0x0d, 0x90, 0x01, 0x91, 0xc7, 0x25, 0xd9, 0xfe, 0x5d,
+	0x3d, 0x53, 0x8d, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0x01, 0xb4, 0x01, 0x53, 0x01, 0xf0,
+	0xd1, 0xfe, 0xdd, 0xfe, 0xbc, 0x01, 0x0a, 0x01, 0x49, 0x14, 0xb9, 0x31, 0xfd, 0xf4, 0xfe, 0xd2,
+	0xfe, 0x5c, 0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0x00, 0x03, 0x00, 0x99, 0xff, 0xe9, 0x05, 0x92,
+	0x06, 0xa6, 0x00, 0x03, 0x00, 0x2e, 0x00, 0x43, 0x00, 0xb5, 0x40, 0x09, 0x43, 0x29, 0x20, 0x09,
+	0x04, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00, 0x06, 0x06, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x31, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x09, 0x05, 0x02, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03,
+	0x01, 0x85, 0x00, 0x04, 0x04, 0x2b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31,
+	0x4d, 0x09, 0x01, 0x05, 0x05, 0x29, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32,
+	0x02, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85,
+	0x00, 0x04, 0x04, 0x2b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x09,
+	0x01, 0x05, 0x05, 0x2c, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x3f, 0x3d, 0x35, 0x33, 0x04, 0x2e, 0x04, 0x2e,
+	0x26, 0x25, 0x1b, 0x19, 0x0f, 0x0d, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x08, 0x17, 0x2b, 0x01,
+	0x01, 0x21, 0x01, 0x13, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x26, 0x36, 0x37, 0x3e,
+	0x05, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x17, 0x3e, 0x03, 0x37, 0x33, 0x06, 0x02, 0x07, 0x1e, 0x03,
+	0x17, 0x01, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x06, 0x06, 0x16, 0x16, 0x33, 0x32, 0x3e,
+	0x02, 0x37, 0x02, 0xf9, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x33, 0x08, 0x0d, 0x10, 0x13, 0x0a,
+	0x25, 0x58, 0x70, 0x88, 0x53, 0x69, 0x7b, 0x39, 0x01, 0x15, 0x0d, 0x2d, 0x43, 0x58, 0x71, 0x8b,
+	0x54, 0x4c, 0x63, 0x3f, 0x27, 0x0e, 0x1b, 0x11, 0x27, 0x29, 0x24, 0x0c, 0xef, 0x3d, 0xaf, 0x64,
+	0x0a, 0x1e, 0x23, 0x22, 0x0f, 0xfe, 0x82, 0x0f, 0x16, 0x1c, 0x28, 0x21, 0x2b, 0x43, 0x32, 0x25,
+	0x0d, 0x0b, 0x10, 0x06, 0x24, 0x29, 0x2d, 0x5c, 0x54, 0x47, 0x19, 0x05, 0x03, 0x01, 0xa3, 0xfe,
+	0x5d, 0xfa, 0xfd, 0x18, 0x43, 0x4e, 0x56, 0x2a, 0x34, 0x71, 0x5e, 0x3d, 0x59, 0x94, 0xbf, 0x66,
+	0x44, 0x91, 0x88, 0x7a, 0x5c, 0x35, 0x2d, 0x50, 0x6b, 0x3f, 0x72, 0x20, 0x59, 0x66, 0x6d, 0x34,
+	0xa9, 0xfe, 0xd9, 0x89, 0x33, 0x7c, 0x83, 0x83, 0x3a, 0x02, 0x41, 0x44, 0x75, 0x55, 0x31, 0x49,
+	0x71, 0x8b, 0x42, 0x36, 0x72, 0x5d, 0x3c, 0x3a, 0x55, 0x62, 0x29, 0x00, 0x00, 0x02, 0x00, 0x64,
+	0xff, 0xe7, 0x04, 0xeb, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x22, 0x00, 0x4f, 0x40, 0x4c, 0x13, 0x01,
+	0x04, 0x03, 0x14, 0x01, 0x05, 0x04, 0x0c, 0x01, 0x06, 0x05, 0x03, 0x4c, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00,
+	0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x32, 0x02, 0x4e, 0x00, 0x00, 0x22, 0x20, 0x1e, 0x1c, 0x1b, 0x19, 0x17, 0x15, 0x12, 0x10,
+	0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x13,
+	0x07, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x26, 0x37, 0x36, 0x24, 0x33, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x22, 0x07, 0x06, 0x21, 0x33, 0x07, 0x23, 0x20, 0x07, 0x06, 0x33, 0x32, 0x02, 0xb3,
+	0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x6d, 0x26, 0xe3, 0xac, 0xc7, 0xd4, 0x1c, 0x28, 0x01, 0x34,
+	0xec, 0x24, 0x1a, 0x01, 0x16, 0xd8, 0x9b, 0x8a, 0x25, 0x8a, 0x7b, 0xc8, 0x17, 0x1d, 0x01, 0x49,
+	0x33, 0x25, 0x6a, 0xfe, 0xd5, 0x23, 0x1f, 0xcb, 0x79, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfb,
+	0xec, 0xbf, 0x49, 0xac, 0x8b, 0xcb, 0x6d, 0x3f, 0xb7, 0x82, 0x95, 0x1d, 0xb8, 0x1d, 0x76, 0x8d,
+	0xb9, 0xb2, 0x9b, 0x00, 0x00, 0x02, 0x00, 0x82, 0xfe, 0x75, 0x05, 0x16, 0x06, 0xa6, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0xa1, 0xb5, 0x06, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02,
+	0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x05,
+	0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d,
+	0x02, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85,
+	0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07,
+	0x01, 0x04, 0x04, 0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x15,
+	0x15, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x00, 0x14, 0x00, 0x14, 0x23, 0x13, 0x23,
+	0x13, 0x09, 0x08, 0x1a, 0x2b, 0x33, 0x13, 0x36, 0x27, 0x21, 0x16, 0x07, 0x36, 0x33, 0x32, 0x16,
+	0x07, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01, 0x21, 0x01, 0x82, 0x99,
+	0x26, 0x25, 0x01, 0x40, 0x07, 0x08, 0xd0, 0xd5, 0x9e, 0x78, 0x27, 0xe2, 0xfe, 0xd8, 0xd8, 0x13,
+	0x30, 0x44, 0x88, 0xa0, 0x8a, 0x01, 0x1d, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x03, 0x01, 0xbe,
+	0x8b, 0x4d, 0x83, 0xe9, 0xc0, 0xbf, 0xfb, 0x91, 0x04, 0x3b, 0x61, 0x61, 0xbc, 0xfd, 0x4a, 0x05,
+	0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc5, 0xff, 0xe7, 0x03, 0xfb,
+	0x06, 0xa6, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x31, 0x40, 0x2e, 0x0f, 0x01, 0x02, 0x01, 0x01, 0x4c,
+	0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x10, 0x10, 0x10, 0x13, 0x10,
+	0x13, 0x13, 0x23, 0x15, 0x21, 0x06, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26,
+	0x37, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x01, 0x21, 0x01, 0x02, 0xe5, 0x80,
+	0x7a, 0xa0, 0x48, 0x34, 0x0a, 0x24, 0x83, 0x01, 0x28, 0x87, 0x1c, 0x2a, 0x4e, 0x42, 0x60, 0xfe,
+	0xb8, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd,
+	0x5e, 0x8c, 0x73, 0x2a, 0x04, 0x30, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x04, 0x00, 0xcf,
+	0xff, 0xe7, 0x05, 0x2e, 0x07, 0x1f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x84,
+	0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x08, 0x04, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04,
+	0x05, 0x04, 0x09, 0x05, 0x80, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04,
+	0x04, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x32, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x08, 0x04, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04,
+	0x05, 0x04, 0x09, 0x05, 0x80, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05,
+	0x68, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32,
+	0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e, 0x21, 0x1e, 0x21, 0x20,
+	0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14, 0x23, 0x10,
+	0x0d, 0x08, 0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03, 0x21,
+	0x12, 0x03, 0x02, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x13, 0x37, 0x33, 0x07, 0x21, 0x37,
+	0x33, 0x07, 0x25, 0x01, 0x21, 0x01, 0x01, 0x62, 0x01, 0x28, 0x6a, 0x2f, 0x3e, 0x6d, 0x72, 0x94,
+	0x16, 0x3e, 0x84, 0x01, 0x34, 0x32, 0x34, 0x36, 0xfe, 0xb9, 0xde, 0xc3, 0x67, 0x41, 0x0f, 0x2b,
+	0x83, 0x2c, 0xde, 0x2c, 0x01, 0xc9, 0x2c, 0xde, 0x2c, 0xfd, 0xd2, 0x01, 0x26, 0x01, 0x12, 0xfe,
+	0x5c, 0x04, 0x4a, 0xfd, 0xf0, 0xed, 0xad, 0xb6, 0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe,
+	0xf9, 0xfe, 0xf5, 0xfe, 0xc5, 0x76, 0x4a, 0xc5, 0xd6, 0x02, 0xcb, 0xde, 0xde, 0xde, 0xde, 0x6f,
+	0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0x99, 0xff, 0xe9, 0x05, 0x92, 0x04, 0x63, 0x00, 0x2a,
+	0x00, 0x3f, 0x00, 0x8a, 0x40, 0x09, 0x3f, 0x25, 0x1c, 0x05, 0x04, 0x05, 0x04, 0x01, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x31,
+	0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x06, 0x03, 0x02, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x02, 0x2b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x31, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x29, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x02, 0x02, 0x2b, 0x4d, 0x00, 0x04,
+	0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x2c, 0x4d, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x3b,
+	0x39, 0x31, 0x2f, 0x00, 0x2a, 0x00, 0x2a, 0x1a, 0x2a, 0x29, 0x07, 0x08, 0x19, 0x2b, 0x21, 0x2e,
+	0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x26, 0x36, 0x37, 0x3e, 0x05, 0x33, 0x32, 0x1e, 0x02,
+	0x17, 0x17, 0x3e, 0x03, 0x37, 0x33, 0x06, 0x02, 0x07, 0x1e, 0x03, 0x17, 0x01, 0x2e, 0x03, 0x23,
+	0x22, 0x0e, 0x02, 0x07, 0x06, 0x06, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x03, 0xc0, 0x08,
+	0x0d, 0x10, 0x13, 0x0a, 0x25, 0x58, 0x70, 0x88, 0x53, 0x69, 0x7b, 0x39, 0x01, 0x15, 0x0d, 0x2d,
+	0x43, 0x58, 0x71, 0x8b, 0x54, 0x4c, 0x63, 0x3f, 0x27, 0x0e, 0x1b, 0x11, 0x27, 0x29, 0x24, 0x0c,
+	0xef, 0x3d, 0xaf, 0x64, 0x0a, 0x1e, 0x23, 0x22, 0x0f, 0xfe, 0x82, 0x0f, 0x16, 0x1c, 0x28, 0x21,
+	0x2b, 0x43, 0x32, 0x25, 0x0d, 0x0b, 0x10, 0x06, 0x24, 0x29, 0x2d, 0x5c, 0x54, 0x47, 0x19, 0x18,
+	0x43, 0x4e, 0x56, 0x2a, 0x34, 0x71, 0x5e, 0x3d, 0x59, 0x94, 0xbf, 0x66, 0x44, 0x91, 0x88, 0x7a,
+	0x5c, 0x35, 0x2d, 0x50, 0x6b, 0x3f, 0x72, 0x20, 0x59, 0x66, 0x6d, 0x34, 0xa9, 0xfe, 0xd9, 0x89,
+	0x33, 0x7c, 0x83, 0x83, 0x3a, 0x02, 0x41, 0x44, 0x75, 0x55, 0x31, 0x49, 0x71, 0x8b, 0x42, 0x36,
+	0x72, 0x5d, 0x3c, 0x3a, 0x55, 0x62, 0x29, 0x00, 0x00, 0x02, 0x00, 0x45, 0xfe, 0x75, 0x05, 0x36,
+	0x06, 0x44, 0x00, 0x13, 0x00, 0x28, 0x00, 0x47, 0x40, 0x44, 0x0a, 0x01, 0x06, 0x03, 0x1f, 0x01,
+	0x05, 0x06, 0x12, 0x01, 0x01, 0x05, 0x03, 0x4c, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x69,
+	0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x32, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x00, 0x00, 0x28, 0x26, 0x22,
+	0x20, 0x1c, 0x1a, 0x16, 0x14, 0x00, 0x13, 0x00, 0x13, 0x2a, 0x23, 0x08, 0x08, 0x18, 0x2b, 0x13,
+	0x01, 0x12, 0x00, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x00, 0x23,
+	0x22, 0x27, 0x03, 0x01, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x03, 0x16,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x45, 0x01, 0x0f, 0x40, 0x01, 0x3d, 0xfe, 0xaa,
+	0xbd, 0x20, 0x18, 0xa7, 0xa6, 0xb3, 0x9b, 0x20, 0x27, 0xfe, 0xb1, 0xdb, 0x60, 0x70, 0x51, 0x01,
+	0x43, 0x19, 0x69, 0x9f, 0x1b, 0x12, 0x36, 0x3a, 0x5c, 0x80, 0x26, 0xa6, 0x5b, 0x5d, 0x61, 0xa0,
+	0x16, 0x1a, 0x9f, 0x89, 0x1b, 0xfe, 0x75, 0x05, 0x4f, 0x01, 0x40, 0x01, 0x40, 0xbf, 0xa0, 0x77,
+	0xbd, 0x4d, 0x2e, 0xe9, 0xa2, 0xc1, 0xfe, 0xfd, 0x26, 0xfe, 0x68, 0x05, 0x1f, 0xb7, 0x87, 0x5c,
+	0x5d, 0xbb, 0xbb, 0xfc, 0xc0, 0x35, 0x97, 0x6e, 0x86, 0xb6, 0x00, 0x00, 0x00, 0x01, 0x00, 0xe2,
+	0xfe, 0x75, 0x05, 0x47, 0x04, 0x4a, 0x00, 0x1e, 0x00, 0x1b, 0x40, 0x18, 0x0b, 0x01, 0x02, 0x00,
+	0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x18,
+	0x15, 0x03, 0x08, 0x19, 0x2b, 0x25, 0x26, 0x02, 0x26, 0x26, 0x27, 0x21, 0x1e, 0x03, 0x17, 0x36,
+	0x12, 0x37, 0x33, 0x0e, 0x05, 0x07, 0x16, 0x07, 0x06, 0x07, 0x23, 0x26, 0x37, 0x36, 0x01, 0xde,
+	0x19, 0x36, 0x3e, 0x47, 0x28, 0x01, 0x52, 0x1b, 0x28, 0x1f, 0x1a, 0x0c, 0x56, 0xd8, 0x7d, 0xe0,
+	0x2d, 0x6b, 0x70, 0x71, 0x67, 0x59, 0x1e, 0x15, 0x13, 0x1b, 0x66, 0xfb, 0x22, 0x16, 0x13, 0x80,
+	0x88, 0x01, 0x0c, 0xfa, 0xe0, 0x5c, 0x4b, 0xa5, 0xad, 0xae, 0x54, 0x98, 0x01, 0x5b, 0xac, 0x34,
+	0x92, 0xa8, 0xb3, 0xab, 0x98, 0x38, 0xba, 0x62, 0x84, 0x99, 0x8a, 0x6e, 0x5f, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x7b, 0xff, 0xe7, 0x05, 0x10, 0x06, 0x44, 0x00, 0x1e, 0x00, 0x2a, 0x00, 0x29,
+	0x40, 0x26, 0x08, 0x01, 0x01, 0x00, 0x09, 0x01, 0x03, 0x01, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02,
+	0x4e, 0x2a, 0x2c, 0x23, 0x25, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x26, 0x26, 0x37, 0x36, 0x24, 0x33,
+	0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x1f, 0x03, 0x16, 0x12, 0x07, 0x06, 0x00,
+	0x23, 0x22, 0x02, 0x37, 0x36, 0x36, 0x05, 0x06, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x36, 0x26, 0x02, 0x80, 0xa3, 0x71, 0x12, 0x1c, 0x01, 0x26, 0xed, 0xa8, 0x8d, 0x29, 0x92, 0xa4,
+	0x64, 0x6d, 0x09, 0x09, 0x54, 0x53, 0x50, 0x4f, 0xaf, 0x7b, 0x22, 0x2e, 0xfe, 0x97, 0xf0, 0xf0,
+	0xfc, 0x2b, 0x23, 0xed, 0x01, 0x5f, 0x85, 0x9c, 0x18, 0x1c, 0x62, 0x6b, 0x66, 0xa6, 0x1c, 0x19,
+	0x47, 0x03, 0xcd, 0x67, 0x96, 0x59, 0x89, 0x98, 0x22, 0xd0, 0x39, 0x2e, 0x2d, 0x2c, 0x3a, 0x3b,
+	0x38, 0x37, 0x7f, 0xfe, 0xfd, 0xad, 0xe7, 0xfe, 0xdd, 0x01, 0x12, 0xd6, 0xad, 0xff, 0x26, 0x44,
+	0xbe, 0x7a, 0x8c, 0xad, 0xb2, 0x8c, 0x7e, 0xa7, 0x00, 0x01, 0x00, 0x64, 0xff, 0xe7, 0x04, 0x41,
+	0x04, 0x63, 0x00, 0x1e, 0x00, 0x37, 0x40, 0x34, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x03, 0x02,
+	0x08, 0x01, 0x04, 0x03, 0x03, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x22, 0x21, 0x22, 0x23, 0x28, 0x22, 0x06, 0x08, 0x1c, 0x2b, 0x25, 0x07, 0x06,
+	0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x26, 0x37, 0x36, 0x24, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23,
+	0x22, 0x07, 0x06, 0x21, 0x33, 0x07, 0x23, 0x20, 0x07, 0x06, 0x33, 0x32, 0x03, 0xb4, 0x26, 0xe3,
+	0xac, 0xc7, 0xd4, 0x1c, 0x28, 0x01, 0x34, 0xec, 0x24, 0x1a, 0x01, 0x16, 0xd8, 0x9b, 0x8a, 0x25,
+	0x8a, 0x7b, 0xc8, 0x17, 0x1d, 0x01, 0x49, 0x33, 0x25, 0x6a, 0xfe, 0xd5, 0x23, 0x1f, 0xcb, 0x79,
+	0xef, 0xbf, 0x49, 0xac, 0x8b, 0xcb, 0x6d, 0x3f, 0xb7, 0x82, 0x95, 0x1d, 0xb8, 0x1d, 0x76, 0x8d,
+	0xb9, 0xb2, 0x9b, 0x00, 0x00, 0x01, 0x00, 0x93, 0xfe, 0x5d, 0x05, 0x34, 0x06, 0x44, 0x00, 0x42,
+	0x00, 0x53, 0x40, 0x11, 0x2d, 0x01, 0x02, 0x03, 0x2c, 0x01, 0x01, 0x02, 0x02, 0x4c, 0x0e, 0x09,
+	0x04, 0x03, 0x04, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2d, 0x01,
+	0x4e, 0x1b, 0x40, 0x12, 0x00, 0x02, 0x00, 0x01, 0x02, 0x01, 0x65, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0b, 0x3c, 0x39, 0x33, 0x31, 0x2a, 0x28, 0x20,
+	0x1d, 0x04, 0x08, 0x16, 0x2b, 0x01, 0x26, 0x26, 0x27, 0x13, 0x1e, 0x03, 0x17, 0x3e, 0x03, 0x37,
+	0x17, 0x0e, 0x03, 0x07, 0x0e, 0x03, 0x07, 0x06, 0x06, 0x16, 0x16, 0x33, 0x33, 0x32, 0x1e, 0x02,
+	0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x27, 0x37, 0x1e, 0x03, 0x33, 0x32, 0x36, 0x37, 0x36, 0x2e,
+	0x02, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x36, 0x12, 0x02, 0x34, 0x5c, 0x9a, 0x4a, 0x33, 0x35,
+	0x5b, 0x67, 0x80, 0x5b, 0x3c, 0x73, 0x7c, 0x8b, 0x56, 0x2f, 0x2b, 0x5e, 0x75, 0x93, 0x62, 0x47,
+	0x77, 0x5d, 0x40, 0x10, 0x0d, 0x04, 0x2b, 0x60, 0x57, 0x14, 0x5d, 0x78, 0x40, 0x0f, 0x0c, 0x11,
+	0x42, 0x67, 0x8e, 0x5a, 0x20, 0x56, 0x32, 0x28, 0x17, 0x26, 0x26, 0x27, 0x1a, 0x36, 0x48, 0x0c,
+	0x06, 0x15, 0x29, 0x36, 0x1b, 0x15, 0x8d, 0xb5, 0x5d, 0x0f, 0x17, 0x21, 0xc4, 0x04, 0x75, 0x07,
+	0x30, 0x1f, 0x01, 0x01, 0x24, 0x36, 0x2a, 0x20, 0x0c, 0x38, 0x5a, 0x48, 0x37, 0x17, 0x98, 0x2d,
+	0x56, 0x4d, 0x43, 0x1b, 0x46, 0xa3, 0xab, 0xad, 0x4f, 0x42, 0x62, 0x40, 0x20, 0x30, 0x53, 0x6f,
+	0x3e, 0x51, 0x81, 0x5a, 0x31, 0x09, 0x0a, 0xcb, 0x06, 0x0a, 0x06, 0x03, 0x3b, 0x3c, 0x20, 0x28,
+	0x17, 0x08, 0x41, 0x7d, 0xb6, 0x74, 0xa3, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x82,
+	0xfe, 0x75, 0x05, 0x16, 0x04, 0x63, 0x00, 0x14, 0x00, 0x78, 0xb5, 0x06, 0x01, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x2b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d,
+	0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x14, 0x00, 0x14, 0x23, 0x13, 0x23, 0x13, 0x06, 0x08,
+	0x1a, 0x2b, 0x33, 0x13, 0x36, 0x27, 0x21, 0x16, 0x07, 0x36, 0x33, 0x32, 0x16, 0x07, 0x03, 0x21,
+	0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x82, 0x99, 0x26, 0x25, 0x01, 0x40, 0x07, 0x08, 0xd0,
+	0xd5, 0x9e, 0x78, 0x27, 0xe2, 0xfe, 0xd8, 0xd8, 0x13, 0x30, 0x44, 0x88, 0xa0, 0x8a, 0x03, 0x01,
+	0xbe, 0x8b, 0x4d, 0x83, 0xe9, 0xc0, 0xbf, 0xfb, 0x91, 0x04, 0x3b, 0x61, 0x61, 0xbc, 0xfd, 0x4a,
+	0x00, 0x03, 0x00, 0xbd, 0xff, 0xe7, 0x04, 0xd1, 0x06, 0x44, 0x00, 0x06, 0x00, 0x17, 0x00, 0x33,
+	0x00, 0x36, 0x40, 0x33, 0x00, 0x00, 0x06, 0x01, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x01, 0x01,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x2a, 0x4d, 0x00, 0x02, 0x02, 0x04, 0x61, 0x07, 0x01, 0x04, 0x04,
+	0x32, 0x04, 0x4e, 0x19, 0x18, 0x07, 0x07, 0x27, 0x25, 0x18, 0x33, 0x19, 0x33, 0x07, 0x17, 0x07,
+	0x17, 0x29, 0x22, 0x10, 0x08, 0x08, 0x19, 0x2b, 0x01, 0x21, 0x12, 0x02, 0x23, 0x22, 0x02, 0x03,
+	0x07, 0x0e, 0x02, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x04, 0x37, 0x37, 0x01, 0x22, 0x2e, 0x03, 0x36,
+	0x37, 0x3e, 0x05, 0x33, 0x32, 0x1e, 0x03, 0x06, 0x07, 0x0e, 0x05, 0x02, 0x21, 0x01, 0x77, 0x34,
+	0x25, 0x62, 0x62, 0x8e, 0x5b, 0x09, 0x0a, 0x14, 0x0b, 0x02, 0x1b, 0x36, 0x2e, 0x2e, 0x49, 0x3a,
+	0x2d, 0x22, 0x19, 0x0a, 0x09, 0xfe, 0xb0, 0x62, 0x87, 0x54, 0x26, 0x05, 0x16, 0x14, 0x14, 0x39,
+	0x4d, 0x65, 0x82, 0xa0, 0x62, 0x62, 0x86, 0x54, 0x26, 0x05, 0x17, 0x14, 0x14, 0x38, 0x4d, 0x65,
+	0x82, 0x9f, 0x03, 0x81, 0x01, 0x06, 0x01, 0x04, 0xfe, 0xfc, 0xfe, 0x41, 0x2b, 0x31, 0x72, 0x71,
+	0x68, 0x51, 0x30, 0x30, 0x51, 0x69, 0x71, 0x71, 0x31, 0x2b, 0xfd, 0x1f, 0x41, 0x73, 0x9c, 0xb5,
+	0xc6, 0x64, 0x63, 0xc6, 0xb5, 0x9c, 0x73, 0x41, 0x41, 0x73, 0x9c, 0xb5, 0xc6, 0x63, 0x64, 0xc6,
+	0xb5, 0x9c, 0x73, 0x41, 0x00, 0x01, 0x00, 0xc5, 0xff, 0xe7, 0x03, 0x0b, 0x04, 0x4a, 0x00, 0x0f,
+	0x00, 0x1f, 0x40, 0x1c, 0x0f, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x23, 0x15, 0x21, 0x03, 0x08, 0x19,
+	0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32,
+	0x37, 0x02, 0xe5, 0x80, 0x7a, 0xa0, 0x48, 0x34, 0x0a, 0x24, 0x83, 0x01, 0x28, 0x87, 0x1c, 0x2a,
+	0x4e, 0x42, 0x60, 0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73, 0x2a,
+	0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x05, 0x0c, 0x04, 0x4a, 0x00, 0x12, 0x00, 0x4a, 0xb7, 0x11,
+	0x0e, 0x03, 0x03, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x13, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x29, 0x03,
+	0x4e, 0x1b, 0x40, 0x13, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05,
+	0x04, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12,
+	0x14, 0x21, 0x15, 0x11, 0x06, 0x08, 0x1a, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x37, 0x37, 0x36, 0x36,
+	0x33, 0x07, 0x27, 0x22, 0x06, 0x07, 0x07, 0x01, 0x21, 0x03, 0x03, 0x94, 0xdb, 0x01, 0x28, 0x69,
+	0x67, 0x7e, 0xc2, 0xad, 0x8a, 0x29, 0x19, 0x40, 0x8d, 0x81, 0x3f, 0x01, 0x26, 0xfe, 0xc1, 0xfe,
+	0x6a, 0x04, 0x4a, 0xfd, 0xf3, 0x68, 0x7e, 0xc1, 0x66, 0xce, 0x01, 0x60, 0x82, 0x3e, 0xfd, 0xa3,
+	0x02, 0x15, 0xfd, 0xeb, 0x00, 0x01, 0x00, 0x1b, 0x00, 0x00, 0x04, 0x8a, 0x06, 0x2b, 0x00, 0x1f,
+	0x00, 0x5d, 0xb6, 0x09, 0x06, 0x02, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x20, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x2a, 0x4d, 0x04, 0x03, 0x02, 0x00, 0x00,
+	0x29, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x00, 0x01, 0x00,
+	0x02, 0x01, 0x69, 0x04, 0x03, 0x02, 0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x04, 0x03, 0x02, 0x00, 0x00, 0x2c, 0x00, 0x4e, 0x59, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f, 0x21, 0x26, 0x17, 0x05, 0x08, 0x19, 0x2b, 0x21,
+	0x2e, 0x03, 0x27, 0x03, 0x01, 0x23, 0x01, 0x27, 0x2e, 0x03, 0x23, 0x23, 0x37, 0x33, 0x32, 0x1e,
+	0x04, 0x17, 0x13, 0x1e, 0x03, 0x17, 0x03, 0x3e, 0x15, 0x17, 0x13, 0x0e, 0x0b, 0x37, 0xfe, 0x4a,
+	0xde, 0x02, 0x61, 0x15, 0x0b, 0x1e, 0x32, 0x51, 0x3d, 0x15, 0x2f, 0x1e, 0x5c, 0x88, 0x61, 0x41,
+	0x2e, 0x1e, 0x0f, 0x79, 0x0d, 0x1b, 0x1d, 0x20, 0x15, 0x3c, 0x65, 0x62, 0x66, 0x3d, 0x01, 0x3f,
+	0xfd, 0x1b, 0x04, 0x08, 0x7b, 0x40, 0x4c, 0x27, 0x0b, 0xea, 0x0c, 0x20, 0x38, 0x56, 0x7b, 0x52,
+	0xfd, 0x3f, 0x4e, 0x86, 0x75, 0x69, 0x31, 0x00, 0x00, 0x01, 0x00, 0x45, 0xfe, 0x75, 0x05, 0x42,
+	0x04, 0x4a, 0x00, 0x15, 0x00, 0x7d, 0xb6, 0x14, 0x10, 0x02, 0x03, 0x01, 0x01, 0x4c, 0x4b, 0xb0,
+	0x1b, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62,
+	0x04, 0x01, 0x03, 0x03, 0x29, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x29, 0x4d,
+	0x00, 0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04, 0x32, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05,
+	0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x2c, 0x4d, 0x00,
+	0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04, 0x32, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05, 0x4e,
+	0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x15, 0x00, 0x15, 0x23, 0x13, 0x12, 0x23, 0x11, 0x07,
+	0x08, 0x1b, 0x2b, 0x13, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x06,
+	0x17, 0x21, 0x26, 0x37, 0x06, 0x23, 0x22, 0x27, 0x03, 0x45, 0x01, 0x2a, 0x01, 0x28, 0x88, 0x14,
+	0x30, 0x5e, 0x65, 0xa2, 0x8a, 0x01, 0x28, 0x9a, 0x26, 0x22, 0xfe, 0xc0, 0x07, 0x0b, 0xa9, 0x8a,
+	0x4d, 0x2a, 0x51, 0xfe, 0x75, 0x05, 0xd5, 0xfd, 0x5a, 0x66, 0x66, 0xbf, 0x02, 0xb3, 0xfc, 0xfe,
+	0xbf, 0x89, 0x4f, 0x80, 0xe2, 0x1f, 0xfe, 0x69, 0x00, 0x01, 0x00, 0xe4, 0x00, 0x00, 0x05, 0x02,
+	0x04, 0x4a, 0x00, 0x22, 0x00, 0x3b, 0xb5, 0x0f, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02,
+	0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02,
+	0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x22, 0x00, 0x22, 0x19, 0x18, 0x17, 0x04, 0x08, 0x17,
+	0x2b, 0x21, 0x2e, 0x05, 0x27, 0x21, 0x1e, 0x05, 0x17, 0x3e, 0x05, 0x37, 0x36, 0x27, 0x21, 0x16,
+	0x07, 0x0e, 0x05, 0x07, 0x01, 0x8d, 0x05, 0x11, 0x18, 0x1d, 0x23, 0x27, 0x14, 0x01, 0x4c, 0x12,
+	0x1a, 0x12, 0x0e, 0x0a, 0x08, 0x03, 0x1d, 0x47, 0x4a, 0x47, 0x3c, 0x2e, 0x0b, 0x0f, 0x0e, 0x01,
+	0x02, 0x04, 0x0b, 0x0d, 0x45, 0x62, 0x74, 0x77, 0x73, 0x2e, 0x4c, 0xbe, 0xcd, 0xd2, 0xc1, 0xa6,
+	0x3a, 0x46, 0x9d, 0xa1, 0x9f, 0x8f, 0x79, 0x2b, 0x24, 0x66, 0x78, 0x84, 0x83, 0x7c, 0x34, 0x4f,
+	0x4e, 0x35, 0x38, 0x41, 0xa2, 0xb2, 0xba, 0xb0, 0xa0, 0x3e, 0x00, 0x00, 0x00, 0x01, 0x00, 0x85,
+	0xfe, 0x5d, 0x04, 0xac, 0x06, 0x50, 0x00, 0x59, 0x00, 0x89, 0x40, 0x15, 0x1a, 0x11, 0x0b, 0x06,
+	0x04, 0x01, 0x00, 0x43, 0x01, 0x06, 0x07, 0x42, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x0c, 0x01, 0x00,
+	0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x80,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x04, 0x04,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x29, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x2d,
+	0x05, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x80, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x6a, 0x00, 0x06, 0x00, 0x05, 0x06, 0x05, 0x65, 0x00, 0x00, 0x00, 0x2a,
+	0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07, 0x07, 0x2c, 0x07, 0x4e, 0x59, 0x40, 0x13, 0x51,
+	0x4d, 0x46, 0x44, 0x40, 0x3e, 0x36, 0x33, 0x2b, 0x29, 0x28, 0x26, 0x1f, 0x1e, 0x17, 0x15, 0x08,
+	0x08, 0x16, 0x2b, 0x01, 0x26, 0x26, 0x37, 0x36, 0x36, 0x37, 0x2e, 0x03, 0x27, 0x37, 0x1e, 0x03,
+	0x17, 0x3e, 0x03, 0x33, 0x32, 0x16, 0x17, 0x17, 0x0e, 0x03, 0x23, 0x06, 0x06, 0x07, 0x06, 0x1e,
+	0x02, 0x33, 0x33, 0x07, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x06, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x1e,
+	0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x27, 0x37, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36,
+	0x2e, 0x02, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03, 0x02, 0x4d, 0x82, 0x61, 0x16, 0x0a,
+	0x37, 0x2c, 0x1f, 0x32, 0x2d, 0x2b, 0x18, 0x2b, 0x29, 0x48, 0x4c, 0x59, 0x3a, 0x1a, 0x45, 0x52,
+	0x5f, 0x35, 0x28, 0x60, 0x2c, 0x0c, 0x23, 0x5d, 0x71, 0x83, 0x4b, 0x1e, 0x2f, 0x0d, 0x07, 0x07,
+	0x31, 0x64, 0x57, 0x81, 0x25, 0x79, 0x6b, 0x91, 0x60, 0x32, 0x0a, 0x0a, 0x10, 0x2e, 0x46, 0x2d,
+	0x20, 0x66, 0x8a, 0x4b, 0x13, 0x0f, 0x14, 0x5f, 0x8c, 0xb2, 0x65, 0x2a, 0x5a, 0x31, 0x28, 0x59,
+	0x69, 0x3d, 0x55, 0x38, 0x1e, 0x06, 0x07, 0x24, 0x3d, 0x47, 0x1b, 0x17, 0x75, 0x9f, 0x59, 0x18,
+	0x13, 0x0e, 0x44, 0x6e, 0x96, 0x03, 0x38, 0x2d, 0x99, 0x70, 0x33, 0x68, 0x2a, 0x05, 0x0e, 0x12,
+	0x15, 0x0c, 0xd7, 0x19, 0x29, 0x20, 0x18, 0x09, 0x15, 0x2b, 0x22, 0x15, 0x09, 0x08, 0x75, 0x20,
+	0x3a, 0x2c, 0x1a, 0x22, 0x4f, 0x3d, 0x24, 0x55, 0x48, 0x31, 0xb9, 0x30, 0x4e, 0x62, 0x32, 0x34,
+	0x4c, 0x31, 0x18, 0x26, 0x4d, 0x73, 0x4c, 0x63, 0x86, 0x50, 0x22, 0x09, 0x0a, 0xcb, 0x19, 0x0f,
+	0x1e, 0x2c, 0x1e, 0x25, 0x2a, 0x14, 0x04, 0x34, 0x65, 0x92, 0x5f, 0x46, 0x83, 0x70, 0x59, 0x00,
+	0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b, 0x04, 0x63, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d,
+	0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16, 0x2b, 0x05, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x06, 0x07, 0x06, 0x16, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef,
+	0x34, 0x35, 0xfe, 0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55,
+	0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe,
+	0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x00, 0x00, 0x00, 0x01, 0x00, 0xba,
+	0x00, 0x00, 0x06, 0xbe, 0x04, 0x4a, 0x00, 0x13, 0x00, 0x4b, 0xb5, 0x04, 0x01, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x04,
+	0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x13, 0x13, 0x11, 0x23,
+	0x21, 0x07, 0x08, 0x1b, 0x2b, 0x21, 0x13, 0x23, 0x22, 0x07, 0x13, 0x36, 0x33, 0x21, 0x07, 0x23,
+	0x03, 0x06, 0x17, 0x21, 0x26, 0x37, 0x13, 0x21, 0x03, 0x01, 0x4e, 0xaf, 0x14, 0x73, 0xbc, 0x34,
+	0x8b, 0xc4, 0x04, 0x81, 0x2c, 0xf5, 0x71, 0x2c, 0x5a, 0xfe, 0xae, 0x32, 0x31, 0x6e, 0xfe, 0xb0,
+	0xaf, 0x03, 0x6c, 0x6c, 0x01, 0x05, 0x45, 0xde, 0xfd, 0xcd, 0xdb, 0x5e, 0x53, 0xf6, 0x02, 0x23,
+	0xfc, 0x94, 0x00, 0x00, 0x00, 0x02, 0x00, 0x38, 0xfe, 0x75, 0x05, 0x54, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x19, 0x00, 0x5a, 0xb5, 0x0c, 0x01, 0x01, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b,
+	0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x2c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00,
+	0x17, 0x15, 0x11, 0x0f, 0x00, 0x0d, 0x00, 0x0d, 0x24, 0x23, 0x06, 0x08, 0x18, 0x2b, 0x13, 0x13,
+	0x12, 0x00, 0x21, 0x20, 0x12, 0x07, 0x02, 0x00, 0x21, 0x22, 0x27, 0x03, 0x13, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x03, 0x38, 0x8e, 0x59, 0x01, 0x3e, 0x01, 0x0b, 0x01,
+	0x1c, 0xd0, 0x2f, 0x36, 0xfe, 0x77, 0xfe, 0xf7, 0x5c, 0x4d, 0x54, 0x7e, 0x42, 0x57, 0x8f, 0xcf,
+	0x24, 0x1f, 0x55, 0x7e, 0x7e, 0x89, 0x38, 0xfe, 0x75, 0x02, 0xca, 0x01, 0xba, 0x01, 0x6a, 0xfe,
+	0xf6, 0xea, 0xfe, 0xf4, 0xfe, 0x9d, 0x1b, 0xfe, 0x5a, 0x02, 0x79, 0x35, 0xec, 0xb5, 0x9c, 0xb4,
+	0xdc, 0xfe, 0xe7, 0x00, 0x00, 0x01, 0x00, 0x8f, 0xfe, 0x5d, 0x04, 0xcf, 0x04, 0x63, 0x00, 0x37,
+	0x00, 0x62, 0x40, 0x0e, 0x1b, 0x01, 0x03, 0x02, 0x1c, 0x01, 0x04, 0x03, 0x37, 0x01, 0x05, 0x00,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x31, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x05, 0x00,
+	0x05, 0x65, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x31, 0x4d, 0x00, 0x04, 0x04, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x27, 0x38, 0x28, 0x3a, 0x44, 0x21,
+	0x06, 0x08, 0x1c, 0x2b, 0x05, 0x16, 0x33, 0x32, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x22, 0x2e,
+	0x02, 0x37, 0x3e, 0x05, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x07, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x02,
+	0x07, 0x06, 0x1e, 0x02, 0x33, 0x33, 0x20, 0x03, 0x0e, 0x05, 0x23, 0x22, 0x27, 0x01, 0x76, 0x59,
+	0x69, 0xf3, 0x18, 0x08, 0x25, 0x3e, 0x4a, 0x1e, 0x3e, 0x84, 0xb4, 0x64, 0x17, 0x17, 0x15, 0x58,
+	0x79, 0x94, 0xa4, 0xac, 0x5f, 0x28, 0x4a, 0x3d, 0x34, 0x1d, 0x28, 0x1e, 0x34, 0x33, 0x35, 0x1e,
+	0x67, 0xaa, 0x81, 0x55, 0x13, 0x0e, 0x09, 0x36, 0x66, 0x4f, 0x22, 0x01, 0x97, 0x3d, 0x0e, 0x41,
+	0x5a, 0x6e, 0x74, 0x75, 0x34, 0x54, 0x61, 0xc5, 0x19, 0x77, 0x27, 0x29, 0x14, 0x03, 0x3a, 0x74,
+	0xae, 0x75, 0x67, 0xb2, 0x92, 0x71, 0x4e, 0x28, 0x03, 0x06, 0x09, 0x06, 0xc8, 0x0a, 0x0e, 0x0a,
+	0x05, 0x42, 0x76, 0xa2, 0x60, 0x45, 0x63, 0x40, 0x1e, 0xfe, 0xce, 0x46, 0x6a, 0x4d, 0x33, 0x1f,
+	0x0c, 0x13, 0x00, 0x00, 0x00, 0x02, 0x00, 0x83, 0xff, 0xe7, 0x06, 0x46, 0x04, 0x63, 0x00, 0x0b,
+	0x00, 0x1b, 0x00, 0x69, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x04, 0x01, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x31,
+	0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b, 0x40,
+	0x21, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x5f,
+	0x00, 0x04, 0x04, 0x2b, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32,
+	0x02, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00, 0x17, 0x16, 0x15, 0x14, 0x13, 0x11, 0x0c,
+	0x1b, 0x0d, 0x1b, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x08, 0x08, 0x16, 0x2b, 0x25, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x17, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33,
+	0x32, 0x17, 0x21, 0x07, 0x21, 0x16, 0x07, 0x02, 0x00, 0x02, 0x8e, 0x70, 0xaa, 0x25, 0x23, 0x57,
+	0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x43, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0x64, 0x4f,
+	0x02, 0x42, 0x2a, 0xfe, 0xd5, 0x3d, 0x27, 0x35, 0xfe, 0x95, 0xa0, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2,
+	0xb3, 0xb1, 0xd4, 0xb9, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0x19, 0xd2, 0x8a, 0xc7,
+	0xfe, 0xf7, 0xfe, 0xc9, 0x00, 0x01, 0x00, 0xb1, 0x00, 0x00, 0x04, 0x63, 0x04, 0x4a, 0x00, 0x0f,
+	0x00, 0x45, 0xb5, 0x06, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12,
+	0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x29,
+	0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f,
+	0x11, 0x23, 0x23, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x26, 0x13, 0x13, 0x23, 0x22, 0x07, 0x37, 0x36,
+	0x33, 0x21, 0x07, 0x21, 0x03, 0x06, 0x17, 0x01, 0x87, 0x26, 0x37, 0x5c, 0x67, 0x61, 0x7b, 0x2c,
+	0x71, 0x83, 0x02, 0x92, 0x2a, 0xfe, 0xe3, 0x72, 0x27, 0x37, 0x99, 0x01, 0x12, 0x01, 0xcd, 0x31,
+	0xdc, 0x27, 0xd2, 0xfd, 0xc5, 0xc4, 0x79, 0x00, 0x00, 0x01, 0x00, 0xcf, 0xff, 0xe7, 0x04, 0xd8,
+	0x04, 0x4a, 0x00, 0x15, 0x00, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01,
+	0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x24, 0x14, 0x23, 0x10, 0x04, 0x08, 0x1a,
+	0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03, 0x21, 0x12, 0x03, 0x02,
+	0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x62, 0x01, 0x28, 0x6a, 0x2f, 0x3e, 0x6d, 0x72,
+	0x94, 0x16, 0x3e, 0x84, 0x01, 0x34, 0x32, 0x34, 0x36, 0xfe, 0xb9, 0xde, 0xc3, 0x67, 0x41, 0x0f,
+	0x2b, 0x04, 0x4a, 0xfd, 0xf0, 0xed, 0xad, 0xb6, 0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe,
+	0xf9, 0xfe, 0xf5, 0xfe, 0xc5, 0x76, 0x4a, 0xc5, 0xd6, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0xfe, 0x75, 0x06, 0x05, 0x04, 0x63, 0x00, 0x27, 0x00, 0x37, 0x00, 0x52, 0xb6, 0x1e, 0x0b, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x13, 0x04, 0x01, 0x00, 0x00, 0x01,
+	0x61, 0x05, 0x03, 0x02, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40,
+	0x1b, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x05, 0x01, 0x03, 0x03, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x33, 0x31, 0x00, 0x27, 0x00, 0x27, 0x1a, 0x2e, 0x11, 0x06, 0x08, 0x19, 0x2b, 0x01, 0x07,
+	0x22, 0x0e, 0x02, 0x07, 0x06, 0x06, 0x16, 0x16, 0x17, 0x37, 0x36, 0x12, 0x36, 0x36, 0x33, 0x32,
+	0x1e, 0x02, 0x07, 0x0e, 0x03, 0x07, 0x03, 0x21, 0x13, 0x2e, 0x03, 0x37, 0x3e, 0x03, 0x13, 0x3e,
+	0x03, 0x37, 0x36, 0x36, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x03, 0x04, 0x25, 0x31, 0x57,
+	0x45, 0x35, 0x0f, 0x11, 0x07, 0x1f, 0x4a, 0x3e, 0x2d, 0x22, 0x4c, 0x77, 0xb1, 0x84, 0x72, 0x95,
+	0x4e, 0x0c, 0x17, 0x1e, 0x77, 0xac, 0xdf, 0x86, 0x4f, 0xfe, 0xf1, 0x4f, 0x84, 0xab, 0x58, 0x0d,
+	0x1c, 0x18, 0x61, 0x91, 0xc1, 0xe0, 0x5a, 0x7e, 0x56, 0x34, 0x10, 0x0c, 0x05, 0x13, 0x2d, 0x26,
+	0x27, 0x3d, 0x2f, 0x26, 0x10, 0x04, 0x4a, 0xb9, 0x31, 0x58, 0x7d, 0x4b, 0x55, 0x80, 0x5e, 0x3f,
+	0x15, 0xe1, 0xa6, 0x01, 0x08, 0xb9, 0x62, 0x41, 0x7d, 0xb4, 0x73, 0x96, 0xe4, 0x9e, 0x5a, 0x0c,
+	0xfe, 0x75, 0x01, 0x8b, 0x0e, 0x56, 0x94, 0xd4, 0x8b, 0x77, 0xba, 0x7f, 0x43, 0xfc, 0x6f, 0x09,
+	0x55, 0x80, 0x9f, 0x53, 0x3c, 0x69, 0x4e, 0x2e, 0x2b, 0x55, 0x7d, 0x52, 0x00, 0x01, 0xff, 0x95,
+	0xfe, 0x75, 0x05, 0x52, 0x04, 0x4a, 0x00, 0x1a, 0x00, 0x1f, 0x40, 0x1c, 0x18, 0x0d, 0x0a, 0x03,
+	0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2d, 0x02,
+	0x4e, 0x15, 0x17, 0x16, 0x14, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x03, 0x26, 0x26, 0x27, 0x21, 0x1e,
+	0x03, 0x17, 0x01, 0x33, 0x01, 0x13, 0x1e, 0x03, 0x17, 0x21, 0x26, 0x26, 0x27, 0x27, 0x01, 0x23,
+	0x02, 0x0c, 0x83, 0x23, 0x41, 0x25, 0x01, 0x39, 0x10, 0x24, 0x2d, 0x36, 0x20, 0x01, 0x6c, 0xf6,
+	0xfd, 0xe7, 0x94, 0x12, 0x28, 0x2a, 0x28, 0x11, 0xfe, 0xbc, 0x28, 0x40, 0x1f, 0x48, 0xfe, 0x34,
+	0xf6, 0x01, 0x79, 0x01, 0x7c, 0x64, 0xa9, 0x48, 0x20, 0x4c, 0x68, 0x8a, 0x5d, 0x01, 0xbb, 0xfd,
+	0x71, 0xfe, 0x59, 0x30, 0x72, 0x71, 0x67, 0x25, 0x55, 0xad, 0x5c, 0xd4, 0xfd, 0xce, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xae, 0xfe, 0x75, 0x06, 0x50, 0x05, 0x03, 0x00, 0x1d, 0x00, 0x56, 0x40, 0x0b,
+	0x11, 0x0e, 0x02, 0x03, 0x00, 0x01, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x17, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x29, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x2d, 0x04, 0x4e, 0x1b, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x2c, 0x4d, 0x05, 0x01, 0x04, 0x04,
+	0x2d, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x1d, 0x14, 0x16, 0x17, 0x17,
+	0x06, 0x08, 0x1a, 0x2b, 0x01, 0x13, 0x26, 0x02, 0x13, 0x37, 0x36, 0x27, 0x21, 0x16, 0x07, 0x07,
+	0x06, 0x16, 0x17, 0x13, 0x21, 0x03, 0x36, 0x36, 0x37, 0x36, 0x27, 0x21, 0x16, 0x07, 0x02, 0x00,
+	0x07, 0x03, 0x02, 0x21, 0x4f, 0xfa, 0xc8, 0x43, 0x1b, 0x2a, 0x2a, 0x01, 0x1a, 0x23, 0x29, 0x18,
+	0x32, 0x2d, 0x91, 0xdc, 0x01, 0x11, 0xdc, 0x7e, 0xbf, 0x2d, 0x2e, 0x26, 0x01, 0x16, 0x23, 0x2f,
+	0x3c, 0xfe, 0x83, 0xe7, 0x4f, 0xfe, 0x75, 0x01, 0x8b, 0x15, 0x01, 0x22, 0x01, 0x52, 0x87, 0xd0,
+	0x6a, 0x66, 0xcb, 0x77, 0xfb, 0xcb, 0x23, 0x04, 0x4a, 0xfb, 0xb6, 0x1a, 0xdb, 0xdf, 0xe4, 0xd9,
+	0xd4, 0xe7, 0xfe, 0xd2, 0xfe, 0xae, 0x0f, 0xfe, 0x75, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9e,
+	0xff, 0xe7, 0x07, 0x00, 0x04, 0x4a, 0x00, 0x3e, 0x00, 0x2f, 0x40, 0x2c, 0x22, 0x19, 0x02, 0x02,
+	0x03, 0x01, 0x4c, 0x00, 0x03, 0x01, 0x02, 0x01, 0x03, 0x02, 0x80, 0x05, 0x01, 0x01, 0x01, 0x2b,
+	0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x29, 0x19,
+	0x28, 0x16, 0x27, 0x19, 0x22, 0x07, 0x08, 0x1d, 0x2b, 0x01, 0x06, 0x06, 0x23, 0x22, 0x2e, 0x03,
+	0x36, 0x37, 0x36, 0x12, 0x37, 0x21, 0x06, 0x02, 0x07, 0x06, 0x06, 0x16, 0x16, 0x33, 0x32, 0x36,
+	0x37, 0x26, 0x37, 0x36, 0x37, 0x33, 0x16, 0x07, 0x06, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e, 0x04,
+	0x37, 0x36, 0x02, 0x27, 0x21, 0x16, 0x12, 0x07, 0x0e, 0x05, 0x23, 0x22, 0x26, 0x03, 0x97, 0x63,
+	0xe7, 0x8d, 0x47, 0x66, 0x44, 0x25, 0x0c, 0x09, 0x0d, 0x1e, 0x78, 0x60, 0x01, 0x27, 0x6f, 0x84,
+	0x1c, 0x0a, 0x05, 0x15, 0x38, 0x33, 0x50, 0x80, 0x3f, 0x1f, 0x18, 0x1c, 0x5a, 0xee, 0x1d, 0x1c,
+	0x17, 0x55, 0x04, 0x0f, 0x24, 0x3e, 0x31, 0x25, 0x3e, 0x33, 0x2a, 0x20, 0x14, 0x06, 0x1c, 0x14,
+	0x35, 0x01, 0x27, 0x2a, 0x08, 0x1e, 0x0c, 0x2b, 0x3e, 0x50, 0x64, 0x79, 0x47, 0x91, 0xac, 0x01,
+	0x13, 0x97, 0x95, 0x2f, 0x51, 0x6d, 0x7c, 0x86, 0x41, 0x96, 0x01, 0x18, 0x85, 0x92, 0xfe, 0xe8,
+	0x8a, 0x32, 0x79, 0x69, 0x47, 0x85, 0x85, 0x89, 0x75, 0x8c, 0x95, 0x96, 0x8b, 0x73, 0x8b, 0x2a,
+	0x5e, 0x4e, 0x34, 0x23, 0x3a, 0x4a, 0x4d, 0x4a, 0x1d, 0x8a, 0x01, 0x18, 0x92, 0x86, 0xfe, 0xe9,
+	0x96, 0x40, 0x84, 0x7c, 0x6e, 0x52, 0x30, 0x96, 0x00, 0x03, 0x00, 0xc5, 0xff, 0xe7, 0x03, 0xb7,
+	0x05, 0xeb, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x64, 0xb5, 0x0f, 0x01, 0x02, 0x01, 0x01,
+	0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x06, 0x07, 0x03, 0x04, 0x04, 0x03, 0x5f,
+	0x05, 0x01, 0x03, 0x03, 0x28, 0x4d, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03,
+	0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x15, 0x14, 0x14, 0x10, 0x10, 0x14, 0x17, 0x14, 0x17,
+	0x16, 0x15, 0x10, 0x13, 0x10, 0x13, 0x13, 0x23, 0x15, 0x21, 0x09, 0x08, 0x1a, 0x2b, 0x25, 0x06,
+	0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x37,
+	0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x02, 0xe5, 0x80, 0x7a, 0xa0, 0x48, 0x34, 0x0a, 0x24, 0x83,
+	0x01, 0x28, 0x87, 0x1c, 0x2a, 0x4e, 0x42, 0x60, 0xfd, 0xea, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf,
+	0x2c, 0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73, 0x2a, 0x04, 0x3a,
+	0xde, 0xde, 0xde, 0xde, 0x00, 0x03, 0x00, 0xcf, 0xff, 0xe7, 0x04, 0xd8, 0x05, 0xeb, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x1d, 0x00, 0x60, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x1f, 0x09, 0x07, 0x08,
+	0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x2b,
+	0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x06,
+	0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x2b,
+	0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x1a,
+	0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14,
+	0x23, 0x10, 0x0a, 0x08, 0x1b, 0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12,
+	0x03, 0x21, 0x12, 0x03, 0x02, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x13, 0x37, 0x33, 0x07,
+	0x33, 0x37, 0x33, 0x07, 0x01, 0x62, 0x01, 0x28, 0x6a, 0x2f, 0x3e, 0x6d, 0x72, 0x94, 0x16, 0x3e,
+	0x84, 0x01, 0x34, 0x32, 0x34, 0x36, 0xfe, 0xb9, 0xde, 0xc3, 0x67, 0x41, 0x0f, 0x2b, 0xff, 0x2c,
+	0xde, 0x2c, 0xd9, 0x2c, 0xdf, 0x2c, 0x04, 0x4a, 0xfd, 0xf0, 0xed, 0xad, 0xb6, 0x7e, 0x01, 0x29,
+	0x01, 0x4d, 0xfe, 0xea, 0xfe, 0xf9, 0xfe, 0xf5, 0xfe, 0xc5, 0x76, 0x4a, 0xc5, 0xd6, 0x02, 0xcb,
+	0xde, 0xde, 0xde, 0xde, 0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x05, 0x3b, 0x06, 0xa6, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x40, 0x40, 0x3d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00,
+	0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b,
+	0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03,
+	0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x13, 0x01,
+	0x21, 0x01, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35, 0xfe,
+	0x95, 0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0xd6, 0x01, 0x26,
+	0x01, 0x12, 0xfe, 0x5c, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe,
+	0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x63,
+	0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0xcf, 0xff, 0xe7, 0x04, 0xf5, 0x06, 0xa6, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05,
+	0x85, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32,
+	0x03, 0x4e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14, 0x23, 0x10, 0x07, 0x08, 0x1b,
+	0x2b, 0x01, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03, 0x21, 0x12, 0x03, 0x02,
+	0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x01, 0x21, 0x01, 0x01, 0x62, 0x01, 0x28, 0x6a,
+	0x2f, 0x3e, 0x6d, 0x72, 0x94, 0x16, 0x3e, 0x84, 0x01, 0x34, 0x32, 0x34, 0x36, 0xfe, 0xb9, 0xde,
+	0xc3, 0x67, 0x41, 0x0f, 0x2b, 0x01, 0xc3, 0x01, 0x26, 0x01, 0x12, 0xfe, 0x5c, 0x04, 0x4a, 0xfd,
+	0xf0, 0xed, 0xad, 0xb6, 0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe, 0xf9, 0xfe, 0xf5, 0xfe,
+	0xc5, 0x76, 0x4a, 0xc5, 0xd6, 0x02, 0xc1, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x02, 0x00, 0x9e,
+	0xff, 0xe7, 0x07, 0x00, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x42, 0x00, 0x48, 0x40, 0x45, 0x26, 0x1d,
+	0x02, 0x04, 0x05, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x01, 0x01, 0x03, 0x01, 0x85,
+	0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x07, 0x01, 0x03, 0x03, 0x2b, 0x4d, 0x06, 0x01,
+	0x04, 0x04, 0x02, 0x62, 0x08, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x00, 0x00, 0x41, 0x3f, 0x36,
+	0x35, 0x2c, 0x2a, 0x22, 0x21, 0x1b, 0x19, 0x12, 0x11, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x08, 0x17, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x03, 0x06, 0x06, 0x23, 0x22, 0x2e, 0x03, 0x36,
+	0x37, 0x36, 0x12, 0x37, 0x21, 0x06, 0x02, 0x07, 0x06, 0x06, 0x16, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x26, 0x37, 0x36, 0x37, 0x33, 0x16, 0x07, 0x06, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e, 0x04, 0x37,
+	0x36, 0x02, 0x27, 0x21, 0x16, 0x12, 0x07, 0x0e, 0x05, 0x23, 0x22, 0x26, 0x03, 0xad, 0x01, 0x26,
+	0x01, 0x12, 0xfe, 0x5c, 0xaa, 0x63, 0xe7, 0x8d, 0x47, 0x66, 0x44, 0x25, 0x0c, 0x09, 0x0d, 0x1e,
+	0x78, 0x60, 0x01, 0x27, 0x6f, 0x84, 0x1c, 0x0a, 0x05, 0x15, 0x38, 0x33, 0x50, 0x80, 0x3f, 0x1f,
+	0x18, 0x1c, 0x5a, 0xee, 0x1d, 0x1c, 0x17, 0x55, 0x04, 0x0f, 0x24, 0x3e, 0x31, 0x25, 0x3e, 0x33,
+	0x2a, 0x20, 0x14, 0x06, 0x1c, 0x14, 0x35, 0x01, 0x27, 0x2a, 0x08, 0x1e, 0x0c, 0x2b, 0x3e, 0x50,
+	0x64, 0x79, 0x47, 0x91, 0xac, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfc, 0x10, 0x97, 0x95, 0x2f,
+	0x51, 0x6d, 0x7c, 0x86, 0x41, 0x96, 0x01, 0x18, 0x85, 0x92, 0xfe, 0xe8, 0x8a, 0x32, 0x79, 0x69,
+	0x47, 0x85, 0x85, 0x89, 0x75, 0x8c, 0x95, 0x96, 0x8b, 0x73, 0x8b, 0x2a, 0x5e, 0x4e, 0x34, 0x23,
+	0x3a, 0x4a, 0x4d, 0x4a, 0x1d, 0x8a, 0x01, 0x18, 0x92, 0x86, 0xfe, 0xe9, 0x96, 0x40, 0x84, 0x7c,
+	0x6e, 0x52, 0x30, 0x96, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x6a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x07, 0x00, 0x06, 0x00,
+	0x07, 0x06, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1b, 0x05,
+	0x4e, 0x1b, 0x40, 0x24, 0x00, 0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x67, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x33, 0x01,
+	0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x03, 0x23, 0x01, 0x21, 0xad, 0x01,
+	0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29,
+	0x65, 0xc9, 0xfe, 0xff, 0x01, 0x19, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06,
+	0x4e, 0x01, 0x41, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x12, 0x07, 0x40, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x06,
+	0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b,
+	0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d,
+	0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12,
+	0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0d, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0xad, 0x01, 0x27, 0x04, 0x3e, 0x28, 0xfc, 0xf6,
+	0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0xfd, 0x9d, 0x2c, 0xde, 0x2c, 0xd9,
+	0x2c, 0xdf, 0x2c, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x62, 0xde, 0xde,
+	0xde, 0xde, 0x00, 0x00, 0x00, 0x01, 0x01, 0x18, 0xff, 0xf4, 0x07, 0x55, 0x05, 0xc8, 0x00, 0x1d,
+	0x00, 0xa4, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x0a, 0x0b, 0x01, 0x02, 0x03, 0x0a, 0x01, 0x01,
+	0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0b, 0x01, 0x02, 0x03, 0x0a, 0x01, 0x04, 0x02, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69,
+	0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00,
+	0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06,
+	0x1a, 0x4d, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x01, 0x05, 0x00, 0x06, 0x05, 0x67, 0x00, 0x00,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x12, 0x24, 0x23,
+	0x24, 0x21, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x36, 0x33, 0x20, 0x12, 0x07, 0x06, 0x00, 0x23, 0x22,
+	0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x21, 0x13, 0x21,
+	0x37, 0x21, 0x07, 0x21, 0x03, 0xbc, 0xe8, 0xc1, 0x01, 0x1f, 0xd1, 0x2a, 0x2f, 0xfe, 0xb2, 0xd1,
+	0x46, 0x5c, 0x25, 0x3b, 0x2c, 0x69, 0x9e, 0x1d, 0x19, 0x78, 0x96, 0xa1, 0xc8, 0x7a, 0xfe, 0xd1,
+	0xff, 0xfe, 0x35, 0x28, 0x05, 0x01, 0x28, 0xfd, 0xf9, 0x03, 0x52, 0x88, 0xfe, 0xf9, 0xd0, 0xeb,
+	0xfe, 0xdc, 0x10, 0xba, 0x0c, 0x9f, 0x92, 0x7b, 0x91, 0x8b, 0xfd, 0x9c, 0x04, 0xfd, 0xcb, 0xcb,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0xa6, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b,
+	0x00, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x68, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40,
+	0x0d, 0x06, 0x06, 0x06, 0x09, 0x06, 0x09, 0x12, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1a, 0x2b, 0x21,
+	0x21, 0x01, 0x21, 0x07, 0x21, 0x13, 0x01, 0x21, 0x01, 0x01, 0xe1, 0xfe, 0xcc, 0x01, 0x27, 0x03,
+	0xd2, 0x2c, 0xfd, 0x62, 0x31, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x05, 0xc8, 0xdf, 0x01, 0x65,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0xa4, 0xff, 0xdb, 0x06, 0x80, 0x05, 0xed, 0x00, 0x18,
+	0x00, 0x5b, 0x40, 0x0a, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x20,
+	0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x00,
+	0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e,
+	0x59, 0x40, 0x09, 0x22, 0x11, 0x12, 0x23, 0x24, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x07, 0x06,
+	0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24, 0x23, 0x22, 0x04, 0x07, 0x21,
+	0x07, 0x21, 0x06, 0x12, 0x33, 0x32, 0x05, 0xa4, 0x2d, 0xf9, 0xfe, 0xd6, 0xfe, 0x94, 0xfe, 0xbc,
+	0x49, 0x4b, 0x01, 0xea, 0x01, 0x7b, 0xf2, 0xf1, 0x30, 0xfe, 0xf6, 0xb7, 0xce, 0xfe, 0xd8, 0x40,
+	0x02, 0xcf, 0x28, 0xfd, 0x2b, 0x2b, 0xe6, 0xe2, 0xda, 0x01, 0x20, 0xe3, 0x62, 0x01, 0x9a, 0x01,
+	0x6f, 0x01, 0x76, 0x01, 0x93, 0x3b, 0xf1, 0x61, 0xe7, 0xd2, 0xc6, 0xdb, 0xfe, 0xe8, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x68, 0xff, 0xda, 0x05, 0xc6, 0x05, 0xed, 0x00, 0x23, 0x00, 0x49, 0x40, 0x0b,
+	0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x20, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01,
+	0x02, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x59, 0xb6, 0x2c,
+	0x23, 0x29, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x37, 0x37, 0x04, 0x33, 0x20, 0x37, 0x36, 0x2f, 0x02,
+	0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x1f,
+	0x02, 0x16, 0x16, 0x07, 0x06, 0x04, 0x21, 0x22, 0x27, 0x68, 0x32, 0x01, 0x09, 0xef, 0x01, 0x54,
+	0x27, 0x1a, 0x76, 0x7f, 0x97, 0xf1, 0x8c, 0x21, 0x53, 0x02, 0x5c, 0xfe, 0xda, 0x2e, 0xde, 0xdf,
+	0xb5, 0x9b, 0x14, 0x0c, 0x36, 0x5a, 0x69, 0x9d, 0xe5, 0x96, 0x21, 0x2f, 0xfe, 0x7c, 0xfe, 0x8d,
+	0x8b, 0xa9, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33,
+	0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x00,
+	0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x04, 0x63, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x16,
+	0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x64, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2,
+	0x29, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x64,
+	0x00, 0x00, 0x04, 0x7d, 0x07, 0x40, 0x00, 0x03, 0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x24, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01,
+	0x67, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x1a, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x1b, 0x09, 0x4e, 0x1b, 0x40, 0x22, 0x02, 0x01, 0x00, 0x0b,
+	0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67, 0x00, 0x06, 0x07, 0x01, 0x05, 0x04, 0x06, 0x05,
+	0x67, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x1d, 0x09, 0x4e, 0x59, 0x40,
+	0x22, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e,
+	0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0d, 0x07, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x37, 0x33, 0x13,
+	0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0xbb, 0x2c, 0xde, 0x2c, 0xd9, 0x2c, 0xdf,
+	0x2c, 0xfc, 0x13, 0x29, 0xd2, 0xd4, 0xd2, 0x2a, 0x02, 0xd8, 0x2a, 0xd2, 0xd4, 0xd2, 0x29, 0x06,
+	0x62, 0xde, 0xde, 0xde, 0xde, 0xf9, 0x9e, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00,
+	0x00, 0x01, 0xff, 0xce, 0xfe, 0xd8, 0x04, 0xc8, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x45, 0xb5, 0x01,
+	0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x01, 0x4e, 0x1b, 0x40,
+	0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x23, 0x11, 0x13, 0x22, 0x04,
+	0x07, 0x1a, 0x2b, 0x07, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x23, 0x37, 0x21, 0x03, 0x02,
+	0x04, 0x21, 0x22, 0x32, 0x2c, 0xae, 0xa9, 0x97, 0x8a, 0x1f, 0xd9, 0xf0, 0x2a, 0x02, 0x24, 0xfc,
+	0x36, 0xfe, 0xc3, 0xfe, 0xd9, 0xae, 0xfc, 0xdd, 0x38, 0x75, 0x9a, 0x04, 0x3e, 0xd2, 0xfb, 0x11,
+	0xfe, 0xf3, 0xf4, 0x00, 0x00, 0x02, 0x00, 0x28, 0x00, 0x00, 0x08, 0xf7, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x24, 0x00, 0x96, 0xb3, 0x08, 0x01, 0x04, 0x49, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x1f,
+	0x00, 0x03, 0x00, 0x06, 0x01, 0x03, 0x06, 0x69, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x1a, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x04, 0x5f, 0x07, 0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x01, 0x06, 0x05, 0x06, 0x01, 0x05, 0x80, 0x00,
+	0x03, 0x00, 0x06, 0x01, 0x03, 0x06, 0x69, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a,
+	0x4d, 0x00, 0x05, 0x05, 0x04, 0x5f, 0x07, 0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x24,
+	0x00, 0x01, 0x06, 0x05, 0x06, 0x01, 0x05, 0x80, 0x00, 0x02, 0x00, 0x00, 0x03, 0x02, 0x00, 0x67,
+	0x00, 0x03, 0x00, 0x06, 0x01, 0x03, 0x06, 0x69, 0x00, 0x05, 0x05, 0x04, 0x5f, 0x07, 0x01, 0x04,
+	0x04, 0x1d, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x24, 0x22, 0x1e, 0x1c, 0x00, 0x1b,
+	0x00, 0x1a, 0x21, 0x15, 0x17, 0x11, 0x08, 0x07, 0x1a, 0x2b, 0x21, 0x13, 0x21, 0x07, 0x02, 0x07,
+	0x06, 0x06, 0x07, 0x37, 0x36, 0x36, 0x37, 0x1b, 0x02, 0x21, 0x03, 0x33, 0x32, 0x16, 0x17, 0x16,
+	0x07, 0x06, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x04, 0x2e,
+	0xff, 0xfe, 0x4b, 0x18, 0x86, 0x67, 0x64, 0xfb, 0xec, 0x2b, 0x9b, 0xb5, 0x34, 0x3b, 0x3a, 0x38,
+	0x03, 0xff, 0x7b, 0x8b, 0xe3, 0xa8, 0x44, 0x95, 0x2d, 0x32, 0xdb, 0x95, 0xfe, 0x6a, 0x11, 0x83,
+	0xb4, 0xc8, 0x1a, 0x17, 0x96, 0xb3, 0x86, 0x04, 0xfd, 0x75, 0xfd, 0x60, 0xb6, 0x9c, 0x85, 0x11,
+	0xda, 0x0b, 0xcc, 0xe5, 0x01, 0x07, 0x01, 0x11, 0x01, 0x1a, 0xfd, 0x96, 0x1c, 0x30, 0x6a, 0xe3,
+	0xf9, 0x79, 0x53, 0xbe, 0x7d, 0x7e, 0x73, 0x73, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x08, 0xb7,
+	0x05, 0xc8, 0x00, 0x14, 0x00, 0x1d, 0x00, 0x5b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x03,
+	0x01, 0x01, 0x08, 0x01, 0x05, 0x07, 0x01, 0x05, 0x6a, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00,
+	0x07, 0x07, 0x04, 0x5f, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1d, 0x02,
+	0x01, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x08, 0x01, 0x05, 0x07, 0x01, 0x05, 0x6a, 0x00,
+	0x07, 0x07, 0x04, 0x5f, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x13, 0x00,
+	0x00, 0x1d, 0x1b, 0x17, 0x15, 0x00, 0x14, 0x00, 0x14, 0x11, 0x26, 0x21, 0x11, 0x11, 0x11, 0x0a,
+	0x07, 0x1c, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x33, 0x20, 0x17, 0x16, 0x07,
+	0x06, 0x07, 0x06, 0x21, 0x21, 0x13, 0x21, 0x03, 0x25, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x23, 0xad, 0x01, 0x27, 0x01, 0x2e, 0x7b, 0x02, 0x13, 0x7b, 0x01, 0x2e, 0x7b, 0x8b, 0x01, 0x64,
+	0x6b, 0x95, 0x2d, 0x32, 0xdb, 0x95, 0xfe, 0x6a, 0xfe, 0x9c, 0x86, 0xfd, 0xed, 0x86, 0x03, 0x67,
+	0x79, 0xb8, 0xce, 0x19, 0x17, 0x97, 0xb2, 0x86, 0x05, 0xc8, 0xfd, 0x96, 0x02, 0x6a, 0xfd, 0x96,
+	0x4b, 0x6b, 0xe3, 0xf9, 0x79, 0x53, 0x02, 0x9f, 0xfd, 0x61, 0xbf, 0x7d, 0x7d, 0x73, 0x73, 0x00,
+	0x00, 0x01, 0x01, 0x27, 0x00, 0x00, 0x07, 0x07, 0x05, 0xc8, 0x00, 0x15, 0x00, 0x58, 0xb5, 0x07,
+	0x01, 0x05, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x00, 0x05,
+	0x04, 0x03, 0x05, 0x69, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x07,
+	0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x67, 0x00, 0x03, 0x00, 0x05, 0x04, 0x03, 0x05, 0x69, 0x07, 0x06, 0x02, 0x04, 0x04,
+	0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x15, 0x00, 0x15, 0x23, 0x13, 0x22, 0x11,
+	0x11, 0x11, 0x08, 0x07, 0x1c, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x36, 0x33,
+	0x20, 0x16, 0x07, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0xc7, 0xff, 0xfe,
+	0x61, 0x28, 0x04, 0x81, 0x28, 0xfe, 0x52, 0x59, 0xc0, 0xef, 0x01, 0x0b, 0xac, 0x32, 0x61, 0xfe,
+	0xcc, 0x60, 0x1d, 0x53, 0x8c, 0xb2, 0xba, 0x77, 0x04, 0xfd, 0xcb, 0xcb, 0xfe, 0x46, 0x8c, 0xef,
+	0xf6, 0xfe, 0x16, 0x01, 0xe5, 0x8d, 0x7d, 0x9a, 0xfd, 0xab, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0xdc, 0x07, 0x8f, 0x00, 0x25, 0x00, 0x29, 0x00, 0x77, 0xb6, 0x15, 0x03, 0x02,
+	0x04, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x1a,
+	0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x08, 0x05, 0x02, 0x03,
+	0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00,
+	0x07, 0x85, 0x00, 0x02, 0x04, 0x00, 0x02, 0x5a, 0x01, 0x01, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04,
+	0x68, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x26, 0x26, 0x00, 0x00,
+	0x26, 0x29, 0x26, 0x29, 0x28, 0x27, 0x00, 0x25, 0x00, 0x25, 0x16, 0x1e, 0x11, 0x37, 0x11, 0x0a,
+	0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x32, 0x37,
+	0x07, 0x22, 0x06, 0x0f, 0x03, 0x06, 0x07, 0x16, 0x16, 0x1f, 0x02, 0x16, 0x17, 0x21, 0x26, 0x2f,
+	0x02, 0x26, 0x27, 0x23, 0x03, 0x01, 0x01, 0x21, 0x01, 0xad, 0x01, 0x27, 0x01, 0x28, 0x7d, 0x31,
+	0x53, 0x98, 0x4c, 0x7f, 0xa2, 0x84, 0x10, 0x40, 0x26, 0x5a, 0x4c, 0x46, 0x35, 0x39, 0x39, 0x5d,
+	0x90, 0x75, 0x74, 0x35, 0x1a, 0x26, 0x1e, 0x30, 0xfe, 0xbc, 0x0e, 0x04, 0x26, 0x33, 0x4c, 0x40,
+	0x4d, 0x85, 0x01, 0x4c, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x05, 0xc8, 0xfd, 0x8b, 0x06, 0x4d,
+	0xbe, 0x5f, 0x9f, 0x64, 0x02, 0xbf, 0x2b, 0x56, 0x41, 0x49, 0x47, 0x75, 0x3e, 0x1e, 0x84, 0xab,
+	0x4a, 0x76, 0x69, 0x8e, 0x2d, 0x0d, 0x75, 0x98, 0xe5, 0x6e, 0xfd, 0x66, 0x06, 0x4e, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x3a, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x0d, 0x00, 0x49, 0xb6, 0x0d, 0x08, 0x02, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x15, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x05, 0x01, 0x02, 0x02, 0x1a,
+	0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x67, 0x05, 0x01, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x40, 0x09, 0x11, 0x12, 0x11, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23, 0x01,
+	0x21, 0x01, 0x21, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x21, 0x03, 0x04, 0xb5, 0xc9, 0xfe, 0xff,
+	0x01, 0x19, 0x01, 0x02, 0x01, 0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0xcb, 0xfd, 0x37, 0xfe, 0xcc, 0x01,
+	0x27, 0x01, 0x34, 0xcc, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0x39, 0xfa, 0x38, 0x03, 0xfc, 0xfc, 0x04,
+	0x05, 0xc8, 0xfc, 0x04, 0x00, 0x02, 0x00, 0x8c, 0xff, 0xdb, 0x06, 0x1a, 0x07, 0x8f, 0x00, 0x11,
+	0x00, 0x21, 0x00, 0x8a, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58,
+	0x40, 0x20, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07,
+	0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x20,
+	0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85,
+	0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03,
+	0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05,
+	0x04, 0x85, 0x01, 0x01, 0x00, 0x07, 0x03, 0x07, 0x00, 0x03, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00,
+	0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x59,
+	0x40, 0x0b, 0x22, 0x13, 0x23, 0x13, 0x21, 0x24, 0x13, 0x11, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x01,
+	0x21, 0x13, 0x33, 0x01, 0x21, 0x01, 0x02, 0x07, 0x06, 0x21, 0x23, 0x37, 0x33, 0x32, 0x36, 0x37,
+	0x13, 0x33, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26,
+	0x02, 0x7b, 0xfe, 0xe6, 0x01, 0x4d, 0xae, 0x07, 0x01, 0xb2, 0x01, 0x05, 0xfd, 0x6a, 0xb7, 0x8b,
+	0x74, 0xfe, 0xe9, 0x2b, 0x2a, 0x25, 0x85, 0x9a, 0x65, 0x7f, 0xd2, 0x05, 0x10, 0x2c, 0x3e, 0x3e,
+	0x4f, 0x11, 0x03, 0xd2, 0x20, 0xc7, 0xa6, 0xa7, 0x86, 0x01, 0x9e, 0x04, 0x2a, 0xfd, 0x0c, 0x02,
+	0xf4, 0xfb, 0xcd, 0xfe, 0xf9, 0x61, 0x52, 0xd2, 0x4b, 0x83, 0x06, 0x14, 0x18, 0x54, 0x53, 0x54,
+	0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0xfe, 0x7f, 0x06, 0x3a,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07,
+	0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21, 0x01, 0x21, 0x03, 0x23, 0x13, 0xad, 0x01,
+	0x27, 0x01, 0x34, 0xfe, 0x01, 0xfe, 0xfe, 0x01, 0x34, 0xfe, 0xd9, 0xfe, 0x3b, 0x4d, 0xdc, 0x4d,
+	0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0xfa, 0x38, 0xfe, 0x7f, 0x01, 0x81, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21,
+	0x03, 0x01, 0x21, 0x03, 0x0c, 0x03, 0x65, 0x01, 0x34, 0x01, 0x15, 0xfe, 0xc5, 0x49, 0xfd, 0x9c,
+	0xe5, 0x01, 0x59, 0x01, 0xcc, 0x70, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50,
+	0x02, 0x4e, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x01, 0x05, 0xc8, 0x00, 0x0f,
+	0x00, 0x18, 0x00, 0x58, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04,
+	0x02, 0x05, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x03,
+	0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x18, 0x16, 0x12,
+	0x10, 0x00, 0x0f, 0x00, 0x0e, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x32, 0x16, 0x17, 0x16, 0x07, 0x06, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x21, 0xad, 0x01, 0x27, 0x04, 0x2d, 0x28, 0xfd, 0x01, 0x53, 0x01, 0x0e,
+	0xdd, 0x9b, 0x43, 0x93, 0x2d, 0x32, 0xd9, 0x94, 0xfe, 0x9b, 0xb2, 0xf6, 0xb8, 0xbe, 0x1a, 0x17,
+	0x87, 0xb2, 0xfe, 0xfd, 0x05, 0xc8, 0xcb, 0xfe, 0x61, 0x1c, 0x30, 0x6a, 0xe3, 0xf9, 0x79, 0x53,
+	0xbe, 0x7d, 0x7e, 0x73, 0x73, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x06, 0x68,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x1d, 0x00, 0x61, 0xb5, 0x06, 0x01, 0x05, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67,
+	0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06,
+	0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03,
+	0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01,
+	0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1d, 0x1b, 0x17, 0x15, 0x14, 0x12,
+	0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x07, 0x07, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03,
+	0x02, 0x05, 0x04, 0x03, 0x06, 0x04, 0x23, 0x01, 0x21, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21,
+	0x03, 0x21, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21, 0xad, 0x01, 0x27, 0x02, 0xcc, 0x01, 0xc8,
+	0x42, 0x35, 0xfe, 0x87, 0x01, 0x8c, 0x3d, 0x24, 0xfe, 0xe8, 0xe4, 0xfe, 0xd4, 0x01, 0x1e, 0x82,
+	0xb3, 0x16, 0x14, 0x6c, 0xab, 0xfe, 0xed, 0xd6, 0x01, 0x17, 0xc2, 0xa4, 0x16, 0x17, 0xa7, 0x96,
+	0xfe, 0xef, 0x05, 0xc8, 0xfe, 0xb7, 0xfe, 0xf5, 0x6f, 0x64, 0xfe, 0xcd, 0xb1, 0xbd, 0x03, 0x60,
+	0x81, 0x6d, 0x65, 0x4a, 0xfb, 0xd5, 0x53, 0x6d, 0x72, 0x96, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0xa6, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x31, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x10, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00,
+	0x4e, 0x1b, 0x40, 0x0e, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x67, 0x00, 0x00, 0x00, 0x1d,
+	0x00, 0x4e, 0x59, 0xb5, 0x11, 0x11, 0x10, 0x03, 0x07, 0x19, 0x2b, 0x21, 0x21, 0x01, 0x21, 0x07,
+	0x21, 0x01, 0xe1, 0xfe, 0xcc, 0x01, 0x27, 0x03, 0xd2, 0x2d, 0xfd, 0x62, 0x05, 0xc8, 0xe1, 0x00,
+	0x00, 0x02, 0xff, 0xc3, 0xfe, 0x7f, 0x06, 0x0b, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x15, 0x00, 0x70,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a,
+	0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x09, 0x07,
+	0x02, 0x03, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x40,
+	0x25, 0x00, 0x01, 0x00, 0x06, 0x00, 0x01, 0x06, 0x67, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05,
+	0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x0f, 0x0f, 0x00, 0x00, 0x0f, 0x15, 0x0f,
+	0x15, 0x11, 0x10, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x0a, 0x07, 0x1b, 0x2b,
+	0x03, 0x13, 0x33, 0x36, 0x12, 0x13, 0x37, 0x21, 0x03, 0x33, 0x03, 0x23, 0x13, 0x21, 0x03, 0x01,
+	0x13, 0x21, 0x07, 0x02, 0x02, 0x07, 0x3d, 0x75, 0x4b, 0xb4, 0xf9, 0x45, 0x22, 0x03, 0x74, 0xfe,
+	0xb4, 0x76, 0xdc, 0x4d, 0xfc, 0x2f, 0x4d, 0x03, 0x51, 0xd7, 0xfe, 0xc0, 0x05, 0x3e, 0xfd, 0x8f,
+	0xfe, 0x7f, 0x02, 0x53, 0xcc, 0x02, 0x24, 0x01, 0x59, 0xad, 0xfb, 0x0a, 0xfd, 0xad, 0x01, 0x81,
+	0xfe, 0x7f, 0x02, 0x53, 0x04, 0x34, 0x19, 0xfe, 0xc5, 0xfd, 0xc0, 0xa0, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x12, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b,
+	0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0xad, 0x01, 0x27, 0x04,
+	0x3e, 0x28, 0xfc, 0xf6, 0x53, 0x02, 0x9b, 0x27, 0xfd, 0x65, 0x5c, 0x03, 0x39, 0x29, 0x05, 0xc8,
+	0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x00, 0x00, 0x01, 0x00, 0x24, 0x00, 0x00, 0x08, 0x05,
+	0x05, 0xc8, 0x00, 0x41, 0x00, 0x69, 0x40, 0x09, 0x35, 0x24, 0x21, 0x11, 0x04, 0x01, 0x03, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x03, 0x03, 0x04, 0x61, 0x06, 0x05,
+	0x02, 0x04, 0x04, 0x1a, 0x4d, 0x0a, 0x09, 0x02, 0x01, 0x01, 0x04, 0x61, 0x06, 0x05, 0x02, 0x04,
+	0x04, 0x1a, 0x4d, 0x08, 0x02, 0x02, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x07, 0x01,
+	0x03, 0x01, 0x04, 0x03, 0x59, 0x06, 0x05, 0x02, 0x04, 0x0a, 0x09, 0x02, 0x01, 0x00, 0x04, 0x01,
+	0x67, 0x08, 0x02, 0x02, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x00, 0x41,
+	0x00, 0x41, 0x1c, 0x11, 0x19, 0x18, 0x11, 0x1c, 0x16, 0x11, 0x11, 0x0b, 0x07, 0x1f, 0x2b, 0x01,
+	0x03, 0x21, 0x13, 0x23, 0x06, 0x06, 0x03, 0x07, 0x06, 0x07, 0x21, 0x36, 0x37, 0x37, 0x36, 0x36,
+	0x37, 0x26, 0x26, 0x27, 0x27, 0x26, 0x26, 0x23, 0x37, 0x32, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x17,
+	0x13, 0x21, 0x03, 0x36, 0x36, 0x37, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x07, 0x22, 0x06, 0x07,
+	0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17, 0x21, 0x27, 0x27, 0x02, 0x26, 0x27,
+	0x04, 0xb3, 0x85, 0xfe, 0xdf, 0x85, 0x45, 0x46, 0x8c, 0xb3, 0x4a, 0x03, 0x11, 0xfe, 0xba, 0x59,
+	0x8a, 0x3a, 0x7b, 0x97, 0x86, 0x42, 0x40, 0x28, 0x12, 0x24, 0x59, 0x42, 0x26, 0xa7, 0xa8, 0x35,
+	0x10, 0x17, 0x26, 0x2d, 0x35, 0x7d, 0x01, 0x21, 0x7d, 0x3c, 0x4e, 0x4f, 0x13, 0x25, 0x24, 0x7c,
+	0xd5, 0xa7, 0x26, 0x43, 0x75, 0x55, 0x28, 0x5d, 0x64, 0x54, 0x7d, 0x64, 0x2c, 0x16, 0x31, 0x2e,
+	0xfe, 0xba, 0x08, 0x1c, 0x43, 0x3f, 0x33, 0x02, 0x9c, 0xfd, 0x64, 0x02, 0x9c, 0x30, 0xbb, 0xfe,
+	0xe4, 0x76, 0x04, 0x1b, 0x72, 0xda, 0x5c, 0xc2, 0x81, 0x1c, 0x25, 0x62, 0x80, 0x39, 0x75, 0x4d,
+	0xbf, 0x75, 0xae, 0x34, 0x4f, 0x7e, 0x42, 0x0d, 0x02, 0x73, 0xfd, 0x8d, 0x11, 0x48, 0x74, 0x1b,
+	0x34, 0x34, 0xaf, 0x74, 0xbf, 0x4c, 0x76, 0x39, 0x82, 0x60, 0x25, 0x1c, 0x82, 0xc1, 0x5c, 0xd7,
+	0x75, 0x1f, 0x76, 0x01, 0x25, 0xb2, 0x30, 0x00, 0x00, 0x01, 0x00, 0x6c, 0xff, 0xdb, 0x05, 0x79,
+	0x05, 0xee, 0x00, 0x24, 0x00, 0x5f, 0x40, 0x0e, 0x14, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00,
+	0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00,
+	0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x2a, 0x23, 0x24, 0x21, 0x24,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23,
+	0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x16, 0x07,
+	0x06, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x04, 0x21, 0x22, 0x6c, 0x2c, 0xdc, 0xb3, 0xa4, 0xc1,
+	0x17, 0x1d, 0xbf, 0xdb, 0x5d, 0x26, 0x5c, 0xc9, 0xe1, 0x18, 0x14, 0x7b, 0xa2, 0xba, 0xce, 0x28,
+	0xd3, 0xf7, 0x01, 0x21, 0xea, 0x23, 0x18, 0xb0, 0x98, 0x98, 0x7b, 0x1f, 0x29, 0xfe, 0x8b, 0xfe,
+	0xd4, 0xea, 0x11, 0xdd, 0x51, 0x86, 0x75, 0x91, 0x91, 0xbf, 0x78, 0x79, 0x62, 0x62, 0x45, 0xc8,
+	0x3d, 0xb1, 0xb0, 0x79, 0xb0, 0x37, 0x30, 0xc9, 0x9a, 0xce, 0xf1, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x3a, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x36, 0xb6, 0x09, 0x04, 0x02, 0x01, 0x00,
+	0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x03, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x02,
+	0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x0d, 0x03, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10, 0x04, 0x07, 0x1a, 0x2b,
+	0x01, 0x21, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x21, 0x03, 0x05, 0x06, 0x01, 0x34, 0xfe, 0xd9,
+	0xfe, 0xcc, 0xcb, 0xfd, 0x37, 0xfe, 0xcc, 0x01, 0x27, 0x01, 0x34, 0xcc, 0x05, 0xc8, 0xfa, 0x38,
+	0x03, 0xfc, 0xfc, 0x04, 0x05, 0xc8, 0xfc, 0x04, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x06, 0x3a,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x19, 0x00, 0x7c, 0xb6, 0x09, 0x04, 0x02, 0x01, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05,
+	0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x03, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x02, 0x01, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x06, 0x01, 0x04, 0x05, 0x04,
+	0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x03, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x02,
+	0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00,
+	0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x03, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x22, 0x13, 0x23, 0x12, 0x11, 0x12, 0x11, 0x10,
+	0x08, 0x07, 0x1e, 0x2b, 0x01, 0x21, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x21, 0x03, 0x13, 0x33,
+	0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x05, 0x06,
+	0x01, 0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0xcb, 0xfd, 0x37, 0xfe, 0xcc, 0x01, 0x27, 0x01, 0x34, 0xcc,
+	0xd4, 0xd2, 0x05, 0x10, 0x2c, 0x3e, 0x3e, 0x4f, 0x11, 0x03, 0xd2, 0x20, 0xc7, 0xa6, 0xa7, 0x86,
+	0x05, 0xc8, 0xfa, 0x38, 0x03, 0xfc, 0xfc, 0x04, 0x05, 0xc8, 0xfc, 0x04, 0x05, 0xc3, 0x18, 0x54,
+	0x53, 0x54, 0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xdc,
+	0x05, 0xc8, 0x00, 0x25, 0x00, 0x59, 0xb6, 0x15, 0x03, 0x02, 0x04, 0x02, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d,
+	0x00, 0x04, 0x04, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x04, 0x00, 0x02, 0x59, 0x01, 0x01, 0x00, 0x00,
+	0x04, 0x03, 0x00, 0x04, 0x67, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e,
+	0x00, 0x00, 0x00, 0x25, 0x00, 0x25, 0x16, 0x1e, 0x11, 0x37, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33,
+	0x01, 0x21, 0x03, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x32, 0x37, 0x07, 0x22, 0x06, 0x0f,
+	0x03, 0x06, 0x07, 0x16, 0x16, 0x1f, 0x02, 0x16, 0x17, 0x21, 0x26, 0x2f, 0x02, 0x26, 0x27, 0x23,
+	0x03, 0xad, 0x01, 0x27, 0x01, 0x28, 0x7d, 0x31, 0x53, 0x98, 0x4c, 0x7f, 0xa2, 0x84, 0x10, 0x40,
+	0x26, 0x5a, 0x4c, 0x46, 0x35, 0x39, 0x39, 0x5d, 0x90, 0x75, 0x74, 0x35, 0x1a, 0x26, 0x1e, 0x30,
+	0xfe, 0xbc, 0x0e, 0x04, 0x26, 0x33, 0x4c, 0x40, 0x4d, 0x85, 0x05, 0xc8, 0xfd, 0x8b, 0x06, 0x4d,
+	0xbe, 0x5f, 0x9f, 0x64, 0x02, 0xbf, 0x2b, 0x56, 0x41, 0x49, 0x47, 0x75, 0x3e, 0x1e, 0x84, 0xab,
+	0x4a, 0x76, 0x69, 0x8e, 0x2d, 0x0d, 0x75, 0x98, 0xe5, 0x6e, 0xfd, 0x66, 0x00, 0x01, 0x00, 0x14,
+	0x00, 0x00, 0x06, 0x17, 0x05, 0xc8, 0x00, 0x12, 0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03,
+	0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x11,
+	0x11, 0x18, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x37, 0x36, 0x36, 0x37, 0x13, 0x36, 0x37, 0x13, 0x21,
+	0x01, 0x21, 0x13, 0x21, 0x07, 0x02, 0x07, 0x06, 0x04, 0x14, 0x2b, 0x9a, 0xa8, 0x3c, 0x40, 0x14,
+	0x27, 0x38, 0x03, 0xa7, 0xfe, 0xd9, 0xfe, 0xcb, 0xff, 0xfe, 0xb0, 0x18, 0x86, 0x67, 0x5a, 0xfe,
+	0xf4, 0xda, 0x0b, 0xbd, 0xf5, 0x01, 0x06, 0x4f, 0xc2, 0x01, 0x1a, 0xfa, 0x38, 0x04, 0xfd, 0x75,
+	0xfd, 0x60, 0xb6, 0x9d, 0x87, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x07, 0x24,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4b, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x01, 0x01, 0x00, 0x00, 0x1a,
+	0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x57, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e,
+	0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06, 0x07, 0x1a,
+	0x2b, 0x33, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x21, 0x13, 0x01, 0x23, 0x03, 0x03, 0xad, 0x01,
+	0x27, 0x01, 0x98, 0x54, 0x01, 0xfe, 0x01, 0x66, 0xfe, 0xd9, 0xfe, 0xe4, 0xdf, 0xfe, 0x0c, 0xf8,
+	0x53, 0xe2, 0x05, 0xc8, 0xfb, 0xef, 0x04, 0x11, 0xfa, 0x38, 0x04, 0x5d, 0xfc, 0x06, 0x04, 0x09,
+	0xfb, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x41, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x68, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13,
+	0x21, 0x01, 0x21, 0x13, 0x21, 0x03, 0xad, 0x01, 0x27, 0x01, 0x34, 0x78, 0x02, 0x05, 0x78, 0x01,
+	0x34, 0xfe, 0xd9, 0xfe, 0xcc, 0x86, 0xfd, 0xfb, 0x86, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa,
+	0x38, 0x02, 0xa3, 0xfd, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9b, 0xff, 0xdb, 0x06, 0xc5,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x20, 0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x40,
+	0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x07, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02,
+	0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x03, 0x0a, 0xfe,
+	0xb8, 0xfe, 0xd9, 0x48, 0x49, 0x01, 0xd0, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x2a, 0x48, 0x4a, 0xfe,
+	0x30, 0xfe, 0xd5, 0xbe, 0x01, 0x09, 0x37, 0x36, 0x91, 0xb8, 0xb9, 0xfe, 0xf7, 0x37, 0x35, 0x8f,
+	0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe,
+	0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3,
+	0xfe, 0xd0, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x06, 0x3a, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x01,
+	0x21, 0x01, 0x21, 0x13, 0x21, 0x03, 0xad, 0x01, 0x27, 0x04, 0x66, 0xfe, 0xd9, 0xfe, 0xcc, 0xff,
+	0xfe, 0x02, 0xff, 0x05, 0xc8, 0xfa, 0x38, 0x04, 0xfd, 0xfb, 0x03, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x02, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00,
+	0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05,
+	0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00,
+	0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x07, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x32, 0x16, 0x17, 0x16,
+	0x07, 0x02, 0x21, 0x23, 0x03, 0x13, 0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x23, 0xad, 0x01, 0x27,
+	0x02, 0x46, 0xbd, 0xb1, 0x33, 0x47, 0x23, 0x66, 0xfd, 0x97, 0xc2, 0x73, 0x9c, 0x7e, 0x01, 0x72,
+	0x37, 0x18, 0x7e, 0xa5, 0xb9, 0x05, 0xc8, 0x2f, 0x46, 0x61, 0xb3, 0xfe, 0x05, 0xfd, 0xbc, 0x03,
+	0x0f, 0x01, 0x12, 0x7a, 0x62, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x99, 0xff, 0xdb, 0x06, 0x94,
+	0x05, 0xed, 0x00, 0x13, 0x00, 0x48, 0x40, 0x0a, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x1f, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x20, 0x00, 0x4e, 0x1b, 0x40,
+	0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x22, 0x00, 0x4e, 0x59, 0xb6, 0x22, 0x23, 0x24, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x01, 0x07,
+	0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x02,
+	0x21, 0x32, 0x05, 0xb7, 0x2e, 0xea, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0xb7, 0x4a, 0x4d, 0x01, 0xed,
+	0x01, 0x8f, 0x01, 0x03, 0xe5, 0x30, 0xfe, 0xc8, 0xfd, 0xff, 0x72, 0x71, 0x02, 0x1e, 0xeb, 0x01,
+	0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6,
+	0xfd, 0xc8, 0x00, 0x00, 0x00, 0x01, 0x01, 0x25, 0x00, 0x00, 0x05, 0xe3, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x1a, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01,
+	0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x21, 0x13,
+	0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x01, 0xd8, 0xfd, 0xfe, 0x50, 0x2a, 0x04, 0x94, 0x2a, 0xfe,
+	0x50, 0xfd, 0x04, 0xf3, 0xd5, 0xd5, 0xfb, 0x0d, 0x00, 0x01, 0x00, 0x8c, 0xff, 0xdb, 0x06, 0x1a,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x3d, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x11, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x01, 0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03,
+	0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0xb6, 0x21, 0x24, 0x13, 0x11, 0x04,
+	0x07, 0x1a, 0x2b, 0x01, 0x01, 0x21, 0x13, 0x33, 0x01, 0x21, 0x01, 0x02, 0x07, 0x06, 0x21, 0x23,
+	0x37, 0x33, 0x32, 0x36, 0x37, 0x02, 0x7b, 0xfe, 0xe6, 0x01, 0x4d, 0xae, 0x07, 0x01, 0xb2, 0x01,
+	0x05, 0xfd, 0x6a, 0xb7, 0x8b, 0x74, 0xfe, 0xe9, 0x2b, 0x2a, 0x25, 0x85, 0x9a, 0x65, 0x01, 0x9e,
+	0x04, 0x2a, 0xfd, 0x0c, 0x02, 0xf4, 0xfb, 0xcd, 0xfe, 0xf9, 0x61, 0x52, 0xd2, 0x4b, 0x83, 0x00,
+	0x00, 0x03, 0x00, 0xb5, 0x00, 0x00, 0x07, 0x46, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x18, 0x00, 0x1f,
+	0x00, 0x6a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x0b, 0x09, 0x02, 0x06,
+	0x07, 0x01, 0x06, 0x6a, 0x08, 0x01, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02,
+	0x02, 0x1a, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x02, 0x01,
+	0x02, 0x85, 0x03, 0x01, 0x01, 0x0b, 0x09, 0x02, 0x06, 0x07, 0x01, 0x06, 0x6a, 0x08, 0x01, 0x07,
+	0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x1a, 0x19, 0x19, 0x00, 0x00, 0x19, 0x1f, 0x19, 0x1f, 0x1b, 0x1a, 0x18, 0x17, 0x13, 0x12, 0x00,
+	0x11, 0x00, 0x11, 0x14, 0x11, 0x11, 0x14, 0x11, 0x0c, 0x07, 0x1b, 0x2b, 0x21, 0x37, 0x24, 0x00,
+	0x37, 0x36, 0x00, 0x25, 0x37, 0x21, 0x07, 0x04, 0x00, 0x07, 0x06, 0x00, 0x05, 0x07, 0x03, 0x06,
+	0x06, 0x07, 0x06, 0x16, 0x17, 0x01, 0x03, 0x36, 0x36, 0x37, 0x36, 0x26, 0x02, 0xe3, 0x28, 0xfe,
+	0xaf, 0xfe, 0xfb, 0x2e, 0x2f, 0x01, 0x7a, 0x01, 0x56, 0x28, 0x01, 0x0e, 0x28, 0x01, 0x50, 0x01,
+	0x06, 0x2f, 0x2e, 0xfe, 0x85, 0xfe, 0xab, 0x28, 0x36, 0xe3, 0xc7, 0x20, 0x20, 0x7e, 0xe3, 0x01,
+	0x97, 0x89, 0xe3, 0xc6, 0x20, 0x20, 0x7d, 0xca, 0x0c, 0x01, 0x26, 0xe8, 0xe9, 0x01, 0x25, 0x0c,
+	0xca, 0xca, 0x0c, 0xfe, 0xdb, 0xe9, 0xe8, 0xfe, 0xda, 0x0c, 0xca, 0x04, 0x3d, 0x03, 0xb7, 0x9f,
+	0xa0, 0xb6, 0x02, 0x02, 0xb1, 0xfd, 0x4f, 0x02, 0xb6, 0xa0, 0x9f, 0xb7, 0x00, 0x01, 0x00, 0x31,
+	0x00, 0x00, 0x06, 0x40, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01,
+	0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00,
+	0x1a, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x21, 0x13,
+	0x01, 0x33, 0x01, 0x01, 0x21, 0x03, 0x01, 0x31, 0x02, 0x6b, 0xfe, 0xd1, 0x01, 0x67, 0xca, 0x01,
+	0xa9, 0xf9, 0xfd, 0xaf, 0x01, 0x3a, 0xfe, 0x9a, 0xd8, 0xfe, 0x3f, 0x02, 0xd9, 0x02, 0xef, 0xfe,
+	0x0e, 0x01, 0xf2, 0xfd, 0x46, 0xfc, 0xf2, 0x02, 0x11, 0xfd, 0xef, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0xfe, 0x7f, 0x06, 0x36, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x51, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1d, 0x05, 0x01, 0x03, 0x03, 0x1a, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x02, 0x60, 0x00, 0x02, 0x02,
+	0x1b, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x60, 0x00, 0x01, 0x01, 0x1e, 0x01, 0x4e, 0x1b, 0x40,
+	0x1d, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x04, 0x01, 0x00, 0x00, 0x02, 0x60, 0x00, 0x02, 0x02,
+	0x1d, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x60, 0x00, 0x01, 0x01, 0x1e, 0x01, 0x4e, 0x59, 0x40,
+	0x09, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x25, 0x33, 0x03, 0x23, 0x13,
+	0x21, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21, 0x05, 0x38, 0x96, 0x76, 0xdc, 0x4d, 0xfb, 0xe4, 0x01,
+	0x27, 0x01, 0x34, 0xfe, 0x01, 0xfa, 0xfe, 0x01, 0x34, 0xd2, 0xfd, 0xad, 0x01, 0x81, 0x05, 0xc8,
+	0xfb, 0x0a, 0x04, 0xf6, 0x00, 0x01, 0x01, 0x11, 0x00, 0x00, 0x06, 0x19, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x4c, 0xb5, 0x01, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15,
+	0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x1a, 0x4d, 0x05, 0x01,
+	0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x15, 0x03, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02,
+	0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x05, 0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x12, 0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21, 0x13,
+	0x06, 0x23, 0x22, 0x26, 0x37, 0x13, 0x21, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x01,
+	0x03, 0xbe, 0x77, 0xc1, 0xc5, 0xef, 0xaf, 0x31, 0x62, 0x01, 0x35, 0x60, 0x1e, 0x49, 0x7c, 0xa6,
+	0x9b, 0x84, 0x01, 0x34, 0xfe, 0xd9, 0x02, 0x54, 0x5a, 0xec, 0xf8, 0x01, 0xea, 0xfe, 0x1c, 0x92,
+	0x78, 0x5a, 0x02, 0x94, 0xfa, 0x38, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x08, 0x84,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x44, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02,
+	0x00, 0x00, 0x1a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05,
+	0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x01, 0x05,
+	0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x03, 0x21, 0x13,
+	0x21, 0x03, 0x21, 0x13, 0x21, 0x01, 0xad, 0x01, 0x27, 0x01, 0x34, 0xfe, 0x01, 0x8f, 0xfe, 0x01,
+	0x2c, 0xfe, 0x01, 0x8d, 0xfe, 0x01, 0x34, 0xfe, 0xd9, 0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0xfb,
+	0x0a, 0x04, 0xf6, 0xfa, 0x38, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0xfe, 0x75, 0x08, 0x94,
+	0x05, 0xc8, 0x00, 0x0f, 0x00, 0x59, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x05, 0x03, 0x02,
+	0x01, 0x01, 0x1a, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02, 0x00, 0x60, 0x00, 0x00, 0x00, 0x1b, 0x4d,
+	0x06, 0x04, 0x02, 0x02, 0x02, 0x07, 0x60, 0x00, 0x07, 0x07, 0x1e, 0x07, 0x4e, 0x1b, 0x40, 0x20,
+	0x05, 0x03, 0x02, 0x01, 0x02, 0x01, 0x85, 0x06, 0x04, 0x02, 0x02, 0x02, 0x00, 0x60, 0x00, 0x00,
+	0x00, 0x1d, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02, 0x07, 0x60, 0x00, 0x07, 0x07, 0x1e, 0x07, 0x4e,
+	0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x08, 0x07, 0x1e, 0x2b, 0x21,
+	0x21, 0x01, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x33, 0x03, 0x23, 0x07,
+	0x19, 0xf9, 0x94, 0x01, 0x27, 0x01, 0x34, 0xfe, 0x01, 0x92, 0xfe, 0x01, 0x34, 0xfe, 0x01, 0x92,
+	0xfe, 0x01, 0x34, 0xfe, 0x87, 0x77, 0xdc, 0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0xfb, 0x0a, 0x04,
+	0xf6, 0xfb, 0x0a, 0xfd, 0xa3, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x17, 0x00, 0x00, 0x07, 0x30,
+	0x05, 0xc8, 0x00, 0x0f, 0x00, 0x18, 0x00, 0x58, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67,
+	0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00,
+	0x00, 0x18, 0x16, 0x12, 0x10, 0x00, 0x0f, 0x00, 0x0e, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b,
+	0x21, 0x13, 0x21, 0x37, 0x21, 0x03, 0x21, 0x32, 0x16, 0x17, 0x16, 0x07, 0x02, 0x07, 0x06, 0x21,
+	0x27, 0x21, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21, 0x01, 0xd2, 0xff, 0xfe, 0x46, 0x28, 0x02,
+	0xef, 0x77, 0x01, 0x15, 0xe4, 0xa9, 0x42, 0x95, 0x2f, 0x34, 0xdb, 0x94, 0xfe, 0x98, 0xc9, 0x01,
+	0x0d, 0xb8, 0xbe, 0x1b, 0x19, 0x8f, 0xb9, 0xfe, 0xf5, 0x04, 0xfd, 0xcb, 0xfd, 0xaa, 0x1d, 0x2f,
+	0x6a, 0xed, 0xfe, 0xfd, 0x79, 0x53, 0xbf, 0x7d, 0x87, 0x7d, 0x73, 0x00, 0x00, 0x03, 0x00, 0xad,
+	0x00, 0x00, 0x08, 0x4f, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x10, 0x00, 0x19, 0x00, 0x5e, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x68, 0x02, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x5f, 0x08, 0x04, 0x07, 0x03, 0x01, 0x01, 0x1b, 0x01,
+	0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03,
+	0x06, 0x68, 0x00, 0x05, 0x05, 0x01, 0x5f, 0x08, 0x04, 0x07, 0x03, 0x01, 0x01, 0x1d, 0x01, 0x4e,
+	0x59, 0x40, 0x18, 0x04, 0x04, 0x00, 0x00, 0x19, 0x17, 0x13, 0x11, 0x04, 0x10, 0x04, 0x0f, 0x09,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x07, 0x17, 0x2b, 0x21, 0x01, 0x21, 0x01,
+	0x21, 0x01, 0x21, 0x03, 0x33, 0x20, 0x17, 0x16, 0x07, 0x02, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32,
+	0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x05, 0xfa, 0x01, 0x27, 0x01, 0x2e, 0xfe, 0xd9, 0xf9, 0x85,
+	0x01, 0x27, 0x01, 0x2e, 0x77, 0xb3, 0x01, 0x68, 0x6b, 0x95, 0x2f, 0x34, 0xdb, 0x95, 0xfe, 0x8e,
+	0x60, 0xab, 0xb8, 0xc8, 0x1b, 0x19, 0x96, 0xb7, 0xae, 0x05, 0xc8, 0xfa, 0x38, 0x05, 0xc8, 0xfd,
+	0xaa, 0x4c, 0x6a, 0xed, 0xfe, 0xfd, 0x79, 0x53, 0xbf, 0x7d, 0x87, 0x7d, 0x73, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0xfb, 0x05, 0xc8, 0x00, 0x0d, 0x00, 0x16, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x00,
+	0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x16, 0x14, 0x10, 0x0e, 0x00, 0x0d, 0x00, 0x0c, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33,
+	0x01, 0x21, 0x03, 0x21, 0x32, 0x16, 0x17, 0x16, 0x07, 0x02, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32,
+	0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0xad, 0x01, 0x27, 0x01, 0x34, 0x77, 0x01, 0x06, 0xe4, 0xa9,
+	0x42, 0x95, 0x2f, 0x34, 0xdb, 0x95, 0xfe, 0x98, 0xb9, 0xfe, 0xb8, 0xbe, 0x1b, 0x19, 0x8f, 0xba,
+	0xfb, 0x05, 0xc8, 0xfd, 0xaa, 0x1c, 0x30, 0x6a, 0xed, 0xfe, 0xfd, 0x79, 0x53, 0xbf, 0x7d, 0x87,
+	0x7d, 0x73, 0x00, 0x00, 0x00, 0x01, 0x00, 0x51, 0xff, 0xdb, 0x06, 0x35, 0x05, 0xed, 0x00, 0x18,
+	0x00, 0x5b, 0x40, 0x0a, 0x0e, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x20,
+	0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e,
+	0x59, 0x40, 0x09, 0x24, 0x23, 0x22, 0x11, 0x12, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16,
+	0x33, 0x32, 0x00, 0x37, 0x21, 0x37, 0x21, 0x36, 0x26, 0x23, 0x22, 0x05, 0x37, 0x36, 0x33, 0x20,
+	0x00, 0x03, 0x02, 0x00, 0x21, 0x20, 0x51, 0x2e, 0xcc, 0xdf, 0xe7, 0x01, 0x56, 0x2b, 0xfd, 0x26,
+	0x28, 0x02, 0xd4, 0x14, 0xcc, 0xd3, 0xbc, 0xfe, 0xda, 0x30, 0xff, 0xf7, 0x01, 0x80, 0x01, 0x48,
+	0x4b, 0x49, 0xfe, 0x18, 0xfe, 0x8f, 0xfe, 0xd1, 0x3b, 0xe3, 0x6e, 0x01, 0x18, 0xdb, 0xc6, 0xd2,
+	0xe7, 0x5f, 0xf1, 0x39, 0xfe, 0x6d, 0xfe, 0x8a, 0xfe, 0x91, 0xfe, 0x66, 0x00, 0x02, 0x00, 0xad,
+	0xff, 0xdb, 0x08, 0xc3, 0x05, 0xed, 0x00, 0x12, 0x00, 0x1e, 0x00, 0xa1, 0x4b, 0xb0, 0x0e, 0x50,
+	0x58, 0x40, 0x21, 0x00, 0x01, 0x00, 0x04, 0x06, 0x01, 0x04, 0x68, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x08, 0x05, 0x02, 0x03,
+	0x03, 0x20, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x04,
+	0x06, 0x01, 0x04, 0x68, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x1f, 0x4d, 0x08, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x20, 0x03, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x00, 0x02, 0x07, 0x02, 0x00, 0x07, 0x80,
+	0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69, 0x00, 0x01, 0x00, 0x04, 0x06, 0x01, 0x04, 0x68,
+	0x08, 0x01, 0x05, 0x05, 0x1d, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22,
+	0x03, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x14, 0x13, 0x00, 0x00, 0x1a, 0x18, 0x13, 0x1e, 0x14, 0x1e,
+	0x00, 0x12, 0x00, 0x12, 0x12, 0x24, 0x22, 0x11, 0x11, 0x0a, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21,
+	0x03, 0x33, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x21, 0x20, 0x00, 0x13, 0x23, 0x03,
+	0x25, 0x32, 0x12, 0x13, 0x12, 0x02, 0x23, 0x22, 0x02, 0x03, 0x02, 0x12, 0xad, 0x01, 0x27, 0x01,
+	0x34, 0x80, 0xdb, 0x68, 0x01, 0x8e, 0x01, 0x25, 0x01, 0x3c, 0x01, 0x09, 0x4a, 0x4a, 0xfe, 0x54,
+	0xfe, 0xc4, 0xfe, 0xd8, 0xfe, 0xf6, 0x28, 0xdc, 0x80, 0x03, 0x86, 0xa8, 0xf9, 0x38, 0x37, 0x7b,
+	0xa3, 0xa3, 0xf9, 0x38, 0x36, 0x79, 0x05, 0xc8, 0xfd, 0x7c, 0x01, 0x54, 0x01, 0x55, 0xfe, 0x69,
+	0xfe, 0x8e, 0xfe, 0x8e, 0xfe, 0x69, 0x01, 0x5b, 0x01, 0x4f, 0xfd, 0x7b, 0x94, 0x01, 0x3a, 0x01,
+	0x1a, 0x01, 0x12, 0x01, 0x3a, 0xfe, 0xc5, 0xfe, 0xeb, 0xfe, 0xee, 0xfe, 0xc2, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x3e, 0x00, 0x00, 0x06, 0x3a, 0x05, 0xc8, 0x00, 0x17, 0x00, 0x20, 0x00, 0x4e,
+	0xb5, 0x0c, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05,
+	0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02,
+	0x04, 0x69, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01,
+	0x4e, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11, 0x2e, 0x13, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23,
+	0x06, 0x01, 0x07, 0x21, 0x36, 0x3f, 0x03, 0x36, 0x37, 0x26, 0x26, 0x37, 0x36, 0x37, 0x36, 0x36,
+	0x33, 0x21, 0x01, 0x21, 0x01, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x33, 0x04, 0x5d, 0xaf,
+	0x88, 0xfe, 0xcc, 0x2d, 0xfe, 0x79, 0x62, 0x6f, 0x39, 0x23, 0x57, 0x85, 0x8a, 0xaf, 0x8e, 0x1f,
+	0x28, 0xab, 0x52, 0xb2, 0xf1, 0x01, 0xbf, 0xfe, 0xd9, 0xfe, 0xd1, 0x01, 0x01, 0x87, 0xb0, 0xb3,
+	0x18, 0x19, 0x8d, 0xba, 0x73, 0x02, 0x61, 0x7c, 0xfe, 0x58, 0x3d, 0x5e, 0x83, 0x43, 0x29, 0x69,
+	0x9c, 0x48, 0x28, 0xc8, 0x9f, 0xc7, 0x7b, 0x3b, 0x22, 0xfa, 0x38, 0x05, 0x09, 0x78, 0x78, 0x7c,
+	0x7c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86, 0xff, 0xe7, 0x05, 0x2a, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x16, 0x00, 0x75, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x02, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x22,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x02, 0x02, 0x1c, 0x4d, 0x00,
+	0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x02, 0x02, 0x1c,
+	0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x1d, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x22,
+	0x22, 0x11, 0x11, 0x24, 0x21, 0x06, 0x07, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x21, 0x03, 0x21, 0x13, 0x26, 0x23, 0x22, 0x03, 0x02, 0x33, 0x32, 0x37,
+	0x03, 0x4b, 0xc5, 0xbc, 0xac, 0x98, 0x31, 0x39, 0x01, 0x51, 0xf3, 0x51, 0x7d, 0x01, 0x28, 0xdb,
+	0xfe, 0xd8, 0xb8, 0x6b, 0x37, 0xf6, 0x4d, 0x46, 0xb3, 0x78, 0x94, 0xb6, 0xcf, 0x01, 0x2b, 0xf5,
+	0x01, 0x1c, 0x01, 0x40, 0x19, 0xfb, 0xb6, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00,
+	0x00, 0x02, 0x00, 0x9f, 0xff, 0xe7, 0x05, 0x47, 0x06, 0x60, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2d,
+	0x40, 0x2a, 0x11, 0x01, 0x02, 0x4a, 0x00, 0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x69, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x22, 0x01, 0x4e, 0x24, 0x25, 0x33, 0x34, 0x24, 0x21, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x36, 0x33,
+	0x32, 0x12, 0x07, 0x02, 0x00, 0x21, 0x20, 0x02, 0x13, 0x12, 0x00, 0x21, 0x33, 0x32, 0x37, 0x07,
+	0x06, 0x23, 0x23, 0x22, 0x06, 0x03, 0x07, 0x06, 0x12, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x02, 0x36, 0xb5, 0xe6, 0xc8, 0xad, 0x31, 0x37, 0xfe, 0x9e, 0xfe, 0xfa, 0xfe, 0xec, 0xc3,
+	0x4a, 0x5b, 0x01, 0x89, 0x01, 0x45, 0x21, 0x94, 0x80, 0x26, 0x65, 0x96, 0x20, 0xae, 0xdc, 0x6a,
+	0x08, 0x2f, 0x56, 0x71, 0x71, 0xa8, 0x24, 0x21, 0x4b, 0x6b, 0x8f, 0x03, 0x6b, 0xd3, 0xfe, 0xe0,
+	0xf7, 0xfe, 0xf0, 0xfe, 0xd0, 0x01, 0x5b, 0x01, 0x74, 0x01, 0xc7, 0x01, 0xae, 0x35, 0xbe, 0x30,
+	0xee, 0xfe, 0x2f, 0x22, 0xe8, 0xfe, 0xf7, 0xcd, 0xb4, 0xa2, 0xa3, 0x00, 0x00, 0x03, 0x00, 0x96,
+	0x00, 0x00, 0x05, 0x14, 0x04, 0x4a, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x20, 0x00, 0x63, 0xb5, 0x08,
+	0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03,
+	0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00,
+	0x20, 0x1e, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07, 0x07, 0x17,
+	0x2b, 0x33, 0x13, 0x21, 0x20, 0x16, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x06, 0x21,
+	0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26,
+	0x23, 0x23, 0x96, 0xdb, 0x01, 0xcb, 0x01, 0x08, 0xd0, 0x1a, 0x14, 0x94, 0x88, 0x90, 0x72, 0x16,
+	0x1f, 0xf6, 0xfe, 0xe7, 0xba, 0x5a, 0xd3, 0x80, 0x11, 0x11, 0x87, 0xa5, 0x66, 0x22, 0x66, 0x91,
+	0x94, 0x0f, 0x0b, 0x76, 0x8f, 0x6b, 0x04, 0x4a, 0x78, 0x82, 0x65, 0x89, 0x24, 0x23, 0x8e, 0x6f,
+	0x9b, 0x83, 0xb3, 0x33, 0x55, 0x52, 0x57, 0xa7, 0x4b, 0x4b, 0x3b, 0x3b, 0x00, 0x01, 0x00, 0x96,
+	0x00, 0x00, 0x04, 0x17, 0x04, 0x4a, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1b,
+	0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03,
+	0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11,
+	0x11, 0x04, 0x07, 0x18, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x21, 0x03, 0x96, 0xdb, 0x02, 0xa6, 0x2a,
+	0xfe, 0xa1, 0xb1, 0x04, 0x4a, 0xd2, 0xfc, 0x88, 0x00, 0x02, 0xff, 0xc6, 0xfe, 0xa7, 0x05, 0x33,
+	0x04, 0x4a, 0x00, 0x0e, 0x00, 0x15, 0x00, 0xea, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x27, 0x00,
+	0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05,
+	0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x1f, 0x08, 0x05,
+	0x02, 0x03, 0x00, 0x03, 0x53, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09,
+	0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09,
+	0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x09, 0x07, 0x02, 0x03,
+	0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1f, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53, 0x00, 0x06, 0x06, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04,
+	0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1f, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53, 0x00, 0x06, 0x06,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00,
+	0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x16, 0x0f, 0x0f, 0x00, 0x00, 0x0f,
+	0x15, 0x0f, 0x15, 0x11, 0x10, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x0a, 0x07,
+	0x1b, 0x2b, 0x03, 0x13, 0x33, 0x36, 0x12, 0x37, 0x37, 0x21, 0x03, 0x33, 0x03, 0x23, 0x13, 0x21,
+	0x03, 0x01, 0x13, 0x23, 0x07, 0x06, 0x02, 0x07, 0x3a, 0x6b, 0x65, 0x86, 0xb9, 0x33, 0x18, 0x03,
+	0x13, 0xb4, 0xa0, 0x6c, 0xdc, 0x45, 0xfc, 0xca, 0x45, 0x02, 0xc2, 0x8e, 0xf1, 0x02, 0x27, 0x9a,
+	0x72, 0xfe, 0xa7, 0x02, 0x1e, 0x88, 0x01, 0x85, 0xfe, 0x7a, 0xfc, 0x7b, 0xfd, 0xe2, 0x01, 0x59,
+	0xfe, 0xa7, 0x02, 0x1e, 0x02, 0xc9, 0x0c, 0xc2, 0xfe, 0xa2, 0x9d, 0x00, 0x00, 0x02, 0x00, 0x83,
+	0xff, 0xe7, 0x04, 0xaa, 0x04, 0x63, 0x00, 0x10, 0x00, 0x15, 0x00, 0x29, 0x40, 0x26, 0x00, 0x04,
+	0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x21, 0x11, 0x21, 0x12, 0x24,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32,
+	0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x04, 0x37, 0x29, 0xc3, 0xb8,
+	0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a,
+	0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe,
+	0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x00, 0x01, 0x00, 0x05,
+	0x00, 0x00, 0x06, 0x38, 0x04, 0x4a, 0x00, 0x3d, 0x00, 0x6c, 0xb6, 0x2f, 0x0e, 0x02, 0x04, 0x00,
+	0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x0a, 0x01, 0x00, 0x06, 0x01, 0x04, 0x03,
+	0x00, 0x04, 0x67, 0x08, 0x01, 0x02, 0x02, 0x01, 0x61, 0x0c, 0x0b, 0x09, 0x03, 0x01, 0x01, 0x1c,
+	0x4d, 0x07, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x0a, 0x01, 0x00, 0x06,
+	0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x08, 0x01, 0x02, 0x02, 0x01, 0x61, 0x0c, 0x0b, 0x09, 0x03,
+	0x01, 0x01, 0x1c, 0x4d, 0x07, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x00, 0x3d, 0x00, 0x3d, 0x3c, 0x3b, 0x36, 0x35, 0x1c, 0x15, 0x11, 0x11, 0x15, 0x1c, 0x11,
+	0x15, 0x11, 0x0d, 0x07, 0x1f, 0x2b, 0x01, 0x03, 0x32, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x07,
+	0x06, 0x07, 0x07, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17, 0x16, 0x17, 0x21, 0x26, 0x27,
+	0x27, 0x26, 0x27, 0x23, 0x03, 0x23, 0x13, 0x23, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x36, 0x37,
+	0x36, 0x37, 0x37, 0x36, 0x36, 0x37, 0x26, 0x27, 0x27, 0x26, 0x27, 0x37, 0x32, 0x16, 0x17, 0x17,
+	0x16, 0x16, 0x33, 0x13, 0x04, 0x2f, 0x5c, 0x2c, 0x57, 0x4e, 0x20, 0x4e, 0x82, 0xa4, 0x25, 0x53,
+	0x37, 0x3b, 0x5c, 0x7f, 0x55, 0x4b, 0x26, 0x1b, 0x06, 0x14, 0x18, 0x21, 0xfe, 0xe2, 0x09, 0x02,
+	0x1b, 0x52, 0x3b, 0x25, 0x5d, 0xfc, 0x5d, 0x25, 0x5f, 0xb8, 0x41, 0x03, 0x12, 0xfe, 0xe2, 0x3c,
+	0x3b, 0x30, 0x0c, 0x3d, 0x54, 0x6e, 0x60, 0x6d, 0x27, 0x16, 0x17, 0x51, 0x25, 0x9a, 0x6b, 0x19,
+	0x0a, 0x1c, 0x3c, 0x2d, 0x5c, 0x04, 0x4a, 0xfe, 0x34, 0x41, 0x7b, 0x39, 0x89, 0x4e, 0xb9, 0x04,
+	0x53, 0x57, 0x87, 0x2d, 0x1b, 0x58, 0x74, 0x55, 0x11, 0x45, 0x56, 0x47, 0x1a, 0x04, 0x5e, 0xfc,
+	0x5d, 0xfe, 0x2b, 0x01, 0xd5, 0x5d, 0xfc, 0x5e, 0x04, 0x1a, 0x47, 0x56, 0x45, 0x11, 0x55, 0x74,
+	0x58, 0x1b, 0x2d, 0x87, 0x57, 0x53, 0x04, 0xb9, 0x4e, 0x89, 0x39, 0x7b, 0x41, 0x01, 0xcc, 0x00,
+	0x00, 0x01, 0x00, 0x40, 0xff, 0xe7, 0x04, 0x47, 0x04, 0x63, 0x00, 0x24, 0x00, 0x37, 0x40, 0x34,
+	0x14, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x2a, 0x23, 0x24, 0x21, 0x24,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23,
+	0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x40, 0x29, 0xab, 0x8d, 0x89, 0x85,
+	0x10, 0x11, 0x8b, 0x9c, 0x35, 0x22, 0x39, 0x8b, 0x9a, 0x0e, 0x0d, 0x61, 0x82, 0x7b, 0xb1, 0x25,
+	0xb3, 0xbd, 0xf0, 0xc2, 0x1b, 0x11, 0x81, 0x70, 0x71, 0x5d, 0x14, 0x1f, 0xfe, 0xcf, 0xf6, 0x9f,
+	0x17, 0xcb, 0x3f, 0x50, 0x50, 0x56, 0x56, 0xaa, 0x47, 0x48, 0x43, 0x44, 0x35, 0xb8, 0x31, 0x88,
+	0x88, 0x53, 0x84, 0x30, 0x25, 0x87, 0x62, 0x9c, 0xbb, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x05, 0x32, 0x04, 0x4a, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00,
+	0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x01, 0x21, 0x03, 0x21,
+	0x13, 0x01, 0x94, 0xdb, 0x01, 0x16, 0x8f, 0x02, 0x14, 0x01, 0x28, 0xdb, 0xfe, 0xea, 0x8e, 0xfd,
+	0xed, 0x04, 0x4a, 0xfd, 0x35, 0x02, 0xcb, 0xfb, 0xb6, 0x02, 0xcb, 0xfd, 0x35, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x05, 0x32, 0x06, 0x44, 0x00, 0x09, 0x00, 0x19, 0x00, 0x88,
+	0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x1d, 0x06,
+	0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05,
+	0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07,
+	0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x14, 0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x10, 0x0e, 0x0b, 0x0a, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x09, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x01, 0x21, 0x03, 0x21,
+	0x13, 0x01, 0x13, 0x33, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x33, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x94, 0xdb, 0x01, 0x16, 0x8f, 0x02, 0x14, 0x01, 0x28, 0xdb, 0xfe, 0xea, 0x8e, 0xfd,
+	0xed, 0xa4, 0xd2, 0x05, 0x11, 0x2d, 0x3e, 0x3e, 0x4f, 0x11, 0x03, 0xd2, 0x20, 0xc7, 0xa6, 0xa7,
+	0x86, 0x04, 0x4a, 0xfd, 0x35, 0x02, 0xcb, 0xfb, 0xb6, 0x02, 0xcb, 0xfd, 0x35, 0x06, 0x44, 0x18,
+	0x54, 0x53, 0x54, 0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x04, 0x88,
+	0x04, 0x4a, 0x00, 0x20, 0x00, 0x70, 0xb5, 0x13, 0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x26, 0x00, 0x01, 0x03, 0x05, 0x03, 0x01, 0x05, 0x80, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00,
+	0x1c, 0x4d, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x01, 0x03,
+	0x05, 0x03, 0x01, 0x05, 0x80, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x07, 0x06, 0x02, 0x04, 0x04,
+	0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x20, 0x00, 0x20, 0x14, 0x1b, 0x21, 0x25,
+	0x11, 0x11, 0x08, 0x07, 0x1c, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x32, 0x36, 0x37, 0x37, 0x36, 0x36,
+	0x37, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17,
+	0x21, 0x26, 0x27, 0x26, 0x27, 0x23, 0x03, 0x94, 0xdb, 0x01, 0x0b, 0x5d, 0x26, 0x3c, 0x69, 0x27,
+	0x5c, 0x87, 0x6a, 0x2c, 0x25, 0x12, 0x30, 0x33, 0x47, 0x26, 0x67, 0x7c, 0x5d, 0x59, 0x25, 0x20,
+	0x36, 0x18, 0xfe, 0xd1, 0x0d, 0x24, 0x37, 0x37, 0x1d, 0x5d, 0x04, 0x4a, 0xfe, 0x2e, 0x4a, 0x8e,
+	0x34, 0x7c, 0x49, 0x01, 0xb9, 0x27, 0x5e, 0x33, 0x80, 0x1e, 0x16, 0x6a, 0x80, 0x5a, 0xb2, 0x2f,
+	0x22, 0x98, 0xe1, 0x37, 0xfe, 0x2e, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x5a,
+	0x04, 0x4a, 0x00, 0x0e, 0x00, 0x49, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x05, 0x04, 0x02, 0x02,
+	0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c,
+	0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x14, 0x11, 0x06, 0x07, 0x1a, 0x2b, 0x33,
+	0x37, 0x32, 0x37, 0x36, 0x13, 0x37, 0x21, 0x03, 0x21, 0x13, 0x21, 0x07, 0x02, 0x00, 0x1e, 0x27,
+	0x89, 0x4d, 0x52, 0x50, 0x21, 0x03, 0x7c, 0xdb, 0xfe, 0xd8, 0xb4, 0xfe, 0xc1, 0x04, 0x67, 0xfe,
+	0xe5, 0xc6, 0xa3, 0xac, 0x01, 0x91, 0xa4, 0xfb, 0xb6, 0x03, 0x85, 0x12, 0xfd, 0xfd, 0xfe, 0x90,
+	0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x06, 0x30, 0x04, 0x4a, 0x00, 0x0e, 0x00, 0x4a, 0xb7, 0x0d,
+	0x09, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03,
+	0x03, 0x00, 0x5f, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02,
+	0x4e, 0x1b, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x05,
+	0x04, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e,
+	0x13, 0x11, 0x13, 0x11, 0x06, 0x07, 0x1a, 0x2b, 0x33, 0x13, 0x21, 0x13, 0x33, 0x01, 0x21, 0x03,
+	0x21, 0x13, 0x23, 0x01, 0x23, 0x03, 0x03, 0x96, 0xdb, 0x01, 0x1d, 0xb4, 0x02, 0x01, 0xc0, 0x01,
+	0x2c, 0xdb, 0xfe, 0xf0, 0x97, 0x01, 0xfe, 0x65, 0xbe, 0x85, 0x95, 0x04, 0x4a, 0xfd, 0x09, 0x02,
+	0xf7, 0xfb, 0xb6, 0x02, 0xf5, 0xfd, 0x55, 0x02, 0xa4, 0xfd, 0x12, 0x00, 0x00, 0x01, 0x00, 0x96,
+	0x00, 0x00, 0x05, 0x1a, 0x04, 0x4a, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06,
+	0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01,
+	0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07,
+	0x1b, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x96, 0xdb,
+	0x01, 0x28, 0x55, 0x01, 0x59, 0x55, 0x01, 0x28, 0xdb, 0xfe, 0xd8, 0x61, 0xfe, 0xa7, 0x61, 0x04,
+	0x4a, 0xfe, 0x58, 0x01, 0xa8, 0xfb, 0xb6, 0x01, 0xe9, 0xfe, 0x17, 0x00, 0x00, 0x02, 0x00, 0x83,
+	0xff, 0xe7, 0x05, 0x3b, 0x04, 0x63, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x22, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33,
+	0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06,
+	0x16, 0x02, 0x66, 0xf6, 0xed, 0x34, 0x35, 0x01, 0x6a, 0xfb, 0xfb, 0xef, 0x34, 0x35, 0xfe, 0x95,
+	0xd9, 0x70, 0xaa, 0x25, 0x23, 0x57, 0x6d, 0x6d, 0xaa, 0x24, 0x23, 0x55, 0x19, 0x01, 0x3b, 0x01,
+	0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6,
+	0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x00, 0x00, 0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x05, 0x1a,
+	0x04, 0x4a, 0x00, 0x07, 0x00, 0x3e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x05, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x96, 0xdb, 0x03, 0xa9,
+	0xdb, 0xfe, 0xd8, 0xb4, 0xfe, 0xa7, 0xb4, 0x04, 0x4a, 0xfb, 0xb6, 0x03, 0x85, 0xfc, 0x7b, 0x00,
+	0x00, 0x02, 0x00, 0x45, 0xfe, 0x75, 0x05, 0x38, 0x04, 0x63, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x5d,
+	0x40, 0x0a, 0x04, 0x01, 0x05, 0x01, 0x0e, 0x01, 0x03, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04,
+	0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x21, 0x4d,
+	0x00, 0x04, 0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e,
+	0x59, 0x40, 0x09, 0x22, 0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x21, 0x01,
+	0x21, 0x07, 0x36, 0x33, 0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32,
+	0x13, 0x12, 0x23, 0x22, 0x07, 0x01, 0x6d, 0xfe, 0xd8, 0x01, 0x2a, 0x01, 0x28, 0x24, 0xc6, 0xbc,
+	0xac, 0x97, 0x31, 0x39, 0xfe, 0xb0, 0xf3, 0x51, 0x7e, 0x23, 0x6c, 0x37, 0xf6, 0x4c, 0x47, 0xb3,
+	0x78, 0x95, 0xfe, 0x75, 0x05, 0xd5, 0xb6, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe, 0xe4, 0xfe, 0xc0, 0x19,
+	0xb0, 0x13, 0x01, 0x7d, 0x01, 0x61, 0xaf, 0x00, 0x00, 0x01, 0x00, 0x81, 0xff, 0xe7, 0x04, 0xee,
+	0x04, 0x63, 0x00, 0x13, 0x00, 0x29, 0x40, 0x26, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x03, 0x02,
+	0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x23, 0x23, 0x23, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x25,
+	0x07, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x04, 0x4d, 0x29, 0xdd, 0xa3, 0xfe, 0xde, 0xfe, 0xff, 0x36, 0x73, 0x02, 0x75,
+	0xae, 0xa1, 0x2a, 0xc6, 0x72, 0xfe, 0xb1, 0x4a, 0x24, 0x99, 0xaa, 0x78, 0xe5, 0xcd, 0x31, 0x01,
+	0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x00, 0x01, 0x00, 0xc8,
+	0x00, 0x00, 0x04, 0xb2, 0x04, 0x4a, 0x00, 0x07, 0x00, 0x3e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c,
+	0x4d, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03,
+	0x01, 0x61, 0xb4, 0xfe, 0xb3, 0x27, 0x03, 0xc3, 0x27, 0xfe, 0xb3, 0xb4, 0x03, 0x85, 0xc5, 0xc5,
+	0xfc, 0x7b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x75, 0x05, 0x4e, 0x04, 0x4a, 0x00, 0x10,
+	0x00, 0x21, 0x40, 0x1e, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x21, 0x23, 0x12, 0x11, 0x04,
+	0x07, 0x1a, 0x2b, 0x25, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x02, 0x06, 0x21, 0x23, 0x37, 0x33,
+	0x32, 0x36, 0x37, 0x37, 0x01, 0xbe, 0xe3, 0x01, 0x23, 0x9c, 0x01, 0xc1, 0xf3, 0xfd, 0x56, 0xb7,
+	0xc3, 0xfe, 0xf6, 0x20, 0x27, 0x1c, 0x74, 0x83, 0x32, 0x37, 0x28, 0x04, 0x22, 0xfd, 0x38, 0x02,
+	0xc8, 0xfb, 0xc9, 0xfe, 0xdf, 0x7d, 0xc6, 0x2d, 0x44, 0x53, 0x00, 0x00, 0x00, 0x03, 0x00, 0x86,
+	0xfe, 0x75, 0x07, 0x53, 0x06, 0x2b, 0x00, 0x19, 0x00, 0x24, 0x00, 0x2f, 0x01, 0x8c, 0x40, 0x10,
+	0x0e, 0x0b, 0x02, 0x06, 0x01, 0x26, 0x01, 0x07, 0x06, 0x18, 0x01, 0x02, 0x00, 0x07, 0x03, 0x4c,
+	0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06,
+	0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50,
+	0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01,
+	0x01, 0x01, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d,
+	0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x24, 0x00,
+	0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d,
+	0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05,
+	0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85,
+	0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06,
+	0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50,
+	0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01,
+	0x01, 0x01, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d,
+	0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00,
+	0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d,
+	0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05,
+	0x1e, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01,
+	0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00,
+	0x00, 0x1d, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59,
+	0x59, 0x40, 0x16, 0x00, 0x00, 0x2f, 0x2d, 0x29, 0x27, 0x23, 0x21, 0x1d, 0x1b, 0x00, 0x19, 0x00,
+	0x19, 0x24, 0x22, 0x12, 0x24, 0x22, 0x0b, 0x07, 0x1b, 0x2b, 0x01, 0x13, 0x06, 0x23, 0x22, 0x02,
+	0x37, 0x36, 0x00, 0x33, 0x32, 0x17, 0x13, 0x21, 0x03, 0x36, 0x33, 0x32, 0x12, 0x07, 0x06, 0x00,
+	0x23, 0x22, 0x27, 0x03, 0x03, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01,
+	0x03, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x02, 0xa9, 0x60, 0x71, 0x8a, 0xcb,
+	0xbd, 0x31, 0x30, 0x01, 0x41, 0xcc, 0x98, 0x36, 0x71, 0x01, 0x0f, 0x71, 0x5b, 0x98, 0xca, 0xc5,
+	0x30, 0x31, 0xfe, 0xc1, 0xc9, 0x8a, 0x48, 0x60, 0x15, 0x3d, 0x3f, 0x6f, 0xb8, 0x1f, 0x1f, 0x65,
+	0x6e, 0x3a, 0x59, 0x01, 0x8a, 0x7b, 0x45, 0x3a, 0x73, 0xae, 0x1f, 0x1f, 0x64, 0x70, 0x3e, 0xfe,
+	0x75, 0x01, 0xe1, 0x62, 0x01, 0x3b, 0xf3, 0xf4, 0x01, 0x41, 0x63, 0x02, 0x37, 0xfd, 0xc9, 0x63,
+	0xfe, 0xbf, 0xf4, 0xf3, 0xfe, 0xc5, 0x62, 0xfe, 0x1f, 0x04, 0xe6, 0x30, 0xd1, 0x99, 0x9f, 0xc3,
+	0x34, 0x02, 0x68, 0xfd, 0x98, 0x34, 0xc3, 0x9f, 0x99, 0xd1, 0x00, 0x00, 0x00, 0x01, 0x00, 0x30,
+	0x00, 0x00, 0x05, 0x04, 0x04, 0x4a, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01,
+	0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00,
+	0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x03, 0x21, 0x13,
+	0x01, 0x33, 0x01, 0x13, 0x21, 0x03, 0x01, 0x30, 0x01, 0xd4, 0xe9, 0x01, 0x51, 0x93, 0x01, 0x15,
+	0xf0, 0xfe, 0x4f, 0xef, 0xfe, 0xaf, 0x9b, 0xfe, 0xcf, 0x02, 0x27, 0x02, 0x23, 0xfe, 0xa4, 0x01,
+	0x5c, 0xfd, 0xe4, 0xfd, 0xd2, 0x01, 0x6b, 0xfe, 0x95, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94,
+	0xfe, 0xa7, 0x05, 0x0d, 0x04, 0x4a, 0x00, 0x0b, 0x00, 0xbb, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x1e, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05,
+	0x05, 0x1b, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00,
+	0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1e, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x01,
+	0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01,
+	0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e,
+	0x59, 0x59, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x33, 0x03, 0x23,
+	0x13, 0x94, 0xdb, 0x01, 0x29, 0xb4, 0x01, 0x4d, 0xb4, 0x01, 0x28, 0xb4, 0xa0, 0x6c, 0xdc, 0x45,
+	0x04, 0x4a, 0xfc, 0x7b, 0x03, 0x85, 0xfc, 0x7b, 0xfd, 0xe2, 0x01, 0x59, 0x00, 0x01, 0x00, 0xc4,
+	0x00, 0x00, 0x04, 0xec, 0x04, 0x4a, 0x00, 0x11, 0x00, 0x4c, 0xb5, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a,
+	0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x15,
+	0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05, 0x01,
+	0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x12, 0x23,
+	0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21, 0x13, 0x06, 0x23, 0x22, 0x26, 0x37, 0x13, 0x21, 0x03,
+	0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x21, 0x03, 0x02, 0xe9, 0x52, 0x98, 0x89, 0xd2, 0x84, 0x27,
+	0x4a, 0x01, 0x28, 0x3e, 0x1c, 0x2d, 0x69, 0x61, 0x64, 0x66, 0x01, 0x28, 0xdb, 0x01, 0x9d, 0x31,
+	0xa7, 0xc4, 0x01, 0x73, 0xfe, 0xcc, 0x90, 0x62, 0x2a, 0x01, 0xfc, 0xfb, 0xb6, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x06, 0xf0, 0x04, 0x4a, 0x00, 0x0b, 0x00, 0x44, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00,
+	0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07,
+	0x1b, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x96, 0xdb,
+	0x01, 0x0e, 0xb4, 0x01, 0x2b, 0xb4, 0x01, 0x0d, 0xb4, 0x01, 0x2b, 0xb4, 0x01, 0x0e, 0xdb, 0x04,
+	0x4a, 0xfc, 0x7b, 0x03, 0x85, 0xfc, 0x7b, 0x03, 0x85, 0xfb, 0xb6, 0x00, 0x00, 0x01, 0x00, 0x94,
+	0xfe, 0xa7, 0x06, 0xc7, 0x04, 0x4a, 0x00, 0x0f, 0x00, 0xc9, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x21, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08,
+	0x01, 0x07, 0x07, 0x1b, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x06, 0x60, 0x00, 0x06, 0x06, 0x1e,
+	0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04,
+	0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07,
+	0x07, 0x1b, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x04, 0x02, 0x02, 0x00,
+	0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x4d,
+	0x05, 0x03, 0x02, 0x01, 0x01, 0x06, 0x60, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c,
+	0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x07, 0x4e, 0x1b,
+	0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03,
+	0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1d, 0x07, 0x4e, 0x59, 0x59, 0x59, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09,
+	0x07, 0x1d, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x33,
+	0x03, 0x23, 0x13, 0x94, 0xdb, 0x01, 0x0e, 0xb4, 0x01, 0x17, 0xb4, 0x01, 0x0e, 0xb4, 0x01, 0x17,
+	0xb4, 0x01, 0x0e, 0xb4, 0xa0, 0x6c, 0xdc, 0x45, 0x04, 0x4a, 0xfc, 0x7b, 0x03, 0x85, 0xfc, 0x7b,
+	0x03, 0x85, 0xfc, 0x7b, 0xfd, 0xe2, 0x01, 0x59, 0x00, 0x02, 0x00, 0xb3, 0x00, 0x00, 0x05, 0xf9,
+	0x04, 0x4a, 0x00, 0x0c, 0x00, 0x15, 0x00, 0x5a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1e,
+	0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x1c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0b, 0x21, 0x11, 0x11, 0x07, 0x07,
+	0x19, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x03, 0x21, 0x20, 0x16, 0x07, 0x06, 0x04, 0x21, 0x27,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x01, 0x42, 0xb4, 0xfe, 0xbd, 0x27, 0x02, 0x6b,
+	0x4d, 0x01, 0x1f, 0x01, 0x19, 0xc9, 0x26, 0x27, 0xfe, 0xe2, 0xfe, 0xd7, 0xd7, 0xfc, 0x88, 0x86,
+	0x13, 0x11, 0x63, 0x87, 0xff, 0x03, 0x85, 0xc5, 0xfe, 0x80, 0xa0, 0xbe, 0xc3, 0xa9, 0xb9, 0x53,
+	0x5e, 0x58, 0x4f, 0x00, 0x00, 0x03, 0x00, 0x94, 0x00, 0x00, 0x07, 0x1c, 0x04, 0x4a, 0x00, 0x0a,
+	0x00, 0x13, 0x00, 0x17, 0x00, 0x5d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x68, 0x05, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f,
+	0x08, 0x06, 0x07, 0x03, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x04,
+	0x03, 0x01, 0x04, 0x68, 0x05, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x08,
+	0x06, 0x07, 0x03, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x14, 0x14, 0x00, 0x00, 0x14,
+	0x17, 0x14, 0x17, 0x16, 0x15, 0x13, 0x11, 0x0d, 0x0b, 0x00, 0x0a, 0x00, 0x09, 0x21, 0x11, 0x09,
+	0x07, 0x18, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x33, 0x20, 0x16, 0x07, 0x06, 0x04, 0x21, 0x27, 0x33,
+	0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x01, 0x13, 0x21, 0x03, 0x94, 0xdb, 0x01, 0x22, 0x4d,
+	0xba, 0x01, 0x05, 0xc9, 0x25, 0x26, 0xfe, 0xe1, 0xfe, 0xeb, 0x73, 0x9d, 0x75, 0x86, 0x13, 0x11,
+	0x63, 0x73, 0xa1, 0x03, 0x00, 0xdb, 0x01, 0x22, 0xdb, 0x04, 0x4a, 0xfe, 0x80, 0xa4, 0xba, 0xc0,
+	0xac, 0xb9, 0x53, 0x5e, 0x58, 0x4f, 0xfd, 0xef, 0x04, 0x4a, 0xfb, 0xb6, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x05, 0x0f, 0x04, 0x4a, 0x00, 0x0a, 0x00, 0x13, 0x00, 0x4f, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x00, 0x00, 0x00, 0x1c, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00,
+	0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0d,
+	0x0b, 0x00, 0x0a, 0x00, 0x09, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x33,
+	0x20, 0x16, 0x07, 0x06, 0x04, 0x21, 0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x94,
+	0xdb, 0x01, 0x28, 0x4d, 0xf7, 0x01, 0x04, 0xca, 0x26, 0x27, 0xfe, 0xe2, 0xfe, 0xd7, 0x9b, 0xc0,
+	0x88, 0x86, 0x13, 0x11, 0x63, 0x87, 0xc3, 0x04, 0x4a, 0xfe, 0x80, 0xa0, 0xbe, 0xc3, 0xa9, 0xb9,
+	0x53, 0x5e, 0x58, 0x4f, 0x00, 0x01, 0x00, 0x3b, 0xff, 0xe7, 0x04, 0xc7, 0x04, 0x63, 0x00, 0x18,
+	0x00, 0x33, 0x40, 0x30, 0x0e, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x24, 0x23, 0x22, 0x11, 0x12,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x21, 0x37, 0x21, 0x36,
+	0x26, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x12, 0x03, 0x02, 0x00, 0x21, 0x22, 0x3b, 0x27,
+	0x84, 0xce, 0xa6, 0xcc, 0x2e, 0xfe, 0x42, 0x25, 0x01, 0xbe, 0x19, 0x82, 0xa7, 0xbd, 0xb0, 0x29,
+	0xb1, 0xce, 0x01, 0x30, 0xf3, 0x37, 0x3a, 0xfe, 0xa6, 0xfe, 0xd1, 0xf0, 0x20, 0xc4, 0x44, 0x9d,
+	0x9e, 0xb9, 0x8f, 0x87, 0x3f, 0xca, 0x2e, 0xfe, 0xde, 0xfe, 0xef, 0xfe, 0xdb, 0xfe, 0xdc, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x07, 0x2d, 0x04, 0x63, 0x00, 0x14, 0x00, 0x20, 0x00, 0xa5,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x00, 0x01, 0x06, 0x04, 0x01, 0x68, 0x00,
+	0x07, 0x07, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03, 0x1c, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61,
+	0x02, 0x08, 0x02, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29,
+	0x00, 0x04, 0x00, 0x01, 0x06, 0x04, 0x01, 0x68, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x00, 0x07, 0x07,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x21, 0x4d, 0x00, 0x02, 0x02, 0x1b, 0x4d, 0x09, 0x01, 0x06, 0x06,
+	0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x04, 0x00, 0x01,
+	0x06, 0x04, 0x01, 0x68, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x21, 0x4d, 0x00, 0x02, 0x02, 0x1d, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x08, 0x01,
+	0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1b, 0x16, 0x15, 0x01, 0x00, 0x1c, 0x1a, 0x15,
+	0x20, 0x16, 0x20, 0x10, 0x0e, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x00, 0x14, 0x01,
+	0x14, 0x0a, 0x07, 0x16, 0x2b, 0x05, 0x22, 0x27, 0x26, 0x37, 0x23, 0x03, 0x21, 0x13, 0x21, 0x03,
+	0x33, 0x36, 0x37, 0x36, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26,
+	0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x04, 0x62, 0xf6, 0x72, 0x5c, 0x12, 0xa5, 0x5b, 0xfe, 0xe4,
+	0xdb, 0x01, 0x1c, 0x5b, 0xa5, 0x3c, 0x91, 0xb0, 0xfb, 0xfb, 0xe5, 0x34, 0x35, 0xfe, 0x9f, 0xd9,
+	0x70, 0xa0, 0x25, 0x23, 0x4d, 0x6d, 0x6d, 0xa0, 0x24, 0x23, 0x4b, 0x19, 0x9d, 0x80, 0xc4, 0xfe,
+	0x38, 0x04, 0x4a, 0xfe, 0x38, 0xc6, 0x7f, 0x9c, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9,
+	0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x35,
+	0x00, 0x00, 0x04, 0xf2, 0x04, 0x4a, 0x00, 0x17, 0x00, 0x20, 0x00, 0x50, 0xb5, 0x0d, 0x01, 0x00,
+	0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05,
+	0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04,
+	0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59,
+	0x40, 0x09, 0x24, 0x21, 0x11, 0x2b, 0x16, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23, 0x06, 0x0f,
+	0x02, 0x06, 0x07, 0x21, 0x36, 0x37, 0x37, 0x36, 0x37, 0x26, 0x26, 0x37, 0x36, 0x37, 0x36, 0x21,
+	0x21, 0x03, 0x21, 0x13, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x33, 0x03, 0x50, 0x9c, 0x4a,
+	0x52, 0x4e, 0x45, 0x09, 0x10, 0xfe, 0xc9, 0x5c, 0x4d, 0x23, 0x93, 0x67, 0x6e, 0x58, 0x15, 0x26,
+	0xb7, 0x5e, 0x01, 0x0e, 0x01, 0x5f, 0xdb, 0xfe, 0xe4, 0xb8, 0x69, 0x65, 0x74, 0x11, 0x11, 0x57,
+	0x67, 0x64, 0x01, 0xaa, 0x43, 0x73, 0x6e, 0x61, 0x0c, 0x19, 0x6a, 0x6a, 0x31, 0xca, 0x26, 0x26,
+	0x91, 0x6a, 0xbb, 0x50, 0x29, 0xfb, 0xb6, 0x03, 0x9d, 0x53, 0x53, 0x54, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x83, 0xff, 0xe7, 0x04, 0xaa, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19,
+	0x00, 0x33, 0x40, 0x30, 0x00, 0x07, 0x00, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02,
+	0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x11, 0x11, 0x21, 0x11, 0x21, 0x12, 0x24,
+	0x22, 0x08, 0x07, 0x1e, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x36, 0x00, 0x33, 0x32,
+	0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22, 0x01, 0x23, 0x01, 0x21, 0x04,
+	0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd, 0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd,
+	0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87, 0x01, 0x65, 0x38, 0x9f, 0xa8, 0x01, 0xb1, 0xc9, 0xff,
+	0x00, 0x01, 0x18, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe,
+	0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x59, 0x01, 0x41, 0x00, 0x00, 0x04, 0x00, 0x83,
+	0xff, 0xe7, 0x04, 0xad, 0x05, 0xeb, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x7a,
+	0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x0b,
+	0x09, 0x0a, 0x03, 0x07, 0x07, 0x06, 0x5f, 0x08, 0x01, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22,
+	0x00, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06, 0x0b, 0x09, 0x0a, 0x03, 0x07, 0x01, 0x06, 0x07,
+	0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x40,
+	0x18, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x12,
+	0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x0c, 0x07, 0x1d, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02,
+	0x13, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x21, 0x02, 0x21, 0x32, 0x01, 0x21, 0x12, 0x23, 0x22,
+	0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x04, 0x37, 0x29, 0xc3, 0xb8, 0xfe, 0xed, 0xfd,
+	0x37, 0x33, 0x01, 0x50, 0xe4, 0xec, 0x9d, 0x42, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x87,
+	0x01, 0x65, 0x38, 0x9f, 0xa8, 0x59, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c, 0xf5, 0xd0, 0x3e,
+	0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01,
+	0x19, 0x01, 0x63, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x01, 0x00, 0xb4, 0xfe, 0x5c, 0x04, 0xf2,
+	0x06, 0x2b, 0x00, 0x22, 0x00, 0xdd, 0x40, 0x0e, 0x0b, 0x01, 0x08, 0x05, 0x16, 0x01, 0x07, 0x09,
+	0x15, 0x01, 0x06, 0x07, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x02, 0x01,
+	0x01, 0x02, 0x70, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00,
+	0x08, 0x09, 0x05, 0x08, 0x69, 0x0a, 0x01, 0x09, 0x09, 0x1b, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x28, 0x00, 0x02,
+	0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00,
+	0x08, 0x09, 0x05, 0x08, 0x69, 0x0a, 0x01, 0x09, 0x09, 0x1b, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00, 0x02,
+	0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00,
+	0x08, 0x09, 0x05, 0x08, 0x69, 0x00, 0x07, 0x00, 0x06, 0x07, 0x06, 0x65, 0x0a, 0x01, 0x09, 0x09,
+	0x1b, 0x09, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x04, 0x01,
+	0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00, 0x08, 0x09, 0x05, 0x08, 0x69, 0x00, 0x07, 0x00,
+	0x06, 0x07, 0x06, 0x65, 0x0a, 0x01, 0x09, 0x09, 0x1d, 0x09, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x00, 0x22, 0x00, 0x22, 0x25, 0x23, 0x24, 0x22, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x07, 0x1f, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37, 0x21, 0x07, 0x21, 0x07, 0x21, 0x03, 0x36,
+	0x33, 0x32, 0x16, 0x07, 0x03, 0x02, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13,
+	0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0xb4, 0xe6, 0xa0, 0x23, 0xa0, 0x32, 0x01, 0x28, 0x32, 0x01,
+	0x58, 0x23, 0xfe, 0xa8, 0x59, 0xb4, 0xbe, 0x93, 0x84, 0x20, 0x83, 0x47, 0xfe, 0x94, 0x50, 0x33,
+	0x22, 0x1e, 0x36, 0x42, 0x43, 0x15, 0x72, 0x13, 0x2c, 0x34, 0x6d, 0xa0, 0x65, 0x04, 0x84, 0xad,
+	0xfa, 0xfa, 0xad, 0xfe, 0x3e, 0xe8, 0xba, 0xa1, 0xfd, 0x74, 0xfe, 0x99, 0x15, 0xab, 0x07, 0x4f,
+	0x65, 0x02, 0x3d, 0x5d, 0x5e, 0xc7, 0xfe, 0x06, 0x00, 0x02, 0x00, 0x96, 0x00, 0x00, 0x04, 0x81,
+	0x06, 0x44, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00,
+	0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03,
+	0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00,
+	0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x07, 0x18,
+	0x2b, 0x33, 0x13, 0x21, 0x07, 0x21, 0x03, 0x13, 0x01, 0x21, 0x01, 0x96, 0xdb, 0x02, 0xa6, 0x2a,
+	0xfe, 0x83, 0xb1, 0x78, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x04, 0x4a, 0xd2, 0xfc, 0x88, 0x05,
+	0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7f, 0xff, 0xe7, 0x04, 0xd6,
+	0x04, 0x63, 0x00, 0x18, 0x00, 0x33, 0x40, 0x30, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02,
+	0x02, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x22,
+	0x11, 0x12, 0x23, 0x24, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x25, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13,
+	0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x21, 0x07, 0x21, 0x06, 0x16,
+	0x33, 0x32, 0x04, 0x45, 0x27, 0xae, 0xe2, 0xfe, 0xd6, 0xe5, 0x3a, 0x37, 0x01, 0x67, 0x01, 0x26,
+	0xba, 0x9f, 0x29, 0x96, 0xa9, 0x9e, 0xb6, 0x22, 0x01, 0xbe, 0x25, 0xfe, 0x42, 0x10, 0x8b, 0xa7,
+	0xb0, 0xe4, 0xc4, 0x39, 0x01, 0x24, 0x01, 0x25, 0x01, 0x11, 0x01, 0x22, 0x2e, 0xca, 0x3f, 0x8b,
+	0x8b, 0xb9, 0x9e, 0x9d, 0x00, 0x01, 0x00, 0x82, 0xff, 0xe7, 0x04, 0x8d, 0x04, 0x63, 0x00, 0x1e,
+	0x00, 0x2a, 0x40, 0x27, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x22, 0x03, 0x4e, 0x29, 0x23, 0x28, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x37, 0x37, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x82, 0x2b, 0xd3, 0x9d, 0xdd,
+	0x18, 0x0f, 0xa1, 0x5c, 0xbc, 0x63, 0x19, 0x42, 0x01, 0xcf, 0x9e, 0xc0, 0x28, 0xd1, 0x66, 0xcf,
+	0x16, 0x0e, 0x95, 0x4f, 0xcc, 0x78, 0x18, 0x20, 0xfe, 0xc9, 0xe8, 0xcc, 0x24, 0xd8, 0x5c, 0x78,
+	0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44, 0x3d, 0x21, 0x53,
+	0x8d, 0x7c, 0x9c, 0xb9, 0x00, 0x02, 0x00, 0x89, 0x00, 0x00, 0x02, 0xe2, 0x05, 0xfa, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0xa8, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x1d, 0x01, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x07, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03,
+	0x03, 0x37, 0x21, 0x07, 0x89, 0xdb, 0x01, 0x28, 0xdb, 0x28, 0x31, 0x01, 0x28, 0x31, 0x04, 0x4a,
+	0xfb, 0xb6, 0x05, 0x03, 0xf7, 0xf7, 0x00, 0x00, 0x00, 0x03, 0x00, 0x8c, 0x00, 0x00, 0x03, 0x83,
+	0x05, 0xeb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x7b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40,
+	0x1a, 0x08, 0x05, 0x07, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x04, 0x01,
+	0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x06,
+	0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00,
+	0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x09, 0x07, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33,
+	0x07, 0x8c, 0xdb, 0x01, 0x28, 0xdb, 0xdf, 0x2c, 0xde, 0x2c, 0xc5, 0x2c, 0xdf, 0x2c, 0x04, 0x4a,
+	0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x00, 0x02, 0xff, 0x67, 0xfe, 0x5d, 0x02, 0xde,
+	0x05, 0xfa, 0x00, 0x0c, 0x00, 0x10, 0x00, 0xbb, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x0a, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1a,
+	0x4d, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x1e, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03,
+	0x04, 0x67, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x1e,
+	0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f,
+	0x00, 0x03, 0x03, 0x1a, 0x4d, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x05,
+	0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03,
+	0x04, 0x67, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x65, 0x00, 0x01, 0x01, 0x1c, 0x01, 0x4e, 0x59,
+	0x59, 0x59, 0x59, 0x40, 0x0d, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06,
+	0x07, 0x1a, 0x2b, 0x03, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x21, 0x03, 0x02, 0x21, 0x22,
+	0x01, 0x37, 0x21, 0x07, 0x99, 0x25, 0x42, 0x29, 0x4d, 0x30, 0x1b, 0xdb, 0x01, 0x28, 0xd8, 0x57,
+	0xfe, 0x99, 0x4d, 0x01, 0xd6, 0x31, 0x01, 0x28, 0x31, 0xfe, 0x71, 0xb8, 0x13, 0x64, 0x86, 0x04,
+	0x4a, 0xfb, 0xc9, 0xfe, 0x4a, 0x06, 0xa6, 0xf7, 0xf7, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x54,
+	0x00, 0x00, 0x07, 0xe3, 0x04, 0x4a, 0x00, 0x15, 0x00, 0x1e, 0x00, 0x9f, 0x4b, 0xb0, 0x26, 0x50,
+	0x58, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07, 0x02, 0x04, 0x07, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f,
+	0x00, 0x03, 0x03, 0x1c, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x01, 0x61, 0x08, 0x05, 0x02, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x04, 0x00, 0x07, 0x02,
+	0x04, 0x07, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x08, 0x05, 0x02, 0x01, 0x01, 0x1b, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x08, 0x05,
+	0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x04, 0x00, 0x07, 0x02, 0x04, 0x07,
+	0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x08, 0x05, 0x02, 0x01, 0x01, 0x1d, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x08, 0x05, 0x02, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1e, 0x1c, 0x18, 0x16, 0x00, 0x15,
+	0x00, 0x14, 0x21, 0x14, 0x11, 0x13, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x21, 0x13, 0x21, 0x07, 0x02,
+	0x00, 0x21, 0x37, 0x32, 0x37, 0x36, 0x13, 0x37, 0x21, 0x03, 0x33, 0x20, 0x16, 0x07, 0x06, 0x04,
+	0x21, 0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x03, 0x80, 0xb4, 0xfe, 0xc8, 0x04,
+	0x67, 0xfe, 0xe6, 0xfe, 0xdd, 0x27, 0x89, 0x4e, 0x51, 0x50, 0x21, 0x03, 0x68, 0x4d, 0xd9, 0x01,
+	0x13, 0xc8, 0x25, 0x26, 0xfe, 0xe4, 0xfe, 0xda, 0x91, 0xb6, 0x88, 0x86, 0x13, 0x11, 0x63, 0x87,
+	0xb9, 0x03, 0x85, 0x12, 0xfd, 0xfe, 0xfe, 0x8f, 0xc6, 0xa3, 0xac, 0x01, 0x91, 0xa4, 0xfe, 0x80,
+	0xa4, 0xba, 0xc0, 0xac, 0xb9, 0x53, 0x5e, 0x58, 0x4f, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x07, 0x5f, 0x04, 0x4a, 0x00, 0x12, 0x00, 0x1b, 0x00, 0x5b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x07, 0x03, 0x00, 0x6a, 0x04, 0x01, 0x02,
+	0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x5f, 0x09, 0x06, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e,
+	0x1b, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x07, 0x03, 0x00, 0x6a, 0x04, 0x01, 0x02,
+	0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x5f, 0x09, 0x06, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e,
+	0x59, 0x40, 0x13, 0x00, 0x00, 0x1b, 0x19, 0x15, 0x13, 0x00, 0x12, 0x00, 0x11, 0x21, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x0a, 0x07, 0x1c, 0x2b, 0x21, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21,
+	0x13, 0x21, 0x03, 0x33, 0x20, 0x16, 0x07, 0x06, 0x04, 0x21, 0x27, 0x33, 0x32, 0x36, 0x37, 0x36,
+	0x26, 0x23, 0x23, 0x03, 0x28, 0x65, 0xfe, 0x8e, 0x65, 0xfe, 0xde, 0xdb, 0x01, 0x22, 0x51, 0x01,
+	0x72, 0x51, 0x01, 0x22, 0x51, 0xa9, 0x01, 0x16, 0xcc, 0x23, 0x24, 0xfe, 0xe1, 0xfe, 0xd7, 0x62,
+	0x8c, 0x89, 0x86, 0x11, 0x0f, 0x63, 0x87, 0x90, 0x01, 0xfd, 0xfe, 0x03, 0x04, 0x4a, 0xfe, 0x6c,
+	0x01, 0x94, 0xfe, 0x6c, 0xa5, 0xaf, 0xb5, 0xad, 0xb9, 0x53, 0x54, 0x4e, 0x4f, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xb4, 0x00, 0x00, 0x04, 0xf2, 0x06, 0x2b, 0x00, 0x19, 0x00, 0x7e, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x05, 0x05, 0x06, 0x70, 0x07, 0x01, 0x05, 0x08, 0x01,
+	0x04, 0x00, 0x05, 0x04, 0x68, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x03, 0x01, 0x01,
+	0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x06, 0x05, 0x06,
+	0x85, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00, 0x05, 0x04, 0x68, 0x00, 0x00, 0x00, 0x02, 0x01,
+	0x00, 0x02, 0x69, 0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x06, 0x05,
+	0x06, 0x85, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00, 0x05, 0x04, 0x68, 0x00, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x02, 0x69, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x12, 0x23, 0x13, 0x21, 0x09, 0x07, 0x1f, 0x2b, 0x01, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x03, 0x21, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x21, 0x13, 0x23, 0x37, 0x33,
+	0x37, 0x21, 0x07, 0x21, 0x07, 0x21, 0x02, 0x69, 0xbe, 0xb4, 0x93, 0x84, 0x20, 0x76, 0xfe, 0xd8,
+	0x67, 0x13, 0x22, 0x3e, 0x63, 0xaa, 0x65, 0xfe, 0xd8, 0xe6, 0xa0, 0x23, 0xa0, 0x32, 0x01, 0x28,
+	0x32, 0x01, 0x58, 0x23, 0xfe, 0xa8, 0x02, 0xc2, 0xe8, 0xba, 0xa1, 0xfd, 0xb1, 0x02, 0x06, 0x5d,
+	0x5e, 0xc7, 0xfe, 0x06, 0x04, 0x84, 0xad, 0xfa, 0xfa, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x04, 0xb3, 0x06, 0x44, 0x00, 0x20, 0x00, 0x24, 0x00, 0x8e, 0xb5, 0x13, 0x01, 0x05,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x31, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a,
+	0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01, 0x03, 0x05, 0x03, 0x01, 0x05, 0x80, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x31, 0x00, 0x07,
+	0x08, 0x07, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01, 0x03, 0x05, 0x03, 0x01, 0x05,
+	0x80, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59,
+	0x40, 0x17, 0x21, 0x21, 0x00, 0x00, 0x21, 0x24, 0x21, 0x24, 0x23, 0x22, 0x00, 0x20, 0x00, 0x20,
+	0x14, 0x1b, 0x21, 0x25, 0x11, 0x11, 0x0b, 0x07, 0x1c, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x32, 0x36,
+	0x37, 0x37, 0x36, 0x36, 0x37, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x07, 0x16, 0x16,
+	0x17, 0x17, 0x16, 0x17, 0x21, 0x26, 0x27, 0x26, 0x27, 0x23, 0x03, 0x13, 0x01, 0x21, 0x01, 0x94,
+	0xdb, 0x01, 0x0b, 0x5d, 0x26, 0x3c, 0x69, 0x27, 0x5c, 0x87, 0x6a, 0x2c, 0x25, 0x12, 0x30, 0x33,
+	0x47, 0x26, 0x67, 0x7c, 0x5d, 0x59, 0x25, 0x20, 0x36, 0x18, 0xfe, 0xd1, 0x0d, 0x24, 0x37, 0x37,
+	0x1d, 0x5d, 0xca, 0x01, 0x31, 0x01, 0x19, 0xfe, 0x7f, 0x04, 0x4a, 0xfe, 0x2e, 0x4a, 0x8e, 0x34,
+	0x7c, 0x49, 0x01, 0xb9, 0x27, 0x5e, 0x33, 0x80, 0x1e, 0x16, 0x6a, 0x80, 0x5a, 0xb2, 0x2f, 0x22,
+	0x98, 0xe1, 0x37, 0xfe, 0x2e, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x05, 0x32, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x52, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x05, 0x00, 0x04, 0x00,
+	0x05, 0x04, 0x67, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02,
+	0x4e, 0x1b, 0x40, 0x16, 0x00, 0x05, 0x00, 0x04, 0x00, 0x05, 0x04, 0x67, 0x01, 0x01, 0x00, 0x00,
+	0x1c, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x0d,
+	0x0c, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x13,
+	0x21, 0x03, 0x01, 0x21, 0x03, 0x21, 0x13, 0x01, 0x01, 0x23, 0x01, 0x21, 0x94, 0xdb, 0x01, 0x16,
+	0x8f, 0x02, 0x14, 0x01, 0x28, 0xdb, 0xfe, 0xea, 0x8e, 0xfd, 0xed, 0x02, 0x61, 0xc9, 0xfe, 0xff,
+	0x01, 0x19, 0x04, 0x4a, 0xfd, 0x35, 0x02, 0xcb, 0xfb, 0xb6, 0x02, 0xcb, 0xfd, 0x35, 0x05, 0x03,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x75, 0x05, 0x4e, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x20, 0x00, 0x5f, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58,
+	0x40, 0x20, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07,
+	0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e,
+	0x02, 0x4e, 0x1b, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00,
+	0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02,
+	0x02, 0x1e, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x22, 0x13, 0x23, 0x14, 0x21, 0x23, 0x12, 0x11, 0x08,
+	0x07, 0x1e, 0x2b, 0x25, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x02, 0x06, 0x21, 0x23, 0x37, 0x33,
+	0x32, 0x36, 0x37, 0x37, 0x13, 0x33, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x33, 0x06,
+	0x06, 0x23, 0x22, 0x26, 0x01, 0xbe, 0xe3, 0x01, 0x23, 0x9c, 0x01, 0xc1, 0xf3, 0xfd, 0x56, 0xb7,
+	0xc3, 0xfe, 0xf6, 0x20, 0x27, 0x1c, 0x74, 0x83, 0x32, 0x37, 0xb3, 0xd2, 0x05, 0x11, 0x2d, 0x3e,
+	0x3e, 0x4f, 0x11, 0x03, 0xd2, 0x20, 0xc7, 0xa6, 0xa7, 0x86, 0x28, 0x04, 0x22, 0xfd, 0x38, 0x02,
+	0xc8, 0xfb, 0xc9, 0xfe, 0xdf, 0x7d, 0xc6, 0x2d, 0x44, 0x53, 0x06, 0x45, 0x18, 0x54, 0x53, 0x54,
+	0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x96, 0xfe, 0xa7, 0x05, 0x1a,
+	0x04, 0x4a, 0x00, 0x0b, 0x00, 0xaf, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x03,
+	0x04, 0x86, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x03,
+	0x04, 0x86, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x59, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21, 0x03, 0x23,
+	0x13, 0x96, 0xdb, 0x01, 0x29, 0xb4, 0x01, 0x57, 0xb4, 0x01, 0x29, 0xdb, 0xfe, 0x99, 0x45, 0xdc,
+	0x45, 0x04, 0x4a, 0xfc, 0x7b, 0x03, 0x85, 0xfb, 0xb6, 0xfe, 0xa7, 0x01, 0x59, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x2a, 0x06, 0xf1, 0x00, 0x07, 0x00, 0x44, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x1a, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01,
+	0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x02, 0x68, 0x04, 0x01, 0x03, 0x03, 0x1d,
+	0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07,
+	0x19, 0x2b, 0x33, 0x01, 0x21, 0x13, 0x33, 0x03, 0x21, 0x03, 0xad, 0x01, 0x27, 0x02, 0x3e, 0x3c,
+	0xdc, 0x64, 0xfe, 0x1a, 0xff, 0x05, 0xc8, 0x01, 0x29, 0xfe, 0x0c, 0xfb, 0x03, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x04, 0x7c, 0x05, 0x41, 0x00, 0x07, 0x00, 0x66, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x01, 0x00, 0x00, 0x01, 0x70, 0x00, 0x02, 0x02, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x1d, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x05, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x37, 0x33, 0x03, 0x21, 0x03, 0x96, 0xdb, 0x01, 0xfe,
+	0x31, 0xdc, 0x5b, 0xfe, 0x4f, 0xb1, 0x04, 0x4a, 0xf7, 0xfe, 0x37, 0xfc, 0x88, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x40, 0x00, 0x00, 0x08, 0x9c, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x56,
+	0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17,
+	0x00, 0x06, 0x00, 0x05, 0x00, 0x06, 0x05, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x07,
+	0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x06, 0x00, 0x05, 0x00, 0x06,
+	0x05, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03,
+	0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12,
+	0x12, 0x11, 0x08, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01,
+	0x21, 0x03, 0x01, 0x01, 0x23, 0x01, 0x21, 0x01, 0x95, 0x55, 0x01, 0x23, 0x41, 0x01, 0xf0, 0x01,
+	0x01, 0x26, 0x02, 0x06, 0xdb, 0xfd, 0x3e, 0xfe, 0xd9, 0x26, 0xfe, 0x2e, 0x02, 0xea, 0xbf, 0xfe,
+	0xff, 0x01, 0x0f, 0x05, 0xc8, 0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03,
+	0xf7, 0xfc, 0x09, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00, 0x02, 0x01, 0x19, 0x00, 0x00, 0x06, 0xd7,
+	0x06, 0x44, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x78, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06,
+	0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x06, 0x00, 0x05, 0x00, 0x06, 0x05,
+	0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x06, 0x00, 0x05, 0x00, 0x06, 0x05, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00,
+	0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x08, 0x09, 0x1a, 0x2b,
+	0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x01, 0x23, 0x01,
+	0x21, 0x01, 0x48, 0x2f, 0x01, 0x0b, 0x1f, 0x01, 0x5b, 0x01, 0x00, 0x0f, 0x01, 0x63, 0xc7, 0xfe,
+	0x07, 0xfe, 0xe5, 0x0e, 0xfe, 0xaf, 0x02, 0x5f, 0xbf, 0xfe, 0xff, 0x01, 0x0f, 0x04, 0x4a, 0xfc,
+	0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x03, 0x01,
+	0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x40, 0x00, 0x00, 0x08, 0x9c, 0x07, 0x8f, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x60, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02,
+	0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40,
+	0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00,
+	0x00, 0x03, 0x5f, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x15, 0x0d, 0x0d,
+	0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11,
+	0x09, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03,
+	0x09, 0x02, 0x21, 0x01, 0x01, 0x95, 0x55, 0x01, 0x23, 0x41, 0x01, 0xf0, 0x01, 0x01, 0x26, 0x02,
+	0x06, 0xdb, 0xfd, 0x3e, 0xfe, 0xd9, 0x26, 0xfe, 0x2e, 0x01, 0xe8, 0x01, 0x31, 0x01, 0x0f, 0xfe,
+	0x7f, 0x05, 0xc8, 0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc,
+	0x09, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x01, 0x19, 0x00, 0x00, 0x06, 0xd7,
+	0x06, 0x44, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x86, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00,
+	0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07,
+	0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08,
+	0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10,
+	0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x03,
+	0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x09, 0x02, 0x21, 0x01, 0x01, 0x48,
+	0x2f, 0x01, 0x0b, 0x1f, 0x01, 0x5b, 0x01, 0x00, 0x0f, 0x01, 0x63, 0xc7, 0xfe, 0x07, 0xfe, 0xe5,
+	0x0e, 0xfe, 0xaf, 0x01, 0x5f, 0x01, 0x31, 0x01, 0x0f, 0xfe, 0x7f, 0x04, 0x4a, 0xfc, 0xff, 0x03,
+	0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x03, 0x01, 0x41, 0xfe,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x40, 0x00, 0x00, 0x08, 0x9c, 0x07, 0x40, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x14, 0x00, 0x6a, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05,
+	0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67,
+	0x02, 0x01, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x09, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x1d, 0x11, 0x11, 0x0d, 0x0d, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10,
+	0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b,
+	0x21, 0x03, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x01, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x95, 0x55, 0x01, 0x23, 0x41, 0x01, 0xf0, 0x01, 0x01, 0x26,
+	0x02, 0x06, 0xdb, 0xfd, 0x3e, 0xfe, 0xd9, 0x26, 0xfe, 0x2e, 0x01, 0x1e, 0x2c, 0xde, 0x2c, 0xd9,
+	0x2c, 0xdf, 0x2c, 0x05, 0xc8, 0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03,
+	0xf7, 0xfc, 0x09, 0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x19,
+	0x00, 0x00, 0x06, 0xd7, 0x05, 0xe1, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x14, 0x00, 0x90, 0xb7, 0x0b,
+	0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x0b, 0x08,
+	0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x02, 0x01, 0x02, 0x00,
+	0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02,
+	0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40,
+	0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01, 0x02,
+	0x00, 0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x1d,
+	0x11, 0x11, 0x0d, 0x0d, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10, 0x0d, 0x10,
+	0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x03,
+	0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x13, 0x37, 0x33, 0x07, 0x33,
+	0x37, 0x33, 0x07, 0x01, 0x48, 0x2f, 0x01, 0x0b, 0x1f, 0x01, 0x5b, 0x01, 0x00, 0x0f, 0x01, 0x63,
+	0xc7, 0xfe, 0x07, 0xfe, 0xe5, 0x0e, 0xfe, 0xaf, 0x8b, 0x2c, 0xde, 0x2c, 0xed, 0x2c, 0xdf, 0x2c,
+	0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1, 0xfd, 0x0f,
+	0x05, 0x03, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x02, 0x01, 0x43, 0x00, 0x00, 0x06, 0x62,
+	0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x4f, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x03, 0x00, 0x04, 0x03, 0x67, 0x01,
+	0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x15, 0x00,
+	0x04, 0x00, 0x03, 0x00, 0x04, 0x03, 0x67, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x01, 0x02,
+	0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x08, 0x00,
+	0x08, 0x12, 0x12, 0x06, 0x09, 0x18, 0x2b, 0x21, 0x13, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x03,
+	0x01, 0x23, 0x01, 0x21, 0x02, 0x07, 0x7b, 0xfe, 0xc1, 0x01, 0x55, 0xe5, 0x01, 0xf1, 0xf4, 0xfd,
+	0x55, 0x7c, 0x01, 0x6f, 0xbf, 0xfe, 0xff, 0x01, 0x0f, 0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02,
+	0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5c,
+	0xfe, 0x75, 0x05, 0x34, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x46, 0xb5, 0x03, 0x01, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00,
+	0x04, 0x04, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e,
+	0x1b, 0x40, 0x14, 0x00, 0x04, 0x00, 0x03, 0x00, 0x04, 0x03, 0x67, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0xb7, 0x11, 0x11, 0x11, 0x12, 0x11, 0x05, 0x09,
+	0x1b, 0x2b, 0x21, 0x03, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x01, 0x23, 0x01, 0x21, 0x01, 0xa3,
+	0xaf, 0x01, 0x38, 0x70, 0x01, 0xbc, 0xdc, 0xfc, 0x56, 0xfe, 0xd2, 0x03, 0xa8, 0xbf, 0xfe, 0xff,
+	0x01, 0x0f, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x8e, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xc3, 0x02, 0x19, 0x04, 0xa7, 0x02, 0xc3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07,
+	0xc3, 0x22, 0x03, 0xc2, 0x22, 0x02, 0x19, 0xaa, 0xaa, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xbb,
+	0x02, 0x19, 0x08, 0x3d, 0x02, 0xc3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0xbb, 0x22, 0x07, 0x60,
+	0x22, 0x02, 0x19, 0xaa, 0xaa, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6b, 0x02, 0x19, 0x08, 0x92,
+	0x02, 0xdc, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0x6b, 0x27, 0x08, 0x00, 0x27, 0x02, 0x19, 0xc3,
+	0xc3, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xad, 0xfe, 0x50, 0x04, 0x6b, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x37, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2c, 0x00, 0x00, 0x04, 0x01, 0x01, 0x02,
+	0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01,
+	0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x07, 0x37, 0x21, 0x07, 0x01,
+	0x37, 0x21, 0x07, 0x1c, 0x1c, 0x04, 0x6b, 0x1d, 0xfb, 0x5f, 0x1d, 0x04, 0x6a, 0x1d, 0x91, 0x91,
+	0x91, 0xfe, 0xe1, 0x91, 0x91, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x37, 0x03, 0xaa, 0x02, 0xf8,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x1c, 0x40, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x63, 0x00,
+	0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x03, 0x4e, 0x11, 0x12, 0x11, 0x10, 0x04, 0x09,
+	0x1a, 0x2b, 0x01, 0x33, 0x03, 0x21, 0x37, 0x12, 0x21, 0x07, 0x22, 0x07, 0x02, 0x3c, 0x7c, 0x40,
+	0xfe, 0xbf, 0x31, 0x4f, 0x01, 0x41, 0x16, 0x7c, 0x24, 0x04, 0xea, 0xfe, 0xc0, 0xf8, 0x01, 0x89,
+	0x6f, 0xb2, 0x00, 0x00, 0x00, 0x01, 0x01, 0x37, 0x03, 0xaa, 0x02, 0xf8, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x74, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3b, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x0e, 0x50, 0x58, 0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x15,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x3b, 0x02, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x00, 0x4e, 0x59, 0x59, 0x59, 0xb6, 0x11, 0x12,
+	0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x23, 0x13, 0x21, 0x07, 0x02, 0x21, 0x37, 0x32, 0x37,
+	0x01, 0xf2, 0x7b, 0x40, 0x01, 0x41, 0x32, 0x4e, 0xfe, 0xbf, 0x16, 0x7b, 0x24, 0x04, 0xea, 0x01,
+	0x41, 0xf8, 0xfe, 0x77, 0x6f, 0xb2, 0x00, 0x00, 0x00, 0x01, 0x00, 0x3b, 0xfe, 0xbf, 0x01, 0xfd,
+	0x01, 0x41, 0x00, 0x09, 0x00, 0x38, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x03, 0x00,
+	0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b,
+	0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3c, 0x00, 0x4e, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x33, 0x23,
+	0x13, 0x21, 0x07, 0x02, 0x21, 0x37, 0x32, 0x37, 0xf7, 0x7b, 0x40, 0x01, 0x41, 0x32, 0x4f, 0xfe,
+	0xbf, 0x17, 0x7b, 0x23, 0x01, 0x41, 0xf9, 0xfe, 0x77, 0x6f, 0xb2, 0x00, 0x00, 0x01, 0x01, 0x37,
+	0x03, 0xaa, 0x02, 0xf8, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x74, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x15, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x01, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x15, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x3a, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x1b, 0x40, 0x12,
+	0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a,
+	0x03, 0x4e, 0x59, 0x59, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x11, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x06,
+	0x33, 0x07, 0x20, 0x13, 0x37, 0x21, 0x03, 0x23, 0x02, 0x37, 0x24, 0x7b, 0x16, 0xfe, 0xbf, 0x4e,
+	0x32, 0x01, 0x41, 0x40, 0x7b, 0x04, 0xcb, 0xb2, 0x6f, 0x01, 0x89, 0xf8, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x42, 0x03, 0xc2, 0x04, 0xba, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x22,
+	0x40, 0x1f, 0x10, 0x06, 0x02, 0x00, 0x4a, 0x02, 0x01, 0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01,
+	0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x17, 0x11, 0x10, 0x04, 0x09,
+	0x1a, 0x2b, 0x01, 0x33, 0x03, 0x21, 0x37, 0x12, 0x25, 0x07, 0x06, 0x07, 0x05, 0x33, 0x03, 0x21,
+	0x37, 0x12, 0x25, 0x07, 0x06, 0x07, 0x02, 0x36, 0x6f, 0x3b, 0xfe, 0xd8, 0x2c, 0x4a, 0x01, 0x2d,
+	0x16, 0x75, 0x1e, 0x01, 0xcf, 0x6f, 0x3b, 0xfe, 0xd8, 0x2c, 0x4a, 0x01, 0x2d, 0x16, 0x75, 0x1e,
+	0x04, 0xea, 0xfe, 0xd8, 0xe0, 0x01, 0x6f, 0x1a, 0x6f, 0x1f, 0x93, 0x20, 0xfe, 0xd8, 0xe0, 0x01,
+	0x6f, 0x1a, 0x6f, 0x1f, 0x93, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x42, 0x03, 0xc2, 0x04, 0xba,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x1c, 0x40, 0x19, 0x10, 0x06, 0x02, 0x00, 0x49, 0x02,
+	0x01, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x01, 0x3a, 0x00, 0x4e, 0x11, 0x17, 0x11, 0x10,
+	0x04, 0x09, 0x1a, 0x2b, 0x01, 0x23, 0x13, 0x21, 0x07, 0x02, 0x05, 0x37, 0x36, 0x37, 0x25, 0x23,
+	0x13, 0x21, 0x07, 0x02, 0x05, 0x37, 0x36, 0x37, 0x01, 0xf1, 0x6f, 0x3b, 0x01, 0x28, 0x2d, 0x49,
+	0xfe, 0xd3, 0x16, 0x75, 0x1e, 0x01, 0xdb, 0x6f, 0x3b, 0x01, 0x28, 0x2d, 0x49, 0xfe, 0xd3, 0x16,
+	0x75, 0x1e, 0x05, 0x03, 0x01, 0x28, 0xdf, 0xfe, 0x90, 0x1a, 0x6f, 0x20, 0x93, 0x1f, 0x01, 0x28,
+	0xdf, 0xfe, 0x90, 0x1a, 0x6f, 0x20, 0x93, 0x00, 0x00, 0x02, 0x00, 0x41, 0xfe, 0xbf, 0x03, 0xba,
+	0x01, 0x28, 0x00, 0x09, 0x00, 0x13, 0x00, 0x34, 0xb4, 0x10, 0x06, 0x02, 0x00, 0x49, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x0d, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x39,
+	0x00, 0x4e, 0x1b, 0x40, 0x0d, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3c,
+	0x00, 0x4e, 0x59, 0xb6, 0x11, 0x17, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x33, 0x23, 0x13, 0x21,
+	0x07, 0x02, 0x05, 0x37, 0x36, 0x37, 0x25, 0x23, 0x13, 0x21, 0x07, 0x02, 0x05, 0x37, 0x36, 0x37,
+	0xf1, 0x6f, 0x3b, 0x01, 0x28, 0x2d, 0x49, 0xfe, 0xd2, 0x17, 0x75, 0x1d, 0x01, 0xdc, 0x6f, 0x3b,
+	0x01, 0x28, 0x2d, 0x49, 0xfe, 0xd2, 0x17, 0x75, 0x1d, 0x01, 0x28, 0xdf, 0xfe, 0x91, 0x1b, 0x6f,
+	0x20, 0x93, 0x1f, 0x01, 0x28, 0xdf, 0xfe, 0x91, 0x1b, 0x6f, 0x20, 0x93, 0x00, 0x01, 0x00, 0xf9,
+	0xfe, 0xd8, 0x04, 0xdb, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x54, 0xb5, 0x09, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x05, 0x01, 0x04, 0x00, 0x04, 0x86, 0x03, 0x01,
+	0x01, 0x00, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x38, 0x02, 0x4e, 0x1b, 0x40, 0x1d,
+	0x00, 0x02, 0x01, 0x02, 0x85, 0x05, 0x01, 0x04, 0x00, 0x04, 0x86, 0x03, 0x01, 0x01, 0x00, 0x00,
+	0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x01, 0x13,
+	0x05, 0x37, 0x05, 0x13, 0x21, 0x03, 0x25, 0x07, 0x25, 0x03, 0x01, 0x69, 0xf5, 0xfe, 0x9b, 0x2c,
+	0x01, 0x5b, 0x4c, 0x01, 0x28, 0x7e, 0x01, 0x65, 0x2c, 0xfe, 0xa5, 0xc3, 0xfe, 0xd8, 0x04, 0x4a,
+	0x19, 0xde, 0x18, 0x01, 0xf9, 0xfe, 0x07, 0x18, 0xde, 0x19, 0xfb, 0xb6, 0x00, 0x01, 0x00, 0x82,
+	0xfe, 0xd8, 0x04, 0xdb, 0x05, 0xc8, 0x00, 0x13, 0x00, 0x78, 0x40, 0x0b, 0x0d, 0x03, 0x02, 0x05,
+	0x01, 0x11, 0x01, 0x06, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05,
+	0x01, 0x00, 0x01, 0x05, 0x00, 0x80, 0x00, 0x00, 0x06, 0x01, 0x00, 0x06, 0x7e, 0x07, 0x01, 0x06,
+	0x06, 0x84, 0x04, 0x01, 0x02, 0x00, 0x01, 0x05, 0x02, 0x01, 0x67, 0x00, 0x03, 0x03, 0x38, 0x03,
+	0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x05, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x80, 0x00, 0x00, 0x06, 0x01, 0x00, 0x06, 0x7e, 0x07, 0x01, 0x06, 0x06, 0x84, 0x04, 0x01, 0x02,
+	0x01, 0x01, 0x02, 0x57, 0x04, 0x01, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x02, 0x01, 0x4f, 0x59,
+	0x40, 0x0f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x13, 0x11, 0x11, 0x11, 0x13, 0x11, 0x08, 0x09,
+	0x1c, 0x2b, 0x01, 0x13, 0x05, 0x37, 0x05, 0x13, 0x05, 0x37, 0x05, 0x13, 0x21, 0x03, 0x25, 0x07,
+	0x25, 0x03, 0x25, 0x07, 0x25, 0x03, 0x01, 0x69, 0x7e, 0xfe, 0x9b, 0x2d, 0x01, 0x5b, 0x54, 0xfe,
+	0x9b, 0x2c, 0x01, 0x5b, 0x4c, 0x01, 0x28, 0x7e, 0x01, 0x65, 0x2c, 0xfe, 0xa5, 0x54, 0x01, 0x65,
+	0x2d, 0xfe, 0xa5, 0x4c, 0xfe, 0xd8, 0x01, 0xfa, 0x19, 0xde, 0x19, 0x01, 0xa4, 0x19, 0xde, 0x18,
+	0x01, 0xf9, 0xfe, 0x07, 0x18, 0xde, 0x19, 0xfe, 0x5c, 0x19, 0xde, 0x19, 0xfe, 0x06, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xb7, 0x01, 0xf7, 0x03, 0x5b, 0x04, 0x69, 0x00, 0x0b, 0x00, 0x1a, 0x40, 0x17,
+	0x02, 0x01, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x00, 0x4e, 0x01, 0x00, 0x07, 0x05,
+	0x00, 0x0b, 0x01, 0x0b, 0x03, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x06, 0x06, 0x01, 0xc5, 0x7c, 0x92, 0x1a, 0x19, 0xdd, 0x81, 0x81, 0x92, 0x1a, 0x1a,
+	0xdd, 0x01, 0xf7, 0xba, 0x7f, 0x81, 0xb8, 0xb8, 0x83, 0x82, 0xb5, 0x00, 0x00, 0x03, 0x00, 0xb5,
+	0x00, 0x00, 0x07, 0x8b, 0x01, 0x41, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x05, 0x07, 0x03,
+	0x06, 0x05, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01,
+	0x5f, 0x08, 0x05, 0x07, 0x03, 0x06, 0x05, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08,
+	0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13,
+	0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0xb5, 0x40, 0x01, 0x41, 0x40, 0x01, 0x6a, 0x40, 0x01, 0x40,
+	0x40, 0x01, 0x6a, 0x40, 0x01, 0x41, 0x40, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x07, 0x00, 0x19, 0xff, 0xdb, 0x08, 0x56, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x1f, 0x00, 0x27, 0x00, 0x33, 0x00, 0x3b, 0x00, 0x3f, 0x01, 0x39, 0x4b, 0xb0,
+	0x0e, 0x50, 0x58, 0x40, 0x32, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09,
+	0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x0c, 0x01,
+	0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x62, 0x14, 0x0d, 0x12, 0x08,
+	0x10, 0x05, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x3a, 0x0f,
+	0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06,
+	0x05, 0x07, 0x69, 0x00, 0x0c, 0x0c, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x62, 0x12, 0x08, 0x10, 0x03, 0x04, 0x04,
+	0x39, 0x4d, 0x14, 0x01, 0x0d, 0x0d, 0x39, 0x0d, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x3a, 0x00, 0x0c, 0x01, 0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x0f, 0x01, 0x02, 0x0e,
+	0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05, 0x07, 0x69,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06,
+	0x04, 0x62, 0x12, 0x08, 0x10, 0x03, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x38, 0x00, 0x0c,
+	0x01, 0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01,
+	0x07, 0x06, 0x05, 0x07, 0x69, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x62, 0x12, 0x08, 0x10,
+	0x03, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x3b, 0x3c, 0x3c, 0x35, 0x34, 0x29,
+	0x28, 0x21, 0x20, 0x15, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x3c, 0x3f, 0x3c, 0x3f, 0x3e, 0x3d, 0x39,
+	0x37, 0x34, 0x3b, 0x35, 0x3b, 0x2f, 0x2d, 0x28, 0x33, 0x29, 0x33, 0x25, 0x23, 0x20, 0x27, 0x21,
+	0x27, 0x1b, 0x19, 0x14, 0x1f, 0x15, 0x1f, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00,
+	0x0b, 0x01, 0x0b, 0x15, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16,
+	0x07, 0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x01, 0x22, 0x26, 0x37, 0x36,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x05,
+	0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23,
+	0x22, 0x07, 0x06, 0x05, 0x01, 0x33, 0x01, 0x01, 0xdf, 0x8f, 0x7e, 0x22, 0x23, 0xce, 0x92, 0x93,
+	0x7e, 0x24, 0x22, 0xcf, 0x74, 0x75, 0x2c, 0x2d, 0x73, 0x74, 0x2d, 0x2c, 0x02, 0x4d, 0x90, 0x7f,
+	0x22, 0x23, 0xce, 0x92, 0x92, 0x80, 0x22, 0x23, 0xcf, 0x76, 0x75, 0x2d, 0x2c, 0x73, 0x73, 0x2d,
+	0x2c, 0x03, 0x27, 0x90, 0x7f, 0x22, 0x23, 0xce, 0x92, 0x92, 0x80, 0x22, 0x23, 0xce, 0x77, 0x76,
+	0x2d, 0x2c, 0x74, 0x73, 0x2c, 0x2d, 0xf9, 0xbe, 0x05, 0x8b, 0x97, 0xfa, 0x75, 0x02, 0xe4, 0xc7,
+	0xac, 0xac, 0xc5, 0xc6, 0xb1, 0xaa, 0xc3, 0x94, 0xdf, 0xdd, 0xde, 0xde, 0xfc, 0x88, 0xc7, 0xab,
+	0xad, 0xc5, 0xc5, 0xac, 0xaf, 0xc4, 0x94, 0xdf, 0xdd, 0xde, 0xde, 0x94, 0xc7, 0xab, 0xad, 0xc5,
+	0xc5, 0xac, 0xaf, 0xc4, 0x94, 0xdf, 0xdd, 0xdd, 0xdf, 0xb9, 0x06, 0x12, 0xf9, 0xee, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xf7, 0x03, 0xdb, 0x02, 0xf3, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16,
+	0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00, 0x00, 0x00, 0x3a, 0x00, 0x4e, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x13, 0x21, 0x01, 0xf7, 0xec, 0x01, 0x10, 0xfe,
+	0xb1, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x00, 0x00, 0x02, 0x01, 0x0e, 0x03, 0xdb, 0x04, 0xc7,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x05, 0x03, 0x04, 0x03, 0x01, 0x01,
+	0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x01,
+	0x21, 0x13, 0x21, 0x01, 0x01, 0x0e, 0xed, 0x01, 0x10, 0xfe, 0xb0, 0x01, 0x0f, 0xee, 0x01, 0x0f,
+	0xfe, 0xb0, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x02, 0x50, 0xfd, 0xb0, 0x00, 0x01, 0x00, 0xab,
+	0x00, 0x69, 0x03, 0x1a, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x06, 0xb3, 0x05, 0x03, 0x01, 0x32, 0x2b,
+	0x01, 0x01, 0x13, 0x07, 0x01, 0x01, 0x03, 0x1a, 0xfe, 0xb6, 0xc2, 0xa0, 0xfe, 0xb9, 0x01, 0xf9,
+	0x03, 0x78, 0xfe, 0xad, 0xfe, 0xad, 0x69, 0x01, 0xbc, 0x01, 0xbc, 0x00, 0x00, 0x01, 0x00, 0x6a,
+	0x00, 0x69, 0x02, 0xd9, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x06, 0xb3, 0x05, 0x03, 0x01, 0x32, 0x2b,
+	0x37, 0x01, 0x03, 0x37, 0x01, 0x01, 0x6a, 0x01, 0x4a, 0xc2, 0xa0, 0x01, 0x47, 0xfe, 0x07, 0xd2,
+	0x01, 0x53, 0x01, 0x53, 0x69, 0xfe, 0x44, 0xfe, 0x44, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0xb4,
+	0x00, 0x00, 0x05, 0x52, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x13, 0x00, 0x68,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x0b, 0x07, 0x09, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x06,
+	0x01, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x0a, 0x05, 0x08, 0x03, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x06, 0x01, 0x02, 0x0b, 0x07, 0x09, 0x03, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x04, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x0a, 0x05, 0x08, 0x03, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x40, 0x22, 0x0e, 0x0e, 0x0a, 0x0a, 0x04, 0x04, 0x00, 0x00, 0x0e, 0x13, 0x0e,
+	0x13, 0x11, 0x10, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x04, 0x09, 0x04, 0x09, 0x07, 0x06, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x0c, 0x09, 0x17, 0x2b, 0x33, 0x37, 0x21, 0x07, 0x03, 0x13, 0x13, 0x21,
+	0x03, 0x03, 0x01, 0x37, 0x21, 0x07, 0x03, 0x13, 0x13, 0x21, 0x03, 0x03, 0xb4, 0x31, 0x01, 0x28,
+	0x31, 0xa4, 0x68, 0x3b, 0x01, 0x28, 0x3b, 0xca, 0x01, 0x05, 0x31, 0x01, 0x28, 0x31, 0xa3, 0x67,
+	0x3b, 0x01, 0x28, 0x3b, 0xca, 0xf7, 0xf7, 0x01, 0xa3, 0x02, 0xfd, 0x01, 0x28, 0xfe, 0xd8, 0xfd,
+	0x03, 0xfe, 0x5d, 0xf7, 0xf7, 0x01, 0xa3, 0x02, 0xfd, 0x01, 0x28, 0xfe, 0xd8, 0xfd, 0x03, 0x00,
+	0x00, 0x01, 0x01, 0x40, 0x06, 0x44, 0x04, 0x0d, 0x06, 0xf3, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x21, 0x07, 0x01, 0x40, 0x23, 0x02, 0xaa, 0x23, 0x06, 0x44,
+	0xaf, 0xaf, 0x00, 0x00, 0x00, 0x01, 0xfe, 0x35, 0xff, 0xdb, 0x04, 0x4b, 0x05, 0xed, 0x00, 0x03,
+	0x00, 0x2e, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x02, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x05, 0x01, 0x33, 0x01, 0xfe, 0x35, 0x05, 0x76, 0xa0, 0xfa, 0x8a, 0x25, 0x06, 0x12, 0xf9, 0xee,
+	0x00, 0x03, 0x01, 0x33, 0x02, 0x9f, 0x04, 0x7b, 0x06, 0x43, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19,
+	0x00, 0x39, 0x40, 0x36, 0x17, 0x10, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x06, 0x01, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00,
+	0x57, 0x00, 0x4e, 0x14, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x19, 0x14, 0x19, 0x0c, 0x12, 0x0d,
+	0x12, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x07, 0x0b, 0x16, 0x2b, 0x01, 0x22, 0x02, 0x37, 0x36,
+	0x24, 0x33, 0x32, 0x16, 0x07, 0x06, 0x04, 0x27, 0x32, 0x13, 0x36, 0x37, 0x01, 0x06, 0x01, 0x22,
+	0x03, 0x06, 0x07, 0x01, 0x36, 0x02, 0x62, 0xa7, 0x88, 0x35, 0x34, 0x01, 0x09, 0xa6, 0xa6, 0x8a,
+	0x34, 0x36, 0xfe, 0xf8, 0x8b, 0x9d, 0x5a, 0x0c, 0x06, 0xfe, 0x94, 0x0f, 0x01, 0x24, 0x9e, 0x58,
+	0x0c, 0x09, 0x01, 0x6d, 0x0f, 0x02, 0x9f, 0x01, 0x00, 0xd2, 0xd3, 0xff, 0xfe, 0xd4, 0xd5, 0xfd,
+	0x6e, 0x01, 0x64, 0x30, 0x2a, 0xfe, 0xfc, 0xba, 0x02, 0xc7, 0xfe, 0x9d, 0x30, 0x29, 0x01, 0x04,
+	0xb8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x0e, 0x02, 0xb5, 0x04, 0x55, 0x06, 0x2d, 0x00, 0x0a,
+	0x00, 0x0d, 0x00, 0x2e, 0x40, 0x2b, 0x0d, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x05, 0x01, 0x01, 0x06,
+	0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00, 0x00, 0x00, 0x54, 0x4d, 0x00, 0x03, 0x03, 0x55,
+	0x03, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x11, 0x11, 0x12, 0x07, 0x0b,
+	0x1a, 0x2b, 0x01, 0x37, 0x01, 0x33, 0x03, 0x33, 0x07, 0x23, 0x07, 0x23, 0x37, 0x25, 0x21, 0x13,
+	0x01, 0x0e, 0x22, 0x02, 0x5a, 0xcb, 0x81, 0x66, 0x22, 0x66, 0x3a, 0xc3, 0x3b, 0xfe, 0xf3, 0x01,
+	0x33, 0x57, 0x03, 0xa2, 0x85, 0x02, 0x06, 0xfd, 0xfa, 0x85, 0xed, 0xed, 0x85, 0x01, 0x5c, 0x00,
+	0x00, 0x01, 0x01, 0x29, 0x02, 0x9f, 0x04, 0x7d, 0x06, 0x2d, 0x00, 0x21, 0x00, 0x65, 0xb5, 0x01,
+	0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x09, 0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x03, 0x01,
+	0x03, 0x04, 0x01, 0x80, 0x00, 0x01, 0x00, 0x03, 0x01, 0x70, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x54, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x1b,
+	0x40, 0x24, 0x00, 0x04, 0x03, 0x01, 0x03, 0x04, 0x01, 0x80, 0x00, 0x01, 0x00, 0x03, 0x01, 0x00,
+	0x7e, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x54, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x28, 0x21, 0x11, 0x11, 0x28, 0x23, 0x06,
+	0x0b, 0x1c, 0x2b, 0x01, 0x37, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23,
+	0x23, 0x13, 0x21, 0x07, 0x21, 0x07, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26,
+	0x01, 0x29, 0x20, 0x33, 0x66, 0x3b, 0x2d, 0x4f, 0x3e, 0x29, 0x09, 0x0c, 0x13, 0x41, 0x6e, 0x4f,
+	0x5c, 0x71, 0x02, 0x64, 0x23, 0xfe, 0x3a, 0x33, 0x18, 0x5e, 0x9e, 0x6a, 0x2d, 0x13, 0x12, 0x60,
+	0x85, 0x9d, 0x4f, 0x31, 0x76, 0x02, 0xb8, 0x81, 0x16, 0x16, 0x1b, 0x2d, 0x3b, 0x21, 0x31, 0x45,
+	0x2c, 0x14, 0x01, 0xc6, 0x8d, 0xcc, 0x1d, 0x42, 0x6a, 0x4d, 0x46, 0x6c, 0x48, 0x25, 0x0c, 0x00,
+	0x00, 0x02, 0x01, 0x17, 0x02, 0x9f, 0x04, 0x7e, 0x06, 0x43, 0x00, 0x16, 0x00, 0x20, 0x00, 0x33,
+	0x40, 0x30, 0x01, 0x01, 0x01, 0x00, 0x06, 0x01, 0x04, 0x01, 0x02, 0x4c, 0x00, 0x01, 0x00, 0x04,
+	0x05, 0x01, 0x04, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x56, 0x4d, 0x00, 0x05,
+	0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x57, 0x02, 0x4e, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06,
+	0x0b, 0x1c, 0x2b, 0x01, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x07, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x00, 0x33, 0x32, 0x03, 0x36, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x16, 0x33, 0x32, 0x04, 0x7e, 0x20, 0x84, 0x47, 0x78, 0xaf, 0x23, 0x03, 0x72, 0x7d, 0x8d,
+	0x7f, 0x1f, 0x26, 0xe1, 0xac, 0xbd, 0x9b, 0x34, 0x39, 0x01, 0x44, 0xda, 0x60, 0xb1, 0x2f, 0x90,
+	0x4b, 0x74, 0x15, 0x15, 0x3e, 0x4b, 0x92, 0x06, 0x27, 0x81, 0x2e, 0x9e, 0x8e, 0x0f, 0x57, 0x94,
+	0x7f, 0x99, 0xa5, 0xe8, 0xcf, 0xe2, 0x01, 0x0b, 0xfd, 0x86, 0xba, 0x65, 0x53, 0x58, 0x66, 0x00,
+	0x00, 0x01, 0x01, 0x41, 0x02, 0xb5, 0x04, 0xae, 0x06, 0x2d, 0x00, 0x0a, 0x00, 0x1f, 0x40, 0x1c,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x54, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x55, 0x02,
+	0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x0b, 0x18, 0x2b, 0x01, 0x36, 0x36,
+	0x37, 0x37, 0x21, 0x37, 0x21, 0x07, 0x00, 0x03, 0x01, 0x41, 0x26, 0xad, 0xd9, 0xe8, 0xfd, 0xf6,
+	0x24, 0x02, 0xbf, 0x24, 0xfe, 0x09, 0x5c, 0x02, 0xb5, 0x60, 0xd0, 0xd4, 0xe4, 0x90, 0x90, 0xfe,
+	0x45, 0xfe, 0xd3, 0x00, 0x00, 0x03, 0x01, 0x17, 0x02, 0x9f, 0x04, 0x7a, 0x06, 0x43, 0x00, 0x16,
+	0x00, 0x20, 0x00, 0x2b, 0x00, 0x25, 0x40, 0x22, 0x0b, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x56, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x57, 0x01, 0x4e, 0x28, 0x28, 0x29, 0x25, 0x04, 0x0b, 0x1a, 0x2b, 0x01, 0x26, 0x26, 0x37, 0x36,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37,
+	0x36, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x17, 0x16, 0x07, 0x06, 0x07, 0x06,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x27, 0x02, 0x43, 0x48, 0x27, 0x11, 0x1a, 0xcd, 0x98, 0x8c,
+	0x8a, 0x17, 0x1f, 0xc0, 0x6c, 0x45, 0x13, 0x1e, 0xfb, 0xaa, 0xa6, 0xa2, 0x1c, 0x14, 0x78, 0x01,
+	0x5e, 0x6a, 0x17, 0x1c, 0x7a, 0x7e, 0x19, 0x10, 0x62, 0x0c, 0x64, 0x7e, 0x1b, 0x25, 0xac, 0x46,
+	0x6b, 0x0e, 0x0c, 0x24, 0x50, 0x04, 0x93, 0x39, 0x52, 0x42, 0x6a, 0x79, 0x6d, 0x5d, 0x7f, 0x5e,
+	0x40, 0x69, 0x4b, 0x75, 0x94, 0x81, 0x6f, 0x4f, 0x72, 0x7f, 0x38, 0x5c, 0x71, 0x62, 0x43, 0x46,
+	0x09, 0x88, 0x50, 0x68, 0x94, 0x43, 0x35, 0x31, 0x37, 0x3a, 0x00, 0x00, 0x00, 0x02, 0x01, 0x2f,
+	0x02, 0x9f, 0x04, 0x95, 0x06, 0x43, 0x00, 0x16, 0x00, 0x20, 0x00, 0x33, 0x40, 0x30, 0x06, 0x01,
+	0x01, 0x04, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x56, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x57, 0x03, 0x4e, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x0b, 0x1c, 0x2b, 0x01,
+	0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x06, 0x00, 0x23, 0x22, 0x13, 0x06, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22,
+	0x01, 0x2f, 0x21, 0x84, 0x46, 0x7a, 0xae, 0x23, 0x04, 0x73, 0x7d, 0x8d, 0x81, 0x21, 0x26, 0xe2,
+	0xab, 0xbd, 0x99, 0x33, 0x39, 0xfe, 0xbc, 0xd9, 0x60, 0xb1, 0x2f, 0x90, 0x4b, 0x74, 0x15, 0x16,
+	0x3f, 0x4b, 0x93, 0x02, 0xba, 0x82, 0x2f, 0x9e, 0x8e, 0x0f, 0x57, 0x95, 0x7f, 0x99, 0xa5, 0xe8,
+	0xcf, 0xe2, 0xfe, 0xf5, 0x02, 0x7a, 0xbb, 0x65, 0x54, 0x57, 0x66, 0x00, 0x00, 0x01, 0x01, 0x54,
+	0x02, 0xf0, 0x04, 0x56, 0x05, 0x40, 0x00, 0x0b, 0x00, 0x5a, 0x4b, 0xb0, 0x0b, 0x50, 0x58, 0x40,
+	0x20, 0x00, 0x02, 0x01, 0x01, 0x02, 0x70, 0x06, 0x01, 0x05, 0x00, 0x00, 0x05, 0x71, 0x03, 0x01,
+	0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x60, 0x04, 0x01, 0x00, 0x01, 0x00,
+	0x50, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x01, 0x02, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03,
+	0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x60, 0x04, 0x01, 0x00, 0x01,
+	0x00, 0x50, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x0b, 0x1b, 0x2b, 0x01, 0x37, 0x21, 0x37, 0x21, 0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x07,
+	0x02, 0x42, 0x3b, 0xfe, 0xd7, 0x1d, 0x01, 0x29, 0x3c, 0x93, 0x3c, 0x01, 0x29, 0x1e, 0xfe, 0xd7,
+	0x3b, 0x02, 0xf0, 0xee, 0x75, 0xed, 0xed, 0x75, 0xee, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x56,
+	0x03, 0xe4, 0x04, 0x54, 0x04, 0x4c, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x0b, 0x17, 0x2b, 0x01, 0x37, 0x21, 0x07, 0x01, 0x56, 0x1a, 0x02,
+	0xe4, 0x19, 0x03, 0xe4, 0x68, 0x68, 0x00, 0x00, 0x00, 0x02, 0x01, 0x32, 0x03, 0x56, 0x04, 0x75,
+	0x04, 0xcf, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x0b, 0x17, 0x2b, 0x01, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x01,
+	0x32, 0x21, 0x02, 0xe4, 0x20, 0xfd, 0x5d, 0x1d, 0x02, 0xe4, 0x1d, 0x03, 0x56, 0x7f, 0x7f, 0x01,
+	0x04, 0x75, 0x75, 0x00, 0x00, 0x01, 0x01, 0x6f, 0x01, 0xfd, 0x03, 0xbc, 0x06, 0x6f, 0x00, 0x0b,
+	0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x07, 0x06, 0x03, 0x02, 0x17, 0x07, 0x26,
+	0x02, 0x37, 0x36, 0x00, 0x03, 0xbc, 0x1c, 0xf0, 0x49, 0x4a, 0x9f, 0x1c, 0xa3, 0x8e, 0x2d, 0x2d,
+	0x01, 0x25, 0x06, 0x6f, 0x72, 0xa1, 0xfe, 0xd9, 0xfe, 0xdb, 0xa1, 0x72, 0x4e, 0x01, 0x38, 0xb3,
+	0xb3, 0x01, 0x33, 0x00, 0x00, 0x01, 0x00, 0xfd, 0x01, 0xfd, 0x03, 0x4a, 0x06, 0x6f, 0x00, 0x0b,
+	0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x13, 0x37, 0x36, 0x13, 0x12, 0x27, 0x37, 0x16,
+	0x12, 0x07, 0x06, 0x00, 0xfd, 0x1b, 0xf0, 0x48, 0x4b, 0x9f, 0x1c, 0xa3, 0x8f, 0x2d, 0x2d, 0xfe,
+	0xda, 0x01, 0xfd, 0x72, 0xa1, 0x01, 0x24, 0x01, 0x28, 0xa1, 0x72, 0x4e, 0xfe, 0xc8, 0xb4, 0xb3,
+	0xfe, 0xcd, 0x00, 0x00, 0x00, 0x01, 0x01, 0x2c, 0x02, 0xb5, 0x04, 0xaa, 0x05, 0x56, 0x00, 0x10,
+	0x00, 0x4b, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x14,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x59, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02,
+	0x02, 0x55, 0x02, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00,
+	0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x0b, 0x1a, 0x2b, 0x01, 0x13, 0x33,
+	0x07, 0x36, 0x33, 0x32, 0x07, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x2c,
+	0xa4, 0xde, 0x1b, 0x9d, 0x99, 0xe1, 0x34, 0x74, 0xde, 0x69, 0x0f, 0x1a, 0x33, 0x5a, 0x80, 0x6b,
+	0x02, 0xb5, 0x02, 0x92, 0x6d, 0x7c, 0xd0, 0xfe, 0x2f, 0x01, 0xa5, 0x41, 0x30, 0x69, 0xfe, 0x53,
+	0x00, 0x03, 0x00, 0x37, 0xfe, 0xb6, 0x03, 0x7f, 0x02, 0x5a, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19,
+	0x00, 0x39, 0x40, 0x36, 0x17, 0x10, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x06, 0x01, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00,
+	0x4d, 0x00, 0x4e, 0x14, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x19, 0x14, 0x19, 0x0c, 0x12, 0x0d,
+	0x12, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x07, 0x0a, 0x16, 0x2b, 0x01, 0x22, 0x02, 0x37, 0x36,
+	0x24, 0x33, 0x32, 0x16, 0x07, 0x06, 0x04, 0x27, 0x32, 0x13, 0x36, 0x37, 0x01, 0x06, 0x01, 0x22,
+	0x03, 0x06, 0x07, 0x01, 0x36, 0x01, 0x66, 0xa7, 0x88, 0x35, 0x34, 0x01, 0x09, 0xa6, 0xa6, 0x8a,
+	0x34, 0x36, 0xfe, 0xf8, 0x8b, 0x9d, 0x5a, 0x0c, 0x06, 0xfe, 0x94, 0x0f, 0x01, 0x24, 0x9e, 0x58,
+	0x0c, 0x09, 0x01, 0x6d, 0x0f, 0xfe, 0xb6, 0x01, 0x00, 0xd2, 0xd3, 0xff, 0xfe, 0xd4, 0xd5, 0xfd,
+	0x6e, 0x01, 0x64, 0x30, 0x2a, 0xfe, 0xfc, 0xba, 0x02, 0xc7, 0xfe, 0x9d, 0x30, 0x29, 0x01, 0x04,
+	0xb8, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x49, 0xfe, 0xcc, 0x02, 0xfd, 0x02, 0x5a, 0x00, 0x09,
+	0x00, 0x21, 0x40, 0x1e, 0x06, 0x04, 0x03, 0x03, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15, 0x11, 0x04,
+	0x0a, 0x18, 0x2b, 0x13, 0x37, 0x33, 0x13, 0x07, 0x37, 0x25, 0x03, 0x33, 0x07, 0x49, 0x1a, 0xde,
+	0xa3, 0xe9, 0x1b, 0x01, 0xd2, 0xc9, 0xde, 0x1a, 0xfe, 0xcc, 0x67, 0x02, 0x90, 0x2d, 0x6b, 0x59,
+	0xfc, 0xd9, 0x67, 0x00, 0x00, 0x01, 0xff, 0xfa, 0xfe, 0xcc, 0x03, 0x78, 0x02, 0x5a, 0x00, 0x1a,
+	0x00, 0x2b, 0x40, 0x28, 0x0c, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x4c, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x49, 0x03, 0x4e,
+	0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x18, 0x23, 0x29, 0x05, 0x0a, 0x19, 0x2b, 0x03, 0x37, 0x36,
+	0x3f, 0x02, 0x36, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06,
+	0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x07, 0x06, 0x23, 0x52, 0x6b, 0x5c, 0x6b, 0x6f, 0x4e, 0x0f,
+	0x23, 0xa5, 0x6b, 0xb4, 0x21, 0xb7, 0x89, 0xaa, 0xa1, 0x1e, 0x13, 0x7c, 0x8e, 0x57, 0xa8, 0x26,
+	0x01, 0xbd, 0x23, 0xfe, 0xcc, 0x8c, 0x56, 0x48, 0x3f, 0x48, 0x4d, 0x53, 0x40, 0x8a, 0x42, 0x82,
+	0x33, 0x86, 0x76, 0x4c, 0x7b, 0x53, 0x32, 0x62, 0x58, 0x8c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x29,
+	0xfe, 0xb6, 0x03, 0x62, 0x02, 0x5a, 0x00, 0x1f, 0x00, 0x37, 0x40, 0x34, 0x11, 0x01, 0x02, 0x03,
+	0x19, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02,
+	0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x4c, 0x4d, 0x00, 0x00, 0x00, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x28, 0x23, 0x23, 0x11, 0x23, 0x22, 0x06, 0x0a, 0x1c,
+	0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x32, 0x36, 0x37, 0x36,
+	0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x05, 0x04, 0x07, 0x06, 0x04, 0x23,
+	0x22, 0x29, 0x21, 0x9a, 0x4f, 0xba, 0x24, 0x1a, 0x6f, 0xa1, 0x26, 0x1b, 0xa8, 0x93, 0x16, 0x1f,
+	0xa1, 0x77, 0x77, 0x1e, 0x85, 0x94, 0xa3, 0x97, 0x19, 0x27, 0xfe, 0xfa, 0x01, 0x0c, 0x2f, 0x1d,
+	0xff, 0x00, 0xb5, 0x7c, 0xfe, 0xd2, 0x85, 0x33, 0x91, 0x64, 0x51, 0x6a, 0x43, 0x56, 0x7e, 0x32,
+	0x79, 0x28, 0x70, 0x65, 0x9c, 0x41, 0x34, 0xbc, 0x74, 0x8e, 0x00, 0x00, 0x00, 0x02, 0x00, 0x12,
+	0xfe, 0xcc, 0x03, 0x59, 0x02, 0x44, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x2e, 0x40, 0x2b, 0x0d, 0x01,
+	0x01, 0x00, 0x01, 0x4c, 0x05, 0x01, 0x01, 0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00,
+	0x00, 0x00, 0x48, 0x4d, 0x00, 0x03, 0x03, 0x49, 0x03, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a,
+	0x00, 0x0a, 0x11, 0x11, 0x11, 0x12, 0x07, 0x0a, 0x1a, 0x2b, 0x17, 0x37, 0x01, 0x33, 0x03, 0x33,
+	0x07, 0x23, 0x07, 0x23, 0x37, 0x25, 0x21, 0x13, 0x12, 0x22, 0x02, 0x5a, 0xcb, 0x81, 0x66, 0x22,
+	0x66, 0x3a, 0xc3, 0x3b, 0xfe, 0xf3, 0x01, 0x33, 0x57, 0x47, 0x85, 0x02, 0x06, 0xfd, 0xfa, 0x85,
+	0xed, 0xed, 0x85, 0x01, 0x5c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x2d, 0xfe, 0xb6, 0x03, 0x81,
+	0x02, 0x44, 0x00, 0x21, 0x00, 0x65, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x09,
+	0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x03, 0x01, 0x03, 0x04, 0x01, 0x80, 0x00, 0x01, 0x00, 0x03,
+	0x01, 0x70, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x48, 0x4d, 0x00, 0x00, 0x00, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x04, 0x03, 0x01, 0x03, 0x04,
+	0x01, 0x80, 0x00, 0x01, 0x00, 0x03, 0x01, 0x00, 0x7e, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x48, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x59, 0x40,
+	0x09, 0x28, 0x21, 0x11, 0x11, 0x28, 0x23, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x37, 0x16, 0x16, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x13, 0x21, 0x07, 0x21, 0x07, 0x33, 0x32,
+	0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x2d, 0x20, 0x33, 0x66, 0x3b, 0x2d, 0x4f, 0x3e,
+	0x29, 0x09, 0x0c, 0x13, 0x41, 0x6e, 0x4f, 0x5c, 0x71, 0x02, 0x64, 0x23, 0xfe, 0x3a, 0x33, 0x18,
+	0x5e, 0x9e, 0x6a, 0x2d, 0x13, 0x12, 0x60, 0x85, 0x9d, 0x4f, 0x31, 0x76, 0xfe, 0xcf, 0x81, 0x16,
+	0x16, 0x1b, 0x2d, 0x3b, 0x21, 0x31, 0x45, 0x2c, 0x14, 0x01, 0xc6, 0x8d, 0xcc, 0x1d, 0x42, 0x6a,
+	0x4d, 0x46, 0x6c, 0x48, 0x25, 0x0c, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1b, 0xfe, 0xb6, 0x03, 0x82,
+	0x02, 0x5a, 0x00, 0x16, 0x00, 0x20, 0x00, 0x33, 0x40, 0x30, 0x01, 0x01, 0x01, 0x00, 0x06, 0x01,
+	0x04, 0x01, 0x02, 0x4c, 0x00, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x69, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x4c, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x4d, 0x02,
+	0x4e, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x01, 0x07, 0x26, 0x23, 0x22,
+	0x06, 0x07, 0x07, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x00,
+	0x33, 0x32, 0x03, 0x36, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x03, 0x82, 0x20, 0x84,
+	0x47, 0x78, 0xaf, 0x23, 0x03, 0x72, 0x7d, 0x8d, 0x7f, 0x1f, 0x26, 0xe1, 0xac, 0xbd, 0x9b, 0x34,
+	0x39, 0x01, 0x44, 0xda, 0x60, 0xb1, 0x2f, 0x90, 0x4b, 0x74, 0x15, 0x15, 0x3e, 0x4b, 0x92, 0x02,
+	0x3e, 0x81, 0x2e, 0x9e, 0x8e, 0x0f, 0x57, 0x94, 0x7f, 0x99, 0xa5, 0xe8, 0xcf, 0xe2, 0x01, 0x0b,
+	0xfd, 0x86, 0xba, 0x65, 0x53, 0x58, 0x66, 0x00, 0x00, 0x01, 0x00, 0x45, 0xfe, 0xcc, 0x03, 0xb2,
+	0x02, 0x44, 0x00, 0x0a, 0x00, 0x1f, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x48, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11,
+	0x14, 0x04, 0x0a, 0x18, 0x2b, 0x13, 0x36, 0x36, 0x37, 0x37, 0x21, 0x37, 0x21, 0x07, 0x00, 0x03,
+	0x45, 0x26, 0xad, 0xd9, 0xe8, 0xfd, 0xf6, 0x24, 0x02, 0xbf, 0x24, 0xfe, 0x09, 0x5c, 0xfe, 0xcc,
+	0x60, 0xd0, 0xd4, 0xe4, 0x90, 0x90, 0xfe, 0x45, 0xfe, 0xd3, 0x00, 0x00, 0x00, 0x03, 0x00, 0x1b,
+	0xfe, 0xb6, 0x03, 0x7e, 0x02, 0x5a, 0x00, 0x16, 0x00, 0x20, 0x00, 0x2b, 0x00, 0x25, 0x40, 0x22,
+	0x0b, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x4c, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4d, 0x01, 0x4e, 0x28, 0x28, 0x29, 0x25, 0x04,
+	0x0a, 0x1a, 0x2b, 0x25, 0x26, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16,
+	0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22,
+	0x07, 0x06, 0x17, 0x16, 0x07, 0x06, 0x07, 0x06, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x27, 0x01,
+	0x47, 0x48, 0x27, 0x11, 0x1a, 0xcd, 0x98, 0x8c, 0x8a, 0x17, 0x1f, 0xc0, 0x6c, 0x45, 0x13, 0x1e,
+	0xfb, 0xaa, 0xa6, 0xa2, 0x1c, 0x14, 0x78, 0x01, 0x5e, 0x6a, 0x17, 0x1c, 0x7a, 0x7e, 0x19, 0x10,
+	0x62, 0x0c, 0x64, 0x7e, 0x1b, 0x25, 0xac, 0x46, 0x6b, 0x0e, 0x0c, 0x24, 0x50, 0xaa, 0x39, 0x52,
+	0x42, 0x6a, 0x79, 0x6d, 0x5d, 0x7f, 0x5e, 0x40, 0x69, 0x4b, 0x75, 0x94, 0x81, 0x6f, 0x4f, 0x72,
+	0x7f, 0x38, 0x5c, 0x71, 0x62, 0x43, 0x46, 0x09, 0x88, 0x50, 0x68, 0x94, 0x43, 0x35, 0x31, 0x37,
+	0x3a, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x33, 0xfe, 0xb6, 0x03, 0x99, 0x02, 0x5a, 0x00, 0x16,
+	0x00, 0x20, 0x00, 0x33, 0x40, 0x30, 0x06, 0x01, 0x01, 0x04, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c,
+	0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x4c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x4d, 0x03, 0x4e, 0x24, 0x22, 0x24,
+	0x24, 0x24, 0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x06,
+	0x23, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x00, 0x23, 0x22, 0x13, 0x06,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x33, 0x21, 0x84, 0x46, 0x7a, 0xae, 0x23, 0x04,
+	0x73, 0x7d, 0x8d, 0x81, 0x21, 0x26, 0xe2, 0xab, 0xbd, 0x99, 0x33, 0x39, 0xfe, 0xbc, 0xd9, 0x60,
+	0xb1, 0x2f, 0x90, 0x4b, 0x74, 0x15, 0x16, 0x3f, 0x4b, 0x93, 0xfe, 0xd1, 0x82, 0x2f, 0x9e, 0x8e,
+	0x0f, 0x57, 0x95, 0x7f, 0x99, 0xa5, 0xe8, 0xcf, 0xe2, 0xfe, 0xf5, 0x02, 0x7a, 0xbb, 0x65, 0x54,
+	0x57, 0x66, 0x00, 0x00, 0x00, 0x01, 0x00, 0x58, 0xff, 0x07, 0x03, 0x5a, 0x01, 0x57, 0x00, 0x0b,
+	0x00, 0x70, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x17, 0x06, 0x01, 0x05, 0x00, 0x00, 0x05, 0x71,
+	0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x4a, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2f, 0x50, 0x58, 0x40, 0x16, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01,
+	0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x4a, 0x02, 0x4e, 0x1b, 0x40,
+	0x1e, 0x00, 0x02, 0x01, 0x02, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01, 0x00,
+	0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x60, 0x04, 0x01, 0x00, 0x01, 0x00, 0x50, 0x59,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x0a,
+	0x1b, 0x2b, 0x05, 0x37, 0x21, 0x37, 0x21, 0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x07, 0x01, 0x46,
+	0x3b, 0xfe, 0xd7, 0x1d, 0x01, 0x29, 0x3c, 0x93, 0x3c, 0x01, 0x29, 0x1e, 0xfe, 0xd7, 0x3b, 0xf9,
+	0xee, 0x75, 0xed, 0xed, 0x75, 0xee, 0x00, 0x00, 0x00, 0x01, 0x00, 0x5a, 0xff, 0xfb, 0x03, 0x58,
+	0x00, 0x63, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x0a, 0x17, 0x2b, 0x17, 0x37, 0x21, 0x07, 0x5a, 0x1a, 0x02, 0xe4, 0x19, 0x05, 0x68, 0x68,
+	0x00, 0x02, 0x00, 0x36, 0xff, 0x6d, 0x03, 0x79, 0x00, 0xe6, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f,
+	0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x0a, 0x17, 0x2b, 0x17,
+	0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x36, 0x21, 0x02, 0xe4, 0x20, 0xfd, 0x5d, 0x1d, 0x02,
+	0xe4, 0x1d, 0x93, 0x7f, 0x7f, 0x01, 0x04, 0x75, 0x75, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5,
+	0xfe, 0x14, 0x02, 0xf2, 0x02, 0x86, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b,
+	0x01, 0x07, 0x06, 0x03, 0x02, 0x17, 0x07, 0x26, 0x02, 0x37, 0x36, 0x00, 0x02, 0xf2, 0x1c, 0xf0,
+	0x49, 0x4a, 0x9f, 0x1c, 0xa3, 0x8e, 0x2d, 0x2d, 0x01, 0x25, 0x02, 0x86, 0x72, 0xa1, 0xfe, 0xd9,
+	0xfe, 0xdb, 0xa1, 0x72, 0x4e, 0x01, 0x38, 0xb3, 0xb3, 0x01, 0x33, 0x00, 0x00, 0x01, 0x00, 0x33,
+	0xfe, 0x14, 0x02, 0x80, 0x02, 0x86, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b,
+	0x13, 0x37, 0x36, 0x13, 0x12, 0x27, 0x37, 0x16, 0x12, 0x07, 0x06, 0x00, 0x33, 0x1b, 0xf0, 0x48,
+	0x4b, 0x9f, 0x1c, 0xa3, 0x8f, 0x2d, 0x2d, 0xfe, 0xda, 0xfe, 0x14, 0x72, 0xa1, 0x01, 0x24, 0x01,
+	0x28, 0xa1, 0x72, 0x4e, 0xfe, 0xc8, 0xb4, 0xb3, 0xfe, 0xcd, 0x00, 0x00, 0x00, 0x01, 0x00, 0x30,
+	0xfe, 0xcc, 0x03, 0xae, 0x01, 0x6d, 0x00, 0x10, 0x00, 0x4c, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x20, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x4a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00,
+	0x00, 0x4a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4a, 0x4d, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x49, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12,
+	0x22, 0x11, 0x06, 0x0a, 0x1a, 0x2b, 0x13, 0x13, 0x33, 0x07, 0x36, 0x33, 0x32, 0x07, 0x03, 0x23,
+	0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x30, 0xa4, 0xde, 0x1b, 0x9d, 0x99, 0xe1, 0x34, 0x74,
+	0xde, 0x69, 0x0f, 0x1a, 0x33, 0x5a, 0x80, 0x6b, 0xfe, 0xcc, 0x02, 0x92, 0x6d, 0x7c, 0xd0, 0xfe,
+	0x2f, 0x01, 0xa5, 0x41, 0x30, 0x69, 0xfe, 0x53, 0x00, 0x01, 0x00, 0x3c, 0x00, 0x00, 0x04, 0xfb,
+	0x05, 0xc8, 0x00, 0x15, 0x00, 0xed, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0e, 0x0b, 0x01, 0x02,
+	0x01, 0x07, 0x01, 0x04, 0x02, 0x0c, 0x01, 0x05, 0x04, 0x03, 0x4c, 0x1b, 0x4b, 0xb0, 0x1d, 0x50,
+	0x58, 0x40, 0x0e, 0x0b, 0x01, 0x02, 0x03, 0x07, 0x01, 0x04, 0x02, 0x0c, 0x01, 0x05, 0x04, 0x03,
+	0x4c, 0x1b, 0x40, 0x0e, 0x0b, 0x01, 0x02, 0x03, 0x07, 0x01, 0x04, 0x06, 0x0c, 0x01, 0x05, 0x04,
+	0x03, 0x4c, 0x59, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x02, 0x06, 0x01,
+	0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08,
+	0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x59, 0x00, 0x02, 0x06, 0x01, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67,
+	0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00,
+	0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00, 0x03, 0x00,
+	0x04, 0x05, 0x03, 0x04, 0x69, 0x08, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x00, 0x15, 0x00, 0x15, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x11, 0x09,
+	0x09, 0x1d, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x36, 0x33, 0x32, 0x17, 0x03,
+	0x26, 0x23, 0x22, 0x07, 0x03, 0x23, 0x13, 0x23, 0x03, 0x3c, 0x01, 0x27, 0x03, 0x53, 0x28, 0xfd,
+	0xa7, 0x53, 0x01, 0xf4, 0x29, 0x75, 0xa7, 0x18, 0x1a, 0x34, 0x40, 0x26, 0x66, 0x75, 0x5c, 0xfa,
+	0x83, 0xfa, 0x83, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xcf, 0xe7, 0x06, 0xfe, 0xfe, 0x12, 0xb3, 0xfe,
+	0x31, 0x02, 0x94, 0xfd, 0x6c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6f, 0x00, 0x00, 0x05, 0x2a,
+	0x05, 0xee, 0x00, 0x22, 0x00, 0x82, 0x40, 0x0a, 0x11, 0x01, 0x05, 0x04, 0x12, 0x01, 0x03, 0x05,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01,
+	0x03, 0x02, 0x67, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x0a, 0x0a, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b,
+	0x39, 0x0b, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x04, 0x00, 0x05, 0x03, 0x04, 0x05, 0x69, 0x06, 0x01,
+	0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x67, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01,
+	0x00, 0x67, 0x00, 0x0a, 0x0a, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x40,
+	0x16, 0x00, 0x00, 0x00, 0x22, 0x00, 0x22, 0x21, 0x20, 0x1e, 0x1d, 0x11, 0x11, 0x12, 0x23, 0x22,
+	0x11, 0x11, 0x11, 0x15, 0x0d, 0x09, 0x1f, 0x2b, 0x33, 0x37, 0x36, 0x36, 0x37, 0x37, 0x23, 0x37,
+	0x33, 0x37, 0x23, 0x37, 0x33, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x07,
+	0x33, 0x07, 0x23, 0x07, 0x33, 0x07, 0x23, 0x06, 0x07, 0x21, 0x07, 0x6f, 0x2e, 0x73, 0x70, 0x1b,
+	0x04, 0xc5, 0x1e, 0xc5, 0x28, 0xc5, 0x1d, 0xc5, 0x04, 0x5d, 0x01, 0xc1, 0x78, 0x8e, 0x29, 0x6f,
+	0x6f, 0xbd, 0x2b, 0x11, 0xd8, 0x1d, 0xd8, 0x28, 0xd8, 0x1e, 0xd8, 0x52, 0xc3, 0x02, 0x8b, 0x2e,
+	0xea, 0x1a, 0x7d, 0x83, 0x18, 0x94, 0xc6, 0x94, 0x12, 0x01, 0xd2, 0x18, 0xcb, 0x29, 0xd6, 0x54,
+	0x94, 0xc6, 0x94, 0xbe, 0x74, 0xea, 0x00, 0x00, 0x00, 0x04, 0x00, 0x3d, 0xff, 0xe7, 0x08, 0xfa,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x15, 0x00, 0x2a, 0x00, 0x49, 0x01, 0xd1, 0x4b, 0xb0, 0x19, 0x50,
+	0x58, 0x40, 0x13, 0x21, 0x01, 0x07, 0x04, 0x3a, 0x01, 0x03, 0x07, 0x3b, 0x01, 0x01, 0x06, 0x2c,
+	0x2a, 0x02, 0x0a, 0x01, 0x04, 0x4c, 0x1b, 0x40, 0x13, 0x21, 0x01, 0x0c, 0x04, 0x3a, 0x01, 0x03,
+	0x07, 0x3b, 0x01, 0x01, 0x06, 0x2c, 0x2a, 0x02, 0x0a, 0x01, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x2d, 0x0c, 0x08, 0x02, 0x07, 0x0d, 0x09, 0x02, 0x06, 0x01, 0x07, 0x06, 0x67,
+	0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x02, 0x61, 0x0e, 0x05, 0x0f, 0x03, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x31, 0x0c, 0x08, 0x02, 0x07, 0x0d, 0x09, 0x02,
+	0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x0b, 0x01, 0x0a,
+	0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58,
+	0x40, 0x36, 0x00, 0x0c, 0x07, 0x06, 0x0c, 0x59, 0x08, 0x01, 0x07, 0x0d, 0x09, 0x02, 0x06, 0x01,
+	0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05,
+	0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x1e, 0x50, 0x58, 0x40, 0x37,
+	0x00, 0x0c, 0x00, 0x0d, 0x06, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01, 0x06, 0x01, 0x07,
+	0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05, 0x61,
+	0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x41, 0x00,
+	0x0c, 0x00, 0x0d, 0x06, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01, 0x06, 0x01, 0x07, 0x06,
+	0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x4d, 0x0f, 0x01,
+	0x02, 0x02, 0x39, 0x4d, 0x00, 0x0b, 0x0b, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e,
+	0x1b, 0x40, 0x3f, 0x00, 0x00, 0x00, 0x04, 0x0c, 0x00, 0x04, 0x69, 0x00, 0x0c, 0x00, 0x0d, 0x06,
+	0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00,
+	0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x4d,
+	0x0f, 0x01, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x0b, 0x0b, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42,
+	0x05, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x23, 0x00, 0x00, 0x49, 0x47, 0x3e, 0x3c, 0x39,
+	0x37, 0x2f, 0x2d, 0x29, 0x27, 0x25, 0x24, 0x23, 0x22, 0x1f, 0x1e, 0x1d, 0x1c, 0x19, 0x17, 0x15,
+	0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x26, 0x21, 0x10, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21,
+	0x32, 0x16, 0x17, 0x16, 0x07, 0x06, 0x00, 0x23, 0x23, 0x03, 0x13, 0x33, 0x32, 0x36, 0x37, 0x36,
+	0x26, 0x23, 0x23, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37, 0x13, 0x23, 0x37, 0x33, 0x37, 0x25, 0x07,
+	0x33, 0x07, 0x23, 0x03, 0x06, 0x33, 0x32, 0x37, 0x17, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27,
+	0x27, 0x26, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17,
+	0x16, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x3d, 0x01, 0x27, 0x01, 0x78, 0x9e, 0x90, 0x2b, 0x3e,
+	0x23, 0x2f, 0xfe, 0xbb, 0xec, 0x30, 0x73, 0x9c, 0x27, 0x6b, 0x9c, 0x19, 0x18, 0x57, 0x7d, 0x28,
+	0x03, 0x34, 0x54, 0x47, 0x9a, 0x6a, 0x21, 0x56, 0x53, 0x22, 0x53, 0x1c, 0x01, 0x11, 0x23, 0x9c,
+	0x22, 0x9c, 0x4f, 0x1f, 0x6c, 0x1b, 0x27, 0x3a, 0x27, 0xa3, 0x72, 0x7d, 0x0f, 0x0a, 0x60, 0x39,
+	0x7b, 0x56, 0x13, 0x38, 0x01, 0x70, 0x7a, 0x81, 0x27, 0x7b, 0x61, 0x81, 0x0f, 0x0a, 0x57, 0x34,
+	0x90, 0x5e, 0x13, 0x1b, 0xe9, 0xa6, 0xad, 0x05, 0xc8, 0x31, 0x44, 0x62, 0xb2, 0xec, 0xfe, 0xf1,
+	0xfd, 0xbc, 0x03, 0x0f, 0x95, 0x7f, 0x76, 0x64, 0xfb, 0x06, 0x1c, 0x90, 0xa5, 0x01, 0xab, 0xaa,
+	0x8d, 0x24, 0xb1, 0xaa, 0xfe, 0x76, 0x9a, 0x0b, 0x98, 0xc2, 0x46, 0x4d, 0x32, 0x2d, 0x1b, 0x3a,
+	0x7e, 0x60, 0x01, 0x14, 0x22, 0xc1, 0x38, 0x4b, 0x35, 0x24, 0x16, 0x3a, 0x7a, 0x62, 0x83, 0xa0,
+	0x00, 0x01, 0x00, 0x60, 0xff, 0xdb, 0x05, 0x4e, 0x05, 0xee, 0x00, 0x26, 0x00, 0x86, 0x40, 0x0e,
+	0x0d, 0x01, 0x04, 0x03, 0x0e, 0x01, 0x02, 0x04, 0x21, 0x01, 0x09, 0x08, 0x03, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x2a, 0x05, 0x01, 0x02, 0x06, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x07,
+	0x01, 0x00, 0x0c, 0x0b, 0x02, 0x08, 0x09, 0x00, 0x08, 0x67, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x3e, 0x4d, 0x00, 0x09, 0x09, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x3f, 0x0a, 0x4e, 0x1b,
+	0x40, 0x28, 0x00, 0x03, 0x00, 0x04, 0x02, 0x03, 0x04, 0x69, 0x05, 0x01, 0x02, 0x06, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x67, 0x07, 0x01, 0x00, 0x0c, 0x0b, 0x02, 0x08, 0x09, 0x00, 0x08, 0x67, 0x00,
+	0x09, 0x09, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x42, 0x0a, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x26, 0x00, 0x26, 0x25, 0x23, 0x20, 0x1e, 0x11, 0x14, 0x11, 0x11, 0x23, 0x21, 0x11, 0x14, 0x11,
+	0x0d, 0x09, 0x1f, 0x2b, 0x13, 0x37, 0x33, 0x36, 0x37, 0x36, 0x37, 0x23, 0x37, 0x33, 0x12, 0x21,
+	0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x03, 0x21, 0x07, 0x21, 0x06, 0x07, 0x06, 0x07, 0x21, 0x07,
+	0x21, 0x16, 0x16, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x03, 0x60, 0x61, 0x42, 0x06, 0x09,
+	0x0b, 0x10, 0x83, 0x61, 0x62, 0xee, 0x01, 0xe4, 0x99, 0x76, 0x2b, 0x6c, 0x8d, 0xfd, 0x98, 0x02,
+	0x06, 0x60, 0xfe, 0x18, 0x09, 0x0a, 0x0d, 0x07, 0x01, 0x9f, 0x61, 0xfe, 0xbe, 0x10, 0x88, 0x9f,
+	0x6d, 0x85, 0x29, 0x83, 0xb2, 0xfe, 0x21, 0x19, 0x01, 0xe1, 0xad, 0x2e, 0x2a, 0x39, 0x34, 0xad,
+	0x01, 0xee, 0x26, 0xd6, 0x37, 0xfe, 0xd7, 0xad, 0x21, 0x30, 0x44, 0x30, 0xad, 0xb3, 0x8e, 0x35,
+	0xcc, 0x2e, 0x02, 0x06, 0x00, 0x04, 0x00, 0x4a, 0x00, 0x00, 0x07, 0xf3, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x17, 0x00, 0x21, 0x00, 0x2b, 0x00, 0x5e, 0x40, 0x5b, 0x0d, 0x01, 0x04, 0x00, 0x17, 0x0e,
+	0x02, 0x05, 0x04, 0x02, 0x4c, 0x03, 0x01, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x05,
+	0x00, 0x02, 0x07, 0x05, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x0c, 0x01,
+	0x08, 0x01, 0x01, 0x08, 0x59, 0x0c, 0x01, 0x08, 0x08, 0x01, 0x61, 0x0b, 0x06, 0x0a, 0x03, 0x01,
+	0x08, 0x01, 0x51, 0x23, 0x22, 0x19, 0x18, 0x00, 0x00, 0x28, 0x26, 0x22, 0x2b, 0x23, 0x2b, 0x1e,
+	0x1c, 0x18, 0x21, 0x19, 0x21, 0x16, 0x14, 0x11, 0x0f, 0x0c, 0x0a, 0x07, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x0d, 0x06, 0x17, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x01, 0x06, 0x23, 0x22, 0x37, 0x36,
+	0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x13, 0x20,
+	0x37, 0x36, 0x00, 0x33, 0x20, 0x07, 0x06, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x23, 0x22, 0x06,
+	0x07, 0x06, 0x4a, 0x06, 0xf0, 0xb9, 0xf9, 0x10, 0x02, 0x7e, 0x91, 0x9f, 0xf9, 0x2c, 0x25, 0x01,
+	0x57, 0xb7, 0x52, 0x54, 0x3d, 0x56, 0x42, 0x4d, 0x9b, 0x16, 0x16, 0x6e, 0x55, 0x8c, 0xef, 0xfe,
+	0xfb, 0x2c, 0x27, 0x01, 0x5b, 0xc8, 0x01, 0x08, 0x2d, 0x27, 0xfe, 0xa6, 0x68, 0x4c, 0x89, 0x19,
+	0x17, 0x58, 0x4b, 0x8a, 0x18, 0x18, 0x05, 0xc8, 0xfa, 0x38, 0x03, 0x49, 0x38, 0xdb, 0xba, 0x01,
+	0x22, 0x27, 0x9b, 0x38, 0xb0, 0x72, 0x6c, 0x41, 0xfc, 0x0f, 0xde, 0xc2, 0x01, 0x18, 0xde, 0xc2,
+	0xfe, 0xe8, 0x8d, 0xaa, 0x7e, 0x76, 0xaa, 0x7b, 0x79, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x72,
+	0xff, 0xe7, 0x04, 0xcb, 0x06, 0x50, 0x00, 0x0f, 0x00, 0x3b, 0x00, 0x2d, 0x40, 0x2a, 0x29, 0x28,
+	0x23, 0x1a, 0x19, 0x05, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x03, 0x00, 0x00, 0x01, 0x03, 0x00, 0x69,
+	0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x01, 0x02, 0x51,
+	0x33, 0x31, 0x25, 0x2c, 0x27, 0x04, 0x06, 0x19, 0x2b, 0x01, 0x36, 0x36, 0x37, 0x36, 0x36, 0x26,
+	0x26, 0x23, 0x22, 0x0e, 0x04, 0x07, 0x03, 0x07, 0x06, 0x06, 0x16, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x17, 0x02, 0x00, 0x23, 0x22, 0x26, 0x26, 0x36, 0x37, 0x37, 0x0e, 0x03, 0x07, 0x27, 0x3e, 0x03,
+	0x37, 0x37, 0x12, 0x00, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x02, 0x9b, 0x7a, 0x95, 0x1c,
+	0x05, 0x06, 0x07, 0x1b, 0x1c, 0x1f, 0x33, 0x2b, 0x22, 0x1d, 0x17, 0x0a, 0x4a, 0x0d, 0x0b, 0x13,
+	0x01, 0x1a, 0x24, 0x39, 0x7b, 0x40, 0xbf, 0x6b, 0xfe, 0xed, 0xa6, 0x68, 0x71, 0x2d, 0x09, 0x11,
+	0x07, 0x19, 0x29, 0x29, 0x2e, 0x1c, 0x03, 0x19, 0x3a, 0x3b, 0x3a, 0x17, 0x32, 0x4a, 0x01, 0x25,
+	0xe5, 0x4c, 0x66, 0x37, 0x0b, 0x0e, 0x17, 0x75, 0xa0, 0xc1, 0x03, 0x19, 0x62, 0xf0, 0x89, 0x18,
+	0x36, 0x2c, 0x1d, 0x2f, 0x4e, 0x66, 0x6e, 0x6e, 0x2f, 0xfe, 0x90, 0x3f, 0x36, 0x6e, 0x59, 0x38,
+	0xaa, 0xb7, 0x25, 0xfe, 0xf7, 0xfe, 0xfb, 0x44, 0x73, 0x96, 0x53, 0x27, 0x05, 0x08, 0x06, 0x06,
+	0x03, 0xac, 0x04, 0x0a, 0x0c, 0x10, 0x09, 0xfa, 0x01, 0x72, 0x01, 0x73, 0x30, 0x55, 0x74, 0x44,
+	0x75, 0xde, 0xc2, 0x9d, 0x00, 0x04, 0x00, 0xaa, 0x00, 0x00, 0x08, 0xfc, 0x05, 0xc8, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x19, 0x00, 0x21, 0x00, 0x5d, 0x40, 0x5a, 0x08, 0x01, 0x09, 0x07, 0x03, 0x01,
+	0x08, 0x09, 0x02, 0x4c, 0x01, 0x01, 0x00, 0x07, 0x00, 0x85, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07,
+	0x09, 0x69, 0x0d, 0x01, 0x08, 0x0c, 0x01, 0x06, 0x04, 0x08, 0x06, 0x69, 0x00, 0x04, 0x02, 0x02,
+	0x04, 0x57, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x0b, 0x05, 0x0a, 0x03, 0x04, 0x02, 0x04, 0x02, 0x4f,
+	0x1b, 0x1a, 0x0f, 0x0e, 0x0a, 0x0a, 0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x21, 0x1b, 0x21, 0x15, 0x13,
+	0x0e, 0x19, 0x0f, 0x19, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12,
+	0x11, 0x0e, 0x06, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x23, 0x01, 0x03, 0x21,
+	0x37, 0x21, 0x07, 0x03, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27,
+	0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0xaa, 0x01, 0x27, 0xf7, 0x01, 0x9a, 0xc2, 0xf7, 0xfe,
+	0xd9, 0xf7, 0xfe, 0x66, 0xc2, 0x04, 0x03, 0x22, 0x02, 0x69, 0x22, 0xfc, 0xa2, 0x9e, 0x23, 0x24,
+	0xf8, 0xa6, 0xa5, 0xa1, 0x23, 0x25, 0xf9, 0x83, 0x7e, 0x2b, 0x2b, 0x7b, 0x7c, 0x2b, 0x2b, 0x05,
+	0xc8, 0xfc, 0x36, 0x03, 0xca, 0xfa, 0x38, 0x03, 0xcb, 0xfc, 0x35, 0xad, 0xad, 0x01, 0x35, 0xdf,
+	0xb2, 0xb3, 0xdd, 0xdd, 0xb2, 0xb6, 0xdc, 0xb9, 0xd8, 0xd7, 0xd7, 0xd8, 0x00, 0x02, 0x01, 0xd4,
+	0x02, 0xe4, 0x08, 0x61, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x14, 0x00, 0x4a, 0x40, 0x47, 0x13, 0x10,
+	0x0b, 0x03, 0x07, 0x00, 0x01, 0x4c, 0x00, 0x07, 0x00, 0x03, 0x00, 0x07, 0x03, 0x80, 0x0a, 0x08,
+	0x06, 0x09, 0x04, 0x03, 0x03, 0x84, 0x05, 0x04, 0x02, 0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x04,
+	0x02, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08,
+	0x14, 0x08, 0x14, 0x12, 0x11, 0x0f, 0x0e, 0x0d, 0x0c, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x0b, 0x06, 0x19, 0x2b, 0x01, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x21, 0x13,
+	0x33, 0x13, 0x13, 0x33, 0x03, 0x23, 0x13, 0x03, 0x23, 0x03, 0x03, 0x02, 0x64, 0x7c, 0xfe, 0xf4,
+	0x18, 0x02, 0xde, 0x18, 0xfe, 0xf4, 0x7c, 0x01, 0x8e, 0x94, 0xfe, 0x3e, 0xfd, 0xdc, 0x94, 0xb3,
+	0x68, 0xfe, 0x90, 0x3e, 0x6a, 0x02, 0xe4, 0x02, 0x69, 0x7b, 0x7b, 0xfd, 0x97, 0x02, 0xe4, 0xfe,
+	0x28, 0x01, 0xd8, 0xfd, 0x1c, 0x02, 0x06, 0xfe, 0x2c, 0x01, 0xe1, 0xfd, 0xed, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x51, 0x00, 0x00, 0x06, 0xaf, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x32, 0x40, 0x2f,
+	0x1e, 0x01, 0x00, 0x01, 0x4b, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x69, 0x02, 0x01, 0x00,
+	0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x00, 0x03,
+	0x4f, 0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f, 0x26, 0x11, 0x15, 0x25, 0x11, 0x07, 0x06, 0x1b, 0x2b,
+	0x33, 0x37, 0x21, 0x26, 0x02, 0x37, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x06, 0x02, 0x07, 0x21,
+	0x07, 0x21, 0x37, 0x36, 0x12, 0x37, 0x36, 0x02, 0x23, 0x22, 0x00, 0x07, 0x06, 0x12, 0x17, 0x07,
+	0x51, 0x28, 0x01, 0x62, 0x91, 0x6b, 0x26, 0x3b, 0x01, 0xcd, 0x01, 0x35, 0x01, 0x34, 0x01, 0x39,
+	0x3b, 0x26, 0xec, 0xc8, 0x01, 0x62, 0x28, 0xfd, 0xa9, 0x28, 0xa4, 0xcb, 0x28, 0x2d, 0x9c, 0xb4,
+	0xb5, 0xfe, 0xfc, 0x2d, 0x28, 0x4f, 0x76, 0x28, 0xcc, 0x88, 0x01, 0x44, 0xbc, 0x01, 0x27, 0x01,
+	0x72, 0xfe, 0x8e, 0xfe, 0xd9, 0xbb, 0xfe, 0xbc, 0x89, 0xcc, 0xcc, 0x70, 0x01, 0x39, 0xc9, 0xe1,
+	0x01, 0x03, 0xfe, 0xfc, 0xe1, 0xc9, 0xfe, 0xc8, 0x70, 0xcc, 0x00, 0x00, 0x00, 0x02, 0x00, 0x99,
+	0xff, 0xe7, 0x05, 0xcc, 0x03, 0x8b, 0x00, 0x1f, 0x00, 0x30, 0x00, 0x35, 0x40, 0x32, 0x00, 0x00,
+	0x03, 0x04, 0x03, 0x00, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x05,
+	0x00, 0x03, 0x00, 0x05, 0x03, 0x67, 0x00, 0x04, 0x01, 0x01, 0x04, 0x59, 0x00, 0x04, 0x04, 0x01,
+	0x61, 0x00, 0x01, 0x04, 0x01, 0x51, 0x27, 0x11, 0x27, 0x24, 0x28, 0x23, 0x10, 0x07, 0x06, 0x1d,
+	0x2b, 0x25, 0x33, 0x06, 0x07, 0x06, 0x23, 0x22, 0x26, 0x27, 0x26, 0x37, 0x36, 0x37, 0x36, 0x24,
+	0x33, 0x32, 0x16, 0x17, 0x16, 0x07, 0x07, 0x21, 0x22, 0x07, 0x07, 0x06, 0x17, 0x16, 0x16, 0x33,
+	0x32, 0x01, 0x21, 0x32, 0x37, 0x37, 0x36, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x07,
+	0x07, 0x06, 0x04, 0x8e, 0x5e, 0x64, 0x5c, 0xa7, 0xaf, 0x8b, 0xea, 0x4a, 0x7e, 0x23, 0x22, 0xb2,
+	0x69, 0x01, 0x0c, 0x8b, 0x8b, 0xea, 0x4a, 0x7d, 0x22, 0x03, 0xfc, 0x09, 0x0f, 0x03, 0x2d, 0x07,
+	0x14, 0x2a, 0xcb, 0x6a, 0xeb, 0xfd, 0xed, 0x03, 0x00, 0x11, 0x03, 0x2e, 0x06, 0x15, 0x2b, 0xca,
+	0x69, 0x69, 0xe7, 0x3f, 0x1e, 0x06, 0x2e, 0x03, 0x9b, 0x4b, 0x25, 0x44, 0x56, 0x4d, 0x83, 0xac,
+	0xac, 0x84, 0x4d, 0x55, 0x55, 0x4d, 0x84, 0xac, 0x0d, 0x0d, 0xe4, 0x20, 0x1a, 0x35, 0x49, 0x01,
+	0xc3, 0x0d, 0xe5, 0x1f, 0x1a, 0x35, 0x4a, 0x4a, 0x35, 0x1a, 0x1f, 0xe5, 0x0d, 0x00, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0xb3, 0xff, 0xdb, 0x07, 0x0c, 0x05, 0xed, 0x00, 0x05, 0x00, 0x09, 0x00, 0x1d,
+	0x00, 0x25, 0x00, 0x30, 0x00, 0xae, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x03, 0x01, 0x14, 0x01, 0x06,
+	0x00, 0x02, 0x4c, 0x04, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01,
+	0x00, 0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x02, 0x61, 0x04, 0x08, 0x02, 0x02, 0x02, 0x3f, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23, 0x00, 0x01, 0x03, 0x01, 0x85, 0x07, 0x01,
+	0x00, 0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00,
+	0x06, 0x06, 0x02, 0x61, 0x04, 0x08, 0x02, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x27, 0x00,
+	0x01, 0x03, 0x01, 0x85, 0x07, 0x01, 0x00, 0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x08, 0x01, 0x02,
+	0x04, 0x02, 0x86, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x19, 0x06, 0x06, 0x00, 0x00, 0x2c, 0x2a,
+	0x23, 0x21, 0x1a, 0x18, 0x10, 0x0e, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05,
+	0x09, 0x09, 0x16, 0x2b, 0x01, 0x13, 0x07, 0x37, 0x25, 0x03, 0x01, 0x01, 0x33, 0x01, 0x01, 0x26,
+	0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26,
+	0x37, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x07, 0x06, 0x07, 0x06, 0x16, 0x33,
+	0x32, 0x36, 0x37, 0x36, 0x27, 0x01, 0x6c, 0x8f, 0xe9, 0x1a, 0x01, 0xd3, 0xb5, 0xfe, 0x69, 0x05,
+	0x77, 0xa0, 0xfa, 0x89, 0x03, 0x9a, 0x70, 0x19, 0x15, 0xc7, 0x8c, 0x87, 0x87, 0x13, 0x16, 0x9f,
+	0xa1, 0x1d, 0x18, 0xe2, 0xa2, 0x9a, 0xa0, 0x15, 0x1f, 0x01, 0xba, 0x4a, 0x0c, 0x16, 0x78, 0x6d,
+	0x10, 0x0d, 0x02, 0x5c, 0x0f, 0x0d, 0x4d, 0x46, 0x3a, 0x5d, 0x09, 0x0e, 0x80, 0x02, 0x67, 0x02,
+	0xc9, 0x37, 0x85, 0x6f, 0xfc, 0x7a, 0xfd, 0x74, 0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8, 0x51, 0x7e,
+	0x69, 0x80, 0x6e, 0x5f, 0x6e, 0x68, 0x66, 0x90, 0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3, 0x3c, 0x3e,
+	0x6e, 0x54, 0x3e, 0xeb, 0x46, 0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44, 0x4f, 0x00, 0x05, 0x00, 0xac,
+	0xff, 0xdb, 0x06, 0xee, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x35, 0x00, 0x3d, 0x00, 0x48,
+	0x00, 0xdf, 0x40, 0x12, 0x10, 0x01, 0x02, 0x03, 0x17, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x0a,
+	0x2c, 0x01, 0x0b, 0x05, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x32, 0x00, 0x08, 0x00,
+	0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x36, 0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x00, 0x08, 0x00,
+	0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e, 0x1b, 0x40, 0x34,
+	0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x06, 0x01, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00,
+	0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00,
+	0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09,
+	0x09, 0x42, 0x09, 0x4e, 0x59, 0x59, 0x40, 0x18, 0x1e, 0x1e, 0x44, 0x42, 0x3b, 0x39, 0x32, 0x30,
+	0x28, 0x26, 0x1e, 0x21, 0x1e, 0x21, 0x12, 0x27, 0x23, 0x22, 0x21, 0x22, 0x22, 0x0d, 0x09, 0x1d,
+	0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x23, 0x37, 0x33, 0x32, 0x37, 0x36, 0x23,
+	0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x03,
+	0x01, 0x33, 0x01, 0x01, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x07,
+	0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x27, 0xac, 0x1e, 0x7d, 0x64, 0xa2, 0x19,
+	0x22, 0xff, 0x3c, 0x19, 0x2e, 0xf2, 0x1d, 0x16, 0x8a, 0x6b, 0x7b, 0x1b, 0x9b, 0x79, 0x01, 0x40,
+	0x2b, 0x20, 0xda, 0xde, 0x26, 0x18, 0xdc, 0xad, 0x7f, 0x25, 0x05, 0x1d, 0xa0, 0xfa, 0xe3, 0x03,
+	0x29, 0x70, 0x19, 0x15, 0xc7, 0x8c, 0x87, 0x87, 0x13, 0x16, 0x9f, 0xa1, 0x1d, 0x18, 0xe2, 0xa2,
+	0x9a, 0xa0, 0x15, 0x1f, 0x01, 0xba, 0x4a, 0x0c, 0x16, 0x78, 0x6d, 0x10, 0x0d, 0x02, 0x5c, 0x0f,
+	0x0d, 0x4d, 0x46, 0x3a, 0x5d, 0x09, 0x0e, 0x80, 0x02, 0x66, 0x96, 0x34, 0x80, 0xa8, 0x7f, 0x92,
+	0x6d, 0x32, 0x86, 0x2b, 0xd7, 0xa0, 0x3e, 0x35, 0xbd, 0x77, 0x86, 0xfd, 0x92, 0x06, 0x12, 0xf9,
+	0xee, 0x01, 0xf8, 0x51, 0x7e, 0x69, 0x80, 0x6e, 0x5f, 0x6e, 0x68, 0x66, 0x90, 0x79, 0x92, 0x83,
+	0x6c, 0x9b, 0xb3, 0x3c, 0x3e, 0x6e, 0x54, 0x3e, 0xeb, 0x46, 0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44,
+	0x4f, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0xbe, 0xff, 0xdb, 0x06, 0xda, 0x05, 0xed, 0x00, 0x16,
+	0x00, 0x1a, 0x00, 0x2e, 0x00, 0x36, 0x00, 0x41, 0x01, 0x9a, 0x40, 0x0e, 0x09, 0x01, 0x08, 0x01,
+	0x01, 0x01, 0x00, 0x0a, 0x25, 0x01, 0x0b, 0x05, 0x03, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40,
+	0x32, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05,
+	0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x04,
+	0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07,
+	0x3f, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x36, 0x00, 0x08, 0x00, 0x0a, 0x00,
+	0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x06, 0x06, 0x38, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x36, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x08,
+	0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x34, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x04, 0x00, 0x01,
+	0x08, 0x04, 0x01, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05,
+	0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x0b,
+	0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x38, 0x00, 0x06, 0x02, 0x06, 0x85, 0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x00, 0x04,
+	0x00, 0x01, 0x08, 0x04, 0x01, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00,
+	0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d,
+	0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e, 0x1b, 0x40, 0x36, 0x00, 0x06,
+	0x02, 0x06, 0x85, 0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x04, 0x00, 0x01, 0x08, 0x04, 0x01, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a,
+	0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09,
+	0x09, 0x42, 0x09, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x18, 0x17, 0x17, 0x3d, 0x3b, 0x34,
+	0x32, 0x2b, 0x29, 0x21, 0x1f, 0x17, 0x1a, 0x17, 0x1a, 0x12, 0x24, 0x21, 0x11, 0x12, 0x22, 0x22,
+	0x0d, 0x09, 0x1d, 0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x21, 0x22, 0x07, 0x13, 0x21,
+	0x07, 0x21, 0x07, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x03, 0x01, 0x33, 0x01, 0x01,
+	0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22,
+	0x26, 0x37, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x07, 0x06, 0x07, 0x06, 0x16,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x27, 0xbe, 0x1d, 0x7a, 0x49, 0x95, 0x1e, 0x24, 0xfe, 0xf0, 0x23,
+	0x20, 0x5b, 0x02, 0x2b, 0x21, 0xfe, 0x5c, 0x20, 0x1e, 0xb7, 0xc1, 0x1b, 0x19, 0xe4, 0xa0, 0x5f,
+	0x32, 0x04, 0xcd, 0xa0, 0xfb, 0x33, 0x03, 0x0b, 0x70, 0x19, 0x15, 0xc7, 0x8c, 0x87, 0x87, 0x13,
+	0x16, 0x9f, 0xa1, 0x1d, 0x18, 0xe2, 0xa2, 0x9a, 0xa0, 0x15, 0x1f, 0x01, 0xba, 0x4a, 0x0c, 0x16,
+	0x78, 0x6d, 0x10, 0x0d, 0x02, 0x5c, 0x0f, 0x0d, 0x4d, 0x46, 0x3a, 0x5d, 0x09, 0x0e, 0x80, 0x02,
+	0x59, 0x92, 0x32, 0x96, 0xb7, 0x06, 0x01, 0xc8, 0xa8, 0x9f, 0xa5, 0x86, 0x80, 0x9c, 0xfd, 0xa1,
+	0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8, 0x51, 0x7e, 0x69, 0x80, 0x6e, 0x5f, 0x6e, 0x68, 0x66, 0x90,
+	0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3, 0x3c, 0x3e, 0x6e, 0x54, 0x3e, 0xeb, 0x46, 0x4e, 0x40, 0x58,
+	0x40, 0x2d, 0x44, 0x4f, 0x00, 0x05, 0x00, 0x5c, 0xff, 0xdb, 0x06, 0xda, 0x05, 0xed, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x21, 0x00, 0x29, 0x00, 0x34, 0x01, 0x2e, 0xb5, 0x18, 0x01, 0x08, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x29, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08,
+	0x80, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01,
+	0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x04, 0x61, 0x06, 0x0a, 0x02, 0x04, 0x04, 0x3f, 0x04,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x2d, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02,
+	0x08, 0x80, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x04, 0x61, 0x06, 0x0a,
+	0x02, 0x04, 0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x03,
+	0x01, 0x03, 0x85, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08, 0x80, 0x00, 0x05, 0x00, 0x07,
+	0x02, 0x05, 0x07, 0x6a, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08,
+	0x08, 0x04, 0x61, 0x06, 0x0a, 0x02, 0x04, 0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x31, 0x00, 0x03, 0x01, 0x03, 0x85, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08,
+	0x80, 0x0a, 0x01, 0x04, 0x06, 0x04, 0x86, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06,
+	0x06, 0x42, 0x06, 0x4e, 0x1b, 0x40, 0x2f, 0x00, 0x03, 0x01, 0x03, 0x85, 0x09, 0x01, 0x02, 0x07,
+	0x08, 0x07, 0x02, 0x08, 0x80, 0x0a, 0x01, 0x04, 0x06, 0x04, 0x86, 0x00, 0x01, 0x00, 0x00, 0x05,
+	0x01, 0x00, 0x67, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x08, 0x08, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x1b, 0x0a, 0x0a, 0x00, 0x00,
+	0x30, 0x2e, 0x27, 0x25, 0x1e, 0x1c, 0x14, 0x12, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09,
+	0x00, 0x09, 0x11, 0x13, 0x0b, 0x09, 0x18, 0x2b, 0x13, 0x36, 0x01, 0x37, 0x21, 0x37, 0x21, 0x07,
+	0x00, 0x03, 0x01, 0x01, 0x33, 0x01, 0x01, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06,
+	0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x36, 0x37, 0x36, 0x23, 0x22,
+	0x07, 0x06, 0x07, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x27, 0xc9, 0x3a, 0x01,
+	0x21, 0xe4, 0xfe, 0x2f, 0x22, 0x02, 0x7b, 0x22, 0xfe, 0x5c, 0x5c, 0xfe, 0xaa, 0x05, 0x77, 0xa0,
+	0xfa, 0x89, 0x03, 0xbf, 0x70, 0x19, 0x15, 0xc7, 0x8c, 0x87, 0x87, 0x13, 0x16, 0x9f, 0xa1, 0x1d,
+	0x18, 0xe2, 0xa2, 0x9a, 0xa0, 0x15, 0x1f, 0x01, 0xba, 0x4a, 0x0c, 0x16, 0x78, 0x6d, 0x10, 0x0d,
+	0x02, 0x5c, 0x0f, 0x0d, 0x4d, 0x46, 0x3a, 0x5d, 0x09, 0x0e, 0x80, 0x02, 0x50, 0xb5, 0x01, 0x2c,
+	0xee, 0xa9, 0xa9, 0xfe, 0x7a, 0xfe, 0xb7, 0xfd, 0x8b, 0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8, 0x51,
+	0x7e, 0x69, 0x80, 0x6e, 0x5f, 0x6e, 0x68, 0x66, 0x90, 0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3, 0x3c,
+	0x3e, 0x6e, 0x54, 0x3e, 0xeb, 0x46, 0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44, 0x4f, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xda, 0x00, 0xa1, 0x07, 0xbf, 0x04, 0x00, 0x00, 0x06, 0x00, 0x26, 0x40, 0x23,
+	0x03, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x04, 0x01, 0x01, 0x4a, 0x02, 0x01, 0x00, 0x49, 0x00, 0x01,
+	0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x14, 0x10,
+	0x02, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x13, 0x01, 0x01, 0x03, 0x21, 0x07, 0x9d, 0xfb, 0x6b, 0x68,
+	0xfd, 0x6a, 0x03, 0x42, 0xf2, 0x04, 0x95, 0x01, 0xfa, 0xfe, 0xa7, 0x01, 0xb0, 0x01, 0xaf, 0xfe,
+	0xa7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xfc, 0xfe, 0x75, 0x04, 0x5b, 0x06, 0x44, 0x00, 0x06,
+	0x00, 0x19, 0x40, 0x16, 0x05, 0x04, 0x03, 0x02, 0x01, 0x05, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00,
+	0x76, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x02, 0x06, 0x16, 0x2b, 0x01, 0x01, 0x05, 0x01, 0x01,
+	0x25, 0x01, 0x01, 0x5b, 0x01, 0x1c, 0xfe, 0x85, 0x02, 0x45, 0x01, 0x1a, 0xfe, 0xc9, 0xfe, 0xe4,
+	0xfe, 0x75, 0x05, 0x90, 0xad, 0x02, 0xec, 0xfd, 0x14, 0xad, 0xfa, 0x70, 0x00, 0x01, 0x01, 0x2d,
+	0x00, 0xa1, 0x08, 0x12, 0x04, 0x00, 0x00, 0x06, 0x00, 0x26, 0x40, 0x23, 0x03, 0x01, 0x01, 0x00,
+	0x01, 0x4c, 0x02, 0x01, 0x00, 0x4a, 0x04, 0x01, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x14, 0x10, 0x02, 0x06, 0x18, 0x2b,
+	0x01, 0x21, 0x03, 0x01, 0x01, 0x13, 0x21, 0x01, 0x4f, 0x04, 0x95, 0x68, 0x02, 0x96, 0xfc, 0xbe,
+	0xf2, 0xfb, 0x6b, 0x02, 0xa7, 0x01, 0x59, 0xfe, 0x50, 0xfe, 0x51, 0x01, 0x59, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x97, 0xfe, 0x75, 0x03, 0xf6, 0x06, 0x44, 0x00, 0x06, 0x00, 0x19, 0x40, 0x16,
+	0x05, 0x04, 0x03, 0x02, 0x01, 0x05, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00,
+	0x06, 0x00, 0x06, 0x02, 0x06, 0x16, 0x2b, 0x01, 0x01, 0x25, 0x01, 0x01, 0x05, 0x01, 0x03, 0x97,
+	0xfe, 0xe3, 0x01, 0x7c, 0xfd, 0xbb, 0xfe, 0xe6, 0x01, 0x36, 0x01, 0x1d, 0x06, 0x44, 0xfa, 0x70,
+	0xad, 0xfd, 0x14, 0x02, 0xec, 0xad, 0x05, 0x90, 0x00, 0x01, 0x00, 0xda, 0x00, 0xa1, 0x08, 0x12,
+	0x04, 0x00, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x04, 0x01,
+	0x02, 0x00, 0x4a, 0x09, 0x06, 0x02, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x14, 0x12, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x01,
+	0x03, 0x21, 0x03, 0x01, 0x01, 0x13, 0x21, 0x13, 0xda, 0x03, 0x42, 0xf2, 0x02, 0xba, 0x68, 0x02,
+	0x96, 0xfc, 0xbe, 0xf2, 0xfd, 0x46, 0x68, 0x02, 0x51, 0x01, 0xaf, 0xfe, 0xa7, 0x01, 0x59, 0xfe,
+	0x51, 0xfe, 0x50, 0x01, 0x59, 0xfe, 0xa7, 0x00, 0x00, 0x01, 0x00, 0x97, 0xfe, 0x75, 0x04, 0x5b,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x06, 0xb3, 0x05, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x01, 0x25, 0x03,
+	0x25, 0x01, 0x01, 0x05, 0x13, 0x05, 0x03, 0x41, 0x01, 0x1a, 0xfe, 0xc9, 0xaa, 0x01, 0x7c, 0xfd,
+	0xbc, 0xfe, 0xe5, 0x01, 0x36, 0xaa, 0xfe, 0x85, 0x06, 0x44, 0xfd, 0x14, 0xad, 0xfc, 0xaf, 0xad,
+	0xfd, 0x14, 0x02, 0xec, 0xad, 0x03, 0x51, 0xad, 0x00, 0x02, 0xff, 0xfe, 0xfe, 0x5d, 0x04, 0x5b,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x24, 0x40, 0x21, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04,
+	0x03, 0x02, 0x01, 0x09, 0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x1a, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x01, 0x25, 0x03,
+	0x25, 0x01, 0x01, 0x05, 0x13, 0x05, 0x03, 0x21, 0x07, 0x21, 0x03, 0x41, 0x01, 0x1a, 0xfe, 0xc9,
+	0x6e, 0x01, 0x7c, 0xfd, 0xbb, 0xfe, 0xe6, 0x01, 0x36, 0x6e, 0xfe, 0x85, 0xdd, 0x03, 0x5f, 0x22,
+	0xfc, 0xa2, 0x06, 0x44, 0xfd, 0x14, 0xad, 0xfd, 0xda, 0xad, 0xfd, 0x14, 0x02, 0xec, 0xad, 0x02,
+	0x26, 0xad, 0xfb, 0xb2, 0xad, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x41, 0xff, 0xe7, 0x04, 0xce,
+	0x06, 0x44, 0x00, 0x16, 0x00, 0x20, 0x00, 0x32, 0x40, 0x2f, 0x11, 0x01, 0x04, 0x02, 0x01, 0x4c,
+	0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02, 0x04, 0x69,
+	0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x05, 0x01, 0x51,
+	0x23, 0x22, 0x24, 0x24, 0x25, 0x21, 0x06, 0x06, 0x1c, 0x2b, 0x01, 0x12, 0x21, 0x32, 0x12, 0x03,
+	0x02, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x01, 0x26, 0x23, 0x22, 0x02, 0x07, 0x06, 0x33, 0x32, 0x00, 0x01, 0x40, 0xe8, 0x01, 0x10,
+	0xdb, 0xbb, 0x3d, 0x56, 0xfe, 0xea, 0xd5, 0xfa, 0x91, 0x84, 0x1f, 0x36, 0x01, 0xba, 0xcd, 0x62,
+	0x5d, 0x07, 0x23, 0xad, 0xab, 0xa2, 0x01, 0x9c, 0x30, 0x4d, 0x6e, 0xfc, 0x25, 0x1b, 0x5f, 0x72,
+	0x01, 0x04, 0x04, 0xfb, 0x01, 0x49, 0xfe, 0x97, 0xfe, 0xcf, 0xfe, 0x52, 0xfe, 0xd3, 0xe8, 0xba,
+	0x9f, 0x01, 0x0d, 0x01, 0xca, 0x4d, 0x21, 0xaf, 0xdd, 0xfd, 0x8b, 0x48, 0xfe, 0xc4, 0xb9, 0x85,
+	0x01, 0x40, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1f, 0x00, 0x00, 0x05, 0xf0, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x08, 0x00, 0x25, 0x40, 0x22, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01, 0x01, 0x02,
+	0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x08, 0x07,
+	0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x06, 0x17, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x01, 0x07, 0x01,
+	0x01, 0x21, 0x1f, 0x31, 0x03, 0x42, 0x01, 0x06, 0x01, 0x58, 0x31, 0xfd, 0xe4, 0xfd, 0x9a, 0x03,
+	0x64, 0xf7, 0x04, 0xd1, 0xfb, 0x2f, 0xf7, 0x04, 0x84, 0xfc, 0x73, 0x00, 0x00, 0x01, 0x00, 0xd1,
+	0xfe, 0x75, 0x07, 0x31, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x06, 0x05, 0x02, 0x03,
+	0x00, 0x03, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x02,
+	0x02, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x06, 0x1b, 0x2b, 0x13, 0x01, 0x23, 0x37, 0x21, 0x07, 0x23, 0x01, 0x21, 0x01, 0x21, 0x01,
+	0xd1, 0x01, 0x4e, 0x94, 0x28, 0x05, 0x7e, 0x28, 0x94, 0xfe, 0xb2, 0xfe, 0xcc, 0x01, 0x4e, 0xfe,
+	0x12, 0xfe, 0xb2, 0xfe, 0x75, 0x06, 0x88, 0xcb, 0xcb, 0xf9, 0x78, 0x06, 0x88, 0xf9, 0x78, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0xd8, 0x06, 0x72, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x34, 0x40, 0x31,
+	0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x03, 0x01, 0x01, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04,
+	0x01, 0x03, 0x02, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x06,
+	0x19, 0x2b, 0x11, 0x13, 0x01, 0x01, 0x37, 0x21, 0x07, 0x21, 0x01, 0x01, 0x21, 0x03, 0x34, 0x02,
+	0x8e, 0xfe, 0x92, 0x28, 0x04, 0xf6, 0x28, 0xfc, 0x7d, 0x01, 0x4e, 0xfd, 0x3a, 0x04, 0x19, 0x34,
+	0xfe, 0xd8, 0x01, 0x00, 0x02, 0x92, 0x02, 0x93, 0xcb, 0xcb, 0xfd, 0xa6, 0xfd, 0x35, 0xff, 0x00,
+	0x00, 0x01, 0x00, 0xcd, 0x01, 0xfa, 0x04, 0xca, 0x02, 0xa7, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07,
+	0xcd, 0x22, 0x03, 0xdb, 0x22, 0x01, 0xfa, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0xfe, 0xdd,
+	0xfe, 0xd8, 0x03, 0x79, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17,
+	0x2b, 0x01, 0x01, 0x33, 0x01, 0xfe, 0xdd, 0x03, 0xe7, 0xb5, 0xfc, 0x19, 0xfe, 0xd8, 0x07, 0x53,
+	0xf8, 0xad, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc1, 0x01, 0xd5, 0x02, 0x87, 0x03, 0x79, 0x00, 0x0b,
+	0x00, 0x18, 0x40, 0x15, 0x00, 0x00, 0x01, 0x01, 0x00, 0x59, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x00, 0x01, 0x51, 0x24, 0x22, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x36, 0x36, 0x33, 0x32, 0x16,
+	0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0xd3, 0x11, 0x94, 0x56, 0x57, 0x62, 0x12, 0x11, 0x93, 0x57,
+	0x58, 0x61, 0x02, 0xa9, 0x55, 0x7b, 0x7b, 0x57, 0x57, 0x7b, 0x7b, 0x00, 0x00, 0x01, 0x00, 0x6a,
+	0xff, 0x3b, 0x05, 0xd3, 0x07, 0x2e, 0x00, 0x08, 0x00, 0x1a, 0x40, 0x17, 0x08, 0x03, 0x02, 0x01,
+	0x04, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x14,
+	0x02, 0x06, 0x18, 0x2b, 0x13, 0x27, 0x25, 0x13, 0x01, 0x33, 0x01, 0x23, 0x01, 0xa9, 0x3f, 0x01,
+	0x92, 0xef, 0x02, 0x49, 0x9f, 0xfd, 0x17, 0xa5, 0xfe, 0xfe, 0x01, 0x76, 0xa0, 0xce, 0xfd, 0x81,
+	0x06, 0xc9, 0xf8, 0x0d, 0x02, 0x8b, 0x00, 0x00, 0x00, 0x03, 0x00, 0x9a, 0x00, 0x94, 0x06, 0x06,
+	0x04, 0x0c, 0x00, 0x0e, 0x00, 0x36, 0x00, 0x45, 0x00, 0x3a, 0x40, 0x37, 0x23, 0x01, 0x06, 0x00,
+	0x01, 0x4c, 0x00, 0x07, 0x00, 0x02, 0x07, 0x59, 0x05, 0x01, 0x02, 0x00, 0x00, 0x06, 0x02, 0x00,
+	0x69, 0x00, 0x06, 0x01, 0x03, 0x06, 0x59, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01,
+	0x03, 0x61, 0x04, 0x01, 0x03, 0x01, 0x03, 0x51, 0x25, 0x26, 0x28, 0x28, 0x28, 0x28, 0x25, 0x22,
+	0x08, 0x06, 0x1e, 0x2b, 0x01, 0x26, 0x26, 0x23, 0x22, 0x07, 0x06, 0x1e, 0x02, 0x33, 0x32, 0x3e,
+	0x02, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x27,
+	0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x16, 0x16,
+	0x33, 0x32, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x22, 0x0e, 0x02, 0x02, 0xd4, 0x2d, 0x69, 0x3a, 0x85,
+	0x30, 0x07, 0x03, 0x18, 0x2f, 0x25, 0x27, 0x4f, 0x49, 0x42, 0xc3, 0x3e, 0x68, 0x5f, 0x5f, 0x34,
+	0x4c, 0x66, 0x37, 0x0a, 0x11, 0x12, 0x4c, 0x6c, 0x8c, 0x53, 0x2f, 0x4a, 0x44, 0x42, 0x27, 0x3e,
+	0x69, 0x60, 0x5e, 0x34, 0x4d, 0x66, 0x37, 0x09, 0x10, 0x13, 0x4b, 0x6d, 0x8c, 0x53, 0x2f, 0x4a,
+	0x44, 0x42, 0x79, 0x2d, 0x68, 0x3a, 0x85, 0x31, 0x06, 0x02, 0x18, 0x2f, 0x25, 0x27, 0x4f, 0x49,
+	0x43, 0x02, 0x40, 0x69, 0x6d, 0xf3, 0x21, 0x48, 0x3d, 0x27, 0x31, 0x48, 0x51, 0xcf, 0x47, 0x6b,
+	0x47, 0x24, 0x43, 0x72, 0x95, 0x52, 0x5c, 0xac, 0x84, 0x50, 0x29, 0x4a, 0x69, 0x41, 0x47, 0x6b,
+	0x47, 0x24, 0x43, 0x72, 0x95, 0x52, 0x5c, 0xac, 0x84, 0x50, 0x29, 0x4a, 0x6a, 0xcf, 0x69, 0x6d,
+	0xf3, 0x21, 0x48, 0x3d, 0x27, 0x31, 0x48, 0x51, 0x00, 0x01, 0x01, 0x6a, 0x00, 0x00, 0x06, 0x95,
+	0x05, 0x04, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02,
+	0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x01, 0x02, 0x4f, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x21, 0x01, 0x33, 0x03, 0x21, 0x07,
+	0x01, 0x6a, 0x01, 0x00, 0xc8, 0xd9, 0x04, 0x3c, 0x27, 0x05, 0x04, 0xfb, 0xc4, 0xc8, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x93, 0x00, 0x00, 0x06, 0x15, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x20, 0x40, 0x1d,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x86, 0x00, 0x03, 0x01, 0x01, 0x03, 0x59, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x03, 0x01, 0x51, 0x23, 0x13, 0x23, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x21, 0x23,
+	0x13, 0x36, 0x26, 0x23, 0x22, 0x04, 0x07, 0x03, 0x23, 0x13, 0x36, 0x00, 0x33, 0x32, 0x00, 0x07,
+	0x05, 0x33, 0xc3, 0xb1, 0x21, 0xba, 0xa5, 0xa5, 0xfe, 0xea, 0x21, 0xb1, 0xc3, 0xb1, 0x31, 0x01,
+	0xa0, 0xf5, 0xf6, 0x01, 0x15, 0x31, 0x03, 0x77, 0xa5, 0xe9, 0xe8, 0xa6, 0xfc, 0x89, 0x03, 0x78,
+	0xf6, 0x01, 0x5a, 0xfe, 0xa6, 0xf6, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd7, 0x00, 0x00, 0x06, 0x5a,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x20, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x01, 0x03, 0x51, 0x23, 0x13,
+	0x23, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x23, 0x03, 0x06, 0x04, 0x23, 0x22, 0x26, 0x37, 0x13,
+	0x23, 0x03, 0x06, 0x00, 0x33, 0x32, 0x00, 0x37, 0x06, 0x5a, 0xc3, 0xb0, 0x21, 0xfe, 0xe8, 0xa5,
+	0xa5, 0xb8, 0x21, 0xb0, 0xc3, 0xb1, 0x32, 0x01, 0x17, 0xf5, 0xf6, 0x01, 0x9e, 0x32, 0x05, 0xc8,
+	0xfc, 0x89, 0xa5, 0xe9, 0xe8, 0xa6, 0x03, 0x77, 0xfc, 0x88, 0xf6, 0xfe, 0xa6, 0x01, 0x5a, 0xf6,
+	0x00, 0x01, 0xff, 0xe5, 0xfe, 0xd8, 0x03, 0x92, 0x07, 0x87, 0x00, 0x5d, 0x00, 0x95, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x25, 0x00, 0x01, 0x02, 0x04, 0x02, 0x01, 0x72, 0x00, 0x04, 0x05, 0x05,
+	0x04, 0x70, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59,
+	0x00, 0x05, 0x05, 0x03, 0x62, 0x00, 0x03, 0x05, 0x03, 0x52, 0x1b, 0x4b, 0xb0, 0x16, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x01, 0x02, 0x04, 0x02, 0x01, 0x72, 0x00, 0x04, 0x05, 0x02, 0x04, 0x05, 0x7e,
+	0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59, 0x00, 0x05,
+	0x05, 0x03, 0x62, 0x00, 0x03, 0x05, 0x03, 0x52, 0x1b, 0x40, 0x27, 0x00, 0x01, 0x02, 0x04, 0x02,
+	0x01, 0x04, 0x80, 0x00, 0x04, 0x05, 0x02, 0x04, 0x05, 0x7e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59, 0x00, 0x05, 0x05, 0x03, 0x62, 0x00, 0x03, 0x05,
+	0x03, 0x52, 0x59, 0x59, 0x40, 0x0c, 0x52, 0x51, 0x48, 0x46, 0x3e, 0x3c, 0x19, 0x28, 0x2d, 0x06,
+	0x06, 0x19, 0x2b, 0x01, 0x3e, 0x05, 0x37, 0x3e, 0x05, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03,
+	0x23, 0x22, 0x2e, 0x02, 0x37, 0x36, 0x36, 0x37, 0x26, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x0e, 0x07,
+	0x07, 0x07, 0x0e, 0x03, 0x07, 0x0e, 0x05, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03, 0x33, 0x32,
+	0x1e, 0x02, 0x07, 0x06, 0x06, 0x07, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x3e, 0x05, 0x37, 0x01,
+	0x76, 0x05, 0x0c, 0x0f, 0x10, 0x10, 0x11, 0x07, 0x0a, 0x1e, 0x2a, 0x36, 0x44, 0x53, 0x31, 0x1b,
+	0x2e, 0x1f, 0x0c, 0x05, 0x04, 0x10, 0x17, 0x1f, 0x13, 0x0a, 0x13, 0x0e, 0x06, 0x05, 0x01, 0x0d,
+	0x05, 0x08, 0x09, 0x18, 0x2b, 0x25, 0x1d, 0x08, 0x02, 0x09, 0x0d, 0x0f, 0x0f, 0x0e, 0x0d, 0x0a,
+	0x02, 0x16, 0x07, 0x18, 0x1a, 0x1a, 0x0c, 0x09, 0x1e, 0x2a, 0x36, 0x44, 0x53, 0x31, 0x1b, 0x2e,
+	0x1e, 0x0c, 0x05, 0x04, 0x10, 0x16, 0x1f, 0x13, 0x0a, 0x13, 0x0e, 0x06, 0x05, 0x01, 0x0d, 0x05,
+	0x08, 0x09, 0x18, 0x2b, 0x25, 0x1c, 0x09, 0x03, 0x0f, 0x13, 0x17, 0x14, 0x10, 0x03, 0x03, 0x91,
+	0x1d, 0x51, 0x5f, 0x66, 0x64, 0x5d, 0x26, 0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20, 0x2f,
+	0x1d, 0x14, 0x24, 0x1d, 0x11, 0x05, 0x0f, 0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e, 0x6b,
+	0x2b, 0x0a, 0x3d, 0x56, 0x6a, 0x6e, 0x6c, 0x5b, 0x45, 0x0f, 0x8b, 0x2f, 0x89, 0x96, 0x93, 0x39,
+	0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20, 0x2f, 0x1d, 0x13, 0x25, 0x1d, 0x11, 0x05, 0x0f,
+	0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e, 0x6b, 0x2b, 0x0e, 0x5f, 0x83, 0x95, 0x89, 0x6b,
+	0x17, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6c, 0x00, 0x92, 0x04, 0xe4, 0x04, 0x0e, 0x00, 0x15,
+	0x00, 0x2b, 0x00, 0x48, 0x40, 0x45, 0x0c, 0x01, 0x03, 0x00, 0x01, 0x01, 0x02, 0x01, 0x22, 0x01,
+	0x07, 0x04, 0x17, 0x01, 0x06, 0x05, 0x04, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00, 0x03, 0x69,
+	0x00, 0x01, 0x00, 0x02, 0x04, 0x01, 0x02, 0x69, 0x00, 0x04, 0x00, 0x07, 0x05, 0x04, 0x07, 0x69,
+	0x00, 0x05, 0x06, 0x06, 0x05, 0x59, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x05, 0x06, 0x51,
+	0x23, 0x24, 0x23, 0x24, 0x23, 0x24, 0x23, 0x22, 0x08, 0x06, 0x1e, 0x2b, 0x01, 0x23, 0x12, 0x33,
+	0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x37, 0x33, 0x02, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23,
+	0x22, 0x07, 0x03, 0x23, 0x12, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x37, 0x33, 0x02,
+	0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x01, 0x40, 0x71, 0x45, 0xee, 0x5d, 0x91, 0x42,
+	0x84, 0x38, 0x66, 0x1d, 0x02, 0x71, 0x46, 0xee, 0x5d, 0x90, 0x42, 0x84, 0x38, 0x67, 0x1d, 0x64,
+	0x71, 0x45, 0xee, 0x5d, 0x91, 0x42, 0x85, 0x38, 0x66, 0x1c, 0x02, 0x71, 0x45, 0xee, 0x5d, 0x91,
+	0x42, 0x84, 0x38, 0x67, 0x1c, 0x02, 0xb3, 0x01, 0x5b, 0x56, 0x28, 0x4e, 0x90, 0x09, 0xfe, 0xa5,
+	0x56, 0x28, 0x4e, 0x90, 0xfe, 0x09, 0x01, 0x5c, 0x57, 0x27, 0x4e, 0x8f, 0x0a, 0xfe, 0xa4, 0x57,
+	0x27, 0x4e, 0x8f, 0x00, 0x00, 0x01, 0x00, 0x97, 0x00, 0x31, 0x04, 0xb9, 0x04, 0x6f, 0x00, 0x13,
+	0x00, 0x6c, 0x4b, 0xb0, 0x0b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x04, 0x03, 0x03, 0x04, 0x70, 0x00,
+	0x09, 0x00, 0x00, 0x09, 0x71, 0x05, 0x01, 0x03, 0x06, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x07,
+	0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x07, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x01, 0x00, 0x01,
+	0x00, 0x4f, 0x1b, 0x40, 0x27, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00, 0x09, 0x00, 0x09, 0x86, 0x05,
+	0x01, 0x03, 0x06, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x07, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57,
+	0x07, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x0e, 0x13,
+	0x12, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x0a, 0x06, 0x1f, 0x2b, 0x01, 0x21,
+	0x37, 0x21, 0x37, 0x21, 0x37, 0x21, 0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x07, 0x21, 0x07, 0x21,
+	0x07, 0x23, 0x01, 0xb9, 0xfe, 0xde, 0x27, 0x01, 0x61, 0x77, 0xfe, 0x54, 0x27, 0x01, 0xed, 0x7d,
+	0x9c, 0x7e, 0x01, 0x20, 0x27, 0xfe, 0xa0, 0x76, 0x01, 0xaa, 0x27, 0xfe, 0x14, 0x7f, 0x9c, 0x01,
+	0x1f, 0xc2, 0xde, 0xc3, 0xed, 0xed, 0xc3, 0xde, 0xc2, 0xee, 0x00, 0x00, 0x00, 0x03, 0x00, 0x7e,
+	0x00, 0x70, 0x05, 0x19, 0x04, 0x33, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x40, 0x40, 0x3d,
+	0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01, 0x01,
+	0x00, 0x01, 0x4f, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04,
+	0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x06, 0x17, 0x2b, 0x37, 0x37,
+	0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x7e, 0x25, 0x03, 0xdb, 0x25, 0xfc,
+	0x73, 0x25, 0x03, 0xdb, 0x25, 0xfc, 0x72, 0x25, 0x03, 0xdb, 0x25, 0x70, 0xb9, 0xb9, 0x01, 0x85,
+	0xb9, 0xb9, 0x01, 0x85, 0xb9, 0xb9, 0x00, 0x00, 0x00, 0x02, 0x00, 0x46, 0x00, 0x00, 0x05, 0x1d,
+	0x05, 0x00, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x25, 0x40, 0x22, 0x0a, 0x08, 0x06, 0x05, 0x04, 0x00,
+	0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00,
+	0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x37, 0x21,
+	0x07, 0x13, 0x01, 0x01, 0x07, 0x01, 0x15, 0x01, 0x46, 0x26, 0x03, 0xd8, 0x26, 0x3b, 0xfc, 0x89,
+	0x04, 0x3b, 0x2b, 0xfd, 0xa6, 0x01, 0xec, 0xc3, 0xc3, 0x01, 0x28, 0x01, 0xec, 0x01, 0xec, 0xda,
+	0xfe, 0xef, 0x02, 0xfe, 0xef, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x46, 0x00, 0x00, 0x04, 0xbc,
+	0x05, 0x00, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x26, 0x40, 0x23, 0x0a, 0x09, 0x08, 0x07, 0x05, 0x05,
+	0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01,
+	0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x37,
+	0x21, 0x07, 0x01, 0x01, 0x35, 0x01, 0x37, 0x01, 0x01, 0x46, 0x26, 0x03, 0xd8, 0x26, 0xfc, 0x8e,
+	0x02, 0x5a, 0xfe, 0x14, 0x2b, 0x03, 0x77, 0xfb, 0xc5, 0xc3, 0xc3, 0x02, 0x02, 0x01, 0x11, 0x02,
+	0x01, 0x11, 0xda, 0xfe, 0x14, 0xfe, 0x14, 0x00, 0x00, 0x02, 0x00, 0x8a, 0x00, 0x00, 0x04, 0xd8,
+	0x04, 0xa0, 0x00, 0x04, 0x00, 0x09, 0x00, 0x26, 0x40, 0x23, 0x07, 0x06, 0x04, 0x03, 0x04, 0x01,
+	0x4a, 0x02, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x01, 0x00, 0x4f, 0x05, 0x05, 0x05, 0x09, 0x05, 0x09, 0x10, 0x03, 0x06, 0x17, 0x2b, 0x21, 0x21,
+	0x13, 0x09, 0x02, 0x13, 0x03, 0x01, 0x03, 0x04, 0x4c, 0xfc, 0x3e, 0x8c, 0x02, 0x41, 0x01, 0x81,
+	0xfe, 0xdf, 0x59, 0xed, 0xfe, 0x9d, 0x59, 0x02, 0xbf, 0x01, 0xe1, 0xfe, 0x1f, 0xfd, 0xfa, 0x01,
+	0xb9, 0x01, 0x28, 0xfe, 0xd8, 0xfe, 0x47, 0x00, 0x00, 0x01, 0x00, 0x8c, 0x01, 0x14, 0x05, 0x05,
+	0x03, 0x78, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x02, 0x00, 0x86, 0x00, 0x01, 0x02,
+	0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x01, 0x02, 0x4f, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x01, 0x03, 0x23, 0x13, 0x21, 0x07,
+	0x01, 0x8b, 0x53, 0xac, 0x7a, 0x03, 0xff, 0x27, 0x02, 0xb5, 0xfe, 0x5f, 0x02, 0x64, 0xc3, 0x00,
+	0x00, 0x01, 0x01, 0xe5, 0xfe, 0x50, 0x04, 0x2c, 0x06, 0x50, 0x00, 0x19, 0x00, 0x5b, 0xb6, 0x10,
+	0x0d, 0x02, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x02,
+	0x03, 0x02, 0x01, 0x72, 0x04, 0x01, 0x03, 0x03, 0x84, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00,
+	0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x40, 0x1d, 0x00, 0x01, 0x02, 0x03,
+	0x02, 0x01, 0x03, 0x80, 0x04, 0x01, 0x03, 0x03, 0x84, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00,
+	0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x19,
+	0x00, 0x19, 0x25, 0x24, 0x24, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x10, 0x37, 0x12, 0x33, 0x32,
+	0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x35, 0x34, 0x37, 0x37, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17,
+	0x16, 0x15, 0x11, 0x01, 0xe5, 0x3b, 0x60, 0xde, 0x5e, 0x70, 0x4e, 0x3c, 0x7f, 0x07, 0x07, 0x15,
+	0x0b, 0x56, 0x0e, 0x1f, 0xfe, 0x50, 0x04, 0xb3, 0x01, 0xa5, 0xa2, 0x01, 0x06, 0x63, 0x53, 0x40,
+	0x51, 0x90, 0x0c, 0x15, 0x14, 0x06, 0x8d, 0x2f, 0x73, 0xf8, 0xaa, 0xfb, 0x4d, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa2, 0xfe, 0x50, 0x02, 0xe8, 0x07, 0x8f, 0x00, 0x19, 0x00, 0x59, 0xb6, 0x10,
+	0x0d, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0x1c, 0x04, 0x01, 0x03,
+	0x01, 0x03, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x70, 0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x02, 0x00, 0x52, 0x1b, 0x40, 0x1b, 0x04, 0x01, 0x03, 0x01,
+	0x03, 0x85, 0x00, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x02, 0x00, 0x52, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19,
+	0x25, 0x24, 0x24, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x10, 0x07, 0x02, 0x23, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x15, 0x14, 0x07, 0x07, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x26, 0x35,
+	0x11, 0x02, 0xe8, 0x3b, 0x5f, 0xde, 0x5e, 0x70, 0x4e, 0x3c, 0x7f, 0x07, 0x07, 0x15, 0x0b, 0x56,
+	0x0f, 0x1f, 0x07, 0x8f, 0xfa, 0x0e, 0xfe, 0x5b, 0xa2, 0xfe, 0xfa, 0x63, 0x54, 0x3f, 0x52, 0x91,
+	0x0b, 0x15, 0x15, 0x06, 0x8d, 0x30, 0x73, 0xf7, 0xaa, 0x05, 0xf2, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xa6, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x04, 0xcd, 0x02, 0xa6,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02,
+	0x06, 0x18, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x94, 0x07, 0x8f, 0xf6, 0xc1, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x05, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b, 0x01, 0x21, 0x15, 0x21,
+	0x11, 0x23, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94, 0xfb, 0xaa, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x03, 0x3a, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21,
+	0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06,
+	0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x02, 0xb1, 0x94, 0x02, 0xa6, 0x94, 0xfb, 0x16,
+	0x04, 0x56, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00,
+	0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x01, 0x02, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b,
+	0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0x50, 0x07, 0x8f, 0xfb,
+	0xab, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x02, 0x1d, 0x94, 0x02,
+	0xa6, 0x94, 0x04, 0x55, 0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x02,
+	0x03, 0x86, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x01,
+	0x02, 0x4f, 0x11, 0x11, 0x11, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21,
+	0x11, 0x23, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f, 0xfb, 0xab, 0x94, 0xfb,
+	0xaa, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x2a, 0x40, 0x27, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03, 0x02, 0x86, 0x00, 0x00,
+	0x03, 0x03, 0x00, 0x57, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x33, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0x94, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xf6, 0xc1, 0x04,
+	0x56, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x07,
+	0x00, 0x27, 0x40, 0x24, 0x00, 0x01, 0x00, 0x01, 0x86, 0x04, 0x01, 0x03, 0x00, 0x00, 0x03, 0x57,
+	0x04, 0x01, 0x03, 0x03, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x03, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21,
+	0x35, 0x04, 0xcd, 0xfd, 0xe3, 0x94, 0xfd, 0xe4, 0x03, 0x3a, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0x94,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x27, 0x40, 0x24,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x05, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x02, 0x1d, 0x94,
+	0x02, 0x1c, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00,
+	0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x15, 0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x02, 0xa6, 0x94,
+	0x04, 0x55, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x12, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x05, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x01,
+	0x35, 0x21, 0x15, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x05, 0x03, 0x04, 0x03,
+	0x01, 0x01, 0x76, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x01, 0x89,
+	0x94, 0x94, 0x94, 0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b,
+	0x05, 0x01, 0x04, 0x03, 0x04, 0x86, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x02, 0x03, 0x4f, 0x00, 0x00,
+	0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x15,
+	0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4,
+	0xfe, 0x50, 0x05, 0x7e, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x89,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x05, 0x04, 0x02, 0x02,
+	0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06,
+	0x1a, 0x2b, 0x01, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0x03, 0x44,
+	0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04, 0xea, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x00,
+	0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x33,
+	0x40, 0x30, 0x04, 0x01, 0x01, 0x03, 0x01, 0x86, 0x06, 0x01, 0x02, 0x00, 0x00, 0x05, 0x02, 0x00,
+	0x67, 0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x00, 0x03, 0x05, 0x03,
+	0x4f, 0x00, 0x00, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07,
+	0x06, 0x18, 0x2b, 0x01, 0x15, 0x21, 0x11, 0x23, 0x11, 0x01, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04,
+	0xcd, 0xfd, 0x50, 0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03, 0xce, 0x94, 0xfb, 0x16,
+	0x05, 0x7e, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1,
+	0x03, 0xce, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x05,
+	0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x03, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11,
+	0x06, 0x06, 0x1a, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0xb1,
+	0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0xfa, 0x82, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25,
+	0x05, 0x04, 0x02, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x03, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11,
+	0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11,
+	0x01, 0x89, 0xfe, 0x77, 0x03, 0x45, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0xfb, 0x16, 0x04,
+	0x56, 0xfb, 0xaa, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x03, 0xce, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01, 0x01, 0x02, 0x01, 0x86, 0x00, 0x03, 0x07, 0x01,
+	0x05, 0x00, 0x03, 0x05, 0x67, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f,
+	0x06, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09,
+	0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x03, 0x45, 0x94,
+	0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0xfa, 0x82, 0x04, 0xea, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x04,
+	0x04, 0x03, 0x57, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11, 0x11,
+	0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21,
+	0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0x50, 0x07, 0x8f, 0xfc, 0x3f, 0x94,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x01, 0x89, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x23, 0x40, 0x20, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x04, 0x04, 0x01,
+	0x57, 0x03, 0x01, 0x01, 0x01, 0x04, 0x5f, 0x00, 0x04, 0x01, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11,
+	0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x01,
+	0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0xfc, 0xbc, 0x07, 0x8f, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x04, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02, 0x00, 0x00,
+	0x05, 0x02, 0x00, 0x67, 0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x00,
+	0x03, 0x05, 0x03, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x06, 0x1c, 0x2b, 0x01, 0x21,
+	0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x04, 0xcd, 0xfd, 0xe4, 0x94, 0x01,
+	0x88, 0xfc, 0xbc, 0x94, 0x02, 0xb0, 0x03, 0x3a, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x05, 0x7d,
+	0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0x12, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x05, 0x01, 0x04, 0x03, 0x00,
+	0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x03,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0xfd, 0x4f, 0x02,
+	0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfa, 0x83, 0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xa6, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x23, 0x40, 0x20, 0x02, 0x01, 0x00, 0x01,
+	0x00, 0x85, 0x04, 0x01, 0x01, 0x03, 0x03, 0x01, 0x57, 0x04, 0x01, 0x01, 0x01, 0x03, 0x5f, 0x00,
+	0x03, 0x01, 0x03, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11,
+	0x33, 0x11, 0x33, 0x11, 0x21, 0x35, 0x21, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfc, 0xbb, 0x01, 0x89,
+	0x07, 0x8f, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0x17, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x12, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01,
+	0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x06, 0x01, 0x02, 0x03, 0x00, 0x02, 0x67, 0x00, 0x03, 0x05,
+	0x05, 0x03, 0x57, 0x00, 0x03, 0x03, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x03, 0x05, 0x4f, 0x06, 0x06,
+	0x00, 0x00, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11,
+	0x08, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x01, 0x89, 0x94, 0xfd, 0xe3, 0x02, 0xb1, 0x94, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0xfe,
+	0xd8, 0x94, 0x04, 0xe9, 0xfa, 0x83, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x05, 0x04,
+	0x05, 0x86, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57,
+	0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10,
+	0x06, 0x06, 0x1c, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x23,
+	0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f, 0xfc, 0x3f,
+	0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x02, 0x01, 0x00, 0x03, 0x00, 0x85,
+	0x07, 0x05, 0x06, 0x03, 0x01, 0x04, 0x01, 0x86, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57, 0x00, 0x03,
+	0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b,
+	0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08, 0x06, 0x17, 0x2b, 0x01,
+	0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x01, 0x89, 0x94, 0x94, 0x94,
+	0x01, 0x88, 0xfe, 0x78, 0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xfb, 0xab, 0x94, 0xfb,
+	0xaa, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x09, 0x00, 0x0f, 0x00, 0x32, 0x40, 0x2f, 0x03, 0x01, 0x00, 0x04, 0x00, 0x85, 0x06, 0x01,
+	0x01, 0x05, 0x01, 0x86, 0x00, 0x04, 0x00, 0x02, 0x07, 0x04, 0x02, 0x67, 0x00, 0x07, 0x05, 0x05,
+	0x07, 0x57, 0x00, 0x07, 0x07, 0x05, 0x5f, 0x00, 0x05, 0x07, 0x05, 0x4f, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x10, 0x08, 0x06, 0x1e, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x01, 0x21, 0x11, 0x33,
+	0x11, 0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x01, 0x89, 0x94, 0x94, 0x03, 0x44, 0xfd, 0xe4,
+	0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x07, 0x8f, 0xf6, 0xc1, 0x04, 0xea, 0x04, 0x55,
+	0xfc, 0x3f, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x34, 0x40, 0x31, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03,
+	0x02, 0x86, 0x00, 0x00, 0x06, 0x01, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x03, 0x03, 0x04,
+	0x57, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x04, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a,
+	0x94, 0x03, 0xc1, 0xf6, 0xc1, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x35, 0x40, 0x32, 0x04, 0x01,
+	0x02, 0x01, 0x02, 0x85, 0x07, 0x05, 0x06, 0x03, 0x03, 0x00, 0x03, 0x86, 0x00, 0x01, 0x00, 0x00,
+	0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00,
+	0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x06, 0x19,
+	0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x01, 0x89, 0xfe,
+	0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04, 0x55, 0xf6, 0xc1, 0x09,
+	0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x0f, 0x00, 0x42, 0x40, 0x3f, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x01,
+	0x01, 0x02, 0x01, 0x86, 0x00, 0x03, 0x09, 0x01, 0x05, 0x00, 0x03, 0x05, 0x67, 0x00, 0x00, 0x02,
+	0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x08, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06,
+	0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05,
+	0x00, 0x05, 0x11, 0x11, 0x0a, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35,
+	0x21, 0x11, 0x33, 0x11, 0x13, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x01, 0x89, 0x94,
+	0x94, 0x94, 0x94, 0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb,
+	0xab, 0x04, 0x55, 0xf6, 0xc1, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x03, 0xce, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x39, 0x40, 0x36, 0x00, 0x04, 0x03, 0x04, 0x86, 0x00,
+	0x00, 0x06, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b,
+	0x04, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08, 0x06, 0x17,
+	0x2b, 0x11, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfb,
+	0x33, 0x04, 0xcd, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94, 0x94, 0xfc, 0x3e,
+	0x03, 0xc2, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x0b,
+	0x00, 0x2a, 0x40, 0x27, 0x04, 0x01, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x05, 0x03, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x15,
+	0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfe, 0x78, 0x94, 0x94, 0x94, 0x02,
+	0xa6, 0x94, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x03, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0f, 0x00, 0x40, 0x40, 0x3d,
+	0x06, 0x01, 0x03, 0x04, 0x03, 0x86, 0x00, 0x00, 0x08, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x07,
+	0x01, 0x02, 0x04, 0x04, 0x02, 0x57, 0x07, 0x01, 0x02, 0x02, 0x04, 0x5f, 0x05, 0x09, 0x02, 0x04,
+	0x02, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x04, 0x09, 0x04,
+	0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x06, 0x17, 0x2b, 0x11, 0x35,
+	0x21, 0x15, 0x01, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04, 0xcd,
+	0xfb, 0x33, 0x02, 0x1d, 0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03, 0x3a, 0x94, 0x94,
+	0xfe, 0xd8, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01,
+	0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x06, 0x01, 0x03, 0x04, 0x00, 0x03, 0x67, 0x00, 0x04, 0x05,
+	0x05, 0x04, 0x57, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x04, 0x05, 0x4f, 0x08, 0x08,
+	0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08,
+	0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x02,
+	0x1d, 0x94, 0x02, 0x1c, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfc, 0x3f, 0x94,
+	0xfe, 0xd8, 0x94, 0x94, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x2c, 0x40, 0x29, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85, 0x04, 0x02, 0x02, 0x00, 0x05, 0x05,
+	0x00, 0x57, 0x04, 0x02, 0x02, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x00, 0x05, 0x4f, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35,
+	0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01,
+	0x88, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x0f,
+	0x00, 0x3e, 0x40, 0x3b, 0x04, 0x01, 0x01, 0x00, 0x01, 0x85, 0x05, 0x01, 0x00, 0x03, 0x08, 0x02,
+	0x02, 0x06, 0x00, 0x02, 0x67, 0x00, 0x06, 0x07, 0x07, 0x06, 0x57, 0x00, 0x06, 0x06, 0x07, 0x5f,
+	0x09, 0x01, 0x07, 0x06, 0x07, 0x4f, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d,
+	0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0a, 0x06, 0x18, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x21, 0x11, 0x33, 0x11, 0x21, 0x01, 0x35, 0x21, 0x15,
+	0x01, 0x89, 0x94, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a,
+	0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x3d, 0x40, 0x3a,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x06, 0x05, 0x06, 0x86, 0x02, 0x01, 0x00, 0x0a, 0x09, 0x02,
+	0x03, 0x04, 0x00, 0x03, 0x67, 0x08, 0x01, 0x04, 0x05, 0x05, 0x04, 0x57, 0x08, 0x01, 0x04, 0x04,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x04, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02,
+	0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03,
+	0x3a, 0x94, 0x03, 0xc1, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x03, 0xc2, 0x94, 0x94, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x38, 0x40, 0x35,
+	0x04, 0x01, 0x02, 0x01, 0x02, 0x85, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x07, 0x86, 0x05, 0x03, 0x02,
+	0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x03, 0x02, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x06, 0x02, 0x00,
+	0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0xfe, 0x77, 0x01, 0x89,
+	0x94, 0x94, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04, 0x55,
+	0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x11,
+	0x00, 0x17, 0x00, 0x4f, 0x40, 0x4c, 0x07, 0x01, 0x04, 0x03, 0x04, 0x85, 0x0a, 0x01, 0x01, 0x02,
+	0x01, 0x86, 0x08, 0x01, 0x03, 0x06, 0x0d, 0x02, 0x05, 0x00, 0x03, 0x05, 0x67, 0x0b, 0x01, 0x00,
+	0x02, 0x02, 0x00, 0x57, 0x0b, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x09, 0x0c, 0x02, 0x02, 0x00, 0x02,
+	0x4f, 0x06, 0x06, 0x00, 0x00, 0x17, 0x16, 0x15, 0x14, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0e,
+	0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x02, 0x1d, 0x94, 0xfe, 0x77,
+	0x01, 0x89, 0x94, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x02,
+	0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xfc,
+	0x3f, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xf0, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x11, 0x11, 0x21,
+	0x11, 0x04, 0xcd, 0x02, 0xf0, 0x04, 0x9f, 0xfb, 0x61, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x02, 0xf0, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04,
+	0xcd, 0xfb, 0x33, 0x02, 0xf0, 0xfb, 0x60, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01,
+	0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x07,
+	0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0x67, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02,
+	0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6, 0xc1, 0x00,
+	0x00, 0x01, 0x02, 0x66, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x01,
+	0x21, 0x11, 0x21, 0x02, 0x66, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x00,
+	0x00, 0x12, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x06, 0xcb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b,
+	0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0xf9,
+	0x40, 0xf6, 0x14, 0x0a, 0x02, 0x00, 0x2e, 0x15, 0x29, 0x0b, 0x24, 0x05, 0x01, 0x02, 0x00, 0x01,
+	0x67, 0x16, 0x0c, 0x02, 0x02, 0x2f, 0x17, 0x2a, 0x0d, 0x25, 0x05, 0x03, 0x04, 0x02, 0x03, 0x67,
+	0x18, 0x0e, 0x02, 0x04, 0x30, 0x19, 0x2b, 0x0f, 0x26, 0x05, 0x05, 0x06, 0x04, 0x05, 0x67, 0x1a,
+	0x10, 0x02, 0x06, 0x31, 0x1b, 0x2c, 0x11, 0x27, 0x05, 0x07, 0x08, 0x06, 0x07, 0x67, 0x1c, 0x12,
+	0x02, 0x08, 0x32, 0x1d, 0x2d, 0x13, 0x28, 0x05, 0x09, 0x1e, 0x08, 0x09, 0x67, 0x22, 0x20, 0x02,
+	0x1e, 0x1f, 0x1f, 0x1e, 0x57, 0x22, 0x20, 0x02, 0x1e, 0x1e, 0x1f, 0x5f, 0x35, 0x23, 0x34, 0x21,
+	0x33, 0x05, 0x1f, 0x1e, 0x1f, 0x4f, 0x44, 0x44, 0x40, 0x40, 0x3c, 0x3c, 0x38, 0x38, 0x34, 0x34,
+	0x30, 0x30, 0x2c, 0x2c, 0x28, 0x28, 0x24, 0x24, 0x20, 0x20, 0x1c, 0x1c, 0x18, 0x18, 0x14, 0x14,
+	0x10, 0x10, 0x0c, 0x0c, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x44, 0x47, 0x44, 0x47, 0x46, 0x45,
+	0x40, 0x43, 0x40, 0x43, 0x42, 0x41, 0x3c, 0x3f, 0x3c, 0x3f, 0x3e, 0x3d, 0x38, 0x3b, 0x38, 0x3b,
+	0x3a, 0x39, 0x34, 0x37, 0x34, 0x37, 0x36, 0x35, 0x30, 0x33, 0x30, 0x33, 0x32, 0x31, 0x2c, 0x2f,
+	0x2c, 0x2f, 0x2e, 0x2d, 0x28, 0x2b, 0x28, 0x2b, 0x2a, 0x29, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25,
+	0x20, 0x23, 0x20, 0x23, 0x22, 0x21, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b,
+	0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f,
+	0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x36, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33,
+	0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce,
+	0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01,
+	0xce, 0xfe, 0x65, 0xce, 0xfc, 0xce, 0xcd, 0xcb, 0xce, 0xcb, 0xce, 0x06, 0x06, 0xc5, 0xc5, 0xfe,
+	0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06,
+	0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe,
+	0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0x00,
+	0x00, 0x24, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b,
+	0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0x4b,
+	0x00, 0x4f, 0x00, 0x53, 0x00, 0x57, 0x00, 0x5b, 0x00, 0x5f, 0x00, 0x63, 0x00, 0x67, 0x00, 0x6b,
+	0x00, 0x6f, 0x00, 0x73, 0x00, 0x77, 0x00, 0x7b, 0x00, 0x7f, 0x00, 0x83, 0x00, 0x87, 0x00, 0x8b,
+	0x00, 0x8f, 0x00, 0x00, 0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xfb,
+	0x33, 0xcc, 0xd0, 0xcc, 0xd0, 0xcc, 0xfc, 0xca, 0xcc, 0xd0, 0xcc, 0xd0, 0xc7, 0x05, 0x41, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x05, 0x67, 0xc3, 0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x05, 0x67, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x06, 0xf1, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xf7, 0x85, 0xc3,
+	0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0x00, 0x00, 0x00, 0x00, 0x13, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b,
+	0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0x4b, 0x00, 0x00, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x21, 0x35, 0x23, 0x15, 0x21, 0x35,
+	0x23, 0x15, 0x01, 0x21, 0x11, 0x21, 0xce, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce,
+	0x01, 0xce, 0x02, 0x67, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x02,
+	0x67, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0xfe, 0x69, 0xcd, 0x02,
+	0x66, 0xce, 0x02, 0x67, 0xce, 0xfc, 0x01, 0x04, 0xcd, 0xfb, 0x33, 0x06, 0x06, 0xc5, 0xc5, 0xfe,
+	0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06,
+	0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe,
+	0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0x09,
+	0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x04, 0x71, 0x04, 0x0d, 0x00, 0x03,
+	0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x64, 0x04, 0x0d,
+	0x04, 0x0d, 0xfb, 0xf3, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0x71, 0x04, 0x0d, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02,
+	0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00,
+	0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x64, 0x04, 0x0d, 0xfc, 0x56, 0x03, 0x48, 0xfc, 0xb8, 0x04,
+	0x0d, 0xfb, 0xf3, 0x63, 0x03, 0x48, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0x01, 0x95, 0x02, 0x72,
+	0x03, 0xa3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x06, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x64, 0x02, 0x0e, 0x01, 0x95, 0x02, 0x0e, 0xfd,
+	0xf2, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64, 0x01, 0x9f, 0x02, 0x72, 0x03, 0xad, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02,
+	0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00,
+	0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x13, 0x11,
+	0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x64, 0x02, 0x0e, 0xfe, 0x55, 0x01, 0x49, 0xfe, 0xb7, 0x01,
+	0x9f, 0x02, 0x0e, 0xfd, 0xf2, 0x63, 0x01, 0x48, 0x00, 0x01, 0x00, 0x00, 0x02, 0x00, 0x08, 0x00,
+	0x04, 0x00, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x06, 0x17, 0x2b, 0x11, 0x11, 0x21, 0x11, 0x08, 0x00, 0x02, 0x00, 0x02, 0x00, 0xfe, 0x00,
+	0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x15, 0x40, 0x12,
+	0x01, 0x01, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x02,
+	0x06, 0x16, 0x2b, 0x33, 0x01, 0x01, 0xfa, 0x02, 0xfc, 0x02, 0xfb, 0x05, 0xf7, 0xfa, 0x09, 0x00,
+	0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02,
+	0x00, 0x01, 0x32, 0x2b, 0x13, 0x01, 0x01, 0xfa, 0x05, 0xf7, 0xfa, 0x09, 0x05, 0xf7, 0xfd, 0x04,
+	0xfd, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02,
+	0x00, 0x15, 0x40, 0x12, 0x01, 0x01, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00,
+	0x02, 0x00, 0x02, 0x02, 0x06, 0x16, 0x2b, 0x09, 0x02, 0x06, 0xf1, 0xfd, 0x04, 0xfd, 0x05, 0x05,
+	0xf7, 0xfa, 0x09, 0x05, 0xf7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1,
+	0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x21, 0x01, 0x01, 0x06,
+	0xf1, 0xfa, 0x09, 0x05, 0xf7, 0x02, 0xfc, 0x02, 0xfb, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x20,
+	0x01, 0x22, 0x03, 0xd3, 0x04, 0xd5, 0x00, 0x03, 0x00, 0x07, 0x00, 0x08, 0xb5, 0x07, 0x05, 0x03,
+	0x01, 0x02, 0x32, 0x2b, 0x09, 0x07, 0x03, 0xd3, 0xfe, 0x26, 0xfe, 0x27, 0x01, 0xd9, 0x01, 0x33,
+	0xfe, 0xcd, 0xfe, 0xce, 0x01, 0x32, 0x02, 0xfc, 0xfe, 0x26, 0x01, 0xda, 0x01, 0xd9, 0xfe, 0x27,
+	0x01, 0x32, 0xfe, 0xce, 0xfe, 0xcd, 0x00, 0x00, 0x00, 0x02, 0x00, 0xae, 0x00, 0xde, 0x04, 0x26,
+	0x04, 0x56, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16, 0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33,
+	0x32, 0x00, 0x15, 0x14, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x02, 0x63, 0xb2, 0xfe, 0xfd, 0x01, 0x04, 0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xba, 0x92,
+	0xcd, 0xca, 0x90, 0x8f, 0xca, 0xc9, 0xde, 0x01, 0x07, 0xb5, 0xb8, 0x01, 0x04, 0xfe, 0xfb, 0xba,
+	0xb8, 0xfe, 0xff, 0x63, 0xc8, 0x8e, 0x92, 0xcb, 0xcb, 0x8f, 0x8d, 0xcc, 0x00, 0x01, 0x00, 0xae,
+	0x00, 0xde, 0x04, 0x26, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x18, 0x40, 0x15, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x02, 0x01, 0x00, 0x00, 0x76, 0x01, 0x00, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x03, 0x06,
+	0x16, 0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x14, 0x00, 0x02, 0x63,
+	0xb2, 0xfe, 0xfd, 0x01, 0x04, 0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xde, 0x01, 0x07, 0xb5, 0xb8,
+	0x01, 0x04, 0xfe, 0xfb, 0xba, 0xb8, 0xfe, 0xff, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x03, 0x01, 0x85, 0x00,
+	0x03, 0x02, 0x03, 0x85, 0x04, 0x01, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x76, 0x05, 0x04,
+	0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x11, 0x10, 0x05, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x11, 0x21,
+	0x01, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x04, 0xcd, 0xfb, 0x33,
+	0x04, 0xcd, 0xfd, 0x93, 0xbc, 0x01, 0x07, 0xfe, 0xfd, 0xb9, 0xb8, 0xfe, 0xfc, 0x01, 0x02, 0xfe,
+	0x50, 0x09, 0x3f, 0xf9, 0xa5, 0x01, 0x01, 0xb8, 0xba, 0x01, 0x05, 0xfe, 0xfc, 0xb8, 0xb5, 0xfe,
+	0xf9, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x1b, 0x00, 0x37, 0x40, 0x34, 0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x05,
+	0x03, 0x85, 0x00, 0x05, 0x04, 0x05, 0x85, 0x07, 0x01, 0x04, 0x02, 0x04, 0x85, 0x06, 0x01, 0x02,
+	0x01, 0x02, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x05, 0x04, 0x17, 0x15, 0x10, 0x1b, 0x11,
+	0x1b, 0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x11, 0x10, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11,
+	0x21, 0x01, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x37, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0x60, 0xec,
+	0x01, 0x46, 0xfe, 0xba, 0xe5, 0xe6, 0xfe, 0xbb, 0x01, 0x43, 0xe2, 0xae, 0xfc, 0xfd, 0xb3, 0xb2,
+	0xfe, 0xfe, 0x07, 0x8f, 0xf6, 0xc1, 0x02, 0x75, 0x01, 0x42, 0xea, 0xe5, 0x01, 0x45, 0xfe, 0xbb,
+	0xe6, 0xe4, 0xfe, 0xb9, 0x7b, 0xff, 0xb1, 0xb3, 0xfd, 0xfd, 0xb2, 0xb6, 0xfb, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x42, 0x01, 0x71, 0x02, 0x94, 0x03, 0xc3, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31,
+	0x40, 0x2e, 0x00, 0x03, 0x00, 0x01, 0x00, 0x03, 0x01, 0x69, 0x04, 0x01, 0x00, 0x02, 0x02, 0x00,
+	0x59, 0x04, 0x01, 0x00, 0x00, 0x02, 0x61, 0x05, 0x01, 0x02, 0x00, 0x02, 0x51, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16,
+	0x2b, 0x01, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x17, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x01, 0x69, 0x52, 0x75, 0x73, 0x52, 0x52,
+	0x72, 0x72, 0x4d, 0x77, 0xad, 0xae, 0x7b, 0x7c, 0xad, 0xb0, 0x01, 0xd6, 0x72, 0x50, 0x54, 0x73,
+	0x73, 0x52, 0x50, 0x74, 0x65, 0xb0, 0x79, 0x7b, 0xae, 0xae, 0x7d, 0x7b, 0xac, 0x00, 0x00, 0x00,
+	0x00, 0x05, 0x01, 0x0c, 0xff, 0xdb, 0x07, 0x1e, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x2f, 0x00, 0x3b, 0x00, 0x66, 0x40, 0x63, 0x06, 0x01, 0x04, 0x08, 0x05, 0x08, 0x04, 0x05,
+	0x80, 0x00, 0x01, 0x00, 0x03, 0x09, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x09, 0x0f, 0x0a, 0x0e, 0x03,
+	0x08, 0x04, 0x09, 0x08, 0x69, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x69, 0x0d, 0x01, 0x02,
+	0x00, 0x00, 0x02, 0x59, 0x0d, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0c, 0x01, 0x00, 0x02, 0x00, 0x51,
+	0x31, 0x30, 0x25, 0x24, 0x0d, 0x0c, 0x01, 0x00, 0x37, 0x35, 0x30, 0x3b, 0x31, 0x3b, 0x2b, 0x29,
+	0x24, 0x2f, 0x25, 0x2f, 0x22, 0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17,
+	0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x10, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11,
+	0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20,
+	0x00, 0x11, 0x10, 0x00, 0x03, 0x33, 0x12, 0x21, 0x20, 0x13, 0x33, 0x06, 0x04, 0x23, 0x22, 0x24,
+	0x13, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x21, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x04, 0x0c, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7,
+	0x01, 0x42, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x37, 0xfe, 0xb8, 0x01, 0x0b, 0x01, 0x72, 0xfe, 0x90,
+	0xfe, 0xfb, 0xfe, 0xfb, 0xfe, 0x90, 0x01, 0x6e, 0xda, 0x6f, 0x49, 0x01, 0x29, 0x01, 0x29, 0x49,
+	0x6f, 0x1f, 0xfe, 0xfc, 0xbe, 0xbe, 0xfe, 0xfc, 0xca, 0x32, 0x48, 0x48, 0x33, 0x33, 0x49, 0x49,
+	0x01, 0xb9, 0x32, 0x48, 0x49, 0x33, 0x33, 0x48, 0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42,
+	0x01, 0xc7, 0xfe, 0x3a, 0xfe, 0xbf, 0xfe, 0xb9, 0xfe, 0x3c, 0x94, 0x01, 0x6e, 0x01, 0x08, 0x01,
+	0x04, 0x01, 0x70, 0xfe, 0x90, 0xfe, 0xfb, 0xfe, 0xfe, 0xfe, 0x8d, 0x02, 0x4a, 0xfe, 0xd2, 0x01,
+	0x2e, 0xd4, 0xfb, 0xfb, 0x01, 0x7b, 0x48, 0x33, 0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x48, 0x33,
+	0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x00, 0x00, 0x00, 0x04, 0x01, 0x2d, 0xff, 0xdb, 0x07, 0x3f,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2f, 0x00, 0x59, 0x40, 0x56, 0x0b, 0x05,
+	0x02, 0x03, 0x06, 0x04, 0x06, 0x03, 0x04, 0x80, 0x00, 0x01, 0x09, 0x01, 0x07, 0x06, 0x01, 0x07,
+	0x69, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x00, 0x04, 0x02, 0x06, 0x04, 0x69, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x02, 0x00, 0x51, 0x25, 0x24, 0x19,
+	0x18, 0x0c, 0x0c, 0x01, 0x00, 0x2b, 0x29, 0x24, 0x2f, 0x25, 0x2f, 0x1f, 0x1d, 0x18, 0x23, 0x19,
+	0x23, 0x0c, 0x17, 0x0c, 0x17, 0x16, 0x14, 0x13, 0x12, 0x10, 0x0e, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0e, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x01, 0x16, 0x04, 0x33, 0x32, 0x24, 0x37, 0x23, 0x02, 0x21, 0x20, 0x03, 0x37, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x04, 0x2d, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7, 0x01, 0x42, 0x01,
+	0x42, 0x01, 0xc7, 0xfe, 0x37, 0xfc, 0xdf, 0x1f, 0x01, 0x04, 0xbe, 0xbe, 0x01, 0x04, 0x1f, 0x6f,
+	0x49, 0xfe, 0xd7, 0xfe, 0xd7, 0x49, 0x7a, 0x34, 0x49, 0x49, 0x33, 0x33, 0x48, 0x48, 0x02, 0x1f,
+	0x35, 0x48, 0x48, 0x33, 0x33, 0x49, 0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42, 0x01, 0xc7,
+	0xfe, 0x3a, 0xfe, 0xbf, 0xfe, 0xb9, 0xfe, 0x3c, 0x02, 0xde, 0xd4, 0xfb, 0xfb, 0xd4, 0xfe, 0xd2,
+	0x01, 0x2e, 0xa7, 0x47, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x34, 0x33, 0x48, 0x48,
+	0x33, 0x33, 0x48, 0x00, 0x00, 0x02, 0x00, 0xad, 0xff, 0xe7, 0x06, 0xa7, 0x05, 0xe1, 0x00, 0x27,
+	0x00, 0x33, 0x00, 0x60, 0x40, 0x5d, 0x19, 0x18, 0x17, 0x15, 0x12, 0x10, 0x0f, 0x0e, 0x08, 0x07,
+	0x02, 0x1a, 0x0d, 0x02, 0x01, 0x07, 0x21, 0x06, 0x02, 0x06, 0x00, 0x26, 0x24, 0x23, 0x22, 0x05,
+	0x04, 0x03, 0x01, 0x08, 0x05, 0x06, 0x04, 0x4c, 0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69,
+	0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x09, 0x01, 0x06, 0x05, 0x05, 0x06,
+	0x59, 0x09, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x06, 0x05, 0x4f, 0x29, 0x28, 0x00,
+	0x00, 0x2f, 0x2d, 0x28, 0x33, 0x29, 0x33, 0x00, 0x27, 0x00, 0x27, 0x11, 0x18, 0x18, 0x11, 0x18,
+	0x0a, 0x06, 0x1b, 0x2b, 0x05, 0x35, 0x26, 0x27, 0x07, 0x27, 0x37, 0x26, 0x27, 0x23, 0x35, 0x33,
+	0x36, 0x37, 0x27, 0x37, 0x17, 0x36, 0x37, 0x35, 0x33, 0x15, 0x16, 0x17, 0x37, 0x17, 0x07, 0x16,
+	0x17, 0x33, 0x15, 0x23, 0x06, 0x07, 0x17, 0x07, 0x27, 0x06, 0x07, 0x15, 0x03, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x60, 0x7b, 0x71, 0xb1, 0x69, 0xb1, 0x4a,
+	0x18, 0xfc, 0xfc, 0x18, 0x4a, 0xb1, 0x69, 0xb1, 0x71, 0x7b, 0x94, 0x7b, 0x71, 0xb1, 0x68, 0xb0,
+	0x4a, 0x18, 0xfc, 0xfc, 0x18, 0x4a, 0xb0, 0x68, 0xb1, 0x71, 0x7b, 0x4f, 0x9e, 0xd9, 0xd9, 0x99,
+	0x9a, 0xd8, 0xd7, 0x19, 0xfc, 0x15, 0x4d, 0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69, 0xb0,
+	0x69, 0xb1, 0x4d, 0x15, 0xfc, 0xfc, 0x15, 0x4d, 0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69,
+	0xb0, 0x69, 0xb1, 0x4d, 0x15, 0xfc, 0x01, 0x8b, 0xd7, 0x9c, 0x99, 0xd8, 0xd8, 0x9a, 0x98, 0xda,
+	0x00, 0x02, 0x00, 0x66, 0xfe, 0x75, 0x05, 0x9a, 0x06, 0x44, 0x00, 0x16, 0x00, 0x22, 0x00, 0x4a,
+	0x40, 0x47, 0x11, 0x05, 0x02, 0x01, 0x06, 0x01, 0x4c, 0x09, 0x01, 0x06, 0x07, 0x01, 0x07, 0x06,
+	0x01, 0x80, 0x08, 0x01, 0x05, 0x00, 0x05, 0x86, 0x00, 0x02, 0x00, 0x07, 0x06, 0x02, 0x07, 0x69,
+	0x03, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x01, 0x00,
+	0x01, 0x00, 0x4f, 0x18, 0x17, 0x00, 0x00, 0x1e, 0x1c, 0x17, 0x22, 0x18, 0x22, 0x00, 0x16, 0x00,
+	0x16, 0x11, 0x16, 0x26, 0x11, 0x11, 0x0a, 0x06, 0x1b, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x21, 0x11,
+	0x24, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x05, 0x11, 0x21, 0x15, 0x21,
+	0x15, 0x03, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x02, 0xb6, 0xfe,
+	0x3e, 0x01, 0xc2, 0xfe, 0xfa, 0xfe, 0xb6, 0x01, 0x86, 0x01, 0x14, 0x01, 0x14, 0x01, 0x86, 0xfe,
+	0xb6, 0xfe, 0xfa, 0x01, 0xc2, 0xfe, 0x3e, 0x50, 0xdc, 0x01, 0x30, 0xfe, 0xd1, 0xd7, 0xd7, 0xfe,
+	0xd1, 0x01, 0x2e, 0xfe, 0x75, 0xf7, 0x94, 0x01, 0x14, 0x25, 0x01, 0x71, 0x01, 0x00, 0x01, 0x14,
+	0x01, 0x86, 0xfe, 0x7a, 0xfe, 0xec, 0xff, 0x00, 0xfe, 0x8f, 0x25, 0xfe, 0xec, 0x94, 0xf7, 0x03,
+	0x2f, 0x01, 0x2d, 0xda, 0xd6, 0x01, 0x2f, 0xfe, 0xd1, 0xd7, 0xd4, 0xfe, 0xce, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x2b, 0xff, 0xb5, 0x06, 0x57, 0x07, 0x2e, 0x00, 0x14, 0x00, 0x20, 0x00, 0x08,
+	0xb5, 0x1d, 0x17, 0x0e, 0x04, 0x02, 0x32, 0x2b, 0x01, 0x13, 0x05, 0x27, 0x25, 0x13, 0x07, 0x03,
+	0x03, 0x16, 0x17, 0x12, 0x00, 0x05, 0x04, 0x00, 0x03, 0x02, 0x00, 0x25, 0x36, 0x01, 0x16, 0x04,
+	0x37, 0x36, 0x12, 0x27, 0x26, 0x24, 0x07, 0x06, 0x02, 0x04, 0x0c, 0xdb, 0xfe, 0x95, 0x26, 0x02,
+	0x5e, 0xa3, 0x8f, 0x61, 0xdb, 0xb6, 0x36, 0x48, 0xfe, 0xeb, 0xfe, 0xf5, 0xfe, 0xf6, 0xfe, 0x24,
+	0x48, 0x47, 0x01, 0x15, 0x01, 0x0c, 0xdb, 0xfd, 0xda, 0x39, 0x01, 0x71, 0xd3, 0xcf, 0xd5, 0x37,
+	0x38, 0xfe, 0x8d, 0xd0, 0xcd, 0xd9, 0x04, 0xe2, 0x01, 0x7c, 0x61, 0x8f, 0xa2, 0xfd, 0xa1, 0x26,
+	0x01, 0x6a, 0xfe, 0x85, 0x99, 0xcd, 0xfe, 0xf5, 0xfe, 0x1d, 0x47, 0x48, 0x01, 0x17, 0x01, 0x0c,
+	0x01, 0x0b, 0x01, 0xd9, 0x48, 0x3b, 0xfc, 0xc1, 0xd4, 0xd8, 0x39, 0x37, 0x01, 0x74, 0xcf, 0xcf,
+	0xd7, 0x38, 0x37, 0xfe, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x32, 0x00, 0x00, 0x04, 0x0d,
+	0x05, 0x36, 0x00, 0x18, 0x00, 0x20, 0x40, 0x1d, 0x17, 0x0c, 0x01, 0x03, 0x00, 0x4a, 0x01, 0x01,
+	0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02, 0x76, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x16,
+	0x14, 0x22, 0x04, 0x06, 0x17, 0x2b, 0x21, 0x13, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x37,
+	0x37, 0x36, 0x37, 0x16, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x13, 0x01,
+	0xa4, 0x5b, 0x68, 0x90, 0x5d, 0x78, 0x48, 0x6c, 0x71, 0x73, 0x55, 0x55, 0x74, 0x71, 0x6c, 0x48,
+	0x78, 0x5e, 0x8f, 0x68, 0x5b, 0x01, 0x64, 0x4a, 0x89, 0x83, 0x6e, 0x95, 0x73, 0x79, 0x7b, 0xa6,
+	0xa6, 0x7b, 0x79, 0x73, 0x95, 0x6f, 0x82, 0x89, 0x4a, 0xfe, 0x9c, 0x00, 0x00, 0x01, 0x00, 0x32,
+	0x00, 0x00, 0x05, 0x0d, 0x04, 0xfb, 0x00, 0x20, 0x00, 0x30, 0x40, 0x2d, 0x1f, 0x15, 0x0b, 0x01,
+	0x04, 0x00, 0x01, 0x01, 0x4c, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85,
+	0x04, 0x01, 0x00, 0x05, 0x00, 0x85, 0x06, 0x01, 0x05, 0x05, 0x76, 0x00, 0x00, 0x00, 0x20, 0x00,
+	0x20, 0x24, 0x25, 0x25, 0x24, 0x22, 0x07, 0x06, 0x1b, 0x2b, 0x21, 0x13, 0x02, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07,
+	0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x03, 0x13, 0x02, 0x19, 0x59, 0x71, 0xc6,
+	0x71, 0x98, 0xa2, 0x85, 0x32, 0x3a, 0x34, 0x9c, 0x73, 0x72, 0x9b, 0x33, 0x39, 0x32, 0x86, 0xa2,
+	0x98, 0x70, 0xc7, 0x72, 0x5a, 0x02, 0x02, 0xfe, 0xef, 0xa0, 0x75, 0x83, 0x9e, 0x11, 0x66, 0x59,
+	0x7d, 0xa9, 0xa9, 0x7d, 0x59, 0x66, 0x11, 0x9e, 0x83, 0x75, 0xa0, 0x01, 0x11, 0xfd, 0xfe, 0x00,
+	0x00, 0x01, 0x00, 0x4a, 0xff, 0xe2, 0x04, 0x75, 0x04, 0xbe, 0x00, 0x19, 0x00, 0x11, 0x40, 0x0e,
+	0x0d, 0x01, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x22, 0x2a, 0x02, 0x06, 0x18, 0x2b, 0x05,
+	0x26, 0x2f, 0x04, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x13, 0x12, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x0f, 0x04, 0x06, 0x02, 0x5f, 0x34, 0x13, 0x5a, 0x42, 0x37, 0x43, 0xb8, 0x95, 0x73, 0xd7, 0x36,
+	0x36, 0xd8, 0x73, 0x95, 0xb8, 0x42, 0x38, 0x42, 0x5a, 0x13, 0x1e, 0x57, 0x19, 0x7f, 0x5f, 0x47,
+	0x54, 0xe9, 0xbe, 0x91, 0xbb, 0xfe, 0xb4, 0x01, 0x4c, 0xbb, 0x91, 0xbe, 0xe9, 0x54, 0x47, 0x5f,
+	0x7f, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x28, 0xff, 0xde, 0x03, 0xed, 0x05, 0x3b, 0x00, 0x07,
+	0x00, 0x06, 0xb3, 0x04, 0x00, 0x01, 0x32, 0x2b, 0x05, 0x02, 0x01, 0x00, 0x13, 0x12, 0x01, 0x00,
+	0x02, 0x0b, 0xc3, 0xfe, 0xe0, 0x01, 0x20, 0xc3, 0xc5, 0x01, 0x1d, 0xfe, 0xe3, 0x22, 0x01, 0x99,
+	0x01, 0x16, 0x01, 0x14, 0x01, 0x9a, 0xfe, 0x67, 0xfe, 0xeb, 0xfe, 0xea, 0x00, 0x01, 0x00, 0x31,
+	0xff, 0xdb, 0x03, 0xcf, 0x05, 0xc8, 0x00, 0x1e, 0x00, 0x2c, 0x40, 0x29, 0x14, 0x0b, 0x0a, 0x03,
+	0x02, 0x00, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4c, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01,
+	0x01, 0x02, 0x59, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x02, 0x01, 0x51, 0x1e, 0x1c, 0x18,
+	0x16, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14,
+	0x07, 0x27, 0x36, 0x35, 0x34, 0x27, 0x27, 0x26, 0x27, 0x26, 0x27, 0x11, 0x10, 0x21, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x01, 0xca, 0x63, 0x83, 0x46, 0xd9, 0x6b, 0x45, 0x3e, 0x58, 0x4a,
+	0x16, 0x34, 0x1d, 0x27, 0xfe, 0xab, 0x49, 0x5e, 0xae, 0x75, 0x3c, 0x01, 0x2d, 0x04, 0x9b, 0x1a,
+	0x83, 0x64, 0x35, 0xa5, 0x8c, 0x68, 0x87, 0x34, 0x54, 0x3d, 0x3d, 0x4e, 0x43, 0x13, 0x25, 0x13,
+	0x2d, 0xfd, 0x2d, 0xfe, 0x31, 0x4c, 0x3c, 0x5a, 0x87, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64,
+	0xfe, 0xeb, 0x05, 0x29, 0x05, 0xed, 0x00, 0x1a, 0x00, 0x33, 0x40, 0x30, 0x19, 0x01, 0x01, 0x03,
+	0x0b, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x1a, 0x0d, 0x0c, 0x00, 0x04, 0x03, 0x4a, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x59, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x69, 0x00, 0x01, 0x01, 0x00, 0x61,
+	0x00, 0x00, 0x01, 0x00, 0x51, 0x23, 0x27, 0x23, 0x23, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x14,
+	0x06, 0x23, 0x22, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x11, 0x01, 0x11, 0x14, 0x07, 0x06, 0x23,
+	0x22, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x11, 0x02, 0x5c, 0xa9, 0xa3, 0xac, 0xac, 0x76, 0x40,
+	0x33, 0x03, 0x30, 0x5e, 0x62, 0x8b, 0xaa, 0xac, 0x7b, 0x33, 0x38, 0x03, 0xf7, 0xfc, 0xc6, 0xe5,
+	0xed, 0x8c, 0x5c, 0x85, 0x18, 0x04, 0x67, 0x01, 0x46, 0xfc, 0x0f, 0xff, 0x63, 0x69, 0x87, 0x5b,
+	0x82, 0x16, 0x03, 0x6f, 0x00, 0x0e, 0x00, 0x99, 0xff, 0x75, 0x08, 0x64, 0x06, 0xa9, 0x00, 0x11,
+	0x00, 0x25, 0x00, 0x36, 0x00, 0x4f, 0x00, 0x6a, 0x00, 0x78, 0x00, 0x83, 0x00, 0x8f, 0x00, 0xa4,
+	0x00, 0xc1, 0x00, 0xd5, 0x00, 0xeb, 0x01, 0x88, 0x01, 0xa3, 0x15, 0x24, 0x4b, 0xb0, 0x0a, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x0c, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x0d, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x0f, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x10, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x12, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x13, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x16, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x18, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x19, 0x50,
+	0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00,
+	0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01,
+	0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00,
+	0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00,
+	0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00,
+	0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00,
+	0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01,
+	0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x1b, 0x41, 0x3c, 0x01, 0x79,
+	0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99,
+	0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e,
+	0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02,
+	0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01,
+	0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12,
+	0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36,
+	0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19,
+	0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59,
+	0x59, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16,
+	0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80,
+	0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80,
+	0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00,
+	0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13,
+	0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80,
+	0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00,
+	0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19,
+	0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b,
+	0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23,
+	0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00,
+	0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b,
+	0xb0, 0x0b, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16,
+	0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e,
+	0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01,
+	0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01,
+	0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14,
+	0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05,
+	0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d,
+	0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02,
+	0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19,
+	0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b,
+	0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06,
+	0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00,
+	0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b,
+	0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0xcf, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f,
+	0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00,
+	0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25,
+	0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26,
+	0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00,
+	0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08,
+	0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02,
+	0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c,
+	0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a,
+	0x19, 0x7e, 0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b, 0x7e, 0x00, 0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00,
+	0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00,
+	0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03,
+	0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02,
+	0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20,
+	0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09,
+	0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21,
+	0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10,
+	0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08,
+	0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01,
+	0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07,
+	0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02,
+	0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69,
+	0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d,
+	0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20,
+	0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e,
+	0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c,
+	0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11,
+	0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14,
+	0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15,
+	0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08,
+	0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07,
+	0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e,
+	0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22,
+	0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11,
+	0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00,
+	0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61,
+	0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x0f, 0x50, 0x58, 0x40, 0xcf, 0x00, 0x20, 0x16,
+	0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22,
+	0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b,
+	0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a,
+	0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05,
+	0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05,
+	0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05,
+	0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d,
+	0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a,
+	0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b, 0x7e, 0x00,
+	0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06,
+	0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05,
+	0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02,
+	0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x10,
+	0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00,
+	0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22,
+	0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10,
+	0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05,
+	0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03,
+	0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02,
+	0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c,
+	0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17,
+	0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25,
+	0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06,
+	0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59,
+	0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x11, 0x50, 0x58,
+	0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17,
+	0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b,
+	0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f,
+	0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10,
+	0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15,
+	0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13,
+	0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80,
+	0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00,
+	0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19,
+	0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b,
+	0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12,
+	0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00,
+	0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x12, 0x50,
+	0x58, 0x40, 0xcf, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f,
+	0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e,
+	0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11,
+	0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11,
+	0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14,
+	0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e,
+	0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d,
+	0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e,
+	0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x00, 0x19,
+	0x1b, 0x02, 0x19, 0x1b, 0x7e, 0x00, 0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22,
+	0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11,
+	0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03,
+	0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00,
+	0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x13, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27,
+	0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09,
+	0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21,
+	0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05,
+	0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e,
+	0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01,
+	0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c,
+	0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b,
+	0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c,
+	0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06,
+	0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69,
+	0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51,
+	0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16,
+	0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80,
+	0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80,
+	0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72,
+	0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80,
+	0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18,
+	0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01,
+	0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07,
+	0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02,
+	0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69,
+	0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69,
+	0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04,
+	0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02,
+	0x51, 0x1b, 0x4b, 0xb0, 0x16, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01,
+	0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e,
+	0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c,
+	0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e,
+	0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18,
+	0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d,
+	0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e,
+	0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01,
+	0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06,
+	0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14,
+	0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00,
+	0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b,
+	0x4b, 0xb0, 0x18, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f,
+	0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00,
+	0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25,
+	0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26,
+	0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00,
+	0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08,
+	0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02,
+	0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c,
+	0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a,
+	0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00,
+	0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00,
+	0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69,
+	0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51,
+	0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16,
+	0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80,
+	0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80,
+	0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00,
+	0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13,
+	0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80,
+	0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00,
+	0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19,
+	0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b,
+	0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23,
+	0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00,
+	0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b,
+	0xb0, 0x1b, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16,
+	0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e,
+	0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01,
+	0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01,
+	0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14,
+	0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05,
+	0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d,
+	0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02,
+	0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19,
+	0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b,
+	0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06,
+	0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00,
+	0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b,
+	0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0xcf, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f,
+	0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00,
+	0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25,
+	0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26,
+	0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00,
+	0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08,
+	0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02,
+	0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c,
+	0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a,
+	0x19, 0x7e, 0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b, 0x7e, 0x00, 0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00,
+	0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00,
+	0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03,
+	0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02,
+	0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20,
+	0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09,
+	0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21,
+	0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00,
+	0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12,
+	0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18,
+	0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03,
+	0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02,
+	0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c,
+	0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17,
+	0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f,
+	0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00,
+	0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00,
+	0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x40, 0xca, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27,
+	0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09,
+	0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21,
+	0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x11,
+	0x0a, 0x10, 0x80, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05,
+	0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18,
+	0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03,
+	0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e,
+	0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00,
+	0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09,
+	0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a,
+	0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x00, 0x02, 0x51, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x41, 0x5c, 0x01, 0x8a, 0x01, 0x89, 0x00, 0xed, 0x00, 0xec, 0x00,
+	0xa6, 0x00, 0xa5, 0x00, 0x79, 0x00, 0x79, 0x00, 0x51, 0x00, 0x50, 0x00, 0x27, 0x00, 0x26, 0x01,
+	0x97, 0x01, 0x95, 0x01, 0x89, 0x01, 0xa3, 0x01, 0x8a, 0x01, 0xa1, 0x01, 0x82, 0x01, 0x80, 0x01,
+	0x73, 0x01, 0x71, 0x01, 0x59, 0x01, 0x58, 0x01, 0x55, 0x01, 0x53, 0x01, 0x4d, 0x01, 0x4b, 0x01,
+	0x3f, 0x01, 0x3d, 0x01, 0x34, 0x01, 0x32, 0x01, 0x2b, 0x01, 0x29, 0x01, 0x0c, 0x01, 0x0b, 0x00,
+	0xf3, 0x00, 0xf1, 0x00, 0xec, 0x01, 0x88, 0x00, 0xed, 0x01, 0x86, 0x00, 0xe8, 0x00, 0xe6, 0x00,
+	0xda, 0x00, 0xd8, 0x00, 0xcf, 0x00, 0xcd, 0x00, 0xbe, 0x00, 0xbc, 0x00, 0xb3, 0x00, 0xb1, 0x00,
+	0xa5, 0x00, 0xc1, 0x00, 0xa6, 0x00, 0xc1, 0x00, 0xa1, 0x00, 0x9f, 0x00, 0x96, 0x00, 0x95, 0x00,
+	0x79, 0x00, 0x83, 0x00, 0x79, 0x00, 0x83, 0x00, 0x80, 0x00, 0x7e, 0x00, 0x74, 0x00, 0x73, 0x00,
+	0x6f, 0x00, 0x6d, 0x00, 0x5d, 0x00, 0x5b, 0x00, 0x50, 0x00, 0x6a, 0x00, 0x51, 0x00, 0x6a, 0x00,
+	0x47, 0x00, 0x45, 0x00, 0x2e, 0x00, 0x2c, 0x00, 0x26, 0x00, 0x36, 0x00, 0x27, 0x00, 0x36, 0x00,
+	0x66, 0x00, 0x26, 0x00, 0x22, 0x00, 0x34, 0x00, 0x14, 0x00, 0x29, 0x00, 0x06, 0x00, 0x1b, 0x2b,
+	0x01, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x22, 0x2e, 0x02, 0x23, 0x22, 0x06, 0x27, 0x3e,
+	0x03, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x02, 0x13, 0x22, 0x2e, 0x02,
+	0x35, 0x34, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x0e, 0x03, 0x01, 0x0e, 0x03, 0x07, 0x0e, 0x03, 0x15,
+	0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x04, 0x37, 0x26, 0x26, 0x13, 0x32, 0x36, 0x37, 0x36, 0x36,
+	0x35, 0x34, 0x26, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x06, 0x15, 0x14, 0x1e, 0x02,
+	0x17, 0x16, 0x17, 0x16, 0x13, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x1e,
+	0x02, 0x07, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x25, 0x14, 0x16, 0x33,
+	0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x07, 0x34, 0x26, 0x35, 0x26, 0x26, 0x23, 0x22,
+	0x0e, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x02, 0x07, 0x32, 0x3e, 0x02, 0x35, 0x34,
+	0x26, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x27, 0x0e, 0x03, 0x15, 0x14, 0x16, 0x33, 0x32, 0x3e,
+	0x02, 0x17, 0x06, 0x07, 0x16, 0x15, 0x14, 0x0e, 0x02, 0x15, 0x14, 0x16, 0x33, 0x32, 0x3e, 0x02,
+	0x35, 0x34, 0x34, 0x37, 0x06, 0x06, 0x23, 0x22, 0x22, 0x27, 0x16, 0x16, 0x15, 0x14, 0x0e, 0x02,
+	0x15, 0x14, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x01, 0x32, 0x16, 0x17, 0x36, 0x36, 0x33, 0x32, 0x16,
+	0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x15, 0x14, 0x14, 0x15,
+	0x3e, 0x03, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x0e, 0x02, 0x07, 0x0e, 0x03, 0x07,
+	0x06, 0x06, 0x07, 0x16, 0x16, 0x17, 0x16, 0x16, 0x17, 0x16, 0x16, 0x15, 0x14, 0x0e, 0x02, 0x23,
+	0x22, 0x26, 0x27, 0x26, 0x26, 0x27, 0x06, 0x06, 0x23, 0x22, 0x26, 0x27, 0x06, 0x07, 0x06, 0x06,
+	0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x35, 0x26, 0x3e, 0x02, 0x37, 0x26, 0x26, 0x27, 0x06, 0x06,
+	0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x36, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x3e, 0x03, 0x35, 0x34,
+	0x2e, 0x02, 0x35, 0x34, 0x36, 0x37, 0x2e, 0x05, 0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02,
+	0x17, 0x36, 0x37, 0x26, 0x26, 0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x36, 0x36,
+	0x01, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02, 0x37, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x16,
+	0x16, 0x17, 0x3e, 0x03, 0x37, 0x06, 0x26, 0x01, 0x3a, 0x0c, 0x16, 0x1f, 0x2f, 0x27, 0x13, 0x43,
+	0x48, 0x3d, 0x0c, 0x11, 0x3b, 0x40, 0x3e, 0x16, 0x1c, 0x49, 0xda, 0x0d, 0x30, 0x34, 0x2e, 0x0c,
+	0x30, 0x59, 0x59, 0x5a, 0x31, 0x29, 0x5e, 0x57, 0x45, 0x10, 0x10, 0x34, 0x3f, 0x44, 0xe4, 0x16,
+	0x1a, 0x0e, 0x04, 0x92, 0x15, 0x33, 0x30, 0x27, 0x0b, 0x37, 0x48, 0x36, 0x2c, 0x02, 0xcf, 0x04,
+	0x1c, 0x22, 0x22, 0x0b, 0x0b, 0x14, 0x12, 0x0a, 0x02, 0x0a, 0x12, 0x11, 0x0d, 0x27, 0x2c, 0x2f,
+	0x27, 0x1b, 0x04, 0x0c, 0x2e, 0x7e, 0x28, 0x55, 0x23, 0x27, 0x25, 0x29, 0x2a, 0x26, 0x56, 0x21,
+	0x2f, 0x53, 0x23, 0x23, 0x25, 0x04, 0x0a, 0x12, 0x0f, 0x1c, 0x30, 0x32, 0xd2, 0x2a, 0x1e, 0x24,
+	0x27, 0x26, 0x25, 0x0b, 0x19, 0x16, 0x0e, 0x6e, 0x08, 0x0d, 0x07, 0x0c, 0x09, 0x0d, 0x02, 0x97,
+	0x0c, 0x08, 0x08, 0x0e, 0x08, 0x0b, 0x07, 0x10, 0xa8, 0x01, 0x03, 0x19, 0x1a, 0x0e, 0x22, 0x1d,
+	0x15, 0x0a, 0x10, 0x15, 0x0b, 0x0d, 0x22, 0x1c, 0x14, 0x4b, 0x0d, 0x26, 0x22, 0x1a, 0x0b, 0x0d,
+	0x04, 0x11, 0x18, 0x21, 0x12, 0x15, 0x2c, 0x08, 0x09, 0x14, 0x11, 0x0b, 0x1c, 0x13, 0x0c, 0x17,
+	0x16, 0x17, 0x10, 0x21, 0x32, 0x03, 0x03, 0x04, 0x02, 0x08, 0x09, 0x13, 0x1b, 0x12, 0x09, 0x60,
+	0x14, 0x18, 0x11, 0x03, 0x08, 0x04, 0x02, 0x01, 0x02, 0x03, 0x03, 0x07, 0x0b, 0x14, 0x19, 0x0e,
+	0x04, 0xfe, 0x66, 0x5a, 0x93, 0x3b, 0x28, 0x52, 0x2b, 0x3e, 0x68, 0x17, 0x13, 0x1e, 0x16, 0x11,
+	0x0a, 0x50, 0x42, 0x09, 0x09, 0x05, 0x18, 0x1b, 0x1a, 0x07, 0x0b, 0x1c, 0x18, 0x13, 0x13, 0x1b,
+	0x32, 0x4b, 0x2f, 0x0a, 0x2c, 0x3b, 0x46, 0x24, 0x0e, 0x1c, 0x0f, 0x0b, 0x12, 0x06, 0x07, 0x0d,
+	0x07, 0x0e, 0x12, 0x11, 0x19, 0x1e, 0x0c, 0x19, 0x34, 0x17, 0x17, 0x1d, 0x0b, 0x49, 0x9e, 0x54,
+	0x2c, 0x4b, 0x22, 0x19, 0x0e, 0x07, 0x10, 0x0a, 0x14, 0x32, 0x1b, 0x1d, 0x2a, 0x02, 0x17, 0x1d,
+	0x1c, 0x05, 0x3c, 0x3b, 0x04, 0x0a, 0x25, 0x17, 0x18, 0x34, 0x2a, 0x1b, 0x2d, 0x21, 0x17, 0x24,
+	0x22, 0x23, 0x15, 0x06, 0x10, 0x0d, 0x0a, 0x1f, 0x25, 0x1f, 0x2f, 0x35, 0x12, 0x28, 0x28, 0x25,
+	0x1c, 0x12, 0x16, 0x23, 0x2d, 0x18, 0x16, 0x33, 0x32, 0x2f, 0x13, 0x44, 0x57, 0x1d, 0x26, 0x16,
+	0x1f, 0x21, 0x0c, 0x18, 0x31, 0x34, 0x3e, 0x24, 0x0f, 0x1f, 0x02, 0x6b, 0x0f, 0x1d, 0x17, 0x0e,
+	0x08, 0x11, 0x1a, 0x13, 0x13, 0x46, 0x33, 0x20, 0x37, 0x19, 0x3c, 0x49, 0x16, 0x12, 0x25, 0x20,
+	0x1a, 0x05, 0x04, 0x02, 0x02, 0x8e, 0x01, 0x0f, 0x11, 0x0e, 0x0d, 0x11, 0x12, 0x05, 0x09, 0x08,
+	0x07, 0x08, 0x96, 0x0a, 0x0f, 0x0a, 0x05, 0x07, 0x0e, 0x11, 0x0a, 0x07, 0x09, 0x06, 0x02, 0x02,
+	0x04, 0x06, 0x01, 0x2e, 0x0d, 0x14, 0x16, 0x08, 0x56, 0x06, 0x0c, 0x0d, 0x06, 0x02, 0x23, 0x29,
+	0x22, 0xfe, 0x43, 0x0a, 0x1f, 0x21, 0x20, 0x0b, 0x0c, 0x15, 0x17, 0x16, 0x0c, 0x04, 0x0c, 0x0d,
+	0x0a, 0x13, 0x1d, 0x26, 0x22, 0x1e, 0x07, 0x22, 0x33, 0x01, 0x2e, 0x1f, 0x1e, 0x23, 0x5a, 0x37,
+	0x39, 0x5e, 0x20, 0x1c, 0x12, 0x24, 0x24, 0x25, 0x5b, 0x2d, 0x0c, 0x22, 0x26, 0x29, 0x14, 0x24,
+	0x16, 0x16, 0x01, 0x1a, 0x1c, 0x27, 0x24, 0x1b, 0x1c, 0x2b, 0x07, 0x10, 0x19, 0x25, 0x0e, 0x08,
+	0x05, 0x0e, 0x0a, 0x09, 0x16, 0x8a, 0x06, 0x08, 0x0a, 0x08, 0x04, 0x0a, 0x08, 0x55, 0x02, 0x03,
+	0x02, 0x11, 0x14, 0x04, 0x0c, 0x15, 0x10, 0x0e, 0x11, 0x09, 0x04, 0x05, 0x0c, 0x14, 0x8f, 0x05,
+	0x0e, 0x1c, 0x18, 0x0f, 0x22, 0x0a, 0x07, 0x11, 0x0f, 0x0a, 0x0e, 0x0b, 0x06, 0x14, 0x18, 0x1b,
+	0x0e, 0x13, 0x14, 0x07, 0x0a, 0x07, 0x1f, 0x16, 0x04, 0x13, 0x0e, 0x0c, 0x11, 0x0d, 0x0a, 0x06,
+	0x08, 0x0c, 0x07, 0x16, 0x26, 0x1f, 0x09, 0x13, 0x23, 0x0c, 0x0c, 0x01, 0x0c, 0x11, 0x07, 0x0d,
+	0x10, 0x0e, 0x0d, 0x09, 0x05, 0x0c, 0x10, 0x21, 0x35, 0x01, 0xfd, 0x35, 0x2f, 0x15, 0x11, 0x36,
+	0x34, 0x07, 0x1f, 0x13, 0x13, 0x1f, 0x0b, 0x4d, 0x61, 0x23, 0x31, 0x68, 0x36, 0x10, 0x1d, 0x0e,
+	0x02, 0x07, 0x0f, 0x18, 0x12, 0x20, 0x2a, 0x1b, 0x26, 0x1c, 0x42, 0x3a, 0x2a, 0x04, 0x67, 0xa8,
+	0x89, 0x6a, 0x2a, 0x0e, 0x1c, 0x0b, 0x11, 0x19, 0x09, 0x0a, 0x12, 0x0a, 0x14, 0x2a, 0x14, 0x14,
+	0x1a, 0x11, 0x07, 0x29, 0x1a, 0x1b, 0x29, 0x10, 0x1d, 0x1d, 0x0a, 0x09, 0x21, 0x10, 0x07, 0x10,
+	0x08, 0x12, 0x17, 0x1d, 0x22, 0x10, 0x27, 0x27, 0x21, 0x0a, 0x33, 0x83, 0x46, 0x02, 0x02, 0x06,
+	0x0f, 0x1d, 0x17, 0x1d, 0x21, 0x03, 0x04, 0x03, 0x01, 0x1e, 0x3e, 0x3f, 0x3f, 0x1f, 0x2d, 0x5c,
+	0x67, 0x72, 0x42, 0x40, 0x8d, 0x41, 0x06, 0x05, 0x05, 0x09, 0x11, 0x1d, 0x17, 0x1b, 0x25, 0x18,
+	0x0a, 0x0d, 0x15, 0x1b, 0x0f, 0x2b, 0x1c, 0x0d, 0x22, 0x18, 0x17, 0x1d, 0x10, 0x07, 0x0b, 0x19,
+	0x27, 0x1c, 0x01, 0x01, 0xfe, 0xd1, 0x07, 0x0f, 0x16, 0x0f, 0x0b, 0x19, 0x15, 0x10, 0x03, 0x1c,
+	0x20, 0x12, 0x0d, 0x3c, 0x96, 0x62, 0x0a, 0x19, 0x22, 0x2c, 0x1f, 0x01, 0x01, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa6, 0x00, 0x00, 0x05, 0x8b, 0x06, 0x44, 0x00, 0x16, 0x00, 0x1a, 0x01, 0x72,
+	0x40, 0x0a, 0x0a, 0x01, 0x08, 0x02, 0x0b, 0x01, 0x09, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50,
+	0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x0b, 0x01, 0x09,
+	0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e,
+	0x50, 0x58, 0x40, 0x27, 0x00, 0x08, 0x0b, 0x01, 0x09, 0x01, 0x08, 0x09, 0x67, 0x00, 0x03, 0x03,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50,
+	0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x0b, 0x01, 0x09,
+	0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x0b, 0x01,
+	0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x27, 0x00, 0x08, 0x0b, 0x01, 0x09, 0x01, 0x08, 0x09, 0x67, 0x00, 0x03,
+	0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x25, 0x00, 0x02, 0x00, 0x03, 0x09, 0x02, 0x03, 0x69, 0x00, 0x08, 0x0b, 0x01,
+	0x09, 0x01, 0x08, 0x09, 0x67, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x02, 0x00, 0x03,
+	0x09, 0x02, 0x03, 0x69, 0x00, 0x08, 0x0b, 0x01, 0x09, 0x01, 0x08, 0x09, 0x67, 0x06, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05,
+	0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x18, 0x17, 0x17, 0x00, 0x00, 0x17, 0x1a, 0x17,
+	0x1a, 0x19, 0x18, 0x00, 0x16, 0x00, 0x16, 0x11, 0x11, 0x12, 0x23, 0x23, 0x11, 0x11, 0x0c, 0x09,
+	0x1d, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37, 0x36, 0x36, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23,
+	0x22, 0x07, 0x07, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x01, 0x37, 0x33, 0x07, 0xa6, 0xb6, 0x72,
+	0x25, 0x72, 0x0f, 0x29, 0xf2, 0xb6, 0x70, 0x6e, 0x26, 0x6c, 0x3b, 0x8a, 0x32, 0x0d, 0x02, 0x8c,
+	0xdb, 0xfe, 0xd8, 0xb6, 0xfe, 0x9c, 0xb6, 0x02, 0x96, 0x31, 0xf6, 0x31, 0x03, 0x91, 0xb9, 0x4c,
+	0xcf, 0xdf, 0x1f, 0xbe, 0x21, 0xfa, 0x44, 0xfb, 0xb6, 0x03, 0x91, 0xfc, 0x6f, 0x05, 0x03, 0xf7,
+	0xf7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa6, 0xff, 0xe7, 0x05, 0x95, 0x06, 0x44, 0x00, 0x20,
+	0x00, 0xda, 0x40, 0x0a, 0x06, 0x01, 0x02, 0x01, 0x00, 0x01, 0x09, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x01, 0x01, 0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x3a, 0x4d,
+	0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x09, 0x09, 0x00,
+	0x62, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2b,
+	0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x05,
+	0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x09, 0x09, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x29, 0x00, 0x07, 0x00, 0x01, 0x02, 0x07, 0x01, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d,
+	0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x09, 0x09, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00,
+	0x07, 0x00, 0x01, 0x02, 0x07, 0x01, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x05, 0x01, 0x03, 0x03,
+	0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x09, 0x09,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0e, 0x20, 0x1e, 0x11,
+	0x22, 0x11, 0x11, 0x11, 0x11, 0x12, 0x23, 0x22, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x07, 0x06, 0x23,
+	0x20, 0x13, 0x13, 0x26, 0x23, 0x22, 0x07, 0x07, 0x33, 0x07, 0x23, 0x03, 0x21, 0x13, 0x23, 0x37,
+	0x33, 0x37, 0x12, 0x21, 0x32, 0x17, 0x21, 0x03, 0x06, 0x17, 0x16, 0x33, 0x32, 0x05, 0x1e, 0x24,
+	0x48, 0x4c, 0xfe, 0xc7, 0x47, 0xd3, 0x50, 0x45, 0xc9, 0x2e, 0x12, 0xd6, 0x25, 0xd6, 0xb6, 0xfe,
+	0xd8, 0xb6, 0x72, 0x25, 0x72, 0x0e, 0x57, 0x01, 0x92, 0x4f, 0x9d, 0x01, 0x31, 0xf6, 0x12, 0x0c,
+	0x0d, 0x42, 0x1b, 0xb6, 0xb6, 0x19, 0x01, 0x68, 0x04, 0x1c, 0x22, 0xe6, 0x5d, 0xb9, 0xfc, 0x6f,
+	0x03, 0x91, 0xb9, 0x45, 0x01, 0xb5, 0x19, 0xfb, 0x33, 0x68, 0x23, 0x26, 0x00, 0x03, 0x00, 0x99,
+	0xff, 0x00, 0x08, 0x99, 0x07, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x1f, 0x00, 0x42, 0x40, 0x3f,
+	0x16, 0x02, 0x02, 0x02, 0x04, 0x01, 0x4c, 0x01, 0x01, 0x03, 0x4a, 0x03, 0x01, 0x00, 0x49, 0x00,
+	0x03, 0x04, 0x03, 0x85, 0x00, 0x04, 0x02, 0x04, 0x85, 0x00, 0x00, 0x01, 0x00, 0x86, 0x05, 0x01,
+	0x02, 0x01, 0x01, 0x02, 0x57, 0x05, 0x01, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x02, 0x01, 0x4f,
+	0x09, 0x08, 0x19, 0x17, 0x14, 0x12, 0x08, 0x1f, 0x09, 0x1f, 0x11, 0x14, 0x06, 0x06, 0x18, 0x2b,
+	0x13, 0x09, 0x02, 0x03, 0x21, 0x13, 0x21, 0x37, 0x21, 0x37, 0x36, 0x36, 0x37, 0x37, 0x36, 0x37,
+	0x36, 0x24, 0x21, 0x22, 0x07, 0x03, 0x36, 0x33, 0x32, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x99,
+	0x04, 0xcd, 0x03, 0x33, 0xfb, 0x33, 0x91, 0x01, 0x69, 0x36, 0xfe, 0x97, 0x1b, 0x01, 0x69, 0x0a,
+	0x12, 0x51, 0x6a, 0x50, 0xb1, 0x19, 0x1e, 0xfe, 0xfd, 0xfe, 0xfc, 0xbc, 0xc9, 0x34, 0xde, 0x85,
+	0xc4, 0x21, 0x18, 0x92, 0x57, 0xa5, 0x16, 0x03, 0x00, 0x04, 0x00, 0xfc, 0x00, 0xfc, 0x00, 0x01,
+	0x00, 0x01, 0x0f, 0x88, 0x35, 0x5a, 0x72, 0x54, 0x40, 0x8c, 0x80, 0x93, 0xa4, 0x3c, 0xfe, 0xfa,
+	0x60, 0xa7, 0x76, 0x81, 0x4d, 0x92, 0x6e, 0x00, 0x00, 0x03, 0x00, 0x9e, 0xff, 0xdb, 0x04, 0xfd,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x42, 0x40, 0x3f, 0x00, 0x01, 0x00, 0x03,
+	0x04, 0x01, 0x03, 0x69, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x07, 0x01, 0x02,
+	0x00, 0x00, 0x02, 0x59, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x02, 0x00, 0x51,
+	0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13,
+	0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x06, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13,
+	0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x13, 0x12, 0x23, 0x22, 0x03, 0x02,
+	0x13, 0x37, 0x33, 0x07, 0x02, 0x2c, 0xd9, 0xb5, 0x46, 0x46, 0x01, 0x61, 0xde, 0xdc, 0xb8, 0x46,
+	0x47, 0xfe, 0x9f, 0xba, 0xb0, 0x79, 0x74, 0xae, 0xaf, 0x76, 0x77, 0xb2, 0x29, 0xc9, 0x29, 0x25,
+	0x01, 0xac, 0x01, 0x5e, 0x01, 0x60, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0xa3, 0xfe, 0x99, 0xfe, 0x59,
+	0xb9, 0x02, 0x5c, 0x02, 0x44, 0xfd, 0xb1, 0xfd, 0xaf, 0x02, 0x05, 0xc9, 0xc9, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9e, 0xff, 0xdb, 0x04, 0xfe, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x31,
+	0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02,
+	0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01,
+	0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16,
+	0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x32, 0x13,
+	0x12, 0x23, 0x22, 0x03, 0x02, 0x02, 0x32, 0xdf, 0xb5, 0x46, 0x46, 0x01, 0x61, 0xde, 0xdd, 0xb8,
+	0x46, 0x47, 0xfe, 0x9f, 0xb9, 0xc8, 0x77, 0x76, 0xc8, 0xc8, 0x76, 0x77, 0x25, 0x01, 0xac, 0x01,
+	0x5e, 0x01, 0x60, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0x9f, 0xfe, 0x9d, 0xfe, 0x59, 0xb9, 0x02, 0x51,
+	0x02, 0x4f, 0xfd, 0xb1, 0xfd, 0xaf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x02, 0x8f,
+	0x25, 0x23, 0x21, 0xbf, 0x5f, 0x0f, 0x3c, 0xf5, 0x00, 0x0d, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xd4, 0x49, 0x69, 0x00, 0x00, 0x00, 0x00, 0x00, 0xde, 0xcc, 0x9b, 0x63, 0xfe, 0x35, 0xfe, 0x14,
+	0x08, 0xfc, 0x08, 0xf3, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0x07, 0x8f, 0xfe, 0x50, 0x00, 0x00, 0x08, 0xeb, 0xfe, 0x35, 0xfd, 0x0b,
+	0x08, 0xfc, 0x08, 0x00, 0x01, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x02, 0xc7, 0x06, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x39, 0x00, 0x00,
+	0x02, 0x39, 0x00, 0x00, 0x02, 0xaa, 0x00, 0xcb, 0x03, 0xcb, 0x01, 0x61, 0x04, 0x73, 0x00, 0x6e,
+	0x04, 0x73, 0x00, 0x72, 0x07, 0x1d, 0x00, 0xdc, 0x05, 0xc7, 0x00, 0x5b, 0x01, 0xe7, 0x01, 0x4f,
+	0x02, 0xaa, 0x00, 0x98, 0x02, 0xaa, 0x00, 0x00, 0x04, 0x77, 0x00, 0xee, 0x04, 0xac, 0x00, 0xca,
+	0x02, 0x39, 0x00, 0x36, 0x04, 0xac, 0x00, 0xca, 0x02, 0x39, 0x00, 0x7c, 0x02, 0x39, 0xff, 0xe8,
+	0x04, 0x73, 0x00, 0x9e, 0x04, 0x73, 0x00, 0xb6, 0x04, 0x73, 0x00, 0x4d, 0x04, 0x73, 0x00, 0x8b,
+	0x04, 0x73, 0x00, 0x6d, 0x04, 0x73, 0x00, 0x91, 0x04, 0x73, 0x00, 0x79, 0x04, 0x73, 0x00, 0xb0,
+	0x04, 0x73, 0x00, 0x79, 0x04, 0x73, 0x00, 0x98, 0x02, 0xaa, 0x00, 0xd6, 0x02, 0xaa, 0x00, 0x90,
+	0x04, 0xac, 0x00, 0xde, 0x04, 0xac, 0x00, 0x9d, 0x04, 0xac, 0x00, 0x7c, 0x04, 0xe3, 0x01, 0x60,
+	0x07, 0xcd, 0x00, 0xfc, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0xad, 0x05, 0xc7, 0x00, 0x99,
+	0x05, 0xc7, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x04, 0xe3, 0x00, 0xad, 0x06, 0x39, 0x00, 0x9c,
+	0x05, 0xc7, 0x00, 0xad, 0x03, 0xa0, 0x00, 0x64, 0x04, 0x73, 0xff, 0xce, 0x05, 0xc7, 0x00, 0xad,
+	0x04, 0xe3, 0x00, 0xad, 0x06, 0xaa, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x9b,
+	0x05, 0x56, 0x00, 0xad, 0x06, 0x39, 0x00, 0x9c, 0x05, 0xc7, 0x00, 0xad, 0x05, 0x56, 0x00, 0x68,
+	0x04, 0xe3, 0x01, 0x25, 0x05, 0xc7, 0x00, 0xeb, 0x05, 0x56, 0x01, 0x40, 0x07, 0x8d, 0x01, 0x40,
+	0x05, 0x56, 0x00, 0x31, 0x05, 0x56, 0x01, 0x43, 0x04, 0xe3, 0x00, 0x5e, 0x02, 0xaa, 0x00, 0x63,
+	0x02, 0x39, 0x01, 0x27, 0x02, 0xaa, 0x00, 0x00, 0x04, 0xac, 0x00, 0xf4, 0x04, 0x73, 0xff, 0xde,
+	0x02, 0xaa, 0x01, 0x8b, 0x04, 0xe3, 0x00, 0x86, 0x04, 0xe3, 0x00, 0x94, 0x04, 0x73, 0x00, 0x81,
+	0x04, 0xe3, 0x00, 0x86, 0x04, 0x73, 0x00, 0x83, 0x02, 0xaa, 0x00, 0xa6, 0x04, 0xe3, 0x00, 0x37,
+	0x04, 0xe3, 0x00, 0x94, 0x02, 0x50, 0x00, 0x94, 0x02, 0x4d, 0xff, 0x25, 0x04, 0x73, 0x00, 0x94,
+	0x02, 0x63, 0x00, 0x82, 0x07, 0x1d, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x83,
+	0x04, 0xe3, 0x00, 0x45, 0x04, 0xe3, 0x00, 0x86, 0x03, 0x1d, 0x00, 0xad, 0x04, 0x73, 0x00, 0x82,
+	0x02, 0xaa, 0x00, 0x9d, 0x04, 0xe3, 0x00, 0x83, 0x04, 0x73, 0x00, 0xf4, 0x06, 0x39, 0x01, 0x19,
+	0x04, 0x73, 0x00, 0x30, 0x04, 0x73, 0x00, 0x5c, 0x04, 0x00, 0x00, 0x6f, 0x03, 0x1d, 0x00, 0xaf,
+	0x02, 0x3d, 0x00, 0x75, 0x03, 0x1d, 0x00, 0x3f, 0x04, 0xac, 0x00, 0xa8, 0x02, 0x39, 0x00, 0x00,
+	0x02, 0xaa, 0x00, 0x6a, 0x04, 0x73, 0x00, 0xf7, 0x04, 0x73, 0x00, 0x66, 0x04, 0x73, 0x00, 0x3c,
+	0x04, 0x73, 0x00, 0xeb, 0x02, 0x3d, 0x00, 0x75, 0x04, 0x73, 0x00, 0x5e, 0x02, 0xaa, 0x01, 0x14,
+	0x05, 0xe5, 0x00, 0x63, 0x02, 0xf6, 0x00, 0xeb, 0x04, 0x73, 0x00, 0xae, 0x04, 0xac, 0x00, 0xf7,
+	0x02, 0xaa, 0x00, 0xb6, 0x05, 0xe5, 0x00, 0x65, 0x04, 0x73, 0x01, 0x6e, 0x03, 0x33, 0x01, 0x5f,
+	0x04, 0xac, 0x00, 0x68, 0x03, 0xf5, 0x00, 0xf6, 0x03, 0xf5, 0x01, 0x25, 0x02, 0xaa, 0x01, 0x55,
+	0x04, 0xe3, 0x00, 0x45, 0x04, 0x73, 0x00, 0xec, 0x02, 0x38, 0x01, 0x16, 0x02, 0xaa, 0x00, 0x2a,
+	0x03, 0xf5, 0x01, 0x45, 0x02, 0xec, 0x00, 0xf1, 0x04, 0x73, 0x00, 0x67, 0x06, 0xac, 0x00, 0x9f,
+	0x06, 0xac, 0x00, 0x7a, 0x06, 0xac, 0x00, 0xdd, 0x04, 0xe3, 0x00, 0x35, 0x05, 0xc7, 0x00, 0x0c,
+	0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c,
+	0x05, 0xc7, 0x00, 0x0c, 0x08, 0x00, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x99, 0x05, 0x56, 0x00, 0xad,
+	0x05, 0x56, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x03, 0xa0, 0x00, 0x64,
+	0x03, 0xa0, 0x00, 0x64, 0x03, 0xa0, 0x00, 0x64, 0x03, 0xa0, 0x00, 0x64, 0x05, 0xc7, 0x00, 0x83,
+	0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x9b, 0x06, 0x39, 0x00, 0x9b, 0x06, 0x39, 0x00, 0x9b,
+	0x06, 0x39, 0x00, 0x9b, 0x06, 0x39, 0x00, 0x9b, 0x04, 0xac, 0x00, 0x8e, 0x06, 0x39, 0x00, 0x48,
+	0x05, 0xc7, 0x00, 0xeb, 0x05, 0xc7, 0x00, 0xeb, 0x05, 0xc7, 0x00, 0xeb, 0x05, 0xc7, 0x00, 0xeb,
+	0x05, 0x56, 0x01, 0x43, 0x05, 0x56, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x86,
+	0x04, 0xe3, 0x00, 0x86, 0x04, 0xe3, 0x00, 0x86, 0x04, 0xe3, 0x00, 0x86, 0x04, 0xe3, 0x00, 0x86,
+	0x04, 0xe3, 0x00, 0x86, 0x07, 0x1d, 0x00, 0x62, 0x04, 0x73, 0x00, 0x81, 0x04, 0x73, 0x00, 0x83,
+	0x04, 0x73, 0x00, 0x83, 0x04, 0x73, 0x00, 0x83, 0x04, 0x73, 0x00, 0x83, 0x02, 0x50, 0x00, 0x94,
+	0x02, 0x50, 0x00, 0x94, 0x02, 0x50, 0x00, 0x94, 0x02, 0x50, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x81,
+	0x04, 0xe3, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xe3, 0x00, 0x83,
+	0x04, 0xe3, 0x00, 0x83, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xac, 0x00, 0xcd, 0x04, 0xe3, 0x00, 0x45,
+	0x04, 0xe3, 0x00, 0x83, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xe3, 0x00, 0x83,
+	0x04, 0x73, 0x00, 0x5c, 0x04, 0xe3, 0x00, 0x45, 0x04, 0x73, 0x00, 0x5c, 0x05, 0xc7, 0x00, 0x0c,
+	0x04, 0xe3, 0x00, 0x86, 0x05, 0xc7, 0x00, 0x0c, 0x04, 0xe3, 0x00, 0x86, 0x05, 0xc7, 0x00, 0x0c,
+	0x04, 0xe3, 0x00, 0x86, 0x05, 0xc7, 0x00, 0x99, 0x04, 0x73, 0x00, 0x81, 0x05, 0xc7, 0x00, 0x99,
+	0x04, 0x73, 0x00, 0x81, 0x05, 0xc7, 0x00, 0x99, 0x04, 0x73, 0x00, 0x81, 0x05, 0xc7, 0x00, 0x99,
+	0x04, 0x73, 0x00, 0x81, 0x05, 0xc7, 0x00, 0xad, 0x05, 0xc0, 0x00, 0x86, 0x05, 0xc7, 0x00, 0x85,
+	0x04, 0xe3, 0x00, 0x86, 0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x83, 0x05, 0x56, 0x00, 0xad,
+	0x04, 0x73, 0x00, 0x83, 0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x83, 0x05, 0x56, 0x00, 0xad,
+	0x04, 0x73, 0x00, 0x83, 0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x83, 0x06, 0x39, 0x00, 0x9c,
+	0x04, 0xe3, 0x00, 0x37, 0x06, 0x39, 0x00, 0x9c, 0x04, 0xe3, 0x00, 0x37, 0x06, 0x39, 0x00, 0x9c,
+	0x04, 0xe3, 0x00, 0x37, 0x06, 0x39, 0x00, 0x9c, 0x04, 0xe3, 0x00, 0x37, 0x05, 0xc7, 0x00, 0xad,
+	0x04, 0xe3, 0x00, 0x94, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94, 0x03, 0xa0, 0x00, 0x64,
+	0x02, 0x50, 0x00, 0x94, 0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0x00, 0x94, 0x03, 0xa0, 0x00, 0x64,
+	0x02, 0x50, 0x00, 0x94, 0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0xff, 0xf4, 0x03, 0xa0, 0x00, 0x64,
+	0x02, 0x50, 0x00, 0x94, 0x06, 0xfb, 0x00, 0x64, 0x04, 0x7d, 0x00, 0x94, 0x04, 0x73, 0xff, 0xce,
+	0x02, 0x43, 0xff, 0x25, 0x05, 0xc7, 0x00, 0xad, 0x04, 0x73, 0x00, 0x94, 0x04, 0x73, 0x00, 0x94,
+	0x04, 0xe3, 0x00, 0xad, 0x02, 0x63, 0x00, 0x82, 0x04, 0xe3, 0x00, 0xad, 0x02, 0x63, 0x00, 0x36,
+	0x04, 0xe3, 0x00, 0xad, 0x03, 0x41, 0x00, 0x82, 0x04, 0xe3, 0x00, 0xad, 0x03, 0xd5, 0x00, 0x82,
+	0x04, 0xe3, 0x00, 0x65, 0x02, 0x85, 0x00, 0x72, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94,
+	0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94,
+	0x05, 0xab, 0x00, 0xd3, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94, 0x06, 0x39, 0x00, 0x9b,
+	0x04, 0xe3, 0x00, 0x83, 0x06, 0x39, 0x00, 0x9b, 0x04, 0xe3, 0x00, 0x83, 0x06, 0x39, 0x00, 0x9b,
+	0x04, 0xe3, 0x00, 0x83, 0x08, 0x00, 0x00, 0x9b, 0x07, 0x8d, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xad,
+	0x03, 0x1d, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad, 0x03, 0x1d, 0x00, 0x72, 0x05, 0xc7, 0x00, 0xad,
+	0x03, 0x1d, 0x00, 0xad, 0x05, 0x56, 0x00, 0x68, 0x04, 0x73, 0x00, 0x82, 0x05, 0x56, 0x00, 0x68,
+	0x04, 0x73, 0x00, 0x82, 0x05, 0x56, 0x00, 0x68, 0x04, 0x73, 0x00, 0x82, 0x05, 0x56, 0x00, 0x68,
+	0x04, 0x73, 0x00, 0x82, 0x04, 0xe3, 0x01, 0x27, 0x02, 0xaa, 0x00, 0x87, 0x04, 0xe3, 0x01, 0x27,
+	0x03, 0xd5, 0x00, 0x9d, 0x04, 0xe3, 0x01, 0x27, 0x02, 0xaa, 0x00, 0x9d, 0x05, 0xc7, 0x00, 0xeb,
+	0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb,
+	0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb,
+	0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83, 0x07, 0x8d, 0x01, 0x40,
+	0x06, 0x39, 0x01, 0x19, 0x05, 0x56, 0x01, 0x43, 0x04, 0x73, 0x00, 0x5c, 0x05, 0x56, 0x01, 0x43,
+	0x04, 0xe3, 0x00, 0x5e, 0x04, 0x00, 0x00, 0x6f, 0x04, 0xe3, 0x00, 0x5e, 0x04, 0x00, 0x00, 0x6f,
+	0x04, 0xe3, 0x00, 0x5e, 0x04, 0x00, 0x00, 0x6f, 0x02, 0x75, 0x00, 0xa6, 0x04, 0x73, 0xff, 0xf6,
+	0x05, 0xc7, 0x00, 0x0c, 0x04, 0xe3, 0x00, 0x86, 0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0x00, 0x94,
+	0x06, 0x39, 0x00, 0x9b, 0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83,
+	0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83,
+	0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83, 0x05, 0xc7, 0x00, 0xeb, 0x04, 0xe3, 0x00, 0x83,
+	0x05, 0xc7, 0x00, 0x0c, 0x04, 0x73, 0x00, 0x86, 0x08, 0x00, 0x00, 0x0c, 0x07, 0x1d, 0x00, 0x62,
+	0x06, 0x39, 0x00, 0x48, 0x04, 0xe3, 0x00, 0x45, 0x05, 0x56, 0x00, 0x68, 0x04, 0x73, 0x00, 0x82,
+	0x04, 0xe3, 0x01, 0x27, 0x02, 0xaa, 0x00, 0x80, 0x02, 0xaa, 0x00, 0xdc, 0x02, 0xaa, 0x01, 0x1c,
+	0x02, 0xaa, 0x00, 0xd9, 0x02, 0xaa, 0x01, 0x26, 0x02, 0xaa, 0x01, 0xb7, 0x02, 0xaa, 0x01, 0x86,
+	0x02, 0xaa, 0x00, 0x14, 0x02, 0xaa, 0x00, 0xeb, 0x02, 0xaa, 0x00, 0xae, 0x02, 0xaa, 0x00, 0x90,
+	0x02, 0xaa, 0x01, 0x76, 0x03, 0xb8, 0x01, 0x0f, 0x05, 0xc7, 0x00, 0x0c, 0x02, 0xaa, 0x01, 0x4f,
+	0x06, 0xd3, 0x01, 0x0a, 0x07, 0x3f, 0x01, 0x0a, 0x04, 0x82, 0x00, 0x6a, 0x06, 0x99, 0x00, 0xfb,
+	0x07, 0x6b, 0x01, 0x14, 0x06, 0xb4, 0x00, 0xa9, 0x03, 0x14, 0x00, 0xbe, 0x05, 0xc7, 0x00, 0x0c,
+	0x05, 0xc7, 0x00, 0xad, 0x04, 0xcf, 0x00, 0xad, 0x05, 0xc0, 0x00, 0x1e, 0x05, 0x56, 0x00, 0xad,
+	0x04, 0xe3, 0x00, 0x5e, 0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x9b, 0x03, 0xa0, 0x00, 0x64,
+	0x05, 0xc7, 0x00, 0xad, 0x05, 0x56, 0x00, 0x0e, 0x06, 0xaa, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad,
+	0x05, 0x26, 0x00, 0x28, 0x06, 0x39, 0x00, 0x9b, 0x05, 0xc7, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad,
+	0x04, 0xcd, 0x00, 0x46, 0x04, 0xe3, 0x01, 0x25, 0x05, 0x56, 0x01, 0x11, 0x06, 0x91, 0x00, 0xce,
+	0x05, 0x56, 0x00, 0x31, 0x06, 0x79, 0x01, 0x4f, 0x06, 0x6a, 0x00, 0x5f, 0x03, 0xa0, 0x00, 0x64,
+	0x05, 0x56, 0x01, 0x11, 0x04, 0xeb, 0x00, 0x99, 0x03, 0x9c, 0x00, 0x64, 0x04, 0xe3, 0x00, 0x82,
+	0x03, 0x14, 0x00, 0xc5, 0x04, 0xa8, 0x00, 0xcf, 0x04, 0xeb, 0x00, 0x99, 0x04, 0xe2, 0x00, 0x45,
+	0x04, 0x73, 0x00, 0xe2, 0x04, 0xda, 0x00, 0x7b, 0x03, 0xcc, 0x00, 0x64, 0x03, 0xaf, 0x00, 0x93,
+	0x04, 0xe3, 0x00, 0x82, 0x04, 0x53, 0x00, 0xbd, 0x03, 0x14, 0x00, 0xc5, 0x04, 0x76, 0x00, 0x94,
+	0x04, 0x73, 0x00, 0x1b, 0x04, 0xe5, 0x00, 0x45, 0x04, 0x73, 0x00, 0xe4, 0x03, 0x90, 0x00, 0x85,
+	0x04, 0xe3, 0x00, 0x83, 0x06, 0x20, 0x00, 0xba, 0x04, 0xf3, 0x00, 0x38, 0x04, 0x29, 0x00, 0x8f,
+	0x05, 0x79, 0x00, 0x83, 0x03, 0x92, 0x00, 0xb1, 0x04, 0xa8, 0x00, 0xcf, 0x05, 0xb9, 0x00, 0xa5,
+	0x04, 0x9b, 0xff, 0x95, 0x06, 0x07, 0x00, 0xae, 0x06, 0xc2, 0x00, 0x9e, 0x03, 0x14, 0x00, 0xc5,
+	0x04, 0xa8, 0x00, 0xcf, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xa8, 0x00, 0xcf, 0x06, 0xc2, 0x00, 0x9e,
+	0x05, 0x56, 0x00, 0xad, 0x05, 0x5a, 0x00, 0xad, 0x07, 0x15, 0x01, 0x18, 0x04, 0x89, 0x00, 0xad,
+	0x05, 0xb1, 0x00, 0xa4, 0x05, 0x56, 0x00, 0x68, 0x03, 0xa0, 0x00, 0x64, 0x03, 0xa0, 0x00, 0x64,
+	0x04, 0x73, 0xff, 0xce, 0x08, 0xc0, 0x00, 0x28, 0x08, 0x80, 0x00, 0xad, 0x07, 0x00, 0x01, 0x27,
+	0x04, 0xe2, 0x00, 0xad, 0x05, 0xc0, 0x00, 0xad, 0x04, 0xfa, 0x00, 0x8c, 0x05, 0xc0, 0x00, 0xad,
+	0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc0, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad, 0x04, 0x89, 0x00, 0xad,
+	0x05, 0xb3, 0xff, 0xc3, 0x05, 0x56, 0x00, 0xad, 0x07, 0x3b, 0x00, 0x24, 0x05, 0x03, 0x00, 0x6c,
+	0x05, 0xc0, 0x00, 0xad, 0x05, 0xc0, 0x00, 0xad, 0x04, 0xe2, 0x00, 0xad, 0x05, 0x9d, 0x00, 0x14,
+	0x06, 0xaa, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x9b, 0x05, 0xc0, 0x00, 0xad,
+	0x05, 0x56, 0x00, 0xad, 0x05, 0xc7, 0x00, 0x99, 0x04, 0xe3, 0x01, 0x25, 0x04, 0xfa, 0x00, 0x8c,
+	0x06, 0xd4, 0x00, 0xb5, 0x05, 0x56, 0x00, 0x31, 0x05, 0xd8, 0x00, 0xad, 0x05, 0x9f, 0x01, 0x11,
+	0x08, 0x0a, 0x00, 0xad, 0x08, 0x27, 0x00, 0xad, 0x06, 0xf5, 0x01, 0x17, 0x07, 0xd5, 0x00, 0xad,
+	0x05, 0xc0, 0x00, 0xad, 0x05, 0xb1, 0x00, 0x51, 0x08, 0x40, 0x00, 0xad, 0x05, 0xc0, 0x00, 0x3e,
+	0x04, 0xe3, 0x00, 0x86, 0x04, 0xf1, 0x00, 0x9f, 0x04, 0xeb, 0x00, 0x96, 0x03, 0x55, 0x00, 0x96,
+	0x05, 0x14, 0xff, 0xc6, 0x04, 0x73, 0x00, 0x83, 0x05, 0xac, 0x00, 0x05, 0x03, 0xfa, 0x00, 0x40,
+	0x04, 0xeb, 0x00, 0x94, 0x04, 0xeb, 0x00, 0x94, 0x04, 0x01, 0x00, 0x94, 0x05, 0x15, 0x00, 0x1e,
+	0x05, 0xeb, 0x00, 0x96, 0x04, 0xd5, 0x00, 0x96, 0x04, 0xe3, 0x00, 0x83, 0x04, 0xd5, 0x00, 0x96,
+	0x04, 0xe3, 0x00, 0x45, 0x04, 0x73, 0x00, 0x81, 0x03, 0xeb, 0x00, 0xc8, 0x04, 0x73, 0x00, 0x00,
+	0x07, 0x00, 0x00, 0x86, 0x04, 0x73, 0x00, 0x30, 0x04, 0xeb, 0x00, 0x94, 0x04, 0xa5, 0x00, 0xc4,
+	0x06, 0xab, 0x00, 0x96, 0x06, 0xc0, 0x00, 0x94, 0x05, 0xd5, 0x00, 0xb3, 0x06, 0xd5, 0x00, 0x94,
+	0x04, 0xeb, 0x00, 0x94, 0x04, 0x6b, 0x00, 0x3b, 0x06, 0xd5, 0x00, 0x94, 0x04, 0xab, 0x00, 0x35,
+	0x04, 0x73, 0x00, 0x83, 0x04, 0x73, 0x00, 0x83, 0x04, 0xe3, 0x00, 0xb4, 0x03, 0x55, 0x00, 0x96,
+	0x04, 0x6b, 0x00, 0x7f, 0x04, 0x73, 0x00, 0x82, 0x02, 0x39, 0x00, 0x89, 0x02, 0x40, 0x00, 0x8c,
+	0x02, 0x39, 0xff, 0x67, 0x07, 0xc0, 0x00, 0x54, 0x07, 0x40, 0x00, 0x94, 0x04, 0xe3, 0x00, 0xb4,
+	0x04, 0x01, 0x00, 0x94, 0x04, 0xeb, 0x00, 0x94, 0x04, 0x73, 0x00, 0x00, 0x04, 0xd5, 0x00, 0x96,
+	0x03, 0xe5, 0x00, 0xad, 0x03, 0x93, 0x00, 0x96, 0x07, 0x8d, 0x01, 0x40, 0x06, 0x39, 0x01, 0x19,
+	0x07, 0x8d, 0x01, 0x40, 0x06, 0x39, 0x01, 0x19, 0x07, 0x8d, 0x01, 0x40, 0x06, 0x39, 0x01, 0x19,
+	0x05, 0x56, 0x01, 0x43, 0x04, 0x73, 0x00, 0x5c, 0x04, 0x73, 0x00, 0xc3, 0x08, 0x00, 0x00, 0xbb,
+	0x08, 0x00, 0x00, 0x6b, 0x04, 0x6b, 0xff, 0xad, 0x02, 0x39, 0x01, 0x37, 0x02, 0x39, 0x01, 0x37,
+	0x02, 0x39, 0x00, 0x3b, 0x02, 0x39, 0x01, 0x37, 0x04, 0x00, 0x01, 0x42, 0x04, 0x00, 0x01, 0x42,
+	0x04, 0x00, 0x00, 0x41, 0x04, 0x73, 0x00, 0xf9, 0x04, 0x73, 0x00, 0x82, 0x02, 0xcd, 0x00, 0xb7,
+	0x08, 0x00, 0x00, 0xb5, 0x08, 0x00, 0x00, 0x19, 0x01, 0xeb, 0x00, 0xf7, 0x03, 0xd5, 0x01, 0x0e,
+	0x02, 0xaa, 0x00, 0xab, 0x02, 0xaa, 0x00, 0x6a, 0x04, 0xd5, 0x00, 0xb4, 0x02, 0xaa, 0x01, 0x40,
+	0x01, 0x56, 0xfe, 0x35, 0x03, 0xf5, 0x01, 0x33, 0x03, 0xf5, 0x01, 0x0e, 0x03, 0xf5, 0x01, 0x29,
+	0x03, 0xf5, 0x01, 0x17, 0x03, 0xf5, 0x01, 0x41, 0x03, 0xf5, 0x01, 0x17, 0x03, 0xf5, 0x01, 0x2f,
+	0x03, 0xf5, 0x01, 0x54, 0x03, 0xf5, 0x01, 0x56, 0x03, 0xf5, 0x01, 0x32, 0x03, 0x2b, 0x01, 0x6f,
+	0x03, 0x2b, 0x00, 0xfd, 0x03, 0xf5, 0x01, 0x2c, 0x03, 0xf5, 0x00, 0x37, 0x03, 0xf5, 0x00, 0x49,
+	0x03, 0xf5, 0xff, 0xfa, 0x03, 0xf5, 0x00, 0x29, 0x03, 0xf5, 0x00, 0x12, 0x03, 0xf5, 0x00, 0x2d,
+	0x03, 0xf5, 0x00, 0x1b, 0x03, 0xf5, 0x00, 0x45, 0x03, 0xf5, 0x00, 0x1b, 0x03, 0xf5, 0x00, 0x33,
+	0x03, 0xf5, 0x00, 0x58, 0x03, 0xf5, 0x00, 0x5a, 0x03, 0xf5, 0x00, 0x36, 0x03, 0x2b, 0x00, 0xa5,
+	0x03, 0x2b, 0x00, 0x33, 0x03, 0xf5, 0x00, 0x30, 0x04, 0x73, 0x00, 0x3c, 0x04, 0x73, 0x00, 0x6f,
+	0x08, 0xc0, 0x00, 0x3d, 0x04, 0x73, 0x00, 0x60, 0x07, 0x15, 0x00, 0x4a, 0x03, 0xe9, 0x00, 0x72,
+	0x08, 0xeb, 0x00, 0xaa, 0x08, 0x00, 0x01, 0xd4, 0x06, 0x25, 0x00, 0x51, 0x05, 0xb6, 0x00, 0x99,
+	0x06, 0xac, 0x00, 0xb3, 0x06, 0xac, 0x00, 0xac, 0x06, 0xac, 0x00, 0xbe, 0x06, 0xac, 0x00, 0x5c,
+	0x08, 0x00, 0x00, 0xda, 0x04, 0x00, 0x00, 0xfc, 0x08, 0x00, 0x01, 0x2d, 0x04, 0x00, 0x00, 0x97,
+	0x08, 0x00, 0x00, 0xda, 0x04, 0x00, 0x00, 0x97, 0x04, 0x00, 0xff, 0xfe, 0x03, 0xf4, 0x00, 0x41,
+	0x04, 0xe5, 0x00, 0x1f, 0x06, 0x96, 0x00, 0xd1, 0x05, 0xb4, 0x00, 0x00, 0x04, 0xac, 0x00, 0xcd,
+	0x01, 0x56, 0xfe, 0xdd, 0x02, 0x39, 0x00, 0xc1, 0x04, 0x64, 0x00, 0x6a, 0x05, 0xb4, 0x00, 0x9a,
+	0x07, 0xd5, 0x01, 0x6a, 0x05, 0xc7, 0x00, 0x93, 0x05, 0xc7, 0x00, 0x93, 0x02, 0x31, 0xff, 0xe5,
+	0x04, 0x64, 0x00, 0x6c, 0x04, 0x64, 0x00, 0x97, 0x04, 0xab, 0x00, 0x7e, 0x04, 0x64, 0x00, 0x46,
+	0x04, 0x64, 0x00, 0x46, 0x04, 0xd5, 0x00, 0x8a, 0x04, 0xac, 0x00, 0x8c, 0x04, 0xcd, 0x01, 0xe5,
+	0x04, 0xcd, 0x00, 0xa2, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x66, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xd5, 0x00, 0x64, 0x04, 0xd5, 0x00, 0x64, 0x02, 0xd6, 0x00, 0x64,
+	0x02, 0xd6, 0x00, 0x64, 0x08, 0x00, 0x00, 0x00, 0x07, 0xeb, 0x00, 0xfa, 0x07, 0xeb, 0x00, 0xfa,
+	0x07, 0xeb, 0x00, 0xfa, 0x07, 0xeb, 0x00, 0xfa, 0x03, 0xf4, 0x00, 0x20, 0x04, 0xd5, 0x00, 0xae,
+	0x04, 0xd5, 0x00, 0xae, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x02, 0xd6, 0x00, 0x42,
+	0x08, 0x2b, 0x01, 0x0c, 0x08, 0x6b, 0x01, 0x2d, 0x07, 0x55, 0x00, 0xad, 0x06, 0x00, 0x00, 0x66,
+	0x06, 0x00, 0x00, 0x2b, 0x04, 0x40, 0x00, 0x32, 0x05, 0x40, 0x00, 0x32, 0x04, 0xc0, 0x00, 0x4a,
+	0x04, 0x15, 0x00, 0x28, 0x04, 0x00, 0x00, 0x31, 0x05, 0xfe, 0x00, 0x64, 0x08, 0x00, 0x00, 0x99,
+	0x04, 0xee, 0x00, 0xa6, 0x05, 0x0e, 0x00, 0xa6, 0x08, 0x00, 0x00, 0x99, 0x04, 0x73, 0x00, 0x9e,
+	0x00, 0x9e, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x54, 0x00, 0x00, 0x00, 0x54,
+	0x00, 0x00, 0x00, 0x54, 0x00, 0x00, 0x00, 0x54, 0x00, 0x00, 0x00, 0xd4, 0x00, 0x00, 0x01, 0x28,
+	0x00, 0x00, 0x02, 0x40, 0x00, 0x00, 0x03, 0x80, 0x00, 0x00, 0x05, 0x24, 0x00, 0x00, 0x06, 0x54,
+	0x00, 0x00, 0x06, 0x8c, 0x00, 0x00, 0x06, 0xd0, 0x00, 0x00, 0x07, 0x14, 0x00, 0x00, 0x07, 0xc4,
+	0x00, 0x00, 0x08, 0x24, 0x00, 0x00, 0x08, 0xa8, 0x00, 0x00, 0x08, 0xe4, 0x00, 0x00, 0x09, 0x30,
+	0x00, 0x00, 0x09, 0x64, 0x00, 0x00, 0x0a, 0x34, 0x00, 0x00, 0x0a, 0xa0, 0x00, 0x00, 0x0b, 0x44,
+	0x00, 0x00, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x9c, 0x00, 0x00, 0x0d, 0x6c, 0x00, 0x00, 0x0e, 0x3c,
+	0x00, 0x00, 0x0e, 0xac, 0x00, 0x00, 0x0f, 0x84, 0x00, 0x00, 0x10, 0x54, 0x00, 0x00, 0x10, 0xf0,
+	0x00, 0x00, 0x11, 0xd8, 0x00, 0x00, 0x12, 0x0c, 0x00, 0x00, 0x12, 0x68, 0x00, 0x00, 0x12, 0x9c,
+	0x00, 0x00, 0x13, 0x64, 0x00, 0x00, 0x16, 0x00, 0x00, 0x00, 0x16, 0x88, 0x00, 0x00, 0x17, 0x5c,
+	0x00, 0x00, 0x17, 0xf8, 0x00, 0x00, 0x18, 0x8c, 0x00, 0x00, 0x19, 0x18, 0x00, 0x00, 0x19, 0x94,
+	0x00, 0x00, 0x1a, 0x68, 0x00, 0x00, 0x1a, 0xec, 0x00, 0x00, 0x1b, 0x68, 0x00, 0x00, 0x1b, 0xec,
+	0x00, 0x00, 0x1c, 0x64, 0x00, 0x00, 0x1c, 0xc4, 0x00, 0x00, 0x1d, 0x50, 0x00, 0x00, 0x1d, 0xc4,
+	0x00, 0x00, 0x1e, 0x80, 0x00, 0x00, 0x1f, 0x1c, 0x00, 0x00, 0x1f, 0xdc, 0x00, 0x00, 0x20, 0x84,
+	0x00, 0x00, 0x21, 0x44, 0x00, 0x00, 0x21, 0xa8, 0x00, 0x00, 0x22, 0x30, 0x00, 0x00, 0x22, 0x94,
+	0x00, 0x00, 0x23, 0x18, 0x00, 0x00, 0x23, 0x98, 0x00, 0x00, 0x24, 0x04, 0x00, 0x00, 0x24, 0x78,
+	0x00, 0x00, 0x24, 0xc4, 0x00, 0x00, 0x25, 0x18, 0x00, 0x00, 0x25, 0x5c, 0x00, 0x00, 0x25, 0xa8,
+	0x00, 0x00, 0x25, 0xe8, 0x00, 0x00, 0x26, 0x28, 0x00, 0x00, 0x26, 0xf4, 0x00, 0x00, 0x27, 0xcc,
+	0x00, 0x00, 0x28, 0x40, 0x00, 0x00, 0x29, 0x1c, 0x00, 0x00, 0x29, 0x9c, 0x00, 0x00, 0x2a, 0x68,
+	0x00, 0x00, 0x2b, 0x74, 0x00, 0x00, 0x2c, 0x08, 0x00, 0x00, 0x2c, 0x84, 0x00, 0x00, 0x2d, 0x00,
+	0x00, 0x00, 0x2d, 0x84, 0x00, 0x00, 0x2d, 0xdc, 0x00, 0x00, 0x2e, 0xbc, 0x00, 0x00, 0x2f, 0x6c,
+	0x00, 0x00, 0x2f, 0xf8, 0x00, 0x00, 0x30, 0xb0, 0x00, 0x00, 0x31, 0x5c, 0x00, 0x00, 0x32, 0x1c,
+	0x00, 0x00, 0x32, 0xac, 0x00, 0x00, 0x33, 0x20, 0x00, 0x00, 0x33, 0xe0, 0x00, 0x00, 0x34, 0x44,
+	0x00, 0x00, 0x34, 0xc8, 0x00, 0x00, 0x35, 0x48, 0x00, 0x00, 0x35, 0x90, 0x00, 0x00, 0x36, 0x08,
+	0x00, 0x00, 0x36, 0xc0, 0x00, 0x00, 0x36, 0xf8, 0x00, 0x00, 0x37, 0xb0, 0x00, 0x00, 0x38, 0x30,
+	0x00, 0x00, 0x38, 0x30, 0x00, 0x00, 0x38, 0x94, 0x00, 0x00, 0x39, 0x74, 0x00, 0x00, 0x3a, 0x34,
+	0x00, 0x00, 0x3b, 0x00, 0x00, 0x00, 0x3b, 0xc0, 0x00, 0x00, 0x3c, 0x18, 0x00, 0x00, 0x3d, 0x0c,
+	0x00, 0x00, 0x3d, 0x68, 0x00, 0x00, 0x3e, 0x70, 0x00, 0x00, 0x3f, 0x20, 0x00, 0x00, 0x3f, 0x70,
+	0x00, 0x00, 0x3f, 0xb8, 0x00, 0x00, 0x3f, 0xf4, 0x00, 0x00, 0x41, 0x04, 0x00, 0x00, 0x41, 0x48,
+	0x00, 0x00, 0x41, 0xd8, 0x00, 0x00, 0x42, 0x8c, 0x00, 0x00, 0x43, 0x14, 0x00, 0x00, 0x43, 0xb4,
+	0x00, 0x00, 0x43, 0xf4, 0x00, 0x00, 0x44, 0xc4, 0x00, 0x00, 0x45, 0x4c, 0x00, 0x00, 0x45, 0x84,
+	0x00, 0x00, 0x45, 0xfc, 0x00, 0x00, 0x46, 0x4c, 0x00, 0x00, 0x46, 0xc8, 0x00, 0x00, 0x47, 0x14,
+	0x00, 0x00, 0x48, 0x58, 0x00, 0x00, 0x49, 0x78, 0x00, 0x00, 0x4b, 0x28, 0x00, 0x00, 0x4b, 0xc8,
+	0x00, 0x00, 0x4c, 0x78, 0x00, 0x00, 0x4d, 0x30, 0x00, 0x00, 0x4d, 0xfc, 0x00, 0x00, 0x4e, 0xf4,
+	0x00, 0x00, 0x4f, 0xc0, 0x00, 0x00, 0x50, 0xbc, 0x00, 0x00, 0x51, 0x84, 0x00, 0x00, 0x52, 0xb8,
+	0x00, 0x00, 0x53, 0x68, 0x00, 0x00, 0x54, 0x28, 0x00, 0x00, 0x54, 0xfc, 0x00, 0x00, 0x55, 0xcc,
+	0x00, 0x00, 0x56, 0x6c, 0x00, 0x00, 0x57, 0x20, 0x00, 0x00, 0x57, 0xe8, 0x00, 0x00, 0x58, 0xac,
+	0x00, 0x00, 0x59, 0x70, 0x00, 0x00, 0x5a, 0x58, 0x00, 0x00, 0x5b, 0x38, 0x00, 0x00, 0x5c, 0x24,
+	0x00, 0x00, 0x5d, 0x24, 0x00, 0x00, 0x5e, 0x50, 0x00, 0x00, 0x5f, 0x4c, 0x00, 0x00, 0x5f, 0x94,
+	0x00, 0x00, 0x60, 0x7c, 0x00, 0x00, 0x61, 0x2c, 0x00, 0x00, 0x61, 0xe4, 0x00, 0x00, 0x62, 0xb0,
+	0x00, 0x00, 0x63, 0x7c, 0x00, 0x00, 0x64, 0x1c, 0x00, 0x00, 0x64, 0xc8, 0x00, 0x00, 0x66, 0x04,
+	0x00, 0x00, 0x67, 0x30, 0x00, 0x00, 0x68, 0x78, 0x00, 0x00, 0x69, 0xd4, 0x00, 0x00, 0x6b, 0x64,
+	0x00, 0x00, 0x6c, 0xbc, 0x00, 0x00, 0x6e, 0x1c, 0x00, 0x00, 0x6f, 0x34, 0x00, 0x00, 0x70, 0x30,
+	0x00, 0x00, 0x71, 0x00, 0x00, 0x00, 0x71, 0xdc, 0x00, 0x00, 0x72, 0xcc, 0x00, 0x00, 0x73, 0xb8,
+	0x00, 0x00, 0x74, 0x48, 0x00, 0x00, 0x74, 0xe8, 0x00, 0x00, 0x75, 0xa0, 0x00, 0x00, 0x76, 0x50,
+	0x00, 0x00, 0x77, 0x4c, 0x00, 0x00, 0x78, 0xb8, 0x00, 0x00, 0x79, 0x8c, 0x00, 0x00, 0x7a, 0x6c,
+	0x00, 0x00, 0x7b, 0x60, 0x00, 0x00, 0x7c, 0xb4, 0x00, 0x00, 0x7d, 0xa4, 0x00, 0x00, 0x7e, 0xc0,
+	0x00, 0x00, 0x7f, 0x90, 0x00, 0x00, 0x80, 0xa8, 0x00, 0x00, 0x81, 0xd4, 0x00, 0x00, 0x83, 0x18,
+	0x00, 0x00, 0x84, 0x54, 0x00, 0x00, 0x84, 0xe8, 0x00, 0x00, 0x85, 0x78, 0x00, 0x00, 0x86, 0x1c,
+	0x00, 0x00, 0x86, 0xd0, 0x00, 0x00, 0x87, 0xd8, 0x00, 0x00, 0x88, 0xa8, 0x00, 0x00, 0x8a, 0x00,
+	0x00, 0x00, 0x8a, 0xec, 0x00, 0x00, 0x8c, 0x34, 0x00, 0x00, 0x8d, 0x00, 0x00, 0x00, 0x8d, 0xcc,
+	0x00, 0x00, 0x8e, 0xa8, 0x00, 0x00, 0x8f, 0x84, 0x00, 0x00, 0x90, 0x4c, 0x00, 0x00, 0x91, 0x10,
+	0x00, 0x00, 0x91, 0xec, 0x00, 0x00, 0x92, 0xc8, 0x00, 0x00, 0x93, 0xa4, 0x00, 0x00, 0x95, 0x08,
+	0x00, 0x00, 0x95, 0xcc, 0x00, 0x00, 0x96, 0xe4, 0x00, 0x00, 0x97, 0x9c, 0x00, 0x00, 0x98, 0x70,
+	0x00, 0x00, 0x99, 0x44, 0x00, 0x00, 0x9a, 0x68, 0x00, 0x00, 0x9b, 0x20, 0x00, 0x00, 0x9b, 0xf4,
+	0x00, 0x00, 0x9c, 0xf4, 0x00, 0x00, 0x9d, 0xe0, 0x00, 0x00, 0x9e, 0xb0, 0x00, 0x00, 0x9f, 0xa0,
+	0x00, 0x00, 0xa0, 0xb4, 0x00, 0x00, 0xa2, 0x4c, 0x00, 0x00, 0xa3, 0x64, 0x00, 0x00, 0xa5, 0x04,
+	0x00, 0x00, 0xa6, 0x04, 0x00, 0x00, 0xa7, 0x80, 0x00, 0x00, 0xa8, 0xb4, 0x00, 0x00, 0xaa, 0x5c,
+	0x00, 0x00, 0xab, 0x24, 0x00, 0x00, 0xab, 0xfc, 0x00, 0x00, 0xac, 0xc0, 0x00, 0x00, 0xad, 0x84,
+	0x00, 0x00, 0xae, 0x6c, 0x00, 0x00, 0xaf, 0x54, 0x00, 0x00, 0xb0, 0x00, 0x00, 0x00, 0xb0, 0x78,
+	0x00, 0x00, 0xb1, 0x38, 0x00, 0x00, 0xb1, 0xf0, 0x00, 0x00, 0xb2, 0xd8, 0x00, 0x00, 0xb3, 0xb0,
+	0x00, 0x00, 0xb4, 0x5c, 0x00, 0x00, 0xb4, 0xa8, 0x00, 0x00, 0xb5, 0x74, 0x00, 0x00, 0xb6, 0x5c,
+	0x00, 0x00, 0xb7, 0x2c, 0x00, 0x00, 0xb7, 0xe4, 0x00, 0x00, 0xb8, 0xbc, 0x00, 0x00, 0xb9, 0xa0,
+	0x00, 0x00, 0xba, 0x1c, 0x00, 0x00, 0xba, 0xac, 0x00, 0x00, 0xbb, 0x28, 0x00, 0x00, 0xbb, 0xec,
+	0x00, 0x00, 0xbc, 0x8c, 0x00, 0x00, 0xbd, 0x34, 0x00, 0x00, 0xbd, 0xbc, 0x00, 0x00, 0xbe, 0x48,
+	0x00, 0x00, 0xbe, 0xc4, 0x00, 0x00, 0xbf, 0x48, 0x00, 0x00, 0xbf, 0xc4, 0x00, 0x00, 0xc0, 0x70,
+	0x00, 0x00, 0xc1, 0x8c, 0x00, 0x00, 0xc2, 0x60, 0x00, 0x00, 0xc3, 0x80, 0x00, 0x00, 0xc4, 0x38,
+	0x00, 0x00, 0xc5, 0x68, 0x00, 0x00, 0xc6, 0x64, 0x00, 0x00, 0xc7, 0x0c, 0x00, 0x00, 0xc7, 0xf8,
+	0x00, 0x00, 0xc8, 0xdc, 0x00, 0x00, 0xc9, 0xb4, 0x00, 0x00, 0xca, 0xb4, 0x00, 0x00, 0xcb, 0xd8,
+	0x00, 0x00, 0xcc, 0xdc, 0x00, 0x00, 0xcd, 0xd4, 0x00, 0x00, 0xcf, 0x04, 0x00, 0x00, 0xd0, 0x24,
+	0x00, 0x00, 0xd1, 0x00, 0x00, 0x00, 0xd2, 0x2c, 0x00, 0x00, 0xd3, 0x38, 0x00, 0x00, 0xd4, 0x70,
+	0x00, 0x00, 0xd5, 0x5c, 0x00, 0x00, 0xd6, 0xa0, 0x00, 0x00, 0xd7, 0x94, 0x00, 0x00, 0xd8, 0x7c,
+	0x00, 0x00, 0xd9, 0x80, 0x00, 0x00, 0xda, 0x78, 0x00, 0x00, 0xdb, 0xd0, 0x00, 0x00, 0xdc, 0xec,
+	0x00, 0x00, 0xdd, 0xf0, 0x00, 0x00, 0xde, 0xe8, 0x00, 0x00, 0xdf, 0xb8, 0x00, 0x00, 0xe0, 0x80,
+	0x00, 0x00, 0xe1, 0x2c, 0x00, 0x00, 0xe1, 0xdc, 0x00, 0x00, 0xe2, 0x74, 0x00, 0x00, 0xe3, 0x14,
+	0x00, 0x00, 0xe4, 0x0c, 0x00, 0x00, 0xe5, 0x88, 0x00, 0x00, 0xe6, 0x3c, 0x00, 0x00, 0xe7, 0x30,
+	0x00, 0x00, 0xe7, 0xfc, 0x00, 0x00, 0xe9, 0x44, 0x00, 0x00, 0xea, 0x4c, 0x00, 0x00, 0xeb, 0x98,
+	0x00, 0x00, 0xec, 0x6c, 0x00, 0x00, 0xed, 0xb0, 0x00, 0x00, 0xee, 0x98, 0x00, 0x00, 0xef, 0xc0,
+	0x00, 0x00, 0xf0, 0x88, 0x00, 0x00, 0xf1, 0x74, 0x00, 0x00, 0xf2, 0x24, 0x00, 0x00, 0xf2, 0xc8,
+	0x00, 0x00, 0xf3, 0x78, 0x00, 0x00, 0xf4, 0x20, 0x00, 0x00, 0xf4, 0xf4, 0x00, 0x00, 0xf5, 0x94,
+	0x00, 0x00, 0xf6, 0x64, 0x00, 0x00, 0xf7, 0x1c, 0x00, 0x00, 0xf8, 0x08, 0x00, 0x00, 0xf8, 0xc0,
+	0x00, 0x00, 0xf9, 0x70, 0x00, 0x00, 0xfa, 0x3c, 0x00, 0x00, 0xfb, 0x94, 0x00, 0x00, 0xfc, 0x54,
+	0x00, 0x00, 0xfd, 0x0c, 0x00, 0x00, 0xfe, 0x0c, 0x00, 0x00, 0xff, 0x04, 0x00, 0x00, 0xff, 0xd0,
+	0x00, 0x01, 0x01, 0x14, 0x00, 0x01, 0x02, 0x0c, 0x00, 0x01, 0x03, 0x84, 0x00, 0x01, 0x04, 0x84,
+	0x00, 0x01, 0x06, 0x08, 0x00, 0x01, 0x07, 0x1c, 0x00, 0x01, 0x08, 0xb8, 0x00, 0x01, 0x09, 0xa8,
+	0x00, 0x01, 0x0b, 0x18, 0x00, 0x01, 0x0c, 0x1c, 0x00, 0x01, 0x0d, 0xa0, 0x00, 0x01, 0x0e, 0x98,
+	0x00, 0x01, 0x10, 0x24, 0x00, 0x01, 0x11, 0x40, 0x00, 0x01, 0x12, 0x6c, 0x00, 0x01, 0x13, 0x88,
+	0x00, 0x01, 0x14, 0x64, 0x00, 0x01, 0x15, 0x2c, 0x00, 0x01, 0x15, 0xec, 0x00, 0x01, 0x16, 0x3c,
+	0x00, 0x01, 0x16, 0x8c, 0x00, 0x01, 0x16, 0xd0, 0x00, 0x01, 0x17, 0x28, 0x00, 0x01, 0x17, 0x6c,
+	0x00, 0x01, 0x17, 0xfc, 0x00, 0x01, 0x18, 0x80, 0x00, 0x01, 0x18, 0xfc, 0x00, 0x01, 0x19, 0x5c,
+	0x00, 0x01, 0x1a, 0x44, 0x00, 0x01, 0x1a, 0x84, 0x00, 0x01, 0x1b, 0x08, 0x00, 0x01, 0x1b, 0xc4,
+	0x00, 0x01, 0x1b, 0xfc, 0x00, 0x01, 0x1c, 0xb4, 0x00, 0x01, 0x1d, 0x6c, 0x00, 0x01, 0x1e, 0x48,
+	0x00, 0x01, 0x1f, 0x64, 0x00, 0x01, 0x20, 0x74, 0x00, 0x01, 0x21, 0x9c, 0x00, 0x01, 0x22, 0x90,
+	0x00, 0x01, 0x23, 0x18, 0x00, 0x01, 0x23, 0xec, 0x00, 0x01, 0x24, 0x40, 0x00, 0x01, 0x24, 0xb0,
+	0x00, 0x01, 0x25, 0x3c, 0x00, 0x01, 0x25, 0xb0, 0x00, 0x01, 0x26, 0x34, 0x00, 0x01, 0x27, 0x18,
+	0x00, 0x01, 0x27, 0x94, 0x00, 0x01, 0x28, 0x0c, 0x00, 0x01, 0x28, 0x70, 0x00, 0x01, 0x28, 0xfc,
+	0x00, 0x01, 0x29, 0x70, 0x00, 0x01, 0x2a, 0x14, 0x00, 0x01, 0x2a, 0xd0, 0x00, 0x01, 0x2b, 0x38,
+	0x00, 0x01, 0x2b, 0xd4, 0x00, 0x01, 0x2c, 0x60, 0x00, 0x01, 0x2c, 0xc4, 0x00, 0x01, 0x2d, 0x58,
+	0x00, 0x01, 0x2e, 0x2c, 0x00, 0x01, 0x2e, 0xac, 0x00, 0x01, 0x2f, 0x7c, 0x00, 0x01, 0x30, 0x44,
+	0x00, 0x01, 0x31, 0x08, 0x00, 0x01, 0x31, 0xdc, 0x00, 0x01, 0x33, 0x60, 0x00, 0x01, 0x34, 0x28,
+	0x00, 0x01, 0x35, 0x2c, 0x00, 0x01, 0x35, 0xb0, 0x00, 0x01, 0x36, 0xb8, 0x00, 0x01, 0x37, 0xfc,
+	0x00, 0x01, 0x38, 0xd0, 0x00, 0x01, 0x39, 0x54, 0x00, 0x01, 0x3a, 0x0c, 0x00, 0x01, 0x3a, 0xa8,
+	0x00, 0x01, 0x3b, 0xc0, 0x00, 0x01, 0x3c, 0x84, 0x00, 0x01, 0x3d, 0x58, 0x00, 0x01, 0x3d, 0xb4,
+	0x00, 0x01, 0x3e, 0x48, 0x00, 0x01, 0x3f, 0x0c, 0x00, 0x01, 0x3f, 0xdc, 0x00, 0x01, 0x40, 0x80,
+	0x00, 0x01, 0x42, 0x04, 0x00, 0x01, 0x42, 0x90, 0x00, 0x01, 0x43, 0x28, 0x00, 0x01, 0x43, 0xe8,
+	0x00, 0x01, 0x44, 0xe8, 0x00, 0x01, 0x45, 0xb8, 0x00, 0x01, 0x46, 0x3c, 0x00, 0x01, 0x46, 0xb0,
+	0x00, 0x01, 0x47, 0xb0, 0x00, 0x01, 0x48, 0x34, 0x00, 0x01, 0x49, 0x00, 0x00, 0x01, 0x49, 0xec,
+	0x00, 0x01, 0x4a, 0xa8, 0x00, 0x01, 0x4b, 0x78, 0x00, 0x01, 0x4c, 0x28, 0x00, 0x01, 0x4c, 0xc0,
+	0x00, 0x01, 0x4d, 0xd8, 0x00, 0x01, 0x4e, 0x88, 0x00, 0x01, 0x4f, 0x58, 0x00, 0x01, 0x50, 0x64,
+	0x00, 0x01, 0x50, 0xe8, 0x00, 0x01, 0x51, 0xa4, 0x00, 0x01, 0x52, 0x64, 0x00, 0x01, 0x52, 0xe0,
+	0x00, 0x01, 0x53, 0xa4, 0x00, 0x01, 0x54, 0x28, 0x00, 0x01, 0x55, 0x3c, 0x00, 0x01, 0x56, 0x04,
+	0x00, 0x01, 0x56, 0xb0, 0x00, 0x01, 0x57, 0xb8, 0x00, 0x01, 0x58, 0x48, 0x00, 0x01, 0x59, 0x4c,
+	0x00, 0x01, 0x59, 0xd0, 0x00, 0x01, 0x5a, 0x58, 0x00, 0x01, 0x5b, 0x0c, 0x00, 0x01, 0x5b, 0xe0,
+	0x00, 0x01, 0x5c, 0x34, 0x00, 0x01, 0x5d, 0x00, 0x00, 0x01, 0x5d, 0x8c, 0x00, 0x01, 0x5e, 0xcc,
+	0x00, 0x01, 0x5f, 0xa0, 0x00, 0x01, 0x60, 0x0c, 0x00, 0x01, 0x60, 0xec, 0x00, 0x01, 0x61, 0xc0,
+	0x00, 0x01, 0x62, 0x4c, 0x00, 0x01, 0x62, 0xd8, 0x00, 0x01, 0x63, 0x5c, 0x00, 0x01, 0x64, 0x18,
+	0x00, 0x01, 0x64, 0x80, 0x00, 0x01, 0x65, 0x1c, 0x00, 0x01, 0x65, 0xb8, 0x00, 0x01, 0x66, 0x1c,
+	0x00, 0x01, 0x66, 0xa4, 0x00, 0x01, 0x67, 0x90, 0x00, 0x01, 0x68, 0x10, 0x00, 0x01, 0x68, 0x98,
+	0x00, 0x01, 0x69, 0x2c, 0x00, 0x01, 0x69, 0xac, 0x00, 0x01, 0x6a, 0x4c, 0x00, 0x01, 0x6b, 0x00,
+	0x00, 0x01, 0x6b, 0xc4, 0x00, 0x01, 0x6c, 0x68, 0x00, 0x01, 0x6d, 0x20, 0x00, 0x01, 0x6e, 0x44,
+	0x00, 0x01, 0x6f, 0x08, 0x00, 0x01, 0x6f, 0xd4, 0x00, 0x01, 0x70, 0x80, 0x00, 0x01, 0x71, 0x50,
+	0x00, 0x01, 0x71, 0xac, 0x00, 0x01, 0x72, 0xf0, 0x00, 0x01, 0x73, 0x70, 0x00, 0x01, 0x74, 0xa4,
+	0x00, 0x01, 0x75, 0x50, 0x00, 0x01, 0x75, 0xc4, 0x00, 0x01, 0x76, 0xac, 0x00, 0x01, 0x77, 0x8c,
+	0x00, 0x01, 0x78, 0x14, 0x00, 0x01, 0x78, 0xa0, 0x00, 0x01, 0x79, 0x20, 0x00, 0x01, 0x79, 0xac,
+	0x00, 0x01, 0x7a, 0x14, 0x00, 0x01, 0x7a, 0xcc, 0x00, 0x01, 0x7b, 0x40, 0x00, 0x01, 0x7b, 0xa8,
+	0x00, 0x01, 0x7c, 0x10, 0x00, 0x01, 0x7e, 0x40, 0x00, 0x01, 0x7e, 0xc0, 0x00, 0x01, 0x7f, 0xb0,
+	0x00, 0x01, 0x80, 0x44, 0x00, 0x01, 0x80, 0xc0, 0x00, 0x01, 0x81, 0xcc, 0x00, 0x01, 0x82, 0x78,
+	0x00, 0x01, 0x83, 0x30, 0x00, 0x01, 0x83, 0xc8, 0x00, 0x01, 0x84, 0x54, 0x00, 0x01, 0x85, 0x70,
+	0x00, 0x01, 0x86, 0x34, 0x00, 0x01, 0x86, 0xd0, 0x00, 0x01, 0x87, 0xbc, 0x00, 0x01, 0x89, 0x0c,
+	0x00, 0x01, 0x89, 0x9c, 0x00, 0x01, 0x8a, 0x28, 0x00, 0x01, 0x8a, 0xb8, 0x00, 0x01, 0x8b, 0x8c,
+	0x00, 0x01, 0x8c, 0x3c, 0x00, 0x01, 0x8d, 0x40, 0x00, 0x01, 0x8e, 0x50, 0x00, 0x01, 0x8f, 0x14,
+	0x00, 0x01, 0x8f, 0xf0, 0x00, 0x01, 0x91, 0x00, 0x00, 0x01, 0x91, 0x98, 0x00, 0x01, 0x92, 0x6c,
+	0x00, 0x01, 0x93, 0x54, 0x00, 0x01, 0x93, 0xc4, 0x00, 0x01, 0x94, 0x54, 0x00, 0x01, 0x94, 0xfc,
+	0x00, 0x01, 0x95, 0xc8, 0x00, 0x01, 0x96, 0x7c, 0x00, 0x01, 0x97, 0x58, 0x00, 0x01, 0x98, 0x20,
+	0x00, 0x01, 0x99, 0x0c, 0x00, 0x01, 0x99, 0xa0, 0x00, 0x01, 0x9a, 0x24, 0x00, 0x01, 0x9a, 0x60,
+	0x00, 0x01, 0x9a, 0x9c, 0x00, 0x01, 0x9a, 0xd8, 0x00, 0x01, 0x9b, 0x3c, 0x00, 0x01, 0x9b, 0x88,
+	0x00, 0x01, 0x9c, 0x2c, 0x00, 0x01, 0x9c, 0x90, 0x00, 0x01, 0x9d, 0x34, 0x00, 0x01, 0x9d, 0xac,
+	0x00, 0x01, 0x9e, 0x1c, 0x00, 0x01, 0x9e, 0xa0, 0x00, 0x01, 0x9f, 0x30, 0x00, 0x01, 0xa0, 0x04,
+	0x00, 0x01, 0xa0, 0x50, 0x00, 0x01, 0xa0, 0xd8, 0x00, 0x01, 0xa2, 0xe4, 0x00, 0x01, 0xa3, 0x1c,
+	0x00, 0x01, 0xa3, 0x70, 0x00, 0x01, 0xa3, 0xa0, 0x00, 0x01, 0xa3, 0xd0, 0x00, 0x01, 0xa4, 0x94,
+	0x00, 0x01, 0xa4, 0xd8, 0x00, 0x01, 0xa5, 0x24, 0x00, 0x01, 0xa5, 0xc8, 0x00, 0x01, 0xa6, 0x34,
+	0x00, 0x01, 0xa7, 0x04, 0x00, 0x01, 0xa7, 0xa4, 0x00, 0x01, 0xa7, 0xf8, 0x00, 0x01, 0xa8, 0xb0,
+	0x00, 0x01, 0xa9, 0x50, 0x00, 0x01, 0xa9, 0xe0, 0x00, 0x01, 0xaa, 0x1c, 0x00, 0x01, 0xaa, 0x78,
+	0x00, 0x01, 0xaa, 0xb8, 0x00, 0x01, 0xaa, 0xf8, 0x00, 0x01, 0xab, 0x84, 0x00, 0x01, 0xac, 0x28,
+	0x00, 0x01, 0xac, 0x78, 0x00, 0x01, 0xad, 0x00, 0x00, 0x01, 0xad, 0xa0, 0x00, 0x01, 0xae, 0x0c,
+	0x00, 0x01, 0xae, 0xdc, 0x00, 0x01, 0xaf, 0x7c, 0x00, 0x01, 0xaf, 0xd0, 0x00, 0x01, 0xb0, 0x88,
+	0x00, 0x01, 0xb1, 0x28, 0x00, 0x01, 0xb1, 0xcc, 0x00, 0x01, 0xb2, 0x04, 0x00, 0x01, 0xb2, 0x60,
+	0x00, 0x01, 0xb2, 0xa0, 0x00, 0x01, 0xb2, 0xe0, 0x00, 0x01, 0xb3, 0x6c, 0x00, 0x01, 0xb4, 0xac,
+	0x00, 0x01, 0xb5, 0x9c, 0x00, 0x01, 0xb8, 0x54, 0x00, 0x01, 0xb9, 0x58, 0x00, 0x01, 0xba, 0x50,
+	0x00, 0x01, 0xbb, 0x38, 0x00, 0x01, 0xbc, 0x10, 0x00, 0x01, 0xbc, 0xb4, 0x00, 0x01, 0xbd, 0x60,
+	0x00, 0x01, 0xbe, 0x34, 0x00, 0x01, 0xbf, 0x90, 0x00, 0x01, 0xc1, 0x58, 0x00, 0x01, 0xc3, 0xc8,
+	0x00, 0x01, 0xc5, 0xb4, 0x00, 0x01, 0xc6, 0x08, 0x00, 0x01, 0xc6, 0x50, 0x00, 0x01, 0xc6, 0xa4,
+	0x00, 0x01, 0xc6, 0xec, 0x00, 0x01, 0xc7, 0x4c, 0x00, 0x01, 0xc7, 0x8c, 0x00, 0x01, 0xc7, 0xfc,
+	0x00, 0x01, 0xc8, 0xa8, 0x00, 0x01, 0xc9, 0x00, 0x00, 0x01, 0xc9, 0x64, 0x00, 0x01, 0xc9, 0xd4,
+	0x00, 0x01, 0xca, 0x10, 0x00, 0x01, 0xca, 0x48, 0x00, 0x01, 0xca, 0x90, 0x00, 0x01, 0xca, 0xdc,
+	0x00, 0x01, 0xcb, 0xdc, 0x00, 0x01, 0xcc, 0x24, 0x00, 0x01, 0xcc, 0x8c, 0x00, 0x01, 0xcc, 0xf4,
+	0x00, 0x01, 0xce, 0x88, 0x00, 0x01, 0xcf, 0x58, 0x00, 0x01, 0xd0, 0x10, 0x00, 0x01, 0xd0, 0x8c,
+	0x00, 0x01, 0xd0, 0xec, 0x00, 0x01, 0xd1, 0x4c, 0x00, 0x01, 0xd1, 0xac, 0x00, 0x01, 0xd1, 0xf4,
+	0x00, 0x01, 0xd2, 0xa4, 0x00, 0x01, 0xd3, 0x50, 0x00, 0x01, 0xd3, 0x88, 0x00, 0x01, 0xd3, 0xb4,
+	0x00, 0x01, 0xd3, 0xf4, 0x00, 0x01, 0xd4, 0x38, 0x00, 0x01, 0xd4, 0x78, 0x00, 0x01, 0xd4, 0xbc,
+	0x00, 0x01, 0xd5, 0x08, 0x00, 0x01, 0xd5, 0x58, 0x00, 0x01, 0xd5, 0xa4, 0x00, 0x01, 0xd5, 0xf0,
+	0x00, 0x01, 0xd6, 0x50, 0x00, 0x01, 0xd6, 0xa8, 0x00, 0x01, 0xd6, 0xf4, 0x00, 0x01, 0xd7, 0x50,
+	0x00, 0x01, 0xd7, 0xa4, 0x00, 0x01, 0xd8, 0x0c, 0x00, 0x01, 0xd8, 0x64, 0x00, 0x01, 0xd8, 0xb8,
+	0x00, 0x01, 0xd9, 0x24, 0x00, 0x01, 0xd9, 0x78, 0x00, 0x01, 0xd9, 0xc8, 0x00, 0x01, 0xda, 0x28,
+	0x00, 0x01, 0xda, 0x80, 0x00, 0x01, 0xda, 0xd0, 0x00, 0x01, 0xdb, 0x3c, 0x00, 0x01, 0xdb, 0x9c,
+	0x00, 0x01, 0xdc, 0x08, 0x00, 0x01, 0xdc, 0x7c, 0x00, 0x01, 0xdc, 0xe0, 0x00, 0x01, 0xdd, 0x48,
+	0x00, 0x01, 0xdd, 0xcc, 0x00, 0x01, 0xde, 0x38, 0x00, 0x01, 0xde, 0x90, 0x00, 0x01, 0xdf, 0x10,
+	0x00, 0x01, 0xdf, 0x78, 0x00, 0x01, 0xdf, 0xd4, 0x00, 0x01, 0xe0, 0x54, 0x00, 0x01, 0xe0, 0xd4,
+	0x00, 0x01, 0xe1, 0x54, 0x00, 0x01, 0xe1, 0xfc, 0x00, 0x01, 0xe2, 0x30, 0x00, 0x01, 0xe2, 0x5c,
+	0x00, 0x01, 0xe2, 0x88, 0x00, 0x01, 0xe2, 0xb4, 0x00, 0x01, 0xe2, 0xe4, 0x00, 0x01, 0xe4, 0xc4,
+	0x00, 0x01, 0xe6, 0x7c, 0x00, 0x01, 0xe7, 0x78, 0x00, 0x01, 0xe7, 0xa8, 0x00, 0x01, 0xe7, 0xfc,
+	0x00, 0x01, 0xe8, 0x38, 0x00, 0x01, 0xe8, 0x8c, 0x00, 0x01, 0xe8, 0xc4, 0x00, 0x01, 0xe8, 0xf4,
+	0x00, 0x01, 0xe9, 0x18, 0x00, 0x01, 0xe9, 0x4c, 0x00, 0x01, 0xe9, 0x70, 0x00, 0x01, 0xe9, 0xac,
+	0x00, 0x01, 0xea, 0x30, 0x00, 0x01, 0xea, 0x7c, 0x00, 0x01, 0xea, 0xe8, 0x00, 0x01, 0xeb, 0x84,
+	0x00, 0x01, 0xec, 0x04, 0x00, 0x01, 0xed, 0x3c, 0x00, 0x01, 0xee, 0x38, 0x00, 0x01, 0xef, 0x34,
+	0x00, 0x01, 0xf0, 0x04, 0x00, 0x01, 0xf0, 0x9c, 0x00, 0x01, 0xf1, 0x10, 0x00, 0x01, 0xf1, 0xa4,
+	0x00, 0x01, 0xf2, 0x08, 0x00, 0x01, 0xf2, 0x40, 0x00, 0x01, 0xf2, 0xd0, 0x00, 0x01, 0xf3, 0x58,
+	0x00, 0x02, 0x0c, 0xe4, 0x00, 0x02, 0x0e, 0xb8, 0x00, 0x02, 0x10, 0x00, 0x00, 0x02, 0x10, 0xbc,
+	0x00, 0x02, 0x11, 0x64, 0x00, 0x02, 0x11, 0xea, 0x00, 0x01, 0x00, 0x00, 0x02, 0xc8, 0x01, 0xa4,
+	0x00, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd8, 0x01, 0x5c, 0x00, 0x8d, 0x00, 0x00,
+	0x01, 0xf4, 0x15, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x19, 0x01, 0x32, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x41, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x41, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0b,
+	0x00, 0x43, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x28, 0x00, 0x4e, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x0e, 0x00, 0x76, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x23, 0x00, 0x84, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, 0x00, 0x0d,
+	0x00, 0xa7, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x15, 0x00, 0xb4, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x09, 0x00, 0x1f, 0x00, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x0a, 0x01, 0x42, 0x00, 0xe8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0f,
+	0x02, 0x2a, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x06, 0x82, 0x02, 0x39, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x0e, 0x08, 0xbb, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x00, 0x00, 0x82, 0x08, 0xc9, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x01, 0x00, 0x04,
+	0x09, 0x4b, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x02, 0x00, 0x16, 0x09, 0x4f, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x03, 0x00, 0x50, 0x09, 0x65, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x04, 0x00, 0x1c, 0x09, 0xb5, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x05, 0x00, 0x46,
+	0x09, 0xd1, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x06, 0x00, 0x1a, 0x0a, 0x17, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x08, 0x00, 0x2a, 0x0a, 0x31, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x09, 0x00, 0x3e, 0x0a, 0x5b, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0a, 0x02, 0x84,
+	0x0a, 0x99, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0c, 0x00, 0x1e, 0x0d, 0x1d, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x0d, 0x0d, 0x04, 0x0d, 0x3b, 0x43, 0x6f, 0x70, 0x79, 0x72, 0x69,
+	0x67, 0x68, 0x74, 0x20, 0x28, 0x63, 0x29, 0x20, 0x32, 0x30, 0x31, 0x36, 0x20, 0x62, 0x79, 0x20,
+	0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73,
+	0x20, 0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x72, 0x69, 0x67, 0x68, 0x74,
+	0x73, 0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x2e, 0x47, 0x6f, 0x42, 0x6f, 0x6c,
+	0x64, 0x20, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x26,
+	0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x49, 0x6e, 0x63, 0x2e, 0x3a, 0x20, 0x47, 0x6f, 0x20, 0x42,
+	0x6f, 0x6c, 0x64, 0x20, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x3a, 0x20, 0x32, 0x30, 0x31, 0x36,
+	0x47, 0x6f, 0x20, 0x42, 0x6f, 0x6c, 0x64, 0x20, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x20, 0x32, 0x2e, 0x30, 0x31, 0x30, 0x3b, 0x20, 0x74, 0x74, 0x66,
+	0x61, 0x75, 0x74, 0x6f, 0x68, 0x69, 0x6e, 0x74, 0x20, 0x28, 0x76, 0x31, 0x2e, 0x38, 0x2e, 0x33,
+	0x29, 0x47, 0x6f, 0x2d, 0x42, 0x6f, 0x6c, 0x64, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x42, 0x69,
+	0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49,
+	0x6e, 0x63, 0x2e, 0x4b, 0x72, 0x69, 0x73, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x61,
+	0x6e, 0x64, 0x20, 0x43, 0x68, 0x61, 0x72, 0x6c, 0x65, 0x73, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c,
+	0x6f, 0x77, 0x47, 0x6f, 0x20, 0x69, 0x73, 0x20, 0x61, 0x20, 0x68, 0x75, 0x6d, 0x61, 0x6e, 0x69,
+	0x73, 0x74, 0x69, 0x63, 0x20, 0x73, 0x61, 0x6e, 0x73, 0x2d, 0x73, 0x65, 0x72, 0x69, 0x66, 0x20,
+	0x66, 0x6f, 0x6e, 0x74, 0x20, 0x66, 0x6f, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x47, 0x6f, 0x20,
+	0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x2e, 0x20, 0x49, 0x74, 0x73, 0x20, 0x78, 0x2d,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x2c, 0x20, 0x73, 0x74, 0x65, 0x6d, 0x20, 0x77, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x2c, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x7a, 0x65,
+	0x72, 0x6f, 0x2c, 0x20, 0x63, 0x61, 0x70, 0x69, 0x74, 0x61, 0x6c, 0x20, 0x4f, 0x2c, 0x20, 0x6c,
+	0x6f, 0x77, 0x65, 0x72, 0x63, 0x61, 0x73, 0x65, 0x20, 0x6c, 0x2c, 0x20, 0x66, 0x69, 0x67, 0x75,
+	0x72, 0x65, 0x20, 0x6f, 0x6e, 0x65, 0x2c, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x63, 0x61, 0x70, 0x69,
+	0x74, 0x61, 0x6c, 0x20, 0x49, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x20, 0x74, 0x68, 0x65,
+	0x20, 0x44, 0x49, 0x4e, 0x20, 0x31, 0x34, 0x35, 0x30, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x6c,
+	0x65, 0x67, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x20, 0x73, 0x74, 0x61, 0x6e, 0x64, 0x61,
+	0x72, 0x64, 0x2e, 0x20, 0x47, 0x6f, 0x27, 0x73, 0x20, 0x57, 0x47, 0x4c, 0x20, 0x63, 0x68, 0x61,
+	0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x20, 0x73, 0x65, 0x74, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75,
+	0x64, 0x65, 0x73, 0x20, 0x55, 0x6e, 0x69, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x4c, 0x61, 0x74, 0x69,
+	0x6e, 0x2c, 0x20, 0x47, 0x72, 0x65, 0x65, 0x6b, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x43, 0x79, 0x72,
+	0x69, 0x6c, 0x6c, 0x69, 0x63, 0x20, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x62, 0x65, 0x74, 0x73, 0x20,
+	0x70, 0x6c, 0x75, 0x73, 0x20, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x20, 0x61, 0x6e, 0x64,
+	0x20, 0x67, 0x72, 0x61, 0x70, 0x68, 0x69, 0x63, 0x61, 0x6c, 0x20, 0x65, 0x6c, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x6c, 0x75, 0x63, 0x69, 0x64, 0x61, 0x66, 0x6f, 0x6e, 0x74, 0x73, 0x2e,
+	0x63, 0x6f, 0x6d, 0x43, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x28, 0x63, 0x29,
+	0x20, 0x32, 0x30, 0x31, 0x36, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20,
+	0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c,
+	0x20, 0x72, 0x69, 0x67, 0x68, 0x74, 0x73, 0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64,
+	0x2e, 0x0a, 0x0a, 0x44, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x20,
+	0x6f, 0x66, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x69, 0x73, 0x20,
+	0x67, 0x6f, 0x76, 0x65, 0x72, 0x6e, 0x65, 0x64, 0x20, 0x62, 0x79, 0x20, 0x74, 0x68, 0x65, 0x20,
+	0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73,
+	0x65, 0x2e, 0x20, 0x49, 0x66, 0x20, 0x79, 0x6f, 0x75, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f, 0x74,
+	0x20, 0x61, 0x67, 0x72, 0x65, 0x65, 0x20, 0x74, 0x6f, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c,
+	0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x2c, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x69, 0x6e,
+	0x67, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72,
+	0x2c, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f, 0x74, 0x20, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x20, 0x6f, 0x72, 0x20, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x20, 0x74, 0x68,
+	0x69, 0x73, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x2e, 0x0a, 0x0a, 0x52, 0x65, 0x64, 0x69, 0x73, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x75, 0x73, 0x65,
+	0x20, 0x69, 0x6e, 0x20, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x62,
+	0x69, 0x6e, 0x61, 0x72, 0x79, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x2c, 0x20, 0x77, 0x69, 0x74,
+	0x68, 0x20, 0x6f, 0x72, 0x20, 0x77, 0x69, 0x74, 0x68, 0x6f, 0x75, 0x74, 0x20, 0x6d, 0x6f, 0x64,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2c, 0x20, 0x61, 0x72, 0x65, 0x20, 0x70,
+	0x65, 0x72, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x20, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x64, 0x20, 0x74, 0x68, 0x61, 0x74, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f,
+	0x77, 0x69, 0x6e, 0x67, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20,
+	0x61, 0x72, 0x65, 0x20, 0x6d, 0x65, 0x74, 0x3a, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x52,
+	0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x6f,
+	0x66, 0x20, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x20, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x6d, 0x75,
+	0x73, 0x74, 0x20, 0x72, 0x65, 0x74, 0x61, 0x69, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x61, 0x62,
+	0x6f, 0x76, 0x65, 0x20, 0x63, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x6e, 0x6f,
+	0x74, 0x69, 0x63, 0x65, 0x2c, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x73, 0x74, 0x20,
+	0x6f, 0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x6e,
+	0x64, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20,
+	0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x2e, 0x0a, 0x0a, 0x20, 0x20, 0x20,
+	0x2a, 0x20, 0x52, 0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x20, 0x69, 0x6e, 0x20, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x20, 0x66, 0x6f, 0x72, 0x6d,
+	0x20, 0x6d, 0x75, 0x73, 0x74, 0x20, 0x72, 0x65, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65, 0x20,
+	0x74, 0x68, 0x65, 0x20, 0x61, 0x62, 0x6f, 0x76, 0x65, 0x20, 0x63, 0x6f, 0x70, 0x79, 0x72, 0x69,
+	0x67, 0x68, 0x74, 0x20, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x2c, 0x20, 0x74, 0x68, 0x69, 0x73,
+	0x20, 0x6c, 0x69, 0x73, 0x74, 0x20, 0x6f, 0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c,
+	0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72,
+	0x20, 0x69, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x61, 0x6e, 0x64, 0x2f, 0x6f, 0x72, 0x20, 0x6f, 0x74, 0x68,
+	0x65, 0x72, 0x20, 0x6d, 0x61, 0x74, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x20, 0x70, 0x72, 0x6f,
+	0x76, 0x69, 0x64, 0x65, 0x64, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64,
+	0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a, 0x20, 0x20,
+	0x20, 0x2a, 0x20, 0x4e, 0x65, 0x69, 0x74, 0x68, 0x65, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6e,
+	0x61, 0x6d, 0x65, 0x20, 0x6f, 0x66, 0x20, 0x47, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x20, 0x49, 0x6e,
+	0x63, 0x2e, 0x20, 0x6e, 0x6f, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x73,
+	0x20, 0x6f, 0x66, 0x20, 0x69, 0x74, 0x73, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75,
+	0x74, 0x6f, 0x72, 0x73, 0x20, 0x6d, 0x61, 0x79, 0x20, 0x62, 0x65, 0x20, 0x75, 0x73, 0x65, 0x64,
+	0x20, 0x74, 0x6f, 0x20, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x73, 0x65, 0x20, 0x6f, 0x72, 0x20, 0x70,
+	0x72, 0x6f, 0x6d, 0x6f, 0x74, 0x65, 0x20, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x73, 0x20,
+	0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x20, 0x66, 0x72, 0x6f, 0x6d, 0x20, 0x74, 0x68, 0x69,
+	0x73, 0x20, 0x73, 0x6f, 0x66, 0x74, 0x77, 0x61, 0x72, 0x65, 0x20, 0x77, 0x69, 0x74, 0x68, 0x6f,
+	0x75, 0x74, 0x20, 0x73, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x20, 0x70, 0x72, 0x69, 0x6f,
+	0x72, 0x20, 0x77, 0x72, 0x69, 0x74, 0x74, 0x65, 0x6e, 0x20, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x45,
+	0x52, 0x3a, 0x20, 0x54, 0x48, 0x49, 0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52, 0x45,
+	0x20, 0x49, 0x53, 0x20, 0x50, 0x52, 0x4f, 0x56, 0x49, 0x44, 0x45, 0x44, 0x20, 0x42, 0x59, 0x20,
+	0x54, 0x48, 0x45, 0x20, 0x43, 0x4f, 0x50, 0x59, 0x52, 0x49, 0x47, 0x48, 0x54, 0x20, 0x48, 0x4f,
+	0x4c, 0x44, 0x45, 0x52, 0x53, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x49,
+	0x42, 0x55, 0x54, 0x4f, 0x52, 0x53, 0x20, 0x22, 0x41, 0x53, 0x20, 0x49, 0x53, 0x22, 0x20, 0x41,
+	0x4e, 0x44, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x45, 0x58, 0x50, 0x52, 0x45, 0x53, 0x53, 0x20, 0x4f,
+	0x52, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x45, 0x44, 0x20, 0x57, 0x41, 0x52, 0x52, 0x41, 0x4e,
+	0x54, 0x49, 0x45, 0x53, 0x2c, 0x20, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x2c,
+	0x20, 0x42, 0x55, 0x54, 0x20, 0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45, 0x44,
+	0x20, 0x54, 0x4f, 0x2c, 0x20, 0x54, 0x48, 0x45, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x45, 0x44,
+	0x20, 0x57, 0x41, 0x52, 0x52, 0x41, 0x4e, 0x54, 0x49, 0x45, 0x53, 0x20, 0x4f, 0x46, 0x20, 0x4d,
+	0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x20, 0x41,
+	0x4e, 0x44, 0x20, 0x46, 0x49, 0x54, 0x4e, 0x45, 0x53, 0x53, 0x20, 0x46, 0x4f, 0x52, 0x20, 0x41,
+	0x20, 0x50, 0x41, 0x52, 0x54, 0x49, 0x43, 0x55, 0x4c, 0x41, 0x52, 0x20, 0x50, 0x55, 0x52, 0x50,
+	0x4f, 0x53, 0x45, 0x20, 0x41, 0x52, 0x45, 0x20, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d,
+	0x45, 0x44, 0x2e, 0x20, 0x49, 0x4e, 0x20, 0x4e, 0x4f, 0x20, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x20,
+	0x53, 0x48, 0x41, 0x4c, 0x4c, 0x20, 0x54, 0x48, 0x45, 0x20, 0x43, 0x4f, 0x50, 0x59, 0x52, 0x49,
+	0x47, 0x48, 0x54, 0x20, 0x4f, 0x57, 0x4e, 0x45, 0x52, 0x20, 0x4f, 0x52, 0x20, 0x43, 0x4f, 0x4e,
+	0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x4f, 0x52, 0x53, 0x20, 0x42, 0x45, 0x20, 0x4c, 0x49, 0x41,
+	0x42, 0x4c, 0x45, 0x20, 0x46, 0x4f, 0x52, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x44, 0x49, 0x52, 0x45,
+	0x43, 0x54, 0x2c, 0x20, 0x49, 0x4e, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x2c, 0x20, 0x49, 0x4e,
+	0x43, 0x49, 0x44, 0x45, 0x4e, 0x54, 0x41, 0x4c, 0x2c, 0x20, 0x53, 0x50, 0x45, 0x43, 0x49, 0x41,
+	0x4c, 0x2c, 0x20, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x59, 0x2c, 0x20, 0x4f, 0x52,
+	0x20, 0x43, 0x4f, 0x4e, 0x53, 0x45, 0x51, 0x55, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x20, 0x44,
+	0x41, 0x4d, 0x41, 0x47, 0x45, 0x53, 0x20, 0x28, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e,
+	0x47, 0x2c, 0x20, 0x42, 0x55, 0x54, 0x20, 0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54,
+	0x45, 0x44, 0x20, 0x54, 0x4f, 0x2c, 0x20, 0x50, 0x52, 0x4f, 0x43, 0x55, 0x52, 0x45, 0x4d, 0x45,
+	0x4e, 0x54, 0x20, 0x4f, 0x46, 0x20, 0x53, 0x55, 0x42, 0x53, 0x54, 0x49, 0x54, 0x55, 0x54, 0x45,
+	0x20, 0x47, 0x4f, 0x4f, 0x44, 0x53, 0x20, 0x4f, 0x52, 0x20, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43,
+	0x45, 0x53, 0x3b, 0x20, 0x4c, 0x4f, 0x53, 0x53, 0x20, 0x4f, 0x46, 0x20, 0x55, 0x53, 0x45, 0x2c,
+	0x20, 0x44, 0x41, 0x54, 0x41, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x50, 0x52, 0x4f, 0x46, 0x49, 0x54,
+	0x53, 0x3b, 0x20, 0x4f, 0x52, 0x20, 0x42, 0x55, 0x53, 0x49, 0x4e, 0x45, 0x53, 0x53, 0x20, 0x49,
+	0x4e, 0x54, 0x45, 0x52, 0x52, 0x55, 0x50, 0x54, 0x49, 0x4f, 0x4e, 0x29, 0x20, 0x48, 0x4f, 0x57,
+	0x45, 0x56, 0x45, 0x52, 0x20, 0x43, 0x41, 0x55, 0x53, 0x45, 0x44, 0x20, 0x41, 0x4e, 0x44, 0x20,
+	0x4f, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x54, 0x48, 0x45, 0x4f, 0x52, 0x59, 0x20, 0x4f, 0x46,
+	0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x2c, 0x20, 0x57, 0x48, 0x45, 0x54,
+	0x48, 0x45, 0x52, 0x20, 0x49, 0x4e, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x41, 0x43, 0x54, 0x2c,
+	0x20, 0x53, 0x54, 0x52, 0x49, 0x43, 0x54, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54,
+	0x59, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x54, 0x4f, 0x52, 0x54, 0x20, 0x28, 0x49, 0x4e, 0x43, 0x4c,
+	0x55, 0x44, 0x49, 0x4e, 0x47, 0x20, 0x4e, 0x45, 0x47, 0x4c, 0x49, 0x47, 0x45, 0x4e, 0x43, 0x45,
+	0x20, 0x4f, 0x52, 0x20, 0x4f, 0x54, 0x48, 0x45, 0x52, 0x57, 0x49, 0x53, 0x45, 0x29, 0x20, 0x41,
+	0x52, 0x49, 0x53, 0x49, 0x4e, 0x47, 0x20, 0x49, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x57, 0x41,
+	0x59, 0x20, 0x4f, 0x55, 0x54, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x55, 0x53, 0x45,
+	0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x49, 0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52,
+	0x45, 0x2c, 0x20, 0x45, 0x56, 0x45, 0x4e, 0x20, 0x49, 0x46, 0x20, 0x41, 0x44, 0x56, 0x49, 0x53,
+	0x45, 0x44, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x50, 0x4f, 0x53, 0x53, 0x49, 0x42,
+	0x49, 0x4c, 0x49, 0x54, 0x59, 0x20, 0x4f, 0x46, 0x20, 0x53, 0x55, 0x43, 0x48, 0x20, 0x44, 0x41,
+	0x4d, 0x41, 0x47, 0x45, 0x2e, 0x47, 0x6f, 0x20, 0x42, 0x6f, 0x6c, 0x64, 0x20, 0x49, 0x74, 0x61,
+	0x6c, 0x69, 0x63, 0x00, 0x43, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x28, 0x00, 0x63, 0x00, 0x29, 0x00, 0x20, 0x00,
+	0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x20, 0x00, 0x62, 0x00, 0x79, 0x00, 0x20, 0x00,
+	0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00,
+	0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x6c, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x76, 0x00,
+	0x65, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x42, 0x00, 0x6f, 0x00, 0x6c, 0x00,
+	0x64, 0x00, 0x20, 0x00, 0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x26, 0x00,
+	0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x49, 0x00, 0x6e, 0x00,
+	0x63, 0x00, 0x2e, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x42, 0x00,
+	0x6f, 0x00, 0x6c, 0x00, 0x64, 0x00, 0x20, 0x00, 0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00,
+	0x69, 0x00, 0x63, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00,
+	0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x42, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x56, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x73, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x32, 0x00, 0x2e, 0x00,
+	0x30, 0x00, 0x31, 0x00, 0x30, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x74, 0x00, 0x74, 0x00, 0x66, 0x00,
+	0x61, 0x00, 0x75, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x68, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x28, 0x00, 0x76, 0x00, 0x31, 0x00, 0x2e, 0x00, 0x38, 0x00, 0x2e, 0x00, 0x33, 0x00,
+	0x29, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x2d, 0x00, 0x42, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x64, 0x00,
+	0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x42, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00,
+	0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x4b, 0x00, 0x72, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x43, 0x00, 0x68, 0x00, 0x61, 0x00, 0x72, 0x00, 0x6c, 0x00,
+	0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00,
+	0x6f, 0x00, 0x77, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x20, 0x00, 0x68, 0x00, 0x75, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00, 0x73, 0x00, 0x61, 0x00, 0x6e, 0x00,
+	0x73, 0x00, 0x2d, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x66, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x6c, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x75, 0x00, 0x61, 0x00, 0x67, 0x00, 0x65, 0x00,
+	0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x78, 0x00, 0x2d, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x74, 0x00, 0x65, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x77, 0x00, 0x65, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00,
+	0x6d, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x7a, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x6f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x63, 0x00, 0x61, 0x00, 0x70, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x6c, 0x00,
+	0x6f, 0x00, 0x77, 0x00, 0x65, 0x00, 0x72, 0x00, 0x63, 0x00, 0x61, 0x00, 0x73, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x6c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x66, 0x00, 0x69, 0x00, 0x67, 0x00, 0x75, 0x00,
+	0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x63, 0x00, 0x61, 0x00, 0x70, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x31, 0x00, 0x34, 0x00, 0x35, 0x00,
+	0x30, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6c, 0x00,
+	0x65, 0x00, 0x67, 0x00, 0x69, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x74, 0x00,
+	0x79, 0x00, 0x20, 0x00, 0x73, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x61, 0x00,
+	0x72, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x27, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x57, 0x00, 0x47, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x63, 0x00, 0x68, 0x00, 0x61, 0x00,
+	0x72, 0x00, 0x61, 0x00, 0x63, 0x00, 0x74, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x73, 0x00,
+	0x65, 0x00, 0x74, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x75, 0x00,
+	0x64, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x55, 0x00, 0x6e, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x6f, 0x00, 0x64, 0x00, 0x65, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x47, 0x00, 0x72, 0x00, 0x65, 0x00, 0x65, 0x00, 0x6b, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x43, 0x00, 0x79, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6c, 0x00,
+	0x70, 0x00, 0x68, 0x00, 0x61, 0x00, 0x62, 0x00, 0x65, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x70, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x73, 0x00, 0x20, 0x00, 0x73, 0x00, 0x79, 0x00, 0x6d, 0x00,
+	0x62, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x67, 0x00, 0x72, 0x00, 0x61, 0x00, 0x70, 0x00, 0x68, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x6d, 0x00, 0x65, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x2e, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x63, 0x00, 0x69, 0x00,
+	0x64, 0x00, 0x61, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x2e, 0x00,
+	0x63, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x43, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x28, 0x00, 0x63, 0x00, 0x29, 0x00,
+	0x20, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00,
+	0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00,
+	0x20, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x72, 0x00, 0x65, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x67, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6e, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x62, 0x00, 0x79, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x67, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x73, 0x00,
+	0x65, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x66, 0x00, 0x20, 0x00, 0x79, 0x00, 0x6f, 0x00,
+	0x75, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x67, 0x00, 0x72, 0x00, 0x65, 0x00, 0x65, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x6f, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00,
+	0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x64, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x67, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00,
+	0x75, 0x00, 0x74, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6d, 0x00,
+	0x6f, 0x00, 0x64, 0x00, 0x69, 0x00, 0x66, 0x00, 0x79, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x2e, 0x00,
+	0x0a, 0x00, 0x0a, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x75, 0x00, 0x73, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x72, 0x00,
+	0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x62, 0x00,
+	0x69, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x72, 0x00, 0x79, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x6d, 0x00, 0x73, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x66, 0x00, 0x69, 0x00, 0x63, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x70, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x69, 0x00, 0x74, 0x00, 0x74, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x69, 0x00, 0x64, 0x00, 0x65, 0x00,
+	0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x61, 0x00, 0x74, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00,
+	0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x74, 0x00, 0x3a, 0x00,
+	0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x52, 0x00,
+	0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00,
+	0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x66, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x72, 0x00, 0x63, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x75, 0x00,
+	0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x74, 0x00, 0x61, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x62, 0x00,
+	0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00,
+	0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00,
+	0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00,
+	0x2a, 0x00, 0x20, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x61, 0x00, 0x72, 0x00, 0x79, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00,
+	0x20, 0x00, 0x6d, 0x00, 0x75, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x75, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x76, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00,
+	0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00,
+	0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x64, 0x00, 0x6f, 0x00, 0x63, 0x00, 0x75, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00,
+	0x64, 0x00, 0x2f, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x74, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x73, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00,
+	0x76, 0x00, 0x69, 0x00, 0x64, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00,
+	0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x65, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6e, 0x00,
+	0x61, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x47, 0x00,
+	0x6f, 0x00, 0x6f, 0x00, 0x67, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00,
+	0x63, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x69, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00,
+	0x74, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x79, 0x00,
+	0x20, 0x00, 0x62, 0x00, 0x65, 0x00, 0x20, 0x00, 0x75, 0x00, 0x73, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x70, 0x00,
+	0x72, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x65, 0x00, 0x20, 0x00, 0x70, 0x00,
+	0x72, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x75, 0x00, 0x63, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x64, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x74, 0x00, 0x77, 0x00, 0x61, 0x00,
+	0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x6f, 0x00,
+	0x75, 0x00, 0x74, 0x00, 0x20, 0x00, 0x73, 0x00, 0x70, 0x00, 0x65, 0x00, 0x63, 0x00, 0x69, 0x00,
+	0x66, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x20, 0x00, 0x77, 0x00, 0x72, 0x00, 0x69, 0x00, 0x74, 0x00, 0x74, 0x00, 0x65, 0x00,
+	0x6e, 0x00, 0x20, 0x00, 0x70, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x73, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00,
+	0x49, 0x00, 0x53, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x45, 0x00,
+	0x52, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00,
+	0x53, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x54, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x56, 0x00,
+	0x49, 0x00, 0x44, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x42, 0x00, 0x59, 0x00, 0x20, 0x00,
+	0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x59, 0x00,
+	0x52, 0x00, 0x49, 0x00, 0x47, 0x00, 0x48, 0x00, 0x54, 0x00, 0x20, 0x00, 0x48, 0x00, 0x4f, 0x00,
+	0x4c, 0x00, 0x44, 0x00, 0x45, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00,
+	0x44, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00,
+	0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x22, 0x00,
+	0x41, 0x00, 0x53, 0x00, 0x20, 0x00, 0x49, 0x00, 0x53, 0x00, 0x22, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x45, 0x00,
+	0x58, 0x00, 0x50, 0x00, 0x52, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x45, 0x00,
+	0x44, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x52, 0x00, 0x41, 0x00, 0x4e, 0x00,
+	0x54, 0x00, 0x49, 0x00, 0x45, 0x00, 0x53, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x49, 0x00, 0x54, 0x00, 0x45, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x45, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x52, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00,
+	0x49, 0x00, 0x45, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x4d, 0x00,
+	0x45, 0x00, 0x52, 0x00, 0x43, 0x00, 0x48, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x41, 0x00,
+	0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x46, 0x00, 0x49, 0x00, 0x54, 0x00, 0x4e, 0x00, 0x45, 0x00,
+	0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x46, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x20, 0x00, 0x50, 0x00, 0x41, 0x00, 0x52, 0x00, 0x54, 0x00, 0x49, 0x00, 0x43, 0x00, 0x55, 0x00,
+	0x4c, 0x00, 0x41, 0x00, 0x52, 0x00, 0x20, 0x00, 0x50, 0x00, 0x55, 0x00, 0x52, 0x00, 0x50, 0x00,
+	0x4f, 0x00, 0x53, 0x00, 0x45, 0x00, 0x20, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x44, 0x00, 0x49, 0x00, 0x53, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x49, 0x00, 0x4d, 0x00,
+	0x45, 0x00, 0x44, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x4e, 0x00,
+	0x4f, 0x00, 0x20, 0x00, 0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x20, 0x00,
+	0x53, 0x00, 0x48, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00,
+	0x45, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x59, 0x00, 0x52, 0x00, 0x49, 0x00,
+	0x47, 0x00, 0x48, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x57, 0x00, 0x4e, 0x00, 0x45, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00,
+	0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x42, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00,
+	0x42, 0x00, 0x4c, 0x00, 0x45, 0x00, 0x20, 0x00, 0x46, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x52, 0x00, 0x45, 0x00,
+	0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x49, 0x00,
+	0x52, 0x00, 0x45, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x43, 0x00, 0x49, 0x00, 0x44, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x41, 0x00, 0x4c, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x53, 0x00, 0x50, 0x00, 0x45, 0x00, 0x43, 0x00, 0x49, 0x00, 0x41, 0x00,
+	0x4c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x45, 0x00, 0x58, 0x00, 0x45, 0x00, 0x4d, 0x00, 0x50, 0x00,
+	0x4c, 0x00, 0x41, 0x00, 0x52, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x53, 0x00, 0x45, 0x00, 0x51, 0x00, 0x55, 0x00,
+	0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x44, 0x00,
+	0x41, 0x00, 0x4d, 0x00, 0x41, 0x00, 0x47, 0x00, 0x45, 0x00, 0x53, 0x00, 0x20, 0x00, 0x28, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x47, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4e, 0x00,
+	0x4f, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x49, 0x00, 0x54, 0x00,
+	0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x50, 0x00,
+	0x52, 0x00, 0x4f, 0x00, 0x43, 0x00, 0x55, 0x00, 0x52, 0x00, 0x45, 0x00, 0x4d, 0x00, 0x45, 0x00,
+	0x4e, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x53, 0x00, 0x55, 0x00,
+	0x42, 0x00, 0x53, 0x00, 0x54, 0x00, 0x49, 0x00, 0x54, 0x00, 0x55, 0x00, 0x54, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x47, 0x00, 0x4f, 0x00, 0x4f, 0x00, 0x44, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x53, 0x00, 0x45, 0x00, 0x52, 0x00, 0x56, 0x00, 0x49, 0x00, 0x43, 0x00,
+	0x45, 0x00, 0x53, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x53, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x54, 0x00, 0x41, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x49, 0x00, 0x54, 0x00,
+	0x53, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00,
+	0x53, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x54, 0x00, 0x45, 0x00, 0x52, 0x00, 0x52, 0x00, 0x55, 0x00, 0x50, 0x00, 0x54, 0x00,
+	0x49, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x29, 0x00, 0x20, 0x00, 0x48, 0x00, 0x4f, 0x00, 0x57, 0x00,
+	0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00, 0x41, 0x00, 0x55, 0x00,
+	0x53, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x54, 0x00,
+	0x48, 0x00, 0x45, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00,
+	0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00,
+	0x54, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x57, 0x00, 0x48, 0x00, 0x45, 0x00, 0x54, 0x00,
+	0x48, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x43, 0x00,
+	0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x41, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x53, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x43, 0x00, 0x54, 0x00, 0x20, 0x00,
+	0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00,
+	0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x54, 0x00, 0x20, 0x00, 0x28, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00,
+	0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x45, 0x00,
+	0x47, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x47, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00,
+	0x52, 0x00, 0x57, 0x00, 0x49, 0x00, 0x53, 0x00, 0x45, 0x00, 0x29, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x52, 0x00, 0x49, 0x00, 0x53, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x49, 0x00, 0x53, 0x00,
+	0x20, 0x00, 0x53, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x54, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00,
+	0x45, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x46, 0x00, 0x20, 0x00, 0x41, 0x00, 0x44, 0x00, 0x56, 0x00, 0x49, 0x00, 0x53, 0x00,
+	0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00,
+	0x45, 0x00, 0x20, 0x00, 0x50, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x53, 0x00, 0x49, 0x00, 0x42, 0x00,
+	0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00,
+	0x20, 0x00, 0x53, 0x00, 0x55, 0x00, 0x43, 0x00, 0x48, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00,
+	0x4d, 0x00, 0x41, 0x00, 0x47, 0x00, 0x45, 0x00, 0x2e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0xff, 0xf5, 0x00, 0x00, 0xfe, 0xd4, 0x00, 0x64, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xc8, 0x00, 0x00,
+	0x02, 0x07, 0x02, 0x08, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05, 0x00, 0x06, 0x00, 0x07, 0x00, 0x08,
+	0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d, 0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10,
+	0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15, 0x00, 0x16, 0x00, 0x17, 0x00, 0x18,
+	0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d, 0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20,
+	0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24, 0x00, 0x25, 0x00, 0x26, 0x00, 0x27, 0x00, 0x28,
+	0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d, 0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30,
+	0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35, 0x00, 0x36, 0x00, 0x37, 0x00, 0x38,
+	0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d, 0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40,
+	0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44, 0x00, 0x45, 0x00, 0x46, 0x00, 0x47, 0x00, 0x48,
+	0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50,
+	0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54, 0x00, 0x55, 0x00, 0x56, 0x00, 0x57, 0x00, 0x58,
+	0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d, 0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60,
+	0x00, 0x61, 0x02, 0x09, 0x00, 0xa3, 0x00, 0x84, 0x00, 0x85, 0x00, 0xbd, 0x00, 0x96, 0x00, 0xe8,
+	0x00, 0x86, 0x00, 0x8e, 0x00, 0x8b, 0x00, 0x9d, 0x00, 0xa9, 0x00, 0xa4, 0x02, 0x0a, 0x00, 0x8a,
+	0x00, 0xda, 0x00, 0x83, 0x00, 0x93, 0x02, 0x0b, 0x02, 0x0c, 0x00, 0x8d, 0x00, 0x97, 0x00, 0x88,
+	0x00, 0xc3, 0x00, 0xde, 0x02, 0x0d, 0x00, 0x9e, 0x00, 0xaa, 0x00, 0xf5, 0x00, 0xf4, 0x00, 0xf6,
+	0x00, 0xa2, 0x00, 0xad, 0x00, 0xc9, 0x00, 0xc7, 0x00, 0xae, 0x00, 0x62, 0x00, 0x63, 0x00, 0x90,
+	0x00, 0x64, 0x00, 0xcb, 0x00, 0x65, 0x00, 0xc8, 0x00, 0xca, 0x00, 0xcf, 0x00, 0xcc, 0x00, 0xcd,
+	0x00, 0xce, 0x00, 0xe9, 0x00, 0x66, 0x00, 0xd3, 0x00, 0xd0, 0x00, 0xd1, 0x00, 0xaf, 0x00, 0x67,
+	0x00, 0xf0, 0x00, 0x91, 0x00, 0xd6, 0x00, 0xd4, 0x00, 0xd5, 0x00, 0x68, 0x00, 0xeb, 0x00, 0xed,
+	0x00, 0x89, 0x00, 0x6a, 0x00, 0x69, 0x00, 0x6b, 0x00, 0x6d, 0x00, 0x6c, 0x00, 0x6e, 0x00, 0xa0,
+	0x00, 0x6f, 0x00, 0x71, 0x00, 0x70, 0x00, 0x72, 0x00, 0x73, 0x00, 0x75, 0x00, 0x74, 0x00, 0x76,
+	0x00, 0x77, 0x00, 0xea, 0x00, 0x78, 0x00, 0x7a, 0x00, 0x79, 0x00, 0x7b, 0x00, 0x7d, 0x00, 0x7c,
+	0x00, 0xb8, 0x00, 0xa1, 0x00, 0x7f, 0x00, 0x7e, 0x00, 0x80, 0x00, 0x81, 0x00, 0xec, 0x00, 0xee,
+	0x00, 0xba, 0x01, 0x06, 0x01, 0x88, 0x01, 0x03, 0x01, 0x84, 0x01, 0x07, 0x01, 0x8a, 0x00, 0xfd,
+	0x00, 0xfe, 0x01, 0x0a, 0x01, 0x95, 0x01, 0x0b, 0x01, 0x96, 0x00, 0xff, 0x01, 0x00, 0x01, 0x0d,
+	0x01, 0x9a, 0x01, 0x0e, 0x01, 0x01, 0x01, 0x12, 0x01, 0xa3, 0x01, 0x0f, 0x01, 0xa0, 0x01, 0x11,
+	0x01, 0xa2, 0x01, 0x14, 0x01, 0xa5, 0x01, 0x10, 0x01, 0xa1, 0x01, 0x1b, 0x01, 0xb2, 0x00, 0xf8,
+	0x00, 0xf9, 0x01, 0x1c, 0x01, 0xb3, 0x02, 0x0e, 0x02, 0x0f, 0x01, 0x22, 0x01, 0xb6, 0x01, 0x21,
+	0x01, 0xb5, 0x01, 0x2a, 0x01, 0xc7, 0x01, 0x25, 0x01, 0xbb, 0x01, 0x24, 0x01, 0xb9, 0x01, 0x26,
+	0x01, 0xc2, 0x00, 0xfa, 0x00, 0xd7, 0x01, 0x23, 0x01, 0xba, 0x01, 0x2b, 0x01, 0xc8, 0x02, 0x10,
+	0x02, 0x11, 0x01, 0xca, 0x01, 0x2d, 0x01, 0xcb, 0x02, 0x12, 0x02, 0x13, 0x01, 0x2f, 0x01, 0xcd,
+	0x01, 0x30, 0x01, 0xce, 0x00, 0xe2, 0x00, 0xe3, 0x01, 0x32, 0x01, 0xd7, 0x02, 0x14, 0x02, 0x15,
+	0x01, 0x33, 0x01, 0xd9, 0x01, 0xd8, 0x01, 0x13, 0x01, 0xa4, 0x01, 0x37, 0x01, 0xdd, 0x01, 0x35,
+	0x01, 0xdb, 0x01, 0x36, 0x01, 0xdc, 0x00, 0xb0, 0x00, 0xb1, 0x01, 0x3f, 0x01, 0xea, 0x02, 0x16,
+	0x02, 0x17, 0x01, 0x40, 0x01, 0xeb, 0x01, 0x6a, 0x01, 0xef, 0x01, 0x6b, 0x01, 0xf0, 0x00, 0xfb,
+	0x00, 0xfc, 0x00, 0xe4, 0x00, 0xe5, 0x02, 0x18, 0x02, 0x19, 0x01, 0x6f, 0x01, 0xfb, 0x01, 0x6e,
+	0x01, 0xfa, 0x01, 0x79, 0x02, 0xc4, 0x01, 0x73, 0x02, 0x05, 0x01, 0x71, 0x02, 0x03, 0x01, 0x78,
+	0x02, 0xc3, 0x01, 0x72, 0x02, 0x04, 0x01, 0x74, 0x02, 0xbd, 0x01, 0x7b, 0x02, 0xc6, 0x01, 0x7f,
+	0x02, 0xca, 0x00, 0xbb, 0x01, 0x81, 0x02, 0xcc, 0x01, 0x82, 0x02, 0xcd, 0x00, 0xe6, 0x00, 0xe7,
+	0x01, 0xd1, 0x00, 0xa6, 0x02, 0x1a, 0x02, 0x1b, 0x02, 0x1c, 0x02, 0x1d, 0x02, 0x1e, 0x02, 0x1f,
+	0x02, 0x20, 0x02, 0x21, 0x02, 0x22, 0x02, 0x23, 0x02, 0x24, 0x02, 0x25, 0x02, 0x26, 0x02, 0x27,
+	0x02, 0x28, 0x02, 0x29, 0x01, 0x08, 0x01, 0x8b, 0x01, 0x02, 0x01, 0x85, 0x01, 0x3b, 0x01, 0xe5,
+	0x02, 0x2a, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0x2d, 0x00, 0xd8, 0x00, 0xe1, 0x02, 0x2e, 0x00, 0xdb,
+	0x00, 0xdc, 0x00, 0xdd, 0x00, 0xe0, 0x00, 0xd9, 0x00, 0xdf, 0x02, 0x2f, 0x01, 0xfe, 0x01, 0x9d,
+	0x01, 0x05, 0x01, 0x89, 0x01, 0x16, 0x01, 0x18, 0x01, 0x29, 0x01, 0x3a, 0x01, 0x77, 0x01, 0x38,
+	0x01, 0xc5, 0x01, 0x04, 0x01, 0x09, 0x01, 0x1a, 0x02, 0x30, 0x01, 0x15, 0x01, 0x83, 0x01, 0x17,
+	0x01, 0x70, 0x01, 0x27, 0x01, 0x2c, 0x01, 0x2e, 0x01, 0x31, 0x01, 0x34, 0x01, 0x7e, 0x01, 0x39,
+	0x01, 0x3d, 0x01, 0x41, 0x01, 0x6c, 0x01, 0x6d, 0x01, 0x75, 0x01, 0x3c, 0x01, 0x0c, 0x01, 0x3e,
+	0x02, 0x31, 0x01, 0x28, 0x01, 0x76, 0x01, 0x87, 0x01, 0xa7, 0x01, 0xab, 0x01, 0xc6, 0x02, 0xc1,
+	0x01, 0x86, 0x01, 0x93, 0x01, 0xb1, 0x01, 0x9b, 0x01, 0xa6, 0x02, 0xd0, 0x01, 0xaa, 0x01, 0xfc,
+	0x01, 0xc3, 0x01, 0xc9, 0x01, 0xcc, 0x02, 0x32, 0x01, 0xda, 0x02, 0xc9, 0x01, 0xe0, 0x00, 0x9b,
+	0x01, 0xed, 0x01, 0xf5, 0x01, 0xf4, 0x01, 0xf9, 0x02, 0xbf, 0x01, 0xe7, 0x01, 0x97, 0x01, 0xe8,
+	0x01, 0xde, 0x01, 0xc4, 0x02, 0xc0, 0x01, 0xe1, 0x02, 0xc2, 0x01, 0xdf, 0x02, 0x33, 0x02, 0x34,
+	0x02, 0x35, 0x02, 0x36, 0x02, 0x37, 0x02, 0x38, 0x02, 0x39, 0x02, 0x3a, 0x02, 0x3b, 0x02, 0x3c,
+	0x02, 0x3d, 0x02, 0x3e, 0x02, 0x3f, 0x02, 0x40, 0x02, 0x41, 0x02, 0x42, 0x02, 0x43, 0x02, 0x44,
+	0x02, 0x45, 0x02, 0x46, 0x02, 0x47, 0x02, 0x48, 0x02, 0x49, 0x02, 0x4a, 0x02, 0x4b, 0x02, 0x4c,
+	0x02, 0x4d, 0x02, 0x4e, 0x02, 0x4f, 0x02, 0x50, 0x02, 0x51, 0x02, 0x52, 0x02, 0x53, 0x02, 0x54,
+	0x02, 0x55, 0x02, 0x56, 0x02, 0x57, 0x02, 0x58, 0x02, 0x59, 0x02, 0x5a, 0x02, 0x5b, 0x02, 0x5c,
+	0x02, 0x5d, 0x02, 0x5e, 0x02, 0x5f, 0x02, 0x60, 0x02, 0x61, 0x02, 0x62, 0x02, 0x63, 0x02, 0x64,
+	0x02, 0x65, 0x02, 0x66, 0x02, 0x67, 0x02, 0x68, 0x02, 0x69, 0x02, 0x6a, 0x02, 0x6b, 0x02, 0x6c,
+	0x02, 0x6d, 0x02, 0x6e, 0x02, 0x6f, 0x02, 0x70, 0x02, 0x71, 0x02, 0x72, 0x02, 0x73, 0x02, 0x74,
+	0x02, 0x75, 0x02, 0x76, 0x02, 0x77, 0x02, 0x78, 0x02, 0x79, 0x02, 0x7a, 0x02, 0x7b, 0x02, 0x7c,
+	0x02, 0x7d, 0x02, 0x7e, 0x02, 0x7f, 0x02, 0x80, 0x02, 0x81, 0x02, 0x82, 0x02, 0x83, 0x02, 0x84,
+	0x02, 0x85, 0x02, 0x86, 0x02, 0x87, 0x02, 0x88, 0x02, 0x89, 0x02, 0x8a, 0x02, 0x8b, 0x02, 0x8c,
+	0x02, 0x8d, 0x02, 0x8e, 0x02, 0x8f, 0x02, 0x90, 0x02, 0x91, 0x02, 0x92, 0x02, 0x93, 0x02, 0x94,
+	0x01, 0x7d, 0x02, 0xc8, 0x01, 0x7a, 0x02, 0xc5, 0x01, 0x7c, 0x02, 0xc7, 0x01, 0x80, 0x02, 0xcb,
+	0x00, 0xb2, 0x00, 0xb3, 0x02, 0x95, 0x02, 0x06, 0x00, 0xb6, 0x00, 0xb7, 0x00, 0xc4, 0x01, 0xe9,
+	0x00, 0xb4, 0x00, 0xb5, 0x00, 0xc5, 0x00, 0x82, 0x00, 0xc2, 0x00, 0x87, 0x00, 0xab, 0x00, 0xc6,
+	0x01, 0xd4, 0x01, 0xf1, 0x00, 0xbe, 0x00, 0xbf, 0x01, 0xac, 0x02, 0x96, 0x00, 0xbc, 0x02, 0x97,
+	0x02, 0x98, 0x02, 0x99, 0x02, 0x9a, 0x02, 0x9b, 0x02, 0x9c, 0x02, 0x9d, 0x02, 0x9e, 0x02, 0x9f,
+	0x02, 0xa0, 0x02, 0xa1, 0x02, 0xa2, 0x02, 0xa3, 0x02, 0xa4, 0x02, 0xa5, 0x02, 0xa6, 0x02, 0xa7,
+	0x02, 0xa8, 0x02, 0xa9, 0x02, 0xaa, 0x02, 0xab, 0x02, 0xac, 0x02, 0xad, 0x02, 0xae, 0x02, 0xaf,
+	0x02, 0xb0, 0x02, 0xb1, 0x02, 0xb2, 0x02, 0xb3, 0x00, 0xf7, 0x01, 0xd0, 0x01, 0xe6, 0x01, 0x19,
+	0x02, 0xb4, 0x02, 0xb5, 0x02, 0xb6, 0x00, 0x8c, 0x00, 0x9f, 0x01, 0xa9, 0x01, 0xe2, 0x01, 0xfd,
+	0x01, 0xb0, 0x01, 0xf2, 0x01, 0x8e, 0x01, 0x90, 0x01, 0x8f, 0x01, 0x8d, 0x01, 0x8c, 0x01, 0x91,
+	0x01, 0x92, 0x00, 0x98, 0x00, 0xa8, 0x00, 0x9a, 0x00, 0x99, 0x00, 0xef, 0x02, 0xb7, 0x02, 0xb8,
+	0x00, 0xa5, 0x00, 0x92, 0x01, 0xe4, 0x01, 0xbe, 0x02, 0xbc, 0x00, 0x9c, 0x00, 0xa7, 0x00, 0x8f,
+	0x01, 0xa8, 0x00, 0x94, 0x00, 0x95, 0x01, 0xb8, 0x01, 0xec, 0x01, 0xbd, 0x01, 0xbc, 0x01, 0x4b,
+	0x01, 0x4c, 0x01, 0x42, 0x01, 0x44, 0x01, 0x43, 0x01, 0x45, 0x01, 0x49, 0x01, 0x4a, 0x01, 0x47,
+	0x01, 0x48, 0x01, 0x46, 0x01, 0x5e, 0x01, 0x52, 0x01, 0x66, 0x01, 0x67, 0x01, 0x5a, 0x01, 0x50,
+	0x01, 0x4f, 0x01, 0x53, 0x01, 0x65, 0x01, 0x64, 0x01, 0x59, 0x01, 0x56, 0x01, 0x55, 0x01, 0x54,
+	0x01, 0x57, 0x01, 0x58, 0x01, 0x5d, 0x01, 0x4d, 0x01, 0x4e, 0x01, 0x51, 0x01, 0x62, 0x01, 0x63,
+	0x01, 0x5c, 0x01, 0x60, 0x01, 0x61, 0x01, 0x5b, 0x01, 0x69, 0x01, 0x68, 0x01, 0x5f, 0x02, 0xbe,
+	0x01, 0x9f, 0x01, 0x94, 0x01, 0xcf, 0x01, 0xee, 0x01, 0xd2, 0x01, 0xf3, 0x01, 0x9e, 0x01, 0xae,
+	0x01, 0x20, 0x01, 0x1e, 0x01, 0x1f, 0x01, 0xaf, 0x02, 0x02, 0x02, 0x01, 0x01, 0xff, 0x02, 0x00,
+	0x00, 0xb9, 0x01, 0x98, 0x01, 0x1d, 0x01, 0xbf, 0x01, 0xc0, 0x01, 0xe3, 0x01, 0xf6, 0x01, 0xc1,
+	0x01, 0xf8, 0x01, 0xad, 0x01, 0xd3, 0x01, 0xf7, 0x01, 0x99, 0x01, 0xb7, 0x01, 0x9c, 0x01, 0xd5,
+	0x01, 0xd6, 0x01, 0xb4, 0x02, 0xb9, 0x02, 0xba, 0x02, 0xbb, 0x02, 0xce, 0x02, 0xcf, 0x07, 0x41,
+	0x45, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x41, 0x62, 0x72, 0x65, 0x76, 0x65, 0x05, 0x41, 0x6c,
+	0x70, 0x68, 0x61, 0x0a, 0x41, 0x6c, 0x70, 0x68, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x41,
+	0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x41, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x0a, 0x41,
+	0x72, 0x69, 0x6e, 0x67, 0x61, 0x63, 0x75, 0x74, 0x65, 0x04, 0x42, 0x65, 0x74, 0x61, 0x0b, 0x43,
+	0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x43, 0x64, 0x6f, 0x74, 0x61,
+	0x63, 0x63, 0x65, 0x6e, 0x74, 0x03, 0x43, 0x68, 0x69, 0x06, 0x44, 0x63, 0x61, 0x72, 0x6f, 0x6e,
+	0x06, 0x44, 0x63, 0x72, 0x6f, 0x61, 0x74, 0x06, 0x45, 0x62, 0x72, 0x65, 0x76, 0x65, 0x06, 0x45,
+	0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0a, 0x45, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74,
+	0x07, 0x45, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x03, 0x45, 0x6e, 0x67, 0x07, 0x45, 0x6f, 0x67,
+	0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x45, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0c, 0x45, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x03, 0x45, 0x74, 0x61, 0x08, 0x45, 0x74,
+	0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x04, 0x45, 0x75, 0x72, 0x6f, 0x05, 0x47, 0x61, 0x6d, 0x6d,
+	0x61, 0x0b, 0x47, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x47, 0x64,
+	0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x06, 0x48, 0x31, 0x38, 0x35, 0x33, 0x33, 0x06,
+	0x48, 0x31, 0x38, 0x35, 0x34, 0x33, 0x06, 0x48, 0x31, 0x38, 0x35, 0x35, 0x31, 0x06, 0x48, 0x32,
+	0x32, 0x30, 0x37, 0x33, 0x04, 0x48, 0x62, 0x61, 0x72, 0x0b, 0x48, 0x63, 0x69, 0x72, 0x63, 0x75,
+	0x6d, 0x66, 0x6c, 0x65, 0x78, 0x02, 0x49, 0x4a, 0x06, 0x49, 0x62, 0x72, 0x65, 0x76, 0x65, 0x07,
+	0x49, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x49, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x04,
+	0x49, 0x6f, 0x74, 0x61, 0x0c, 0x49, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69,
+	0x73, 0x09, 0x49, 0x6f, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x06, 0x49, 0x74, 0x69, 0x6c,
+	0x64, 0x65, 0x0b, 0x4a, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x4b,
+	0x61, 0x70, 0x70, 0x61, 0x06, 0x4c, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x4c, 0x61, 0x6d, 0x62,
+	0x64, 0x61, 0x06, 0x4c, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x04, 0x4c, 0x64, 0x6f, 0x74, 0x02, 0x4d,
+	0x75, 0x06, 0x4e, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x4e, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x02,
+	0x4e, 0x75, 0x06, 0x4f, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x4f, 0x68, 0x75, 0x6e, 0x67, 0x61,
+	0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x4f, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0a,
+	0x4f, 0x6d, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x4f, 0x6d, 0x69, 0x63, 0x72,
+	0x6f, 0x6e, 0x0c, 0x4f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0b,
+	0x4f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x61, 0x63, 0x75, 0x74, 0x65, 0x03, 0x50, 0x68, 0x69, 0x02,
+	0x50, 0x69, 0x03, 0x50, 0x73, 0x69, 0x06, 0x52, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x52, 0x63,
+	0x61, 0x72, 0x6f, 0x6e, 0x03, 0x52, 0x68, 0x6f, 0x08, 0x53, 0x46, 0x30, 0x31, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x30, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x33, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30,
+	0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x30, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x38, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x30, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x30, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x39,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x32, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x32, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x34, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x32, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x36, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32,
+	0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x33, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x38, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x33, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x30, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x32,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x34, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x34, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x37, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x34, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x39, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35,
+	0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x35, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x34, 0x30, 0x30, 0x30, 0x30,
+	0x06, 0x53, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x53, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66,
+	0x6c, 0x65, 0x78, 0x05, 0x53, 0x69, 0x67, 0x6d, 0x61, 0x03, 0x54, 0x61, 0x75, 0x04, 0x54, 0x62,
+	0x61, 0x72, 0x06, 0x54, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x54, 0x68, 0x65, 0x74, 0x61, 0x06,
+	0x55, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x55, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d,
+	0x6c, 0x61, 0x75, 0x74, 0x07, 0x55, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x55, 0x6f, 0x67,
+	0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0f, 0x55, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x0c, 0x55, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x05, 0x55, 0x72, 0x69, 0x6e, 0x67, 0x06,
+	0x55, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x06, 0x57, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x57, 0x63,
+	0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x09, 0x57, 0x64, 0x69, 0x65, 0x72, 0x65,
+	0x73, 0x69, 0x73, 0x06, 0x57, 0x67, 0x72, 0x61, 0x76, 0x65, 0x02, 0x58, 0x69, 0x0b, 0x59, 0x63,
+	0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x59, 0x67, 0x72, 0x61, 0x76, 0x65,
+	0x06, 0x5a, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0a, 0x5a, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65,
+	0x6e, 0x74, 0x04, 0x5a, 0x65, 0x74, 0x61, 0x06, 0x61, 0x62, 0x72, 0x65, 0x76, 0x65, 0x07, 0x61,
+	0x65, 0x61, 0x63, 0x75, 0x74, 0x65, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x0a, 0x61, 0x6c, 0x70,
+	0x68, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x61, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x09,
+	0x61, 0x6e, 0x6f, 0x74, 0x65, 0x6c, 0x65, 0x69, 0x61, 0x07, 0x61, 0x6f, 0x67, 0x6f, 0x6e, 0x65,
+	0x6b, 0x0a, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x61, 0x63, 0x75, 0x74, 0x65, 0x09, 0x61, 0x72, 0x72,
+	0x6f, 0x77, 0x62, 0x6f, 0x74, 0x68, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x64, 0x6f, 0x77, 0x6e,
+	0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x6c, 0x65, 0x66, 0x74, 0x0a, 0x61, 0x72, 0x72, 0x6f, 0x77,
+	0x72, 0x69, 0x67, 0x68, 0x74, 0x07, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x09, 0x61, 0x72,
+	0x72, 0x6f, 0x77, 0x75, 0x70, 0x64, 0x6e, 0x0c, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x64,
+	0x6e, 0x62, 0x73, 0x65, 0x04, 0x62, 0x65, 0x74, 0x61, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x0b,
+	0x63, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x63, 0x64, 0x6f, 0x74,
+	0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x03, 0x63, 0x68, 0x69, 0x06, 0x63, 0x69, 0x72, 0x63, 0x6c,
+	0x65, 0x04, 0x63, 0x6c, 0x75, 0x62, 0x06, 0x64, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x64, 0x65,
+	0x6c, 0x74, 0x61, 0x07, 0x64, 0x69, 0x61, 0x6d, 0x6f, 0x6e, 0x64, 0x0d, 0x64, 0x69, 0x65, 0x72,
+	0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x64, 0x6b, 0x73, 0x68, 0x61, 0x64,
+	0x65, 0x07, 0x64, 0x6e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x06, 0x65, 0x62, 0x72, 0x65, 0x76, 0x65,
+	0x06, 0x65, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0a, 0x65, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65,
+	0x6e, 0x74, 0x07, 0x65, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x03, 0x65, 0x6e, 0x67, 0x07, 0x65,
+	0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x65, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0c, 0x65,
+	0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0b, 0x65, 0x71, 0x75, 0x69,
+	0x76, 0x61, 0x6c, 0x65, 0x6e, 0x63, 0x65, 0x09, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65,
+	0x64, 0x03, 0x65, 0x74, 0x61, 0x08, 0x65, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x65,
+	0x78, 0x63, 0x6c, 0x61, 0x6d, 0x64, 0x62, 0x6c, 0x06, 0x66, 0x65, 0x6d, 0x61, 0x6c, 0x65, 0x09,
+	0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x62, 0x6f, 0x78, 0x0a, 0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64,
+	0x72, 0x65, 0x63, 0x74, 0x0b, 0x66, 0x69, 0x76, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73,
+	0x05, 0x67, 0x61, 0x6d, 0x6d, 0x61, 0x0b, 0x67, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c,
+	0x65, 0x78, 0x0a, 0x67, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x06, 0x67, 0x6f,
+	0x70, 0x68, 0x65, 0x72, 0x04, 0x68, 0x62, 0x61, 0x72, 0x0b, 0x68, 0x63, 0x69, 0x72, 0x63, 0x75,
+	0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x68, 0x65, 0x61, 0x72, 0x74, 0x05, 0x68, 0x6f, 0x75, 0x73,
+	0x65, 0x06, 0x69, 0x62, 0x72, 0x65, 0x76, 0x65, 0x02, 0x69, 0x6a, 0x07, 0x69, 0x6d, 0x61, 0x63,
+	0x72, 0x6f, 0x6e, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x61, 0x6c, 0x62, 0x74, 0x0a, 0x69,
+	0x6e, 0x74, 0x65, 0x67, 0x72, 0x61, 0x6c, 0x74, 0x70, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x73,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x09, 0x69, 0x6e, 0x76, 0x62, 0x75, 0x6c, 0x6c, 0x65, 0x74,
+	0x09, 0x69, 0x6e, 0x76, 0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x0c, 0x69, 0x6e, 0x76, 0x73, 0x6d,
+	0x69, 0x6c, 0x65, 0x66, 0x61, 0x63, 0x65, 0x07, 0x69, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x04,
+	0x69, 0x6f, 0x74, 0x61, 0x0c, 0x69, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69,
+	0x73, 0x11, 0x69, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x09, 0x69, 0x6f, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x06, 0x69, 0x74,
+	0x69, 0x6c, 0x64, 0x65, 0x0b, 0x6a, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78,
+	0x05, 0x6b, 0x61, 0x70, 0x70, 0x61, 0x0c, 0x6b, 0x67, 0x72, 0x65, 0x65, 0x6e, 0x6c, 0x61, 0x6e,
+	0x64, 0x69, 0x63, 0x06, 0x6c, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x6c, 0x61, 0x6d, 0x62, 0x64,
+	0x61, 0x06, 0x6c, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x04, 0x6c, 0x64, 0x6f, 0x74, 0x07, 0x6c, 0x66,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x04, 0x6c, 0x69, 0x72, 0x61, 0x05, 0x6c, 0x6f, 0x6e, 0x67, 0x73,
+	0x07, 0x6c, 0x74, 0x73, 0x68, 0x61, 0x64, 0x65, 0x04, 0x6d, 0x61, 0x6c, 0x65, 0x06, 0x6d, 0x69,
+	0x6e, 0x75, 0x74, 0x65, 0x0b, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x65,
+	0x0e, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x65, 0x64, 0x62, 0x6c, 0x06,
+	0x6e, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x6e, 0x61, 0x70, 0x6f, 0x73, 0x74, 0x72, 0x6f, 0x70,
+	0x68, 0x65, 0x06, 0x6e, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x02, 0x6e, 0x75, 0x06, 0x6f, 0x62, 0x72,
+	0x65, 0x76, 0x65, 0x0d, 0x6f, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75,
+	0x74, 0x07, 0x6f, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x05, 0x6f, 0x6d, 0x65, 0x67, 0x61, 0x0a,
+	0x6f, 0x6d, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x6f, 0x6d, 0x69, 0x63, 0x72,
+	0x6f, 0x6e, 0x0c, 0x6f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09,
+	0x6f, 0x6e, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x0a, 0x6f, 0x70, 0x65, 0x6e, 0x62, 0x75,
+	0x6c, 0x6c, 0x65, 0x74, 0x0a, 0x6f, 0x72, 0x74, 0x68, 0x6f, 0x67, 0x6f, 0x6e, 0x61, 0x6c, 0x0b,
+	0x6f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x70, 0x65, 0x73, 0x65,
+	0x74, 0x61, 0x03, 0x70, 0x68, 0x69, 0x03, 0x70, 0x73, 0x69, 0x0d, 0x71, 0x75, 0x6f, 0x74, 0x65,
+	0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x64, 0x06, 0x72, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06,
+	0x72, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0d, 0x72, 0x65, 0x76, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61,
+	0x6c, 0x6e, 0x6f, 0x74, 0x03, 0x72, 0x68, 0x6f, 0x07, 0x72, 0x74, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x06, 0x73, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x73, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66,
+	0x6c, 0x65, 0x78, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x0c, 0x73, 0x65, 0x76, 0x65, 0x6e,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x73, 0x68, 0x61, 0x64, 0x65, 0x05, 0x73, 0x69,
+	0x67, 0x6d, 0x61, 0x06, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x31, 0x09, 0x73, 0x6d, 0x69, 0x6c, 0x65,
+	0x66, 0x61, 0x63, 0x65, 0x05, 0x73, 0x70, 0x61, 0x64, 0x65, 0x03, 0x73, 0x75, 0x6e, 0x03, 0x74,
+	0x61, 0x75, 0x04, 0x74, 0x62, 0x61, 0x72, 0x06, 0x74, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x74,
+	0x68, 0x65, 0x74, 0x61, 0x0c, 0x74, 0x68, 0x72, 0x65, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68,
+	0x73, 0x05, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x64, 0x6e, 0x07,
+	0x74, 0x72, 0x69, 0x61, 0x67, 0x6c, 0x66, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x72, 0x74, 0x07,
+	0x74, 0x72, 0x69, 0x61, 0x67, 0x75, 0x70, 0x06, 0x75, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x75,
+	0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x75, 0x6d, 0x61,
+	0x63, 0x72, 0x6f, 0x6e, 0x0d, 0x75, 0x6e, 0x64, 0x65, 0x72, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x64,
+	0x62, 0x6c, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x30, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x30, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x41, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x41, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x42, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x42, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x42, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x32, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x32, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x33, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x33, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x34, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x34, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x35, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x35, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x36, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x36, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x43, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x43, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x43, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32,
+	0x31, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32,
+	0x31, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32,
+	0x43, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x37, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33,
+	0x39, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x41, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33,
+	0x42, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x39, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x39, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x33, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x39, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31,
+	0x30, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31, 0x31, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31,
+	0x31, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x32, 0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x32,
+	0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x42, 0x30, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x42,
+	0x30, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x46, 0x46, 0x44, 0x05, 0x75, 0x6e, 0x69, 0x6f, 0x6e,
+	0x07, 0x75, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x75, 0x70, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x07, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0f, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x14, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0c, 0x75, 0x70,
+	0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x05, 0x75, 0x72, 0x69, 0x6e, 0x67,
+	0x06, 0x75, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x06, 0x77, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x77,
+	0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x09, 0x77, 0x64, 0x69, 0x65, 0x72,
+	0x65, 0x73, 0x69, 0x73, 0x06, 0x77, 0x67, 0x72, 0x61, 0x76, 0x65, 0x02, 0x78, 0x69, 0x0b, 0x79,
+	0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x79, 0x67, 0x72, 0x61, 0x76,
+	0x65, 0x06, 0x7a, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0a, 0x7a, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63,
+	0x65, 0x6e, 0x74, 0x08, 0x7a, 0x65, 0x72, 0x6f, 0x2e, 0x64, 0x6f, 0x74, 0x0a, 0x7a, 0x65, 0x72,
+	0x6f, 0x2e, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x04, 0x7a, 0x65, 0x74, 0x61, 0x00, 0x01, 0x00, 0x01,
+	0xff, 0xff, 0x00, 0x0f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0xb9, 0x00, 0xb9, 0x05, 0xc8, 0x00, 0x00, 0x04, 0x4a, 0x00, 0x00,
+	0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x04, 0x63, 0xff, 0xe7, 0xfe, 0x75, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0xb9, 0x00, 0xb9, 0x05, 0xc8, 0x00, 0x00, 0x06, 0x4a, 0x04, 0x4a, 0x00, 0x00, 0xfe, 0x75,
+	0x05, 0xed, 0xff, 0xdb, 0x06, 0x4a, 0x04, 0x63, 0xff, 0xe7, 0xfe, 0x75, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0xb9, 0x00, 0xb9, 0x05, 0xc8, 0x00, 0x00, 0x06, 0x2b, 0x04, 0x4a, 0x00, 0x00, 0xfe, 0x75,
+	0x05, 0xed, 0xff, 0xdb, 0x06, 0x2b, 0x04, 0x63, 0xff, 0xe7, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x6e, 0x00, 0x6e, 0x02, 0x44, 0xfe, 0xcc, 0x01, 0x6d, 0xfe, 0xcc, 0x02, 0x5a, 0xfe, 0xb6,
+	0x01, 0x6d, 0xfe, 0xcc, 0x00, 0x00, 0x00, 0x00, 0x00, 0x6e, 0x00, 0x6e, 0x06, 0x2d, 0x02, 0xb5,
+	0x06, 0x43, 0x02, 0x9f, 0xb0, 0x00, 0x2c, 0x20, 0xb0, 0x00, 0x55, 0x58, 0x45, 0x59, 0x20, 0x20,
+	0x4b, 0xb8, 0x00, 0x0e, 0x51, 0x4b, 0xb0, 0x06, 0x53, 0x5a, 0x58, 0xb0, 0x34, 0x1b, 0xb0, 0x28,
+	0x59, 0x60, 0x66, 0x20, 0x8a, 0x55, 0x58, 0xb0, 0x02, 0x25, 0x61, 0xb9, 0x08, 0x00, 0x08, 0x00,
+	0x63, 0x63, 0x23, 0x62, 0x1b, 0x21, 0x21, 0xb0, 0x00, 0x59, 0xb0, 0x00, 0x43, 0x23, 0x44, 0xb2,
+	0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x01, 0x2c, 0xb0, 0x20, 0x60, 0x66, 0x2d, 0xb0,
+	0x02, 0x2c, 0x23, 0x21, 0x23, 0x21, 0x2d, 0xb0, 0x03, 0x2c, 0x20, 0x64, 0xb3, 0x03, 0x14, 0x15,
+	0x00, 0x42, 0x43, 0xb0, 0x13, 0x43, 0x20, 0x60, 0x60, 0x42, 0xb1, 0x02, 0x14, 0x43, 0x42, 0xb1,
+	0x25, 0x03, 0x43, 0xb0, 0x02, 0x43, 0x54, 0x78, 0x20, 0xb0, 0x0c, 0x23, 0xb0, 0x02, 0x43, 0x43,
+	0x61, 0x64, 0xb0, 0x04, 0x50, 0x78, 0xb2, 0x02, 0x02, 0x02, 0x43, 0x60, 0x42, 0xb0, 0x21, 0x65,
+	0x1c, 0x21, 0xb0, 0x02, 0x43, 0x43, 0xb2, 0x0e, 0x15, 0x01, 0x42, 0x1c, 0x20, 0xb0, 0x02, 0x43,
+	0x23, 0x42, 0xb2, 0x13, 0x01, 0x13, 0x43, 0x60, 0x42, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59,
+	0xb2, 0x16, 0x01, 0x02, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x04, 0x2c, 0xb0, 0x03, 0x2b, 0xb0, 0x15,
+	0x43, 0x58, 0x23, 0x21, 0x23, 0x21, 0xb0, 0x16, 0x43, 0x43, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65,
+	0x59, 0x1b, 0x20, 0x64, 0x20, 0xb0, 0xc0, 0x50, 0xb0, 0x04, 0x26, 0x5a, 0xb2, 0x28, 0x01, 0x0d,
+	0x43, 0x45, 0x63, 0x45, 0xb0, 0x06, 0x45, 0x58, 0x21, 0xb0, 0x03, 0x25, 0x59, 0x52, 0x5b, 0x58,
+	0x21, 0x23, 0x21, 0x1b, 0x8a, 0x58, 0x20, 0xb0, 0x50, 0x50, 0x58, 0x21, 0xb0, 0x40, 0x59, 0x1b,
+	0x20, 0xb0, 0x38, 0x50, 0x58, 0x21, 0xb0, 0x38, 0x59, 0x59, 0x20, 0xb1, 0x01, 0x0d, 0x43, 0x45,
+	0x63, 0x45, 0x61, 0x64, 0xb0, 0x28, 0x50, 0x58, 0x21, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45,
+	0x20, 0xb0, 0x30, 0x50, 0x58, 0x21, 0xb0, 0x30, 0x59, 0x1b, 0x20, 0xb0, 0xc0, 0x50, 0x58, 0x20,
+	0x66, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x0a, 0x50, 0x58, 0x60, 0x1b, 0x20, 0xb0, 0x20, 0x50,
+	0x58, 0x21, 0xb0, 0x0a, 0x60, 0x1b, 0x20, 0xb0, 0x36, 0x50, 0x58, 0x21, 0xb0, 0x36, 0x60, 0x1b,
+	0x60, 0x59, 0x59, 0x59, 0x1b, 0xb0, 0x02, 0x25, 0xb0, 0x0c, 0x43, 0x63, 0xb0, 0x00, 0x52, 0x58,
+	0xb0, 0x00, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x21, 0xb0, 0x0c, 0x43, 0x1b, 0x4b, 0xb0, 0x1e, 0x50,
+	0x58, 0x21, 0xb0, 0x1e, 0x4b, 0x61, 0xb8, 0x10, 0x00, 0x63, 0xb0, 0x0c, 0x43, 0x63, 0xb8, 0x05,
+	0x00, 0x62, 0x59, 0x59, 0x64, 0x61, 0x59, 0xb0, 0x01, 0x2b, 0x59, 0x59, 0x23, 0xb0, 0x00, 0x50,
+	0x58, 0x65, 0x59, 0x59, 0x20, 0x64, 0xb0, 0x16, 0x43, 0x23, 0x42, 0x59, 0x2d, 0xb0, 0x05, 0x2c,
+	0x20, 0x45, 0x20, 0xb0, 0x04, 0x25, 0x61, 0x64, 0x20, 0xb0, 0x07, 0x43, 0x50, 0x58, 0xb0, 0x07,
+	0x23, 0x42, 0xb0, 0x08, 0x23, 0x42, 0x1b, 0x21, 0x21, 0x59, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x06,
+	0x2c, 0x23, 0x21, 0x23, 0x21, 0xb0, 0x03, 0x2b, 0x20, 0x64, 0xb1, 0x07, 0x62, 0x42, 0x20, 0xb0,
+	0x08, 0x23, 0x42, 0xb0, 0x06, 0x45, 0x58, 0x1b, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0xb1, 0x01,
+	0x0d, 0x43, 0xb0, 0x05, 0x60, 0x45, 0x63, 0xb0, 0x05, 0x2a, 0x21, 0x20, 0xb0, 0x08, 0x43, 0x20,
+	0x8a, 0x20, 0x8a, 0xb0, 0x01, 0x2b, 0xb1, 0x30, 0x05, 0x25, 0xb0, 0x04, 0x26, 0x51, 0x58, 0x60,
+	0x50, 0x1b, 0x61, 0x52, 0x59, 0x58, 0x23, 0x59, 0x21, 0x59, 0x20, 0xb0, 0x40, 0x53, 0x58, 0xb0,
+	0x01, 0x2b, 0x1b, 0x21, 0xb0, 0x40, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x2d, 0xb0,
+	0x07, 0x2c, 0xb0, 0x09, 0x43, 0x2b, 0xb2, 0x00, 0x02, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x08,
+	0x2c, 0xb0, 0x09, 0x23, 0x42, 0x23, 0x20, 0xb0, 0x00, 0x23, 0x42, 0x61, 0xb0, 0x02, 0x62, 0x66,
+	0xb0, 0x01, 0x63, 0xb0, 0x01, 0x60, 0xb0, 0x07, 0x2a, 0x2d, 0xb0, 0x09, 0x2c, 0x20, 0x20, 0x45,
+	0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40,
+	0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0a, 0x2c, 0xb2,
+	0x09, 0x0e, 0x00, 0x43, 0x45, 0x42, 0x2a, 0x21, 0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d,
+	0xb0, 0x0b, 0x2c, 0xb0, 0x00, 0x43, 0x23, 0x44, 0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d,
+	0xb0, 0x0c, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x00, 0x43, 0xb0, 0x04,
+	0x25, 0x60, 0x20, 0x45, 0x8a, 0x23, 0x61, 0x20, 0x64, 0x20, 0xb0, 0x20, 0x50, 0x58, 0x21, 0xb0,
+	0x00, 0x1b, 0xb0, 0x30, 0x50, 0x58, 0xb0, 0x20, 0x1b, 0xb0, 0x40, 0x59, 0x59, 0x23, 0xb0, 0x00,
+	0x50, 0x58, 0x65, 0x59, 0xb0, 0x03, 0x25, 0x23, 0x61, 0x44, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0,
+	0x0d, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x00, 0x43, 0xb0, 0x04, 0x25,
+	0x60, 0x20, 0x45, 0x8a, 0x23, 0x61, 0x20, 0x64, 0xb0, 0x24, 0x50, 0x58, 0xb0, 0x00, 0x1b, 0xb0,
+	0x40, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0xb0, 0x03, 0x25, 0x23, 0x61, 0x44, 0x44,
+	0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0e, 0x2c, 0x20, 0xb0, 0x00, 0x23, 0x42, 0xb3, 0x0d, 0x0c, 0x00,
+	0x03, 0x45, 0x50, 0x58, 0x21, 0x1b, 0x23, 0x21, 0x59, 0x2a, 0x21, 0x2d, 0xb0, 0x0f, 0x2c, 0xb1,
+	0x02, 0x02, 0x45, 0xb0, 0x64, 0x61, 0x44, 0x2d, 0xb0, 0x10, 0x2c, 0xb0, 0x01, 0x60, 0x20, 0x20,
+	0xb0, 0x0f, 0x43, 0x4a, 0xb0, 0x00, 0x50, 0x58, 0x20, 0xb0, 0x0f, 0x23, 0x42, 0x59, 0xb0, 0x10,
+	0x43, 0x4a, 0xb0, 0x00, 0x52, 0x58, 0x20, 0xb0, 0x10, 0x23, 0x42, 0x59, 0x2d, 0xb0, 0x11, 0x2c,
+	0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0x20, 0xb8, 0x04, 0x00, 0x63, 0x8a, 0x23, 0x61,
+	0xb0, 0x11, 0x43, 0x60, 0x20, 0x8a, 0x60, 0x20, 0xb0, 0x11, 0x23, 0x42, 0x23, 0x2d, 0xb0, 0x12,
+	0x2c, 0x4b, 0x54, 0x58, 0xb1, 0x04, 0x64, 0x44, 0x59, 0x24, 0xb0, 0x0d, 0x65, 0x23, 0x78, 0x2d,
+	0xb0, 0x13, 0x2c, 0x4b, 0x51, 0x58, 0x4b, 0x53, 0x58, 0xb1, 0x04, 0x64, 0x44, 0x59, 0x1b, 0x21,
+	0x59, 0x24, 0xb0, 0x13, 0x65, 0x23, 0x78, 0x2d, 0xb0, 0x14, 0x2c, 0xb1, 0x00, 0x12, 0x43, 0x55,
+	0x58, 0xb1, 0x12, 0x12, 0x43, 0xb0, 0x01, 0x61, 0x42, 0xb0, 0x11, 0x2b, 0x59, 0xb0, 0x00, 0x43,
+	0xb0, 0x02, 0x25, 0x42, 0xb1, 0x0f, 0x02, 0x25, 0x42, 0xb1, 0x10, 0x02, 0x25, 0x42, 0xb0, 0x01,
+	0x16, 0x23, 0x20, 0xb0, 0x03, 0x25, 0x50, 0x58, 0xb1, 0x01, 0x00, 0x43, 0x60, 0xb0, 0x04, 0x25,
+	0x42, 0x8a, 0x8a, 0x20, 0x8a, 0x23, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x23, 0xb0, 0x01, 0x61, 0x20,
+	0x8a, 0x23, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x1b, 0xb1, 0x01, 0x00, 0x43, 0x60, 0xb0, 0x02, 0x25,
+	0x42, 0xb0, 0x02, 0x25, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x59, 0xb0, 0x0f, 0x43, 0x47, 0xb0, 0x10,
+	0x43, 0x47, 0x60, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66,
+	0xb0, 0x01, 0x63, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50,
+	0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb1, 0x00, 0x00, 0x13, 0x23, 0x44,
+	0xb0, 0x01, 0x43, 0xb0, 0x00, 0x3e, 0xb2, 0x01, 0x01, 0x01, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x15,
+	0x2c, 0x00, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb0, 0x12, 0x23, 0x42, 0x20, 0x45, 0xb0, 0x0e,
+	0x23, 0x42, 0xb0, 0x0d, 0x23, 0xb0, 0x05, 0x60, 0x42, 0x20, 0x60, 0xb7, 0x18, 0x18, 0x01, 0x00,
+	0x11, 0x00, 0x13, 0x00, 0x42, 0x42, 0x42, 0x8a, 0x60, 0x20, 0xb0, 0x14, 0x23, 0x42, 0xb0, 0x01,
+	0x61, 0xb1, 0x14, 0x08, 0x2b, 0xb0, 0x8b, 0x2b, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x16, 0x2c, 0xb1,
+	0x00, 0x15, 0x2b, 0x2d, 0xb0, 0x17, 0x2c, 0xb1, 0x01, 0x15, 0x2b, 0x2d, 0xb0, 0x18, 0x2c, 0xb1,
+	0x02, 0x15, 0x2b, 0x2d, 0xb0, 0x19, 0x2c, 0xb1, 0x03, 0x15, 0x2b, 0x2d, 0xb0, 0x1a, 0x2c, 0xb1,
+	0x04, 0x15, 0x2b, 0x2d, 0xb0, 0x1b, 0x2c, 0xb1, 0x05, 0x15, 0x2b, 0x2d, 0xb0, 0x1c, 0x2c, 0xb1,
+	0x06, 0x15, 0x2b, 0x2d, 0xb0, 0x1d, 0x2c, 0xb1, 0x07, 0x15, 0x2b, 0x2d, 0xb0, 0x1e, 0x2c, 0xb1,
+	0x08, 0x15, 0x2b, 0x2d, 0xb0, 0x1f, 0x2c, 0xb1, 0x09, 0x15, 0x2b, 0x2d, 0xb0, 0x2b, 0x2c, 0x23,
+	0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x06, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20,
+	0x2e, 0xb0, 0x01, 0x5d, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x2c, 0x2c, 0x23, 0x20, 0xb0, 0x10,
+	0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x16, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01,
+	0x71, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x2d, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0,
+	0x01, 0x63, 0xb0, 0x26, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x72, 0x1b, 0x21,
+	0x21, 0x59, 0x2d, 0xb0, 0x20, 0x2c, 0x00, 0xb0, 0x0f, 0x2b, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58,
+	0xb0, 0x12, 0x23, 0x42, 0x20, 0x45, 0xb0, 0x0e, 0x23, 0x42, 0xb0, 0x0d, 0x23, 0xb0, 0x05, 0x60,
+	0x42, 0x20, 0x60, 0xb0, 0x01, 0x61, 0xb5, 0x18, 0x18, 0x01, 0x00, 0x11, 0x00, 0x42, 0x42, 0x8a,
+	0x60, 0xb1, 0x14, 0x08, 0x2b, 0xb0, 0x8b, 0x2b, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x21, 0x2c, 0xb1,
+	0x00, 0x20, 0x2b, 0x2d, 0xb0, 0x22, 0x2c, 0xb1, 0x01, 0x20, 0x2b, 0x2d, 0xb0, 0x23, 0x2c, 0xb1,
+	0x02, 0x20, 0x2b, 0x2d, 0xb0, 0x24, 0x2c, 0xb1, 0x03, 0x20, 0x2b, 0x2d, 0xb0, 0x25, 0x2c, 0xb1,
+	0x04, 0x20, 0x2b, 0x2d, 0xb0, 0x26, 0x2c, 0xb1, 0x05, 0x20, 0x2b, 0x2d, 0xb0, 0x27, 0x2c, 0xb1,
+	0x06, 0x20, 0x2b, 0x2d, 0xb0, 0x28, 0x2c, 0xb1, 0x07, 0x20, 0x2b, 0x2d, 0xb0, 0x29, 0x2c, 0xb1,
+	0x08, 0x20, 0x2b, 0x2d, 0xb0, 0x2a, 0x2c, 0xb1, 0x09, 0x20, 0x2b, 0x2d, 0xb0, 0x2e, 0x2c, 0x20,
+	0x3c, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x2f, 0x2c, 0x20, 0x60, 0xb0, 0x18, 0x60, 0x20, 0x43, 0x23,
+	0xb0, 0x01, 0x60, 0x43, 0xb0, 0x02, 0x25, 0x61, 0xb0, 0x01, 0x60, 0xb0, 0x2e, 0x2a, 0x21, 0x2d,
+	0xb0, 0x30, 0x2c, 0xb0, 0x2f, 0x2b, 0xb0, 0x2f, 0x2a, 0x2d, 0xb0, 0x31, 0x2c, 0x20, 0x20, 0x47,
+	0x20, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0,
+	0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x61, 0x38, 0x23, 0x20, 0x8a, 0x55, 0x58,
+	0x20, 0x47, 0x20, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50,
+	0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x61, 0x38, 0x1b, 0x21, 0x59,
+	0x2d, 0xb0, 0x32, 0x2c, 0x00, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb1, 0x0e, 0x06, 0x45, 0x42,
+	0xb0, 0x01, 0x16, 0xb0, 0x31, 0x2a, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x1b, 0x22,
+	0x59, 0x2d, 0xb0, 0x33, 0x2c, 0x00, 0xb0, 0x0f, 0x2b, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb1,
+	0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x16, 0xb0, 0x31, 0x2a, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58,
+	0x30, 0x59, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x34, 0x2c, 0x20, 0x35, 0xb0, 0x01, 0x60, 0x2d, 0xb0,
+	0x35, 0x2c, 0x00, 0xb1, 0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x45, 0x63, 0xb8, 0x04, 0x00, 0x62,
+	0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x2b,
+	0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60,
+	0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x2b, 0xb0, 0x00, 0x16, 0xb4, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x44, 0x3e, 0x23, 0x38, 0xb1, 0x34, 0x01, 0x15, 0x2a, 0x21, 0x2d, 0xb0, 0x36, 0x2c, 0x20,
+	0x3c, 0x20, 0x47, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50,
+	0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb0, 0x00, 0x43, 0x61, 0x38, 0x2d,
+	0xb0, 0x37, 0x2c, 0x2e, 0x17, 0x3c, 0x2d, 0xb0, 0x38, 0x2c, 0x20, 0x3c, 0x20, 0x47, 0x20, 0xb0,
+	0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59,
+	0x66, 0xb0, 0x01, 0x63, 0x60, 0xb0, 0x00, 0x43, 0x61, 0xb0, 0x01, 0x43, 0x63, 0x38, 0x2d, 0xb0,
+	0x39, 0x2c, 0xb1, 0x02, 0x00, 0x16, 0x25, 0x20, 0x2e, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb0,
+	0x02, 0x25, 0x49, 0x8a, 0x8a, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0x58, 0x62, 0x1b, 0x21, 0x59,
+	0xb0, 0x01, 0x23, 0x42, 0xb2, 0x38, 0x01, 0x01, 0x15, 0x14, 0x2a, 0x2d, 0xb0, 0x3a, 0x2c, 0xb0,
+	0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x47, 0x23, 0x47, 0x23,
+	0x61, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x65, 0x8a, 0x2e, 0x23, 0x20, 0x20, 0x3c,
+	0x8a, 0x38, 0x2d, 0xb0, 0x3b, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x04, 0x25,
+	0xb0, 0x04, 0x25, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x06, 0x23, 0x42, 0xb1,
+	0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x20, 0xb0, 0x60, 0x50, 0x58, 0x20, 0xb0, 0x40, 0x51,
+	0x58, 0xb3, 0x04, 0x20, 0x05, 0x20, 0x1b, 0xb3, 0x04, 0x26, 0x05, 0x1a, 0x59, 0x42, 0x42, 0x23,
+	0x20, 0xb0, 0x0a, 0x43, 0x20, 0x8a, 0x23, 0x47, 0x23, 0x47, 0x23, 0x61, 0x23, 0x46, 0x60, 0xb0,
+	0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0,
+	0x01, 0x63, 0x60, 0x20, 0xb0, 0x01, 0x2b, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x04, 0x43, 0x60,
+	0x64, 0x23, 0xb0, 0x05, 0x43, 0x61, 0x64, 0x50, 0x58, 0xb0, 0x04, 0x43, 0x61, 0x1b, 0xb0, 0x05,
+	0x43, 0x60, 0x59, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40,
+	0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x61, 0x23, 0x20, 0x20, 0xb0, 0x04, 0x26, 0x23, 0x46, 0x61,
+	0x38, 0x1b, 0x23, 0xb0, 0x0a, 0x43, 0x46, 0xb0, 0x02, 0x25, 0xb0, 0x0a, 0x43, 0x47, 0x23, 0x47,
+	0x23, 0x61, 0x60, 0x20, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0,
+	0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x06,
+	0x43, 0x60, 0xb0, 0x01, 0x2b, 0xb0, 0x05, 0x25, 0x61, 0xb0, 0x05, 0x25, 0xb0, 0x02, 0x62, 0x20,
+	0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x04, 0x26, 0x61,
+	0x20, 0xb0, 0x04, 0x25, 0x60, 0x64, 0x23, 0xb0, 0x03, 0x25, 0x60, 0x64, 0x50, 0x58, 0x21, 0x1b,
+	0x23, 0x21, 0x59, 0x23, 0x20, 0x20, 0xb0, 0x04, 0x26, 0x23, 0x46, 0x61, 0x38, 0x59, 0x2d, 0xb0,
+	0x3c, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0x20, 0x20, 0xb0, 0x05, 0x26, 0x20,
+	0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x23, 0x3c, 0x38, 0x2d, 0xb0, 0x3d, 0x2c, 0xb0, 0x00, 0x16,
+	0xb0, 0x17, 0x23, 0x42, 0x20, 0xb0, 0x0a, 0x23, 0x42, 0x20, 0x20, 0x20, 0x46, 0x23, 0x47, 0xb0,
+	0x01, 0x2b, 0x23, 0x61, 0x38, 0x2d, 0xb0, 0x3e, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42,
+	0xb0, 0x03, 0x25, 0xb0, 0x02, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0, 0x00, 0x54, 0x58, 0x2e,
+	0x20, 0x3c, 0x23, 0x21, 0x1b, 0xb0, 0x02, 0x25, 0xb0, 0x02, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61,
+	0x20, 0xb0, 0x05, 0x25, 0xb0, 0x04, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0, 0x06, 0x25, 0xb0,
+	0x05, 0x25, 0x49, 0xb0, 0x02, 0x25, 0x61, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63, 0x63, 0x23, 0x20,
+	0x58, 0x62, 0x1b, 0x21, 0x59, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0,
+	0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x2e, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38,
+	0x23, 0x21, 0x59, 0x2d, 0xb0, 0x3f, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0xb0,
+	0x0a, 0x43, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0x60, 0xb0, 0x20, 0x60, 0x66, 0xb0,
+	0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x23,
+	0x20, 0x20, 0x3c, 0x8a, 0x38, 0x2d, 0xb0, 0x40, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25,
+	0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30,
+	0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x41, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0,
+	0x17, 0x43, 0x58, 0x52, 0x1b, 0x50, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14,
+	0x2b, 0x2d, 0xb0, 0x42, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43,
+	0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25,
+	0x46, 0xb0, 0x17, 0x43, 0x58, 0x52, 0x1b, 0x50, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30,
+	0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x43, 0x2c, 0xb0, 0x3a, 0x2b, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02,
+	0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1,
+	0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x44, 0x2c, 0xb0, 0x3b, 0x2b, 0x8a, 0x20, 0x20, 0x3c, 0xb0,
+	0x06, 0x23, 0x42, 0x8a, 0x38, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43,
+	0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0xb0,
+	0x06, 0x43, 0x2e, 0xb0, 0x30, 0x2b, 0x2d, 0xb0, 0x45, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x04, 0x25,
+	0xb0, 0x04, 0x26, 0x20, 0x20, 0x20, 0x46, 0x23, 0x47, 0x61, 0xb0, 0x0c, 0x23, 0x42, 0x2e, 0x47,
+	0x23, 0x47, 0x23, 0x61, 0xb0, 0x0b, 0x43, 0x2b, 0x23, 0x20, 0x3c, 0x20, 0x2e, 0x23, 0x38, 0xb1,
+	0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x46, 0x2c, 0xb1, 0x0a, 0x04, 0x25, 0x42, 0xb0, 0x00, 0x16,
+	0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x06,
+	0x23, 0x42, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x20, 0xb0, 0x60, 0x50, 0x58, 0x20,
+	0xb0, 0x40, 0x51, 0x58, 0xb3, 0x04, 0x20, 0x05, 0x20, 0x1b, 0xb3, 0x04, 0x26, 0x05, 0x1a, 0x59,
+	0x42, 0x42, 0x23, 0x20, 0x47, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58,
+	0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x20, 0xb0, 0x01, 0x2b, 0x20, 0x8a, 0x8a,
+	0x61, 0x20, 0xb0, 0x04, 0x43, 0x60, 0x64, 0x23, 0xb0, 0x05, 0x43, 0x61, 0x64, 0x50, 0x58, 0xb0,
+	0x04, 0x43, 0x61, 0x1b, 0xb0, 0x05, 0x43, 0x60, 0x59, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x62, 0x20,
+	0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x61, 0xb0, 0x02, 0x25,
+	0x46, 0x61, 0x38, 0x23, 0x20, 0x3c, 0x23, 0x38, 0x1b, 0x21, 0x20, 0x20, 0x46, 0x23, 0x47, 0xb0,
+	0x01, 0x2b, 0x23, 0x61, 0x38, 0x21, 0x59, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x47, 0x2c,
+	0xb1, 0x00, 0x3a, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x48, 0x2c, 0xb1, 0x00,
+	0x3b, 0x2b, 0x21, 0x23, 0x20, 0x20, 0x3c, 0xb0, 0x06, 0x23, 0x42, 0x23, 0x38, 0xb1, 0x30, 0x01,
+	0x14, 0x2b, 0xb0, 0x06, 0x43, 0x2e, 0xb0, 0x30, 0x2b, 0x2d, 0xb0, 0x49, 0x2c, 0xb0, 0x00, 0x15,
+	0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb2, 0x00, 0x01, 0x01, 0x15, 0x14, 0x13, 0x2e, 0xb0, 0x36,
+	0x2a, 0x2d, 0xb0, 0x4a, 0x2c, 0xb0, 0x00, 0x15, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb2, 0x00,
+	0x01, 0x01, 0x15, 0x14, 0x13, 0x2e, 0xb0, 0x36, 0x2a, 0x2d, 0xb0, 0x4b, 0x2c, 0xb1, 0x00, 0x01,
+	0x14, 0x13, 0xb0, 0x37, 0x2a, 0x2d, 0xb0, 0x4c, 0x2c, 0xb0, 0x39, 0x2a, 0x2d, 0xb0, 0x4d, 0x2c,
+	0xb0, 0x00, 0x16, 0x45, 0x23, 0x20, 0x2e, 0x20, 0x46, 0x8a, 0x23, 0x61, 0x38, 0xb1, 0x30, 0x01,
+	0x14, 0x2b, 0x2d, 0xb0, 0x4e, 0x2c, 0xb0, 0x0a, 0x23, 0x42, 0xb0, 0x4d, 0x2b, 0x2d, 0xb0, 0x4f,
+	0x2c, 0xb2, 0x00, 0x00, 0x46, 0x2b, 0x2d, 0xb0, 0x50, 0x2c, 0xb2, 0x00, 0x01, 0x46, 0x2b, 0x2d,
+	0xb0, 0x51, 0x2c, 0xb2, 0x01, 0x00, 0x46, 0x2b, 0x2d, 0xb0, 0x52, 0x2c, 0xb2, 0x01, 0x01, 0x46,
+	0x2b, 0x2d, 0xb0, 0x53, 0x2c, 0xb2, 0x00, 0x00, 0x47, 0x2b, 0x2d, 0xb0, 0x54, 0x2c, 0xb2, 0x00,
+	0x01, 0x47, 0x2b, 0x2d, 0xb0, 0x55, 0x2c, 0xb2, 0x01, 0x00, 0x47, 0x2b, 0x2d, 0xb0, 0x56, 0x2c,
+	0xb2, 0x01, 0x01, 0x47, 0x2b, 0x2d, 0xb0, 0x57, 0x2c, 0xb3, 0x00, 0x00, 0x00, 0x43, 0x2b, 0x2d,
+	0xb0, 0x58, 0x2c, 0xb3, 0x00, 0x01, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x59, 0x2c, 0xb3, 0x01, 0x00,
+	0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x5a, 0x2c, 0xb3, 0x01, 0x01, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x5b,
+	0x2c, 0xb3, 0x00, 0x00, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5c, 0x2c, 0xb3, 0x00, 0x01, 0x01, 0x43,
+	0x2b, 0x2d, 0xb0, 0x5d, 0x2c, 0xb3, 0x01, 0x00, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5e, 0x2c, 0xb3,
+	0x01, 0x01, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5f, 0x2c, 0xb2, 0x00, 0x00, 0x45, 0x2b, 0x2d, 0xb0,
+	0x60, 0x2c, 0xb2, 0x00, 0x01, 0x45, 0x2b, 0x2d, 0xb0, 0x61, 0x2c, 0xb2, 0x01, 0x00, 0x45, 0x2b,
+	0x2d, 0xb0, 0x62, 0x2c, 0xb2, 0x01, 0x01, 0x45, 0x2b, 0x2d, 0xb0, 0x63, 0x2c, 0xb2, 0x00, 0x00,
+	0x48, 0x2b, 0x2d, 0xb0, 0x64, 0x2c, 0xb2, 0x00, 0x01, 0x48, 0x2b, 0x2d, 0xb0, 0x65, 0x2c, 0xb2,
+	0x01, 0x00, 0x48, 0x2b, 0x2d, 0xb0, 0x66, 0x2c, 0xb2, 0x01, 0x01, 0x48, 0x2b, 0x2d, 0xb0, 0x67,
+	0x2c, 0xb3, 0x00, 0x00, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x68, 0x2c, 0xb3, 0x00, 0x01, 0x00, 0x44,
+	0x2b, 0x2d, 0xb0, 0x69, 0x2c, 0xb3, 0x01, 0x00, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x6a, 0x2c, 0xb3,
+	0x01, 0x01, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x6b, 0x2c, 0xb3, 0x00, 0x00, 0x01, 0x44, 0x2b, 0x2d,
+	0xb0, 0x6c, 0x2c, 0xb3, 0x00, 0x01, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6d, 0x2c, 0xb3, 0x01, 0x00,
+	0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6e, 0x2c, 0xb3, 0x01, 0x01, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6f,
+	0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x70, 0x2c, 0xb1,
+	0x00, 0x3c, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x71, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x41,
+	0x2b, 0x2d, 0xb0, 0x72, 0x2c, 0xb0, 0x00, 0x16, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x42, 0x2b, 0x2d,
+	0xb0, 0x73, 0x2c, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x74, 0x2c, 0xb1, 0x01,
+	0x3c, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x75, 0x2c, 0xb0, 0x00, 0x16, 0xb1, 0x01, 0x3c, 0x2b,
+	0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x76, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14,
+	0x2b, 0x2d, 0xb0, 0x77, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x78, 0x2c,
+	0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x79, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0,
+	0x42, 0x2b, 0x2d, 0xb0, 0x7a, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x7b,
+	0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x7c, 0x2c, 0xb1, 0x01, 0x3d, 0x2b,
+	0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x7d, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14,
+	0x2b, 0x2d, 0xb0, 0x7e, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x7f, 0x2c,
+	0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x80, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0,
+	0x42, 0x2b, 0x2d, 0xb0, 0x81, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x82,
+	0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x83, 0x2c, 0xb1, 0x01, 0x3e, 0x2b,
+	0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x84, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14,
+	0x2b, 0x2d, 0xb0, 0x85, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x86, 0x2c,
+	0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x87, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0,
+	0x42, 0x2b, 0x2d, 0xb0, 0x88, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x89,
+	0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x8a, 0x2c, 0xb1, 0x01, 0x3f, 0x2b,
+	0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x8b, 0x2c, 0xb2, 0x0b, 0x00, 0x03, 0x45, 0x50, 0x58, 0xb0, 0x06,
+	0x1b, 0xb2, 0x04, 0x02, 0x03, 0x45, 0x58, 0x23, 0x21, 0x1b, 0x21, 0x59, 0x59, 0x42, 0x2b, 0xb0,
+	0x08, 0x65, 0xb0, 0x03, 0x24, 0x50, 0x78, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x2d,
+	0x00, 0x4b, 0xb8, 0x00, 0xc8, 0x52, 0x58, 0xb1, 0x01, 0x01, 0x8e, 0x59, 0xb0, 0x01, 0xb9, 0x08,
+	0x00, 0x08, 0x00, 0x63, 0x70, 0xb1, 0x00, 0x07, 0x42, 0xb6, 0x00, 0x4e, 0x41, 0x31, 0x21, 0x05,
+	0x00, 0x2a, 0xb1, 0x00, 0x07, 0x42, 0x40, 0x0c, 0x52, 0x04, 0x46, 0x06, 0x36, 0x08, 0x26, 0x08,
+	0x18, 0x07, 0x05, 0x0a, 0x2a, 0xb1, 0x00, 0x07, 0x42, 0x40, 0x0c, 0x56, 0x02, 0x4c, 0x04, 0x3e,
+	0x06, 0x2e, 0x06, 0x1f, 0x05, 0x05, 0x0a, 0x2a, 0xb1, 0x00, 0x0c, 0x42, 0xbe, 0x14, 0xc0, 0x11,
+	0xc0, 0x0d, 0xc0, 0x09, 0xc0, 0x06, 0x40, 0x00, 0x05, 0x00, 0x0b, 0x2a, 0xb1, 0x00, 0x11, 0x42,
+	0xbe, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x05, 0x00, 0x0b, 0x2a,
+	0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0xb1, 0x24, 0x01, 0x88, 0x51, 0x58, 0xb0, 0x40, 0x88, 0x58,
+	0xb9, 0x00, 0x03, 0x00, 0x64, 0x44, 0xb1, 0x28, 0x01, 0x88, 0x51, 0x58, 0xb8, 0x08, 0x00, 0x88,
+	0x58, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0x59, 0x1b, 0xb1, 0x27, 0x01, 0x88, 0x51, 0x58, 0xba,
+	0x08, 0x80, 0x00, 0x01, 0x04, 0x40, 0x88, 0x63, 0x54, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0c, 0x54, 0x02, 0x48, 0x04, 0x38, 0x06, 0x28, 0x06, 0x1a,
+	0x05, 0x05, 0x0e, 0x2a, 0xb8, 0x01, 0xff, 0x85, 0xb0, 0x04, 0x8d, 0xb1, 0x02, 0x00, 0x44, 0xb3,
+	0x05, 0x64, 0x06, 0x00, 0x44, 0x44, 0x00, 0x00,
+}
@@ -0,0 +1,9834 @@
+// generated by go run gen.go; DO NOT EDIT
+
+// Package goitalic provides the "Go Italic" TrueType font
+// from the Go font family. It is a proportional-width, sans-serif font.
+//
+// See https://blog.golang.org/go-fonts for details.
+package goitalic
+
+// TTF is the data for the "Go Italic" TrueType font.
+var TTF = []byte{
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x80, 0x00, 0x03, 0x00, 0x60, 0x4f, 0x53, 0x2f, 0x32,
+	0xc0, 0xa9, 0x38, 0xa0, 0x00, 0x00, 0x00, 0xec, 0x00, 0x00, 0x00, 0x60, 0x63, 0x6d, 0x61, 0x70,
+	0xbe, 0x92, 0x2d, 0x51, 0x00, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x05, 0x3e, 0x63, 0x76, 0x74, 0x20,
+	0x4e, 0xaa, 0x16, 0x2c, 0x00, 0x02, 0x56, 0x58, 0x00, 0x00, 0x00, 0xb0, 0x66, 0x70, 0x67, 0x6d,
+	0x62, 0x2f, 0x03, 0x7f, 0x00, 0x02, 0x57, 0x08, 0x00, 0x00, 0x0e, 0x0c, 0x67, 0x61, 0x73, 0x70,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x02, 0x56, 0x50, 0x00, 0x00, 0x00, 0x08, 0x67, 0x6c, 0x79, 0x66,
+	0x25, 0xfa, 0x8e, 0xda, 0x00, 0x00, 0x06, 0x8c, 0x00, 0x02, 0x09, 0x20, 0x68, 0x65, 0x61, 0x64,
+	0x19, 0x6a, 0x52, 0xc8, 0x00, 0x02, 0x0f, 0xac, 0x00, 0x00, 0x00, 0x36, 0x68, 0x68, 0x65, 0x61,
+	0x10, 0x50, 0x0f, 0x10, 0x00, 0x02, 0x0f, 0xe4, 0x00, 0x00, 0x00, 0x24, 0x68, 0x6d, 0x74, 0x78,
+	0x24, 0xbf, 0xc7, 0xc8, 0x00, 0x02, 0x10, 0x08, 0x00, 0x00, 0x0b, 0x1e, 0x6c, 0x6f, 0x63, 0x61,
+	0x02, 0xd4, 0x29, 0xe8, 0x00, 0x02, 0x1b, 0x28, 0x00, 0x00, 0x0b, 0x24, 0x6d, 0x61, 0x78, 0x70,
+	0x06, 0x46, 0x18, 0x26, 0x00, 0x02, 0x26, 0x4c, 0x00, 0x00, 0x00, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+	0x61, 0xe8, 0xe8, 0xa4, 0x00, 0x02, 0x26, 0x6c, 0x00, 0x00, 0x1b, 0x33, 0x70, 0x6f, 0x73, 0x74,
+	0xfc, 0x94, 0x10, 0xa5, 0x00, 0x02, 0x41, 0xa0, 0x00, 0x00, 0x14, 0xb0, 0x70, 0x72, 0x65, 0x70,
+	0x8e, 0xd0, 0xa0, 0x76, 0x00, 0x02, 0x65, 0x14, 0x00, 0x00, 0x00, 0xd6, 0x00, 0x03, 0x04, 0xc8,
+	0x01, 0x90, 0x00, 0x05, 0x00, 0x00, 0x05, 0x9a, 0x05, 0x33, 0x00, 0x00, 0x01, 0x1b, 0x05, 0x9a,
+	0x05, 0x33, 0x00, 0x00, 0x03, 0xd1, 0x00, 0x66, 0x02, 0x00, 0x08, 0x02, 0x02, 0x0b, 0x06, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xa0, 0x00, 0x02, 0xaf, 0x50, 0x00, 0x78, 0xfb, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x20, 0x20, 0x20, 0x00, 0x01, 0x00, 0x00, 0xff, 0xfd,
+	0x06, 0x2b, 0xfe, 0x75, 0x01, 0x89, 0x07, 0x8f, 0x01, 0xb0, 0x20, 0x00, 0x00, 0x9f, 0xdf, 0xd7,
+	0x00, 0x00, 0x04, 0x3e, 0x05, 0xc8, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03, 0x34,
+	0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x04, 0x03, 0x18, 0x00, 0x00, 0x00, 0xc2,
+	0x00, 0x80, 0x00, 0x06, 0x00, 0x42, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x7e, 0x01, 0x7f, 0x01, 0x92,
+	0x01, 0xdc, 0x01, 0xff, 0x02, 0x1b, 0x02, 0xc7, 0x02, 0xc9, 0x02, 0xdd, 0x03, 0x7e, 0x03, 0x8a,
+	0x03, 0x8c, 0x03, 0xa1, 0x03, 0xce, 0x04, 0x5f, 0x04, 0x91, 0x1e, 0x85, 0x1e, 0xf3, 0x20, 0x15,
+	0x20, 0x1e, 0x20, 0x22, 0x20, 0x26, 0x20, 0x30, 0x20, 0x33, 0x20, 0x3a, 0x20, 0x3c, 0x20, 0x3e,
+	0x20, 0x44, 0x20, 0x70, 0x20, 0x8e, 0x20, 0x99, 0x20, 0xa4, 0x20, 0xa7, 0x20, 0xac, 0x21, 0x05,
+	0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5e, 0x21, 0x95, 0x21, 0xa8,
+	0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x12, 0x22, 0x15, 0x22, 0x1a, 0x22, 0x1f, 0x22, 0x2b,
+	0x22, 0x48, 0x22, 0x61, 0x22, 0x65, 0x23, 0x02, 0x23, 0x10, 0x23, 0x21, 0x25, 0x00, 0x25, 0x02,
+	0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24, 0x25, 0x2c, 0x25, 0x34,
+	0x25, 0x3c, 0x25, 0x6c, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c, 0x25, 0x93, 0x25, 0xa1,
+	0x25, 0xac, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xcb, 0x25, 0xcf, 0x25, 0xd9,
+	0x25, 0xe6, 0x26, 0x3c, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63, 0x26, 0x66, 0x26, 0x6b,
+	0xf8, 0x00, 0xfb, 0x02, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x20,
+	0x00, 0xa0, 0x01, 0x92, 0x01, 0xcd, 0x01, 0xfa, 0x02, 0x18, 0x02, 0xc6, 0x02, 0xc9, 0x02, 0xd8,
+	0x03, 0x7e, 0x03, 0x84, 0x03, 0x8c, 0x03, 0x8e, 0x03, 0xa3, 0x04, 0x00, 0x04, 0x90, 0x1e, 0x80,
+	0x1e, 0xf2, 0x20, 0x13, 0x20, 0x17, 0x20, 0x20, 0x20, 0x26, 0x20, 0x30, 0x20, 0x32, 0x20, 0x39,
+	0x20, 0x3c, 0x20, 0x3e, 0x20, 0x44, 0x20, 0x70, 0x20, 0x74, 0x20, 0x99, 0x20, 0xa3, 0x20, 0xa7,
+	0x20, 0xac, 0x21, 0x05, 0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5b,
+	0x21, 0x90, 0x21, 0xa8, 0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x11, 0x22, 0x15, 0x22, 0x19,
+	0x22, 0x1e, 0x22, 0x29, 0x22, 0x48, 0x22, 0x60, 0x22, 0x64, 0x23, 0x02, 0x23, 0x10, 0x23, 0x20,
+	0x25, 0x00, 0x25, 0x02, 0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24,
+	0x25, 0x2c, 0x25, 0x34, 0x25, 0x3c, 0x25, 0x50, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c,
+	0x25, 0x90, 0x25, 0xa0, 0x25, 0xaa, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xca,
+	0x25, 0xcf, 0x25, 0xd8, 0x25, 0xe6, 0x26, 0x3a, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63,
+	0x26, 0x65, 0x26, 0x6a, 0xf8, 0x00, 0xfb, 0x01, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x01, 0xff, 0xf5,
+	0xff, 0xe3, 0xff, 0xc2, 0xff, 0xb0, 0xff, 0x76, 0xff, 0x59, 0xff, 0x41, 0xfe, 0x97, 0xfe, 0x96,
+	0xfe, 0x88, 0xfd, 0xe8, 0xfd, 0xe3, 0xfd, 0xe2, 0xfd, 0xe1, 0xfd, 0xe0, 0xfd, 0xaf, 0xfd, 0x7f,
+	0xe3, 0x91, 0xe3, 0x25, 0xe2, 0x06, 0xe2, 0x05, 0xe2, 0x04, 0xe2, 0x01, 0xe1, 0xf8, 0xe1, 0xf7,
+	0xe1, 0xf2, 0xe1, 0xf1, 0xe1, 0xf0, 0xe1, 0xeb, 0xe1, 0xc0, 0xe1, 0xbd, 0xe1, 0xb3, 0xe1, 0xaa,
+	0xe1, 0xa8, 0xe1, 0xa4, 0xe1, 0x4c, 0xe1, 0x3f, 0xe1, 0x3d, 0xe1, 0x32, 0xe1, 0x2f, 0xe1, 0x28,
+	0xe0, 0xfc, 0xe0, 0xcb, 0xe0, 0xb9, 0xe0, 0x60, 0xe0, 0x5d, 0xe0, 0x55, 0xe0, 0x54, 0xe0, 0x52,
+	0xe0, 0x4f, 0xe0, 0x4c, 0xe0, 0x43, 0xe0, 0x27, 0xe0, 0x10, 0xe0, 0x0e, 0xdf, 0x72, 0xdf, 0x65,
+	0xdf, 0x56, 0xdd, 0x78, 0xdd, 0x77, 0xdd, 0x6e, 0xdd, 0x6b, 0xdd, 0x68, 0xdd, 0x65, 0xdd, 0x62,
+	0xdd, 0x5b, 0xdd, 0x54, 0xdd, 0x4d, 0xdd, 0x46, 0xdd, 0x33, 0xdd, 0x20, 0xdd, 0x1d, 0xdd, 0x1a,
+	0xdd, 0x17, 0xdd, 0x14, 0xdd, 0x08, 0xdd, 0x00, 0xdc, 0xfb, 0xdc, 0xf4, 0xdc, 0xf3, 0xdc, 0xec,
+	0xdc, 0xe7, 0xdc, 0xe4, 0xdc, 0xdc, 0xdc, 0xd0, 0xdc, 0x7d, 0xdc, 0x7a, 0xdc, 0x79, 0xdc, 0x5c,
+	0xdc, 0x5a, 0xdc, 0x59, 0xdc, 0x56, 0x0a, 0xc2, 0x07, 0xc2, 0x02, 0xc8, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x02, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05,
+	0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d,
+	0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15,
+	0x00, 0x16, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d,
+	0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24, 0x00, 0x25,
+	0x00, 0x26, 0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d,
+	0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35,
+	0x00, 0x36, 0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d,
+	0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44, 0x00, 0x45,
+	0x00, 0x46, 0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d,
+	0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54, 0x00, 0x55,
+	0x00, 0x56, 0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d,
+	0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x00, 0x00, 0x00, 0x86, 0x00, 0x87, 0x00, 0x89,
+	0x00, 0x8b, 0x00, 0x93, 0x00, 0x98, 0x00, 0x9e, 0x00, 0xa3, 0x00, 0xa2, 0x00, 0xa4, 0x00, 0xa6,
+	0x00, 0xa5, 0x00, 0xa7, 0x00, 0xa9, 0x00, 0xab, 0x00, 0xaa, 0x00, 0xac, 0x00, 0xad, 0x00, 0xaf,
+	0x00, 0xae, 0x00, 0xb0, 0x00, 0xb1, 0x00, 0xb3, 0x00, 0xb5, 0x00, 0xb4, 0x00, 0xb6, 0x00, 0xb8,
+	0x00, 0xb7, 0x00, 0xbc, 0x00, 0xbb, 0x00, 0xbd, 0x00, 0xbe, 0x02, 0x24, 0x00, 0x72, 0x00, 0x64,
+	0x00, 0x65, 0x00, 0x69, 0x02, 0x26, 0x00, 0x78, 0x00, 0xa1, 0x00, 0x70, 0x00, 0x6b, 0x02, 0x54,
+	0x00, 0x76, 0x00, 0x6a, 0x02, 0x70, 0x00, 0x88, 0x00, 0x9a, 0x02, 0x6a, 0x00, 0x73, 0x02, 0x72,
+	0x02, 0x73, 0x00, 0x67, 0x00, 0x77, 0x02, 0x62, 0x02, 0x65, 0x02, 0x64, 0x01, 0xa0, 0x02, 0x6e,
+	0x00, 0x6c, 0x00, 0x7c, 0x02, 0x55, 0x00, 0xa8, 0x00, 0xba, 0x00, 0x81, 0x00, 0x63, 0x00, 0x6e,
+	0x02, 0x69, 0x01, 0x42, 0x02, 0x6f, 0x02, 0x63, 0x00, 0x6d, 0x00, 0x7d, 0x02, 0x27, 0x00, 0x03,
+	0x00, 0x82, 0x00, 0x85, 0x00, 0x97, 0x01, 0x14, 0x01, 0x15, 0x02, 0x19, 0x02, 0x1a, 0x02, 0x21,
+	0x02, 0x22, 0x02, 0x1d, 0x02, 0x1e, 0x00, 0xb9, 0x02, 0xb1, 0x00, 0xc1, 0x01, 0x3a, 0x02, 0x2f,
+	0x02, 0x50, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0xc3, 0x02, 0xc4, 0x02, 0x25, 0x00, 0x79, 0x02, 0x1f,
+	0x02, 0x23, 0x02, 0x28, 0x00, 0x84, 0x00, 0x8c, 0x00, 0x83, 0x00, 0x8d, 0x00, 0x8a, 0x00, 0x8f,
+	0x00, 0x90, 0x00, 0x91, 0x00, 0x8e, 0x00, 0x95, 0x00, 0x96, 0x00, 0x00, 0x00, 0x94, 0x00, 0x9c,
+	0x00, 0x9d, 0x00, 0x9b, 0x00, 0xf3, 0x01, 0x5d, 0x01, 0x64, 0x00, 0x71, 0x01, 0x60, 0x01, 0x61,
+	0x01, 0x62, 0x00, 0x7a, 0x01, 0x65, 0x01, 0x63, 0x01, 0x5e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x0b,
+	0x00, 0x00, 0x05, 0x0b, 0x05, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x21, 0x11, 0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x01, 0x0b,
+	0x04, 0x00, 0xfc, 0x40, 0x03, 0x80, 0xfc, 0x80, 0x05, 0x00, 0xfb, 0x00, 0x40, 0x04, 0x80, 0x00,
+	0x00, 0x02, 0x00, 0xd3, 0x00, 0x00, 0x02, 0xc9, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x51,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80,
+	0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04,
+	0x00, 0x00, 0x04, 0x09, 0x04, 0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17,
+	0x2b, 0x33, 0x37, 0x33, 0x07, 0x03, 0x13, 0x13, 0x33, 0x03, 0x03, 0xd3, 0x29, 0xd9, 0x29, 0x65,
+	0x82, 0x3b, 0xc5, 0x3b, 0xb3, 0xcf, 0xcf, 0x01, 0x97, 0x03, 0x09, 0x01, 0x28, 0xfe, 0xd8, 0xfc,
+	0xf7, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x53, 0x04, 0x20, 0x03, 0xc1, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x05, 0x03, 0x04, 0x03, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01,
+	0x00, 0x00, 0x3a, 0x01, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x33, 0x03, 0x33, 0x13, 0x33, 0x03,
+	0x01, 0x53, 0x4f, 0xc5, 0x80, 0xc5, 0x4f, 0xc6, 0x81, 0x04, 0x20, 0x02, 0x0b, 0xfd, 0xf5, 0x02,
+	0x0b, 0xfd, 0xf5, 0x00, 0x00, 0x02, 0x00, 0x7c, 0x00, 0x00, 0x05, 0x34, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0xa9, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x28, 0x0e, 0x09, 0x02, 0x01, 0x0c,
+	0x0a, 0x02, 0x00, 0x0b, 0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0f, 0x08, 0x02,
+	0x02, 0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x03, 0x3b, 0x4d, 0x10, 0x0d, 0x02, 0x0b, 0x0b,
+	0x39, 0x0b, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x07, 0x05, 0x02, 0x03, 0x0f,
+	0x08, 0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b,
+	0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x39, 0x0b,
+	0x4e, 0x1b, 0x40, 0x26, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x05, 0x02, 0x03, 0x0f, 0x08,
+	0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b, 0x01,
+	0x00, 0x67, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x59, 0x40, 0x1e, 0x00, 0x00,
+	0x1f, 0x1e, 0x1d, 0x1c, 0x00, 0x1b, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x17, 0x16, 0x15, 0x14, 0x13,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x1f, 0x2b, 0x33, 0x13, 0x23,
+	0x37, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0x33, 0x07, 0x23,
+	0x03, 0x33, 0x07, 0x23, 0x03, 0x23, 0x13, 0x21, 0x03, 0x13, 0x21, 0x13, 0x21, 0x88, 0xc7, 0xd3,
+	0x31, 0xda, 0x9c, 0xec, 0x32, 0xf1, 0xc8, 0x7f, 0xc7, 0x01, 0x07, 0xc7, 0x80, 0xc7, 0xd3, 0x31,
+	0xda, 0x9c, 0xec, 0x31, 0xf2, 0xc7, 0x80, 0xc7, 0xfe, 0xf9, 0xc7, 0xfe, 0x01, 0x08, 0x9c, 0xfe,
+	0xf8, 0x01, 0xbc, 0x7c, 0x01, 0x59, 0x7b, 0x01, 0xbc, 0xfe, 0x44, 0x01, 0xbc, 0xfe, 0x44, 0x7b,
+	0xfe, 0xa7, 0x7c, 0xfe, 0x44, 0x01, 0xbc, 0xfe, 0x44, 0x02, 0x38, 0x01, 0x59, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x96, 0xff, 0x85, 0x04, 0xe3, 0x06, 0x44, 0x00, 0x1f, 0x00, 0x25, 0x00, 0x2a,
+	0x00, 0xb2, 0x40, 0x11, 0x27, 0x25, 0x16, 0x15, 0x13, 0x12, 0x07, 0x04, 0x08, 0x01, 0x02, 0x03,
+	0x01, 0x00, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x05, 0x00,
+	0x00, 0x05, 0x71, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x1c, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x04, 0x01, 0x02, 0x02,
+	0x38, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x02, 0x03, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86,
+	0x04, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x39, 0x00,
+	0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x02, 0x03, 0x85, 0x04, 0x01, 0x02, 0x01, 0x02, 0x85, 0x06,
+	0x01, 0x05, 0x00, 0x05, 0x86, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3c, 0x00, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f, 0x11, 0x11, 0x16, 0x13, 0x11,
+	0x07, 0x09, 0x1b, 0x2b, 0x05, 0x37, 0x22, 0x27, 0x37, 0x16, 0x33, 0x13, 0x26, 0x26, 0x37, 0x36,
+	0x36, 0x37, 0x37, 0x33, 0x07, 0x32, 0x17, 0x07, 0x26, 0x27, 0x03, 0x16, 0x17, 0x16, 0x16, 0x07,
+	0x06, 0x06, 0x07, 0x07, 0x13, 0x36, 0x37, 0x36, 0x26, 0x27, 0x03, 0x13, 0x06, 0x07, 0x06, 0x02,
+	0x02, 0x19, 0xbb, 0xca, 0x22, 0xcc, 0xb4, 0x6a, 0xbb, 0x6f, 0x1a, 0x1e, 0xe8, 0xaa, 0x19, 0x63,
+	0x19, 0x9a, 0xa4, 0x20, 0xaf, 0x8a, 0x69, 0x2e, 0x18, 0x98, 0x52, 0x17, 0x1f, 0xe7, 0xb6, 0x19,
+	0x38, 0xc6, 0x24, 0x0f, 0x30, 0x6c, 0x2f, 0x5b, 0xc6, 0x21, 0x1a, 0x7b, 0x7b, 0x53, 0xaa, 0x69,
+	0x02, 0x13, 0x7c, 0xbd, 0x85, 0x94, 0xc3, 0x0c, 0x7c, 0x7c, 0x43, 0xa1, 0x53, 0x0a, 0xfd, 0xf1,
+	0x21, 0x10, 0x5d, 0x96, 0x6f, 0x9e, 0xe0, 0x21, 0x7b, 0x01, 0x1b, 0x2a, 0xb7, 0x47, 0x5b, 0x4a,
+	0x01, 0x06, 0x01, 0xc8, 0x2b, 0xa7, 0x83, 0x00, 0x00, 0x05, 0x01, 0x05, 0x00, 0x00, 0x07, 0x46,
+	0x05, 0xc8, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x1b, 0x00, 0x27, 0x00, 0x33, 0x00, 0xaf, 0x4b, 0xb0,
+	0x1b, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x04, 0x00, 0x03, 0x09, 0x04, 0x03, 0x69, 0x00, 0x06, 0x00,
+	0x09, 0x08, 0x06, 0x09, 0x69, 0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x0a, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x04, 0x00, 0x03, 0x09, 0x04, 0x03,
+	0x69, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69, 0x00, 0x08, 0x00, 0x07, 0x01, 0x08, 0x07,
+	0x69, 0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x0a, 0x01, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x28, 0x02, 0x01, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x69, 0x00,
+	0x04, 0x00, 0x03, 0x09, 0x04, 0x03, 0x69, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69, 0x00,
+	0x08, 0x00, 0x07, 0x01, 0x08, 0x07, 0x69, 0x0a, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x1a, 0x00, 0x00, 0x32, 0x30, 0x2c, 0x2a, 0x26, 0x24, 0x20, 0x1e, 0x1a, 0x18, 0x14, 0x12,
+	0x0e, 0x0c, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0b, 0x09, 0x17, 0x2b, 0x21, 0x01, 0x33,
+	0x01, 0x03, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x06, 0x16,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x01, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22,
+	0x06, 0x01, 0x05, 0x05, 0xa7, 0x9a, 0xfa, 0x5a, 0x3f, 0x22, 0xd3, 0x9b, 0x9d, 0x84, 0x23, 0x22,
+	0xd3, 0x9c, 0x9f, 0x81, 0xc7, 0x17, 0x3b, 0x4a, 0x4a, 0x78, 0x16, 0x17, 0x3c, 0x4a, 0x49, 0x78,
+	0x02, 0x62, 0x21, 0xde, 0x92, 0x93, 0x8c, 0x22, 0x22, 0xd2, 0x9d, 0x9f, 0x81, 0xc5, 0x15, 0x3b,
+	0x4b, 0x49, 0x77, 0x15, 0x18, 0x3c, 0x49, 0x4a, 0x77, 0x05, 0xc8, 0xfa, 0x38, 0x04, 0x5c, 0xa7,
+	0xc5, 0xc6, 0xac, 0xab, 0xc7, 0xc8, 0xaf, 0x74, 0x96, 0x95, 0x70, 0x71, 0x95, 0x94, 0xfc, 0xd5,
+	0xa7, 0xc5, 0xc7, 0xab, 0xab, 0xc7, 0xc8, 0xa5, 0x6a, 0x96, 0x95, 0x66, 0x7b, 0x95, 0x94, 0x00,
+	0x00, 0x03, 0x00, 0x71, 0xff, 0xdb, 0x05, 0xa0, 0x05, 0xed, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x2d,
+	0x00, 0x6f, 0x40, 0x11, 0x1f, 0x12, 0x08, 0x03, 0x02, 0x05, 0x1a, 0x14, 0x02, 0x04, 0x02, 0x01,
+	0x01, 0x03, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x05, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39,
+	0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00,
+	0x01, 0x00, 0x05, 0x02, 0x01, 0x05, 0x69, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03,
+	0x3c, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x10,
+	0x00, 0x00, 0x2b, 0x29, 0x25, 0x23, 0x00, 0x1c, 0x00, 0x1c, 0x19, 0x28, 0x22, 0x07, 0x09, 0x19,
+	0x2b, 0x21, 0x27, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x25, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x06, 0x05, 0x12, 0x17, 0x36, 0x37, 0x37, 0x33, 0x02, 0x07, 0x16, 0x17, 0x25, 0x26,
+	0x03, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x13, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x04,
+	0x0c, 0x38, 0xdb, 0xb7, 0xdf, 0xf2, 0x29, 0x44, 0x01, 0x76, 0x38, 0x18, 0x1f, 0xdd, 0x9d, 0x95,
+	0x8d, 0x1b, 0x32, 0xfe, 0xa4, 0x6a, 0x7f, 0x7c, 0x22, 0x10, 0xc3, 0x34, 0xf6, 0x41, 0x61, 0xfe,
+	0x7d, 0x98, 0x7a, 0xf0, 0x2b, 0x1f, 0xa2, 0x94, 0x70, 0x26, 0xe3, 0x22, 0x1f, 0x8b, 0x95, 0x21,
+	0x14, 0x57, 0x7c, 0x01, 0x10, 0xcd, 0x01, 0x54, 0x7c, 0x9f, 0x78, 0x9a, 0xb4, 0xa2, 0x8a, 0xf7,
+	0x8a, 0xfe, 0xcf, 0xc7, 0x7e, 0xa9, 0x50, 0xfe, 0xfa, 0xdc, 0x70, 0x6d, 0xca, 0xdf, 0x01, 0x6d,
+	0x63, 0xd5, 0x9a, 0xd5, 0x03, 0x4d, 0x55, 0xac, 0x9c, 0xa4, 0x64, 0x00, 0x00, 0x01, 0x01, 0x53,
+	0x04, 0x0c, 0x02, 0x85, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x01, 0x13, 0x33, 0x03, 0x01, 0x53, 0x3b, 0xf7, 0x9e, 0x04, 0x0c, 0x02, 0x1f,
+	0xfd, 0xe1, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd5, 0xfe, 0xd8, 0x03, 0x9e, 0x06, 0x2b, 0x00, 0x0d,
+	0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b, 0x05, 0x07, 0x26, 0x02, 0x13, 0x12, 0x00, 0x37,
+	0x07, 0x06, 0x02, 0x03, 0x02, 0x12, 0x02, 0x43, 0x1c, 0xba, 0x98, 0x39, 0x39, 0x01, 0x62, 0xf5,
+	0x1b, 0xb3, 0xc7, 0x36, 0x37, 0x34, 0xa0, 0x88, 0x93, 0x01, 0xf9, 0x01, 0x1e, 0x01, 0x1d, 0x01,
+	0xf9, 0x93, 0x88, 0xa0, 0xfe, 0x90, 0xfe, 0xef, 0xfe, 0xee, 0xfe, 0x90, 0x00, 0x01, 0x00, 0x21,
+	0xfe, 0xd8, 0x02, 0xeb, 0x06, 0x2b, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b,
+	0x01, 0x37, 0x16, 0x12, 0x03, 0x02, 0x00, 0x07, 0x37, 0x36, 0x12, 0x13, 0x12, 0x02, 0x01, 0x7d,
+	0x1b, 0xbb, 0x98, 0x39, 0x39, 0xfe, 0x9e, 0xf6, 0x1c, 0xb3, 0xc5, 0x37, 0x36, 0x32, 0x05, 0xa3,
+	0x88, 0x93, 0xfe, 0x07, 0xfe, 0xe3, 0xfe, 0xe2, 0xfe, 0x07, 0x93, 0x88, 0xa0, 0x01, 0x71, 0x01,
+	0x11, 0x01, 0x11, 0x01, 0x70, 0x00, 0x00, 0x00, 0x00, 0x05, 0x01, 0x28, 0x01, 0x06, 0x04, 0xb9,
+	0x04, 0x65, 0x00, 0x06, 0x00, 0x0e, 0x00, 0x16, 0x00, 0x1e, 0x00, 0x26, 0x00, 0x44, 0x40, 0x15,
+	0x09, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x26, 0x22, 0x21, 0x1e, 0x1a, 0x19, 0x16, 0x12, 0x11, 0x0e,
+	0x0a, 0x06, 0x0c, 0x01, 0x49, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x0b, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57,
+	0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x00, 0x01, 0x51, 0x59, 0xb4, 0x22, 0x10, 0x02, 0x09,
+	0x18, 0x2b, 0x01, 0x33, 0x03, 0x26, 0x23, 0x26, 0x07, 0x05, 0x37, 0x37, 0x05, 0x06, 0x07, 0x06,
+	0x15, 0x01, 0x17, 0x17, 0x05, 0x36, 0x27, 0x26, 0x27, 0x13, 0x07, 0x07, 0x03, 0x36, 0x37, 0x36,
+	0x37, 0x01, 0x27, 0x27, 0x01, 0x16, 0x17, 0x16, 0x17, 0x02, 0xda, 0xd0, 0x78, 0x15, 0x20, 0x1e,
+	0x1a, 0xfe, 0x63, 0x33, 0x34, 0x01, 0x29, 0x18, 0x0f, 0x10, 0x02, 0x20, 0x0c, 0x0c, 0xfe, 0x8e,
+	0x09, 0x03, 0x03, 0x0f, 0xd0, 0x61, 0x5f, 0x6e, 0x1c, 0x1d, 0x1d, 0x0e, 0xfe, 0xa4, 0x47, 0x49,
+	0x01, 0x2f, 0x08, 0x16, 0x13, 0x1a, 0x04, 0x65, 0xfe, 0x98, 0x0d, 0x01, 0x0e, 0x2b, 0x61, 0x64,
+	0x9e, 0x13, 0x1e, 0x1b, 0x1a, 0x01, 0x03, 0x64, 0x62, 0x42, 0x1b, 0x1e, 0x1d, 0x11, 0xfe, 0x8a,
+	0x3e, 0x3b, 0x01, 0x40, 0x04, 0x12, 0x12, 0x16, 0xfe, 0x82, 0x3b, 0x3e, 0x01, 0x07, 0x17, 0x13,
+	0x13, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0xda, 0x00, 0x63, 0x04, 0xd3, 0x04, 0x3e, 0x00, 0x0b,
+	0x00, 0x27, 0x40, 0x24, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00,
+	0x05, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x3b, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x13, 0x21, 0x37, 0x21, 0x13, 0x33,
+	0x03, 0x21, 0x07, 0x21, 0x03, 0x02, 0x29, 0x54, 0xfe, 0x5d, 0x1e, 0x01, 0xa3, 0x54, 0x94, 0x54,
+	0x01, 0xa4, 0x1e, 0xfe, 0x5c, 0x54, 0x63, 0x01, 0xa3, 0x94, 0x01, 0xa4, 0xfe, 0x5c, 0x94, 0xfe,
+	0x5d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8d, 0xfe, 0xa2, 0x01, 0xfb, 0x00, 0xf7, 0x00, 0x09,
+	0x00, 0x55, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x39, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x11, 0x03, 0x01, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x39, 0x00, 0x4e, 0x1b, 0x40, 0x11, 0x03, 0x01, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x14, 0x04, 0x09, 0x18, 0x2b, 0x13, 0x37, 0x36, 0x37, 0x37, 0x23, 0x37, 0x33, 0x07,
+	0x02, 0x8d, 0x0e, 0x66, 0x2e, 0x04, 0x60, 0x31, 0xf7, 0x2b, 0x4c, 0xfe, 0xa2, 0x4a, 0x1b, 0xe5,
+	0x14, 0xf7, 0xd6, 0xfe, 0x81, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xda, 0x02, 0x06, 0x04, 0xd4,
+	0x02, 0x9a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0xda, 0x1e, 0x03, 0xdc, 0x1e, 0x02, 0x06, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd3, 0x00, 0x00, 0x02, 0x07, 0x01, 0x01, 0x00, 0x03,
+	0x00, 0x30, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09,
+	0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0xd3, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0xfe, 0xff, 0x00,
+	0x00, 0x01, 0xff, 0xf0, 0xff, 0x74, 0x03, 0x6b, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x2e, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x0c, 0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00, 0x00, 0x00, 0x38, 0x00,
+	0x4e, 0x1b, 0x40, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x59, 0x40,
+	0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x07, 0x01, 0x33, 0x01,
+	0x10, 0x02, 0xe0, 0x9b, 0xfd, 0x1f, 0x8c, 0x06, 0x54, 0xf9, 0xac, 0x00, 0x00, 0x03, 0x00, 0x53,
+	0xff, 0xdb, 0x05, 0x56, 0x05, 0xed, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x17, 0x00, 0x52, 0x40, 0x09,
+	0x17, 0x10, 0x0f, 0x08, 0x04, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x00, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f,
+	0x01, 0x00, 0x13, 0x11, 0x0b, 0x09, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x05, 0x09, 0x16, 0x2b,
+	0x05, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x12, 0x33, 0x20, 0x13, 0x36, 0x36, 0x37,
+	0x37, 0x02, 0x23, 0x20, 0x03, 0x06, 0x06, 0x07, 0x02, 0x3c, 0xfe, 0x17, 0x9c, 0x9b, 0x01, 0xe9,
+	0x01, 0xe3, 0x95, 0x9c, 0xfd, 0x73, 0x04, 0xbe, 0x01, 0x1d, 0x7d, 0x0b, 0x11, 0x04, 0x04, 0x05,
+	0xbe, 0xfe, 0xe4, 0x7e, 0x0c, 0x0f, 0x03, 0x25, 0x03, 0x0a, 0x03, 0x08, 0xfc, 0xf8, 0xfc, 0xf6,
+	0x01, 0xb0, 0xfe, 0xe4, 0x02, 0x72, 0x3a, 0x70, 0x36, 0x7d, 0x01, 0x1b, 0xfd, 0x8b, 0x3c, 0x6c,
+	0x33, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xdd, 0x00, 0x00, 0x04, 0x38, 0x05, 0xed, 0x00, 0x09,
+	0x00, 0x3a, 0xb5, 0x06, 0x04, 0x03, 0x03, 0x00, 0x4a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b,
+	0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x37, 0x21, 0x13,
+	0x05, 0x37, 0x25, 0x01, 0x21, 0x07, 0xdd, 0x1d, 0x01, 0x3c, 0xe9, 0xfe, 0xb5, 0x1e, 0x02, 0x1c,
+	0xfe, 0xee, 0x01, 0x3c, 0x1d, 0x94, 0x04, 0x90, 0x4f, 0x98, 0x80, 0xfa, 0xa7, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x71, 0x00, 0x00, 0x04, 0xba, 0x05, 0xed, 0x00, 0x19, 0x00, 0x4b, 0xb5, 0x0b,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39,
+	0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x19,
+	0x00, 0x19, 0x18, 0x23, 0x28, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x37,
+	0x12, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x07, 0x06, 0x07,
+	0x21, 0x07, 0x71, 0x22, 0x64, 0xc4, 0x82, 0x76, 0xe8, 0x26, 0x36, 0xf2, 0x8e, 0xe8, 0x23, 0xd7,
+	0xb7, 0xc1, 0xb9, 0x26, 0x1a, 0x9f, 0xc3, 0x51, 0xf6, 0x50, 0x02, 0x51, 0x22, 0xad, 0x9f, 0xaa,
+	0x6e, 0x64, 0xc6, 0xbd, 0x01, 0x0f, 0x78, 0xae, 0x5d, 0xe1, 0xbf, 0x82, 0xc9, 0x96, 0x3e, 0xbd,
+	0xc4, 0xad, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa6, 0xff, 0xdb, 0x04, 0xcf, 0x05, 0xed, 0x00, 0x21,
+	0x00, 0x5f, 0x40, 0x0e, 0x14, 0x01, 0x02, 0x03, 0x1b, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x27, 0x23, 0x23, 0x21, 0x23, 0x24, 0x06, 0x09, 0x1c,
+	0x2b, 0x37, 0x37, 0x16, 0x17, 0x16, 0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x23, 0x37, 0x37, 0x32,
+	0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x03, 0x02, 0x05, 0x04, 0x03, 0x06,
+	0x04, 0x23, 0x22, 0xa6, 0x24, 0x1b, 0x0e, 0x9a, 0x5a, 0x01, 0x2d, 0x3a, 0x1e, 0xa8, 0xba, 0x4e,
+	0x1b, 0x44, 0xa9, 0xe0, 0x1c, 0x2c, 0xf3, 0x7c, 0xc5, 0x23, 0xbc, 0x88, 0x01, 0xb0, 0x45, 0x34,
+	0xfe, 0xb6, 0x01, 0x54, 0x3e, 0x28, 0xfe, 0xc6, 0xdf, 0x71, 0x0b, 0xb8, 0x0c, 0x05, 0x43, 0x01,
+	0x24, 0x98, 0xa4, 0x85, 0x01, 0x9d, 0x89, 0xde, 0x53, 0xac, 0x3b, 0xfe, 0xa7, 0xfe, 0xfd, 0x6f,
+	0x52, 0xfe, 0xca, 0xcc, 0xf3, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x7d, 0x00, 0x00, 0x04, 0xad,
+	0x05, 0xc8, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x50, 0xb5, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x05, 0x01, 0x02, 0x03, 0x01, 0x00, 0x04, 0x02, 0x00, 0x68,
+	0x00, 0x01, 0x01, 0x38, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x01, 0x02, 0x01, 0x85, 0x05, 0x01, 0x02, 0x03, 0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x06, 0x01,
+	0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a,
+	0x11, 0x11, 0x12, 0x11, 0x07, 0x09, 0x1a, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x01, 0x33, 0x03, 0x33,
+	0x07, 0x23, 0x03, 0x01, 0x21, 0x13, 0x02, 0xbb, 0x53, 0xfd, 0x6f, 0x1e, 0x03, 0x47, 0xb9, 0xb4,
+	0xc6, 0x20, 0xc6, 0x53, 0xfd, 0xea, 0x01, 0xdd, 0x84, 0x01, 0xa3, 0x95, 0x03, 0x90, 0xfc, 0x7c,
+	0xa1, 0xfe, 0x5d, 0x02, 0x44, 0x02, 0x92, 0x00, 0x00, 0x01, 0x00, 0xac, 0xff, 0xdb, 0x04, 0xda,
+	0x05, 0xc8, 0x00, 0x20, 0x00, 0x56, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1d, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x67, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x05,
+	0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x00, 0x01,
+	0x00, 0x04, 0x01, 0x67, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59,
+	0x40, 0x09, 0x28, 0x21, 0x11, 0x11, 0x28, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x17, 0x37, 0x16, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x13, 0x21, 0x07, 0x21, 0x03, 0x33, 0x32,
+	0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0xac, 0x23, 0x89, 0x84, 0x52, 0x80, 0x5e, 0x3b,
+	0x0e, 0x0f, 0x15, 0x4f, 0x8e, 0x6d, 0xaa, 0x93, 0x02, 0xec, 0x22, 0xfd, 0xc1, 0x53, 0x41, 0x81,
+	0xbd, 0x73, 0x26, 0x17, 0x19, 0x7d, 0xae, 0xd1, 0x6e, 0x38, 0x7b, 0x06, 0xb0, 0x3b, 0x31, 0x57,
+	0x76, 0x45, 0x48, 0x72, 0x50, 0x2a, 0x02, 0xe2, 0xac, 0xfe, 0x61, 0x3c, 0x74, 0xab, 0x70, 0x7e,
+	0xb3, 0x72, 0x34, 0x0f, 0x00, 0x02, 0x00, 0xa5, 0xff, 0xdb, 0x04, 0xe2, 0x05, 0xee, 0x00, 0x14,
+	0x00, 0x1e, 0x00, 0x5b, 0x40, 0x0a, 0x10, 0x01, 0x03, 0x02, 0x11, 0x01, 0x00, 0x03, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00,
+	0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x69, 0x00,
+	0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42,
+	0x01, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x01,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x00, 0x23, 0x22, 0x02, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17,
+	0x07, 0x26, 0x23, 0x20, 0x01, 0x12, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x01, 0xd0,
+	0xa5, 0xcc, 0xb8, 0xa1, 0x2b, 0x33, 0xfe, 0xdd, 0xde, 0xe1, 0xb5, 0x43, 0x4e, 0x01, 0x8e, 0x01,
+	0x14, 0x82, 0x88, 0x23, 0xa1, 0x64, 0xfe, 0x8c, 0x01, 0x5d, 0x4a, 0xf7, 0x80, 0xbb, 0x1d, 0x22,
+	0x74, 0x7b, 0xf7, 0x03, 0x0a, 0xac, 0xf7, 0xd8, 0xfc, 0xfe, 0xf0, 0x01, 0x85, 0x01, 0x52, 0x01,
+	0x86, 0x01, 0xb6, 0x38, 0xac, 0x50, 0xfc, 0x5e, 0x01, 0x70, 0xac, 0x91, 0xa6, 0xd6, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xf8, 0x00, 0x00, 0x05, 0x70, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x39, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x03,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x67, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x0a, 0x00,
+	0x0a, 0x11, 0x14, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x36, 0x36, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07,
+	0x00, 0x03, 0xf8, 0x3f, 0x9d, 0xa5, 0x02, 0x16, 0xfd, 0x06, 0x25, 0x03, 0xb6, 0x25, 0xfd, 0x1e,
+	0x9d, 0xad, 0xfc, 0xdc, 0x02, 0x8a, 0xb9, 0xb9, 0xfc, 0xb8, 0xfe, 0x39, 0x00, 0x03, 0x00, 0x92,
+	0xff, 0xdb, 0x05, 0x1c, 0x05, 0xed, 0x00, 0x13, 0x00, 0x1e, 0x00, 0x2b, 0x00, 0x43, 0xb5, 0x0a,
+	0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3f, 0x01,
+	0x4e, 0x1b, 0x40, 0x13, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x02, 0x69, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0xb6, 0x2a, 0x28, 0x28, 0x24, 0x04, 0x09, 0x1a,
+	0x2b, 0x01, 0x26, 0x37, 0x36, 0x24, 0x33, 0x32, 0x16, 0x07, 0x06, 0x05, 0x04, 0x03, 0x06, 0x04,
+	0x23, 0x22, 0x26, 0x37, 0x12, 0x25, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16,
+	0x07, 0x06, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x27, 0x02, 0x35, 0xbb,
+	0x24, 0x22, 0x01, 0x20, 0xc6, 0xb8, 0xbe, 0x1d, 0x2a, 0xfe, 0xc9, 0x01, 0x2e, 0x34, 0x25, 0xfe,
+	0xb8, 0xde, 0xdc, 0xdf, 0x25, 0x35, 0x02, 0x50, 0xea, 0x20, 0x13, 0x72, 0x75, 0x6e, 0x9a, 0x12,
+	0x10, 0x61, 0x19, 0x8a, 0x70, 0x14, 0x1a, 0x85, 0x86, 0x81, 0xbd, 0x16, 0x11, 0x4c, 0x81, 0x03,
+	0x26, 0x97, 0xb7, 0xa8, 0xd1, 0xb1, 0x92, 0xd3, 0xb1, 0xa4, 0xfe, 0xfd, 0xba, 0xea, 0xde, 0xb9,
+	0x01, 0x05, 0xed, 0x89, 0x9e, 0x5f, 0x6f, 0x69, 0x58, 0x52, 0x84, 0xec, 0x5c, 0x89, 0x65, 0x80,
+	0x9d, 0x86, 0x6b, 0x56, 0x77, 0x56, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb1, 0xff, 0xda, 0x04, 0xef,
+	0x05, 0xee, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x5b, 0x40, 0x0a, 0x11, 0x01, 0x03, 0x00, 0x10, 0x01,
+	0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x04, 0x00, 0x00, 0x03,
+	0x04, 0x00, 0x69, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05, 0x04,
+	0x01, 0x05, 0x69, 0x00, 0x04, 0x00, 0x00, 0x03, 0x04, 0x00, 0x69, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06,
+	0x09, 0x1c, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x20, 0x01, 0x02, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26,
+	0x23, 0x22, 0x03, 0xc4, 0xa6, 0xcc, 0xb8, 0xa0, 0x2b, 0x32, 0x01, 0x24, 0xdd, 0xe1, 0xb6, 0x44,
+	0x4e, 0xfe, 0x73, 0xfe, 0xec, 0x83, 0x88, 0x22, 0xa3, 0x64, 0x01, 0x74, 0xfe, 0xa2, 0x49, 0xf7,
+	0x80, 0xbb, 0x1d, 0x21, 0x74, 0x7b, 0xf6, 0x02, 0xbe, 0xac, 0xf7, 0xd9, 0xfb, 0x01, 0x11, 0xfe,
+	0x7a, 0xfe, 0xae, 0xfe, 0x7a, 0xfe, 0x4a, 0x38, 0xac, 0x4f, 0x03, 0xa1, 0xfe, 0x90, 0xac, 0x91,
+	0xa6, 0xd6, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd3, 0x00, 0x00, 0x02, 0xa5, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x4e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3b, 0x4d,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09,
+	0x17, 0x2b, 0x33, 0x37, 0x33, 0x07, 0x03, 0x37, 0x33, 0x07, 0xd3, 0x31, 0xf7, 0x31, 0x4d, 0x31,
+	0xf7, 0x31, 0xf7, 0xf7, 0x03, 0x53, 0xf7, 0xf7, 0x00, 0x02, 0x00, 0x8d, 0xfe, 0xa2, 0x02, 0xa5,
+	0x04, 0x4a, 0x00, 0x03, 0x00, 0x0d, 0x00, 0x7f, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x1c, 0x05,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x39, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59,
+	0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0d, 0x04, 0x0d, 0x0b, 0x0a, 0x09, 0x08, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x01, 0x37, 0x36, 0x37, 0x37,
+	0x23, 0x37, 0x33, 0x07, 0x02, 0x01, 0x7d, 0x31, 0xf7, 0x31, 0xfe, 0x19, 0x0e, 0x66, 0x2e, 0x04,
+	0x60, 0x31, 0xf7, 0x2b, 0x4c, 0x03, 0x53, 0xf7, 0xf7, 0xfb, 0x4f, 0x4a, 0x1b, 0xe5, 0x14, 0xf7,
+	0xd6, 0xfe, 0x81, 0x00, 0x00, 0x01, 0x00, 0xe9, 0x00, 0x63, 0x05, 0x27, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x25, 0x01, 0x01, 0x07, 0x01, 0x15, 0x01, 0x04,
+	0x61, 0xfc, 0x88, 0x04, 0x3e, 0x22, 0xfd, 0x31, 0x02, 0x4c, 0x63, 0x01, 0xed, 0x01, 0xee, 0xa6,
+	0xfe, 0xb9, 0x02, 0xfe, 0xb9, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x63, 0x01, 0x26, 0x05, 0x4a,
+	0x03, 0x7a, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x63,
+	0x22, 0x04, 0x70, 0x22, 0xfb, 0xe5, 0x22, 0x04, 0x70, 0x22, 0x01, 0x26, 0xaa, 0xaa, 0x01, 0xaa,
+	0xaa, 0xaa, 0x00, 0x00, 0x00, 0x01, 0x00, 0x86, 0x00, 0x63, 0x04, 0xc4, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x09, 0x02, 0x37, 0x01, 0x35, 0x01, 0x01, 0x4c,
+	0x03, 0x78, 0xfb, 0xc2, 0x21, 0x02, 0xd0, 0xfd, 0xb3, 0x04, 0x3e, 0xfe, 0x12, 0xfe, 0x13, 0xa5,
+	0x01, 0x47, 0x02, 0x01, 0x47, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x97, 0x00, 0x00, 0x05, 0x30,
+	0x05, 0xed, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x65, 0xb5, 0x0e, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x02, 0x00, 0x02, 0x04, 0x00, 0x80, 0x00,
+	0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x02, 0x00, 0x02, 0x04, 0x00,
+	0x80, 0x00, 0x03, 0x00, 0x02, 0x04, 0x03, 0x02, 0x69, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01,
+	0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x1a, 0x04, 0x1a,
+	0x12, 0x10, 0x0d, 0x0b, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x21, 0x37, 0x33,
+	0x07, 0x03, 0x37, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36, 0x21, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20,
+	0x03, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x07, 0x01, 0x97, 0x27, 0xc5, 0x27, 0x77, 0x0b, 0x31,
+	0xb3, 0x5d, 0xcb, 0x1d, 0x27, 0xfe, 0xed, 0xae, 0xc7, 0x22, 0xbd, 0xc3, 0x01, 0xd6, 0x46, 0x23,
+	0xd7, 0x51, 0x70, 0x56, 0x19, 0x16, 0xc5, 0xc5, 0x01, 0x8b, 0x36, 0xf5, 0x80, 0x45, 0x89, 0x90,
+	0xc5, 0x45, 0xa7, 0x32, 0xfe, 0xa6, 0xb4, 0x78, 0x32, 0x3e, 0x82, 0x7c, 0x6e, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x45, 0xff, 0xdb, 0x08, 0x26, 0x05, 0xed, 0x00, 0x33, 0x00, 0x3d, 0x00, 0x8e,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0b, 0x35, 0x13, 0x02, 0x05, 0x08, 0x33, 0x01, 0x07, 0x02,
+	0x02, 0x4c, 0x1b, 0x40, 0x0b, 0x35, 0x13, 0x02, 0x09, 0x08, 0x33, 0x01, 0x07, 0x02, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x04, 0x00, 0x08, 0x05, 0x04, 0x08, 0x69,
+	0x09, 0x01, 0x05, 0x03, 0x01, 0x02, 0x07, 0x05, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x2a, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x04, 0x00, 0x08, 0x09, 0x04,
+	0x08, 0x69, 0x00, 0x09, 0x05, 0x02, 0x09, 0x59, 0x00, 0x05, 0x03, 0x01, 0x02, 0x07, 0x05, 0x02,
+	0x69, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x3d,
+	0x3b, 0x24, 0x24, 0x24, 0x24, 0x63, 0x26, 0x24, 0x24, 0x21, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x06,
+	0x23, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x06, 0x00, 0x23, 0x22, 0x37, 0x36,
+	0x37, 0x37, 0x23, 0x02, 0x23, 0x22, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x16, 0x33, 0x33, 0x03,
+	0x06, 0x07, 0x06, 0x33, 0x32, 0x00, 0x37, 0x36, 0x00, 0x23, 0x20, 0x00, 0x03, 0x06, 0x12, 0x33,
+	0x32, 0x37, 0x13, 0x37, 0x26, 0x23, 0x22, 0x02, 0x07, 0x06, 0x33, 0x32, 0x04, 0xf8, 0xc4, 0xad,
+	0xfe, 0xe7, 0xfe, 0xd7, 0x36, 0x4a, 0x02, 0xa6, 0x01, 0x73, 0x01, 0x18, 0x01, 0x30, 0x36, 0x31,
+	0xfe, 0x8d, 0xd8, 0xa6, 0x17, 0x08, 0x22, 0x41, 0x0c, 0xfa, 0xce, 0xc1, 0x2c, 0x33, 0x01, 0x94,
+	0xca, 0x1f, 0x2f, 0x31, 0x1d, 0x89, 0xfd, 0x0d, 0x06, 0x0d, 0x4b, 0x86, 0x01, 0x09, 0x24, 0x2e,
+	0xfe, 0xff, 0xf2, 0xfe, 0xc3, 0xfd, 0xb0, 0x40, 0x2d, 0xfb, 0xf2, 0x9e, 0x9f, 0x99, 0x4e, 0x55,
+	0x44, 0x8d, 0xf6, 0x2a, 0x1d, 0x52, 0x87, 0x2c, 0x51, 0x01, 0x5b, 0x01, 0x0a, 0x01, 0x76, 0x02,
+	0x37, 0xfe, 0x98, 0xfe, 0xf5, 0xf8, 0xfe, 0xa6, 0x73, 0x29, 0x40, 0x7e, 0xfe, 0xa6, 0xdd, 0x01,
+	0x00, 0x01, 0x95, 0x03, 0x03, 0xfd, 0x84, 0x20, 0x1e, 0x43, 0x01, 0x1c, 0xb6, 0xe6, 0x01, 0x30,
+	0xfe, 0x0d, 0xfe, 0xbf, 0xe2, 0xfe, 0xe1, 0x48, 0x02, 0xaf, 0xc3, 0x21, 0xfe, 0xe2, 0xd6, 0x8e,
+	0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x49, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d,
+	0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x68, 0x05, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00,
+	0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33,
+	0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49,
+	0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66,
+	0x02, 0x36, 0x02, 0x7a, 0x00, 0x03, 0x00, 0xb0, 0x00, 0x00, 0x05, 0xa8, 0x05, 0xc8, 0x00, 0x0e,
+	0x00, 0x17, 0x00, 0x1f, 0x00, 0x61, 0xb5, 0x07, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01,
+	0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e,
+	0x00, 0x0d, 0x21, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x16, 0x07, 0x02, 0x05, 0x04,
+	0x03, 0x06, 0x07, 0x06, 0x06, 0x23, 0x25, 0x33, 0x20, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37,
+	0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x23, 0xb0, 0x01, 0x27, 0x01, 0xda, 0x01, 0x24, 0xd3, 0x25,
+	0x36, 0xfe, 0xa4, 0x01, 0x6d, 0x3a, 0x1d, 0x64, 0x50, 0xc4, 0xd1, 0xfe, 0xe3, 0x9b, 0x01, 0x28,
+	0xc8, 0x1c, 0x1f, 0xce, 0xe1, 0xab, 0x1a, 0xb3, 0x01, 0x92, 0x38, 0x19, 0x8e, 0xe3, 0xc2, 0x05,
+	0xc8, 0x97, 0xb8, 0xfe, 0xf2, 0x68, 0x6a, 0xfe, 0xda, 0x8f, 0x61, 0x4e, 0x35, 0x9d, 0x57, 0x8c,
+	0x98, 0xa1, 0x85, 0x01, 0x19, 0x7c, 0x58, 0x00, 0x00, 0x01, 0x00, 0xc6, 0xff, 0xdb, 0x06, 0x73,
+	0x05, 0xed, 0x00, 0x15, 0x00, 0x49, 0x40, 0x0b, 0x0a, 0x01, 0x02, 0x01, 0x15, 0x0b, 0x02, 0x03,
+	0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0xb6, 0x24, 0x23, 0x24, 0x21, 0x04, 0x09, 0x1a, 0x2b, 0x25,
+	0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03,
+	0x02, 0x12, 0x21, 0x32, 0x25, 0x05, 0x62, 0xf2, 0xfe, 0xf2, 0xfe, 0x92, 0xfe, 0xd2, 0x4c, 0x4c,
+	0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe, 0xe3, 0xb4, 0xff, 0xfe, 0xb5, 0x3d, 0x3a, 0xde,
+	0x01, 0x05, 0xdf, 0x01, 0x0b, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41,
+	0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x00, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x06, 0x9c, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x12, 0x00, 0x46, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x16, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x03,
+	0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x12, 0x10, 0x0a, 0x08, 0x00, 0x07, 0x00, 0x06, 0x21, 0x05, 0x09,
+	0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x00, 0x21, 0x27, 0x33, 0x20, 0x00, 0x13, 0x12,
+	0x27, 0x26, 0x26, 0x23, 0x23, 0xb0, 0x01, 0x27, 0x01, 0xda, 0x02, 0xeb, 0x8d, 0x49, 0xfe, 0x2a,
+	0xfe, 0x9d, 0xec, 0xfc, 0x01, 0x0e, 0x01, 0x43, 0x3c, 0x35, 0x61, 0x3b, 0xc8, 0xd6, 0x9b, 0x05,
+	0xc8, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27, 0x01, 0x2f, 0x01, 0x05, 0x95, 0x5b,
+	0x43, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x56, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x07, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f,
+	0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x00,
+	0x00, 0x01, 0x00, 0xca, 0x00, 0x00, 0x05, 0xde, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x4b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40,
+	0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21,
+	0x07, 0x21, 0x03, 0xca, 0x01, 0x27, 0x03, 0xed, 0x1f, 0xfc, 0xe5, 0x63, 0x02, 0xb7, 0x1f, 0xfd,
+	0x49, 0x86, 0x05, 0xc8, 0x9d, 0xfe, 0x10, 0x9b, 0xfd, 0x60, 0x00, 0x00, 0x00, 0x01, 0x00, 0x60,
+	0xff, 0xdb, 0x06, 0xa7, 0x05, 0xed, 0x00, 0x17, 0x00, 0x62, 0x40, 0x0a, 0x0a, 0x01, 0x02, 0x01,
+	0x0b, 0x01, 0x05, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x06, 0x01, 0x05,
+	0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01,
+	0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x06, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00,
+	0x17, 0x00, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x03, 0x04, 0x21,
+	0x20, 0x13, 0x12, 0x00, 0x21, 0x20, 0x05, 0x07, 0x24, 0x23, 0x20, 0x03, 0x02, 0x12, 0x21, 0x32,
+	0x37, 0x13, 0x23, 0x37, 0x06, 0x11, 0x82, 0xfe, 0xe9, 0xfe, 0xef, 0xfc, 0xf9, 0x9b, 0x4b, 0x01,
+	0xe3, 0x01, 0x75, 0x01, 0x08, 0x01, 0x01, 0x27, 0xfe, 0xdb, 0xdd, 0xfd, 0xda, 0x7c, 0x3c, 0xef,
+	0x01, 0x1b, 0x74, 0xb8, 0x4b, 0xf7, 0x1f, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78,
+	0x01, 0x94, 0x43, 0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0x00,
+	0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x02, 0x01,
+	0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x06, 0x05, 0x02, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x21, 0x13, 0x33, 0x01, 0x23, 0x13,
+	0x21, 0x03, 0xb0, 0x01, 0x27, 0xd2, 0x7c, 0x02, 0xd9, 0x7c, 0xd1, 0xfe, 0xd9, 0xd1, 0x8b, 0xfd,
+	0x27, 0x8b, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb, 0xfd, 0x45, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x87, 0x00, 0x00, 0x03, 0xe7, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x16,
+	0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4,
+	0x1f, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x00, 0x00, 0x00, 0x00, 0x01, 0xff, 0xf1,
+	0xfe, 0xd8, 0x04, 0x5d, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x45, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00,
+	0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x00,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x22, 0x11, 0x13, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x07,
+	0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x23, 0x37, 0x21, 0x01, 0x02, 0x21, 0x22, 0x0f, 0x23,
+	0x97, 0x95, 0x9f, 0x84, 0x24, 0xe5, 0xfa, 0x1f, 0x01, 0xcc, 0xfe, 0xfe, 0x61, 0xfe, 0x1e, 0xa7,
+	0xe8, 0xb5, 0x4d, 0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x00, 0x01, 0x00, 0xca,
+	0x00, 0x00, 0x05, 0xf0, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a,
+	0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33, 0x01,
+	0x01, 0x21, 0x01, 0x03, 0xca, 0x01, 0x27, 0xc5, 0x91, 0x02, 0xf8, 0xd3, 0xfd, 0x1f, 0x02, 0x21,
+	0xfe, 0xf6, 0xfd, 0xfe, 0x95, 0x05, 0xc8, 0xfd, 0x28, 0x02, 0xd8, 0xfd, 0x3e, 0xfc, 0xfa, 0x02,
+	0xee, 0xfd, 0x12, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x04, 0x77, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x3b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01,
+	0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x0b, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x33,
+	0x01, 0x21, 0x07, 0xb0, 0x01, 0x27, 0xd2, 0xfe, 0xf8, 0x02, 0xd6, 0x1f, 0x05, 0xc8, 0xfa, 0xd5,
+	0x9d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x07, 0x37, 0x05, 0xc8, 0x00, 0x0c,
+	0x00, 0x4d, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x13, 0x01, 0x01, 0x00, 0x03, 0x00,
+	0x85, 0x00, 0x03, 0x02, 0x03, 0x85, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33,
+	0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x23, 0x13, 0x01, 0x23, 0x03, 0x03, 0xb0, 0x01, 0x27, 0x01,
+	0x23, 0xb2, 0x02, 0x87, 0x01, 0x04, 0xfe, 0xd9, 0xc4, 0xf0, 0xfd, 0x8f, 0xcb, 0xaa, 0xf1, 0x05,
+	0xc8, 0xfb, 0x87, 0x04, 0x79, 0xfa, 0x38, 0x04, 0xb3, 0xfb, 0xb0, 0x04, 0x54, 0xfb, 0x49, 0x00,
+	0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08,
+	0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01,
+	0x00, 0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01,
+	0x13, 0x33, 0x01, 0x23, 0x01, 0x03, 0xb0, 0x01, 0x27, 0xcd, 0x02, 0x17, 0xe4, 0xb4, 0xfe, 0xd9,
+	0xce, 0xfd, 0xea, 0xe4, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89,
+	0x00, 0x02, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc2, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01,
+	0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a,
+	0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01,
+	0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23,
+	0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb2,
+	0x00, 0x00, 0x06, 0x03, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00,
+	0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00,
+	0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x32, 0x16, 0x17, 0x16,
+	0x07, 0x02, 0x21, 0x21, 0x03, 0x13, 0x21, 0x20, 0x13, 0x36, 0x26, 0x23, 0x21, 0xb2, 0x01, 0x27,
+	0x02, 0x1c, 0xe4, 0xbd, 0x31, 0x3c, 0x22, 0x67, 0xfd, 0x87, 0xfe, 0xf4, 0x71, 0x91, 0x01, 0x03,
+	0x01, 0xa4, 0x44, 0x1e, 0x98, 0xf2, 0xfe, 0xf8, 0x05, 0xc8, 0x34, 0x4d, 0x60, 0xad, 0xfd, 0xfe,
+	0xfd, 0xc8, 0x02, 0xd7, 0x01, 0x54, 0x99, 0x67, 0x00, 0x02, 0x00, 0xb7, 0xfe, 0xd8, 0x06, 0xc3,
+	0x05, 0xed, 0x00, 0x11, 0x00, 0x1d, 0x00, 0x48, 0x40, 0x0a, 0x10, 0x01, 0x00, 0x03, 0x01, 0x4c,
+	0x01, 0x01, 0x00, 0x49, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e,
+	0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0xb6, 0x24, 0x28, 0x24, 0x24, 0x04, 0x09, 0x1a, 0x2b,
+	0x05, 0x07, 0x24, 0x27, 0x06, 0x23, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02,
+	0x05, 0x16, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x33, 0x32, 0x00, 0x06, 0x58,
+	0xa7, 0xfe, 0xba, 0xcc, 0x65, 0x36, 0xfe, 0xd6, 0xfe, 0xdd, 0x44, 0x47, 0x01, 0xd3, 0x01, 0x3e,
+	0x01, 0x44, 0x01, 0x2c, 0x47, 0x66, 0xfe, 0x54, 0xe1, 0x4e, 0x3c, 0xbb, 0xe8, 0xde, 0xfe, 0xc3,
+	0x3b, 0x3a, 0xba, 0xde, 0xe3, 0x01, 0x42, 0x81, 0xa7, 0x72, 0x9b, 0x0b, 0x01, 0xb3, 0x01, 0x57,
+	0x01, 0x61, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0x9c, 0xfe, 0x04, 0xc8, 0x6f, 0x03, 0x2c, 0x01, 0x2d,
+	0x01, 0x48, 0xfe, 0xb7, 0xfe, 0xdd, 0xfe, 0xdd, 0xfe, 0xb7, 0x01, 0x44, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x06, 0x09, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x57, 0xb5, 0x06, 0x01, 0x02,
+	0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x03, 0x02, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x06, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x07,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x01, 0x21, 0x01, 0x21, 0x03, 0x13,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21, 0xb0, 0x01, 0x27, 0x02, 0x6a, 0x01, 0xc8, 0x49,
+	0x3b, 0xfe, 0xbc, 0x01, 0x64, 0xfe, 0xfe, 0xfe, 0xd8, 0xfe, 0x84, 0x7d, 0x9c, 0xeb, 0xd6, 0xe5,
+	0x20, 0x18, 0x8b, 0xbb, 0xfe, 0xd4, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b, 0x02,
+	0x72, 0xfd, 0x8e, 0x03, 0x0f, 0x94, 0xa1, 0x7c, 0x6b, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8d,
+	0xff, 0xdb, 0x05, 0xac, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x49, 0x40, 0x0b, 0x0f, 0x01, 0x02, 0x01,
+	0x10, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6, 0x2a, 0x23, 0x28, 0x22, 0x04,
+	0x09, 0x1a, 0x2b, 0x37, 0x37, 0x04, 0x21, 0x20, 0x37, 0x36, 0x26, 0x27, 0x27, 0x24, 0x13, 0x12,
+	0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x07, 0x06, 0x16, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06,
+	0x04, 0x23, 0x20, 0x8d, 0x29, 0x01, 0x01, 0x01, 0x31, 0x01, 0x3d, 0x30, 0x15, 0x64, 0xb0, 0xbc,
+	0xfe, 0x97, 0x38, 0x51, 0x02, 0x1c, 0xf4, 0xe2, 0x27, 0xe4, 0xf8, 0xfe, 0xbc, 0x2c, 0x11, 0x63,
+	0x98, 0xc0, 0xda, 0x97, 0x21, 0x27, 0xfe, 0xaf, 0xf9, 0xfe, 0xf3, 0x34, 0xd0, 0x8c, 0xef, 0x6a,
+	0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43,
+	0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x27, 0x00, 0x00, 0x06, 0x00,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x3c,
+	0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x09,
+	0x19, 0x2b, 0x21, 0x01, 0x21, 0x37, 0x21, 0x07, 0x21, 0x01, 0x02, 0x13, 0x01, 0x08, 0xfe, 0x0c,
+	0x1f, 0x04, 0xba, 0x1f, 0xfe, 0x0c, 0xfe, 0xf8, 0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x05, 0xc8, 0x00, 0x15, 0x00, 0x36, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x11, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x11, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6, 0x25, 0x13, 0x25, 0x10,
+	0x04, 0x09, 0x1a, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13,
+	0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16,
+	0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd,
+	0xe2, 0x3d, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56,
+	0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x00, 0x01, 0x01, 0x56, 0x00, 0x00, 0x06, 0x7d,
+	0x05, 0xc8, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x11, 0x04, 0x09, 0x18, 0x2b,
+	0x21, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x02, 0x6a, 0xfe, 0xec, 0xd8, 0xe5, 0x02, 0xb7, 0xb3,
+	0xfc, 0xb3, 0x05, 0xc8, 0xfb, 0x41, 0x04, 0xbf, 0xfa, 0x38, 0x00, 0x00, 0x00, 0x01, 0x01, 0x4b,
+	0x00, 0x00, 0x08, 0xa6, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x42, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38,
+	0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00,
+	0x00, 0x03, 0x5f, 0x05, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00,
+	0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13,
+	0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x01, 0x01, 0xa1, 0x56, 0xca, 0x46, 0x02, 0x44,
+	0xca, 0x66, 0x02, 0x2c, 0xab, 0xfd, 0x39, 0xd0, 0x66, 0xfd, 0xc8, 0x05, 0xc8, 0xfb, 0x6f, 0x04,
+	0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x00, 0x00, 0x01, 0x00, 0x27,
+	0x00, 0x00, 0x06, 0x61, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01,
+	0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x33, 0x01,
+	0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x01, 0x27, 0x02, 0xb3, 0xfe, 0x8c, 0xf8, 0x01, 0x1e, 0x02,
+	0x1e, 0xc7, 0xfd, 0x61, 0x01, 0x83, 0xf8, 0xfe, 0xd3, 0xfd, 0xcd, 0x02, 0xdf, 0x02, 0xe9, 0xfd,
+	0xc1, 0x02, 0x3f, 0xfd, 0x3a, 0xfc, 0xfe, 0x02, 0x56, 0xfd, 0xaa, 0x00, 0x00, 0x01, 0x01, 0x50,
+	0x00, 0x00, 0x06, 0x6b, 0x05, 0xc8, 0x00, 0x08, 0x00, 0x3b, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00,
+	0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x03,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x03,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x08, 0x00, 0x08, 0x12,
+	0x12, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x03, 0x02, 0x3c,
+	0x7b, 0xfe, 0x99, 0xf0, 0x01, 0x1c, 0x02, 0x4c, 0xc3, 0xfd, 0x1f, 0x7c, 0x02, 0x69, 0x03, 0x5f,
+	0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x00, 0x01, 0x00, 0x70, 0x00, 0x00, 0x05, 0xae,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x44, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21,
+	0x07, 0x01, 0x21, 0x07, 0x70, 0x21, 0x04, 0x02, 0xfd, 0x16, 0x1f, 0x03, 0xe6, 0x1f, 0xfb, 0xfe,
+	0x03, 0x1b, 0x21, 0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x00, 0x00, 0x01, 0x00, 0x3d,
+	0xfe, 0xd8, 0x03, 0x3f, 0x06, 0x2b, 0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x00, 0x02, 0x04, 0x01,
+	0x03, 0x02, 0x03, 0x63, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x13, 0x01, 0x21, 0x07,
+	0x23, 0x01, 0x33, 0x07, 0x3d, 0x01, 0x77, 0x01, 0x8b, 0x1e, 0xde, 0xfe, 0xc5, 0xde, 0x1e, 0xfe,
+	0xd8, 0x07, 0x53, 0x94, 0xf9, 0xd5, 0x94, 0x00, 0x00, 0x01, 0x01, 0x28, 0xff, 0x74, 0x02, 0x28,
+	0x05, 0x96, 0x00, 0x03, 0x00, 0x26, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x0b, 0x00, 0x00, 0x01,
+	0x00, 0x86, 0x00, 0x01, 0x01, 0x38, 0x01, 0x4e, 0x1b, 0x40, 0x09, 0x00, 0x01, 0x00, 0x01, 0x85,
+	0x00, 0x00, 0x00, 0x76, 0x59, 0xb4, 0x11, 0x10, 0x02, 0x09, 0x18, 0x2b, 0x05, 0x23, 0x03, 0x33,
+	0x02, 0x28, 0x9b, 0x65, 0x9b, 0x8c, 0x06, 0x22, 0x00, 0x01, 0x00, 0x0f, 0xfe, 0xd8, 0x03, 0x11,
+	0x06, 0x2b, 0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x63, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3a, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x01, 0x21, 0x37, 0x33, 0x01, 0x23, 0x37,
+	0x03, 0x11, 0xfe, 0x89, 0xfe, 0x75, 0x1e, 0xde, 0x01, 0x3b, 0xde, 0x1e, 0x06, 0x2b, 0xf8, 0xad,
+	0x94, 0x06, 0x2b, 0x94, 0x00, 0x01, 0x00, 0xdd, 0x02, 0xbf, 0x04, 0x11, 0x05, 0xed, 0x00, 0x05,
+	0x00, 0x19, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0e, 0x03, 0x01, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00,
+	0x76, 0x12, 0x11, 0x02, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x01, 0x23, 0x01, 0x13,
+	0x23, 0x02, 0xd7, 0xfe, 0xab, 0xa5, 0x02, 0x3d, 0xf7, 0xa6, 0x04, 0xa2, 0xfe, 0x1d, 0x03, 0x2e,
+	0xfc, 0xd2, 0x00, 0x00, 0x00, 0x01, 0xff, 0xee, 0xff, 0x6c, 0x04, 0x7e, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x07, 0x37, 0x21, 0x07, 0x12, 0x1d, 0x04, 0x73,
+	0x1e, 0x94, 0x94, 0x94, 0x00, 0x01, 0x01, 0xb5, 0x05, 0x03, 0x03, 0x4a, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x19, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0e, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00,
+	0x76, 0x11, 0x10, 0x02, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x23, 0x01, 0x33, 0x03,
+	0x4a, 0x94, 0xfe, 0xff, 0xe4, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x96,
+	0xff, 0xe7, 0x04, 0xbc, 0x04, 0x56, 0x00, 0x09, 0x00, 0x17, 0x00, 0xa0, 0xb5, 0x01, 0x01, 0x01,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04,
+	0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01,
+	0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x04, 0x3b,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04,
+	0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x3c, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x09, 0x11, 0x11, 0x24, 0x22, 0x23, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x13, 0x26, 0x23,
+	0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32,
+	0x17, 0x33, 0x03, 0x23, 0x03, 0x6a, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81,
+	0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x01,
+	0x7e, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01,
+	0x38, 0x18, 0xfb, 0xc2, 0x00, 0x02, 0x00, 0xa2, 0xff, 0xe7, 0x04, 0xca, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x17, 0x00, 0x80, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58,
+	0x40, 0x1b, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x11, 0x11, 0x24, 0x22, 0x23, 0x22,
+	0x06, 0x09, 0x1c, 0x2b, 0x01, 0x03, 0x16, 0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x36,
+	0x33, 0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x07, 0x01, 0x33, 0x01, 0xf6, 0x6f, 0x83,
+	0x45, 0x01, 0x1b, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0xfe,
+	0xb7, 0xf5, 0x5f, 0x59, 0xc8, 0x01, 0x3e, 0xc5, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1,
+	0xcd, 0x38, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1, 0xfe, 0xc8, 0x19, 0x0c, 0x06, 0x37, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x9a, 0xff, 0xe7, 0x04, 0x73, 0x04, 0x56, 0x00, 0x14, 0x00, 0x2a, 0x40, 0x27,
+	0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x23, 0x23, 0x24, 0x21, 0x04, 0x09, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00,
+	0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x03, 0xaf, 0xb7,
+	0xb0, 0xda, 0xd4, 0x33, 0x35, 0x01, 0x53, 0xf8, 0x84, 0xa2, 0x21, 0x96, 0x64, 0xfe, 0xa1, 0x53,
+	0x27, 0x8b, 0xa0, 0x7c, 0xab, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4,
+	0x31, 0xfe, 0x5e, 0xc2, 0xd5, 0x45, 0x00, 0x00, 0x00, 0x02, 0x00, 0x96, 0xff, 0xe7, 0x05, 0x1e,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x18, 0x00, 0x85, 0x40, 0x0a, 0x14, 0x01, 0x00, 0x03, 0x01, 0x01,
+	0x01, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05,
+	0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x1f,
+	0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x3c, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x09, 0x11, 0x12, 0x24, 0x22, 0x23, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x13, 0x26,
+	0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33,
+	0x32, 0x17, 0x13, 0x33, 0x01, 0x23, 0x03, 0x6a, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46,
+	0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0x62, 0xc5,
+	0xfe, 0xc5, 0xc5, 0x01, 0x7e, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27,
+	0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0x01, 0xed, 0xf9, 0xd5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9b,
+	0xff, 0xe7, 0x04, 0xe3, 0x04, 0x56, 0x00, 0x04, 0x00, 0x15, 0x00, 0x33, 0x40, 0x30, 0x06, 0x01,
+	0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x00, 0x00, 0x15, 0x13,
+	0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x07, 0x09, 0x17, 0x2b, 0x01,
+	0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03,
+	0x07, 0x21, 0x02, 0x21, 0x32, 0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7,
+	0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69,
+	0x9c, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe,
+	0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x00, 0x00, 0x01, 0x00, 0xba, 0x00, 0x00, 0x03, 0xbc,
+	0x06, 0x44, 0x00, 0x14, 0x00, 0x63, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x40, 0x4d, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07,
+	0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x40, 0x4d, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07,
+	0x01, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x14, 0x00, 0x14, 0x11,
+	0x13, 0x23, 0x22, 0x11, 0x11, 0x08, 0x09, 0x1c, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37, 0x12,
+	0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x07, 0x33, 0x07, 0x23, 0x03, 0xba, 0xbb,
+	0x90, 0x1e, 0x90, 0x19, 0x4e, 0x01, 0x37, 0x3f, 0x4c, 0x20, 0x42, 0x34, 0x4a, 0x4d, 0x18, 0x1e,
+	0xe1, 0x1e, 0xe1, 0xbb, 0x03, 0xaa, 0x94, 0x82, 0x01, 0x84, 0x1a, 0x9d, 0x23, 0x61, 0x7a, 0x97,
+	0x94, 0xfc, 0x56, 0x00, 0x00, 0x02, 0x00, 0x26, 0xfe, 0x5c, 0x04, 0xc3, 0x04, 0x56, 0x00, 0x09,
+	0x00, 0x22, 0x00, 0x97, 0x40, 0x0e, 0x01, 0x01, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02, 0x1d, 0x01,
+	0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50,
+	0x58, 0x40, 0x24, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01,
+	0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0a,
+	0x23, 0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x07, 0x09, 0x1d, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x33, 0x03, 0x02, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x20, 0x13, 0x03, 0x7b,
+	0x65, 0x83, 0x43, 0xfe, 0xe3, 0x4e, 0x22, 0x47, 0x5f, 0x81, 0xa3, 0xa3, 0xcf, 0xa8, 0x96, 0x2e,
+	0x36, 0x01, 0x45, 0xf3, 0x61, 0x5a, 0xc5, 0x9e, 0x34, 0x65, 0x58, 0x9c, 0xfe, 0xf0, 0xbe, 0xa4,
+	0x23, 0xc0, 0x99, 0x01, 0x4c, 0x47, 0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38,
+	0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a,
+	0x3b, 0xab, 0x51, 0x01, 0x61, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcd,
+	0x06, 0x2b, 0x00, 0x10, 0x00, 0x50, 0xb5, 0x03, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00,
+	0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12,
+	0x22, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23,
+	0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa5, 0x01, 0x3b, 0xc5, 0x8b, 0xca, 0xd2, 0x01, 0x17,
+	0x42, 0x9b, 0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0x06, 0x2b, 0xfd, 0x47, 0xe4, 0xfe,
+	0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x02, 0x9f, 0x05, 0xdc, 0x00, 0x03, 0x00, 0x07, 0x00, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x05,
+	0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x13, 0x37, 0x33, 0x07,
+	0xa5, 0xd9, 0xc5, 0xd9, 0x31, 0x2b, 0xd9, 0x2b, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0xd9, 0xd9,
+	0x00, 0x02, 0xff, 0x68, 0xfe, 0x5d, 0x02, 0xb9, 0x05, 0xdc, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x56,
+	0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01,
+	0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x05, 0x01,
+	0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22,
+	0x13, 0x22, 0x06, 0x09, 0x1a, 0x2b, 0x03, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03,
+	0x02, 0x21, 0x22, 0x01, 0x37, 0x33, 0x07, 0x98, 0x1d, 0x31, 0x47, 0x54, 0x4b, 0x22, 0xd9, 0xc6,
+	0xd9, 0x54, 0xfe, 0xc1, 0x5b, 0x02, 0x1e, 0x2b, 0xda, 0x2b, 0xfe, 0x73, 0x90, 0x12, 0x69, 0xa6,
+	0x04, 0x3e, 0xfb, 0xc2, 0xfe, 0x5d, 0x06, 0xa6, 0xd9, 0xd9, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0x6b, 0x06, 0x2b, 0x00, 0x0a, 0x00, 0x47, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x12, 0x00,
+	0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19,
+	0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x03, 0xa5, 0x01, 0x3b, 0xc5,
+	0xcc, 0x01, 0xd6, 0xbc, 0xfe, 0x3e, 0x01, 0x50, 0xf0, 0xfe, 0xd0, 0x6f, 0x06, 0x2b, 0xfc, 0x04,
+	0x02, 0x0f, 0xfd, 0xff, 0xfd, 0xc3, 0x02, 0x2d, 0xfd, 0xd3, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd1,
+	0xff, 0xe7, 0x02, 0xa5, 0x06, 0x2b, 0x00, 0x0f, 0x00, 0x1c, 0x40, 0x19, 0x00, 0x00, 0x02, 0x01,
+	0x02, 0x00, 0x01, 0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x15,
+	0x22, 0x14, 0x03, 0x09, 0x19, 0x2b, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07, 0x06, 0x23, 0x22, 0x2e,
+	0x02, 0x37, 0x01, 0x33, 0x01, 0xad, 0x0c, 0x04, 0x23, 0x3c, 0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d,
+	0x42, 0x14, 0x0f, 0x01, 0x00, 0xc5, 0x01, 0x50, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c,
+	0x53, 0x79, 0x4d, 0x04, 0xff, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x07, 0x04,
+	0x04, 0x56, 0x00, 0x1c, 0x00, 0x79, 0xb6, 0x08, 0x03, 0x02, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x16, 0x06, 0x01, 0x04, 0x04, 0x00, 0x61, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x3b, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x01, 0x41, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00,
+	0x00, 0x1c, 0x00, 0x1c, 0x22, 0x12, 0x22, 0x12, 0x23, 0x23, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x33,
+	0x13, 0x33, 0x07, 0x36, 0x36, 0x33, 0x32, 0x17, 0x36, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13,
+	0x36, 0x23, 0x22, 0x07, 0x03, 0x23, 0x13, 0x36, 0x23, 0x22, 0x07, 0x03, 0xa5, 0xd9, 0xc5, 0x29,
+	0x77, 0x8d, 0x64, 0xd7, 0x20, 0x77, 0x8c, 0x64, 0x01, 0x24, 0x40, 0x9d, 0xc5, 0x96, 0x26, 0x94,
+	0x80, 0xb0, 0x91, 0xc6, 0x97, 0x26, 0x95, 0x80, 0xb0, 0x91, 0x04, 0x3e, 0xcc, 0x8b, 0x59, 0xe4,
+	0x8b, 0x59, 0xfe, 0xc0, 0xfc, 0xea, 0x02, 0xf7, 0xbb, 0xda, 0xfd, 0x28, 0x02, 0xf7, 0xbb, 0xda,
+	0xfd, 0x28, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcd, 0x04, 0x56, 0x00, 0x10,
+	0x00, 0x6c, 0xb5, 0x03, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x13,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x13,
+	0x33, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa5,
+	0xd9, 0xc5, 0x29, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b, 0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6,
+	0x8c, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41,
+	0x00, 0x02, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x2d,
+	0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03,
+	0x02, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0,
+	0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x19, 0x01, 0x34, 0x01, 0x04,
+	0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01,
+	0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0xfe, 0x75, 0x04, 0xca,
+	0x04, 0x56, 0x00, 0x0e, 0x00, 0x18, 0x00, 0x5e, 0x40, 0x0b, 0x10, 0x04, 0x02, 0x04, 0x05, 0x0e,
+	0x01, 0x03, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x01,
+	0x61, 0x02, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42,
+	0x4d, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x4e, 0x59, 0x40, 0x09, 0x23, 0x24, 0x24, 0x22,
+	0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x23, 0x01, 0x33, 0x07, 0x36, 0x33, 0x32, 0x12, 0x07,
+	0x02, 0x00, 0x23, 0x22, 0x27, 0x13, 0x03, 0x16, 0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x22, 0x01,
+	0x1b, 0xc5, 0x01, 0x28, 0xc5, 0x29, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0xfe, 0xb7, 0xf5, 0x5f,
+	0x59, 0x8c, 0x6f, 0x83, 0x45, 0x01, 0x1b, 0x57, 0x23, 0x46, 0x60, 0x81, 0xfe, 0x75, 0x05, 0xc9,
+	0xcc, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1, 0xfe, 0xc8, 0x19, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01,
+	0xb1, 0xb1, 0xcd, 0x00, 0x00, 0x02, 0x00, 0x96, 0xfe, 0x75, 0x04, 0xbc, 0x04, 0x56, 0x00, 0x0d,
+	0x00, 0x17, 0x00, 0x59, 0xb6, 0x0f, 0x04, 0x02, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x05,
+	0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x4d, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x4d, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e,
+	0x59, 0x40, 0x09, 0x23, 0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x01,
+	0x23, 0x13, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x03, 0x13, 0x26, 0x23, 0x20,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x03, 0xf7, 0xc5, 0xfe, 0xd8, 0xc5, 0x77, 0xa3, 0xce, 0xaa, 0x95,
+	0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x33, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60,
+	0x81, 0x04, 0x3e, 0xfa, 0x37, 0x02, 0x56, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0xfd,
+	0x28, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x03, 0x83,
+	0x04, 0x56, 0x00, 0x0d, 0x00, 0x82, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0b, 0x08, 0x03, 0x02,
+	0x03, 0x02, 0x01, 0x4c, 0x07, 0x01, 0x00, 0x4a, 0x1b, 0x40, 0x0b, 0x07, 0x01, 0x00, 0x01, 0x08,
+	0x03, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40,
+	0x16, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0d, 0x00,
+	0x0d, 0x23, 0x22, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x33, 0x07, 0x36, 0x33, 0x32, 0x17,
+	0x07, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa5, 0xd9, 0xc5, 0x29, 0x87, 0xaa, 0x17, 0x21, 0x24, 0x30,
+	0x20, 0x72, 0xa4, 0x8f, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd, 0x34, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x86, 0xff, 0xe7, 0x04, 0x1b, 0x04, 0x56, 0x00, 0x1c, 0x00, 0x2a, 0x40, 0x27,
+	0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x28, 0x23, 0x27, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27,
+	0x27, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x04,
+	0x07, 0x06, 0x04, 0x23, 0x22, 0x86, 0x24, 0xaf, 0xab, 0xe5, 0x21, 0x15, 0x95, 0xa4, 0xec, 0x2b,
+	0x3d, 0x01, 0xa1, 0x78, 0xa0, 0x21, 0x87, 0xa2, 0xc9, 0x1c, 0x13, 0x84, 0x93, 0x01, 0x11, 0x2e,
+	0x1e, 0xfe, 0xf5, 0xca, 0xa3, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31,
+	0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0x00, 0x00, 0x01, 0x00, 0x9e,
+	0xff, 0xe7, 0x03, 0x31, 0x05, 0x34, 0x00, 0x14, 0x00, 0x2d, 0x40, 0x2a, 0x14, 0x01, 0x05, 0x01,
+	0x01, 0x4c, 0x0a, 0x01, 0x02, 0x4a, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02,
+	0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x23, 0x11, 0x13,
+	0x11, 0x12, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x05, 0x06, 0x23, 0x20, 0x13, 0x13, 0x23, 0x37, 0x33,
+	0x37, 0x37, 0x07, 0x33, 0x07, 0x23, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x02, 0x1b, 0x3d, 0x34,
+	0xfe, 0xf4, 0x40, 0x80, 0x7f, 0x1e, 0x7f, 0x2d, 0xc9, 0x31, 0xf0, 0x1e, 0xf0, 0x78, 0x1a, 0x1b,
+	0x45, 0x29, 0x1d, 0x06, 0x13, 0x01, 0x45, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xfd, 0xa6,
+	0x82, 0x53, 0x0b, 0x00, 0x00, 0x01, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc, 0x04, 0x3e, 0x00, 0x10,
+	0x00, 0x6c, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x13,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x05, 0x04, 0x02, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x17, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x37,
+	0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x03,
+	0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d,
+	0xc5, 0xd9, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb,
+	0xc2, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xf7, 0x00, 0x00, 0x04, 0xd8, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x3a, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b,
+	0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x03, 0x33, 0x13,
+	0x01, 0x33, 0x01, 0x01, 0x9f, 0xa8, 0xc7, 0x84, 0x01, 0xe7, 0xaf, 0xfd, 0x8c, 0x04, 0x3e, 0xfc,
+	0xb3, 0x03, 0x4d, 0xfb, 0xc2, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xef, 0x00, 0x00, 0x06, 0x9d,
+	0x04, 0x3e, 0x00, 0x0c, 0x00, 0x42, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05,
+	0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c,
+	0x11, 0x12, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01,
+	0x33, 0x01, 0x23, 0x03, 0x01, 0x01, 0x1c, 0x2d, 0xc1, 0x20, 0x01, 0x9e, 0xc5, 0x37, 0x01, 0x89,
+	0xaa, 0xfd, 0xf6, 0xc6, 0x3e, 0xfe, 0x54, 0x04, 0x3e, 0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb, 0x03,
+	0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x00, 0x00, 0x01, 0x00, 0x27, 0x00, 0x00, 0x04, 0xaa,
+	0x04, 0x3e, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03,
+	0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x12, 0x12, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x03, 0x33, 0x13, 0x01, 0x33, 0x01, 0x13,
+	0x23, 0x03, 0x01, 0x27, 0x01, 0xd3, 0xee, 0xe4, 0xbe, 0x01, 0x46, 0xb6, 0xfe, 0x46, 0xfc, 0xe3,
+	0xcf, 0xfe, 0xa3, 0x02, 0x3e, 0x02, 0x00, 0xfe, 0x69, 0x01, 0x97, 0xfd, 0xdd, 0xfd, 0xe5, 0x01,
+	0xb4, 0xfe, 0x4c, 0x00, 0x00, 0x01, 0x00, 0xa5, 0xfe, 0x75, 0x04, 0xd8, 0x04, 0x3e, 0x00, 0x07,
+	0x00, 0x1b, 0x40, 0x18, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x11, 0x12, 0x11, 0x03, 0x09, 0x19, 0x2b, 0x21, 0x03, 0x33,
+	0x13, 0x01, 0x33, 0x01, 0x23, 0x01, 0x9f, 0xa8, 0xc8, 0x80, 0x01, 0xeb, 0xae, 0xfc, 0x9a, 0xcd,
+	0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37, 0x00, 0x01, 0x00, 0x55, 0x00, 0x00, 0x04, 0x8e,
+	0x04, 0x3e, 0x00, 0x09, 0x00, 0x46, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21,
+	0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x55, 0x1d, 0x03, 0x0b, 0xfd, 0xb2, 0x1e, 0x03, 0x41, 0x1e,
+	0xfc, 0xf5, 0x02, 0x79, 0x1d, 0x94, 0x03, 0x16, 0x94, 0x94, 0xfc, 0xea, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x95, 0xfe, 0xd8, 0x03, 0x7e, 0x06, 0x2b, 0x00, 0x2e, 0x00, 0x2f, 0x40, 0x2c,
+	0x17, 0x01, 0x05, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x05, 0x03, 0x00, 0x05, 0x69, 0x00, 0x03,
+	0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3a, 0x02, 0x4e,
+	0x2e, 0x2c, 0x24, 0x23, 0x22, 0x20, 0x21, 0x18, 0x20, 0x06, 0x09, 0x19, 0x2b, 0x13, 0x33, 0x32,
+	0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x36, 0x36, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x06, 0x07,
+	0x07, 0x06, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x16, 0x33, 0x33,
+	0x07, 0x22, 0x26, 0x37, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36, 0x23, 0x23, 0xb3, 0x3d, 0x99, 0x20,
+	0x0d, 0x01, 0x02, 0x02, 0x10, 0x1c, 0xf4, 0xad, 0x1e, 0x35, 0x44, 0x68, 0x0b, 0x04, 0x01, 0x04,
+	0x02, 0x12, 0x25, 0xac, 0x7a, 0x26, 0x12, 0x18, 0x25, 0x1c, 0x04, 0x0b, 0x4d, 0x43, 0x35, 0x1e,
+	0xad, 0xb0, 0x1c, 0x10, 0x24, 0x25, 0x1e, 0x0e, 0x20, 0x99, 0x3d, 0x02, 0xcc, 0xa1, 0x44, 0x48,
+	0x57, 0x56, 0x51, 0x8b, 0xa9, 0x94, 0x47, 0x36, 0x16, 0x48, 0x66, 0x42, 0x59, 0xbd, 0x7c, 0x7d,
+	0xbd, 0x59, 0x42, 0x66, 0x48, 0x17, 0x35, 0x47, 0x94, 0xaa, 0x8b, 0x51, 0x55, 0x57, 0x48, 0x46,
+	0xa0, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8a, 0xfe, 0xd8, 0x02, 0x9f, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00, 0x00, 0x00, 0x3a, 0x00, 0x4e,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x01, 0x33, 0x01, 0x8a,
+	0x01, 0x77, 0x9e, 0xfe, 0x89, 0xfe, 0xd8, 0x07, 0x53, 0xf8, 0xad, 0x00, 0x00, 0x01, 0x00, 0x43,
+	0xfe, 0xd8, 0x03, 0x2d, 0x06, 0x2b, 0x00, 0x2e, 0x00, 0x2f, 0x40, 0x2c, 0x17, 0x01, 0x00, 0x05,
+	0x01, 0x4c, 0x00, 0x05, 0x00, 0x00, 0x02, 0x05, 0x00, 0x69, 0x00, 0x02, 0x00, 0x01, 0x02, 0x01,
+	0x65, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3a, 0x03, 0x4e, 0x2e, 0x2c, 0x24, 0x23,
+	0x22, 0x20, 0x21, 0x18, 0x20, 0x06, 0x09, 0x19, 0x2b, 0x01, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17,
+	0x16, 0x07, 0x06, 0x06, 0x23, 0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36,
+	0x37, 0x26, 0x37, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x32, 0x16, 0x07,
+	0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x33, 0x33, 0x03, 0x0f, 0x3e, 0x98, 0x20, 0x0e, 0x01, 0x02,
+	0x02, 0x10, 0x1c, 0xf5, 0xac, 0x1e, 0x34, 0x44, 0x68, 0x0b, 0x04, 0x03, 0x02, 0x02, 0x12, 0x26,
+	0xac, 0x7a, 0x25, 0x12, 0x18, 0x27, 0x1a, 0x05, 0x0a, 0x4d, 0x43, 0x34, 0x1e, 0xac, 0xb1, 0x1c,
+	0x10, 0x24, 0x25, 0x1e, 0x0d, 0x20, 0x98, 0x3e, 0x02, 0x38, 0xa2, 0x44, 0x48, 0x57, 0x55, 0x52,
+	0x8b, 0xa9, 0x94, 0x47, 0x36, 0x16, 0x48, 0x66, 0x43, 0x58, 0xbd, 0x7d, 0x7c, 0xbd, 0x59, 0x42,
+	0x66, 0x48, 0x18, 0x34, 0x47, 0x94, 0xa9, 0x8c, 0x50, 0x56, 0x57, 0x48, 0x45, 0xa0, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xcb, 0x01, 0x9c, 0x04, 0xe1, 0x03, 0x04, 0x00, 0x15, 0x00, 0x6d, 0xb1, 0x06,
+	0x64, 0x44, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x26, 0x00, 0x03, 0x01, 0x05, 0x02, 0x03, 0x72,
+	0x00, 0x00, 0x02, 0x04, 0x05, 0x00, 0x72, 0x00, 0x01, 0x00, 0x05, 0x02, 0x01, 0x05, 0x69, 0x00,
+	0x02, 0x00, 0x04, 0x02, 0x59, 0x00, 0x02, 0x02, 0x04, 0x62, 0x00, 0x04, 0x02, 0x04, 0x52, 0x1b,
+	0x40, 0x28, 0x00, 0x03, 0x01, 0x05, 0x01, 0x03, 0x05, 0x80, 0x00, 0x00, 0x02, 0x04, 0x02, 0x00,
+	0x04, 0x80, 0x00, 0x01, 0x00, 0x05, 0x02, 0x01, 0x05, 0x69, 0x00, 0x02, 0x00, 0x04, 0x02, 0x59,
+	0x00, 0x02, 0x02, 0x04, 0x62, 0x00, 0x04, 0x02, 0x04, 0x52, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11,
+	0x24, 0x21, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x23, 0x12, 0x21, 0x32,
+	0x1f, 0x02, 0x16, 0x33, 0x32, 0x37, 0x33, 0x02, 0x21, 0x22, 0x2f, 0x02, 0x26, 0x23, 0x22, 0x01,
+	0x5f, 0x94, 0x42, 0x01, 0x0f, 0x5e, 0x56, 0x61, 0x38, 0x1e, 0x2b, 0x77, 0x24, 0x94, 0x41, 0xfe,
+	0xf2, 0x5e, 0x56, 0x61, 0x3a, 0x1d, 0x2b, 0x78, 0x01, 0xbc, 0x01, 0x48, 0x45, 0x4d, 0x2e, 0x14,
+	0xb4, 0xfe, 0xb8, 0x45, 0x4d, 0x2e, 0x14, 0x00, 0x00, 0x02, 0x00, 0xae, 0xfe, 0x75, 0x02, 0x9b,
+	0x04, 0x3e, 0x00, 0x03, 0x00, 0x09, 0x00, 0x2f, 0x40, 0x2c, 0x05, 0x01, 0x03, 0x00, 0x02, 0x00,
+	0x03, 0x02, 0x80, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x3d, 0x02, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x09, 0x04, 0x09, 0x07, 0x06, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x07, 0x23, 0x37, 0x13, 0x03, 0x03, 0x23, 0x13,
+	0x13, 0x02, 0x9b, 0x28, 0xc5, 0x28, 0x5e, 0x86, 0x3b, 0xc5, 0x3b, 0xb7, 0x04, 0x3e, 0xc6, 0xc6,
+	0xfe, 0x75, 0xfc, 0xea, 0xfe, 0xd8, 0x01, 0x28, 0x03, 0x16, 0x00, 0x00, 0x00, 0x02, 0x01, 0x1d,
+	0x00, 0x00, 0x04, 0xfa, 0x05, 0xc8, 0x00, 0x16, 0x00, 0x1b, 0x00, 0x61, 0x40, 0x0f, 0x1b, 0x12,
+	0x0f, 0x0d, 0x0c, 0x05, 0x02, 0x01, 0x01, 0x4c, 0x01, 0x01, 0x03, 0x01, 0x4b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x69, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x69, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00,
+	0x00, 0x00, 0x16, 0x00, 0x16, 0x13, 0x15, 0x11, 0x18, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x26,
+	0x02, 0x37, 0x36, 0x00, 0x37, 0x37, 0x33, 0x07, 0x16, 0x17, 0x07, 0x26, 0x27, 0x03, 0x32, 0x37,
+	0x07, 0x06, 0x23, 0x07, 0x13, 0x06, 0x03, 0x02, 0x17, 0x02, 0x82, 0x22, 0xc7, 0xc0, 0x2e, 0x2f,
+	0x01, 0x2a, 0xe0, 0x25, 0x63, 0x25, 0x84, 0x8f, 0x21, 0xa5, 0x69, 0xa8, 0x88, 0xa1, 0x1d, 0xa1,
+	0x87, 0x22, 0x81, 0xf6, 0x4e, 0x42, 0xe2, 0xad, 0x14, 0x01, 0x3a, 0xe7, 0xec, 0x01, 0x24, 0x1d,
+	0xb9, 0xb9, 0x06, 0x28, 0xa6, 0x3c, 0x0a, 0xfc, 0xb8, 0x43, 0x95, 0x3a, 0xad, 0x04, 0x78, 0x16,
+	0xfe, 0x7a, 0xfe, 0xb6, 0x4e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x84, 0x00, 0x00, 0x04, 0xeb,
+	0x05, 0xed, 0x00, 0x1c, 0x00, 0x68, 0x40, 0x0a, 0x0d, 0x01, 0x03, 0x02, 0x0e, 0x01, 0x01, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06,
+	0x01, 0x00, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x06, 0x06,
+	0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03,
+	0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x06,
+	0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00,
+	0x1c, 0x00, 0x1c, 0x14, 0x11, 0x12, 0x23, 0x23, 0x11, 0x14, 0x09, 0x09, 0x1d, 0x2b, 0x33, 0x37,
+	0x36, 0x37, 0x37, 0x23, 0x37, 0x33, 0x37, 0x36, 0x36, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x07, 0x03, 0x33, 0x07, 0x23, 0x07, 0x06, 0x06, 0x07, 0x21, 0x07, 0x84, 0x22, 0xdf, 0x32, 0x2e,
+	0xb3, 0x1d, 0xb3, 0x2b, 0x2b, 0xf7, 0xbf, 0x69, 0x74, 0x22, 0x71, 0x74, 0xb8, 0x2e, 0x37, 0xd8,
+	0x1d, 0xd8, 0x1a, 0x1f, 0x6b, 0x76, 0x02, 0x63, 0x22, 0xad, 0x43, 0xf9, 0xe3, 0x94, 0xd7, 0xd5,
+	0xe1, 0x1e, 0xa7, 0x31, 0xe6, 0xfe, 0xed, 0x94, 0x7f, 0x9e, 0xae, 0x54, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xd0, 0x01, 0x25, 0x04, 0xde, 0x04, 0xa4, 0x00, 0x1b, 0x00, 0x27, 0x00, 0x43,
+	0x40, 0x40, 0x0e, 0x0a, 0x02, 0x03, 0x00, 0x15, 0x11, 0x07, 0x03, 0x04, 0x02, 0x03, 0x18, 0x01,
+	0x01, 0x02, 0x03, 0x4c, 0x10, 0x0f, 0x09, 0x08, 0x04, 0x00, 0x4a, 0x17, 0x16, 0x02, 0x01, 0x04,
+	0x01, 0x49, 0x04, 0x01, 0x02, 0x00, 0x01, 0x02, 0x01, 0x65, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3b, 0x03, 0x4e, 0x1d, 0x1c, 0x23, 0x21, 0x1c, 0x27, 0x1d, 0x27, 0x2c, 0x2b, 0x05,
+	0x09, 0x18, 0x2b, 0x01, 0x07, 0x27, 0x37, 0x26, 0x37, 0x36, 0x37, 0x27, 0x37, 0x17, 0x36, 0x33,
+	0x32, 0x17, 0x37, 0x17, 0x07, 0x16, 0x07, 0x06, 0x07, 0x17, 0x07, 0x27, 0x06, 0x23, 0x22, 0x37,
+	0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x01, 0xd1, 0xbb, 0x46, 0xbb,
+	0x2b, 0x14, 0x15, 0x54, 0x7d, 0x69, 0x7d, 0x6a, 0x6e, 0x6e, 0x52, 0xbb, 0x45, 0xbb, 0x2c, 0x15,
+	0x14, 0x53, 0x7b, 0x68, 0x7d, 0x6c, 0x6d, 0x6d, 0x83, 0x64, 0xa2, 0x14, 0x13, 0x6b, 0x62, 0x62,
+	0xa1, 0x14, 0x13, 0x6a, 0x01, 0xc1, 0x9c, 0x57, 0x9c, 0x64, 0x68, 0x68, 0x64, 0x9c, 0x58, 0x9c,
+	0x3f, 0x3f, 0x9c, 0x58, 0x9c, 0x64, 0x68, 0x68, 0x64, 0x9c, 0x57, 0x9c, 0x40, 0x7b, 0x86, 0x63,
+	0x61, 0x86, 0x86, 0x62, 0x61, 0x87, 0x00, 0x00, 0x00, 0x01, 0x00, 0xec, 0x00, 0x00, 0x05, 0x67,
+	0x05, 0xc8, 0x00, 0x17, 0x00, 0x6b, 0xb5, 0x0b, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x21, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x08, 0x01,
+	0x01, 0x09, 0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x05, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0b, 0x01,
+	0x0a, 0x0a, 0x39, 0x0a, 0x4e, 0x1b, 0x40, 0x21, 0x05, 0x01, 0x04, 0x03, 0x04, 0x85, 0x06, 0x01,
+	0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01,
+	0x00, 0x67, 0x0b, 0x01, 0x0a, 0x0a, 0x3c, 0x0a, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x00, 0x17,
+	0x00, 0x17, 0x16, 0x15, 0x11, 0x11, 0x11, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x1f,
+	0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x37, 0x21, 0x37, 0x21, 0x01, 0x33, 0x13, 0x33, 0x01, 0x33,
+	0x01, 0x21, 0x07, 0x21, 0x07, 0x21, 0x07, 0x21, 0x03, 0x01, 0xd0, 0x44, 0xfe, 0xd8, 0x17, 0x01,
+	0x28, 0x22, 0xfe, 0xd8, 0x16, 0x01, 0x28, 0xfe, 0xe8, 0xe4, 0xd3, 0x02, 0x01, 0xb2, 0xb1, 0xfd,
+	0xc1, 0x01, 0x28, 0x16, 0xfe, 0xd8, 0x22, 0x01, 0x28, 0x17, 0xfe, 0xd8, 0x44, 0x01, 0x59, 0x72,
+	0xa8, 0x71, 0x02, 0xe4, 0xfd, 0xd2, 0x02, 0x2e, 0xfd, 0x1c, 0x71, 0xa8, 0x72, 0xfe, 0xa7, 0x00,
+	0x00, 0x02, 0x00, 0x8f, 0xfe, 0xd8, 0x02, 0x9a, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x30,
+	0x40, 0x2d, 0x05, 0x01, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x7e, 0x04, 0x01, 0x01, 0x01, 0x84, 0x00, 0x02, 0x02, 0x3a, 0x02, 0x4e, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b,
+	0x13, 0x13, 0x33, 0x03, 0x13, 0x13, 0x33, 0x03, 0x8f, 0x94, 0x94, 0x94, 0x4f, 0x94, 0x94, 0x94,
+	0xfe, 0xd8, 0x02, 0xe4, 0xfd, 0x1c, 0x04, 0x6f, 0x02, 0xe4, 0xfd, 0x1c, 0x00, 0x02, 0x00, 0x58,
+	0xfe, 0xb2, 0x04, 0xea, 0x05, 0xed, 0x00, 0x29, 0x00, 0x34, 0x00, 0x4e, 0x40, 0x0e, 0x15, 0x01,
+	0x02, 0x01, 0x30, 0x23, 0x16, 0x0e, 0x01, 0x05, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69,
+	0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51,
+	0x59, 0xb6, 0x2e, 0x23, 0x2e, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x27, 0x27, 0x24, 0x37, 0x36, 0x37, 0x26, 0x37, 0x36, 0x24, 0x33, 0x32, 0x17,
+	0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07,
+	0x06, 0x04, 0x23, 0x22, 0x01, 0x36, 0x37, 0x36, 0x26, 0x27, 0x27, 0x06, 0x07, 0x06, 0x17, 0x58,
+	0x24, 0xdf, 0xa5, 0x82, 0xb6, 0x10, 0x0d, 0x47, 0x83, 0xa2, 0xfe, 0xf8, 0x2a, 0x1e, 0xb0, 0x7a,
+	0x1d, 0x21, 0x01, 0x2b, 0xd3, 0x96, 0xb9, 0x20, 0xbd, 0x91, 0x82, 0xb4, 0x11, 0x15, 0xa1, 0x7d,
+	0xbe, 0x7f, 0x19, 0x1c, 0xb6, 0x91, 0x23, 0x1f, 0xfe, 0xd0, 0xe3, 0x99, 0x02, 0x08, 0x5d, 0x13,
+	0x0f, 0x4d, 0x72, 0xcb, 0x5c, 0x13, 0x1b, 0xd3, 0xfe, 0xfc, 0xb4, 0x69, 0x64, 0x50, 0x43, 0x4d,
+	0x3e, 0x4c, 0x7d, 0xd3, 0x97, 0x94, 0x5e, 0x92, 0xa5, 0xc8, 0x2f, 0xa0, 0x3b, 0x66, 0x53, 0x6c,
+	0x46, 0x37, 0x53, 0x9e, 0x7d, 0x8e, 0xa6, 0x5f, 0xad, 0x9d, 0xba, 0x02, 0xa3, 0x63, 0x5f, 0x48,
+	0x5d, 0x35, 0x5d, 0x5a, 0x5f, 0x85, 0x61, 0x00, 0x00, 0x02, 0x01, 0x44, 0x05, 0x03, 0x03, 0x9e,
+	0x05, 0xb0, 0x00, 0x03, 0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01,
+	0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37,
+	0x33, 0x07, 0x01, 0x44, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0x05, 0x03, 0xad, 0xad, 0xad,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x71, 0x00, 0x00, 0x06, 0xb3, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x2e, 0x00, 0x5c, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x51, 0x23, 0x01, 0x06, 0x05,
+	0x2e, 0x24, 0x02, 0x07, 0x06, 0x02, 0x4c, 0x00, 0x01, 0x00, 0x03, 0x05, 0x01, 0x03, 0x69, 0x00,
+	0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00, 0x07, 0x00, 0x04, 0x02, 0x07, 0x04, 0x69, 0x09,
+	0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x02,
+	0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x2d, 0x2b, 0x27, 0x25, 0x21, 0x1f, 0x1b, 0x19, 0x13, 0x11,
+	0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x20,
+	0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37,
+	0x36, 0x24, 0x33, 0x32, 0x17, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32,
+	0x37, 0x02, 0xf5, 0xfe, 0xd5, 0xfe, 0xa7, 0x3c, 0x3e, 0x02, 0x08, 0x01, 0x32, 0x01, 0x32, 0x01,
+	0x5c, 0x3d, 0x3e, 0xfd, 0xf7, 0xfe, 0xdb, 0x01, 0x0d, 0x01, 0xc1, 0x35, 0x34, 0xfe, 0xd5, 0xfe,
+	0xfa, 0xfe, 0xfa, 0xfe, 0x42, 0x35, 0x33, 0x01, 0x27, 0x02, 0x49, 0x90, 0x6b, 0xb5, 0xb6, 0x24,
+	0x27, 0x01, 0x0e, 0xbc, 0x59, 0x7a, 0x17, 0x18, 0x74, 0x69, 0x7d, 0xbe, 0x1d, 0x1d, 0x7d, 0x89,
+	0x6c, 0x77, 0x01, 0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe, 0xcf, 0xfe, 0xc9,
+	0xfe, 0x51, 0x6a, 0x01, 0x72, 0x01, 0x09, 0x01, 0x05, 0x01, 0x75, 0xfe, 0x8b, 0xfe, 0xfa, 0xfe,
+	0xfd, 0xfe, 0x89, 0x01, 0x02, 0x2f, 0xea, 0xb8, 0xc1, 0xe5, 0x18, 0x05, 0x76, 0x35, 0xb2, 0x92,
+	0x92, 0xaa, 0x3b, 0x00, 0x00, 0x02, 0x01, 0x1a, 0x03, 0x36, 0x03, 0xab, 0x05, 0xee, 0x00, 0x1c,
+	0x00, 0x24, 0x00, 0x82, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x01, 0x02, 0x17,
+	0x01, 0x04, 0x06, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x01, 0x02, 0x17, 0x01, 0x04, 0x07,
+	0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x23, 0x00, 0x03, 0x00, 0x02, 0x01, 0x03,
+	0x02, 0x69, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x07, 0x01, 0x04, 0x00, 0x00, 0x04,
+	0x59, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x04, 0x00, 0x51, 0x1b, 0x40, 0x27,
+	0x00, 0x03, 0x00, 0x02, 0x01, 0x03, 0x02, 0x69, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69,
+	0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x00, 0x04, 0x00, 0x00, 0x04, 0x59, 0x00, 0x04, 0x04, 0x00,
+	0x61, 0x05, 0x01, 0x00, 0x04, 0x00, 0x51, 0x59, 0x40, 0x0b, 0x22, 0x23, 0x24, 0x13, 0x23, 0x22,
+	0x23, 0x21, 0x08, 0x0b, 0x1e, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x21, 0x33, 0x37,
+	0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x07, 0x03, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06,
+	0x23, 0x22, 0x27, 0x37, 0x37, 0x23, 0x22, 0x07, 0x06, 0x33, 0x32, 0x02, 0xa6, 0x76, 0x67, 0x56,
+	0x59, 0x10, 0x2e, 0x01, 0x56, 0x30, 0x0e, 0x16, 0x77, 0x67, 0x79, 0x16, 0x85, 0x73, 0xf2, 0x2a,
+	0x3b, 0x12, 0x39, 0x09, 0x0f, 0x0a, 0x35, 0x2f, 0x65, 0x07, 0x03, 0x1e, 0x26, 0xcc, 0x18, 0x13,
+	0x62, 0x45, 0x03, 0x93, 0x5d, 0x6a, 0x51, 0xe4, 0x46, 0x6e, 0x3b, 0x6f, 0x31, 0xcf, 0xfe, 0xd6,
+	0x5b, 0x02, 0x53, 0x13, 0x5d, 0x51, 0x9a, 0x79, 0x61, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xea,
+	0x00, 0x63, 0x04, 0xac, 0x03, 0xdb, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x08, 0xb5, 0x0b, 0x09, 0x05,
+	0x03, 0x02, 0x32, 0x2b, 0x01, 0x01, 0x13, 0x07, 0x01, 0x01, 0x05, 0x01, 0x13, 0x07, 0x01, 0x01,
+	0x04, 0xac, 0xfe, 0x8e, 0xde, 0x71, 0xfe, 0xce, 0x01, 0xe4, 0xfe, 0xc8, 0xfe, 0x8e, 0xde, 0x71,
+	0xfe, 0xce, 0x01, 0xe4, 0x03, 0x91, 0xfe, 0x8e, 0xfe, 0x8e, 0x4a, 0x01, 0xbc, 0x01, 0xbc, 0x4a,
+	0xfe, 0x8e, 0xfe, 0x8e, 0x4a, 0x01, 0xbc, 0x01, 0xbc, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xf4,
+	0x01, 0x28, 0x04, 0xed, 0x03, 0x78, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x02, 0x01,
+	0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x13, 0x37,
+	0x21, 0x03, 0x23, 0x13, 0xf4, 0x1e, 0x03, 0xdb, 0x76, 0x94, 0x58, 0x02, 0xe4, 0x94, 0xfd, 0xb0,
+	0x01, 0xbc, 0x00, 0x00, 0x00, 0x01, 0x00, 0xca, 0x02, 0x06, 0x02, 0xe2, 0x02, 0x9a, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x13, 0x37, 0x21, 0x07, 0xca, 0x1e, 0x01, 0xfa, 0x1e, 0x02, 0x06, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x71, 0x00, 0x00, 0x06, 0xb3, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x2a, 0x00, 0x69, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x5e, 0x1e, 0x01, 0x06, 0x08, 0x01, 0x4c,
+	0x0c, 0x07, 0x02, 0x05, 0x06, 0x02, 0x06, 0x05, 0x02, 0x80, 0x00, 0x01, 0x00, 0x03, 0x04, 0x01,
+	0x03, 0x69, 0x00, 0x04, 0x00, 0x09, 0x08, 0x04, 0x09, 0x69, 0x00, 0x08, 0x00, 0x06, 0x05, 0x08,
+	0x06, 0x67, 0x0b, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x2a, 0x28, 0x26, 0x24, 0x18,
+	0x23, 0x18, 0x23, 0x22, 0x21, 0x20, 0x1f, 0x1b, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0d, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21,
+	0x20, 0x00, 0x03, 0x02, 0x00, 0x37, 0x13, 0x33, 0x32, 0x07, 0x06, 0x07, 0x13, 0x23, 0x03, 0x23,
+	0x03, 0x13, 0x33, 0x32, 0x37, 0x36, 0x23, 0x23, 0x02, 0xf5, 0xfe, 0xd5, 0xfe, 0xa7, 0x3c, 0x3e,
+	0x02, 0x08, 0x01, 0x32, 0x01, 0x32, 0x01, 0x5c, 0x3d, 0x3e, 0xfd, 0xf7, 0xfe, 0xdb, 0x01, 0x0d,
+	0x01, 0xc1, 0x35, 0x34, 0xfe, 0xd5, 0xfe, 0xfa, 0xfe, 0xfa, 0xfe, 0x42, 0x35, 0x33, 0x01, 0x27,
+	0x42, 0xa0, 0xfc, 0xf2, 0x27, 0x1d, 0x9e, 0xa7, 0x95, 0x95, 0x65, 0x43, 0x4e, 0x24, 0xd4, 0x20,
+	0x19, 0xb1, 0x47, 0x01, 0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe, 0xcf, 0xfe,
+	0xc9, 0xfe, 0x51, 0x6a, 0x01, 0x72, 0x01, 0x09, 0x01, 0x05, 0x01, 0x75, 0xfe, 0x8b, 0xfe, 0xfa,
+	0xfe, 0xfd, 0xfe, 0x89, 0xe7, 0x03, 0x20, 0xc4, 0x90, 0x58, 0xfe, 0x8c, 0x01, 0x4e, 0xfe, 0xb2,
+	0x01, 0xb1, 0x9d, 0x80, 0x00, 0x01, 0x01, 0x90, 0x05, 0xb0, 0x05, 0x5b, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x21, 0x07, 0x01, 0x90, 0x1e, 0x03,
+	0xad, 0x1e, 0x05, 0xb0, 0x94, 0x94, 0x00, 0x00, 0x00, 0x02, 0x01, 0x58, 0x03, 0x9d, 0x03, 0xd9,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x39, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x02, 0x59, 0x77, 0x8a, 0x18, 0x19, 0xd1,
+	0x7a, 0x7a, 0x8b, 0x18, 0x19, 0xd1, 0x63, 0x49, 0x7a, 0x0f, 0x0e, 0x52, 0x47, 0x47, 0x7a, 0x0f,
+	0x0e, 0x51, 0x03, 0x9d, 0xaf, 0x79, 0x7b, 0xad, 0xad, 0x7a, 0x7c, 0xad, 0x7c, 0x64, 0x49, 0x47,
+	0x65, 0x65, 0x48, 0x46, 0x66, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x73, 0x00, 0x00, 0x04, 0xf0,
+	0x04, 0xa0, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00,
+	0x02, 0x01, 0x02, 0x85, 0x08, 0x01, 0x05, 0x00, 0x06, 0x00, 0x05, 0x06, 0x80, 0x03, 0x01, 0x01,
+	0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07,
+	0x39, 0x07, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x08, 0x01, 0x05, 0x00, 0x06,
+	0x00, 0x05, 0x06, 0x80, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x06,
+	0x06, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x13, 0x21, 0x37, 0x21, 0x13, 0x33, 0x03, 0x21, 0x07, 0x21, 0x03,
+	0x01, 0x37, 0x21, 0x07, 0x02, 0x51, 0x4a, 0xfe, 0x5d, 0x1d, 0x01, 0xa3, 0x4a, 0x95, 0x4a, 0x01,
+	0xa3, 0x1d, 0xfe, 0x5d, 0x4a, 0xfd, 0x8d, 0x1d, 0x03, 0xdb, 0x1d, 0x01, 0x28, 0x01, 0x72, 0x94,
+	0x01, 0x72, 0xfe, 0x8e, 0x94, 0xfe, 0x8e, 0xfe, 0xd8, 0x94, 0x94, 0x00, 0x00, 0x01, 0x01, 0x08,
+	0x02, 0xb5, 0x04, 0x3f, 0x06, 0x43, 0x00, 0x19, 0x00, 0x2b, 0x40, 0x28, 0x0b, 0x01, 0x02, 0x00,
+	0x01, 0x4c, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x04, 0x01, 0x03, 0x03, 0x55, 0x03, 0x4e, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x18, 0x23,
+	0x28, 0x05, 0x0b, 0x19, 0x2b, 0x01, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07,
+	0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x07, 0x01, 0x08,
+	0x1a, 0x4b, 0x93, 0x61, 0x59, 0xae, 0x1c, 0x29, 0xb6, 0x6a, 0xae, 0x1a, 0xa1, 0x8a, 0x90, 0x8b,
+	0x1c, 0x14, 0x77, 0x92, 0x3d, 0xb9, 0x3c, 0x01, 0xbd, 0x19, 0x02, 0xb5, 0x67, 0x60, 0x66, 0x42,
+	0x3c, 0x77, 0x71, 0xa3, 0x48, 0x68, 0x38, 0x87, 0x73, 0x4e, 0x78, 0x5a, 0x26, 0x71, 0x76, 0x67,
+	0x00, 0x01, 0x01, 0x30, 0x02, 0x9f, 0x04, 0x4f, 0x06, 0x43, 0x00, 0x21, 0x00, 0x37, 0x40, 0x34,
+	0x14, 0x01, 0x02, 0x03, 0x1b, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x56, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x27, 0x23, 0x23, 0x21, 0x23,
+	0x24, 0x06, 0x0b, 0x1c, 0x2b, 0x01, 0x37, 0x16, 0x17, 0x16, 0x33, 0x32, 0x37, 0x36, 0x26, 0x23,
+	0x23, 0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x07, 0x06,
+	0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x01, 0x30, 0x1b, 0x14, 0x0b, 0x73, 0x44, 0xe2, 0x2b,
+	0x17, 0x7e, 0x8c, 0x3a, 0x14, 0x33, 0x7f, 0xa8, 0x15, 0x21, 0xb7, 0x5d, 0x93, 0x1a, 0x8d, 0x66,
+	0x01, 0x44, 0x34, 0x27, 0xf7, 0xff, 0x2f, 0x1e, 0xeb, 0xa8, 0x54, 0x02, 0xbb, 0x6f, 0x08, 0x03,
+	0x28, 0xaf, 0x5c, 0x62, 0x50, 0x5f, 0x52, 0x85, 0x32, 0x67, 0x24, 0xcf, 0x9c, 0x42, 0x31, 0xba,
+	0x7b, 0x91, 0x00, 0x00, 0x00, 0x01, 0x01, 0x76, 0x05, 0x03, 0x03, 0x8b, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x1f, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x01, 0x01, 0x33, 0x01, 0x01, 0x76, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05, 0x03, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x51, 0xfe, 0x75, 0x04, 0xc3, 0x04, 0x3e, 0x00, 0x12,
+	0x00, 0x79, 0x40, 0x0a, 0x0c, 0x01, 0x01, 0x00, 0x10, 0x01, 0x03, 0x01, 0x02, 0x4c, 0x4b, 0xb0,
+	0x1b, 0x50, 0x58, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62,
+	0x04, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1b, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00,
+	0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b,
+	0x40, 0x1b, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x01, 0x01,
+	0x04, 0x62, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x59, 0x59, 0x40,
+	0x09, 0x12, 0x22, 0x11, 0x12, 0x23, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16,
+	0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x23, 0x37, 0x06, 0x23, 0x22, 0x27, 0x03, 0x23, 0x01, 0x79,
+	0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xc5, 0x28, 0xc4, 0xa8, 0x40, 0x38,
+	0x53, 0xc5, 0x04, 0x3e, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0xcb, 0xde, 0x2c,
+	0xfe, 0x5c, 0x00, 0x00, 0x00, 0x01, 0x01, 0x31, 0xfe, 0xd8, 0x04, 0xb0, 0x05, 0xc8, 0x00, 0x0d,
+	0x00, 0x4a, 0xb5, 0x01, 0x01, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12,
+	0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x86, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38,
+	0x02, 0x4e, 0x1b, 0x40, 0x17, 0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x00, 0x02, 0x00, 0x02, 0x4f, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x0d, 0x00, 0x0d, 0x11, 0x11, 0x26, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x13, 0x26, 0x26,
+	0x37, 0x36, 0x36, 0x33, 0x21, 0x01, 0x23, 0x01, 0x23, 0x01, 0x01, 0xc2, 0xcf, 0xae, 0xb2, 0x24,
+	0x23, 0xde, 0xe3, 0x01, 0x77, 0xfe, 0x9d, 0x71, 0x01, 0x4b, 0xa8, 0xfe, 0xb5, 0xfe, 0xd8, 0x04,
+	0x0c, 0x0e, 0xda, 0xb6, 0xb1, 0x95, 0xf9, 0x10, 0x06, 0x75, 0xf9, 0x8b, 0x00, 0x01, 0x01, 0x48,
+	0x03, 0x47, 0x02, 0x71, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x01, 0x48, 0x32, 0xf7, 0x32, 0x03, 0x47, 0xf7, 0xf7,
+	0x00, 0x01, 0x00, 0x60, 0xfe, 0x50, 0x01, 0xe5, 0x00, 0x00, 0x00, 0x11, 0x00, 0x68, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x0a, 0x0b, 0x01, 0x03, 0x04, 0x0a, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x17, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x72, 0x00, 0x00, 0x00, 0x04,
+	0x03, 0x00, 0x04, 0x69, 0x00, 0x03, 0x02, 0x02, 0x03, 0x59, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00,
+	0x02, 0x03, 0x02, 0x51, 0x1b, 0x40, 0x20, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x80, 0x00,
+	0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x69, 0x00, 0x03, 0x02, 0x02, 0x03, 0x59, 0x00, 0x03, 0x03,
+	0x02, 0x61, 0x00, 0x02, 0x03, 0x02, 0x51, 0x59, 0xb7, 0x12, 0x23, 0x24, 0x11, 0x10, 0x05, 0x09,
+	0x1b, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22,
+	0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x01, 0x27, 0x61, 0x52, 0x4e, 0x61, 0x0d, 0x0e,
+	0x88, 0x54, 0x47, 0x47, 0x11, 0x2b, 0x3b, 0x67, 0x0e, 0x14, 0xbb, 0x6d, 0x5f, 0x40, 0x45, 0x5f,
+	0x15, 0x51, 0x0f, 0x4a, 0x60, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x59, 0x02, 0xb5, 0x03, 0xde,
+	0x06, 0x43, 0x00, 0x09, 0x00, 0x21, 0x40, 0x1e, 0x06, 0x04, 0x03, 0x03, 0x00, 0x4a, 0x01, 0x01,
+	0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x15, 0x11, 0x04, 0x0b, 0x18, 0x2b, 0x01, 0x37, 0x33, 0x13, 0x07, 0x37, 0x25, 0x03, 0x33,
+	0x07, 0x01, 0x59, 0x16, 0xed, 0xaf, 0xf8, 0x16, 0x01, 0x95, 0xcd, 0xed, 0x16, 0x02, 0xb5, 0x58,
+	0x02, 0xbd, 0x2f, 0x5b, 0x4d, 0xfc, 0xca, 0x58, 0x00, 0x02, 0x01, 0x1f, 0x03, 0x36, 0x03, 0xb5,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x0b, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x02, 0x21, 0x88,
+	0x7a, 0x1f, 0x20, 0xc7, 0x8b, 0x8a, 0x7b, 0x20, 0x20, 0xc6, 0x78, 0x90, 0x32, 0x30, 0x8e, 0x8f,
+	0x31, 0x31, 0x03, 0x36, 0xbd, 0x9f, 0xa0, 0xbb, 0xba, 0xa0, 0xa3, 0xba, 0x66, 0xf8, 0xf4, 0xf6,
+	0xf6, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb5, 0x00, 0x63, 0x04, 0x77, 0x03, 0xdb, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x08, 0xb5, 0x0b, 0x09, 0x05, 0x03, 0x02, 0x32, 0x2b, 0x37, 0x01, 0x03, 0x37,
+	0x01, 0x01, 0x25, 0x01, 0x03, 0x37, 0x01, 0x01, 0xb5, 0x01, 0x72, 0xde, 0x72, 0x01, 0x31, 0xfe,
+	0x1d, 0x01, 0x37, 0x01, 0x72, 0xde, 0x71, 0x01, 0x32, 0xfe, 0x1c, 0xad, 0x01, 0x72, 0x01, 0x72,
+	0x4a, 0xfe, 0x44, 0xfe, 0x44, 0x4a, 0x01, 0x72, 0x01, 0x72, 0x4a, 0xfe, 0x44, 0xfe, 0x44, 0x00,
+	0x00, 0x04, 0x00, 0xc2, 0xff, 0xdb, 0x06, 0xc0, 0x05, 0xed, 0x00, 0x05, 0x00, 0x10, 0x00, 0x13,
+	0x00, 0x17, 0x00, 0xa6, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0c, 0x04, 0x02, 0x01, 0x03, 0x02, 0x07,
+	0x13, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x30, 0x00, 0x07, 0x02,
+	0x07, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x09, 0x01, 0x00, 0x03, 0x00, 0x85, 0x0a, 0x01, 0x05,
+	0x01, 0x08, 0x01, 0x05, 0x72, 0x0b, 0x01, 0x08, 0x08, 0x84, 0x06, 0x01, 0x03, 0x01, 0x01, 0x03,
+	0x57, 0x06, 0x01, 0x03, 0x03, 0x01, 0x60, 0x04, 0x01, 0x01, 0x03, 0x01, 0x50, 0x1b, 0x40, 0x31,
+	0x00, 0x07, 0x02, 0x07, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x09, 0x01, 0x00, 0x03, 0x00, 0x85,
+	0x0a, 0x01, 0x05, 0x01, 0x08, 0x01, 0x05, 0x08, 0x80, 0x0b, 0x01, 0x08, 0x08, 0x84, 0x06, 0x01,
+	0x03, 0x01, 0x01, 0x03, 0x57, 0x06, 0x01, 0x03, 0x03, 0x01, 0x60, 0x04, 0x01, 0x01, 0x03, 0x01,
+	0x50, 0x59, 0x40, 0x21, 0x14, 0x14, 0x06, 0x06, 0x00, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15,
+	0x12, 0x11, 0x06, 0x10, 0x06, 0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x08, 0x07, 0x00, 0x05,
+	0x00, 0x05, 0x0c, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x13, 0x07, 0x37, 0x25, 0x03,
+	0x01, 0x37, 0x21, 0x37, 0x01, 0x33, 0x03, 0x33, 0x07, 0x23, 0x07, 0x01, 0x21, 0x13, 0x01, 0x01,
+	0x33, 0x01, 0x01, 0xba, 0x97, 0xce, 0x16, 0x01, 0x6b, 0xb6, 0x02, 0xea, 0x30, 0xfe, 0x69, 0x16,
+	0x01, 0xfe, 0x8c, 0x6a, 0x7b, 0x17, 0x7b, 0x30, 0xfe, 0xa9, 0x01, 0x16, 0x49, 0xfa, 0xfa, 0x05,
+	0x77, 0x87, 0xfa, 0x89, 0x02, 0x50, 0x02, 0xf7, 0x31, 0x72, 0x57, 0xfc, 0x71, 0xfd, 0xb0, 0xf2,
+	0x71, 0x02, 0x15, 0xfd, 0xef, 0x75, 0xf2, 0x01, 0x67, 0x01, 0x6c, 0xfd, 0x08, 0x06, 0x12, 0xf9,
+	0xee, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x8f, 0xff, 0xdb, 0x06, 0xd5, 0x05, 0xed, 0x00, 0x17,
+	0x00, 0x1b, 0x00, 0x21, 0x00, 0x5e, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x53, 0x20, 0x1e, 0x1d, 0x03,
+	0x01, 0x04, 0x0a, 0x01, 0x06, 0x00, 0x02, 0x4c, 0x00, 0x04, 0x01, 0x04, 0x85, 0x09, 0x01, 0x06,
+	0x00, 0x02, 0x00, 0x06, 0x02, 0x80, 0x08, 0x01, 0x05, 0x03, 0x05, 0x86, 0x00, 0x01, 0x00, 0x00,
+	0x06, 0x01, 0x00, 0x6a, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07,
+	0x01, 0x03, 0x02, 0x03, 0x4f, 0x1c, 0x1c, 0x18, 0x18, 0x00, 0x00, 0x1c, 0x21, 0x1c, 0x21, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x00, 0x17, 0x00, 0x17, 0x17, 0x23, 0x27, 0x0a, 0x09, 0x19, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x21, 0x37, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x07, 0x05, 0x01, 0x33, 0x01,
+	0x13, 0x13, 0x07, 0x37, 0x25, 0x03, 0x04, 0x06, 0x18, 0x56, 0x8c, 0x52, 0xb8, 0x17, 0x1e, 0xa3,
+	0x5f, 0x8e, 0x18, 0x89, 0x7d, 0x83, 0x85, 0x16, 0x1c, 0xd6, 0x3d, 0x9b, 0x2b, 0x01, 0x85, 0x18,
+	0xfa, 0x58, 0x05, 0x77, 0x88, 0xfa, 0x89, 0xa3, 0x97, 0xce, 0x16, 0x01, 0x6b, 0xb6, 0x7a, 0x71,
+	0x6a, 0x3e, 0x8a, 0x77, 0x95, 0x45, 0x75, 0x35, 0x87, 0x6f, 0x8b, 0x97, 0x2b, 0x6d, 0x64, 0x7a,
+	0x25, 0x06, 0x12, 0xf9, 0xee, 0x02, 0x75, 0x02, 0xf7, 0x31, 0x72, 0x57, 0xfc, 0x71, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0xf1, 0xff, 0xdb, 0x07, 0x1d, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x28, 0x00, 0x2b,
+	0x00, 0x2f, 0x01, 0x17, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0f, 0x07, 0x01, 0x03, 0x04, 0x2b, 0x0f,
+	0x02, 0x02, 0x07, 0x0e, 0x01, 0x01, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x41,
+	0x00, 0x07, 0x03, 0x02, 0x03, 0x07, 0x02, 0x80, 0x0e, 0x01, 0x0a, 0x06, 0x0d, 0x06, 0x0a, 0x72,
+	0x0f, 0x01, 0x0d, 0x0d, 0x84, 0x0c, 0x01, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x69, 0x00, 0x04,
+	0x00, 0x03, 0x07, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x08, 0x02, 0x01, 0x69, 0x0b, 0x01,
+	0x08, 0x06, 0x06, 0x08, 0x57, 0x0b, 0x01, 0x08, 0x08, 0x06, 0x60, 0x09, 0x01, 0x06, 0x08, 0x06,
+	0x50, 0x1b, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x42, 0x00, 0x07, 0x03, 0x02, 0x03, 0x07, 0x02,
+	0x80, 0x0e, 0x01, 0x0a, 0x06, 0x0d, 0x06, 0x0a, 0x0d, 0x80, 0x0f, 0x01, 0x0d, 0x0d, 0x84, 0x0c,
+	0x01, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x69, 0x00, 0x04, 0x00, 0x03, 0x07, 0x04, 0x03, 0x69,
+	0x00, 0x02, 0x00, 0x01, 0x08, 0x02, 0x01, 0x69, 0x0b, 0x01, 0x08, 0x06, 0x06, 0x08, 0x57, 0x0b,
+	0x01, 0x08, 0x08, 0x06, 0x60, 0x09, 0x01, 0x06, 0x08, 0x06, 0x50, 0x1b, 0x40, 0x46, 0x00, 0x0c,
+	0x00, 0x0c, 0x85, 0x00, 0x07, 0x03, 0x02, 0x03, 0x07, 0x02, 0x80, 0x0e, 0x01, 0x0a, 0x06, 0x0d,
+	0x06, 0x0a, 0x0d, 0x80, 0x0f, 0x01, 0x0d, 0x0d, 0x84, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05,
+	0x69, 0x00, 0x04, 0x00, 0x03, 0x07, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x08, 0x02, 0x01,
+	0x69, 0x0b, 0x01, 0x08, 0x06, 0x06, 0x08, 0x57, 0x0b, 0x01, 0x08, 0x08, 0x06, 0x60, 0x09, 0x01,
+	0x06, 0x08, 0x06, 0x50, 0x59, 0x59, 0x40, 0x1e, 0x2c, 0x2c, 0x1e, 0x1e, 0x2c, 0x2f, 0x2c, 0x2f,
+	0x2e, 0x2d, 0x2a, 0x29, 0x1e, 0x28, 0x1e, 0x28, 0x27, 0x26, 0x11, 0x12, 0x12, 0x22, 0x21, 0x22,
+	0x23, 0x27, 0x22, 0x10, 0x09, 0x1f, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x36, 0x33, 0x20,
+	0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36,
+	0x21, 0x23, 0x37, 0x33, 0x32, 0x37, 0x36, 0x23, 0x22, 0x01, 0x37, 0x21, 0x37, 0x01, 0x33, 0x03,
+	0x33, 0x07, 0x23, 0x07, 0x01, 0x21, 0x13, 0x01, 0x01, 0x33, 0x01, 0x01, 0x94, 0x16, 0x76, 0x74,
+	0x01, 0x1a, 0x2a, 0x20, 0xcb, 0xd2, 0x26, 0x18, 0xca, 0x96, 0x6b, 0x71, 0x19, 0x78, 0x4e, 0xb8,
+	0x21, 0x24, 0xfe, 0xfc, 0x33, 0x13, 0x2c, 0xf4, 0x21, 0x1a, 0x9c, 0x5c, 0x03, 0x39, 0x30, 0xfe,
+	0x69, 0x16, 0x01, 0xfe, 0x8b, 0x6a, 0x7c, 0x17, 0x7c, 0x30, 0xfe, 0xaa, 0x01, 0x16, 0x49, 0xfb,
+	0x4a, 0x05, 0x77, 0x87, 0xfa, 0x89, 0x05, 0x49, 0x70, 0x26, 0xd2, 0x9d, 0x41, 0x32, 0xbc, 0x7a,
+	0x8d, 0x1d, 0x7a, 0x33, 0xa4, 0xb5, 0x5d, 0xa6, 0x81, 0xfa, 0x85, 0xf2, 0x71, 0x02, 0x15, 0xfd,
+	0xef, 0x75, 0xf2, 0x01, 0x67, 0x01, 0x6c, 0xfd, 0x08, 0x06, 0x12, 0xf9, 0xee, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x6d, 0xfe, 0x50, 0x04, 0x1b, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x3c,
+	0x40, 0x39, 0x0e, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x06, 0x01, 0x04, 0x00, 0x02, 0x00, 0x04, 0x02,
+	0x80, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x1a, 0x04, 0x1a, 0x12,
+	0x10, 0x0d, 0x0b, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x01, 0x07, 0x23, 0x37,
+	0x13, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x06, 0x21, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x13,
+	0x36, 0x37, 0x37, 0x36, 0x36, 0x37, 0x37, 0x04, 0x1b, 0x28, 0xc5, 0x28, 0x76, 0x0b, 0x31, 0xbd,
+	0x67, 0xcb, 0x1d, 0x27, 0x01, 0x13, 0xae, 0xe8, 0x22, 0xde, 0xc3, 0xfe, 0x2a, 0x46, 0x23, 0xd7,
+	0x5b, 0x70, 0x60, 0x18, 0x17, 0x04, 0x3e, 0xc6, 0xc6, 0xfe, 0x75, 0x37, 0xf4, 0x80, 0x45, 0x89,
+	0x90, 0xc6, 0x4b, 0xa7, 0x38, 0x01, 0x5b, 0xb4, 0x78, 0x32, 0x3d, 0x83, 0x7b, 0x6f, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x49, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e,
+	0x00, 0x65, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f,
+	0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x1f, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x04, 0x00,
+	0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01,
+	0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07,
+	0x11, 0x11, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01,
+	0x21, 0x03, 0x13, 0x23, 0x01, 0x33, 0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49, 0xfd, 0xae,
+	0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xf7, 0x94, 0xfe, 0xff, 0xe4, 0x05, 0xc8, 0xfa, 0x38, 0x01,
+	0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x9e, 0x01, 0x41, 0x00, 0x00, 0x03, 0x00, 0x1e,
+	0x00, 0x00, 0x05, 0x79, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6b, 0xb5, 0x0a,
+	0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05,
+	0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x00,
+	0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59,
+	0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21,
+	0x03, 0x01, 0x21, 0x03, 0x03, 0x01, 0x33, 0x01, 0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49,
+	0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0x31, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05, 0xc8,
+	0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x9e, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x56, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x12,
+	0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x06, 0x05, 0x0a, 0x01, 0x04, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03,
+	0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07,
+	0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x0b, 0x0b, 0x00,
+	0x00, 0x0b, 0x12, 0x0b, 0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21,
+	0x03, 0x03, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2,
+	0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xfb, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x9e,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x74,
+	0x07, 0x4c, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x1e, 0x00, 0x86, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x07, 0x01, 0x05, 0x00, 0x09, 0x08, 0x05, 0x09,
+	0x69, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x00, 0x06, 0x08, 0x6a, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0b, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e,
+	0x1b, 0x40, 0x2b, 0x00, 0x00, 0x08, 0x04, 0x08, 0x00, 0x04, 0x80, 0x07, 0x01, 0x05, 0x00, 0x09,
+	0x08, 0x05, 0x09, 0x69, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x00, 0x06, 0x08, 0x6a, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x0b, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x1e, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x1e, 0x0b, 0x1e, 0x1d, 0x1b, 0x18, 0x16, 0x15, 0x14, 0x13,
+	0x11, 0x0e, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x19, 0x2b,
+	0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x03, 0x36, 0x33, 0x32, 0x17,
+	0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x1e,
+	0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xea,
+	0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35, 0x31,
+	0x1e, 0x44, 0x1f, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01,
+	0xb2, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x04, 0x00, 0x1e,
+	0x00, 0x00, 0x05, 0x49, 0x07, 0x0f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x12, 0x00, 0x78,
+	0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01,
+	0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x24, 0x00, 0x00, 0x06, 0x04, 0x06, 0x00, 0x04, 0x80, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x09, 0x03,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1e, 0x0f, 0x0f, 0x0b, 0x0b, 0x00, 0x00, 0x0f,
+	0x12, 0x0f, 0x12, 0x11, 0x10, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03,
+	0x01, 0x21, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x1e, 0x03, 0x59, 0xd0, 0x01,
+	0x02, 0xe2, 0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xb9, 0x23, 0xad, 0x23, 0xde,
+	0x23, 0xad, 0x23, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01,
+	0xb2, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e,
+	0x07, 0x8f, 0x00, 0x16, 0x00, 0x19, 0x00, 0x25, 0x00, 0x78, 0xb5, 0x19, 0x01, 0x06, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x01, 0x00, 0x08, 0x07, 0x01, 0x08, 0x69,
+	0x00, 0x06, 0x00, 0x04, 0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x09, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x27, 0x02,
+	0x01, 0x00, 0x07, 0x06, 0x07, 0x00, 0x06, 0x80, 0x00, 0x01, 0x00, 0x08, 0x07, 0x01, 0x08, 0x69,
+	0x00, 0x06, 0x00, 0x04, 0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x09, 0x05,
+	0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18, 0x1b, 0x1a, 0x00, 0x00, 0x21, 0x1f, 0x1a,
+	0x25, 0x1b, 0x25, 0x18, 0x17, 0x00, 0x16, 0x00, 0x16, 0x11, 0x11, 0x16, 0x26, 0x11, 0x0b, 0x09,
+	0x1b, 0x2b, 0x33, 0x01, 0x33, 0x26, 0x27, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06,
+	0x07, 0x06, 0x07, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x13, 0x32, 0x36, 0x37,
+	0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x13, 0x03, 0x59, 0x51, 0x4c, 0x2d, 0x35, 0x13,
+	0x13, 0x9e, 0x5f, 0x5e, 0x6b, 0x13, 0x13, 0x50, 0x48, 0x56, 0x55, 0x01, 0x02, 0xe2, 0x49, 0xfd,
+	0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0x5d, 0x3c, 0x62, 0x0c, 0x0c, 0x42, 0x3a, 0x3b, 0x61,
+	0x0c, 0x0c, 0x41, 0x05, 0xc8, 0x08, 0x3b, 0x43, 0x5f, 0x5d, 0x85, 0x84, 0x5e, 0x60, 0x42, 0x3c,
+	0x07, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x6f, 0x52, 0x3c, 0x3a,
+	0x51, 0x50, 0x3b, 0x3a, 0x54, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x08, 0xcd,
+	0x05, 0xc8, 0x00, 0x02, 0x00, 0x12, 0x00, 0x72, 0xb5, 0x02, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x67, 0x00, 0x00,
+	0x00, 0x07, 0x05, 0x00, 0x07, 0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d,
+	0x00, 0x05, 0x05, 0x06, 0x5f, 0x09, 0x08, 0x02, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x25,
+	0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x67,
+	0x00, 0x00, 0x00, 0x07, 0x05, 0x00, 0x07, 0x67, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x09, 0x08, 0x02,
+	0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x11, 0x03, 0x03, 0x03, 0x12, 0x03, 0x12, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x13, 0x10, 0x0a, 0x09, 0x1e, 0x2b, 0x01, 0x21, 0x13, 0x01, 0x01, 0x21,
+	0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x13, 0x21, 0x01, 0x02, 0xd1, 0x01,
+	0xa2, 0x84, 0xfb, 0x27, 0x04, 0xd3, 0x03, 0xdc, 0x1f, 0xfd, 0x2e, 0x5f, 0x02, 0x6e, 0x1f, 0xfd,
+	0x92, 0x6b, 0x02, 0xfd, 0x1f, 0xfc, 0x31, 0x52, 0xfd, 0xfb, 0xfe, 0xa8, 0x02, 0x39, 0x02, 0x92,
+	0xfb, 0x35, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x01, 0x9e, 0xfe, 0x62, 0x00,
+	0x00, 0x01, 0x00, 0xc6, 0xfe, 0x50, 0x06, 0x73, 0x05, 0xed, 0x00, 0x28, 0x00, 0x7e, 0x40, 0x17,
+	0x1d, 0x01, 0x06, 0x05, 0x28, 0x1e, 0x02, 0x07, 0x06, 0x14, 0x01, 0x00, 0x07, 0x0d, 0x01, 0x03,
+	0x04, 0x0c, 0x01, 0x02, 0x03, 0x05, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x01,
+	0x00, 0x04, 0x03, 0x01, 0x04, 0x69, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3e, 0x4d,
+	0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69,
+	0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x69, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0b,
+	0x24, 0x23, 0x27, 0x12, 0x23, 0x24, 0x11, 0x21, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x06, 0x21, 0x23,
+	0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23,
+	0x37, 0x24, 0x27, 0x26, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03,
+	0x02, 0x12, 0x21, 0x32, 0x25, 0x05, 0x62, 0xf2, 0xfe, 0xf2, 0x14, 0x35, 0x4e, 0x61, 0x0d, 0x0e,
+	0x88, 0x54, 0x47, 0x47, 0x11, 0x2b, 0x3b, 0x67, 0x0e, 0x14, 0xbb, 0x69, 0xfe, 0xeb, 0x7f, 0x97,
+	0x4c, 0x4c, 0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe, 0xe3, 0xb4, 0xff, 0xfe, 0xb5, 0x3d,
+	0x3a, 0xde, 0x01, 0x05, 0xdf, 0x01, 0x0b, 0x4c, 0x71, 0x48, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51,
+	0x0f, 0x4a, 0x60, 0x8f, 0x1b, 0xa6, 0xc6, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69,
+	0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc9,
+	0x00, 0x00, 0x06, 0x21, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x28, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x07,
+	0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05,
+	0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03,
+	0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x03, 0x23, 0x01, 0x33, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f,
+	0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0x5e, 0x94, 0xfe, 0xff,
+	0xe4, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x74,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00,
+	0x07, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x01, 0x33, 0x01, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f,
+	0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0xfe, 0x7a, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05, 0xc8, 0x9d,
+	0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xc9,
+	0x00, 0x00, 0x06, 0x21, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x7f, 0xb5, 0x11, 0x01, 0x07,
+	0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a,
+	0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07,
+	0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04,
+	0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b,
+	0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21,
+	0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xc9,
+	0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b,
+	0x1f, 0xfd, 0xb3, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0x9d, 0xfe, 0x25,
+	0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x03, 0x00, 0xc9,
+	0x00, 0x00, 0x06, 0x21, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07,
+	0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b,
+	0x40, 0x28, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c,
+	0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03,
+	0x8b, 0x1f, 0xfd, 0xe6, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23, 0x05, 0xc8, 0x9d, 0xfe, 0x25,
+	0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x87,
+	0x00, 0x00, 0x03, 0xe7, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x62, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x22, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02,
+	0x06, 0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1b, 0x2b, 0x33, 0x37,
+	0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x13, 0x23, 0x01, 0x33, 0x87, 0x1f,
+	0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0xb7, 0x94, 0xfe, 0xff, 0xe4,
+	0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x02, 0x00, 0x87,
+	0x00, 0x00, 0x04, 0x64, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x07, 0x85, 0x03, 0x01,
+	0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01,
+	0x07, 0x02, 0x07, 0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x68, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07,
+	0x03, 0x01, 0x33, 0x01, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4,
+	0x1f, 0x71, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x87, 0x00, 0x00, 0x04, 0x42,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x73, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x02,
+	0x07, 0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07,
+	0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x02, 0x07, 0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02,
+	0x01, 0x68, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b,
+	0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23,
+	0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x87,
+	0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0xfe, 0xc6, 0x01, 0x31,
+	0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x4e,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0x87, 0x00, 0x00, 0x04, 0x23,
+	0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x72, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x24, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07,
+	0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c,
+	0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13,
+	0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0xf9, 0x23, 0xad,
+	0x23, 0xdf, 0x23, 0xad, 0x23, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x62, 0xad,
+	0xad, 0xad, 0xad, 0x00, 0x00, 0x02, 0x00, 0xa1, 0x00, 0x00, 0x06, 0xa6, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x1a, 0x00, 0x60, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01,
+	0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02,
+	0x00, 0x05, 0x01, 0x02, 0x05, 0x67, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67,
+	0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x1a, 0x19, 0x18, 0x17, 0x16, 0x14, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x11, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x21, 0x20, 0x03, 0x02, 0x00, 0x21,
+	0x27, 0x33, 0x20, 0x00, 0x13, 0x12, 0x27, 0x26, 0x26, 0x23, 0x23, 0x03, 0x21, 0x07, 0x21, 0xba,
+	0x87, 0xa0, 0x20, 0xa0, 0x80, 0x01, 0xda, 0x02, 0xeb, 0x8d, 0x49, 0xfe, 0x2a, 0xfe, 0x9d, 0xec,
+	0xfc, 0x01, 0x0e, 0x01, 0x43, 0x3c, 0x35, 0x61, 0x3b, 0xc8, 0xd6, 0x9b, 0x61, 0x01, 0x4d, 0x20,
+	0xfe, 0xb3, 0x02, 0xa7, 0x9d, 0x02, 0x84, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27,
+	0x01, 0x2f, 0x01, 0x05, 0x95, 0x5b, 0x43, 0xfe, 0x19, 0x9d, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x06, 0x53, 0x07, 0x4c, 0x00, 0x09, 0x00, 0x1d, 0x00, 0x77, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x06, 0x01, 0x04, 0x00, 0x08,
+	0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0b, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x0a, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x24, 0x01,
+	0x01, 0x00, 0x07, 0x02, 0x07, 0x00, 0x02, 0x80, 0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08,
+	0x69, 0x00, 0x05, 0x0b, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x0a, 0x03, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x1c, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x1d, 0x0a, 0x1d, 0x1c, 0x1a,
+	0x17, 0x15, 0x14, 0x13, 0x12, 0x10, 0x0d, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x0c,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x23, 0x01, 0x03, 0x01, 0x36, 0x33,
+	0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22,
+	0x07, 0xb0, 0x01, 0x27, 0xcd, 0x02, 0x17, 0xe4, 0xb4, 0xfe, 0xd9, 0xce, 0xfd, 0xea, 0xe4, 0x01,
+	0x8d, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35,
+	0x31, 0x1e, 0x44, 0x1f, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89,
+	0x06, 0x62, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x03, 0x00, 0xb5,
+	0xff, 0xdb, 0x06, 0xc2, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x65, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x07, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00, 0x1b,
+	0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x08,
+	0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25,
+	0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x23, 0x01, 0x33, 0x03,
+	0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46,
+	0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b,
+	0x3a, 0xb9, 0x02, 0x96, 0x94, 0xfe, 0xff, 0xe4, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01,
+	0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23,
+	0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xd6, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc2, 0x07, 0x85, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x6b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01,
+	0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x6a, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x01, 0x33, 0x01, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46,
+	0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9,
+	0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0x01, 0x6e, 0x01, 0x31,
+	0xe4, 0xfe, 0x7f, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0,
+	0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe,
+	0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xcc, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0xb5,
+	0xff, 0xdb, 0x06, 0xc2, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d,
+	0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f,
+	0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x0a, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00,
+	0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x01, 0x33, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41,
+	0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc,
+	0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0xae, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1,
+	0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe,
+	0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde,
+	0xfe, 0xb6, 0x05, 0xd6, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0xb5,
+	0xff, 0xdb, 0x06, 0xc2, 0x07, 0x4c, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2b, 0x00, 0x83, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2a, 0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08, 0x69, 0x00, 0x05,
+	0x0c, 0x09, 0x02, 0x07, 0x01, 0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x28, 0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0c, 0x09, 0x02,
+	0x07, 0x01, 0x05, 0x07, 0x6a, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x18, 0x18, 0x0d,
+	0x0c, 0x01, 0x00, 0x18, 0x2b, 0x18, 0x2b, 0x2a, 0x28, 0x25, 0x23, 0x22, 0x21, 0x20, 0x1e, 0x1b,
+	0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0d, 0x09, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00,
+	0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16,
+	0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x03, 0x16, 0xfe,
+	0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe,
+	0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9,
+	0xb5, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35,
+	0x31, 0x1e, 0x44, 0x1f, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe,
+	0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba,
+	0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xea, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25,
+	0x22, 0x6e, 0x00, 0x00, 0x00, 0x04, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc2, 0x07, 0x0f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x75, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x06,
+	0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x21, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05,
+	0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01,
+	0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03, 0x16, 0xfe,
+	0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe,
+	0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9,
+	0xf0, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01,
+	0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23,
+	0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xea, 0xad, 0xad, 0xad, 0xad,
+	0x00, 0x01, 0x00, 0x95, 0x00, 0x66, 0x05, 0x03, 0x04, 0x3a, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x09,
+	0x03, 0x01, 0x32, 0x2b, 0x37, 0x01, 0x01, 0x37, 0x01, 0x01, 0x17, 0x01, 0x01, 0x07, 0x01, 0x01,
+	0x95, 0x01, 0xce, 0xfe, 0xcc, 0x7e, 0x01, 0x34, 0x01, 0xce, 0x54, 0xfe, 0x32, 0x01, 0x34, 0x7e,
+	0xfe, 0xcc, 0xfe, 0x32, 0xcf, 0x01, 0x81, 0x01, 0x81, 0x69, 0xfe, 0x7f, 0x01, 0x81, 0x69, 0xfe,
+	0x7f, 0xfe, 0x7f, 0x69, 0x01, 0x81, 0xfe, 0x7f, 0x00, 0x03, 0x00, 0x6b, 0xff, 0xdb, 0x07, 0x17,
+	0x05, 0xed, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x5f, 0x40, 0x11, 0x08, 0x01, 0x05, 0x00,
+	0x23, 0x1b, 0x0b, 0x01, 0x04, 0x04, 0x05, 0x12, 0x01, 0x02, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x18, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00,
+	0x04, 0x04, 0x02, 0x61, 0x06, 0x03, 0x02, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x01,
+	0x01, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x69, 0x00, 0x04, 0x04, 0x02, 0x61, 0x06, 0x03, 0x02,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x1f, 0x1d, 0x17, 0x15, 0x00, 0x13,
+	0x00, 0x13, 0x25, 0x12, 0x25, 0x07, 0x09, 0x19, 0x2b, 0x17, 0x37, 0x26, 0x13, 0x12, 0x00, 0x21,
+	0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x03, 0x02, 0x00, 0x21, 0x22, 0x27, 0x07, 0x01, 0x16, 0x33,
+	0x32, 0x00, 0x13, 0x36, 0x27, 0x27, 0x26, 0x23, 0x22, 0x00, 0x03, 0x06, 0x17, 0x6b, 0xda, 0x8e,
+	0x45, 0x46, 0x01, 0xd4, 0x01, 0x40, 0xfb, 0x95, 0x85, 0xac, 0xe1, 0x88, 0x43, 0x47, 0xfe, 0x2d,
+	0xfe, 0xbf, 0xf2, 0x97, 0x80, 0x01, 0x0d, 0x64, 0xb7, 0xe2, 0x01, 0x3f, 0x3a, 0x30, 0x34, 0x3e,
+	0x67, 0xba, 0xe2, 0xfe, 0xc2, 0x3a, 0x31, 0x38, 0x25, 0xdd, 0xd8, 0x01, 0x55, 0x01, 0x62, 0x01,
+	0xa6, 0x85, 0x85, 0xe3, 0xd9, 0xfe, 0xb3, 0xfe, 0x9d, 0xfe, 0x5a, 0x80, 0x80, 0x01, 0x10, 0x73,
+	0x01, 0x46, 0x01, 0x23, 0xf2, 0x94, 0x71, 0x78, 0xfe, 0xba, 0xfe, 0xde, 0xf6, 0x99, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04,
+	0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02,
+	0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x59, 0x40, 0x09, 0x11, 0x13, 0x25, 0x13, 0x25, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x03,
+	0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23,
+	0x20, 0x02, 0x13, 0x01, 0x23, 0x01, 0x33, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa,
+	0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x03,
+	0xa7, 0x94, 0xfe, 0xff, 0xe4, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03,
+	0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x2a, 0x01, 0x41, 0x00,
+	0x00, 0x02, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x07, 0x85, 0x00, 0x15, 0x00, 0x19, 0x00, 0x54,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00,
+	0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03,
+	0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05,
+	0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42,
+	0x03, 0x4e, 0x59, 0x40, 0x0e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10,
+	0x07, 0x09, 0x1b, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13,
+	0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x01, 0x33, 0x01, 0x01, 0xd8,
+	0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78,
+	0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x02, 0x7f, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05, 0xc8, 0xfc,
+	0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01,
+	0x18, 0x01, 0x31, 0x04, 0x20, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xe1,
+	0xff, 0xdb, 0x06, 0x52, 0x07, 0x85, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x5e, 0xb5, 0x1b, 0x01, 0x05,
+	0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07,
+	0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07,
+	0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d,
+	0x11, 0x14, 0x25, 0x13, 0x25, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17,
+	0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13,
+	0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54,
+	0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d,
+	0x01, 0xb5, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93,
+	0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31,
+	0x04, 0x20, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x03, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52,
+	0x07, 0x0f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x61, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40,
+	0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08,
+	0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x16, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19,
+	0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16,
+	0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02,
+	0x13, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d,
+	0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2,
+	0x3d, 0x01, 0xf7, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93,
+	0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31,
+	0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0x01, 0x50, 0x00, 0x00, 0x06, 0x6b,
+	0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x59, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00,
+	0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00,
+	0x02, 0x00, 0x85, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x09, 0x09, 0x00,
+	0x00, 0x09, 0x0c, 0x09, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x07, 0x09, 0x18,
+	0x2b, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x03, 0x13, 0x01, 0x33, 0x01, 0x02, 0x3c,
+	0x7b, 0xfe, 0x99, 0xf0, 0x01, 0x1c, 0x02, 0x4c, 0xc3, 0xfd, 0x1f, 0x7c, 0x52, 0x01, 0x31, 0xe4,
+	0xfe, 0x7f, 0x02, 0x69, 0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xb2, 0x00, 0x00, 0x05, 0xf4, 0x05, 0xc8, 0x00, 0x0d,
+	0x00, 0x15, 0x00, 0x56, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x05, 0x04,
+	0x01, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00,
+	0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x06,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x15, 0x13, 0x10, 0x0e, 0x00,
+	0x0d, 0x00, 0x0d, 0x25, 0x21, 0x11, 0x07, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x21, 0x32,
+	0x16, 0x17, 0x16, 0x07, 0x02, 0x21, 0x21, 0x03, 0x13, 0x21, 0x20, 0x13, 0x36, 0x26, 0x23, 0x21,
+	0xb2, 0x01, 0x27, 0xd2, 0x38, 0x01, 0x72, 0xe4, 0xbd, 0x32, 0x3c, 0x21, 0x65, 0xfd, 0x87, 0xfe,
+	0xca, 0x3d, 0x5d, 0x01, 0x2d, 0x01, 0xa4, 0x42, 0x1c, 0x98, 0xf2, 0xfe, 0xce, 0x05, 0xc8, 0xfe,
+	0xe9, 0x35, 0x4d, 0x5f, 0xa3, 0xfe, 0x07, 0xfe, 0xcc, 0x01, 0xd3, 0x01, 0x4a, 0x8f, 0x67, 0x00,
+	0x00, 0x01, 0x00, 0x8c, 0xff, 0xe7, 0x04, 0xf8, 0x06, 0x44, 0x00, 0x27, 0x00, 0x90, 0x4b, 0xb0,
+	0x14, 0x50, 0x58, 0x40, 0x0a, 0x14, 0x01, 0x02, 0x03, 0x13, 0x01, 0x01, 0x02, 0x02, 0x4c, 0x1b,
+	0x40, 0x0a, 0x14, 0x01, 0x02, 0x03, 0x13, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x14,
+	0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x40, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x05, 0x04, 0x02, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x40, 0x4d, 0x05, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x40,
+	0x1b, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x40, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x27, 0x00, 0x27, 0x2b, 0x23, 0x2b, 0x23, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x13,
+	0x36, 0x36, 0x33, 0x20, 0x03, 0x06, 0x07, 0x06, 0x07, 0x06, 0x17, 0x17, 0x16, 0x07, 0x02, 0x21,
+	0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x36, 0x37, 0x36, 0x37,
+	0x36, 0x23, 0x22, 0x06, 0x07, 0x03, 0x8c, 0xe5, 0x33, 0xdf, 0xed, 0x01, 0x7d, 0x37, 0x1a, 0x9d,
+	0x7d, 0x0b, 0x0d, 0x5e, 0x9e, 0x92, 0x25, 0x40, 0xfe, 0x8d, 0xb0, 0x79, 0x23, 0xa8, 0x72, 0xc3,
+	0x1f, 0x14, 0x62, 0xb6, 0x6d, 0x17, 0x13, 0x8f, 0x7b, 0x11, 0x20, 0xc4, 0x76, 0x70, 0x19, 0xf6,
+	0x04, 0x7f, 0xff, 0xc6, 0xfe, 0xee, 0x82, 0x89, 0x6d, 0x37, 0x43, 0x53, 0x89, 0x80, 0xb8, 0xfe,
+	0xbb, 0x37, 0xac, 0x4f, 0x9b, 0x64, 0x57, 0xa4, 0x62, 0x74, 0x60, 0x91, 0x7d, 0x56, 0xa1, 0x62,
+	0x7c, 0xfb, 0x2e, 0x00, 0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbc, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0d, 0x00, 0x1b, 0x01, 0x05, 0xb5, 0x05, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x14,
+	0x50, 0x58, 0x40, 0x24, 0x00, 0x00, 0x01, 0x05, 0x01, 0x00, 0x05, 0x80, 0x00, 0x01, 0x01, 0x3a,
+	0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x04,
+	0x61, 0x07, 0x01, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x28,
+	0x00, 0x00, 0x01, 0x05, 0x01, 0x00, 0x05, 0x80, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2c,
+	0x00, 0x00, 0x01, 0x05, 0x01, 0x00, 0x05, 0x80, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x06, 0x06,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39,
+	0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x05, 0x00, 0x85, 0x00, 0x06,
+	0x06, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07,
+	0x39, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x40, 0x29,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x05, 0x00, 0x85, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0b, 0x11,
+	0x11, 0x24, 0x22, 0x23, 0x23, 0x11, 0x10, 0x08, 0x09, 0x1e, 0x2b, 0x01, 0x23, 0x01, 0x33, 0x13,
+	0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x23, 0x03, 0xd2, 0x94, 0xfe, 0xfd, 0xe4, 0x4b, 0x6f, 0x83,
+	0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01,
+	0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x05, 0x03, 0x01, 0x41, 0xfb, 0x3a, 0x02, 0x2b, 0x19,
+	0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2,
+	0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbd, 0x06, 0x44, 0x00, 0x03, 0x00, 0x0d, 0x00, 0x1b,
+	0x01, 0x15, 0xb5, 0x05, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x25,
+	0x08, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x80, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x02,
+	0x02, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x07, 0x01,
+	0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x29, 0x08, 0x01, 0x01,
+	0x00, 0x05, 0x00, 0x01, 0x05, 0x80, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61,
+	0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2d, 0x08, 0x01,
+	0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x80, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x06, 0x06, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39, 0x4d,
+	0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x05, 0x01, 0x85, 0x00, 0x06,
+	0x06, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07,
+	0x39, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x40, 0x2a,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x05, 0x01, 0x85, 0x00, 0x06, 0x06, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00,
+	0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x16,
+	0x00, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x0d, 0x0b, 0x08, 0x06, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x01, 0x01, 0x33, 0x01, 0x13, 0x13, 0x26, 0x23, 0x20,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x33, 0x03, 0x23, 0x02, 0xaa, 0x01, 0x2f, 0xe4, 0xfe, 0x81, 0x2c, 0x6f, 0x83, 0x44, 0xfe, 0xe4,
+	0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f,
+	0x5a, 0xc5, 0xd9, 0xc5, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xfc, 0x7b, 0x02, 0x2b, 0x19, 0xfe,
+	0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2, 0x00,
+	0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbc, 0x06, 0x44, 0x00, 0x07, 0x00, 0x11, 0x00, 0x1f,
+	0x01, 0x20, 0x40, 0x0a, 0x05, 0x01, 0x01, 0x00, 0x09, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x14, 0x50, 0x58, 0x40, 0x26, 0x09, 0x02, 0x02, 0x01, 0x00, 0x06, 0x00, 0x01, 0x06, 0x80, 0x00,
+	0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x06, 0x61, 0x07, 0x01, 0x06, 0x06, 0x41, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x61, 0x08, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x2a, 0x09, 0x02, 0x02, 0x01, 0x00, 0x06, 0x00, 0x01, 0x06, 0x80, 0x00, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x03, 0x03, 0x06, 0x61, 0x07, 0x01, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x08, 0x08,
+	0x39, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x2e, 0x09, 0x02, 0x02, 0x01, 0x00, 0x06, 0x00, 0x01, 0x06, 0x80, 0x00,
+	0x00, 0x00, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x06, 0x61, 0x00, 0x06,
+	0x06, 0x41, 0x4d, 0x00, 0x08, 0x08, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85,
+	0x09, 0x02, 0x02, 0x01, 0x06, 0x01, 0x85, 0x00, 0x07, 0x07, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x06,
+	0x61, 0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x08, 0x08, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x02,
+	0x02, 0x01, 0x06, 0x01, 0x85, 0x00, 0x07, 0x07, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x41, 0x4d, 0x00, 0x08, 0x08, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x42, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x17, 0x00, 0x00, 0x1f, 0x1e, 0x1d, 0x1c,
+	0x1b, 0x19, 0x15, 0x13, 0x11, 0x0f, 0x0c, 0x0a, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x0a, 0x09,
+	0x18, 0x2b, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x13, 0x13, 0x26, 0x23, 0x20, 0x03,
+	0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33,
+	0x03, 0x23, 0x01, 0xe9, 0x01, 0x2f, 0xda, 0xb3, 0x94, 0xa2, 0x02, 0xf0, 0xed, 0x6f, 0x83, 0x44,
+	0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49,
+	0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0xfc, 0x7b,
+	0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38,
+	0x18, 0xfb, 0xc2, 0x00, 0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbc, 0x05, 0xf7, 0x00, 0x13,
+	0x00, 0x1d, 0x00, 0x2b, 0x01, 0x03, 0xb5, 0x15, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x14,
+	0x50, 0x58, 0x40, 0x2c, 0x00, 0x01, 0x0c, 0x05, 0x02, 0x03, 0x09, 0x01, 0x03, 0x6a, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x06, 0x06, 0x09, 0x61, 0x0a, 0x01,
+	0x09, 0x09, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x08, 0x61, 0x0b, 0x01, 0x08, 0x08, 0x42, 0x08, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x30, 0x00, 0x01, 0x0c, 0x05, 0x02, 0x03, 0x09, 0x01,
+	0x03, 0x6a, 0x00, 0x04, 0x04, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x06, 0x06,
+	0x09, 0x61, 0x0a, 0x01, 0x09, 0x09, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x39, 0x4d, 0x00, 0x07, 0x07,
+	0x08, 0x61, 0x00, 0x08, 0x08, 0x42, 0x08, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x34,
+	0x00, 0x01, 0x0c, 0x05, 0x02, 0x03, 0x09, 0x01, 0x03, 0x6a, 0x00, 0x04, 0x04, 0x00, 0x61, 0x02,
+	0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x0a, 0x0a, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x09, 0x61, 0x00,
+	0x09, 0x09, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x39, 0x4d, 0x00, 0x07, 0x07, 0x08, 0x61, 0x00, 0x08,
+	0x08, 0x42, 0x08, 0x4e, 0x1b, 0x40, 0x32, 0x02, 0x01, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x69,
+	0x00, 0x01, 0x0c, 0x05, 0x02, 0x03, 0x09, 0x01, 0x03, 0x6a, 0x00, 0x0a, 0x0a, 0x3b, 0x4d, 0x00,
+	0x06, 0x06, 0x09, 0x61, 0x00, 0x09, 0x09, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x3c, 0x4d, 0x00, 0x07,
+	0x07, 0x08, 0x61, 0x00, 0x08, 0x08, 0x42, 0x08, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1a, 0x00, 0x00,
+	0x2b, 0x2a, 0x29, 0x28, 0x27, 0x25, 0x21, 0x1f, 0x1d, 0x1b, 0x18, 0x16, 0x00, 0x13, 0x00, 0x13,
+	0x23, 0x21, 0x11, 0x23, 0x21, 0x0d, 0x09, 0x1b, 0x2b, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16,
+	0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x01, 0x13, 0x26,
+	0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33,
+	0x32, 0x17, 0x33, 0x03, 0x23, 0x01, 0xda, 0x39, 0xad, 0x49, 0x37, 0x35, 0x31, 0x1e, 0x44, 0x1e,
+	0x7b, 0x38, 0xae, 0x49, 0x37, 0x34, 0x32, 0x1e, 0x44, 0x1e, 0x01, 0x15, 0x6f, 0x83, 0x44, 0xfe,
+	0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5,
+	0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x05, 0x0d, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22,
+	0x6e, 0xfc, 0x71, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01,
+	0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2, 0x00, 0x00, 0x00, 0x04, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbc,
+	0x05, 0xba, 0x00, 0x03, 0x00, 0x07, 0x00, 0x11, 0x00, 0x1f, 0x00, 0xeb, 0xb5, 0x09, 0x01, 0x05,
+	0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x01,
+	0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x08, 0x01, 0x07,
+	0x07, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x09, 0x01, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x29, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x01, 0x00, 0x5f, 0x02,
+	0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x41, 0x4d,
+	0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2d, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x01, 0x00, 0x5f,
+	0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61,
+	0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x40, 0x2b, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01,
+	0x07, 0x00, 0x01, 0x67, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07,
+	0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x42, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1e, 0x04, 0x04, 0x00, 0x00, 0x1f, 0x1e, 0x1d, 0x1c,
+	0x1b, 0x19, 0x15, 0x13, 0x11, 0x0f, 0x0c, 0x0a, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x0c, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03,
+	0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x23, 0x02, 0x25, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22,
+	0xf3, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95,
+	0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad,
+	0xfc, 0x71, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e,
+	0x01, 0x38, 0x18, 0xfb, 0xc2, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbc,
+	0x06, 0xc9, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x21, 0x00, 0x2f, 0x00, 0xfe, 0xb5, 0x19, 0x01, 0x05,
+	0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x07, 0x02, 0x00, 0x69, 0x00, 0x04, 0x04, 0x07,
+	0x61, 0x08, 0x01, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x09, 0x01, 0x06, 0x06,
+	0x42, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x01, 0x00, 0x03, 0x02,
+	0x01, 0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x07, 0x02, 0x00, 0x69, 0x00, 0x04, 0x04,
+	0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x05, 0x05,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x31,
+	0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x07, 0x02,
+	0x00, 0x69, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41,
+	0x4d, 0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06,
+	0x4e, 0x1b, 0x40, 0x31, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a,
+	0x01, 0x00, 0x07, 0x02, 0x00, 0x69, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61,
+	0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x42, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1f, 0x0d, 0x0c, 0x01, 0x00, 0x2f, 0x2e,
+	0x2d, 0x2c, 0x2b, 0x29, 0x25, 0x23, 0x21, 0x1f, 0x1c, 0x1a, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17,
+	0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36,
+	0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x16, 0x13, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22,
+	0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x23, 0x03, 0x44, 0x5c, 0x6a, 0x12, 0x12,
+	0x9e, 0x5f, 0x5e, 0x6b, 0x12, 0x12, 0x9f, 0x4f, 0x3c, 0x63, 0x0b, 0x0b, 0x43, 0x3a, 0x3b, 0x62,
+	0x0b, 0x0b, 0x42, 0x4d, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3,
+	0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x05, 0x03, 0x85,
+	0x5e, 0x5e, 0x85, 0x84, 0x5e, 0x60, 0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53,
+	0xfc, 0x25, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e,
+	0x01, 0x38, 0x18, 0xfb, 0xc2, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x7a, 0xff, 0xe7, 0x07, 0x82,
+	0x04, 0x56, 0x00, 0x07, 0x00, 0x2a, 0x00, 0x2f, 0x01, 0x0e, 0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40,
+	0x0b, 0x26, 0x01, 0x07, 0x08, 0x17, 0x12, 0x02, 0x01, 0x00, 0x02, 0x4c, 0x1b, 0x4b, 0xb0, 0x21,
+	0x50, 0x58, 0x40, 0x0b, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12, 0x02, 0x01, 0x00, 0x02, 0x4c, 0x1b,
+	0x40, 0x0b, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12, 0x02, 0x01, 0x03, 0x02, 0x4c, 0x59, 0x59, 0x4b,
+	0xb0, 0x1c, 0x50, 0x58, 0x40, 0x23, 0x0a, 0x01, 0x07, 0x03, 0x01, 0x00, 0x01, 0x07, 0x00, 0x69,
+	0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40,
+	0x28, 0x00, 0x0a, 0x07, 0x00, 0x0a, 0x57, 0x00, 0x07, 0x03, 0x01, 0x00, 0x01, 0x07, 0x00, 0x69,
+	0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40,
+	0x29, 0x00, 0x07, 0x00, 0x00, 0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00, 0x03, 0x01, 0x0a, 0x03,
+	0x67, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01,
+	0x01, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x33, 0x00, 0x07, 0x00,
+	0x00, 0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00, 0x03, 0x01, 0x0a, 0x03, 0x67, 0x0b, 0x01, 0x08,
+	0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01,
+	0x05, 0x05, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x12, 0x2f, 0x2d, 0x2c, 0x2b, 0x2a, 0x28, 0x23, 0x23, 0x23, 0x23, 0x21,
+	0x12, 0x22, 0x22, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x13, 0x27, 0x20, 0x07, 0x06, 0x33, 0x32,
+	0x01, 0x36, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x27,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x12, 0x21, 0x33, 0x37, 0x36, 0x26, 0x23, 0x22, 0x07, 0x37,
+	0x36, 0x33, 0x32, 0x13, 0x21, 0x12, 0x23, 0x20, 0x03, 0x2f, 0x39, 0x4b, 0xfe, 0x70, 0x2c, 0x22,
+	0xca, 0x8b, 0x01, 0xc4, 0xa9, 0xc4, 0x01, 0xbd, 0x6b, 0x0c, 0xfd, 0x1c, 0x33, 0x01, 0x77, 0x9e,
+	0xbd, 0x20, 0xce, 0xbd, 0xfe, 0xd1, 0x6a, 0x95, 0xca, 0x7f, 0x95, 0x95, 0x1b, 0x4a, 0x02, 0x72,
+	0x2e, 0x1a, 0x15, 0x51, 0x7b, 0xb0, 0xc8, 0x20, 0xd8, 0xc1, 0xe9, 0x42, 0x02, 0x14, 0x3d, 0xfc,
+	0xff, 0x00, 0xf5, 0x01, 0x19, 0x02, 0xdd, 0xab, 0x03, 0x4f, 0x7f, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d,
+	0x42, 0x9c, 0x3c, 0xe6, 0x85, 0x61, 0xa4, 0x86, 0x01, 0x71, 0x83, 0x69, 0x54, 0x60, 0xa3, 0x51,
+	0xfe, 0x3e, 0x01, 0x2e, 0x00, 0x01, 0x00, 0x9a, 0xfe, 0x50, 0x04, 0x73, 0x04, 0x56, 0x00, 0x26,
+	0x00, 0x4c, 0x40, 0x49, 0x1c, 0x01, 0x06, 0x05, 0x26, 0x1d, 0x02, 0x07, 0x06, 0x13, 0x01, 0x01,
+	0x00, 0x0c, 0x01, 0x03, 0x04, 0x0b, 0x01, 0x02, 0x03, 0x05, 0x4c, 0x00, 0x01, 0x00, 0x04, 0x03,
+	0x01, 0x04, 0x69, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43,
+	0x02, 0x4e, 0x23, 0x23, 0x27, 0x12, 0x23, 0x24, 0x11, 0x11, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x06,
+	0x07, 0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36,
+	0x23, 0x37, 0x26, 0x27, 0x26, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03,
+	0x06, 0x16, 0x33, 0x32, 0x37, 0x03, 0xaf, 0x9e, 0x97, 0x40, 0x4e, 0x61, 0x0d, 0x0e, 0x88, 0x54,
+	0x47, 0x47, 0x11, 0x2b, 0x3b, 0x67, 0x0e, 0x14, 0xbb, 0x70, 0xb7, 0x5e, 0x6a, 0x33, 0x35, 0x01,
+	0x53, 0xf8, 0x84, 0xa2, 0x21, 0x96, 0x64, 0xfe, 0xa1, 0x53, 0x27, 0x8b, 0xa0, 0x7c, 0xab, 0x21,
+	0x32, 0x06, 0x56, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x98, 0x0f, 0x8c, 0x9e,
+	0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5e, 0xc2, 0xd5, 0x45, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3, 0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19,
+	0x00, 0x77, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x06, 0x07, 0x03, 0x07, 0x06, 0x03,
+	0x80, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06,
+	0x85, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59,
+	0x40, 0x16, 0x00, 0x00, 0x19, 0x18, 0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07,
+	0x00, 0x04, 0x00, 0x04, 0x21, 0x09, 0x09, 0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07,
+	0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x13,
+	0x23, 0x01, 0x33, 0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec,
+	0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0x70,
+	0x94, 0xfe, 0xff, 0xe4, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c,
+	0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x86, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3, 0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19,
+	0x00, 0x7d, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2c, 0x09, 0x01, 0x07, 0x06, 0x03, 0x06, 0x07,
+	0x03, 0x80, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x06, 0x06, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07,
+	0x03, 0x07, 0x85, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x59, 0x40, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13,
+	0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0a, 0x09, 0x17, 0x2b, 0x01,
+	0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03,
+	0x07, 0x21, 0x02, 0x21, 0x32, 0x03, 0x01, 0x33, 0x01, 0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02,
+	0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd,
+	0x2b, 0x32, 0x01, 0x69, 0x9c, 0xb8, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x02, 0x94, 0x01, 0x2f, 0xfe,
+	0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe,
+	0x7d, 0x04, 0x86, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3,
+	0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x88, 0xb5, 0x1b, 0x01, 0x07, 0x06, 0x01,
+	0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2d, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x03, 0x06, 0x07,
+	0x03, 0x80, 0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x06, 0x06, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02,
+	0x07, 0x03, 0x07, 0x85, 0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42,
+	0x02, 0x4e, 0x59, 0x40, 0x1c, 0x16, 0x16, 0x00, 0x00, 0x16, 0x1d, 0x16, 0x1d, 0x1a, 0x19, 0x18,
+	0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0b, 0x09,
+	0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00,
+	0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07,
+	0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01,
+	0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0xfe, 0x89, 0x01, 0x31,
+	0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c,
+	0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x86, 0x01, 0x41,
+	0xfe, 0xbf, 0xca, 0xca, 0x00, 0x04, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3, 0x05, 0xba, 0x00, 0x04,
+	0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x86, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x0a,
+	0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x07, 0x06, 0x5f,
+	0x08, 0x01, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01,
+	0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x03, 0x06, 0x07, 0x67, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05,
+	0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x22, 0x1a, 0x1a, 0x16, 0x16, 0x00,
+	0x00, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12,
+	0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0d, 0x09, 0x17, 0x2b, 0x01, 0x12,
+	0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03, 0x07,
+	0x21, 0x02, 0x21, 0x32, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03, 0xc0, 0x3d, 0xf5,
+	0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb,
+	0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0xfe, 0xc2, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad,
+	0x22, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe,
+	0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x90, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x02, 0xb8, 0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x6a,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00,
+	0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00,
+	0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x16, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x07, 0x06, 0x05,
+	0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x01, 0x23,
+	0x01, 0x33, 0xa5, 0xd9, 0xc5, 0xd9, 0x01, 0x4e, 0x94, 0xfe, 0xff, 0xe4, 0x04, 0x3e, 0xfb, 0xc2,
+	0x05, 0x03, 0x01, 0x41, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x03, 0x6c, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x71, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x02, 0x00,
+	0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x02, 0x03,
+	0x02, 0x85, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x00,
+	0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x01, 0x33, 0x01, 0xa5, 0xd9, 0xc5,
+	0xd9, 0x13, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0x01, 0x41, 0xfe,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x03, 0x65, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x1b, 0x06, 0x04, 0x02, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18,
+	0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17,
+	0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xa5, 0xd9, 0xc5,
+	0xd9, 0xc1, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x03, 0x3b,
+	0x05, 0xba, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1a, 0x08, 0x05, 0x07, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x04, 0x01,
+	0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08,
+	0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x09, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0xa5, 0xd9, 0xc5, 0xd9, 0x89, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0x04, 0x3e, 0xfb, 0xc2,
+	0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x98, 0xff, 0xe7, 0x05, 0x04,
+	0x06, 0x8c, 0x00, 0x19, 0x00, 0x22, 0x00, 0x4a, 0x40, 0x47, 0x06, 0x03, 0x02, 0x03, 0x00, 0x18,
+	0x17, 0x16, 0x15, 0x04, 0x02, 0x03, 0x13, 0x01, 0x05, 0x02, 0x03, 0x4c, 0x05, 0x04, 0x02, 0x00,
+	0x4a, 0x06, 0x01, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01,
+	0x4e, 0x00, 0x00, 0x21, 0x1f, 0x1d, 0x1b, 0x00, 0x19, 0x00, 0x19, 0x24, 0x28, 0x11, 0x07, 0x09,
+	0x19, 0x2b, 0x01, 0x37, 0x32, 0x17, 0x37, 0x17, 0x07, 0x00, 0x03, 0x02, 0x00, 0x23, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x17, 0x26, 0x27, 0x07, 0x27, 0x37, 0x26, 0x03, 0x02, 0x21, 0x20,
+	0x13, 0x12, 0x21, 0x22, 0x06, 0x01, 0xa7, 0x1e, 0xc8, 0x93, 0xbb, 0x3e, 0x9f, 0x01, 0x8a, 0x71,
+	0x34, 0xfe, 0xc3, 0xdc, 0xe1, 0xcd, 0x33, 0x34, 0x01, 0x3e, 0xe5, 0x34, 0x3d, 0x30, 0x79, 0xb5,
+	0x3e, 0x9c, 0x5b, 0x9a, 0x53, 0x01, 0x12, 0x01, 0x10, 0x51, 0x52, 0xfe, 0xef, 0x82, 0xb9, 0x05,
+	0x9a, 0x95, 0x4c, 0xa9, 0x58, 0x8f, 0xfe, 0xb2, 0xfd, 0xc9, 0xfe, 0xf9, 0xfe, 0xce, 0x01, 0x2f,
+	0x01, 0x00, 0x01, 0x03, 0x01, 0x25, 0x0e, 0x91, 0x63, 0xa4, 0x57, 0x8d, 0x36, 0xfc, 0x7f, 0xfe,
+	0x62, 0x01, 0x97, 0x01, 0x98, 0xd3, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcd,
+	0x05, 0xf7, 0x00, 0x10, 0x00, 0x24, 0x00, 0xb9, 0xb5, 0x03, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x28, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x00, 0x06, 0x08, 0x6a,
+	0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a,
+	0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x07, 0x01, 0x05, 0x00, 0x09, 0x08, 0x05, 0x09, 0x69, 0x00, 0x06,
+	0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x1d, 0x11, 0x11, 0x00, 0x00, 0x11, 0x24, 0x11, 0x24, 0x23, 0x21, 0x1e, 0x1c, 0x1b,
+	0x1a, 0x19, 0x17, 0x14, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0d, 0x09, 0x1a,
+	0x2b, 0x33, 0x13, 0x33, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22,
+	0x07, 0x03, 0x13, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22,
+	0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0xa5, 0xd9, 0xc5, 0x29, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b,
+	0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0x7c, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e,
+	0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x04, 0x3e, 0xcc, 0xe4,
+	0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x05, 0x0d, 0xea, 0x26, 0x25,
+	0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x03, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x6a, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x24, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00,
+	0x17, 0x16, 0x15, 0x14, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x08, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00,
+	0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x23, 0x01, 0x33, 0x02, 0x38, 0xdb, 0xc4,
+	0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53,
+	0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x02, 0x8a, 0x94, 0xfe, 0xff, 0xe4, 0x19, 0x01, 0x34, 0x01,
+	0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9,
+	0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x88, 0x01, 0x41, 0x00, 0x00, 0x00, 0x03, 0x00, 0x99,
+	0xff, 0xe7, 0x04, 0xc8, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x70, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x04, 0x05,
+	0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x1b, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f,
+	0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22,
+	0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20,
+	0x03, 0x02, 0x01, 0x01, 0x33, 0x01, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf,
+	0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54,
+	0x01, 0x62, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30,
+	0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe,
+	0x5c, 0x04, 0x88, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x7b, 0xb5, 0x19, 0x01, 0x05, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x09, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05,
+	0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x1b, 0x14,
+	0x1b, 0x18, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x13, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23,
+	0x07, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0,
+	0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x98, 0x01, 0x31, 0xda, 0xb1,
+	0x94, 0xa1, 0x02, 0xf1, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe,
+	0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x88,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8,
+	0x05, 0xf7, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x27, 0x00, 0x87, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x2c, 0x00, 0x05, 0x0c, 0x09, 0x02, 0x07, 0x01, 0x05, 0x07, 0x6a, 0x00, 0x08, 0x08, 0x04, 0x61,
+	0x06, 0x01, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2a,
+	0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0c, 0x09, 0x02, 0x07, 0x01,
+	0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x14, 0x14, 0x0d,
+	0x0c, 0x01, 0x00, 0x14, 0x27, 0x14, 0x27, 0x26, 0x24, 0x21, 0x1f, 0x1e, 0x1d, 0x1c, 0x1a, 0x17,
+	0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0d, 0x09, 0x16,
+	0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13,
+	0x12, 0x21, 0x20, 0x03, 0x02, 0x13, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33,
+	0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01,
+	0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe,
+	0xf2, 0x54, 0x54, 0x9f, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae,
+	0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30,
+	0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe,
+	0x5c, 0x04, 0x92, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8, 0x05, 0xba, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17,
+	0x00, 0x1b, 0x00, 0x79, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05,
+	0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x18, 0x18, 0x14, 0x14, 0x0d, 0x0c, 0x01,
+	0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03,
+	0x02, 0x13, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01,
+	0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe,
+	0xf2, 0x54, 0x54, 0xd0, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0x19, 0x01, 0x34, 0x01, 0x04,
+	0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01,
+	0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x92, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x03, 0x00, 0xda,
+	0x00, 0x00, 0x04, 0xd3, 0x04, 0xa0, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x64, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00,
+	0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05,
+	0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05,
+	0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08,
+	0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09,
+	0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0x01, 0x37, 0x33, 0x07, 0x01, 0x37, 0x33, 0x07, 0xda, 0x1e,
+	0x03, 0xdb, 0x1e, 0xfd, 0xeb, 0x31, 0xf7, 0x31, 0xfe, 0x4e, 0x31, 0xf7, 0x31, 0x02, 0x06, 0x94,
+	0x94, 0x01, 0xa4, 0xf6, 0xf6, 0xfc, 0x56, 0xf7, 0xf7, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x95,
+	0xff, 0xe7, 0x05, 0x3d, 0x04, 0x56, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x30, 0x40, 0x2d,
+	0x0a, 0x01, 0x05, 0x01, 0x23, 0x1b, 0x0d, 0x03, 0x04, 0x04, 0x05, 0x02, 0x4c, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x26, 0x23, 0x25, 0x12, 0x25, 0x11, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x07,
+	0x23, 0x37, 0x26, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x07, 0x02, 0x00,
+	0x23, 0x22, 0x37, 0x17, 0x16, 0x33, 0x20, 0x13, 0x36, 0x2f, 0x02, 0x26, 0x23, 0x20, 0x03, 0x06,
+	0x17, 0x01, 0x72, 0x4d, 0x90, 0x9a, 0x59, 0x30, 0x35, 0x01, 0x40, 0xdf, 0xaa, 0x5c, 0x4d, 0x90,
+	0x9a, 0x59, 0x30, 0x35, 0xfe, 0xc1, 0xe0, 0xa7, 0x24, 0x01, 0x34, 0x6b, 0x01, 0x14, 0x55, 0x19,
+	0x10, 0x2a, 0x01, 0x3d, 0x62, 0xfe, 0xec, 0x52, 0x1e, 0x11, 0x3b, 0x54, 0xa7, 0x9f, 0xf1, 0x01,
+	0x0a, 0x01, 0x2e, 0x53, 0x53, 0xa7, 0x9f, 0xf0, 0xfe, 0xf8, 0xfe, 0xcf, 0xe2, 0x02, 0x4c, 0x01,
+	0xa8, 0x7e, 0x66, 0x6e, 0x02, 0x4b, 0xfe, 0x65, 0x96, 0x5b, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0xff, 0xe7, 0x04, 0xbc, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xbe, 0xb5, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05,
+	0x01, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x24, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06,
+	0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x21, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x14, 0x13, 0x12, 0x11, 0x00, 0x10,
+	0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x08, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13,
+	0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x03, 0x23, 0x01, 0x33, 0x03,
+	0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d,
+	0xc5, 0xd9, 0x05, 0x94, 0xfe, 0xff, 0xe4, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83,
+	0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0xff, 0xe7, 0x04, 0xbf, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xc6, 0xb5, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05,
+	0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50,
+	0x58, 0x40, 0x25, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22,
+	0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00,
+	0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x09, 0x09,
+	0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37,
+	0x13, 0x33, 0x03, 0x01, 0x01, 0x33, 0x01, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c,
+	0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0xc7, 0x01, 0x31, 0xe4, 0xfe,
+	0x7f, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2,
+	0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd1, 0x40, 0x0a, 0x16, 0x01, 0x06, 0x05, 0x01, 0x01,
+	0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x22, 0x09, 0x07, 0x02, 0x06, 0x05,
+	0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x26, 0x09, 0x07, 0x02, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00,
+	0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x01, 0x06, 0x85, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07,
+	0x02, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x3c,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40,
+	0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00,
+	0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13,
+	0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d,
+	0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfd, 0xfd, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0xcb,
+	0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc,
+	0x05, 0xba, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0xa4, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01,
+	0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x21, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f,
+	0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x25, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03,
+	0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1d,
+	0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14,
+	0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x37,
+	0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x01,
+	0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c,
+	0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0x35, 0x22, 0xad, 0x22, 0xde,
+	0x22, 0xad, 0x22, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0,
+	0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x02, 0x00, 0xa5, 0xfe, 0x75, 0x04, 0xd8,
+	0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x53, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00,
+	0x03, 0x03, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01,
+	0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x08, 0x08, 0x08,
+	0x0b, 0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01,
+	0x33, 0x01, 0x23, 0x01, 0x01, 0x33, 0x01, 0x01, 0x9f, 0xa8, 0xc8, 0x80, 0x01, 0xeb, 0xae, 0xfc,
+	0x9a, 0xcd, 0x01, 0xd4, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa,
+	0x37, 0x06, 0x8e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x56, 0xfe, 0x75, 0x04, 0xca,
+	0x06, 0x2b, 0x00, 0x0e, 0x00, 0x18, 0x00, 0x36, 0x40, 0x33, 0x10, 0x04, 0x02, 0x04, 0x05, 0x0e,
+	0x01, 0x03, 0x04, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00,
+	0x00, 0x3d, 0x00, 0x4e, 0x23, 0x24, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x23,
+	0x01, 0x33, 0x03, 0x36, 0x33, 0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x13, 0x03, 0x16,
+	0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x22, 0x01, 0x1b, 0xc5, 0x01, 0x8a, 0xc5, 0x8b, 0xa3, 0xce,
+	0xaa, 0x95, 0x31, 0x39, 0xfe, 0xb7, 0xf5, 0x5f, 0x59, 0x8c, 0x6f, 0x83, 0x45, 0x01, 0x1b, 0x57,
+	0x23, 0x46, 0x60, 0x81, 0xfe, 0x75, 0x07, 0xb6, 0xfd, 0x47, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1,
+	0xfe, 0xc8, 0x19, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1, 0xcd, 0x00, 0x03, 0x00, 0xa5,
+	0xfe, 0x75, 0x04, 0xd8, 0x05, 0xba, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x5c, 0xb5, 0x03,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x07, 0x03,
+	0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x38, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03,
+	0x04, 0x00, 0x03, 0x04, 0x67, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02,
+	0x4e, 0x59, 0x40, 0x15, 0x0c, 0x0c, 0x08, 0x08, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b,
+	0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01, 0x33,
+	0x01, 0x23, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x9f, 0xa8, 0xc8, 0x80, 0x01,
+	0xeb, 0xae, 0xfc, 0x9a, 0xcd, 0x01, 0x51, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0x04, 0x3e,
+	0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37, 0x06, 0x98, 0xad, 0xad, 0xad, 0xad, 0x00, 0x03, 0x00, 0x20,
+	0x00, 0x00, 0x05, 0x59, 0x07, 0x00, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6a, 0xb5, 0x0a,
+	0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x05, 0x08, 0x01,
+	0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x00, 0x06,
+	0x04, 0x06, 0x00, 0x04, 0x80, 0x00, 0x05, 0x08, 0x01, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03,
+	0x01, 0x21, 0x03, 0x03, 0x37, 0x21, 0x07, 0x20, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49, 0xfd,
+	0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xde, 0x1e, 0x02, 0x82, 0x1e, 0x05, 0xc8, 0xfa, 0x38,
+	0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0xbc, 0x94, 0x94, 0x00, 0x03, 0x00, 0x96,
+	0xff, 0xe7, 0x04, 0xbc, 0x05, 0xab, 0x00, 0x03, 0x00, 0x0d, 0x00, 0x1b, 0x01, 0x08, 0xb5, 0x05,
+	0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x07, 0x01, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x26, 0x08, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x39,
+	0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x22,
+	0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x06, 0x06, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07,
+	0x07, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x00, 0x08, 0x01, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00,
+	0x06, 0x06, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07,
+	0x07, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x1b, 0x40,
+	0x28, 0x00, 0x00, 0x08, 0x01, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x06, 0x06, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x1b, 0x1a, 0x19, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x0d, 0x0b, 0x08, 0x06, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x01, 0x37, 0x21, 0x07, 0x01, 0x13, 0x26, 0x23, 0x20, 0x03,
+	0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33,
+	0x03, 0x23, 0x02, 0x05, 0x1d, 0x02, 0x82, 0x1d, 0xfe, 0xe3, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57,
+	0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a,
+	0xc5, 0xd9, 0xc5, 0x05, 0x17, 0x94, 0x94, 0xfc, 0x67, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd,
+	0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2, 0x00, 0x03, 0x00, 0x20,
+	0x00, 0x00, 0x05, 0x87, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x16, 0x00, 0x74, 0xb5, 0x0a,
+	0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x06,
+	0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06, 0x08, 0x69, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x26, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x00, 0x08, 0x04, 0x08, 0x00, 0x04, 0x80,
+	0x00, 0x06, 0x00, 0x08, 0x00, 0x06, 0x08, 0x69, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68,
+	0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x15, 0x13, 0x11,
+	0x10, 0x0f, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09,
+	0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x03, 0x33, 0x06,
+	0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x20, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2,
+	0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xaa, 0x7b, 0x01, 0xb1, 0xb2, 0x42, 0x7b,
+	0x2c, 0xd9, 0x88, 0x88, 0x92, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02,
+	0x7a, 0x02, 0xdf, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xd3,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x15, 0x00, 0x23, 0x01, 0x17, 0xb5, 0x0d, 0x01, 0x05, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x27, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x08, 0x01, 0x07,
+	0x07, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x09, 0x01, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2b, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x08, 0x01, 0x07, 0x07,
+	0x41, 0x4d, 0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42,
+	0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x01, 0x00, 0x03, 0x07, 0x01,
+	0x03, 0x69, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x06,
+	0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2d, 0x02,
+	0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x03, 0x07, 0x01, 0x03, 0x69, 0x00, 0x08, 0x08,
+	0x3b, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x39,
+	0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x40, 0x2d, 0x02,
+	0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x03, 0x07, 0x01, 0x03, 0x69, 0x00, 0x08, 0x08,
+	0x3b, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x3c,
+	0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x59,
+	0x40, 0x0e, 0x23, 0x22, 0x11, 0x24, 0x22, 0x23, 0x24, 0x22, 0x11, 0x21, 0x10, 0x0a, 0x09, 0x1f,
+	0x2b, 0x01, 0x33, 0x14, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x01, 0x13, 0x26,
+	0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33,
+	0x32, 0x17, 0x33, 0x03, 0x23, 0x02, 0x39, 0x7b, 0xb1, 0xb2, 0x41, 0x7b, 0x2b, 0xd8, 0x88, 0x88,
+	0x93, 0x01, 0x3d, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce,
+	0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x06, 0x44, 0xad, 0xad,
+	0x92, 0xaf, 0xae, 0xfb, 0xcd, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27,
+	0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2, 0x00, 0x02, 0x00, 0x1e, 0xfe, 0x8e, 0x05, 0x49,
+	0x05, 0xc8, 0x00, 0x14, 0x00, 0x17, 0x00, 0x8f, 0x40, 0x0f, 0x17, 0x01, 0x06, 0x00, 0x0a, 0x01,
+	0x02, 0x01, 0x02, 0x4c, 0x11, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f,
+	0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x05, 0x02,
+	0x01, 0x01, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3d, 0x03, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00,
+	0x02, 0x00, 0x03, 0x02, 0x03, 0x65, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x05, 0x02, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x00, 0x04, 0x01,
+	0x06, 0x04, 0x68, 0x00, 0x02, 0x00, 0x03, 0x02, 0x03, 0x65, 0x07, 0x05, 0x02, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x16, 0x15, 0x00, 0x14, 0x00, 0x14, 0x14, 0x23,
+	0x23, 0x11, 0x11, 0x08, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x06, 0x07, 0x06, 0x33,
+	0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x1e,
+	0x03, 0x59, 0xd0, 0x01, 0x02, 0x77, 0x90, 0x14, 0x13, 0x72, 0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc,
+	0x20, 0x19, 0xaf, 0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0x05, 0xc8, 0xfa, 0x38,
+	0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02,
+	0x7a, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x96, 0xfe, 0x8e, 0x04, 0xbc, 0x04, 0x56, 0x00, 0x1a,
+	0x00, 0x24, 0x00, 0xfb, 0x40, 0x0a, 0x1c, 0x01, 0x07, 0x06, 0x12, 0x01, 0x03, 0x00, 0x02, 0x4c,
+	0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x06, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25,
+	0x00, 0x06, 0x06, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x29, 0x00, 0x02, 0x02,
+	0x3b, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39,
+	0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x03,
+	0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x02,
+	0x02, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x3c, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x59, 0x40, 0x0b, 0x23, 0x23, 0x13, 0x23, 0x24, 0x11, 0x24, 0x21, 0x08, 0x09, 0x1e, 0x2b, 0x25,
+	0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x06, 0x07, 0x06, 0x33,
+	0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x23, 0x13, 0x13, 0x26, 0x23, 0x20, 0x03,
+	0x06, 0x16, 0x33, 0x32, 0x03, 0x46, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f,
+	0x5a, 0xc5, 0xd9, 0x8f, 0x14, 0x13, 0x72, 0x38, 0x26, 0x10, 0x41, 0x4e, 0xcc, 0x1f, 0x18, 0xaf,
+	0x5a, 0x4c, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xcb, 0xe4, 0x01, 0x27,
+	0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d,
+	0x55, 0x01, 0x7e, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc6,
+	0xff, 0xdb, 0x06, 0x73, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x67, 0x40, 0x0b, 0x0a, 0x01,
+	0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x40, 0x0e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x13, 0x24, 0x23, 0x24, 0x21, 0x07,
+	0x09, 0x1b, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24,
+	0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x25, 0x01, 0x01, 0x33, 0x01, 0x05, 0x62, 0xf2,
+	0xfe, 0xf2, 0xfe, 0x92, 0xfe, 0xd2, 0x4c, 0x4c, 0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe,
+	0xe3, 0xb4, 0xff, 0xfe, 0xb5, 0x3d, 0x3a, 0xde, 0x01, 0x05, 0xdf, 0x01, 0x0b, 0xfe, 0x86, 0x01,
+	0x31, 0xe4, 0xfe, 0x7f, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5,
+	0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x05, 0x4e, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x04, 0xeb, 0x06, 0x44, 0x00, 0x14, 0x00, 0x18, 0x00, 0x6c,
+	0x40, 0x0b, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06,
+	0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x15, 0x15, 0x15,
+	0x18, 0x15, 0x18, 0x13, 0x23, 0x23, 0x24, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x06, 0x23, 0x22,
+	0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32,
+	0x37, 0x03, 0x01, 0x33, 0x01, 0x03, 0xaf, 0xb7, 0xb0, 0xda, 0xd4, 0x33, 0x35, 0x01, 0x53, 0xf8,
+	0x84, 0xa2, 0x21, 0x96, 0x64, 0xfe, 0xa6, 0x54, 0x26, 0x86, 0xa0, 0x7c, 0xab, 0xfb, 0x01, 0x31,
+	0xe4, 0xfe, 0x7f, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe,
+	0x5d, 0xc1, 0xd5, 0x45, 0x04, 0x38, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc6,
+	0xff, 0xdb, 0x06, 0x73, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x6e, 0x40, 0x0f, 0x1b, 0x01,
+	0x05, 0x04, 0x0a, 0x01, 0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02,
+	0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d,
+	0x11, 0x13, 0x24, 0x23, 0x24, 0x21, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x13,
+	0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x25,
+	0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x05, 0x62, 0xf2, 0xfe, 0xf2, 0xfe, 0x92, 0xfe,
+	0xd2, 0x4c, 0x4c, 0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe, 0xe3, 0xb4, 0xff, 0xfe, 0xb5,
+	0x3d, 0x3a, 0xde, 0x01, 0x05, 0xdf, 0x01, 0x0b, 0xfd, 0xbc, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe,
+	0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x05, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x04, 0xc8, 0x06, 0x44, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x73,
+	0x40, 0x0f, 0x1a, 0x01, 0x05, 0x04, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x03,
+	0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05,
+	0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x0f, 0x15, 0x15, 0x15, 0x1c, 0x15, 0x1c, 0x11, 0x13, 0x23, 0x23, 0x24, 0x21, 0x08,
+	0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26,
+	0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07,
+	0x03, 0xaf, 0xb7, 0xb0, 0xda, 0xd4, 0x33, 0x35, 0x01, 0x53, 0xf8, 0x84, 0xa2, 0x21, 0x96, 0x64,
+	0xfe, 0xa6, 0x54, 0x26, 0x86, 0xa0, 0x7c, 0xab, 0xfe, 0x3b, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5d,
+	0xc1, 0xd5, 0x45, 0x04, 0x38, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0xc6,
+	0xff, 0xdb, 0x06, 0x73, 0x07, 0x31, 0x00, 0x15, 0x00, 0x19, 0x00, 0x63, 0x40, 0x0b, 0x0a, 0x01,
+	0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x1c, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01,
+	0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e,
+	0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x13, 0x24, 0x23, 0x24, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0x25,
+	0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03,
+	0x02, 0x12, 0x21, 0x32, 0x25, 0x01, 0x37, 0x33, 0x07, 0x05, 0x62, 0xf2, 0xfe, 0xf2, 0xfe, 0x92,
+	0xfe, 0xd2, 0x4c, 0x4c, 0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe, 0xe3, 0xb4, 0xff, 0xfe,
+	0xb5, 0x3d, 0x3a, 0xde, 0x01, 0x05, 0xdf, 0x01, 0x0b, 0xfe, 0xb7, 0x27, 0xc5, 0x27, 0x4c, 0x71,
+	0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe,
+	0xdd, 0xfe, 0xc1, 0x81, 0x05, 0x6c, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x04, 0x73,
+	0x05, 0xdc, 0x00, 0x14, 0x00, 0x18, 0x00, 0x67, 0x40, 0x0b, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b,
+	0x02, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x05, 0x05,
+	0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00,
+	0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e,
+	0x15, 0x15, 0x15, 0x18, 0x15, 0x18, 0x13, 0x23, 0x23, 0x24, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0x25,
+	0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x03, 0x37, 0x33, 0x07, 0x03, 0xaf, 0xb7, 0xb0, 0xda, 0xd4, 0x33, 0x35,
+	0x01, 0x53, 0xf8, 0x84, 0xa2, 0x21, 0x96, 0x64, 0xfe, 0xa6, 0x54, 0x26, 0x86, 0xa0, 0x7c, 0xab,
+	0xde, 0x27, 0xc5, 0x27, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31,
+	0xfe, 0x5d, 0xc1, 0xd5, 0x45, 0x04, 0x4c, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc6,
+	0xff, 0xdb, 0x06, 0x73, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x6e, 0x40, 0x0f, 0x1b, 0x01,
+	0x04, 0x05, 0x0a, 0x01, 0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d,
+	0x11, 0x13, 0x24, 0x23, 0x24, 0x21, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x13,
+	0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x25,
+	0x13, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x05, 0x62, 0xf2, 0xfe, 0xf2, 0xfe, 0x92, 0xfe,
+	0xd2, 0x4c, 0x4c, 0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe, 0xe3, 0xb4, 0xff, 0xfe, 0xb5,
+	0x3d, 0x3a, 0xde, 0x01, 0x05, 0xdf, 0x01, 0x0b, 0xae, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02,
+	0xf1, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1,
+	0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x06, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00,
+	0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x04, 0xfe, 0x06, 0x44, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x73,
+	0x40, 0x0f, 0x1a, 0x01, 0x04, 0x05, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x03,
+	0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80,
+	0x07, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x07,
+	0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x0f, 0x15, 0x15, 0x15, 0x1c, 0x15, 0x1c, 0x11, 0x13, 0x23, 0x23, 0x24, 0x21, 0x08,
+	0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26,
+	0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x03, 0xaf, 0xb7, 0xb0, 0xda, 0xd4, 0x33, 0x35, 0x01, 0x53, 0xf8, 0x84, 0xa2, 0x21, 0x96, 0x64,
+	0xfe, 0xa6, 0x54, 0x26, 0x86, 0xa0, 0x7c, 0xab, 0x01, 0x2d, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5d,
+	0xc1, 0xd5, 0x45, 0x05, 0x79, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0xb0,
+	0x00, 0x00, 0x06, 0x9c, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x12, 0x00, 0x1a, 0x00, 0x6f, 0xb5, 0x18,
+	0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x07, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x20, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x00,
+	0x03, 0x02, 0x00, 0x03, 0x68, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x07, 0x01, 0x01, 0x01, 0x3c, 0x01,
+	0x4e, 0x59, 0x40, 0x18, 0x13, 0x13, 0x00, 0x00, 0x13, 0x1a, 0x13, 0x1a, 0x17, 0x16, 0x15, 0x14,
+	0x12, 0x10, 0x0a, 0x08, 0x00, 0x07, 0x00, 0x06, 0x21, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x01, 0x21,
+	0x20, 0x03, 0x02, 0x00, 0x21, 0x27, 0x33, 0x20, 0x00, 0x13, 0x12, 0x27, 0x26, 0x26, 0x23, 0x23,
+	0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xb0, 0x01, 0x27, 0x01, 0xda, 0x02, 0xeb, 0x8d,
+	0x49, 0xfe, 0x2a, 0xfe, 0x9d, 0xec, 0xfc, 0x01, 0x0e, 0x01, 0x43, 0x3c, 0x35, 0x61, 0x3b, 0xc8,
+	0xd6, 0x9b, 0x02, 0xff, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfd, 0x3f,
+	0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27, 0x01, 0x2f, 0x01, 0x05, 0x95, 0x5b, 0x43, 0x02, 0x64,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x06, 0x7a,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x18, 0x00, 0x22, 0x00, 0x99, 0x40, 0x0a, 0x14, 0x01, 0x00, 0x03,
+	0x01, 0x01, 0x01, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x06,
+	0x04, 0x5f, 0x07, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x06, 0x04, 0x5f, 0x07, 0x01, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06,
+	0x06, 0x04, 0x5f, 0x07, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x42, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x11, 0x15, 0x11, 0x12, 0x24, 0x22, 0x23, 0x22, 0x08,
+	0x09, 0x1e, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23,
+	0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x13, 0x33, 0x01, 0x23, 0x01, 0x37, 0x36, 0x37,
+	0x37, 0x23, 0x37, 0x33, 0x07, 0x02, 0x03, 0x6a, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46,
+	0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0x62, 0xc5,
+	0xfe, 0xc5, 0xc5, 0x02, 0x3b, 0x0c, 0x51, 0x20, 0x04, 0x4d, 0x27, 0xc6, 0x22, 0x35, 0x01, 0x7e,
+	0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38,
+	0x18, 0x01, 0xed, 0xf9, 0xd5, 0x04, 0x65, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00,
+	0x00, 0x02, 0x00, 0xa1, 0x00, 0x00, 0x06, 0xa6, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x1a, 0x00, 0x60,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00,
+	0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f,
+	0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x01, 0x02,
+	0x05, 0x67, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x03,
+	0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x1a, 0x19, 0x18,
+	0x17, 0x16, 0x14, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b,
+	0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x21, 0x20, 0x03, 0x02, 0x00, 0x21, 0x27, 0x33, 0x20, 0x00,
+	0x13, 0x12, 0x27, 0x26, 0x26, 0x23, 0x23, 0x03, 0x21, 0x07, 0x21, 0xba, 0x87, 0xa0, 0x20, 0xa0,
+	0x80, 0x01, 0xda, 0x02, 0xeb, 0x8d, 0x49, 0xfe, 0x2a, 0xfe, 0x9d, 0xec, 0xfc, 0x01, 0x0e, 0x01,
+	0x43, 0x3c, 0x35, 0x61, 0x3b, 0xc8, 0xd6, 0x9b, 0x61, 0x01, 0x4d, 0x20, 0xfe, 0xb3, 0x02, 0xa7,
+	0x9d, 0x02, 0x84, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27, 0x01, 0x2f, 0x01, 0x05,
+	0x95, 0x5b, 0x43, 0xfe, 0x19, 0x9d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x96, 0xff, 0xe7, 0x05, 0x8b,
+	0x06, 0x2b, 0x00, 0x16, 0x00, 0x20, 0x00, 0xa9, 0x40, 0x0b, 0x16, 0x01, 0x08, 0x07, 0x18, 0x0c,
+	0x02, 0x09, 0x08, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x25, 0x03, 0x01, 0x01, 0x04,
+	0x01, 0x00, 0x07, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61,
+	0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x07,
+	0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07,
+	0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42,
+	0x06, 0x4e, 0x1b, 0x40, 0x29, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x07, 0x01, 0x00, 0x68, 0x00,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x05,
+	0x05, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x59, 0x59,
+	0x40, 0x0e, 0x20, 0x1e, 0x24, 0x24, 0x22, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x0a, 0x09, 0x1f,
+	0x2b, 0x01, 0x21, 0x37, 0x21, 0x37, 0x33, 0x07, 0x33, 0x07, 0x23, 0x03, 0x23, 0x37, 0x06, 0x23,
+	0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x03, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16,
+	0x33, 0x32, 0x04, 0x19, 0xfe, 0xbf, 0x19, 0x01, 0x41, 0x27, 0xc5, 0x27, 0x94, 0x19, 0x94, 0xfb,
+	0xc5, 0x28, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0x8d, 0x6f, 0x83,
+	0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0x04, 0xea, 0x7c, 0xc5, 0xc5, 0x7c, 0xfb, 0x16,
+	0xcb, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfd, 0x40, 0x02, 0x2b, 0x19, 0xfe,
+	0x4f, 0xb0, 0xcd, 0x00, 0x00, 0x02, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x07, 0x00, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x70, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x37, 0x21, 0x07, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f,
+	0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0xfd, 0xb9, 0x1e, 0x02, 0x82, 0x1e, 0x05, 0xc8, 0x9d, 0xfe,
+	0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x6c, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x9b,
+	0xff, 0xe7, 0x04, 0xe3, 0x05, 0xab, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x78, 0x4b, 0xb0,
+	0x22, 0x50, 0x58, 0x40, 0x29, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x09, 0x01,
+	0x07, 0x07, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40,
+	0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x03, 0x06, 0x07, 0x67, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05,
+	0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x16,
+	0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00,
+	0x04, 0x21, 0x0a, 0x09, 0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22,
+	0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x01, 0x37, 0x21, 0x07,
+	0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01,
+	0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0xfe, 0x90, 0x1d, 0x02,
+	0x82, 0x1d, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09,
+	0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x9a, 0x94, 0x94, 0x00, 0x02, 0x00, 0xc9,
+	0x00, 0x00, 0x06, 0x21, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x7a, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09,
+	0x69, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b,
+	0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09, 0x69,
+	0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x16, 0x14, 0x12, 0x11, 0x10, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03,
+	0x21, 0x07, 0x01, 0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xc9, 0x01,
+	0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f,
+	0xfd, 0xf8, 0x7b, 0x01, 0xb1, 0xb2, 0x42, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0x05, 0xc8, 0x9d,
+	0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x07, 0x8f, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3, 0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x21,
+	0x00, 0xb7, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2e, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01,
+	0x04, 0x67, 0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x09, 0x09, 0x07, 0x61, 0x00, 0x07, 0x07,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2c, 0x00,
+	0x07, 0x00, 0x09, 0x03, 0x07, 0x09, 0x69, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67,
+	0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2c, 0x08, 0x01,
+	0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x03, 0x07, 0x09, 0x69, 0x0a, 0x01, 0x01, 0x00,
+	0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x00, 0x00,
+	0x20, 0x1e, 0x1c, 0x1b, 0x1a, 0x18, 0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07,
+	0x00, 0x04, 0x00, 0x04, 0x21, 0x0b, 0x09, 0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07,
+	0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x01,
+	0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0xc0, 0x3d, 0xf5, 0xfd,
+	0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb, 0x6b,
+	0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0xfe, 0xc4, 0x7b, 0x02, 0xb1, 0xb2, 0x43, 0x7b, 0x2c,
+	0xd9, 0x88, 0x88, 0x92, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c,
+	0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x05, 0xc7, 0xad, 0xad, 0x92, 0xaf,
+	0xae, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x07, 0x31, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x70, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x37, 0x33, 0x07, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f,
+	0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0xfe, 0x9f, 0x27, 0xc5, 0x27, 0x05, 0xc8, 0x9d, 0xfe, 0x25,
+	0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x6c, 0xc5, 0xc5, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3,
+	0x05, 0xdc, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x78, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x29, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x09, 0x01, 0x07, 0x07, 0x06, 0x5f,
+	0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x06, 0x09,
+	0x01, 0x07, 0x03, 0x06, 0x07, 0x67, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x16, 0x19, 0x16, 0x19, 0x18,
+	0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0a, 0x09,
+	0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00,
+	0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x03, 0x37, 0x33, 0x07, 0x03, 0xc0, 0x3d, 0xf5,
+	0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb,
+	0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0x88, 0x27, 0xc5, 0x27, 0x02, 0x94, 0x01, 0x2f,
+	0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d,
+	0xfe, 0x7d, 0x04, 0x9a, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc9, 0xfe, 0x8e, 0x06, 0x21,
+	0x05, 0xc8, 0x00, 0x19, 0x00, 0xa2, 0xb5, 0x12, 0x01, 0x06, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x29, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x00,
+	0x07, 0x06, 0x07, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06,
+	0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x23, 0x23, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x21, 0x03, 0x21, 0x07, 0x23, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37,
+	0x36, 0x37, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04,
+	0x6b, 0x03, 0x8b, 0x1f, 0x7e, 0x90, 0x14, 0x13, 0x72, 0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc, 0x20,
+	0x19, 0xaf, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x4d, 0x66, 0x60, 0x0f, 0x51,
+	0x1d, 0xa0, 0x7d, 0x55, 0x00, 0x02, 0x00, 0x9b, 0xfe, 0x8e, 0x04, 0xe3, 0x04, 0x56, 0x00, 0x1e,
+	0x00, 0x23, 0x00, 0x72, 0xb5, 0x09, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x28, 0x08, 0x01, 0x07, 0x00, 0x04, 0x05, 0x07, 0x04, 0x67, 0x00, 0x06, 0x06, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x40, 0x25, 0x08, 0x01, 0x07,
+	0x00, 0x04, 0x05, 0x07, 0x04, 0x67, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x65, 0x00, 0x06, 0x06,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42,
+	0x02, 0x4e, 0x59, 0x40, 0x10, 0x1f, 0x1f, 0x1f, 0x23, 0x1f, 0x23, 0x22, 0x21, 0x12, 0x24, 0x23,
+	0x23, 0x26, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x07, 0x06, 0x07, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37,
+	0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03,
+	0x07, 0x21, 0x02, 0x21, 0x32, 0x13, 0x12, 0x23, 0x22, 0x03, 0x04, 0x26, 0x20, 0x86, 0x7e, 0x72,
+	0x12, 0x13, 0x72, 0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc, 0x20, 0x15, 0x84, 0x06, 0xfb, 0xec, 0x35,
+	0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0x57, 0x3d,
+	0xf5, 0xfd, 0x55, 0xbf, 0x9c, 0x27, 0x0d, 0x47, 0x5b, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x6c, 0x4d,
+	0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x02, 0x17, 0x01, 0x2f,
+	0xfe, 0xd1, 0x00, 0x00, 0x00, 0x02, 0x00, 0xca, 0x00, 0x00, 0x06, 0x22, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x7f, 0xb5, 0x11, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x2a, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x0a,
+	0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13,
+	0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x13,
+	0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xca, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f,
+	0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0x94, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x07, 0x8f, 0xfe, 0xbf, 0x01,
+	0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3, 0x06, 0x44, 0x00, 0x04,
+	0x00, 0x15, 0x00, 0x1d, 0x00, 0x88, 0xb5, 0x1b, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x2d, 0x00, 0x06, 0x07, 0x03, 0x07, 0x06, 0x03, 0x80, 0x09, 0x01, 0x01, 0x00,
+	0x04, 0x05, 0x01, 0x04, 0x67, 0x0a, 0x08, 0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x1b, 0x40, 0x2a, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85,
+	0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40,
+	0x1c, 0x16, 0x16, 0x00, 0x00, 0x16, 0x1d, 0x16, 0x1d, 0x1a, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12,
+	0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0b, 0x09, 0x17, 0x2b, 0x01, 0x12,
+	0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03, 0x07,
+	0x21, 0x02, 0x21, 0x32, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0xc0, 0x3d, 0xf5,
+	0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01, 0xbb,
+	0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0x01, 0x6f, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09,
+	0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x05, 0xc7, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0x60, 0xff, 0xdb, 0x06, 0xa7, 0x07, 0x8f, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x88,
+	0x40, 0x0e, 0x1d, 0x01, 0x07, 0x06, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x05, 0x02, 0x03, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07,
+	0x01, 0x07, 0x85, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00,
+	0x4e, 0x1b, 0x40, 0x28, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x6a, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04,
+	0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x18, 0x18,
+	0x18, 0x00, 0x00, 0x18, 0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x00, 0x17, 0x00, 0x17, 0x12,
+	0x23, 0x23, 0x23, 0x22, 0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x03, 0x04, 0x21, 0x20, 0x13, 0x12, 0x00,
+	0x21, 0x20, 0x05, 0x07, 0x24, 0x23, 0x20, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37,
+	0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x06, 0x11, 0x82, 0xfe, 0xe9, 0xfe, 0xef, 0xfc,
+	0xf9, 0x9b, 0x4b, 0x01, 0xe3, 0x01, 0x75, 0x01, 0x08, 0x01, 0x01, 0x27, 0xfe, 0xdb, 0xdd, 0xfd,
+	0xda, 0x7c, 0x3c, 0xef, 0x01, 0x1b, 0x74, 0xb8, 0x4b, 0xf7, 0x1f, 0xfe, 0xfd, 0x01, 0x31, 0xda,
+	0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78, 0x01, 0x94,
+	0x43, 0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0x03, 0x9e, 0x01,
+	0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x26, 0xfe, 0x5c, 0x04, 0xc3,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x22, 0x00, 0x2a, 0x00, 0xcd, 0x40, 0x12, 0x28, 0x01, 0x08, 0x07,
+	0x01, 0x01, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02, 0x1d, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x2f, 0x0a, 0x09, 0x02, 0x08, 0x07, 0x03, 0x07, 0x08, 0x03, 0x80, 0x00,
+	0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x33, 0x0a, 0x09, 0x02, 0x08,
+	0x07, 0x03, 0x07, 0x08, 0x03, 0x80, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b,
+	0x40, 0x2e, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a, 0x09, 0x02, 0x08, 0x03, 0x08, 0x85, 0x00, 0x01,
+	0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x59, 0x59, 0x40, 0x12, 0x23, 0x23, 0x23, 0x2a, 0x23, 0x2a, 0x11, 0x13, 0x23, 0x25, 0x11, 0x24,
+	0x22, 0x23, 0x22, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x02, 0x06,
+	0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x20, 0x13, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x03, 0x7b, 0x65, 0x83, 0x43, 0xfe, 0xe3, 0x4e, 0x22, 0x47, 0x5f, 0x81, 0xa3, 0xa3,
+	0xcf, 0xa8, 0x96, 0x2e, 0x36, 0x01, 0x45, 0xf3, 0x61, 0x5a, 0xc5, 0x9e, 0x34, 0x65, 0x58, 0x9c,
+	0xfe, 0xf0, 0xbe, 0xa4, 0x23, 0xc0, 0x99, 0x01, 0x4c, 0x47, 0xfe, 0xcf, 0x01, 0x31, 0xda, 0xb1,
+	0x94, 0xa1, 0x02, 0xf1, 0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01,
+	0x23, 0xea, 0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab,
+	0x51, 0x01, 0x61, 0x04, 0xb1, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x60,
+	0xff, 0xdb, 0x06, 0xa7, 0x07, 0x8f, 0x00, 0x17, 0x00, 0x23, 0x00, 0x86, 0x40, 0x0a, 0x0a, 0x01,
+	0x02, 0x01, 0x0b, 0x01, 0x05, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x08,
+	0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69, 0x0a, 0x01, 0x05,
+	0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01,
+	0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69, 0x00, 0x01, 0x00, 0x02,
+	0x05, 0x01, 0x02, 0x6a, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x22, 0x20, 0x1e,
+	0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x00, 0x17, 0x00, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22, 0x0b, 0x09,
+	0x1b, 0x2b, 0x01, 0x03, 0x04, 0x21, 0x20, 0x13, 0x12, 0x00, 0x21, 0x20, 0x05, 0x07, 0x24, 0x23,
+	0x20, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37, 0x03, 0x33, 0x06, 0x33, 0x32, 0x37,
+	0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x06, 0x11, 0x82, 0xfe, 0xe9, 0xfe, 0xef, 0xfc, 0xf9, 0x9b,
+	0x4b, 0x01, 0xe3, 0x01, 0x75, 0x01, 0x08, 0x01, 0x01, 0x27, 0xfe, 0xdb, 0xdd, 0xfd, 0xda, 0x7c,
+	0x3c, 0xef, 0x01, 0x1b, 0x74, 0xb8, 0x4b, 0xf7, 0x1f, 0xb2, 0x7b, 0x01, 0xb1, 0xb2, 0x42, 0x7b,
+	0x2c, 0xd9, 0x88, 0x88, 0x92, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78, 0x01, 0x94,
+	0x43, 0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0x04, 0xdf, 0xad,
+	0xad, 0x92, 0xaf, 0xae, 0x00, 0x03, 0x00, 0x26, 0xfe, 0x5c, 0x04, 0xef, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x22, 0x00, 0x2e, 0x00, 0xc9, 0x40, 0x0e, 0x01, 0x01, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02,
+	0x1d, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x30, 0x09, 0x01, 0x07,
+	0x07, 0x3a, 0x4d, 0x00, 0x0a, 0x0a, 0x08, 0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x32, 0x00, 0x08, 0x00, 0x0a, 0x03, 0x08, 0x0a, 0x69, 0x09, 0x01, 0x07,
+	0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x30, 0x09, 0x01, 0x07, 0x08, 0x07, 0x85,
+	0x00, 0x08, 0x00, 0x0a, 0x03, 0x08, 0x0a, 0x69, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69,
+	0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x2d, 0x2b,
+	0x29, 0x28, 0x21, 0x12, 0x23, 0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x0b, 0x09, 0x1f, 0x2b, 0x01,
+	0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12,
+	0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x02, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33,
+	0x20, 0x13, 0x03, 0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x7b,
+	0x65, 0x83, 0x43, 0xfe, 0xe3, 0x4e, 0x22, 0x47, 0x5f, 0x81, 0xa3, 0xa3, 0xcf, 0xa8, 0x96, 0x2e,
+	0x36, 0x01, 0x45, 0xf3, 0x61, 0x5a, 0xc5, 0x9e, 0x34, 0x65, 0x58, 0x9c, 0xfe, 0xf0, 0xbe, 0xa4,
+	0x23, 0xc0, 0x99, 0x01, 0x4c, 0x47, 0xe0, 0x7b, 0x02, 0xb1, 0xb2, 0x43, 0x7b, 0x2c, 0xd9, 0x88,
+	0x88, 0x92, 0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea,
+	0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01,
+	0x61, 0x05, 0xf2, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x02, 0x00, 0x60, 0xff, 0xdb, 0x06, 0xa7,
+	0x07, 0x31, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x7c, 0x40, 0x0a, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01,
+	0x05, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x01, 0x06, 0x07, 0x67, 0x08, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x01,
+	0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x08, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x16, 0x18, 0x18, 0x00,
+	0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x00, 0x17, 0x00, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22,
+	0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x03, 0x04, 0x21, 0x20, 0x13, 0x12, 0x00, 0x21, 0x20, 0x05, 0x07,
+	0x24, 0x23, 0x20, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23, 0x37, 0x03, 0x37, 0x33, 0x07,
+	0x06, 0x11, 0x82, 0xfe, 0xe9, 0xfe, 0xef, 0xfc, 0xf9, 0x9b, 0x4b, 0x01, 0xe3, 0x01, 0x75, 0x01,
+	0x08, 0x01, 0x01, 0x27, 0xfe, 0xdb, 0xdd, 0xfd, 0xda, 0x7c, 0x3c, 0xef, 0x01, 0x1b, 0x74, 0xb8,
+	0x4b, 0xf7, 0x1f, 0x08, 0x27, 0xc5, 0x27, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78,
+	0x01, 0x94, 0x43, 0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0x03,
+	0xbc, 0xc5, 0xc5, 0x00, 0x00, 0x03, 0x00, 0x26, 0xfe, 0x5c, 0x04, 0xc3, 0x05, 0xdc, 0x00, 0x09,
+	0x00, 0x22, 0x00, 0x26, 0x00, 0xf3, 0x40, 0x0e, 0x01, 0x01, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02,
+	0x1d, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2b, 0x09, 0x01, 0x08,
+	0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2f,
+	0x09, 0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x09,
+	0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x07, 0x09, 0x01, 0x08, 0x03, 0x07, 0x08, 0x67,
+	0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43,
+	0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11, 0x23, 0x23, 0x23, 0x26, 0x23, 0x26, 0x13, 0x23, 0x25,
+	0x11, 0x24, 0x22, 0x23, 0x22, 0x0a, 0x09, 0x1e, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03,
+	0x02, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16, 0x33, 0x20, 0x13, 0x03, 0x37, 0x33, 0x07,
+	0x03, 0x7b, 0x65, 0x83, 0x43, 0xfe, 0xe3, 0x4e, 0x22, 0x47, 0x5f, 0x81, 0xa3, 0xa3, 0xcf, 0xa8,
+	0x96, 0x2e, 0x36, 0x01, 0x45, 0xf3, 0x61, 0x5a, 0xc5, 0x9e, 0x34, 0x65, 0x58, 0x9c, 0xfe, 0xf0,
+	0xbe, 0xa4, 0x23, 0xc0, 0x99, 0x01, 0x4c, 0x47, 0x4c, 0x27, 0xc5, 0x27, 0x01, 0xb0, 0x01, 0xf9,
+	0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc,
+	0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01, 0x61, 0x04, 0xc5, 0xc5, 0xc5, 0x00,
+	0x00, 0x02, 0x00, 0x60, 0xfe, 0x50, 0x06, 0xa7, 0x05, 0xed, 0x00, 0x17, 0x00, 0x25, 0x00, 0xc9,
+	0x40, 0x0f, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x05, 0x02, 0x1f, 0x19, 0x02, 0x06, 0x07, 0x03,
+	0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x2f, 0x00, 0x07, 0x00, 0x06, 0x06, 0x07, 0x72, 0x09,
+	0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x08,
+	0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x30, 0x00,
+	0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x80, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b,
+	0x40, 0x2e, 0x00, 0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x80, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01,
+	0x02, 0x69, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e,
+	0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x25, 0x23, 0x21, 0x20, 0x1c, 0x1a, 0x00, 0x17, 0x00, 0x17,
+	0x12, 0x23, 0x23, 0x23, 0x22, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x03, 0x04, 0x21, 0x20, 0x13, 0x12,
+	0x00, 0x21, 0x20, 0x05, 0x07, 0x24, 0x23, 0x20, 0x03, 0x02, 0x12, 0x21, 0x32, 0x37, 0x13, 0x23,
+	0x37, 0x01, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0x06,
+	0x11, 0x82, 0xfe, 0xe9, 0xfe, 0xef, 0xfc, 0xf9, 0x9b, 0x4b, 0x01, 0xe3, 0x01, 0x75, 0x01, 0x08,
+	0x01, 0x01, 0x27, 0xfe, 0xdb, 0xdd, 0xfd, 0xda, 0x7c, 0x3c, 0xef, 0x01, 0x1b, 0x74, 0xb8, 0x4b,
+	0xf7, 0x1f, 0xfe, 0x29, 0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f,
+	0xd9, 0x3e, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78, 0x01, 0x94, 0x43, 0xc2, 0x68,
+	0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0xfb, 0xab, 0x55, 0x09, 0x43, 0x4c,
+	0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x03, 0x00, 0x26, 0xfe, 0x5c, 0x04, 0xc3, 0x06, 0xc9, 0x00, 0x09,
+	0x00, 0x22, 0x00, 0x2c, 0x00, 0xea, 0x40, 0x0e, 0x01, 0x01, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02,
+	0x1d, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x08, 0x08,
+	0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2e, 0x00,
+	0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x00, 0x08, 0x08,
+	0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05,
+	0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x07, 0x00, 0x08, 0x03, 0x07, 0x08, 0x67, 0x00, 0x01, 0x00, 0x02,
+	0x06, 0x01, 0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59,
+	0x59, 0x40, 0x0c, 0x11, 0x16, 0x23, 0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x09, 0x09, 0x1f, 0x2b,
+	0x01, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37,
+	0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x02, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x37, 0x16,
+	0x33, 0x20, 0x13, 0x13, 0x07, 0x06, 0x07, 0x07, 0x33, 0x07, 0x23, 0x37, 0x12, 0x03, 0x7b, 0x65,
+	0x83, 0x43, 0xfe, 0xe3, 0x4e, 0x22, 0x47, 0x5f, 0x81, 0xa3, 0xa3, 0xcf, 0xa8, 0x96, 0x2e, 0x36,
+	0x01, 0x45, 0xf3, 0x61, 0x5a, 0xc5, 0x9e, 0x34, 0x65, 0x58, 0x9c, 0xfe, 0xf0, 0xbe, 0xa4, 0x23,
+	0xc0, 0x99, 0x01, 0x4c, 0x47, 0xdf, 0x0c, 0x51, 0x20, 0x04, 0x4d, 0x27, 0xc6, 0x22, 0x35, 0x01,
+	0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b, 0x01,
+	0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01, 0x61, 0x06, 0x77,
+	0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0x01, 0x07, 0x00, 0x02, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x71, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00,
+	0x07, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x09, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x68, 0x09, 0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18,
+	0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x21, 0x13, 0x33,
+	0x01, 0x23, 0x13, 0x21, 0x03, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xb0, 0x01, 0x27,
+	0xd2, 0x7c, 0x02, 0xd9, 0x7c, 0xd1, 0xfe, 0xd9, 0xd1, 0x8b, 0xfd, 0x27, 0x8b, 0x01, 0x44, 0x01,
+	0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02,
+	0xbb, 0xfd, 0x45, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0xfa, 0x07, 0xcf, 0x00, 0x10, 0x00, 0x18, 0x00, 0x77, 0x40, 0x0a, 0x16, 0x01,
+	0x06, 0x05, 0x03, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00,
+	0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3a, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11,
+	0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0a,
+	0x09, 0x1a, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26,
+	0x23, 0x22, 0x07, 0x03, 0x13, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xa5, 0x01, 0x3b, 0xc5,
+	0x8b, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b, 0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0xd4,
+	0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x06, 0x2b, 0xfd, 0x47, 0xe4, 0xfe, 0xb6, 0xfc,
+	0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x06, 0x8e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0xb0, 0x00, 0x00, 0x06, 0xb6, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x17, 0x00, 0x68,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x07, 0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01, 0x00,
+	0x03, 0x01, 0x68, 0x00, 0x00, 0x00, 0x0a, 0x09, 0x00, 0x0a, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38,
+	0x4d, 0x0c, 0x0b, 0x02, 0x09, 0x09, 0x39, 0x09, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x03,
+	0x04, 0x85, 0x07, 0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01, 0x00, 0x03, 0x01, 0x68, 0x00, 0x00,
+	0x00, 0x0a, 0x09, 0x00, 0x0a, 0x67, 0x0c, 0x0b, 0x02, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40,
+	0x16, 0x04, 0x04, 0x04, 0x17, 0x04, 0x17, 0x16, 0x15, 0x14, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x12, 0x11, 0x10, 0x0d, 0x09, 0x1f, 0x2b, 0x01, 0x21, 0x37, 0x21, 0x01, 0x13, 0x23, 0x37,
+	0x33, 0x37, 0x33, 0x07, 0x21, 0x37, 0x33, 0x07, 0x33, 0x07, 0x23, 0x03, 0x23, 0x13, 0x21, 0x03,
+	0x02, 0x2d, 0x02, 0xd9, 0x32, 0xfd, 0x27, 0xfe, 0x51, 0xdd, 0x94, 0x19, 0x94, 0x31, 0xd2, 0x31,
+	0x02, 0xd9, 0x31, 0xd1, 0x31, 0x94, 0x19, 0x94, 0xdd, 0xd1, 0x8b, 0xfd, 0x27, 0x8b, 0x03, 0x58,
+	0xfe, 0xfb, 0xaa, 0x04, 0x56, 0x7c, 0xf6, 0xf6, 0xf6, 0xf6, 0x7c, 0xfb, 0xaa, 0x02, 0xbb, 0xfd,
+	0x45, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcd, 0x06, 0x2b, 0x00, 0x18,
+	0x00, 0x68, 0xb5, 0x0b, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21,
+	0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00,
+	0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x09, 0x08, 0x02, 0x06, 0x06, 0x39, 0x06,
+	0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02,
+	0x02, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x09, 0x08, 0x02,
+	0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x23, 0x12,
+	0x22, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37,
+	0x33, 0x07, 0x21, 0x07, 0x21, 0x03, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23,
+	0x22, 0x07, 0x03, 0xa5, 0xfb, 0x94, 0x19, 0x94, 0x27, 0xc5, 0x27, 0x01, 0x28, 0x19, 0xfe, 0xd8,
+	0x4b, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b, 0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0x04,
+	0xea, 0x7c, 0xc5, 0xc5, 0x7c, 0xfe, 0x88, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e,
+	0xee, 0xfd, 0x41, 0x00, 0x00, 0x02, 0x00, 0x87, 0x00, 0x00, 0x04, 0x56, 0x07, 0x4c, 0x00, 0x0b,
+	0x00, 0x1f, 0x00, 0x80, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2b, 0x08, 0x01, 0x06, 0x00, 0x0a,
+	0x09, 0x06, 0x0a, 0x69, 0x00, 0x07, 0x0d, 0x0b, 0x02, 0x09, 0x02, 0x07, 0x09, 0x6a, 0x03, 0x01,
+	0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0c,
+	0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x09, 0x06,
+	0x0a, 0x69, 0x00, 0x07, 0x0d, 0x0b, 0x02, 0x09, 0x02, 0x07, 0x09, 0x6a, 0x00, 0x02, 0x03, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0c, 0x01, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x1e, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x1f, 0x0c, 0x1f, 0x1e, 0x1c, 0x19,
+	0x17, 0x16, 0x15, 0x14, 0x12, 0x0f, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0e, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07,
+	0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27,
+	0x26, 0x23, 0x22, 0x07, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4,
+	0x1f, 0xfe, 0xcd, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49,
+	0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x62,
+	0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x03, 0x79, 0x05, 0xf7, 0x00, 0x03, 0x00, 0x17, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x21, 0x00, 0x03, 0x09, 0x07, 0x02, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06,
+	0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x04, 0x01, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69,
+	0x00, 0x03, 0x09, 0x07, 0x02, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x17, 0x04,
+	0x17, 0x16, 0x14, 0x11, 0x0f, 0x0e, 0x0d, 0x0c, 0x0a, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0xa5, 0xd9, 0xc5, 0xd9,
+	0xba, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35,
+	0x31, 0x1e, 0x44, 0x1f, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x0d, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea,
+	0x27, 0x25, 0x22, 0x6e, 0x00, 0x02, 0x00, 0x87, 0x00, 0x00, 0x04, 0x39, 0x07, 0x00, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x64, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x06, 0x09, 0x01, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x01, 0x37, 0x21, 0x07, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39,
+	0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0xfe, 0xd9, 0x1e, 0x02, 0x82, 0x1e, 0x9d, 0x04, 0x8e, 0x9d, 0x9d,
+	0xfb, 0x72, 0x9d, 0x06, 0x6c, 0x94, 0x94, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x03, 0x5b,
+	0x05, 0xab, 0x00, 0x03, 0x00, 0x07, 0x00, 0x6a, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x17, 0x05,
+	0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09,
+	0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x37, 0x21, 0x07, 0xa5, 0xd9, 0xc5, 0xd9, 0xae, 0x1d,
+	0x02, 0x82, 0x1d, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x17, 0x94, 0x94, 0x00, 0x00, 0x02, 0x00, 0x87,
+	0x00, 0x00, 0x04, 0x67, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x26, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x02, 0x07, 0x09,
+	0x69, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x08, 0x01, 0x06, 0x07,
+	0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x02, 0x07, 0x09, 0x69, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00,
+	0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e,
+	0x59, 0x40, 0x16, 0x00, 0x00, 0x16, 0x14, 0x12, 0x11, 0x10, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37,
+	0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x03, 0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0xf3,
+	0x7b, 0x01, 0xb1, 0xb2, 0x42, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0x9d, 0x04, 0x8e, 0x9d, 0x9d,
+	0xfb, 0x72, 0x9d, 0x07, 0x8f, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x03, 0x94, 0x06, 0x44, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x9c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x1c, 0x04, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x69, 0x04,
+	0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x02, 0x85, 0x00,
+	0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x02, 0x85, 0x00, 0x03, 0x00, 0x05,
+	0x00, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0c, 0x0a, 0x09, 0x08, 0x06, 0x05, 0x04, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x33, 0x06, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xa5, 0xd9, 0xc5, 0xd9, 0x70, 0x7b, 0x02, 0xb1,
+	0xb2, 0x43, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0x04, 0x3e, 0xfb, 0xc2, 0x06, 0x44, 0xad, 0xad,
+	0x92, 0xaf, 0xae, 0x00, 0x00, 0x01, 0x00, 0x87, 0xfe, 0x8e, 0x03, 0xe7, 0x05, 0xc8, 0x00, 0x19,
+	0x00, 0x90, 0xb5, 0x12, 0x01, 0x06, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x23,
+	0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05,
+	0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07,
+	0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x06, 0x00, 0x07, 0x06,
+	0x07, 0x65, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02,
+	0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x04, 0x01,
+	0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11,
+	0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x23, 0x23, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09,
+	0x1e, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x23, 0x06,
+	0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x87, 0x1f, 0xb4, 0xe9,
+	0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0xb7, 0x90, 0x14, 0x13, 0x72, 0x38, 0x26,
+	0x11, 0x41, 0x4e, 0xcc, 0x20, 0x19, 0xaf, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x4d,
+	0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x00, 0x02, 0x00, 0x17, 0xfe, 0x8e, 0x02, 0x9f,
+	0x05, 0xdc, 0x00, 0x10, 0x00, 0x14, 0x00, 0x84, 0xb5, 0x06, 0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38,
+	0x4d, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x01, 0x65, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d,
+	0x00, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04,
+	0x06, 0x01, 0x05, 0x03, 0x04, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x65, 0x00, 0x03,
+	0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x11, 0x11, 0x11,
+	0x14, 0x11, 0x14, 0x12, 0x11, 0x13, 0x23, 0x23, 0x07, 0x09, 0x1b, 0x2b, 0x21, 0x06, 0x07, 0x06,
+	0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x23, 0x13, 0x33, 0x27, 0x37, 0x33,
+	0x07, 0x01, 0x6a, 0x90, 0x14, 0x13, 0x72, 0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc, 0x20, 0x19, 0xaf,
+	0x5a, 0xd9, 0xc5, 0xa8, 0x2b, 0xd9, 0x2b, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55,
+	0x04, 0x3e, 0xc5, 0xd9, 0xd9, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x87, 0x00, 0x00, 0x03, 0xe7,
+	0x07, 0x31, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x64, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x06, 0x09, 0x01, 0x07, 0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e,
+	0x1b, 0x40, 0x1f, 0x00, 0x06, 0x09, 0x01, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13,
+	0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x03, 0x37, 0x33, 0x07, 0x87, 0x1f, 0xb4, 0xe9,
+	0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x49, 0x27, 0xc5, 0x27, 0x9d, 0x04, 0x8e,
+	0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x6c, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5,
+	0x00, 0x00, 0x02, 0x43, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x30, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0c,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x00,
+	0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0xa5, 0xd9,
+	0xc5, 0xd9, 0x04, 0x3e, 0xfb, 0xc2, 0x00, 0x00, 0x00, 0x02, 0x00, 0x87, 0xfe, 0xd8, 0x06, 0xd8,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x1a, 0x00, 0x6b, 0xb5, 0x0d, 0x01, 0x06, 0x05, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x00, 0x09, 0x06, 0x09, 0x65, 0x07, 0x03, 0x02,
+	0x01, 0x01, 0x02, 0x5f, 0x08, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f,
+	0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1f, 0x08, 0x01, 0x02, 0x07, 0x03, 0x02,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x06, 0x00, 0x09, 0x06, 0x09, 0x65, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x1a, 0x18,
+	0x16, 0x15, 0x14, 0x13, 0x10, 0x0e, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x07,
+	0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x23, 0x37, 0x21, 0x01, 0x02, 0x21, 0x22, 0x87, 0x1f,
+	0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x55, 0x24, 0x97, 0x95, 0x9f,
+	0x84, 0x24, 0xe5, 0xf0, 0x1f, 0x01, 0xc2, 0xfe, 0xfe, 0x61, 0xfe, 0x1e, 0xa7, 0x9d, 0x04, 0x8e,
+	0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0xe8, 0xb5, 0x4d, 0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe,
+	0x1d, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0xa5, 0xfe, 0x5d, 0x04, 0x5d, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x18, 0x00, 0x7d, 0xb5, 0x0d, 0x01, 0x06, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x05, 0x0a, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01,
+	0x02, 0x02, 0x38, 0x4d, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x01, 0x01, 0x01, 0x39, 0x4d,
+	0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x40, 0x23, 0x04, 0x01,
+	0x02, 0x0b, 0x05, 0x0a, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x09, 0x01, 0x01, 0x01, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08,
+	0x4e, 0x59, 0x40, 0x20, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x10, 0x0e,
+	0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x0c, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x13, 0x37, 0x33, 0x07, 0x21, 0x37, 0x33,
+	0x07, 0x01, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x02, 0x21, 0x22, 0xa5, 0xd9,
+	0xc5, 0xd9, 0x3b, 0x27, 0xc5, 0x27, 0x01, 0x07, 0x27, 0xc5, 0x27, 0xfc, 0xe4, 0x1d, 0x31, 0x47,
+	0x55, 0x4b, 0x22, 0xd9, 0xc5, 0xd9, 0x54, 0xfe, 0xc1, 0x5b, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03,
+	0xc5, 0xc5, 0xc5, 0xc5, 0xf9, 0x70, 0x90, 0x12, 0x69, 0xa6, 0x04, 0x3e, 0xfb, 0xc2, 0xfe, 0x5d,
+	0x00, 0x02, 0x00, 0x0d, 0xfe, 0xd8, 0x05, 0x34, 0x07, 0x8f, 0x00, 0x0e, 0x00, 0x16, 0x00, 0x6b,
+	0x40, 0x0a, 0x14, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x02, 0x05, 0x85, 0x00,
+	0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x01,
+	0x4e, 0x1b, 0x40, 0x24, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x02, 0x05, 0x85,
+	0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0x40, 0x0f, 0x0f, 0x0f, 0x0f, 0x16, 0x0f,
+	0x16, 0x11, 0x12, 0x22, 0x11, 0x13, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x17, 0x37, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x23, 0x37, 0x21, 0x01, 0x02, 0x21, 0x22, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x0d, 0x24, 0x97, 0x95, 0x9f, 0x84, 0x24, 0xe5, 0xfa, 0x1f, 0x01, 0xcc, 0xfe, 0xfe,
+	0x61, 0xfe, 0x1e, 0xa7, 0x01, 0xea, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0xe8, 0xb5,
+	0x4d, 0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x07, 0x76, 0x01, 0x41, 0xfe, 0xbf,
+	0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0xff, 0x68, 0xfe, 0x5d, 0x03, 0x6c, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x14, 0x00, 0x63, 0x40, 0x0a, 0x12, 0x01, 0x04, 0x03, 0x01, 0x01, 0x00, 0x01, 0x02, 0x4c,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x05, 0x02, 0x04, 0x03, 0x01, 0x03, 0x04, 0x01,
+	0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x05,
+	0x02, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x0d, 0x0d, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x12,
+	0x22, 0x13, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x03, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33,
+	0x03, 0x02, 0x21, 0x22, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x98, 0x1d, 0x31, 0x47,
+	0x54, 0x4b, 0x22, 0xd9, 0xc6, 0xd9, 0x54, 0xfe, 0xc1, 0x5b, 0x01, 0x1b, 0x01, 0x30, 0xdb, 0xb0,
+	0x94, 0xa0, 0x03, 0xf0, 0xfe, 0x73, 0x90, 0x12, 0x69, 0xa6, 0x04, 0x3e, 0xfb, 0xc2, 0xfe, 0x5d,
+	0x06, 0xa6, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x02, 0x00, 0xca, 0xfe, 0x50, 0x05, 0xf0,
+	0x05, 0xc8, 0x00, 0x0a, 0x00, 0x18, 0x00, 0x97, 0x40, 0x0d, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00,
+	0x12, 0x0c, 0x02, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05,
+	0x02, 0x04, 0x04, 0x05, 0x72, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80, 0x01, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00,
+	0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80,
+	0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x07, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x04, 0x04,
+	0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x18, 0x16,
+	0x14, 0x13, 0x0f, 0x0d, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33,
+	0x01, 0x33, 0x03, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x03, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37,
+	0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0xca, 0x01, 0x27, 0xc5, 0x91, 0x02, 0xf8, 0xd3,
+	0xfd, 0x1f, 0x02, 0x21, 0xfe, 0xf6, 0xfd, 0xfe, 0x95, 0x0e, 0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f,
+	0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x05, 0xc8, 0xfd, 0x28, 0x02, 0xd8, 0xfd, 0x3e,
+	0xfc, 0xfa, 0x02, 0xee, 0xfd, 0x12, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99,
+	0x00, 0x02, 0x00, 0xa5, 0xfe, 0x50, 0x04, 0x6b, 0x06, 0x2b, 0x00, 0x0a, 0x00, 0x18, 0x00, 0xa3,
+	0x40, 0x0d, 0x09, 0x06, 0x03, 0x03, 0x02, 0x01, 0x12, 0x0c, 0x02, 0x04, 0x05, 0x02, 0x4c, 0x4b,
+	0xb0, 0x0a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x02, 0x04, 0x04, 0x05, 0x72, 0x00, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04,
+	0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x24, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01,
+	0x01, 0x3b, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00,
+	0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80,
+	0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x0f, 0x0d, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x08,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x03, 0x03, 0x37,
+	0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0xa5, 0x01, 0x3b, 0xc5,
+	0xcc, 0x01, 0xd6, 0xbc, 0xfe, 0x3e, 0x01, 0x50, 0xf0, 0xfe, 0xd0, 0x6f, 0x30, 0x11, 0x31, 0x30,
+	0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x06, 0x2b, 0xfc, 0x04, 0x02,
+	0x0f, 0xfd, 0xff, 0xfd, 0xc3, 0x02, 0x2d, 0xfd, 0xd3, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e,
+	0x4d, 0xa8, 0x99, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x7f, 0x04, 0x3e, 0x00, 0x0a,
+	0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19,
+	0x2b, 0x33, 0x13, 0x33, 0x03, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x03, 0xa5, 0xd9, 0xc5, 0x6a,
+	0x01, 0xea, 0xbc, 0xfe, 0x2a, 0x01, 0x50, 0xf0, 0xfe, 0xd0, 0x6f, 0x04, 0x3e, 0xfd, 0xf1, 0x02,
+	0x0f, 0xfd, 0xff, 0xfd, 0xc3, 0x02, 0x2d, 0xfd, 0xd3, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x04, 0x77, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x01,
+	0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11,
+	0x11, 0x07, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x21, 0x07, 0x01, 0x01, 0x33, 0x01, 0xb0,
+	0x01, 0x27, 0xd2, 0xfe, 0xf8, 0x02, 0xd6, 0x1f, 0xfd, 0xa4, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05,
+	0xc8, 0xfa, 0xd5, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd1,
+	0xff, 0xe7, 0x03, 0xbe, 0x07, 0xcf, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x03,
+	0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x02, 0x04, 0x85, 0x00, 0x00, 0x02, 0x01, 0x02, 0x00, 0x01,
+	0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x10, 0x10, 0x10, 0x13,
+	0x10, 0x13, 0x12, 0x15, 0x22, 0x14, 0x06, 0x09, 0x1a, 0x2b, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07,
+	0x06, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x01, 0x33, 0x27, 0x01, 0x33, 0x01, 0x01, 0xad, 0x0c, 0x04,
+	0x23, 0x3c, 0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d, 0x42, 0x14, 0x0f, 0x01, 0x00, 0xc5, 0xfc, 0x01,
+	0x31, 0xe4, 0xfe, 0x7f, 0x01, 0x50, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79,
+	0x4d, 0x04, 0xff, 0x63, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xb0, 0xfe, 0x50, 0x04, 0x77,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x13, 0x00, 0x98, 0xb6, 0x0d, 0x07, 0x02, 0x03, 0x04, 0x01, 0x4c,
+	0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x04, 0x02, 0x03, 0x03, 0x04, 0x72, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x03,
+	0x03, 0x05, 0x62, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x23, 0x00, 0x04, 0x02, 0x03, 0x02, 0x04, 0x03, 0x80, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x05, 0x62, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x04, 0x02, 0x03,
+	0x02, 0x04, 0x03, 0x80, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x3c, 0x4d, 0x00,
+	0x03, 0x03, 0x05, 0x62, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00,
+	0x13, 0x11, 0x0f, 0x0e, 0x0a, 0x08, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18, 0x2b,
+	0x33, 0x01, 0x33, 0x01, 0x21, 0x07, 0x01, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20,
+	0x07, 0x06, 0x23, 0x22, 0xb0, 0x01, 0x27, 0xd2, 0xfe, 0xf8, 0x02, 0xd6, 0x1f, 0xfd, 0x1f, 0x11,
+	0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x05, 0xc8, 0xfa,
+	0xd5, 0x9d, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x02, 0x00, 0x61,
+	0xfe, 0x50, 0x02, 0xa5, 0x06, 0x2b, 0x00, 0x0d, 0x00, 0x1d, 0x00, 0x68, 0xb6, 0x07, 0x01, 0x02,
+	0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x03, 0x05, 0x04, 0x05,
+	0x03, 0x04, 0x80, 0x00, 0x01, 0x04, 0x00, 0x00, 0x01, 0x72, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00,
+	0x04, 0x04, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b,
+	0x40, 0x25, 0x00, 0x03, 0x05, 0x04, 0x05, 0x03, 0x04, 0x80, 0x00, 0x01, 0x04, 0x00, 0x04, 0x01,
+	0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x02,
+	0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x09, 0x15, 0x22, 0x15, 0x22, 0x14, 0x22,
+	0x06, 0x09, 0x1c, 0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06,
+	0x23, 0x22, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x01, 0x33,
+	0x61, 0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x01,
+	0x0f, 0x0c, 0x04, 0x23, 0x3c, 0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d, 0x42, 0x14, 0x0f, 0x01, 0x00,
+	0xc5, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x03, 0x00, 0x39, 0x51, 0x33,
+	0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x04, 0x96, 0x05, 0xc9, 0x00, 0x05, 0x00, 0x0f, 0x00, 0x49, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x04, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x15, 0x04, 0x01, 0x00,
+	0x00, 0x03, 0x01, 0x00, 0x03, 0x67, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x05, 0x00, 0x05, 0x11,
+	0x11, 0x06, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x21, 0x07, 0x03, 0x37, 0x36, 0x37, 0x37,
+	0x23, 0x37, 0x33, 0x07, 0x02, 0xb0, 0x01, 0x27, 0xd2, 0xfe, 0xf8, 0x02, 0xd6, 0x1f, 0xe1, 0x0c,
+	0x50, 0x20, 0x03, 0x4c, 0x27, 0xc5, 0x22, 0x34, 0x05, 0xc8, 0xfa, 0xd5, 0x9d, 0x04, 0x03, 0x3b,
+	0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00, 0x00, 0x02, 0x00, 0xd1, 0xff, 0xe7, 0x03, 0xe7,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x19, 0x00, 0x24, 0x40, 0x21, 0x00, 0x02, 0x00, 0x03, 0x00, 0x02,
+	0x03, 0x80, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x15, 0x22, 0x17, 0x11, 0x14, 0x05, 0x09, 0x1b, 0x2b, 0x01, 0x37, 0x36, 0x37,
+	0x37, 0x23, 0x37, 0x33, 0x07, 0x02, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07, 0x06, 0x23, 0x22, 0x2e,
+	0x02, 0x37, 0x01, 0x33, 0x02, 0xc7, 0x0c, 0x50, 0x20, 0x04, 0x4c, 0x27, 0xc5, 0x22, 0x35, 0xfe,
+	0x1d, 0x0c, 0x04, 0x23, 0x3c, 0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d, 0x42, 0x14, 0x0f, 0x01, 0x00,
+	0xc5, 0x04, 0x65, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0xfc, 0xd7, 0x39, 0x51, 0x33,
+	0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x04, 0x77, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x09, 0x00, 0x55, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1a, 0x00, 0x03, 0x06, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1a,
+	0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x06, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00,
+	0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18,
+	0x2b, 0x33, 0x01, 0x33, 0x01, 0x21, 0x07, 0x03, 0x37, 0x33, 0x07, 0xb0, 0x01, 0x27, 0xd2, 0xfe,
+	0xf8, 0x02, 0xd6, 0x1f, 0xe6, 0x27, 0xc5, 0x27, 0x05, 0xc8, 0xfa, 0xd5, 0x9d, 0x02, 0x83, 0xc5,
+	0xc5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd1, 0xff, 0xe7, 0x03, 0x69, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x13, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02, 0x01, 0x03, 0x01, 0x02, 0x03, 0x80, 0x00, 0x00,
+	0x05, 0x01, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x42,
+	0x03, 0x4e, 0x00, 0x00, 0x13, 0x12, 0x0d, 0x0b, 0x09, 0x08, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06,
+	0x09, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07, 0x06, 0x23, 0x22,
+	0x2e, 0x02, 0x37, 0x01, 0x33, 0x02, 0x7c, 0x27, 0xc6, 0x27, 0xfe, 0x6b, 0x0c, 0x04, 0x23, 0x3c,
+	0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d, 0x42, 0x14, 0x0f, 0x01, 0x00, 0xc5, 0x02, 0x82, 0xc5, 0xc5,
+	0xfe, 0xce, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x00,
+	0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x04, 0x76, 0x05, 0xc8, 0x00, 0x0d, 0x00, 0x46, 0x40, 0x09,
+	0x08, 0x07, 0x02, 0x01, 0x04, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x15, 0x15,
+	0x04, 0x09, 0x18, 0x2b, 0x33, 0x13, 0x07, 0x37, 0x37, 0x13, 0x33, 0x03, 0x25, 0x07, 0x05, 0x03,
+	0x21, 0x07, 0xb0, 0x8a, 0xa4, 0x21, 0xa5, 0x7b, 0xd2, 0x64, 0x01, 0x10, 0x21, 0xfe, 0xef, 0x82,
+	0x02, 0xd5, 0x1f, 0x02, 0xb4, 0x50, 0xa8, 0x52, 0x02, 0x6a, 0xfe, 0x08, 0x86, 0xa9, 0x86, 0xfd,
+	0x76, 0x9d, 0x00, 0x00, 0x00, 0x01, 0x00, 0x91, 0xff, 0xe7, 0x03, 0x20, 0x06, 0x2b, 0x00, 0x17,
+	0x00, 0x25, 0x40, 0x22, 0x15, 0x14, 0x0f, 0x0e, 0x04, 0x00, 0x02, 0x01, 0x4c, 0x00, 0x00, 0x02,
+	0x01, 0x02, 0x00, 0x01, 0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e,
+	0x19, 0x22, 0x14, 0x03, 0x09, 0x19, 0x2b, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07, 0x06, 0x23, 0x22,
+	0x2e, 0x02, 0x37, 0x13, 0x07, 0x37, 0x37, 0x13, 0x33, 0x03, 0x37, 0x07, 0x07, 0x01, 0xd3, 0x0c,
+	0x04, 0x23, 0x3c, 0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d, 0x42, 0x14, 0x0f, 0x55, 0xca, 0x21, 0xca,
+	0x8a, 0xc5, 0x74, 0xc9, 0x20, 0xcb, 0x01, 0x50, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c,
+	0x53, 0x79, 0x4d, 0x01, 0xa5, 0x62, 0xa6, 0x64, 0x02, 0xb2, 0xfd, 0xbb, 0x5c, 0xa3, 0x61, 0x00,
+	0x00, 0x02, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x5c,
+	0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x00, 0x05, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x06, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x01, 0x05, 0x00, 0x05, 0x85, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x06, 0x03, 0x02, 0x02,
+	0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c,
+	0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01,
+	0x13, 0x33, 0x01, 0x23, 0x01, 0x03, 0x01, 0x01, 0x33, 0x01, 0xb0, 0x01, 0x27, 0xcd, 0x02, 0x17,
+	0xe4, 0xb4, 0xfe, 0xd9, 0xce, 0xfd, 0xea, 0xe4, 0x02, 0x2c, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05,
+	0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0x06, 0x4e, 0x01, 0x41, 0xfe,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcd, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0xc6, 0xb5, 0x03, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x06, 0x05, 0x01,
+	0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06,
+	0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10,
+	0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x33, 0x13, 0x33, 0x07, 0x36, 0x33,
+	0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01, 0x33, 0x01, 0xa5,
+	0xd9, 0xc5, 0x29, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b, 0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6,
+	0x8c, 0x01, 0x3e, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4,
+	0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xb0, 0xfe, 0x50, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x17, 0x00, 0x96,
+	0x40, 0x0c, 0x08, 0x03, 0x02, 0x02, 0x00, 0x11, 0x0b, 0x02, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x02, 0x04, 0x04, 0x05, 0x72, 0x01, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06,
+	0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x02, 0x04,
+	0x02, 0x05, 0x04, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x20, 0x01,
+	0x01, 0x00, 0x02, 0x00, 0x85, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80, 0x07, 0x03, 0x02,
+	0x02, 0x02, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59,
+	0x59, 0x40, 0x12, 0x00, 0x00, 0x17, 0x15, 0x13, 0x12, 0x0e, 0x0c, 0x00, 0x09, 0x00, 0x09, 0x11,
+	0x12, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x23, 0x01, 0x03,
+	0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0xb0, 0x01,
+	0x27, 0xcd, 0x02, 0x17, 0xe4, 0xb4, 0xfe, 0xd9, 0xce, 0xfd, 0xea, 0xe4, 0x58, 0x11, 0x31, 0x30,
+	0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x05, 0xc8, 0xfb, 0x89, 0x04,
+	0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8,
+	0x99, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0xfe, 0x50, 0x04, 0xcd, 0x04, 0x56, 0x00, 0x10,
+	0x00, 0x1e, 0x00, 0xdb, 0x40, 0x0b, 0x03, 0x01, 0x02, 0x03, 0x18, 0x12, 0x02, 0x05, 0x06, 0x02,
+	0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x06, 0x02, 0x05, 0x05, 0x06, 0x72, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x4d, 0x00, 0x05, 0x05, 0x07, 0x62, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x02, 0x05, 0x02, 0x06, 0x05, 0x80, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x05,
+	0x05, 0x07, 0x62, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x29, 0x00, 0x06, 0x02, 0x05, 0x02, 0x06, 0x05, 0x80, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00,
+	0x05, 0x05, 0x07, 0x62, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x02,
+	0x05, 0x02, 0x06, 0x05, 0x80, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x07, 0x62,
+	0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x13, 0x00, 0x00, 0x1e, 0x1c, 0x1a,
+	0x19, 0x15, 0x13, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x33,
+	0x13, 0x33, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03,
+	0x03, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0xa5, 0xd9,
+	0xc5, 0x29, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b, 0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c,
+	0x17, 0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x04,
+	0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0xfe, 0x5b,
+	0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x02, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x65, 0x40, 0x0b, 0x0f, 0x01, 0x04, 0x05, 0x08, 0x03,
+	0x02, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x02, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03,
+	0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1a, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85,
+	0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x07, 0x03, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e, 0x0d,
+	0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33,
+	0x01, 0x13, 0x33, 0x01, 0x23, 0x01, 0x03, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xb0,
+	0x01, 0x27, 0xcd, 0x02, 0x17, 0xe4, 0xb4, 0xfe, 0xd9, 0xce, 0xfd, 0xea, 0xe4, 0x04, 0x5e, 0xfe,
+	0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04,
+	0x77, 0xfb, 0x89, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0xe7, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd1, 0x40, 0x0a, 0x16, 0x01,
+	0x05, 0x06, 0x03, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x00,
+	0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01,
+	0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x07, 0x02,
+	0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13,
+	0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33, 0x13, 0x33,
+	0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01,
+	0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xa5, 0xd9, 0xc5, 0x29, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b,
+	0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0x03, 0x7d, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1,
+	0x02, 0xf1, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd,
+	0x41, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xec,
+	0x00, 0x00, 0x05, 0x2f, 0x06, 0x2b, 0x00, 0x10, 0x00, 0x1a, 0x00, 0x8e, 0xb5, 0x03, 0x01, 0x02,
+	0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07,
+	0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x40, 0x21, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02,
+	0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x18, 0x17, 0x16, 0x15, 0x00, 0x10,
+	0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x08, 0x09, 0x1a, 0x2b, 0x21, 0x13, 0x33, 0x07, 0x36, 0x33,
+	0x20, 0x03, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x03, 0x37, 0x36, 0x37, 0x37,
+	0x23, 0x37, 0x33, 0x07, 0x02, 0x01, 0x07, 0xd9, 0xc5, 0x29, 0xca, 0xd2, 0x01, 0x17, 0x42, 0x9b,
+	0xc6, 0x8f, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0xe0, 0x0c, 0x50, 0x20, 0x04, 0x4c, 0x27, 0xc5,
+	0x22, 0x35, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd,
+	0x41, 0x04, 0x65, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00, 0x00, 0x01, 0x00, 0xb0,
+	0xfe, 0x5c, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x12, 0x00, 0x5a, 0x40, 0x0f, 0x11, 0x03, 0x02, 0x04,
+	0x00, 0x0b, 0x01, 0x03, 0x04, 0x0a, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x17, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x03,
+	0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x01, 0x01, 0x00, 0x04,
+	0x00, 0x85, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x43, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x23, 0x22, 0x12, 0x11,
+	0x06, 0x09, 0x1a, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x02, 0x21, 0x22, 0x27, 0x37,
+	0x16, 0x33, 0x32, 0x37, 0x37, 0x01, 0x03, 0xb0, 0x01, 0x27, 0xcd, 0x02, 0x17, 0xe4, 0xb4, 0xfe,
+	0xc6, 0x41, 0xfe, 0xbf, 0x49, 0x47, 0x1f, 0x37, 0x55, 0x8f, 0x2c, 0x03, 0xfd, 0xd8, 0xe4, 0x05,
+	0xc8, 0xfb, 0x89, 0x04, 0x77, 0xf9, 0xdc, 0xfe, 0xb8, 0x15, 0x9a, 0x1b, 0xd9, 0x0f, 0x04, 0x9f,
+	0xfb, 0x89, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0xfe, 0x5c, 0x04, 0xcd, 0x04, 0x56, 0x00, 0x18,
+	0x00, 0x91, 0x40, 0x0e, 0x03, 0x01, 0x05, 0x04, 0x0d, 0x01, 0x03, 0x05, 0x0c, 0x01, 0x02, 0x03,
+	0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x04, 0x04, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x05, 0x05,
+	0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x20,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06,
+	0x01, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x24, 0x23, 0x23, 0x22, 0x11, 0x07,
+	0x09, 0x1b, 0x2b, 0x33, 0x13, 0x33, 0x07, 0x36, 0x33, 0x20, 0x03, 0x03, 0x02, 0x21, 0x22, 0x27,
+	0x37, 0x16, 0x33, 0x32, 0x37, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa5, 0xd9, 0xc5, 0x29,
+	0xca, 0xd2, 0x01, 0x17, 0x42, 0xae, 0x41, 0xfe, 0xbf, 0x49, 0x47, 0x1f, 0x38, 0x3f, 0x92, 0x2c,
+	0x9a, 0x1a, 0x24, 0x4c, 0xa7, 0xc6, 0x8c, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0x98, 0xfe,
+	0xb8, 0x15, 0x9a, 0x1b, 0xd9, 0x03, 0x03, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x00, 0x03, 0x00, 0xb5,
+	0xff, 0xdb, 0x06, 0xc2, 0x06, 0xf6, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x67, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05,
+	0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02,
+	0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x37, 0x21,
+	0x07, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01,
+	0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe,
+	0xc3, 0x3b, 0x3a, 0xb9, 0xcc, 0x1e, 0x02, 0x82, 0x1e, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63,
+	0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01,
+	0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xea, 0x94, 0x94, 0x00,
+	0x00, 0x03, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8, 0x05, 0xab, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17,
+	0x00, 0x6b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00,
+	0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04,
+	0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x1b, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03,
+	0x02, 0x13, 0x37, 0x21, 0x07, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8,
+	0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0xab,
+	0x1d, 0x02, 0x82, 0x1d, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe,
+	0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x9c,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x03, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc2, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x23, 0x00, 0x71, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x06, 0x01, 0x04,
+	0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01,
+	0x05, 0x07, 0x69, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x09, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x22,
+	0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00,
+	0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00,
+	0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13,
+	0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x16, 0xfe, 0xc7, 0xfe,
+	0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe,
+	0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0xf6, 0x7b,
+	0x01, 0xb1, 0xb2, 0x42, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01,
+	0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a,
+	0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x07, 0x17, 0xad, 0xad,
+	0x92, 0xaf, 0xae, 0x00, 0x00, 0x03, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x1f, 0x00, 0xa3, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x27, 0x06, 0x01, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x00, 0x05, 0x00, 0x07,
+	0x01, 0x05, 0x07, 0x69, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05,
+	0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01,
+	0x00, 0x1e, 0x1c, 0x1a, 0x19, 0x18, 0x16, 0x15, 0x14, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33,
+	0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x13, 0x33, 0x06,
+	0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01,
+	0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe,
+	0xf2, 0x54, 0x54, 0xdf, 0x7b, 0x02, 0xb1, 0xb2, 0x43, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0x19,
+	0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2,
+	0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x05, 0xc9, 0xad, 0xad, 0x92, 0xaf, 0xae,
+	0x00, 0x04, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc6, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x75, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07,
+	0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x21, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c,
+	0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21,
+	0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02,
+	0x12, 0x01, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33, 0x01, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46,
+	0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9,
+	0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0x01, 0x1c, 0x01, 0x31,
+	0xbf, 0xfe, 0x7f, 0xf1, 0x01, 0x30, 0xbf, 0xfe, 0x80, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63,
+	0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01,
+	0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xd6, 0x01, 0x41, 0xfe,
+	0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x99, 0xff, 0xe7, 0x05, 0xac,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x79, 0x4b, 0xb0, 0x28, 0x50,
+	0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07,
+	0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x18, 0x18, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x14,
+	0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02,
+	0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33,
+	0x01, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0,
+	0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x01, 0x0f, 0x01, 0x31, 0xbf,
+	0xfe, 0x7f, 0xf1, 0x01, 0x30, 0xbf, 0xfe, 0x80, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01,
+	0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d,
+	0xfe, 0x5c, 0x04, 0x88, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xb5,
+	0xff, 0xdb, 0x08, 0xcd, 0x05, 0xed, 0x00, 0x16, 0x00, 0x22, 0x00, 0x8e, 0x40, 0x0a, 0x0b, 0x01,
+	0x08, 0x02, 0x01, 0x01, 0x07, 0x09, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x32, 0x00,
+	0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x67, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x5f,
+	0x0a, 0x01, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00,
+	0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x08, 0x03, 0x01, 0x08, 0x69, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x67, 0x00, 0x06, 0x06, 0x07,
+	0x5f, 0x0a, 0x01, 0x07, 0x07, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x22, 0x20, 0x1c, 0x1a, 0x00, 0x16, 0x00, 0x16, 0x11,
+	0x11, 0x11, 0x11, 0x12, 0x24, 0x22, 0x0b, 0x09, 0x1d, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x37, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21,
+	0x07, 0x01, 0x13, 0x36, 0x26, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x33, 0x20, 0x04, 0x74, 0x0b,
+	0xb0, 0xc3, 0xfe, 0xcc, 0xfe, 0xdd, 0x46, 0x48, 0x01, 0xce, 0x01, 0x3e, 0xb9, 0x88, 0x0b, 0x03,
+	0x32, 0x1f, 0xfd, 0xa0, 0x5f, 0x01, 0xfd, 0x1f, 0xfe, 0x03, 0x6b, 0x02, 0x8c, 0x1f, 0xfd, 0x13,
+	0x45, 0x2f, 0x78, 0xb3, 0xdf, 0xfe, 0xc6, 0x3b, 0x3a, 0xb8, 0xe2, 0x01, 0x51, 0x3a, 0x5f, 0x01,
+	0xab, 0x01, 0x5e, 0x01, 0x64, 0x01, 0xa5, 0x5e, 0x39, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d,
+	0x02, 0x39, 0x01, 0x56, 0xec, 0xd5, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xda, 0xfe, 0xba, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x99, 0xff, 0xe7, 0x07, 0xc4, 0x04, 0x56, 0x00, 0x1a, 0x00, 0x22, 0x00, 0x27,
+	0x00, 0x39, 0x40, 0x36, 0x11, 0x0b, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x00, 0x08, 0x00, 0x01, 0x02,
+	0x08, 0x01, 0x67, 0x09, 0x01, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x41, 0x4d, 0x06,
+	0x01, 0x02, 0x02, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x27, 0x25, 0x11, 0x22,
+	0x22, 0x24, 0x23, 0x23, 0x22, 0x12, 0x21, 0x0a, 0x09, 0x1f, 0x2b, 0x01, 0x36, 0x33, 0x32, 0x12,
+	0x03, 0x21, 0x06, 0x16, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x26, 0x27, 0x06, 0x21, 0x22,
+	0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x01, 0x02, 0x21, 0x20, 0x13, 0x12, 0x21, 0x20, 0x01, 0x21,
+	0x12, 0x23, 0x22, 0x04, 0x9e, 0xaf, 0xf6, 0xe8, 0x99, 0x3f, 0xfd, 0x37, 0x19, 0x95, 0xc2, 0xa1,
+	0xaa, 0x1f, 0xc8, 0xb2, 0xa1, 0xb6, 0x45, 0xa9, 0xfe, 0xfe, 0xef, 0xdd, 0x34, 0x34, 0x01, 0x58,
+	0xf2, 0xfb, 0xfd, 0x59, 0x55, 0x01, 0x41, 0x01, 0x1a, 0x54, 0x54, 0xfe, 0xe5, 0xfe, 0xc0, 0x02,
+	0xeb, 0x01, 0xf1, 0x3f, 0xe4, 0xf3, 0x03, 0x8e, 0xc8, 0xfe, 0xe5, 0xfe, 0xc5, 0xce, 0xb7, 0x47,
+	0x9d, 0x3e, 0x58, 0x6f, 0xc7, 0x01, 0x34, 0x01, 0x04, 0x01, 0x06, 0x01, 0x31, 0xfd, 0xd0, 0xfe,
+	0x55, 0x01, 0xa5, 0x01, 0xa2, 0xfe, 0xc6, 0x01, 0x3a, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xb0,
+	0x00, 0x00, 0x06, 0x09, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x18, 0x00, 0x75, 0xb5, 0x06,
+	0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x07, 0x06,
+	0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00,
+	0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00,
+	0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x08,
+	0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x15, 0x15, 0x00, 0x00, 0x15, 0x18,
+	0x15, 0x18, 0x17, 0x16, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x0a,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x01, 0x21, 0x01, 0x21, 0x03, 0x13,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x21, 0x13, 0x01, 0x33, 0x01, 0xb0, 0x01, 0x27, 0x02,
+	0x6a, 0x01, 0xc8, 0x49, 0x3b, 0xfe, 0xbc, 0x01, 0x64, 0xfe, 0xfe, 0xfe, 0xd8, 0xfe, 0x84, 0x7d,
+	0x9c, 0xeb, 0xd6, 0xe5, 0x20, 0x18, 0x8b, 0xbb, 0xfe, 0xd4, 0xc2, 0x01, 0x31, 0xe4, 0xfe, 0x7f,
+	0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b, 0x02, 0x72, 0xfd, 0x8e, 0x03, 0x0f, 0x94,
+	0xa1, 0x7c, 0x6b, 0x01, 0x23, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0x1d, 0x06, 0x44, 0x00, 0x0d, 0x00, 0x11, 0x00, 0xdb, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x0b, 0x07, 0x01, 0x00, 0x05, 0x08, 0x03, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x1b, 0x40,
+	0x0b, 0x07, 0x01, 0x00, 0x01, 0x08, 0x03, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x20, 0x07, 0x01, 0x05, 0x04, 0x00, 0x04, 0x05, 0x00, 0x80, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x07, 0x01, 0x05, 0x04,
+	0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x14, 0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x11, 0x0e, 0x11, 0x10, 0x0f, 0x00, 0x0d, 0x00, 0x0d,
+	0x23, 0x22, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x33, 0x07, 0x36, 0x33, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x22, 0x07, 0x03, 0x13, 0x01, 0x33, 0x01, 0xa5, 0xd9, 0xc5, 0x29, 0x87, 0xaa, 0x17,
+	0x21, 0x24, 0x30, 0x20, 0x72, 0xa4, 0x8f, 0x9e, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04, 0x3e, 0xcc,
+	0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd, 0x34, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xb0, 0xfe, 0x50, 0x06, 0x09, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x22,
+	0x00, 0xbb, 0x40, 0x0b, 0x06, 0x01, 0x02, 0x04, 0x1c, 0x16, 0x02, 0x06, 0x07, 0x02, 0x4c, 0x4b,
+	0xb0, 0x0a, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x07, 0x01, 0x06, 0x06, 0x07, 0x72, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x07, 0x01, 0x06, 0x01, 0x07, 0x06,
+	0x80, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00,
+	0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x07, 0x01, 0x06, 0x01, 0x07, 0x06, 0x80,
+	0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67,
+	0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43,
+	0x08, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00, 0x22, 0x20, 0x1e, 0x1d, 0x19, 0x17, 0x14, 0x12,
+	0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21,
+	0x20, 0x03, 0x02, 0x05, 0x01, 0x21, 0x01, 0x21, 0x03, 0x13, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26,
+	0x23, 0x21, 0x03, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22,
+	0xb0, 0x01, 0x27, 0x02, 0x6a, 0x01, 0xc8, 0x49, 0x3b, 0xfe, 0xbc, 0x01, 0x64, 0xfe, 0xfe, 0xfe,
+	0xd8, 0xfe, 0x84, 0x7d, 0x9c, 0xeb, 0xd6, 0xe5, 0x20, 0x18, 0x8b, 0xbb, 0xfe, 0xd4, 0xc9, 0x11,
+	0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x05, 0xc8, 0xfe,
+	0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b, 0x02, 0x72, 0xfd, 0x8e, 0x03, 0x0f, 0x94, 0xa1, 0x7c, 0x6b,
+	0xf9, 0x30, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x02, 0x00, 0x51,
+	0xfe, 0x50, 0x03, 0x83, 0x04, 0x56, 0x00, 0x0d, 0x00, 0x1b, 0x00, 0xf4, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x10, 0x08, 0x03, 0x02, 0x03, 0x02, 0x15, 0x0f, 0x02, 0x04, 0x05, 0x02, 0x4c, 0x07,
+	0x01, 0x00, 0x4a, 0x1b, 0x40, 0x10, 0x07, 0x01, 0x00, 0x01, 0x08, 0x03, 0x02, 0x03, 0x02, 0x15,
+	0x0f, 0x02, 0x04, 0x05, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05,
+	0x03, 0x04, 0x04, 0x05, 0x72, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x07, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x24, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04,
+	0x80, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x01, 0x03, 0x03,
+	0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03,
+	0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x28,
+	0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x04, 0x04,
+	0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1b,
+	0x19, 0x17, 0x16, 0x12, 0x10, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x08, 0x09, 0x19, 0x2b,
+	0x33, 0x13, 0x33, 0x07, 0x36, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x37,
+	0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0xa5, 0xd9, 0xc5, 0x29,
+	0x87, 0xaa, 0x17, 0x21, 0x24, 0x30, 0x20, 0x72, 0xa4, 0x8f, 0xfe, 0xe7, 0x11, 0x31, 0x30, 0x6d,
+	0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8,
+	0x11, 0xde, 0xfd, 0x34, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x09, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x1c,
+	0x00, 0x7e, 0x40, 0x0a, 0x1a, 0x01, 0x06, 0x07, 0x06, 0x01, 0x02, 0x04, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x26, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x0a, 0x08,
+	0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00,
+	0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x40, 0x1a, 0x15, 0x15, 0x00, 0x00, 0x15, 0x1c, 0x15, 0x1c, 0x19, 0x18, 0x17,
+	0x16, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x0b, 0x09, 0x19, 0x2b,
+	0x33, 0x01, 0x21, 0x20, 0x03, 0x02, 0x05, 0x01, 0x21, 0x01, 0x21, 0x03, 0x13, 0x33, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x21, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xb0, 0x01, 0x27,
+	0x02, 0x6a, 0x01, 0xc8, 0x49, 0x3b, 0xfe, 0xbc, 0x01, 0x64, 0xfe, 0xfe, 0xfe, 0xd8, 0xfe, 0x84,
+	0x7d, 0x9c, 0xeb, 0xd6, 0xe5, 0x20, 0x18, 0x8b, 0xbb, 0xfe, 0xd4, 0x02, 0xd7, 0xfe, 0xcf, 0xda,
+	0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b, 0x02, 0x72,
+	0xfd, 0x8e, 0x03, 0x0f, 0x94, 0xa1, 0x7c, 0x6b, 0x02, 0x64, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0xa6, 0x00, 0x00, 0x04, 0x09, 0x06, 0x44, 0x00, 0x0d, 0x00, 0x15, 0x00, 0xe9,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0f, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01, 0x00, 0x04, 0x08,
+	0x03, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x0f, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01, 0x00,
+	0x01, 0x08, 0x03, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21,
+	0x00, 0x04, 0x05, 0x00, 0x05, 0x04, 0x00, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01,
+	0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04,
+	0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x16, 0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x15, 0x0e, 0x15, 0x12, 0x11, 0x10, 0x0f, 0x00, 0x0d,
+	0x00, 0x0d, 0x23, 0x22, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x13, 0x33, 0x07, 0x36, 0x33, 0x32,
+	0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xa6,
+	0xd9, 0xc5, 0x29, 0x87, 0xaa, 0x17, 0x21, 0x24, 0x30, 0x20, 0x72, 0xa4, 0x8f, 0x02, 0x9e, 0xfe,
+	0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd,
+	0x34, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8d,
+	0xff, 0xdb, 0x05, 0xac, 0x07, 0x8f, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x67, 0x40, 0x0b, 0x0f, 0x01,
+	0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x0e, 0x20, 0x20, 0x20, 0x23, 0x20, 0x23, 0x12, 0x2a, 0x23, 0x28, 0x22, 0x07,
+	0x09, 0x1b, 0x2b, 0x37, 0x37, 0x04, 0x21, 0x20, 0x37, 0x36, 0x26, 0x27, 0x27, 0x24, 0x13, 0x12,
+	0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x07, 0x06, 0x16, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06,
+	0x04, 0x23, 0x20, 0x01, 0x01, 0x33, 0x01, 0x8d, 0x29, 0x01, 0x01, 0x01, 0x31, 0x01, 0x3d, 0x30,
+	0x15, 0x64, 0xb0, 0xbc, 0xfe, 0x97, 0x38, 0x51, 0x02, 0x1c, 0xf4, 0xe2, 0x27, 0xe4, 0xf8, 0xfe,
+	0xbc, 0x2c, 0x11, 0x63, 0x98, 0xc0, 0xda, 0x97, 0x21, 0x27, 0xfe, 0xaf, 0xf9, 0xfe, 0xf3, 0x01,
+	0xa1, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01,
+	0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5,
+	0x06, 0x73, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86, 0xff, 0xe7, 0x04, 0xb1,
+	0x06, 0x44, 0x00, 0x1c, 0x00, 0x20, 0x00, 0x6c, 0x40, 0x0b, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01,
+	0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x05, 0x04,
+	0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b,
+	0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42,
+	0x03, 0x4e, 0x59, 0x40, 0x0e, 0x1d, 0x1d, 0x1d, 0x20, 0x1d, 0x20, 0x12, 0x28, 0x23, 0x27, 0x22,
+	0x07, 0x09, 0x1b, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x12,
+	0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x04, 0x07, 0x06, 0x04, 0x23,
+	0x22, 0x01, 0x01, 0x33, 0x01, 0x86, 0x24, 0xaf, 0xab, 0xe5, 0x21, 0x15, 0x95, 0xa4, 0xec, 0x2b,
+	0x3d, 0x01, 0xa1, 0x78, 0xa0, 0x21, 0x87, 0xa2, 0xc9, 0x1c, 0x13, 0x84, 0x93, 0x01, 0x11, 0x2e,
+	0x1e, 0xfe, 0xf5, 0xca, 0xa3, 0x01, 0x5f, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x26, 0xb5, 0x60, 0xa5,
+	0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7,
+	0x99, 0xb0, 0x05, 0x1c, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x8d, 0xff, 0xdb, 0x05, 0xac,
+	0x07, 0x8f, 0x00, 0x1f, 0x00, 0x27, 0x00, 0x6e, 0x40, 0x0f, 0x25, 0x01, 0x05, 0x04, 0x0f, 0x01,
+	0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x20, 0x20, 0x20, 0x27, 0x20, 0x27, 0x11, 0x12, 0x2a, 0x23,
+	0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x04, 0x21, 0x20, 0x37, 0x36, 0x26, 0x27, 0x27,
+	0x24, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x07, 0x06, 0x16, 0x17, 0x17, 0x16,
+	0x16, 0x07, 0x06, 0x04, 0x23, 0x20, 0x13, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x8d, 0x29,
+	0x01, 0x01, 0x01, 0x31, 0x01, 0x3d, 0x30, 0x15, 0x64, 0xb0, 0xbc, 0xfe, 0x97, 0x38, 0x51, 0x02,
+	0x1c, 0xf4, 0xe2, 0x27, 0xe4, 0xf8, 0xfe, 0xbc, 0x2c, 0x11, 0x63, 0x98, 0xc0, 0xda, 0x97, 0x21,
+	0x27, 0xfe, 0xaf, 0xf9, 0xfe, 0xf3, 0xd7, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x34,
+	0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc,
+	0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x06, 0x73, 0x01, 0x41, 0xfe, 0xbf, 0xca,
+	0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86, 0xff, 0xe7, 0x04, 0x8e, 0x06, 0x44, 0x00, 0x1c,
+	0x00, 0x24, 0x00, 0x73, 0x40, 0x0f, 0x22, 0x01, 0x05, 0x04, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01,
+	0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05,
+	0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1d, 0x1d, 0x1d, 0x24, 0x1d, 0x24, 0x11, 0x12, 0x28,
+	0x23, 0x27, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27,
+	0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x04, 0x07,
+	0x06, 0x04, 0x23, 0x22, 0x13, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x86, 0x24, 0xaf, 0xab,
+	0xe5, 0x21, 0x15, 0x95, 0xa4, 0xec, 0x2b, 0x3d, 0x01, 0xa1, 0x78, 0xa0, 0x21, 0x87, 0xa2, 0xc9,
+	0x1c, 0x13, 0x84, 0x93, 0x01, 0x11, 0x2e, 0x1e, 0xfe, 0xf5, 0xca, 0xa3, 0x95, 0x01, 0x31, 0xda,
+	0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31,
+	0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0x05, 0x1c, 0x01, 0x41, 0xfe,
+	0xbf, 0xca, 0xca, 0x00, 0x00, 0x01, 0x00, 0x8d, 0xfe, 0x50, 0x05, 0xac, 0x05, 0xed, 0x00, 0x30,
+	0x00, 0x7d, 0x40, 0x13, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x28, 0x01, 0x06,
+	0x07, 0x27, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x04,
+	0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x08, 0x01, 0x03, 0x03, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02,
+	0x69, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x08, 0x01,
+	0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59,
+	0x40, 0x0c, 0x11, 0x12, 0x23, 0x24, 0x11, 0x1a, 0x23, 0x28, 0x22, 0x09, 0x09, 0x1f, 0x2b, 0x37,
+	0x37, 0x04, 0x21, 0x20, 0x37, 0x36, 0x26, 0x27, 0x27, 0x24, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x20, 0x07, 0x06, 0x16, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x07, 0x32,
+	0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x37, 0x26,
+	0x8d, 0x29, 0x01, 0x01, 0x01, 0x31, 0x01, 0x3d, 0x30, 0x15, 0x64, 0xb0, 0xbc, 0xfe, 0x97, 0x38,
+	0x51, 0x02, 0x1c, 0xf4, 0xe2, 0x27, 0xe4, 0xf8, 0xfe, 0xbc, 0x2c, 0x11, 0x63, 0x98, 0xc0, 0xda,
+	0x97, 0x21, 0x27, 0xfe, 0xaf, 0xf9, 0x35, 0x4e, 0x61, 0x0d, 0x0e, 0x88, 0x54, 0x47, 0x47, 0x11,
+	0x2b, 0x3b, 0x67, 0x0e, 0x14, 0xbb, 0x68, 0xe2, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42,
+	0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3,
+	0xc6, 0xe5, 0x48, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x8d, 0x0d, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x86, 0xfe, 0x50, 0x04, 0x1b, 0x04, 0x56, 0x00, 0x2e, 0x00, 0x4a, 0x40, 0x47,
+	0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x26, 0x01, 0x06, 0x07, 0x25, 0x01, 0x05,
+	0x06, 0x04, 0x4c, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x08, 0x01, 0x03, 0x03, 0x42, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x11, 0x12, 0x23, 0x24, 0x11,
+	0x19, 0x23, 0x27, 0x22, 0x09, 0x09, 0x1f, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27,
+	0x27, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x04,
+	0x07, 0x06, 0x07, 0x06, 0x07, 0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16,
+	0x33, 0x32, 0x37, 0x36, 0x23, 0x37, 0x26, 0x86, 0x24, 0xaf, 0xab, 0xe5, 0x21, 0x15, 0x95, 0xa4,
+	0xec, 0x2b, 0x3d, 0x01, 0xa1, 0x78, 0xa0, 0x21, 0x87, 0xa2, 0xc9, 0x1c, 0x13, 0x84, 0x93, 0x01,
+	0x11, 0x2e, 0x1e, 0x86, 0x72, 0xa3, 0x40, 0x4e, 0x61, 0x0d, 0x0e, 0x88, 0x54, 0x47, 0x47, 0x11,
+	0x2b, 0x3b, 0x67, 0x0e, 0x14, 0xbb, 0x70, 0x92, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54,
+	0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0x58, 0x4b, 0x0b,
+	0x56, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x97, 0x07, 0x00, 0x02, 0x00, 0x8d,
+	0xff, 0xdb, 0x05, 0xac, 0x07, 0x8f, 0x00, 0x1f, 0x00, 0x27, 0x00, 0x6e, 0x40, 0x0f, 0x25, 0x01,
+	0x04, 0x05, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x20, 0x20, 0x20, 0x27, 0x20, 0x27,
+	0x11, 0x12, 0x2a, 0x23, 0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x04, 0x21, 0x20, 0x37,
+	0x36, 0x26, 0x27, 0x27, 0x24, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x07, 0x06,
+	0x16, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x20, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17,
+	0x33, 0x37, 0x8d, 0x29, 0x01, 0x01, 0x01, 0x31, 0x01, 0x3d, 0x30, 0x15, 0x64, 0xb0, 0xbc, 0xfe,
+	0x97, 0x38, 0x51, 0x02, 0x1c, 0xf4, 0xe2, 0x27, 0xe4, 0xf8, 0xfe, 0xbc, 0x2c, 0x11, 0x63, 0x98,
+	0xc0, 0xda, 0x97, 0x21, 0x27, 0xfe, 0xaf, 0xf9, 0xfe, 0xf3, 0x03, 0xc9, 0xfe, 0xcf, 0xda, 0xb1,
+	0x94, 0xa1, 0x02, 0xf1, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01,
+	0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x07, 0xb4,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86, 0xff, 0xe7, 0x04, 0xce,
+	0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x73, 0x40, 0x0f, 0x22, 0x01, 0x04, 0x05, 0x0e, 0x01,
+	0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x03, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24,
+	0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x07, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1d, 0x1d, 0x1d, 0x24, 0x1d,
+	0x24, 0x11, 0x12, 0x28, 0x23, 0x27, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32,
+	0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06,
+	0x17, 0x17, 0x04, 0x07, 0x06, 0x04, 0x23, 0x22, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x86, 0x24, 0xaf, 0xab, 0xe5, 0x21, 0x15, 0x95, 0xa4, 0xec, 0x2b, 0x3d, 0x01, 0xa1, 0x78, 0xa0,
+	0x21, 0x87, 0xa2, 0xc9, 0x1c, 0x13, 0x84, 0x93, 0x01, 0x11, 0x2e, 0x1e, 0xfe, 0xf5, 0xca, 0xa3,
+	0x03, 0x91, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35,
+	0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0,
+	0x06, 0x5d, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x01, 0x01, 0x27, 0xfe, 0x50, 0x06, 0x00,
+	0x05, 0xc8, 0x00, 0x19, 0x00, 0x73, 0x40, 0x0a, 0x12, 0x01, 0x06, 0x07, 0x11, 0x01, 0x05, 0x06,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x69, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x09, 0x08, 0x02, 0x03,
+	0x03, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04,
+	0x07, 0x69, 0x09, 0x08, 0x02, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x12, 0x23, 0x24,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x21, 0x01, 0x21, 0x37, 0x21, 0x07, 0x21,
+	0x01, 0x23, 0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x37,
+	0x36, 0x23, 0x37, 0x02, 0x13, 0x01, 0x08, 0xfe, 0x0c, 0x1f, 0x04, 0xba, 0x1f, 0xfe, 0x0c, 0xfe,
+	0xf8, 0x2f, 0x52, 0x4e, 0x61, 0x0d, 0x0e, 0x88, 0x54, 0x47, 0x47, 0x11, 0x2b, 0x3b, 0x67, 0x0e,
+	0x14, 0xbb, 0x82, 0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x6d, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51,
+	0x0f, 0x4a, 0x60, 0xaf, 0x00, 0x01, 0x00, 0x6f, 0xfe, 0x50, 0x03, 0x31, 0x05, 0x34, 0x00, 0x27,
+	0x00, 0x50, 0x40, 0x4d, 0x27, 0x01, 0x09, 0x05, 0x15, 0x01, 0x00, 0x09, 0x0e, 0x01, 0x03, 0x04,
+	0x0d, 0x01, 0x02, 0x03, 0x04, 0x4c, 0x1d, 0x01, 0x06, 0x4a, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01,
+	0x04, 0x69, 0x08, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x07, 0x01, 0x06, 0x06, 0x3b, 0x4d, 0x00, 0x09,
+	0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x43, 0x02, 0x4e, 0x26, 0x24, 0x11, 0x13, 0x11, 0x14, 0x12, 0x23, 0x24, 0x11, 0x31, 0x0a, 0x09,
+	0x1f, 0x2b, 0x05, 0x06, 0x23, 0x22, 0x27, 0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27,
+	0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x23, 0x37, 0x26, 0x37, 0x13, 0x23, 0x37, 0x33, 0x37, 0x37,
+	0x07, 0x33, 0x07, 0x23, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x02, 0x1b, 0x3d, 0x34, 0x13, 0x12,
+	0x40, 0x4e, 0x61, 0x0d, 0x0e, 0x88, 0x54, 0x47, 0x47, 0x11, 0x2b, 0x3b, 0x67, 0x0e, 0x14, 0xbb,
+	0x7f, 0x85, 0x30, 0x80, 0x7f, 0x1e, 0x7f, 0x2d, 0xc9, 0x31, 0xf0, 0x1e, 0xf0, 0x78, 0x1a, 0x1b,
+	0x45, 0x29, 0x1d, 0x06, 0x13, 0x02, 0x56, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60,
+	0xad, 0x3e, 0xf0, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xfd, 0xa6, 0x82, 0x53, 0x0b, 0x00,
+	0x00, 0x02, 0x01, 0x27, 0x00, 0x00, 0x06, 0x00, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x65,
+	0xb5, 0x0d, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x38, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x08,
+	0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x02, 0x01, 0x00,
+	0x03, 0x01, 0x00, 0x68, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x08, 0x08,
+	0x00, 0x00, 0x08, 0x0f, 0x08, 0x0f, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x09, 0x09, 0x19, 0x2b, 0x21, 0x01, 0x21, 0x37, 0x21, 0x07, 0x21, 0x09, 0x02, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x02, 0x13, 0x01, 0x08, 0xfe, 0x0c, 0x1f, 0x04, 0xba, 0x1f, 0xfe, 0x0c,
+	0xfe, 0xf8, 0x02, 0x6b, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0x2b, 0x9d, 0x9d,
+	0xfa, 0xd5, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9e,
+	0xff, 0xe7, 0x04, 0x2b, 0x06, 0x98, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x37, 0x40, 0x34, 0x0a, 0x01,
+	0x02, 0x06, 0x14, 0x01, 0x05, 0x01, 0x02, 0x4c, 0x00, 0x07, 0x00, 0x06, 0x02, 0x07, 0x06, 0x67,
+	0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x11, 0x16, 0x23, 0x11, 0x13, 0x11, 0x12, 0x21, 0x08,
+	0x09, 0x1e, 0x2b, 0x05, 0x06, 0x23, 0x20, 0x13, 0x13, 0x23, 0x37, 0x33, 0x37, 0x37, 0x07, 0x33,
+	0x07, 0x23, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x37, 0x36, 0x37, 0x37, 0x23, 0x37, 0x33,
+	0x07, 0x02, 0x02, 0x1b, 0x3d, 0x34, 0xfe, 0xf4, 0x40, 0x80, 0x7f, 0x1e, 0x7f, 0x2d, 0xc9, 0x31,
+	0xf0, 0x1e, 0xf0, 0x78, 0x1a, 0x1b, 0x45, 0x29, 0x1d, 0xd4, 0x0c, 0x50, 0x20, 0x03, 0x4c, 0x28,
+	0xc5, 0x22, 0x35, 0x06, 0x13, 0x01, 0x45, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xfd, 0xa6,
+	0x82, 0x53, 0x0b, 0x04, 0x4c, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x27, 0x00, 0x00, 0x06, 0x00, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x54, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1c, 0x05, 0x01, 0x01, 0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x04,
+	0x01, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07,
+	0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x03, 0x04, 0x01, 0x02, 0x01, 0x03, 0x02, 0x67, 0x05, 0x01, 0x01,
+	0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09,
+	0x1d, 0x2b, 0x21, 0x13, 0x21, 0x37, 0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x21, 0x03, 0x02, 0x13, 0x8e, 0xfe, 0xd1, 0x1e, 0x01, 0x2f, 0x5c, 0xfe, 0x0c, 0x1f, 0x04, 0xba,
+	0x1f, 0xfe, 0x0c, 0x5c, 0x01, 0x2f, 0x1e, 0xfe, 0xd1, 0x8e, 0x02, 0xcb, 0x94, 0x01, 0xcc, 0x9d,
+	0x9d, 0xfe, 0x34, 0x94, 0xfd, 0x35, 0x00, 0x00, 0x00, 0x01, 0x00, 0x99, 0xff, 0xe7, 0x03, 0x31,
+	0x05, 0x34, 0x00, 0x1c, 0x00, 0x3c, 0x40, 0x39, 0x17, 0x01, 0x08, 0x00, 0x01, 0x4c, 0x09, 0x01,
+	0x03, 0x4a, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x08, 0x01, 0x00, 0x67, 0x05, 0x01, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x09, 0x61, 0x00, 0x09, 0x09,
+	0x42, 0x09, 0x4e, 0x1b, 0x19, 0x23, 0x11, 0x11, 0x11, 0x13, 0x11, 0x11, 0x11, 0x10, 0x0a, 0x09,
+	0x1f, 0x2b, 0x01, 0x23, 0x37, 0x33, 0x37, 0x23, 0x37, 0x33, 0x37, 0x37, 0x07, 0x33, 0x07, 0x23,
+	0x07, 0x33, 0x07, 0x23, 0x07, 0x06, 0x16, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x13, 0x01,
+	0x14, 0x7b, 0x19, 0x7b, 0x31, 0x7f, 0x1e, 0x7f, 0x2d, 0xc9, 0x31, 0xf0, 0x1e, 0xf0, 0x31, 0xd2,
+	0x19, 0xd2, 0x2e, 0x1a, 0x1b, 0x45, 0x29, 0x1d, 0x1c, 0x3d, 0x34, 0xfe, 0xf4, 0x40, 0x02, 0x38,
+	0x7b, 0xf7, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xf7, 0x7b, 0xe8, 0x82, 0x53, 0x0b, 0x8c, 0x13, 0x01,
+	0x45, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x07, 0x4c, 0x00, 0x15,
+	0x00, 0x29, 0x00, 0x6b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x06, 0x01, 0x04, 0x00, 0x08,
+	0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b,
+	0x40, 0x27, 0x02, 0x01, 0x00, 0x07, 0x01, 0x07, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x00, 0x08,
+	0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x00, 0x01,
+	0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x12, 0x16, 0x16, 0x16, 0x29,
+	0x16, 0x29, 0x23, 0x21, 0x11, 0x23, 0x24, 0x25, 0x13, 0x25, 0x10, 0x0b, 0x09, 0x1f, 0x2b, 0x01,
+	0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07,
+	0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33,
+	0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d,
+	0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2,
+	0x3d, 0x01, 0xc6, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49,
+	0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8,
+	0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xea, 0x26,
+	0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc,
+	0x05, 0xed, 0x00, 0x10, 0x00, 0x24, 0x00, 0xb9, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x14, 0x50, 0x58, 0x40, 0x28, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a,
+	0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a,
+	0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x07, 0x01, 0x05, 0x00, 0x09, 0x08, 0x05, 0x09, 0x69, 0x00, 0x06,
+	0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01,
+	0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x59, 0x40, 0x1d, 0x11, 0x11, 0x00, 0x00, 0x11, 0x24, 0x11, 0x24, 0x23, 0x21, 0x1e, 0x1c, 0x1b,
+	0x1a, 0x19, 0x17, 0x14, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0d, 0x09, 0x1a,
+	0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13,
+	0x33, 0x03, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22,
+	0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5,
+	0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0x0e, 0x3b, 0xad, 0x49, 0x36, 0x35,
+	0x31, 0x1e, 0x44, 0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0xcb, 0xe4,
+	0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03, 0xea,
+	0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xe1,
+	0xff, 0xdb, 0x06, 0x52, 0x07, 0x00, 0x00, 0x15, 0x00, 0x19, 0x00, 0x53, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1a, 0x00, 0x04, 0x06, 0x01, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d,
+	0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x04, 0x06, 0x01, 0x05, 0x00, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e,
+	0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x07, 0x09, 0x1b, 0x2b, 0x01,
+	0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07,
+	0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x37, 0x21, 0x07, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d,
+	0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2,
+	0x3d, 0x01, 0xd2, 0x1e, 0x02, 0x82, 0x1e, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb,
+	0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x48, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc, 0x05, 0xab, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0xbc, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58,
+	0x40, 0x1e, 0x08, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x00, 0x05,
+	0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x20, 0x00, 0x05, 0x08, 0x01, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x08, 0x01, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14,
+	0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x09, 0x09, 0x1a, 0x2b,
+	0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33,
+	0x03, 0x01, 0x37, 0x21, 0x07, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90,
+	0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0x10, 0x1d, 0x02, 0x82, 0x1d, 0xcb, 0xe4,
+	0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x17, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x07, 0x8f, 0x00, 0x15,
+	0x00, 0x21, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04,
+	0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x69, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04,
+	0x05, 0x04, 0x85, 0x02, 0x01, 0x00, 0x07, 0x01, 0x07, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07,
+	0x00, 0x05, 0x07, 0x69, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59,
+	0x40, 0x0b, 0x22, 0x11, 0x21, 0x13, 0x25, 0x13, 0x25, 0x10, 0x08, 0x09, 0x1e, 0x2b, 0x01, 0x33,
+	0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06,
+	0x23, 0x20, 0x02, 0x13, 0x01, 0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26,
+	0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28,
+	0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x02, 0x06, 0x7b, 0x01, 0xb1, 0xb2, 0x42, 0x7b,
+	0x2c, 0xd9, 0x88, 0x88, 0x92, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03,
+	0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x05, 0x6b, 0xad, 0xad, 0x92,
+	0xaf, 0xae, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x1c, 0x00, 0xfe, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58,
+	0x40, 0x23, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x27, 0x07, 0x01, 0x05,
+	0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x00, 0x08,
+	0x01, 0x06, 0x08, 0x69, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00,
+	0x06, 0x00, 0x08, 0x01, 0x06, 0x08, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x25, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x01, 0x06, 0x08, 0x69, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x15, 0x00, 0x00, 0x1b, 0x19,
+	0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09,
+	0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37,
+	0x13, 0x33, 0x03, 0x01, 0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03,
+	0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d,
+	0xc5, 0xd9, 0xfe, 0x3a, 0x7b, 0x02, 0xb1, 0xb2, 0x43, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0xcb,
+	0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x06, 0x44,
+	0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52,
+	0x07, 0xf1, 0x00, 0x15, 0x00, 0x21, 0x00, 0x2d, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x23, 0x00, 0x05, 0x00, 0x07, 0x06, 0x05, 0x07, 0x69, 0x09, 0x01, 0x06, 0x08, 0x01, 0x04, 0x00,
+	0x06, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x26, 0x02, 0x01, 0x00, 0x04, 0x01, 0x04, 0x00, 0x01, 0x80,
+	0x00, 0x05, 0x00, 0x07, 0x06, 0x05, 0x07, 0x69, 0x09, 0x01, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06,
+	0x04, 0x69, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x17,
+	0x23, 0x22, 0x17, 0x16, 0x29, 0x27, 0x22, 0x2d, 0x23, 0x2d, 0x1d, 0x1b, 0x16, 0x21, 0x17, 0x21,
+	0x25, 0x13, 0x25, 0x10, 0x0a, 0x09, 0x1a, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33,
+	0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x22,
+	0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26,
+	0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8,
+	0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x03, 0x0b,
+	0x5c, 0x69, 0x12, 0x13, 0x9f, 0x5f, 0x5e, 0x6a, 0x12, 0x14, 0x9f, 0x4f, 0x3c, 0x64, 0x0c, 0x0b,
+	0x43, 0x3a, 0x3b, 0x62, 0x0c, 0x0b, 0x41, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb,
+	0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x07, 0x85,
+	0x5e, 0x5e, 0x85, 0x84, 0x5e, 0x60, 0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53,
+	0x00, 0x03, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xbc, 0x06, 0xc9, 0x00, 0x10, 0x00, 0x1c, 0x00, 0x28,
+	0x00, 0xb2, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x25,
+	0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07,
+	0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x00,
+	0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08,
+	0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x1d, 0x1e, 0x1d, 0x12, 0x11, 0x00, 0x00, 0x24, 0x22, 0x1d, 0x28,
+	0x1e, 0x28, 0x18, 0x16, 0x11, 0x1c, 0x12, 0x1c, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22,
+	0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0x37, 0x13, 0x33, 0x03, 0x03, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06,
+	0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x03, 0x1e, 0x28,
+	0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9,
+	0xb2, 0x5c, 0x6a, 0x13, 0x13, 0x9f, 0x5f, 0x5e, 0x6a, 0x13, 0x13, 0x9f, 0x4f, 0x3c, 0x63, 0x0c,
+	0x0c, 0x43, 0x3a, 0x3b, 0x62, 0x0c, 0x0b, 0x41, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34,
+	0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03, 0x85, 0x5e, 0x5e, 0x85, 0x84, 0x5e, 0x60,
+	0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00, 0x00, 0x03, 0x00, 0xe1,
+	0xff, 0xdb, 0x06, 0x83, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x61, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05,
+	0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01,
+	0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d,
+	0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0a, 0x09, 0x1b, 0x2b, 0x01,
+	0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07,
+	0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33, 0x01, 0x01, 0xd8, 0xd2,
+	0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0,
+	0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x02, 0x16, 0x01, 0x31, 0xbf, 0xfe, 0x7f, 0xf1, 0x01, 0x30, 0xbf,
+	0xfe, 0x80, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56,
+	0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x2a, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x03, 0x00, 0x94, 0xff, 0xe7, 0x05, 0x7c, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0xd0, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14,
+	0x50, 0x58, 0x40, 0x21, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05,
+	0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04,
+	0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23,
+	0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1d, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18,
+	0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23,
+	0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x01, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33, 0x01, 0x03,
+	0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d,
+	0xc5, 0xd9, 0xfe, 0x4a, 0x01, 0x31, 0xbf, 0xfe, 0x7f, 0xf1, 0x01, 0x30, 0xbf, 0xfe, 0x80, 0xcb,
+	0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0xe1, 0xfe, 0x8e, 0x06, 0x52,
+	0x05, 0xc8, 0x00, 0x23, 0x00, 0x72, 0xb5, 0x18, 0x01, 0x03, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x1b, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x62, 0x00,
+	0x05, 0x05, 0x3f, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x62, 0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b,
+	0x40, 0x18, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00,
+	0x01, 0x01, 0x05, 0x62, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x23, 0x23,
+	0x29, 0x13, 0x25, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33,
+	0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x07, 0x06, 0x07, 0x06, 0x33, 0x32,
+	0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x23, 0x20, 0x02, 0x13, 0x01, 0xd8, 0xd2, 0xba,
+	0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0x6a, 0x8b,
+	0x67, 0x11, 0x13, 0x72, 0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc, 0x20, 0x13, 0x72, 0x13, 0xfe, 0xcd,
+	0xe2, 0x3d, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56,
+	0xc6, 0xcc, 0x4c, 0x43, 0x16, 0x44, 0x56, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x63, 0x4a, 0x01, 0x18,
+	0x01, 0x31, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94, 0xfe, 0x8e, 0x04, 0xbc, 0x04, 0x3e, 0x00, 0x1e,
+	0x00, 0xac, 0x40, 0x0a, 0x01, 0x01, 0x02, 0x01, 0x17, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x14, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x04, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3d, 0x06,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x20, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05,
+	0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3d, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x04,
+	0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59,
+	0x59, 0x40, 0x0a, 0x23, 0x23, 0x11, 0x12, 0x23, 0x12, 0x22, 0x07, 0x09, 0x1d, 0x2b, 0x21, 0x37,
+	0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x23,
+	0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x37, 0x03, 0x1e, 0x28,
+	0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9,
+	0x54, 0x90, 0x14, 0x13, 0x72, 0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc, 0x20, 0x19, 0xaf, 0xcb, 0xe4,
+	0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x4d, 0x66, 0x60,
+	0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x00, 0x00, 0x00, 0x02, 0x01, 0x4b, 0x00, 0x00, 0x08, 0xa6,
+	0x07, 0x8f, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x69, 0x40, 0x0c, 0x12, 0x01, 0x06, 0x05, 0x0b, 0x06,
+	0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d,
+	0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85,
+	0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x04,
+	0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x14, 0x0d,
+	0x14, 0x11, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0a, 0x09, 0x1a,
+	0x2b, 0x21, 0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x09, 0x02, 0x33,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0xa1, 0x56, 0xca, 0x46, 0x02, 0x44, 0xca, 0x66, 0x02, 0x2c,
+	0xab, 0xfd, 0x39, 0xd0, 0x66, 0xfd, 0xc8, 0x01, 0x4e, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02,
+	0xf1, 0x05, 0xc8, 0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb,
+	0x8b, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xef,
+	0x00, 0x00, 0x06, 0x9d, 0x06, 0x44, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x90, 0x40, 0x0c, 0x12, 0x01,
+	0x06, 0x05, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x1e, 0x09, 0x07, 0x02, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d,
+	0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06,
+	0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39,
+	0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06,
+	0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e,
+	0x59, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x10, 0x0f, 0x0e,
+	0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13,
+	0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x01, 0x13, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23,
+	0x07, 0x01, 0x1c, 0x2d, 0xc1, 0x20, 0x01, 0x9e, 0xc5, 0x37, 0x01, 0x89, 0xaa, 0xfd, 0xf6, 0xc6,
+	0x3e, 0xfe, 0x54, 0xb6, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x04, 0x3e, 0xfc, 0xce,
+	0x03, 0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x05, 0x03, 0x01, 0x41,
+	0xfe, 0xbf, 0xca, 0xca, 0x00, 0x02, 0x01, 0x50, 0x00, 0x00, 0x06, 0x6b, 0x07, 0x8f, 0x00, 0x08,
+	0x00, 0x10, 0x00, 0x62, 0x40, 0x0b, 0x0e, 0x01, 0x04, 0x03, 0x04, 0x01, 0x02, 0x02, 0x00, 0x02,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x07, 0x05, 0x02,
+	0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x07, 0x05, 0x02, 0x04, 0x00, 0x04, 0x85,
+	0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x06, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x15,
+	0x09, 0x09, 0x00, 0x00, 0x09, 0x10, 0x09, 0x10, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08,
+	0x12, 0x12, 0x08, 0x09, 0x18, 0x2b, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x03, 0x03,
+	0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x02, 0x3c, 0x7b, 0xfe, 0x99, 0xf0, 0x01, 0x1c, 0x02,
+	0x4c, 0xc3, 0xfd, 0x1f, 0x7c, 0x69, 0x01, 0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x02, 0x69,
+	0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf,
+	0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0xfe, 0x75, 0x04, 0xd8, 0x06, 0x44, 0x00, 0x07,
+	0x00, 0x0f, 0x00, 0x5b, 0x40, 0x0a, 0x0d, 0x01, 0x04, 0x03, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4c,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1b, 0x06, 0x05, 0x02, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00,
+	0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d,
+	0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x05, 0x02, 0x04, 0x00, 0x04,
+	0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0x40, 0x0e,
+	0x08, 0x08, 0x08, 0x0f, 0x08, 0x0f, 0x11, 0x12, 0x11, 0x12, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x21,
+	0x03, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01,
+	0x9f, 0xa8, 0xc8, 0x80, 0x01, 0xeb, 0xae, 0xfc, 0x9a, 0xcd, 0x01, 0x18, 0x01, 0x31, 0xda, 0xb1,
+	0x94, 0xa1, 0x02, 0xf1, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37, 0x06, 0x8e, 0x01, 0x41,
+	0xfe, 0xbf, 0xca, 0xca, 0x00, 0x03, 0x01, 0x50, 0x00, 0x00, 0x06, 0x6b, 0x07, 0x0f, 0x00, 0x08,
+	0x00, 0x0c, 0x00, 0x10, 0x00, 0x66, 0xb6, 0x04, 0x01, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x19, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x00, 0x03, 0x04,
+	0x67, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x1c, 0x01, 0x01, 0x00, 0x04, 0x02, 0x04, 0x00, 0x02, 0x80, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08,
+	0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x07, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x1b,
+	0x0d, 0x0d, 0x09, 0x09, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x09, 0x0c, 0x09, 0x0c,
+	0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x0a, 0x09, 0x18, 0x2b, 0x21, 0x13, 0x01, 0x33,
+	0x01, 0x01, 0x33, 0x01, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x02, 0x3c, 0x7b,
+	0xfe, 0x99, 0xf0, 0x01, 0x1c, 0x02, 0x4c, 0xc3, 0xfd, 0x1f, 0x7c, 0x27, 0x23, 0xad, 0x23, 0xde,
+	0x23, 0xad, 0x23, 0x02, 0x69, 0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06,
+	0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x70, 0x00, 0x00, 0x05, 0xae,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x62, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x00, 0x02, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c,
+	0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00,
+	0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21,
+	0x07, 0x01, 0x21, 0x07, 0x01, 0x01, 0x33, 0x01, 0x70, 0x21, 0x04, 0x02, 0xfd, 0x16, 0x1f, 0x03,
+	0xe6, 0x1f, 0xfb, 0xfe, 0x03, 0x1b, 0x21, 0xfe, 0xa1, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xa9, 0x04,
+	0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x55,
+	0x00, 0x00, 0x04, 0x8e, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x91, 0x4b, 0xb0, 0x28, 0x50,
+	0x58, 0x40, 0x24, 0x07, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d,
+	0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01,
+	0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01, 0x01, 0x33, 0x01, 0x55, 0x1d, 0x03, 0x0b, 0xfd,
+	0xb2, 0x1e, 0x03, 0x41, 0x1e, 0xfc, 0xf5, 0x02, 0x79, 0x1d, 0xfe, 0xb6, 0x01, 0x31, 0xe4, 0xfe,
+	0x7f, 0x94, 0x03, 0x16, 0x94, 0x94, 0xfc, 0xea, 0x94, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x70, 0x00, 0x00, 0x05, 0xae, 0x07, 0x31, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x5e,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06,
+	0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a,
+	0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37,
+	0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01, 0x37, 0x33, 0x07, 0x70, 0x21, 0x04, 0x02,
+	0xfd, 0x16, 0x1f, 0x03, 0xe6, 0x1f, 0xfb, 0xfe, 0x03, 0x1b, 0x21, 0xfe, 0xd4, 0x27, 0xc5, 0x27,
+	0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x06, 0x6c, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x55,
+	0x00, 0x00, 0x04, 0x8e, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x62, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x21, 0x07, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a,
+	0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x37,
+	0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x01, 0x37, 0x33, 0x07, 0x55, 0x1d, 0x03, 0x0b,
+	0xfd, 0xb2, 0x1e, 0x03, 0x41, 0x1e, 0xfc, 0xf5, 0x02, 0x79, 0x1d, 0xfe, 0xe4, 0x27, 0xc5, 0x27,
+	0x94, 0x03, 0x16, 0x94, 0x94, 0xfc, 0xea, 0x94, 0x05, 0x03, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x70,
+	0x00, 0x00, 0x05, 0xae, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x6d, 0xb5, 0x0f, 0x01, 0x04,
+	0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05,
+	0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d,
+	0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x08,
+	0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09,
+	0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07,
+	0x01, 0x21, 0x07, 0x13, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x70, 0x21, 0x04, 0x02, 0xfd,
+	0x16, 0x1f, 0x03, 0xe6, 0x1f, 0xfb, 0xfe, 0x03, 0x1b, 0x21, 0xd5, 0xfe, 0xcf, 0xda, 0xb1, 0x94,
+	0xa1, 0x02, 0xf1, 0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x07, 0x8f, 0xfe, 0xbf, 0x01,
+	0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x55, 0x00, 0x00, 0x04, 0xa2, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x11, 0x00, 0x9d, 0xb5, 0x0f, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x25, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08,
+	0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11,
+	0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09, 0x19,
+	0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x01, 0x21, 0x07, 0x13, 0x01, 0x23, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0x55, 0x1d, 0x03, 0x0b, 0xfd, 0xb2, 0x1e, 0x03, 0x41, 0x1e, 0xfc, 0xf5, 0x02,
+	0x79, 0x1d, 0xe1, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x94, 0x03, 0x16, 0x94, 0x94,
+	0xfc, 0xea, 0x94, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x01, 0x00, 0x9b,
+	0x00, 0x00, 0x03, 0x4a, 0x06, 0x44, 0x00, 0x10, 0x00, 0x58, 0xb5, 0x0a, 0x01, 0x03, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x40, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04,
+	0x39, 0x04, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x23, 0x11, 0x11, 0x06, 0x09,
+	0x1a, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37, 0x36, 0x36, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23,
+	0x22, 0x07, 0x03, 0x9b, 0xbb, 0x88, 0x1e, 0x88, 0x19, 0x25, 0xc8, 0x8f, 0x18, 0x29, 0x1d, 0x1b,
+	0x11, 0x7f, 0x2b, 0xf7, 0x03, 0xaa, 0x94, 0x82, 0xb7, 0xcd, 0x05, 0x93, 0x04, 0xdb, 0xfb, 0x2b,
+	0x00, 0x01, 0x00, 0x01, 0xfe, 0xd8, 0x05, 0x35, 0x05, 0xed, 0x00, 0x13, 0x00, 0x65, 0x40, 0x0a,
+	0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1b, 0x07, 0x01, 0x06, 0x00, 0x06, 0x86, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00,
+	0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07,
+	0x01, 0x06, 0x00, 0x06, 0x86, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01,
+	0x00, 0x00, 0x01, 0x57, 0x04, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x05, 0x01, 0x00, 0x01, 0x00, 0x4f,
+	0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x08,
+	0x09, 0x1c, 0x2b, 0x13, 0x01, 0x23, 0x37, 0x33, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23,
+	0x22, 0x03, 0x07, 0x33, 0x07, 0x23, 0x01, 0x01, 0x01, 0x93, 0xa6, 0x1d, 0xc4, 0x2a, 0xdb, 0x01,
+	0x83, 0x6e, 0x70, 0x3d, 0x63, 0x5d, 0xd6, 0x7c, 0x4e, 0xbd, 0x1d, 0xdb, 0xfe, 0x6c, 0xfe, 0xd8,
+	0x03, 0xf4, 0x94, 0x69, 0x02, 0x24, 0x1c, 0x9d, 0x26, 0xfe, 0xca, 0xc4, 0x94, 0xfc, 0x0c, 0x00,
+	0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x93, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x12,
+	0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x05, 0x06, 0x0a, 0x01, 0x04, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x21, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05,
+	0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03,
+	0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85,
+	0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x0b, 0x0b, 0x00,
+	0x00, 0x0b, 0x12, 0x0b, 0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21,
+	0x03, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2,
+	0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0x01, 0xfe, 0xfe, 0xcf, 0xda, 0xb1, 0x94,
+	0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x02,
+	0xdf, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbf,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x17, 0x00, 0x1f, 0x01, 0x1a, 0x40, 0x0a, 0x1d, 0x01, 0x06, 0x07,
+	0x01, 0x01, 0x01, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x26, 0x00, 0x06, 0x07,
+	0x03, 0x07, 0x06, 0x03, 0x80, 0x09, 0x08, 0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02,
+	0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x03, 0x07,
+	0x06, 0x03, 0x80, 0x09, 0x08, 0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04,
+	0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x06, 0x07,
+	0x03, 0x07, 0x06, 0x03, 0x80, 0x09, 0x08, 0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2b, 0x09, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05,
+	0x05, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40,
+	0x2b, 0x09, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85, 0x00, 0x04, 0x04,
+	0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x3c,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x59,
+	0x40, 0x11, 0x18, 0x18, 0x18, 0x1f, 0x18, 0x1f, 0x11, 0x12, 0x11, 0x11, 0x24, 0x22, 0x23, 0x22,
+	0x0a, 0x09, 0x1e, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06,
+	0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x23, 0x01, 0x01, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x03, 0x6a, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81,
+	0xa3, 0xa3, 0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x01,
+	0xa1, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x01, 0x7e, 0x02, 0x2b, 0x19, 0xfe, 0x4f,
+	0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2, 0x06, 0x44,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x87, 0x00, 0x00, 0x04, 0x83,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x73, 0xb5, 0x11, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02,
+	0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x0a, 0x08, 0x02,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02,
+	0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b,
+	0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23,
+	0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x87,
+	0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x01, 0xc3, 0xfe, 0xcf,
+	0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x07, 0x8f,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x03, 0xa5,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x06, 0x04,
+	0x02, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85,
+	0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x18, 0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14,
+	0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33,
+	0x37, 0xa5, 0xd9, 0xc5, 0xd9, 0x02, 0x3b, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x04,
+	0x3e, 0xfb, 0xc2, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0xb5,
+	0xff, 0xdb, 0x06, 0xc2, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d,
+	0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x21, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f,
+	0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x0a, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00,
+	0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x01, 0x01, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41,
+	0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc,
+	0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0x03, 0xac, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02,
+	0xf1, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98,
+	0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe,
+	0xde, 0xfe, 0xb6, 0x07, 0x17, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x99,
+	0xff, 0xe7, 0x04, 0xe2, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x7b, 0xb5, 0x19,
+	0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x00, 0x04, 0x05, 0x01,
+	0x05, 0x04, 0x01, 0x80, 0x09, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04,
+	0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x14, 0x14, 0x0d, 0x0c, 0x01,
+	0x00, 0x14, 0x1b, 0x14, 0x1b, 0x18, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33,
+	0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x01, 0x23,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8,
+	0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x03,
+	0x94, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07,
+	0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe,
+	0x5d, 0xfe, 0x5c, 0x05, 0xc9, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0xe1,
+	0xff, 0xdb, 0x06, 0x52, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x5e, 0xb5, 0x1b, 0x01, 0x04,
+	0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05,
+	0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05,
+	0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d,
+	0x11, 0x14, 0x25, 0x13, 0x25, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17,
+	0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13,
+	0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54,
+	0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d,
+	0x04, 0xaf, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93,
+	0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31,
+	0x05, 0x6b, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xdc,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd1, 0x40, 0x0a, 0x16, 0x01, 0x05, 0x06, 0x01, 0x01,
+	0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x01, 0x06,
+	0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02,
+	0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85,
+	0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x3c,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40,
+	0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00,
+	0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13,
+	0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x13, 0x01, 0x23, 0x03, 0x33, 0x17,
+	0x33, 0x37, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d,
+	0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xf9, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0xcb, 0xe4,
+	0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x06, 0x44, 0xfe,
+	0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52,
+	0x08, 0x64, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x7b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x26, 0x00, 0x08, 0x0c, 0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x06, 0x01, 0x04, 0x0b,
+	0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x29, 0x02, 0x01, 0x00, 0x05,
+	0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x08, 0x0c, 0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x06, 0x01,
+	0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e, 0x21,
+	0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25,
+	0x13, 0x25, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x37, 0x21, 0x07, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d,
+	0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2,
+	0x3d, 0x01, 0xf7, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23, 0xfd, 0xef, 0x1e, 0x02, 0x82, 0x1e,
+	0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc,
+	0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x01, 0x6e, 0x94, 0x94,
+	0x00, 0x04, 0x00, 0x94, 0xff, 0xe7, 0x04, 0xe7, 0x07, 0x0f, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18,
+	0x00, 0x1c, 0x00, 0xc7, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58,
+	0x40, 0x2a, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x2e, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c,
+	0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x2c, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x07, 0x01,
+	0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x40, 0x25, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19,
+	0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00,
+	0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20,
+	0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x01, 0x37, 0x33, 0x07,
+	0x33, 0x37, 0x33, 0x07, 0x01, 0x37, 0x21, 0x07, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42,
+	0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0x35, 0x22, 0xad, 0x22,
+	0xde, 0x22, 0xad, 0x22, 0xfd, 0xf8, 0x1d, 0x02, 0x82, 0x1d, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c,
+	0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x01,
+	0x6e, 0x94, 0x94, 0x00, 0x00, 0x04, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x08, 0xe9, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x7f, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00,
+	0x08, 0x09, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x09, 0x85, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a,
+	0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0c,
+	0x01, 0x09, 0x04, 0x09, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01,
+	0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e, 0x21,
+	0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25,
+	0x13, 0x25, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x01, 0x33, 0x01, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d,
+	0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2,
+	0x3d, 0x01, 0xf7, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23, 0xfe, 0xde, 0x01, 0x31, 0xe4, 0xfe,
+	0x7f, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6,
+	0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x01, 0x46, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x04, 0x00, 0x94, 0xff, 0xe7, 0x05, 0x50, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0xcd, 0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x14, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a,
+	0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e,
+	0x01, 0x0a, 0x05, 0x0a, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05,
+	0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x09, 0x0a,
+	0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x25, 0x19,
+	0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15,
+	0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12,
+	0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16,
+	0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x01,
+	0x33, 0x01, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d,
+	0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0x35, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0xfe, 0xeb,
+	0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed,
+	0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x01, 0x5a, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x04, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x08, 0xf3, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d,
+	0x00, 0x25, 0x00, 0x8a, 0xb5, 0x23, 0x01, 0x08, 0x09, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x29, 0x0d, 0x0a, 0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x06, 0x01,
+	0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2c, 0x0d, 0x0a,
+	0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05,
+	0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x20, 0x1e, 0x1e, 0x1a,
+	0x1a, 0x16, 0x16, 0x1e, 0x25, 0x1e, 0x25, 0x22, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c,
+	0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0e, 0x09, 0x1b, 0x2b, 0x01, 0x33,
+	0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06, 0x06, 0x07, 0x06,
+	0x23, 0x20, 0x02, 0x13, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x13, 0x01, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e,
+	0xbc, 0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x01, 0xf7, 0x23, 0xad,
+	0x23, 0xde, 0x23, 0xad, 0x23, 0xc8, 0xfe, 0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0xc8,
+	0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65,
+	0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x02, 0x91, 0xfe, 0xbf, 0x01, 0x41,
+	0xca, 0xca, 0x00, 0x00, 0x00, 0x04, 0x00, 0x94, 0xff, 0xe7, 0x05, 0x23, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x20, 0x00, 0xd7, 0x40, 0x0a, 0x1e, 0x01, 0x09, 0x0a, 0x01, 0x01,
+	0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2d, 0x0f, 0x0b, 0x02, 0x0a, 0x09,
+	0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07,
+	0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x0c, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x31,
+	0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0e, 0x08, 0x0d, 0x03,
+	0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x0c, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x2f, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85,
+	0x07, 0x01, 0x05, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x0c, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x27, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19,
+	0x20, 0x19, 0x20, 0x1d, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11,
+	0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x10, 0x09, 0x1a, 0x2b, 0x21,
+	0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03,
+	0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x13, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24, 0x4d, 0xa7, 0xc5,
+	0x8d, 0xc5, 0xd9, 0xfe, 0x35, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0xd3, 0xfe, 0xcf, 0xda,
+	0xb1, 0x94, 0xa1, 0x02, 0xf1, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed,
+	0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x02, 0x9b, 0xfe, 0xbf, 0x01, 0x41,
+	0xca, 0xca, 0x00, 0x00, 0x00, 0x04, 0x00, 0xe1, 0xff, 0xdb, 0x06, 0x52, 0x08, 0xf3, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x79, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00,
+	0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03,
+	0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08,
+	0x04, 0x08, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x0b,
+	0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x1a, 0x1a, 0x1a, 0x16, 0x16, 0x21, 0x20, 0x1f, 0x1e, 0x1a, 0x1d,
+	0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0c, 0x09, 0x1b,
+	0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x06,
+	0x06, 0x07, 0x06, 0x23, 0x20, 0x02, 0x13, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03,
+	0x23, 0x01, 0x33, 0x01, 0xd8, 0xd2, 0xba, 0x20, 0x16, 0x3d, 0x54, 0xaa, 0xc8, 0xc7, 0x2e, 0xbc,
+	0xb8, 0xbb, 0x28, 0x77, 0x78, 0xa0, 0xea, 0xfe, 0xcd, 0xe2, 0x3d, 0x01, 0xf7, 0x23, 0xad, 0x23,
+	0xde, 0x23, 0xad, 0x23, 0x8c, 0x94, 0xfe, 0xff, 0xe4, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33,
+	0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04,
+	0x3e, 0xad, 0xad, 0xad, 0xad, 0x01, 0x50, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x94,
+	0xff, 0xe7, 0x04, 0xbc, 0x07, 0xa8, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0xc6,
+	0xb5, 0x01, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x0a,
+	0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f,
+	0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x2f, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b,
+	0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x2d, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x07, 0x01, 0x05,
+	0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b,
+	0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x40, 0x21, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x1c, 0x1b, 0x1a, 0x19, 0x15, 0x18,
+	0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23,
+	0x12, 0x22, 0x0e, 0x09, 0x1a, 0x2b, 0x21, 0x37, 0x06, 0x23, 0x20, 0x13, 0x13, 0x33, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x03,
+	0x23, 0x01, 0x33, 0x03, 0x1e, 0x28, 0xc9, 0xd3, 0xfe, 0xea, 0x42, 0x9c, 0xc5, 0x90, 0x1a, 0x24,
+	0x4d, 0xa7, 0xc5, 0x8d, 0xc5, 0xd9, 0xfe, 0x35, 0x22, 0xad, 0x22, 0xde, 0x22, 0xad, 0x22, 0x81,
+	0x94, 0xfe, 0xff, 0xe4, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02,
+	0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x01, 0x5a, 0x01, 0x41, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x05, 0xc5, 0x08, 0x46, 0x00, 0x1b, 0x00, 0x1e, 0x00, 0x2c,
+	0x00, 0x6a, 0x40, 0x0c, 0x03, 0x01, 0x06, 0x00, 0x1e, 0x13, 0x0c, 0x03, 0x04, 0x05, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x05, 0x06,
+	0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06,
+	0x05, 0x06, 0x85, 0x07, 0x01, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x68, 0x03, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x21, 0x1f, 0x28, 0x26, 0x1f,
+	0x2c, 0x21, 0x2c, 0x1a, 0x11, 0x11, 0x1b, 0x11, 0x08, 0x09, 0x1b, 0x2b, 0x01, 0x01, 0x33, 0x01,
+	0x23, 0x16, 0x17, 0x16, 0x07, 0x06, 0x07, 0x06, 0x07, 0x13, 0x23, 0x03, 0x21, 0x03, 0x23, 0x01,
+	0x26, 0x27, 0x26, 0x37, 0x36, 0x37, 0x36, 0x37, 0x01, 0x21, 0x03, 0x13, 0x33, 0x36, 0x37, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x03, 0xe0, 0x01, 0x01, 0xe4, 0xfe, 0xaf,
+	0x02, 0x2d, 0x20, 0x35, 0x12, 0x14, 0x50, 0x16, 0x18, 0xf9, 0xe2, 0x49, 0xfd, 0xae, 0xeb, 0xc3,
+	0x03, 0x3c, 0x0f, 0x0e, 0x34, 0x12, 0x13, 0x4f, 0x2f, 0x35, 0xfe, 0x47, 0x01, 0xdc, 0x6f, 0x4b,
+	0x09, 0x37, 0x2d, 0x33, 0x0c, 0x0b, 0x43, 0x3a, 0x3b, 0x62, 0x0c, 0x0b, 0x41, 0x07, 0x2d, 0x01,
+	0x19, 0xfe, 0xe7, 0x10, 0x27, 0x42, 0x5e, 0x60, 0x42, 0x13, 0x0d, 0xfa, 0x6c, 0x01, 0x9a, 0xfe,
+	0x66, 0x05, 0x97, 0x0c, 0x11, 0x43, 0x5e, 0x5e, 0x42, 0x28, 0x10, 0xfb, 0x09, 0x02, 0x7a, 0x01,
+	0x18, 0x03, 0x26, 0x29, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00, 0x04, 0x00, 0x96,
+	0xff, 0xe7, 0x05, 0x42, 0x07, 0xbb, 0x00, 0x09, 0x00, 0x17, 0x00, 0x2a, 0x00, 0x36, 0x00, 0xfb,
+	0x40, 0x0a, 0x1b, 0x01, 0x09, 0x06, 0x01, 0x01, 0x01, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50,
+	0x58, 0x40, 0x2a, 0x00, 0x06, 0x09, 0x06, 0x85, 0x00, 0x09, 0x08, 0x09, 0x85, 0x0a, 0x01, 0x08,
+	0x00, 0x07, 0x03, 0x08, 0x07, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x06, 0x09, 0x06, 0x85, 0x00, 0x09, 0x08, 0x09, 0x85, 0x0a,
+	0x01, 0x08, 0x00, 0x07, 0x03, 0x08, 0x07, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x32, 0x00, 0x06, 0x09, 0x06, 0x85,
+	0x00, 0x09, 0x08, 0x09, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x07, 0x03, 0x08, 0x07, 0x69, 0x00, 0x04,
+	0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x32,
+	0x00, 0x06, 0x09, 0x06, 0x85, 0x00, 0x09, 0x08, 0x09, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x07, 0x03,
+	0x08, 0x07, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42,
+	0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x13, 0x2c, 0x2b, 0x32, 0x30, 0x2b, 0x36, 0x2c, 0x36, 0x28,
+	0x12, 0x11, 0x11, 0x24, 0x22, 0x23, 0x22, 0x0b, 0x09, 0x1e, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17,
+	0x33, 0x03, 0x23, 0x13, 0x01, 0x33, 0x01, 0x23, 0x16, 0x17, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22,
+	0x26, 0x37, 0x36, 0x37, 0x36, 0x37, 0x03, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x16, 0x03, 0x6a, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3,
+	0xce, 0xaa, 0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x0f, 0x01, 0x31,
+	0xe4, 0xfe, 0x7f, 0x02, 0x2e, 0x1f, 0x36, 0x13, 0x13, 0xa0, 0x61, 0x5c, 0x69, 0x13, 0x12, 0x50,
+	0x2f, 0x34, 0x01, 0x3c, 0x64, 0x0c, 0x0b, 0x42, 0x3a, 0x3b, 0x63, 0x0b, 0x0c, 0x41, 0x01, 0x7e,
+	0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38,
+	0x18, 0xfb, 0xc2, 0x06, 0x7a, 0x01, 0x41, 0xfe, 0xbf, 0x10, 0x27, 0x42, 0x5e, 0x60, 0x84, 0x85,
+	0x5e, 0x5e, 0x42, 0x28, 0x10, 0xfe, 0x9b, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00,
+	0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x08, 0xcd, 0x07, 0x8f, 0x00, 0x02, 0x00, 0x12, 0x00, 0x16,
+	0x00, 0x90, 0xb5, 0x02, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x32,
+	0x00, 0x09, 0x0a, 0x09, 0x85, 0x0c, 0x01, 0x0a, 0x01, 0x0a, 0x85, 0x00, 0x03, 0x00, 0x04, 0x00,
+	0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x07, 0x05, 0x00, 0x07, 0x67, 0x00, 0x02, 0x02, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x0b, 0x08, 0x02, 0x06, 0x06, 0x39,
+	0x06, 0x4e, 0x1b, 0x40, 0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0c, 0x01, 0x0a, 0x01, 0x0a, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x67,
+	0x00, 0x00, 0x00, 0x07, 0x05, 0x00, 0x07, 0x67, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x0b, 0x08, 0x02,
+	0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x19, 0x13, 0x13, 0x03, 0x03, 0x13, 0x16, 0x13, 0x16,
+	0x15, 0x14, 0x03, 0x12, 0x03, 0x12, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x13, 0x10, 0x0d, 0x09,
+	0x1e, 0x2b, 0x01, 0x21, 0x13, 0x01, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21,
+	0x07, 0x21, 0x13, 0x21, 0x09, 0x02, 0x33, 0x01, 0x02, 0xd1, 0x01, 0xa2, 0x84, 0xfb, 0x27, 0x04,
+	0xd3, 0x03, 0xdc, 0x1f, 0xfd, 0x2e, 0x5f, 0x02, 0x6e, 0x1f, 0xfd, 0x92, 0x6b, 0x02, 0xfd, 0x1f,
+	0xfc, 0x31, 0x52, 0xfd, 0xfb, 0xfe, 0xa8, 0x04, 0x5b, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x02, 0x39,
+	0x02, 0x92, 0xfb, 0x35, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x01, 0x9e, 0xfe,
+	0x62, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x04, 0x00, 0x7a, 0xff, 0xe7, 0x07, 0x82,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x2e, 0x00, 0x33, 0x01, 0x90, 0x4b, 0xb0, 0x1c, 0x50,
+	0x58, 0x40, 0x0b, 0x2a, 0x01, 0x09, 0x0a, 0x1b, 0x16, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x1b, 0x4b,
+	0xb0, 0x21, 0x50, 0x58, 0x40, 0x0b, 0x2a, 0x01, 0x0c, 0x0a, 0x1b, 0x16, 0x02, 0x03, 0x02, 0x02,
+	0x4c, 0x1b, 0x40, 0x0b, 0x2a, 0x01, 0x0c, 0x0a, 0x1b, 0x16, 0x02, 0x03, 0x05, 0x02, 0x4c, 0x59,
+	0x59, 0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0x31, 0x0e, 0x01, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04,
+	0x80, 0x0c, 0x01, 0x09, 0x05, 0x01, 0x02, 0x03, 0x09, 0x02, 0x69, 0x00, 0x00, 0x00, 0x3a, 0x4d,
+	0x0d, 0x01, 0x0a, 0x0a, 0x04, 0x61, 0x0b, 0x01, 0x04, 0x04, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03,
+	0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x42, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40,
+	0x36, 0x0e, 0x01, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x80, 0x00, 0x0c, 0x09, 0x02, 0x0c, 0x57,
+	0x00, 0x09, 0x05, 0x01, 0x02, 0x03, 0x09, 0x02, 0x69, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x0d, 0x01,
+	0x0a, 0x0a, 0x04, 0x61, 0x0b, 0x01, 0x04, 0x04, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x07, 0x61,
+	0x08, 0x01, 0x07, 0x07, 0x42, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x37, 0x0e,
+	0x01, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x80, 0x00, 0x09, 0x00, 0x02, 0x05, 0x09, 0x02, 0x69,
+	0x00, 0x0c, 0x00, 0x05, 0x03, 0x0c, 0x05, 0x67, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x0d, 0x01, 0x0a,
+	0x0a, 0x04, 0x61, 0x0b, 0x01, 0x04, 0x04, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x07, 0x61, 0x08,
+	0x01, 0x07, 0x07, 0x42, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x34, 0x00, 0x00,
+	0x01, 0x00, 0x85, 0x0e, 0x01, 0x01, 0x04, 0x01, 0x85, 0x00, 0x09, 0x00, 0x02, 0x05, 0x09, 0x02,
+	0x69, 0x00, 0x0c, 0x00, 0x05, 0x03, 0x0c, 0x05, 0x67, 0x0d, 0x01, 0x0a, 0x0a, 0x04, 0x61, 0x0b,
+	0x01, 0x04, 0x04, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x42,
+	0x07, 0x4e, 0x1b, 0x40, 0x3e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x0e, 0x01, 0x01, 0x04, 0x01, 0x85,
+	0x00, 0x09, 0x00, 0x02, 0x05, 0x09, 0x02, 0x69, 0x00, 0x0c, 0x00, 0x05, 0x03, 0x0c, 0x05, 0x67,
+	0x0d, 0x01, 0x0a, 0x0a, 0x04, 0x61, 0x0b, 0x01, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x07,
+	0x61, 0x08, 0x01, 0x07, 0x07, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x08, 0x01, 0x07, 0x07,
+	0x42, 0x07, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x22, 0x00, 0x00, 0x33, 0x31, 0x30, 0x2f, 0x2e,
+	0x2c, 0x29, 0x27, 0x24, 0x22, 0x1f, 0x1d, 0x1a, 0x18, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x0b,
+	0x09, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0f, 0x09, 0x17, 0x2b, 0x01, 0x01, 0x33, 0x01,
+	0x01, 0x13, 0x27, 0x20, 0x07, 0x06, 0x33, 0x32, 0x01, 0x36, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02,
+	0x21, 0x32, 0x37, 0x07, 0x06, 0x23, 0x20, 0x27, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x12, 0x21,
+	0x33, 0x37, 0x36, 0x26, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x13, 0x21, 0x12, 0x23, 0x20,
+	0x04, 0x08, 0x01, 0x2f, 0xe4, 0xfe, 0x81, 0xfe, 0x93, 0x39, 0x4b, 0xfe, 0x70, 0x2c, 0x22, 0xca,
+	0x8b, 0x01, 0xc4, 0xa9, 0xc4, 0x01, 0xbd, 0x6b, 0x0c, 0xfd, 0x1c, 0x33, 0x01, 0x77, 0x9e, 0xbd,
+	0x20, 0xce, 0xbd, 0xfe, 0xd1, 0x6a, 0x95, 0xca, 0x7f, 0x95, 0x95, 0x1b, 0x4a, 0x02, 0x72, 0x2e,
+	0x1a, 0x15, 0x51, 0x7b, 0xb0, 0xc8, 0x20, 0xd8, 0xc1, 0xe9, 0x42, 0x02, 0x14, 0x3d, 0xfc, 0xff,
+	0x00, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xfb, 0xf2, 0x01, 0x19, 0x02, 0xdd, 0xab, 0x03, 0x4f,
+	0x7f, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x42, 0x9c, 0x3c, 0xe6, 0x85, 0x61, 0xa4, 0x86, 0x01, 0x71,
+	0x83, 0x69, 0x54, 0x60, 0xa3, 0x51, 0xfe, 0x3e, 0x01, 0x2e, 0x00, 0x00, 0x00, 0x04, 0x00, 0x6b,
+	0xff, 0xdb, 0x07, 0x17, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x27, 0x00, 0x7d,
+	0x40, 0x11, 0x08, 0x01, 0x05, 0x00, 0x23, 0x1b, 0x0b, 0x01, 0x04, 0x04, 0x05, 0x12, 0x01, 0x02,
+	0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09,
+	0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3e, 0x4d,
+	0x00, 0x04, 0x04, 0x02, 0x61, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x21,
+	0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x01, 0x01, 0x00, 0x00, 0x05,
+	0x04, 0x00, 0x05, 0x69, 0x00, 0x04, 0x04, 0x02, 0x61, 0x08, 0x03, 0x02, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x59, 0x40, 0x18, 0x24, 0x24, 0x00, 0x00, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25, 0x1f, 0x1d,
+	0x17, 0x15, 0x00, 0x13, 0x00, 0x13, 0x25, 0x12, 0x25, 0x0a, 0x09, 0x19, 0x2b, 0x17, 0x37, 0x26,
+	0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x03, 0x02, 0x00, 0x21, 0x22, 0x27,
+	0x07, 0x01, 0x16, 0x33, 0x32, 0x00, 0x13, 0x36, 0x27, 0x27, 0x26, 0x23, 0x22, 0x00, 0x03, 0x06,
+	0x17, 0x01, 0x01, 0x33, 0x01, 0x6b, 0xda, 0x8e, 0x45, 0x46, 0x01, 0xd4, 0x01, 0x40, 0xfb, 0x95,
+	0x85, 0xac, 0xe1, 0x88, 0x43, 0x47, 0xfe, 0x2d, 0xfe, 0xbf, 0xf2, 0x97, 0x80, 0x01, 0x0d, 0x64,
+	0xb7, 0xe2, 0x01, 0x3f, 0x3a, 0x30, 0x34, 0x3e, 0x67, 0xba, 0xe2, 0xfe, 0xc2, 0x3a, 0x31, 0x38,
+	0x01, 0xe5, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x25, 0xdd, 0xd8, 0x01, 0x55, 0x01, 0x62, 0x01, 0xa6,
+	0x85, 0x85, 0xe3, 0xd9, 0xfe, 0xb3, 0xfe, 0x9d, 0xfe, 0x5a, 0x80, 0x80, 0x01, 0x10, 0x73, 0x01,
+	0x46, 0x01, 0x23, 0xf2, 0x94, 0x71, 0x78, 0xfe, 0xba, 0xfe, 0xde, 0xf6, 0x99, 0x04, 0xf5, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x04, 0x00, 0x95, 0xff, 0xe7, 0x05, 0x3d, 0x06, 0x44, 0x00, 0x13,
+	0x00, 0x1b, 0x00, 0x23, 0x00, 0x27, 0x00, 0x74, 0x40, 0x0d, 0x0a, 0x01, 0x05, 0x01, 0x23, 0x1b,
+	0x0d, 0x03, 0x04, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01,
+	0x07, 0x06, 0x01, 0x06, 0x07, 0x01, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01,
+	0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x08, 0x01, 0x07, 0x01, 0x07,
+	0x85, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x00,
+	0x61, 0x03, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x10, 0x24, 0x24, 0x24, 0x27, 0x24,
+	0x27, 0x15, 0x26, 0x23, 0x25, 0x12, 0x25, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x07, 0x23, 0x37,
+	0x26, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x07, 0x02, 0x00, 0x23, 0x22,
+	0x37, 0x17, 0x16, 0x33, 0x20, 0x13, 0x36, 0x2f, 0x02, 0x26, 0x23, 0x20, 0x03, 0x06, 0x17, 0x01,
+	0x01, 0x33, 0x01, 0x01, 0x72, 0x4d, 0x90, 0x9a, 0x59, 0x30, 0x35, 0x01, 0x40, 0xdf, 0xaa, 0x5c,
+	0x4d, 0x90, 0x9a, 0x59, 0x30, 0x35, 0xfe, 0xc1, 0xe0, 0xa7, 0x24, 0x01, 0x34, 0x6b, 0x01, 0x14,
+	0x55, 0x19, 0x10, 0x2a, 0x01, 0x3d, 0x62, 0xfe, 0xec, 0x52, 0x1e, 0x11, 0x01, 0x29, 0x01, 0x31,
+	0xe4, 0xfe, 0x7f, 0x3b, 0x54, 0xa7, 0x9f, 0xf1, 0x01, 0x0a, 0x01, 0x2e, 0x53, 0x53, 0xa7, 0x9f,
+	0xf0, 0xfe, 0xf8, 0xfe, 0xcf, 0xe2, 0x02, 0x4c, 0x01, 0xa8, 0x7e, 0x66, 0x6e, 0x02, 0x4b, 0xfe,
+	0x65, 0x96, 0x5b, 0x03, 0xcd, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8d,
+	0xfe, 0x50, 0x05, 0xac, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x2d, 0x00, 0xa5, 0x40, 0x10, 0x0f, 0x01,
+	0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x27, 0x21, 0x02, 0x04, 0x05, 0x03, 0x4c, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x03, 0x04, 0x04, 0x05, 0x72, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x4d,
+	0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x4d, 0x00,
+	0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x05, 0x03,
+	0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43,
+	0x06, 0x4e, 0x59, 0x59, 0x40, 0x0a, 0x22, 0x14, 0x23, 0x2a, 0x23, 0x28, 0x22, 0x07, 0x09, 0x1d,
+	0x2b, 0x37, 0x37, 0x04, 0x21, 0x20, 0x37, 0x36, 0x26, 0x27, 0x27, 0x24, 0x13, 0x12, 0x21, 0x32,
+	0x17, 0x07, 0x26, 0x23, 0x20, 0x07, 0x06, 0x16, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23,
+	0x20, 0x13, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0x8d,
+	0x29, 0x01, 0x01, 0x01, 0x31, 0x01, 0x3d, 0x30, 0x15, 0x64, 0xb0, 0xbc, 0xfe, 0x97, 0x38, 0x51,
+	0x02, 0x1c, 0xf4, 0xe2, 0x27, 0xe4, 0xf8, 0xfe, 0xbc, 0x2c, 0x11, 0x63, 0x98, 0xc0, 0xda, 0x97,
+	0x21, 0x27, 0xfe, 0xaf, 0xf9, 0xfe, 0xf3, 0x0d, 0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f,
+	0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01,
+	0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5,
+	0xfe, 0x80, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x02, 0x00, 0x86,
+	0xfe, 0x50, 0x04, 0x1b, 0x04, 0x56, 0x00, 0x1c, 0x00, 0x2a, 0x00, 0x77, 0x40, 0x10, 0x0e, 0x01,
+	0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x24, 0x1e, 0x02, 0x04, 0x05, 0x03, 0x4c, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x03, 0x04, 0x04, 0x05, 0x72, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d,
+	0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x05,
+	0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00,
+	0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x40, 0x0a, 0x22, 0x14, 0x23, 0x28, 0x23, 0x27, 0x22, 0x07,
+	0x09, 0x1d, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x12, 0x21,
+	0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x04, 0x07, 0x06, 0x04, 0x23, 0x22,
+	0x03, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0x86, 0x24,
+	0xaf, 0xab, 0xe5, 0x21, 0x15, 0x95, 0xa4, 0xec, 0x2b, 0x3d, 0x01, 0xa1, 0x78, 0xa0, 0x21, 0x87,
+	0xa2, 0xc9, 0x1c, 0x13, 0x84, 0x93, 0x01, 0x11, 0x2e, 0x1e, 0xfe, 0xf5, 0xca, 0xa3, 0x18, 0x11,
+	0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x26, 0xb5, 0x60,
+	0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61,
+	0xe7, 0x99, 0xb0, 0xfe, 0x74, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x27, 0xfe, 0x50, 0x06, 0x00, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x15, 0x00, 0x9a,
+	0xb6, 0x0f, 0x09, 0x02, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x23, 0x00,
+	0x05, 0x03, 0x04, 0x04, 0x05, 0x72, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38,
+	0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43,
+	0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05,
+	0x04, 0x80, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x07, 0x01, 0x03,
+	0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40,
+	0x22, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01,
+	0x00, 0x67, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06,
+	0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x15, 0x13, 0x11, 0x10, 0x0c, 0x0a, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x21, 0x01, 0x21, 0x37, 0x21, 0x07,
+	0x21, 0x01, 0x01, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22,
+	0x02, 0x13, 0x01, 0x08, 0xfe, 0x0c, 0x1f, 0x04, 0xba, 0x1f, 0xfe, 0x0c, 0xfe, 0xf8, 0xfe, 0xa8,
+	0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f, 0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x05, 0x2b,
+	0x9d, 0x9d, 0xfa, 0xd5, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x67, 0xfe, 0x50, 0x03, 0x31, 0x05, 0x34, 0x00, 0x14, 0x00, 0x22, 0x00, 0x7c,
+	0x40, 0x0f, 0x14, 0x01, 0x05, 0x01, 0x1c, 0x16, 0x02, 0x06, 0x07, 0x02, 0x4c, 0x0a, 0x01, 0x02,
+	0x4a, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x07, 0x00, 0x06, 0x06, 0x07, 0x72, 0x04,
+	0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e,
+	0x1b, 0x40, 0x29, 0x00, 0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x80, 0x04, 0x01, 0x01, 0x01, 0x02,
+	0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x59, 0x40, 0x0c, 0x22,
+	0x14, 0x24, 0x23, 0x11, 0x13, 0x11, 0x12, 0x21, 0x09, 0x09, 0x1f, 0x2b, 0x05, 0x06, 0x23, 0x20,
+	0x13, 0x13, 0x23, 0x37, 0x33, 0x37, 0x37, 0x07, 0x33, 0x07, 0x23, 0x03, 0x06, 0x16, 0x33, 0x32,
+	0x37, 0x01, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x37, 0x20, 0x07, 0x06, 0x23, 0x22, 0x02,
+	0x1b, 0x3d, 0x34, 0xfe, 0xf4, 0x40, 0x80, 0x7f, 0x1e, 0x7f, 0x2d, 0xc9, 0x31, 0xf0, 0x1e, 0xf0,
+	0x78, 0x1a, 0x1b, 0x45, 0x29, 0x1d, 0xfe, 0x30, 0x11, 0x31, 0x30, 0x6d, 0x0d, 0x0f, 0x9b, 0x0f,
+	0x01, 0x25, 0x21, 0x1f, 0xd9, 0x3e, 0x06, 0x13, 0x01, 0x45, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6,
+	0x94, 0xfd, 0xa6, 0x82, 0x53, 0x0b, 0xfd, 0xd5, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99,
+	0x00, 0x01, 0x01, 0x02, 0x05, 0x03, 0x03, 0xbe, 0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1c, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x03,
+	0x02, 0x02, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18,
+	0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x01, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x02, 0x01,
+	0x31, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00,
+	0x00, 0x01, 0x01, 0x42, 0x05, 0x03, 0x03, 0xfe, 0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1c, 0x05, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x03, 0x02, 0x02, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18,
+	0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x01, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0xfe, 0xfe,
+	0xcf, 0xda, 0xb1, 0x94, 0xa1, 0x02, 0xf1, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00,
+	0x00, 0x01, 0x01, 0x15, 0x05, 0x17, 0x03, 0xb4, 0x05, 0xab, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x21, 0x07, 0x01, 0x15, 0x1d, 0x02, 0x82, 0x1d, 0x05, 0x17,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x01, 0x46, 0x05, 0x03, 0x03, 0xed, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x28, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x01, 0x03, 0x51, 0x22, 0x11,
+	0x21, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x33, 0x06, 0x33, 0x32, 0x37,
+	0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x01, 0x53, 0x7b, 0x02, 0xb1, 0xb2, 0x43, 0x7b, 0x2c, 0xd9,
+	0x88, 0x88, 0x92, 0x06, 0x44, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x01, 0x01, 0xf3,
+	0x05, 0x17, 0x02, 0xdf, 0x05, 0xdc, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44,
+	0x01, 0x37, 0x33, 0x07, 0x01, 0xf3, 0x27, 0xc5, 0x27, 0x05, 0x17, 0xc5, 0xc5, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x97, 0x05, 0x03, 0x03, 0x83, 0x06, 0xc9, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x39,
+	0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01,
+	0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00,
+	0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06,
+	0x16, 0x02, 0x5d, 0x5c, 0x6a, 0x13, 0x13, 0x9f, 0x5f, 0x5e, 0x6a, 0x13, 0x13, 0x9f, 0x4f, 0x3c,
+	0x63, 0x0c, 0x0c, 0x43, 0x3a, 0x3b, 0x62, 0x0c, 0x0b, 0x41, 0x05, 0x03, 0x85, 0x5e, 0x5e, 0x85,
+	0x84, 0x5e, 0x60, 0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x6b, 0xfe, 0x8e, 0x01, 0xd7, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x4d, 0xb1, 0x06,
+	0x64, 0x44, 0xb5, 0x07, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x16,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x70, 0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02,
+	0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x1b, 0x40, 0x15, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x59, 0xb5,
+	0x23, 0x23, 0x10, 0x03, 0x09, 0x19, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x06, 0x07, 0x06,
+	0x33, 0x32, 0x37, 0x07, 0x06, 0x23, 0x22, 0x37, 0x36, 0x01, 0x53, 0x6b, 0x90, 0x14, 0x13, 0x72,
+	0x38, 0x26, 0x11, 0x41, 0x4e, 0xcc, 0x20, 0x19, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d,
+	0x00, 0x01, 0x01, 0x09, 0x05, 0x0d, 0x03, 0xd2, 0x05, 0xf7, 0x00, 0x13, 0x00, 0x34, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x29, 0x00, 0x01, 0x04, 0x03, 0x01, 0x59, 0x02, 0x01, 0x00, 0x00, 0x04, 0x03,
+	0x00, 0x04, 0x69, 0x00, 0x01, 0x01, 0x03, 0x62, 0x06, 0x05, 0x02, 0x03, 0x01, 0x03, 0x52, 0x00,
+	0x00, 0x00, 0x13, 0x00, 0x13, 0x23, 0x21, 0x11, 0x23, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22,
+	0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x01, 0x09, 0x3b, 0xad, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44,
+	0x1f, 0x7b, 0x3a, 0xae, 0x49, 0x36, 0x35, 0x31, 0x1e, 0x44, 0x1f, 0x05, 0x0d, 0xea, 0x26, 0x25,
+	0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x02, 0x00, 0xd8, 0x05, 0x03, 0x04, 0x27,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01,
+	0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x01, 0x33, 0x01, 0x33, 0x01,
+	0x33, 0x01, 0xd8, 0x01, 0x31, 0xbf, 0xfe, 0x7f, 0xf1, 0x01, 0x30, 0xbf, 0xfe, 0x80, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x8d, 0xfe, 0xa2, 0x02, 0xa5,
+	0x04, 0x4a, 0x00, 0x03, 0x00, 0x0d, 0x00, 0x7f, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x1c, 0x05,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x29, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x2d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b,
+	0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x59,
+	0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0d, 0x04, 0x0d, 0x0b, 0x0a, 0x09, 0x08, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x07, 0x08, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x01, 0x37, 0x36, 0x37, 0x37,
+	0x23, 0x37, 0x33, 0x07, 0x02, 0x01, 0x7d, 0x31, 0xf7, 0x31, 0xfe, 0x19, 0x0e, 0x66, 0x2e, 0x04,
+	0x60, 0x31, 0xf7, 0x2b, 0x4c, 0x03, 0x53, 0xf7, 0xf7, 0xfb, 0x4f, 0x4a, 0x1b, 0xe5, 0x14, 0xf7,
+	0xd6, 0xfe, 0x81, 0x00, 0x00, 0x01, 0x01, 0xbf, 0x05, 0x03, 0x03, 0xbf, 0x06, 0xa6, 0x00, 0x03,
+	0x00, 0x1f, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x08, 0x17, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x01, 0x01, 0x33, 0x01, 0x01, 0xbf, 0x01, 0x1c, 0xe4, 0xfe, 0x88, 0x05, 0x03, 0x01, 0xa3,
+	0xfe, 0x5d, 0x00, 0x00, 0x00, 0x03, 0x00, 0xeb, 0x05, 0x0d, 0x03, 0xf7, 0x07, 0x07, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x48, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x3d, 0x00, 0x04, 0x00, 0x04,
+	0x85, 0x08, 0x01, 0x05, 0x00, 0x01, 0x00, 0x05, 0x01, 0x80, 0x02, 0x01, 0x00, 0x05, 0x01, 0x00,
+	0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x60, 0x07, 0x03, 0x06, 0x03, 0x01, 0x00, 0x01, 0x50, 0x08,
+	0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x37,
+	0x33, 0x07, 0x21, 0x37, 0x33, 0x07, 0x25, 0x01, 0x33, 0x01, 0xeb, 0x22, 0xac, 0x22, 0x01, 0x7f,
+	0x22, 0xad, 0x22, 0xfe, 0x34, 0x01, 0x26, 0xda, 0xfe, 0x7e, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad,
+	0x56, 0x01, 0xa4, 0xfe, 0x5c, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x21, 0x00, 0x00, 0x05, 0x4c,
+	0x06, 0x2b, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x73, 0xb5, 0x0a, 0x01, 0x06, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x08, 0x01, 0x06, 0x00, 0x04, 0x00, 0x06, 0x04,
+	0x80, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x05, 0x05, 0x2a, 0x4d, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x00,
+	0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x08, 0x01, 0x06, 0x04, 0x05, 0x06, 0x04, 0x7e, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x05, 0x05, 0x2a, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01,
+	0x2c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c,
+	0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x33,
+	0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03, 0x05, 0x01, 0x33, 0x01, 0x21, 0x03, 0x59, 0xd0,
+	0x01, 0x02, 0xe2, 0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f, 0xfd, 0x70, 0x01, 0x1b,
+	0xe5, 0xfe, 0x87, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x28,
+	0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x01, 0x01, 0x53, 0x03, 0x47, 0x02, 0x7c, 0x04, 0x3e, 0x00, 0x03,
+	0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x01, 0x4e,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x08, 0x17, 0x2b, 0x01, 0x37, 0x33, 0x07, 0x01,
+	0x53, 0x32, 0xf7, 0x32, 0x03, 0x47, 0xf7, 0xf7, 0x00, 0x02, 0x00, 0xf2, 0x00, 0x00, 0x07, 0x12,
+	0x06, 0x2b, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x7a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x09,
+	0x01, 0x07, 0x01, 0x02, 0x01, 0x07, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67,
+	0x00, 0x06, 0x06, 0x2a, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x2a, 0x09, 0x01,
+	0x07, 0x01, 0x02, 0x01, 0x07, 0x02, 0x80, 0x00, 0x00, 0x00, 0x01, 0x07, 0x00, 0x01, 0x67, 0x00,
+	0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c,
+	0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x08,
+	0x1b, 0x2b, 0x21, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x01, 0x01,
+	0x33, 0x01, 0x01, 0xf6, 0x01, 0x27, 0x03, 0xf5, 0x1f, 0xfc, 0xdd, 0x5f, 0x02, 0xc0, 0x1f, 0xfd,
+	0x40, 0x6b, 0x03, 0x4f, 0x1f, 0xfa, 0xdb, 0x01, 0x1c, 0xe4, 0xfe, 0x88, 0x05, 0xc8, 0x9d, 0xfe,
+	0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x02, 0x00, 0xf2,
+	0x00, 0x00, 0x07, 0x40, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x72, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x24, 0x08, 0x01, 0x01, 0x02, 0x03, 0x02, 0x01, 0x03, 0x80, 0x00, 0x03, 0x00, 0x06,
+	0x05, 0x03, 0x06, 0x68, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x28, 0x4d, 0x09,
+	0x07, 0x02, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x04, 0x01, 0x02, 0x00, 0x01, 0x00,
+	0x02, 0x01, 0x80, 0x08, 0x01, 0x01, 0x03, 0x00, 0x01, 0x03, 0x7e, 0x00, 0x03, 0x00, 0x06, 0x05,
+	0x03, 0x06, 0x68, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x09, 0x07, 0x02, 0x05, 0x05, 0x2c, 0x05, 0x4e,
+	0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a,
+	0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x08, 0x17, 0x2b, 0x13, 0x01,
+	0x33, 0x01, 0x13, 0x01, 0x33, 0x03, 0x21, 0x13, 0x33, 0x01, 0x23, 0x13, 0x21, 0x03, 0xf2, 0x01,
+	0x1c, 0xe4, 0xfe, 0x88, 0x5f, 0x01, 0x27, 0xd2, 0x7c, 0x02, 0x9d, 0x7c, 0xd1, 0xfe, 0xd9, 0xd1,
+	0x8b, 0xfd, 0x63, 0x8b, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0xfb, 0x78, 0x05, 0xc8, 0xfd, 0x90,
+	0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb, 0xfd, 0x45, 0x00, 0x02, 0xff, 0xc7, 0x00, 0x00, 0x03, 0xe3,
+	0x06, 0x2b, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x09,
+	0x01, 0x07, 0x01, 0x00, 0x01, 0x07, 0x00, 0x80, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01,
+	0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x09, 0x01, 0x07, 0x01, 0x00, 0x01, 0x07, 0x00,
+	0x80, 0x00, 0x02, 0x03, 0x01, 0x01, 0x07, 0x02, 0x01, 0x67, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c,
+	0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03,
+	0x33, 0x07, 0x01, 0x01, 0x33, 0x01, 0x83, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4,
+	0xe9, 0xb4, 0x1f, 0xfd, 0x0b, 0x01, 0x1b, 0xe4, 0xfe, 0x88, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb,
+	0x72, 0x9d, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x03, 0x00, 0x75, 0xff, 0xdb, 0x06, 0xbb,
+	0x06, 0x2b, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x71, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x25, 0x08, 0x01, 0x05, 0x01, 0x00, 0x01, 0x05, 0x00, 0x80, 0x00, 0x04, 0x04, 0x2a, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x2e, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07,
+	0x01, 0x02, 0x02, 0x2f, 0x02, 0x4e, 0x1b, 0x40, 0x23, 0x08, 0x01, 0x05, 0x01, 0x00, 0x01, 0x05,
+	0x00, 0x80, 0x00, 0x03, 0x00, 0x01, 0x05, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x2a, 0x4d, 0x06,
+	0x01, 0x00, 0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x59, 0x40, 0x1b, 0x18,
+	0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d,
+	0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x25, 0x32, 0x00, 0x13, 0x12,
+	0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x17, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00,
+	0x03, 0x02, 0x00, 0x01, 0x01, 0x33, 0x01, 0x03, 0x52, 0xd9, 0x01, 0x2b, 0x3c, 0x3a, 0xa9, 0xd2,
+	0xd3, 0xfe, 0xd6, 0x3b, 0x3a, 0xa6, 0xad, 0xfe, 0xd7, 0xfe, 0xeb, 0x46, 0x47, 0x01, 0xc1, 0x01,
+	0x31, 0x01, 0x30, 0x01, 0x18, 0x46, 0x48, 0xfe, 0x3f, 0xfc, 0x09, 0x01, 0x1c, 0xe4, 0xfe, 0x88,
+	0x78, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe,
+	0xb6, 0x9d, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98,
+	0xfe, 0x5c, 0x04, 0xad, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0xf3, 0x00, 0x00, 0x07, 0xd1,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x15, 0x00, 0x6b, 0x40, 0x0b, 0x0d, 0x01, 0x04, 0x01, 0x01, 0x4c,
+	0x10, 0x01, 0x03, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x05, 0x01, 0x01, 0x02,
+	0x04, 0x02, 0x01, 0x04, 0x80, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x28, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x29, 0x04, 0x4e, 0x1b, 0x40, 0x1d, 0x05, 0x01,
+	0x01, 0x02, 0x04, 0x02, 0x01, 0x04, 0x80, 0x00, 0x03, 0x00, 0x02, 0x01, 0x03, 0x02, 0x69, 0x00,
+	0x00, 0x00, 0x2a, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x2c, 0x04, 0x4e, 0x59, 0x40, 0x14, 0x04, 0x04,
+	0x00, 0x00, 0x04, 0x15, 0x04, 0x15, 0x0b, 0x09, 0x08, 0x07, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07,
+	0x08, 0x17, 0x2b, 0x13, 0x01, 0x33, 0x01, 0x01, 0x13, 0x12, 0x02, 0x23, 0x37, 0x33, 0x32, 0x12,
+	0x13, 0x36, 0x00, 0x37, 0x07, 0x06, 0x00, 0x03, 0x03, 0xf3, 0x01, 0x26, 0xe4, 0xfe, 0x7e, 0x02,
+	0x7d, 0x5f, 0x47, 0xa0, 0xcf, 0x22, 0x0f, 0xcb, 0xf3, 0x09, 0x8c, 0x01, 0x67, 0xb7, 0x1d, 0xeb,
+	0xfe, 0x90, 0x3c, 0x5f, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0xfb, 0x78, 0x01, 0xdf, 0x01, 0x60,
+	0x01, 0xdd, 0xac, 0xfe, 0xd5, 0xfe, 0xd6, 0xf4, 0x01, 0x45, 0x1c, 0x94, 0x42, 0xfe, 0x16, 0xfe,
+	0xd7, 0xfe, 0x21, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x00, 0x06, 0x75, 0x06, 0x2b, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x74, 0xb4, 0x1a, 0x01, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x26, 0x09, 0x01, 0x07, 0x04, 0x00, 0x04, 0x07, 0x00, 0x80, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x00,
+	0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x08,
+	0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x24, 0x09, 0x01, 0x07, 0x04, 0x00, 0x04,
+	0x07, 0x00, 0x80, 0x00, 0x01, 0x00, 0x04, 0x07, 0x01, 0x04, 0x69, 0x00, 0x06, 0x06, 0x2a, 0x4d,
+	0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40,
+	0x16, 0x1c, 0x1c, 0x00, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x00, 0x1b, 0x00, 0x1b, 0x25,
+	0x11, 0x14, 0x24, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x21, 0x00, 0x13, 0x12, 0x00, 0x21,
+	0x20, 0x00, 0x03, 0x02, 0x01, 0x21, 0x07, 0x21, 0x37, 0x24, 0x13, 0x36, 0x02, 0x23, 0x22, 0x00,
+	0x03, 0x02, 0x17, 0x07, 0x01, 0x01, 0x33, 0x01, 0xbb, 0x1e, 0x01, 0x52, 0xfe, 0xe4, 0x52, 0x3c,
+	0x01, 0xb0, 0x01, 0x09, 0x01, 0x09, 0x01, 0x16, 0x3c, 0x52, 0xfe, 0x78, 0x01, 0x52, 0x1e, 0xfe,
+	0x03, 0x1e, 0x01, 0x4d, 0x57, 0x33, 0xa6, 0xae, 0xad, 0xfe, 0xe5, 0x33, 0x57, 0xf1, 0x1e, 0xfd,
+	0xcd, 0x01, 0x1c, 0xe4, 0xfe, 0x88, 0x9a, 0x01, 0x0e, 0x01, 0x98, 0x01, 0x2c, 0x01, 0x81, 0xfe,
+	0x80, 0xfe, 0xd3, 0xfe, 0x67, 0xfe, 0xf3, 0x9a, 0x9a, 0xe5, 0x01, 0xb3, 0xff, 0x01, 0x22, 0xfe,
+	0xde, 0xff, 0x00, 0xfe, 0x4f, 0xe6, 0x9a, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0xf0, 0xff, 0xe7, 0x04, 0x0d, 0x07, 0x07, 0x00, 0x0d, 0x00, 0x11, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x88, 0xb5, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x2c, 0x00, 0x07, 0x03, 0x07, 0x85, 0x0b, 0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80,
+	0x0a, 0x06, 0x09, 0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x28, 0x4d, 0x00, 0x01,
+	0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40,
+	0x2a, 0x00, 0x07, 0x03, 0x07, 0x85, 0x0b, 0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x05,
+	0x01, 0x03, 0x0a, 0x06, 0x09, 0x03, 0x04, 0x01, 0x03, 0x04, 0x68, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x16, 0x16,
+	0x12, 0x12, 0x0e, 0x0e, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x12, 0x15, 0x12, 0x15, 0x14, 0x13,
+	0x0e, 0x11, 0x0e, 0x11, 0x13, 0x23, 0x13, 0x21, 0x0c, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22,
+	0x26, 0x37, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x37, 0x33, 0x07, 0x21, 0x37,
+	0x33, 0x07, 0x25, 0x01, 0x33, 0x01, 0x02, 0xcd, 0x64, 0x65, 0xa8, 0x6c, 0x2c, 0x8d, 0xc5, 0x89,
+	0x1f, 0x2e, 0x56, 0x49, 0x57, 0xfe, 0x17, 0x22, 0xac, 0x22, 0x01, 0x7f, 0x22, 0xad, 0x22, 0xfe,
+	0x34, 0x01, 0x26, 0xda, 0xfe, 0x7e, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0, 0xfd, 0x53, 0x98, 0x7e,
+	0x2a, 0x04, 0x68, 0xad, 0xad, 0xad, 0xad, 0x56, 0x01, 0xa4, 0xfe, 0x5c, 0x00, 0x02, 0x00, 0x1e,
+	0x00, 0x00, 0x05, 0x49, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21,
+	0x03, 0x01, 0x21, 0x03, 0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49, 0xfd, 0xae, 0xeb, 0x01,
+	0x47, 0x01, 0xdc, 0x6f, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a,
+	0x00, 0x03, 0x00, 0xb0, 0x00, 0x00, 0x05, 0xa8, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x1f,
+	0x00, 0x61, 0xb5, 0x07, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x28, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40,
+	0x1c, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07,
+	0x08, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x16, 0x07, 0x02, 0x05, 0x04, 0x03, 0x06, 0x07, 0x06,
+	0x06, 0x23, 0x25, 0x33, 0x20, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x20, 0x13, 0x36,
+	0x26, 0x23, 0x23, 0xb0, 0x01, 0x27, 0x01, 0xda, 0x01, 0x24, 0xd3, 0x25, 0x36, 0xfe, 0xa4, 0x01,
+	0x6d, 0x3a, 0x1d, 0x64, 0x50, 0xc4, 0xd1, 0xfe, 0xe3, 0x9b, 0x01, 0x28, 0xc8, 0x1c, 0x1f, 0xce,
+	0xe1, 0xab, 0x1a, 0xb3, 0x01, 0x92, 0x38, 0x19, 0x8e, 0xe3, 0xc2, 0x05, 0xc8, 0x97, 0xb8, 0xfe,
+	0xf2, 0x68, 0x6a, 0xfe, 0xda, 0x8f, 0x61, 0x4e, 0x35, 0x9d, 0x57, 0x8c, 0x98, 0xa1, 0x85, 0x01,
+	0x19, 0x7c, 0x58, 0x00, 0x00, 0x01, 0x00, 0xbf, 0x00, 0x00, 0x05, 0x68, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x39, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x00, 0x00,
+	0x01, 0x02, 0x00, 0x01, 0x67, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x08, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21,
+	0x03, 0x07, 0x03, 0xbf, 0x01, 0x27, 0x03, 0x82, 0x1f, 0xfd, 0x50, 0x63, 0x1f, 0x86, 0x05, 0xc8,
+	0x9d, 0xfe, 0x10, 0x9b, 0xfd, 0x60, 0x00, 0x00, 0x00, 0x02, 0x00, 0x2f, 0x00, 0x00, 0x05, 0x63,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x08, 0x00, 0x43, 0xb5, 0x08, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x60,
+	0x03, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x60, 0x03, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x08, 0x17, 0x2b, 0x33, 0x37, 0x01, 0x33, 0x01,
+	0x07, 0x25, 0x21, 0x03, 0x2f, 0x24, 0x03, 0x24, 0xd0, 0x01, 0x1c, 0x24, 0xfb, 0xc8, 0x03, 0x7a,
+	0xe7, 0xb9, 0x05, 0x0f, 0xfa, 0xf1, 0xb9, 0xb9, 0x04, 0x28, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc9,
+	0x00, 0x00, 0x06, 0x21, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b,
+	0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0xc9, 0x01, 0x27, 0x04,
+	0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0x05, 0xc8,
+	0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x00, 0x00, 0x01, 0x00, 0x70, 0x00, 0x00, 0x05, 0xae,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x44, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x37, 0x01, 0x21, 0x37, 0x21,
+	0x07, 0x01, 0x21, 0x07, 0x70, 0x21, 0x04, 0x02, 0xfd, 0x16, 0x1f, 0x03, 0xe6, 0x1f, 0xfb, 0xfe,
+	0x03, 0x1b, 0x21, 0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x00, 0x00, 0x01, 0x00, 0xb0,
+	0x00, 0x00, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x06,
+	0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85,
+	0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08,
+	0x1b, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x21, 0x13, 0x33, 0x01, 0x23, 0x13, 0x21, 0x03, 0xb0, 0x01,
+	0x27, 0xd2, 0x7c, 0x02, 0xd9, 0x7c, 0xd1, 0xfe, 0xd9, 0xd1, 0x8b, 0xfd, 0x27, 0x8b, 0x05, 0xc8,
+	0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb, 0xfd, 0x45, 0x00, 0x00, 0x00, 0x03, 0x00, 0xb5,
+	0xff, 0xdb, 0x06, 0xc2, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x67, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x01, 0x00, 0x03, 0x04, 0x01, 0x03, 0x69,
+	0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02,
+	0x00, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x13, 0x37, 0x21,
+	0x07, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46, 0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01,
+	0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9, 0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe,
+	0xc3, 0x3b, 0x3a, 0xb9, 0x3f, 0x20, 0x02, 0x2c, 0x20, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63,
+	0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01,
+	0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x02, 0x35, 0xa0, 0xa0, 0x00,
+	0x00, 0x01, 0x00, 0x87, 0x00, 0x00, 0x03, 0xe7, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x16,
+	0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4,
+	0x1f, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xca,
+	0x00, 0x00, 0x05, 0xf0, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a,
+	0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33, 0x01,
+	0x01, 0x21, 0x01, 0x03, 0xca, 0x01, 0x27, 0xc5, 0x91, 0x02, 0xf8, 0xd3, 0xfd, 0x1f, 0x02, 0x21,
+	0xfe, 0xf6, 0xfd, 0xfe, 0x95, 0x05, 0xc8, 0xfd, 0x28, 0x02, 0xd8, 0xfd, 0x3e, 0xfc, 0xfa, 0x02,
+	0xee, 0xfd, 0x12, 0x00, 0x00, 0x01, 0x00, 0x20, 0x00, 0x00, 0x05, 0x4a, 0x05, 0xc8, 0x00, 0x06,
+	0x00, 0x2b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x02, 0x01,
+	0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00,
+	0x00, 0x2c, 0x00, 0x4e, 0x59, 0xb5, 0x11, 0x11, 0x11, 0x03, 0x08, 0x19, 0x2b, 0x01, 0x01, 0x23,
+	0x01, 0x33, 0x01, 0x23, 0x03, 0x96, 0xfd, 0x4d, 0xc3, 0x03, 0x58, 0xd0, 0x01, 0x02, 0xe2, 0x04,
+	0xb0, 0xfb, 0x50, 0x05, 0xc8, 0xfa, 0x38, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x07, 0x37,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4d, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01,
+	0x00, 0x00, 0x28, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x13, 0x01,
+	0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x02, 0x03, 0x85, 0x05, 0x04, 0x02, 0x02, 0x02, 0x2c,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06,
+	0x08, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x23, 0x13, 0x01, 0x23, 0x03, 0x03,
+	0xb0, 0x01, 0x27, 0x01, 0x23, 0xb2, 0x02, 0x87, 0x01, 0x04, 0xfe, 0xd9, 0xc4, 0xf0, 0xfd, 0x8f,
+	0xcb, 0xaa, 0xf1, 0x05, 0xc8, 0xfb, 0x87, 0x04, 0x79, 0xfa, 0x38, 0x04, 0xb3, 0xfb, 0xb0, 0x04,
+	0x54, 0xfb, 0x49, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x09,
+	0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b,
+	0x40, 0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b,
+	0x33, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x23, 0x01, 0x03, 0xb0, 0x01, 0x27, 0xcd, 0x02, 0x17,
+	0xe4, 0xb4, 0xfe, 0xd9, 0xce, 0xfd, 0xea, 0xe4, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38,
+	0x04, 0x77, 0xfb, 0x89, 0x00, 0x03, 0x00, 0x5b, 0x00, 0x00, 0x05, 0xde, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x66, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x07,
+	0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x08, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x28,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x1e,
+	0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40,
+	0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0x33, 0x37, 0x21, 0x07,
+	0x01, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x5b, 0x26, 0x04, 0x93, 0x26, 0xfc, 0x8d, 0x27,
+	0x03, 0x5f, 0x27, 0xfc, 0xba, 0x26, 0x04, 0x24, 0x26, 0xbf, 0xbf, 0x02, 0xa3, 0xc0, 0xc0, 0x02,
+	0x66, 0xbf, 0xbf, 0x00, 0x00, 0x02, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc2, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x2e, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x2f,
+	0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00,
+	0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46,
+	0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9,
+	0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0x25, 0x01, 0xaa, 0x01,
+	0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45,
+	0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x04,
+	0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x02, 0x67, 0x04, 0x03, 0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x23, 0x01,
+	0x21, 0x01, 0xb0, 0x01, 0x27, 0x04, 0x7c, 0xfe, 0xd9, 0xd1, 0x01, 0x03, 0xfd, 0x27, 0xfe, 0xfd,
+	0x05, 0xc8, 0xfa, 0x38, 0x05, 0x13, 0xfa, 0xed, 0x00, 0x02, 0x00, 0xb2, 0x00, 0x00, 0x06, 0x03,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03,
+	0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x2c,
+	0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25,
+	0x21, 0x06, 0x08, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x32, 0x16, 0x17, 0x16, 0x07, 0x02, 0x21, 0x21,
+	0x03, 0x13, 0x21, 0x20, 0x13, 0x36, 0x26, 0x23, 0x21, 0xb2, 0x01, 0x27, 0x02, 0x1c, 0xe4, 0xbd,
+	0x31, 0x3c, 0x22, 0x67, 0xfd, 0x87, 0xfe, 0xf4, 0x71, 0x91, 0x01, 0x03, 0x01, 0xa4, 0x44, 0x1e,
+	0x98, 0xf2, 0xfe, 0xf8, 0x05, 0xc8, 0x34, 0x4d, 0x60, 0xad, 0xfd, 0xfe, 0xfd, 0xc8, 0x02, 0xd7,
+	0x01, 0x54, 0x99, 0x67, 0x00, 0x01, 0x00, 0x7b, 0x00, 0x00, 0x05, 0x98, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x4c, 0xb6, 0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x37,
+	0x01, 0x01, 0x37, 0x21, 0x07, 0x21, 0x01, 0x01, 0x21, 0x07, 0x7b, 0x25, 0x02, 0x95, 0xfe, 0x66,
+	0x1f, 0x03, 0xde, 0x1f, 0xfd, 0x2c, 0x01, 0x86, 0xfd, 0x4c, 0x03, 0x3d, 0x25, 0xbc, 0x02, 0x3e,
+	0x02, 0x31, 0x9d, 0x9d, 0xfd, 0xea, 0xfd, 0xa7, 0xbc, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x27,
+	0x00, 0x00, 0x06, 0x00, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04,
+	0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x01, 0x21, 0x37, 0x21, 0x07, 0x21, 0x01, 0x02, 0x13,
+	0x01, 0x08, 0xfe, 0x0c, 0x1f, 0x04, 0xba, 0x1f, 0xfe, 0x0c, 0xfe, 0xf8, 0x05, 0x2b, 0x9d, 0x9d,
+	0xfa, 0xd5, 0x00, 0x00, 0x00, 0x01, 0x01, 0x49, 0x00, 0x00, 0x06, 0x4f, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x45, 0x40, 0x0a, 0x09, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x0c, 0x01, 0x01, 0x4a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x03,
+	0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x69, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x11, 0x00,
+	0x11, 0x21, 0x13, 0x04, 0x08, 0x18, 0x2b, 0x21, 0x13, 0x12, 0x02, 0x23, 0x37, 0x33, 0x32, 0x12,
+	0x13, 0x36, 0x00, 0x37, 0x07, 0x06, 0x00, 0x03, 0x03, 0x02, 0x44, 0x5f, 0x47, 0xc8, 0xd9, 0x22,
+	0x0f, 0xf4, 0xfc, 0x09, 0x8c, 0x01, 0x8f, 0xc1, 0x1d, 0xf5, 0xfe, 0x68, 0x3c, 0x5f, 0x01, 0xdf,
+	0x01, 0x60, 0x01, 0xdd, 0xac, 0xfe, 0xd5, 0xfe, 0xd6, 0xf4, 0x01, 0x45, 0x1c, 0x94, 0x42, 0xfe,
+	0x16, 0xfe, 0xd7, 0xfe, 0x21, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x1d, 0x00, 0x00, 0x07, 0x26,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x18, 0x00, 0x1f, 0x00, 0x6a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x21, 0x03, 0x01, 0x01, 0x09, 0x01, 0x06, 0x07, 0x01, 0x06, 0x6a, 0x08, 0x0b, 0x02, 0x07, 0x04,
+	0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x29,
+	0x05, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x09, 0x01, 0x06,
+	0x07, 0x01, 0x06, 0x6a, 0x08, 0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x0a,
+	0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x1a, 0x12, 0x12, 0x00, 0x00, 0x1f, 0x1e, 0x1a,
+	0x19, 0x12, 0x18, 0x12, 0x18, 0x14, 0x13, 0x00, 0x11, 0x00, 0x11, 0x14, 0x11, 0x11, 0x14, 0x11,
+	0x0c, 0x08, 0x1b, 0x2b, 0x21, 0x37, 0x20, 0x00, 0x37, 0x36, 0x00, 0x21, 0x37, 0x33, 0x07, 0x20,
+	0x00, 0x07, 0x06, 0x00, 0x21, 0x07, 0x03, 0x13, 0x22, 0x06, 0x07, 0x06, 0x16, 0x21, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x03, 0x31, 0x2c, 0xfe, 0xe0, 0xfe, 0xe0, 0x2e, 0x2f, 0x01, 0x92, 0x01,
+	0x20, 0x2c, 0xb9, 0x2c, 0x01, 0x21, 0x01, 0x20, 0x2f, 0x2e, 0xfe, 0x6e, 0xfe, 0xdf, 0x2c, 0x6f,
+	0x92, 0xc4, 0xf5, 0x23, 0x22, 0xa8, 0x01, 0x7d, 0xc5, 0xf5, 0x22, 0x23, 0xa8, 0xc5, 0xde, 0x01,
+	0x1f, 0xe7, 0xe8, 0x01, 0x1e, 0xde, 0xde, 0xfe, 0xe2, 0xe8, 0xe7, 0xfe, 0xe1, 0xde, 0x01, 0x77,
+	0x02, 0xda, 0xbf, 0xae, 0xae, 0xbf, 0xbf, 0xae, 0xae, 0xbf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x27,
+	0x00, 0x00, 0x06, 0x61, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01,
+	0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00,
+	0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x33, 0x01,
+	0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x01, 0x27, 0x02, 0xb3, 0xfe, 0x8c, 0xf8, 0x01, 0x1e, 0x02,
+	0x1e, 0xc7, 0xfd, 0x61, 0x01, 0x83, 0xf8, 0xfe, 0xd3, 0xfd, 0xcd, 0x02, 0xdf, 0x02, 0xe9, 0xfd,
+	0xc1, 0x02, 0x3f, 0xfd, 0x3a, 0xfc, 0xfe, 0x02, 0x56, 0xfd, 0xaa, 0x00, 0x00, 0x01, 0x01, 0x91,
+	0x00, 0x00, 0x07, 0x60, 0x05, 0xc8, 0x00, 0x2b, 0x00, 0x57, 0xb5, 0x01, 0x01, 0x07, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x06, 0x01, 0x00, 0x00, 0x01, 0x61, 0x05, 0x03,
+	0x02, 0x01, 0x01, 0x28, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x29,
+	0x07, 0x4e, 0x1b, 0x40, 0x18, 0x05, 0x03, 0x02, 0x01, 0x06, 0x01, 0x00, 0x02, 0x01, 0x00, 0x69,
+	0x04, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x2c, 0x07, 0x4e, 0x59, 0x40, 0x10,
+	0x00, 0x00, 0x00, 0x2b, 0x00, 0x2b, 0x22, 0x15, 0x31, 0x13, 0x15, 0x22, 0x17, 0x09, 0x08, 0x1d,
+	0x2b, 0x21, 0x13, 0x26, 0x26, 0x37, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x32, 0x16, 0x07,
+	0x07, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x16, 0x33, 0x32, 0x36, 0x37, 0x37, 0x36,
+	0x36, 0x33, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x03, 0x02, 0xfe, 0x7c,
+	0xb9, 0x9e, 0x08, 0x05, 0x05, 0x34, 0x62, 0x0e, 0x1f, 0x11, 0xaf, 0x77, 0x03, 0x03, 0x03, 0x48,
+	0x62, 0x05, 0x0d, 0x8b, 0xc6, 0x8b, 0x0a, 0x06, 0x62, 0x7a, 0x3e, 0x2c, 0x43, 0xa7, 0xaf, 0x11,
+	0x1f, 0x0e, 0x63, 0x4e, 0x2e, 0x2d, 0x44, 0xe4, 0xbf, 0x7c, 0x02, 0x6f, 0x0e, 0xb2, 0xbd, 0x7e,
+	0x7f, 0x45, 0x9a, 0x79, 0xb1, 0x73, 0xa3, 0x7c, 0x01, 0x02, 0xbb, 0xfd, 0x45, 0x01, 0x7b, 0xa4,
+	0x73, 0xb1, 0x79, 0x9a, 0x45, 0x7f, 0x7e, 0xbd, 0xb2, 0x0e, 0xfd, 0x91, 0x00, 0x01, 0x00, 0x50,
+	0x00, 0x00, 0x06, 0x46, 0x05, 0xed, 0x00, 0x1b, 0x00, 0x50, 0xb4, 0x1a, 0x01, 0x00, 0x01, 0x4b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e,
+	0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x1b, 0x00,
+	0x1b, 0x25, 0x11, 0x14, 0x24, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x21, 0x00, 0x13, 0x12,
+	0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x01, 0x21, 0x07, 0x21, 0x37, 0x24, 0x13, 0x36, 0x02, 0x23,
+	0x22, 0x00, 0x03, 0x02, 0x05, 0x07, 0x50, 0x1e, 0x01, 0x52, 0xfe, 0xe4, 0x52, 0x3c, 0x01, 0xba,
+	0x01, 0x1d, 0x01, 0x1d, 0x01, 0x20, 0x3c, 0x52, 0xfe, 0x78, 0x01, 0x52, 0x1e, 0xfd, 0xef, 0x1e,
+	0x01, 0x61, 0x57, 0x33, 0xb0, 0xc2, 0xc1, 0xfe, 0xdb, 0x33, 0x57, 0x01, 0x05, 0x1e, 0x9a, 0x01,
+	0x0e, 0x01, 0x98, 0x01, 0x2c, 0x01, 0x81, 0xfe, 0x80, 0xfe, 0xd3, 0xfe, 0x67, 0xfe, 0xf3, 0x9a,
+	0x9a, 0xe5, 0x01, 0xb3, 0xff, 0x01, 0x22, 0xfe, 0xde, 0xff, 0x00, 0xfe, 0x4f, 0xe6, 0x9a, 0x00,
+	0x00, 0x03, 0x00, 0x87, 0x00, 0x00, 0x04, 0x2d, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13,
+	0x00, 0x72, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03,
+	0x07, 0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x22,
+	0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x2c,
+	0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12,
+	0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0d, 0x08, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07,
+	0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x87, 0x1f, 0xbe, 0xe9, 0xbe, 0x1f, 0x02, 0x4d,
+	0x1f, 0xbe, 0xe9, 0xbe, 0x1f, 0xfe, 0xe9, 0x23, 0xad, 0x23, 0xf3, 0x23, 0xad, 0x23, 0x9d, 0x04,
+	0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x03, 0x01, 0x49,
+	0x00, 0x00, 0x06, 0x4f, 0x07, 0x0f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x19, 0x00, 0x6f, 0x40, 0x0b,
+	0x11, 0x01, 0x06, 0x04, 0x01, 0x4c, 0x14, 0x01, 0x05, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x1d, 0x02, 0x01, 0x00, 0x08, 0x03, 0x07, 0x03, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x61, 0x00, 0x05, 0x05, 0x28, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x29, 0x06, 0x4e, 0x1b,
+	0x40, 0x1b, 0x02, 0x01, 0x00, 0x08, 0x03, 0x07, 0x03, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x05,
+	0x00, 0x04, 0x06, 0x05, 0x04, 0x69, 0x09, 0x01, 0x06, 0x06, 0x2c, 0x06, 0x4e, 0x59, 0x40, 0x1c,
+	0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x19, 0x08, 0x19, 0x0f, 0x0d, 0x0c, 0x0b, 0x04, 0x07,
+	0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x08, 0x17, 0x2b, 0x01, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x13, 0x12, 0x02, 0x23, 0x37, 0x33, 0x32, 0x12, 0x13, 0x36,
+	0x00, 0x37, 0x07, 0x06, 0x00, 0x03, 0x03, 0x02, 0xfe, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23,
+	0xfd, 0x0e, 0x5f, 0x47, 0xc8, 0xd9, 0x22, 0x0f, 0xf4, 0xfc, 0x09, 0x8c, 0x01, 0x8f, 0xc1, 0x1d,
+	0xf5, 0xfe, 0x68, 0x3c, 0x5f, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0xf9, 0x9e, 0x01, 0xdf, 0x01,
+	0x60, 0x01, 0xdd, 0xac, 0xfe, 0xd5, 0xfe, 0xd6, 0xf4, 0x01, 0x45, 0x1c, 0x94, 0x42, 0xfe, 0x16,
+	0xfe, 0xd7, 0xfe, 0x21, 0x00, 0x03, 0x00, 0xac, 0xff, 0xe7, 0x05, 0x39, 0x06, 0xa6, 0x00, 0x03,
+	0x00, 0x30, 0x00, 0x4b, 0x00, 0xac, 0xb7, 0x4b, 0x18, 0x0f, 0x03, 0x07, 0x06, 0x01, 0x4c, 0x4b,
+	0xb0, 0x14, 0x50, 0x58, 0x40, 0x22, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x02, 0x01,
+	0x85, 0x00, 0x06, 0x06, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x2b, 0x4d, 0x00, 0x07, 0x07, 0x03,
+	0x61, 0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x05, 0x01, 0x85, 0x00, 0x02, 0x02, 0x2b, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x29, 0x4d, 0x00,
+	0x07, 0x07, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x04, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x00, 0x01,
+	0x00, 0x85, 0x08, 0x01, 0x01, 0x05, 0x01, 0x85, 0x00, 0x02, 0x02, 0x2b, 0x4d, 0x00, 0x06, 0x06,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x2c, 0x4d, 0x00, 0x07, 0x07, 0x04,
+	0x61, 0x00, 0x04, 0x04, 0x32, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00, 0x45, 0x43, 0x37,
+	0x35, 0x2c, 0x2a, 0x1e, 0x1c, 0x13, 0x12, 0x0a, 0x09, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08,
+	0x17, 0x2b, 0x01, 0x01, 0x33, 0x01, 0x13, 0x3e, 0x03, 0x37, 0x33, 0x0e, 0x03, 0x07, 0x16, 0x12,
+	0x17, 0x23, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x03, 0x36, 0x37, 0x3e, 0x05, 0x33,
+	0x32, 0x1e, 0x02, 0x17, 0x07, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x04, 0x07, 0x0e, 0x02, 0x1e, 0x02,
+	0x33, 0x32, 0x3e, 0x04, 0x37, 0x02, 0xb5, 0x01, 0x25, 0xdb, 0xfe, 0x7d, 0xa9, 0x17, 0x32, 0x2e,
+	0x24, 0x0b, 0xb8, 0x13, 0x43, 0x54, 0x5f, 0x30, 0x1c, 0x3b, 0x23, 0xce, 0x0a, 0x0f, 0x10, 0x11,
+	0x0a, 0x29, 0x59, 0x67, 0x7d, 0x4d, 0x47, 0x60, 0x3e, 0x1d, 0x07, 0x0c, 0x0c, 0x0e, 0x2a, 0x3b,
+	0x4f, 0x65, 0x7d, 0x4d, 0x49, 0x5b, 0x3a, 0x23, 0x13, 0xac, 0x10, 0x15, 0x1a, 0x28, 0x24, 0x25,
+	0x3c, 0x32, 0x27, 0x1f, 0x15, 0x08, 0x06, 0x0e, 0x07, 0x03, 0x17, 0x2e, 0x26, 0x22, 0x44, 0x3f,
+	0x3c, 0x36, 0x2f, 0x13, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfd, 0xc3, 0x26, 0x60, 0x66, 0x63,
+	0x29, 0x3c, 0x89, 0x90, 0x91, 0x44, 0x96, 0xfe, 0xfb, 0x79, 0x19, 0x48, 0x55, 0x5d, 0x2d, 0x3f,
+	0x7b, 0x62, 0x3d, 0x2e, 0x4d, 0x68, 0x74, 0x7a, 0x39, 0x46, 0x92, 0x89, 0x78, 0x59, 0x34, 0x24,
+	0x4c, 0x77, 0x54, 0x67, 0x4a, 0x67, 0x40, 0x1c, 0x2b, 0x48, 0x5b, 0x60, 0x5e, 0x25, 0x21, 0x55,
+	0x5a, 0x57, 0x45, 0x2a, 0x27, 0x3f, 0x50, 0x54, 0x4f, 0x1e, 0x00, 0x00, 0x00, 0x02, 0x00, 0x78,
+	0xff, 0xe7, 0x04, 0x94, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x23, 0x00, 0x4f, 0x40, 0x4c, 0x12, 0x01,
+	0x04, 0x03, 0x13, 0x01, 0x05, 0x04, 0x0c, 0x01, 0x06, 0x05, 0x03, 0x4c, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00,
+	0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x32, 0x02, 0x4e, 0x00, 0x00, 0x23, 0x21, 0x1d, 0x1b, 0x1a, 0x18, 0x16, 0x14, 0x11, 0x0f,
+	0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0x01, 0x01, 0x33, 0x01, 0x13,
+	0x07, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26,
+	0x23, 0x22, 0x07, 0x06, 0x21, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x02,
+	0x94, 0x01, 0x25, 0xdb, 0xfe, 0x7d, 0x52, 0x20, 0xad, 0x90, 0xbf, 0xcf, 0x1d, 0x29, 0x01, 0x0a,
+	0xc3, 0x22, 0x34, 0x01, 0x86, 0x9a, 0x73, 0x1d, 0x77, 0x79, 0xe4, 0x1a, 0x23, 0x01, 0x6a, 0x27,
+	0x1f, 0x8c, 0x7d, 0xb0, 0x12, 0x10, 0x78, 0x71, 0x7b, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfb,
+	0xbe, 0x9f, 0x3b, 0xb8, 0x91, 0xcd, 0x5f, 0x48, 0xab, 0x01, 0x07, 0x23, 0x94, 0x23, 0x82, 0xaf,
+	0x9a, 0x6e, 0x58, 0x51, 0x65, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa4, 0xfe, 0x75, 0x04, 0xc6,
+	0x06, 0xa6, 0x00, 0x14, 0x00, 0x18, 0x00, 0xa1, 0xb5, 0x06, 0x01, 0x04, 0x03, 0x01, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x07, 0x01, 0x04, 0x04,
+	0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26,
+	0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x2b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d,
+	0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01,
+	0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x31, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x15, 0x15, 0x15, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x00, 0x14, 0x00,
+	0x14, 0x23, 0x13, 0x23, 0x13, 0x09, 0x08, 0x1a, 0x2b, 0x33, 0x13, 0x36, 0x27, 0x33, 0x16, 0x07,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x01,
+	0x33, 0x01, 0xa4, 0x96, 0x24, 0x23, 0xdc, 0x09, 0x08, 0xc4, 0xd0, 0x86, 0x73, 0x28, 0xdc, 0xc5,
+	0xd7, 0x18, 0x36, 0x59, 0x8d, 0xb9, 0x8d, 0x01, 0x5d, 0x01, 0x25, 0xdb, 0xfe, 0x7d, 0x02, 0xf1,
+	0xb6, 0x97, 0x58, 0x76, 0xe6, 0xc9, 0xc8, 0xfb, 0xb0, 0x04, 0x38, 0x78, 0x78, 0xd8, 0xfd, 0x3b,
+	0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0xf0, 0xff, 0xe7, 0x03, 0xc4,
+	0x06, 0xa6, 0x00, 0x0d, 0x00, 0x11, 0x00, 0x31, 0x40, 0x2e, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x4c,
+	0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x0e, 0x0e, 0x0e, 0x11, 0x0e,
+	0x11, 0x13, 0x23, 0x13, 0x21, 0x06, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x37, 0x13,
+	0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x01, 0x33, 0x01, 0x02, 0xcd, 0x64, 0x65, 0xa8,
+	0x6c, 0x2c, 0x8d, 0xc5, 0x89, 0x1f, 0x2e, 0x56, 0x49, 0x57, 0xfe, 0xda, 0x01, 0x25, 0xdb, 0xfe,
+	0x7d, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0, 0xfd, 0x53, 0x98, 0x7e, 0x2a, 0x04, 0x5e, 0x01, 0xa3,
+	0xfe, 0x5d, 0x00, 0x00, 0x00, 0x04, 0x00, 0xda, 0xff, 0xe7, 0x04, 0xab, 0x07, 0x07, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x84, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2d, 0x00,
+	0x08, 0x04, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x05, 0x04, 0x09, 0x05, 0x80, 0x0b, 0x07, 0x0a,
+	0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x2b,
+	0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00,
+	0x08, 0x04, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x05, 0x04, 0x09, 0x05, 0x80, 0x06, 0x01, 0x04,
+	0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1e, 0x1e, 0x1a,
+	0x1a, 0x16, 0x16, 0x1e, 0x21, 0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16,
+	0x19, 0x16, 0x19, 0x16, 0x24, 0x14, 0x23, 0x10, 0x0d, 0x08, 0x1b, 0x2b, 0x01, 0x33, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03, 0x33, 0x12, 0x03, 0x06, 0x00, 0x23, 0x22, 0x27, 0x26,
+	0x26, 0x37, 0x13, 0x37, 0x33, 0x07, 0x21, 0x37, 0x33, 0x07, 0x25, 0x01, 0x33, 0x01, 0x01, 0x72,
+	0xc5, 0x6d, 0x31, 0x42, 0x87, 0x6e, 0xc4, 0x24, 0x3e, 0x63, 0xd6, 0x40, 0x3a, 0x32, 0xfe, 0xb8,
+	0xc1, 0xbb, 0x5a, 0x37, 0x0e, 0x23, 0xa2, 0x22, 0xac, 0x22, 0x01, 0x7f, 0x22, 0xad, 0x22, 0xfe,
+	0x34, 0x01, 0x26, 0xda, 0xfe, 0x7e, 0x04, 0x3e, 0xfd, 0xe1, 0xf6, 0xae, 0xc8, 0xb3, 0x01, 0x39,
+	0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2, 0x6b, 0x41, 0xb3, 0xaf, 0x03, 0x18, 0xad,
+	0xad, 0xad, 0xad, 0x56, 0x01, 0xa4, 0xfe, 0x5c, 0x00, 0x02, 0x00, 0xac, 0xff, 0xe7, 0x05, 0x39,
+	0x04, 0x57, 0x00, 0x2c, 0x00, 0x47, 0x00, 0x7e, 0xb7, 0x47, 0x14, 0x0b, 0x03, 0x05, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x17, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x04, 0x04, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x04,
+	0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x01, 0x01, 0x2c, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x2c, 0x29, 0x2c, 0x29,
+	0x18, 0x15, 0x06, 0x08, 0x1c, 0x2b, 0x01, 0x3e, 0x03, 0x37, 0x33, 0x0e, 0x03, 0x07, 0x16, 0x12,
+	0x17, 0x23, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x03, 0x36, 0x37, 0x3e, 0x05, 0x33,
+	0x32, 0x1e, 0x02, 0x17, 0x07, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x04, 0x07, 0x0e, 0x02, 0x1e, 0x02,
+	0x33, 0x32, 0x3e, 0x04, 0x37, 0x03, 0xd6, 0x18, 0x34, 0x2e, 0x26, 0x0b, 0xb8, 0x14, 0x42, 0x54,
+	0x62, 0x34, 0x1c, 0x3a, 0x24, 0xce, 0x05, 0x0d, 0x10, 0x12, 0x09, 0x29, 0x59, 0x67, 0x7d, 0x4d,
+	0x46, 0x61, 0x3e, 0x1d, 0x07, 0x0c, 0x0c, 0x0e, 0x2a, 0x3b, 0x4f, 0x65, 0x7d, 0x4d, 0x44, 0x58,
+	0x39, 0x25, 0x13, 0xa5, 0x10, 0x15, 0x1a, 0x28, 0x24, 0x25, 0x3c, 0x32, 0x27, 0x1f, 0x15, 0x08,
+	0x06, 0x0e, 0x07, 0x03, 0x17, 0x2e, 0x26, 0x22, 0x44, 0x3f, 0x3c, 0x36, 0x2f, 0x13, 0x02, 0xbf,
+	0x28, 0x62, 0x66, 0x65, 0x2a, 0x3c, 0x89, 0x91, 0x95, 0x48, 0x8f, 0xfe, 0xfd, 0x79, 0x19, 0x48,
+	0x55, 0x5d, 0x2d, 0x3f, 0x7b, 0x62, 0x3d, 0x2e, 0x4d, 0x68, 0x74, 0x7a, 0x39, 0x46, 0x92, 0x89,
+	0x78, 0x59, 0x34, 0x23, 0x4c, 0x78, 0x54, 0x67, 0x4a, 0x67, 0x40, 0x1c, 0x2b, 0x48, 0x5b, 0x60,
+	0x5e, 0x25, 0x21, 0x55, 0x5a, 0x57, 0x45, 0x2a, 0x27, 0x3f, 0x50, 0x54, 0x4f, 0x1e, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xfe, 0x75, 0x04, 0xfc, 0x06, 0x44, 0x00, 0x13, 0x00, 0x28, 0x00, 0x47,
+	0x40, 0x44, 0x0a, 0x01, 0x06, 0x03, 0x1f, 0x01, 0x05, 0x06, 0x12, 0x01, 0x01, 0x05, 0x03, 0x4c,
+	0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x69, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x30, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x32, 0x4d, 0x07, 0x01, 0x02, 0x02,
+	0x2d, 0x02, 0x4e, 0x00, 0x00, 0x28, 0x26, 0x22, 0x20, 0x1c, 0x1a, 0x16, 0x14, 0x00, 0x13, 0x00,
+	0x13, 0x2a, 0x23, 0x08, 0x08, 0x18, 0x2b, 0x13, 0x01, 0x12, 0x00, 0x33, 0x32, 0x16, 0x07, 0x06,
+	0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x22, 0x27, 0x03, 0x01, 0x33, 0x32, 0x36, 0x37,
+	0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x03, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23,
+	0x56, 0x01, 0x21, 0x34, 0x01, 0x24, 0xd1, 0xac, 0xb0, 0x1d, 0x18, 0xb0, 0x97, 0xa8, 0x9b, 0x1f,
+	0x28, 0xfe, 0xbb, 0xd7, 0x81, 0x72, 0x52, 0x01, 0x4f, 0x24, 0x81, 0xeb, 0x1b, 0x14, 0x51, 0x63,
+	0x75, 0x99, 0x25, 0xb3, 0x82, 0x6e, 0x79, 0xcc, 0x19, 0x1f, 0xc5, 0xc4, 0x27, 0xfe, 0x75, 0x05,
+	0xa9, 0x01, 0x04, 0x01, 0x22, 0xb4, 0x93, 0x76, 0xc7, 0x51, 0x39, 0xef, 0x9a, 0xc7, 0xff, 0x2a,
+	0xfe, 0x64, 0x05, 0x1c, 0xd1, 0x8b, 0x61, 0x62, 0xba, 0xb9, 0xfc, 0x7f, 0x41, 0xaf, 0x7b, 0x9c,
+	0xbc, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xf1, 0xfe, 0x75, 0x04, 0xd8, 0x04, 0x3e, 0x00, 0x23,
+	0x00, 0x1b, 0x40, 0x18, 0x0d, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d,
+	0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x1a, 0x17, 0x03, 0x08, 0x19, 0x2b, 0x25, 0x2e, 0x05,
+	0x27, 0x33, 0x1e, 0x03, 0x17, 0x3e, 0x03, 0x37, 0x33, 0x0e, 0x05, 0x07, 0x16, 0x07, 0x06, 0x07,
+	0x23, 0x27, 0x26, 0x37, 0x36, 0x01, 0xbd, 0x07, 0x11, 0x19, 0x20, 0x2a, 0x33, 0x1e, 0xe3, 0x1e,
+	0x2b, 0x1f, 0x16, 0x0a, 0x2e, 0x6a, 0x74, 0x7b, 0x40, 0xb5, 0x33, 0x6b, 0x6d, 0x6c, 0x68, 0x5f,
+	0x2b, 0x11, 0x19, 0x1a, 0x5e, 0x96, 0x05, 0x0f, 0x10, 0x11, 0x6a, 0x3b, 0xa2, 0xb6, 0xc0, 0xb2,
+	0x9a, 0x35, 0x5a, 0xb4, 0xb8, 0xc0, 0x65, 0x4e, 0xb8, 0xc4, 0xc6, 0x5b, 0x42, 0x9c, 0xa9, 0xb0,
+	0xaa, 0x9e, 0x43, 0x6a, 0x80, 0x80, 0x9d, 0x21, 0x85, 0x50, 0x54, 0x00, 0x00, 0x02, 0x00, 0xab,
+	0xff, 0xe7, 0x04, 0xbd, 0x06, 0x44, 0x00, 0x33, 0x00, 0x47, 0x00, 0x2c, 0x40, 0x29, 0x10, 0x01,
+	0x01, 0x00, 0x33, 0x11, 0x02, 0x03, 0x01, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x30, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x3f, 0x3d,
+	0x2c, 0x2a, 0x37, 0x39, 0x04, 0x08, 0x18, 0x2b, 0x01, 0x2e, 0x03, 0x37, 0x3e, 0x03, 0x33, 0x32,
+	0x1e, 0x02, 0x17, 0x17, 0x07, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x04, 0x07, 0x06, 0x1e, 0x02, 0x17,
+	0x17, 0x1e, 0x03, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x36, 0x12, 0x37, 0x17, 0x0e,
+	0x03, 0x07, 0x06, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x02, 0x70, 0x3e,
+	0x55, 0x30, 0x0e, 0x0a, 0x0d, 0x47, 0x79, 0xb0, 0x75, 0x18, 0x3c, 0x42, 0x46, 0x20, 0x26, 0x21,
+	0x4c, 0x9b, 0x49, 0x10, 0x2f, 0x36, 0x36, 0x2e, 0x21, 0x04, 0x06, 0x2e, 0x50, 0x66, 0x34, 0x32,
+	0x39, 0x62, 0x40, 0x14, 0x13, 0x15, 0x5c, 0x94, 0xcb, 0x85, 0x7e, 0xa5, 0x59, 0x12, 0x15, 0x25,
+	0xe9, 0xc9, 0x6d, 0x59, 0x7f, 0x57, 0x35, 0x0e, 0x0e, 0x06, 0x2e, 0x5c, 0x49, 0x4a, 0x76, 0x58,
+	0x39, 0x0e, 0x0f, 0x0f, 0x33, 0x52, 0x04, 0x06, 0x30, 0x4f, 0x4b, 0x4f, 0x31, 0x40, 0x5c, 0x3c,
+	0x1c, 0x03, 0x06, 0x08, 0x06, 0x06, 0xa4, 0x17, 0x16, 0x01, 0x06, 0x0b, 0x14, 0x20, 0x16, 0x1c,
+	0x43, 0x4a, 0x4f, 0x29, 0x27, 0x2e, 0x69, 0x7f, 0x99, 0x5f, 0x69, 0xc2, 0x94, 0x58, 0x4f, 0x88,
+	0xb9, 0x69, 0xb6, 0x01, 0x05, 0x4d, 0x53, 0x23, 0x5d, 0x6f, 0x81, 0x48, 0x44, 0x7f, 0x63, 0x3c,
+	0x45, 0x6f, 0x88, 0x44, 0x4b, 0x77, 0x62, 0x51, 0x00, 0x01, 0x00, 0x78, 0xff, 0xe7, 0x03, 0xee,
+	0x04, 0x56, 0x00, 0x1f, 0x00, 0x37, 0x40, 0x34, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x03, 0x02,
+	0x08, 0x01, 0x04, 0x03, 0x03, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x24, 0x21, 0x22, 0x23, 0x27, 0x22, 0x06, 0x08, 0x1c, 0x2b, 0x25, 0x07, 0x06,
+	0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x07, 0x06, 0x21, 0x33, 0x07, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x03, 0x63, 0x20,
+	0xad, 0x90, 0xbf, 0xcf, 0x1d, 0x29, 0x01, 0x0a, 0xc3, 0x22, 0x34, 0x01, 0x86, 0x9a, 0x73, 0x1d,
+	0x77, 0x79, 0xe4, 0x1a, 0x23, 0x01, 0x6a, 0x27, 0x1f, 0x8c, 0x7d, 0xb0, 0x12, 0x10, 0x78, 0x71,
+	0x7b, 0xc1, 0x9f, 0x3b, 0xb8, 0x91, 0xcd, 0x5f, 0x48, 0xab, 0x01, 0x07, 0x23, 0x94, 0x23, 0x82,
+	0xaf, 0x9a, 0x6e, 0x58, 0x51, 0x65, 0x00, 0x00, 0x00, 0x01, 0x00, 0xb5, 0xfe, 0x5c, 0x04, 0xc4,
+	0x06, 0x31, 0x00, 0x3d, 0x00, 0x88, 0x40, 0x0f, 0x1a, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01,
+	0x02, 0x4c, 0x22, 0x1b, 0x02, 0x03, 0x4a, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x03,
+	0x02, 0x03, 0x85, 0x00, 0x02, 0x04, 0x02, 0x85, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x29, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x04, 0x02, 0x85, 0x00,
+	0x00, 0x00, 0x05, 0x00, 0x05, 0x65, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x01,
+	0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x04, 0x02, 0x85, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x05, 0x65, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2c, 0x01, 0x4e,
+	0x59, 0x59, 0x40, 0x09, 0x28, 0x3f, 0x19, 0x19, 0x34, 0x23, 0x06, 0x08, 0x1c, 0x2b, 0x01, 0x37,
+	0x16, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03,
+	0x37, 0x22, 0x2e, 0x02, 0x27, 0x37, 0x1e, 0x03, 0x33, 0x36, 0x37, 0x17, 0x06, 0x06, 0x07, 0x0e,
+	0x03, 0x07, 0x06, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26,
+	0x01, 0x11, 0x20, 0x3c, 0x60, 0x26, 0x58, 0x77, 0x10, 0x0b, 0x43, 0x42, 0x36, 0x77, 0x9b, 0x50,
+	0x0b, 0x19, 0x13, 0x59, 0x77, 0x8c, 0x49, 0x3d, 0x5f, 0x54, 0x50, 0x2f, 0x23, 0x33, 0x56, 0x63,
+	0x7d, 0x5a, 0x91, 0xfe, 0x38, 0x3e, 0xc8, 0x80, 0x56, 0x8a, 0x67, 0x44, 0x11, 0x14, 0x06, 0x35,
+	0x60, 0x48, 0x27, 0x3f, 0x5e, 0x3a, 0x15, 0x0b, 0x0b, 0x3c, 0x72, 0xae, 0x7f, 0x1e, 0x41, 0xfe,
+	0x66, 0xa1, 0x0b, 0x0c, 0x50, 0x4f, 0x36, 0x3b, 0x3f, 0x7d, 0xbc, 0x7e, 0x5f, 0xc8, 0xbd, 0xaa,
+	0x42, 0x07, 0x10, 0x1a, 0x12, 0xaf, 0x1e, 0x2d, 0x1e, 0x10, 0xa5, 0x4d, 0x68, 0x45, 0x7c, 0x27,
+	0x5b, 0xbe, 0xbd, 0xb7, 0x54, 0x61, 0x82, 0x4f, 0x21, 0x27, 0x45, 0x5e, 0x36, 0x38, 0x77, 0x63,
+	0x3f, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa4, 0xfe, 0x75, 0x04, 0xa5, 0x04, 0x56, 0x00, 0x14,
+	0x00, 0x78, 0xb5, 0x06, 0x01, 0x04, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x29,
+	0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00,
+	0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01,
+	0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x04, 0x04,
+	0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x14,
+	0x00, 0x14, 0x23, 0x13, 0x23, 0x13, 0x06, 0x08, 0x1a, 0x2b, 0x33, 0x13, 0x36, 0x27, 0x33, 0x16,
+	0x07, 0x36, 0x33, 0x32, 0x16, 0x07, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa4,
+	0x96, 0x24, 0x23, 0xdc, 0x09, 0x08, 0xc4, 0xd0, 0x86, 0x73, 0x28, 0xdc, 0xc5, 0xd7, 0x18, 0x36,
+	0x59, 0x8d, 0xb9, 0x8d, 0x02, 0xf1, 0xb6, 0x97, 0x58, 0x76, 0xe6, 0xc9, 0xc8, 0xfb, 0xb0, 0x04,
+	0x38, 0x78, 0x78, 0xd8, 0xfd, 0x3b, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0xff, 0xe7, 0x05, 0x14,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19, 0x00, 0x29, 0x40, 0x26, 0x00, 0x02, 0x00, 0x04,
+	0x05, 0x02, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x30, 0x4d, 0x00, 0x05,
+	0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x32, 0x01, 0x4e, 0x22, 0x12, 0x22, 0x12, 0x24, 0x22, 0x06,
+	0x08, 0x1c, 0x2b, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x23, 0x22, 0x02, 0x01,
+	0x21, 0x12, 0x02, 0x23, 0x22, 0x02, 0x01, 0x21, 0x02, 0x12, 0x33, 0x32, 0x12, 0xfe, 0x4f, 0x01,
+	0x52, 0xe6, 0xe5, 0xaa, 0x4f, 0x4f, 0xfe, 0xaf, 0xe5, 0xed, 0xa6, 0x01, 0x27, 0x02, 0x3d, 0x33,
+	0x61, 0x7d, 0x7c, 0xe3, 0x01, 0xee, 0xfd, 0xc3, 0x38, 0x5c, 0x7f, 0x7d, 0xe7, 0x03, 0x15, 0x01,
+	0x8b, 0x01, 0xa4, 0xfe, 0x5c, 0xfe, 0x76, 0xfe, 0x75, 0xfe, 0x5c, 0x01, 0x97, 0x01, 0xea, 0x01,
+	0x02, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0x72, 0xfe, 0xea, 0xfe, 0xb5, 0x01, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xf0, 0xff, 0xe7, 0x02, 0xea, 0x04, 0x3e, 0x00, 0x0d, 0x00, 0x1f, 0x40, 0x1c,
+	0x0d, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x23, 0x13, 0x21, 0x03, 0x08, 0x19, 0x2b, 0x25, 0x06, 0x23,
+	0x22, 0x26, 0x37, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x02, 0xcd, 0x64, 0x65, 0xa8,
+	0x6c, 0x2c, 0x8d, 0xc5, 0x89, 0x1f, 0x2e, 0x56, 0x49, 0x57, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0,
+	0xfd, 0x53, 0x98, 0x7e, 0x2a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xa6,
+	0x04, 0x3e, 0x00, 0x12, 0x00, 0x4a, 0xb7, 0x11, 0x0e, 0x03, 0x03, 0x03, 0x02, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x13, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b,
+	0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x02, 0x02, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59,
+	0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x14, 0x22, 0x14, 0x11, 0x06, 0x08, 0x1a, 0x2b,
+	0x33, 0x13, 0x33, 0x03, 0x37, 0x36, 0x36, 0x33, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x07, 0x01,
+	0x23, 0x01, 0x03, 0xa5, 0xd9, 0xc5, 0x6b, 0xf6, 0xb0, 0xb9, 0x6f, 0x24, 0x15, 0x0d, 0x33, 0x8f,
+	0x9d, 0x4d, 0x01, 0x4b, 0xee, 0xfe, 0xc7, 0x6e, 0x04, 0x3e, 0xfd, 0xec, 0xf4, 0xb0, 0x70, 0xb2,
+	0x03, 0x68, 0x97, 0x49, 0xfd, 0xb9, 0x02, 0x2a, 0xfd, 0xd6, 0x00, 0x00, 0x00, 0x01, 0x00, 0x23,
+	0x00, 0x00, 0x03, 0xf6, 0x06, 0x2b, 0x00, 0x1d, 0x00, 0x3d, 0xb5, 0x1b, 0x01, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x2a, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x2a, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0xb6, 0x17,
+	0x1a, 0x21, 0x25, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x27, 0x2e, 0x03, 0x23, 0x23, 0x37, 0x33, 0x32,
+	0x1e, 0x04, 0x17, 0x13, 0x16, 0x16, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x03, 0x01, 0x23, 0x02, 0x50,
+	0x25, 0x0a, 0x19, 0x29, 0x43, 0x34, 0x1d, 0x23, 0x25, 0x3d, 0x5b, 0x42, 0x2d, 0x22, 0x19, 0x0d,
+	0xa7, 0x17, 0x33, 0x23, 0xcc, 0x0e, 0x17, 0x15, 0x13, 0x0b, 0x4f, 0xfe, 0x5f, 0xbf, 0x03, 0xe5,
+	0xb9, 0x32, 0x53, 0x3b, 0x20, 0xad, 0x0a, 0x1a, 0x2d, 0x46, 0x62, 0x41, 0xfc, 0xc7, 0x74, 0xd3,
+	0x71, 0x2e, 0x53, 0x53, 0x59, 0x34, 0x01, 0x84, 0xfd, 0x1b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x56,
+	0xfe, 0x75, 0x04, 0xdf, 0x04, 0x3e, 0x00, 0x15, 0x00, 0x81, 0x40, 0x0a, 0x10, 0x01, 0x01, 0x00,
+	0x14, 0x01, 0x03, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x04, 0x01, 0x03, 0x03, 0x29, 0x4d, 0x06, 0x01,
+	0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x02, 0x01, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x29, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04,
+	0x32, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x03, 0x03, 0x2c, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x62, 0x00, 0x04, 0x04, 0x32,
+	0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x15,
+	0x00, 0x15, 0x23, 0x13, 0x12, 0x23, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x13, 0x01, 0x33, 0x03, 0x06,
+	0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x06, 0x17, 0x23, 0x26, 0x37, 0x06, 0x23, 0x22, 0x27,
+	0x03, 0x56, 0x01, 0x28, 0xc5, 0x90, 0x15, 0x46, 0x5b, 0x8e, 0xc0, 0x8d, 0xc5, 0x97, 0x25, 0x1e,
+	0xd8, 0x07, 0x07, 0xb9, 0x99, 0x66, 0x43, 0x53, 0xfe, 0x75, 0x05, 0xc9, 0xfd, 0x34, 0x69, 0x69,
+	0xde, 0x02, 0xc0, 0xfd, 0x0d, 0xb9, 0x92, 0x4b, 0x81, 0xe5, 0x32, 0xfe, 0x5c, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xe4, 0x00, 0x00, 0x04, 0xa7, 0x04, 0x3e, 0x00, 0x1a, 0x00, 0x3a, 0xb5, 0x0d,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x1a,
+	0x00, 0x1a, 0x1c, 0x15, 0x04, 0x08, 0x18, 0x2b, 0x21, 0x26, 0x0a, 0x02, 0x27, 0x33, 0x1e, 0x05,
+	0x17, 0x36, 0x12, 0x37, 0x36, 0x27, 0x33, 0x16, 0x07, 0x06, 0x03, 0x06, 0x02, 0x07, 0x01, 0x90,
+	0x0e, 0x1e, 0x27, 0x35, 0x24, 0xd0, 0x15, 0x23, 0x1b, 0x15, 0x0f, 0x09, 0x02, 0xba, 0xd2, 0x18,
+	0x12, 0x0c, 0xc3, 0x04, 0x0c, 0x18, 0xa8, 0x54, 0xc2, 0x6f, 0x92, 0x01, 0x11, 0x01, 0x09, 0x01,
+	0x08, 0x8a, 0x52, 0xad, 0xac, 0xa4, 0x92, 0x7b, 0x2b, 0xf8, 0x01, 0x7c, 0x7c, 0x59, 0x3e, 0x33,
+	0x3f, 0x79, 0xfe, 0xe9, 0x8d, 0xfe, 0xe4, 0x93, 0x00, 0x01, 0x00, 0x87, 0xfe, 0x5c, 0x04, 0x5c,
+	0x06, 0x46, 0x00, 0x55, 0x00, 0xb4, 0x40, 0x12, 0x2e, 0x28, 0x23, 0x22, 0x1d, 0x05, 0x03, 0x02,
+	0x15, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x2a, 0x00, 0x03, 0x02, 0x04, 0x02, 0x03, 0x04, 0x80, 0x00, 0x04, 0x00, 0x05, 0x06, 0x04, 0x05,
+	0x69, 0x00, 0x02, 0x02, 0x30, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x4d,
+	0x00, 0x00, 0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x2d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x03, 0x02, 0x04, 0x02, 0x03, 0x04, 0x80, 0x00, 0x04, 0x00, 0x05, 0x06,
+	0x04, 0x05, 0x69, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x65, 0x00, 0x02, 0x02, 0x30, 0x4d, 0x00,
+	0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x03, 0x02,
+	0x04, 0x02, 0x03, 0x04, 0x80, 0x00, 0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x69, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x65, 0x00, 0x02, 0x02, 0x30, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x2c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x54, 0x52, 0x4a, 0x47, 0x41, 0x3f, 0x3e, 0x3c,
+	0x33, 0x32, 0x2d, 0x2c, 0x34, 0x23, 0x08, 0x08, 0x18, 0x2b, 0x01, 0x37, 0x16, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03, 0x37, 0x2e, 0x03, 0x37,
+	0x36, 0x36, 0x37, 0x2e, 0x03, 0x27, 0x37, 0x1e, 0x03, 0x17, 0x3e, 0x03, 0x37, 0x17, 0x0e, 0x03,
+	0x07, 0x0e, 0x03, 0x07, 0x06, 0x1e, 0x02, 0x33, 0x33, 0x07, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x06,
+	0x16, 0x33, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x01, 0x1b, 0x20, 0x3c,
+	0x5d, 0x1f, 0x60, 0x79, 0x10, 0x0b, 0x53, 0x4f, 0x23, 0x69, 0xa4, 0x68, 0x26, 0x16, 0x10, 0x55,
+	0x76, 0x8d, 0x48, 0x3a, 0x56, 0x31, 0x0f, 0x0b, 0x0d, 0x44, 0x30, 0x1f, 0x33, 0x33, 0x3a, 0x23,
+	0x20, 0x26, 0x41, 0x4f, 0x68, 0x4d, 0x34, 0x5e, 0x5f, 0x67, 0x3c, 0x16, 0x24, 0x47, 0x5d, 0x7d,
+	0x5c, 0x15, 0x24, 0x1d, 0x16, 0x07, 0x0f, 0x25, 0x52, 0x79, 0x45, 0x41, 0x1e, 0x79, 0x3b, 0x86,
+	0x7c, 0x61, 0x13, 0x18, 0x78, 0x9a, 0x23, 0x45, 0x68, 0x43, 0x1a, 0x0b, 0x0b, 0x3c, 0x72, 0xae,
+	0x7f, 0x1e, 0x41, 0xfe, 0x66, 0xa1, 0x0b, 0x0c, 0x50, 0x4f, 0x36, 0x3b, 0x2d, 0x61, 0x99, 0x6c,
+	0x51, 0x87, 0x6d, 0x51, 0x1a, 0x10, 0x3b, 0x52, 0x65, 0x3a, 0x41, 0x6e, 0x33, 0x03, 0x0a, 0x0d,
+	0x12, 0x0c, 0xa1, 0x14, 0x1f, 0x19, 0x13, 0x07, 0x23, 0x2c, 0x18, 0x0a, 0x01, 0x6b, 0x1b, 0x2d,
+	0x21, 0x16, 0x06, 0x14, 0x29, 0x2f, 0x37, 0x22, 0x4c, 0x6b, 0x43, 0x1f, 0x94, 0x1d, 0x4b, 0x7f,
+	0x62, 0x77, 0x77, 0x27, 0x45, 0x5e, 0x36, 0x38, 0x77, 0x63, 0x3f, 0x05, 0x00, 0x02, 0x00, 0x99,
+	0xff, 0xe7, 0x04, 0xc8, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x32, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16, 0x2b, 0x05, 0x22, 0x02, 0x13, 0x12, 0x00, 0x33,
+	0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x02, 0x38, 0xdb,
+	0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55,
+	0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30,
+	0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe,
+	0x5c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xe0, 0x00, 0x00, 0x06, 0x41, 0x04, 0x3e, 0x00, 0x13,
+	0x00, 0x4b, 0xb5, 0x04, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x14,
+	0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02, 0x03,
+	0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00,
+	0x00, 0x13, 0x00, 0x13, 0x13, 0x13, 0x11, 0x23, 0x21, 0x07, 0x08, 0x1b, 0x2b, 0x21, 0x13, 0x23,
+	0x22, 0x07, 0x37, 0x36, 0x33, 0x21, 0x07, 0x23, 0x03, 0x06, 0x17, 0x23, 0x26, 0x37, 0x13, 0x21,
+	0x03, 0x01, 0x50, 0xb8, 0x2c, 0x6c, 0x90, 0x25, 0x7f, 0x8f, 0x04, 0x2e, 0x21, 0xf7, 0x7e, 0x21,
+	0x34, 0xd1, 0x26, 0x20, 0x7c, 0xfe, 0x6b, 0xb8, 0x03, 0x9d, 0x46, 0xb5, 0x32, 0xa1, 0xfd, 0x8a,
+	0xa8, 0x7f, 0x90, 0x9f, 0x02, 0x6e, 0xfc, 0x63, 0x00, 0x02, 0x00, 0x3d, 0xfe, 0x75, 0x04, 0xee,
+	0x04, 0x56, 0x00, 0x0d, 0x00, 0x19, 0x00, 0x5a, 0xb5, 0x0c, 0x01, 0x01, 0x03, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x31, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x2d, 0x02,
+	0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x31, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59,
+	0x40, 0x0f, 0x00, 0x00, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0d, 0x00, 0x0d, 0x24, 0x23, 0x06, 0x08,
+	0x18, 0x2b, 0x13, 0x13, 0x12, 0x00, 0x33, 0x32, 0x16, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x03,
+	0x13, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x3d, 0xa2, 0x47, 0x01,
+	0x3d, 0xfa, 0xd6, 0xbb, 0x2c, 0x38, 0xfe, 0x7f, 0xef, 0x6b, 0x58, 0x55, 0x7c, 0x4f, 0x76, 0x9a,
+	0xf0, 0x29, 0x21, 0x70, 0x80, 0x8b, 0xb9, 0x2e, 0xfe, 0x75, 0x03, 0x2c, 0x01, 0x64, 0x01, 0x51,
+	0xf5, 0xde, 0xfe, 0xea, 0xfe, 0x93, 0x23, 0xfe, 0x52, 0x02, 0x6d, 0x4e, 0xfe, 0xcf, 0xa3, 0xbe,
+	0xe5, 0xe4, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8d, 0xfe, 0x5c, 0x04, 0xa8, 0x04, 0x55, 0x00, 0x35,
+	0x00, 0x8b, 0x40, 0x12, 0x00, 0x01, 0x00, 0x05, 0x01, 0x01, 0x01, 0x00, 0x1b, 0x01, 0x03, 0x04,
+	0x1a, 0x01, 0x02, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x31, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x29,
+	0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x31, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x29, 0x04, 0x4e,
+	0x1b, 0x40, 0x1c, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x31, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x2c, 0x04, 0x4e, 0x59,
+	0x59, 0x40, 0x09, 0x36, 0x38, 0x25, 0x26, 0x38, 0x23, 0x06, 0x08, 0x1c, 0x2b, 0x01, 0x07, 0x26,
+	0x26, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x06, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x06,
+	0x06, 0x23, 0x22, 0x26, 0x27, 0x37, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02,
+	0x23, 0x23, 0x22, 0x26, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x04, 0xa8, 0x1f, 0x31, 0x7d,
+	0x3a, 0x6c, 0xb2, 0x88, 0x5b, 0x14, 0x11, 0x08, 0x38, 0x6e, 0x56, 0x1c, 0x52, 0x80, 0x50, 0x1f,
+	0x0e, 0x20, 0xea, 0xd7, 0x1d, 0x3b, 0x23, 0x20, 0x3b, 0x5a, 0x26, 0x3a, 0x4e, 0x33, 0x1a, 0x05,
+	0x08, 0x22, 0x3b, 0x4c, 0x22, 0x28, 0xec, 0xc3, 0x31, 0x1f, 0x95, 0xcd, 0xf6, 0x81, 0x31, 0x47,
+	0x38, 0x2c, 0x04, 0x39, 0x99, 0x0e, 0x14, 0x4a, 0x82, 0xb0, 0x65, 0x51, 0x75, 0x4b, 0x24, 0x1f,
+	0x42, 0x66, 0x47, 0xa1, 0xa1, 0x05, 0x05, 0xa1, 0x0b, 0x0c, 0x1a, 0x2a, 0x34, 0x1a, 0x25, 0x30,
+	0x1d, 0x0b, 0xe2, 0xf2, 0x9d, 0xf0, 0xa2, 0x53, 0x05, 0x07, 0x0a, 0x00, 0x00, 0x02, 0x00, 0x99,
+	0xff, 0xe7, 0x05, 0xbd, 0x04, 0x56, 0x00, 0x07, 0x00, 0x17, 0x00, 0x69, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x23, 0x00, 0x01, 0x01, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x05,
+	0x05, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x31, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07,
+	0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x2b, 0x4d, 0x06, 0x01, 0x00,
+	0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x09, 0x08, 0x01,
+	0x00, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0d, 0x08, 0x17, 0x09, 0x17, 0x05, 0x03, 0x00, 0x07, 0x01,
+	0x07, 0x08, 0x08, 0x16, 0x2b, 0x25, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x17, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x17, 0x21, 0x07, 0x21, 0x16, 0x07, 0x02, 0x00, 0x02, 0x58, 0x01,
+	0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0xea, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f,
+	0xe0, 0x59, 0x46, 0x01, 0xfd, 0x23, 0xfe, 0xe3, 0x43, 0x2d, 0x35, 0xfe, 0xc0, 0x7b, 0x01, 0xa9,
+	0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x94, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0x18,
+	0xad, 0x90, 0xe0, 0xfe, 0xf4, 0xfe, 0xd2, 0x00, 0x00, 0x01, 0x00, 0xcb, 0x00, 0x00, 0x03, 0xf9,
+	0x04, 0x3e, 0x00, 0x0f, 0x00, 0x45, 0xb5, 0x06, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x04,
+	0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0f, 0x00, 0x0f, 0x11, 0x23, 0x23, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x26, 0x37, 0x13, 0x23,
+	0x22, 0x07, 0x37, 0x36, 0x33, 0x21, 0x07, 0x21, 0x03, 0x06, 0x17, 0x01, 0x8b, 0x2a, 0x25, 0x77,
+	0x43, 0x76, 0x79, 0x25, 0x71, 0x8a, 0x02, 0x0e, 0x23, 0xfe, 0xed, 0x77, 0x27, 0x37, 0x86, 0xb6,
+	0x02, 0x55, 0x30, 0xb7, 0x26, 0xad, 0xfd, 0xac, 0xc4, 0x79, 0x00, 0x00, 0x00, 0x01, 0x00, 0xda,
+	0xff, 0xe7, 0x04, 0xa9, 0x04, 0x3e, 0x00, 0x15, 0x00, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x24, 0x14, 0x23,
+	0x10, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03,
+	0x33, 0x12, 0x03, 0x06, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x72, 0xc5, 0x6d, 0x31,
+	0x42, 0x87, 0x6e, 0xc4, 0x24, 0x3e, 0x63, 0xd6, 0x40, 0x3a, 0x32, 0xfe, 0xb8, 0xc1, 0xbb, 0x5a,
+	0x37, 0x0e, 0x23, 0x04, 0x3e, 0xfd, 0xe1, 0xf6, 0xae, 0xc8, 0xb3, 0x01, 0x39, 0x01, 0x0f, 0xfe,
+	0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2, 0x6b, 0x41, 0xb3, 0xaf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc0,
+	0xfe, 0x75, 0x05, 0x75, 0x04, 0x57, 0x00, 0x29, 0x00, 0x3b, 0x00, 0x7a, 0xb6, 0x20, 0x0b, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x13, 0x04, 0x01, 0x00, 0x00, 0x01,
+	0x61, 0x05, 0x03, 0x02, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x1d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31,
+	0x4d, 0x04, 0x01, 0x00, 0x00, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03, 0x2b, 0x4d, 0x00, 0x02, 0x02,
+	0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02,
+	0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x37, 0x35, 0x00, 0x29, 0x00, 0x29, 0x1f, 0x1e, 0x14,
+	0x12, 0x11, 0x06, 0x08, 0x17, 0x2b, 0x01, 0x07, 0x22, 0x0e, 0x02, 0x07, 0x06, 0x06, 0x16, 0x16,
+	0x17, 0x13, 0x3e, 0x05, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x07, 0x03, 0x23, 0x13, 0x2e,
+	0x03, 0x37, 0x3e, 0x03, 0x13, 0x3e, 0x05, 0x37, 0x36, 0x36, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x02,
+	0x07, 0x02, 0xdb, 0x1e, 0x40, 0x5b, 0x48, 0x33, 0x10, 0x14, 0x04, 0x27, 0x60, 0x4c, 0x36, 0x11,
+	0x26, 0x31, 0x42, 0x5c, 0x78, 0x4f, 0x65, 0x7d, 0x3c, 0x02, 0x14, 0x17, 0x5d, 0x95, 0xd2, 0x8a,
+	0x4f, 0xc5, 0x4f, 0x87, 0xa3, 0x4b, 0x02, 0x19, 0x17, 0x5a, 0x82, 0xa9, 0xa2, 0x4b, 0x6a, 0x50,
+	0x38, 0x29, 0x1d, 0x0a, 0x0c, 0x0e, 0x0f, 0x33, 0x3b, 0x27, 0x3c, 0x31, 0x2c, 0x17, 0x04, 0x3e,
+	0x94, 0x2e, 0x58, 0x7f, 0x51, 0x61, 0x97, 0x75, 0x4e, 0x0f, 0x01, 0x10, 0x54, 0xaa, 0x9b, 0x87,
+	0x64, 0x39, 0x4b, 0x82, 0xb0, 0x65, 0x72, 0xd8, 0xac, 0x72, 0x0d, 0xfe, 0x75, 0x01, 0x8b, 0x10,
+	0x62, 0x9b, 0xcc, 0x7b, 0x75, 0xb7, 0x7d, 0x41, 0xfc, 0x4c, 0x07, 0x33, 0x4b, 0x5e, 0x67, 0x75,
+	0x32, 0x3c, 0x75, 0x5d, 0x39, 0x2a, 0x62, 0x9e, 0x73, 0x00, 0x00, 0x00, 0x00, 0x01, 0xff, 0xc5,
+	0xfe, 0x75, 0x04, 0xc4, 0x04, 0x3e, 0x00, 0x19, 0x00, 0x1f, 0x40, 0x1c, 0x17, 0x0e, 0x0b, 0x03,
+	0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2d, 0x02,
+	0x4e, 0x15, 0x15, 0x15, 0x16, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x03, 0x2e, 0x03, 0x27, 0x33, 0x16,
+	0x16, 0x17, 0x17, 0x01, 0x33, 0x01, 0x13, 0x16, 0x16, 0x17, 0x23, 0x26, 0x26, 0x27, 0x03, 0x01,
+	0x23, 0x01, 0xfd, 0x76, 0x24, 0x31, 0x21, 0x17, 0x08, 0xea, 0x20, 0x32, 0x1e, 0x4c, 0x01, 0x82,
+	0xaa, 0xfe, 0x08, 0x95, 0x34, 0x46, 0x14, 0xe3, 0x34, 0x35, 0x10, 0x61, 0xfe, 0x3e, 0xab, 0x01,
+	0x6c, 0x01, 0x55, 0x69, 0x85, 0x53, 0x2c, 0x10, 0x3d, 0x99, 0x57, 0xd6, 0x02, 0x03, 0xfd, 0x61,
+	0xfe, 0x4c, 0x98, 0xb4, 0x2a, 0x73, 0x9e, 0x30, 0x01, 0x19, 0xfd, 0xa6, 0x00, 0x01, 0x00, 0xd1,
+	0xfe, 0x75, 0x05, 0xfa, 0x05, 0x03, 0x00, 0x2b, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x03, 0x01, 0x03, 0x85, 0x05, 0x01, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02, 0x02,
+	0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x29, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x2d, 0x07, 0x4e, 0x1b,
+	0x40, 0x1e, 0x00, 0x03, 0x01, 0x03, 0x85, 0x05, 0x01, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02,
+	0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x2c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x2d, 0x07, 0x4e,
+	0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x2b, 0x00, 0x2b, 0x18, 0x16, 0x11, 0x11, 0x1a, 0x18, 0x11,
+	0x09, 0x08, 0x1d, 0x2b, 0x01, 0x13, 0x22, 0x26, 0x26, 0x36, 0x37, 0x37, 0x36, 0x36, 0x27, 0x33,
+	0x16, 0x06, 0x07, 0x07, 0x06, 0x06, 0x14, 0x1e, 0x02, 0x33, 0x13, 0x33, 0x03, 0x3e, 0x03, 0x37,
+	0x36, 0x27, 0x33, 0x16, 0x07, 0x0e, 0x05, 0x07, 0x03, 0x02, 0x34, 0x4f, 0xa2, 0xbc, 0x54, 0x07,
+	0x20, 0x1e, 0x12, 0x06, 0x0d, 0xcc, 0x11, 0x07, 0x12, 0x1f, 0x0c, 0x12, 0x17, 0x38, 0x60, 0x49,
+	0xe3, 0xc5, 0xe3, 0x65, 0x8c, 0x60, 0x3d, 0x16, 0x32, 0x2c, 0xc5, 0x26, 0x33, 0x10, 0x34, 0x4a,
+	0x65, 0x84, 0xa3, 0x65, 0x4f, 0xfe, 0x75, 0x01, 0x8b, 0x53, 0xa2, 0xf2, 0x9f, 0x99, 0x57, 0x8f,
+	0x39, 0x2f, 0x88, 0x5b, 0x99, 0x3d, 0x7c, 0x71, 0x63, 0x48, 0x2a, 0x04, 0x6f, 0xfb, 0x91, 0x05,
+	0x4f, 0x87, 0xba, 0x70, 0xfa, 0xab, 0xcb, 0xfd, 0x53, 0x9a, 0x88, 0x73, 0x54, 0x34, 0x06, 0xfe,
+	0x75, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc4, 0xff, 0xe7, 0x06, 0x67, 0x04, 0x3e, 0x00, 0x48,
+	0x00, 0x2e, 0x40, 0x2b, 0x2a, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x00, 0x03, 0x01, 0x02, 0x01, 0x03,
+	0x02, 0x80, 0x05, 0x01, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x62, 0x06, 0x01,
+	0x00, 0x00, 0x32, 0x00, 0x4e, 0x29, 0x1b, 0x29, 0x19, 0x29, 0x19, 0x24, 0x07, 0x08, 0x1d, 0x2b,
+	0x25, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x03, 0x36, 0x37, 0x36, 0x12, 0x37, 0x33, 0x06, 0x02, 0x07,
+	0x0e, 0x02, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x26, 0x26, 0x37, 0x36, 0x37, 0x33, 0x16,
+	0x07, 0x06, 0x06, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e, 0x04, 0x37, 0x36, 0x36, 0x26, 0x26, 0x27,
+	0x33, 0x16, 0x12, 0x07, 0x0e, 0x05, 0x23, 0x22, 0x26, 0x03, 0x58, 0x23, 0x51, 0x5a, 0x65, 0x3b,
+	0x50, 0x6d, 0x44, 0x1f, 0x06, 0x0f, 0x0d, 0x1f, 0x81, 0x65, 0xcd, 0x69, 0x8e, 0x1d, 0x08, 0x0e,
+	0x06, 0x08, 0x1c, 0x35, 0x2a, 0x37, 0x5d, 0x4c, 0x39, 0x13, 0x0b, 0x03, 0x0d, 0x1b, 0x49, 0xb5,
+	0x15, 0x1c, 0x0d, 0x3c, 0x24, 0x05, 0x12, 0x27, 0x43, 0x35, 0x29, 0x43, 0x36, 0x29, 0x1f, 0x15,
+	0x06, 0x0e, 0x06, 0x11, 0x24, 0x1a, 0xcd, 0x36, 0x0f, 0x1e, 0x0b, 0x2f, 0x43, 0x56, 0x65, 0x71,
+	0x3e, 0x70, 0x84, 0xfe, 0x3a, 0x65, 0x4c, 0x2c, 0x2f, 0x51, 0x6d, 0x7d, 0x85, 0x41, 0x98, 0x01,
+	0x15, 0x7a, 0x7a, 0xfe, 0xe0, 0x8f, 0x28, 0x59, 0x59, 0x51, 0x3d, 0x25, 0x3e, 0x5f, 0x6e, 0x30,
+	0x3e, 0x8c, 0x40, 0x8a, 0x7e, 0x7b, 0x8d, 0x40, 0x95, 0x42, 0x39, 0x6d, 0x54, 0x34, 0x2c, 0x47,
+	0x5b, 0x5d, 0x57, 0x20, 0x47, 0x8e, 0x88, 0x7f, 0x38, 0x80, 0xfe, 0xeb, 0x92, 0x39, 0x80, 0x7c,
+	0x72, 0x56, 0x33, 0x8c, 0x00, 0x03, 0x00, 0xf0, 0xff, 0xe7, 0x03, 0x7e, 0x05, 0xba, 0x00, 0x0d,
+	0x00, 0x11, 0x00, 0x15, 0x00, 0x64, 0xb5, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1e, 0x08, 0x06, 0x07, 0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03,
+	0x28, 0x4d, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32,
+	0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03, 0x04, 0x01, 0x03, 0x04,
+	0x67, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00,
+	0x4e, 0x59, 0x40, 0x15, 0x12, 0x12, 0x0e, 0x0e, 0x12, 0x15, 0x12, 0x15, 0x14, 0x13, 0x0e, 0x11,
+	0x0e, 0x11, 0x13, 0x23, 0x13, 0x21, 0x09, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x37,
+	0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0x02, 0xcd, 0x64, 0x65, 0xa8, 0x6c, 0x2c, 0x8d, 0xc5, 0x89, 0x1f, 0x2e, 0x56, 0x49, 0x57, 0xfe,
+	0x35, 0x23, 0xad, 0x23, 0xe2, 0x23, 0xad, 0x23, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0, 0xfd, 0x53,
+	0x98, 0x7e, 0x2a, 0x04, 0x68, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xda,
+	0xff, 0xe7, 0x04, 0xa9, 0x05, 0xba, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x60, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1f, 0x09, 0x07, 0x08, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04,
+	0x04, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x32, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00,
+	0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x32, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c,
+	0x1b, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14, 0x23, 0x10, 0x0a, 0x08, 0x1b, 0x2b, 0x01, 0x33,
+	0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03, 0x33, 0x12, 0x03, 0x06, 0x00, 0x23, 0x22,
+	0x27, 0x26, 0x26, 0x37, 0x13, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x72, 0xc5, 0x6d,
+	0x31, 0x42, 0x87, 0x6e, 0xc4, 0x24, 0x3e, 0x63, 0xd6, 0x40, 0x3a, 0x32, 0xfe, 0xb8, 0xc1, 0xbb,
+	0x5a, 0x37, 0x0e, 0x23, 0xe0, 0x23, 0xad, 0x23, 0xe2, 0x23, 0xad, 0x23, 0x04, 0x3e, 0xfd, 0xe1,
+	0xf6, 0xae, 0xc8, 0xb3, 0x01, 0x39, 0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2, 0x6b,
+	0x41, 0xb3, 0xaf, 0x03, 0x18, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x99,
+	0xff, 0xe7, 0x04, 0xc8, 0x06, 0xa6, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x40, 0x40, 0x3d,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32,
+	0x00, 0x4e, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f,
+	0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x22,
+	0x02, 0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20,
+	0x03, 0x02, 0x01, 0x01, 0x33, 0x01, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf,
+	0xc8, 0x35, 0x35, 0xfe, 0xc0, 0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54,
+	0x01, 0x79, 0x01, 0x25, 0xdb, 0xfe, 0x7d, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30,
+	0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe,
+	0x5c, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x02, 0x00, 0xda, 0xff, 0xe7, 0x04, 0xa9,
+	0x06, 0xa6, 0x00, 0x15, 0x00, 0x19, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06,
+	0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62,
+	0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14, 0x23,
+	0x10, 0x07, 0x08, 0x1b, 0x2b, 0x01, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x12, 0x03,
+	0x33, 0x12, 0x03, 0x06, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x37, 0x01, 0x01, 0x33, 0x01, 0x01,
+	0x72, 0xc5, 0x6d, 0x31, 0x42, 0x87, 0x6e, 0xc4, 0x24, 0x3e, 0x63, 0xd6, 0x40, 0x3a, 0x32, 0xfe,
+	0xb8, 0xc1, 0xbb, 0x5a, 0x37, 0x0e, 0x23, 0x01, 0xac, 0x01, 0x25, 0xdb, 0xfe, 0x7d, 0x04, 0x3e,
+	0xfd, 0xe1, 0xf6, 0xae, 0xc8, 0xb3, 0x01, 0x39, 0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe,
+	0xd2, 0x6b, 0x41, 0xb3, 0xaf, 0x03, 0x0e, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x02, 0x00, 0xc4,
+	0xff, 0xe7, 0x06, 0x67, 0x06, 0xa6, 0x00, 0x48, 0x00, 0x4c, 0x00, 0x40, 0x40, 0x3d, 0x2a, 0x01,
+	0x02, 0x03, 0x01, 0x4c, 0x00, 0x07, 0x08, 0x07, 0x85, 0x09, 0x01, 0x08, 0x01, 0x08, 0x85, 0x00,
+	0x03, 0x01, 0x02, 0x01, 0x03, 0x02, 0x80, 0x05, 0x01, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02,
+	0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x49, 0x49, 0x49, 0x4c, 0x49, 0x4c,
+	0x13, 0x29, 0x1b, 0x29, 0x19, 0x29, 0x19, 0x24, 0x0a, 0x08, 0x1e, 0x2b, 0x25, 0x0e, 0x03, 0x23,
+	0x22, 0x2e, 0x03, 0x36, 0x37, 0x36, 0x12, 0x37, 0x33, 0x06, 0x02, 0x07, 0x0e, 0x02, 0x1e, 0x02,
+	0x33, 0x32, 0x3e, 0x02, 0x37, 0x26, 0x26, 0x37, 0x36, 0x37, 0x33, 0x16, 0x07, 0x06, 0x06, 0x07,
+	0x1e, 0x03, 0x33, 0x32, 0x3e, 0x04, 0x37, 0x36, 0x36, 0x26, 0x26, 0x27, 0x33, 0x16, 0x12, 0x07,
+	0x0e, 0x05, 0x23, 0x22, 0x26, 0x13, 0x01, 0x33, 0x01, 0x03, 0x58, 0x23, 0x51, 0x5a, 0x65, 0x3b,
+	0x50, 0x6d, 0x44, 0x1f, 0x06, 0x0f, 0x0d, 0x1f, 0x81, 0x65, 0xcd, 0x69, 0x8e, 0x1d, 0x08, 0x0e,
+	0x06, 0x08, 0x1c, 0x35, 0x2a, 0x37, 0x5d, 0x4c, 0x39, 0x13, 0x0b, 0x03, 0x0d, 0x1b, 0x49, 0xb5,
+	0x15, 0x1c, 0x0d, 0x3c, 0x24, 0x05, 0x12, 0x27, 0x43, 0x35, 0x29, 0x43, 0x36, 0x29, 0x1f, 0x15,
+	0x06, 0x0e, 0x06, 0x11, 0x24, 0x1a, 0xcd, 0x36, 0x0f, 0x1e, 0x0b, 0x2f, 0x43, 0x56, 0x65, 0x71,
+	0x3e, 0x70, 0x84, 0x21, 0x01, 0x25, 0xdb, 0xfe, 0x7d, 0xfe, 0x3a, 0x65, 0x4c, 0x2c, 0x2f, 0x51,
+	0x6d, 0x7d, 0x85, 0x41, 0x98, 0x01, 0x15, 0x7a, 0x7a, 0xfe, 0xe0, 0x8f, 0x28, 0x59, 0x59, 0x51,
+	0x3d, 0x25, 0x3e, 0x5f, 0x6e, 0x30, 0x3e, 0x8c, 0x40, 0x8a, 0x7e, 0x7b, 0x8d, 0x40, 0x95, 0x42,
+	0x39, 0x6d, 0x54, 0x34, 0x2c, 0x47, 0x5b, 0x5d, 0x57, 0x20, 0x47, 0x8e, 0x88, 0x7f, 0x38, 0x80,
+	0xfe, 0xeb, 0x92, 0x39, 0x80, 0x7c, 0x72, 0x56, 0x33, 0x8c, 0x04, 0x90, 0x01, 0xa3, 0xfe, 0x5d,
+	0x00, 0x02, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6e,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b,
+	0x40, 0x26, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x33, 0x01,
+	0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x03, 0x23, 0x01, 0x33, 0xc9, 0x01,
+	0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f,
+	0x68, 0x94, 0xfe, 0xff, 0xe4, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x03, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x07, 0x0f, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x06,
+	0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b,
+	0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d,
+	0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12,
+	0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0d, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1,
+	0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03, 0x8b, 0x1f, 0xfd, 0xdc, 0x23, 0xad, 0x23, 0xde,
+	0x23, 0xad, 0x23, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x62, 0xad, 0xad,
+	0xad, 0xad, 0x00, 0x00, 0x00, 0x01, 0x01, 0x31, 0xff, 0xf4, 0x07, 0x14, 0x05, 0xc8, 0x00, 0x29,
+	0x00, 0x79, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0a, 0x11, 0x01, 0x02, 0x03, 0x10, 0x01, 0x01,
+	0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0b, 0x11, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x10, 0x01, 0x04, 0x01,
+	0x4b, 0x59, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03,
+	0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x01, 0x05,
+	0x00, 0x06, 0x05, 0x67, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x04, 0x04, 0x1d,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0b, 0x11,
+	0x11, 0x11, 0x13, 0x28, 0x25, 0x28, 0x22, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x36, 0x36, 0x33, 0x32,
+	0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x27, 0x37, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02,
+	0x37, 0x36, 0x2e, 0x02, 0x23, 0x22, 0x06, 0x07, 0x03, 0x23, 0x01, 0x21, 0x37, 0x21, 0x07, 0x21,
+	0x03, 0x89, 0x65, 0xeb, 0x76, 0x81, 0xb7, 0x6c, 0x21, 0x15, 0x15, 0x6e, 0x9d, 0xc6, 0x6c, 0x2a,
+	0x51, 0x18, 0x1f, 0x0e, 0x3e, 0x1e, 0x4c, 0x80, 0x62, 0x41, 0x0f, 0x0c, 0x14, 0x42, 0x72, 0x52,
+	0x7a, 0xd6, 0x60, 0x82, 0xd1, 0x01, 0x08, 0xfe, 0x19, 0x1f, 0x04, 0x8b, 0x1f, 0xfe, 0x2d, 0x03,
+	0x4c, 0x42, 0x4c, 0x47, 0x7f, 0xaf, 0x69, 0x68, 0xbd, 0x8f, 0x54, 0x08, 0x04, 0x9d, 0x04, 0x0b,
+	0x3c, 0x65, 0x84, 0x47, 0x3d, 0x6d, 0x52, 0x31, 0x51, 0x48, 0xfd, 0x72, 0x05, 0x2b, 0x9d, 0x9d,
+	0x00, 0x02, 0x00, 0xbf, 0x00, 0x00, 0x05, 0x70, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b,
+	0x00, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x68, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40,
+	0x0d, 0x06, 0x06, 0x06, 0x09, 0x06, 0x09, 0x12, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1a, 0x2b, 0x21,
+	0x23, 0x01, 0x21, 0x07, 0x21, 0x13, 0x01, 0x33, 0x01, 0x01, 0x91, 0xd2, 0x01, 0x27, 0x03, 0x8a,
+	0x1f, 0xfd, 0x48, 0x7b, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05, 0xc8, 0x9d, 0x01, 0x23, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0xff, 0xdb, 0x06, 0x71, 0x05, 0xed, 0x00, 0x22,
+	0x00, 0x5b, 0x40, 0x0a, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x20,
+	0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x00,
+	0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e,
+	0x59, 0x40, 0x09, 0x24, 0x11, 0x14, 0x27, 0x26, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x07, 0x06,
+	0x21, 0x20, 0x00, 0x13, 0x36, 0x12, 0x36, 0x24, 0x33, 0x32, 0x16, 0x17, 0x07, 0x2e, 0x03, 0x23,
+	0x22, 0x0e, 0x02, 0x07, 0x21, 0x07, 0x21, 0x06, 0x1e, 0x02, 0x33, 0x32, 0x05, 0x90, 0x24, 0xf3,
+	0xfe, 0xfe, 0xfe, 0x6f, 0xfe, 0xc7, 0x4e, 0x28, 0xa6, 0xf7, 0x01, 0x43, 0xc4, 0x67, 0xca, 0x79,
+	0x26, 0x37, 0x67, 0x64, 0x61, 0x33, 0x7b, 0xdd, 0xb5, 0x89, 0x27, 0x03, 0x1b, 0x1f, 0xfc, 0xde,
+	0x1d, 0x27, 0x7d, 0xcf, 0x8b, 0xd3, 0x01, 0x00, 0xb4, 0x71, 0x01, 0x80, 0x01, 0x88, 0xc7, 0x01,
+	0x25, 0xc0, 0x5e, 0x1f, 0x1f, 0xc0, 0x18, 0x23, 0x17, 0x0c, 0x3f, 0x7f, 0xbe, 0x7e, 0x9a, 0x8f,
+	0xd6, 0x8e, 0x47, 0x00, 0x00, 0x01, 0x00, 0x8d, 0xff, 0xdb, 0x05, 0xac, 0x05, 0xed, 0x00, 0x1f,
+	0x00, 0x49, 0x40, 0x0b, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x02, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x20, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01,
+	0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03,
+	0x4e, 0x59, 0xb6, 0x2a, 0x23, 0x28, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x37, 0x37, 0x04, 0x21, 0x20,
+	0x37, 0x36, 0x26, 0x27, 0x27, 0x24, 0x13, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x07,
+	0x06, 0x16, 0x17, 0x17, 0x16, 0x16, 0x07, 0x06, 0x04, 0x23, 0x20, 0x8d, 0x29, 0x01, 0x01, 0x01,
+	0x31, 0x01, 0x3d, 0x30, 0x15, 0x64, 0xb0, 0xbc, 0xfe, 0x97, 0x38, 0x51, 0x02, 0x1c, 0xf4, 0xe2,
+	0x27, 0xe4, 0xf8, 0xfe, 0xbc, 0x2c, 0x11, 0x63, 0x98, 0xc0, 0xda, 0x97, 0x21, 0x27, 0xfe, 0xaf,
+	0xf9, 0xfe, 0xf3, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92,
+	0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x87, 0x00, 0x00, 0x03, 0xe7, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x16,
+	0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07,
+	0x23, 0x03, 0x33, 0x07, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4,
+	0x1f, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x87,
+	0x00, 0x00, 0x04, 0x19, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x72, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x24, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07,
+	0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x01, 0x06, 0x0c,
+	0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c,
+	0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x07, 0x1b, 0x2b,
+	0x33, 0x37, 0x33, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x33, 0x07, 0x01, 0x37, 0x33, 0x07,
+	0x33, 0x37, 0x33, 0x07, 0x87, 0x1f, 0xb4, 0xe9, 0xb4, 0x1f, 0x02, 0x39, 0x1f, 0xb4, 0xe9, 0xb4,
+	0x1f, 0xfe, 0xfd, 0x23, 0xad, 0x23, 0xdf, 0x23, 0xad, 0x23, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb,
+	0x72, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x01, 0x00, 0x2c, 0xfe, 0xd8, 0x04, 0x99,
+	0x05, 0xc8, 0x00, 0x0e, 0x00, 0x45, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f,
+	0x00, 0x02, 0x02, 0x1a, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03,
+	0x51, 0x59, 0xb6, 0x22, 0x11, 0x13, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x17, 0x37, 0x16, 0x33, 0x32,
+	0x36, 0x37, 0x13, 0x23, 0x37, 0x21, 0x01, 0x02, 0x21, 0x22, 0x2c, 0x24, 0x97, 0x95, 0x9f, 0x84,
+	0x24, 0xe5, 0xe6, 0x1f, 0x01, 0xb8, 0xfe, 0xfe, 0x61, 0xfe, 0x1e, 0xa7, 0xe8, 0xb5, 0x4d, 0x7d,
+	0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x00, 0x02, 0x00, 0x23, 0x00, 0x00, 0x08, 0x90,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x2f, 0x00, 0x5c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00,
+	0x05, 0x00, 0x01, 0x00, 0x05, 0x01, 0x67, 0x08, 0x01, 0x07, 0x07, 0x04, 0x5f, 0x00, 0x04, 0x04,
+	0x1a, 0x4d, 0x03, 0x01, 0x00, 0x00, 0x02, 0x61, 0x06, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b,
+	0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x07, 0x05, 0x04, 0x07, 0x67, 0x00, 0x05, 0x00, 0x01, 0x00,
+	0x05, 0x01, 0x67, 0x03, 0x01, 0x00, 0x00, 0x02, 0x61, 0x06, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e,
+	0x59, 0x40, 0x10, 0x0d, 0x0d, 0x0d, 0x2f, 0x0d, 0x2f, 0x28, 0x21, 0x17, 0x21, 0x28, 0x28, 0x20,
+	0x09, 0x07, 0x1d, 0x2b, 0x25, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x01,
+	0x07, 0x06, 0x02, 0x02, 0x0e, 0x02, 0x23, 0x23, 0x37, 0x33, 0x32, 0x3e, 0x02, 0x12, 0x12, 0x37,
+	0x37, 0x21, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x21, 0x01, 0x05, 0x05, 0xd5,
+	0x6a, 0xa0, 0x70, 0x43, 0x0f, 0x0e, 0x19, 0x56, 0x93, 0x6c, 0xd5, 0xfd, 0xd2, 0x16, 0x28, 0x51,
+	0x5d, 0x6d, 0x8c, 0xaf, 0x6f, 0x1d, 0x1e, 0x19, 0x3f, 0x68, 0x5c, 0x53, 0x53, 0x56, 0x32, 0x1e,
+	0x03, 0x65, 0x7f, 0xc5, 0x8b, 0xe0, 0x95, 0x3c, 0x18, 0x1a, 0x83, 0xbe, 0xed, 0x84, 0xfe, 0x69,
+	0x01, 0x08, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x02, 0x7b, 0x6e, 0xcb, 0xfe,
+	0xae, 0xfe, 0xf2, 0xcb, 0x87, 0x43, 0x9a, 0x24, 0x60, 0xa6, 0x01, 0x05, 0x01, 0x6e, 0xf8, 0x99,
+	0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x05, 0x2e, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x08, 0x30, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x23, 0x00, 0x52, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x05, 0x01, 0x03, 0x07, 0x01, 0x01, 0x00, 0x03, 0x01, 0x68, 0x04, 0x01, 0x02,
+	0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x5f, 0x08, 0x01, 0x06, 0x06, 0x1b, 0x06, 0x4e, 0x1b,
+	0x40, 0x1c, 0x04, 0x01, 0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x07, 0x01, 0x01, 0x00, 0x03,
+	0x01, 0x68, 0x00, 0x00, 0x00, 0x06, 0x5f, 0x08, 0x01, 0x06, 0x06, 0x1d, 0x06, 0x4e, 0x59, 0x40,
+	0x0c, 0x11, 0x11, 0x28, 0x21, 0x11, 0x11, 0x11, 0x28, 0x20, 0x09, 0x07, 0x1f, 0x2b, 0x25, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x01, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03,
+	0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x21, 0x13, 0x21, 0x03, 0x23, 0x04, 0xb9, 0xc1,
+	0x6a, 0xa0, 0x70, 0x43, 0x0f, 0x0e, 0x19, 0x56, 0x93, 0x6c, 0xc1, 0xfc, 0xb2, 0xd2, 0x7f, 0x02,
+	0x47, 0x7f, 0xd2, 0x7f, 0xb1, 0x8b, 0xe0, 0x95, 0x3c, 0x18, 0x1a, 0x83, 0xbe, 0xed, 0x84, 0xfe,
+	0x7d, 0x8a, 0xfd, 0xb9, 0x8a, 0xd2, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x03,
+	0x15, 0xfd, 0x85, 0x02, 0x7b, 0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x02,
+	0xb3, 0xfd, 0x4d, 0x00, 0x00, 0x01, 0x01, 0x2e, 0x00, 0x00, 0x06, 0xba, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x58, 0xb5, 0x03, 0x01, 0x03, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x00, 0x00, 0x06, 0x5f, 0x07, 0x01,
+	0x06, 0x06, 0x1a, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x07, 0x01,
+	0x06, 0x05, 0x01, 0x00, 0x01, 0x06, 0x00, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69,
+	0x04, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x1b,
+	0x11, 0x13, 0x25, 0x15, 0x23, 0x11, 0x08, 0x07, 0x1c, 0x2b, 0x01, 0x07, 0x21, 0x03, 0x36, 0x36,
+	0x33, 0x32, 0x1e, 0x02, 0x07, 0x03, 0x23, 0x13, 0x36, 0x2e, 0x02, 0x23, 0x22, 0x06, 0x07, 0x03,
+	0x23, 0x01, 0x21, 0x37, 0x05, 0xdb, 0x1f, 0xfe, 0x30, 0x62, 0x60, 0xe0, 0x6f, 0x70, 0xa0, 0x5c,
+	0x15, 0x1b, 0x61, 0xd2, 0x60, 0x12, 0x08, 0x38, 0x67, 0x4c, 0x61, 0xcc, 0x59, 0x81, 0xd2, 0x01,
+	0x08, 0xfe, 0x14, 0x1f, 0x05, 0xc8, 0x9d, 0xfe, 0x18, 0x46, 0x46, 0x34, 0x74, 0xb9, 0x84, 0xfe,
+	0x16, 0x01, 0xe5, 0x5a, 0x79, 0x4a, 0x20, 0x4c, 0x4e, 0xfd, 0x78, 0x05, 0x2b, 0x9d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xb0, 0x00, 0x00, 0x05, 0x77, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x35, 0x00, 0x7b,
+	0xb5, 0x22, 0x01, 0x07, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x00,
+	0x01, 0x00, 0x85, 0x09, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x03, 0x00, 0x07, 0x06, 0x03, 0x07,
+	0x68, 0x00, 0x05, 0x05, 0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x0a, 0x08, 0x02, 0x06,
+	0x06, 0x1b, 0x06, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x01, 0x01, 0x02,
+	0x01, 0x85, 0x04, 0x01, 0x02, 0x00, 0x05, 0x03, 0x02, 0x05, 0x6a, 0x00, 0x03, 0x00, 0x07, 0x06,
+	0x03, 0x07, 0x68, 0x0a, 0x08, 0x02, 0x06, 0x06, 0x1d, 0x06, 0x4e, 0x59, 0x40, 0x1c, 0x04, 0x04,
+	0x00, 0x00, 0x04, 0x35, 0x04, 0x35, 0x34, 0x33, 0x2c, 0x2b, 0x18, 0x17, 0x16, 0x12, 0x09, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0b, 0x07, 0x17, 0x2b, 0x01, 0x01, 0x33, 0x09, 0x02,
+	0x33, 0x03, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x32, 0x37, 0x07, 0x22,
+	0x0e, 0x02, 0x07, 0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17, 0x16, 0x16, 0x17, 0x23, 0x2e,
+	0x05, 0x27, 0x23, 0x03, 0x03, 0x32, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0xfc, 0xea, 0x01, 0x27, 0xd2,
+	0x7f, 0x1e, 0x29, 0x4a, 0x48, 0x48, 0x26, 0x69, 0x34, 0x54, 0x54, 0x63, 0x43, 0x01, 0x0d, 0x0d,
+	0x1f, 0x2a, 0x3f, 0x38, 0x33, 0x1c, 0x58, 0x23, 0x3e, 0x45, 0x4f, 0x35, 0x44, 0x58, 0x3c, 0x2b,
+	0x18, 0x20, 0x19, 0x36, 0x1c, 0xdc, 0x16, 0x2a, 0x2c, 0x32, 0x3c, 0x46, 0x2c, 0x5a, 0x88, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xf9, 0xb2, 0x05, 0xc8, 0xfd, 0x85, 0x26, 0x42, 0x57, 0x32, 0x89,
+	0x44, 0x61, 0x3e, 0x1d, 0x01, 0x9a, 0x17, 0x2a, 0x3c, 0x25, 0x73, 0x2e, 0x4d, 0x42, 0x39, 0x1a,
+	0x14, 0x36, 0x52, 0x73, 0x4f, 0x6c, 0x55, 0x9c, 0x4e, 0x3a, 0x89, 0x8d, 0x88, 0x71, 0x53, 0x11,
+	0xfd, 0x53, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb5, 0x00, 0x00, 0x06, 0x48, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x56, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x06, 0x03,
+	0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x0d, 0x0c, 0x0b, 0x0a, 0x00,
+	0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33,
+	0x01, 0x23, 0x13, 0x01, 0x01, 0x23, 0x01, 0x33, 0xb5, 0x01, 0x27, 0xd2, 0xeb, 0x03, 0xb3, 0xd2,
+	0xfe, 0xd9, 0xd2, 0xeb, 0xfc, 0x4d, 0x03, 0x10, 0x94, 0xfe, 0xff, 0xe4, 0x05, 0xc8, 0xfb, 0x66,
+	0x04, 0x9a, 0xfa, 0x38, 0x04, 0x9a, 0xfb, 0x66, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x02, 0x00, 0x6d,
+	0xff, 0xdb, 0x06, 0x34, 0x07, 0x8f, 0x00, 0x10, 0x00, 0x22, 0x00, 0x8f, 0x40, 0x0a, 0x14, 0x01,
+	0x05, 0x04, 0x03, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0x20, 0x06,
+	0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01,
+	0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00,
+	0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62,
+	0x00, 0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x01,
+	0x01, 0x00, 0x07, 0x03, 0x07, 0x00, 0x03, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a,
+	0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x23,
+	0x13, 0x23, 0x13, 0x21, 0x23, 0x13, 0x11, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x01, 0x33, 0x13, 0x33,
+	0x01, 0x33, 0x01, 0x06, 0x04, 0x23, 0x23, 0x37, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x06, 0x15,
+	0x16, 0x33, 0x32, 0x37, 0x36, 0x37, 0x33, 0x06, 0x07, 0x06, 0x21, 0x20, 0x35, 0x34, 0x02, 0x98,
+	0xfe, 0xc6, 0xea, 0xf3, 0x04, 0x02, 0x34, 0xc1, 0xfc, 0xdd, 0x96, 0xfe, 0xf5, 0xdd, 0x26, 0x23,
+	0x29, 0x9e, 0xb2, 0x64, 0x74, 0xa1, 0x0e, 0x09, 0x85, 0x85, 0x37, 0x0e, 0x0e, 0xa1, 0x0f, 0x0f,
+	0x55, 0xfe, 0xe6, 0xfe, 0xe6, 0x01, 0xb3, 0x04, 0x15, 0xfc, 0xd9, 0x03, 0x27, 0xfb, 0x83, 0xd6,
+	0x9a, 0xad, 0x61, 0x8c, 0x06, 0x1a, 0x48, 0x22, 0x73, 0x73, 0x22, 0x48, 0x47, 0x1e, 0xdc, 0xcf,
+	0x2b, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xb0, 0xfe, 0x75, 0x06, 0x4d, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04,
+	0x4e, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06,
+	0x05, 0x02, 0x03, 0x03, 0x1d, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x01,
+	0x33, 0x01, 0x21, 0x01, 0x33, 0x01, 0x21, 0x03, 0x23, 0x13, 0xb0, 0x01, 0x27, 0xd2, 0xfe, 0xf8,
+	0x02, 0xd3, 0x01, 0x08, 0xd1, 0xfe, 0xd9, 0xfe, 0x27, 0x4f, 0xc3, 0x4f, 0x05, 0xc8, 0xfa, 0xd4,
+	0x05, 0x2c, 0xfa, 0x38, 0xfe, 0x75, 0x01, 0x8b, 0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x49,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x00,
+	0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05, 0x03, 0x02, 0x01, 0x01,
+	0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x06, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x01, 0x21, 0x03,
+	0x1e, 0x03, 0x59, 0xd0, 0x01, 0x02, 0xe2, 0x49, 0xfd, 0xae, 0xeb, 0x01, 0x47, 0x01, 0xdc, 0x6f,
+	0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x00, 0x02, 0x00, 0xb0,
+	0x00, 0x00, 0x05, 0x8c, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x1d, 0x00, 0x4f, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x05, 0x05, 0x04, 0x5f,
+	0x00, 0x04, 0x04, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1b, 0x03, 0x4e,
+	0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x00, 0x01, 0x00,
+	0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40,
+	0x09, 0x11, 0x11, 0x28, 0x21, 0x28, 0x20, 0x06, 0x07, 0x1c, 0x2b, 0x25, 0x21, 0x32, 0x3e, 0x02,
+	0x37, 0x36, 0x2e, 0x02, 0x23, 0x21, 0x37, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x21,
+	0x01, 0x21, 0x07, 0x21, 0x01, 0xa0, 0x01, 0x05, 0x6a, 0xa0, 0x70, 0x43, 0x0f, 0x0e, 0x19, 0x56,
+	0x93, 0x6c, 0xfe, 0xfb, 0x1e, 0xf5, 0x8b, 0xe0, 0x95, 0x3c, 0x18, 0x1a, 0x83, 0xbe, 0xed, 0x84,
+	0xfe, 0x39, 0x01, 0x27, 0x03, 0xb5, 0x1f, 0xfd, 0x1d, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66,
+	0x41, 0x1e, 0x9a, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x05, 0xc8, 0x9d, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xb0, 0x00, 0x00, 0x05, 0xa8, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x1f,
+	0x00, 0x61, 0xb5, 0x07, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x1a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40,
+	0x1c, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07,
+	0x07, 0x17, 0x2b, 0x33, 0x01, 0x21, 0x20, 0x16, 0x07, 0x02, 0x05, 0x04, 0x03, 0x06, 0x07, 0x06,
+	0x06, 0x23, 0x25, 0x33, 0x20, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x20, 0x13, 0x36,
+	0x26, 0x23, 0x23, 0xb0, 0x01, 0x27, 0x01, 0xda, 0x01, 0x24, 0xd3, 0x25, 0x36, 0xfe, 0xa4, 0x01,
+	0x6d, 0x3a, 0x1d, 0x64, 0x50, 0xc4, 0xd1, 0xfe, 0xe3, 0x9b, 0x01, 0x28, 0xc8, 0x1c, 0x1f, 0xce,
+	0xe1, 0xab, 0x1a, 0xb3, 0x01, 0x92, 0x38, 0x19, 0x8e, 0xe3, 0xc2, 0x05, 0xc8, 0x97, 0xb8, 0xfe,
+	0xf2, 0x68, 0x6a, 0xfe, 0xda, 0x8f, 0x61, 0x4e, 0x35, 0x9d, 0x57, 0x8c, 0x98, 0xa1, 0x85, 0x01,
+	0x19, 0x7c, 0x58, 0x00, 0x00, 0x01, 0x00, 0xbf, 0x00, 0x00, 0x05, 0x73, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x31, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40, 0x0e, 0x00, 0x01, 0x00, 0x02,
+	0x00, 0x01, 0x02, 0x67, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0xb5, 0x11, 0x11, 0x10, 0x03,
+	0x07, 0x19, 0x2b, 0x21, 0x23, 0x01, 0x21, 0x07, 0x21, 0x01, 0x91, 0xd2, 0x01, 0x27, 0x03, 0x8d,
+	0x1f, 0xfd, 0x45, 0x05, 0xc8, 0x9d, 0x00, 0x00, 0x00, 0x02, 0xff, 0xf9, 0xfe, 0x75, 0x05, 0xb2,
+	0x05, 0xc8, 0x00, 0x0e, 0x00, 0x15, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x00,
+	0x07, 0x07, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04, 0x5f,
+	0x00, 0x04, 0x04, 0x1b, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03,
+	0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x01, 0x00, 0x07, 0x00, 0x01, 0x07, 0x67, 0x06,
+	0x02, 0x02, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00,
+	0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x12,
+	0x11, 0x10, 0x0f, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x09, 0x07, 0x1b, 0x2b,
+	0x03, 0x13, 0x33, 0x12, 0x12, 0x13, 0x37, 0x21, 0x01, 0x33, 0x03, 0x23, 0x13, 0x21, 0x03, 0x13,
+	0x21, 0x13, 0x21, 0x07, 0x02, 0x00, 0x07, 0x6d, 0x39, 0xe6, 0xf4, 0x4e, 0x1b, 0x02, 0xd0, 0xfe,
+	0xf8, 0xaf, 0x6e, 0xc3, 0x4f, 0xfc, 0x93, 0x4f, 0xb7, 0x02, 0x67, 0xe9, 0xfe, 0xc0, 0x04, 0x41,
+	0xfe, 0xfa, 0xfe, 0x75, 0x02, 0x28, 0x01, 0x10, 0x02, 0x0a, 0x01, 0x88, 0x89, 0xfa, 0xd5, 0xfd,
+	0xd8, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x28, 0x04, 0x91, 0x18, 0xfe, 0xbe, 0xfd, 0xc4, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xc9, 0x00, 0x00, 0x06, 0x21, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05,
+	0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1d,
+	0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07,
+	0xc9, 0x01, 0x27, 0x04, 0x31, 0x1f, 0xfc, 0xa1, 0x5f, 0x02, 0xfc, 0x1f, 0xfd, 0x04, 0x6b, 0x03,
+	0x8b, 0x1f, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x00, 0x00, 0x01, 0x00, 0x88,
+	0x00, 0x00, 0x07, 0xde, 0x05, 0xc9, 0x00, 0x46, 0x00, 0x67, 0xb7, 0x38, 0x26, 0x12, 0x03, 0x01,
+	0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x0b, 0x0a, 0x02, 0x01,
+	0x00, 0x05, 0x01, 0x67, 0x08, 0x01, 0x03, 0x03, 0x04, 0x61, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1a,
+	0x4d, 0x09, 0x02, 0x02, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40, 0x1d, 0x07, 0x06, 0x02, 0x04,
+	0x08, 0x01, 0x03, 0x05, 0x04, 0x03, 0x69, 0x00, 0x05, 0x0b, 0x0a, 0x02, 0x01, 0x00, 0x05, 0x01,
+	0x67, 0x09, 0x02, 0x02, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x00, 0x46,
+	0x00, 0x46, 0x40, 0x3f, 0x11, 0x29, 0x11, 0x16, 0x21, 0x1d, 0x16, 0x11, 0x11, 0x0c, 0x07, 0x1f,
+	0x2b, 0x01, 0x03, 0x23, 0x13, 0x23, 0x06, 0x07, 0x06, 0x03, 0x06, 0x07, 0x23, 0x37, 0x36, 0x37,
+	0x37, 0x36, 0x36, 0x37, 0x26, 0x26, 0x27, 0x27, 0x26, 0x26, 0x23, 0x37, 0x37, 0x32, 0x16, 0x1f,
+	0x02, 0x16, 0x16, 0x17, 0x13, 0x33, 0x03, 0x36, 0x36, 0x37, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33,
+	0x17, 0x07, 0x22, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17, 0x17,
+	0x23, 0x26, 0x27, 0x02, 0x27, 0x26, 0x27, 0x04, 0xab, 0x8b, 0xc6, 0x8b, 0x7e, 0x65, 0x47, 0x61,
+	0xa3, 0x40, 0x17, 0xd8, 0x1f, 0x57, 0x49, 0x46, 0x6e, 0x99, 0x70, 0x31, 0x32, 0x27, 0x16, 0x25,
+	0x39, 0x3b, 0x1f, 0x15, 0x67, 0x72, 0x33, 0x12, 0x18, 0x24, 0x3c, 0x6d, 0x7f, 0xc6, 0x7f, 0x6f,
+	0x60, 0x4c, 0x26, 0x12, 0x2c, 0x77, 0xa4, 0x67, 0x15, 0x1f, 0x3b, 0x57, 0x59, 0x34, 0x5d, 0x5a,
+	0x3d, 0x62, 0x5f, 0x26, 0x17, 0x1a, 0x21, 0x0d, 0xd8, 0x0a, 0x14, 0x32, 0x2d, 0x21, 0x7b, 0x02,
+	0xb9, 0xfd, 0x47, 0x02, 0xb9, 0x2e, 0x5f, 0x82, 0xfe, 0xea, 0x6f, 0x25, 0x32, 0x87, 0x78, 0x70,
+	0xb4, 0x94, 0x21, 0x20, 0x61, 0x88, 0x4e, 0x81, 0x4c, 0x9a, 0x01, 0x7f, 0xab, 0x40, 0x51, 0x78,
+	0x49, 0x03, 0x02, 0x7e, 0xfd, 0x82, 0x08, 0x4c, 0x70, 0x36, 0x1b, 0x40, 0xab, 0x7f, 0x01, 0x9a,
+	0x4c, 0x81, 0x4e, 0x88, 0x61, 0x20, 0x21, 0x94, 0xb4, 0x70, 0x78, 0x87, 0x32, 0x26, 0x6e, 0x01,
+	0x14, 0x84, 0x5f, 0x2e, 0x00, 0x01, 0x00, 0x7d, 0xff, 0xdb, 0x05, 0x28, 0x05, 0xed, 0x00, 0x23,
+	0x00, 0x5f, 0x40, 0x0e, 0x14, 0x01, 0x02, 0x03, 0x1c, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x29, 0x23, 0x24, 0x21, 0x24, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x32, 0x36,
+	0x37, 0x36, 0x26, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x02, 0x05, 0x16, 0x16,
+	0x07, 0x06, 0x00, 0x23, 0x22, 0x7d, 0x25, 0xd8, 0xbe, 0x97, 0xd4, 0x19, 0x1e, 0xce, 0xe5, 0x33,
+	0x1e, 0x31, 0xcd, 0xff, 0x1b, 0x16, 0x83, 0x98, 0xb3, 0xe0, 0x22, 0xcc, 0xd0, 0xf3, 0xe5, 0x22,
+	0x35, 0xfe, 0xab, 0xa8, 0x98, 0x1e, 0x27, 0xfe, 0x8e, 0xea, 0xe6, 0x19, 0xb9, 0x56, 0x98, 0x7e,
+	0x98, 0x9f, 0x94, 0x95, 0x88, 0x6c, 0x6c, 0x4d, 0xaa, 0x3e, 0xb9, 0xaa, 0xfe, 0xf9, 0x5f, 0x1c,
+	0xcb, 0x98, 0xc3, 0xfe, 0xf9, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xb5, 0x00, 0x00, 0x06, 0x48,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02,
+	0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11,
+	0x05, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33, 0x01, 0x23, 0x13, 0x01, 0xb5, 0x01,
+	0x27, 0xd2, 0xeb, 0x03, 0xb3, 0xd2, 0xfe, 0xd9, 0xd2, 0xeb, 0xfc, 0x4d, 0x05, 0xc8, 0xfb, 0x66,
+	0x04, 0x9a, 0xfa, 0x38, 0x04, 0x9a, 0xfb, 0x66, 0x00, 0x02, 0x00, 0xb5, 0x00, 0x00, 0x06, 0x48,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x1b, 0x00, 0x90, 0x40, 0x0b, 0x0d, 0x01, 0x05, 0x04, 0x08, 0x03,
+	0x02, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x05,
+	0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a,
+	0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01,
+	0x01, 0x00, 0x00, 0x1a, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1f,
+	0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x01, 0x01, 0x00, 0x07, 0x02, 0x07, 0x00, 0x02, 0x80, 0x00,
+	0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x17, 0x14, 0x13, 0x10, 0x0e, 0x0b, 0x0a, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x09, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x01, 0x33, 0x01, 0x23,
+	0x13, 0x01, 0x01, 0x33, 0x06, 0x15, 0x16, 0x33, 0x32, 0x37, 0x36, 0x37, 0x33, 0x06, 0x07, 0x06,
+	0x21, 0x20, 0x35, 0x34, 0xb5, 0x01, 0x27, 0xd2, 0xeb, 0x03, 0xb3, 0xd2, 0xfe, 0xd9, 0xd2, 0xeb,
+	0xfc, 0x4d, 0x01, 0x85, 0xa1, 0x0e, 0x09, 0x85, 0x85, 0x37, 0x0e, 0x0e, 0xa1, 0x0f, 0x0f, 0x55,
+	0xfe, 0xe6, 0xfe, 0xe6, 0x05, 0xc8, 0xfb, 0x66, 0x04, 0x9a, 0xfa, 0x38, 0x04, 0x9a, 0xfb, 0x66,
+	0x07, 0x8f, 0x48, 0x22, 0x73, 0x73, 0x22, 0x48, 0x47, 0x1e, 0xdc, 0xcf, 0x2b, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x05, 0x77, 0x05, 0xc8, 0x00, 0x31, 0x00, 0x5a, 0xb5, 0x1e,
+	0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05,
+	0x04, 0x01, 0x05, 0x68, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x07,
+	0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x19, 0x02, 0x01, 0x00, 0x00, 0x03, 0x01,
+	0x00, 0x03, 0x69, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x07, 0x06, 0x02, 0x04, 0x04,
+	0x1d, 0x04, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x31, 0x00, 0x31, 0x30, 0x2f, 0x28, 0x27,
+	0x11, 0x49, 0x21, 0x11, 0x08, 0x07, 0x1a, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x33, 0x32, 0x3e, 0x02,
+	0x37, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x32, 0x37, 0x07, 0x22, 0x0e, 0x02, 0x07, 0x07, 0x0e, 0x03,
+	0x07, 0x1e, 0x03, 0x17, 0x17, 0x16, 0x16, 0x17, 0x23, 0x2e, 0x05, 0x27, 0x23, 0x03, 0xb0, 0x01,
+	0x27, 0xd2, 0x7f, 0x1e, 0x29, 0x4a, 0x48, 0x48, 0x26, 0x69, 0x34, 0x54, 0x55, 0x62, 0x43, 0x01,
+	0x0d, 0x0d, 0x1f, 0x2a, 0x3f, 0x37, 0x33, 0x1d, 0x58, 0x23, 0x3e, 0x45, 0x4f, 0x35, 0x45, 0x57,
+	0x3d, 0x2a, 0x18, 0x20, 0x19, 0x36, 0x1c, 0xdc, 0x16, 0x29, 0x2c, 0x33, 0x3c, 0x47, 0x2b, 0x5a,
+	0x88, 0x05, 0xc8, 0xfd, 0x85, 0x26, 0x42, 0x57, 0x32, 0x89, 0x44, 0x61, 0x3e, 0x1d, 0x01, 0x9a,
+	0x16, 0x2a, 0x3c, 0x26, 0x73, 0x2e, 0x4d, 0x42, 0x39, 0x1a, 0x13, 0x37, 0x52, 0x73, 0x4f, 0x6c,
+	0x54, 0x9e, 0x4d, 0x3a, 0x89, 0x8d, 0x88, 0x71, 0x53, 0x11, 0xfd, 0x53, 0x00, 0x01, 0x00, 0x1e,
+	0x00, 0x00, 0x05, 0xcc, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x3e, 0xb3, 0x0a, 0x01, 0x00, 0x49, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x1a,
+	0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40, 0x0f, 0x03, 0x01, 0x02, 0x00, 0x01, 0x00,
+	0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x11,
+	0x00, 0x11, 0x11, 0x11, 0x04, 0x07, 0x18, 0x2b, 0x01, 0x01, 0x23, 0x01, 0x21, 0x0f, 0x02, 0x02,
+	0x00, 0x05, 0x37, 0x36, 0x36, 0x37, 0x36, 0x13, 0x37, 0x05, 0xcc, 0xfe, 0xd9, 0xd2, 0x01, 0x08,
+	0xfe, 0x64, 0x06, 0x1b, 0x3a, 0x63, 0xfe, 0xce, 0xfe, 0xcf, 0x1e, 0x88, 0x9d, 0x37, 0x5f, 0x9b,
+	0x13, 0x05, 0xc8, 0xfa, 0x38, 0x05, 0x2e, 0x21, 0x82, 0xf8, 0xfe, 0x0e, 0xfe, 0x77, 0x18, 0x9a,
+	0x10, 0x6f, 0x7a, 0xce, 0x03, 0x09, 0x5e, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x07, 0x37,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4d, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01,
+	0x00, 0x00, 0x1a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x13, 0x01,
+	0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x02, 0x03, 0x85, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06,
+	0x07, 0x1a, 0x2b, 0x33, 0x01, 0x21, 0x13, 0x01, 0x21, 0x01, 0x23, 0x13, 0x01, 0x23, 0x03, 0x03,
+	0xb0, 0x01, 0x27, 0x01, 0x23, 0xb2, 0x02, 0x87, 0x01, 0x04, 0xfe, 0xd9, 0xc4, 0xf0, 0xfd, 0x8f,
+	0xcb, 0xaa, 0xf1, 0x05, 0xc8, 0xfb, 0x87, 0x04, 0x79, 0xfa, 0x38, 0x04, 0xb3, 0xfb, 0xb0, 0x04,
+	0x54, 0xfb, 0x49, 0x00, 0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x54, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x48, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x68, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x03, 0x21, 0x13,
+	0x33, 0x01, 0x23, 0x13, 0x21, 0x03, 0xb0, 0x01, 0x27, 0xd2, 0x7c, 0x02, 0xda, 0x7c, 0xd1, 0xfe,
+	0xd9, 0xd1, 0x8b, 0xfd, 0x26, 0x8b, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb,
+	0xfd, 0x45, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb5, 0xff, 0xdb, 0x06, 0xc2, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x1f, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x20,
+	0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x20, 0x00, 0x03, 0x02, 0x00, 0x25, 0x32, 0x00,
+	0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x03, 0x16, 0xfe, 0xc7, 0xfe, 0xd8, 0x46,
+	0x47, 0x01, 0xd4, 0x01, 0x41, 0x01, 0x40, 0x01, 0x2b, 0x46, 0x48, 0xfe, 0x2c, 0xfe, 0xd8, 0xe9,
+	0x01, 0x3e, 0x3c, 0x3a, 0xbc, 0xe2, 0xe3, 0xfe, 0xc3, 0x3b, 0x3a, 0xb9, 0x25, 0x01, 0xaa, 0x01,
+	0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45,
+	0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xb0, 0x00, 0x00, 0x06, 0x4d, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x34, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02,
+	0x67, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0xb6, 0x11, 0x11, 0x11, 0x10, 0x04, 0x07,
+	0x1a, 0x2b, 0x01, 0x21, 0x01, 0x23, 0x01, 0x21, 0x01, 0x23, 0x01, 0xd7, 0x04, 0x76, 0xfe, 0xd9,
+	0xd1, 0x01, 0x08, 0xfd, 0x2d, 0xfe, 0xf8, 0xd2, 0x05, 0xc8, 0xfa, 0x38, 0x05, 0x2b, 0xfa, 0xd5,
+	0x00, 0x02, 0x00, 0xb2, 0x00, 0x00, 0x06, 0x03, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00,
+	0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02,
+	0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13,
+	0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x07, 0x18, 0x2b, 0x33, 0x01, 0x21,
+	0x32, 0x16, 0x17, 0x16, 0x07, 0x02, 0x21, 0x21, 0x03, 0x13, 0x21, 0x20, 0x13, 0x36, 0x26, 0x23,
+	0x21, 0xb2, 0x01, 0x27, 0x02, 0x1c, 0xe4, 0xbd, 0x31, 0x3c, 0x22, 0x67, 0xfd, 0x87, 0xfe, 0xf4,
+	0x71, 0x91, 0x01, 0x03, 0x01, 0xa4, 0x44, 0x1e, 0x98, 0xf2, 0xfe, 0xf8, 0x05, 0xc8, 0x34, 0x4d,
+	0x60, 0xad, 0xfd, 0xfe, 0xfd, 0xc8, 0x02, 0xd7, 0x01, 0x54, 0x99, 0x67, 0x00, 0x01, 0x00, 0xc6,
+	0xff, 0xdb, 0x06, 0x73, 0x05, 0xed, 0x00, 0x15, 0x00, 0x49, 0x40, 0x0b, 0x0a, 0x01, 0x02, 0x01,
+	0x15, 0x0b, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x20, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0xb6, 0x24, 0x23, 0x24, 0x21, 0x04,
+	0x07, 0x1a, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x13, 0x12, 0x00, 0x21, 0x32, 0x17, 0x07, 0x24,
+	0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0x21, 0x32, 0x25, 0x05, 0x62, 0xf2, 0xfe, 0xf2, 0xfe, 0x92,
+	0xfe, 0xd2, 0x4c, 0x4c, 0x01, 0xd4, 0x01, 0x6f, 0xd5, 0xfd, 0x28, 0xfe, 0xe3, 0xb4, 0xff, 0xfe,
+	0xb5, 0x3d, 0x3a, 0xde, 0x01, 0x05, 0xdf, 0x01, 0x0b, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01,
+	0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x00,
+	0x00, 0x01, 0x01, 0x27, 0x00, 0x00, 0x06, 0x00, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x21, 0x01, 0x21, 0x37, 0x21, 0x07,
+	0x21, 0x01, 0x02, 0x13, 0x01, 0x08, 0xfe, 0x0c, 0x1f, 0x04, 0xba, 0x1f, 0xfe, 0x0c, 0xfe, 0xf8,
+	0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6d, 0xff, 0xdb, 0x06, 0x34,
+	0x05, 0xc8, 0x00, 0x10, 0x00, 0x3d, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x11, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x01, 0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03,
+	0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0xb6, 0x21, 0x23, 0x13, 0x11, 0x04,
+	0x07, 0x1a, 0x2b, 0x01, 0x01, 0x33, 0x13, 0x33, 0x01, 0x33, 0x01, 0x06, 0x04, 0x23, 0x23, 0x37,
+	0x33, 0x32, 0x36, 0x37, 0x02, 0x98, 0xfe, 0xc6, 0xea, 0xf3, 0x04, 0x02, 0x34, 0xc1, 0xfc, 0xdd,
+	0x96, 0xfe, 0xf5, 0xdd, 0x26, 0x23, 0x29, 0x9e, 0xb2, 0x64, 0x01, 0xb3, 0x04, 0x15, 0xfc, 0xd9,
+	0x03, 0x27, 0xfb, 0x83, 0xd6, 0x9a, 0xad, 0x61, 0x8c, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xb6,
+	0x00, 0x00, 0x06, 0x9c, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x18, 0x00, 0x1f, 0x00, 0x6a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x09, 0x01, 0x06, 0x07, 0x01, 0x06, 0x6a, 0x08,
+	0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x0a,
+	0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01,
+	0x01, 0x09, 0x01, 0x06, 0x07, 0x01, 0x06, 0x6a, 0x08, 0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05,
+	0x07, 0x00, 0x69, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x1a, 0x12, 0x12, 0x00,
+	0x00, 0x1f, 0x1e, 0x1a, 0x19, 0x12, 0x18, 0x12, 0x18, 0x14, 0x13, 0x00, 0x11, 0x00, 0x11, 0x14,
+	0x11, 0x11, 0x14, 0x11, 0x0c, 0x07, 0x1b, 0x2b, 0x21, 0x37, 0x20, 0x00, 0x37, 0x36, 0x00, 0x21,
+	0x37, 0x33, 0x07, 0x20, 0x00, 0x07, 0x06, 0x00, 0x21, 0x07, 0x03, 0x13, 0x22, 0x06, 0x07, 0x06,
+	0x16, 0x21, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x02, 0xb9, 0x2c, 0xfe, 0xe7, 0xfe, 0xea, 0x2e,
+	0x2f, 0x01, 0x88, 0x01, 0x19, 0x2c, 0xb9, 0x2c, 0x01, 0x19, 0x01, 0x16, 0x2f, 0x2e, 0xfe, 0x78,
+	0xfe, 0xe7, 0x2c, 0x6f, 0x92, 0xbd, 0xeb, 0x23, 0x22, 0x9e, 0x01, 0x76, 0xbd, 0xeb, 0x22, 0x23,
+	0x9e, 0xbd, 0xde, 0x01, 0x1f, 0xe7, 0xe8, 0x01, 0x1e, 0xde, 0xde, 0xfe, 0xe2, 0xe8, 0xe7, 0xfe,
+	0xe1, 0xde, 0x01, 0x77, 0x02, 0xda, 0xbf, 0xae, 0xae, 0xbf, 0xbf, 0xae, 0xae, 0xbf, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x27, 0x00, 0x00, 0x06, 0x61, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09,
+	0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e,
+	0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33,
+	0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x01, 0x27, 0x02, 0xb3, 0xfe, 0x8c,
+	0xf8, 0x01, 0x1e, 0x02, 0x1e, 0xc7, 0xfd, 0x61, 0x01, 0x83, 0xf8, 0xfe, 0xd3, 0xfd, 0xcd, 0x02,
+	0xdf, 0x02, 0xe9, 0xfd, 0xc1, 0x02, 0x3f, 0xfd, 0x3a, 0xfc, 0xfe, 0x02, 0x56, 0xfd, 0xaa, 0x00,
+	0x00, 0x01, 0x00, 0xb0, 0xfe, 0x75, 0x06, 0x4d, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x58, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1e, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05,
+	0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04,
+	0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x1e, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x01,
+	0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04,
+	0x04, 0x1e, 0x04, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x21, 0x01, 0x33, 0x01, 0x33, 0x03,
+	0x23, 0x13, 0xb0, 0x01, 0x27, 0xd2, 0xfe, 0xf8, 0x02, 0xd3, 0x01, 0x08, 0xd1, 0xfe, 0xf8, 0x80,
+	0x6e, 0xc3, 0x4f, 0x05, 0xc8, 0xfa, 0xd4, 0x05, 0x2c, 0xfa, 0xd4, 0xfd, 0xd9, 0x01, 0x8b, 0x00,
+	0x00, 0x01, 0x00, 0xf9, 0x00, 0x00, 0x05, 0xdd, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x4c, 0xb5, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00,
+	0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x1a, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1b, 0x04,
+	0x4e, 0x1b, 0x40, 0x15, 0x03, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02,
+	0x00, 0x6a, 0x05, 0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x11,
+	0x00, 0x11, 0x12, 0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21, 0x13, 0x06, 0x23, 0x22, 0x26,
+	0x37, 0x13, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x01, 0x03, 0xe4, 0x77, 0xce,
+	0xe4, 0xf6, 0xba, 0x31, 0x62, 0xd2, 0x60, 0x24, 0x78, 0xad, 0xc2, 0xbc, 0x8e, 0xd2, 0xfe, 0xd9,
+	0x02, 0x54, 0x5a, 0xeb, 0xf9, 0x01, 0xea, 0xfe, 0x1c, 0xb2, 0x8c, 0x59, 0x02, 0xc9, 0xfa, 0x38,
+	0x00, 0x01, 0x00, 0xb5, 0x00, 0x00, 0x07, 0xdd, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x3d, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x13, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x01, 0x01, 0x01,
+	0x03, 0x60, 0x00, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x04, 0x02, 0x02, 0x00, 0x01,
+	0x00, 0x85, 0x05, 0x01, 0x01, 0x01, 0x03, 0x60, 0x00, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40,
+	0x09, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x33, 0x01, 0x21, 0x01,
+	0x33, 0x01, 0x21, 0x01, 0x33, 0x01, 0x21, 0x04, 0x76, 0xcd, 0xfe, 0xf8, 0x01, 0xcd, 0x01, 0x08,
+	0xcd, 0xfe, 0xd9, 0xf9, 0xff, 0x01, 0x27, 0xcd, 0xfe, 0xf8, 0x01, 0xcd, 0x05, 0xc8, 0xfa, 0xd5,
+	0x05, 0x2b, 0xfa, 0x38, 0x05, 0xc8, 0xfa, 0xd5, 0x00, 0x01, 0x00, 0xb5, 0xfe, 0x75, 0x07, 0xd9,
+	0x05, 0xc8, 0x00, 0x0f, 0x00, 0x59, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x02, 0x02,
+	0x00, 0x00, 0x1a, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x05, 0x60, 0x00, 0x05, 0x05, 0x1b, 0x4d,
+	0x07, 0x03, 0x02, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x20,
+	0x06, 0x02, 0x02, 0x00, 0x01, 0x00, 0x85, 0x07, 0x03, 0x02, 0x01, 0x01, 0x05, 0x60, 0x00, 0x05,
+	0x05, 0x1d, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e,
+	0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x08, 0x07, 0x1e, 0x2b, 0x01,
+	0x33, 0x01, 0x21, 0x01, 0x33, 0x01, 0x33, 0x03, 0x23, 0x13, 0x21, 0x01, 0x33, 0x01, 0x21, 0x04,
+	0x74, 0xcd, 0xfe, 0xf8, 0x01, 0xcb, 0x01, 0x08, 0xcd, 0xfe, 0xf8, 0x88, 0x6e, 0xc3, 0x4f, 0xfa,
+	0x3e, 0x01, 0x27, 0xcd, 0xfe, 0xf8, 0x01, 0xcb, 0x05, 0xc8, 0xfa, 0xd5, 0x05, 0x2b, 0xfa, 0xd4,
+	0xfd, 0xd9, 0x01, 0x8b, 0x05, 0xc8, 0xfa, 0xd5, 0x00, 0x02, 0x01, 0x31, 0x00, 0x00, 0x06, 0x86,
+	0x05, 0xc8, 0x00, 0x10, 0x00, 0x1d, 0x00, 0x58, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67,
+	0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00,
+	0x00, 0x1d, 0x1b, 0x13, 0x11, 0x00, 0x10, 0x00, 0x0f, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b,
+	0x21, 0x01, 0x21, 0x37, 0x21, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x27, 0x21,
+	0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x21, 0x01, 0xdc, 0x01, 0x08, 0xfe, 0x4d, 0x1f,
+	0x02, 0x85, 0x7f, 0xf4, 0x8b, 0xe0, 0x95, 0x3c, 0x18, 0x1a, 0x83, 0xbe, 0xed, 0x84, 0xd6, 0x01,
+	0x04, 0x6a, 0xa0, 0x70, 0x43, 0x0f, 0x0e, 0x19, 0x56, 0x93, 0x6c, 0xfe, 0xfc, 0x05, 0x2b, 0x9d,
+	0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48,
+	0x66, 0x41, 0x1e, 0x00, 0x00, 0x03, 0x00, 0xb0, 0x00, 0x00, 0x07, 0xa2, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x12, 0x00, 0x1f, 0x00, 0x5e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x00,
+	0x06, 0x05, 0x03, 0x06, 0x68, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x5f,
+	0x08, 0x04, 0x07, 0x03, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x03,
+	0x00, 0x85, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x68, 0x00, 0x05, 0x05, 0x01, 0x5f, 0x08,
+	0x04, 0x07, 0x03, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x04, 0x04, 0x00, 0x00, 0x1f,
+	0x1d, 0x15, 0x13, 0x04, 0x12, 0x04, 0x11, 0x09, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x09, 0x07, 0x17, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x21, 0x01, 0x33, 0x03, 0x33, 0x32, 0x1e, 0x02,
+	0x07, 0x0e, 0x03, 0x23, 0x27, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x05,
+	0xa9, 0x01, 0x27, 0xd2, 0xfe, 0xd9, 0xfa, 0x35, 0x01, 0x27, 0xd2, 0x7f, 0xbf, 0x8b, 0xe0, 0x95,
+	0x3c, 0x18, 0x1a, 0x83, 0xbe, 0xed, 0x84, 0xa1, 0xcf, 0x6a, 0xa0, 0x70, 0x43, 0x0f, 0x0e, 0x19,
+	0x56, 0x93, 0x6c, 0xcf, 0x05, 0xc8, 0xfa, 0x38, 0x05, 0xc8, 0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b,
+	0x81, 0xa4, 0x5e, 0x23, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xb1, 0x00, 0x00, 0x05, 0x5b, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x1b, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x00,
+	0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x1b, 0x19, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33,
+	0x01, 0x33, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x27, 0x21, 0x32, 0x3e, 0x02,
+	0x37, 0x36, 0x2e, 0x02, 0x23, 0x21, 0xb1, 0x01, 0x27, 0xd2, 0x7f, 0xf4, 0x8b, 0xe0, 0x95, 0x3c,
+	0x18, 0x1a, 0x83, 0xbe, 0xed, 0x84, 0xd7, 0x01, 0x05, 0x6a, 0xa0, 0x70, 0x43, 0x0f, 0x0e, 0x19,
+	0x56, 0x93, 0x6c, 0xfe, 0xfb, 0x05, 0xc8, 0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e,
+	0x23, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x00, 0x00, 0x00, 0x01, 0x00, 0xce,
+	0xff, 0xdb, 0x06, 0x4b, 0x05, 0xed, 0x00, 0x18, 0x00, 0x5b, 0x40, 0x0a, 0x0e, 0x01, 0x02, 0x03,
+	0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00,
+	0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x23, 0x22, 0x11, 0x12,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16, 0x33, 0x32, 0x00, 0x37, 0x21, 0x37, 0x21, 0x36,
+	0x26, 0x23, 0x22, 0x05, 0x37, 0x24, 0x33, 0x20, 0x00, 0x03, 0x02, 0x00, 0x21, 0x20, 0xce, 0x24,
+	0xd6, 0xd3, 0xea, 0x01, 0x62, 0x33, 0xfd, 0x24, 0x1f, 0x02, 0xd5, 0x16, 0xcc, 0xe3, 0xcc, 0xfe,
+	0xfc, 0x26, 0x01, 0x0a, 0xce, 0x01, 0x58, 0x01, 0x2c, 0x4a, 0x4a, 0xfe, 0x37, 0xfe, 0xa6, 0xfe,
+	0xfe, 0x4c, 0xb4, 0x81, 0x01, 0x3c, 0xfe, 0x9a, 0xfd, 0xfd, 0x5e, 0xc0, 0x3e, 0xfe, 0x67, 0xfe,
+	0x8f, 0xfe, 0x8c, 0xfe, 0x6c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb1, 0xff, 0xdb, 0x08, 0x9e,
+	0x05, 0xed, 0x00, 0x12, 0x00, 0x1e, 0x00, 0x77, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00,
+	0x01, 0x00, 0x04, 0x06, 0x01, 0x04, 0x68, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x07, 0x07, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x1f, 0x4d, 0x08, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x09, 0x01, 0x06, 0x06,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x20, 0x03, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x00, 0x02, 0x07, 0x02,
+	0x00, 0x07, 0x80, 0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69, 0x00, 0x01, 0x00, 0x04, 0x06,
+	0x01, 0x04, 0x68, 0x08, 0x01, 0x05, 0x05, 0x1d, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x22, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x14, 0x13, 0x00, 0x00, 0x1a, 0x18, 0x13, 0x1e,
+	0x14, 0x1e, 0x00, 0x12, 0x00, 0x12, 0x12, 0x24, 0x22, 0x11, 0x11, 0x0a, 0x07, 0x1b, 0x2b, 0x33,
+	0x01, 0x33, 0x03, 0x21, 0x12, 0x00, 0x21, 0x20, 0x12, 0x03, 0x02, 0x00, 0x21, 0x20, 0x02, 0x13,
+	0x21, 0x03, 0x25, 0x32, 0x00, 0x13, 0x12, 0x02, 0x23, 0x22, 0x00, 0x03, 0x02, 0x12, 0xb1, 0x01,
+	0x27, 0xd2, 0x84, 0x01, 0x71, 0x5a, 0x01, 0x8a, 0x01, 0x0e, 0x01, 0x1e, 0xf7, 0x48, 0x48, 0xfe,
+	0x62, 0xfe, 0xe2, 0xfe, 0xf3, 0xfc, 0x2f, 0xfe, 0x8f, 0x84, 0x03, 0xe9, 0xbe, 0x01, 0x14, 0x3b,
+	0x3a, 0x90, 0xb9, 0xb9, 0xfe, 0xed, 0x3b, 0x39, 0x8d, 0x05, 0xc8, 0xfd, 0x6b, 0x01, 0x4d, 0x01,
+	0x6d, 0xfe, 0x5f, 0xfe, 0x98, 0xfe, 0x98, 0xfe, 0x5f, 0x01, 0x75, 0x01, 0x46, 0xfd, 0x6a, 0x75,
+	0x01, 0x49, 0x01, 0x29, 0x01, 0x22, 0x01, 0x4a, 0xfe, 0xb5, 0xfe, 0xdc, 0xfe, 0xdf, 0xfe, 0xb2,
+	0x00, 0x02, 0x00, 0x6e, 0x00, 0x00, 0x06, 0x53, 0x05, 0xc8, 0x00, 0x18, 0x00, 0x21, 0x00, 0x4e,
+	0xb5, 0x0e, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05,
+	0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02,
+	0x04, 0x67, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01,
+	0x4e, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11, 0x2d, 0x15, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x21,
+	0x06, 0x01, 0x06, 0x07, 0x07, 0x21, 0x36, 0x3f, 0x03, 0x36, 0x37, 0x26, 0x26, 0x37, 0x36, 0x37,
+	0x36, 0x21, 0x21, 0x01, 0x23, 0x01, 0x21, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x33, 0x04, 0xd7,
+	0xfe, 0xe4, 0xb3, 0xfe, 0xf9, 0x24, 0x3d, 0x22, 0xfe, 0xf0, 0x68, 0x69, 0x39, 0x23, 0x4d, 0x96,
+	0x89, 0x95, 0xa0, 0x1d, 0x27, 0xa8, 0x7e, 0x01, 0x27, 0x01, 0xf0, 0xfe, 0xd9, 0xd2, 0x01, 0x08,
+	0xfe, 0xe4, 0xa3, 0xbd, 0x1a, 0x1c, 0xab, 0xbe, 0xdd, 0x02, 0x75, 0x8d, 0xfe, 0xba, 0x2d, 0x4b,
+	0x2a, 0x63, 0x7e, 0x43, 0x29, 0x5a, 0xaf, 0x46, 0x1f, 0xe0, 0x93, 0xc1, 0x7c, 0x5d, 0xfa, 0x38,
+	0x05, 0x2e, 0x83, 0x82, 0x8d, 0x8d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x96, 0xff, 0xe7, 0x04, 0xbc,
+	0x04, 0x56, 0x00, 0x09, 0x00, 0x17, 0x00, 0xa0, 0xb5, 0x01, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x14, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x21,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x21, 0x4d,
+	0x00, 0x05, 0x05, 0x1b, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x04, 0x1c, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x1b, 0x4d, 0x00, 0x01, 0x01, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x04, 0x1c, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x1d, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x09, 0x11, 0x11,
+	0x24, 0x22, 0x23, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x13, 0x26, 0x23, 0x20, 0x03, 0x06, 0x16,
+	0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x33, 0x03, 0x23,
+	0x03, 0x6a, 0x6f, 0x83, 0x44, 0xfe, 0xe4, 0x57, 0x23, 0x46, 0x60, 0x81, 0xa3, 0xa3, 0xce, 0xaa,
+	0x95, 0x31, 0x39, 0x01, 0x49, 0xf5, 0x5f, 0x5a, 0xc5, 0xd9, 0xc5, 0x01, 0x7e, 0x02, 0x2b, 0x19,
+	0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfb, 0xc2,
+	0x00, 0x02, 0x00, 0xa5, 0xff, 0xe7, 0x05, 0x19, 0x06, 0x60, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2d,
+	0x40, 0x2a, 0x11, 0x01, 0x02, 0x4a, 0x00, 0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x69, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x22, 0x01, 0x4e, 0x24, 0x25, 0x33, 0x34, 0x24, 0x21, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x36, 0x33,
+	0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x02, 0x13, 0x12, 0x00, 0x21, 0x33, 0x32, 0x37, 0x07,
+	0x06, 0x23, 0x23, 0x22, 0x06, 0x03, 0x07, 0x02, 0x12, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x01, 0xd0, 0xc8, 0xed, 0xba, 0xa8, 0x30, 0x34, 0xfe, 0xa9, 0xe4, 0xf2, 0xb1, 0x49, 0x5c,
+	0x01, 0x68, 0x01, 0x2a, 0x2a, 0x93, 0x80, 0x20, 0x66, 0x94, 0x18, 0xd2, 0xeb, 0x74, 0x08, 0x37,
+	0x65, 0x94, 0x84, 0xc8, 0x26, 0x25, 0x5c, 0x80, 0xad, 0x03, 0x4f, 0xef, 0xfe, 0xda, 0xf0, 0xfe,
+	0xfd, 0xfe, 0xc2, 0x01, 0x6d, 0x01, 0x6d, 0x01, 0xcb, 0x01, 0x9f, 0x35, 0x9f, 0x2a, 0xfd, 0xfe,
+	0x2c, 0x23, 0xfe, 0xec, 0xfe, 0xec, 0xe2, 0xbf, 0xb7, 0xb8, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0x6f, 0x04, 0x3e, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x63, 0xb5, 0x08,
+	0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03,
+	0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00,
+	0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07, 0x07, 0x17,
+	0x2b, 0x33, 0x13, 0x21, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x06, 0x23,
+	0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x32, 0x37, 0x36, 0x26, 0x23,
+	0x23, 0xa5, 0xd9, 0x01, 0x9d, 0xb6, 0x9e, 0x18, 0x14, 0x8e, 0x70, 0x76, 0x6a, 0x15, 0x1a, 0xe0,
+	0xad, 0xe3, 0x75, 0xa7, 0x88, 0x11, 0x12, 0x88, 0x79, 0x80, 0x1a, 0x86, 0xeb, 0x24, 0x0d, 0x68,
+	0x6a, 0x92, 0x04, 0x3e, 0x76, 0x76, 0x64, 0x8d, 0x2a, 0x29, 0x92, 0x6a, 0x81, 0x91, 0x94, 0x3b,
+	0x55, 0x5b, 0x71, 0x82, 0xb7, 0x40, 0x41, 0x00, 0x00, 0x01, 0x00, 0x97, 0x00, 0x00, 0x03, 0xcf,
+	0x04, 0x3e, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40,
+	0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x07, 0x18,
+	0x2b, 0x33, 0x13, 0x21, 0x07, 0x21, 0x03, 0x97, 0xd9, 0x02, 0x5f, 0x23, 0xfe, 0x66, 0xb6, 0x04,
+	0x3e, 0xad, 0xfc, 0x6f, 0x00, 0x02, 0xff, 0xef, 0xfe, 0xa7, 0x04, 0xb6, 0x04, 0x3e, 0x00, 0x0e,
+	0x00, 0x15, 0x00, 0x8a, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x25, 0x00, 0x07, 0x07, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b,
+	0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53, 0x00,
+	0x07, 0x07, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04, 0x5f,
+	0x00, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1e, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53,
+	0x00, 0x07, 0x07, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x12, 0x11, 0x10,
+	0x0f, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x03, 0x13,
+	0x33, 0x36, 0x12, 0x13, 0x37, 0x21, 0x03, 0x33, 0x03, 0x23, 0x13, 0x21, 0x03, 0x13, 0x21, 0x13,
+	0x21, 0x07, 0x06, 0x02, 0x11, 0x63, 0x59, 0x7c, 0xc3, 0x33, 0x12, 0x02, 0x87, 0xba, 0xa7, 0x64,
+	0xb4, 0x45, 0xfd, 0x17, 0x45, 0xbf, 0x01, 0xe9, 0x9c, 0xfe, 0xd7, 0x04, 0x29, 0xac, 0xfe, 0xa7,
+	0x01, 0xf6, 0xa5, 0x01, 0xa5, 0x01, 0x01, 0x56, 0xfc, 0x5f, 0xfe, 0x0a, 0x01, 0x59, 0xfe, 0xa7,
+	0x01, 0xf6, 0x03, 0x0d, 0x13, 0xcc, 0xfe, 0x83, 0x00, 0x02, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3,
+	0x04, 0x56, 0x00, 0x04, 0x00, 0x15, 0x00, 0x33, 0x40, 0x30, 0x06, 0x01, 0x01, 0x00, 0x04, 0x05,
+	0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x00, 0x00, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d,
+	0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x07, 0x07, 0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03,
+	0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21,
+	0x32, 0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32,
+	0x01, 0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0x02, 0x94, 0x01,
+	0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7,
+	0x3d, 0xfe, 0x7d, 0x00, 0x00, 0x01, 0x00, 0x10, 0x00, 0x00, 0x05, 0xe9, 0x04, 0x3e, 0x00, 0x4b,
+	0x00, 0x68, 0x40, 0x0b, 0x1a, 0x01, 0x03, 0x02, 0x38, 0x13, 0x02, 0x00, 0x03, 0x02, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x01, 0x03, 0x00, 0x68,
+	0x06, 0x04, 0x02, 0x02, 0x02, 0x1c, 0x4d, 0x0a, 0x09, 0x07, 0x03, 0x01, 0x01, 0x1b, 0x01, 0x4e,
+	0x1b, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x01, 0x03, 0x00, 0x68, 0x06, 0x04, 0x02,
+	0x02, 0x02, 0x1c, 0x4d, 0x0a, 0x09, 0x07, 0x03, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x19,
+	0x00, 0x00, 0x00, 0x4b, 0x00, 0x4b, 0x4a, 0x49, 0x41, 0x40, 0x2f, 0x2e, 0x28, 0x27, 0x26, 0x25,
+	0x24, 0x23, 0x1d, 0x1b, 0x18, 0x11, 0x0b, 0x07, 0x18, 0x2b, 0x21, 0x13, 0x23, 0x06, 0x07, 0x06,
+	0x07, 0x06, 0x0f, 0x02, 0x23, 0x36, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x37, 0x26, 0x27, 0x27, 0x26,
+	0x27, 0x26, 0x27, 0x37, 0x33, 0x32, 0x16, 0x17, 0x17, 0x16, 0x16, 0x17, 0x17, 0x13, 0x33, 0x03,
+	0x37, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x33, 0x07, 0x06, 0x07, 0x06, 0x07, 0x07, 0x06,
+	0x07, 0x16, 0x1f, 0x02, 0x16, 0x17, 0x16, 0x17, 0x23, 0x27, 0x27, 0x26, 0x27, 0x26, 0x27, 0x26,
+	0x27, 0x23, 0x03, 0x02, 0x55, 0x63, 0x4b, 0x38, 0x44, 0x04, 0x5f, 0x22, 0x36, 0x3e, 0x14, 0xd4,
+	0x3b, 0x33, 0x35, 0x09, 0x48, 0x37, 0x51, 0x77, 0x4a, 0x26, 0x0b, 0x04, 0x09, 0x19, 0x47, 0x1e,
+	0x12, 0x5b, 0x52, 0x22, 0x0c, 0x1c, 0x38, 0x2d, 0x27, 0x60, 0xc6, 0x60, 0x27, 0x31, 0x58, 0x45,
+	0x23, 0x58, 0x78, 0x5c, 0x11, 0x1e, 0x4b, 0x45, 0x17, 0x0a, 0x1d, 0x5c, 0x5b, 0x6a, 0x25, 0x18,
+	0x23, 0x03, 0x17, 0x17, 0x1f, 0xd5, 0x09, 0x1d, 0x17, 0x10, 0x2f, 0x02, 0x1e, 0x26, 0x4b, 0x63,
+	0x01, 0xf1, 0x2d, 0x5e, 0x06, 0x78, 0x2b, 0x4a, 0x58, 0x1b, 0x48, 0x48, 0x4c, 0x0b, 0x62, 0x4c,
+	0x6b, 0x26, 0x25, 0x8a, 0x2a, 0x0f, 0x26, 0x6e, 0x08, 0x94, 0x5c, 0x88, 0x35, 0x6d, 0x4e, 0x0a,
+	0x01, 0x01, 0xdf, 0xfe, 0x21, 0x01, 0x0a, 0x4e, 0x6d, 0x35, 0x88, 0x5c, 0x94, 0x08, 0x6e, 0x26,
+	0x0f, 0x2a, 0x8a, 0x25, 0x26, 0x6b, 0x4c, 0x62, 0x0b, 0x4c, 0x48, 0x48, 0x1b, 0x58, 0x4a, 0x2b,
+	0x78, 0x06, 0x5e, 0x2d, 0xfe, 0x0f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x65, 0xff, 0xe7, 0x03, 0xfe,
+	0x04, 0x56, 0x00, 0x24, 0x00, 0x37, 0x40, 0x34, 0x14, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x22, 0x05, 0x4e, 0x2a, 0x23, 0x24, 0x21, 0x24, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23,
+	0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x07, 0x06, 0x04,
+	0x23, 0x22, 0x65, 0x21, 0x90, 0x91, 0x73, 0xa4, 0x11, 0x14, 0x8b, 0x9e, 0x2e, 0x1a, 0x35, 0x8c,
+	0x9d, 0x10, 0x10, 0x6f, 0x7e, 0x6a, 0xa5, 0x20, 0x98, 0x99, 0xc4, 0xc1, 0x19, 0x0f, 0x7f, 0x6d,
+	0x6d, 0x5a, 0x13, 0x1e, 0xfe, 0xcd, 0xcb, 0x70, 0x16, 0xa6, 0x3c, 0x69, 0x54, 0x63, 0x63, 0x82,
+	0x4f, 0x4f, 0x4e, 0x4f, 0x37, 0x9f, 0x2e, 0x8c, 0x7d, 0x4f, 0x83, 0x35, 0x24, 0x85, 0x61, 0x93,
+	0xc2, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9c, 0x00, 0x00, 0x04, 0xcb, 0x04, 0x3e, 0x00, 0x09,
+	0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b,
+	0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x07, 0x19, 0x2b,
+	0x33, 0x13, 0x33, 0x03, 0x01, 0x33, 0x03, 0x23, 0x13, 0x01, 0x9c, 0xd9, 0xb9, 0xa5, 0x02, 0x7d,
+	0xc5, 0xd9, 0xb9, 0xa4, 0xfd, 0x85, 0x04, 0x3e, 0xfc, 0xca, 0x03, 0x36, 0xfb, 0xc2, 0x03, 0x36,
+	0xfc, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9c, 0x00, 0x00, 0x04, 0xcb, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x1b, 0x00, 0x8d, 0x40, 0x0b, 0x0d, 0x01, 0x05, 0x04, 0x08, 0x03, 0x02, 0x02, 0x00, 0x02,
+	0x4c, 0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00,
+	0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02,
+	0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04,
+	0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c,
+	0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05,
+	0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x08, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x17,
+	0x14, 0x13, 0x10, 0x0e, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x07, 0x19,
+	0x2b, 0x33, 0x13, 0x33, 0x03, 0x01, 0x33, 0x03, 0x23, 0x13, 0x01, 0x13, 0x33, 0x06, 0x15, 0x16,
+	0x33, 0x32, 0x37, 0x36, 0x37, 0x33, 0x06, 0x07, 0x06, 0x21, 0x20, 0x35, 0x34, 0x9c, 0xd9, 0xb9,
+	0xa5, 0x02, 0x7d, 0xc5, 0xd9, 0xb9, 0xa4, 0xfd, 0x85, 0xcb, 0xa1, 0x0e, 0x09, 0x85, 0x85, 0x37,
+	0x0e, 0x0e, 0xa1, 0x0f, 0x0f, 0x55, 0xfe, 0xe6, 0xfe, 0xe6, 0x04, 0x3e, 0xfc, 0xca, 0x03, 0x36,
+	0xfb, 0xc2, 0x03, 0x36, 0xfc, 0xca, 0x06, 0x44, 0x48, 0x22, 0x73, 0x73, 0x22, 0x48, 0x47, 0x1e,
+	0xdc, 0xcf, 0x2b, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x3b, 0x04, 0x3e, 0x00, 0x2e,
+	0x00, 0x5c, 0xb5, 0x1b, 0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01,
+	0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c,
+	0x4d, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x2e,
+	0x00, 0x2e, 0x2d, 0x2c, 0x27, 0x26, 0x21, 0x19, 0x11, 0x11, 0x08, 0x07, 0x1a, 0x2b, 0x33, 0x13,
+	0x33, 0x03, 0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e, 0x03, 0x33, 0x07, 0x23, 0x22, 0x0e, 0x02, 0x07,
+	0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17, 0x1e, 0x03, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x23,
+	0x03, 0xa5, 0xd9, 0xbb, 0x5e, 0x2f, 0x49, 0x3a, 0x2f, 0x17, 0x0e, 0x25, 0x42, 0x4c, 0x62, 0x45,
+	0x1e, 0x0b, 0x1d, 0x2d, 0x27, 0x23, 0x13, 0x12, 0x1d, 0x39, 0x3c, 0x40, 0x24, 0x23, 0x2c, 0x20,
+	0x1c, 0x12, 0x10, 0x09, 0x1d, 0x23, 0x22, 0x10, 0xd1, 0x14, 0x2f, 0x32, 0x2f, 0x13, 0x41, 0x62,
+	0x04, 0x3e, 0xfe, 0x2e, 0x20, 0x36, 0x48, 0x29, 0x19, 0x41, 0x5c, 0x3a, 0x1b, 0x94, 0x11, 0x23,
+	0x32, 0x21, 0x20, 0x32, 0x45, 0x2d, 0x1a, 0x08, 0x0c, 0x37, 0x49, 0x58, 0x2d, 0x28, 0x16, 0x44,
+	0x4a, 0x47, 0x19, 0x34, 0x86, 0x88, 0x7e, 0x2c, 0xfe, 0x14, 0x00, 0x00, 0x00, 0x01, 0x00, 0x33,
+	0x00, 0x00, 0x04, 0xef, 0x04, 0x3e, 0x00, 0x12, 0x00, 0x3e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d,
+	0x04, 0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x12, 0x00,
+	0x12, 0x11, 0x11, 0x17, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x37, 0x36, 0x36, 0x3f, 0x03, 0x21, 0x03,
+	0x23, 0x13, 0x21, 0x07, 0x06, 0x07, 0x03, 0x02, 0x00, 0x33, 0x1d, 0x84, 0xaa, 0x30, 0x34, 0x1d,
+	0x16, 0x02, 0xda, 0xd9, 0xc5, 0xb9, 0xfe, 0x9e, 0x04, 0x04, 0x12, 0x40, 0x49, 0xfe, 0xf4, 0x94,
+	0x0a, 0xea, 0xf0, 0xdb, 0x80, 0x6b, 0xfb, 0xc2, 0x03, 0xa0, 0x12, 0x18, 0x45, 0xff, 0x00, 0xfe,
+	0xe7, 0xfe, 0xe8, 0x00, 0x00, 0x01, 0x00, 0xab, 0x00, 0x00, 0x05, 0xc4, 0x04, 0x3e, 0x00, 0x0e,
+	0x00, 0x50, 0xb7, 0x0d, 0x09, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00,
+	0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d, 0x02,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x13, 0x11, 0x13, 0x11, 0x06, 0x07,
+	0x1a, 0x2b, 0x33, 0x13, 0x33, 0x13, 0x33, 0x01, 0x33, 0x03, 0x23, 0x13, 0x37, 0x01, 0x23, 0x03,
+	0x03, 0xab, 0xd9, 0xd7, 0xa6, 0x02, 0x01, 0xdc, 0xe5, 0xd9, 0xc4, 0xa2, 0x04, 0xfe, 0x58, 0xbe,
+	0x80, 0xa2, 0x04, 0x3e, 0xfc, 0xaf, 0x03, 0x51, 0xfb, 0xc2, 0x03, 0x39, 0x06, 0xfd, 0x0b, 0x02,
+	0xdb, 0xfc, 0xdb, 0x00, 0x00, 0x01, 0x00, 0x9c, 0x00, 0x00, 0x04, 0xbe, 0x04, 0x3e, 0x00, 0x0b,
+	0x00, 0x48, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x68, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x21, 0x13,
+	0x33, 0x03, 0x23, 0x13, 0x21, 0x03, 0x9c, 0xd9, 0xc5, 0x59, 0x01, 0xbf, 0x59, 0xc5, 0xd9, 0xc5,
+	0x63, 0xfe, 0x41, 0x63, 0x04, 0x3e, 0xfe, 0x47, 0x01, 0xb9, 0xfb, 0xc2, 0x01, 0xf1, 0xfe, 0x0f,
+	0x00, 0x02, 0x00, 0x99, 0xff, 0xe7, 0x04, 0xc8, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x2d,
+	0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16, 0x2b, 0x05, 0x22, 0x02,
+	0x13, 0x12, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x27, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03,
+	0x02, 0x02, 0x38, 0xdb, 0xc4, 0x34, 0x35, 0x01, 0x3f, 0xe0, 0xdf, 0xc8, 0x35, 0x35, 0xfe, 0xc0,
+	0xc6, 0x01, 0x12, 0x55, 0x53, 0xfe, 0xf2, 0xfe, 0xf2, 0x54, 0x54, 0x19, 0x01, 0x34, 0x01, 0x04,
+	0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01,
+	0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9c, 0x00, 0x00, 0x04, 0xa8,
+	0x04, 0x3e, 0x00, 0x07, 0x00, 0x3e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x05, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x23, 0x13, 0x21, 0x03, 0x9c, 0xd9, 0x03, 0x33,
+	0xd9, 0xc5, 0xb9, 0xfe, 0x57, 0xb9, 0x04, 0x3e, 0xfb, 0xc2, 0x03, 0xa0, 0xfc, 0x60, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x52, 0xfe, 0x75, 0x04, 0xc6, 0x04, 0x56, 0x00, 0x0e, 0x00, 0x18, 0x00, 0x5e,
+	0x40, 0x0b, 0x10, 0x04, 0x02, 0x04, 0x05, 0x0e, 0x01, 0x03, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x1b,
+	0x40, 0x1f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x21,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x62, 0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00,
+	0x4e, 0x59, 0x40, 0x09, 0x23, 0x24, 0x24, 0x22, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23,
+	0x01, 0x33, 0x07, 0x36, 0x33, 0x32, 0x12, 0x07, 0x02, 0x00, 0x23, 0x22, 0x27, 0x13, 0x03, 0x16,
+	0x33, 0x20, 0x13, 0x36, 0x26, 0x23, 0x22, 0x01, 0x17, 0xc5, 0x01, 0x28, 0xc5, 0x29, 0xa3, 0xce,
+	0xaa, 0x95, 0x31, 0x39, 0xfe, 0xb7, 0xf5, 0x5f, 0x59, 0x8c, 0x6f, 0x83, 0x45, 0x01, 0x1b, 0x57,
+	0x23, 0x46, 0x60, 0x81, 0xfe, 0x75, 0x05, 0xc9, 0xcc, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1, 0xfe,
+	0xc8, 0x19, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1, 0xcd, 0x00, 0x00, 0x01, 0x00, 0xa3,
+	0xff, 0xe7, 0x04, 0x7c, 0x04, 0x56, 0x00, 0x14, 0x00, 0x2a, 0x40, 0x27, 0x0a, 0x01, 0x02, 0x01,
+	0x14, 0x0b, 0x02, 0x03, 0x02, 0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x23, 0x23, 0x24, 0x21,
+	0x04, 0x07, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x37, 0x12, 0x00, 0x33, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x20, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x03, 0xb8, 0xb7, 0xb0, 0xda, 0xd4, 0x33,
+	0x35, 0x01, 0x53, 0xf8, 0x84, 0xa2, 0x21, 0x96, 0x64, 0xfe, 0xa1, 0x53, 0x27, 0x8b, 0xa0, 0x7c,
+	0xab, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5e, 0xc2,
+	0xd5, 0x45, 0x00, 0x00, 0x00, 0x01, 0x00, 0xed, 0x00, 0x00, 0x04, 0x66, 0x04, 0x3e, 0x00, 0x07,
+	0x00, 0x3e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b,
+	0x21, 0x13, 0x21, 0x37, 0x21, 0x07, 0x21, 0x03, 0x01, 0x7e, 0xb9, 0xfe, 0xb6, 0x20, 0x03, 0x59,
+	0x20, 0xfe, 0xb6, 0xb9, 0x03, 0xa0, 0x9e, 0x9e, 0xfc, 0x60, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0c,
+	0xfe, 0x75, 0x04, 0xd7, 0x04, 0x3e, 0x00, 0x0e, 0x00, 0x28, 0x40, 0x25, 0x08, 0x05, 0x02, 0x00,
+	0x01, 0x01, 0x4c, 0x02, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x62, 0x04, 0x01,
+	0x03, 0x03, 0x1e, 0x03, 0x4e, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x0d, 0x12, 0x13, 0x21, 0x05, 0x07,
+	0x19, 0x2b, 0x13, 0x37, 0x33, 0x32, 0x36, 0x37, 0x03, 0x33, 0x13, 0x01, 0x33, 0x01, 0x02, 0x06,
+	0x23, 0x0c, 0x22, 0x1c, 0x9e, 0x74, 0x4f, 0xbc, 0xd4, 0x8c, 0x01, 0xce, 0xba, 0xfd, 0x9d, 0xa4,
+	0xc3, 0xea, 0xfe, 0x75, 0xad, 0x4f, 0x8f, 0x04, 0x3e, 0xfc, 0xdc, 0x03, 0x24, 0xfb, 0xdb, 0xfe,
+	0xed, 0x91, 0x00, 0x00, 0x00, 0x03, 0x00, 0xb9, 0xfe, 0x75, 0x06, 0xca, 0x06, 0x2b, 0x00, 0x2d,
+	0x00, 0x3e, 0x00, 0x4f, 0x00, 0x70, 0x40, 0x0c, 0x19, 0x16, 0x02, 0x06, 0x02, 0x2d, 0x02, 0x02,
+	0x01, 0x07, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x03, 0x02, 0x03, 0x85,
+	0x09, 0x01, 0x06, 0x06, 0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07,
+	0x01, 0x61, 0x05, 0x01, 0x01, 0x01, 0x1b, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x03, 0x02, 0x03, 0x85, 0x09, 0x01, 0x06, 0x06, 0x02, 0x61, 0x04, 0x01, 0x02, 0x02,
+	0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x01, 0x61, 0x05, 0x01, 0x01, 0x01, 0x1d, 0x4d, 0x00, 0x00,
+	0x00, 0x1e, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x4d, 0x4b, 0x25, 0x28, 0x27, 0x28, 0x25, 0x15, 0x28,
+	0x25, 0x10, 0x0a, 0x07, 0x1f, 0x2b, 0x01, 0x23, 0x13, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x37,
+	0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x13, 0x33, 0x03, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02,
+	0x07, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x27, 0x03, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x02, 0x07,
+	0x06, 0x06, 0x16, 0x16, 0x33, 0x32, 0x36, 0x37, 0x33, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37,
+	0x36, 0x36, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x03, 0x66, 0xbf, 0x65, 0x17, 0x37, 0x41, 0x4b,
+	0x2a, 0x75, 0x8f, 0x45, 0x06, 0x14, 0x14, 0x59, 0x89, 0xb8, 0x75, 0x2a, 0x40, 0x2f, 0x22, 0x0d,
+	0x79, 0xbf, 0x79, 0x17, 0x37, 0x41, 0x4b, 0x2a, 0x75, 0x8f, 0x45, 0x06, 0x14, 0x14, 0x59, 0x89,
+	0xb8, 0x75, 0x2a, 0x40, 0x2f, 0x22, 0x0d, 0x2f, 0x22, 0x54, 0x43, 0x48, 0x73, 0x56, 0x38, 0x0e,
+	0x0e, 0x03, 0x24, 0x52, 0x48, 0x43, 0x75, 0x37, 0xbf, 0x22, 0x54, 0x43, 0x48, 0x73, 0x56, 0x38,
+	0x0e, 0x0e, 0x03, 0x24, 0x52, 0x48, 0x43, 0x75, 0x37, 0xfe, 0x75, 0x01, 0xfd, 0x18, 0x2d, 0x24,
+	0x15, 0x61, 0x9e, 0xc6, 0x66, 0x66, 0xc6, 0x9e, 0x61, 0x15, 0x24, 0x2d, 0x18, 0x02, 0x5f, 0xfd,
+	0xa1, 0x18, 0x2d, 0x24, 0x15, 0x61, 0x9e, 0xc6, 0x66, 0x66, 0xc6, 0x9e, 0x61, 0x15, 0x24, 0x2d,
+	0x18, 0x02, 0xd0, 0x2f, 0x3c, 0x49, 0x73, 0x8e, 0x44, 0x44, 0x8e, 0x73, 0x49, 0x3b, 0x30, 0x2f,
+	0x3c, 0x49, 0x73, 0x8e, 0x44, 0x44, 0x8e, 0x73, 0x49, 0x3b, 0x30, 0x00, 0x00, 0x01, 0x00, 0x27,
+	0x00, 0x00, 0x04, 0xaa, 0x04, 0x3e, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01,
+	0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00,
+	0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x03, 0x33, 0x13,
+	0x01, 0x33, 0x01, 0x13, 0x23, 0x03, 0x01, 0x27, 0x01, 0xd3, 0xee, 0xe4, 0xbe, 0x01, 0x46, 0xb6,
+	0xfe, 0x46, 0xfc, 0xe3, 0xcf, 0xfe, 0xa3, 0x02, 0x3e, 0x02, 0x00, 0xfe, 0x69, 0x01, 0x97, 0xfd,
+	0xdd, 0xfd, 0xe5, 0x01, 0xb4, 0xfe, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x9c, 0xfe, 0xa7, 0x04, 0xd9,
+	0x04, 0x3e, 0x00, 0x0b, 0x00, 0x73, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x1e, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x18, 0x00, 0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01,
+	0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04,
+	0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06,
+	0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33,
+	0x03, 0x33, 0x03, 0x23, 0x13, 0x9c, 0xd9, 0xc5, 0xba, 0x01, 0xda, 0xba, 0xc5, 0xba, 0xa0, 0x64,
+	0xb4, 0x45, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfc, 0x5f, 0xfe, 0x0a, 0x01, 0x59, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xde, 0x00, 0x00, 0x04, 0x75, 0x04, 0x3e, 0x00, 0x11, 0x00, 0x4c, 0xb5, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00,
+	0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1b, 0x04,
+	0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01,
+	0x1c, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x11,
+	0x00, 0x11, 0x12, 0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21, 0x13, 0x06, 0x23, 0x22, 0x26,
+	0x13, 0x37, 0x33, 0x03, 0x06, 0x16, 0x33, 0x32, 0x37, 0x13, 0x33, 0x03, 0x02, 0xd6, 0x54, 0x7e,
+	0x8e, 0xca, 0x76, 0x34, 0x32, 0xc5, 0x39, 0x21, 0x3e, 0x75, 0x83, 0x62, 0x68, 0xc6, 0xd9, 0x01,
+	0xa9, 0x31, 0xcb, 0x01, 0x05, 0xf6, 0xfe, 0xe4, 0xa3, 0x77, 0x32, 0x02, 0x04, 0xfb, 0xc2, 0x00,
+	0x00, 0x01, 0x00, 0xab, 0x00, 0x00, 0x06, 0xaf, 0x04, 0x3e, 0x00, 0x0b, 0x00, 0x44, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00,
+	0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07,
+	0x1b, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0xab, 0xd9,
+	0xc3, 0xba, 0x01, 0x6d, 0xba, 0xc3, 0xba, 0x01, 0x75, 0xba, 0xc3, 0xd9, 0x04, 0x3e, 0xfc, 0x5f,
+	0x03, 0xa1, 0xfc, 0x5f, 0x03, 0xa1, 0xfb, 0xc2, 0x00, 0x01, 0x00, 0xab, 0xfe, 0xa7, 0x06, 0xb6,
+	0x04, 0x3e, 0x00, 0x0f, 0x00, 0x7c, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x21, 0x04, 0x02, 0x02,
+	0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b,
+	0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x06, 0x60, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00,
+	0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x07, 0x4e,
+	0x1b, 0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1d, 0x07, 0x4e, 0x59, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x07,
+	0x1d, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0x33, 0x03,
+	0x23, 0x13, 0xab, 0xd9, 0xc3, 0xba, 0x01, 0x76, 0xba, 0xc3, 0xba, 0x01, 0x73, 0xba, 0xc3, 0xba,
+	0xb4, 0x64, 0xb4, 0x45, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfc, 0x5f, 0x03, 0xa1, 0xfc, 0x5f,
+	0xfe, 0x0a, 0x01, 0x59, 0x00, 0x02, 0x00, 0xea, 0x00, 0x00, 0x05, 0x07, 0x04, 0x3e, 0x00, 0x10,
+	0x00, 0x1b, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04,
+	0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05,
+	0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04,
+	0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x1b,
+	0x19, 0x13, 0x11, 0x00, 0x10, 0x00, 0x0f, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x21, 0x13,
+	0x21, 0x37, 0x21, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x27, 0x33, 0x32, 0x36,
+	0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x01, 0x59, 0xb9, 0xfe, 0xd8, 0x20, 0x01, 0xed, 0x54, 0xea,
+	0x70, 0x99, 0x58, 0x19, 0x10, 0x10, 0x4c, 0x7c, 0xae, 0x73, 0xc3, 0xda, 0x7f, 0x7f, 0x12, 0x09,
+	0x0c, 0x2e, 0x53, 0x3f, 0xdd, 0x03, 0xa0, 0x9e, 0xfe, 0x5e, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d,
+	0x54, 0x2a, 0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa5,
+	0x00, 0x00, 0x06, 0x0a, 0x04, 0x3e, 0x00, 0x0e, 0x00, 0x12, 0x00, 0x1d, 0x00, 0x55, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x00, 0x06, 0x05, 0x00, 0x06, 0x68, 0x03, 0x01, 0x02,
+	0x02, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x5f, 0x07, 0x04, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e,
+	0x1b, 0x40, 0x1b, 0x00, 0x00, 0x00, 0x06, 0x05, 0x00, 0x06, 0x68, 0x03, 0x01, 0x02, 0x02, 0x1c,
+	0x4d, 0x00, 0x05, 0x05, 0x01, 0x5f, 0x07, 0x04, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40,
+	0x11, 0x0f, 0x0f, 0x1d, 0x1b, 0x15, 0x13, 0x0f, 0x12, 0x0f, 0x12, 0x12, 0x11, 0x28, 0x20, 0x08,
+	0x07, 0x1a, 0x2b, 0x01, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x21, 0x13, 0x33, 0x01,
+	0x13, 0x33, 0x03, 0x25, 0x33, 0x32, 0x36, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x01, 0xef, 0xae,
+	0x70, 0x99, 0x58, 0x19, 0x10, 0x10, 0x4c, 0x7c, 0xae, 0x73, 0xfe, 0x97, 0xd9, 0xc5, 0x02, 0x28,
+	0xd9, 0xc6, 0xd9, 0xfc, 0x56, 0x9e, 0x7f, 0x7f, 0x12, 0x09, 0x0c, 0x2e, 0x53, 0x3f, 0xa1, 0x02,
+	0x9c, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x04, 0x3e, 0xfb, 0xc2, 0x04, 0x3e, 0xfb,
+	0xc2, 0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x32,
+	0x04, 0x3e, 0x00, 0x0e, 0x00, 0x19, 0x00, 0x4f, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03,
+	0x01, 0x04, 0x68, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02,
+	0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x19, 0x17, 0x11, 0x0f, 0x00, 0x0e, 0x00,
+	0x0d, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07,
+	0x0e, 0x03, 0x23, 0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0xa5, 0xd9, 0xc5,
+	0x54, 0xc9, 0x70, 0x99, 0x58, 0x19, 0x10, 0x10, 0x4c, 0x7c, 0xae, 0x73, 0xa2, 0xb9, 0x7f, 0x7f,
+	0x12, 0x09, 0x0c, 0x2e, 0x53, 0x3f, 0xbc, 0x04, 0x3e, 0xfe, 0x5e, 0x2d, 0x56, 0x7c, 0x4f, 0x53,
+	0x7d, 0x54, 0x2a, 0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6f,
+	0xff, 0xe7, 0x04, 0x51, 0x04, 0x57, 0x00, 0x20, 0x00, 0x33, 0x40, 0x30, 0x10, 0x01, 0x02, 0x03,
+	0x01, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x22, 0x05, 0x4e, 0x28, 0x25, 0x22, 0x11, 0x12, 0x23, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x37, 0x16,
+	0x16, 0x33, 0x32, 0x36, 0x37, 0x21, 0x37, 0x21, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x37, 0x36,
+	0x36, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x6f, 0x1e, 0x45, 0x92, 0x44,
+	0xac, 0xd7, 0x31, 0xfe, 0x41, 0x1e, 0x01, 0xbf, 0x12, 0x87, 0x95, 0x3e, 0xa7, 0x60, 0x20, 0x4f,
+	0x9d, 0x47, 0x8f, 0xc1, 0x6b, 0x18, 0x1b, 0x19, 0x6d, 0xa6, 0xd8, 0x85, 0x4e, 0xa3, 0x1f, 0x99,
+	0x1f, 0x1e, 0xb9, 0xb9, 0x95, 0x99, 0xa7, 0x18, 0x19, 0xa1, 0x13, 0x12, 0x4e, 0x93, 0xd2, 0x83,
+	0x7d, 0xd1, 0x98, 0x54, 0x19, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0xff, 0xe7, 0x06, 0x55,
+	0x04, 0x56, 0x00, 0x0b, 0x00, 0x1e, 0x00, 0xbb, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1f, 0x00,
+	0x05, 0x00, 0x02, 0x00, 0x05, 0x02, 0x68, 0x00, 0x01, 0x01, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04,
+	0x1c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x07, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x00, 0x02, 0x00, 0x05, 0x02, 0x68, 0x00, 0x01,
+	0x01, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x00,
+	0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x22, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x27, 0x00, 0x05, 0x00, 0x02, 0x00, 0x05, 0x02, 0x68, 0x00, 0x04, 0x04, 0x1c, 0x4d, 0x00, 0x01,
+	0x01, 0x06, 0x61, 0x00, 0x06, 0x06, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x00, 0x00,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x22, 0x07, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x05, 0x00, 0x02, 0x00,
+	0x05, 0x02, 0x68, 0x00, 0x04, 0x04, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x21, 0x4d, 0x00, 0x03, 0x03, 0x1d, 0x4d, 0x00, 0x00, 0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x22,
+	0x07, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0b, 0x24, 0x22, 0x11, 0x11, 0x11, 0x12, 0x24, 0x22, 0x08,
+	0x07, 0x1e, 0x2b, 0x01, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x01,
+	0x23, 0x03, 0x23, 0x13, 0x33, 0x03, 0x33, 0x36, 0x00, 0x33, 0x32, 0x12, 0x03, 0x02, 0x00, 0x23,
+	0x22, 0x02, 0x03, 0x59, 0x2b, 0x54, 0x7e, 0x7d, 0xa8, 0x2a, 0x2a, 0x54, 0x7e, 0x7e, 0xa7, 0xfe,
+	0xf9, 0xb4, 0x5d, 0xc5, 0xd9, 0xc5, 0x5e, 0xb4, 0x41, 0x01, 0x1f, 0xcb, 0xdc, 0xb5, 0x34, 0x34,
+	0xfe, 0xd1, 0xdc, 0xcc, 0xb4, 0x02, 0x24, 0xd5, 0xd4, 0xd2, 0xd2, 0xd1, 0xd2, 0xcf, 0xfe, 0xe2,
+	0xfe, 0x2b, 0x04, 0x3e, 0xfe, 0x2b, 0xe6, 0x01, 0x07, 0xfe, 0xcc, 0xfe, 0xfd, 0xfe, 0xfc, 0xfe,
+	0xcc, 0x01, 0x05, 0x00, 0x00, 0x02, 0x00, 0x4b, 0x00, 0x00, 0x04, 0x9f, 0x04, 0x3e, 0x00, 0x16,
+	0x00, 0x1f, 0x00, 0x50, 0xb5, 0x0c, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x05,
+	0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1c, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11, 0x2b, 0x15, 0x10,
+	0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23, 0x06, 0x07, 0x07, 0x06, 0x07, 0x23, 0x36, 0x37, 0x37, 0x36,
+	0x37, 0x26, 0x26, 0x37, 0x36, 0x37, 0x36, 0x33, 0x21, 0x03, 0x23, 0x13, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x16, 0x33, 0x33, 0x03, 0x57, 0xc0, 0x54, 0x66, 0x55, 0x38, 0x28, 0xdd, 0x5b, 0x4d, 0x24,
+	0x81, 0x65, 0x5f, 0x59, 0x14, 0x23, 0xb7, 0x5e, 0xee, 0x01, 0x20, 0xd9, 0xc6, 0xbb, 0x7d, 0x80,
+	0x86, 0x11, 0x12, 0x69, 0x85, 0x72, 0x01, 0xb6, 0x45, 0x83, 0x6e, 0x49, 0x37, 0x6a, 0x6a, 0x31,
+	0xb3, 0x34, 0x25, 0xa0, 0x62, 0xb1, 0x50, 0x2a, 0xfb, 0xc2, 0x03, 0xaa, 0x56, 0x56, 0x5a, 0x5a,
+	0x00, 0x03, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3, 0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19,
+	0x00, 0x41, 0x40, 0x3e, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85, 0x08, 0x01,
+	0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21,
+	0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x00, 0x00, 0x19, 0x18,
+	0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x09,
+	0x07, 0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36,
+	0x00, 0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x13, 0x23, 0x01, 0x33, 0x03, 0xc0, 0x3d,
+	0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01, 0x45, 0xe1, 0x01,
+	0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0x70, 0x94, 0xfe, 0xff, 0xe4, 0x02, 0x94,
+	0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd,
+	0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x86, 0x01, 0x41, 0x00, 0x04, 0x00, 0x9b, 0xff, 0xe7, 0x04, 0xe3,
+	0x05, 0xba, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x86, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2c, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x0c, 0x09, 0x0b, 0x03,
+	0x07, 0x07, 0x06, 0x5f, 0x08, 0x01, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x1b,
+	0x40, 0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x03, 0x06, 0x07, 0x67, 0x0a, 0x01,
+	0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21,
+	0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x40, 0x22, 0x1a,
+	0x1a, 0x16, 0x16, 0x00, 0x00, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x18,
+	0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0d, 0x07,
+	0x17, 0x2b, 0x01, 0x12, 0x23, 0x22, 0x03, 0x01, 0x07, 0x06, 0x23, 0x22, 0x02, 0x13, 0x36, 0x00,
+	0x33, 0x20, 0x03, 0x07, 0x21, 0x02, 0x21, 0x32, 0x01, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0x03, 0xc0, 0x3d, 0xf5, 0xfd, 0x55, 0x02, 0x70, 0x20, 0xcd, 0xb7, 0xfb, 0xec, 0x35, 0x32, 0x01,
+	0x45, 0xe1, 0x01, 0xbb, 0x6b, 0x0d, 0xfd, 0x2b, 0x32, 0x01, 0x69, 0x9c, 0xfe, 0xc2, 0x22, 0xad,
+	0x22, 0xde, 0x22, 0xad, 0x22, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01,
+	0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x90, 0xad, 0xad, 0xad,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0xfe, 0x75, 0x04, 0x94, 0x06, 0x2b, 0x00, 0x23,
+	0x00, 0xb2, 0x40, 0x0e, 0x0b, 0x01, 0x09, 0x08, 0x17, 0x01, 0x07, 0x09, 0x16, 0x01, 0x06, 0x07,
+	0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x02, 0x01, 0x01, 0x02, 0x70, 0x03,
+	0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00, 0x08, 0x09, 0x05, 0x08,
+	0x69, 0x0a, 0x01, 0x09, 0x09, 0x1b, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x1e,
+	0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03,
+	0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00, 0x08, 0x09, 0x05, 0x08,
+	0x69, 0x0a, 0x01, 0x09, 0x09, 0x1b, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x1e,
+	0x06, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00,
+	0x05, 0x01, 0x00, 0x68, 0x00, 0x05, 0x00, 0x08, 0x09, 0x05, 0x08, 0x69, 0x0a, 0x01, 0x09, 0x09,
+	0x1d, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x59, 0x59, 0x40,
+	0x12, 0x00, 0x00, 0x00, 0x23, 0x00, 0x23, 0x25, 0x23, 0x25, 0x22, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0b, 0x07, 0x1f, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x03,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x03, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36,
+	0x37, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0xa5, 0xef, 0x90, 0x1b, 0x90, 0x31, 0xc5, 0x31,
+	0x01, 0x6e, 0x1b, 0xfe, 0x92, 0x55, 0xa9, 0xd5, 0x8d, 0x85, 0x1f, 0x93, 0x1f, 0xcb, 0x98, 0x49,
+	0x47, 0x1f, 0x39, 0x3e, 0x49, 0x60, 0x16, 0x7e, 0x19, 0x33, 0x52, 0xa8, 0xaf, 0x76, 0x04, 0xb0,
+	0x88, 0xf3, 0xf3, 0x88, 0xfe, 0x54, 0xe4, 0xb3, 0x98, 0xfd, 0x20, 0x9a, 0xae, 0x15, 0x9a, 0x1b,
+	0x6d, 0x6c, 0x02, 0x7b, 0x7a, 0x67, 0xed, 0xfd, 0xaf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x97,
+	0x00, 0x00, 0x04, 0x33, 0x06, 0x44, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40,
+	0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11,
+	0x11, 0x07, 0x07, 0x18, 0x2b, 0x33, 0x13, 0x21, 0x07, 0x21, 0x03, 0x13, 0x01, 0x33, 0x01, 0x97,
+	0xd9, 0x02, 0x5f, 0x23, 0xfe, 0x66, 0xb6, 0xc2, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04, 0x3e, 0xad,
+	0xfc, 0x6f, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0xb4, 0xff, 0xe7, 0x04, 0x8c,
+	0x04, 0x57, 0x00, 0x20, 0x00, 0x37, 0x40, 0x34, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x03, 0x02,
+	0x20, 0x01, 0x05, 0x04, 0x03, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x22, 0x00, 0x4e, 0x22, 0x11, 0x12, 0x25, 0x28, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x25, 0x06, 0x06,
+	0x23, 0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x16, 0x17, 0x07, 0x26, 0x26, 0x23, 0x22,
+	0x06, 0x07, 0x21, 0x07, 0x21, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x03, 0xc8, 0x59, 0xad, 0x4e,
+	0x85, 0xb8, 0x68, 0x1b, 0x19, 0x1b, 0x6c, 0xa5, 0xdf, 0x8f, 0x47, 0x97, 0x47, 0x20, 0x56, 0x9d,
+	0x3e, 0x95, 0xcb, 0x2a, 0x01, 0xbf, 0x1e, 0xfe, 0x41, 0x19, 0x8c, 0xad, 0x44, 0x9e, 0x51, 0x1f,
+	0x1f, 0x19, 0x54, 0x98, 0xd1, 0x7d, 0x83, 0xd2, 0x93, 0x4e, 0x12, 0x13, 0xa1, 0x19, 0x18, 0xa7,
+	0x99, 0x95, 0xb9, 0xb9, 0x1e, 0x1f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x86, 0xff, 0xe7, 0x04, 0x1b,
+	0x04, 0x56, 0x00, 0x1c, 0x00, 0x2a, 0x40, 0x27, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00,
+	0x02, 0x02, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x28, 0x23, 0x27, 0x22, 0x04, 0x07, 0x1a, 0x2b,
+	0x37, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x27, 0x27, 0x26, 0x37, 0x12, 0x21, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x22, 0x07, 0x06, 0x17, 0x17, 0x04, 0x07, 0x06, 0x04, 0x23, 0x22, 0x86, 0x24, 0xaf,
+	0xab, 0xe5, 0x21, 0x15, 0x95, 0xa4, 0xec, 0x2b, 0x3d, 0x01, 0xa1, 0x78, 0xa0, 0x21, 0x87, 0xa2,
+	0xc9, 0x1c, 0x13, 0x84, 0x93, 0x01, 0x11, 0x2e, 0x1e, 0xfe, 0xf5, 0xca, 0xa3, 0x26, 0xb5, 0x60,
+	0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61,
+	0xe7, 0x99, 0xb0, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x02, 0x9f, 0x05, 0xdc, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x07, 0x17, 0x2b,
+	0x33, 0x13, 0x33, 0x03, 0x13, 0x37, 0x33, 0x07, 0xa5, 0xd9, 0xc5, 0xd9, 0x31, 0x2b, 0xd9, 0x2b,
+	0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0xd9, 0xd9, 0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x03, 0x3c,
+	0x05, 0xba, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1a, 0x08, 0x05, 0x07, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x04, 0x01,
+	0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x06,
+	0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08,
+	0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x09, 0x07, 0x17, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x03, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07,
+	0xa5, 0xd9, 0xc5, 0xd9, 0x89, 0x22, 0xad, 0x22, 0xdf, 0x22, 0xad, 0x22, 0x04, 0x3e, 0xfb, 0xc2,
+	0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0xff, 0x62, 0xfe, 0x75, 0x02, 0x7d,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x5b, 0x40, 0x0a, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01,
+	0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03,
+	0x5f, 0x00, 0x03, 0x03, 0x1a, 0x4d, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03,
+	0x04, 0x67, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x02, 0x1e,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06,
+	0x07, 0x1a, 0x2b, 0x03, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x13, 0x33, 0x03, 0x02, 0x21, 0x22,
+	0x01, 0x37, 0x33, 0x07, 0x9e, 0x1d, 0x0e, 0x47, 0x54, 0x4b, 0x1d, 0xd9, 0xc6, 0xd9, 0x4f, 0xfe,
+	0xc1, 0x5b, 0x02, 0x23, 0x27, 0xc6, 0x27, 0xfe, 0x80, 0x90, 0x07, 0x69, 0x8e, 0x04, 0x3e, 0xfb,
+	0xc2, 0xfe, 0x75, 0x06, 0x8e, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x4c, 0x00, 0x00, 0x07, 0x47,
+	0x04, 0x3e, 0x00, 0x22, 0x00, 0x2d, 0x00, 0x60, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00,
+	0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x67, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05,
+	0x1c, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x20, 0x00, 0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x67, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x05, 0x1c, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x1d,
+	0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x2d, 0x2b, 0x25, 0x23, 0x00, 0x22, 0x00, 0x22, 0x21,
+	0x27, 0x11, 0x28, 0x21, 0x09, 0x07, 0x1b, 0x2b, 0x01, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e,
+	0x03, 0x23, 0x21, 0x13, 0x21, 0x07, 0x0e, 0x05, 0x23, 0x23, 0x37, 0x33, 0x32, 0x3e, 0x03, 0x12,
+	0x37, 0x37, 0x01, 0x33, 0x32, 0x36, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x05, 0x44, 0x54, 0xdd,
+	0x70, 0x99, 0x58, 0x19, 0x10, 0x10, 0x4c, 0x7c, 0xae, 0x73, 0xfe, 0x68, 0xb9, 0xfe, 0xa8, 0x0a,
+	0x14, 0x2d, 0x3f, 0x59, 0x83, 0xb1, 0x79, 0x2b, 0x1d, 0x21, 0x34, 0x5a, 0x50, 0x47, 0x45, 0x45,
+	0x25, 0x16, 0x02, 0x14, 0xcd, 0x7f, 0x7f, 0x12, 0x09, 0x0c, 0x2e, 0x53, 0x3f, 0xd0, 0x04, 0x3e,
+	0xfe, 0x5e, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x03, 0xa0, 0x34, 0x63, 0xcf, 0xc3,
+	0xac, 0x80, 0x4b, 0x94, 0x15, 0x3e, 0x71, 0xb9, 0x01, 0x0a, 0xb8, 0x6b, 0xfc, 0x56, 0x61, 0x59,
+	0x2a, 0x45, 0x31, 0x1a, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x06, 0x89, 0x04, 0x3e, 0x00, 0x16,
+	0x00, 0x21, 0x00, 0x5b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01,
+	0x00, 0x07, 0x03, 0x00, 0x68, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x5f,
+	0x09, 0x06, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01,
+	0x00, 0x07, 0x03, 0x00, 0x68, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x5f,
+	0x09, 0x06, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x13, 0x00, 0x00, 0x21, 0x1f, 0x19,
+	0x17, 0x00, 0x16, 0x00, 0x15, 0x21, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x07, 0x1c, 0x2b, 0x21,
+	0x13, 0x21, 0x03, 0x23, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07,
+	0x0e, 0x03, 0x23, 0x27, 0x33, 0x32, 0x36, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x03, 0x1e, 0x68,
+	0xfe, 0x4d, 0x68, 0xc6, 0xd9, 0xc6, 0x54, 0x01, 0xb3, 0x54, 0xc5, 0x54, 0xa7, 0x70, 0x99, 0x58,
+	0x19, 0x10, 0x10, 0x4c, 0x7c, 0xae, 0x73, 0x80, 0x97, 0x7f, 0x7f, 0x12, 0x09, 0x0c, 0x2e, 0x53,
+	0x3f, 0x9a, 0x02, 0x09, 0xfd, 0xf7, 0x04, 0x3e, 0xfe, 0x5f, 0x01, 0xa1, 0xfe, 0x5e, 0x2d, 0x56,
+	0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x94, 0x06, 0x12, 0x00, 0x19, 0x00, 0x7e, 0x4b, 0xb0,
+	0x0c, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x05, 0x05, 0x06, 0x70, 0x07, 0x01, 0x05, 0x08, 0x01,
+	0x04, 0x00, 0x05, 0x04, 0x68, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x03, 0x01, 0x01,
+	0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x06, 0x05, 0x06,
+	0x85, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00, 0x05, 0x04, 0x68, 0x00, 0x00, 0x00, 0x02, 0x01,
+	0x00, 0x02, 0x69, 0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x06, 0x05,
+	0x06, 0x85, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00, 0x05, 0x04, 0x68, 0x00, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x02, 0x69, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x12, 0x23, 0x13, 0x21, 0x09, 0x07, 0x1f, 0x2b, 0x01, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x23, 0x13, 0x23, 0x37, 0x33,
+	0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x02, 0x04, 0xab, 0xd3, 0x8d, 0x85, 0x1f, 0x85, 0xc5, 0x78,
+	0x19, 0x33, 0x52, 0xa6, 0xb1, 0x76, 0xc5, 0xef, 0x90, 0x1b, 0x90, 0x2c, 0xc5, 0x2c, 0x01, 0x5a,
+	0x1b, 0xfe, 0xa6, 0x03, 0x04, 0xe4, 0xb3, 0x97, 0xfd, 0x62, 0x02, 0x5d, 0x7a, 0x67, 0xed, 0xfd,
+	0xaf, 0x04, 0xb0, 0x88, 0xda, 0xda, 0x88, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x8e,
+	0x06, 0x44, 0x00, 0x2e, 0x00, 0x32, 0x00, 0x7a, 0xb5, 0x1b, 0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x08,
+	0x85, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x68, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x26, 0x00,
+	0x07, 0x08, 0x07, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01,
+	0x05, 0x68, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x09, 0x06, 0x02,
+	0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x19, 0x2f, 0x2f, 0x00, 0x00, 0x2f, 0x32, 0x2f, 0x32,
+	0x31, 0x30, 0x00, 0x2e, 0x00, 0x2e, 0x2d, 0x2c, 0x27, 0x26, 0x21, 0x19, 0x11, 0x11, 0x0b, 0x07,
+	0x1a, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e, 0x03, 0x33, 0x07, 0x23,
+	0x22, 0x0e, 0x02, 0x07, 0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17, 0x1e, 0x03, 0x17, 0x23,
+	0x2e, 0x03, 0x27, 0x23, 0x03, 0x01, 0x01, 0x33, 0x01, 0xa5, 0xd9, 0xbb, 0x5e, 0x2f, 0x49, 0x3a,
+	0x2f, 0x17, 0x0e, 0x25, 0x42, 0x4c, 0x62, 0x45, 0x1e, 0x0b, 0x1d, 0x2d, 0x27, 0x23, 0x13, 0x12,
+	0x1d, 0x39, 0x3c, 0x40, 0x24, 0x23, 0x2c, 0x20, 0x1c, 0x12, 0x10, 0x09, 0x1d, 0x23, 0x22, 0x10,
+	0xd1, 0x14, 0x2f, 0x32, 0x2f, 0x13, 0x41, 0x62, 0x01, 0x19, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04,
+	0x3e, 0xfe, 0x2e, 0x20, 0x36, 0x48, 0x29, 0x19, 0x41, 0x5c, 0x3a, 0x1b, 0x94, 0x11, 0x23, 0x32,
+	0x21, 0x20, 0x32, 0x45, 0x2d, 0x1a, 0x08, 0x0c, 0x37, 0x49, 0x58, 0x2d, 0x28, 0x16, 0x44, 0x4a,
+	0x47, 0x19, 0x34, 0x86, 0x88, 0x7e, 0x2c, 0xfe, 0x14, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x9c, 0x00, 0x00, 0x04, 0xcb, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x56,
+	0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00,
+	0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06,
+	0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11,
+	0x12, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x33, 0x03, 0x01, 0x33, 0x03, 0x23, 0x13, 0x01,
+	0x01, 0x23, 0x01, 0x33, 0x9c, 0xd9, 0xb9, 0xa5, 0x02, 0x7d, 0xc5, 0xd9, 0xb9, 0xa4, 0xfd, 0x85,
+	0x02, 0x4f, 0x94, 0xfe, 0xff, 0xe5, 0x04, 0x3e, 0xfc, 0xca, 0x03, 0x36, 0xfb, 0xc2, 0x03, 0x36,
+	0xfc, 0xca, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0c, 0xfe, 0x75, 0x04, 0xd7,
+	0x06, 0x44, 0x00, 0x0e, 0x00, 0x1a, 0x00, 0x6c, 0xb6, 0x08, 0x05, 0x02, 0x00, 0x01, 0x01, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x07, 0x07,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x1a, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x62, 0x08, 0x01, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x06, 0x01, 0x04, 0x05,
+	0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x02, 0x01, 0x01, 0x01, 0x1c, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x62, 0x08, 0x01, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x19, 0x17, 0x15, 0x14, 0x13, 0x11, 0x10, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x12, 0x13, 0x21,
+	0x09, 0x07, 0x19, 0x2b, 0x13, 0x37, 0x33, 0x32, 0x36, 0x37, 0x03, 0x33, 0x13, 0x01, 0x33, 0x01,
+	0x02, 0x06, 0x23, 0x01, 0x33, 0x06, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x0c,
+	0x22, 0x1c, 0x9e, 0x74, 0x4f, 0xbc, 0xd4, 0x8c, 0x01, 0xce, 0xba, 0xfd, 0x9d, 0xa4, 0xc3, 0xea,
+	0x01, 0xf6, 0x7b, 0x02, 0xb1, 0xb2, 0x43, 0x7b, 0x2c, 0xd9, 0x88, 0x88, 0x92, 0xfe, 0x75, 0xad,
+	0x4f, 0x8f, 0x04, 0x3e, 0xfc, 0xdc, 0x03, 0x24, 0xfb, 0xdb, 0xfe, 0xed, 0x91, 0x07, 0xcf, 0xad,
+	0xad, 0x92, 0xaf, 0xae, 0x00, 0x01, 0x00, 0x9c, 0xfe, 0xa7, 0x04, 0xbe, 0x04, 0x3e, 0x00, 0x0b,
+	0x00, 0x6d, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03,
+	0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0e,
+	0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33,
+	0x13, 0x33, 0x03, 0x21, 0x13, 0x33, 0x03, 0x21, 0x03, 0x23, 0x13, 0x9c, 0xd9, 0xc5, 0xba, 0x01,
+	0xbf, 0xba, 0xc5, 0xd9, 0xfe, 0xb5, 0x45, 0xb4, 0x45, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfb,
+	0xc2, 0xfe, 0xa7, 0x01, 0x59, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xbf, 0x00, 0x00, 0x05, 0x43,
+	0x06, 0xf1, 0x00, 0x07, 0x00, 0x44, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x02, 0x03,
+	0x00, 0x02, 0x68, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x13, 0x33, 0x03,
+	0x21, 0x01, 0xbf, 0x01, 0x27, 0x02, 0x6d, 0x3c, 0xb4, 0x5e, 0xfd, 0xb1, 0xfe, 0xfb, 0x05, 0xc8,
+	0x01, 0x29, 0xfe, 0x2b, 0xfa, 0xe4, 0x00, 0x00, 0x00, 0x01, 0x00, 0xb5, 0x00, 0x00, 0x04, 0x4b,
+	0x05, 0x34, 0x00, 0x07, 0x00, 0x66, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x01, 0x00,
+	0x00, 0x01, 0x70, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03,
+	0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b,
+	0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x13, 0x21, 0x37,
+	0x33, 0x03, 0x21, 0x03, 0xb5, 0xd9, 0x01, 0xd8, 0x31, 0xb4, 0x54, 0xfe, 0x39, 0xb6, 0x04, 0x3e,
+	0xf6, 0xfe, 0x5d, 0xfc, 0x6f, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x4b, 0x00, 0x00, 0x08, 0xa6,
+	0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x5a, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00,
+	0x05, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x03, 0x5f, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x11,
+	0x00, 0x00, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x08, 0x09,
+	0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x01, 0x01,
+	0x23, 0x01, 0x33, 0x01, 0xa1, 0x56, 0xca, 0x46, 0x02, 0x44, 0xca, 0x66, 0x02, 0x2c, 0xab, 0xfd,
+	0x39, 0xd0, 0x66, 0xfd, 0xc8, 0x03, 0x07, 0x94, 0xfe, 0xff, 0xe5, 0x05, 0xc8, 0xfb, 0x6f, 0x04,
+	0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x06, 0x4e, 0x01, 0x41, 0x00,
+	0x00, 0x02, 0x00, 0xef, 0x00, 0x00, 0x06, 0x9d, 0x06, 0x44, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x7f,
+	0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1c,
+	0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x02, 0x01, 0x02,
+	0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x19,
+	0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b,
+	0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x10,
+	0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x08, 0x09, 0x1a, 0x2b, 0x21,
+	0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x01, 0x01, 0x23, 0x01, 0x33,
+	0x01, 0x1c, 0x2d, 0xc1, 0x20, 0x01, 0x9e, 0xc5, 0x37, 0x01, 0x89, 0xaa, 0xfd, 0xf6, 0xc6, 0x3e,
+	0xfe, 0x54, 0x02, 0x77, 0x94, 0xfe, 0xff, 0xe4, 0x04, 0x3e, 0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb,
+	0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x05, 0x03, 0x01, 0x41, 0x00, 0x02, 0x01, 0x4b,
+	0x00, 0x00, 0x08, 0xa6, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x60, 0xb7, 0x0b, 0x06, 0x03,
+	0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x05, 0x06, 0x05,
+	0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x04,
+	0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01,
+	0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x07, 0x04, 0x02, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e,
+	0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13,
+	0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x09, 0x02, 0x33, 0x01, 0x01, 0xa1, 0x56, 0xca,
+	0x46, 0x02, 0x44, 0xca, 0x66, 0x02, 0x2c, 0xab, 0xfd, 0x39, 0xd0, 0x66, 0xfd, 0xc8, 0x02, 0x3d,
+	0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x05, 0xc8, 0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa,
+	0x38, 0x04, 0x75, 0xfb, 0x8b, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0xef,
+	0x00, 0x00, 0x06, 0x9d, 0x06, 0x44, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x86, 0xb7, 0x0b, 0x06, 0x03,
+	0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05,
+	0x00, 0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b,
+	0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00,
+	0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x05,
+	0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d,
+	0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00,
+	0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09,
+	0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x09, 0x02,
+	0x33, 0x01, 0x01, 0x1c, 0x2d, 0xc1, 0x20, 0x01, 0x9e, 0xc5, 0x37, 0x01, 0x89, 0xaa, 0xfd, 0xf6,
+	0xc6, 0x3e, 0xfe, 0x54, 0x01, 0xb2, 0x01, 0x31, 0xe4, 0xfe, 0x7f, 0x04, 0x3e, 0xfc, 0xce, 0x03,
+	0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x05, 0x03, 0x01, 0x41, 0xfe,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x4b, 0x00, 0x00, 0x08, 0xa6, 0x06, 0xfb, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x14, 0x00, 0x6a, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05,
+	0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67,
+	0x02, 0x01, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x09, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x1d, 0x11, 0x11, 0x0d, 0x0d, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10,
+	0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b,
+	0x21, 0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x01, 0x01, 0x37, 0x33,
+	0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0xa1, 0x56, 0xca, 0x46, 0x02, 0x44, 0xca, 0x66, 0x02, 0x2c,
+	0xab, 0xfd, 0x39, 0xd0, 0x66, 0xfd, 0xc8, 0x01, 0x86, 0x23, 0xad, 0x23, 0xde, 0x23, 0xad, 0x23,
+	0x05, 0xc8, 0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b,
+	0x06, 0x4e, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0xef, 0x00, 0x00, 0x06, 0x9d,
+	0x05, 0xba, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x14, 0x00, 0x6c, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x09,
+	0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02,
+	0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x1d, 0x11, 0x11, 0x0d, 0x0d, 0x00, 0x00, 0x11, 0x14,
+	0x11, 0x14, 0x13, 0x12, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12,
+	0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01, 0x33, 0x13, 0x01, 0x33, 0x01,
+	0x23, 0x03, 0x01, 0x13, 0x37, 0x33, 0x07, 0x33, 0x37, 0x33, 0x07, 0x01, 0x1c, 0x2d, 0xc1, 0x20,
+	0x01, 0x9e, 0xc5, 0x37, 0x01, 0x89, 0xaa, 0xfd, 0xf6, 0xc6, 0x3e, 0xfe, 0x54, 0xef, 0x22, 0xad,
+	0x22, 0xde, 0x22, 0xad, 0x22, 0x04, 0x3e, 0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb,
+	0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x02, 0x01, 0x50,
+	0x00, 0x00, 0x06, 0x6b, 0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x53, 0xb6, 0x04, 0x01, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x04, 0x03, 0x04, 0x85,
+	0x00, 0x03, 0x00, 0x03, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00, 0x03, 0x00, 0x03, 0x85, 0x01,
+	0x01, 0x00, 0x02, 0x00, 0x85, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x06, 0x09, 0x18, 0x2b, 0x21,
+	0x13, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x03, 0x01, 0x23, 0x01, 0x33, 0x02, 0x3c, 0x7b, 0xfe,
+	0x99, 0xf0, 0x01, 0x1c, 0x02, 0x4c, 0xc3, 0xfd, 0x1f, 0x7c, 0x01, 0x70, 0x94, 0xfe, 0xff, 0xe5,
+	0x02, 0x69, 0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41,
+	0x00, 0x02, 0x00, 0xa5, 0xfe, 0x75, 0x04, 0xd8, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4b,
+	0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03,
+	0x04, 0x00, 0x04, 0x03, 0x00, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00,
+	0x03, 0x00, 0x03, 0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e,
+	0x59, 0xb7, 0x11, 0x11, 0x11, 0x12, 0x11, 0x05, 0x09, 0x1b, 0x2b, 0x21, 0x03, 0x33, 0x13, 0x01,
+	0x33, 0x01, 0x23, 0x01, 0x23, 0x01, 0x33, 0x01, 0x9f, 0xa8, 0xc8, 0x80, 0x01, 0xeb, 0xae, 0xfc,
+	0x9a, 0xcd, 0x02, 0xf2, 0x94, 0xfe, 0xff, 0xe4, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37,
+	0x06, 0x8e, 0x01, 0x41, 0x00, 0x01, 0x00, 0xf7, 0x02, 0x1f, 0x04, 0x15, 0x02, 0xb3, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x13, 0x37, 0x21, 0x07, 0xf7, 0x1e, 0x03, 0x00, 0x1e, 0x02, 0x1f, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xf7, 0x02, 0x1f, 0x08, 0x15, 0x02, 0xb3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07,
+	0xf7, 0x1e, 0x07, 0x00, 0x1e, 0x02, 0x1f, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x77,
+	0x02, 0x1f, 0x08, 0x95, 0x02, 0xb3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0x77, 0x1e, 0x08, 0x00,
+	0x1e, 0x02, 0x1f, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb2, 0xfe, 0x50, 0x04, 0x81,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x37, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2c, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x05, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x07,
+	0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x18, 0x18, 0x04, 0x81, 0x19, 0xfb, 0x4a, 0x18, 0x04,
+	0x80, 0x18, 0x7c, 0x7c, 0x7c, 0xfe, 0xcc, 0x7c, 0x7c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x31,
+	0x03, 0xf4, 0x02, 0x99, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x18, 0x40, 0x15, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x14, 0x02, 0x09,
+	0x18, 0x2b, 0x01, 0x07, 0x06, 0x07, 0x07, 0x33, 0x07, 0x23, 0x37, 0x12, 0x02, 0x99, 0x0f, 0x65,
+	0x28, 0x04, 0x60, 0x31, 0xf7, 0x2a, 0x42, 0x06, 0x2b, 0x4a, 0x1b, 0xc7, 0x15, 0xf6, 0xd6, 0x01,
+	0x46, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x49, 0x03, 0xf4, 0x02, 0xb1, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x13, 0x40, 0x10, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x00, 0x4e, 0x11,
+	0x14, 0x02, 0x09, 0x18, 0x2b, 0x01, 0x37, 0x36, 0x37, 0x37, 0x23, 0x37, 0x33, 0x07, 0x02, 0x01,
+	0x49, 0x0f, 0x65, 0x28, 0x04, 0x60, 0x31, 0xf7, 0x2b, 0x41, 0x03, 0xf4, 0x4a, 0x1b, 0xc7, 0x14,
+	0xf7, 0xd6, 0xfe, 0xb7, 0x00, 0x01, 0x00, 0x37, 0xfe, 0xd8, 0x01, 0x9b, 0x00, 0xf7, 0x00, 0x09,
+	0x00, 0x28, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0b, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x0b, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3c,
+	0x00, 0x4e, 0x59, 0xb4, 0x11, 0x14, 0x02, 0x09, 0x18, 0x2b, 0x13, 0x37, 0x36, 0x37, 0x37, 0x23,
+	0x37, 0x33, 0x07, 0x02, 0x37, 0x0f, 0x66, 0x23, 0x04, 0x60, 0x31, 0xf7, 0x2b, 0x3c, 0xfe, 0xd8,
+	0x4a, 0x1b, 0xaf, 0x14, 0xf7, 0xd6, 0xfe, 0xd1, 0x00, 0x01, 0x01, 0x3a, 0x03, 0xf4, 0x02, 0x9d,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x17, 0x40, 0x14, 0x09, 0x01, 0x01, 0x49, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x11, 0x13, 0x02, 0x09, 0x18, 0x2b, 0x01, 0x26, 0x13,
+	0x37, 0x33, 0x07, 0x23, 0x07, 0x06, 0x17, 0x02, 0x2c, 0xf2, 0x41, 0x2b, 0xf7, 0x31, 0x60, 0x04,
+	0x28, 0x5b, 0x03, 0xf4, 0x18, 0x01, 0x49, 0xd6, 0xf7, 0x14, 0xc7, 0x1b, 0x00, 0x02, 0x01, 0x11,
+	0x03, 0xf4, 0x04, 0x38, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x1d, 0x40, 0x1a, 0x02, 0x01,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x11, 0x17, 0x11, 0x14, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x07, 0x06, 0x07, 0x07, 0x33, 0x07,
+	0x23, 0x37, 0x12, 0x25, 0x07, 0x06, 0x07, 0x07, 0x33, 0x07, 0x23, 0x37, 0x12, 0x02, 0x79, 0x0f,
+	0x65, 0x28, 0x04, 0x60, 0x31, 0xf7, 0x2a, 0x42, 0x02, 0xbb, 0x0f, 0x65, 0x28, 0x04, 0x60, 0x31,
+	0xf7, 0x2a, 0x42, 0x06, 0x2b, 0x4a, 0x1b, 0xc7, 0x15, 0xf6, 0xd6, 0x01, 0x46, 0x1b, 0x4a, 0x1b,
+	0xc7, 0x15, 0xf6, 0xd6, 0x01, 0x46, 0x00, 0x00, 0x00, 0x02, 0x01, 0x39, 0x03, 0xf4, 0x04, 0x60,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x17, 0x40, 0x14, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x03, 0x01, 0x01, 0x01, 0x3a, 0x00, 0x4e, 0x11, 0x17, 0x11, 0x14, 0x04, 0x09, 0x1a, 0x2b, 0x01,
+	0x37, 0x36, 0x37, 0x37, 0x23, 0x37, 0x33, 0x07, 0x02, 0x17, 0x37, 0x36, 0x37, 0x37, 0x23, 0x37,
+	0x33, 0x07, 0x02, 0x01, 0x39, 0x0f, 0x65, 0x28, 0x04, 0x60, 0x31, 0xf7, 0x2b, 0x41, 0xc3, 0x0f,
+	0x65, 0x28, 0x04, 0x60, 0x31, 0xf7, 0x2b, 0x41, 0x03, 0xf4, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6,
+	0xfe, 0xb7, 0x18, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe, 0xb7, 0x00, 0x00, 0x02, 0x00, 0x2f,
+	0xfe, 0xc0, 0x03, 0x56, 0x00, 0xf7, 0x00, 0x09, 0x00, 0x13, 0x00, 0x2e, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x0d, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e,
+	0x1b, 0x40, 0x0d, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3c, 0x00, 0x4e,
+	0x59, 0xb6, 0x11, 0x17, 0x11, 0x14, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x37, 0x36, 0x37, 0x37, 0x23,
+	0x37, 0x33, 0x07, 0x02, 0x17, 0x37, 0x36, 0x37, 0x37, 0x23, 0x37, 0x33, 0x07, 0x02, 0x2f, 0x0e,
+	0x66, 0x28, 0x04, 0x60, 0x31, 0xf7, 0x2b, 0x42, 0xc4, 0x0e, 0x66, 0x28, 0x04, 0x60, 0x31, 0xf7,
+	0x2b, 0x42, 0xfe, 0xc0, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe, 0xb7, 0x18, 0x4a, 0x1b, 0xc7,
+	0x14, 0xf7, 0xd6, 0xfe, 0xb7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x43, 0xfe, 0xd8, 0x04, 0xa8,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0xb7, 0x09, 0x02, 0x01, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0f, 0x02, 0x01, 0x00, 0x04, 0x01, 0x03, 0x00, 0x03, 0x63, 0x00,
+	0x01, 0x01, 0x38, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00,
+	0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x13, 0x05, 0x09, 0x19, 0x2b,
+	0x01, 0x13, 0x05, 0x37, 0x05, 0x13, 0x33, 0x03, 0x25, 0x07, 0x25, 0x03, 0x01, 0xa6, 0xfb, 0xfe,
+	0xa2, 0x1e, 0x01, 0x54, 0x54, 0xc5, 0x85, 0x01, 0x5f, 0x1e, 0xfe, 0xab, 0xca, 0xfe, 0xd8, 0x04,
+	0x6f, 0x19, 0x94, 0x18, 0x02, 0x1e, 0xfd, 0xe2, 0x18, 0x94, 0x19, 0xfb, 0x91, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xcd, 0xfe, 0xd8, 0x04, 0xa8, 0x05, 0xc8, 0x00, 0x13, 0x00, 0x63, 0x40, 0x0f,
+	0x0d, 0x06, 0x05, 0x03, 0x04, 0x03, 0x00, 0x11, 0x02, 0x01, 0x03, 0x04, 0x03, 0x02, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x80, 0x02, 0x01,
+	0x00, 0x05, 0x01, 0x04, 0x00, 0x04, 0x63, 0x00, 0x01, 0x01, 0x38, 0x01, 0x4e, 0x1b, 0x40, 0x20,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x80, 0x02, 0x01, 0x00,
+	0x03, 0x04, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x04, 0x5f, 0x05, 0x01, 0x04, 0x00, 0x04, 0x4f,
+	0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x13, 0x11, 0x11, 0x17, 0x06, 0x09, 0x1a,
+	0x2b, 0x01, 0x13, 0x05, 0x37, 0x05, 0x13, 0x05, 0x37, 0x05, 0x13, 0x33, 0x03, 0x25, 0x07, 0x25,
+	0x03, 0x25, 0x07, 0x25, 0x03, 0x01, 0xa6, 0x85, 0xfe, 0xa2, 0x1d, 0x01, 0x54, 0x63, 0xfe, 0xa2,
+	0x1e, 0x01, 0x54, 0x54, 0xc5, 0x85, 0x01, 0x5f, 0x1e, 0xfe, 0xab, 0x63, 0x01, 0x5f, 0x1d, 0xfe,
+	0xab, 0x54, 0xfe, 0xd8, 0x02, 0x1f, 0x19, 0x94, 0x19, 0x01, 0xee, 0x19, 0x94, 0x18, 0x02, 0x1e,
+	0xfd, 0xe2, 0x18, 0x94, 0x19, 0xfe, 0x12, 0x19, 0x94, 0x19, 0xfd, 0xe1, 0x00, 0x01, 0x00, 0xeb,
+	0x02, 0x2b, 0x03, 0x44, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x1a, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x00, 0x4e, 0x01, 0x00, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x03, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06,
+	0x01, 0xdb, 0x6f, 0x81, 0x17, 0x17, 0xc3, 0x73, 0x73, 0x82, 0x17, 0x17, 0xc5, 0x02, 0x2b, 0xa4,
+	0x72, 0x73, 0xa2, 0xa3, 0x74, 0x73, 0xa1, 0x00, 0x00, 0x03, 0x00, 0xc7, 0x00, 0x00, 0x07, 0x81,
+	0x01, 0x01, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x05, 0x07, 0x03, 0x06, 0x05, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x05, 0x07,
+	0x03, 0x06, 0x05, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00,
+	0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x21, 0x13,
+	0x21, 0x03, 0xc7, 0x33, 0x01, 0x01, 0x33, 0x01, 0xc2, 0x33, 0x01, 0x01, 0x33, 0x01, 0xc2, 0x33,
+	0x01, 0x01, 0x33, 0x01, 0x01, 0xfe, 0xff, 0x01, 0x01, 0xfe, 0xff, 0x01, 0x01, 0xfe, 0xff, 0x00,
+	0x00, 0x07, 0x00, 0x40, 0xff, 0xdb, 0x08, 0x5f, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x20,
+	0x00, 0x29, 0x00, 0x35, 0x00, 0x3e, 0x00, 0x42, 0x00, 0xfe, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40,
+	0x3a, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01,
+	0x07, 0x06, 0x05, 0x07, 0x69, 0x00, 0x0c, 0x0c, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x62, 0x12, 0x08, 0x10, 0x03,
+	0x04, 0x04, 0x39, 0x4d, 0x14, 0x01, 0x0d, 0x0d, 0x39, 0x0d, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x3a, 0x00, 0x0c, 0x01, 0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x0f, 0x01,
+	0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05,
+	0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03,
+	0x06, 0x06, 0x04, 0x62, 0x12, 0x08, 0x10, 0x03, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x38,
+	0x00, 0x0c, 0x01, 0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x00, 0x01, 0x00, 0x03, 0x02,
+	0x01, 0x03, 0x69, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05,
+	0x0b, 0x01, 0x07, 0x06, 0x05, 0x07, 0x69, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x62, 0x12,
+	0x08, 0x10, 0x03, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x3b, 0x3f, 0x3f, 0x37, 0x36,
+	0x2b, 0x2a, 0x22, 0x21, 0x16, 0x15, 0x0d, 0x0c, 0x01, 0x00, 0x3f, 0x42, 0x3f, 0x42, 0x41, 0x40,
+	0x3c, 0x3a, 0x36, 0x3e, 0x37, 0x3e, 0x31, 0x2f, 0x2a, 0x35, 0x2b, 0x35, 0x27, 0x25, 0x21, 0x29,
+	0x22, 0x29, 0x1c, 0x1a, 0x15, 0x20, 0x16, 0x20, 0x12, 0x10, 0x0c, 0x14, 0x0d, 0x14, 0x07, 0x05,
+	0x00, 0x0b, 0x01, 0x0b, 0x15, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x13, 0x36, 0x26, 0x23, 0x22, 0x03, 0x02, 0x01, 0x22, 0x26,
+	0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x13, 0x36, 0x26, 0x23, 0x22,
+	0x03, 0x02, 0x05, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32,
+	0x13, 0x36, 0x26, 0x23, 0x22, 0x03, 0x02, 0x05, 0x01, 0x33, 0x01, 0x01, 0xed, 0x8d, 0x80, 0x21,
+	0x22, 0xd2, 0x91, 0x90, 0x82, 0x21, 0x23, 0xd2, 0x7e, 0xa8, 0x37, 0x18, 0x3c, 0x4c, 0xa7, 0x37,
+	0x36, 0x02, 0x96, 0x8e, 0x81, 0x21, 0x22, 0xd2, 0x91, 0x90, 0x83, 0x22, 0x23, 0xd1, 0x7f, 0xa8,
+	0x37, 0x18, 0x3c, 0x4c, 0xa7, 0x37, 0x36, 0x03, 0x4f, 0x8e, 0x81, 0x21, 0x22, 0xd2, 0x91, 0x90,
+	0x84, 0x22, 0x23, 0xd2, 0x7f, 0xa9, 0x37, 0x18, 0x3d, 0x4c, 0xa7, 0x37, 0x36, 0xfa, 0x1c, 0x05,
+	0x77, 0x87, 0xfa, 0x89, 0x02, 0xe4, 0xca, 0xa8, 0xaa, 0xc8, 0xc7, 0xa9, 0xae, 0xc6, 0x63, 0x01,
+	0x11, 0x7b, 0x93, 0xfe, 0xf1, 0xfe, 0xf0, 0xfc, 0xb9, 0xc9, 0xa9, 0xaa, 0xc8, 0xc7, 0xa9, 0xae,
+	0xc6, 0x63, 0x01, 0x11, 0x7b, 0x93, 0xfe, 0xf0, 0xfe, 0xf1, 0x63, 0xca, 0xa8, 0xaa, 0xc8, 0xc7,
+	0xa9, 0xae, 0xc6, 0x63, 0x01, 0x11, 0x7b, 0x93, 0xfe, 0xf0, 0xfe, 0xf1, 0x88, 0x06, 0x12, 0xf9,
+	0xee, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xe6, 0x03, 0xdb, 0x02, 0xb1, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00, 0x00, 0x00, 0x3a, 0x00, 0x4e,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x13, 0x33, 0x01, 0xe6,
+	0xed, 0xde, 0xfe, 0xb0, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x00, 0x00, 0x00, 0x02, 0x00, 0xe5,
+	0x03, 0xdb, 0x04, 0x07, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x05, 0x03,
+	0x04, 0x03, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b,
+	0x13, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0xe5, 0xec, 0xde, 0xfe, 0xb1, 0xdd, 0xec, 0xde,
+	0xfe, 0xb1, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x02, 0x50, 0xfd, 0xb0, 0x00, 0x01, 0x00, 0xc1,
+	0x00, 0x63, 0x02, 0xf8, 0x03, 0xdb, 0x00, 0x05, 0x00, 0x06, 0xb3, 0x05, 0x03, 0x01, 0x32, 0x2b,
+	0x01, 0x01, 0x13, 0x07, 0x01, 0x01, 0x02, 0xf8, 0xfe, 0x8e, 0xde, 0x71, 0xfe, 0xce, 0x01, 0xe4,
+	0x03, 0x91, 0xfe, 0x8e, 0xfe, 0x8e, 0x4a, 0x01, 0xbc, 0x01, 0xbc, 0x00, 0x00, 0x01, 0x00, 0x9f,
+	0x00, 0x63, 0x02, 0xd6, 0x03, 0xdb, 0x00, 0x05, 0x00, 0x06, 0xb3, 0x05, 0x03, 0x01, 0x32, 0x2b,
+	0x37, 0x01, 0x03, 0x37, 0x01, 0x01, 0x9f, 0x01, 0x72, 0xde, 0x72, 0x01, 0x31, 0xfe, 0x1d, 0xad,
+	0x01, 0x72, 0x01, 0x72, 0x4a, 0xfe, 0x44, 0xfe, 0x44, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0xdd,
+	0x00, 0x00, 0x04, 0x88, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x13, 0x00, 0x6d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x0b, 0x07, 0x09, 0x03, 0x03, 0x02, 0x00, 0x02, 0x03,
+	0x00, 0x80, 0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x60, 0x0a, 0x05,
+	0x08, 0x03, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x06, 0x01, 0x02, 0x03, 0x02, 0x85,
+	0x0b, 0x07, 0x09, 0x03, 0x03, 0x00, 0x03, 0x85, 0x04, 0x01, 0x00, 0x00, 0x01, 0x60, 0x0a, 0x05,
+	0x08, 0x03, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x22, 0x0e, 0x0e, 0x0a, 0x0a, 0x04, 0x04,
+	0x00, 0x00, 0x0e, 0x13, 0x0e, 0x13, 0x11, 0x10, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x04, 0x09,
+	0x04, 0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0c, 0x09, 0x17, 0x2b, 0x33, 0x37, 0x33,
+	0x07, 0x03, 0x13, 0x13, 0x33, 0x03, 0x03, 0x13, 0x37, 0x33, 0x07, 0x03, 0x13, 0x13, 0x33, 0x03,
+	0x03, 0xdd, 0x27, 0xc5, 0x27, 0x5e, 0x85, 0x3b, 0xc5, 0x3b, 0xb6, 0xc4, 0x27, 0xc5, 0x27, 0x5f,
+	0x86, 0x3b, 0xc5, 0x3b, 0xb7, 0xc5, 0xc5, 0x01, 0x8b, 0x03, 0x15, 0x01, 0x28, 0xfe, 0xd8, 0xfc,
+	0xeb, 0xfe, 0x75, 0xc5, 0xc5, 0x01, 0x8b, 0x03, 0x15, 0x01, 0x28, 0xfe, 0xd8, 0xfc, 0xeb, 0x00,
+	0x00, 0x01, 0x01, 0x4b, 0x06, 0x44, 0x04, 0x13, 0x06, 0xda, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x01, 0x37, 0x21, 0x07, 0x01, 0x4b, 0x1e, 0x02, 0xaa, 0x1e, 0x06, 0x44,
+	0x96, 0x96, 0x00, 0x00, 0x00, 0x01, 0xfe, 0x4c, 0xff, 0xdb, 0x04, 0x49, 0x05, 0xed, 0x00, 0x03,
+	0x00, 0x2e, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x02, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x05, 0x01, 0x33, 0x01, 0xfe, 0x4c, 0x05, 0x76, 0x87, 0xfa, 0x8b, 0x25, 0x06, 0x12, 0xf9, 0xee,
+	0x00, 0x03, 0x00, 0xf2, 0x02, 0x9f, 0x04, 0xb4, 0x06, 0x43, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x17,
+	0x00, 0x31, 0x40, 0x2e, 0x17, 0x10, 0x0f, 0x08, 0x04, 0x02, 0x03, 0x01, 0x4c, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00,
+	0x57, 0x00, 0x4e, 0x01, 0x00, 0x13, 0x11, 0x0b, 0x09, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x05,
+	0x0b, 0x16, 0x2b, 0x01, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x16, 0x33, 0x32, 0x13,
+	0x36, 0x36, 0x37, 0x37, 0x26, 0x23, 0x22, 0x03, 0x06, 0x06, 0x07, 0x02, 0x61, 0xfe, 0x91, 0x75,
+	0x74, 0x01, 0x6f, 0x01, 0x6a, 0x70, 0x75, 0xfe, 0x17, 0x03, 0x8e, 0xd6, 0x5e, 0x08, 0x0d, 0x03,
+	0x03, 0x04, 0x8f, 0xd5, 0x5e, 0x09, 0x0b, 0x03, 0x02, 0x9f, 0x01, 0xd2, 0x01, 0xd2, 0xfe, 0x2e,
+	0xfe, 0x2e, 0x01, 0x03, 0xab, 0x01, 0x78, 0x23, 0x43, 0x20, 0x4b, 0xaa, 0xfe, 0x87, 0x24, 0x41,
+	0x1f, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x11, 0x02, 0xb5, 0x04, 0x35, 0x06, 0x2d, 0x00, 0x0a,
+	0x00, 0x0d, 0x00, 0x2e, 0x40, 0x2b, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x05, 0x01, 0x02, 0x03,
+	0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x00, 0x01, 0x01, 0x54, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x55,
+	0x04, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x11, 0x12, 0x11, 0x07, 0x0b,
+	0x1a, 0x2b, 0x01, 0x37, 0x21, 0x37, 0x01, 0x33, 0x03, 0x33, 0x07, 0x23, 0x07, 0x01, 0x21, 0x13,
+	0x02, 0xc0, 0x3e, 0xfe, 0x13, 0x17, 0x02, 0x75, 0x8b, 0x87, 0x94, 0x18, 0x94, 0x3e, 0xfe, 0x6f,
+	0x01, 0x66, 0x63, 0x02, 0xb5, 0xfb, 0x59, 0x02, 0x24, 0xfd, 0xe4, 0x61, 0xfb, 0x01, 0x5c, 0x01,
+	0x8a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x35, 0x02, 0x9f, 0x04, 0x57, 0x06, 0x2d, 0x00, 0x20,
+	0x00, 0x2f, 0x40, 0x2c, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04,
+	0x01, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x54, 0x4d, 0x00, 0x00, 0x00, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x28, 0x21, 0x11, 0x11, 0x28, 0x22, 0x06, 0x0b, 0x1c,
+	0x2b, 0x01, 0x37, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x13, 0x21,
+	0x07, 0x21, 0x07, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x01, 0x35, 0x1a,
+	0x67, 0x63, 0x3d, 0x60, 0x47, 0x2c, 0x0a, 0x0c, 0x10, 0x3b, 0x6b, 0x52, 0x7f, 0x6e, 0x02, 0x31,
+	0x19, 0xfe, 0x50, 0x3e, 0x31, 0x61, 0x8d, 0x57, 0x1c, 0x11, 0x13, 0x5e, 0x82, 0x9d, 0x52, 0x2a,
+	0x5d, 0x02, 0xb2, 0x69, 0x24, 0x1e, 0x34, 0x47, 0x29, 0x2b, 0x45, 0x30, 0x19, 0x01, 0xbb, 0x68,
+	0xf9, 0x24, 0x45, 0x67, 0x43, 0x4c, 0x6b, 0x44, 0x1f, 0x09, 0x00, 0x00, 0x00, 0x02, 0x01, 0x2f,
+	0x02, 0x9f, 0x04, 0x5d, 0x06, 0x43, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x33, 0x40, 0x30, 0x10, 0x01,
+	0x03, 0x02, 0x11, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x00, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69,
+	0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x56, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x57, 0x01, 0x4e, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x0b, 0x1c, 0x2b, 0x01,
+	0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x00, 0x33, 0x32, 0x17,
+	0x07, 0x26, 0x23, 0x20, 0x01, 0x36, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x02, 0x10,
+	0x7b, 0x99, 0x8a, 0x79, 0x20, 0x26, 0xdb, 0xa6, 0xa9, 0x88, 0x33, 0x3a, 0x01, 0x2b, 0xcf, 0x61,
+	0x66, 0x1a, 0x79, 0x4b, 0xfe, 0xe9, 0x01, 0x06, 0x37, 0xb9, 0x60, 0x8c, 0x16, 0x19, 0x57, 0x5c,
+	0xb9, 0x04, 0x87, 0x68, 0x95, 0x81, 0x97, 0xa3, 0xe9, 0xcb, 0xea, 0x01, 0x06, 0x21, 0x67, 0x30,
+	0xfd, 0xd2, 0xdc, 0x67, 0x57, 0x63, 0x81, 0x00, 0x00, 0x01, 0x01, 0x6e, 0x02, 0xb5, 0x04, 0xc8,
+	0x06, 0x2d, 0x00, 0x0a, 0x00, 0x1f, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x54, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11,
+	0x14, 0x04, 0x0b, 0x18, 0x2b, 0x01, 0x36, 0x36, 0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x00, 0x03,
+	0x01, 0x6e, 0x2f, 0x76, 0x7b, 0x01, 0x91, 0xfd, 0xc4, 0x1c, 0x02, 0xc9, 0x1c, 0xfd, 0xd6, 0x75,
+	0x02, 0xb5, 0x67, 0x98, 0x84, 0x01, 0x86, 0x6f, 0x6f, 0xfe, 0x08, 0xfe, 0xef, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x01, 0x21, 0x02, 0x9f, 0x04, 0x89, 0x06, 0x43, 0x00, 0x13, 0x00, 0x1e, 0x00, 0x2b,
+	0x00, 0x25, 0x40, 0x22, 0x0a, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x56, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x57, 0x01, 0x4e, 0x2a,
+	0x28, 0x28, 0x24, 0x04, 0x0b, 0x1a, 0x2b, 0x01, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07,
+	0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x36, 0x37, 0x36, 0x26,
+	0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x07, 0x06, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x36, 0x26, 0x27, 0x02, 0x5b, 0x8c, 0x1b, 0x1a, 0xd8, 0x94, 0x8a, 0x8f, 0x16, 0x20, 0xe9, 0xe3,
+	0x27, 0x1c, 0xf6, 0xa7, 0xa5, 0xa7, 0x1c, 0x28, 0x01, 0xbc, 0xaf, 0x18, 0x0e, 0x55, 0x58, 0x52,
+	0x74, 0x0d, 0x0c, 0x48, 0x12, 0x68, 0x54, 0x0f, 0x13, 0x63, 0x65, 0x61, 0x8d, 0x11, 0x0d, 0x39,
+	0x61, 0x04, 0x98, 0x5b, 0x6e, 0x64, 0x7e, 0x6a, 0x58, 0x7f, 0x6a, 0x62, 0x9c, 0x6f, 0x8c, 0x85,
+	0x6f, 0x9c, 0x8e, 0x53, 0x5e, 0x39, 0x43, 0x3f, 0x35, 0x31, 0x4f, 0x8e, 0x37, 0x52, 0x3d, 0x4d,
+	0x5e, 0x51, 0x40, 0x33, 0x48, 0x33, 0x00, 0x00, 0x00, 0x02, 0x01, 0x38, 0x02, 0x9f, 0x04, 0x67,
+	0x06, 0x43, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x33, 0x40, 0x30, 0x11, 0x01, 0x03, 0x00, 0x10, 0x01,
+	0x02, 0x03, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x00, 0x03, 0x04, 0x00, 0x69, 0x00, 0x05, 0x05, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x57, 0x02,
+	0x4e, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x0b, 0x1c, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26,
+	0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x00, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x20,
+	0x01, 0x06, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x03, 0x87, 0x7d, 0x99, 0x8a, 0x78,
+	0x20, 0x26, 0xdb, 0xa6, 0xa8, 0x89, 0x33, 0x3b, 0xfe, 0xd7, 0xcf, 0x63, 0x66, 0x1a, 0x7a, 0x4b,
+	0x01, 0x17, 0xfe, 0xfa, 0x37, 0xb9, 0x60, 0x8c, 0x16, 0x19, 0x57, 0x5c, 0xb9, 0x04, 0x5a, 0x67,
+	0x94, 0x82, 0x97, 0xa3, 0xea, 0xca, 0xea, 0xfe, 0xfa, 0x20, 0x68, 0x30, 0x02, 0x2e, 0xdd, 0x67,
+	0x57, 0x64, 0x80, 0x00, 0x00, 0x01, 0x01, 0x57, 0x02, 0xf0, 0x04, 0x52, 0x05, 0x40, 0x00, 0x0b,
+	0x00, 0x5a, 0x4b, 0xb0, 0x0b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x01, 0x01, 0x02, 0x70, 0x06,
+	0x01, 0x05, 0x00, 0x00, 0x05, 0x71, 0x03, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01, 0x01,
+	0x01, 0x00, 0x60, 0x04, 0x01, 0x00, 0x01, 0x00, 0x50, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x01, 0x02,
+	0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01,
+	0x01, 0x01, 0x00, 0x60, 0x04, 0x01, 0x00, 0x01, 0x00, 0x50, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x0b, 0x1b, 0x2b, 0x01, 0x37, 0x21, 0x37,
+	0x21, 0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x07, 0x02, 0x52, 0x3f, 0xfe, 0xc6, 0x17, 0x01, 0x3a,
+	0x3f, 0x6f, 0x3f, 0x01, 0x3b, 0x17, 0xfe, 0xc5, 0x3f, 0x02, 0xf0, 0xfb, 0x59, 0xfc, 0xfc, 0x59,
+	0xfb, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x54, 0x03, 0xeb, 0x04, 0x56, 0x04, 0x44, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x0b, 0x17, 0x2b,
+	0x01, 0x37, 0x21, 0x07, 0x01, 0x54, 0x17, 0x02, 0xeb, 0x17, 0x03, 0xeb, 0x59, 0x59, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xfe, 0x03, 0x65, 0x04, 0xab, 0x04, 0xcb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f,
+	0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x0b, 0x17, 0x2b, 0x13,
+	0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0xfe, 0x19, 0x03, 0x54, 0x19, 0xfc, 0xec, 0x19, 0x03,
+	0x54, 0x19, 0x03, 0x65, 0x66, 0x66, 0x01, 0x00, 0x66, 0x66, 0x00, 0x00, 0x00, 0x01, 0x01, 0x8f,
+	0x02, 0x04, 0x03, 0xa6, 0x06, 0x68, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b,
+	0x01, 0x07, 0x26, 0x02, 0x37, 0x36, 0x00, 0x37, 0x07, 0x06, 0x06, 0x07, 0x06, 0x16, 0x02, 0xa2,
+	0x15, 0x8c, 0x72, 0x2b, 0x2b, 0x01, 0x09, 0xb8, 0x14, 0x86, 0x96, 0x28, 0x29, 0x27, 0x02, 0x55,
+	0x51, 0x58, 0x01, 0x2e, 0xac, 0xab, 0x01, 0x2f, 0x58, 0x52, 0x60, 0xdc, 0xa4, 0xa5, 0xdc, 0x00,
+	0x00, 0x01, 0x01, 0x08, 0x02, 0x04, 0x03, 0x20, 0x06, 0x68, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07,
+	0x01, 0x01, 0x32, 0x2b, 0x01, 0x37, 0x16, 0x12, 0x07, 0x06, 0x00, 0x07, 0x37, 0x36, 0x36, 0x37,
+	0x36, 0x26, 0x02, 0x0d, 0x15, 0x8c, 0x72, 0x2b, 0x2b, 0xfe, 0xf7, 0xb9, 0x15, 0x87, 0x93, 0x2a,
+	0x28, 0x25, 0x06, 0x16, 0x52, 0x58, 0xfe, 0xd1, 0xab, 0xac, 0xfe, 0xd2, 0x58, 0x51, 0x60, 0xdd,
+	0xa4, 0xa4, 0xdc, 0x00, 0x00, 0x01, 0x01, 0x2f, 0x02, 0xb5, 0x04, 0x4d, 0x05, 0x4f, 0x00, 0x10,
+	0x00, 0x4b, 0xb5, 0x03, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x11,
+	0x01, 0x01, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x05, 0x04, 0x02, 0x02, 0x02, 0x55, 0x02,
+	0x4e, 0x1b, 0x40, 0x18, 0x00, 0x00, 0x01, 0x03, 0x01, 0x00, 0x03, 0x80, 0x00, 0x01, 0x00, 0x03,
+	0x02, 0x01, 0x03, 0x69, 0x05, 0x04, 0x02, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00,
+	0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x0b, 0x1a, 0x2b, 0x01, 0x13, 0x33,
+	0x07, 0x36, 0x33, 0x32, 0x07, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x01, 0x2f,
+	0xa3, 0x94, 0x1f, 0x98, 0x9d, 0xd1, 0x31, 0x74, 0x95, 0x6b, 0x14, 0x1b, 0x39, 0x7d, 0x95, 0x69,
+	0x02, 0xb5, 0x02, 0x8b, 0x7a, 0x89, 0xc6, 0xfe, 0x2c, 0x01, 0xad, 0x4f, 0x38, 0x8f, 0xfe, 0x5b,
+	0x00, 0x03, 0x00, 0x02, 0xfe, 0xb6, 0x03, 0xc4, 0x02, 0x5a, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x17,
+	0x00, 0x31, 0x40, 0x2e, 0x17, 0x10, 0x0f, 0x08, 0x04, 0x02, 0x03, 0x01, 0x4c, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00,
+	0x4d, 0x00, 0x4e, 0x01, 0x00, 0x13, 0x11, 0x0b, 0x09, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x05,
+	0x0a, 0x16, 0x2b, 0x01, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x16, 0x33, 0x32, 0x13,
+	0x36, 0x36, 0x37, 0x37, 0x26, 0x23, 0x22, 0x03, 0x06, 0x06, 0x07, 0x01, 0x71, 0xfe, 0x91, 0x75,
+	0x74, 0x01, 0x6f, 0x01, 0x6a, 0x70, 0x75, 0xfe, 0x17, 0x03, 0x8e, 0xd6, 0x5e, 0x08, 0x0d, 0x03,
+	0x03, 0x04, 0x8f, 0xd5, 0x5e, 0x09, 0x0b, 0x03, 0xfe, 0xb6, 0x01, 0xd2, 0x01, 0xd2, 0xfe, 0x2e,
+	0xfe, 0x2e, 0x01, 0x03, 0xab, 0x01, 0x78, 0x23, 0x43, 0x20, 0x4b, 0xaa, 0xfe, 0x87, 0x24, 0x41,
+	0x1f, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x69, 0xfe, 0xcc, 0x02, 0xee, 0x02, 0x5a, 0x00, 0x09,
+	0x00, 0x21, 0x40, 0x1e, 0x06, 0x04, 0x03, 0x03, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15, 0x11, 0x04,
+	0x0a, 0x18, 0x2b, 0x13, 0x37, 0x33, 0x13, 0x07, 0x37, 0x25, 0x03, 0x33, 0x07, 0x69, 0x16, 0xed,
+	0xaf, 0xf8, 0x16, 0x01, 0x95, 0xcd, 0xed, 0x16, 0xfe, 0xcc, 0x58, 0x02, 0xbd, 0x2f, 0x5b, 0x4d,
+	0xfc, 0xca, 0x58, 0x00, 0x00, 0x01, 0x00, 0x18, 0xfe, 0xcc, 0x03, 0x4f, 0x02, 0x5a, 0x00, 0x19,
+	0x00, 0x2b, 0x40, 0x28, 0x0b, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x4c, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x49, 0x03, 0x4e,
+	0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x18, 0x23, 0x28, 0x05, 0x0a, 0x19, 0x2b, 0x13, 0x37, 0x36,
+	0x3f, 0x02, 0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x37, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06,
+	0x07, 0x07, 0x06, 0x07, 0x21, 0x07, 0x18, 0x1a, 0x4b, 0x93, 0x61, 0x59, 0xae, 0x1c, 0x29, 0xb6,
+	0x6a, 0xae, 0x1a, 0xa1, 0x8a, 0x90, 0x8b, 0x1c, 0x14, 0x77, 0x92, 0x3d, 0xb9, 0x3c, 0x01, 0xbd,
+	0x19, 0xfe, 0xcc, 0x67, 0x60, 0x66, 0x42, 0x3c, 0x77, 0x71, 0xa3, 0x48, 0x68, 0x38, 0x87, 0x73,
+	0x4e, 0x78, 0x5a, 0x26, 0x71, 0x76, 0x67, 0x00, 0x00, 0x01, 0x00, 0x40, 0xfe, 0xb6, 0x03, 0x5f,
+	0x02, 0x5a, 0x00, 0x21, 0x00, 0x37, 0x40, 0x34, 0x14, 0x01, 0x02, 0x03, 0x1b, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x03, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x4c, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x4d, 0x05, 0x4e, 0x27, 0x23, 0x23, 0x21, 0x23, 0x24, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x37, 0x16,
+	0x17, 0x16, 0x33, 0x32, 0x37, 0x36, 0x26, 0x23, 0x23, 0x37, 0x33, 0x32, 0x36, 0x37, 0x36, 0x23,
+	0x22, 0x07, 0x37, 0x36, 0x33, 0x20, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x40,
+	0x1b, 0x14, 0x0b, 0x73, 0x44, 0xe2, 0x2b, 0x17, 0x7e, 0x8c, 0x3a, 0x14, 0x33, 0x7f, 0xa8, 0x15,
+	0x21, 0xb7, 0x5d, 0x93, 0x1a, 0x8d, 0x66, 0x01, 0x44, 0x34, 0x27, 0xf7, 0xff, 0x2f, 0x1e, 0xeb,
+	0xa8, 0x54, 0xfe, 0xd2, 0x6f, 0x08, 0x03, 0x28, 0xaf, 0x5c, 0x62, 0x50, 0x5f, 0x52, 0x85, 0x32,
+	0x67, 0x24, 0xcf, 0x9c, 0x42, 0x31, 0xba, 0x7b, 0x91, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x21,
+	0xfe, 0xcc, 0x03, 0x45, 0x02, 0x44, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x2e, 0x40, 0x2b, 0x0d, 0x01,
+	0x02, 0x01, 0x01, 0x4c, 0x05, 0x01, 0x02, 0x03, 0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x00, 0x01,
+	0x01, 0x48, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x49, 0x04, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a,
+	0x00, 0x0a, 0x11, 0x11, 0x12, 0x11, 0x07, 0x0a, 0x1a, 0x2b, 0x01, 0x37, 0x21, 0x37, 0x01, 0x33,
+	0x03, 0x33, 0x07, 0x23, 0x07, 0x01, 0x21, 0x13, 0x01, 0xd0, 0x3e, 0xfe, 0x13, 0x17, 0x02, 0x75,
+	0x8b, 0x87, 0x94, 0x18, 0x94, 0x3e, 0xfe, 0x6f, 0x01, 0x66, 0x63, 0xfe, 0xcc, 0xfb, 0x59, 0x02,
+	0x24, 0xfd, 0xe4, 0x61, 0xfb, 0x01, 0x5c, 0x01, 0x8a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x45,
+	0xfe, 0xb6, 0x03, 0x67, 0x02, 0x44, 0x00, 0x20, 0x00, 0x2f, 0x40, 0x2c, 0x01, 0x01, 0x00, 0x01,
+	0x01, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x48, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x28,
+	0x21, 0x11, 0x11, 0x28, 0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x37, 0x16, 0x33, 0x32, 0x3e, 0x02,
+	0x37, 0x36, 0x2e, 0x02, 0x23, 0x23, 0x13, 0x21, 0x07, 0x21, 0x07, 0x33, 0x32, 0x1e, 0x02, 0x07,
+	0x0e, 0x03, 0x23, 0x22, 0x26, 0x45, 0x1a, 0x67, 0x63, 0x3d, 0x60, 0x47, 0x2c, 0x0a, 0x0c, 0x10,
+	0x3b, 0x6b, 0x52, 0x7f, 0x6e, 0x02, 0x31, 0x19, 0xfe, 0x50, 0x3e, 0x31, 0x61, 0x8d, 0x57, 0x1c,
+	0x11, 0x13, 0x5e, 0x82, 0x9d, 0x52, 0x2a, 0x5d, 0xfe, 0xc9, 0x69, 0x24, 0x1e, 0x34, 0x47, 0x29,
+	0x2b, 0x45, 0x30, 0x19, 0x01, 0xbb, 0x68, 0xf9, 0x24, 0x45, 0x67, 0x43, 0x4c, 0x6b, 0x44, 0x1f,
+	0x09, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3f, 0xfe, 0xb6, 0x03, 0x6d, 0x02, 0x5a, 0x00, 0x14,
+	0x00, 0x1e, 0x00, 0x33, 0x40, 0x30, 0x10, 0x01, 0x03, 0x02, 0x11, 0x01, 0x00, 0x03, 0x02, 0x4c,
+	0x00, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x4c, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4d, 0x01, 0x4e, 0x24, 0x22, 0x23,
+	0x24, 0x24, 0x21, 0x06, 0x0a, 0x1c, 0x2b, 0x25, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x37, 0x36, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x20, 0x01, 0x36, 0x23, 0x22,
+	0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x01, 0x20, 0x7b, 0x99, 0x8a, 0x79, 0x20, 0x26, 0xdb, 0xa6,
+	0xa9, 0x88, 0x33, 0x3a, 0x01, 0x2b, 0xcf, 0x61, 0x66, 0x1a, 0x79, 0x4b, 0xfe, 0xe9, 0x01, 0x06,
+	0x37, 0xb9, 0x60, 0x8c, 0x16, 0x19, 0x57, 0x5c, 0xb9, 0x9e, 0x68, 0x95, 0x81, 0x97, 0xa3, 0xe9,
+	0xcb, 0xea, 0x01, 0x06, 0x21, 0x67, 0x30, 0xfd, 0xd2, 0xdc, 0x67, 0x57, 0x63, 0x81, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x7e, 0xfe, 0xcc, 0x03, 0xd8, 0x02, 0x44, 0x00, 0x0a, 0x00, 0x1f, 0x40, 0x1c,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x48, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x49, 0x02,
+	0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x0a, 0x18, 0x2b, 0x13, 0x36, 0x36,
+	0x37, 0x01, 0x21, 0x37, 0x21, 0x07, 0x00, 0x03, 0x7e, 0x2f, 0x76, 0x7b, 0x01, 0x91, 0xfd, 0xc4,
+	0x1c, 0x02, 0xc9, 0x1c, 0xfd, 0xd6, 0x75, 0xfe, 0xcc, 0x67, 0x98, 0x84, 0x01, 0x86, 0x6f, 0x6f,
+	0xfe, 0x08, 0xfe, 0xef, 0x00, 0x03, 0x00, 0x31, 0xfe, 0xb6, 0x03, 0x99, 0x02, 0x5a, 0x00, 0x13,
+	0x00, 0x1e, 0x00, 0x2b, 0x00, 0x25, 0x40, 0x22, 0x0a, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x4c, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x4d, 0x01, 0x4e, 0x2a, 0x28, 0x28, 0x24, 0x04, 0x0a, 0x1a, 0x2b, 0x25, 0x26, 0x37, 0x36, 0x36,
+	0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25,
+	0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x07, 0x06, 0x06, 0x07, 0x06, 0x16,
+	0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x27, 0x01, 0x6b, 0x8c, 0x1b, 0x1a, 0xd8, 0x94, 0x8a, 0x8f,
+	0x16, 0x20, 0xe9, 0xe3, 0x27, 0x1c, 0xf6, 0xa7, 0xa5, 0xa7, 0x1c, 0x28, 0x01, 0xbc, 0xaf, 0x18,
+	0x0e, 0x55, 0x58, 0x52, 0x74, 0x0d, 0x0c, 0x48, 0x12, 0x68, 0x54, 0x0f, 0x13, 0x63, 0x65, 0x61,
+	0x8d, 0x11, 0x0d, 0x39, 0x61, 0xaf, 0x5b, 0x6e, 0x64, 0x7e, 0x6a, 0x58, 0x7f, 0x6a, 0x62, 0x9c,
+	0x6f, 0x8c, 0x85, 0x6f, 0x9c, 0x8e, 0x53, 0x5e, 0x39, 0x43, 0x3f, 0x35, 0x31, 0x4f, 0x8e, 0x37,
+	0x52, 0x3d, 0x4d, 0x5e, 0x51, 0x40, 0x33, 0x48, 0x33, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x48,
+	0xfe, 0xb6, 0x03, 0x77, 0x02, 0x5a, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x33, 0x40, 0x30, 0x11, 0x01,
+	0x03, 0x00, 0x10, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x00, 0x03, 0x04, 0x00, 0x69,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x4d, 0x02, 0x4e, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x0a, 0x1c, 0x2b, 0x25,
+	0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x00, 0x23, 0x22, 0x27,
+	0x37, 0x16, 0x33, 0x20, 0x01, 0x06, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x22, 0x02, 0x97,
+	0x7d, 0x99, 0x8a, 0x78, 0x20, 0x26, 0xdb, 0xa6, 0xa8, 0x89, 0x33, 0x3b, 0xfe, 0xd7, 0xcf, 0x63,
+	0x66, 0x1a, 0x7a, 0x4b, 0x01, 0x17, 0xfe, 0xfa, 0x37, 0xb9, 0x60, 0x8c, 0x16, 0x19, 0x57, 0x5c,
+	0xb9, 0x71, 0x67, 0x94, 0x82, 0x97, 0xa3, 0xea, 0xca, 0xea, 0xfe, 0xfa, 0x20, 0x68, 0x30, 0x02,
+	0x2e, 0xdd, 0x67, 0x57, 0x64, 0x80, 0x00, 0x00, 0x00, 0x01, 0x00, 0x67, 0xff, 0x07, 0x03, 0x62,
+	0x01, 0x57, 0x00, 0x0b, 0x00, 0x49, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x17, 0x06, 0x01, 0x05,
+	0x00, 0x00, 0x05, 0x71, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02,
+	0x02, 0x4a, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01,
+	0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x4a, 0x02, 0x4e, 0x59, 0x40, 0x0e,
+	0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x0a, 0x1b, 0x2b, 0x05,
+	0x37, 0x21, 0x37, 0x21, 0x37, 0x33, 0x07, 0x21, 0x07, 0x21, 0x07, 0x01, 0x62, 0x3f, 0xfe, 0xc6,
+	0x17, 0x01, 0x3a, 0x3f, 0x6f, 0x3f, 0x01, 0x3b, 0x17, 0xfe, 0xc5, 0x3f, 0xf9, 0xfb, 0x59, 0xfc,
+	0xfc, 0x59, 0xfb, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0x02, 0x03, 0x66, 0x00, 0x5b, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x0a, 0x17, 0x2b,
+	0x37, 0x37, 0x21, 0x07, 0x64, 0x17, 0x02, 0xeb, 0x17, 0x02, 0x59, 0x59, 0x00, 0x02, 0x00, 0x0e,
+	0xff, 0x7c, 0x03, 0xbb, 0x00, 0xe2, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x0a, 0x17, 0x2b, 0x17, 0x37, 0x21, 0x07, 0x01,
+	0x37, 0x21, 0x07, 0x0e, 0x19, 0x03, 0x54, 0x19, 0xfc, 0xec, 0x19, 0x03, 0x54, 0x19, 0x84, 0x66,
+	0x66, 0x01, 0x00, 0x66, 0x66, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xcf, 0xfe, 0x1b, 0x02, 0xe6,
+	0x02, 0x7f, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b, 0x01, 0x07, 0x26, 0x02,
+	0x37, 0x36, 0x00, 0x37, 0x07, 0x06, 0x06, 0x07, 0x06, 0x16, 0x01, 0xe2, 0x15, 0x8c, 0x72, 0x2b,
+	0x2b, 0x01, 0x09, 0xb8, 0x14, 0x86, 0x96, 0x28, 0x29, 0x27, 0xfe, 0x6c, 0x51, 0x58, 0x01, 0x2e,
+	0xac, 0xab, 0x01, 0x2f, 0x58, 0x52, 0x60, 0xdc, 0xa4, 0xa5, 0xdc, 0x00, 0x00, 0x01, 0x00, 0x48,
+	0xfe, 0x1b, 0x02, 0x60, 0x02, 0x7f, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b,
+	0x01, 0x37, 0x16, 0x12, 0x07, 0x06, 0x00, 0x07, 0x37, 0x36, 0x36, 0x37, 0x36, 0x26, 0x01, 0x4d,
+	0x15, 0x8c, 0x72, 0x2b, 0x2b, 0xfe, 0xf7, 0xb9, 0x15, 0x87, 0x93, 0x2a, 0x28, 0x25, 0x02, 0x2d,
+	0x52, 0x58, 0xfe, 0xd1, 0xab, 0xac, 0xfe, 0xd2, 0x58, 0x51, 0x60, 0xdd, 0xa4, 0xa4, 0xdc, 0x00,
+	0x00, 0x01, 0x00, 0x3f, 0xfe, 0xcc, 0x03, 0x5d, 0x01, 0x66, 0x00, 0x10, 0x00, 0x4c, 0xb5, 0x03,
+	0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x4a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x1b,
+	0x40, 0x17, 0x00, 0x00, 0x00, 0x4a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4a,
+	0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10,
+	0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x0a, 0x1a, 0x2b, 0x13, 0x13, 0x33, 0x07, 0x36, 0x33,
+	0x32, 0x07, 0x03, 0x23, 0x13, 0x36, 0x26, 0x23, 0x22, 0x07, 0x03, 0x3f, 0xa3, 0x94, 0x1f, 0x98,
+	0x9d, 0xd1, 0x31, 0x74, 0x95, 0x6b, 0x14, 0x1b, 0x39, 0x7d, 0x95, 0x69, 0xfe, 0xcc, 0x02, 0x8b,
+	0x7a, 0x89, 0xc6, 0xfe, 0x2c, 0x01, 0xad, 0x4f, 0x38, 0x8f, 0xfe, 0x5b, 0x00, 0x01, 0x00, 0x97,
+	0x00, 0x00, 0x05, 0x2e, 0x05, 0xc8, 0x00, 0x13, 0x00, 0xb6, 0xb5, 0x07, 0x01, 0x05, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x02, 0x06, 0x01, 0x04, 0x05, 0x02,
+	0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x21, 0x00, 0x03, 0x02, 0x04,
+	0x03, 0x59, 0x00, 0x02, 0x06, 0x01, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00, 0x03, 0x00,
+	0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08,
+	0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00, 0x01, 0x03, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03,
+	0x04, 0x69, 0x08, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x10, 0x00,
+	0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x12, 0x22, 0x12, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1d, 0x2b,
+	0x33, 0x01, 0x21, 0x07, 0x21, 0x03, 0x21, 0x07, 0x36, 0x33, 0x07, 0x26, 0x23, 0x22, 0x07, 0x03,
+	0x23, 0x13, 0x21, 0x03, 0x97, 0x01, 0x27, 0x03, 0x2f, 0x1f, 0xfd, 0x96, 0x5c, 0x01, 0xd5, 0x23,
+	0xb3, 0xc1, 0x26, 0x18, 0x0e, 0xa4, 0xa7, 0x66, 0xc5, 0x8d, 0xfe, 0xf0, 0x8d, 0x05, 0xc8, 0x9d,
+	0xfe, 0x35, 0xb1, 0xc4, 0xbe, 0x02, 0xb7, 0xfe, 0x00, 0x02, 0xc5, 0xfd, 0x3b, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x97, 0x00, 0x00, 0x04, 0xfe, 0x05, 0xed, 0x00, 0x26, 0x00, 0x77, 0xb5, 0x01,
+	0x01, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x0a, 0x01, 0x01, 0x09,
+	0x01, 0x02, 0x03, 0x01, 0x02, 0x67, 0x08, 0x01, 0x03, 0x07, 0x01, 0x04, 0x05, 0x03, 0x04, 0x67,
+	0x00, 0x00, 0x00, 0x0b, 0x61, 0x00, 0x0b, 0x0b, 0x3e, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x0b, 0x00, 0x00, 0x01, 0x0b, 0x00, 0x69,
+	0x0a, 0x01, 0x01, 0x09, 0x01, 0x02, 0x03, 0x01, 0x02, 0x67, 0x08, 0x01, 0x03, 0x07, 0x01, 0x04,
+	0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59,
+	0x40, 0x12, 0x26, 0x24, 0x21, 0x20, 0x1f, 0x1e, 0x11, 0x15, 0x11, 0x14, 0x11, 0x11, 0x11, 0x13,
+	0x22, 0x0c, 0x09, 0x1f, 0x2b, 0x01, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x07, 0x33, 0x07, 0x23,
+	0x07, 0x33, 0x07, 0x23, 0x07, 0x06, 0x06, 0x07, 0x21, 0x07, 0x21, 0x37, 0x36, 0x36, 0x37, 0x37,
+	0x23, 0x37, 0x33, 0x37, 0x23, 0x37, 0x33, 0x37, 0x36, 0x36, 0x33, 0x32, 0x04, 0xfe, 0x22, 0x72,
+	0x73, 0x5c, 0x73, 0x17, 0x1c, 0xec, 0x19, 0xec, 0x22, 0xec, 0x19, 0xec, 0x03, 0x19, 0x7e, 0x64,
+	0x02, 0x71, 0x22, 0xfc, 0xa5, 0x22, 0x70, 0x88, 0x19, 0x12, 0xc6, 0x19, 0xc6, 0x22, 0xc6, 0x19,
+	0xc6, 0x10, 0x2b, 0xf8, 0xbe, 0x68, 0x05, 0xcf, 0xa7, 0x31, 0x73, 0x73, 0x8e, 0x7c, 0xac, 0x7c,
+	0x10, 0x7a, 0xc2, 0x48, 0xad, 0xad, 0x21, 0x9e, 0x7d, 0x58, 0x7c, 0xac, 0x7c, 0x52, 0xd5, 0xe1,
+	0x00, 0x04, 0x00, 0x6f, 0xff, 0xe7, 0x08, 0xe4, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x13, 0x00, 0x29,
+	0x00, 0x4d, 0x01, 0x04, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x13, 0x1f, 0x01, 0x07, 0x04, 0x3c,
+	0x01, 0x03, 0x07, 0x3d, 0x01, 0x01, 0x06, 0x2b, 0x29, 0x02, 0x0a, 0x01, 0x04, 0x4c, 0x1b, 0x40,
+	0x13, 0x1f, 0x01, 0x0c, 0x04, 0x3c, 0x01, 0x0d, 0x07, 0x3d, 0x01, 0x01, 0x06, 0x2b, 0x29, 0x02,
+	0x0a, 0x01, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2d, 0x0c, 0x08, 0x02, 0x07,
+	0x0d, 0x09, 0x02, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69,
+	0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x02, 0x61,
+	0x0e, 0x05, 0x0f, 0x03, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x37, 0x00, 0x0c, 0x00, 0x0d, 0x03, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01, 0x06, 0x01,
+	0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05,
+	0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x35, 0x00, 0x00, 0x00, 0x04, 0x0c,
+	0x00, 0x04, 0x69, 0x00, 0x0c, 0x00, 0x0d, 0x03, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01,
+	0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x0f, 0x01, 0x02,
+	0x02, 0x3c, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e,
+	0x59, 0x59, 0x40, 0x23, 0x00, 0x00, 0x4d, 0x4b, 0x40, 0x3e, 0x3b, 0x39, 0x2e, 0x2c, 0x28, 0x26,
+	0x23, 0x22, 0x21, 0x20, 0x1d, 0x1c, 0x1b, 0x1a, 0x17, 0x15, 0x13, 0x11, 0x0d, 0x0b, 0x00, 0x0a,
+	0x00, 0x0a, 0x24, 0x21, 0x10, 0x09, 0x18, 0x2b, 0x33, 0x01, 0x21, 0x32, 0x16, 0x07, 0x06, 0x04,
+	0x21, 0x23, 0x03, 0x13, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x23, 0x23, 0x01, 0x06, 0x23, 0x22,
+	0x26, 0x37, 0x13, 0x23, 0x37, 0x33, 0x37, 0x37, 0x07, 0x33, 0x07, 0x23, 0x03, 0x06, 0x16, 0x33,
+	0x32, 0x37, 0x17, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x26, 0x27, 0x27, 0x26, 0x26, 0x37,
+	0x36, 0x36, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x17, 0x17, 0x16,
+	0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x6f, 0x01, 0x27, 0x01, 0x62, 0xed, 0xb1, 0x2a, 0x2e, 0xfe,
+	0xa9, 0xfe, 0xf9, 0x2c, 0x75, 0x95, 0x24, 0xaa, 0xc9, 0x1e, 0x1e, 0x6c, 0xa4, 0x50, 0x03, 0x5d,
+	0x53, 0x35, 0x8c, 0x70, 0x1b, 0x61, 0x68, 0x1b, 0x68, 0x1f, 0xc9, 0x23, 0xcf, 0x1b, 0xcf, 0x5b,
+	0x10, 0x34, 0x46, 0x1e, 0x2f, 0x46, 0x20, 0x96, 0x78, 0x4c, 0x57, 0x0c, 0x07, 0x32, 0x38, 0x4f,
+	0x66, 0x56, 0x10, 0x18, 0xca, 0x9c, 0x5b, 0x89, 0x1d, 0x82, 0x56, 0x4b, 0x54, 0x0a, 0x06, 0x2c,
+	0x34, 0x43, 0x82, 0x5a, 0x13, 0x17, 0xdd, 0x95, 0x8c, 0x05, 0xc8, 0xc2, 0xd5, 0xe6, 0xff, 0xfd,
+	0xb4, 0x02, 0xeb, 0x96, 0x97, 0x98, 0x7b, 0xfa, 0xd2, 0x16, 0x89, 0x89, 0x01, 0xe6, 0x85, 0x99,
+	0x15, 0xae, 0x85, 0xfe, 0x38, 0x53, 0x53, 0x0b, 0x5f, 0x9f, 0x4a, 0x38, 0x39, 0x24, 0x3e, 0x19,
+	0x23, 0x2e, 0x7f, 0x52, 0x77, 0x86, 0x1d, 0x94, 0x2c, 0x33, 0x32, 0x21, 0x38, 0x16, 0x1d, 0x38,
+	0x79, 0x5c, 0x76, 0x98, 0x00, 0x01, 0x00, 0x76, 0xff, 0xdb, 0x05, 0x67, 0x05, 0xeb, 0x00, 0x23,
+	0x00, 0x86, 0x40, 0x0e, 0x16, 0x01, 0x07, 0x06, 0x17, 0x01, 0x05, 0x07, 0x04, 0x01, 0x00, 0x02,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x05, 0x09, 0x01, 0x04, 0x03,
+	0x05, 0x04, 0x67, 0x0a, 0x01, 0x03, 0x0c, 0x0b, 0x02, 0x02, 0x00, 0x03, 0x02, 0x67, 0x00, 0x07,
+	0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x06, 0x00, 0x07, 0x05, 0x06, 0x07, 0x69, 0x08, 0x01,
+	0x05, 0x09, 0x01, 0x04, 0x03, 0x05, 0x04, 0x67, 0x0a, 0x01, 0x03, 0x0c, 0x0b, 0x02, 0x02, 0x00,
+	0x03, 0x02, 0x67, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x40,
+	0x16, 0x00, 0x00, 0x00, 0x23, 0x00, 0x23, 0x22, 0x21, 0x1e, 0x1d, 0x11, 0x23, 0x21, 0x11, 0x13,
+	0x11, 0x11, 0x23, 0x21, 0x0d, 0x09, 0x1f, 0x2b, 0x01, 0x12, 0x21, 0x32, 0x37, 0x07, 0x06, 0x23,
+	0x20, 0x13, 0x23, 0x37, 0x33, 0x37, 0x36, 0x37, 0x23, 0x37, 0x33, 0x12, 0x21, 0x32, 0x17, 0x07,
+	0x26, 0x23, 0x20, 0x03, 0x21, 0x07, 0x21, 0x06, 0x07, 0x07, 0x21, 0x07, 0x01, 0xef, 0x08, 0x01,
+	0x33, 0x81, 0xbf, 0x22, 0xcd, 0x88, 0xfe, 0x1b, 0x19, 0xb1, 0x4b, 0x6f, 0x0d, 0x08, 0x16, 0xa5,
+	0x4c, 0x84, 0xd8, 0x01, 0xeb, 0x80, 0x9e, 0x24, 0x95, 0x83, 0xfe, 0xd3, 0xb0, 0x02, 0x37, 0x4c,
+	0xfd, 0xe7, 0x15, 0x08, 0x0e, 0x01, 0xcb, 0x4b, 0x02, 0x19, 0xfe, 0x66, 0x48, 0xac, 0x40, 0x02,
+	0x3e, 0x7b, 0x4b, 0x28, 0x52, 0x7c, 0x02, 0x16, 0x2c, 0xb6, 0x47, 0xfe, 0x85, 0x7c, 0x51, 0x28,
+	0x4c, 0x7b, 0x00, 0x00, 0x00, 0x04, 0x00, 0x62, 0x00, 0x00, 0x07, 0x76, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x17, 0x00, 0x21, 0x00, 0x2b, 0x00, 0x5e, 0x40, 0x5b, 0x0d, 0x01, 0x04, 0x00, 0x17, 0x0e,
+	0x02, 0x05, 0x04, 0x02, 0x4c, 0x03, 0x01, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x05,
+	0x00, 0x02, 0x07, 0x05, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x0c, 0x01,
+	0x08, 0x01, 0x01, 0x08, 0x59, 0x0c, 0x01, 0x08, 0x08, 0x01, 0x61, 0x0b, 0x06, 0x0a, 0x03, 0x01,
+	0x08, 0x01, 0x51, 0x23, 0x22, 0x19, 0x18, 0x00, 0x00, 0x28, 0x26, 0x22, 0x2b, 0x23, 0x2b, 0x1e,
+	0x1c, 0x18, 0x21, 0x19, 0x21, 0x16, 0x14, 0x11, 0x0f, 0x0c, 0x0a, 0x07, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x0d, 0x06, 0x17, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x01, 0x06, 0x23, 0x22, 0x37, 0x36,
+	0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x33, 0x32, 0x37, 0x01, 0x22,
+	0x37, 0x36, 0x00, 0x33, 0x32, 0x07, 0x06, 0x00, 0x27, 0x32, 0x36, 0x37, 0x36, 0x23, 0x22, 0x06,
+	0x07, 0x06, 0x62, 0x06, 0x73, 0xa1, 0xf9, 0x8c, 0x02, 0x23, 0x94, 0x72, 0xdf, 0x2d, 0x23, 0x01,
+	0x3b, 0xa6, 0x40, 0x55, 0x2c, 0x4b, 0x3d, 0x68, 0xc0, 0x1c, 0x1a, 0x75, 0x65, 0x8b, 0x01, 0x37,
+	0xe7, 0x2b, 0x26, 0x01, 0x2a, 0xa7, 0xea, 0x2b, 0x27, 0xfe, 0xd7, 0x75, 0x5b, 0xad, 0x1b, 0x1d,
+	0x6d, 0x59, 0xae, 0x1b, 0x1d, 0x05, 0xc8, 0xfa, 0x38, 0x03, 0x56, 0x3a, 0xe1, 0xb4, 0x01, 0x17,
+	0x19, 0x6f, 0x24, 0xca, 0x8a, 0x82, 0x47, 0xfc, 0x2b, 0xdb, 0xbe, 0x01, 0x14, 0xda, 0xc0, 0xfe,
+	0xed, 0x66, 0xc9, 0x88, 0x90, 0xc9, 0x86, 0x92, 0x00, 0x02, 0x00, 0x68, 0xff, 0xe7, 0x03, 0x6d,
+	0x06, 0x44, 0x00, 0x2d, 0x00, 0x3f, 0x00, 0x2c, 0x40, 0x29, 0x23, 0x22, 0x03, 0x00, 0x04, 0x01,
+	0x03, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00, 0x03, 0x69, 0x00, 0x01, 0x02, 0x02, 0x01,
+	0x59, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x01, 0x02, 0x51, 0x3b, 0x39, 0x29, 0x2e, 0x2c,
+	0x04, 0x06, 0x19, 0x2b, 0x13, 0x06, 0x06, 0x07, 0x37, 0x36, 0x36, 0x37, 0x13, 0x3e, 0x03, 0x33,
+	0x32, 0x16, 0x16, 0x06, 0x07, 0x0e, 0x03, 0x07, 0x07, 0x0e, 0x02, 0x16, 0x33, 0x32, 0x3e, 0x02,
+	0x37, 0x17, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x26, 0x36, 0x37, 0x13, 0x3e, 0x03, 0x37, 0x3e, 0x03,
+	0x26, 0x26, 0x23, 0x22, 0x0e, 0x02, 0x07, 0xf0, 0x16, 0x4a, 0x28, 0x17, 0x23, 0x4d, 0x1a, 0x4e,
+	0x21, 0x4f, 0x5a, 0x67, 0x39, 0x3f, 0x4b, 0x22, 0x04, 0x10, 0x11, 0x41, 0x6d, 0x9b, 0x6a, 0x18,
+	0x0e, 0x19, 0x06, 0x14, 0x20, 0x1b, 0x3f, 0x40, 0x3c, 0x18, 0x54, 0x1e, 0x4d, 0x63, 0x74, 0x43,
+	0x40, 0x3c, 0x0b, 0x1a, 0x17, 0xc7, 0x3d, 0x5c, 0x45, 0x2e, 0x11, 0x02, 0x07, 0x05, 0x01, 0x0c,
+	0x18, 0x16, 0x21, 0x31, 0x2b, 0x29, 0x19, 0x02, 0x04, 0x0c, 0x17, 0x0e, 0x72, 0x0e, 0x1c, 0x0d,
+	0x01, 0x87, 0xa8, 0xde, 0x84, 0x37, 0x2c, 0x56, 0x7e, 0x51, 0x54, 0xb1, 0xad, 0xa4, 0x46, 0x77,
+	0x48, 0x8a, 0x6d, 0x42, 0x31, 0x50, 0x62, 0x32, 0x22, 0x3b, 0x80, 0x6b, 0x45, 0x3d, 0x7e, 0xc1,
+	0x84, 0x01, 0x00, 0x33, 0x71, 0x81, 0x91, 0x53, 0x09, 0x28, 0x32, 0x36, 0x2c, 0x1c, 0x3d, 0x7b,
+	0xb7, 0x7b, 0x00, 0x00, 0x00, 0x04, 0x00, 0xa1, 0x00, 0x00, 0x08, 0xcc, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x25, 0x00, 0x57, 0x40, 0x54, 0x21, 0x01, 0x00, 0x02, 0x01, 0x4c,
+	0x08, 0x01, 0x07, 0x01, 0x07, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01,
+	0x02, 0x0a, 0x01, 0x00, 0x04, 0x02, 0x00, 0x69, 0x00, 0x04, 0x05, 0x05, 0x04, 0x57, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x09, 0x06, 0x0c, 0x03, 0x05, 0x04, 0x05, 0x4f, 0x18, 0x18, 0x0d, 0x0c, 0x01,
+	0x00, 0x25, 0x24, 0x23, 0x22, 0x20, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13,
+	0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0d, 0x06, 0x16, 0x2b, 0x01,
+	0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x27, 0x32, 0x36, 0x37, 0x36,
+	0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x16, 0x01, 0x37, 0x21, 0x07, 0x01, 0x03, 0x23, 0x01, 0x33,
+	0x01, 0x13, 0x33, 0x01, 0x23, 0x06, 0xf0, 0xa9, 0x9d, 0x22, 0x21, 0xf0, 0xa8, 0xa8, 0x9f, 0x22,
+	0x23, 0xef, 0x8f, 0x56, 0x7e, 0x19, 0x18, 0x4a, 0x58, 0x58, 0x7c, 0x18, 0x19, 0x48, 0xfe, 0xd2,
+	0x1d, 0x02, 0x56, 0x1d, 0xfa, 0x63, 0xe1, 0xb8, 0x01, 0x27, 0xc5, 0x01, 0x9e, 0xe0, 0xb6, 0xfe,
+	0xd9, 0xc4, 0x01, 0x59, 0xcb, 0xa8, 0xa9, 0xc9, 0xc8, 0xa9, 0xac, 0xc8, 0x7c, 0x7c, 0x7c, 0x7a,
+	0x7b, 0x7b, 0x7b, 0x7c, 0x7b, 0xfe, 0x2b, 0x94, 0x94, 0x04, 0x68, 0xfb, 0x98, 0x05, 0xc8, 0xfb,
+	0x9f, 0x04, 0x61, 0xfa, 0x38, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0xf6, 0x02, 0xe4, 0x08, 0x14,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x14, 0x00, 0x4a, 0x40, 0x47, 0x13, 0x10, 0x0b, 0x03, 0x07, 0x00,
+	0x01, 0x4c, 0x00, 0x07, 0x00, 0x03, 0x00, 0x07, 0x03, 0x80, 0x0a, 0x08, 0x06, 0x09, 0x04, 0x03,
+	0x03, 0x84, 0x05, 0x04, 0x02, 0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x04, 0x02, 0x01, 0x01, 0x00,
+	0x5f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08, 0x14, 0x08, 0x14, 0x12,
+	0x11, 0x0f, 0x0e, 0x0d, 0x0c, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0b, 0x06,
+	0x19, 0x2b, 0x01, 0x13, 0x23, 0x37, 0x21, 0x07, 0x23, 0x03, 0x21, 0x13, 0x33, 0x13, 0x13, 0x33,
+	0x03, 0x23, 0x13, 0x01, 0x23, 0x03, 0x03, 0x02, 0x71, 0x7c, 0xf7, 0x18, 0x02, 0x9a, 0x18, 0xf7,
+	0x7c, 0x01, 0x73, 0x94, 0xe9, 0x47, 0xeb, 0xd5, 0x94, 0xa3, 0x6e, 0xfe, 0xf2, 0x6c, 0x51, 0x69,
+	0x02, 0xe4, 0x02, 0x69, 0x7b, 0x7b, 0xfd, 0x97, 0x02, 0xe4, 0xfe, 0x55, 0x01, 0xab, 0xfd, 0x1c,
+	0x02, 0x23, 0xfe, 0x1b, 0x01, 0xce, 0xfd, 0xf4, 0x00, 0x01, 0x00, 0x93, 0x00, 0x00, 0x06, 0x89,
+	0x05, 0xed, 0x00, 0x1b, 0x00, 0x32, 0x40, 0x2f, 0x1a, 0x01, 0x00, 0x01, 0x4b, 0x00, 0x01, 0x00,
+	0x04, 0x00, 0x01, 0x04, 0x69, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00,
+	0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x1b, 0x25,
+	0x11, 0x14, 0x24, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x33, 0x37, 0x21, 0x00, 0x13, 0x12, 0x00, 0x21,
+	0x20, 0x00, 0x03, 0x02, 0x01, 0x21, 0x07, 0x21, 0x37, 0x24, 0x13, 0x36, 0x02, 0x23, 0x22, 0x00,
+	0x03, 0x02, 0x05, 0x07, 0x93, 0x1e, 0x01, 0x52, 0xfe, 0xe4, 0x52, 0x3c, 0x01, 0xba, 0x01, 0x1d,
+	0x01, 0x1d, 0x01, 0x20, 0x3c, 0x52, 0xfe, 0x78, 0x01, 0x52, 0x1e, 0xfd, 0xef, 0x1e, 0x01, 0x61,
+	0x57, 0x33, 0xb0, 0xc2, 0xc1, 0xfe, 0xdb, 0x33, 0x57, 0x01, 0x05, 0x1e, 0x9a, 0x01, 0x0e, 0x01,
+	0x98, 0x01, 0x2c, 0x01, 0x81, 0xfe, 0x80, 0xfe, 0xd3, 0xfe, 0x67, 0xfe, 0xf3, 0x9a, 0x9a, 0xe5,
+	0x01, 0xb3, 0xff, 0x01, 0x22, 0xfe, 0xde, 0xff, 0x00, 0xfe, 0x4f, 0xe6, 0x9a, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x6f, 0xff, 0xe7, 0x05, 0x5d, 0x03, 0x8b, 0x00, 0x1f, 0x00, 0x30, 0x00, 0x40,
+	0x40, 0x3d, 0x2f, 0x23, 0x02, 0x05, 0x06, 0x18, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x00, 0x00, 0x03,
+	0x04, 0x03, 0x00, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x05, 0x00,
+	0x03, 0x00, 0x05, 0x03, 0x67, 0x00, 0x04, 0x01, 0x01, 0x04, 0x59, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x04, 0x01, 0x51, 0x27, 0x11, 0x27, 0x24, 0x28, 0x23, 0x10, 0x07, 0x06, 0x1d, 0x2b,
+	0x25, 0x33, 0x06, 0x07, 0x06, 0x23, 0x22, 0x26, 0x27, 0x26, 0x35, 0x34, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x17, 0x16, 0x15, 0x15, 0x21, 0x22, 0x15, 0x15, 0x14, 0x17, 0x16, 0x16, 0x33, 0x32,
+	0x01, 0x21, 0x32, 0x35, 0x35, 0x34, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x15, 0x15,
+	0x14, 0x04, 0x7b, 0x5e, 0x55, 0x55, 0x9a, 0xaf, 0x8b, 0xfb, 0x59, 0x98, 0x98, 0x59, 0xfb, 0x8b,
+	0x8b, 0xfb, 0x5a, 0x97, 0xfc, 0x09, 0x0f, 0x19, 0x34, 0xda, 0x6a, 0xeb, 0xfd, 0x93, 0x03, 0x00,
+	0x11, 0x1a, 0x36, 0xd8, 0x69, 0x69, 0xd9, 0x34, 0x19, 0x9b, 0x4b, 0x25, 0x44, 0x56, 0x4d, 0x83,
+	0xac, 0xac, 0x84, 0x4d, 0x55, 0x55, 0x4d, 0x84, 0xac, 0x0d, 0x0d, 0xe4, 0x20, 0x1a, 0x35, 0x49,
+	0x01, 0xc3, 0x0d, 0xe5, 0x1f, 0x1a, 0x35, 0x4a, 0x4a, 0x35, 0x1a, 0x1f, 0xe5, 0x0d, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x9c, 0xff, 0xdb, 0x06, 0xab, 0x05, 0xed, 0x00, 0x03, 0x00, 0x09, 0x00, 0x1d,
+	0x00, 0x25, 0x00, 0x30, 0x00, 0xaa, 0x40, 0x0c, 0x08, 0x06, 0x05, 0x03, 0x03, 0x00, 0x14, 0x01,
+	0x06, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x23, 0x08, 0x01, 0x02, 0x05, 0x06,
+	0x05, 0x02, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x02, 0x03, 0x05, 0x6a, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x04, 0x07, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x21, 0x50, 0x58, 0x40, 0x23, 0x00, 0x00, 0x03, 0x00, 0x85, 0x08, 0x01, 0x02, 0x05, 0x06,
+	0x05, 0x02, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x02, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06, 0x01,
+	0x61, 0x04, 0x07, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x00, 0x03, 0x00,
+	0x85, 0x08, 0x01, 0x02, 0x05, 0x06, 0x05, 0x02, 0x06, 0x80, 0x07, 0x01, 0x01, 0x04, 0x01, 0x86,
+	0x00, 0x03, 0x00, 0x05, 0x02, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x42, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x18, 0x04, 0x04, 0x00, 0x00, 0x2c, 0x2a, 0x23, 0x21, 0x1a,
+	0x18, 0x10, 0x0e, 0x04, 0x09, 0x04, 0x09, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b,
+	0x17, 0x01, 0x33, 0x01, 0x13, 0x13, 0x07, 0x37, 0x25, 0x03, 0x05, 0x26, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x36,
+	0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x17, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36,
+	0x27, 0x9c, 0x05, 0x77, 0x87, 0xfa, 0x89, 0x74, 0x97, 0xcf, 0x16, 0x01, 0x6c, 0xb6, 0x02, 0xa6,
+	0x70, 0x17, 0x14, 0xb4, 0x7d, 0x75, 0x79, 0x12, 0x18, 0xb3, 0xb0, 0x1f, 0x17, 0xcd, 0x8d, 0x89,
+	0x8f, 0x16, 0x20, 0x01, 0x7b, 0x7a, 0x12, 0x18, 0x8e, 0x88, 0x16, 0x10, 0x24, 0x80, 0x13, 0x0f,
+	0x50, 0x4d, 0x48, 0x6c, 0x0c, 0x10, 0x82, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x02, 0x75, 0x02, 0xf7,
+	0x31, 0x72, 0x57, 0xfc, 0x71, 0x70, 0x58, 0x72, 0x66, 0x7e, 0x6b, 0x59, 0x7b, 0x69, 0x63, 0x99,
+	0x72, 0x8e, 0x84, 0x6c, 0x9f, 0x99, 0x4b, 0x5a, 0x74, 0x6b, 0x50, 0xc6, 0x58, 0x61, 0x48, 0x5c,
+	0x4c, 0x3a, 0x52, 0x55, 0x00, 0x05, 0x00, 0xbe, 0xff, 0xdb, 0x06, 0xc3, 0x05, 0xed, 0x00, 0x03,
+	0x00, 0x22, 0x00, 0x36, 0x00, 0x3e, 0x00, 0x49, 0x01, 0x2c, 0x40, 0x12, 0x0b, 0x01, 0x05, 0x06,
+	0x13, 0x01, 0x04, 0x0a, 0x12, 0x01, 0x03, 0x04, 0x2d, 0x01, 0x0b, 0x03, 0x04, 0x4c, 0x4b, 0xb0,
+	0x21, 0x50, 0x58, 0x40, 0x32, 0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x69, 0x00, 0x04, 0x00,
+	0x03, 0x0b, 0x04, 0x03, 0x69, 0x00, 0x07, 0x07, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x3e, 0x4d,
+	0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x01, 0x61, 0x09,
+	0x0c, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x36, 0x0c,
+	0x01, 0x01, 0x09, 0x01, 0x86, 0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x69, 0x00, 0x04, 0x00,
+	0x03, 0x0b, 0x04, 0x03, 0x69, 0x00, 0x07, 0x07, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x3e, 0x4d,
+	0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00,
+	0x09, 0x09, 0x42, 0x09, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x3a, 0x00, 0x00, 0x02,
+	0x00, 0x85, 0x0c, 0x01, 0x01, 0x09, 0x01, 0x86, 0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x69,
+	0x00, 0x04, 0x00, 0x03, 0x0b, 0x04, 0x03, 0x69, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x3e, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09,
+	0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e, 0x1b, 0x40, 0x38, 0x00, 0x00, 0x02, 0x00, 0x85, 0x0c,
+	0x01, 0x01, 0x09, 0x01, 0x86, 0x00, 0x02, 0x00, 0x07, 0x06, 0x02, 0x07, 0x69, 0x00, 0x08, 0x00,
+	0x0a, 0x04, 0x08, 0x0a, 0x69, 0x00, 0x04, 0x00, 0x03, 0x0b, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42,
+	0x09, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1e, 0x00, 0x00, 0x45, 0x43, 0x3c, 0x3a, 0x33, 0x31, 0x29,
+	0x27, 0x22, 0x20, 0x1e, 0x1c, 0x1b, 0x19, 0x16, 0x14, 0x11, 0x0f, 0x08, 0x06, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x0d, 0x09, 0x17, 0x2b, 0x17, 0x01, 0x33, 0x01, 0x13, 0x37, 0x36, 0x33, 0x20, 0x07,
+	0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x27, 0x37, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36,
+	0x21, 0x23, 0x37, 0x33, 0x32, 0x37, 0x36, 0x23, 0x22, 0x01, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32,
+	0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25, 0x36, 0x37,
+	0x36, 0x23, 0x22, 0x07, 0x06, 0x17, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x36, 0x27,
+	0xc4, 0x05, 0x77, 0x88, 0xfa, 0x89, 0x15, 0x16, 0x77, 0x73, 0x01, 0x1a, 0x2a, 0x1f, 0xcc, 0xd2,
+	0x26, 0x18, 0xca, 0x96, 0x6b, 0x71, 0x19, 0x77, 0x50, 0x51, 0x78, 0x0f, 0x24, 0xfe, 0xfc, 0x33,
+	0x13, 0x2c, 0xf4, 0x21, 0x1a, 0x9c, 0x5d, 0x03, 0x10, 0x70, 0x17, 0x14, 0xb4, 0x7d, 0x75, 0x79,
+	0x12, 0x18, 0xb3, 0xb0, 0x1f, 0x17, 0xcd, 0x8d, 0x89, 0x8f, 0x16, 0x20, 0x01, 0x7b, 0x7a, 0x12,
+	0x18, 0x8e, 0x88, 0x16, 0x10, 0x24, 0x80, 0x13, 0x0f, 0x50, 0x4d, 0x48, 0x6b, 0x0c, 0x10, 0x81,
+	0x25, 0x06, 0x12, 0xf9, 0xee, 0x05, 0x6e, 0x70, 0x26, 0xd1, 0x9d, 0x42, 0x32, 0xbc, 0x7a, 0x8d,
+	0x1d, 0x7a, 0x33, 0x5a, 0x49, 0xb6, 0x5d, 0xa6, 0x81, 0xfc, 0x65, 0x57, 0x73, 0x66, 0x7e, 0x6b,
+	0x59, 0x7b, 0x69, 0x63, 0x99, 0x72, 0x8e, 0x84, 0x6c, 0x9f, 0x99, 0x4b, 0x59, 0x75, 0x6b, 0x50,
+	0xc6, 0x57, 0x61, 0x49, 0x5c, 0x4b, 0x3b, 0x52, 0x55, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0xcb,
+	0xff, 0xdb, 0x06, 0xc9, 0x05, 0xed, 0x00, 0x03, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x2a, 0x00, 0x40,
+	0x01, 0x65, 0x40, 0x0e, 0x34, 0x01, 0x02, 0x07, 0x2c, 0x01, 0x06, 0x04, 0x0e, 0x01, 0x05, 0x0b,
+	0x03, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x36, 0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04,
+	0x69, 0x00, 0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x09,
+	0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x07, 0x07, 0x0a, 0x61, 0x00, 0x0a, 0x0a,
+	0x41, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x0c, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x36, 0x00, 0x00, 0x08, 0x00, 0x85, 0x00, 0x02, 0x00, 0x04,
+	0x06, 0x02, 0x04, 0x69, 0x00, 0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x09, 0x09, 0x08,
+	0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x07, 0x07, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x41, 0x4d,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x0c, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x21, 0x50, 0x58, 0x40, 0x34, 0x00, 0x00, 0x08, 0x00, 0x85, 0x00, 0x0a, 0x00, 0x07, 0x02, 0x0a,
+	0x07, 0x69, 0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04, 0x69, 0x00, 0x06, 0x00, 0x0b, 0x05, 0x06,
+	0x0b, 0x69, 0x00, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01,
+	0x61, 0x03, 0x0c, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x38, 0x00, 0x00, 0x08, 0x00, 0x85, 0x0c, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x0a, 0x00, 0x07,
+	0x02, 0x0a, 0x07, 0x69, 0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04, 0x69, 0x00, 0x06, 0x00, 0x0b,
+	0x05, 0x06, 0x0b, 0x69, 0x00, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x05,
+	0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x36, 0x00, 0x00, 0x08, 0x00,
+	0x85, 0x0c, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x08, 0x00, 0x09, 0x0a, 0x08, 0x09, 0x67, 0x00,
+	0x0a, 0x00, 0x07, 0x02, 0x0a, 0x07, 0x69, 0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04, 0x69, 0x00,
+	0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42,
+	0x03, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x1e, 0x00, 0x00, 0x40, 0x3e, 0x3a, 0x39, 0x38, 0x37,
+	0x36, 0x35, 0x33, 0x31, 0x2f, 0x2d, 0x26, 0x24, 0x1d, 0x1b, 0x14, 0x12, 0x0a, 0x08, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x0d, 0x09, 0x17, 0x2b, 0x17, 0x01, 0x33, 0x01, 0x01, 0x26, 0x37, 0x36, 0x36,
+	0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25,
+	0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x17, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x36, 0x27, 0x25, 0x37, 0x16, 0x33, 0x32, 0x37, 0x36, 0x21, 0x22, 0x07, 0x13, 0x21, 0x07, 0x21,
+	0x07, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0xcb, 0x05, 0x77, 0x87, 0xfa, 0x8a, 0x03, 0x9b,
+	0x6f, 0x17, 0x14, 0xb4, 0x7d, 0x75, 0x78, 0x12, 0x18, 0xb2, 0xb0, 0x1f, 0x17, 0xcd, 0x8e, 0x89,
+	0x8f, 0x16, 0x20, 0x01, 0x7c, 0x79, 0x12, 0x18, 0x8d, 0x89, 0x16, 0x10, 0x24, 0x7f, 0x13, 0x0f,
+	0x4f, 0x4e, 0x47, 0x6c, 0x0c, 0x10, 0x81, 0xfb, 0x61, 0x17, 0x5d, 0x4e, 0xb1, 0x24, 0x28, 0xfe,
+	0xed, 0x20, 0x22, 0x57, 0x01, 0xdf, 0x18, 0xfe, 0x96, 0x2b, 0xb1, 0xac, 0x1c, 0x1a, 0xd3, 0x9e,
+	0x47, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x02, 0x05, 0x58, 0x72, 0x66, 0x7e, 0x6b, 0x59, 0x7b, 0x69,
+	0x63, 0x99, 0x72, 0x8e, 0x84, 0x6c, 0x9f, 0x99, 0x4b, 0x5a, 0x74, 0x6c, 0x4f, 0xc6, 0x57, 0x61,
+	0x49, 0x5c, 0x4c, 0x3a, 0x52, 0x55, 0xd6, 0x75, 0x27, 0xb4, 0xc4, 0x05, 0x01, 0xb7, 0x7a, 0xd4,
+	0x9f, 0x8a, 0x82, 0x95, 0x00, 0x05, 0x00, 0x89, 0xff, 0xdb, 0x06, 0xb6, 0x05, 0xed, 0x00, 0x03,
+	0x00, 0x17, 0x00, 0x1f, 0x00, 0x2a, 0x00, 0x34, 0x00, 0xfd, 0xb5, 0x0e, 0x01, 0x05, 0x08, 0x01,
+	0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x2d, 0x0a, 0x01, 0x08, 0x04, 0x05, 0x04, 0x08, 0x05,
+	0x80, 0x00, 0x02, 0x00, 0x04, 0x08, 0x02, 0x04, 0x6a, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x06,
+	0x06, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x09, 0x02,
+	0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x00, 0x07,
+	0x00, 0x85, 0x0a, 0x01, 0x08, 0x04, 0x05, 0x04, 0x08, 0x05, 0x80, 0x00, 0x02, 0x00, 0x04, 0x08,
+	0x02, 0x04, 0x6a, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x03, 0x09, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x31, 0x00, 0x00, 0x07, 0x00, 0x85, 0x0a, 0x01, 0x08, 0x04, 0x05, 0x04, 0x08, 0x05, 0x80,
+	0x09, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x02, 0x00, 0x04, 0x08, 0x02, 0x04, 0x6a, 0x00, 0x06,
+	0x06, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x1b, 0x40, 0x2f, 0x00, 0x00, 0x07, 0x00, 0x85, 0x0a, 0x01, 0x08, 0x04, 0x05,
+	0x04, 0x08, 0x05, 0x80, 0x09, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x07, 0x00, 0x06, 0x02, 0x07,
+	0x06, 0x67, 0x00, 0x02, 0x00, 0x04, 0x08, 0x02, 0x04, 0x6a, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1c, 0x2b, 0x2b, 0x00, 0x00, 0x2b, 0x34,
+	0x2b, 0x34, 0x31, 0x30, 0x2f, 0x2e, 0x26, 0x24, 0x1d, 0x1b, 0x14, 0x12, 0x0a, 0x08, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x0b, 0x09, 0x17, 0x2b, 0x17, 0x01, 0x33, 0x01, 0x01, 0x26, 0x37, 0x36, 0x36,
+	0x33, 0x32, 0x16, 0x07, 0x06, 0x07, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x25,
+	0x36, 0x37, 0x36, 0x23, 0x22, 0x07, 0x06, 0x17, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x36, 0x27, 0x25, 0x36, 0x13, 0x01, 0x21, 0x37, 0x21, 0x07, 0x00, 0x03, 0x89, 0x05, 0x77, 0x87,
+	0xfa, 0x89, 0x03, 0xcc, 0x70, 0x17, 0x14, 0xb4, 0x7d, 0x75, 0x79, 0x12, 0x18, 0xb3, 0xb0, 0x1f,
+	0x17, 0xcd, 0x8e, 0x89, 0x8f, 0x16, 0x20, 0x01, 0x7c, 0x79, 0x12, 0x18, 0x8d, 0x89, 0x16, 0x10,
+	0x25, 0x80, 0x13, 0x0f, 0x50, 0x4d, 0x47, 0x6c, 0x0c, 0x10, 0x81, 0xfb, 0xaa, 0x35, 0xdc, 0x01,
+	0x26, 0xfe, 0x2b, 0x19, 0x02, 0x56, 0x19, 0xfe, 0x3f, 0x50, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x02,
+	0x05, 0x57, 0x73, 0x66, 0x7e, 0x6b, 0x59, 0x7b, 0x69, 0x63, 0x99, 0x72, 0x8e, 0x84, 0x6c, 0x9f,
+	0x99, 0x4b, 0x5a, 0x74, 0x6c, 0x4f, 0xc6, 0x57, 0x62, 0x48, 0x5c, 0x4c, 0x3a, 0x52, 0x55, 0xd6,
+	0x9c, 0x01, 0x02, 0x01, 0x5b, 0x7f, 0x7f, 0xfe, 0x1e, 0xfe, 0xe9, 0x00, 0x00, 0x01, 0x01, 0x21,
+	0x00, 0xdd, 0x07, 0xf0, 0x03, 0xc2, 0x00, 0x06, 0x00, 0x20, 0x40, 0x1d, 0x01, 0x01, 0x00, 0x4a,
+	0x06, 0x01, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x00, 0x01, 0x4f, 0x11, 0x12, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x01, 0x03, 0x21, 0x07, 0x21,
+	0x13, 0x01, 0x21, 0x02, 0xcb, 0xcf, 0x04, 0xd3, 0x1e, 0xfb, 0x2d, 0x59, 0x02, 0x50, 0x01, 0x72,
+	0xfe, 0xd8, 0x94, 0xfe, 0xd7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x58, 0xfe, 0x75, 0x04, 0x3d,
+	0x06, 0x44, 0x00, 0x06, 0x00, 0x12, 0x40, 0x0f, 0x06, 0x05, 0x02, 0x01, 0x04, 0x00, 0x4a, 0x00,
+	0x00, 0x00, 0x76, 0x13, 0x01, 0x06, 0x17, 0x2b, 0x01, 0x13, 0x25, 0x01, 0x23, 0x01, 0x05, 0x03,
+	0x4b, 0xf2, 0xfe, 0xf5, 0xfe, 0xd4, 0x94, 0x01, 0x2c, 0xfe, 0xba, 0x06, 0x44, 0xfd, 0x7f, 0x94,
+	0xfa, 0x1e, 0x05, 0xe2, 0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x12, 0x00, 0xdd, 0x07, 0xe1,
+	0x03, 0xc2, 0x00, 0x06, 0x00, 0x22, 0x40, 0x1f, 0x06, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x05, 0x01,
+	0x01, 0x4a, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01,
+	0x00, 0x4f, 0x11, 0x11, 0x02, 0x06, 0x18, 0x2b, 0x25, 0x13, 0x21, 0x37, 0x21, 0x03, 0x01, 0x05,
+	0x16, 0xcf, 0xfb, 0x2d, 0x1e, 0x04, 0xd3, 0x59, 0x02, 0x37, 0xdd, 0x01, 0x29, 0x94, 0x01, 0x28,
+	0xfe, 0x8e, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc9, 0xfe, 0x75, 0x03, 0xae, 0x06, 0x44, 0x00, 0x06,
+	0x00, 0x12, 0x40, 0x0f, 0x06, 0x05, 0x02, 0x01, 0x04, 0x00, 0x49, 0x00, 0x00, 0x00, 0x76, 0x13,
+	0x01, 0x06, 0x17, 0x2b, 0x01, 0x03, 0x05, 0x01, 0x33, 0x01, 0x25, 0x01, 0xbb, 0xf2, 0x01, 0x0a,
+	0x01, 0x2d, 0x94, 0xfe, 0xd3, 0x01, 0x47, 0xfe, 0x75, 0x02, 0x81, 0x94, 0x05, 0xe2, 0xfa, 0x1e,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd1, 0x00, 0xdd, 0x08, 0x31, 0x03, 0xc2, 0x00, 0x09,
+	0x00, 0x28, 0x40, 0x25, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x04, 0x01, 0x02, 0x00, 0x4a, 0x09,
+	0x06, 0x02, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x00, 0x01, 0x4f, 0x14, 0x12, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x01, 0x03, 0x21, 0x03, 0x01,
+	0x01, 0x13, 0x21, 0x13, 0xd1, 0x02, 0xcb, 0xcf, 0x03, 0x86, 0x59, 0x02, 0x37, 0xfd, 0x35, 0xcf,
+	0xfc, 0x7a, 0x59, 0x02, 0x50, 0x01, 0x72, 0xfe, 0xd8, 0x01, 0x28, 0xfe, 0x8e, 0xfe, 0x8d, 0x01,
+	0x29, 0xfe, 0xd7, 0x00, 0x00, 0x01, 0x00, 0xca, 0xfe, 0x75, 0x04, 0x3d, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x06, 0xb3, 0x05, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x13, 0x25, 0x03, 0x25, 0x01, 0x03, 0x05,
+	0x13, 0x05, 0x03, 0x4b, 0xf2, 0xfe, 0xf5, 0xca, 0x01, 0x46, 0xfe, 0x0e, 0xf2, 0x01, 0x0a, 0xca,
+	0xfe, 0xbb, 0x06, 0x44, 0xfd, 0x7f, 0x94, 0xfc, 0x0b, 0x94, 0xfd, 0x7f, 0x02, 0x81, 0x94, 0x03,
+	0xf5, 0x94, 0x00, 0x00, 0x00, 0x02, 0x00, 0x2c, 0xfd, 0xe1, 0x04, 0x3d, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x24, 0x40, 0x21, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x09,
+	0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00,
+	0x01, 0x4f, 0x11, 0x1a, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x13, 0x25, 0x03, 0x25, 0x01, 0x03, 0x05,
+	0x13, 0x05, 0x01, 0x21, 0x07, 0x21, 0x03, 0x4b, 0xf2, 0xfe, 0xf5, 0xa2, 0x01, 0x46, 0xfe, 0x0e,
+	0xf2, 0x01, 0x0a, 0xa2, 0xfe, 0xbb, 0xfe, 0xf1, 0x02, 0xe4, 0x1e, 0xfd, 0x1c, 0x06, 0x44, 0xfd,
+	0x7f, 0x94, 0xfc, 0xd3, 0x94, 0xfd, 0x7f, 0x02, 0x81, 0x94, 0x03, 0x2d, 0x94, 0xfa, 0xb2, 0x94,
+	0x00, 0x02, 0x00, 0x65, 0xff, 0xe7, 0x04, 0xbc, 0x06, 0x44, 0x00, 0x15, 0x00, 0x20, 0x00, 0x32,
+	0x40, 0x2f, 0x10, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69,
+	0x00, 0x02, 0x00, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05,
+	0x05, 0x01, 0x61, 0x00, 0x01, 0x05, 0x01, 0x51, 0x24, 0x22, 0x24, 0x24, 0x24, 0x21, 0x06, 0x06,
+	0x1c, 0x2b, 0x01, 0x12, 0x21, 0x32, 0x12, 0x03, 0x02, 0x00, 0x21, 0x22, 0x26, 0x37, 0x12, 0x00,
+	0x33, 0x32, 0x17, 0x37, 0x36, 0x02, 0x23, 0x22, 0x01, 0x26, 0x23, 0x22, 0x00, 0x07, 0x06, 0x16,
+	0x33, 0x32, 0x00, 0x01, 0x74, 0xd0, 0x01, 0x0b, 0xd0, 0x9d, 0x42, 0x50, 0xfe, 0x43, 0xff, 0x00,
+	0x88, 0x80, 0x20, 0x34, 0x01, 0xb0, 0xcf, 0x54, 0x5e, 0x06, 0x26, 0x91, 0x94, 0xc3, 0x01, 0x98,
+	0x4d, 0x6a, 0x84, 0xfe, 0xe7, 0x24, 0x19, 0x46, 0x51, 0x89, 0x01, 0x21, 0x05, 0x12, 0x01, 0x32,
+	0xfe, 0x93, 0xfe, 0xb7, 0xfe, 0x6e, 0xfd, 0xeb, 0xbe, 0x9c, 0x01, 0x06, 0x01, 0xb5, 0x45, 0x1e,
+	0xc3, 0x01, 0x03, 0xfd, 0x6b, 0x67, 0xfe, 0xd3, 0xb4, 0x79, 0x94, 0x01, 0x72, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x04, 0xce, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x08, 0x00, 0x2b,
+	0x40, 0x28, 0x08, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01,
+	0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00,
+	0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x06, 0x17, 0x2b, 0x33, 0x37, 0x01, 0x33, 0x13,
+	0x07, 0x25, 0x21, 0x03, 0x51, 0x24, 0x02, 0xda, 0xad, 0xd2, 0x24, 0xfc, 0x62, 0x03, 0x05, 0xad,
+	0xb9, 0x05, 0x0f, 0xfa, 0xf1, 0xb9, 0xb9, 0x04, 0x28, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd5,
+	0xfe, 0x75, 0x07, 0x11, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x06, 0x05, 0x02, 0x03,
+	0x00, 0x03, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x02,
+	0x02, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x06, 0x1b, 0x2b, 0x13, 0x01, 0x23, 0x37, 0x21, 0x07, 0x23, 0x01, 0x23, 0x01, 0x21, 0x01,
+	0xd5, 0x01, 0x57, 0x63, 0x1f, 0x05, 0x29, 0x1f, 0x63, 0xfe, 0xa9, 0xd1, 0x01, 0x57, 0xfd, 0x3f,
+	0xfe, 0xa9, 0xfe, 0x75, 0x06, 0xb6, 0x9d, 0x9d, 0xf9, 0x4a, 0x06, 0xb6, 0xf9, 0x4a, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x2c, 0xfe, 0x74, 0x06, 0x4d, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x2f, 0x40, 0x2c,
+	0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00,
+	0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x02, 0x03, 0x4f,
+	0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x06, 0x19, 0x2b, 0x13, 0x37, 0x01,
+	0x01, 0x37, 0x21, 0x07, 0x21, 0x01, 0x01, 0x21, 0x07, 0x2c, 0x26, 0x03, 0x3a, 0xfe, 0x2b, 0x1f,
+	0x04, 0x77, 0x1f, 0xfc, 0xa8, 0x01, 0xc1, 0xfc, 0xa9, 0x03, 0xee, 0x26, 0xfe, 0x74, 0xbb, 0x02,
+	0xed, 0x03, 0x0f, 0x9d, 0x9d, 0xfd, 0x08, 0xfc, 0xfc, 0xbb, 0x00, 0x00, 0x00, 0x01, 0x00, 0xd6,
+	0x02, 0x06, 0x04, 0xd8, 0x02, 0x9a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x13, 0x37, 0x21, 0x07, 0xd6, 0x1e, 0x03, 0xe4,
+	0x1e, 0x02, 0x06, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0xfe, 0xf5, 0xfe, 0xd8, 0x03, 0x78,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x01, 0x01, 0x33,
+	0x01, 0xfe, 0xf5, 0x03, 0xe7, 0x9c, 0xfc, 0x19, 0xfe, 0xd8, 0x07, 0x53, 0xf8, 0xad, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa5, 0x01, 0x75, 0x02, 0x80, 0x03, 0x2c, 0x00, 0x0b, 0x00, 0x18, 0x40, 0x15,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x59, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x00, 0x01, 0x51,
+	0x24, 0x22, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0xb7, 0x12, 0x9c, 0x5a, 0x5b, 0x66, 0x12, 0x12, 0x9a, 0x5b, 0x5c, 0x66, 0x02, 0x53,
+	0x59, 0x80, 0x81, 0x5b, 0x5a, 0x81, 0x81, 0x00, 0x00, 0x01, 0x00, 0x7a, 0xff, 0x3a, 0x05, 0xde,
+	0x07, 0x2e, 0x00, 0x08, 0x00, 0x1a, 0x40, 0x17, 0x08, 0x03, 0x02, 0x01, 0x04, 0x01, 0x00, 0x01,
+	0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x14, 0x02, 0x06, 0x18, 0x2b,
+	0x13, 0x27, 0x25, 0x13, 0x01, 0x33, 0x01, 0x23, 0x03, 0x94, 0x1a, 0x01, 0x54, 0xc3, 0x02, 0xdf,
+	0x6e, 0xfc, 0xb4, 0x58, 0xe5, 0x01, 0xdc, 0x52, 0x9a, 0xfd, 0x72, 0x06, 0xf4, 0xf8, 0x0c, 0x02,
+	0xfa, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xe4, 0x01, 0x39, 0x05, 0xf8, 0x04, 0x2b, 0x00, 0x17,
+	0x00, 0x24, 0x00, 0x31, 0x01, 0xbd, 0xb5, 0x0c, 0x01, 0x06, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x0b,
+	0x50, 0x58, 0x40, 0x26, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06,
+	0x00, 0x04, 0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x0c, 0x50, 0x58,
+	0x40, 0x28, 0x00, 0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03,
+	0x04, 0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69,
+	0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x06, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06, 0x00, 0x04,
+	0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x0f, 0x50, 0x58, 0x40, 0x28,
+	0x00, 0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03, 0x04, 0x69,
+	0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69, 0x00, 0x06,
+	0x06, 0x01, 0x61, 0x00, 0x01, 0x06, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x11, 0x50, 0x58, 0x40, 0x26,
+	0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06, 0x00, 0x04, 0x69, 0x00,
+	0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x28, 0x00, 0x00,
+	0x00, 0x07, 0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03, 0x04, 0x69, 0x00, 0x06,
+	0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01,
+	0x61, 0x00, 0x01, 0x06, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x07,
+	0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06, 0x00, 0x04, 0x69, 0x00, 0x06, 0x05,
+	0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0x28, 0x00, 0x00, 0x00, 0x07,
+	0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03, 0x04, 0x69, 0x00, 0x06, 0x05, 0x01,
+	0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00,
+	0x01, 0x06, 0x01, 0x51, 0x1b, 0x40, 0x26, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00,
+	0x00, 0x04, 0x06, 0x00, 0x04, 0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01,
+	0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0b, 0x24, 0x25, 0x24, 0x25, 0x24, 0x24, 0x24, 0x22, 0x08,
+	0x06, 0x1e, 0x2b, 0x01, 0x36, 0x36, 0x33, 0x32, 0x16, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x27,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16, 0x03, 0x27, 0x26, 0x26, 0x23,
+	0x22, 0x06, 0x07, 0x06, 0x16, 0x33, 0x32, 0x36, 0x37, 0x17, 0x16, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x36, 0x26, 0x23, 0x22, 0x06, 0x03, 0x99, 0x59, 0xb1, 0x57, 0x7c, 0x82, 0x1e, 0x20, 0xe7, 0x80,
+	0x4d, 0x88, 0x3b, 0x5a, 0xb1, 0x56, 0x7b, 0x83, 0x1d, 0x20, 0xe8, 0x80, 0x4c, 0x88, 0x41, 0x0d,
+	0x47, 0x4c, 0x2e, 0x43, 0x7a, 0x14, 0x12, 0x4d, 0x4e, 0x3a, 0x8c, 0xf4, 0x0e, 0x3a, 0x60, 0x26,
+	0x44, 0x79, 0x13, 0x13, 0x4e, 0x4e, 0x3b, 0x8b, 0x03, 0x1e, 0x82, 0x82, 0xce, 0x93, 0xa0, 0xe8,
+	0x86, 0x87, 0x82, 0x82, 0xce, 0x93, 0xa0, 0xe8, 0x87, 0xfe, 0xea, 0x1b, 0x83, 0x55, 0x8a, 0x63,
+	0x5e, 0x7e, 0x6b, 0xb3, 0x1b, 0x6c, 0x6c, 0x8a, 0x63, 0x5e, 0x7e, 0x6c, 0x00, 0x01, 0x01, 0x73,
+	0x00, 0x00, 0x06, 0x72, 0x04, 0xe2, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x01,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x21, 0x13,
+	0x33, 0x03, 0x21, 0x07, 0x01, 0x73, 0xf9, 0x94, 0xdc, 0x04, 0x4e, 0x1d, 0x04, 0xe2, 0xfb, 0xb2,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x90, 0x00, 0x00, 0x06, 0x12, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x26, 0x40, 0x23, 0x04, 0x03, 0x02, 0x01, 0x00, 0x01, 0x86, 0x00, 0x02, 0x00, 0x00, 0x02,
+	0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x00, 0x11, 0x00,
+	0x11, 0x23, 0x13, 0x23, 0x05, 0x06, 0x19, 0x2b, 0x21, 0x13, 0x36, 0x02, 0x23, 0x22, 0x00, 0x07,
+	0x03, 0x23, 0x13, 0x36, 0x00, 0x33, 0x32, 0x00, 0x07, 0x03, 0x04, 0x9c, 0xb1, 0x25, 0xcf, 0xb9,
+	0xb8, 0xfe, 0xc8, 0x25, 0xb1, 0x94, 0xb1, 0x31, 0x01, 0xa0, 0xf5, 0xf6, 0x01, 0x15, 0x31, 0xb1,
+	0x03, 0x78, 0xb9, 0x01, 0x03, 0xfe, 0xfd, 0xb9, 0xfc, 0x88, 0x03, 0x78, 0xf6, 0x01, 0x5a, 0xfe,
+	0xa6, 0xf6, 0xfc, 0x88, 0x00, 0x01, 0x00, 0xd4, 0x00, 0x00, 0x06, 0x57, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x26, 0x40, 0x23, 0x04, 0x03, 0x02, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02, 0x02, 0x00,
+	0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x00, 0x00, 0x00, 0x11, 0x00,
+	0x11, 0x23, 0x13, 0x23, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x03, 0x06, 0x00, 0x23, 0x22, 0x02, 0x37,
+	0x13, 0x23, 0x03, 0x06, 0x00, 0x33, 0x32, 0x00, 0x37, 0x13, 0x05, 0xc3, 0xb1, 0x24, 0xfe, 0xc9,
+	0xb9, 0xb8, 0xd0, 0x24, 0xb1, 0x94, 0xb1, 0x32, 0x01, 0x17, 0xf5, 0xf6, 0x01, 0x9e, 0x32, 0xb1,
+	0x05, 0xc8, 0xfc, 0x88, 0xb9, 0xfe, 0xfd, 0x01, 0x03, 0xb9, 0x03, 0x78, 0xfc, 0x88, 0xf6, 0xfe,
+	0xa6, 0x01, 0x5a, 0xf6, 0x03, 0x78, 0x00, 0x00, 0x00, 0x01, 0xff, 0xf0, 0xfe, 0xd8, 0x03, 0x9d,
+	0x07, 0x87, 0x00, 0x5d, 0x00, 0x95, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x00, 0x01, 0x02,
+	0x04, 0x02, 0x01, 0x72, 0x00, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59, 0x00, 0x05, 0x05, 0x03, 0x62, 0x00, 0x03, 0x05,
+	0x03, 0x52, 0x1b, 0x4b, 0xb0, 0x16, 0x50, 0x58, 0x40, 0x26, 0x00, 0x01, 0x02, 0x04, 0x02, 0x01,
+	0x72, 0x00, 0x04, 0x05, 0x02, 0x04, 0x05, 0x7e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69,
+	0x00, 0x05, 0x03, 0x03, 0x05, 0x59, 0x00, 0x05, 0x05, 0x03, 0x62, 0x00, 0x03, 0x05, 0x03, 0x52,
+	0x1b, 0x40, 0x27, 0x00, 0x01, 0x02, 0x04, 0x02, 0x01, 0x04, 0x80, 0x00, 0x04, 0x05, 0x02, 0x04,
+	0x05, 0x7e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59,
+	0x00, 0x05, 0x05, 0x03, 0x62, 0x00, 0x03, 0x05, 0x03, 0x52, 0x59, 0x59, 0x40, 0x0c, 0x52, 0x51,
+	0x48, 0x46, 0x3e, 0x3c, 0x19, 0x28, 0x2d, 0x06, 0x06, 0x19, 0x2b, 0x01, 0x3e, 0x05, 0x37, 0x3e,
+	0x05, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x37, 0x36, 0x36, 0x37,
+	0x26, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x0e, 0x07, 0x07, 0x07, 0x0e, 0x03, 0x07, 0x0e, 0x05, 0x23,
+	0x22, 0x2e, 0x02, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x06, 0x06, 0x07, 0x16, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x3e, 0x05, 0x37, 0x01, 0x81, 0x05, 0x0c, 0x0f, 0x10, 0x10, 0x11, 0x07,
+	0x0a, 0x1e, 0x2a, 0x36, 0x44, 0x53, 0x31, 0x1b, 0x2e, 0x1f, 0x0c, 0x05, 0x04, 0x10, 0x17, 0x1f,
+	0x13, 0x0a, 0x13, 0x0e, 0x06, 0x05, 0x01, 0x0d, 0x05, 0x08, 0x09, 0x18, 0x2b, 0x25, 0x1d, 0x08,
+	0x02, 0x09, 0x0d, 0x0f, 0x0f, 0x0e, 0x0d, 0x0a, 0x02, 0x16, 0x07, 0x18, 0x1a, 0x1a, 0x0c, 0x09,
+	0x1e, 0x2a, 0x36, 0x44, 0x53, 0x31, 0x1b, 0x2e, 0x1e, 0x0c, 0x05, 0x04, 0x10, 0x16, 0x1f, 0x13,
+	0x0a, 0x13, 0x0e, 0x06, 0x05, 0x01, 0x0d, 0x05, 0x08, 0x09, 0x18, 0x2b, 0x25, 0x1c, 0x09, 0x03,
+	0x0f, 0x13, 0x17, 0x14, 0x10, 0x03, 0x03, 0x91, 0x1d, 0x51, 0x5f, 0x66, 0x64, 0x5d, 0x26, 0x31,
+	0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20, 0x2f, 0x1d, 0x14, 0x24, 0x1d, 0x11, 0x05, 0x0f, 0x1a,
+	0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e, 0x6b, 0x2b, 0x0a, 0x3d, 0x56, 0x6a, 0x6e, 0x6c, 0x5b,
+	0x45, 0x0f, 0x8b, 0x2f, 0x89, 0x96, 0x93, 0x39, 0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20,
+	0x2f, 0x1d, 0x13, 0x25, 0x1d, 0x11, 0x05, 0x0f, 0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e,
+	0x6b, 0x2b, 0x0e, 0x5f, 0x83, 0x95, 0x89, 0x6b, 0x17, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x88,
+	0x01, 0x03, 0x04, 0xf6, 0x04, 0x19, 0x00, 0x1a, 0x00, 0x35, 0x00, 0x40, 0x40, 0x3d, 0x0d, 0x01,
+	0x03, 0x00, 0x28, 0x01, 0x07, 0x04, 0x02, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00, 0x03, 0x69,
+	0x00, 0x01, 0x00, 0x02, 0x04, 0x01, 0x02, 0x69, 0x00, 0x04, 0x00, 0x07, 0x05, 0x04, 0x07, 0x69,
+	0x00, 0x05, 0x06, 0x06, 0x05, 0x59, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x05, 0x06, 0x51,
+	0x26, 0x24, 0x25, 0x24, 0x26, 0x24, 0x25, 0x21, 0x08, 0x06, 0x1e, 0x2b, 0x13, 0x12, 0x33, 0x32,
+	0x1f, 0x03, 0x16, 0x33, 0x32, 0x37, 0x37, 0x33, 0x02, 0x23, 0x22, 0x2f, 0x02, 0x26, 0x27, 0x26,
+	0x23, 0x22, 0x07, 0x07, 0x03, 0x12, 0x33, 0x32, 0x1f, 0x03, 0x16, 0x33, 0x32, 0x37, 0x37, 0x33,
+	0x02, 0x23, 0x22, 0x2f, 0x02, 0x26, 0x27, 0x26, 0x23, 0x22, 0x07, 0x07, 0xe1, 0x40, 0xe3, 0x55,
+	0x68, 0x3b, 0x45, 0x46, 0x53, 0x2d, 0x66, 0x22, 0x02, 0x65, 0x40, 0xe3, 0x55, 0x68, 0x39, 0x46,
+	0x34, 0x13, 0x52, 0x2e, 0x65, 0x22, 0x02, 0xbf, 0x40, 0xe3, 0x55, 0x68, 0x3b, 0x46, 0x45, 0x54,
+	0x2d, 0x66, 0x22, 0x01, 0x65, 0x40, 0xe3, 0x55, 0x68, 0x39, 0x46, 0x34, 0x13, 0x52, 0x2e, 0x65,
+	0x22, 0x02, 0x02, 0xd8, 0x01, 0x41, 0x38, 0x20, 0x24, 0x24, 0x2c, 0xaa, 0x09, 0xfe, 0xbf, 0x38,
+	0x20, 0x24, 0x1a, 0x0b, 0x2b, 0xaa, 0x09, 0xfe, 0x44, 0x01, 0x41, 0x38, 0x20, 0x24, 0x24, 0x2c,
+	0xaa, 0x09, 0xfe, 0xbf, 0x38, 0x20, 0x24, 0x1a, 0x0b, 0x2b, 0xaa, 0x09, 0x00, 0x01, 0x00, 0xbf,
+	0x00, 0x18, 0x04, 0xef, 0x04, 0x87, 0x00, 0x13, 0x00, 0x72, 0x4b, 0xb0, 0x09, 0x50, 0x58, 0x40,
+	0x2a, 0x00, 0x05, 0x04, 0x04, 0x05, 0x70, 0x00, 0x00, 0x01, 0x01, 0x00, 0x71, 0x06, 0x01, 0x04,
+	0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x68, 0x08, 0x01, 0x02, 0x01, 0x01, 0x02, 0x57, 0x08, 0x01,
+	0x02, 0x02, 0x01, 0x5f, 0x0a, 0x09, 0x02, 0x01, 0x02, 0x01, 0x4f, 0x1b, 0x40, 0x28, 0x00, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x00, 0x01, 0x00, 0x86, 0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04,
+	0x03, 0x68, 0x08, 0x01, 0x02, 0x01, 0x01, 0x02, 0x57, 0x08, 0x01, 0x02, 0x02, 0x01, 0x5f, 0x0a,
+	0x09, 0x02, 0x01, 0x02, 0x01, 0x4f, 0x59, 0x40, 0x12, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x01, 0x03, 0x23, 0x13,
+	0x21, 0x37, 0x21, 0x37, 0x21, 0x37, 0x21, 0x13, 0x33, 0x03, 0x21, 0x07, 0x21, 0x07, 0x21, 0x07,
+	0x02, 0x91, 0xa5, 0x8f, 0xab, 0xfe, 0xb7, 0x1e, 0x01, 0x74, 0x76, 0xfe, 0x42, 0x1e, 0x01, 0xef,
+	0xa4, 0x8f, 0xa4, 0x01, 0x4a, 0x1e, 0xfe, 0x85, 0x76, 0x01, 0xc5, 0x1e, 0x01, 0x4d, 0xfe, 0xcb,
+	0x01, 0x35, 0x94, 0xde, 0x94, 0x01, 0x34, 0xfe, 0xcc, 0x94, 0xde, 0x94, 0x00, 0x03, 0x00, 0x9a,
+	0x00, 0x94, 0x05, 0x13, 0x04, 0x0c, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x40, 0x40, 0x3d,
+	0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01, 0x01,
+	0x00, 0x01, 0x4f, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04,
+	0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x06, 0x17, 0x2b, 0x37, 0x37,
+	0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x01, 0x37, 0x21, 0x07, 0x9a, 0x1e, 0x03, 0xc7, 0x1e, 0xfc,
+	0x83, 0x1e, 0x03, 0xc7, 0x1e, 0xfc, 0x83, 0x1e, 0x03, 0xc7, 0x1e, 0x94, 0x94, 0x94, 0x01, 0x72,
+	0x94, 0x94, 0x01, 0x72, 0x94, 0x94, 0x00, 0x00, 0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x05, 0x07,
+	0x04, 0x58, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x25, 0x40, 0x22, 0x0a, 0x08, 0x06, 0x05, 0x04, 0x00,
+	0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00,
+	0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x37, 0x21,
+	0x07, 0x13, 0x01, 0x01, 0x07, 0x05, 0x07, 0x05, 0x51, 0x1d, 0x03, 0xd8, 0x1d, 0x3d, 0xfc, 0x65,
+	0x04, 0x3c, 0x20, 0xfd, 0x75, 0x01, 0x02, 0x2b, 0x94, 0x94, 0x01, 0x35, 0x01, 0x92, 0x01, 0x91,
+	0x9f, 0xf1, 0x02, 0xf2, 0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x04, 0xcb, 0x04, 0x58, 0x00, 0x03,
+	0x00, 0x0a, 0x00, 0x26, 0x40, 0x23, 0x0a, 0x09, 0x08, 0x07, 0x05, 0x05, 0x00, 0x4a, 0x00, 0x00,
+	0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00,
+	0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x37, 0x21, 0x07, 0x01, 0x25,
+	0x37, 0x25, 0x37, 0x01, 0x01, 0x51, 0x1d, 0x03, 0xd8, 0x1d, 0xfc, 0x85, 0x02, 0x8b, 0x01, 0xfd,
+	0xd5, 0x20, 0x03, 0x9c, 0xfb, 0xc3, 0x94, 0x94, 0x01, 0xd4, 0xf2, 0x02, 0xf1, 0x9f, 0xfe, 0x6f,
+	0xfe, 0x6e, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8a, 0x00, 0x00, 0x04, 0xd8, 0x04, 0xa0, 0x00, 0x04,
+	0x00, 0x09, 0x00, 0x26, 0x40, 0x23, 0x07, 0x06, 0x04, 0x03, 0x04, 0x01, 0x4a, 0x02, 0x01, 0x01,
+	0x00, 0x00, 0x01, 0x57, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x05,
+	0x05, 0x05, 0x09, 0x05, 0x09, 0x10, 0x03, 0x06, 0x17, 0x2b, 0x21, 0x21, 0x13, 0x09, 0x02, 0x13,
+	0x01, 0x01, 0x03, 0x04, 0x4c, 0xfc, 0x3e, 0x8c, 0x02, 0x41, 0x01, 0x81, 0xfe, 0xfd, 0x63, 0xfe,
+	0xf6, 0xfe, 0x70, 0x63, 0x02, 0xbf, 0x01, 0xe1, 0xfe, 0x1f, 0xfd, 0xd5, 0x01, 0xef, 0x01, 0x4d,
+	0xfe, 0xb3, 0xfe, 0x11, 0x00, 0x01, 0x00, 0xae, 0x01, 0x28, 0x05, 0x00, 0x03, 0x78, 0x00, 0x05,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x01, 0x86, 0x00, 0x02, 0x00, 0x00, 0x02, 0x57, 0x00,
+	0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x02, 0x00, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b,
+	0x01, 0x21, 0x03, 0x23, 0x13, 0x21, 0x04, 0xe2, 0xfc, 0xb8, 0x58, 0x94, 0x76, 0x03, 0xdc, 0x02,
+	0xe4, 0xfe, 0x44, 0x02, 0x50, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0xfe, 0x50, 0x04, 0x15,
+	0x06, 0x50, 0x00, 0x17, 0x00, 0x53, 0xb6, 0x16, 0x00, 0x02, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x00, 0x01, 0x00, 0x03, 0x72, 0x00, 0x01, 0x01, 0x84,
+	0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x02, 0x00, 0x51,
+	0x1b, 0x40, 0x1c, 0x00, 0x03, 0x00, 0x01, 0x00, 0x03, 0x01, 0x80, 0x00, 0x01, 0x01, 0x84, 0x00,
+	0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x02, 0x00, 0x51, 0x59,
+	0xb6, 0x24, 0x24, 0x14, 0x21, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x07, 0x06, 0x11,
+	0x11, 0x23, 0x11, 0x10, 0x37, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x34, 0x03, 0x71, 0x10, 0x0a, 0x43, 0x27, 0x25, 0xc5, 0x55, 0x63, 0xc1, 0x4c, 0x4d, 0x2e, 0x2d,
+	0x29, 0x28, 0x05, 0xea, 0x05, 0x5d, 0x59, 0xfd, 0xed, 0xfb, 0x2a, 0x03, 0xd5, 0x02, 0x70, 0xcd,
+	0xee, 0x3c, 0x3b, 0x32, 0x31, 0x28, 0x28, 0x0e, 0x00, 0x01, 0x00, 0xea, 0xfe, 0x50, 0x02, 0xc9,
+	0x07, 0x8f, 0x00, 0x14, 0x00, 0x50, 0xb5, 0x0d, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x17,
+	0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x03, 0x03, 0x02, 0x70, 0x00,
+	0x03, 0x01, 0x01, 0x03, 0x59, 0x00, 0x03, 0x03, 0x01, 0x62, 0x00, 0x01, 0x03, 0x01, 0x52, 0x1b,
+	0x40, 0x1a, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x03, 0x02, 0x85, 0x00, 0x03, 0x01, 0x01,
+	0x03, 0x59, 0x00, 0x03, 0x03, 0x01, 0x62, 0x00, 0x01, 0x03, 0x01, 0x52, 0x59, 0xb6, 0x33, 0x24,
+	0x23, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x33, 0x11, 0x10, 0x02, 0x23, 0x22, 0x26, 0x35, 0x34,
+	0x36, 0x33, 0x32, 0x15, 0x14, 0x07, 0x16, 0x33, 0x32, 0x11, 0x03, 0x02, 0x03, 0xc6, 0x98, 0xae,
+	0x41, 0x58, 0x3a, 0x28, 0x54, 0x04, 0x08, 0x04, 0x64, 0x09, 0x07, 0x8f, 0xfa, 0x1d, 0xfe, 0x33,
+	0xfe, 0x71, 0x48, 0x36, 0x2b, 0x3e, 0x54, 0x08, 0x11, 0x01, 0x01, 0x6c, 0x01, 0x80, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15,
+	0x04, 0xcd, 0x02, 0xa6, 0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x02, 0xb1,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01,
+	0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x94, 0x07,
+	0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x05,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b,
+	0x01, 0x21, 0x15, 0x21, 0x11, 0x23, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94,
+	0xfb, 0xaa, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x03, 0x3a, 0x00, 0x05,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x02, 0xb1, 0x94, 0x02,
+	0xa6, 0x94, 0xfb, 0x16, 0x04, 0x56, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0x02, 0xa6, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x05, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02,
+	0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x01, 0x02, 0x4f, 0x11, 0x11, 0x10,
+	0x03, 0x06, 0x19, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd,
+	0x50, 0x07, 0x8f, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x02, 0xb1,
+	0x07, 0x8f, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02,
+	0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x02, 0x1d, 0x94, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x03, 0x02, 0x03, 0x86, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02,
+	0x5f, 0x00, 0x02, 0x01, 0x02, 0x4f, 0x11, 0x11, 0x11, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f,
+	0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03,
+	0x02, 0x86, 0x00, 0x00, 0x03, 0x03, 0x00, 0x57, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03,
+	0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0x94, 0x02, 0xa6, 0x94, 0x04,
+	0x55, 0xf6, 0xc1, 0x04, 0x56, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x03, 0x3a, 0x00, 0x07, 0x00, 0x27, 0x40, 0x24, 0x00, 0x01, 0x00, 0x01, 0x86, 0x04, 0x01, 0x03,
+	0x00, 0x00, 0x03, 0x57, 0x04, 0x01, 0x03, 0x03, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x03, 0x00, 0x4f,
+	0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x15, 0x21,
+	0x11, 0x23, 0x11, 0x21, 0x35, 0x04, 0xcd, 0xfd, 0xe3, 0x94, 0xfd, 0xe4, 0x03, 0x3a, 0x94, 0xfb,
+	0xaa, 0x04, 0x56, 0x94, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x27, 0x40, 0x24, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57,
+	0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x15, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2f, 0x40, 0x2c,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00,
+	0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21,
+	0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4,
+	0x94, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f,
+	0x40, 0x2c, 0x00, 0x00, 0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02,
+	0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x11,
+	0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94,
+	0x94, 0xfe, 0xd8, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x03, 0x45,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85,
+	0x05, 0x03, 0x04, 0x03, 0x01, 0x01, 0x76, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x11, 0x33, 0x11,
+	0x33, 0x11, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xf6,
+	0xc1, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x09,
+	0x00, 0x2e, 0x40, 0x2b, 0x05, 0x01, 0x04, 0x03, 0x04, 0x86, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x02,
+	0x03, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b,
+	0x01, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4,
+	0x02, 0x1c, 0xfd, 0xe4, 0xfe, 0x50, 0x05, 0x7e, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25,
+	0x05, 0x04, 0x02, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x03, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11,
+	0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11,
+	0x01, 0x89, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04, 0xea, 0x94, 0xfb, 0xaa, 0x04,
+	0x56, 0xfb, 0xaa, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x33, 0x40, 0x30, 0x04, 0x01, 0x01, 0x03, 0x01, 0x86, 0x06, 0x01, 0x02, 0x00,
+	0x00, 0x05, 0x02, 0x00, 0x67, 0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f,
+	0x00, 0x03, 0x05, 0x03, 0x4f, 0x00, 0x00, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00,
+	0x05, 0x11, 0x11, 0x07, 0x06, 0x18, 0x2b, 0x01, 0x15, 0x21, 0x11, 0x23, 0x11, 0x01, 0x21, 0x11,
+	0x23, 0x11, 0x21, 0x04, 0xcd, 0xfd, 0x50, 0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03,
+	0xce, 0x94, 0xfb, 0x16, 0x05, 0x7e, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x02, 0xb1, 0x03, 0xce, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x02, 0x01,
+	0x86, 0x00, 0x00, 0x05, 0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x03, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09,
+	0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35,
+	0x21, 0x35, 0x02, 0xb1, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0xfa, 0x82, 0x03, 0xc2,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x03, 0x3a, 0x00, 0x09,
+	0x00, 0x28, 0x40, 0x25, 0x05, 0x04, 0x02, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01,
+	0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x03, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x09,
+	0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11,
+	0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0xfe, 0x77, 0x03, 0x45, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56,
+	0x94, 0xfb, 0x16, 0x04, 0x56, 0xfb, 0xaa, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45,
+	0x03, 0xce, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01, 0x01, 0x02, 0x01, 0x86,
+	0x00, 0x03, 0x07, 0x01, 0x05, 0x00, 0x03, 0x05, 0x67, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x06, 0x0b,
+	0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x08, 0x06, 0x18, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0xfe,
+	0x77, 0x03, 0x45, 0x94, 0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0xfa, 0x82,
+	0x04, 0xea, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x28, 0x40, 0x25, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02,
+	0x67, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04,
+	0x4f, 0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21,
+	0x15, 0x21, 0x15, 0x21, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0x50, 0x07,
+	0x8f, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x01, 0x89, 0x02, 0xa6, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x23, 0x40, 0x20, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01,
+	0x01, 0x04, 0x04, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x04, 0x5f, 0x00, 0x04, 0x01, 0x04, 0x4f,
+	0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11,
+	0x21, 0x15, 0x21, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0xfc, 0xbc, 0x07, 0x8f, 0xfb, 0xab,
+	0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0x02, 0x12, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x04, 0x01, 0x01, 0x02, 0x01, 0x85,
+	0x00, 0x02, 0x00, 0x00, 0x05, 0x02, 0x00, 0x67, 0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05,
+	0x05, 0x03, 0x5f, 0x00, 0x03, 0x05, 0x03, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x06,
+	0x1c, 0x2b, 0x01, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x04, 0xcd,
+	0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfc, 0xbc, 0x94, 0x02, 0xb0, 0x03, 0x3a, 0x04, 0x55, 0xfc, 0x3f,
+	0xfe, 0x44, 0x05, 0x7d, 0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0x12, 0x02, 0xb1,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x05,
+	0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x03, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11,
+	0x06, 0x06, 0x1a, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d,
+	0x94, 0xfd, 0x4f, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfa, 0x83, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x23, 0x40, 0x20,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x04, 0x01, 0x01, 0x03, 0x03, 0x01, 0x57, 0x04, 0x01, 0x01,
+	0x01, 0x03, 0x5f, 0x00, 0x03, 0x01, 0x03, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b,
+	0x2b, 0x01, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x35, 0x21, 0x01, 0x89, 0x94, 0x94, 0x94,
+	0xfc, 0xbb, 0x01, 0x89, 0x07, 0x8f, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0x17, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0x02, 0x12, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x38,
+	0x40, 0x35, 0x04, 0x01, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x06, 0x01, 0x02, 0x03, 0x00, 0x02,
+	0x67, 0x00, 0x03, 0x05, 0x05, 0x03, 0x57, 0x00, 0x03, 0x03, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x03,
+	0x05, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05,
+	0x00, 0x05, 0x11, 0x11, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x01, 0x35,
+	0x21, 0x11, 0x33, 0x11, 0x01, 0x89, 0x94, 0xfd, 0xe3, 0x02, 0xb1, 0x94, 0x03, 0x3a, 0x94, 0x03,
+	0xc1, 0xfb, 0xab, 0xfe, 0xd8, 0x94, 0x04, 0xe9, 0xfa, 0x83, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x05, 0x04, 0x05, 0x86, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03,
+	0x04, 0x04, 0x03, 0x57, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x10, 0x06, 0x06, 0x1c, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21,
+	0x15, 0x21, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94,
+	0x07, 0x8f, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x02, 0x01,
+	0x00, 0x03, 0x00, 0x85, 0x07, 0x05, 0x06, 0x03, 0x01, 0x04, 0x01, 0x86, 0x00, 0x03, 0x04, 0x04,
+	0x03, 0x57, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x0b, 0x04, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08,
+	0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x01,
+	0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0xfe, 0x78, 0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f,
+	0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0f, 0x00, 0x32, 0x40, 0x2f, 0x03, 0x01, 0x00, 0x04,
+	0x00, 0x85, 0x06, 0x01, 0x01, 0x05, 0x01, 0x86, 0x00, 0x04, 0x00, 0x02, 0x07, 0x04, 0x02, 0x67,
+	0x00, 0x07, 0x05, 0x05, 0x07, 0x57, 0x00, 0x07, 0x07, 0x05, 0x5f, 0x00, 0x05, 0x07, 0x05, 0x4f,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x08, 0x06, 0x1e, 0x2b, 0x01, 0x33, 0x11, 0x23,
+	0x01, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x01, 0x89, 0x94, 0x94,
+	0x03, 0x44, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x07, 0x8f, 0xf6, 0xc1,
+	0x04, 0xea, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x34, 0x40, 0x31, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x02, 0x03, 0x02, 0x86, 0x00, 0x00, 0x06, 0x01, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00,
+	0x04, 0x03, 0x03, 0x04, 0x57, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x04, 0x03, 0x4f, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35,
+	0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0x94, 0xfd, 0xe3,
+	0x02, 0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xf6, 0xc1, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x35,
+	0x40, 0x32, 0x04, 0x01, 0x02, 0x01, 0x02, 0x85, 0x07, 0x05, 0x06, 0x03, 0x03, 0x00, 0x03, 0x86,
+	0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f,
+	0x08, 0x08, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x08, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33,
+	0x11, 0x01, 0x89, 0xfe, 0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04,
+	0x55, 0xf6, 0xc1, 0x09, 0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45,
+	0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x42, 0x40, 0x3f, 0x06, 0x01, 0x04, 0x03,
+	0x04, 0x85, 0x07, 0x01, 0x01, 0x02, 0x01, 0x86, 0x00, 0x03, 0x09, 0x01, 0x05, 0x00, 0x03, 0x05,
+	0x67, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x08, 0x01, 0x02, 0x00,
+	0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09,
+	0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0a, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11, 0x13, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0xfe,
+	0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0x94, 0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28,
+	0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xf6, 0xc1, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x39, 0x40, 0x36, 0x00, 0x04,
+	0x03, 0x04, 0x86, 0x00, 0x00, 0x06, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03,
+	0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04,
+	0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x08, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23,
+	0x11, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8,
+	0x94, 0x94, 0xfc, 0x3e, 0x03, 0xc2, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x03, 0x3a, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x04, 0x01, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00,
+	0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x05, 0x03, 0x03, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b,
+	0x11, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfe, 0x78,
+	0x94, 0x94, 0x94, 0x02, 0xa6, 0x94, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x04, 0x56, 0x00,
+	0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0f,
+	0x00, 0x40, 0x40, 0x3d, 0x06, 0x01, 0x03, 0x04, 0x03, 0x86, 0x00, 0x00, 0x08, 0x01, 0x01, 0x02,
+	0x00, 0x01, 0x67, 0x07, 0x01, 0x02, 0x04, 0x04, 0x02, 0x57, 0x07, 0x01, 0x02, 0x02, 0x04, 0x5f,
+	0x05, 0x09, 0x02, 0x04, 0x02, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b,
+	0x0a, 0x04, 0x09, 0x04, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x06,
+	0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x21, 0x11, 0x23,
+	0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0x1d, 0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c,
+	0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0xfc, 0x3e, 0x04, 0x56, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x37,
+	0x40, 0x34, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x06, 0x01, 0x03, 0x04, 0x00, 0x03,
+	0x67, 0x00, 0x04, 0x05, 0x05, 0x04, 0x57, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x04,
+	0x05, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07,
+	0x11, 0x11, 0x11, 0x08, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01,
+	0x35, 0x21, 0x15, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x03,
+	0xc1, 0xfc, 0x3f, 0x94, 0xfe, 0xd8, 0x94, 0x94, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2c, 0x40, 0x29, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85, 0x04, 0x02,
+	0x02, 0x00, 0x05, 0x05, 0x00, 0x57, 0x04, 0x02, 0x02, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05,
+	0x00, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06,
+	0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x89,
+	0x94, 0x94, 0x94, 0x01, 0x88, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x0f, 0x00, 0x3e, 0x40, 0x3b, 0x04, 0x01, 0x01, 0x00, 0x01, 0x85, 0x05, 0x01,
+	0x00, 0x03, 0x08, 0x02, 0x02, 0x06, 0x00, 0x02, 0x67, 0x00, 0x06, 0x07, 0x07, 0x06, 0x57, 0x00,
+	0x06, 0x06, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x06, 0x07, 0x4f, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f,
+	0x0c, 0x0f, 0x0e, 0x0d, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11,
+	0x0a, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x01, 0x35, 0x21, 0x15, 0x01, 0x89, 0x94, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfb, 0x33,
+	0x04, 0xcd, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13,
+	0x00, 0x3d, 0x40, 0x3a, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x06, 0x05, 0x06, 0x86, 0x02, 0x01,
+	0x00, 0x0a, 0x09, 0x02, 0x03, 0x04, 0x00, 0x03, 0x67, 0x08, 0x01, 0x04, 0x05, 0x05, 0x04, 0x57,
+	0x08, 0x01, 0x04, 0x04, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x04, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x13,
+	0x00, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x11,
+	0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21,
+	0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0xfd,
+	0xe3, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x03,
+	0xc2, 0x94, 0x94, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13,
+	0x00, 0x38, 0x40, 0x35, 0x04, 0x01, 0x02, 0x01, 0x02, 0x85, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x07,
+	0x86, 0x05, 0x03, 0x02, 0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x03, 0x02, 0x01, 0x01, 0x00, 0x5f,
+	0x08, 0x06, 0x02, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11,
+	0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89,
+	0xfe, 0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04,
+	0x56, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb,
+	0xaa, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x11, 0x00, 0x17, 0x00, 0x4f, 0x40, 0x4c, 0x07, 0x01, 0x04, 0x03, 0x04, 0x85,
+	0x0a, 0x01, 0x01, 0x02, 0x01, 0x86, 0x08, 0x01, 0x03, 0x06, 0x0d, 0x02, 0x05, 0x00, 0x03, 0x05,
+	0x67, 0x0b, 0x01, 0x00, 0x02, 0x02, 0x00, 0x57, 0x0b, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x09, 0x0c,
+	0x02, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x17, 0x16, 0x15, 0x14, 0x13, 0x12, 0x11,
+	0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00,
+	0x05, 0x11, 0x11, 0x0e, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35, 0x21,
+	0x11, 0x33, 0x11, 0x21, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x02,
+	0x1d, 0x94, 0xfe, 0x77, 0x01, 0x89, 0x94, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfe, 0x78,
+	0x94, 0x02, 0x1c, 0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb,
+	0xab, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xf0, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17,
+	0x2b, 0x11, 0x11, 0x21, 0x11, 0x04, 0xcd, 0x02, 0xf0, 0x04, 0x9f, 0xfb, 0x61, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x02, 0xf0, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11,
+	0x21, 0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0xf0, 0xfb, 0x60, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04,
+	0xcd, 0xfb, 0x33, 0x07, 0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0x67,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01,
+	0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x02, 0x67, 0xfd, 0x99, 0x07,
+	0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x02, 0x66, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02,
+	0x06, 0x18, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x02, 0x66, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6,
+	0xc1, 0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x06, 0xcb, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23,
+	0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43,
+	0x00, 0x47, 0x00, 0xf9, 0x40, 0xf6, 0x14, 0x0a, 0x02, 0x00, 0x2e, 0x15, 0x29, 0x0b, 0x24, 0x05,
+	0x01, 0x02, 0x00, 0x01, 0x67, 0x16, 0x0c, 0x02, 0x02, 0x2f, 0x17, 0x2a, 0x0d, 0x25, 0x05, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x18, 0x0e, 0x02, 0x04, 0x30, 0x19, 0x2b, 0x0f, 0x26, 0x05, 0x05, 0x06,
+	0x04, 0x05, 0x67, 0x1a, 0x10, 0x02, 0x06, 0x31, 0x1b, 0x2c, 0x11, 0x27, 0x05, 0x07, 0x08, 0x06,
+	0x07, 0x67, 0x1c, 0x12, 0x02, 0x08, 0x32, 0x1d, 0x2d, 0x13, 0x28, 0x05, 0x09, 0x1e, 0x08, 0x09,
+	0x67, 0x22, 0x20, 0x02, 0x1e, 0x1f, 0x1f, 0x1e, 0x57, 0x22, 0x20, 0x02, 0x1e, 0x1e, 0x1f, 0x5f,
+	0x35, 0x23, 0x34, 0x21, 0x33, 0x05, 0x1f, 0x1e, 0x1f, 0x4f, 0x44, 0x44, 0x40, 0x40, 0x3c, 0x3c,
+	0x38, 0x38, 0x34, 0x34, 0x30, 0x30, 0x2c, 0x2c, 0x28, 0x28, 0x24, 0x24, 0x20, 0x20, 0x1c, 0x1c,
+	0x18, 0x18, 0x14, 0x14, 0x10, 0x10, 0x0c, 0x0c, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x44, 0x47,
+	0x44, 0x47, 0x46, 0x45, 0x40, 0x43, 0x40, 0x43, 0x42, 0x41, 0x3c, 0x3f, 0x3c, 0x3f, 0x3e, 0x3d,
+	0x38, 0x3b, 0x38, 0x3b, 0x3a, 0x39, 0x34, 0x37, 0x34, 0x37, 0x36, 0x35, 0x30, 0x33, 0x30, 0x33,
+	0x32, 0x31, 0x2c, 0x2f, 0x2c, 0x2f, 0x2e, 0x2d, 0x28, 0x2b, 0x28, 0x2b, 0x2a, 0x29, 0x24, 0x27,
+	0x24, 0x27, 0x26, 0x25, 0x20, 0x23, 0x20, 0x23, 0x22, 0x21, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d,
+	0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10, 0x13,
+	0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07,
+	0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x36, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x33,
+	0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce,
+	0xcb, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce,
+	0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xfc, 0xce, 0xcd, 0xcb, 0xce, 0xcb, 0xce, 0x06,
+	0x06, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe,
+	0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4,
+	0xc4, 0xc4, 0xc4, 0x00, 0x00, 0x24, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23,
+	0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43,
+	0x00, 0x47, 0x00, 0x4b, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x57, 0x00, 0x5b, 0x00, 0x5f, 0x00, 0x63,
+	0x00, 0x67, 0x00, 0x6b, 0x00, 0x6f, 0x00, 0x73, 0x00, 0x77, 0x00, 0x7b, 0x00, 0x7f, 0x00, 0x83,
+	0x00, 0x87, 0x00, 0x8b, 0x00, 0x8f, 0x00, 0x00, 0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7,
+	0xc7, 0xc7, 0xc7, 0xfb, 0x33, 0xcc, 0xd0, 0xcc, 0xd0, 0xcc, 0xfc, 0xca, 0xcc, 0xd0, 0xcc, 0xd0,
+	0xc7, 0x05, 0x41, 0xc3, 0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x05, 0x67, 0xc3, 0xc3, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0x05, 0x67, 0xc3, 0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x06, 0xf1, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4,
+	0xc4, 0xf7, 0x85, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0x00, 0x00, 0x00, 0x00, 0x13, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33,
+	0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0x4b, 0x00, 0x00, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x21, 0x35,
+	0x23, 0x15, 0x21, 0x35, 0x23, 0x15, 0x01, 0x21, 0x11, 0x21, 0xce, 0xce, 0x01, 0x9b, 0xce, 0x01,
+	0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x02, 0x67, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b,
+	0xce, 0x01, 0xce, 0x02, 0x67, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce,
+	0xfe, 0x69, 0xcd, 0x02, 0x66, 0xce, 0x02, 0x67, 0xce, 0xfc, 0x01, 0x04, 0xcd, 0xfb, 0x33, 0x06,
+	0x06, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe,
+	0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4,
+	0xc4, 0xc4, 0xc4, 0x09, 0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x00, 0x6f, 0x00, 0x00, 0x04, 0x7c,
+	0x04, 0x0d, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x6f, 0x04, 0x0d, 0x04, 0x0d, 0xfb, 0xf3, 0x00, 0x02, 0x00, 0x6f, 0x00, 0x00, 0x04, 0x7c,
+	0x04, 0x0d, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01,
+	0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06,
+	0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x6f, 0x04, 0x0d, 0xfc, 0x56, 0x03,
+	0x48, 0xfc, 0xb8, 0x04, 0x0d, 0xfb, 0xf3, 0x63, 0x03, 0x48, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6f,
+	0x01, 0x95, 0x02, 0x7d, 0x03, 0xa3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x6f, 0x02, 0x0e, 0x01,
+	0x95, 0x02, 0x0e, 0xfd, 0xf2, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6f, 0x01, 0x9f, 0x02, 0x7d,
+	0x03, 0xad, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01,
+	0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06,
+	0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x6f, 0x02, 0x0e, 0xfe, 0x55, 0x01,
+	0x49, 0xfe, 0xb7, 0x01, 0x9f, 0x02, 0x0e, 0xfd, 0xf2, 0x63, 0x01, 0x48, 0x00, 0x01, 0x00, 0x0b,
+	0x02, 0x00, 0x08, 0x0b, 0x04, 0x00, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x0b, 0x08, 0x00, 0x02,
+	0x00, 0x02, 0x00, 0xfe, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x05, 0x00, 0x00, 0x06, 0xfc,
+	0x05, 0xf7, 0x00, 0x02, 0x00, 0x15, 0x40, 0x12, 0x01, 0x01, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00,
+	0x76, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x02, 0x06, 0x16, 0x2b, 0x21, 0x01, 0x01, 0x01, 0x05,
+	0x02, 0xfc, 0x02, 0xfb, 0x05, 0xf7, 0xfa, 0x09, 0x00, 0x01, 0x01, 0x05, 0x00, 0x00, 0x06, 0xfc,
+	0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x09, 0x02, 0x01, 0x05,
+	0x05, 0xf7, 0xfa, 0x09, 0x05, 0xf7, 0xfd, 0x04, 0xfd, 0x05, 0x00, 0x00, 0x00, 0x01, 0x01, 0x05,
+	0x00, 0x00, 0x06, 0xfc, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x15, 0x40, 0x12, 0x01, 0x01, 0x00, 0x49,
+	0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x02, 0x06, 0x16, 0x2b, 0x09,
+	0x02, 0x06, 0xfc, 0xfd, 0x04, 0xfd, 0x05, 0x05, 0xf7, 0xfa, 0x09, 0x05, 0xf7, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x05, 0x00, 0x00, 0x06, 0xfc, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02,
+	0x00, 0x01, 0x32, 0x2b, 0x21, 0x01, 0x01, 0x06, 0xfc, 0xfa, 0x09, 0x05, 0xf7, 0x02, 0xfc, 0x02,
+	0xfb, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x2b, 0x01, 0x22, 0x03, 0xde, 0x04, 0xd5, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x08, 0xb5, 0x07, 0x05, 0x03, 0x01, 0x02, 0x32, 0x2b, 0x09, 0x07, 0x03, 0xde,
+	0xfe, 0x26, 0xfe, 0x27, 0x01, 0xd9, 0x01, 0x33, 0xfe, 0xcd, 0xfe, 0xce, 0x01, 0x32, 0x02, 0xfc,
+	0xfe, 0x26, 0x01, 0xda, 0x01, 0xd9, 0xfe, 0x27, 0x01, 0x32, 0xfe, 0xce, 0xfe, 0xcd, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xb9, 0x00, 0xde, 0x04, 0x31, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31,
+	0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02,
+	0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16,
+	0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x14, 0x00, 0x27, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x02, 0x6e, 0xb2, 0xfe, 0xfd, 0x01, 0x04,
+	0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xba, 0x92, 0xcd, 0xca, 0x90, 0x8f, 0xca, 0xc9, 0xde, 0x01,
+	0x07, 0xb5, 0xb8, 0x01, 0x04, 0xfe, 0xfb, 0xba, 0xb8, 0xfe, 0xff, 0x63, 0xc8, 0x8e, 0x92, 0xcb,
+	0xcb, 0x8f, 0x8d, 0xcc, 0x00, 0x01, 0x00, 0xb9, 0x00, 0xde, 0x04, 0x31, 0x04, 0x56, 0x00, 0x0b,
+	0x00, 0x18, 0x40, 0x15, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x00, 0x76, 0x01, 0x00,
+	0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x03, 0x06, 0x16, 0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00,
+	0x33, 0x32, 0x00, 0x15, 0x14, 0x00, 0x02, 0x6e, 0xb2, 0xfe, 0xfd, 0x01, 0x04, 0xb8, 0xb9, 0x01,
+	0x03, 0xfe, 0xf9, 0xde, 0x01, 0x07, 0xb5, 0xb8, 0x01, 0x04, 0xfe, 0xfb, 0xba, 0xb8, 0xfe, 0xff,
+	0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x24,
+	0x40, 0x21, 0x00, 0x01, 0x03, 0x01, 0x85, 0x00, 0x03, 0x02, 0x03, 0x85, 0x04, 0x01, 0x02, 0x00,
+	0x02, 0x85, 0x00, 0x00, 0x00, 0x76, 0x05, 0x04, 0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x11, 0x10,
+	0x05, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x01, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22,
+	0x00, 0x15, 0x14, 0x00, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0xfd, 0x93, 0xbc, 0x01, 0x07, 0xfe,
+	0xfd, 0xb9, 0xb8, 0xfe, 0xfc, 0x01, 0x02, 0xfe, 0x50, 0x09, 0x3f, 0xf9, 0xa5, 0x01, 0x01, 0xb8,
+	0xba, 0x01, 0x05, 0xfe, 0xfc, 0xb8, 0xb5, 0xfe, 0xf9, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x1b, 0x00, 0x37, 0x40, 0x34,
+	0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x05, 0x03, 0x85, 0x00, 0x05, 0x04, 0x05, 0x85, 0x07,
+	0x01, 0x04, 0x02, 0x04, 0x85, 0x06, 0x01, 0x02, 0x01, 0x02, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11,
+	0x10, 0x05, 0x04, 0x17, 0x15, 0x10, 0x1b, 0x11, 0x1b, 0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x11,
+	0x10, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x01, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23,
+	0x22, 0x00, 0x15, 0x14, 0x00, 0x37, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x06, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0x60, 0xec, 0x01, 0x46, 0xfe, 0xba, 0xe5, 0xe6, 0xfe, 0xbb,
+	0x01, 0x43, 0xe2, 0xae, 0xfc, 0xfd, 0xb3, 0xb2, 0xfe, 0xfe, 0x07, 0x8f, 0xf6, 0xc1, 0x02, 0x75,
+	0x01, 0x42, 0xea, 0xe5, 0x01, 0x45, 0xfe, 0xbb, 0xe6, 0xe4, 0xfe, 0xb9, 0x7b, 0xff, 0xb1, 0xb3,
+	0xfd, 0xfd, 0xb2, 0xb6, 0xfb, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4d, 0x01, 0x71, 0x02, 0x9f,
+	0x03, 0xc3, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x03, 0x00, 0x01, 0x00, 0x03,
+	0x01, 0x69, 0x04, 0x01, 0x00, 0x02, 0x02, 0x00, 0x59, 0x04, 0x01, 0x00, 0x00, 0x02, 0x61, 0x05,
+	0x01, 0x02, 0x00, 0x02, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16, 0x2b, 0x01, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x17, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x06, 0x01, 0x74, 0x52, 0x75, 0x73, 0x52, 0x52, 0x72, 0x72, 0x4d, 0x77, 0xad, 0xae, 0x7b, 0x7c,
+	0xad, 0xb0, 0x01, 0xd6, 0x72, 0x50, 0x54, 0x73, 0x73, 0x52, 0x50, 0x74, 0x65, 0xb0, 0x79, 0x7b,
+	0xae, 0xae, 0x7d, 0x7b, 0xac, 0x00, 0x00, 0x00, 0x00, 0x05, 0x01, 0x17, 0xff, 0xdb, 0x07, 0x29,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2f, 0x00, 0x3b, 0x00, 0x66, 0x40, 0x63,
+	0x06, 0x01, 0x04, 0x08, 0x05, 0x08, 0x04, 0x05, 0x80, 0x00, 0x01, 0x00, 0x03, 0x09, 0x01, 0x03,
+	0x69, 0x0b, 0x01, 0x09, 0x0f, 0x0a, 0x0e, 0x03, 0x08, 0x04, 0x09, 0x08, 0x69, 0x00, 0x05, 0x00,
+	0x07, 0x02, 0x05, 0x07, 0x69, 0x0d, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x0d, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x0c, 0x01, 0x00, 0x02, 0x00, 0x51, 0x31, 0x30, 0x25, 0x24, 0x0d, 0x0c, 0x01, 0x00,
+	0x37, 0x35, 0x30, 0x3b, 0x31, 0x3b, 0x2b, 0x29, 0x24, 0x2f, 0x25, 0x2f, 0x22, 0x20, 0x1e, 0x1d,
+	0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x10, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x25, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x03, 0x33, 0x12, 0x21,
+	0x20, 0x13, 0x33, 0x06, 0x04, 0x23, 0x22, 0x24, 0x13, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32,
+	0x16, 0x15, 0x14, 0x06, 0x21, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06,
+	0x04, 0x17, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7, 0x01, 0x42, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x37,
+	0xfe, 0xb8, 0x01, 0x0b, 0x01, 0x72, 0xfe, 0x90, 0xfe, 0xfb, 0xfe, 0xfb, 0xfe, 0x90, 0x01, 0x6e,
+	0xda, 0x6f, 0x49, 0x01, 0x29, 0x01, 0x29, 0x49, 0x6f, 0x1f, 0xfe, 0xfc, 0xbe, 0xbe, 0xfe, 0xfc,
+	0xca, 0x32, 0x48, 0x48, 0x33, 0x33, 0x49, 0x49, 0x01, 0xb9, 0x32, 0x48, 0x49, 0x33, 0x33, 0x48,
+	0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x3a, 0xfe, 0xbf, 0xfe, 0xb9,
+	0xfe, 0x3c, 0x94, 0x01, 0x6e, 0x01, 0x08, 0x01, 0x04, 0x01, 0x70, 0xfe, 0x90, 0xfe, 0xfb, 0xfe,
+	0xfe, 0xfe, 0x8d, 0x02, 0x4a, 0xfe, 0xd2, 0x01, 0x2e, 0xd4, 0xfb, 0xfb, 0x01, 0x7b, 0x48, 0x33,
+	0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x48, 0x33, 0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x00, 0x00,
+	0x00, 0x04, 0x01, 0x38, 0xff, 0xdb, 0x07, 0x4a, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x2f, 0x00, 0x59, 0x40, 0x56, 0x0b, 0x05, 0x02, 0x03, 0x06, 0x04, 0x06, 0x03, 0x04, 0x80,
+	0x00, 0x01, 0x09, 0x01, 0x07, 0x06, 0x01, 0x07, 0x69, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x00, 0x04,
+	0x02, 0x06, 0x04, 0x69, 0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x0a,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x25, 0x24, 0x19, 0x18, 0x0c, 0x0c, 0x01, 0x00, 0x2b, 0x29, 0x24,
+	0x2f, 0x25, 0x2f, 0x1f, 0x1d, 0x18, 0x23, 0x19, 0x23, 0x0c, 0x17, 0x0c, 0x17, 0x16, 0x14, 0x13,
+	0x12, 0x10, 0x0e, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0e, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x01, 0x16, 0x04, 0x33, 0x32, 0x24, 0x37,
+	0x23, 0x02, 0x21, 0x20, 0x03, 0x37, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x04, 0x38, 0xfe,
+	0xc5, 0xfe, 0x3b, 0x01, 0xc7, 0x01, 0x42, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x37, 0xfc, 0xdf, 0x1f,
+	0x01, 0x04, 0xbe, 0xbe, 0x01, 0x04, 0x1f, 0x6f, 0x49, 0xfe, 0xd7, 0xfe, 0xd7, 0x49, 0x7a, 0x34,
+	0x49, 0x49, 0x33, 0x33, 0x48, 0x48, 0x02, 0x1f, 0x35, 0x48, 0x48, 0x33, 0x33, 0x49, 0x48, 0x25,
+	0x01, 0xca, 0x01, 0x3f, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x3a, 0xfe, 0xbf, 0xfe, 0xb9, 0xfe, 0x3c,
+	0x02, 0xde, 0xd4, 0xfb, 0xfb, 0xd4, 0xfe, 0xd2, 0x01, 0x2e, 0xa7, 0x47, 0x34, 0x33, 0x48, 0x48,
+	0x33, 0x33, 0x48, 0x47, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x48, 0x00, 0x00, 0x02, 0x00, 0xb8,
+	0xff, 0xe7, 0x06, 0xb2, 0x05, 0xe1, 0x00, 0x27, 0x00, 0x33, 0x00, 0x60, 0x40, 0x5d, 0x19, 0x18,
+	0x17, 0x15, 0x12, 0x10, 0x0f, 0x0e, 0x08, 0x07, 0x02, 0x1a, 0x0d, 0x02, 0x01, 0x07, 0x21, 0x06,
+	0x02, 0x06, 0x00, 0x26, 0x24, 0x23, 0x22, 0x05, 0x04, 0x03, 0x01, 0x08, 0x05, 0x06, 0x04, 0x4c,
+	0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x06, 0x01,
+	0x00, 0x67, 0x09, 0x01, 0x06, 0x05, 0x05, 0x06, 0x59, 0x09, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x06, 0x05, 0x4f, 0x29, 0x28, 0x00, 0x00, 0x2f, 0x2d, 0x28, 0x33, 0x29, 0x33, 0x00,
+	0x27, 0x00, 0x27, 0x11, 0x18, 0x18, 0x11, 0x18, 0x0a, 0x06, 0x1b, 0x2b, 0x05, 0x35, 0x26, 0x27,
+	0x07, 0x27, 0x37, 0x26, 0x27, 0x23, 0x35, 0x33, 0x36, 0x37, 0x27, 0x37, 0x17, 0x36, 0x37, 0x35,
+	0x33, 0x15, 0x16, 0x17, 0x37, 0x17, 0x07, 0x16, 0x17, 0x33, 0x15, 0x23, 0x06, 0x07, 0x17, 0x07,
+	0x27, 0x06, 0x07, 0x15, 0x03, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16,
+	0x03, 0x6b, 0x7b, 0x71, 0xb1, 0x69, 0xb1, 0x4a, 0x18, 0xfc, 0xfc, 0x18, 0x4a, 0xb1, 0x69, 0xb1,
+	0x71, 0x7b, 0x94, 0x7b, 0x71, 0xb1, 0x68, 0xb0, 0x4a, 0x18, 0xfc, 0xfc, 0x18, 0x4a, 0xb0, 0x68,
+	0xb1, 0x71, 0x7b, 0x4f, 0x9e, 0xd9, 0xd9, 0x99, 0x9a, 0xd8, 0xd7, 0x19, 0xfc, 0x15, 0x4d, 0xb1,
+	0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69, 0xb0, 0x69, 0xb1, 0x4d, 0x15, 0xfc, 0xfc, 0x15, 0x4d,
+	0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69, 0xb0, 0x69, 0xb1, 0x4d, 0x15, 0xfc, 0x01, 0x8b,
+	0xd7, 0x9c, 0x99, 0xd8, 0xd8, 0x9a, 0x98, 0xda, 0x00, 0x02, 0x00, 0x71, 0xfe, 0x75, 0x05, 0xa5,
+	0x06, 0x44, 0x00, 0x16, 0x00, 0x22, 0x00, 0x4a, 0x40, 0x47, 0x11, 0x05, 0x02, 0x01, 0x06, 0x01,
+	0x4c, 0x09, 0x01, 0x06, 0x07, 0x01, 0x07, 0x06, 0x01, 0x80, 0x08, 0x01, 0x05, 0x00, 0x05, 0x86,
+	0x00, 0x02, 0x00, 0x07, 0x06, 0x02, 0x07, 0x69, 0x03, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x18, 0x17, 0x00, 0x00, 0x1e,
+	0x1c, 0x17, 0x22, 0x18, 0x22, 0x00, 0x16, 0x00, 0x16, 0x11, 0x16, 0x26, 0x11, 0x11, 0x0a, 0x06,
+	0x1b, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x21, 0x11, 0x24, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x05, 0x11, 0x21, 0x15, 0x21, 0x15, 0x03, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23,
+	0x22, 0x00, 0x15, 0x14, 0x00, 0x02, 0xc1, 0xfe, 0x3e, 0x01, 0xc2, 0xfe, 0xfa, 0xfe, 0xb6, 0x01,
+	0x86, 0x01, 0x14, 0x01, 0x14, 0x01, 0x86, 0xfe, 0xb6, 0xfe, 0xfa, 0x01, 0xc2, 0xfe, 0x3e, 0x50,
+	0xdc, 0x01, 0x30, 0xfe, 0xd1, 0xd7, 0xd7, 0xfe, 0xd1, 0x01, 0x2e, 0xfe, 0x75, 0xf7, 0x94, 0x01,
+	0x14, 0x25, 0x01, 0x71, 0x01, 0x00, 0x01, 0x14, 0x01, 0x86, 0xfe, 0x7a, 0xfe, 0xec, 0xff, 0x00,
+	0xfe, 0x8f, 0x25, 0xfe, 0xec, 0x94, 0xf7, 0x03, 0x2f, 0x01, 0x2d, 0xda, 0xd6, 0x01, 0x2f, 0xfe,
+	0xd1, 0xd7, 0xd4, 0xfe, 0xce, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x36, 0xff, 0xb5, 0x06, 0x62,
+	0x07, 0x2e, 0x00, 0x14, 0x00, 0x20, 0x00, 0x08, 0xb5, 0x1d, 0x17, 0x0e, 0x04, 0x02, 0x32, 0x2b,
+	0x01, 0x13, 0x05, 0x27, 0x25, 0x13, 0x07, 0x03, 0x03, 0x16, 0x17, 0x12, 0x00, 0x05, 0x04, 0x00,
+	0x03, 0x02, 0x00, 0x25, 0x36, 0x01, 0x16, 0x04, 0x37, 0x36, 0x12, 0x27, 0x26, 0x24, 0x07, 0x06,
+	0x02, 0x04, 0x17, 0xdb, 0xfe, 0x95, 0x26, 0x02, 0x5e, 0xa3, 0x8f, 0x61, 0xdb, 0xb6, 0x36, 0x48,
+	0xfe, 0xeb, 0xfe, 0xf5, 0xfe, 0xf6, 0xfe, 0x24, 0x48, 0x47, 0x01, 0x15, 0x01, 0x0c, 0xdb, 0xfd,
+	0xda, 0x39, 0x01, 0x71, 0xd3, 0xcf, 0xd5, 0x37, 0x38, 0xfe, 0x8d, 0xd0, 0xcd, 0xd9, 0x04, 0xe2,
+	0x01, 0x7c, 0x61, 0x8f, 0xa2, 0xfd, 0xa1, 0x26, 0x01, 0x6a, 0xfe, 0x85, 0x99, 0xcd, 0xfe, 0xf5,
+	0xfe, 0x1d, 0x47, 0x48, 0x01, 0x17, 0x01, 0x0c, 0x01, 0x0b, 0x01, 0xd9, 0x48, 0x3b, 0xfc, 0xc1,
+	0xd4, 0xd8, 0x39, 0x37, 0x01, 0x74, 0xcf, 0xcf, 0xd7, 0x38, 0x37, 0xfe, 0x8e, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x3d, 0x00, 0x00, 0x04, 0x18, 0x05, 0x36, 0x00, 0x18, 0x00, 0x20, 0x40, 0x1d,
+	0x17, 0x0c, 0x01, 0x03, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02,
+	0x76, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x16, 0x14, 0x22, 0x04, 0x06, 0x17, 0x2b, 0x21, 0x13,
+	0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x37, 0x37, 0x36, 0x37, 0x16, 0x17, 0x17, 0x16, 0x16,
+	0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x13, 0x01, 0xaf, 0x5b, 0x68, 0x90, 0x5d, 0x78, 0x48, 0x6c,
+	0x71, 0x73, 0x55, 0x55, 0x74, 0x71, 0x6c, 0x48, 0x78, 0x5e, 0x8f, 0x68, 0x5b, 0x01, 0x64, 0x4a,
+	0x89, 0x83, 0x6e, 0x95, 0x73, 0x79, 0x7b, 0xa6, 0xa6, 0x7b, 0x79, 0x73, 0x95, 0x6f, 0x82, 0x89,
+	0x4a, 0xfe, 0x9c, 0x00, 0x00, 0x01, 0x00, 0x3d, 0x00, 0x00, 0x05, 0x18, 0x04, 0xfb, 0x00, 0x20,
+	0x00, 0x30, 0x40, 0x2d, 0x1f, 0x15, 0x0b, 0x01, 0x04, 0x00, 0x01, 0x01, 0x4c, 0x00, 0x02, 0x01,
+	0x02, 0x85, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85, 0x04, 0x01, 0x00, 0x05, 0x00, 0x85, 0x06, 0x01,
+	0x05, 0x05, 0x76, 0x00, 0x00, 0x00, 0x20, 0x00, 0x20, 0x24, 0x25, 0x25, 0x24, 0x22, 0x07, 0x06,
+	0x1b, 0x2b, 0x21, 0x13, 0x02, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x03, 0x13, 0x02, 0x24, 0x59, 0x71, 0xc6, 0x71, 0x98, 0xa2, 0x85, 0x32, 0x3a, 0x34, 0x9c,
+	0x73, 0x72, 0x9b, 0x33, 0x39, 0x32, 0x86, 0xa2, 0x98, 0x70, 0xc7, 0x72, 0x5a, 0x02, 0x02, 0xfe,
+	0xef, 0xa0, 0x75, 0x83, 0x9e, 0x11, 0x66, 0x59, 0x7d, 0xa9, 0xa9, 0x7d, 0x59, 0x66, 0x11, 0x9e,
+	0x83, 0x75, 0xa0, 0x01, 0x11, 0xfd, 0xfe, 0x00, 0x00, 0x01, 0x00, 0x55, 0xff, 0xe2, 0x04, 0x80,
+	0x04, 0xbe, 0x00, 0x19, 0x00, 0x11, 0x40, 0x0e, 0x0d, 0x01, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00,
+	0x76, 0x22, 0x2a, 0x02, 0x06, 0x18, 0x2b, 0x05, 0x26, 0x2f, 0x04, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x13, 0x12, 0x33, 0x32, 0x16, 0x15, 0x14, 0x0f, 0x04, 0x06, 0x02, 0x6a, 0x34, 0x13, 0x5a,
+	0x42, 0x37, 0x43, 0xb8, 0x95, 0x73, 0xd7, 0x36, 0x36, 0xd8, 0x73, 0x95, 0xb8, 0x42, 0x38, 0x42,
+	0x5a, 0x13, 0x1e, 0x57, 0x19, 0x7f, 0x5f, 0x47, 0x54, 0xe9, 0xbe, 0x91, 0xbb, 0xfe, 0xb4, 0x01,
+	0x4c, 0xbb, 0x91, 0xbe, 0xe9, 0x54, 0x47, 0x5f, 0x7f, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x33,
+	0xff, 0xde, 0x03, 0xf8, 0x05, 0x3b, 0x00, 0x07, 0x00, 0x06, 0xb3, 0x04, 0x00, 0x01, 0x32, 0x2b,
+	0x05, 0x02, 0x01, 0x00, 0x13, 0x12, 0x01, 0x00, 0x02, 0x16, 0xc3, 0xfe, 0xe0, 0x01, 0x20, 0xc3,
+	0xc5, 0x01, 0x1d, 0xfe, 0xe3, 0x22, 0x01, 0x99, 0x01, 0x16, 0x01, 0x14, 0x01, 0x9a, 0xfe, 0x67,
+	0xfe, 0xeb, 0xfe, 0xea, 0x00, 0x01, 0x00, 0x3c, 0xff, 0xdb, 0x03, 0xda, 0x05, 0xc8, 0x00, 0x1e,
+	0x00, 0x2c, 0x40, 0x29, 0x14, 0x0b, 0x0a, 0x03, 0x02, 0x00, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4c,
+	0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01, 0x01, 0x02, 0x59, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x02, 0x01, 0x51, 0x1e, 0x1c, 0x18, 0x16, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x01, 0x11,
+	0x33, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14, 0x07, 0x27, 0x36, 0x35, 0x34, 0x27, 0x27, 0x26,
+	0x27, 0x26, 0x27, 0x11, 0x10, 0x21, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x01, 0xd5, 0x63,
+	0x83, 0x46, 0xd9, 0x6b, 0x45, 0x3e, 0x58, 0x4a, 0x16, 0x34, 0x1d, 0x27, 0xfe, 0xab, 0x49, 0x5e,
+	0xae, 0x75, 0x3c, 0x01, 0x2d, 0x04, 0x9b, 0x1a, 0x83, 0x64, 0x35, 0xa5, 0x8c, 0x68, 0x87, 0x34,
+	0x54, 0x3d, 0x3d, 0x4e, 0x43, 0x13, 0x25, 0x13, 0x2d, 0xfd, 0x2d, 0xfe, 0x31, 0x4c, 0x3c, 0x5a,
+	0x87, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6f, 0xfe, 0xeb, 0x05, 0x34, 0x05, 0xed, 0x00, 0x1a,
+	0x00, 0x33, 0x40, 0x30, 0x19, 0x01, 0x01, 0x03, 0x0b, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x1a, 0x0d,
+	0x0c, 0x00, 0x04, 0x03, 0x4a, 0x00, 0x01, 0x02, 0x00, 0x01, 0x59, 0x00, 0x03, 0x00, 0x02, 0x00,
+	0x03, 0x02, 0x69, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x01, 0x00, 0x51, 0x23, 0x27, 0x23,
+	0x23, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x14, 0x06, 0x23, 0x22, 0x35, 0x34, 0x36, 0x33, 0x32,
+	0x17, 0x11, 0x01, 0x11, 0x14, 0x07, 0x06, 0x23, 0x22, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x11,
+	0x02, 0x67, 0xa9, 0xa3, 0xac, 0xac, 0x76, 0x40, 0x33, 0x03, 0x30, 0x5e, 0x62, 0x8b, 0xaa, 0xac,
+	0x7b, 0x33, 0x38, 0x03, 0xf7, 0xfc, 0xc6, 0xe5, 0xed, 0x8c, 0x5c, 0x85, 0x18, 0x04, 0x67, 0x01,
+	0x46, 0xfc, 0x0f, 0xff, 0x63, 0x69, 0x87, 0x5b, 0x82, 0x16, 0x03, 0x6f, 0x00, 0x0e, 0x00, 0x99,
+	0xff, 0x75, 0x08, 0x64, 0x06, 0xa9, 0x00, 0x11, 0x00, 0x25, 0x00, 0x36, 0x00, 0x4f, 0x00, 0x6a,
+	0x00, 0x78, 0x00, 0x83, 0x00, 0x8f, 0x00, 0xa4, 0x00, 0xc1, 0x00, 0xd5, 0x00, 0xeb, 0x01, 0x88,
+	0x01, 0xa3, 0x15, 0x24, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x0f, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x13, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x16, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x18, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00,
+	0x17, 0x00, 0x1f, 0x00, 0xef, 0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00,
+	0x02, 0x00, 0x09, 0x00, 0x22, 0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00,
+	0xad, 0x00, 0x01, 0x00, 0x06, 0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00,
+	0x11, 0x01, 0x9c, 0x00, 0x01, 0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00,
+	0x10, 0x00, 0xfe, 0x00, 0x01, 0x00, 0x15, 0x00, 0x12, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x04, 0x01, 0x49, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01,
+	0x1c, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00,
+	0x0d, 0x00, 0x4c, 0x1b, 0x41, 0x3c, 0x01, 0x79, 0x00, 0x01, 0x00, 0x17, 0x00, 0x1f, 0x00, 0xef,
+	0x00, 0x01, 0x00, 0x22, 0x00, 0x17, 0x01, 0x99, 0x01, 0x77, 0x00, 0x02, 0x00, 0x09, 0x00, 0x22,
+	0x01, 0x93, 0x01, 0x66, 0x00, 0x02, 0x00, 0x0e, 0x00, 0x09, 0x00, 0xad, 0x00, 0x01, 0x00, 0x06,
+	0x00, 0x0c, 0x00, 0xaa, 0x00, 0x62, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x11, 0x01, 0x9c, 0x00, 0x01,
+	0x00, 0x10, 0x00, 0x0a, 0x00, 0xd6, 0x00, 0x01, 0x00, 0x05, 0x00, 0x10, 0x00, 0xfe, 0x00, 0x01,
+	0x00, 0x15, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x12, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x01, 0x49,
+	0x00, 0x01, 0x00, 0x1c, 0x00, 0x07, 0x01, 0x36, 0x01, 0x30, 0x01, 0x1c, 0x00, 0x03, 0x00, 0x1a,
+	0x00, 0x1c, 0x01, 0x41, 0x00, 0x01, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x0d, 0x00, 0x4c, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0xb6,
+	0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85,
+	0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28,
+	0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26,
+	0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00,
+	0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e,
+	0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e,
+	0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a,
+	0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17,
+	0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11,
+	0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03,
+	0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02,
+	0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x0b, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20,
+	0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09,
+	0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21,
+	0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00,
+	0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12,
+	0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18,
+	0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03,
+	0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02,
+	0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c,
+	0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17,
+	0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f,
+	0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00,
+	0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00,
+	0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0xcf, 0x00,
+	0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00,
+	0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01,
+	0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80,
+	0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00,
+	0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15,
+	0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08,
+	0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07,
+	0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02,
+	0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b,
+	0x7e, 0x00, 0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00,
+	0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23,
+	0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00,
+	0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b,
+	0xb0, 0x0d, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16,
+	0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e,
+	0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a,
+	0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05,
+	0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03,
+	0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d,
+	0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a,
+	0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84,
+	0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69,
+	0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05,
+	0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02,
+	0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x0e,
+	0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00,
+	0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22,
+	0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f,
+	0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05,
+	0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05,
+	0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08,
+	0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01,
+	0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c,
+	0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b,
+	0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c,
+	0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01,
+	0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02,
+	0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0,
+	0x0f, 0x50, 0x58, 0x40, 0xcf, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85,
+	0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b,
+	0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d,
+	0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10,
+	0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15,
+	0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18,
+	0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02,
+	0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07,
+	0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e,
+	0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b, 0x7e, 0x00, 0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09,
+	0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a,
+	0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00,
+	0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0xb6, 0x00, 0x20, 0x16, 0x20,
+	0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e,
+	0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c,
+	0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11,
+	0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00, 0x15, 0x08, 0x05, 0x15,
+	0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d,
+	0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02,
+	0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19,
+	0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b,
+	0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69,
+	0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03,
+	0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00,
+	0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x11, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27,
+	0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09,
+	0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21,
+	0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c,
+	0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12,
+	0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e,
+	0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e,
+	0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e,
+	0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a,
+	0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17,
+	0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f,
+	0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00,
+	0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02,
+	0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0xcf, 0x00, 0x20, 0x16, 0x20, 0x85,
+	0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22,
+	0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b,
+	0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10,
+	0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05,
+	0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18,
+	0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03,
+	0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e,
+	0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00,
+	0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b, 0x7e, 0x00, 0x1b, 0x1b,
+	0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c,
+	0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06,
+	0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59,
+	0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x13, 0x50, 0x58,
+	0x40, 0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17,
+	0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b,
+	0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a,
+	0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15,
+	0x80, 0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08,
+	0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07,
+	0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e,
+	0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22,
+	0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d,
+	0x00, 0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69,
+	0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00,
+	0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0xc9,
+	0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85,
+	0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28,
+	0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11,
+	0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e,
+	0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00,
+	0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02,
+	0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d,
+	0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a,
+	0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84,
+	0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69,
+	0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05,
+	0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00,
+	0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x16, 0x50, 0x58, 0x40,
+	0xb6, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f,
+	0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e,
+	0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72,
+	0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80,
+	0x00, 0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03,
+	0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e,
+	0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00,
+	0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09,
+	0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00,
+	0x11, 0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00,
+	0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00,
+	0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x18, 0x50, 0x58, 0x40, 0xc9, 0x00,
+	0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00,
+	0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01,
+	0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80,
+	0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00,
+	0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15,
+	0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08,
+	0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07,
+	0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02,
+	0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00,
+	0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00,
+	0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69,
+	0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00,
+	0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0xb6,
+	0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85,
+	0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28,
+	0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26,
+	0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x15, 0x05, 0x12, 0x15, 0x80, 0x00,
+	0x15, 0x08, 0x05, 0x15, 0x08, 0x7e, 0x18, 0x13, 0x24, 0x03, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e,
+	0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e,
+	0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a,
+	0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17,
+	0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x0f, 0x25, 0x02, 0x0d, 0x00, 0x11,
+	0x0a, 0x0d, 0x11, 0x69, 0x00, 0x06, 0x14, 0x23, 0x02, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03,
+	0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02,
+	0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20,
+	0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09,
+	0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21,
+	0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00,
+	0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12,
+	0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18,
+	0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03,
+	0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02,
+	0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c,
+	0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17,
+	0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f,
+	0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00,
+	0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00,
+	0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0xcf, 0x00,
+	0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00,
+	0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01,
+	0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80,
+	0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01, 0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00,
+	0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15,
+	0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08,
+	0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07,
+	0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02,
+	0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x00, 0x19, 0x1b, 0x02, 0x19, 0x1b,
+	0x7e, 0x00, 0x1b, 0x1b, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00,
+	0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23,
+	0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00,
+	0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x4b,
+	0xb0, 0x1d, 0x50, 0x58, 0x40, 0xc9, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16,
+	0x85, 0x00, 0x1f, 0x17, 0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e,
+	0x0b, 0x22, 0x0e, 0x0b, 0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01,
+	0x0d, 0x0f, 0x11, 0x0f, 0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x0c, 0x0a, 0x72, 0x26, 0x01,
+	0x10, 0x05, 0x11, 0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14,
+	0x15, 0x05, 0x14, 0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05,
+	0x18, 0x08, 0x7e, 0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d,
+	0x02, 0x01, 0x1d, 0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02,
+	0x07, 0x1c, 0x7e, 0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19,
+	0x7e, 0x1b, 0x01, 0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b,
+	0x00, 0x0c, 0x06, 0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06,
+	0x23, 0x01, 0x05, 0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00,
+	0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x1b,
+	0x40, 0xca, 0x00, 0x20, 0x16, 0x20, 0x85, 0x27, 0x01, 0x16, 0x1f, 0x16, 0x85, 0x00, 0x1f, 0x17,
+	0x1f, 0x85, 0x00, 0x09, 0x22, 0x0e, 0x22, 0x09, 0x0e, 0x80, 0x00, 0x0e, 0x0b, 0x22, 0x0e, 0x0b,
+	0x7e, 0x28, 0x01, 0x21, 0x0b, 0x0c, 0x0b, 0x21, 0x0c, 0x80, 0x25, 0x01, 0x0d, 0x0f, 0x11, 0x0f,
+	0x0d, 0x11, 0x80, 0x00, 0x0a, 0x11, 0x10, 0x11, 0x0a, 0x10, 0x80, 0x26, 0x01, 0x10, 0x05, 0x11,
+	0x10, 0x05, 0x7e, 0x00, 0x12, 0x05, 0x14, 0x05, 0x12, 0x14, 0x80, 0x00, 0x14, 0x15, 0x05, 0x14,
+	0x15, 0x7e, 0x00, 0x15, 0x18, 0x05, 0x15, 0x18, 0x7e, 0x00, 0x18, 0x08, 0x05, 0x18, 0x08, 0x7e,
+	0x13, 0x24, 0x02, 0x08, 0x03, 0x05, 0x08, 0x03, 0x7e, 0x00, 0x01, 0x02, 0x1d, 0x02, 0x01, 0x1d,
+	0x80, 0x00, 0x1d, 0x07, 0x02, 0x1d, 0x07, 0x7e, 0x1e, 0x01, 0x07, 0x1c, 0x02, 0x07, 0x1c, 0x7e,
+	0x00, 0x1c, 0x1a, 0x02, 0x1c, 0x1a, 0x7e, 0x00, 0x1a, 0x19, 0x02, 0x1a, 0x19, 0x7e, 0x1b, 0x01,
+	0x19, 0x19, 0x84, 0x00, 0x17, 0x00, 0x22, 0x09, 0x17, 0x22, 0x69, 0x00, 0x0b, 0x00, 0x0c, 0x06,
+	0x0b, 0x0c, 0x69, 0x00, 0x0f, 0x00, 0x11, 0x0a, 0x0f, 0x11, 0x69, 0x00, 0x06, 0x23, 0x01, 0x05,
+	0x12, 0x06, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x69, 0x00, 0x00, 0x02, 0x02,
+	0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x59, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x41, 0x5c, 0x01,
+	0x8a, 0x01, 0x89, 0x00, 0xed, 0x00, 0xec, 0x00, 0xa6, 0x00, 0xa5, 0x00, 0x79, 0x00, 0x79, 0x00,
+	0x51, 0x00, 0x50, 0x00, 0x27, 0x00, 0x26, 0x01, 0x97, 0x01, 0x95, 0x01, 0x89, 0x01, 0xa3, 0x01,
+	0x8a, 0x01, 0xa1, 0x01, 0x82, 0x01, 0x80, 0x01, 0x73, 0x01, 0x71, 0x01, 0x59, 0x01, 0x58, 0x01,
+	0x55, 0x01, 0x53, 0x01, 0x4d, 0x01, 0x4b, 0x01, 0x3f, 0x01, 0x3d, 0x01, 0x34, 0x01, 0x32, 0x01,
+	0x2b, 0x01, 0x29, 0x01, 0x0c, 0x01, 0x0b, 0x00, 0xf3, 0x00, 0xf1, 0x00, 0xec, 0x01, 0x88, 0x00,
+	0xed, 0x01, 0x86, 0x00, 0xe8, 0x00, 0xe6, 0x00, 0xda, 0x00, 0xd8, 0x00, 0xcf, 0x00, 0xcd, 0x00,
+	0xbe, 0x00, 0xbc, 0x00, 0xb3, 0x00, 0xb1, 0x00, 0xa5, 0x00, 0xc1, 0x00, 0xa6, 0x00, 0xc1, 0x00,
+	0xa1, 0x00, 0x9f, 0x00, 0x96, 0x00, 0x95, 0x00, 0x79, 0x00, 0x83, 0x00, 0x79, 0x00, 0x83, 0x00,
+	0x80, 0x00, 0x7e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x5d, 0x00, 0x5b, 0x00,
+	0x50, 0x00, 0x6a, 0x00, 0x51, 0x00, 0x6a, 0x00, 0x47, 0x00, 0x45, 0x00, 0x2e, 0x00, 0x2c, 0x00,
+	0x26, 0x00, 0x36, 0x00, 0x27, 0x00, 0x36, 0x00, 0x66, 0x00, 0x26, 0x00, 0x22, 0x00, 0x34, 0x00,
+	0x14, 0x00, 0x29, 0x00, 0x06, 0x00, 0x1b, 0x2b, 0x01, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x17,
+	0x22, 0x2e, 0x02, 0x23, 0x22, 0x06, 0x27, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x2e, 0x03,
+	0x23, 0x22, 0x0e, 0x02, 0x13, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x0e,
+	0x03, 0x01, 0x0e, 0x03, 0x07, 0x0e, 0x03, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x04, 0x37,
+	0x26, 0x26, 0x13, 0x32, 0x36, 0x37, 0x36, 0x36, 0x35, 0x34, 0x26, 0x27, 0x26, 0x26, 0x23, 0x22,
+	0x06, 0x07, 0x06, 0x06, 0x15, 0x14, 0x1e, 0x02, 0x17, 0x16, 0x17, 0x16, 0x13, 0x14, 0x06, 0x23,
+	0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x1e, 0x02, 0x07, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x25, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06,
+	0x07, 0x34, 0x26, 0x35, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32,
+	0x3e, 0x02, 0x07, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x26, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x26, 0x27,
+	0x0e, 0x03, 0x15, 0x14, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x17, 0x06, 0x07, 0x16, 0x15, 0x14, 0x0e,
+	0x02, 0x15, 0x14, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x34, 0x37, 0x06, 0x06, 0x23, 0x22,
+	0x22, 0x27, 0x16, 0x16, 0x15, 0x14, 0x0e, 0x02, 0x15, 0x14, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x01,
+	0x32, 0x16, 0x17, 0x36, 0x36, 0x33, 0x32, 0x16, 0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x07, 0x06,
+	0x06, 0x07, 0x16, 0x16, 0x15, 0x14, 0x14, 0x15, 0x3e, 0x03, 0x37, 0x36, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x0e, 0x02, 0x07, 0x0e, 0x03, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x17, 0x16, 0x16,
+	0x17, 0x16, 0x16, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x27, 0x26, 0x26, 0x27, 0x06, 0x06,
+	0x23, 0x22, 0x26, 0x27, 0x06, 0x07, 0x06, 0x06, 0x07, 0x06, 0x06, 0x23, 0x22, 0x26, 0x35, 0x26,
+	0x3e, 0x02, 0x37, 0x26, 0x26, 0x27, 0x06, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x1e, 0x02, 0x17, 0x3e, 0x03, 0x35, 0x34, 0x2e, 0x02, 0x35, 0x34, 0x36, 0x37, 0x2e, 0x05,
+	0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x36, 0x37, 0x26, 0x26, 0x35, 0x34, 0x3e,
+	0x02, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x36, 0x36, 0x01, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02,
+	0x37, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x16, 0x16, 0x17, 0x3e, 0x03, 0x37, 0x06, 0x26, 0x01,
+	0x3a, 0x0c, 0x16, 0x1f, 0x2f, 0x27, 0x13, 0x43, 0x48, 0x3d, 0x0c, 0x11, 0x3b, 0x40, 0x3e, 0x16,
+	0x1c, 0x49, 0xda, 0x0d, 0x30, 0x34, 0x2e, 0x0c, 0x30, 0x59, 0x59, 0x5a, 0x31, 0x29, 0x5e, 0x57,
+	0x45, 0x10, 0x10, 0x34, 0x3f, 0x44, 0xe4, 0x16, 0x1a, 0x0e, 0x04, 0x92, 0x15, 0x33, 0x30, 0x27,
+	0x0b, 0x37, 0x48, 0x36, 0x2c, 0x02, 0xcf, 0x04, 0x1c, 0x22, 0x22, 0x0b, 0x0b, 0x14, 0x12, 0x0a,
+	0x02, 0x0a, 0x12, 0x11, 0x0d, 0x27, 0x2c, 0x2f, 0x27, 0x1b, 0x04, 0x0c, 0x2e, 0x7e, 0x28, 0x55,
+	0x23, 0x27, 0x25, 0x29, 0x2a, 0x26, 0x56, 0x21, 0x2f, 0x53, 0x23, 0x23, 0x25, 0x04, 0x0a, 0x12,
+	0x0f, 0x1c, 0x30, 0x32, 0xd2, 0x2a, 0x1e, 0x24, 0x27, 0x26, 0x25, 0x0b, 0x19, 0x16, 0x0e, 0x6e,
+	0x08, 0x0d, 0x07, 0x0c, 0x09, 0x0d, 0x02, 0x97, 0x0c, 0x08, 0x08, 0x0e, 0x08, 0x0b, 0x07, 0x10,
+	0xa8, 0x01, 0x03, 0x19, 0x1a, 0x0e, 0x22, 0x1d, 0x15, 0x0a, 0x10, 0x15, 0x0b, 0x0d, 0x22, 0x1c,
+	0x14, 0x4b, 0x0d, 0x26, 0x22, 0x1a, 0x0b, 0x0d, 0x04, 0x11, 0x18, 0x21, 0x12, 0x15, 0x2c, 0x08,
+	0x09, 0x14, 0x11, 0x0b, 0x1c, 0x13, 0x0c, 0x17, 0x16, 0x17, 0x10, 0x21, 0x32, 0x03, 0x03, 0x04,
+	0x02, 0x08, 0x09, 0x13, 0x1b, 0x12, 0x09, 0x60, 0x14, 0x18, 0x11, 0x03, 0x08, 0x04, 0x02, 0x01,
+	0x02, 0x03, 0x03, 0x07, 0x0b, 0x14, 0x19, 0x0e, 0x04, 0xfe, 0x66, 0x5a, 0x93, 0x3b, 0x28, 0x52,
+	0x2b, 0x3e, 0x68, 0x17, 0x13, 0x1e, 0x16, 0x11, 0x0a, 0x50, 0x42, 0x09, 0x09, 0x05, 0x18, 0x1b,
+	0x1a, 0x07, 0x0b, 0x1c, 0x18, 0x13, 0x13, 0x1b, 0x32, 0x4b, 0x2f, 0x0a, 0x2c, 0x3b, 0x46, 0x24,
+	0x0e, 0x1c, 0x0f, 0x0b, 0x12, 0x06, 0x07, 0x0d, 0x07, 0x0e, 0x12, 0x11, 0x19, 0x1e, 0x0c, 0x19,
+	0x34, 0x17, 0x17, 0x1d, 0x0b, 0x49, 0x9e, 0x54, 0x2c, 0x4b, 0x22, 0x19, 0x0e, 0x07, 0x10, 0x0a,
+	0x14, 0x32, 0x1b, 0x1d, 0x2a, 0x02, 0x17, 0x1d, 0x1c, 0x05, 0x3c, 0x3b, 0x04, 0x0a, 0x25, 0x17,
+	0x18, 0x34, 0x2a, 0x1b, 0x2d, 0x21, 0x17, 0x24, 0x22, 0x23, 0x15, 0x06, 0x10, 0x0d, 0x0a, 0x1f,
+	0x25, 0x1f, 0x2f, 0x35, 0x12, 0x28, 0x28, 0x25, 0x1c, 0x12, 0x16, 0x23, 0x2d, 0x18, 0x16, 0x33,
+	0x32, 0x2f, 0x13, 0x44, 0x57, 0x1d, 0x26, 0x16, 0x1f, 0x21, 0x0c, 0x18, 0x31, 0x34, 0x3e, 0x24,
+	0x0f, 0x1f, 0x02, 0x6b, 0x0f, 0x1d, 0x17, 0x0e, 0x08, 0x11, 0x1a, 0x13, 0x13, 0x46, 0x33, 0x20,
+	0x37, 0x19, 0x3c, 0x49, 0x16, 0x12, 0x25, 0x20, 0x1a, 0x05, 0x04, 0x02, 0x02, 0x8e, 0x01, 0x0f,
+	0x11, 0x0e, 0x0d, 0x11, 0x12, 0x05, 0x09, 0x08, 0x07, 0x08, 0x96, 0x0a, 0x0f, 0x0a, 0x05, 0x07,
+	0x0e, 0x11, 0x0a, 0x07, 0x09, 0x06, 0x02, 0x02, 0x04, 0x06, 0x01, 0x2e, 0x0d, 0x14, 0x16, 0x08,
+	0x56, 0x06, 0x0c, 0x0d, 0x06, 0x02, 0x23, 0x29, 0x22, 0xfe, 0x43, 0x0a, 0x1f, 0x21, 0x20, 0x0b,
+	0x0c, 0x15, 0x17, 0x16, 0x0c, 0x04, 0x0c, 0x0d, 0x0a, 0x13, 0x1d, 0x26, 0x22, 0x1e, 0x07, 0x22,
+	0x33, 0x01, 0x2e, 0x1f, 0x1e, 0x23, 0x5a, 0x37, 0x39, 0x5e, 0x20, 0x1c, 0x12, 0x24, 0x24, 0x25,
+	0x5b, 0x2d, 0x0c, 0x22, 0x26, 0x29, 0x14, 0x24, 0x16, 0x16, 0x01, 0x1a, 0x1c, 0x27, 0x24, 0x1b,
+	0x1c, 0x2b, 0x07, 0x10, 0x19, 0x25, 0x0e, 0x08, 0x05, 0x0e, 0x0a, 0x09, 0x16, 0x8a, 0x06, 0x08,
+	0x0a, 0x08, 0x04, 0x0a, 0x08, 0x55, 0x02, 0x03, 0x02, 0x11, 0x14, 0x04, 0x0c, 0x15, 0x10, 0x0e,
+	0x11, 0x09, 0x04, 0x05, 0x0c, 0x14, 0x8f, 0x05, 0x0e, 0x1c, 0x18, 0x0f, 0x22, 0x0a, 0x07, 0x11,
+	0x0f, 0x0a, 0x0e, 0x0b, 0x06, 0x14, 0x18, 0x1b, 0x0e, 0x13, 0x14, 0x07, 0x0a, 0x07, 0x1f, 0x16,
+	0x04, 0x13, 0x0e, 0x0c, 0x11, 0x0d, 0x0a, 0x06, 0x08, 0x0c, 0x07, 0x16, 0x26, 0x1f, 0x09, 0x13,
+	0x23, 0x0c, 0x0c, 0x01, 0x0c, 0x11, 0x07, 0x0d, 0x10, 0x0e, 0x0d, 0x09, 0x05, 0x0c, 0x10, 0x21,
+	0x35, 0x01, 0xfd, 0x35, 0x2f, 0x15, 0x11, 0x36, 0x34, 0x07, 0x1f, 0x13, 0x13, 0x1f, 0x0b, 0x4d,
+	0x61, 0x23, 0x31, 0x68, 0x36, 0x10, 0x1d, 0x0e, 0x02, 0x07, 0x0f, 0x18, 0x12, 0x20, 0x2a, 0x1b,
+	0x26, 0x1c, 0x42, 0x3a, 0x2a, 0x04, 0x67, 0xa8, 0x89, 0x6a, 0x2a, 0x0e, 0x1c, 0x0b, 0x11, 0x19,
+	0x09, 0x0a, 0x12, 0x0a, 0x14, 0x2a, 0x14, 0x14, 0x1a, 0x11, 0x07, 0x29, 0x1a, 0x1b, 0x29, 0x10,
+	0x1d, 0x1d, 0x0a, 0x09, 0x21, 0x10, 0x07, 0x10, 0x08, 0x12, 0x17, 0x1d, 0x22, 0x10, 0x27, 0x27,
+	0x21, 0x0a, 0x33, 0x83, 0x46, 0x02, 0x02, 0x06, 0x0f, 0x1d, 0x17, 0x1d, 0x21, 0x03, 0x04, 0x03,
+	0x01, 0x1e, 0x3e, 0x3f, 0x3f, 0x1f, 0x2d, 0x5c, 0x67, 0x72, 0x42, 0x40, 0x8d, 0x41, 0x06, 0x05,
+	0x05, 0x09, 0x11, 0x1d, 0x17, 0x1b, 0x25, 0x18, 0x0a, 0x0d, 0x15, 0x1b, 0x0f, 0x2b, 0x1c, 0x0d,
+	0x22, 0x18, 0x17, 0x1d, 0x10, 0x07, 0x0b, 0x19, 0x27, 0x1c, 0x01, 0x01, 0xfe, 0xd1, 0x07, 0x0f,
+	0x16, 0x0f, 0x0b, 0x19, 0x15, 0x10, 0x03, 0x1c, 0x20, 0x12, 0x0d, 0x3c, 0x96, 0x62, 0x0a, 0x19,
+	0x22, 0x2c, 0x1f, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xba, 0x00, 0x00, 0x04, 0xb2,
+	0x06, 0x44, 0x00, 0x16, 0x00, 0x1a, 0x00, 0x82, 0x40, 0x0a, 0x09, 0x01, 0x08, 0x02, 0x0a, 0x01,
+	0x09, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x40, 0x4d, 0x0b, 0x01, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d,
+	0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x08, 0x0b, 0x01, 0x09, 0x01, 0x08, 0x09, 0x67,
+	0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x18, 0x17, 0x17, 0x00, 0x00, 0x17, 0x1a, 0x17, 0x1a, 0x19, 0x18, 0x00, 0x16, 0x00, 0x16, 0x11,
+	0x11, 0x13, 0x23, 0x22, 0x11, 0x11, 0x0c, 0x09, 0x1d, 0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x37,
+	0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x06, 0x07, 0x07, 0x21, 0x03, 0x23, 0x13, 0x21,
+	0x03, 0x01, 0x37, 0x33, 0x07, 0xba, 0xbb, 0x90, 0x1e, 0x90, 0x19, 0x4e, 0x01, 0x37, 0x3f, 0x4c,
+	0x1b, 0x47, 0x34, 0x4a, 0x4d, 0x18, 0x1e, 0x02, 0x0b, 0xd9, 0xc5, 0xbb, 0xfe, 0xba, 0xbb, 0x02,
+	0x46, 0x27, 0xc5, 0x27, 0x03, 0xaa, 0x94, 0x82, 0x01, 0x84, 0x1a, 0x87, 0x0d, 0x61, 0x7a, 0x97,
+	0xfb, 0xc2, 0x03, 0xaa, 0xfc, 0x56, 0x05, 0x03, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x01, 0x00, 0xba,
+	0xff, 0xe7, 0x04, 0xc6, 0x06, 0x44, 0x00, 0x25, 0x00, 0xae, 0xb5, 0x0f, 0x01, 0x03, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x26, 0x00, 0x00, 0x04, 0x01, 0x04, 0x00, 0x01, 0x80,
+	0x00, 0x02, 0x02, 0x08, 0x61, 0x09, 0x01, 0x08, 0x08, 0x40, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x03,
+	0x5f, 0x07, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x00, 0x04, 0x05, 0x04, 0x00, 0x05, 0x80, 0x00, 0x09,
+	0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x08, 0x61, 0x00, 0x08, 0x08, 0x40, 0x4d, 0x06, 0x01, 0x04,
+	0x04, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x01,
+	0x01, 0x42, 0x01, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x00, 0x04, 0x05, 0x04, 0x00, 0x05, 0x80, 0x00,
+	0x09, 0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x08, 0x61, 0x00, 0x08, 0x08, 0x40, 0x4d, 0x06, 0x01,
+	0x04, 0x04, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00,
+	0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x25, 0x24, 0x22, 0x11, 0x11, 0x11, 0x11,
+	0x13, 0x28, 0x22, 0x14, 0x0a, 0x09, 0x1f, 0x2b, 0x01, 0x06, 0x1e, 0x02, 0x37, 0x07, 0x06, 0x23,
+	0x22, 0x2e, 0x02, 0x37, 0x13, 0x37, 0x27, 0x26, 0x23, 0x22, 0x06, 0x07, 0x07, 0x33, 0x07, 0x23,
+	0x03, 0x23, 0x13, 0x23, 0x37, 0x33, 0x37, 0x12, 0x21, 0x32, 0x17, 0x33, 0x03, 0xce, 0x0c, 0x04,
+	0x23, 0x3c, 0x2a, 0x1c, 0x17, 0x26, 0x41, 0x6d, 0x42, 0x14, 0x0f, 0xdf, 0x02, 0x27, 0x5b, 0x33,
+	0x50, 0x4e, 0x19, 0x1e, 0xb2, 0x1e, 0xb2, 0xbb, 0xc5, 0xbb, 0x90, 0x1e, 0x90, 0x19, 0x4e, 0x01,
+	0x31, 0x37, 0x9e, 0xc6, 0x01, 0x50, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79,
+	0x4d, 0x04, 0x59, 0x12, 0x08, 0x11, 0x5e, 0x7d, 0x97, 0x94, 0xfc, 0x56, 0x03, 0xaa, 0x94, 0x82,
+	0x01, 0x84, 0x19, 0x00, 0x00, 0x03, 0x00, 0x99, 0xff, 0x00, 0x08, 0x99, 0x07, 0x00, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x1f, 0x00, 0x39, 0x40, 0x36, 0x16, 0x02, 0x02, 0x02, 0x04, 0x01, 0x4c, 0x01,
+	0x01, 0x03, 0x4a, 0x03, 0x01, 0x00, 0x49, 0x00, 0x03, 0x04, 0x03, 0x85, 0x00, 0x04, 0x02, 0x04,
+	0x85, 0x00, 0x00, 0x01, 0x00, 0x86, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x00, 0x01, 0x02, 0x01, 0x4f, 0x23, 0x29, 0x11, 0x11, 0x14, 0x05, 0x06, 0x1b, 0x2b, 0x13,
+	0x09, 0x02, 0x03, 0x21, 0x37, 0x21, 0x37, 0x21, 0x37, 0x36, 0x36, 0x37, 0x37, 0x36, 0x37, 0x36,
+	0x26, 0x23, 0x22, 0x07, 0x07, 0x36, 0x33, 0x32, 0x07, 0x06, 0x07, 0x07, 0x06, 0x07, 0x99, 0x04,
+	0xcd, 0x03, 0x33, 0xfb, 0x33, 0x54, 0x01, 0x10, 0x2d, 0xfe, 0xf0, 0x1f, 0x01, 0x10, 0x10, 0x19,
+	0x32, 0x56, 0x49, 0xb3, 0x1a, 0x1d, 0xda, 0xd9, 0xae, 0xc2, 0x29, 0xc3, 0x8a, 0xd6, 0x22, 0x17,
+	0xa1, 0x4e, 0x78, 0x26, 0x03, 0x00, 0x04, 0x00, 0xfc, 0x00, 0xfc, 0x00, 0x01, 0x00, 0xe2, 0x9e,
+	0x4e, 0x7f, 0x59, 0x44, 0x3b, 0x8f, 0x84, 0x90, 0xa7, 0x38, 0xcf, 0x52, 0xab, 0x72, 0x92, 0x47,
+	0x6c, 0xbe, 0x00, 0x00, 0x00, 0x03, 0x00, 0x53, 0xff, 0xdb, 0x05, 0x56, 0x05, 0xed, 0x00, 0x07,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x42, 0x40, 0x3f, 0x00, 0x01, 0x00, 0x03, 0x04, 0x01, 0x03, 0x69,
+	0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x07, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59,
+	0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x02, 0x00, 0x51, 0x10, 0x10, 0x09, 0x08,
+	0x01, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0d, 0x0b, 0x08, 0x0f, 0x09, 0x0f, 0x05, 0x03,
+	0x00, 0x07, 0x01, 0x07, 0x09, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02,
+	0x25, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x01, 0x37, 0x33, 0x07, 0x02, 0x3c, 0xfe, 0x17,
+	0x9c, 0x9b, 0x01, 0xe9, 0x01, 0xe3, 0x95, 0x9c, 0xfe, 0x35, 0x01, 0x1d, 0x7e, 0x7c, 0xfe, 0xe4,
+	0xfe, 0xe4, 0x7d, 0x7e, 0x01, 0x28, 0x27, 0xc6, 0x27, 0x25, 0x03, 0x0a, 0x03, 0x08, 0xfc, 0xf8,
+	0xfc, 0xf6, 0x94, 0x02, 0x76, 0x02, 0x74, 0xfd, 0x8c, 0xfd, 0x8a, 0x02, 0x2b, 0xc5, 0xc5, 0x00,
+	0x00, 0x02, 0x00, 0x53, 0xff, 0xdb, 0x05, 0x56, 0x05, 0xed, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x31,
+	0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02,
+	0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x09, 0x08, 0x01,
+	0x00, 0x0d, 0x0b, 0x08, 0x0f, 0x09, 0x0f, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x06, 0x06, 0x16,
+	0x2b, 0x05, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03, 0x02, 0x25, 0x20, 0x13, 0x12, 0x21, 0x20, 0x03,
+	0x02, 0x02, 0x3c, 0xfe, 0x17, 0x9c, 0x9b, 0x01, 0xe9, 0x01, 0xe3, 0x95, 0x9c, 0xfe, 0x35, 0x01,
+	0x1d, 0x7e, 0x7c, 0xfe, 0xe4, 0xfe, 0xe4, 0x7d, 0x7e, 0x25, 0x03, 0x0a, 0x03, 0x08, 0xfc, 0xf8,
+	0xfc, 0xf6, 0x94, 0x02, 0x76, 0x02, 0x74, 0xfd, 0x8c, 0xfd, 0x8a, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x02, 0x02, 0x8f, 0xcd, 0xdd, 0x21, 0x01, 0x5f, 0x0f, 0x3c, 0xf5, 0x00, 0x0d, 0x08, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0xd4, 0x49, 0x69, 0x00, 0x00, 0x00, 0x00, 0x00, 0xde, 0xcc, 0x9b, 0x66,
+	0xfe, 0x4c, 0xfd, 0xe1, 0x08, 0xe4, 0x08, 0xf3, 0x00, 0x02, 0x00, 0x09, 0x00, 0x02, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x07, 0x8f, 0xfe, 0x50, 0x00, 0x00, 0x08, 0xd6,
+	0xfe, 0x4c, 0xfd, 0x23, 0x08, 0xe4, 0x08, 0x00, 0x01, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xc7, 0x06, 0x16, 0x01, 0x0b, 0x00, 0x00, 0x00, 0x00,
+	0x02, 0x39, 0x00, 0x00, 0x02, 0x39, 0x00, 0x00, 0x02, 0x4f, 0x00, 0xd3, 0x02, 0xed, 0x01, 0x53,
+	0x04, 0x89, 0x00, 0x7c, 0x04, 0x89, 0x00, 0x96, 0x07, 0x33, 0x01, 0x05, 0x05, 0x6c, 0x00, 0x71,
+	0x01, 0x9d, 0x01, 0x53, 0x02, 0xc0, 0x00, 0xd5, 0x02, 0xc0, 0x00, 0x21, 0x04, 0xc2, 0x01, 0x28,
+	0x04, 0xc2, 0x00, 0xda, 0x02, 0x9e, 0x00, 0x8d, 0x04, 0xc2, 0x00, 0xda, 0x02, 0x9e, 0x00, 0xd3,
+	0x02, 0x4f, 0xff, 0xf0, 0x04, 0x89, 0x00, 0x53, 0x04, 0x89, 0x00, 0xdd, 0x04, 0x89, 0x00, 0x71,
+	0x04, 0x89, 0x00, 0xa6, 0x04, 0x89, 0x00, 0x7d, 0x04, 0x89, 0x00, 0xac, 0x04, 0x89, 0x00, 0xa5,
+	0x04, 0x89, 0x00, 0xf8, 0x04, 0x89, 0x00, 0x92, 0x04, 0x89, 0x00, 0xb1, 0x02, 0x89, 0x00, 0xd3,
+	0x02, 0x89, 0x00, 0x8d, 0x04, 0xc2, 0x00, 0xe9, 0x04, 0xc2, 0x00, 0x63, 0x04, 0xc2, 0x00, 0x86,
+	0x04, 0x89, 0x01, 0x97, 0x08, 0x35, 0x01, 0x45, 0x05, 0x6c, 0x00, 0x1e, 0x05, 0x6c, 0x00, 0xb0,
+	0x05, 0xdd, 0x00, 0xc6, 0x05, 0xdd, 0x00, 0xb0, 0x05, 0x6c, 0x00, 0xc9, 0x04, 0xf9, 0x00, 0xca,
+	0x06, 0x4f, 0x00, 0x60, 0x05, 0xdd, 0x00, 0xb0, 0x03, 0x47, 0x00, 0x87, 0x04, 0x0d, 0xff, 0xf1,
+	0x05, 0x6c, 0x00, 0xca, 0x04, 0x89, 0x00, 0xb0, 0x06, 0xc0, 0x00, 0xb0, 0x05, 0xdd, 0x00, 0xb0,
+	0x06, 0x4f, 0x00, 0xb5, 0x05, 0x6c, 0x00, 0xb2, 0x06, 0x4f, 0x00, 0xb7, 0x05, 0xdd, 0x00, 0xb0,
+	0x05, 0x6c, 0x00, 0x8d, 0x04, 0xf9, 0x01, 0x27, 0x05, 0xdd, 0x00, 0xe1, 0x05, 0x6c, 0x01, 0x56,
+	0x07, 0xa3, 0x01, 0x4b, 0x05, 0x6c, 0x00, 0x27, 0x05, 0x6c, 0x01, 0x50, 0x04, 0xf9, 0x00, 0x70,
+	0x02, 0x4f, 0x00, 0x3d, 0x02, 0x4f, 0x01, 0x28, 0x02, 0x4f, 0x00, 0x0f, 0x03, 0xd6, 0x00, 0xdd,
+	0x04, 0x89, 0xff, 0xee, 0x02, 0xc0, 0x01, 0xb5, 0x04, 0x89, 0x00, 0x96, 0x04, 0x89, 0x00, 0xa2,
+	0x04, 0x16, 0x00, 0x9a, 0x04, 0x89, 0x00, 0x96, 0x04, 0x89, 0x00, 0x9b, 0x02, 0x4f, 0x00, 0xba,
+	0x04, 0x89, 0x00, 0x26, 0x04, 0x89, 0x00, 0xa5, 0x02, 0x0f, 0x00, 0xa5, 0x02, 0x1d, 0xff, 0x68,
+	0x04, 0x16, 0x00, 0xa5, 0x02, 0x3a, 0x00, 0xd1, 0x06, 0xc0, 0x00, 0xa5, 0x04, 0x89, 0x00, 0xa5,
+	0x04, 0x89, 0x00, 0x99, 0x04, 0x89, 0x00, 0x56, 0x04, 0x89, 0x00, 0x96, 0x02, 0xc0, 0x00, 0xa5,
+	0x04, 0x16, 0x00, 0x86, 0x02, 0x59, 0x00, 0x9e, 0x04, 0x89, 0x00, 0x94, 0x04, 0x16, 0x00, 0xf7,
+	0x05, 0xdd, 0x00, 0xef, 0x04, 0x16, 0x00, 0x27, 0x04, 0x16, 0x00, 0xa5, 0x04, 0x16, 0x00, 0x55,
+	0x02, 0xc2, 0x00, 0x95, 0x02, 0x2a, 0x00, 0x8a, 0x02, 0xc2, 0x00, 0x43, 0x04, 0xc2, 0x00, 0xcb,
+	0x02, 0x39, 0x00, 0x00, 0x02, 0xc0, 0x00, 0xae, 0x04, 0x89, 0x01, 0x1d, 0x04, 0x89, 0x00, 0x84,
+	0x04, 0x89, 0x00, 0xd0, 0x04, 0x89, 0x00, 0xec, 0x02, 0x2a, 0x00, 0x8f, 0x04, 0x89, 0x00, 0x58,
+	0x02, 0xc0, 0x01, 0x44, 0x05, 0xfb, 0x00, 0x71, 0x03, 0x0c, 0x01, 0x1a, 0x04, 0x89, 0x00, 0xea,
+	0x04, 0xc2, 0x00, 0xf4, 0x02, 0xc0, 0x00, 0xca, 0x05, 0xfb, 0x00, 0x71, 0x04, 0x89, 0x01, 0x90,
+	0x03, 0x49, 0x01, 0x58, 0x04, 0xc2, 0x00, 0x73, 0x03, 0xc1, 0x01, 0x08, 0x03, 0xc1, 0x01, 0x30,
+	0x02, 0xc0, 0x01, 0x76, 0x04, 0x89, 0x00, 0x51, 0x04, 0x62, 0x01, 0x31, 0x02, 0x39, 0x01, 0x48,
+	0x02, 0xc0, 0x00, 0x60, 0x03, 0xc1, 0x01, 0x59, 0x03, 0x02, 0x01, 0x1f, 0x04, 0x89, 0x00, 0xb5,
+	0x06, 0xc2, 0x00, 0xc2, 0x06, 0xc2, 0x00, 0x8f, 0x06, 0xc2, 0x00, 0xf1, 0x04, 0xf9, 0x00, 0x6d,
+	0x05, 0x6c, 0x00, 0x1e, 0x05, 0x6c, 0x00, 0x1e, 0x05, 0x6c, 0x00, 0x1e, 0x05, 0x6c, 0x00, 0x1e,
+	0x05, 0x6c, 0x00, 0x1e, 0x05, 0x56, 0x00, 0x13, 0x08, 0x16, 0x00, 0x1e, 0x05, 0xdd, 0x00, 0xc6,
+	0x05, 0x6c, 0x00, 0xc9, 0x05, 0x6c, 0x00, 0xc9, 0x05, 0x6c, 0x00, 0xc9, 0x05, 0x6c, 0x00, 0xc9,
+	0x03, 0x47, 0x00, 0x87, 0x03, 0x47, 0x00, 0x87, 0x03, 0x47, 0x00, 0x87, 0x03, 0x47, 0x00, 0x87,
+	0x05, 0xe7, 0x00, 0xa1, 0x05, 0xdd, 0x00, 0xb0, 0x06, 0x4f, 0x00, 0xb5, 0x06, 0x4f, 0x00, 0xb5,
+	0x06, 0x4f, 0x00, 0xb5, 0x06, 0x4f, 0x00, 0xb5, 0x06, 0x4f, 0x00, 0xb5, 0x04, 0xac, 0x00, 0x95,
+	0x06, 0x4f, 0x00, 0x6b, 0x05, 0xdd, 0x00, 0xe1, 0x05, 0xdd, 0x00, 0xe1, 0x05, 0xdd, 0x00, 0xe1,
+	0x05, 0xdd, 0x00, 0xe1, 0x05, 0x6c, 0x01, 0x50, 0x05, 0x6c, 0x00, 0xb2, 0x04, 0xf9, 0x00, 0x8c,
+	0x04, 0x89, 0x00, 0x96, 0x04, 0x89, 0x00, 0x96, 0x04, 0x89, 0x00, 0x96, 0x04, 0x89, 0x00, 0x96,
+	0x04, 0x89, 0x00, 0x96, 0x04, 0x89, 0x00, 0x96, 0x07, 0x1d, 0x00, 0x7a, 0x04, 0x16, 0x00, 0x9a,
+	0x04, 0x89, 0x00, 0x9b, 0x04, 0x89, 0x00, 0x9b, 0x04, 0x89, 0x00, 0x9b, 0x04, 0x89, 0x00, 0x9b,
+	0x02, 0x0f, 0x00, 0xa5, 0x02, 0x0f, 0x00, 0xa5, 0x02, 0x0f, 0x00, 0xa5, 0x02, 0x0f, 0x00, 0xa5,
+	0x04, 0x89, 0x00, 0x98, 0x04, 0x89, 0x00, 0xa5, 0x04, 0x89, 0x00, 0x99, 0x04, 0x89, 0x00, 0x99,
+	0x04, 0x89, 0x00, 0x99, 0x04, 0x89, 0x00, 0x99, 0x04, 0x89, 0x00, 0x99, 0x04, 0xc2, 0x00, 0xda,
+	0x04, 0xf9, 0x00, 0x95, 0x04, 0x89, 0x00, 0x94, 0x04, 0x89, 0x00, 0x94, 0x04, 0x89, 0x00, 0x94,
+	0x04, 0x89, 0x00, 0x94, 0x04, 0x16, 0x00, 0xa5, 0x04, 0x89, 0x00, 0x56, 0x04, 0x16, 0x00, 0xa5,
+	0x05, 0x71, 0x00, 0x20, 0x04, 0x97, 0x00, 0x96, 0x05, 0x71, 0x00, 0x20, 0x04, 0x97, 0x00, 0x96,
+	0x05, 0x6c, 0x00, 0x1e, 0x04, 0x89, 0x00, 0x96, 0x05, 0xdd, 0x00, 0xc6, 0x04, 0x16, 0x00, 0x9a,
+	0x05, 0xdd, 0x00, 0xc6, 0x04, 0x16, 0x00, 0x9a, 0x05, 0xdd, 0x00, 0xc6, 0x04, 0x16, 0x00, 0x9a,
+	0x05, 0xdd, 0x00, 0xc6, 0x04, 0x16, 0x00, 0x9a, 0x05, 0xdd, 0x00, 0xb0, 0x05, 0x4a, 0x00, 0x96,
+	0x05, 0xe7, 0x00, 0xa1, 0x04, 0x89, 0x00, 0x96, 0x05, 0x6c, 0x00, 0xc9, 0x04, 0x89, 0x00, 0x9b,
+	0x05, 0x6c, 0x00, 0xc9, 0x04, 0x89, 0x00, 0x9b, 0x05, 0x6c, 0x00, 0xc9, 0x04, 0x89, 0x00, 0x9b,
+	0x05, 0x6c, 0x00, 0xc9, 0x04, 0x89, 0x00, 0x9b, 0x05, 0x6c, 0x00, 0xca, 0x04, 0x89, 0x00, 0x9b,
+	0x06, 0x4f, 0x00, 0x60, 0x04, 0x89, 0x00, 0x26, 0x06, 0x4f, 0x00, 0x60, 0x04, 0x89, 0x00, 0x26,
+	0x06, 0x4f, 0x00, 0x60, 0x04, 0x89, 0x00, 0x26, 0x06, 0x4f, 0x00, 0x60, 0x04, 0x89, 0x00, 0x26,
+	0x05, 0xdd, 0x00, 0xb0, 0x04, 0x89, 0x00, 0xa5, 0x05, 0xdd, 0x00, 0xb0, 0x04, 0x89, 0x00, 0xa5,
+	0x03, 0x47, 0x00, 0x87, 0x02, 0x0f, 0x00, 0xa5, 0x03, 0x47, 0x00, 0x87, 0x02, 0x0f, 0x00, 0xa5,
+	0x03, 0x47, 0x00, 0x87, 0x02, 0x0f, 0x00, 0xa5, 0x03, 0x47, 0x00, 0x87, 0x02, 0x0f, 0x00, 0x17,
+	0x03, 0x47, 0x00, 0x87, 0x02, 0x0f, 0x00, 0xa5, 0x06, 0x84, 0x00, 0x87, 0x03, 0xcf, 0x00, 0xa5,
+	0x04, 0x16, 0x00, 0x0d, 0x02, 0x1d, 0xff, 0x68, 0x05, 0x6c, 0x00, 0xca, 0x04, 0x16, 0x00, 0xa5,
+	0x04, 0x16, 0x00, 0xa5, 0x04, 0x89, 0x00, 0xb0, 0x02, 0x3a, 0x00, 0xd1, 0x04, 0x89, 0x00, 0xb0,
+	0x02, 0x3a, 0x00, 0x61, 0x04, 0x89, 0x00, 0xb0, 0x02, 0xb8, 0x00, 0xd1, 0x04, 0x89, 0x00, 0xb0,
+	0x02, 0xd2, 0x00, 0xd1, 0x04, 0x89, 0x00, 0x96, 0x02, 0x66, 0x00, 0x91, 0x05, 0xdd, 0x00, 0xb0,
+	0x04, 0x89, 0x00, 0xa5, 0x05, 0xdd, 0x00, 0xb0, 0x04, 0x89, 0x00, 0xa5, 0x05, 0xdd, 0x00, 0xb0,
+	0x04, 0x89, 0x00, 0xa5, 0x04, 0xeb, 0x00, 0xec, 0x05, 0xdd, 0x00, 0xb0, 0x04, 0x89, 0x00, 0xa5,
+	0x06, 0x4f, 0x00, 0xb5, 0x04, 0x89, 0x00, 0x99, 0x06, 0x4f, 0x00, 0xb5, 0x04, 0x89, 0x00, 0x99,
+	0x06, 0x4f, 0x00, 0xb5, 0x04, 0x89, 0x00, 0x99, 0x08, 0x16, 0x00, 0xb5, 0x07, 0xa3, 0x00, 0x99,
+	0x05, 0xdd, 0x00, 0xb0, 0x02, 0xc0, 0x00, 0xa5, 0x05, 0xdd, 0x00, 0xb0, 0x02, 0xc0, 0x00, 0x51,
+	0x05, 0xdd, 0x00, 0xb0, 0x02, 0xc0, 0x00, 0xa6, 0x05, 0x6c, 0x00, 0x8d, 0x04, 0x16, 0x00, 0x86,
+	0x05, 0x6c, 0x00, 0x8d, 0x04, 0x16, 0x00, 0x86, 0x05, 0x6c, 0x00, 0x8d, 0x04, 0x16, 0x00, 0x86,
+	0x05, 0x6c, 0x00, 0x8d, 0x04, 0x16, 0x00, 0x86, 0x04, 0xf9, 0x01, 0x27, 0x02, 0x4f, 0x00, 0x6f,
+	0x04, 0xf9, 0x01, 0x27, 0x03, 0x16, 0x00, 0x9e, 0x04, 0xf9, 0x01, 0x27, 0x02, 0x4f, 0x00, 0x99,
+	0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94,
+	0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94,
+	0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94,
+	0x07, 0xa3, 0x01, 0x4b, 0x05, 0xdd, 0x00, 0xef, 0x05, 0x6c, 0x01, 0x50, 0x04, 0x16, 0x00, 0xa5,
+	0x05, 0x6c, 0x01, 0x50, 0x04, 0xf9, 0x00, 0x70, 0x04, 0x16, 0x00, 0x55, 0x04, 0xf9, 0x00, 0x70,
+	0x04, 0x16, 0x00, 0x55, 0x04, 0xf9, 0x00, 0x70, 0x04, 0x16, 0x00, 0x55, 0x01, 0xdd, 0x00, 0x9b,
+	0x04, 0x89, 0x00, 0x01, 0x05, 0x6c, 0x00, 0x1e, 0x04, 0x89, 0x00, 0x96, 0x03, 0x47, 0x00, 0x87,
+	0x02, 0x0f, 0x00, 0xa5, 0x06, 0x4f, 0x00, 0xb5, 0x04, 0x89, 0x00, 0x99, 0x05, 0xdd, 0x00, 0xe1,
+	0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1,
+	0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1, 0x04, 0x89, 0x00, 0x94, 0x05, 0xdd, 0x00, 0xe1,
+	0x04, 0x89, 0x00, 0x94, 0x05, 0x6c, 0x00, 0x1e, 0x04, 0x89, 0x00, 0x96, 0x08, 0x16, 0x00, 0x1e,
+	0x07, 0x33, 0x00, 0x7a, 0x06, 0x4f, 0x00, 0x6b, 0x04, 0xf9, 0x00, 0x95, 0x05, 0x6c, 0x00, 0x8d,
+	0x04, 0x16, 0x00, 0x86, 0x04, 0xf9, 0x01, 0x27, 0x02, 0x4f, 0x00, 0x67, 0x02, 0xc0, 0x01, 0x02,
+	0x02, 0xc0, 0x01, 0x42, 0x02, 0xc0, 0x01, 0x15, 0x02, 0xc0, 0x01, 0x46, 0x02, 0xc0, 0x01, 0xf3,
+	0x02, 0xc0, 0x01, 0x97, 0x02, 0xc0, 0x00, 0x6b, 0x02, 0xc0, 0x01, 0x09, 0x02, 0xc0, 0x00, 0xd8,
+	0x02, 0x89, 0x00, 0x8d, 0x02, 0xc0, 0x01, 0xbf, 0x02, 0xc0, 0x00, 0xeb, 0x05, 0x6d, 0x00, 0x21,
+	0x02, 0x4f, 0x01, 0x53, 0x06, 0x5c, 0x00, 0xf2, 0x06, 0xca, 0x00, 0xf2, 0x03, 0x43, 0xff, 0xc7,
+	0x06, 0x48, 0x00, 0x75, 0x06, 0xee, 0x00, 0xf3, 0x06, 0x1b, 0x00, 0x85, 0x03, 0x08, 0x00, 0xf0,
+	0x05, 0x6c, 0x00, 0x1e, 0x05, 0x6c, 0x00, 0xb0, 0x04, 0x7e, 0x00, 0xbf, 0x05, 0x6e, 0x00, 0x2f,
+	0x05, 0x6c, 0x00, 0xc9, 0x04, 0xf9, 0x00, 0x70, 0x05, 0xdd, 0x00, 0xb0, 0x06, 0x4f, 0x00, 0xb5,
+	0x03, 0x47, 0x00, 0x87, 0x05, 0x6c, 0x00, 0xca, 0x05, 0x6e, 0x00, 0x20, 0x06, 0xc0, 0x00, 0xb0,
+	0x05, 0xdd, 0x00, 0xb0, 0x05, 0x49, 0x00, 0x5b, 0x06, 0x4f, 0x00, 0xb5, 0x05, 0xdd, 0x00, 0xb0,
+	0x05, 0x6c, 0x00, 0xb2, 0x04, 0xc9, 0x00, 0x7b, 0x04, 0xf9, 0x01, 0x27, 0x05, 0x6c, 0x01, 0x49,
+	0x07, 0x1c, 0x01, 0x1d, 0x05, 0x6c, 0x00, 0x27, 0x06, 0xc5, 0x01, 0x91, 0x05, 0xb5, 0x00, 0x50,
+	0x03, 0x5b, 0x00, 0x87, 0x05, 0x6c, 0x01, 0x49, 0x04, 0xb6, 0x00, 0xac, 0x03, 0xa7, 0x00, 0x78,
+	0x04, 0x89, 0x00, 0xa4, 0x03, 0x08, 0x00, 0xf0, 0x04, 0x76, 0x00, 0xda, 0x04, 0xb6, 0x00, 0xac,
+	0x04, 0xb0, 0x00, 0x56, 0x04, 0x16, 0x00, 0xf1, 0x04, 0x8a, 0x00, 0xab, 0x03, 0xa7, 0x00, 0x78,
+	0x03, 0x9d, 0x00, 0xb5, 0x04, 0x89, 0x00, 0xa4, 0x04, 0x89, 0x00, 0xad, 0x03, 0x08, 0x00, 0xf0,
+	0x04, 0x16, 0x00, 0xa5, 0x04, 0x16, 0x00, 0x23, 0x04, 0xb2, 0x00, 0x56, 0x04, 0x16, 0x00, 0xe4,
+	0x03, 0xab, 0x00, 0x87, 0x04, 0x89, 0x00, 0x99, 0x05, 0x9b, 0x00, 0xe0, 0x04, 0xa3, 0x00, 0x3d,
+	0x03, 0xf1, 0x00, 0x8d, 0x05, 0x06, 0x00, 0x99, 0x03, 0x3f, 0x00, 0xcb, 0x04, 0x76, 0x00, 0xda,
+	0x05, 0x46, 0x00, 0xc0, 0x04, 0x49, 0xff, 0xc5, 0x05, 0xca, 0x00, 0xd1, 0x06, 0x55, 0x00, 0xc4,
+	0x03, 0x08, 0x00, 0xf0, 0x04, 0x76, 0x00, 0xda, 0x04, 0x89, 0x00, 0x99, 0x04, 0x76, 0x00, 0xda,
+	0x06, 0x55, 0x00, 0xc4, 0x05, 0x6c, 0x00, 0xc9, 0x05, 0x6d, 0x00, 0xc9, 0x07, 0x01, 0x01, 0x31,
+	0x04, 0x6b, 0x00, 0xbf, 0x05, 0xd6, 0x00, 0xad, 0x05, 0x6c, 0x00, 0x8d, 0x03, 0x47, 0x00, 0x87,
+	0x03, 0x47, 0x00, 0x87, 0x04, 0x16, 0x00, 0x2c, 0x08, 0x8b, 0x00, 0x23, 0x08, 0x2b, 0x00, 0xb0,
+	0x06, 0xeb, 0x01, 0x2e, 0x04, 0xbf, 0x00, 0xb0, 0x05, 0xd6, 0x00, 0xb5, 0x05, 0x2b, 0x00, 0x6d,
+	0x05, 0xd6, 0x00, 0xb0, 0x05, 0x6c, 0x00, 0x1e, 0x05, 0x56, 0x00, 0xb0, 0x05, 0x6c, 0x00, 0xb0,
+	0x04, 0x6b, 0x00, 0xbf, 0x05, 0x81, 0xff, 0xf9, 0x05, 0x6c, 0x00, 0xc9, 0x07, 0x79, 0x00, 0x88,
+	0x04, 0xeb, 0x00, 0x7d, 0x05, 0xd6, 0x00, 0xb5, 0x05, 0xd6, 0x00, 0xb5, 0x04, 0xbf, 0x00, 0xb0,
+	0x05, 0x56, 0x00, 0x1e, 0x06, 0xc0, 0x00, 0xb0, 0x05, 0xdd, 0x00, 0xb0, 0x06, 0x4f, 0x00, 0xb5,
+	0x05, 0xd6, 0x00, 0xb0, 0x05, 0x6c, 0x00, 0xb2, 0x05, 0xdd, 0x00, 0xc6, 0x04, 0xf9, 0x01, 0x27,
+	0x05, 0x2b, 0x00, 0x6d, 0x06, 0x2b, 0x00, 0xb6, 0x05, 0x6c, 0x00, 0x27, 0x06, 0x01, 0x00, 0xb0,
+	0x05, 0x6b, 0x00, 0xf9, 0x07, 0x6b, 0x00, 0xb5, 0x07, 0x96, 0x00, 0xb5, 0x06, 0x6b, 0x01, 0x31,
+	0x07, 0x2b, 0x00, 0xb0, 0x05, 0x56, 0x00, 0xb1, 0x05, 0xd6, 0x00, 0xce, 0x08, 0x2b, 0x00, 0xb1,
+	0x05, 0xdd, 0x00, 0x6e, 0x04, 0x89, 0x00, 0x96, 0x04, 0xab, 0x00, 0xa5, 0x04, 0x56, 0x00, 0xa5,
+	0x03, 0x01, 0x00, 0x97, 0x04, 0xc1, 0xff, 0xef, 0x04, 0x89, 0x00, 0x9b, 0x05, 0x70, 0x00, 0x10,
+	0x03, 0xc1, 0x00, 0x65, 0x04, 0x8e, 0x00, 0x9c, 0x04, 0x8e, 0x00, 0x9c, 0x03, 0x96, 0x00, 0xa5,
+	0x04, 0xc1, 0x00, 0x33, 0x05, 0x96, 0x00, 0xab, 0x04, 0x81, 0x00, 0x9c, 0x04, 0x89, 0x00, 0x99,
+	0x04, 0x6b, 0x00, 0x9c, 0x04, 0x89, 0x00, 0x52, 0x04, 0x16, 0x00, 0xa3, 0x03, 0xc0, 0x00, 0xed,
+	0x04, 0x16, 0x00, 0x0c, 0x06, 0xab, 0x00, 0xb9, 0x04, 0x16, 0x00, 0x27, 0x04, 0xab, 0x00, 0x9c,
+	0x04, 0x41, 0x00, 0xde, 0x06, 0x81, 0x00, 0xab, 0x06, 0xab, 0x00, 0xab, 0x05, 0x16, 0x00, 0xea,
+	0x05, 0xd6, 0x00, 0xa5, 0x04, 0x41, 0x00, 0xa5, 0x04, 0x2b, 0x00, 0x6f, 0x06, 0x16, 0x00, 0xa5,
+	0x04, 0x6b, 0x00, 0x4b, 0x04, 0x89, 0x00, 0x9b, 0x04, 0x89, 0x00, 0x9b, 0x04, 0x89, 0x00, 0xa5,
+	0x03, 0x01, 0x00, 0x97, 0x04, 0x2b, 0x00, 0xb4, 0x04, 0x16, 0x00, 0x86, 0x02, 0x0f, 0x00, 0xa5,
+	0x02, 0x0f, 0x00, 0xa5, 0x01, 0xed, 0xff, 0x62, 0x07, 0x56, 0x00, 0x4c, 0x06, 0x96, 0x00, 0xa5,
+	0x04, 0x89, 0x00, 0xa5, 0x03, 0x96, 0x00, 0xa5, 0x04, 0x8e, 0x00, 0x9c, 0x04, 0x16, 0x00, 0x0c,
+	0x04, 0x81, 0x00, 0x9c, 0x03, 0xff, 0x00, 0xbf, 0x03, 0x60, 0x00, 0xb5, 0x07, 0xa3, 0x01, 0x4b,
+	0x05, 0xdd, 0x00, 0xef, 0x07, 0xa3, 0x01, 0x4b, 0x05, 0xdd, 0x00, 0xef, 0x07, 0xa3, 0x01, 0x4b,
+	0x05, 0xdd, 0x00, 0xef, 0x05, 0x6c, 0x01, 0x50, 0x04, 0x16, 0x00, 0xa5, 0x04, 0x16, 0x00, 0xf7,
+	0x08, 0x16, 0x00, 0xf7, 0x08, 0x16, 0x00, 0x77, 0x04, 0x81, 0xff, 0xb2, 0x01, 0xdd, 0x01, 0x31,
+	0x01, 0xdd, 0x01, 0x49, 0x01, 0xdd, 0x00, 0x37, 0x01, 0xdd, 0x01, 0x3a, 0x03, 0x6c, 0x01, 0x11,
+	0x03, 0x6c, 0x01, 0x39, 0x03, 0x6c, 0x00, 0x2f, 0x04, 0x89, 0x01, 0x43, 0x04, 0x89, 0x00, 0xcd,
+	0x02, 0xe3, 0x00, 0xeb, 0x08, 0x16, 0x00, 0xc7, 0x08, 0x16, 0x00, 0x40, 0x01, 0x96, 0x00, 0xe6,
+	0x02, 0xeb, 0x00, 0xe5, 0x02, 0xc0, 0x00, 0xc1, 0x02, 0xc0, 0x00, 0x9f, 0x04, 0x16, 0x00, 0xdd,
+	0x02, 0xc0, 0x01, 0x4b, 0x01, 0x6c, 0xfe, 0x4c, 0x03, 0xc1, 0x00, 0xf2, 0x03, 0xc1, 0x01, 0x11,
+	0x03, 0xc1, 0x01, 0x35, 0x03, 0xc1, 0x01, 0x2f, 0x03, 0xc1, 0x01, 0x6e, 0x03, 0xc1, 0x01, 0x21,
+	0x03, 0xc1, 0x01, 0x38, 0x03, 0xc1, 0x01, 0x57, 0x03, 0xc1, 0x01, 0x54, 0x03, 0xc1, 0x00, 0xfe,
+	0x03, 0x01, 0x01, 0x8f, 0x03, 0x01, 0x01, 0x08, 0x03, 0xc1, 0x01, 0x2f, 0x03, 0xc1, 0x00, 0x02,
+	0x03, 0xc1, 0x00, 0x69, 0x03, 0xc1, 0x00, 0x18, 0x03, 0xc1, 0x00, 0x40, 0x03, 0xc1, 0x00, 0x21,
+	0x03, 0xc1, 0x00, 0x45, 0x03, 0xc1, 0x00, 0x3f, 0x03, 0xc1, 0x00, 0x7e, 0x03, 0xc1, 0x00, 0x31,
+	0x03, 0xc1, 0x00, 0x48, 0x03, 0xc1, 0x00, 0x67, 0x03, 0xc1, 0x00, 0x64, 0x03, 0xc1, 0x00, 0x0e,
+	0x03, 0x01, 0x00, 0xcf, 0x03, 0x01, 0x00, 0x48, 0x03, 0xc1, 0x00, 0x3f, 0x04, 0x89, 0x00, 0x97,
+	0x04, 0x89, 0x00, 0x97, 0x08, 0xd6, 0x00, 0x6f, 0x04, 0x89, 0x00, 0x76, 0x07, 0x2b, 0x00, 0x62,
+	0x02, 0xac, 0x00, 0x68, 0x08, 0xab, 0x00, 0xa1, 0x08, 0x16, 0x01, 0xf6, 0x06, 0x3b, 0x00, 0x93,
+	0x05, 0xcc, 0x00, 0x6f, 0x06, 0xc2, 0x00, 0x9c, 0x06, 0xc2, 0x00, 0xbe, 0x06, 0xc2, 0x00, 0xcb,
+	0x06, 0xc2, 0x00, 0x89, 0x08, 0x16, 0x01, 0x21, 0x04, 0x16, 0x01, 0x58, 0x08, 0x16, 0x01, 0x12,
+	0x04, 0x16, 0x00, 0xc9, 0x08, 0x16, 0x00, 0xd1, 0x04, 0x16, 0x00, 0xca, 0x04, 0x16, 0x00, 0x2c,
+	0x04, 0x0a, 0x00, 0x65, 0x04, 0xfb, 0x00, 0x51, 0x06, 0xac, 0x00, 0xd5, 0x05, 0xca, 0x00, 0x2c,
+	0x04, 0xc2, 0x00, 0xd6, 0x01, 0x6c, 0xfe, 0xf5, 0x02, 0x39, 0x00, 0xa5, 0x04, 0x7a, 0x00, 0x7a,
+	0x05, 0xca, 0x00, 0xe4, 0x07, 0xeb, 0x01, 0x73, 0x05, 0xc0, 0x00, 0x90, 0x05, 0xc0, 0x00, 0x90,
+	0x02, 0x47, 0xff, 0xf0, 0x04, 0x7a, 0x00, 0x88, 0x04, 0xc2, 0x00, 0xbf, 0x04, 0xc1, 0x00, 0x9a,
+	0x04, 0x7a, 0x00, 0x51, 0x04, 0x7a, 0x00, 0x51, 0x04, 0xd5, 0x00, 0x8a, 0x04, 0xc2, 0x00, 0xae,
+	0x04, 0xcd, 0x02, 0x03, 0x04, 0xcd, 0x00, 0xea, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x66, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xeb, 0x00, 0x6f, 0x04, 0xeb, 0x00, 0x6f,
+	0x02, 0xec, 0x00, 0x6f, 0x02, 0xec, 0x00, 0x6f, 0x08, 0x16, 0x00, 0x0b, 0x08, 0x01, 0x01, 0x05,
+	0x08, 0x01, 0x01, 0x05, 0x08, 0x01, 0x01, 0x05, 0x08, 0x01, 0x01, 0x05, 0x04, 0x0a, 0x00, 0x2b,
+	0x04, 0xeb, 0x00, 0xb9, 0x04, 0xeb, 0x00, 0xb9, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x02, 0xec, 0x00, 0x4d, 0x08, 0x41, 0x01, 0x17, 0x08, 0x81, 0x01, 0x38, 0x07, 0x6b, 0x00, 0xb8,
+	0x06, 0x16, 0x00, 0x71, 0x06, 0x16, 0x00, 0x36, 0x04, 0x56, 0x00, 0x3d, 0x05, 0x56, 0x00, 0x3d,
+	0x04, 0xd6, 0x00, 0x55, 0x04, 0x2b, 0x00, 0x33, 0x04, 0x16, 0x00, 0x3c, 0x06, 0x14, 0x00, 0x6f,
+	0x08, 0x00, 0x00, 0x99, 0x04, 0x30, 0x00, 0xba, 0x04, 0x5b, 0x00, 0xba, 0x08, 0x00, 0x00, 0x99,
+	0x04, 0x89, 0x00, 0x53, 0x00, 0x53, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x54,
+	0x00, 0x00, 0x00, 0x54, 0x00, 0x00, 0x00, 0x54, 0x00, 0x00, 0x00, 0x54, 0x00, 0x00, 0x00, 0xd8,
+	0x00, 0x00, 0x01, 0x28, 0x00, 0x00, 0x02, 0x44, 0x00, 0x00, 0x03, 0x8c, 0x00, 0x00, 0x04, 0xe4,
+	0x00, 0x00, 0x05, 0xf0, 0x00, 0x00, 0x06, 0x28, 0x00, 0x00, 0x06, 0x70, 0x00, 0x00, 0x06, 0xbc,
+	0x00, 0x00, 0x07, 0x98, 0x00, 0x00, 0x07, 0xf8, 0x00, 0x00, 0x08, 0x7c, 0x00, 0x00, 0x08, 0xb8,
+	0x00, 0x00, 0x09, 0x04, 0x00, 0x00, 0x09, 0x50, 0x00, 0x00, 0x0a, 0x08, 0x00, 0x00, 0x0a, 0x74,
+	0x00, 0x00, 0x0b, 0x18, 0x00, 0x00, 0x0b, 0xec, 0x00, 0x00, 0x0c, 0x7c, 0x00, 0x00, 0x0d, 0x38,
+	0x00, 0x00, 0x0e, 0x04, 0x00, 0x00, 0x0e, 0x70, 0x00, 0x00, 0x0f, 0x4c, 0x00, 0x00, 0x10, 0x18,
+	0x00, 0x00, 0x10, 0x8c, 0x00, 0x00, 0x11, 0x48, 0x00, 0x00, 0x11, 0x7c, 0x00, 0x00, 0x11, 0xd8,
+	0x00, 0x00, 0x12, 0x0c, 0x00, 0x00, 0x12, 0xd4, 0x00, 0x00, 0x14, 0x34, 0x00, 0x00, 0x14, 0xb8,
+	0x00, 0x00, 0x15, 0x8c, 0x00, 0x00, 0x16, 0x30, 0x00, 0x00, 0x16, 0xc8, 0x00, 0x00, 0x17, 0x54,
+	0x00, 0x00, 0x17, 0xd0, 0x00, 0x00, 0x18, 0x94, 0x00, 0x00, 0x19, 0x14, 0x00, 0x00, 0x19, 0x90,
+	0x00, 0x00, 0x1a, 0x10, 0x00, 0x00, 0x1a, 0x88, 0x00, 0x00, 0x1a, 0xe8, 0x00, 0x00, 0x1b, 0x74,
+	0x00, 0x00, 0x1b, 0xe4, 0x00, 0x00, 0x1c, 0xa0, 0x00, 0x00, 0x1d, 0x3c, 0x00, 0x00, 0x1e, 0x00,
+	0x00, 0x00, 0x1e, 0xb0, 0x00, 0x00, 0x1f, 0x6c, 0x00, 0x00, 0x1f, 0xd4, 0x00, 0x00, 0x20, 0x5c,
+	0x00, 0x00, 0x20, 0xc0, 0x00, 0x00, 0x21, 0x40, 0x00, 0x00, 0x21, 0xc0, 0x00, 0x00, 0x22, 0x2c,
+	0x00, 0x00, 0x22, 0xa0, 0x00, 0x00, 0x22, 0xec, 0x00, 0x00, 0x23, 0x2c, 0x00, 0x00, 0x23, 0x78,
+	0x00, 0x00, 0x23, 0xb8, 0x00, 0x00, 0x23, 0xf8, 0x00, 0x00, 0x24, 0x30, 0x00, 0x00, 0x25, 0x28,
+	0x00, 0x00, 0x26, 0x04, 0x00, 0x00, 0x26, 0x7c, 0x00, 0x00, 0x27, 0x60, 0x00, 0x00, 0x27, 0xec,
+	0x00, 0x00, 0x28, 0x98, 0x00, 0x00, 0x29, 0xac, 0x00, 0x00, 0x2a, 0x40, 0x00, 0x00, 0x2a, 0xb4,
+	0x00, 0x00, 0x2b, 0x50, 0x00, 0x00, 0x2b, 0xd0, 0x00, 0x00, 0x2c, 0x2c, 0x00, 0x00, 0x2d, 0x08,
+	0x00, 0x00, 0x2d, 0xb4, 0x00, 0x00, 0x2e, 0x3c, 0x00, 0x00, 0x2e, 0xf8, 0x00, 0x00, 0x2f, 0xac,
+	0x00, 0x00, 0x30, 0x64, 0x00, 0x00, 0x30, 0xf0, 0x00, 0x00, 0x31, 0x68, 0x00, 0x00, 0x32, 0x18,
+	0x00, 0x00, 0x32, 0x7c, 0x00, 0x00, 0x32, 0xfc, 0x00, 0x00, 0x33, 0x78, 0x00, 0x00, 0x33, 0xbc,
+	0x00, 0x00, 0x34, 0x34, 0x00, 0x00, 0x34, 0xf8, 0x00, 0x00, 0x35, 0x30, 0x00, 0x00, 0x35, 0xf4,
+	0x00, 0x00, 0x36, 0xac, 0x00, 0x00, 0x36, 0xac, 0x00, 0x00, 0x37, 0x10, 0x00, 0x00, 0x37, 0xdc,
+	0x00, 0x00, 0x38, 0xa4, 0x00, 0x00, 0x39, 0x6c, 0x00, 0x00, 0x3a, 0x34, 0x00, 0x00, 0x3a, 0x90,
+	0x00, 0x00, 0x3b, 0x8c, 0x00, 0x00, 0x3b, 0xe8, 0x00, 0x00, 0x3c, 0xf8, 0x00, 0x00, 0x3d, 0xf0,
+	0x00, 0x00, 0x3e, 0x40, 0x00, 0x00, 0x3e, 0x88, 0x00, 0x00, 0x3e, 0xc4, 0x00, 0x00, 0x3f, 0xd8,
+	0x00, 0x00, 0x40, 0x1c, 0x00, 0x00, 0x40, 0xac, 0x00, 0x00, 0x41, 0x60, 0x00, 0x00, 0x41, 0xe4,
+	0x00, 0x00, 0x42, 0x88, 0x00, 0x00, 0x42, 0xc8, 0x00, 0x00, 0x43, 0x88, 0x00, 0x00, 0x44, 0x10,
+	0x00, 0x00, 0x44, 0x44, 0x00, 0x00, 0x44, 0xec, 0x00, 0x00, 0x45, 0x3c, 0x00, 0x00, 0x45, 0xb8,
+	0x00, 0x00, 0x46, 0x04, 0x00, 0x00, 0x47, 0x18, 0x00, 0x00, 0x47, 0xf4, 0x00, 0x00, 0x49, 0xb4,
+	0x00, 0x00, 0x4a, 0x54, 0x00, 0x00, 0x4b, 0x00, 0x00, 0x00, 0x4b, 0xb4, 0x00, 0x00, 0x4c, 0x7c,
+	0x00, 0x00, 0x4d, 0x70, 0x00, 0x00, 0x4e, 0x3c, 0x00, 0x00, 0x4f, 0x3c, 0x00, 0x00, 0x50, 0x04,
+	0x00, 0x00, 0x51, 0x10, 0x00, 0x00, 0x51, 0xc4, 0x00, 0x00, 0x52, 0x80, 0x00, 0x00, 0x53, 0x50,
+	0x00, 0x00, 0x54, 0x20, 0x00, 0x00, 0x54, 0xc0, 0x00, 0x00, 0x55, 0x6c, 0x00, 0x00, 0x56, 0x2c,
+	0x00, 0x00, 0x56, 0xe8, 0x00, 0x00, 0x57, 0xb0, 0x00, 0x00, 0x58, 0x90, 0x00, 0x00, 0x59, 0x74,
+	0x00, 0x00, 0x5a, 0x60, 0x00, 0x00, 0x5b, 0x60, 0x00, 0x00, 0x5c, 0x88, 0x00, 0x00, 0x5d, 0x84,
+	0x00, 0x00, 0x5d, 0xcc, 0x00, 0x00, 0x5e, 0xb4, 0x00, 0x00, 0x5f, 0x64, 0x00, 0x00, 0x60, 0x20,
+	0x00, 0x00, 0x60, 0xec, 0x00, 0x00, 0x61, 0xbc, 0x00, 0x00, 0x62, 0x58, 0x00, 0x00, 0x63, 0x04,
+	0x00, 0x00, 0x64, 0x18, 0x00, 0x00, 0x65, 0x84, 0x00, 0x00, 0x67, 0x04, 0x00, 0x00, 0x68, 0x98,
+	0x00, 0x00, 0x6a, 0x2c, 0x00, 0x00, 0x6b, 0x8c, 0x00, 0x00, 0x6d, 0x2c, 0x00, 0x00, 0x6e, 0xd8,
+	0x00, 0x00, 0x6f, 0xa4, 0x00, 0x00, 0x70, 0x84, 0x00, 0x00, 0x71, 0x6c, 0x00, 0x00, 0x72, 0x68,
+	0x00, 0x00, 0x73, 0x64, 0x00, 0x00, 0x73, 0xf8, 0x00, 0x00, 0x74, 0x98, 0x00, 0x00, 0x75, 0x4c,
+	0x00, 0x00, 0x75, 0xdc, 0x00, 0x00, 0x76, 0xac, 0x00, 0x00, 0x77, 0xdc, 0x00, 0x00, 0x78, 0xb0,
+	0x00, 0x00, 0x79, 0x8c, 0x00, 0x00, 0x7a, 0x7c, 0x00, 0x00, 0x7b, 0x94, 0x00, 0x00, 0x7c, 0x80,
+	0x00, 0x00, 0x7d, 0x20, 0x00, 0x00, 0x7d, 0xd0, 0x00, 0x00, 0x7e, 0xe0, 0x00, 0x00, 0x7f, 0xfc,
+	0x00, 0x00, 0x81, 0x2c, 0x00, 0x00, 0x82, 0x2c, 0x00, 0x00, 0x82, 0xbc, 0x00, 0x00, 0x83, 0x50,
+	0x00, 0x00, 0x83, 0xf0, 0x00, 0x00, 0x84, 0xa0, 0x00, 0x00, 0x86, 0x10, 0x00, 0x00, 0x86, 0xdc,
+	0x00, 0x00, 0x88, 0x6c, 0x00, 0x00, 0x89, 0x58, 0x00, 0x00, 0x8a, 0xd0, 0x00, 0x00, 0x8b, 0xa4,
+	0x00, 0x00, 0x8c, 0x70, 0x00, 0x00, 0x8d, 0x54, 0x00, 0x00, 0x8e, 0x30, 0x00, 0x00, 0x8e, 0xfc,
+	0x00, 0x00, 0x8f, 0xc0, 0x00, 0x00, 0x90, 0xa4, 0x00, 0x00, 0x91, 0x80, 0x00, 0x00, 0x92, 0x5c,
+	0x00, 0x00, 0x93, 0x74, 0x00, 0x00, 0x94, 0x3c, 0x00, 0x00, 0x95, 0x58, 0x00, 0x00, 0x96, 0x10,
+	0x00, 0x00, 0x96, 0xf0, 0x00, 0x00, 0x97, 0xc4, 0x00, 0x00, 0x98, 0xf8, 0x00, 0x00, 0x99, 0xac,
+	0x00, 0x00, 0x9a, 0x8c, 0x00, 0x00, 0x9b, 0x88, 0x00, 0x00, 0x9c, 0x78, 0x00, 0x00, 0x9d, 0x48,
+	0x00, 0x00, 0x9e, 0x44, 0x00, 0x00, 0x9f, 0x4c, 0x00, 0x00, 0xa0, 0xb0, 0x00, 0x00, 0xa1, 0xb8,
+	0x00, 0x00, 0xa3, 0x1c, 0x00, 0x00, 0xa4, 0x08, 0x00, 0x00, 0xa5, 0x84, 0x00, 0x00, 0xa6, 0xd8,
+	0x00, 0x00, 0xa8, 0x5c, 0x00, 0x00, 0xa9, 0x20, 0x00, 0x00, 0xa9, 0xf4, 0x00, 0x00, 0xaa, 0xb8,
+	0x00, 0x00, 0xab, 0x78, 0x00, 0x00, 0xac, 0x60, 0x00, 0x00, 0xad, 0x18, 0x00, 0x00, 0xad, 0xbc,
+	0x00, 0x00, 0xae, 0x50, 0x00, 0x00, 0xaf, 0x10, 0x00, 0x00, 0xaf, 0xe8, 0x00, 0x00, 0xb0, 0xcc,
+	0x00, 0x00, 0xb1, 0x9c, 0x00, 0x00, 0xb2, 0x40, 0x00, 0x00, 0xb2, 0x8c, 0x00, 0x00, 0xb3, 0x58,
+	0x00, 0x00, 0xb4, 0x34, 0x00, 0x00, 0xb4, 0xf8, 0x00, 0x00, 0xb5, 0xac, 0x00, 0x00, 0xb6, 0xa4,
+	0x00, 0x00, 0xb7, 0xa8, 0x00, 0x00, 0xb8, 0x20, 0x00, 0x00, 0xb8, 0xb0, 0x00, 0x00, 0xb9, 0x2c,
+	0x00, 0x00, 0xba, 0x10, 0x00, 0x00, 0xba, 0xe0, 0x00, 0x00, 0xbb, 0x6c, 0x00, 0x00, 0xbb, 0xf0,
+	0x00, 0x00, 0xbc, 0x78, 0x00, 0x00, 0xbc, 0xf4, 0x00, 0x00, 0xbd, 0x78, 0x00, 0x00, 0xbd, 0xf4,
+	0x00, 0x00, 0xbe, 0x98, 0x00, 0x00, 0xbf, 0xb4, 0x00, 0x00, 0xc0, 0xa8, 0x00, 0x00, 0xc1, 0xec,
+	0x00, 0x00, 0xc2, 0xa0, 0x00, 0x00, 0xc3, 0xd0, 0x00, 0x00, 0xc4, 0xc0, 0x00, 0x00, 0xc5, 0x68,
+	0x00, 0x00, 0xc6, 0x50, 0x00, 0x00, 0xc7, 0x34, 0x00, 0x00, 0xc8, 0x08, 0x00, 0x00, 0xc9, 0x08,
+	0x00, 0x00, 0xca, 0x24, 0x00, 0x00, 0xcb, 0x2c, 0x00, 0x00, 0xcc, 0x20, 0x00, 0x00, 0xcd, 0x34,
+	0x00, 0x00, 0xce, 0x00, 0x00, 0x00, 0xce, 0xe0, 0x00, 0x00, 0xd0, 0x04, 0x00, 0x00, 0xd1, 0x40,
+	0x00, 0x00, 0xd2, 0x94, 0x00, 0x00, 0xd3, 0x84, 0x00, 0x00, 0xd4, 0xc0, 0x00, 0x00, 0xd5, 0xac,
+	0x00, 0x00, 0xd6, 0x8c, 0x00, 0x00, 0xd7, 0x88, 0x00, 0x00, 0xd8, 0x78, 0x00, 0x00, 0xd9, 0x94,
+	0x00, 0x00, 0xda, 0x70, 0x00, 0x00, 0xdb, 0x6c, 0x00, 0x00, 0xdc, 0x5c, 0x00, 0x00, 0xdd, 0x28,
+	0x00, 0x00, 0xdd, 0xf4, 0x00, 0x00, 0xde, 0xa0, 0x00, 0x00, 0xdf, 0x44, 0x00, 0x00, 0xdf, 0xdc,
+	0x00, 0x00, 0xe0, 0x78, 0x00, 0x00, 0xe1, 0x6c, 0x00, 0x00, 0xe2, 0xa0, 0x00, 0x00, 0xe3, 0x58,
+	0x00, 0x00, 0xe4, 0x68, 0x00, 0x00, 0xe5, 0x38, 0x00, 0x00, 0xe6, 0x9c, 0x00, 0x00, 0xe7, 0xa4,
+	0x00, 0x00, 0xe8, 0xe0, 0x00, 0x00, 0xe9, 0xb8, 0x00, 0x00, 0xea, 0xec, 0x00, 0x00, 0xeb, 0xd8,
+	0x00, 0x00, 0xec, 0xec, 0x00, 0x00, 0xed, 0xb0, 0x00, 0x00, 0xee, 0x98, 0x00, 0x00, 0xef, 0x48,
+	0x00, 0x00, 0xef, 0xe8, 0x00, 0x00, 0xf0, 0x9c, 0x00, 0x00, 0xf1, 0x40, 0x00, 0x00, 0xf2, 0x14,
+	0x00, 0x00, 0xf2, 0xb0, 0x00, 0x00, 0xf3, 0x50, 0x00, 0x00, 0xf4, 0x08, 0x00, 0x00, 0xf4, 0xf0,
+	0x00, 0x00, 0xf5, 0x84, 0x00, 0x00, 0xf6, 0x34, 0x00, 0x00, 0xf6, 0xfc, 0x00, 0x00, 0xf8, 0x8c,
+	0x00, 0x00, 0xf9, 0x4c, 0x00, 0x00, 0xfa, 0x00, 0x00, 0x00, 0xfb, 0x00, 0x00, 0x00, 0xfb, 0xf0,
+	0x00, 0x00, 0xfc, 0xbc, 0x00, 0x00, 0xfd, 0xec, 0x00, 0x00, 0xfe, 0xe4, 0x00, 0x01, 0x00, 0x18,
+	0x00, 0x01, 0x01, 0x18, 0x00, 0x01, 0x02, 0x54, 0x00, 0x01, 0x03, 0x68, 0x00, 0x01, 0x04, 0xb8,
+	0x00, 0x01, 0x05, 0xb0, 0x00, 0x01, 0x06, 0xe4, 0x00, 0x01, 0x07, 0xf0, 0x00, 0x01, 0x09, 0xa4,
+	0x00, 0x01, 0x0a, 0x9c, 0x00, 0x01, 0x0c, 0xe0, 0x00, 0x01, 0x0d, 0xf8, 0x00, 0x01, 0x0f, 0x00,
+	0x00, 0x01, 0x10, 0x40, 0x00, 0x01, 0x11, 0x44, 0x00, 0x01, 0x12, 0x34, 0x00, 0x01, 0x13, 0x24,
+	0x00, 0x01, 0x13, 0x74, 0x00, 0x01, 0x13, 0xc4, 0x00, 0x01, 0x14, 0x08, 0x00, 0x01, 0x14, 0x60,
+	0x00, 0x01, 0x14, 0xa4, 0x00, 0x01, 0x15, 0x34, 0x00, 0x01, 0x15, 0xb4, 0x00, 0x01, 0x16, 0x2c,
+	0x00, 0x01, 0x16, 0x8c, 0x00, 0x01, 0x17, 0x48, 0x00, 0x01, 0x17, 0x88, 0x00, 0x01, 0x18, 0x0c,
+	0x00, 0x01, 0x18, 0xc8, 0x00, 0x01, 0x18, 0xfc, 0x00, 0x01, 0x19, 0xc0, 0x00, 0x01, 0x1a, 0x7c,
+	0x00, 0x01, 0x1b, 0x2c, 0x00, 0x01, 0x1c, 0x1c, 0x00, 0x01, 0x1c, 0xe8, 0x00, 0x01, 0x1d, 0xe4,
+	0x00, 0x01, 0x1e, 0xd0, 0x00, 0x01, 0x1f, 0x54, 0x00, 0x01, 0x20, 0x28, 0x00, 0x01, 0x20, 0x8c,
+	0x00, 0x01, 0x21, 0x00, 0x00, 0x01, 0x21, 0x8c, 0x00, 0x01, 0x22, 0x00, 0x00, 0x01, 0x22, 0x80,
+	0x00, 0x01, 0x23, 0x64, 0x00, 0x01, 0x23, 0xe0, 0x00, 0x01, 0x24, 0x58, 0x00, 0x01, 0x24, 0xac,
+	0x00, 0x01, 0x25, 0x38, 0x00, 0x01, 0x25, 0xa8, 0x00, 0x01, 0x26, 0x48, 0x00, 0x01, 0x27, 0x04,
+	0x00, 0x01, 0x27, 0x6c, 0x00, 0x01, 0x28, 0x08, 0x00, 0x01, 0x28, 0x90, 0x00, 0x01, 0x28, 0xf8,
+	0x00, 0x01, 0x29, 0x8c, 0x00, 0x01, 0x2a, 0x70, 0x00, 0x01, 0x2a, 0xf0, 0x00, 0x01, 0x2b, 0xd0,
+	0x00, 0x01, 0x2c, 0x94, 0x00, 0x01, 0x2d, 0x50, 0x00, 0x01, 0x2e, 0x28, 0x00, 0x01, 0x2f, 0xb0,
+	0x00, 0x01, 0x30, 0x7c, 0x00, 0x01, 0x31, 0x7c, 0x00, 0x01, 0x31, 0xf8, 0x00, 0x01, 0x32, 0xfc,
+	0x00, 0x01, 0x34, 0x44, 0x00, 0x01, 0x35, 0x18, 0x00, 0x01, 0x35, 0xa0, 0x00, 0x01, 0x36, 0x9c,
+	0x00, 0x01, 0x37, 0x3c, 0x00, 0x01, 0x38, 0x78, 0x00, 0x01, 0x39, 0x3c, 0x00, 0x01, 0x39, 0xd4,
+	0x00, 0x01, 0x3a, 0x2c, 0x00, 0x01, 0x3a, 0xc0, 0x00, 0x01, 0x3b, 0x60, 0x00, 0x01, 0x3c, 0x34,
+	0x00, 0x01, 0x3c, 0xcc, 0x00, 0x01, 0x3e, 0x70, 0x00, 0x01, 0x3e, 0xf8, 0x00, 0x01, 0x3f, 0x8c,
+	0x00, 0x01, 0x40, 0x48, 0x00, 0x01, 0x41, 0x70, 0x00, 0x01, 0x42, 0x3c, 0x00, 0x01, 0x42, 0xc0,
+	0x00, 0x01, 0x43, 0x30, 0x00, 0x01, 0x44, 0x60, 0x00, 0x01, 0x44, 0xe0, 0x00, 0x01, 0x45, 0xc8,
+	0x00, 0x01, 0x46, 0xc8, 0x00, 0x01, 0x47, 0x80, 0x00, 0x01, 0x48, 0x50, 0x00, 0x01, 0x48, 0xfc,
+	0x00, 0x01, 0x49, 0x90, 0x00, 0x01, 0x4a, 0xb4, 0x00, 0x01, 0x4b, 0x68, 0x00, 0x01, 0x4c, 0x38,
+	0x00, 0x01, 0x4d, 0x34, 0x00, 0x01, 0x4d, 0xb8, 0x00, 0x01, 0x4e, 0x88, 0x00, 0x01, 0x4f, 0x44,
+	0x00, 0x01, 0x4f, 0xc0, 0x00, 0x01, 0x50, 0x7c, 0x00, 0x01, 0x50, 0xfc, 0x00, 0x01, 0x51, 0xf0,
+	0x00, 0x01, 0x52, 0xb8, 0x00, 0x01, 0x53, 0x74, 0x00, 0x01, 0x54, 0x98, 0x00, 0x01, 0x55, 0x30,
+	0x00, 0x01, 0x56, 0x38, 0x00, 0x01, 0x56, 0xbc, 0x00, 0x01, 0x57, 0x40, 0x00, 0x01, 0x57, 0xf4,
+	0x00, 0x01, 0x58, 0xc8, 0x00, 0x01, 0x59, 0x1c, 0x00, 0x01, 0x59, 0xe4, 0x00, 0x01, 0x5a, 0x70,
+	0x00, 0x01, 0x5b, 0xb8, 0x00, 0x01, 0x5c, 0x8c, 0x00, 0x01, 0x5c, 0xfc, 0x00, 0x01, 0x5d, 0xf4,
+	0x00, 0x01, 0x5e, 0xe0, 0x00, 0x01, 0x5f, 0x6c, 0x00, 0x01, 0x5f, 0xf8, 0x00, 0x01, 0x60, 0x78,
+	0x00, 0x01, 0x61, 0x34, 0x00, 0x01, 0x61, 0x94, 0x00, 0x01, 0x62, 0x30, 0x00, 0x01, 0x62, 0xd4,
+	0x00, 0x01, 0x63, 0x3c, 0x00, 0x01, 0x63, 0xc0, 0x00, 0x01, 0x64, 0xa4, 0x00, 0x01, 0x65, 0x24,
+	0x00, 0x01, 0x65, 0xb4, 0x00, 0x01, 0x66, 0x44, 0x00, 0x01, 0x66, 0xbc, 0x00, 0x01, 0x67, 0x5c,
+	0x00, 0x01, 0x68, 0x18, 0x00, 0x01, 0x68, 0xe4, 0x00, 0x01, 0x69, 0x90, 0x00, 0x01, 0x6a, 0x4c,
+	0x00, 0x01, 0x6b, 0x44, 0x00, 0x01, 0x6c, 0x0c, 0x00, 0x01, 0x6d, 0x04, 0x00, 0x01, 0x6d, 0xb0,
+	0x00, 0x01, 0x6e, 0x7c, 0x00, 0x01, 0x6e, 0xd8, 0x00, 0x01, 0x6f, 0xbc, 0x00, 0x01, 0x70, 0x48,
+	0x00, 0x01, 0x71, 0x9c, 0x00, 0x01, 0x72, 0x48, 0x00, 0x01, 0x72, 0xb8, 0x00, 0x01, 0x73, 0xa8,
+	0x00, 0x01, 0x74, 0x90, 0x00, 0x01, 0x75, 0x18, 0x00, 0x01, 0x75, 0xa8, 0x00, 0x01, 0x76, 0x24,
+	0x00, 0x01, 0x76, 0xac, 0x00, 0x01, 0x77, 0x14, 0x00, 0x01, 0x77, 0xd0, 0x00, 0x01, 0x78, 0x48,
+	0x00, 0x01, 0x78, 0xb0, 0x00, 0x01, 0x79, 0x18, 0x00, 0x01, 0x7a, 0x70, 0x00, 0x01, 0x7a, 0xec,
+	0x00, 0x01, 0x7b, 0x94, 0x00, 0x01, 0x7c, 0x24, 0x00, 0x01, 0x7c, 0x9c, 0x00, 0x01, 0x7d, 0x58,
+	0x00, 0x01, 0x7e, 0x10, 0x00, 0x01, 0x7e, 0xcc, 0x00, 0x01, 0x7f, 0x70, 0x00, 0x01, 0x80, 0x0c,
+	0x00, 0x01, 0x81, 0x38, 0x00, 0x01, 0x81, 0xf4, 0x00, 0x01, 0x82, 0x9c, 0x00, 0x01, 0x83, 0x98,
+	0x00, 0x01, 0x84, 0xc0, 0x00, 0x01, 0x85, 0x4c, 0x00, 0x01, 0x85, 0xec, 0x00, 0x01, 0x86, 0x78,
+	0x00, 0x01, 0x86, 0xec, 0x00, 0x01, 0x87, 0x7c, 0x00, 0x01, 0x88, 0x1c, 0x00, 0x01, 0x89, 0x08,
+	0x00, 0x01, 0x89, 0xd4, 0x00, 0x01, 0x8a, 0xac, 0x00, 0x01, 0x8b, 0xc4, 0x00, 0x01, 0x8c, 0x5c,
+	0x00, 0x01, 0x8d, 0x28, 0x00, 0x01, 0x8d, 0xcc, 0x00, 0x01, 0x8e, 0x3c, 0x00, 0x01, 0x8e, 0xcc,
+	0x00, 0x01, 0x8f, 0x74, 0x00, 0x01, 0x90, 0x40, 0x00, 0x01, 0x90, 0xf0, 0x00, 0x01, 0x91, 0xc8,
+	0x00, 0x01, 0x92, 0x8c, 0x00, 0x01, 0x93, 0x50, 0x00, 0x01, 0x93, 0xe4, 0x00, 0x01, 0x94, 0x68,
+	0x00, 0x01, 0x94, 0xa4, 0x00, 0x01, 0x94, 0xe0, 0x00, 0x01, 0x95, 0x1c, 0x00, 0x01, 0x95, 0x80,
+	0x00, 0x01, 0x95, 0xc8, 0x00, 0x01, 0x96, 0x08, 0x00, 0x01, 0x96, 0x5c, 0x00, 0x01, 0x96, 0xa0,
+	0x00, 0x01, 0x97, 0x0c, 0x00, 0x01, 0x97, 0x70, 0x00, 0x01, 0x97, 0xec, 0x00, 0x01, 0x98, 0x74,
+	0x00, 0x01, 0x99, 0x30, 0x00, 0x01, 0x99, 0x7c, 0x00, 0x01, 0x9a, 0x04, 0x00, 0x01, 0x9b, 0xe8,
+	0x00, 0x01, 0x9c, 0x20, 0x00, 0x01, 0x9c, 0x70, 0x00, 0x01, 0x9c, 0xa0, 0x00, 0x01, 0x9c, 0xd0,
+	0x00, 0x01, 0x9d, 0x94, 0x00, 0x01, 0x9d, 0xd8, 0x00, 0x01, 0x9e, 0x24, 0x00, 0x01, 0x9e, 0xb8,
+	0x00, 0x01, 0x9f, 0x28, 0x00, 0x01, 0x9f, 0xc0, 0x00, 0x01, 0xa0, 0x5c, 0x00, 0x01, 0xa0, 0xb4,
+	0x00, 0x01, 0xa1, 0x6c, 0x00, 0x01, 0xa2, 0x08, 0x00, 0x01, 0xa2, 0x98, 0x00, 0x01, 0xa2, 0xd4,
+	0x00, 0x01, 0xa3, 0x30, 0x00, 0x01, 0xa3, 0x74, 0x00, 0x01, 0xa3, 0xb8, 0x00, 0x01, 0xa4, 0x44,
+	0x00, 0x01, 0xa4, 0xd8, 0x00, 0x01, 0xa5, 0x28, 0x00, 0x01, 0xa5, 0xac, 0x00, 0x01, 0xa6, 0x50,
+	0x00, 0x01, 0xa6, 0xc0, 0x00, 0x01, 0xa7, 0x58, 0x00, 0x01, 0xa7, 0xf4, 0x00, 0x01, 0xa8, 0x48,
+	0x00, 0x01, 0xa9, 0x00, 0x00, 0x01, 0xa9, 0x9c, 0x00, 0x01, 0xaa, 0x18, 0x00, 0x01, 0xaa, 0x50,
+	0x00, 0x01, 0xaa, 0xac, 0x00, 0x01, 0xaa, 0xf0, 0x00, 0x01, 0xab, 0x34, 0x00, 0x01, 0xab, 0xc0,
+	0x00, 0x01, 0xac, 0xc4, 0x00, 0x01, 0xad, 0xb4, 0x00, 0x01, 0xaf, 0xa8, 0x00, 0x01, 0xb0, 0xa8,
+	0x00, 0x01, 0xb1, 0x9c, 0x00, 0x01, 0xb2, 0x88, 0x00, 0x01, 0xb3, 0x6c, 0x00, 0x01, 0xb4, 0x0c,
+	0x00, 0x01, 0xb4, 0xb4, 0x00, 0x01, 0xb5, 0x84, 0x00, 0x01, 0xb6, 0xd8, 0x00, 0x01, 0xb8, 0xf0,
+	0x00, 0x01, 0xbb, 0x28, 0x00, 0x01, 0xbc, 0xe0, 0x00, 0x01, 0xbd, 0x2c, 0x00, 0x01, 0xbd, 0x6c,
+	0x00, 0x01, 0xbd, 0xb8, 0x00, 0x01, 0xbd, 0xf8, 0x00, 0x01, 0xbe, 0x58, 0x00, 0x01, 0xbe, 0x98,
+	0x00, 0x01, 0xbf, 0x04, 0x00, 0x01, 0xbf, 0xb4, 0x00, 0x01, 0xc0, 0x10, 0x00, 0x01, 0xc0, 0x74,
+	0x00, 0x01, 0xc0, 0xe0, 0x00, 0x01, 0xc1, 0x1c, 0x00, 0x01, 0xc1, 0x54, 0x00, 0x01, 0xc1, 0x9c,
+	0x00, 0x01, 0xc1, 0xe8, 0x00, 0x01, 0xc4, 0x40, 0x00, 0x01, 0xc4, 0x88, 0x00, 0x01, 0xc4, 0xf8,
+	0x00, 0x01, 0xc5, 0x6c, 0x00, 0x01, 0xc7, 0x00, 0x00, 0x01, 0xc7, 0xe0, 0x00, 0x01, 0xc8, 0xa0,
+	0x00, 0x01, 0xc9, 0x1c, 0x00, 0x01, 0xc9, 0x78, 0x00, 0x01, 0xc9, 0xd8, 0x00, 0x01, 0xca, 0x38,
+	0x00, 0x01, 0xca, 0x7c, 0x00, 0x01, 0xcb, 0x1c, 0x00, 0x01, 0xcb, 0xb4, 0x00, 0x01, 0xcb, 0xec,
+	0x00, 0x01, 0xcc, 0x18, 0x00, 0x01, 0xcc, 0x58, 0x00, 0x01, 0xcc, 0x9c, 0x00, 0x01, 0xcc, 0xdc,
+	0x00, 0x01, 0xcd, 0x20, 0x00, 0x01, 0xcd, 0x6c, 0x00, 0x01, 0xcd, 0xbc, 0x00, 0x01, 0xce, 0x08,
+	0x00, 0x01, 0xce, 0x54, 0x00, 0x01, 0xce, 0xb4, 0x00, 0x01, 0xcf, 0x0c, 0x00, 0x01, 0xcf, 0x58,
+	0x00, 0x01, 0xcf, 0xb4, 0x00, 0x01, 0xd0, 0x08, 0x00, 0x01, 0xd0, 0x70, 0x00, 0x01, 0xd0, 0xc8,
+	0x00, 0x01, 0xd1, 0x1c, 0x00, 0x01, 0xd1, 0x88, 0x00, 0x01, 0xd1, 0xdc, 0x00, 0x01, 0xd2, 0x2c,
+	0x00, 0x01, 0xd2, 0x8c, 0x00, 0x01, 0xd2, 0xe4, 0x00, 0x01, 0xd3, 0x34, 0x00, 0x01, 0xd3, 0xa0,
+	0x00, 0x01, 0xd4, 0x00, 0x00, 0x01, 0xd4, 0x6c, 0x00, 0x01, 0xd4, 0xe0, 0x00, 0x01, 0xd5, 0x44,
+	0x00, 0x01, 0xd5, 0xac, 0x00, 0x01, 0xd6, 0x30, 0x00, 0x01, 0xd6, 0x9c, 0x00, 0x01, 0xd6, 0xf4,
+	0x00, 0x01, 0xd7, 0x74, 0x00, 0x01, 0xd7, 0xdc, 0x00, 0x01, 0xd8, 0x38, 0x00, 0x01, 0xd8, 0xb8,
+	0x00, 0x01, 0xd9, 0x38, 0x00, 0x01, 0xd9, 0xb8, 0x00, 0x01, 0xda, 0x60, 0x00, 0x01, 0xda, 0x94,
+	0x00, 0x01, 0xda, 0xc0, 0x00, 0x01, 0xda, 0xec, 0x00, 0x01, 0xdb, 0x18, 0x00, 0x01, 0xdb, 0x48,
+	0x00, 0x01, 0xdd, 0x28, 0x00, 0x01, 0xde, 0xe0, 0x00, 0x01, 0xdf, 0xdc, 0x00, 0x01, 0xe0, 0x0c,
+	0x00, 0x01, 0xe0, 0x60, 0x00, 0x01, 0xe0, 0x9c, 0x00, 0x01, 0xe0, 0xf0, 0x00, 0x01, 0xe1, 0x2c,
+	0x00, 0x01, 0xe1, 0x5c, 0x00, 0x01, 0xe1, 0x80, 0x00, 0x01, 0xe1, 0xb4, 0x00, 0x01, 0xe1, 0xd8,
+	0x00, 0x01, 0xe2, 0x14, 0x00, 0x01, 0xe2, 0x98, 0x00, 0x01, 0xe2, 0xe4, 0x00, 0x01, 0xe3, 0x50,
+	0x00, 0x01, 0xe3, 0xec, 0x00, 0x01, 0xe4, 0x6c, 0x00, 0x01, 0xe5, 0xa4, 0x00, 0x01, 0xe6, 0xa0,
+	0x00, 0x01, 0xe7, 0x9c, 0x00, 0x01, 0xe8, 0x6c, 0x00, 0x01, 0xe9, 0x04, 0x00, 0x01, 0xe9, 0x78,
+	0x00, 0x01, 0xea, 0x0c, 0x00, 0x01, 0xea, 0x70, 0x00, 0x01, 0xea, 0xa8, 0x00, 0x01, 0xeb, 0x38,
+	0x00, 0x01, 0xeb, 0xc0, 0x00, 0x02, 0x05, 0x4c, 0x00, 0x02, 0x06, 0x30, 0x00, 0x02, 0x07, 0x58,
+	0x00, 0x02, 0x08, 0x08, 0x00, 0x02, 0x08, 0xa4, 0x00, 0x02, 0x09, 0x20, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xc8, 0x01, 0xa4, 0x00, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd8, 0x01, 0x5c,
+	0x00, 0x8d, 0x00, 0x00, 0x01, 0xf4, 0x15, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x19,
+	0x01, 0x32, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x41, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x41, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x06, 0x00, 0x43, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x23,
+	0x00, 0x49, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x09, 0x00, 0x6c, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x23, 0x00, 0x75, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x00, 0x09, 0x00, 0x98, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x15,
+	0x00, 0xa1, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09, 0x00, 0x1f, 0x00, 0xb6, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, 0x01, 0x42, 0x00, 0xd5, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x0c, 0x00, 0x0f, 0x02, 0x17, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x06, 0x82,
+	0x02, 0x26, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x09, 0x08, 0xa8, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x00, 0x00, 0x82, 0x08, 0xb1, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x01, 0x00, 0x04, 0x09, 0x33, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x02, 0x00, 0x0c,
+	0x09, 0x37, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x03, 0x00, 0x46, 0x09, 0x43, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x04, 0x00, 0x12, 0x09, 0x89, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x05, 0x00, 0x46, 0x09, 0x9b, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x06, 0x00, 0x12,
+	0x09, 0xe1, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x08, 0x00, 0x2a, 0x09, 0xf3, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x09, 0x00, 0x3e, 0x0a, 0x1d, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x0a, 0x02, 0x84, 0x0a, 0x5b, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0c, 0x00, 0x1e,
+	0x0c, 0xdf, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0d, 0x0d, 0x04, 0x0c, 0xfd, 0x43, 0x6f,
+	0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x28, 0x63, 0x29, 0x20, 0x32, 0x30, 0x31, 0x36,
+	0x20, 0x62, 0x79, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f,
+	0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x72,
+	0x69, 0x67, 0x68, 0x74, 0x73, 0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x2e, 0x47,
+	0x6f, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x26, 0x48,
+	0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x49, 0x6e, 0x63, 0x2e, 0x3a, 0x20, 0x47, 0x6f, 0x20, 0x49, 0x74,
+	0x61, 0x6c, 0x69, 0x63, 0x3a, 0x20, 0x32, 0x30, 0x31, 0x36, 0x47, 0x6f, 0x20, 0x49, 0x74, 0x61,
+	0x6c, 0x69, 0x63, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x20, 0x32, 0x2e, 0x30, 0x31, 0x30,
+	0x3b, 0x20, 0x74, 0x74, 0x66, 0x61, 0x75, 0x74, 0x6f, 0x68, 0x69, 0x6e, 0x74, 0x20, 0x28, 0x76,
+	0x31, 0x2e, 0x38, 0x2e, 0x33, 0x29, 0x47, 0x6f, 0x2d, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x42,
+	0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20,
+	0x49, 0x6e, 0x63, 0x2e, 0x4b, 0x72, 0x69, 0x73, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20,
+	0x61, 0x6e, 0x64, 0x20, 0x43, 0x68, 0x61, 0x72, 0x6c, 0x65, 0x73, 0x20, 0x42, 0x69, 0x67, 0x65,
+	0x6c, 0x6f, 0x77, 0x47, 0x6f, 0x20, 0x69, 0x73, 0x20, 0x61, 0x20, 0x68, 0x75, 0x6d, 0x61, 0x6e,
+	0x69, 0x73, 0x74, 0x69, 0x63, 0x20, 0x73, 0x61, 0x6e, 0x73, 0x2d, 0x73, 0x65, 0x72, 0x69, 0x66,
+	0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x66, 0x6f, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x47, 0x6f,
+	0x20, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x2e, 0x20, 0x49, 0x74, 0x73, 0x20, 0x78,
+	0x2d, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x2c, 0x20, 0x73, 0x74, 0x65, 0x6d, 0x20, 0x77, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x2c, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e,
+	0x63, 0x74, 0x69, 0x76, 0x65, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x7a,
+	0x65, 0x72, 0x6f, 0x2c, 0x20, 0x63, 0x61, 0x70, 0x69, 0x74, 0x61, 0x6c, 0x20, 0x4f, 0x2c, 0x20,
+	0x6c, 0x6f, 0x77, 0x65, 0x72, 0x63, 0x61, 0x73, 0x65, 0x20, 0x6c, 0x2c, 0x20, 0x66, 0x69, 0x67,
+	0x75, 0x72, 0x65, 0x20, 0x6f, 0x6e, 0x65, 0x2c, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x63, 0x61, 0x70,
+	0x69, 0x74, 0x61, 0x6c, 0x20, 0x49, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x20, 0x74, 0x68,
+	0x65, 0x20, 0x44, 0x49, 0x4e, 0x20, 0x31, 0x34, 0x35, 0x30, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20,
+	0x6c, 0x65, 0x67, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x20, 0x73, 0x74, 0x61, 0x6e, 0x64,
+	0x61, 0x72, 0x64, 0x2e, 0x20, 0x47, 0x6f, 0x27, 0x73, 0x20, 0x57, 0x47, 0x4c, 0x20, 0x63, 0x68,
+	0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x20, 0x73, 0x65, 0x74, 0x20, 0x69, 0x6e, 0x63, 0x6c,
+	0x75, 0x64, 0x65, 0x73, 0x20, 0x55, 0x6e, 0x69, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x4c, 0x61, 0x74,
+	0x69, 0x6e, 0x2c, 0x20, 0x47, 0x72, 0x65, 0x65, 0x6b, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x43, 0x79,
+	0x72, 0x69, 0x6c, 0x6c, 0x69, 0x63, 0x20, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x62, 0x65, 0x74, 0x73,
+	0x20, 0x70, 0x6c, 0x75, 0x73, 0x20, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x20, 0x61, 0x6e,
+	0x64, 0x20, 0x67, 0x72, 0x61, 0x70, 0x68, 0x69, 0x63, 0x61, 0x6c, 0x20, 0x65, 0x6c, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x73, 0x2e, 0x6c, 0x75, 0x63, 0x69, 0x64, 0x61, 0x66, 0x6f, 0x6e, 0x74, 0x73,
+	0x2e, 0x63, 0x6f, 0x6d, 0x43, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x28, 0x63,
+	0x29, 0x20, 0x32, 0x30, 0x31, 0x36, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26,
+	0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c,
+	0x6c, 0x20, 0x72, 0x69, 0x67, 0x68, 0x74, 0x73, 0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x64, 0x2e, 0x0a, 0x0a, 0x44, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e,
+	0x20, 0x6f, 0x66, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x69, 0x73,
+	0x20, 0x67, 0x6f, 0x76, 0x65, 0x72, 0x6e, 0x65, 0x64, 0x20, 0x62, 0x79, 0x20, 0x74, 0x68, 0x65,
+	0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x6c, 0x69, 0x63, 0x65, 0x6e,
+	0x73, 0x65, 0x2e, 0x20, 0x49, 0x66, 0x20, 0x79, 0x6f, 0x75, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f,
+	0x74, 0x20, 0x61, 0x67, 0x72, 0x65, 0x65, 0x20, 0x74, 0x6f, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20,
+	0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x2c, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x69,
+	0x6e, 0x67, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65,
+	0x72, 0x2c, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f, 0x74, 0x20, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x20, 0x6f, 0x72, 0x20, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x20, 0x74,
+	0x68, 0x69, 0x73, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x2e, 0x0a, 0x0a, 0x52, 0x65, 0x64, 0x69, 0x73,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x75, 0x73,
+	0x65, 0x20, 0x69, 0x6e, 0x20, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x20, 0x61, 0x6e, 0x64, 0x20,
+	0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x2c, 0x20, 0x77, 0x69,
+	0x74, 0x68, 0x20, 0x6f, 0x72, 0x20, 0x77, 0x69, 0x74, 0x68, 0x6f, 0x75, 0x74, 0x20, 0x6d, 0x6f,
+	0x64, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2c, 0x20, 0x61, 0x72, 0x65, 0x20,
+	0x70, 0x65, 0x72, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x20, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64,
+	0x65, 0x64, 0x20, 0x74, 0x68, 0x61, 0x74, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c,
+	0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x20, 0x61, 0x72, 0x65, 0x20, 0x6d, 0x65, 0x74, 0x3a, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20,
+	0x52, 0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20,
+	0x6f, 0x66, 0x20, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x20, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x6d,
+	0x75, 0x73, 0x74, 0x20, 0x72, 0x65, 0x74, 0x61, 0x69, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x61,
+	0x62, 0x6f, 0x76, 0x65, 0x20, 0x63, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x6e,
+	0x6f, 0x74, 0x69, 0x63, 0x65, 0x2c, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x73, 0x74,
+	0x20, 0x6f, 0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61,
+	0x6e, 0x64, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67,
+	0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x2e, 0x0a, 0x0a, 0x20, 0x20,
+	0x20, 0x2a, 0x20, 0x52, 0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x20, 0x69, 0x6e, 0x20, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x20, 0x66, 0x6f, 0x72,
+	0x6d, 0x20, 0x6d, 0x75, 0x73, 0x74, 0x20, 0x72, 0x65, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65,
+	0x20, 0x74, 0x68, 0x65, 0x20, 0x61, 0x62, 0x6f, 0x76, 0x65, 0x20, 0x63, 0x6f, 0x70, 0x79, 0x72,
+	0x69, 0x67, 0x68, 0x74, 0x20, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x2c, 0x20, 0x74, 0x68, 0x69,
+	0x73, 0x20, 0x6c, 0x69, 0x73, 0x74, 0x20, 0x6f, 0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c,
+	0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65,
+	0x72, 0x20, 0x69, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e,
+	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x61, 0x6e, 0x64, 0x2f, 0x6f, 0x72, 0x20, 0x6f, 0x74,
+	0x68, 0x65, 0x72, 0x20, 0x6d, 0x61, 0x74, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x20, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x64, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x74, 0x68, 0x65, 0x20,
+	0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a, 0x20,
+	0x20, 0x20, 0x2a, 0x20, 0x4e, 0x65, 0x69, 0x74, 0x68, 0x65, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20,
+	0x6e, 0x61, 0x6d, 0x65, 0x20, 0x6f, 0x66, 0x20, 0x47, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x20, 0x49,
+	0x6e, 0x63, 0x2e, 0x20, 0x6e, 0x6f, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+	0x73, 0x20, 0x6f, 0x66, 0x20, 0x69, 0x74, 0x73, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x6f, 0x72, 0x73, 0x20, 0x6d, 0x61, 0x79, 0x20, 0x62, 0x65, 0x20, 0x75, 0x73, 0x65,
+	0x64, 0x20, 0x74, 0x6f, 0x20, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x73, 0x65, 0x20, 0x6f, 0x72, 0x20,
+	0x70, 0x72, 0x6f, 0x6d, 0x6f, 0x74, 0x65, 0x20, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x73,
+	0x20, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x20, 0x66, 0x72, 0x6f, 0x6d, 0x20, 0x74, 0x68,
+	0x69, 0x73, 0x20, 0x73, 0x6f, 0x66, 0x74, 0x77, 0x61, 0x72, 0x65, 0x20, 0x77, 0x69, 0x74, 0x68,
+	0x6f, 0x75, 0x74, 0x20, 0x73, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x20, 0x70, 0x72, 0x69,
+	0x6f, 0x72, 0x20, 0x77, 0x72, 0x69, 0x74, 0x74, 0x65, 0x6e, 0x20, 0x70, 0x65, 0x72, 0x6d, 0x69,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d,
+	0x45, 0x52, 0x3a, 0x20, 0x54, 0x48, 0x49, 0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52,
+	0x45, 0x20, 0x49, 0x53, 0x20, 0x50, 0x52, 0x4f, 0x56, 0x49, 0x44, 0x45, 0x44, 0x20, 0x42, 0x59,
+	0x20, 0x54, 0x48, 0x45, 0x20, 0x43, 0x4f, 0x50, 0x59, 0x52, 0x49, 0x47, 0x48, 0x54, 0x20, 0x48,
+	0x4f, 0x4c, 0x44, 0x45, 0x52, 0x53, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52,
+	0x49, 0x42, 0x55, 0x54, 0x4f, 0x52, 0x53, 0x20, 0x22, 0x41, 0x53, 0x20, 0x49, 0x53, 0x22, 0x20,
+	0x41, 0x4e, 0x44, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x45, 0x58, 0x50, 0x52, 0x45, 0x53, 0x53, 0x20,
+	0x4f, 0x52, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x45, 0x44, 0x20, 0x57, 0x41, 0x52, 0x52, 0x41,
+	0x4e, 0x54, 0x49, 0x45, 0x53, 0x2c, 0x20, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47,
+	0x2c, 0x20, 0x42, 0x55, 0x54, 0x20, 0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45,
+	0x44, 0x20, 0x54, 0x4f, 0x2c, 0x20, 0x54, 0x48, 0x45, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x45,
+	0x44, 0x20, 0x57, 0x41, 0x52, 0x52, 0x41, 0x4e, 0x54, 0x49, 0x45, 0x53, 0x20, 0x4f, 0x46, 0x20,
+	0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x20,
+	0x41, 0x4e, 0x44, 0x20, 0x46, 0x49, 0x54, 0x4e, 0x45, 0x53, 0x53, 0x20, 0x46, 0x4f, 0x52, 0x20,
+	0x41, 0x20, 0x50, 0x41, 0x52, 0x54, 0x49, 0x43, 0x55, 0x4c, 0x41, 0x52, 0x20, 0x50, 0x55, 0x52,
+	0x50, 0x4f, 0x53, 0x45, 0x20, 0x41, 0x52, 0x45, 0x20, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49,
+	0x4d, 0x45, 0x44, 0x2e, 0x20, 0x49, 0x4e, 0x20, 0x4e, 0x4f, 0x20, 0x45, 0x56, 0x45, 0x4e, 0x54,
+	0x20, 0x53, 0x48, 0x41, 0x4c, 0x4c, 0x20, 0x54, 0x48, 0x45, 0x20, 0x43, 0x4f, 0x50, 0x59, 0x52,
+	0x49, 0x47, 0x48, 0x54, 0x20, 0x4f, 0x57, 0x4e, 0x45, 0x52, 0x20, 0x4f, 0x52, 0x20, 0x43, 0x4f,
+	0x4e, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x4f, 0x52, 0x53, 0x20, 0x42, 0x45, 0x20, 0x4c, 0x49,
+	0x41, 0x42, 0x4c, 0x45, 0x20, 0x46, 0x4f, 0x52, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x44, 0x49, 0x52,
+	0x45, 0x43, 0x54, 0x2c, 0x20, 0x49, 0x4e, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x2c, 0x20, 0x49,
+	0x4e, 0x43, 0x49, 0x44, 0x45, 0x4e, 0x54, 0x41, 0x4c, 0x2c, 0x20, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x41, 0x4c, 0x2c, 0x20, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x59, 0x2c, 0x20, 0x4f,
+	0x52, 0x20, 0x43, 0x4f, 0x4e, 0x53, 0x45, 0x51, 0x55, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x20,
+	0x44, 0x41, 0x4d, 0x41, 0x47, 0x45, 0x53, 0x20, 0x28, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49,
+	0x4e, 0x47, 0x2c, 0x20, 0x42, 0x55, 0x54, 0x20, 0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49,
+	0x54, 0x45, 0x44, 0x20, 0x54, 0x4f, 0x2c, 0x20, 0x50, 0x52, 0x4f, 0x43, 0x55, 0x52, 0x45, 0x4d,
+	0x45, 0x4e, 0x54, 0x20, 0x4f, 0x46, 0x20, 0x53, 0x55, 0x42, 0x53, 0x54, 0x49, 0x54, 0x55, 0x54,
+	0x45, 0x20, 0x47, 0x4f, 0x4f, 0x44, 0x53, 0x20, 0x4f, 0x52, 0x20, 0x53, 0x45, 0x52, 0x56, 0x49,
+	0x43, 0x45, 0x53, 0x3b, 0x20, 0x4c, 0x4f, 0x53, 0x53, 0x20, 0x4f, 0x46, 0x20, 0x55, 0x53, 0x45,
+	0x2c, 0x20, 0x44, 0x41, 0x54, 0x41, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x50, 0x52, 0x4f, 0x46, 0x49,
+	0x54, 0x53, 0x3b, 0x20, 0x4f, 0x52, 0x20, 0x42, 0x55, 0x53, 0x49, 0x4e, 0x45, 0x53, 0x53, 0x20,
+	0x49, 0x4e, 0x54, 0x45, 0x52, 0x52, 0x55, 0x50, 0x54, 0x49, 0x4f, 0x4e, 0x29, 0x20, 0x48, 0x4f,
+	0x57, 0x45, 0x56, 0x45, 0x52, 0x20, 0x43, 0x41, 0x55, 0x53, 0x45, 0x44, 0x20, 0x41, 0x4e, 0x44,
+	0x20, 0x4f, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x54, 0x48, 0x45, 0x4f, 0x52, 0x59, 0x20, 0x4f,
+	0x46, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x2c, 0x20, 0x57, 0x48, 0x45,
+	0x54, 0x48, 0x45, 0x52, 0x20, 0x49, 0x4e, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x41, 0x43, 0x54,
+	0x2c, 0x20, 0x53, 0x54, 0x52, 0x49, 0x43, 0x54, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c, 0x49,
+	0x54, 0x59, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x54, 0x4f, 0x52, 0x54, 0x20, 0x28, 0x49, 0x4e, 0x43,
+	0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x20, 0x4e, 0x45, 0x47, 0x4c, 0x49, 0x47, 0x45, 0x4e, 0x43,
+	0x45, 0x20, 0x4f, 0x52, 0x20, 0x4f, 0x54, 0x48, 0x45, 0x52, 0x57, 0x49, 0x53, 0x45, 0x29, 0x20,
+	0x41, 0x52, 0x49, 0x53, 0x49, 0x4e, 0x47, 0x20, 0x49, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x57,
+	0x41, 0x59, 0x20, 0x4f, 0x55, 0x54, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x55, 0x53,
+	0x45, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x49, 0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41,
+	0x52, 0x45, 0x2c, 0x20, 0x45, 0x56, 0x45, 0x4e, 0x20, 0x49, 0x46, 0x20, 0x41, 0x44, 0x56, 0x49,
+	0x53, 0x45, 0x44, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x50, 0x4f, 0x53, 0x53, 0x49,
+	0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x20, 0x4f, 0x46, 0x20, 0x53, 0x55, 0x43, 0x48, 0x20, 0x44,
+	0x41, 0x4d, 0x41, 0x47, 0x45, 0x2e, 0x47, 0x6f, 0x20, 0x49, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x00,
+	0x43, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x28, 0x00, 0x63, 0x00, 0x29, 0x00, 0x20, 0x00, 0x32, 0x00, 0x30, 0x00,
+	0x31, 0x00, 0x36, 0x00, 0x20, 0x00, 0x62, 0x00, 0x79, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00,
+	0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00,
+	0x20, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x72, 0x00, 0x65, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x2e, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x26, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x49, 0x00,
+	0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x3a, 0x00, 0x20, 0x00,
+	0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x56, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x73, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x32, 0x00, 0x2e, 0x00, 0x30, 0x00,
+	0x31, 0x00, 0x30, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x74, 0x00, 0x74, 0x00, 0x66, 0x00, 0x61, 0x00,
+	0x75, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x68, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x28, 0x00, 0x76, 0x00, 0x31, 0x00, 0x2e, 0x00, 0x38, 0x00, 0x2e, 0x00, 0x33, 0x00, 0x29, 0x00,
+	0x47, 0x00, 0x6f, 0x00, 0x2d, 0x00, 0x49, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x4b, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x43, 0x00, 0x68, 0x00,
+	0x61, 0x00, 0x72, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x20, 0x00, 0x68, 0x00, 0x75, 0x00, 0x6d, 0x00,
+	0x61, 0x00, 0x6e, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x2d, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x66, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x75, 0x00,
+	0x61, 0x00, 0x67, 0x00, 0x65, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x74, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x78, 0x00, 0x2d, 0x00, 0x68, 0x00, 0x65, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x73, 0x00, 0x74, 0x00, 0x65, 0x00, 0x6d, 0x00, 0x20, 0x00,
+	0x77, 0x00, 0x65, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x74, 0x00, 0x69, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00,
+	0x20, 0x00, 0x7a, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x63, 0x00,
+	0x61, 0x00, 0x70, 0x00, 0x69, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x65, 0x00, 0x72, 0x00, 0x63, 0x00,
+	0x61, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x66, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x75, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x63, 0x00,
+	0x61, 0x00, 0x70, 0x00, 0x69, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00,
+	0x31, 0x00, 0x34, 0x00, 0x35, 0x00, 0x30, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x67, 0x00, 0x69, 0x00, 0x62, 0x00, 0x69, 0x00,
+	0x6c, 0x00, 0x69, 0x00, 0x74, 0x00, 0x79, 0x00, 0x20, 0x00, 0x73, 0x00, 0x74, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x61, 0x00, 0x72, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x47, 0x00,
+	0x6f, 0x00, 0x27, 0x00, 0x73, 0x00, 0x20, 0x00, 0x57, 0x00, 0x47, 0x00, 0x4c, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x68, 0x00, 0x61, 0x00, 0x72, 0x00, 0x61, 0x00, 0x63, 0x00, 0x74, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x20, 0x00, 0x73, 0x00, 0x65, 0x00, 0x74, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x63, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x64, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x55, 0x00,
+	0x6e, 0x00, 0x69, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x65, 0x00, 0x20, 0x00, 0x4c, 0x00,
+	0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x47, 0x00, 0x72, 0x00,
+	0x65, 0x00, 0x65, 0x00, 0x6b, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x43, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x70, 0x00, 0x68, 0x00, 0x61, 0x00, 0x62, 0x00, 0x65, 0x00,
+	0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x70, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x79, 0x00, 0x6d, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x67, 0x00, 0x72, 0x00, 0x61, 0x00, 0x70, 0x00,
+	0x68, 0x00, 0x69, 0x00, 0x63, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x65, 0x00, 0x6c, 0x00,
+	0x65, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x2e, 0x00, 0x6c, 0x00,
+	0x75, 0x00, 0x63, 0x00, 0x69, 0x00, 0x64, 0x00, 0x61, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x74, 0x00, 0x73, 0x00, 0x2e, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x43, 0x00, 0x6f, 0x00,
+	0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x28, 0x00, 0x63, 0x00, 0x29, 0x00, 0x20, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00,
+	0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x67, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x6e, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x62, 0x00, 0x79, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00,
+	0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x65, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x65, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x66, 0x00,
+	0x20, 0x00, 0x79, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x20, 0x00, 0x61, 0x00, 0x67, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x73, 0x00,
+	0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x75, 0x00,
+	0x64, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x69, 0x00,
+	0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x69, 0x00, 0x66, 0x00, 0x79, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x75, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x73, 0x00,
+	0x6f, 0x00, 0x75, 0x00, 0x72, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00,
+	0x64, 0x00, 0x20, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x72, 0x00, 0x79, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x73, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00,
+	0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x69, 0x00, 0x66, 0x00, 0x69, 0x00, 0x63, 0x00, 0x61, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x72, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x70, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x69, 0x00, 0x74, 0x00,
+	0x74, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x76, 0x00,
+	0x69, 0x00, 0x64, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x61, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x74, 0x00, 0x3a, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00,
+	0x2a, 0x00, 0x20, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x75, 0x00,
+	0x72, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x6d, 0x00, 0x75, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x74, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00,
+	0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00, 0x6c, 0x00,
+	0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00,
+	0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00,
+	0x62, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x72, 0x00, 0x79, 0x00, 0x20, 0x00, 0x66, 0x00,
+	0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x75, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x75, 0x00,
+	0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x62, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x70, 0x00,
+	0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6e, 0x00,
+	0x6f, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00,
+	0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00,
+	0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x69, 0x00,
+	0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x63, 0x00, 0x75, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x2f, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x61, 0x00,
+	0x74, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x69, 0x00, 0x64, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2e, 0x00, 0x0a, 0x00,
+	0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x65, 0x00,
+	0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x66, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x6f, 0x00, 0x67, 0x00, 0x6c, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x61, 0x00,
+	0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x6d, 0x00, 0x61, 0x00, 0x79, 0x00, 0x20, 0x00, 0x62, 0x00, 0x65, 0x00, 0x20, 0x00, 0x75, 0x00,
+	0x73, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x65, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x74, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x75, 0x00, 0x63, 0x00,
+	0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x64, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x76, 0x00,
+	0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x66, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x66, 0x00,
+	0x74, 0x00, 0x77, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x74, 0x00, 0x20, 0x00, 0x73, 0x00, 0x70, 0x00,
+	0x65, 0x00, 0x63, 0x00, 0x69, 0x00, 0x66, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00, 0x70, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x77, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x74, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x70, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x6d, 0x00, 0x69, 0x00, 0x73, 0x00, 0x73, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2e, 0x00,
+	0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00, 0x49, 0x00, 0x53, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x41, 0x00,
+	0x49, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x52, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00,
+	0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x53, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x54, 0x00, 0x57, 0x00,
+	0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x20, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x50, 0x00,
+	0x52, 0x00, 0x4f, 0x00, 0x56, 0x00, 0x49, 0x00, 0x44, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00,
+	0x42, 0x00, 0x59, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x43, 0x00,
+	0x4f, 0x00, 0x50, 0x00, 0x59, 0x00, 0x52, 0x00, 0x49, 0x00, 0x47, 0x00, 0x48, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x48, 0x00, 0x4f, 0x00, 0x4c, 0x00, 0x44, 0x00, 0x45, 0x00, 0x52, 0x00, 0x53, 0x00,
+	0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00,
+	0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x22, 0x00, 0x41, 0x00, 0x53, 0x00, 0x20, 0x00, 0x49, 0x00, 0x53, 0x00,
+	0x22, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x45, 0x00, 0x58, 0x00, 0x50, 0x00, 0x52, 0x00, 0x45, 0x00, 0x53, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x50, 0x00,
+	0x4c, 0x00, 0x49, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00,
+	0x52, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x45, 0x00, 0x53, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x20, 0x00,
+	0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x49, 0x00,
+	0x54, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00,
+	0x49, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x52, 0x00,
+	0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x45, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x20, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x52, 0x00, 0x43, 0x00, 0x48, 0x00, 0x41, 0x00,
+	0x4e, 0x00, 0x54, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x46, 0x00, 0x49, 0x00,
+	0x54, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x46, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x41, 0x00, 0x20, 0x00, 0x50, 0x00, 0x41, 0x00, 0x52, 0x00, 0x54, 0x00,
+	0x49, 0x00, 0x43, 0x00, 0x55, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x52, 0x00, 0x20, 0x00, 0x50, 0x00,
+	0x55, 0x00, 0x52, 0x00, 0x50, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x45, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x52, 0x00, 0x45, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x53, 0x00, 0x43, 0x00, 0x4c, 0x00,
+	0x41, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x44, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x20, 0x00, 0x45, 0x00, 0x56, 0x00, 0x45, 0x00,
+	0x4e, 0x00, 0x54, 0x00, 0x20, 0x00, 0x53, 0x00, 0x48, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x4c, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x50, 0x00,
+	0x59, 0x00, 0x52, 0x00, 0x49, 0x00, 0x47, 0x00, 0x48, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x57, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x42, 0x00, 0x55, 0x00,
+	0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x42, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x4c, 0x00, 0x45, 0x00, 0x20, 0x00, 0x46, 0x00,
+	0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x44, 0x00,
+	0x49, 0x00, 0x52, 0x00, 0x45, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x44, 0x00, 0x49, 0x00, 0x52, 0x00, 0x45, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x49, 0x00, 0x44, 0x00, 0x45, 0x00, 0x4e, 0x00,
+	0x54, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x53, 0x00, 0x50, 0x00, 0x45, 0x00,
+	0x43, 0x00, 0x49, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x45, 0x00, 0x58, 0x00,
+	0x45, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x52, 0x00, 0x59, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x53, 0x00,
+	0x45, 0x00, 0x51, 0x00, 0x55, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x41, 0x00,
+	0x4c, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x4d, 0x00, 0x41, 0x00, 0x47, 0x00, 0x45, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x28, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00,
+	0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00,
+	0x54, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00,
+	0x4d, 0x00, 0x49, 0x00, 0x54, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x43, 0x00, 0x55, 0x00, 0x52, 0x00,
+	0x45, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00,
+	0x20, 0x00, 0x53, 0x00, 0x55, 0x00, 0x42, 0x00, 0x53, 0x00, 0x54, 0x00, 0x49, 0x00, 0x54, 0x00,
+	0x55, 0x00, 0x54, 0x00, 0x45, 0x00, 0x20, 0x00, 0x47, 0x00, 0x4f, 0x00, 0x4f, 0x00, 0x44, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x53, 0x00, 0x45, 0x00, 0x52, 0x00,
+	0x56, 0x00, 0x49, 0x00, 0x43, 0x00, 0x45, 0x00, 0x53, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x4c, 0x00,
+	0x4f, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x55, 0x00,
+	0x53, 0x00, 0x45, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x54, 0x00, 0x41, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x49, 0x00, 0x54, 0x00, 0x53, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x53, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x53, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x45, 0x00, 0x52, 0x00, 0x52, 0x00,
+	0x55, 0x00, 0x50, 0x00, 0x54, 0x00, 0x49, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x29, 0x00, 0x20, 0x00,
+	0x48, 0x00, 0x4f, 0x00, 0x57, 0x00, 0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x43, 0x00, 0x41, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x59, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00,
+	0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x57, 0x00,
+	0x48, 0x00, 0x45, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x41, 0x00,
+	0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x53, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00,
+	0x43, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00,
+	0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x54, 0x00, 0x20, 0x00, 0x28, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00,
+	0x20, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x47, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x47, 0x00, 0x45, 0x00,
+	0x4e, 0x00, 0x43, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x52, 0x00, 0x57, 0x00, 0x49, 0x00, 0x53, 0x00, 0x45, 0x00,
+	0x29, 0x00, 0x20, 0x00, 0x41, 0x00, 0x52, 0x00, 0x49, 0x00, 0x53, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x47, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00,
+	0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x55, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00,
+	0x48, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x53, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x54, 0x00,
+	0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x45, 0x00, 0x56, 0x00,
+	0x45, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x46, 0x00, 0x20, 0x00, 0x41, 0x00, 0x44, 0x00,
+	0x56, 0x00, 0x49, 0x00, 0x53, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x50, 0x00, 0x4f, 0x00, 0x53, 0x00,
+	0x53, 0x00, 0x49, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x53, 0x00, 0x55, 0x00, 0x43, 0x00, 0x48, 0x00,
+	0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x4d, 0x00, 0x41, 0x00, 0x47, 0x00, 0x45, 0x00, 0x2e, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0xff, 0xf5, 0x00, 0x00, 0xfe, 0xed, 0x00, 0x32, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x02, 0xc8, 0x00, 0x00, 0x02, 0x07, 0x02, 0x08, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05, 0x00, 0x06,
+	0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d, 0x00, 0x0e,
+	0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15, 0x00, 0x16,
+	0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d, 0x00, 0x1e,
+	0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24, 0x00, 0x25, 0x00, 0x26,
+	0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d, 0x00, 0x2e,
+	0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35, 0x00, 0x36,
+	0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d, 0x00, 0x3e,
+	0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44, 0x00, 0x45, 0x00, 0x46,
+	0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d, 0x00, 0x4e,
+	0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54, 0x00, 0x55, 0x00, 0x56,
+	0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d, 0x00, 0x5e,
+	0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x02, 0x09, 0x00, 0xa3, 0x00, 0x84, 0x00, 0x85, 0x00, 0xbd,
+	0x00, 0x96, 0x00, 0xe8, 0x00, 0x86, 0x00, 0x8e, 0x00, 0x8b, 0x00, 0x9d, 0x00, 0xa9, 0x00, 0xa4,
+	0x02, 0x0a, 0x00, 0x8a, 0x00, 0xda, 0x00, 0x83, 0x00, 0x93, 0x02, 0x0b, 0x02, 0x0c, 0x00, 0x8d,
+	0x00, 0x97, 0x00, 0x88, 0x00, 0xc3, 0x00, 0xde, 0x02, 0x0d, 0x00, 0x9e, 0x00, 0xaa, 0x00, 0xf5,
+	0x00, 0xf4, 0x00, 0xf6, 0x00, 0xa2, 0x00, 0xad, 0x00, 0xc9, 0x00, 0xc7, 0x00, 0xae, 0x00, 0x62,
+	0x00, 0x63, 0x00, 0x90, 0x00, 0x64, 0x00, 0xcb, 0x00, 0x65, 0x00, 0xc8, 0x00, 0xca, 0x00, 0xcf,
+	0x00, 0xcc, 0x00, 0xcd, 0x00, 0xce, 0x00, 0xe9, 0x00, 0x66, 0x00, 0xd3, 0x00, 0xd0, 0x00, 0xd1,
+	0x00, 0xaf, 0x00, 0x67, 0x00, 0xf0, 0x00, 0x91, 0x00, 0xd6, 0x00, 0xd4, 0x00, 0xd5, 0x00, 0x68,
+	0x00, 0xeb, 0x00, 0xed, 0x00, 0x89, 0x00, 0x6a, 0x00, 0x69, 0x00, 0x6b, 0x00, 0x6d, 0x00, 0x6c,
+	0x00, 0x6e, 0x00, 0xa0, 0x00, 0x6f, 0x00, 0x71, 0x00, 0x70, 0x00, 0x72, 0x00, 0x73, 0x00, 0x75,
+	0x00, 0x74, 0x00, 0x76, 0x00, 0x77, 0x00, 0xea, 0x00, 0x78, 0x00, 0x7a, 0x00, 0x79, 0x00, 0x7b,
+	0x00, 0x7d, 0x00, 0x7c, 0x00, 0xb8, 0x00, 0xa1, 0x00, 0x7f, 0x00, 0x7e, 0x00, 0x80, 0x00, 0x81,
+	0x00, 0xec, 0x00, 0xee, 0x00, 0xba, 0x01, 0x06, 0x01, 0x88, 0x01, 0x03, 0x01, 0x84, 0x01, 0x07,
+	0x01, 0x8a, 0x00, 0xfd, 0x00, 0xfe, 0x01, 0x0a, 0x01, 0x95, 0x01, 0x0b, 0x01, 0x96, 0x00, 0xff,
+	0x01, 0x00, 0x01, 0x0d, 0x01, 0x9a, 0x01, 0x0e, 0x01, 0x01, 0x01, 0x12, 0x01, 0xa3, 0x01, 0x0f,
+	0x01, 0xa0, 0x01, 0x11, 0x01, 0xa2, 0x01, 0x14, 0x01, 0xa5, 0x01, 0x10, 0x01, 0xa1, 0x01, 0x1b,
+	0x01, 0xb2, 0x00, 0xf8, 0x00, 0xf9, 0x01, 0x1c, 0x01, 0xb3, 0x02, 0x0e, 0x02, 0x0f, 0x01, 0x22,
+	0x01, 0xb6, 0x01, 0x21, 0x01, 0xb5, 0x01, 0x2a, 0x01, 0xc7, 0x01, 0x25, 0x01, 0xbb, 0x01, 0x24,
+	0x01, 0xb9, 0x01, 0x26, 0x01, 0xc2, 0x00, 0xfa, 0x00, 0xd7, 0x01, 0x23, 0x01, 0xba, 0x01, 0x2b,
+	0x01, 0xc8, 0x02, 0x10, 0x02, 0x11, 0x01, 0xca, 0x01, 0x2d, 0x01, 0xcb, 0x02, 0x12, 0x02, 0x13,
+	0x01, 0x2f, 0x01, 0xcd, 0x01, 0x30, 0x01, 0xce, 0x00, 0xe2, 0x00, 0xe3, 0x01, 0x32, 0x01, 0xd7,
+	0x02, 0x14, 0x02, 0x15, 0x01, 0x33, 0x01, 0xd9, 0x01, 0xd8, 0x01, 0x13, 0x01, 0xa4, 0x01, 0x37,
+	0x01, 0xdd, 0x01, 0x35, 0x01, 0xdb, 0x01, 0x36, 0x01, 0xdc, 0x00, 0xb0, 0x00, 0xb1, 0x01, 0x3f,
+	0x01, 0xea, 0x02, 0x16, 0x02, 0x17, 0x01, 0x40, 0x01, 0xeb, 0x01, 0x6a, 0x01, 0xef, 0x01, 0x6b,
+	0x01, 0xf0, 0x00, 0xfb, 0x00, 0xfc, 0x00, 0xe4, 0x00, 0xe5, 0x02, 0x18, 0x02, 0x19, 0x01, 0x6f,
+	0x01, 0xfb, 0x01, 0x6e, 0x01, 0xfa, 0x01, 0x79, 0x02, 0xc4, 0x01, 0x73, 0x02, 0x05, 0x01, 0x71,
+	0x02, 0x03, 0x01, 0x78, 0x02, 0xc3, 0x01, 0x72, 0x02, 0x04, 0x01, 0x74, 0x02, 0xbd, 0x01, 0x7b,
+	0x02, 0xc6, 0x01, 0x7f, 0x02, 0xca, 0x00, 0xbb, 0x01, 0x81, 0x02, 0xcc, 0x01, 0x82, 0x02, 0xcd,
+	0x00, 0xe6, 0x00, 0xe7, 0x01, 0xd1, 0x00, 0xa6, 0x02, 0x1a, 0x02, 0x1b, 0x02, 0x1c, 0x02, 0x1d,
+	0x02, 0x1e, 0x02, 0x1f, 0x02, 0x20, 0x02, 0x21, 0x02, 0x22, 0x02, 0x23, 0x02, 0x24, 0x02, 0x25,
+	0x02, 0x26, 0x02, 0x27, 0x02, 0x28, 0x02, 0x29, 0x01, 0x08, 0x01, 0x8b, 0x01, 0x02, 0x01, 0x85,
+	0x01, 0x3b, 0x01, 0xe5, 0x02, 0x2a, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0x2d, 0x00, 0xd8, 0x00, 0xe1,
+	0x02, 0x2e, 0x00, 0xdb, 0x00, 0xdc, 0x00, 0xdd, 0x00, 0xe0, 0x00, 0xd9, 0x00, 0xdf, 0x02, 0x2f,
+	0x01, 0xfe, 0x01, 0x9d, 0x01, 0x05, 0x01, 0x89, 0x01, 0x16, 0x01, 0x18, 0x01, 0x29, 0x01, 0x3a,
+	0x01, 0x77, 0x01, 0x38, 0x01, 0xc5, 0x01, 0x04, 0x01, 0x09, 0x01, 0x1a, 0x02, 0x30, 0x01, 0x15,
+	0x01, 0x83, 0x01, 0x17, 0x01, 0x70, 0x01, 0x27, 0x01, 0x2c, 0x01, 0x2e, 0x01, 0x31, 0x01, 0x34,
+	0x01, 0x7e, 0x01, 0x39, 0x01, 0x3d, 0x01, 0x41, 0x01, 0x6c, 0x01, 0x6d, 0x01, 0x75, 0x01, 0x3c,
+	0x01, 0x0c, 0x01, 0x3e, 0x02, 0x31, 0x01, 0x28, 0x01, 0x76, 0x01, 0x87, 0x01, 0xa7, 0x01, 0xab,
+	0x01, 0xc6, 0x02, 0xc1, 0x01, 0x86, 0x01, 0x93, 0x01, 0xb1, 0x01, 0x9b, 0x01, 0xa6, 0x02, 0xd0,
+	0x01, 0xaa, 0x01, 0xfc, 0x01, 0xc3, 0x01, 0xc9, 0x01, 0xcc, 0x02, 0x32, 0x01, 0xda, 0x02, 0xc9,
+	0x01, 0xe0, 0x00, 0x9b, 0x01, 0xed, 0x01, 0xf5, 0x01, 0xf4, 0x01, 0xf9, 0x02, 0xbf, 0x01, 0xe7,
+	0x01, 0x97, 0x01, 0xe8, 0x01, 0xde, 0x01, 0xc4, 0x02, 0xc0, 0x01, 0xe1, 0x02, 0xc2, 0x01, 0xdf,
+	0x02, 0x33, 0x02, 0x34, 0x02, 0x35, 0x02, 0x36, 0x02, 0x37, 0x02, 0x38, 0x02, 0x39, 0x02, 0x3a,
+	0x02, 0x3b, 0x02, 0x3c, 0x02, 0x3d, 0x02, 0x3e, 0x02, 0x3f, 0x02, 0x40, 0x02, 0x41, 0x02, 0x42,
+	0x02, 0x43, 0x02, 0x44, 0x02, 0x45, 0x02, 0x46, 0x02, 0x47, 0x02, 0x48, 0x02, 0x49, 0x02, 0x4a,
+	0x02, 0x4b, 0x02, 0x4c, 0x02, 0x4d, 0x02, 0x4e, 0x02, 0x4f, 0x02, 0x50, 0x02, 0x51, 0x02, 0x52,
+	0x02, 0x53, 0x02, 0x54, 0x02, 0x55, 0x02, 0x56, 0x02, 0x57, 0x02, 0x58, 0x02, 0x59, 0x02, 0x5a,
+	0x02, 0x5b, 0x02, 0x5c, 0x02, 0x5d, 0x02, 0x5e, 0x02, 0x5f, 0x02, 0x60, 0x02, 0x61, 0x02, 0x62,
+	0x02, 0x63, 0x02, 0x64, 0x02, 0x65, 0x02, 0x66, 0x02, 0x67, 0x02, 0x68, 0x02, 0x69, 0x02, 0x6a,
+	0x02, 0x6b, 0x02, 0x6c, 0x02, 0x6d, 0x02, 0x6e, 0x02, 0x6f, 0x02, 0x70, 0x02, 0x71, 0x02, 0x72,
+	0x02, 0x73, 0x02, 0x74, 0x02, 0x75, 0x02, 0x76, 0x02, 0x77, 0x02, 0x78, 0x02, 0x79, 0x02, 0x7a,
+	0x02, 0x7b, 0x02, 0x7c, 0x02, 0x7d, 0x02, 0x7e, 0x02, 0x7f, 0x02, 0x80, 0x02, 0x81, 0x02, 0x82,
+	0x02, 0x83, 0x02, 0x84, 0x02, 0x85, 0x02, 0x86, 0x02, 0x87, 0x02, 0x88, 0x02, 0x89, 0x02, 0x8a,
+	0x02, 0x8b, 0x02, 0x8c, 0x02, 0x8d, 0x02, 0x8e, 0x02, 0x8f, 0x02, 0x90, 0x02, 0x91, 0x02, 0x92,
+	0x02, 0x93, 0x02, 0x94, 0x01, 0x7d, 0x02, 0xc8, 0x01, 0x7a, 0x02, 0xc5, 0x01, 0x7c, 0x02, 0xc7,
+	0x01, 0x80, 0x02, 0xcb, 0x00, 0xb2, 0x00, 0xb3, 0x02, 0x95, 0x02, 0x06, 0x00, 0xb6, 0x00, 0xb7,
+	0x00, 0xc4, 0x01, 0xe9, 0x00, 0xb4, 0x00, 0xb5, 0x00, 0xc5, 0x00, 0x82, 0x00, 0xc2, 0x00, 0x87,
+	0x00, 0xab, 0x00, 0xc6, 0x01, 0xd4, 0x01, 0xf1, 0x00, 0xbe, 0x00, 0xbf, 0x01, 0xac, 0x02, 0x96,
+	0x00, 0xbc, 0x02, 0x97, 0x02, 0x98, 0x02, 0x99, 0x02, 0x9a, 0x02, 0x9b, 0x02, 0x9c, 0x02, 0x9d,
+	0x02, 0x9e, 0x02, 0x9f, 0x02, 0xa0, 0x02, 0xa1, 0x02, 0xa2, 0x02, 0xa3, 0x02, 0xa4, 0x02, 0xa5,
+	0x02, 0xa6, 0x02, 0xa7, 0x02, 0xa8, 0x02, 0xa9, 0x02, 0xaa, 0x02, 0xab, 0x02, 0xac, 0x02, 0xad,
+	0x02, 0xae, 0x02, 0xaf, 0x02, 0xb0, 0x02, 0xb1, 0x02, 0xb2, 0x02, 0xb3, 0x00, 0xf7, 0x01, 0xd0,
+	0x01, 0xe6, 0x01, 0x19, 0x02, 0xb4, 0x02, 0xb5, 0x02, 0xb6, 0x00, 0x8c, 0x00, 0x9f, 0x01, 0xa9,
+	0x01, 0xe2, 0x01, 0xfd, 0x01, 0xb0, 0x01, 0xf2, 0x01, 0x8e, 0x01, 0x90, 0x01, 0x8f, 0x01, 0x8d,
+	0x01, 0x8c, 0x01, 0x91, 0x01, 0x92, 0x00, 0x98, 0x00, 0xa8, 0x00, 0x9a, 0x00, 0x99, 0x00, 0xef,
+	0x02, 0xb7, 0x02, 0xb8, 0x00, 0xa5, 0x00, 0x92, 0x01, 0xe4, 0x01, 0xbe, 0x02, 0xbc, 0x00, 0x9c,
+	0x00, 0xa7, 0x00, 0x8f, 0x01, 0xa8, 0x00, 0x94, 0x00, 0x95, 0x01, 0xb8, 0x01, 0xec, 0x01, 0xbd,
+	0x01, 0xbc, 0x01, 0x4b, 0x01, 0x4c, 0x01, 0x42, 0x01, 0x44, 0x01, 0x43, 0x01, 0x45, 0x01, 0x49,
+	0x01, 0x4a, 0x01, 0x47, 0x01, 0x48, 0x01, 0x46, 0x01, 0x5e, 0x01, 0x52, 0x01, 0x66, 0x01, 0x67,
+	0x01, 0x5a, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x53, 0x01, 0x65, 0x01, 0x64, 0x01, 0x59, 0x01, 0x56,
+	0x01, 0x55, 0x01, 0x54, 0x01, 0x57, 0x01, 0x58, 0x01, 0x5d, 0x01, 0x4d, 0x01, 0x4e, 0x01, 0x51,
+	0x01, 0x62, 0x01, 0x63, 0x01, 0x5c, 0x01, 0x60, 0x01, 0x61, 0x01, 0x5b, 0x01, 0x69, 0x01, 0x68,
+	0x01, 0x5f, 0x02, 0xbe, 0x01, 0x9f, 0x01, 0x94, 0x01, 0xcf, 0x01, 0xee, 0x01, 0xd2, 0x01, 0xf3,
+	0x01, 0x9e, 0x01, 0xae, 0x01, 0x20, 0x01, 0x1e, 0x01, 0x1f, 0x01, 0xaf, 0x02, 0x02, 0x02, 0x01,
+	0x01, 0xff, 0x02, 0x00, 0x00, 0xb9, 0x01, 0x98, 0x01, 0x1d, 0x01, 0xbf, 0x01, 0xc0, 0x01, 0xe3,
+	0x01, 0xf6, 0x01, 0xc1, 0x01, 0xf8, 0x01, 0xad, 0x01, 0xd3, 0x01, 0xf7, 0x01, 0x99, 0x01, 0xb7,
+	0x01, 0x9c, 0x01, 0xd5, 0x01, 0xd6, 0x01, 0xb4, 0x02, 0xb9, 0x02, 0xba, 0x02, 0xbb, 0x02, 0xce,
+	0x02, 0xcf, 0x07, 0x41, 0x45, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x41, 0x62, 0x72, 0x65, 0x76,
+	0x65, 0x05, 0x41, 0x6c, 0x70, 0x68, 0x61, 0x0a, 0x41, 0x6c, 0x70, 0x68, 0x61, 0x74, 0x6f, 0x6e,
+	0x6f, 0x73, 0x07, 0x41, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x41, 0x6f, 0x67, 0x6f, 0x6e,
+	0x65, 0x6b, 0x0a, 0x41, 0x72, 0x69, 0x6e, 0x67, 0x61, 0x63, 0x75, 0x74, 0x65, 0x04, 0x42, 0x65,
+	0x74, 0x61, 0x0b, 0x43, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x43,
+	0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x03, 0x43, 0x68, 0x69, 0x06, 0x44, 0x63,
+	0x61, 0x72, 0x6f, 0x6e, 0x06, 0x44, 0x63, 0x72, 0x6f, 0x61, 0x74, 0x06, 0x45, 0x62, 0x72, 0x65,
+	0x76, 0x65, 0x06, 0x45, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0a, 0x45, 0x64, 0x6f, 0x74, 0x61, 0x63,
+	0x63, 0x65, 0x6e, 0x74, 0x07, 0x45, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x03, 0x45, 0x6e, 0x67,
+	0x07, 0x45, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x45, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x0c, 0x45, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x03, 0x45, 0x74,
+	0x61, 0x08, 0x45, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x04, 0x45, 0x75, 0x72, 0x6f, 0x05,
+	0x47, 0x61, 0x6d, 0x6d, 0x61, 0x0b, 0x47, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65,
+	0x78, 0x0a, 0x47, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x06, 0x48, 0x31, 0x38,
+	0x35, 0x33, 0x33, 0x06, 0x48, 0x31, 0x38, 0x35, 0x34, 0x33, 0x06, 0x48, 0x31, 0x38, 0x35, 0x35,
+	0x31, 0x06, 0x48, 0x32, 0x32, 0x30, 0x37, 0x33, 0x04, 0x48, 0x62, 0x61, 0x72, 0x0b, 0x48, 0x63,
+	0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x02, 0x49, 0x4a, 0x06, 0x49, 0x62, 0x72,
+	0x65, 0x76, 0x65, 0x07, 0x49, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x49, 0x6f, 0x67, 0x6f,
+	0x6e, 0x65, 0x6b, 0x04, 0x49, 0x6f, 0x74, 0x61, 0x0c, 0x49, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65,
+	0x72, 0x65, 0x73, 0x69, 0x73, 0x09, 0x49, 0x6f, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x06,
+	0x49, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x0b, 0x4a, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c,
+	0x65, 0x78, 0x05, 0x4b, 0x61, 0x70, 0x70, 0x61, 0x06, 0x4c, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06,
+	0x4c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x06, 0x4c, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x04, 0x4c, 0x64,
+	0x6f, 0x74, 0x02, 0x4d, 0x75, 0x06, 0x4e, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x4e, 0x63, 0x61,
+	0x72, 0x6f, 0x6e, 0x02, 0x4e, 0x75, 0x06, 0x4f, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x4f, 0x68,
+	0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x4f, 0x6d, 0x61, 0x63,
+	0x72, 0x6f, 0x6e, 0x0a, 0x4f, 0x6d, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x4f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x0c, 0x4f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x0b, 0x4f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x61, 0x63, 0x75, 0x74, 0x65, 0x03,
+	0x50, 0x68, 0x69, 0x02, 0x50, 0x69, 0x03, 0x50, 0x73, 0x69, 0x06, 0x52, 0x61, 0x63, 0x75, 0x74,
+	0x65, 0x06, 0x52, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x03, 0x52, 0x68, 0x6f, 0x08, 0x53, 0x46, 0x30,
+	0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x30, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x34, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x30, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x36, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x38,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x31, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x31, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x30, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x32, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x32, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32,
+	0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x32, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x37, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x32, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x36, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x38,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x34, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x34, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x33, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x34, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x35, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34,
+	0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x34, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x30, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x35, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x32, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x34,
+	0x30, 0x30, 0x30, 0x30, 0x06, 0x53, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x53, 0x63, 0x69, 0x72,
+	0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x53, 0x69, 0x67, 0x6d, 0x61, 0x03, 0x54, 0x61,
+	0x75, 0x04, 0x54, 0x62, 0x61, 0x72, 0x06, 0x54, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x54, 0x68,
+	0x65, 0x74, 0x61, 0x06, 0x55, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x55, 0x68, 0x75, 0x6e, 0x67,
+	0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x55, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e,
+	0x07, 0x55, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x0f, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73,
+	0x0c, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x05, 0x55, 0x72,
+	0x69, 0x6e, 0x67, 0x06, 0x55, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x06, 0x57, 0x61, 0x63, 0x75, 0x74,
+	0x65, 0x0b, 0x57, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x09, 0x57, 0x64,
+	0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x06, 0x57, 0x67, 0x72, 0x61, 0x76, 0x65, 0x02, 0x58,
+	0x69, 0x0b, 0x59, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x59, 0x67,
+	0x72, 0x61, 0x76, 0x65, 0x06, 0x5a, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0a, 0x5a, 0x64, 0x6f, 0x74,
+	0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x04, 0x5a, 0x65, 0x74, 0x61, 0x06, 0x61, 0x62, 0x72, 0x65,
+	0x76, 0x65, 0x07, 0x61, 0x65, 0x61, 0x63, 0x75, 0x74, 0x65, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61,
+	0x0a, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x61, 0x6d, 0x61, 0x63,
+	0x72, 0x6f, 0x6e, 0x09, 0x61, 0x6e, 0x6f, 0x74, 0x65, 0x6c, 0x65, 0x69, 0x61, 0x07, 0x61, 0x6f,
+	0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x0a, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x61, 0x63, 0x75, 0x74, 0x65,
+	0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x62, 0x6f, 0x74, 0x68, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77,
+	0x64, 0x6f, 0x77, 0x6e, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x6c, 0x65, 0x66, 0x74, 0x0a, 0x61,
+	0x72, 0x72, 0x6f, 0x77, 0x72, 0x69, 0x67, 0x68, 0x74, 0x07, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75,
+	0x70, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x64, 0x6e, 0x0c, 0x61, 0x72, 0x72, 0x6f,
+	0x77, 0x75, 0x70, 0x64, 0x6e, 0x62, 0x73, 0x65, 0x04, 0x62, 0x65, 0x74, 0x61, 0x05, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x0b, 0x63, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a,
+	0x63, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x03, 0x63, 0x68, 0x69, 0x06, 0x63,
+	0x69, 0x72, 0x63, 0x6c, 0x65, 0x04, 0x63, 0x6c, 0x75, 0x62, 0x06, 0x64, 0x63, 0x61, 0x72, 0x6f,
+	0x6e, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x07, 0x64, 0x69, 0x61, 0x6d, 0x6f, 0x6e, 0x64, 0x0d,
+	0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x64, 0x6b,
+	0x73, 0x68, 0x61, 0x64, 0x65, 0x07, 0x64, 0x6e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x06, 0x65, 0x62,
+	0x72, 0x65, 0x76, 0x65, 0x06, 0x65, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0a, 0x65, 0x64, 0x6f, 0x74,
+	0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x07, 0x65, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x03, 0x65,
+	0x6e, 0x67, 0x07, 0x65, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x65, 0x70, 0x73, 0x69, 0x6c,
+	0x6f, 0x6e, 0x0c, 0x65, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0b,
+	0x65, 0x71, 0x75, 0x69, 0x76, 0x61, 0x6c, 0x65, 0x6e, 0x63, 0x65, 0x09, 0x65, 0x73, 0x74, 0x69,
+	0x6d, 0x61, 0x74, 0x65, 0x64, 0x03, 0x65, 0x74, 0x61, 0x08, 0x65, 0x74, 0x61, 0x74, 0x6f, 0x6e,
+	0x6f, 0x73, 0x09, 0x65, 0x78, 0x63, 0x6c, 0x61, 0x6d, 0x64, 0x62, 0x6c, 0x06, 0x66, 0x65, 0x6d,
+	0x61, 0x6c, 0x65, 0x09, 0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x62, 0x6f, 0x78, 0x0a, 0x66, 0x69,
+	0x6c, 0x6c, 0x65, 0x64, 0x72, 0x65, 0x63, 0x74, 0x0b, 0x66, 0x69, 0x76, 0x65, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x68, 0x73, 0x05, 0x67, 0x61, 0x6d, 0x6d, 0x61, 0x0b, 0x67, 0x63, 0x69, 0x72, 0x63,
+	0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x67, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e,
+	0x74, 0x06, 0x67, 0x6f, 0x70, 0x68, 0x65, 0x72, 0x04, 0x68, 0x62, 0x61, 0x72, 0x0b, 0x68, 0x63,
+	0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x68, 0x65, 0x61, 0x72, 0x74, 0x05,
+	0x68, 0x6f, 0x75, 0x73, 0x65, 0x06, 0x69, 0x62, 0x72, 0x65, 0x76, 0x65, 0x02, 0x69, 0x6a, 0x07,
+	0x69, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x61, 0x6c,
+	0x62, 0x74, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x61, 0x6c, 0x74, 0x70, 0x0c, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x09, 0x69, 0x6e, 0x76, 0x62, 0x75,
+	0x6c, 0x6c, 0x65, 0x74, 0x09, 0x69, 0x6e, 0x76, 0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x0c, 0x69,
+	0x6e, 0x76, 0x73, 0x6d, 0x69, 0x6c, 0x65, 0x66, 0x61, 0x63, 0x65, 0x07, 0x69, 0x6f, 0x67, 0x6f,
+	0x6e, 0x65, 0x6b, 0x04, 0x69, 0x6f, 0x74, 0x61, 0x0c, 0x69, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65,
+	0x72, 0x65, 0x73, 0x69, 0x73, 0x11, 0x69, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73,
+	0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x69, 0x6f, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f,
+	0x73, 0x06, 0x69, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x0b, 0x6a, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d,
+	0x66, 0x6c, 0x65, 0x78, 0x05, 0x6b, 0x61, 0x70, 0x70, 0x61, 0x0c, 0x6b, 0x67, 0x72, 0x65, 0x65,
+	0x6e, 0x6c, 0x61, 0x6e, 0x64, 0x69, 0x63, 0x06, 0x6c, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x6c,
+	0x61, 0x6d, 0x62, 0x64, 0x61, 0x06, 0x6c, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x04, 0x6c, 0x64, 0x6f,
+	0x74, 0x07, 0x6c, 0x66, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x04, 0x6c, 0x69, 0x72, 0x61, 0x05, 0x6c,
+	0x6f, 0x6e, 0x67, 0x73, 0x07, 0x6c, 0x74, 0x73, 0x68, 0x61, 0x64, 0x65, 0x04, 0x6d, 0x61, 0x6c,
+	0x65, 0x06, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x0b, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x61, 0x6c,
+	0x6e, 0x6f, 0x74, 0x65, 0x0e, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x65,
+	0x64, 0x62, 0x6c, 0x06, 0x6e, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x6e, 0x61, 0x70, 0x6f, 0x73,
+	0x74, 0x72, 0x6f, 0x70, 0x68, 0x65, 0x06, 0x6e, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x02, 0x6e, 0x75,
+	0x06, 0x6f, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x6f, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75,
+	0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x6f, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x05, 0x6f, 0x6d,
+	0x65, 0x67, 0x61, 0x0a, 0x6f, 0x6d, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x6f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x0c, 0x6f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x09, 0x6f, 0x6e, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x0a, 0x6f, 0x70,
+	0x65, 0x6e, 0x62, 0x75, 0x6c, 0x6c, 0x65, 0x74, 0x0a, 0x6f, 0x72, 0x74, 0x68, 0x6f, 0x67, 0x6f,
+	0x6e, 0x61, 0x6c, 0x0b, 0x6f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06,
+	0x70, 0x65, 0x73, 0x65, 0x74, 0x61, 0x03, 0x70, 0x68, 0x69, 0x03, 0x70, 0x73, 0x69, 0x0d, 0x71,
+	0x75, 0x6f, 0x74, 0x65, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x64, 0x06, 0x72, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x06, 0x72, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0d, 0x72, 0x65, 0x76, 0x6c, 0x6f,
+	0x67, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x03, 0x72, 0x68, 0x6f, 0x07, 0x72, 0x74, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x06, 0x73, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x73, 0x63, 0x69, 0x72,
+	0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x0c, 0x73,
+	0x65, 0x76, 0x65, 0x6e, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x73, 0x68, 0x61, 0x64,
+	0x65, 0x05, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x06, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x31, 0x09, 0x73,
+	0x6d, 0x69, 0x6c, 0x65, 0x66, 0x61, 0x63, 0x65, 0x05, 0x73, 0x70, 0x61, 0x64, 0x65, 0x03, 0x73,
+	0x75, 0x6e, 0x03, 0x74, 0x61, 0x75, 0x04, 0x74, 0x62, 0x61, 0x72, 0x06, 0x74, 0x63, 0x61, 0x72,
+	0x6f, 0x6e, 0x05, 0x74, 0x68, 0x65, 0x74, 0x61, 0x0c, 0x74, 0x68, 0x72, 0x65, 0x65, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x74, 0x72, 0x69, 0x61,
+	0x67, 0x64, 0x6e, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x6c, 0x66, 0x07, 0x74, 0x72, 0x69, 0x61,
+	0x67, 0x72, 0x74, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x75, 0x70, 0x06, 0x75, 0x62, 0x72, 0x65,
+	0x76, 0x65, 0x0d, 0x75, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74,
+	0x07, 0x75, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0d, 0x75, 0x6e, 0x64, 0x65, 0x72, 0x73, 0x63,
+	0x6f, 0x72, 0x65, 0x64, 0x62, 0x6c, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x30, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x30, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x41, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x41, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x42, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x42, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x42, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x32, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x32, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x33, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x33, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x34, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x34, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x35, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x35, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x36, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x36, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x43, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x43, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x43, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x32, 0x31, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x32, 0x31, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x32, 0x43, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x37, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x33, 0x39, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x41, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x33, 0x42, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x39, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x39, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x31, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x33, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x39, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x31, 0x30, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31, 0x31, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x31, 0x31, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x32, 0x31, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x32, 0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x42, 0x30, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x46, 0x42, 0x30, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x46, 0x46, 0x44, 0x05, 0x75,
+	0x6e, 0x69, 0x6f, 0x6e, 0x07, 0x75, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x75, 0x70, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x07, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0f, 0x75, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x14, 0x75, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f,
+	0x73, 0x0c, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x05, 0x75,
+	0x72, 0x69, 0x6e, 0x67, 0x06, 0x75, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x06, 0x77, 0x61, 0x63, 0x75,
+	0x74, 0x65, 0x0b, 0x77, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x09, 0x77,
+	0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x06, 0x77, 0x67, 0x72, 0x61, 0x76, 0x65, 0x02,
+	0x78, 0x69, 0x0b, 0x79, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x79,
+	0x67, 0x72, 0x61, 0x76, 0x65, 0x06, 0x7a, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0a, 0x7a, 0x64, 0x6f,
+	0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x08, 0x7a, 0x65, 0x72, 0x6f, 0x2e, 0x64, 0x6f, 0x74,
+	0x0a, 0x7a, 0x65, 0x72, 0x6f, 0x2e, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x04, 0x7a, 0x65, 0x74, 0x61,
+	0x00, 0x01, 0x00, 0x01, 0xff, 0xff, 0x00, 0x0f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x94, 0x00, 0x94, 0x05, 0xc8, 0x00, 0x00,
+	0x04, 0x3e, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x04, 0x56, 0xff, 0xe7, 0xfe, 0x75,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x94, 0x00, 0x94, 0x05, 0xc8, 0x00, 0x00, 0x06, 0x31, 0x04, 0x3e,
+	0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x06, 0x44, 0x04, 0x56, 0xff, 0xe7, 0xfe, 0x75,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x94, 0x00, 0x94, 0x05, 0xc8, 0x00, 0x00, 0x06, 0x2b, 0x04, 0x3e,
+	0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x06, 0x44, 0x04, 0x56, 0xff, 0xe7, 0xfe, 0x5d,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x58, 0x00, 0x58, 0x02, 0x44, 0xfe, 0xcc, 0x01, 0x66, 0xfe, 0xcc,
+	0x02, 0x5a, 0xfe, 0xb6, 0x01, 0x66, 0xfe, 0xcc, 0x00, 0x00, 0x00, 0x00, 0x00, 0x58, 0x00, 0x58,
+	0x06, 0x2d, 0x02, 0xb5, 0x06, 0x43, 0x02, 0x9f, 0xb0, 0x00, 0x2c, 0x20, 0xb0, 0x00, 0x55, 0x58,
+	0x45, 0x59, 0x20, 0x20, 0x4b, 0xb8, 0x00, 0x0e, 0x51, 0x4b, 0xb0, 0x06, 0x53, 0x5a, 0x58, 0xb0,
+	0x34, 0x1b, 0xb0, 0x28, 0x59, 0x60, 0x66, 0x20, 0x8a, 0x55, 0x58, 0xb0, 0x02, 0x25, 0x61, 0xb9,
+	0x08, 0x00, 0x08, 0x00, 0x63, 0x63, 0x23, 0x62, 0x1b, 0x21, 0x21, 0xb0, 0x00, 0x59, 0xb0, 0x00,
+	0x43, 0x23, 0x44, 0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x01, 0x2c, 0xb0, 0x20,
+	0x60, 0x66, 0x2d, 0xb0, 0x02, 0x2c, 0x23, 0x21, 0x23, 0x21, 0x2d, 0xb0, 0x03, 0x2c, 0x20, 0x64,
+	0xb3, 0x03, 0x14, 0x15, 0x00, 0x42, 0x43, 0xb0, 0x13, 0x43, 0x20, 0x60, 0x60, 0x42, 0xb1, 0x02,
+	0x14, 0x43, 0x42, 0xb1, 0x25, 0x03, 0x43, 0xb0, 0x02, 0x43, 0x54, 0x78, 0x20, 0xb0, 0x0c, 0x23,
+	0xb0, 0x02, 0x43, 0x43, 0x61, 0x64, 0xb0, 0x04, 0x50, 0x78, 0xb2, 0x02, 0x02, 0x02, 0x43, 0x60,
+	0x42, 0xb0, 0x21, 0x65, 0x1c, 0x21, 0xb0, 0x02, 0x43, 0x43, 0xb2, 0x0e, 0x15, 0x01, 0x42, 0x1c,
+	0x20, 0xb0, 0x02, 0x43, 0x23, 0x42, 0xb2, 0x13, 0x01, 0x13, 0x43, 0x60, 0x42, 0x23, 0xb0, 0x00,
+	0x50, 0x58, 0x65, 0x59, 0xb2, 0x16, 0x01, 0x02, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x04, 0x2c, 0xb0,
+	0x03, 0x2b, 0xb0, 0x15, 0x43, 0x58, 0x23, 0x21, 0x23, 0x21, 0xb0, 0x16, 0x43, 0x43, 0x23, 0xb0,
+	0x00, 0x50, 0x58, 0x65, 0x59, 0x1b, 0x20, 0x64, 0x20, 0xb0, 0xc0, 0x50, 0xb0, 0x04, 0x26, 0x5a,
+	0xb2, 0x28, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0xb0, 0x06, 0x45, 0x58, 0x21, 0xb0, 0x03, 0x25,
+	0x59, 0x52, 0x5b, 0x58, 0x21, 0x23, 0x21, 0x1b, 0x8a, 0x58, 0x20, 0xb0, 0x50, 0x50, 0x58, 0x21,
+	0xb0, 0x40, 0x59, 0x1b, 0x20, 0xb0, 0x38, 0x50, 0x58, 0x21, 0xb0, 0x38, 0x59, 0x59, 0x20, 0xb1,
+	0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0x61, 0x64, 0xb0, 0x28, 0x50, 0x58, 0x21, 0xb1, 0x01, 0x0d,
+	0x43, 0x45, 0x63, 0x45, 0x20, 0xb0, 0x30, 0x50, 0x58, 0x21, 0xb0, 0x30, 0x59, 0x1b, 0x20, 0xb0,
+	0xc0, 0x50, 0x58, 0x20, 0x66, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x0a, 0x50, 0x58, 0x60, 0x1b,
+	0x20, 0xb0, 0x20, 0x50, 0x58, 0x21, 0xb0, 0x0a, 0x60, 0x1b, 0x20, 0xb0, 0x36, 0x50, 0x58, 0x21,
+	0xb0, 0x36, 0x60, 0x1b, 0x60, 0x59, 0x59, 0x59, 0x1b, 0xb0, 0x02, 0x25, 0xb0, 0x0c, 0x43, 0x63,
+	0xb0, 0x00, 0x52, 0x58, 0xb0, 0x00, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x21, 0xb0, 0x0c, 0x43, 0x1b,
+	0x4b, 0xb0, 0x1e, 0x50, 0x58, 0x21, 0xb0, 0x1e, 0x4b, 0x61, 0xb8, 0x10, 0x00, 0x63, 0xb0, 0x0c,
+	0x43, 0x63, 0xb8, 0x05, 0x00, 0x62, 0x59, 0x59, 0x64, 0x61, 0x59, 0xb0, 0x01, 0x2b, 0x59, 0x59,
+	0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x59, 0x20, 0x64, 0xb0, 0x16, 0x43, 0x23, 0x42, 0x59,
+	0x2d, 0xb0, 0x05, 0x2c, 0x20, 0x45, 0x20, 0xb0, 0x04, 0x25, 0x61, 0x64, 0x20, 0xb0, 0x07, 0x43,
+	0x50, 0x58, 0xb0, 0x07, 0x23, 0x42, 0xb0, 0x08, 0x23, 0x42, 0x1b, 0x21, 0x21, 0x59, 0xb0, 0x01,
+	0x60, 0x2d, 0xb0, 0x06, 0x2c, 0x23, 0x21, 0x23, 0x21, 0xb0, 0x03, 0x2b, 0x20, 0x64, 0xb1, 0x07,
+	0x62, 0x42, 0x20, 0xb0, 0x08, 0x23, 0x42, 0xb0, 0x06, 0x45, 0x58, 0x1b, 0xb1, 0x01, 0x0d, 0x43,
+	0x45, 0x63, 0xb1, 0x01, 0x0d, 0x43, 0xb0, 0x05, 0x60, 0x45, 0x63, 0xb0, 0x05, 0x2a, 0x21, 0x20,
+	0xb0, 0x08, 0x43, 0x20, 0x8a, 0x20, 0x8a, 0xb0, 0x01, 0x2b, 0xb1, 0x30, 0x05, 0x25, 0xb0, 0x04,
+	0x26, 0x51, 0x58, 0x60, 0x50, 0x1b, 0x61, 0x52, 0x59, 0x58, 0x23, 0x59, 0x21, 0x59, 0x20, 0xb0,
+	0x40, 0x53, 0x58, 0xb0, 0x01, 0x2b, 0x1b, 0x21, 0xb0, 0x40, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58,
+	0x65, 0x59, 0x2d, 0xb0, 0x07, 0x2c, 0xb0, 0x09, 0x43, 0x2b, 0xb2, 0x00, 0x02, 0x00, 0x43, 0x60,
+	0x42, 0x2d, 0xb0, 0x08, 0x2c, 0xb0, 0x09, 0x23, 0x42, 0x23, 0x20, 0xb0, 0x00, 0x23, 0x42, 0x61,
+	0xb0, 0x02, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x60, 0xb0, 0x07, 0x2a, 0x2d, 0xb0, 0x09,
+	0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00,
+	0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x44, 0xb0, 0x01, 0x60, 0x2d,
+	0xb0, 0x0a, 0x2c, 0xb2, 0x09, 0x0e, 0x00, 0x43, 0x45, 0x42, 0x2a, 0x21, 0xb2, 0x00, 0x01, 0x00,
+	0x43, 0x60, 0x42, 0x2d, 0xb0, 0x0b, 0x2c, 0xb0, 0x00, 0x43, 0x23, 0x44, 0xb2, 0x00, 0x01, 0x00,
+	0x43, 0x60, 0x42, 0x2d, 0xb0, 0x0c, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0,
+	0x00, 0x43, 0xb0, 0x04, 0x25, 0x60, 0x20, 0x45, 0x8a, 0x23, 0x61, 0x20, 0x64, 0x20, 0xb0, 0x20,
+	0x50, 0x58, 0x21, 0xb0, 0x00, 0x1b, 0xb0, 0x30, 0x50, 0x58, 0xb0, 0x20, 0x1b, 0xb0, 0x40, 0x59,
+	0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0xb0, 0x03, 0x25, 0x23, 0x61, 0x44, 0x44, 0xb0,
+	0x01, 0x60, 0x2d, 0xb0, 0x0d, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x00,
+	0x43, 0xb0, 0x04, 0x25, 0x60, 0x20, 0x45, 0x8a, 0x23, 0x61, 0x20, 0x64, 0xb0, 0x24, 0x50, 0x58,
+	0xb0, 0x00, 0x1b, 0xb0, 0x40, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0xb0, 0x03, 0x25,
+	0x23, 0x61, 0x44, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0e, 0x2c, 0x20, 0xb0, 0x00, 0x23, 0x42,
+	0xb3, 0x0d, 0x0c, 0x00, 0x03, 0x45, 0x50, 0x58, 0x21, 0x1b, 0x23, 0x21, 0x59, 0x2a, 0x21, 0x2d,
+	0xb0, 0x0f, 0x2c, 0xb1, 0x02, 0x02, 0x45, 0xb0, 0x64, 0x61, 0x44, 0x2d, 0xb0, 0x10, 0x2c, 0xb0,
+	0x01, 0x60, 0x20, 0x20, 0xb0, 0x0f, 0x43, 0x4a, 0xb0, 0x00, 0x50, 0x58, 0x20, 0xb0, 0x0f, 0x23,
+	0x42, 0x59, 0xb0, 0x10, 0x43, 0x4a, 0xb0, 0x00, 0x52, 0x58, 0x20, 0xb0, 0x10, 0x23, 0x42, 0x59,
+	0x2d, 0xb0, 0x11, 0x2c, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0x20, 0xb8, 0x04, 0x00,
+	0x63, 0x8a, 0x23, 0x61, 0xb0, 0x11, 0x43, 0x60, 0x20, 0x8a, 0x60, 0x20, 0xb0, 0x11, 0x23, 0x42,
+	0x23, 0x2d, 0xb0, 0x12, 0x2c, 0x4b, 0x54, 0x58, 0xb1, 0x04, 0x64, 0x44, 0x59, 0x24, 0xb0, 0x0d,
+	0x65, 0x23, 0x78, 0x2d, 0xb0, 0x13, 0x2c, 0x4b, 0x51, 0x58, 0x4b, 0x53, 0x58, 0xb1, 0x04, 0x64,
+	0x44, 0x59, 0x1b, 0x21, 0x59, 0x24, 0xb0, 0x13, 0x65, 0x23, 0x78, 0x2d, 0xb0, 0x14, 0x2c, 0xb1,
+	0x00, 0x12, 0x43, 0x55, 0x58, 0xb1, 0x12, 0x12, 0x43, 0xb0, 0x01, 0x61, 0x42, 0xb0, 0x11, 0x2b,
+	0x59, 0xb0, 0x00, 0x43, 0xb0, 0x02, 0x25, 0x42, 0xb1, 0x0f, 0x02, 0x25, 0x42, 0xb1, 0x10, 0x02,
+	0x25, 0x42, 0xb0, 0x01, 0x16, 0x23, 0x20, 0xb0, 0x03, 0x25, 0x50, 0x58, 0xb1, 0x01, 0x00, 0x43,
+	0x60, 0xb0, 0x04, 0x25, 0x42, 0x8a, 0x8a, 0x20, 0x8a, 0x23, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x23,
+	0xb0, 0x01, 0x61, 0x20, 0x8a, 0x23, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x1b, 0xb1, 0x01, 0x00, 0x43,
+	0x60, 0xb0, 0x02, 0x25, 0x42, 0xb0, 0x02, 0x25, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x59, 0xb0, 0x0f,
+	0x43, 0x47, 0xb0, 0x10, 0x43, 0x47, 0x60, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0,
+	0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62,
+	0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb1, 0x00,
+	0x00, 0x13, 0x23, 0x44, 0xb0, 0x01, 0x43, 0xb0, 0x00, 0x3e, 0xb2, 0x01, 0x01, 0x01, 0x43, 0x60,
+	0x42, 0x2d, 0xb0, 0x15, 0x2c, 0x00, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb0, 0x12, 0x23, 0x42,
+	0x20, 0x45, 0xb0, 0x0e, 0x23, 0x42, 0xb0, 0x0d, 0x23, 0xb0, 0x05, 0x60, 0x42, 0x20, 0x60, 0xb7,
+	0x18, 0x18, 0x01, 0x00, 0x11, 0x00, 0x13, 0x00, 0x42, 0x42, 0x42, 0x8a, 0x60, 0x20, 0xb0, 0x14,
+	0x23, 0x42, 0xb0, 0x01, 0x61, 0xb1, 0x14, 0x08, 0x2b, 0xb0, 0x8b, 0x2b, 0x1b, 0x22, 0x59, 0x2d,
+	0xb0, 0x16, 0x2c, 0xb1, 0x00, 0x15, 0x2b, 0x2d, 0xb0, 0x17, 0x2c, 0xb1, 0x01, 0x15, 0x2b, 0x2d,
+	0xb0, 0x18, 0x2c, 0xb1, 0x02, 0x15, 0x2b, 0x2d, 0xb0, 0x19, 0x2c, 0xb1, 0x03, 0x15, 0x2b, 0x2d,
+	0xb0, 0x1a, 0x2c, 0xb1, 0x04, 0x15, 0x2b, 0x2d, 0xb0, 0x1b, 0x2c, 0xb1, 0x05, 0x15, 0x2b, 0x2d,
+	0xb0, 0x1c, 0x2c, 0xb1, 0x06, 0x15, 0x2b, 0x2d, 0xb0, 0x1d, 0x2c, 0xb1, 0x07, 0x15, 0x2b, 0x2d,
+	0xb0, 0x1e, 0x2c, 0xb1, 0x08, 0x15, 0x2b, 0x2d, 0xb0, 0x1f, 0x2c, 0xb1, 0x09, 0x15, 0x2b, 0x2d,
+	0xb0, 0x2b, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x06, 0x60, 0x4b,
+	0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x5d, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x2c, 0x2c,
+	0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x16, 0x60, 0x4b, 0x54, 0x58, 0x23,
+	0x20, 0x2e, 0xb0, 0x01, 0x71, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x2d, 0x2c, 0x23, 0x20, 0xb0,
+	0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x26, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0,
+	0x01, 0x72, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x20, 0x2c, 0x00, 0xb0, 0x0f, 0x2b, 0xb1, 0x00,
+	0x02, 0x45, 0x54, 0x58, 0xb0, 0x12, 0x23, 0x42, 0x20, 0x45, 0xb0, 0x0e, 0x23, 0x42, 0xb0, 0x0d,
+	0x23, 0xb0, 0x05, 0x60, 0x42, 0x20, 0x60, 0xb0, 0x01, 0x61, 0xb5, 0x18, 0x18, 0x01, 0x00, 0x11,
+	0x00, 0x42, 0x42, 0x8a, 0x60, 0xb1, 0x14, 0x08, 0x2b, 0xb0, 0x8b, 0x2b, 0x1b, 0x22, 0x59, 0x2d,
+	0xb0, 0x21, 0x2c, 0xb1, 0x00, 0x20, 0x2b, 0x2d, 0xb0, 0x22, 0x2c, 0xb1, 0x01, 0x20, 0x2b, 0x2d,
+	0xb0, 0x23, 0x2c, 0xb1, 0x02, 0x20, 0x2b, 0x2d, 0xb0, 0x24, 0x2c, 0xb1, 0x03, 0x20, 0x2b, 0x2d,
+	0xb0, 0x25, 0x2c, 0xb1, 0x04, 0x20, 0x2b, 0x2d, 0xb0, 0x26, 0x2c, 0xb1, 0x05, 0x20, 0x2b, 0x2d,
+	0xb0, 0x27, 0x2c, 0xb1, 0x06, 0x20, 0x2b, 0x2d, 0xb0, 0x28, 0x2c, 0xb1, 0x07, 0x20, 0x2b, 0x2d,
+	0xb0, 0x29, 0x2c, 0xb1, 0x08, 0x20, 0x2b, 0x2d, 0xb0, 0x2a, 0x2c, 0xb1, 0x09, 0x20, 0x2b, 0x2d,
+	0xb0, 0x2e, 0x2c, 0x20, 0x3c, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x2f, 0x2c, 0x20, 0x60, 0xb0, 0x18,
+	0x60, 0x20, 0x43, 0x23, 0xb0, 0x01, 0x60, 0x43, 0xb0, 0x02, 0x25, 0x61, 0xb0, 0x01, 0x60, 0xb0,
+	0x2e, 0x2a, 0x21, 0x2d, 0xb0, 0x30, 0x2c, 0xb0, 0x2f, 0x2b, 0xb0, 0x2f, 0x2a, 0x2d, 0xb0, 0x31,
+	0x2c, 0x20, 0x20, 0x47, 0x20, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0,
+	0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x61, 0x38, 0x23,
+	0x20, 0x8a, 0x55, 0x58, 0x20, 0x47, 0x20, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62,
+	0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x61,
+	0x38, 0x1b, 0x21, 0x59, 0x2d, 0xb0, 0x32, 0x2c, 0x00, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb1,
+	0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x16, 0xb0, 0x31, 0x2a, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58,
+	0x30, 0x59, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x33, 0x2c, 0x00, 0xb0, 0x0f, 0x2b, 0xb1, 0x00, 0x02,
+	0x45, 0x54, 0x58, 0xb1, 0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x16, 0xb0, 0x31, 0x2a, 0xb1, 0x05,
+	0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x34, 0x2c, 0x20, 0x35, 0xb0,
+	0x01, 0x60, 0x2d, 0xb0, 0x35, 0x2c, 0x00, 0xb1, 0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x45, 0x63,
+	0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01,
+	0x63, 0xb0, 0x01, 0x2b, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50,
+	0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x2b, 0xb0, 0x00, 0x16, 0xb4,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x44, 0x3e, 0x23, 0x38, 0xb1, 0x34, 0x01, 0x15, 0x2a, 0x21, 0x2d,
+	0xb0, 0x36, 0x2c, 0x20, 0x3c, 0x20, 0x47, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62,
+	0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb0, 0x00,
+	0x43, 0x61, 0x38, 0x2d, 0xb0, 0x37, 0x2c, 0x2e, 0x17, 0x3c, 0x2d, 0xb0, 0x38, 0x2c, 0x20, 0x3c,
+	0x20, 0x47, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58,
+	0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb0, 0x00, 0x43, 0x61, 0xb0, 0x01, 0x43,
+	0x63, 0x38, 0x2d, 0xb0, 0x39, 0x2c, 0xb1, 0x02, 0x00, 0x16, 0x25, 0x20, 0x2e, 0x20, 0x47, 0xb0,
+	0x00, 0x23, 0x42, 0xb0, 0x02, 0x25, 0x49, 0x8a, 0x8a, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0x58,
+	0x62, 0x1b, 0x21, 0x59, 0xb0, 0x01, 0x23, 0x42, 0xb2, 0x38, 0x01, 0x01, 0x15, 0x14, 0x2a, 0x2d,
+	0xb0, 0x3a, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25,
+	0x47, 0x23, 0x47, 0x23, 0x61, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x65, 0x8a, 0x2e,
+	0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x2d, 0xb0, 0x3b, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23,
+	0x42, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0,
+	0x06, 0x23, 0x42, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x20, 0xb0, 0x60, 0x50, 0x58,
+	0x20, 0xb0, 0x40, 0x51, 0x58, 0xb3, 0x04, 0x20, 0x05, 0x20, 0x1b, 0xb3, 0x04, 0x26, 0x05, 0x1a,
+	0x59, 0x42, 0x42, 0x23, 0x20, 0xb0, 0x0a, 0x43, 0x20, 0x8a, 0x23, 0x47, 0x23, 0x47, 0x23, 0x61,
+	0x23, 0x46, 0x60, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40,
+	0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x20, 0xb0, 0x01, 0x2b, 0x20, 0x8a, 0x8a, 0x61, 0x20,
+	0xb0, 0x04, 0x43, 0x60, 0x64, 0x23, 0xb0, 0x05, 0x43, 0x61, 0x64, 0x50, 0x58, 0xb0, 0x04, 0x43,
+	0x61, 0x1b, 0xb0, 0x05, 0x43, 0x60, 0x59, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00,
+	0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x61, 0x23, 0x20, 0x20, 0xb0, 0x04,
+	0x26, 0x23, 0x46, 0x61, 0x38, 0x1b, 0x23, 0xb0, 0x0a, 0x43, 0x46, 0xb0, 0x02, 0x25, 0xb0, 0x0a,
+	0x43, 0x47, 0x23, 0x47, 0x23, 0x61, 0x60, 0x20, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0,
+	0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x20, 0xb0, 0x01,
+	0x2b, 0x23, 0xb0, 0x06, 0x43, 0x60, 0xb0, 0x01, 0x2b, 0xb0, 0x05, 0x25, 0x61, 0xb0, 0x05, 0x25,
+	0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63,
+	0xb0, 0x04, 0x26, 0x61, 0x20, 0xb0, 0x04, 0x25, 0x60, 0x64, 0x23, 0xb0, 0x03, 0x25, 0x60, 0x64,
+	0x50, 0x58, 0x21, 0x1b, 0x23, 0x21, 0x59, 0x23, 0x20, 0x20, 0xb0, 0x04, 0x26, 0x23, 0x46, 0x61,
+	0x38, 0x59, 0x2d, 0xb0, 0x3c, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0x20, 0x20,
+	0xb0, 0x05, 0x26, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x23, 0x3c, 0x38, 0x2d, 0xb0, 0x3d,
+	0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0xb0, 0x0a, 0x23, 0x42, 0x20, 0x20, 0x20,
+	0x46, 0x23, 0x47, 0xb0, 0x01, 0x2b, 0x23, 0x61, 0x38, 0x2d, 0xb0, 0x3e, 0x2c, 0xb0, 0x00, 0x16,
+	0xb0, 0x17, 0x23, 0x42, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0,
+	0x00, 0x54, 0x58, 0x2e, 0x20, 0x3c, 0x23, 0x21, 0x1b, 0xb0, 0x02, 0x25, 0xb0, 0x02, 0x25, 0x47,
+	0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x05, 0x25, 0xb0, 0x04, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61,
+	0xb0, 0x06, 0x25, 0xb0, 0x05, 0x25, 0x49, 0xb0, 0x02, 0x25, 0x61, 0xb9, 0x08, 0x00, 0x08, 0x00,
+	0x63, 0x63, 0x23, 0x20, 0x58, 0x62, 0x1b, 0x21, 0x59, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0,
+	0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x2e, 0x23, 0x20,
+	0x20, 0x3c, 0x8a, 0x38, 0x23, 0x21, 0x59, 0x2d, 0xb0, 0x3f, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17,
+	0x23, 0x42, 0x20, 0xb0, 0x0a, 0x43, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0x60, 0xb0,
+	0x20, 0x60, 0x66, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66,
+	0xb0, 0x01, 0x63, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x2d, 0xb0, 0x40, 0x2c, 0x23, 0x20, 0x2e,
+	0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c,
+	0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x41, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0,
+	0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x52, 0x1b, 0x50, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e,
+	0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x42, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25,
+	0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x23, 0x20, 0x2e,
+	0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x52, 0x1b, 0x50, 0x59, 0x58, 0x20, 0x3c,
+	0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x43, 0x2c, 0xb0, 0x3a, 0x2b, 0x23, 0x20,
+	0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20,
+	0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x44, 0x2c, 0xb0, 0x3b, 0x2b, 0x8a,
+	0x20, 0x20, 0x3c, 0xb0, 0x06, 0x23, 0x42, 0x8a, 0x38, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25,
+	0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30,
+	0x01, 0x14, 0x2b, 0xb0, 0x06, 0x43, 0x2e, 0xb0, 0x30, 0x2b, 0x2d, 0xb0, 0x45, 0x2c, 0xb0, 0x00,
+	0x16, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x26, 0x20, 0x20, 0x20, 0x46, 0x23, 0x47, 0x61, 0xb0, 0x0c,
+	0x23, 0x42, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0, 0x0b, 0x43, 0x2b, 0x23, 0x20, 0x3c, 0x20,
+	0x2e, 0x23, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x46, 0x2c, 0xb1, 0x0a, 0x04, 0x25,
+	0x42, 0xb0, 0x00, 0x16, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23,
+	0x61, 0x20, 0xb0, 0x06, 0x23, 0x42, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x20, 0xb0,
+	0x60, 0x50, 0x58, 0x20, 0xb0, 0x40, 0x51, 0x58, 0xb3, 0x04, 0x20, 0x05, 0x20, 0x1b, 0xb3, 0x04,
+	0x26, 0x05, 0x1a, 0x59, 0x42, 0x42, 0x23, 0x20, 0x47, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20,
+	0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x20, 0xb0, 0x01,
+	0x2b, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x04, 0x43, 0x60, 0x64, 0x23, 0xb0, 0x05, 0x43, 0x61,
+	0x64, 0x50, 0x58, 0xb0, 0x04, 0x43, 0x61, 0x1b, 0xb0, 0x05, 0x43, 0x60, 0x59, 0xb0, 0x03, 0x25,
+	0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63,
+	0x61, 0xb0, 0x02, 0x25, 0x46, 0x61, 0x38, 0x23, 0x20, 0x3c, 0x23, 0x38, 0x1b, 0x21, 0x20, 0x20,
+	0x46, 0x23, 0x47, 0xb0, 0x01, 0x2b, 0x23, 0x61, 0x38, 0x21, 0x59, 0xb1, 0x30, 0x01, 0x14, 0x2b,
+	0x2d, 0xb0, 0x47, 0x2c, 0xb1, 0x00, 0x3a, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0,
+	0x48, 0x2c, 0xb1, 0x00, 0x3b, 0x2b, 0x21, 0x23, 0x20, 0x20, 0x3c, 0xb0, 0x06, 0x23, 0x42, 0x23,
+	0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0xb0, 0x06, 0x43, 0x2e, 0xb0, 0x30, 0x2b, 0x2d, 0xb0, 0x49,
+	0x2c, 0xb0, 0x00, 0x15, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb2, 0x00, 0x01, 0x01, 0x15, 0x14,
+	0x13, 0x2e, 0xb0, 0x36, 0x2a, 0x2d, 0xb0, 0x4a, 0x2c, 0xb0, 0x00, 0x15, 0x20, 0x47, 0xb0, 0x00,
+	0x23, 0x42, 0xb2, 0x00, 0x01, 0x01, 0x15, 0x14, 0x13, 0x2e, 0xb0, 0x36, 0x2a, 0x2d, 0xb0, 0x4b,
+	0x2c, 0xb1, 0x00, 0x01, 0x14, 0x13, 0xb0, 0x37, 0x2a, 0x2d, 0xb0, 0x4c, 0x2c, 0xb0, 0x39, 0x2a,
+	0x2d, 0xb0, 0x4d, 0x2c, 0xb0, 0x00, 0x16, 0x45, 0x23, 0x20, 0x2e, 0x20, 0x46, 0x8a, 0x23, 0x61,
+	0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x4e, 0x2c, 0xb0, 0x0a, 0x23, 0x42, 0xb0, 0x4d,
+	0x2b, 0x2d, 0xb0, 0x4f, 0x2c, 0xb2, 0x00, 0x00, 0x46, 0x2b, 0x2d, 0xb0, 0x50, 0x2c, 0xb2, 0x00,
+	0x01, 0x46, 0x2b, 0x2d, 0xb0, 0x51, 0x2c, 0xb2, 0x01, 0x00, 0x46, 0x2b, 0x2d, 0xb0, 0x52, 0x2c,
+	0xb2, 0x01, 0x01, 0x46, 0x2b, 0x2d, 0xb0, 0x53, 0x2c, 0xb2, 0x00, 0x00, 0x47, 0x2b, 0x2d, 0xb0,
+	0x54, 0x2c, 0xb2, 0x00, 0x01, 0x47, 0x2b, 0x2d, 0xb0, 0x55, 0x2c, 0xb2, 0x01, 0x00, 0x47, 0x2b,
+	0x2d, 0xb0, 0x56, 0x2c, 0xb2, 0x01, 0x01, 0x47, 0x2b, 0x2d, 0xb0, 0x57, 0x2c, 0xb3, 0x00, 0x00,
+	0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x58, 0x2c, 0xb3, 0x00, 0x01, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x59,
+	0x2c, 0xb3, 0x01, 0x00, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x5a, 0x2c, 0xb3, 0x01, 0x01, 0x00, 0x43,
+	0x2b, 0x2d, 0xb0, 0x5b, 0x2c, 0xb3, 0x00, 0x00, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5c, 0x2c, 0xb3,
+	0x00, 0x01, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5d, 0x2c, 0xb3, 0x01, 0x00, 0x01, 0x43, 0x2b, 0x2d,
+	0xb0, 0x5e, 0x2c, 0xb3, 0x01, 0x01, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5f, 0x2c, 0xb2, 0x00, 0x00,
+	0x45, 0x2b, 0x2d, 0xb0, 0x60, 0x2c, 0xb2, 0x00, 0x01, 0x45, 0x2b, 0x2d, 0xb0, 0x61, 0x2c, 0xb2,
+	0x01, 0x00, 0x45, 0x2b, 0x2d, 0xb0, 0x62, 0x2c, 0xb2, 0x01, 0x01, 0x45, 0x2b, 0x2d, 0xb0, 0x63,
+	0x2c, 0xb2, 0x00, 0x00, 0x48, 0x2b, 0x2d, 0xb0, 0x64, 0x2c, 0xb2, 0x00, 0x01, 0x48, 0x2b, 0x2d,
+	0xb0, 0x65, 0x2c, 0xb2, 0x01, 0x00, 0x48, 0x2b, 0x2d, 0xb0, 0x66, 0x2c, 0xb2, 0x01, 0x01, 0x48,
+	0x2b, 0x2d, 0xb0, 0x67, 0x2c, 0xb3, 0x00, 0x00, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x68, 0x2c, 0xb3,
+	0x00, 0x01, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x69, 0x2c, 0xb3, 0x01, 0x00, 0x00, 0x44, 0x2b, 0x2d,
+	0xb0, 0x6a, 0x2c, 0xb3, 0x01, 0x01, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x6b, 0x2c, 0xb3, 0x00, 0x00,
+	0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6c, 0x2c, 0xb3, 0x00, 0x01, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6d,
+	0x2c, 0xb3, 0x01, 0x00, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6e, 0x2c, 0xb3, 0x01, 0x01, 0x01, 0x44,
+	0x2b, 0x2d, 0xb0, 0x6f, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d,
+	0xb0, 0x70, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x71, 0x2c, 0xb1, 0x00,
+	0x3c, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x72, 0x2c, 0xb0, 0x00, 0x16, 0xb1, 0x00, 0x3c, 0x2b,
+	0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x73, 0x2c, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0,
+	0x74, 0x2c, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x75, 0x2c, 0xb0, 0x00, 0x16,
+	0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x76, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0x2e,
+	0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x77, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x40, 0x2b,
+	0x2d, 0xb0, 0x78, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x79, 0x2c, 0xb1,
+	0x00, 0x3d, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x7a, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x40,
+	0x2b, 0x2d, 0xb0, 0x7b, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x7c, 0x2c,
+	0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x7d, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0x2e,
+	0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x7e, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x40, 0x2b,
+	0x2d, 0xb0, 0x7f, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x80, 0x2c, 0xb1,
+	0x00, 0x3e, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x81, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x40,
+	0x2b, 0x2d, 0xb0, 0x82, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x83, 0x2c,
+	0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x84, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0x2e,
+	0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x85, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x40, 0x2b,
+	0x2d, 0xb0, 0x86, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x87, 0x2c, 0xb1,
+	0x00, 0x3f, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x88, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x40,
+	0x2b, 0x2d, 0xb0, 0x89, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x8a, 0x2c,
+	0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x8b, 0x2c, 0xb2, 0x0b, 0x00, 0x03, 0x45,
+	0x50, 0x58, 0xb0, 0x06, 0x1b, 0xb2, 0x04, 0x02, 0x03, 0x45, 0x58, 0x23, 0x21, 0x1b, 0x21, 0x59,
+	0x59, 0x42, 0x2b, 0xb0, 0x08, 0x65, 0xb0, 0x03, 0x24, 0x50, 0x78, 0xb1, 0x05, 0x01, 0x15, 0x45,
+	0x58, 0x30, 0x59, 0x2d, 0x00, 0x4b, 0xb8, 0x00, 0xc8, 0x52, 0x58, 0xb1, 0x01, 0x01, 0x8e, 0x59,
+	0xb0, 0x01, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63, 0x70, 0xb1, 0x00, 0x07, 0x42, 0xb6, 0x00, 0x4e,
+	0x41, 0x31, 0x21, 0x05, 0x00, 0x2a, 0xb1, 0x00, 0x07, 0x42, 0x40, 0x0c, 0x52, 0x04, 0x46, 0x06,
+	0x36, 0x08, 0x26, 0x08, 0x18, 0x07, 0x05, 0x0a, 0x2a, 0xb1, 0x00, 0x07, 0x42, 0x40, 0x0c, 0x56,
+	0x02, 0x4c, 0x04, 0x3e, 0x06, 0x2e, 0x06, 0x1f, 0x05, 0x05, 0x0a, 0x2a, 0xb1, 0x00, 0x0c, 0x42,
+	0xbe, 0x14, 0xc0, 0x11, 0xc0, 0x0d, 0xc0, 0x09, 0xc0, 0x06, 0x40, 0x00, 0x05, 0x00, 0x0b, 0x2a,
+	0xb1, 0x00, 0x11, 0x42, 0xbe, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00,
+	0x05, 0x00, 0x0b, 0x2a, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0xb1, 0x24, 0x01, 0x88, 0x51, 0x58,
+	0xb0, 0x40, 0x88, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x64, 0x44, 0xb1, 0x28, 0x01, 0x88, 0x51, 0x58,
+	0xb8, 0x08, 0x00, 0x88, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0x59, 0x1b, 0xb1, 0x27, 0x01,
+	0x88, 0x51, 0x58, 0xba, 0x08, 0x80, 0x00, 0x01, 0x04, 0x40, 0x88, 0x63, 0x54, 0x58, 0xb9, 0x00,
+	0x03, 0x00, 0x00, 0x44, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0c, 0x54, 0x02, 0x48, 0x04, 0x38,
+	0x06, 0x28, 0x06, 0x1a, 0x05, 0x05, 0x0e, 0x2a, 0xb8, 0x01, 0xff, 0x85, 0xb0, 0x04, 0x8d, 0xb1,
+	0x02, 0x00, 0x44, 0xb3, 0x05, 0x64, 0x06, 0x00, 0x44, 0x44, 0x00, 0x00,
+}
@@ -1,19 +1,23 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
-	"github.com/btracey/numcsv"
-	"github.com/davecheney/profile"
-	"github.com/gonum/blas/dbw"
-	"github.com/gonum/blas/goblas"
+	"github.com/btracey/gobench/nettrainbench/benchdata"
+	"github.com/btracey/gobench/nettrainbench/optimize"
 	"github.com/gonum/matrix/mat64"
 	"github.com/gonum/opt"
+	"github.com/reggo/reggo/common"
 	"github.com/reggo/reggo/loss"
 	"github.com/reggo/reggo/regularize"
 	"github.com/reggo/reggo/scale"
@@ -21,121 +25,1051 @@ import (
 	"github.com/reggo/reggo/train"
 )
 
-func init() {
-	mat64.Register(goblas.Blas{}) // use a go-based blas library
-	dbw.Register(goblas.Blas{})
-}
-
+// nettrainbench trains neural nets on a data file and reports the time taken.
+// The cases to run are read from a JSON config given by -config, for example
+// cases.json, or are a single default case. Flags override the fields of
+// every case.
 func main() {
-	nData := 10000
-	nCPU := runtime.NumCPU()
-	//nCPU := 1
-	nHiddenNeurons := 30
+	config := flag.String("config", "", "JSON file listing the benchmark cases")
+	suiteName := flag.String("suite", "", "run only the cases of the named suite of the -config")
+	nCPU := flag.Int("cpu", runtime.NumCPU(), "number of processors to use")
+	cpuList := flag.String("cpus", "", `pin the benchmark to these processors, for example "0-3,6", with -cpu defaulting to their number (Linux only)`)
+	governor := flag.String("governor", governorWarn, "if the cpu frequency governor is not performance: warn, require to refuse to run, or ignore")
+	blasName := flag.String("blas", "go", "BLAS implementation: go, or cblas, openblas or atlas when built with that tag")
+	var override Case
+	flag.StringVar(&override.Data, "data", "", "data file (default "+defaultCase.Data+")")
+	flag.StringVar(&override.Comma, "comma", "", "delimiter of the data file")
+	flag.IntVar(&override.NData, "ndata", 0, fmt.Sprintf("number of samples (default %d)", defaultCase.NData))
+	flag.IntVar(&override.Layers, "layers", 0, fmt.Sprintf("number of hidden layers (default %d)", defaultCase.Layers))
+	flag.IntVar(&override.Neurons, "neurons", 0, fmt.Sprintf("neurons per hidden layer (default %d)", defaultCase.Neurons))
+	flag.Float64Var(&override.Tolerance, "tol", 0, fmt.Sprintf("absolute function tolerance (default %g)", defaultCase.Tolerance))
+	flag.IntVar(&override.MaxEvals, "evals", 0, fmt.Sprintf("maximum function evaluations (default %d)", defaultCase.MaxEvals))
+	flag.Float64Var(&override.GradTolerance, "gradtol", 0, "gradient norm below which training stops, negative for none (default 1e-6)")
+	flag.Float64Var(&override.RelTolerance, "reltol", 0, "stop when the loss changes by no more than this fraction between major iterations")
+	flag.IntVar(&override.MaxIterations, "iterations", 0, "maximum major iterations of the optimizer")
+	flag.Float64Var(&override.MaxSeconds, "maxseconds", 0, "maximum wall time of the optimizer in seconds")
+	flag.IntVar(&override.GOGC, "gogc", 0, "garbage collection target percentage during each run, as for GOGC, or negative to turn collection off")
+	flag.Float64Var(&override.Timeout, "timeout", 0, "seconds after which a run is abandoned and recorded as failed, continuing with the rest")
+	flag.IntVar(&override.Workers, "workers", 0, "objective workers (default GOMAXPROCS)")
+	flag.IntVar(&override.Warmup, "warmup", 0, "number of runs of each case discarded before the measured runs")
+	flag.IntVar(&override.Repeat, "repeat", 0, "number of runs of each case with different seeds (default 1)")
+	flag.Float64Var(&override.Holdout, "holdout", 0, "fraction of ndata held out after the training samples to test on, negative for none (default 0.2)")
+	flag.IntVar(&override.BatchSize, "batch", 0, "mini-batch size, zero for full-batch training")
+	flag.IntVar(&override.Epochs, "epochs", 0, "number of passes over the data in mini-batch training, replacing -evals")
+	flag.StringVar(&override.Activation, "activation", "", "hidden layer activation: "+strings.Join(activatorNames(), ", ")+", or all to compare them (default tanh)")
+	flag.IntVar(&override.Outputs, "outputs", 0, "number of output columns at the end of the data (default 1)")
+	flag.Float64Var(&override.L1, "l1", 0, "strength of the L1 penalty on the parameters")
+	flag.Float64Var(&override.L2, "l2", 0, "strength of the L2 penalty (weight decay) on the parameters, with -l1 for the elastic net")
+	flag.StringVar(&override.Weights, "weights", "", "sample weights: column to read them from the column before the outputs, or importance for random log-normal weights (default none)")
+	flag.StringVar(&override.InputScaling, "inscale", "", "scaling of the inputs: normal to mean zero and variance one, minmax to between zero and one, or none (default normal)")
+	flag.StringVar(&override.OutputScaling, "outscale", "", "scaling of the outputs, as for -inscale; test errors are always in the units of the data (default normal)")
+	flag.StringVar(&override.Task, "task", "", "regression, or classification of the integer labels in the last column (default regression)")
+	flag.IntVar(&override.Folds, "folds", 0, "train and test on each of this many folds of ndata in place of -holdout, with no -trace")
+	flag.StringVar(&override.Stream, "stream", "", "train on -batch mini-batches read from the data file during training rather than loading it: sequential, or shuffled each epoch")
+	flag.BoolVar(&override.ParallelFolds, "parallelfolds", false, "train the -folds at once rather than one after another")
+	flag.IntVar(&override.Ensemble, "ensemble", 0, "train this many networks from different random parameters and average their predictions")
+	flag.BoolVar(&override.ParallelEnsemble, "parallelensemble", false, "train the -ensemble networks at once, splitting the -workers between them, rather than one after another with all of them")
+	flag.StringVar(&override.Method, "method", "", "model to train: "+strings.Join(methodNames(), ", ")+" for a neural net and the least squares, k nearest neighbor and kernel ridge baselines, or all to compare them (default nnet)")
+	flag.IntVar(&override.K, "k", 0, fmt.Sprintf("neighbors averaged by the knn method (default %d)", defaultK))
+	flag.Float64Var(&override.Gamma, "gamma", 0, "width of the Gaussian kernels exp(-gamma |x-y|²) of the krr method (default one over the number of inputs)")
+	flag.Float64Var(&override.Ridge, "ridge", 0, fmt.Sprintf("ridge penalty of the krr method (default %g)", defaultRidge))
+	flag.StringVar(&override.Optimizer, "optimizer", "", "optimization method: "+strings.Join(optimize.Names(), ", ")+", or all to compare them (default bfgs)")
+	flag.Float64Var(&override.Rate, "rate", 0, "learning rate of the sgd, nesterov and adam optimizers (default that of the method)")
+	sweep := flag.String("sweep", "", `grid of cases to run, for example "neurons=5,20;layers=1,2"`)
+	search := flag.String("search", "", `random search drawing n cases from ranges, for example "n=20;neurons=5:50;l2=1e-5:1e-2;rate=1e-4:1e-1;optimizers=adam,nesterov"`)
+	scaling := flag.Bool("scaling", false, "run each case with 1, 2, 4, ... up to -cpu workers and report the speedup")
+	phases := flag.Bool("phases", false, "time the network and loss function in the objective workers, and print the time of each phase")
+	predict := flag.Bool("predict", false, "after training, time prediction with the network on the training inputs, batched and a row at a time")
+	save := flag.String("save", "", "write the trained network and scalers of each run to the file, as JSON if it ends in .json and gob otherwise")
+	load := flag.String("load", "", "start training from the network and scalers in the file written by -save, in place of random parameters")
+	gradCheck := flag.Bool("gradcheck", false, fmt.Sprintf("instead of training, check the gradient of each case against central finite differences, exiting with status 1 if a relative error exceeds %g", gradCheckTolerance))
+	iters := flag.Bool("iters", false, "record and summarize the wall time of each major iteration of the optimizer")
+	var prof profiles
+	flag.StringVar(&prof.cpu, "cpuprofile", "", "write a CPU profile of each run to the file, adding the case name if there are several")
+	profileSummary := flag.Bool("profilesummary", false, fmt.Sprintf("CPU profile each run, to -cpuprofile if given, and record the %d functions with the most cumulative time in the results", profileTop))
+	flag.StringVar(&prof.mem, "memprofile", "", "write an allocation profile after each run to the file")
+	flag.StringVar(&prof.block, "blockprofile", "", "write a goroutine blocking profile after each run to the file")
+	flag.StringVar(&prof.mutex, "mutexprofile", "", "write a mutex contention profile after each run to the file")
+	flag.StringVar(&prof.exec, "exectrace", "", "write a runtime execution trace of each run to the file")
+	trace := flag.String("trace", "", "write the convergence history of each run as CSV to the file, adding the case name if there are several")
+	plotDir := flag.String("plot", "", "record the convergence of each run, and draw it, and the speedup of -scaling runs, as charts in this directory")
+	plotFormat := flag.String("plotformat", "svg", "file format of the -plot charts: "+strings.Join(plotFormats, " or "))
+	out := flag.String("out", "", "write the results to the file, as JSON if it ends in .json, benchstat input if .txt or .bench, and CSV otherwise")
+	baseline := flag.String("baseline", "", "JSON results to compare against, exiting with status 1 on a regression")
+	threshold := flag.Float64("threshold", 0.1, "fractional slowdown from the baseline counted as a regression")
+	subprocess := flag.Bool("subprocess", false, "run each case in a new process of this program, so that the heap, garbage collector and goroutines of one case do not affect the next")
+	caseOf := flag.Int("caseof", 0, "used by -subprocess to run one of this many cases")
+	flag.BoolVar(&quiet, "quiet", false, "print only a JSON line as each case finishes, with its status, the time taken and the time left")
+	remote := flag.String("remote", "", "run the cases on these comma separated workers, host:port of programs started with -listen, one case at a time on each")
+	listen := flag.String("listen", "", "serve as a worker for -remote on this address, such as :7070, running each case in a subprocess")
+	store := flag.String("store", "", "append the records of the finished runs to this results store of JSON lines, to track them across commits with -history")
+	historyQuery := flag.String("history", "", `instead of running, write the history of the -store records matching a query such as "name=FiveNeurons;machine=bd71e3c9a573;git=7a5b9be;since=2024-01-02", or all of them for "all"`)
+	report := flag.String("report", "", "instead of running, write the trend of each benchmark in the -store, or those matching -history, over its last -commits to the file, as HTML if it ends in .html and text otherwise, exiting with status 1 if the last commit of one regressed")
+	commits := flag.Int("commits", 10, "number of the latest commits of each benchmark in the -report and -serve dashboard")
+	alpha := flag.Float64("alpha", 0.05, "Mann-Whitney p-value below which a change in the -report or dashboard beyond -threshold is significant")
+	serve := flag.String("serve", "", "instead of running, serve a dashboard of the -store on this address, such as :8080, to list, filter and compare the benchmarks and chart their trends, with the metrics of their last commits for Prometheus at /metrics")
+	push := flag.String("push", "", "push the metrics of the finished runs to the Prometheus Pushgateway at this URL, such as http://localhost:9091, as the job nettrainbench")
+	checkpointFile := flag.String("checkpoint", "", "append the records of each case to this file of JSON lines as it finishes")
+	resume := flag.Bool("resume", false, "skip the cases in the -checkpoint file, reusing their records, and append the rest to it")
+	seedFlag := flag.Int64("seed", 0, "seed of run 0 of each case, with run i seeded by seed+i (default from the clock); runs with one worker are then bit-reproducible")
+	flag.Parse()
+	if quiet {
+		console = io.Discard
+	}
+	if *listen != "" {
+		log.Fatal(serveWorker(*listen))
+	}
+	if *serve != "" {
+		if *store == "" {
+			log.Fatal("-serve needs a -store")
+		}
+		log.Fatal(serveDashboard(*serve, &dashboard{store: *store, commits: *commits, alpha: *alpha, threshold: *threshold}))
+	}
+	if *historyQuery != "" || *report != "" {
+		if *store == "" {
+			log.Fatal("-history and -report need a -store")
+		}
+		query := *historyQuery
+		if query == "all" {
+			query = ""
+		}
+		q, err := parseStoreQuery(query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recs, err := readStore(*store, q)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *report == "" {
+			if err := writeHistory(os.Stdout, recs, *threshold); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		regressed, err := writeReport(*report, trends(recs, *commits, *alpha, *threshold))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(regressed) > 0 {
+			log.Printf("%d benchmarks regressed at their last commit: %s", len(regressed), strings.Join(regressed, ", "))
+			os.Exit(1)
+		}
+		return
+	}
 
-	t := time.Now()
+	cases := []Case{defaultCase}
+	searching := *search != ""
+	var suite Suite
+	if *suiteName != "" && *config == "" {
+		log.Fatal("-suite needs a -config")
+	}
+	if *config != "" {
+		c, err := readConfig(*config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *suiteName != "" {
+			suite, err = c.selectSuite(*suiteName)
+			if err != nil {
+				log.Fatalf("config %s: %v", *config, err)
+			}
+		}
+		cases = c.resolve()
+		searching = searching || c.Search != nil
+	}
+	for i := range cases {
+		name := cases[i].Name
+		cases[i].Name = ""
+		defaults := cases[i]
+		cases[i] = override
+		cases[i].fill(defaults)
+		cases[i].Name = name
+	}
+	if *sweep != "" {
+		sw, err := parseSweep(*sweep)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var grid []Case
+		for _, c := range cases {
+			grid = append(grid, sw.cases(c)...)
+		}
+		cases = grid
+	}
+	if *search != "" {
+		se, err := parseSearch(*search)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var drawn []Case
+		for _, c := range cases {
+			drawn = append(drawn, se.cases(c)...)
+		}
+		cases = drawn
+	}
 
-	rand.Seed(time.Now().UnixNano()) // Set the random number seed
-	runtime.GOMAXPROCS(nCPU)         // Set the number of processors to use
-	filename := "data.txt"           // Assumes exp4 is in the working directory
+	cases = expandCases(cases)
+	if *scaling {
+		sw := &Sweep{Workers: scalingWorkers(*nCPU)}
+		var grid []Case
+		for _, c := range cases {
+			grid = append(grid, sw.cases(c)...)
+		}
+		cases = grid
+	}
 
-	// Open the data file
-	f, err := os.Open(filename)
-	if err != nil {
+	if err := registerBLAS(*blasName); err != nil {
 		log.Fatal(err)
 	}
-
-	// Read in the data
-	// numcsv is a wrapper I wrote over the normal go csv parser. The Go csv
-	// parser returns strings. This assumes that the data is numeric with possibly
-	// some column headings at the top, so it returns a matrix of data instead
-	// of strings.
-	r := numcsv.NewReader(f)
-	r.Comma = " " // the file is space dilimeted (ish)
-	_, err = r.ReadHeading()
-	if err != nil {
+	if err := checkPlotFormat(*plotFormat); err != nil {
 		log.Fatal(err)
 	}
-	//log.Println("The headings are: ", headings)
-
-	allData, err := r.ReadAll()
-	if err != nil {
+	seed := time.Now().UnixNano() // Run i of every case is seeded with seed+i
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seed = *seedFlag
+		}
+	})
+	if *cpuList != "" {
+		cpus, err := parseCPUList(*cpuList)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := setAffinity(cpus); err != nil {
+			log.Fatal(err)
+		}
+		affinity, pinned = *cpuList, cpus
+		cpuSet := false
+		flag.Visit(func(f *flag.Flag) { cpuSet = cpuSet || f.Name == "cpu" })
+		if !cpuSet {
+			*nCPU = len(cpus)
+		}
+	}
+	if err := checkGovernor(*governor, pinned); err != nil {
 		log.Fatal(err)
 	}
+	runtime.GOMAXPROCS(*nCPU) // Set the number of processors to use
+	if *gradCheck {
+		ok, err := checkGradients(os.Stdout, cases, seed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			log.Printf("gradient relative errors exceed %g", gradCheckTolerance)
+			os.Exit(1)
+		}
+		return
+	}
 
-	nSamples, nDim := allData.Dims()
-	_ = nSamples
-	if nDim != 4 {
-		log.Fatal("Code assumes there are 4 columns")
+	if *profileSummary && prof.cpu == "" {
+		dir, err := os.MkdirTemp("", "nettrainbench")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		prof.cpu = filepath.Join(dir, "cpu.prof")
 	}
 
-	// Great! Data is ready. Now let's set up a problem. First, let's define
-	// our algoritm
-	inputDim := nDim - 1
-	outputDim := 1
-	nHiddenLayers := 2
-	nNeuronsPerLayer := nHiddenNeurons // I usually use more, but let's keep this example cheap
-	finalActivator := nnet.Linear{}    // doing regression, so use a linear activator in the last output
+	env := environment()
+	records := make([]Record, 0, len(cases))
+	loaded := make(map[*mat64.Dense]bool) // data whose load time is charged to a case
+	suiteStart := time.Now()
+	var skipped []string
+	var failed []Record
+	var ckpt *checkpoint
+	var resumed map[string][]Record
+	if *resume && *checkpointFile == "" {
+		log.Fatal("-resume needs a -checkpoint")
+	}
+	if *checkpointFile != "" {
+		var err error
+		ckpt, resumed, err = createCheckpoint(*checkpointFile, *resume)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer ckpt.Close()
+	}
+	var progressOut io.Writer = os.Stdout
+	if *caseOf > 0 {
+		progressOut = io.Discard // the parent reports the progress
+	}
+	prog := newProgress(progressOut, len(cases), quiet)
+	collect := func(recs []Record) {
+		for _, r := range recs {
+			if r.Error != "" {
+				failed = append(failed, r)
+			} else {
+				records = append(records, r)
+			}
+		}
+	}
+	caseDone := func(c Case, start time.Time, recordsBefore, failedBefore int) {
+		status := progressOK
+		if len(failed) > failedBefore {
+			status = progressFailed
+		}
+		if ckpt != nil {
+			var done []Record
+			done = append(done, records[recordsBefore:]...)
+			done = append(done, failed[failedBefore:]...)
+			if err := ckpt.write(done); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := prog.finish(c.Name, status, time.Since(start)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var pool *remotePool
+	handleRemote := func(r remoteResult) {
+		recordsBefore, failedBefore := len(records), len(failed)
+		if r.err != nil {
+			fmt.Fprintf(console, "%s: %v\n", r.c.Name, r.err)
+			failed = append(failed, failedRecord(r.c, &runFailure{statusFailed, r.err.Error()}))
+		}
+		collect(r.records)
+		caseDone(r.c, r.start, recordsBefore, failedBefore)
+	}
+	if *remote != "" {
+		if err := checkWorkerFlags(childFlags()); err != nil {
+			log.Fatalf("-remote: %v", err)
+		}
+		workers := strings.Split(*remote, ",")
+		envs, err := checkWorkers(workers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, e := range envs {
+			fmt.Fprintf(console, "worker %s: machine %s, %s, %d cores, %s\n", workers[i], e.Machine, e.CPU, e.Cores, e.OS)
+		}
+		pool = newRemotePool(workers, seed, len(cases), childFlags())
+	}
+cases:
+	for _, c := range cases {
+		caseStart, recordsBefore, failedBefore := time.Now(), len(records), len(failed)
+		if done, ok := resumed[c.Name]; ok {
+			collect(done)
+			if err := prog.finish(c.Name, progressResumed, 0); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
+		if suite.Timeout > 0 && time.Since(suiteStart).Seconds() > suite.Timeout {
+			skipped = append(skipped, c.Name)
+			if err := prog.finish(c.Name, progressSkipped, 0); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
+		if pool != nil {
+			pool.submit(c, handleRemote)
+			continue
+		}
+		if *subprocess {
+			recs, err := runSubprocess(c, seed, len(cases), childFlags())
+			if err != nil {
+				fmt.Fprintf(console, "%s: %v\n", c.Name, err)
+				failed = append(failed, failedRecord(c, &runFailure{statusFailed, err.Error()}))
+			}
+			collect(recs)
+			caseDone(c, caseStart, recordsBefore, failedBefore)
+			continue
+		}
+		loadStart := time.Now()
+		allData, err := loadData(&c)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var loadTime float64
+		if !loaded[allData] {
+			loadTime = time.Since(loadStart).Seconds()
+			loaded[allData] = true
+		}
+		if c.Workers == 0 {
+			c.Workers = runtime.GOMAXPROCS(0)
+		}
+		several := len(cases) > 1 || *caseOf > 1 || c.Repeat > 1
+		for w := 0; w < c.Warmup; w++ {
+			// Warm-up runs are seeded apart from the measured runs
+			c.rand = rand.New(rand.NewSource(seed - 1 - int64(w)))
+			if *load != "" {
+				c.model, err = loadModel(caseFile(*load, c, 0, several))
+				if err != nil {
+					log.Fatal(err)
+				}
+				c.model.architecture(&c)
+			}
+			t := time.Now()
+			if _, err := isolate(c.Timeout, func() (*Record, error) {
+				defer setGOGC(c.GOGC)()
+				return fit(c, allData, nil, *phases)
+			}); err != nil {
+				fmt.Fprintf(console, "%s: warm-up run %d %v, skipping the case\n", c.Name, w, err)
+				failed = append(failed, failedRecord(c, err.(*runFailure)))
+				caseDone(c, caseStart, recordsBefore, failedBefore)
+				continue cases
+			}
+			fmt.Fprintf(console, "%s: warm-up run %d discarded (%v)\n", c.Name, w, time.Since(t))
+		}
+		var times, losses []float64
+		for run := 0; run < c.Repeat; run++ {
+			runSeed := seed + int64(run)
+			c.rand = rand.New(rand.NewSource(runSeed)) // Set the random number seed
+			if *load != "" {
+				c.model, err = loadModel(caseFile(*load, c, run, several))
+				if err != nil {
+					log.Fatal(err)
+				}
+				c.model.architecture(&c)
+			}
+			var tr *tracer
+			if (*trace != "" || *iters || *plotDir != "") && c.Folds == 0 {
+				filename := *trace
+				if filename != "" {
+					filename = caseFile(*trace, c, run, several)
+				}
+				tr, err = newTracer(filename)
+				if err != nil {
+					log.Fatal(err)
+				}
+				tr.recordConvergence = *plotDir != ""
+			}
+			stopProfiles, err := prof.start(c, run, several)
+			if err != nil {
+				log.Fatal(err)
+			}
+			meter := startMemory()
+			t := time.Now()
+			rec, fitErr := isolate(c.Timeout, func() (*Record, error) {
+				defer setGOGC(c.GOGC)()
+				return fit(c, allData, tr, *phases)
+			})
+			elapsed := time.Since(t)
+			memory := meter.stop()
+			if err := stopProfiles(); err != nil {
+				log.Fatal(err)
+			}
+			if fitErr != nil {
+				// An abandoned run may still be writing its trace, so leave
+				// the file open.
+				fmt.Fprintf(console, "%s: run %d %v\n", c.Name, run, fitErr)
+				f := failedRecord(c, fitErr.(*runFailure))
+				f.Seed, f.Run, f.Start, f.Seconds, f.Env = runSeed, run, t, elapsed.Seconds(), env
+				failed = append(failed, f)
+				continue
+			}
+			if tr != nil {
+				if err := tr.Close(); err != nil {
+					log.Fatal(err)
+				}
+				if *iters {
+					rec.IterSeconds = tr.iterSeconds
+				}
+				rec.Convergence = tr.convergence
+			}
+			if *predict {
+				rec.Prediction, err = measurePrediction(rec.predictor, rec.inputs)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			if *save != "" {
+				if rec.model == nil {
+					log.Fatalf("%s: no single network to save", c.Name)
+				}
+				if err := saveModel(caseFile(*save, c, run, several), rec.model); err != nil {
+					log.Fatal(err)
+				}
+			}
+			rec.predictor, rec.inputs, rec.model = nil, nil, nil
+			rec.Seed = runSeed
+			rec.Run = run
+			rec.Start = t
+			rec.Seconds = elapsed.Seconds()
+			rec.Memory = memory
+			if *profileSummary {
+				rec.Profile, err = topFunctions(caseFile(prof.cpu, c, run, several), profileTop)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			rec.NsPerOp = elapsed.Nanoseconds()
+			if n := rec.evals(); n > 0 {
+				rec.NsPerEval = float64(rec.NsPerOp) / float64(n)
+			}
+			rec.Env = env
+			rec.ConfigHash = configHash(c)
+			if run == 0 {
+				rec.Phases.Load = loadTime
+			}
+			fmt.Fprintf(console, "%s: optimum value is %v (%v, %.4g ns/eval, %d parameters, stopped by %s)\n", c.Name, rec.Loss, elapsed, rec.NsPerEval, rec.NParams, rec.Status)
+			if rec.Test != nil {
+				fmt.Fprintf(console, "%s: test %v\n", c.Name, rec.Test)
+			}
+			if len(rec.Members) > 0 {
+				fmt.Fprintf(console, "%s: networks of the ensemble %s\n", c.Name, memberSummary(rec.Members))
+			}
+			if rec.CV != nil {
+				fmt.Fprintf(console, "%s: cross-validation %v\n", c.Name, rec.CV)
+			}
+			if *phases {
+				fmt.Fprintf(console, "%s: %v\n", c.Name, rec.Phases)
+			}
+			fmt.Fprintf(console, "%s: memory %v\n", c.Name, rec.Memory)
+			if rec.Prediction != nil {
+				fmt.Fprintf(console, "%s: prediction %v\n", c.Name, rec.Prediction)
+			}
+			if n := len(rec.IterSeconds); n > 1 {
+				fmt.Fprintf(console, "%s: first iteration %.3gs, then %v\n", c.Name, rec.IterSeconds[0], summarize(rec.IterSeconds[1:]))
+			}
+			records = append(records, *rec)
+			times = append(times, rec.Seconds)
+			losses = append(losses, rec.Loss)
+		}
+		if c.Repeat > 1 {
+			fmt.Fprintf(console, "%s: seconds %v\n", c.Name, summarize(times))
+			fmt.Fprintf(console, "%s: loss %v\n", c.Name, summarize(losses))
+		}
+		caseDone(c, caseStart, recordsBefore, failedBefore)
+	}
+	if pool != nil {
+		pool.wait(handleRemote)
+	}
+	if *scaling {
+		if err := reportScaling(console, records); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if searching {
+		if err := rankSearch(console, records); err != nil {
+			log.Fatal(err)
+		}
+	}
+	method := func(c Case) string { return c.Method }
+	if len(distinct(records, method)) > 1 {
+		if err := compareBy(console, records, "method", method); err != nil {
+			log.Fatal(err)
+		}
+	}
+	optimizer := func(c Case) string { return c.Optimizer }
+	if len(distinct(records, optimizer)) > 1 {
+		if err := compareBy(console, records, "optimizer", optimizer); err != nil {
+			log.Fatal(err)
+		}
+	}
+	activation := func(c Case) string { return c.Activation }
+	if len(distinct(records, activation)) > 1 {
+		if err := compareBy(console, records, "activation", activation); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *out != "" {
+		if err := writeResults(*out, append(records, failed...)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *store != "" {
+		if err := appendStore(*store, records); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *push != "" {
+		if err := pushMetrics(*push, records); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// A subprocess records the convergence of its case for the parent to
+	// plot with the others.
+	if *plotDir != "" && *caseOf == 0 {
+		files, err := writePlots(*plotDir, *plotFormat, records)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, f := range files {
+			fmt.Fprintf(console, "plot: %s\n", f)
+		}
+	}
+	if *baseline != "" {
+		base, err := readRecords(*baseline)
+		if err != nil {
+			log.Fatal(err)
+		}
+		regressed, err := compareBaseline(console, base, records, *threshold)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(regressed) > 0 {
+			log.Printf("%d cases regressed by more than %g%%: %v", len(regressed), 100**threshold, regressed)
+			os.Exit(1)
+		}
+	}
+	if len(failed) > 0 {
+		var names []string
+		for _, f := range failed {
+			names = append(names, fmt.Sprintf("%s run %d %s", f.Name, f.Run, f.Status))
+		}
+		log.Printf("%d runs did not finish: %s", len(failed), strings.Join(names, ", "))
+	}
+	if len(skipped) > 0 {
+		log.Printf("suite %s exceeded its timeout of %gs, skipping %d cases: %v", *suiteName, suite.Timeout, len(skipped), skipped)
+	}
+	if len(failed) > 0 || len(skipped) > 0 {
+		os.Exit(1)
+	}
+}
 
-	hiddenActivator := nnet.Tanh{}
+// fit trains the case with runCase, streams it with streamCase, or
+// cross-validates it if it has folds.
+func fit(c Case, allData *mat64.Dense, trace *tracer, workerTiming bool) (*Record, error) {
+	if c.Stream != "" {
+		return streamCase(c, trace, workerTiming)
+	}
+	if c.Folds > 0 {
+		return crossValidate(c, allData, workerTiming)
+	}
+	return runCase(c, allData, trace, workerTiming)
+}
 
-	algorithm, err := nnet.NewSimpleTrainer(inputDim, outputDim, nHiddenLayers, nNeuronsPerLayer, hiddenActivator, finalActivator)
+// randomizeParameters sets the parameters of a network from
+// nnet.NewSimpleTrainer to a random initial condition drawn from rnd. The
+// draws are those of RandomizeParameters, which can only use the global
+// source: each neuron's weights and bias are normal with a variance of one
+// over their number.
+func randomizeParameters(algorithm *nnet.Trainer, inputDim, layers, neurons, outputDim int, rnd *rand.Rand) {
+	params := make([]float64, 0, algorithm.NumParameters())
+	in := inputDim
+	for l := 0; l <= layers; l++ {
+		out := neurons
+		if l == layers {
+			out = outputDim
+		}
+		scale := math.Pow(float64(in+1), -0.5)
+		for j := 0; j < out*(in+1); j++ {
+			params = append(params, rnd.NormFloat64()*scale)
+		}
+		in = out
+	}
+	algorithm.SetParameters(params)
+}
+
+// loadData returns the data of the case, reading or generating it with
+// benchdata, which keeps it for other cases of the same data. If the default
+// data file is missing, synthetic data is used in its place. Streamed cases
+// read their data as they train, so there is none for them.
+func loadData(c *Case) (*mat64.Dense, error) {
+	if c.Stream != "" {
+		return nil, nil
+	}
+	if c.Synthetic == nil && c.Data == defaultCase.Data {
+		if _, err := os.Stat(c.Data); os.IsNotExist(err) {
+			log.Printf("%s not found, using synthetic %s data", c.Data, benchdata.Exp4Fallback.Kind)
+			spec := benchdata.Exp4Fallback
+			c.Synthetic = &spec
+		}
+	}
+	if c.Synthetic != nil {
+		c.Data = string(c.Synthetic.Kind)
+		return benchdata.Generate(*c.Synthetic)
+	}
+	return benchdata.LoadFile(c.Data, c.Comma)
+}
+
+// runCase trains a neural net on the first c.NData samples of allData,
+// recording the convergence history with trace if it is not nil. If
+// workerTiming is set, the network and loss function are timed in the
+// objective workers, at some cost to performance. The timing and environment
+// of the returned record are left for the caller, apart from the phases after
+// loading.
+func runCase(c Case, allData *mat64.Dense, trace *tracer, workerTiming bool) (*Record, error) {
+	nSamples, nDim := allData.Dims()
+	nIn, err := inputColumns(c, nDim)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	nTest := 0
+	if c.Holdout > 0 {
+		nTest = int(c.Holdout * float64(c.NData))
+	}
+	if c.NData+nTest > nSamples {
+		return nil, fmt.Errorf("ndata %d and %d held out samples exceed the %d samples in %s", c.NData, nTest, nSamples, c.Data)
 	}
 
+	start := time.Now()
 	// Make the input and output data, copied from submatrices of all data
 	// Uses the gonum matrix package: https://godoc.org/github.com/gonum/matrix/mat64
 	inputData := &mat64.Dense{} // allocate a new matrix that the data can be copied into
 	outputData := &mat64.Dense{}
-	inputData.Submatrix(allData, 0, 0, nData, nDim-1)  // copy the first nDim - 1 columns to inputs
-	outputData.Submatrix(allData, 0, nDim-1, nData, 1) // copy the last column
+	inputData.Submatrix(allData, 0, 0, c.NData, nIn)         // copy the first nIn columns to inputs
+	outputData.Submatrix(allData, 0, nIn, c.NData, nDim-nIn) // copy the outputs, and any weights
+
+	var testInputs, testOutputs *mat64.Dense
+	if nTest > 0 {
+		testInputs, testOutputs = &mat64.Dense{}, &mat64.Dense{}
+		testInputs.Submatrix(allData, c.NData, 0, nTest, nIn)
+		testOutputs.Submatrix(allData, c.NData, nIn, nTest, nDim-nIn)
+	}
+	copyTime := time.Since(start).Seconds()
+	rec, err := trainCase(c, inputData, outputData, testInputs, testOutputs, trace, workerTiming)
+	if err != nil {
+		return nil, err
+	}
+	rec.Phases.Scale += copyTime
+	return rec, nil
+}
+
+// inputColumns returns the number of input columns of data with nDim columns,
+// the last c.Outputs of which are the outputs, preceded by the weights if they
+// are read from a column.
+func inputColumns(c Case, nDim int) (int, error) {
+	nOut := c.Outputs
+	if c.Weights == weightColumn {
+		nOut++
+	}
+	if c.Outputs < 1 || nDim <= nOut {
+		return 0, fmt.Errorf("data has %d columns, need more than the %d outputs and weights", nDim, nOut)
+	}
+	return nDim - nOut, nil
+}
 
-	// Let's scale the data to have mean zero and variance 1
-	inputScaler := &scale.Normal{}
-	scale.ScaleData(inputScaler, inputData)
+// trainCase trains a neural net or an ensemble of them, or fits the baseline
+// of c.Method, on the inputs and outputs, which are scaled in place, and
+// tests it on the test samples if they are not nil. The outputs begin with
+// the column of weights if c.Weights is "column". The other arguments are as
+// for runCase.
+func trainCase(c Case, inputData, outputData, testInputs, testOutputs *mat64.Dense, trace *tracer, workerTiming bool) (*Record, error) {
+	if c.Ensemble > 1 {
+		return trainEnsemble(c, inputData, outputData, testInputs, testOutputs, trace, workerTiming)
+	}
+	if c.Method != methodNet {
+		return trainBaseline(c, inputData, outputData, testInputs, testOutputs)
+	}
+	var phases Phases
+	start := time.Now()
+	lap := func() float64 {
+		now := time.Now()
+		d := now.Sub(start).Seconds()
+		start = now
+		return d
+	}
 
-	outputScaler := &scale.Normal{}
-	scale.ScaleData(outputScaler, outputData)
+	nTrain, inputDim := inputData.Dims()
+	if c.BatchSize < 0 || c.BatchSize > nTrain {
+		return nil, fmt.Errorf("batch size %d not between 0 and the %d training samples", c.BatchSize, nTrain)
+	}
+
+	weights, outputData, testOutputs, classes, err := trainingOutputs(c, outputData, testOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Great! Data is ready. Now let's set up a problem. First, let's define
+	// our algoritm
+	_, outputDim := outputData.Dims()
+	finalActivator := nnet.Linear{} // doing regression, so use a linear activator in the last output
+
+	hiddenActivator, ok := activators[c.Activation]
+	if !ok {
+		return nil, fmt.Errorf("unknown activation %q", c.Activation)
+	}
+
+	inputScaler, outputScaler, outputScaling, err := scaleCase(c, inputData, outputData, classes)
+	if err != nil {
+		return nil, err
+	}
+	phases.Scale = lap()
+
+	algorithm, err := nnet.NewSimpleTrainer(inputDim, outputDim, c.Layers, c.Neurons, hiddenActivator, finalActivator)
+	if err != nil {
+		return nil, err
+	}
 
 	// Now let's define other things
-	var weights []float64 = nil                  // Don't weight our data
-	losser := loss.SquaredDistance{}             // SquaredDistance loss function
-	var regularizer regularize.Regularizer = nil // Let's not place any penalty on large nnet parameter values
+	var losser loss.DerivLosser = loss.SquaredDistance{} // SquaredDistance loss function
+	var regularizer regularize.Regularizer = nil         // Let's not place any penalty on large nnet parameter values
+	if r := newRegularizer(c); r != nil {
+		regularizer = r // unless the case has a penalty
+	}
+	if classes > 0 {
+		losser = softmaxCrossEntropy{}
+	}
 
 	// Set a random initial starting condition
-	algorithm.RandomizeParameters()
+	randomizeParameters(algorithm, inputDim, c.Layers, c.Neurons, outputDim, c.random())
 	initLoc := algorithm.Parameters(nil)
+	if c.model != nil {
+		// or start from the loaded network
+		if len(c.model.Parameters) != len(initLoc) {
+			return nil, fmt.Errorf("loaded model has %d parameters, network needs %d", len(c.model.Parameters), len(initLoc))
+		}
+		copy(initLoc, c.model.Parameters)
+	}
+
+	var trainable train.Trainable = algorithm
+	times := &workerTimes{}
+	if workerTiming {
+		trainable = timedTrainable{algorithm, times}
+		losser = timedLosser{losser, times}
+		if regularizer != nil {
+			regularizer = timedRegularizer{regularizer, times}
+		}
+	}
 
 	// Set up the objective function
 	gradOpt := &train.GradOptimizable{
-		Trainable: algorithm,
+		Trainable: trainable,
 		Inputs:    inputData,
 		Outputs:   outputData,
 		Weights:   weights,
 
-		NumWorkers:  runtime.GOMAXPROCS(0),
+		NumWorkers:  c.Workers,
 		Losser:      losser,
 		Regularizer: regularizer,
 	}
+	fullBatch := *gradOpt
+	if c.BatchSize > 0 {
+		// Sample a mini-batch for each gradient evaluation
+		gradOpt.Sampler = &miniBatch{BatchSize: c.BatchSize, Rand: c.random()}
+	}
 
 	err = gradOpt.Init()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer gradOpt.Close()
 
-	settings := opt.DefaultSettings()
-	settings.FunctionAbsoluteTolerance = 1e-6
-	settings.MaximumFunctionEvaluations = 100
+	settings := stopSettings(c, trace, nTrain)
+	method, err := newMethod(c)
+	if err != nil {
+		return nil, err
+	}
+	objective := &timedObjective{GradOptimizable: gradOpt}
+	phases.Setup = lap()
+	result, err := opt.Minimize(objective, initLoc, settings, method)
+	if err != nil {
+		return nil, err
+	}
+	phases.Optimize = lap()
+	phases.Eval = objective.eval.Seconds()
+	phases.Predict = time.Duration(times.predict).Seconds()
+	phases.Deriv = time.Duration(times.deriv).Seconds()
+	phases.Loss = time.Duration(times.loss).Seconds()
+	phases.Regularize = time.Duration(times.regularize).Seconds()
+	loss := result.F
+	if c.BatchSize > 0 {
+		// The optimizer only saw mini-batch losses, so evaluate the loss on
+		// all of the data.
+		if err := fullBatch.Init(); err != nil {
+			return nil, err
+		}
+		loss = fullBatch.F(result.X)
+		fullBatch.Close()
+	}
+	algorithm.SetParameters(result.X)
+	var test *Metrics
+	if testInputs != nil {
+		test, err = testMetrics(algorithm.Predictor(), testInputs, testOutputs, classes, inputScaler.scaler(), outputScaling)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Record{
+		Name:         c.Name,
+		Case:         c,
+		Loss:         loss,
+		Iterations:   result.NumMajorIterations,
+		FunEvals:     result.NumFunEvals,
+		GradEvals:    result.NumGradEvals,
+		FunGradEvals: result.NumFunGradEvals,
+		NParams:      len(initLoc),
+		Status:       result.Status.String(),
+		Phases:       phases,
+		Test:         test,
+		predictor:    algorithm.Predictor(),
+		inputs:       inputData,
+		model: &Model{
+			Case:         c,
+			InputDim:     inputDim,
+			OutputDim:    outputDim,
+			Parameters:   result.X,
+			InputScaler:  inputScaler,
+			OutputScaler: outputScaler,
+		},
+	}, nil
+}
 
-	fmt.Println("nparams is ", len(initLoc))
-	defer profile.Start(profile.CPUProfile).Stop()
+// trainingOutputs splits the weights of the samples from the outputs, and
+// from the test outputs if they are not nil, and encodes the labels of a
+// classifier one-hot, returning the number of classes. The weights are nil if
+// the case does not weight the samples.
+func trainingOutputs(c Case, outputData, testOutputs *mat64.Dense) ([]float64, *mat64.Dense, *mat64.Dense, int, error) {
+	var weights []float64 = nil // Don't weight our data
+	switch c.Weights {
+	case "":
+	case weightColumn:
+		// unless the case has weights, which are read before the outputs
+		weights, outputData = firstColumn(outputData)
+		if testOutputs != nil {
+			_, testOutputs = firstColumn(testOutputs)
+		}
+	case weightImportance:
+		nTrain, _ := outputData.Dims()
+		weights = importanceWeights(nTrain, c.random())
+	default:
+		return nil, nil, nil, 0, fmt.Errorf("unknown weights %q", c.Weights)
+	}
+	if weights != nil {
+		if err := normalizeWeights(weights); err != nil {
+			return nil, nil, nil, 0, err
+		}
+	}
 
-	result, err := opt.Minimize(gradOpt, initLoc, settings, &opt.BFGS{})
+	classes := 0
+	switch c.Task {
+	case regression:
+	case classification:
+		// Predict the log odds of each class from the one-hot labels
+		if c.Outputs != 1 {
+			return nil, nil, nil, 0, fmt.Errorf("classification with %d outputs, need a single label column", c.Outputs)
+		}
+		var err error
+		classes, err = numClasses(outputData, testOutputs)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		outputData = oneHot(outputData, classes)
+	default:
+		return nil, nil, nil, 0, fmt.Errorf("unknown task %q", c.Task)
+	}
+	return weights, outputData, testOutputs, classes, nil
+}
+
+// scaleCase scales the training data of the case in place, returning the
+// scalers, which are nil for the outputs of a classifier with classes, and the
+// scaling of the outputs.
+func scaleCase(c Case, inputData, outputData *mat64.Dense, classes int) (inputScaler, outputScaler *Scaler, outputScaling scale.Scaler, err error) {
+	_, inputDim := inputData.Dims()
+	_, outputDim := outputData.Dims()
+	// Let's scale the data, by default to have mean zero and variance 1.
+	// ScaleData alone does not set the scale, so the scalers are fit by
+	// ScaleTrainingData. The labels of a classifier are left unscaled.
+	inputScaler, err = newScaler(c.InputScaling)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, nil, err
 	}
-	fmt.Println("optimum value is ", result.F)
-	fmt.Println(time.Since(t))
+	outputScaler, err = newScaler(c.OutputScaling)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	outputScaling = outputScaler.scaler()
+	if classes > 0 {
+		outputScaler = nil
+		outputScaling = &scale.None{}
+	}
+	if c.model != nil {
+		// Warm starts keep the scaling the loaded network was trained with
+		if c.model.InputDim != inputDim || c.model.OutputDim != outputDim {
+			return nil, nil, nil, fmt.Errorf("loaded model maps %d inputs to %d outputs, data has %d and %d", c.model.InputDim, c.model.OutputDim, inputDim, outputDim)
+		}
+		inputScaler = c.model.InputScaler
+		if classes == 0 {
+			outputScaler = c.model.OutputScaler
+			outputScaling = outputScaler.scaler()
+		}
+		if err := scale.ScaleData(inputScaler.scaler(), inputData); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := scale.ScaleData(outputScaling, outputData); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if err := scale.ScaleTrainingData(inputData, outputData, inputScaler.scaler(), outputScaling); err != nil {
+		return nil, nil, nil, err
+	}
+	return inputScaler, outputScaler, outputScaling, nil
+}
 
+// stopSettings returns the settings of the optimizer with the stopping
+// criteria of the case, which trains on nTrain samples, recording the
+// iterations with trace if it is not nil.
+func stopSettings(c Case, trace *tracer, nTrain int) *opt.Settings {
+	settings := opt.DefaultSettings()
+	settings.FunctionAbsoluteTolerance = c.Tolerance
+	settings.MaximumFunctionEvaluations = c.MaxEvals
+	if c.GradTolerance != 0 {
+		settings.GradientAbsoluteTolerance = c.GradTolerance
+	}
+	settings.MaximumMajorIterations = c.MaxIterations
+	settings.MaximumRuntime = time.Duration(c.MaxSeconds * float64(time.Second))
+	if quiet {
+		settings.Recorder = nil
+	}
+	if trace != nil {
+		if settings.Recorder == nil {
+			settings.Recorder = trace
+		} else {
+			settings.Recorder = recorders{settings.Recorder, trace}
+		}
+	}
+	if c.BatchSize > 0 && c.Epochs > 0 {
+		settings.MaximumFunctionEvaluations = c.Epochs * batchesPerEpoch(nTrain, c.BatchSize)
+	}
+	return settings
+}
+
+// newMethod returns the optimizer of the case with its learning rate, stopping
+// on the relative tolerance if it has one.
+func newMethod(c Case) (opt.Method, error) {
+	method, err := optimize.New(c.Optimizer)
+	if err != nil {
+		return nil, err
+	}
+	if c.Rate > 0 && !optimize.SetLearningRate(method, c.Rate) {
+		return nil, fmt.Errorf("optimizer %s has no learning rate", c.Optimizer)
+	}
+	if c.RelTolerance > 0 {
+		method = &optimize.RelativeStop{Method: method, Tolerance: c.RelTolerance}
+	}
+	return method, nil
+}
+
+// testMetrics returns the errors of the trained model on the test inputs,
+// which are scaled in place, and outputs. The inputs are scaled, and the
+// predictions unscaled, with the scalers of the training data. If classes is
+// not zero, the outputs are class labels and the network predicts their log
+// odds.
+func testMetrics(predictor common.Predictor, inputs, truth *mat64.Dense, classes int, inputScaler, outputScaler scale.Scaler) (*Metrics, error) {
+	n, inputDim := inputs.Dims()
+	row := make([]float64, inputDim)
+	for i := 0; i < n; i++ {
+		inputs.Row(row, i)
+		if err := inputScaler.Scale(row); err != nil {
+			return nil, err
+		}
+		inputs.SetRow(i, row)
+	}
+	return predictionMetrics(predictor, inputs, truth, classes, outputScaler)
+}
+
+// predictionMetrics returns the errors of the predictions of the scaled
+// inputs, unscaled with outputScaler, as for testMetrics.
+func predictionMetrics(predictor common.Predictor, inputs, truth *mat64.Dense, classes int, outputScaler scale.Scaler) (*Metrics, error) {
+	n, _ := inputs.Dims()
+	if classes > 0 {
+		pred := mat64.NewDense(n, classes, nil)
+		if _, err := predictor.PredictBatch(inputs, pred); err != nil {
+			return nil, err
+		}
+		m := classMetrics(pred, truth)
+		return &m, nil
+	}
+	_, outputDim := truth.Dims()
+	pred := mat64.NewDense(n, outputDim, nil)
+	if _, err := predictor.PredictBatch(inputs, pred); err != nil {
+		return nil, err
+	}
+	if err := scale.UnscaleData(outputScaler, pred); err != nil {
+		return nil, err
+	}
+	m := errorMetrics(pred, truth)
+	return &m, nil
 }
@@ -1,20 +1,23 @@
 package nettrainbench
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"log"
-	"os"
 	"runtime"
 	"testing"
 
+	"github.com/btracey/gobench/nettrainbench/benchdata"
+	"github.com/btracey/gobench/nettrainbench/datagen"
 	"github.com/btracey/numcsv"
 	"github.com/gonum/blas/dbw"
 	"github.com/gonum/blas/goblas"
 	"github.com/gonum/matrix/mat64"
 	"github.com/gonum/opt"
+	"github.com/reggo/reggo/common"
 	"github.com/reggo/reggo/loss"
 	"github.com/reggo/reggo/regularize"
-	"github.com/reggo/reggo/scale"
 	"github.com/reggo/reggo/supervised/nnet"
 	"github.com/reggo/reggo/train"
 )
@@ -24,57 +27,25 @@ func init() {
 	dbw.Register(goblas.Blas{})
 }
 
-func setupBenchmark(nData int) (inputData, outputData *mat64.Dense) {
-	filename := "data.txt" // Assumes exp4 is in the working directory
-
-	// Open the data file
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Read in the data
-	// numcsv is a wrapper I wrote over the normal go csv parser. The Go csv
-	// parser returns strings. This assumes that the data is numeric with possibly
-	// some column headings at the top, so it returns a matrix of data instead
-	// of strings.
-	r := numcsv.NewReader(f)
-	r.Comma = " " // the file is space dilimeted (ish)
-	_, err = r.ReadHeading()
-	if err != nil {
-		log.Fatal(err)
-	}
-	//log.Println("The headings are: ", headings)
+// perEval reports the time of the training benchmarks per function
+// evaluation as well as per run, for comparing runs that converge early.
+var perEval = flag.Bool("pereval", false, "also report the training time per function evaluation")
 
-	allData, err := r.ReadAll()
+// setupBenchmark returns the first nData samples of exp4, or of synthetic data
+// if the file is missing, scaled to mean zero and variance one. The data is
+// shared by the benchmarks and must not be modified.
+func setupBenchmark(nData int) (inputData, outputData *mat64.Dense) {
+	inputData, outputData, err := benchdata.LoadDataset(benchdata.Exp4.Name, nData)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	nSamples, nDim := allData.Dims()
-	_ = nSamples
-	if nDim != 4 {
-		log.Fatal("Code assumes there are 4 columns")
-	}
-
-	// Make the input and output data, copied from submatrices of all data
-	// Uses the gonum matrix package: https://godoc.org/github.com/gonum/matrix/mat64
-	inputData = &mat64.Dense{} // allocate a new matrix that the data can be copied into
-	outputData = &mat64.Dense{}
-	inputData.Submatrix(allData, 0, 0, nData, nDim-1)  // copy the first nDim - 1 columns to inputs
-	outputData.Submatrix(allData, 0, nDim-1, nData, 1) // copy the last column
-
-	// Let's scale the data to have mean zero and variance 1
-	inputScaler := &scale.Normal{}
-	scale.ScaleData(inputScaler, inputData)
-
-	outputScaler := &scale.Normal{}
-	scale.ScaleData(outputScaler, outputData)
-
 	return inputData, outputData
 }
 
-func benchmarkNeuralNet(inputData, outputData *mat64.Dense, nHiddenNeurons int, nFunEvals int) {
+// trainNeuralNet trains a network on the data with at most nFunEvals function
+// evaluations, returning the result of the optimization. The optimizer may
+// converge in fewer.
+func trainNeuralNet(inputData, outputData *mat64.Dense, nHiddenNeurons int, nFunEvals int) *opt.Result {
 
 	_, inputDim := inputData.Dims()
 	_, outputDim := outputData.Dims()
@@ -126,34 +97,138 @@ func benchmarkNeuralNet(inputData, outputData *mat64.Dense, nHiddenNeurons int,
 	if err != nil {
 		log.Fatal(err)
 	}
-	totalFunEvals := result.NumFunGradEvals
-	if totalFunEvals < nFunEvals {
-		fmt.Println(totalFunEvals)
-		fmt.Println(nFunEvals)
-		panic("didn't reach total number of function evaluations")
+	return result
+}
+
+// trainCases are the networks of the training benchmarks: their hidden
+// neurons and their budgets of function evaluations.
+var trainCases = []struct{ neurons, evals int }{{5, 50}, {20, 100}, {100, 20}}
+
+// BenchmarkTrain times training each of the trainCases, as sub-benchmarks
+// named by their neurons and budget such as neurons=5/evals=50.
+func BenchmarkTrain(b *testing.B) {
+	for _, c := range trainCases {
+		b.Run(fmt.Sprintf("neurons=%d/evals=%d", c.neurons, c.evals), func(b *testing.B) {
+			benchmarkNeuralNet(b, c.neurons, c.evals)
+		})
 	}
 }
 
-func BenchmarkFiveNeurons(b *testing.B) {
+// benchmarkNeuralNet times training with a budget of nFunEvals function
+// evaluations. A run that converges within the budget is not an error; the
+// evaluations actually made are reported as evals/op, the fraction of runs
+// that stopped before the budget as early/op, and the mean final training loss
+// as final-loss. With -pereval the time per evaluation is reported as ns/eval.
+func benchmarkNeuralNet(b *testing.B, nHiddenNeurons int, nFunEvals int) {
 	inputs, outputs := setupBenchmark(10000)
+	var evals, early int
+	var finalLoss float64
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		benchmarkNeuralNet(inputs, outputs, 5, 50)
+		result := trainNeuralNet(inputs, outputs, nHiddenNeurons, nFunEvals)
+		evals += result.NumFunEvals + result.NumFunGradEvals
+		finalLoss += result.F
+		if result.Status != opt.FunctionEvaluationLimit {
+			early++
+		}
+	}
+	b.ReportMetric(float64(evals)/float64(b.N), "evals/op")
+	b.ReportMetric(float64(early)/float64(b.N), "early/op")
+	b.ReportMetric(finalLoss/float64(b.N), "final-loss")
+	if *perEval && evals > 0 {
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(evals), "ns/eval")
+	}
+}
+
+// newNet returns a network like that of trainNeuralNet for the data, with
+// random parameters. Prediction takes the same time whatever the values of the
+// parameters, so the inference benchmarks do not train it.
+func newNet(inputData, outputData *mat64.Dense, nHiddenNeurons int) common.Predictor {
+	_, inputDim := inputData.Dims()
+	_, outputDim := outputData.Dims()
+	algorithm, err := nnet.NewSimpleTrainer(inputDim, outputDim, 2, nHiddenNeurons, nnet.Tanh{}, nnet.Linear{})
+	if err != nil {
+		log.Fatal(err)
 	}
+	algorithm.RandomizeParameters()
+	return algorithm.Predictor()
 }
 
-func BenchmarkTwentydNeurons(b *testing.B) {
+// benchmarkPredictBatch times predicting all of the rows with PredictBatch,
+// which splits them between goroutines.
+func benchmarkPredictBatch(b *testing.B, nHiddenNeurons int) {
 	inputs, outputs := setupBenchmark(10000)
+	net := newNet(inputs, outputs, nHiddenNeurons)
+	pred := &mat64.Dense{}
+	pred.Clone(outputs)
+	nRows, _ := inputs.Dims()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		benchmarkNeuralNet(inputs, outputs, 20, 100)
+		if _, err := net.PredictBatch(inputs, pred); err != nil {
+			b.Fatal(err)
+		}
 	}
+	b.ReportMetric(float64(b.N*nRows)/b.Elapsed().Seconds(), "rows/s")
 }
 
-func BenchmarkHundredNeurons(b *testing.B) {
+// benchmarkPredictRow times predicting a single row with Predict.
+func benchmarkPredictRow(b *testing.B, nHiddenNeurons int) {
 	inputs, outputs := setupBenchmark(10000)
+	net := newNet(inputs, outputs, nHiddenNeurons)
+	nRows, _ := inputs.Dims()
+	output := make([]float64, net.OutputDim())
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		benchmarkNeuralNet(inputs, outputs, 100, 20)
+		if _, err := net.Predict(inputs.RowView(i%nRows), output); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+// BenchmarkPredict times prediction with the networks of the trainCases,
+// batched and a row at a time, as sub-benchmarks such as batch/neurons=5.
+func BenchmarkPredict(b *testing.B) {
+	for _, mode := range []struct {
+		name  string
+		bench func(*testing.B, int)
+	}{{"batch", benchmarkPredictBatch}, {"row", benchmarkPredictRow}} {
+		for _, c := range trainCases {
+			b.Run(fmt.Sprintf("%s/neurons=%d", mode.name, c.neurons), func(b *testing.B) {
+				mode.bench(b, c.neurons)
+			})
+		}
+	}
+}
+
+// BenchmarkReadCSV times reading synthetic data like exp4 as CSV with
+// numcsv, as sub-benchmarks by the number of rows, reporting rows/s and MB/s.
+// The CSV is in memory, so the file system is not timed.
+func BenchmarkReadCSV(b *testing.B) {
+	for _, n := range []int{1000, 100000} {
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			spec := benchdata.Exp4Fallback
+			spec.N = n
+			var buf bytes.Buffer
+			if err := datagen.Write(&buf, spec); err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(buf.Len()))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r := numcsv.NewReader(bytes.NewReader(buf.Bytes()))
+				if _, err := r.ReadHeading(); err != nil {
+					b.Fatal(err)
+				}
+				d, err := r.ReadAll()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if rows, _ := d.Dims(); rows != n {
+					b.Fatalf("read %d rows, want %d", rows, n)
+				}
+			}
+			b.ReportMetric(float64(b.N*n)/b.Elapsed().Seconds(), "rows/s")
+		})
 	}
 }
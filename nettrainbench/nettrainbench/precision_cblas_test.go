@@ -0,0 +1,9 @@
+//go:build cblas
+
+package nettrainbench
+
+import "github.com/gonum/blas/cblas"
+
+func init() {
+	sgemm, dgemm = cblas.Blas{}.Sgemm, cblas.Blas{}.Dgemm
+}
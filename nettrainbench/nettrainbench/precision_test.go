@@ -0,0 +1,282 @@
+package nettrainbench
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/blas"
+	"github.com/gonum/matrix/mat64"
+)
+
+// The nnet package of reggo trains in float64 only, so the precision
+// benchmarks train a network of the same shape as trainNeuralNet, written
+// once for both float32 and float64 over the gemm of each precision.
+
+type float interface{ ~float32 | ~float64 }
+
+// gemm computes c = alpha * op(a) * op(b) + beta * c of row-major matrices,
+// as the Sgemm and Dgemm routines of BLAS.
+type gemm[T float] func(tA, tB blas.Transpose, m, n, k int, alpha T, a []T, lda int, b []T, ldb int, beta T, c []T, ldc int)
+
+// The gemm of each precision. The pure Go BLAS has no single precision
+// routines, so by default both precisions use goGemm and compare the same
+// code. Building with the cblas tag uses the Sgemm and Dgemm of cblas.
+var (
+	sgemm gemm[float32] = goGemm[float32]
+	dgemm gemm[float64] = goGemm[float64]
+)
+
+// goGemm is a plain Go gemm, sufficient for the products of mlp.
+func goGemm[T float](tA, tB blas.Transpose, m, n, k int, alpha T, a []T, lda int, b []T, ldb int, beta T, c []T, ldc int) {
+	for i := 0; i < m; i++ {
+		ci := c[i*ldc : i*ldc+n]
+		for j := range ci {
+			ci[j] *= beta
+		}
+		if tB != blas.NoTrans {
+			// Rows of b are columns of op(b), so each element is a dot product.
+			for j := range ci {
+				var sum T
+				bj := b[j*ldb : j*ldb+k]
+				for l, v := range bj {
+					sum += aik(tA, a, lda, i, l) * v
+				}
+				ci[j] += alpha * sum
+			}
+			continue
+		}
+		for l := 0; l < k; l++ {
+			s := alpha * aik(tA, a, lda, i, l)
+			if s == 0 {
+				continue
+			}
+			for j, v := range b[l*ldb : l*ldb+n] {
+				ci[j] += s * v
+			}
+		}
+	}
+}
+
+// aik returns the element i, k of op(a).
+func aik[T float](tA blas.Transpose, a []T, lda, i, k int) T {
+	if tA == blas.NoTrans {
+		return a[i*lda+k]
+	}
+	return a[k*lda+i]
+}
+
+// mlp is a network with tanh hidden layers and a linear output layer, fit to
+// a batch of rows by full-batch gradient descent on the mean squared distance.
+type mlp[T float] struct {
+	gemm   gemm[T]
+	sizes  []int // units of each layer, the inputs first
+	params []T   // the weights then the biases of each layer
+	grad   []T
+	w, b   [][]T // of each layer, in params
+	dw, db [][]T // of each layer, in grad
+	acts   [][]T // of each layer for the batch, the inputs first
+	deltas [][]T // of the loss with respect to the inputs of each layer
+	rows   int
+}
+
+// newMLP returns a network with the units of sizes for batches of rows, with
+// parameters drawn from the seed. The parameters are drawn in float64 and
+// rounded, so the networks of both precisions start from the same place.
+func newMLP[T float](g gemm[T], sizes []int, rows int, seed int64) *mlp[T] {
+	m := &mlp[T]{gemm: g, sizes: sizes, rows: rows}
+	var n int
+	for l := 1; l < len(sizes); l++ {
+		n += (sizes[l-1] + 1) * sizes[l]
+	}
+	m.params, m.grad = make([]T, n), make([]T, n)
+	rnd := rand.New(rand.NewSource(seed))
+	var off int
+	for l := 1; l < len(sizes); l++ {
+		in, out := sizes[l-1], sizes[l]
+		m.w = append(m.w, m.params[off:off+in*out])
+		m.dw = append(m.dw, m.grad[off:off+in*out])
+		off += in * out
+		m.b = append(m.b, m.params[off:off+out])
+		m.db = append(m.db, m.grad[off:off+out])
+		off += out
+		r := math.Sqrt(6 / float64(in+out)) // Glorot uniform
+		for i := range m.w[l-1] {
+			m.w[l-1][i] = T(r * (2*rnd.Float64() - 1))
+		}
+	}
+	for _, s := range sizes {
+		m.acts = append(m.acts, make([]T, rows*s))
+		m.deltas = append(m.deltas, make([]T, rows*s))
+	}
+	return m
+}
+
+// lossGrad returns the mean squared distance of the network on the inputs x
+// from the targets t, halved, and sets grad to its gradient.
+func (m *mlp[T]) lossGrad(x, t []T) T {
+	copy(m.acts[0], x)
+	last := len(m.sizes) - 1
+	for l := 1; l <= last; l++ {
+		in, out := m.sizes[l-1], m.sizes[l]
+		a := m.acts[l]
+		for i := 0; i < m.rows; i++ {
+			copy(a[i*out:(i+1)*out], m.b[l-1])
+		}
+		m.gemm(blas.NoTrans, blas.NoTrans, m.rows, out, in, 1, m.acts[l-1], in, m.w[l-1], out, 1, a, out)
+		if l < last {
+			for i, v := range a {
+				a[i] = T(math.Tanh(float64(v)))
+			}
+		}
+	}
+	var loss T
+	scale := 1 / T(m.rows)
+	d := m.deltas[last]
+	for i, v := range m.acts[last] {
+		d[i] = v - t[i]
+		loss += d[i] * d[i]
+		d[i] *= scale
+	}
+	for l := last; l >= 1; l-- {
+		in, out := m.sizes[l-1], m.sizes[l]
+		d := m.deltas[l]
+		m.gemm(blas.Trans, blas.NoTrans, in, out, m.rows, 1, m.acts[l-1], in, d, out, 0, m.dw[l-1], out)
+		db := m.db[l-1]
+		for j := range db {
+			db[j] = 0
+		}
+		for i := 0; i < m.rows; i++ {
+			for j, v := range d[i*out : (i+1)*out] {
+				db[j] += v
+			}
+		}
+		if l == 1 {
+			break
+		}
+		prev := m.deltas[l-1]
+		m.gemm(blas.NoTrans, blas.Trans, m.rows, in, out, 1, d, out, m.w[l-1], out, 0, prev, in)
+		for i, a := range m.acts[l-1] {
+			prev[i] *= 1 - a*a
+		}
+	}
+	return loss * scale / 2
+}
+
+// train fits the network to the targets with evals evaluations of the loss
+// and gradient by Adam, returning the loss of the last.
+func (m *mlp[T]) train(x, t []T, evals int) T {
+	const (
+		rate  = 1e-2
+		beta1 = 0.9
+		beta2 = 0.999
+		eps   = 1e-8
+	)
+	mean, sq := make([]T, len(m.params)), make([]T, len(m.params))
+	var loss T
+	for k := 1; k <= evals; k++ {
+		loss = m.lossGrad(x, t)
+		step := rate * math.Sqrt(1-math.Pow(beta2, float64(k))) / (1 - math.Pow(beta1, float64(k)))
+		for i, g := range m.grad {
+			mean[i] = beta1*mean[i] + (1-beta1)*g
+			sq[i] = beta2*sq[i] + (1-beta2)*g*g
+			m.params[i] -= T(step) * mean[i] / (T(math.Sqrt(float64(sq[i]))) + eps)
+		}
+	}
+	return loss
+}
+
+// rowMajor returns the elements of d in row-major order.
+func rowMajor[T float](d *mat64.Dense) []T {
+	r, c := d.Dims()
+	s := make([]T, 0, r*c)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			s = append(s, T(d.At(i, j)))
+		}
+	}
+	return s
+}
+
+// BenchmarkTrainPrecision times training networks like those of the
+// trainCases with float32 and float64 parameters, as sub-benchmarks such as
+// float32/neurons=5/evals=50, reporting the final training loss as final-loss
+// and the time per evaluation of the loss and gradient as ns/eval.
+func BenchmarkTrainPrecision(b *testing.B) {
+	for _, c := range trainCases {
+		name := fmt.Sprintf("neurons=%d/evals=%d", c.neurons, c.evals)
+		b.Run("float32/"+name, func(b *testing.B) {
+			benchmarkMLP(b, sgemm, c.neurons, c.evals)
+		})
+		b.Run("float64/"+name, func(b *testing.B) {
+			benchmarkMLP(b, dgemm, c.neurons, c.evals)
+		})
+	}
+}
+
+func benchmarkMLP[T float](b *testing.B, g gemm[T], nHiddenNeurons, evals int) {
+	inputs, outputs := setupBenchmark(10000)
+	rows, inputDim := inputs.Dims()
+	_, outputDim := outputs.Dims()
+	x, t := rowMajor[T](inputs), rowMajor[T](outputs)
+	sizes := []int{inputDim, nHiddenNeurons, nHiddenNeurons, outputDim}
+	var finalLoss float64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := newMLP(g, sizes, rows, 1)
+		finalLoss += float64(m.train(x, t, evals))
+	}
+	b.ReportMetric(finalLoss/float64(b.N), "final-loss")
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*evals), "ns/eval")
+}
+
+// TestMLPGradient checks the gradient of mlp against central differences in
+// float64, and that the float32 network agrees with it.
+func TestMLPGradient(t *testing.T) {
+	const rows = 7
+	sizes := []int{3, 4, 4, 2}
+	rnd := rand.New(rand.NewSource(2))
+	x, y := make([]float64, rows*sizes[0]), make([]float64, rows*sizes[3])
+	for i := range x {
+		x[i] = rnd.NormFloat64()
+	}
+	for i := range y {
+		y[i] = rnd.NormFloat64()
+	}
+	m := newMLP(goGemm[float64], sizes, rows, 1)
+	loss := m.lossGrad(x, y)
+	grad := append([]float64(nil), m.grad...)
+	const h = 1e-6
+	for i := range m.params {
+		p := m.params[i]
+		m.params[i] = p + h
+		up := m.lossGrad(x, y)
+		m.params[i] = p - h
+		down := m.lossGrad(x, y)
+		m.params[i] = p
+		if fd := (up - down) / (2 * h); math.Abs(fd-grad[i]) > 1e-6 {
+			t.Errorf("parameter %d: gradient %v, central difference %v", i, grad[i], fd)
+		}
+	}
+
+	m32 := newMLP(goGemm[float32], sizes, rows, 1)
+	var x32, y32 []float32
+	for _, v := range x {
+		x32 = append(x32, float32(v))
+	}
+	for _, v := range y {
+		y32 = append(y32, float32(v))
+	}
+	if loss32 := m32.lossGrad(x32, y32); math.Abs(float64(loss32)-loss) > 1e-5*loss {
+		t.Errorf("float32 loss %v, float64 loss %v", loss32, loss)
+	}
+	for i, g := range m32.grad {
+		if math.Abs(float64(g)-grad[i]) > 1e-4 {
+			t.Errorf("parameter %d: float32 gradient %v, float64 gradient %v", i, g, grad[i])
+		}
+	}
+	if trained := m.train(x, y, 200); !(trained < loss) {
+		t.Errorf("loss %v after training from %v", trained, loss)
+	}
+}
@@ -0,0 +1,1333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btracey/gobench/nettrainbench/datagen"
+	"github.com/gonum/blas/goblas"
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/scale"
+)
+
+func init() {
+	mat64.Register(goblas.Blas{})
+}
+
+var (
+	update     = flag.Bool("update", false, "rewrite the golden results of TestGolden")
+	goldenBLAS = flag.String("blas", "go", "BLAS implementation TestGolden trains with")
+)
+
+// testCase is a small case on synthetic data.
+var testCase = Case{
+	Name:       "test",
+	NData:      500,
+	Layers:     2,
+	Neurons:    5,
+	Tolerance:  1e-6,
+	MaxEvals:   100,
+	Workers:    1,
+	Optimizer:  "bfgs",
+	Activation: "tanh",
+	Task:       regression,
+	Outputs:    1,
+	Synthetic:  &datagen.Spec{Kind: datagen.Friedman1, N: 500, Seed: 1},
+
+	InputScaling:  scaleNormal,
+	OutputScaling: scaleNormal,
+
+	Method: methodNet,
+}
+
+func TestSeed(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var losses []float64
+	for i := 0; i < 2; i++ {
+		c := testCase
+		c.rand = rand.New(rand.NewSource(42))
+		rec, err := runCase(c, data, nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		losses = append(losses, rec.Loss)
+	}
+	if losses[0] != losses[1] {
+		t.Errorf("losses differ with the same seed: %v", losses)
+	}
+	// The golden loss allows for the fused multiply-adds of some
+	// architectures.
+	const golden = 0.004042850394487853
+	if math.Abs(losses[0]-golden) > 1e-9*math.Abs(golden) {
+		t.Errorf("loss %v, want %v", losses[0], golden)
+	}
+}
+
+func TestSoftmaxCrossEntropy(t *testing.T) {
+	pred := []float64{0.5, -1, 2}
+	truth := []float64{0, 1, 0}
+	deriv := make([]float64, len(pred))
+	l := softmaxCrossEntropy{}.LossDeriv(pred, truth, deriv)
+	if got := (softmaxCrossEntropy{}).Loss(pred, truth); got != l {
+		t.Errorf("Loss %v, LossDeriv %v", got, l)
+	}
+	const h = 1e-6
+	for i := range pred {
+		x := pred[i]
+		pred[i] = x + h
+		up := softmaxCrossEntropy{}.Loss(pred, truth)
+		pred[i] = x - h
+		down := softmaxCrossEntropy{}.Loss(pred, truth)
+		pred[i] = x
+		if fd := (up - down) / (2 * h); math.Abs(fd-deriv[i]) > 1e-6 {
+			t.Errorf("derivative %d: got %v, finite difference %v", i, deriv[i], fd)
+		}
+	}
+}
+
+func TestClassification(t *testing.T) {
+	c := testCase
+	c.Task = classification
+	c.Holdout = 0.2
+	c.Synthetic = &datagen.Spec{Kind: datagen.Shells, N: 600, Seed: 1}
+	data, err := datagen.Generate(*c.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.rand = rand.New(rand.NewSource(1))
+	rec, err := runCase(c, data, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Test == nil || rec.Test.Classes != 3 || rec.Test.N != 100 {
+		t.Fatalf("test metrics %+v, want 3 classes and 100 samples", rec.Test)
+	}
+	if rec.Test.Accuracy < 0.7 {
+		t.Errorf("accuracy %v, want at least 0.7", rec.Test.Accuracy)
+	}
+}
+
+func TestMultiOutput(t *testing.T) {
+	c := testCase
+	c.Outputs = 3
+	c.Holdout = 0.2
+	c.Synthetic = &datagen.Spec{Kind: datagen.Sinusoid, N: 600, Dim: 2, Out: 3, Seed: 1}
+	data, err := datagen.Generate(*c.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.rand = rand.New(rand.NewSource(1))
+	rec, err := runCase(c, data, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Test == nil || len(rec.Test.Outputs) != 3 {
+		t.Fatalf("test metrics %+v, want 3 outputs", rec.Test)
+	}
+	// The pooled mean squared error is the mean over the outputs.
+	var mse float64
+	for _, o := range rec.Test.Outputs {
+		mse += o.RMSE * o.RMSE / 3
+	}
+	if math.Abs(mse-rec.Test.RMSE*rec.Test.RMSE) > 1e-12*mse {
+		t.Errorf("pooled MSE %v, mean over outputs %v", rec.Test.RMSE*rec.Test.RMSE, mse)
+	}
+}
+
+func TestPenalty(t *testing.T) {
+	p := penalty{L1: 0.3, L2: 0.7}
+	w := []float64{1.5, -2, 0.25}
+	if got, want := p.Loss(w), 0.3*3.75+0.7*6.3125; math.Abs(got-want) > 1e-14 {
+		t.Errorf("loss %v, want %v", got, want)
+	}
+	deriv := []float64{5, 5, 5}
+	p.LossDeriv(w, deriv)
+	for i, want := range []float64{0.3 + 1.4*1.5, -0.3 - 1.4*2, 0.3 + 1.4*0.25} {
+		if math.Abs(deriv[i]-want) > 1e-14 {
+			t.Errorf("derivative %d: got %v, want %v", i, deriv[i], want)
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := testCase
+	uc.rand = rand.New(rand.NewSource(42))
+	want, err := runCase(uc, data, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A column of equal weights gives the unweighted loss.
+	r, c := data.Dims()
+	weighted := mat64.NewDense(r, c+1, nil)
+	row := make([]float64, c+1)
+	for i := 0; i < r; i++ {
+		data.Row(row[:c], i)
+		row[c-1], row[c] = 2, row[c-1]
+		weighted.SetRow(i, row)
+	}
+	wc := testCase
+	wc.Weights = weightColumn
+	wc.rand = rand.New(rand.NewSource(42))
+	got, err := runCase(wc, weighted, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Loss != want.Loss {
+		t.Errorf("loss with equal weights %v, want %v", got.Loss, want.Loss)
+	}
+
+	wc.Weights = weightImportance
+	if _, err := runCase(wc, data, nil, false); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCase
+	c.rand = rand.New(rand.NewSource(1))
+	rec, err := runCase(c, data, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"model.gob", "model.json"} {
+		filename := filepath.Join(dir, name)
+		if err := saveModel(filename, rec.model); err != nil {
+			t.Fatal(err)
+		}
+		m, err := loadModel(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(m.Parameters, rec.model.Parameters) || !reflect.DeepEqual(m.InputScaler, rec.model.InputScaler) {
+			t.Errorf("%s: model differs after round trip", name)
+		}
+
+		// Training from the saved network starts at its loss.
+		c := testCase
+		c.model = m
+		c.MaxEvals = 10
+		warm, err := runCase(c, data, nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if warm.Loss > rec.Loss*(1+1e-12) {
+			t.Errorf("%s: warm start loss %v, above the saved loss %v", name, warm.Loss, rec.Loss)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	se, err := parseSearch("n=50;seed=3;neurons=5:8;l2=1e-4:1e-2;rate=1e-3:1e-1;optimizers=bfgs,adam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := se.cases(testCase)
+	if len(cases) != 50 {
+		t.Fatalf("%d cases, want 50", len(cases))
+	}
+	for _, c := range cases {
+		if c.Neurons < 5 || c.Neurons > 8 || c.Layers != testCase.Layers {
+			t.Errorf("%s: %d layers of %d neurons out of range", c.Name, c.Layers, c.Neurons)
+		}
+		if c.L2 < 1e-4 || c.L2 > 1e-2 || c.L1 != 0 {
+			t.Errorf("%s: l1 %v, l2 %v out of range", c.Name, c.L1, c.L2)
+		}
+		if (c.Optimizer == "bfgs") != (c.Rate == 0) || c.Rate > 1e-1 {
+			t.Errorf("%s: rate %v for %s", c.Name, c.Rate, c.Optimizer)
+		}
+	}
+	if again := se.cases(testCase); !reflect.DeepEqual(again, cases) {
+		t.Error("draws differ between calls")
+	}
+	for _, bad := range []string{"n=0", "n=5;l2=0:1", "n=5;neurons=8:5", "n=5;optimizers=newton", "n=5;width=3:4"} {
+		if _, err := parseSearch(bad); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+}
+
+func TestSuites(t *testing.T) {
+	c, err := readConfig("cases.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := len(c.resolve())
+	full := *c
+	if _, err := full.selectSuite("full"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(full.resolve()); n != all {
+		t.Errorf("full suite has %d cases, want all %d", n, all)
+	}
+	smoke := *c
+	suite, err := smoke.selectSuite("smoke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, cs := range smoke.resolve() {
+		names = append(names, cs.Name)
+	}
+	if want := []string{"FiveNeurons", "LeastSquares"}; !reflect.DeepEqual(names, want) || suite.Timeout != 60 {
+		t.Errorf("smoke suite has cases %v and timeout %v, want %v and 60", names, suite.Timeout, want)
+	}
+	if _, err := c.selectSuite("weekly"); err == nil {
+		t.Error("unknown suite: no error")
+	}
+	for _, bad := range []Suite{{Cases: []string{"Missing"}}, {Cases: []string{"FiveNeurons", "FiveNeurons"}}, {Timeout: -1}} {
+		if err := c.checkSuite("bad", bad); err == nil {
+			t.Errorf("%+v: no error", bad)
+		}
+	}
+}
+
+func TestIsolate(t *testing.T) {
+	ok := &Record{Name: "ok"}
+	for _, test := range []struct {
+		fit    func() (*Record, error)
+		status string
+	}{
+		{func() (*Record, error) { return ok, nil }, ""},
+		{func() (*Record, error) { return nil, errors.New("bad case") }, statusFailed},
+		{func() (*Record, error) { panic("diverged") }, statusPanicked},
+		{func() (*Record, error) { time.Sleep(time.Second); return ok, nil }, statusTimedOut},
+	} {
+		rec, err := isolate(0.05, test.fit)
+		if test.status == "" {
+			if err != nil || rec != ok {
+				t.Errorf("finished run: record %v, error %v", rec, err)
+			}
+			continue
+		}
+		f, isFailure := err.(*runFailure)
+		if !isFailure || f.status != test.status {
+			t.Errorf("error %v, want status %s", err, test.status)
+		}
+	}
+}
+
+func TestCPUList(t *testing.T) {
+	cpus, err := parseCPUList("6,0-3, 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2, 3, 6}; !reflect.DeepEqual(cpus, want) {
+		t.Errorf("cpus %v, want %v", cpus, want)
+	}
+	for _, bad := range []string{"", "a", "3-1", "-2", "1-"} {
+		if _, err := parseCPUList(bad); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+	restore := setGOGC(400)
+	if old := debug.SetGCPercent(400); old != 400 {
+		t.Errorf("GOGC %d during the run, want 400", old)
+	}
+	restore()
+	if old := debug.SetGCPercent(100); old == 400 {
+		t.Error("GOGC not restored")
+	}
+}
+
+func TestMachine(t *testing.T) {
+	e := environment()
+	if len(e.Machine) != 12 || e.OS == "" {
+		t.Fatalf("machine %q, os %q", e.Machine, e.OS)
+	}
+	pinned := e
+	pinned.GOMAXPROCS, pinned.Affinity, pinned.GoVersion = 1, "0", "go1"
+	if pinned.machine() != e.Machine {
+		t.Error("machine depends on the settings of the run")
+	}
+	other := e
+	other.Memory++
+	if other.machine() == e.Machine {
+		t.Error("machine does not depend on the memory")
+	}
+}
+
+//go:noinline
+func spin(d time.Duration) (x float64) {
+	for start := time.Now(); time.Since(start) < d; {
+		for i := 0; i < 1000; i++ {
+			x += math.Sqrt(float64(i))
+		}
+	}
+	return x
+}
+
+func TestTopFunctions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cpu.prof")
+	stop, err := profiles{cpu: filename}.start(testCase, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spin(300 * time.Millisecond)
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	top, err := topFunctions(filename, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 3 {
+		t.Fatalf("%d functions, want 3", len(top))
+	}
+	for i, f := range top {
+		if i > 0 && f.Cum > top[i-1].Cum || f.Flat > f.Cum {
+			t.Errorf("%d: %+v out of order", i, f)
+		}
+	}
+	for _, f := range top {
+		if strings.HasSuffix(f.Function, ".spin") {
+			if f.Cum < 0.1 {
+				t.Errorf("spin took %gs, want most of 0.3s", f.Cum)
+			}
+			return
+		}
+	}
+	t.Errorf("spin not in %+v", top)
+}
+
+var sink []byte
+
+func TestMemory(t *testing.T) {
+	meter := startMemory()
+	for i := 0; i < 100; i++ {
+		sink = make([]byte, 1<<20)
+	}
+	runtime.GC()
+	m := meter.stop()
+	if m.Mallocs < 100 || m.Bytes < 100<<20 {
+		t.Errorf("allocated %d objects of %d bytes, want at least 100 of 100 MiB", m.Mallocs, m.Bytes)
+	}
+	if m.NumGC == 0 || m.PauseSeconds < m.MaxPause || m.PeakHeap < 1<<20 {
+		t.Errorf("memory %v: collections and peak heap missing", m)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, 4, true)
+	for i, status := range []string{progressOK, progressFailed} {
+		if err := p.finish(fmt.Sprint("case", i), status, time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dec := json.NewDecoder(&buf)
+	for i := 0; i < 2; i++ {
+		var line caseProgress
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		if line.Done != i+1 || line.Total != 4 || line.ETA < 0 {
+			t.Errorf("line %d: %+v", i, line)
+		}
+		if i == 1 && (line.Status != progressFailed || line.ETA < line.Elapsed*0.99) {
+			t.Errorf("line %d: %+v, want failed with an ETA of the elapsed time", i, line)
+		}
+	}
+	buf.Reset()
+	if err := newProgress(&buf, 1, false).finish("only", progressOK, time.Second); err != nil || buf.Len() != 0 {
+		t.Errorf("single case reported %q, error %v", buf.String(), err)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	ckpt, _, err := createCheckpoint(filename, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := []Record{{Name: "a", Run: 0}, {Name: "a", Run: 1}}
+	b := []Record{{Name: "b", Status: statusFailed, Error: "bad case"}}
+	for _, recs := range [][]Record{a, b} {
+		if err := ckpt.write(recs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ckpt.Close()
+	// Cut the last case short, as if interrupted while writing it.
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(filename, info.Size()-5); err != nil {
+		t.Fatal(err)
+	}
+	ckpt, done, err := createCheckpoint(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || len(done["a"]) != 2 || done["a"][1].Run != 1 {
+		t.Fatalf("resumed %v, want the two runs of a", done)
+	}
+	if err := ckpt.write(b); err != nil {
+		t.Fatal(err)
+	}
+	ckpt.Close()
+	done, _, err = readCheckpoint(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || done["b"][0].Error != "bad case" {
+		t.Errorf("after resuming, checkpoint has %v", done)
+	}
+}
+
+func TestRemotePool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req runRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Case.Name == "bad" {
+			http.Error(w, "no data", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, []Record{{Name: req.Case.Name, Seed: req.Seed}})
+	}))
+	defer server.Close()
+	pool := newRemotePool([]string{server.URL, strings.TrimPrefix(server.URL, "http://")}, 7, 3, nil)
+	got := make(map[string]remoteResult)
+	handle := func(r remoteResult) { got[r.c.Name] = r }
+	for _, name := range []string{"a", "b", "bad"} {
+		c := testCase
+		c.Name = name
+		pool.submit(c, handle)
+	}
+	pool.wait(handle)
+	if len(got) != 3 {
+		t.Fatalf("%d results, want 3", len(got))
+	}
+	for _, name := range []string{"a", "b"} {
+		r := got[name]
+		if r.err != nil || len(r.records) != 1 || r.records[0].Seed != 7 || r.records[0].Host == "" {
+			t.Errorf("%s: records %+v, error %v", name, r.records, r.err)
+		}
+	}
+	if got["bad"].err == nil {
+		t.Error("bad case: no error")
+	}
+}
+
+func TestWorkerFlags(t *testing.T) {
+	// The flags of main are not defined in the test binary.
+	for _, name := range []string{"workers", "out", "save"} {
+		if flag.Lookup(name) == nil {
+			flag.String(name, "", "")
+		}
+	}
+	if err := checkWorkerFlags([]string{"-workers=2"}); err != nil {
+		t.Error(err)
+	}
+	for _, flags := range [][]string{
+		{"-out=/tmp/x"},
+		{"-save=/tmp/x"},
+		{"-workers=2", "-nosuch=1"},
+		{"-workers", "2"},
+		{"--save=/tmp/x"},
+	} {
+		if err := checkWorkerFlags(flags); err == nil {
+			t.Errorf("flags %q allowed", flags)
+		}
+	}
+
+	srv := httptest.NewServer(workerHandler())
+	defer srv.Close()
+	body, _ := json.Marshal(runRequest{Case: testCase, Flags: []string{"-out=/tmp/x"}})
+	resp, err := http.Post(srv.URL+"/run", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("run with -out: status %s", resp.Status)
+	}
+}
+
+func TestStore(t *testing.T) {
+	c := testCase
+	other := c
+	other.Workers = 2
+	if configHash(c) == configHash(other) {
+		t.Error("config hash does not depend on the workers")
+	}
+	renamed := c
+	renamed.Name, renamed.Repeat = "renamed", 5
+	if configHash(c) != configHash(renamed) {
+		t.Error("config hash depends on the name and repeats")
+	}
+
+	filename := filepath.Join(t.TempDir(), "store.jsonl")
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var records []Record
+	for i, commit := range []struct {
+		sha       string
+		nsPerEval float64
+	}{{"aaa", 100}, {"bbb", 101}, {"ccc", 150}} {
+		for run := 0; run < 2; run++ {
+			records = append(records, Record{
+				Name: "case", ConfigHash: configHash(c), Run: run,
+				Start:     day.AddDate(0, 0, i),
+				NsPerEval: commit.nsPerEval,
+				Env:       Env{Machine: "m", GitSHA: commit.sha},
+			})
+		}
+	}
+	for _, r := range records {
+		if err := appendStore(filename, []Record{r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, err := readStore(filename, storeQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(records) {
+		t.Fatalf("read %d records, want %d", len(all), len(records))
+	}
+	q, err := parseStoreQuery("name=case;git=bb;since=2024-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readStore(filename, q); err != nil || len(got) != 2 || got[0].Env.GitSHA != "bbb" {
+		t.Errorf("query read %d records, error %v, want the 2 of bbb", len(got), err)
+	}
+	rows := history(all)
+	if len(rows) != 3 || rows[2].key.Git != "ccc" || rows[2].runs != 2 || rows[2].nsPerEval != 150 {
+		t.Fatalf("history %+v", rows)
+	}
+	var buf bytes.Buffer
+	if err := writeHistory(&buf, all, 0.1); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Count(out, "SLOWER") != 1 || !strings.Contains(out, "+48.5% SLOWER") {
+		t.Errorf("history does not mark the slowdown at ccc alone:\n%s", out)
+	}
+	for _, bad := range []string{"name", "who=me", "since=March"} {
+		if _, err := parseStoreQuery(bad); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	for _, test := range []struct {
+		x, y []float64
+		p    float64
+	}{
+		{[]float64{1, 2, 3}, []float64{4, 5, 6}, 0.1},
+		{[]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{[]float64{1, 3, 5}, []float64{2, 4, 6}, 0.7},
+		{[]float64{1}, nil, 1},
+	} {
+		for _, swap := range []bool{false, true} {
+			x, y := test.x, test.y
+			if swap {
+				x, y = y, x
+			}
+			if p := mannWhitney(x, y); math.Abs(p-test.p) > 1e-12 {
+				t.Errorf("%v, %v: p %v, want %v", x, y, p, test.p)
+			}
+		}
+	}
+	// With ties the normal approximation is used.
+	if p := mannWhitney([]float64{1, 1, 2, 2, 3}, []float64{1, 2, 2, 3, 3}); !(p > 0.3 && p <= 1) {
+		t.Errorf("tied samples: p %v", p)
+	}
+}
+
+func TestTrends(t *testing.T) {
+	var records []Record
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, base := range []float64{100, 101, 130} {
+		for run := 0; run < 6; run++ {
+			records = append(records, Record{
+				Name: "case", ConfigHash: "c", Start: day.AddDate(0, 0, i),
+				NsPerEval: base + float64(run%3),
+				Env:       Env{Machine: "m", GitSHA: fmt.Sprint("commit", i)},
+			})
+		}
+	}
+	series := trends(records, 2, 0.05, 0.1)
+	if len(series) != 1 || len(series[0].Points) != 2 {
+		t.Fatalf("series %+v, want one of the last 2 commits", series)
+	}
+	s := series[0]
+	if s.Points[0].Git != "commit1" || !s.regressed() || s.Points[1].P >= 0.05 {
+		t.Errorf("last commit %+v not a regression from %+v", s.Points[1], s.Points[0])
+	}
+	if all := trends(records, 10, 0.05, 0.1)[0]; all.Points[1].Mark != "" {
+		t.Errorf("1%% change marked %s", all.Points[1].Mark)
+	}
+	for _, write := range []func(io.Writer, []*trendSeries) error{writeTrendText, writeTrendHTML} {
+		var buf bytes.Buffer
+		if err := write(&buf, series); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), trendSlower) {
+			t.Errorf("report does not show the regression:\n%s", buf.String())
+		}
+	}
+}
+
+func TestPlots(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := newTracer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.recordConvergence = true
+	rec, err := fit(testCase, data, tr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Convergence = tr.convergence
+	conv := rec.Convergence
+	if len(conv) < 2 || conv[len(conv)-1].Objective >= conv[0].Objective || conv[len(conv)-1].Evals > rec.evals() {
+		t.Fatalf("convergence %v of a run with %d evaluations", conv, rec.evals())
+	}
+	records := []Record{*rec}
+	for _, w := range []int{1, 2, 4} {
+		c := testCase
+		c.Name = fmt.Sprint("test_workers", w)
+		c.Workers = w
+		records = append(records, Record{Name: c.Name, Case: c, Seconds: 1 / math.Sqrt(float64(w))})
+	}
+	dir := filepath.Join(t.TempDir(), "plots")
+	files, err := writePlots(dir, "svg", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("wrote %v", files)
+	}
+	for name, legend := range map[string]string{"convergence.svg": rec.Name, "scaling.svg": "ideal"} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var text []string
+		for dec := xml.NewDecoder(bytes.NewReader(b)); ; {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if c, ok := tok.(xml.CharData); ok {
+				text = append(text, string(c))
+			}
+		}
+		if !strings.Contains(strings.Join(text, " "), legend) {
+			t.Errorf("%s has no line %s in its legend", name, legend)
+		}
+	}
+
+	files, err = writePlots(dir, "png", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil || cfg.Width <= cfg.Height {
+			t.Errorf("%s: %+v, %v", name, cfg, err)
+		}
+	}
+	if err := checkPlotFormat("gif"); err == nil {
+		t.Error("plot format gif allowed")
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	var records []Record
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, base := range []float64{100, 130} {
+		for _, blas := range []string{"go", "openblas"} {
+			for run := 0; run < 6; run++ {
+				records = append(records, Record{
+					Name: "case", ConfigHash: "c", Start: day.AddDate(0, 0, i),
+					NsPerOp: int64(base + float64(run%3)), NsPerEval: base,
+					Env: Env{Machine: "m", BLAS: blas, GitSHA: fmt.Sprint("commit", i)},
+				})
+			}
+		}
+	}
+	store := filepath.Join(t.TempDir(), "store.jsonl")
+	if err := appendStore(store, records); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer((&dashboard{store: store, commits: 10, alpha: 0.05, threshold: 0.1}).handler())
+	defer srv.Close()
+	get := func(path string, status int) string {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Errorf("%s: status %d, want %d: %s", path, resp.StatusCode, status, b)
+		}
+		return string(b)
+	}
+	if page := get("/", http.StatusOK); !strings.Contains(page, "blas=openblas") || strings.Count(page, ">"+trendSlower+"<") != 2 {
+		t.Errorf("index does not show a regression of each backend:\n%s", page)
+	}
+	if page := get("/?blas=go", http.StatusOK); strings.Contains(page, "blas=openblas") {
+		t.Errorf("index filtered to go shows openblas:\n%s", page)
+	}
+	if page := get("/compare?a=commit0&b=commit1&blas=go", http.StatusOK); strings.Count(page, ">"+trendSlower+"<") != 1 {
+		t.Errorf("comparison does not show the regression:\n%s", page)
+	}
+	get("/benchmark?name=case&blas=go", http.StatusOK)
+	get("/benchmark?name=other", http.StatusNotFound)
+	get("/trend.svg?name=case", http.StatusOK)
+	get("/convergence.svg?name=case", http.StatusNotFound)
+	if page := get("/metrics", http.StatusOK); !strings.Contains(page, `git="commit1"`) || strings.Contains(page, "commit0") {
+		t.Errorf("metrics are not of the last commit:\n%s", page)
+	}
+	get("/?since=March", http.StatusBadRequest)
+}
+
+func TestMetricsExport(t *testing.T) {
+	c := testCase
+	c.Name = `quoted "case"`
+	var records []Record
+	for run, sec := range []float64{1, 3} {
+		records = append(records, Record{
+			Name: c.Name, Case: c, ConfigHash: "c", Run: run, Seconds: sec,
+			Test: &Metrics{Classes: 2, Accuracy: 0.9},
+			Env:  Env{Machine: "m", BLAS: "go", GitSHA: "abc"},
+		})
+	}
+	records = append(records, Record{Name: c.Name, Error: "timed out"})
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Type"), string(b))
+	}))
+	defer srv.Close()
+	if err := pushMetrics(srv.URL, records); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "PUT /metrics/job/nettrainbench "+metricsContentType {
+		t.Fatalf("pushed %q", got)
+	}
+	labels := `{case="quoted \"case\"",config="c",machine="m",blas="go",method="nnet",optimizer="bfgs",activation="tanh",neurons="5",layers="2",ndata="500",workers="1"}`
+	for _, line := range []string{
+		"nettrainbench_runs" + labels + " 2",
+		"nettrainbench_seconds" + labels + " 2",
+		"nettrainbench_test_accuracy" + labels + " 0.9",
+		"# TYPE nettrainbench_loss gauge",
+	} {
+		if !strings.Contains(got[1], line+"\n") {
+			t.Errorf("no line %s in\n%s", line, got[1])
+		}
+	}
+	if strings.Contains(got[1], "test_rmse{") || strings.Contains(got[1], "bytes_per_op{") {
+		t.Errorf("metrics the runs do not have:\n%s", got[1])
+	}
+}
+
+func TestStream(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCase
+	c.Synthetic = nil
+	c.Data = filepath.Join(t.TempDir(), "data.txt")
+	c.Comma = ","
+	c.Optimizer = "adam"
+	c.BatchSize = 64
+	c.Epochs = 2
+	f, err := os.Create(c.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := datagen.Write(f, *testCase.Synthetic); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	n, _, inputScaler, outputScaler, err := streamScalers(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, nDim := data.Dims()
+	in, out := &scale.Normal{}, &scale.Normal{}
+	inputs, outputs := &mat64.Dense{}, &mat64.Dense{}
+	inputs.Submatrix(data, 0, 0, n, nDim-1)
+	outputs.Submatrix(data, 0, nDim-1, n, 1)
+	if err := scale.ScaleTrainingData(inputs, outputs, in, out); err != nil {
+		t.Fatal(err)
+	}
+	if n != 500 || !floats.EqualApprox(in.Mu, inputScaler.Normal.Mu, 1e-10) || !floats.EqualApprox(in.Sigma, inputScaler.Normal.Sigma, 1e-10) ||
+		!floats.EqualApprox(out.Mu, outputScaler.Normal.Mu, 1e-10) || !floats.EqualApprox(out.Sigma, outputScaler.Normal.Sigma, 1e-10) {
+		t.Errorf("streamed scalers of %d samples differ from scale.Normal", n)
+	}
+
+	for _, stream := range []string{streamSequential, streamShuffled} {
+		c.Stream = stream
+		c.rand = rand.New(rand.NewSource(1))
+		rec, err := streamCase(c, nil, false)
+		if err != nil {
+			t.Fatalf("%s: %v", stream, err)
+		}
+		if want := c.Epochs * batchesPerEpoch(n, c.BatchSize); rec.evals() != want+1 {
+			t.Errorf("%s: %d evaluations, want %d", stream, rec.evals(), want+1)
+		}
+		if math.IsNaN(rec.Loss) || rec.Loss <= 0 {
+			t.Errorf("%s: loss %v", stream, rec.Loss)
+		}
+	}
+}
+
+func TestScalings(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lin := &scale.Linear{}
+	if err := lin.SetScale(data); err != nil {
+		t.Fatal(err)
+	}
+	s, err := fittedScaler(scaleMinMax, make([]float64, len(lin.Min)), nil, lin.Min, lin.Max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.MinMax, lin) {
+		t.Errorf("fitted min-max scaler %+v, want %+v", s.MinMax, lin)
+	}
+
+	// The test errors are in the units of the data whatever the scaling, so
+	// they are all below the spread of the outputs.
+	_, nDim := data.Dims()
+	outputs := &mat64.Dense{}
+	outputs.Submatrix(data, 0, nDim-1, testCase.NData, 1)
+	std := &scale.Normal{}
+	if err := std.SetScale(outputs); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{scaleNormal, scaleMinMax, scaleNone} {
+		c := testCase
+		c.Holdout = 0.2
+		c.NData = 400
+		c.InputScaling, c.OutputScaling = kind, kind
+		c.rand = rand.New(rand.NewSource(1))
+		rec, err := runCase(c, data, nil, false)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if rec.Test == nil || !(rec.Test.RMSE < std.Sigma[0]) {
+			t.Errorf("%s: test %v, output standard deviation %v", kind, rec.Test, std.Sigma[0])
+		}
+	}
+	if _, err := newScaler("log"); err == nil {
+		t.Error("no error for unknown scaling")
+	}
+}
+
+func TestBaselines(t *testing.T) {
+	// Least squares recovers a linear function, and a single neighbor the
+	// training outputs.
+	n := 50
+	inputs := mat64.NewDense(n, 2, nil)
+	outputs := mat64.NewDense(n, 1, nil)
+	for i := 0; i < n; i++ {
+		x0, x1 := rand.Float64(), rand.Float64()
+		inputs.SetRow(i, []float64{x0, x1})
+		outputs.Set(i, 0, 3*x0-2*x1+1)
+	}
+	l, err := fitLinear(inputs, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := l.Predict([]float64{0.5, 0.25}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(out[0]-2) > 1e-10 {
+		t.Errorf("least squares predicts %v, want 2", out[0])
+	}
+	if l.numParameters() != 3 {
+		t.Errorf("least squares has %d parameters, want 3", l.numParameters())
+	}
+	knn := &kNearest{K: 1, Inputs: inputs, Outputs: outputs}
+	if loss, err := meanLoss(knn, inputs, outputs); err != nil || loss != 0 {
+		t.Errorf("nearest neighbor training loss %v, %v, want 0", loss, err)
+	}
+	// With all of the samples as neighbors, knn predicts their mean.
+	knn.K = n
+	out, err = knn.Predict([]float64{0, 0}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mean := floats.Sum(outputs.Col(nil, 0)) / float64(n); math.Abs(out[0]-mean) > 1e-12 {
+		t.Errorf("knn of all samples predicts %v, want the mean %v", out[0], mean)
+	}
+
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{methodOLS, methodKNN} {
+		c := testCase
+		c.Method = method
+		c.Holdout = 0.2
+		c.NData = 400
+		rec, err := runCase(c, data, nil, false)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if rec.Test == nil || !(rec.Test.RMSE > 0) || math.IsInf(rec.Test.RMSE, 0) {
+			t.Errorf("%s: test %v", method, rec.Test)
+		}
+		if rec.model != nil {
+			t.Errorf("%s: has a network to save", method)
+		}
+	}
+	c := testCase
+	c.Method = methodKNN
+	c.Task = classification
+	if _, err := runCase(c, data, nil, false); err == nil {
+		t.Error("no error for a knn classifier")
+	}
+}
+
+func TestKernelRidge(t *testing.T) {
+	// The blocked factorization matches mat64's over several blocks, and
+	// solves the system.
+	n := 2*choleskyBlock + 13
+	x := mat64.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			x.Set(i, j, rand.NormFloat64())
+		}
+	}
+	xt := &mat64.Dense{}
+	xt.TCopy(x)
+	a := &mat64.Dense{}
+	a.Mul(x, xt)
+	for i := 0; i < n; i++ {
+		a.Set(i, i, a.At(i, i)+1)
+	}
+	want := mat64.Cholesky(a)
+	l := mat64.DenseCopyOf(a)
+	if err := cholesky(l); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			if math.Abs(l.At(i, j)-want.L.At(i, j)) > 1e-9 {
+				t.Fatalf("L[%d,%d] = %v, want %v", i, j, l.At(i, j), want.L.At(i, j))
+			}
+		}
+	}
+	b := mat64.NewDense(n, 2, nil)
+	for i := 0; i < n; i++ {
+		b.SetRow(i, []float64{rand.NormFloat64(), rand.NormFloat64()})
+	}
+	sol := mat64.DenseCopyOf(b)
+	choleskySolve(l, sol)
+	ax := &mat64.Dense{}
+	ax.Mul(a, sol)
+	for i := 0; i < n; i++ {
+		for j := 0; j < 2; j++ {
+			if math.Abs(ax.At(i, j)-b.At(i, j)) > 1e-8 {
+				t.Fatalf("A x = %v at %d,%d, want %v", ax.At(i, j), i, j, b.At(i, j))
+			}
+		}
+	}
+	if err := cholesky(mat64.NewDense(2, 2, []float64{1, 2, 2, 1})); err == nil {
+		t.Error("no error factoring an indefinite matrix")
+	}
+
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCase
+	c.Method = methodKRR
+	c.Holdout = 0.2
+	c.NData = 400
+	rec, err := runCase(c, data, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.NParams != c.NData || rec.Test == nil || !(rec.Test.R2 > 0.5) {
+		t.Errorf("krr has %d parameters, test %v", rec.NParams, rec.Test)
+	}
+}
+
+func TestEnsemble(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, parallel := range []bool{false, true} {
+		c := testCase
+		c.Holdout = 0.2
+		c.NData = 400
+		c.MaxEvals = 30
+		c.Ensemble = 3
+		c.ParallelEnsemble = parallel
+		c.rand = rand.New(rand.NewSource(1))
+		rec, err := runCase(c, data, nil, false)
+		if err != nil {
+			t.Fatalf("parallel %v: %v", parallel, err)
+		}
+		if len(rec.Members) != c.Ensemble || rec.Test == nil {
+			t.Fatalf("parallel %v: %d networks tested, test %v", parallel, len(rec.Members), rec.Test)
+		}
+		// By the triangle inequality, the mean prediction is no worse than
+		// the networks are on average.
+		var mean float64
+		for _, m := range rec.Members {
+			mean += m.RMSE / float64(len(rec.Members))
+		}
+		if rec.Test.RMSE > mean*(1+1e-12) {
+			t.Errorf("parallel %v: ensemble RMSE %v above the mean %v of the networks", parallel, rec.Test.RMSE, mean)
+		}
+		if rec.NParams%c.Ensemble != 0 {
+			t.Errorf("parallel %v: %d parameters", parallel, rec.NParams)
+		}
+		if rec.evals() < c.Ensemble {
+			t.Errorf("parallel %v: %d evaluations", parallel, rec.evals())
+		}
+	}
+}
+
+func TestGradCheck(t *testing.T) {
+	data, err := datagen.Generate(*testCase.Synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	penalized := testCase
+	penalized.L1, penalized.L2 = 1e-3, 1e-3
+	weighted := testCase
+	weighted.Weights = weightImportance
+	for _, c := range []Case{testCase, penalized, weighted} {
+		check, err := checkGradient(c, data, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check.Checked != check.Params || !(check.MaxRelErr <= gradCheckTolerance) {
+			t.Errorf("l1 %g, l2 %g, weights %q: %v", c.L1, c.L2, c.Weights, check)
+		}
+	}
+	c := testCase
+	c.Method = methodOLS
+	if _, err := checkGradient(c, data, 1); err == nil {
+		t.Error("no error checking the gradient of least squares")
+	}
+}
+
+// goldenFile holds the results of training the goldenCases.
+const goldenFile = "testdata/golden.json"
+
+// goldenCases are trained with fixed seeds and data by TestGolden, covering
+// the optimizers, losses and penalties that performance changes touch.
+func goldenCases() []Case {
+	bfgs := testCase
+	bfgs.Name = "bfgs"
+	bfgs.MaxEvals = 30
+
+	adam := testCase
+	adam.Name = "adam"
+	adam.Optimizer = "adam"
+	adam.BatchSize = 50
+	adam.Epochs = 2
+
+	elastic := bfgs
+	elastic.Name = "elasticnet"
+	elastic.Activation = "sigmoid"
+	elastic.L1, elastic.L2 = 1e-4, 1e-3
+
+	shells := bfgs
+	shells.Name = "shells"
+	shells.Task = classification
+	shells.Synthetic = &datagen.Spec{Kind: datagen.Shells, N: 500, Seed: 1}
+
+	sinusoid := bfgs
+	sinusoid.Name = "sinusoid"
+	sinusoid.Outputs = 3
+	sinusoid.Synthetic = &datagen.Spec{Kind: datagen.Sinusoid, N: 500, Out: 3, Seed: 1}
+	return []Case{bfgs, adam, elastic, shells, sinusoid}
+}
+
+// golden is the final loss of a golden case and some of its parameters.
+type golden struct {
+	Loss   float64   `json:"loss"`
+	Index  []int     `json:"index"`
+	Params []float64 `json:"params"`
+}
+
+// TestGolden checks that training the golden cases with one worker gives the
+// losses and parameters recorded in goldenFile, to within the differences of
+// fused multiply-adds and the summation order of BLAS backends. Run it with
+// -update to record new results after a change that is meant to alter them,
+// and with -blas to check another backend.
+func TestGolden(t *testing.T) {
+	if err := registerBLAS(*goldenBLAS); err != nil {
+		t.Fatal(err)
+	}
+	defer registerBLAS("go")
+	want := make(map[string]golden)
+	if !*update {
+		b, err := os.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &want); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := make(map[string]golden)
+	for _, c := range goldenCases() {
+		data, err := datagen.Generate(*c.Synthetic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.rand = rand.New(rand.NewSource(1))
+		rec, err := runCase(c, data, nil, false)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		params := rec.model.Parameters
+		g := golden{Loss: rec.Loss}
+		for i := 0; i < len(params); i += 1 + len(params)/8 {
+			g.Index = append(g.Index, i)
+			g.Params = append(g.Params, params[i])
+		}
+		got[c.Name] = g
+		if *update {
+			continue
+		}
+		w, ok := want[c.Name]
+		if !ok {
+			t.Errorf("%s: no golden result, run with -update", c.Name)
+			continue
+		}
+		if math.Abs(g.Loss-w.Loss) > 1e-6*math.Abs(w.Loss) {
+			t.Errorf("%s: loss %v, golden %v", c.Name, g.Loss, w.Loss)
+		}
+		for j, i := range w.Index {
+			if i >= len(params) {
+				t.Errorf("%s: %d parameters, golden has parameter %d", c.Name, len(params), i)
+				break
+			}
+			if math.Abs(params[i]-w.Params[j]) > 1e-4*(1+math.Abs(w.Params[j])) {
+				t.Errorf("%s: parameter %d is %v, golden %v", c.Name, i, params[i], w.Params[j])
+			}
+		}
+	}
+	if *update {
+		b, err := json.MarshalIndent(got, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenFile, append(b, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var (
+	benchConfig = flag.String("benchconfig", "cases.json", "config of the cases run by BenchmarkCases")
+	benchSuite  = flag.String("benchsuite", "", "suite of the -benchconfig run by BenchmarkCases, all of its cases if empty")
+)
+
+// BenchmarkCases runs each case of the -benchconfig as a sub-benchmark named
+// as in the benchstat output of -out, training it with fit as the program
+// does, so that go test -bench and the program time the same code. Run i of
+// each is seeded with i, and each reports the mean evaluations and training
+// loss of its runs.
+func BenchmarkCases(b *testing.B) {
+	config, err := readConfig(*benchConfig)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if *benchSuite != "" {
+		if _, err := config.selectSuite(*benchSuite); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for _, c := range expandCases(config.resolve()) {
+		b.Run(strings.TrimPrefix(benchmarkName(c.Name), "Benchmark"), func(b *testing.B) {
+			allData, err := loadData(&c)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if c.Workers == 0 {
+				c.Workers = runtime.GOMAXPROCS(0)
+			}
+			var evals int
+			var loss float64
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.rand = rand.New(rand.NewSource(int64(i)))
+				rec, err := fit(c, allData, nil, false)
+				if err != nil {
+					b.Fatal(err)
+				}
+				evals += rec.evals()
+				loss += rec.Loss
+			}
+			b.ReportMetric(float64(evals)/float64(b.N), "evals/op")
+			b.ReportMetric(loss/float64(b.N), "loss")
+		})
+	}
+}
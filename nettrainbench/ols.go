@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+	predHelp "github.com/reggo/reggo/common/predict"
+)
+
+// linearModel predicts the outputs as an affine function of the inputs.
+type linearModel struct {
+	inputDim, outputDim int
+	coef                *mat64.Dense // inputDim+1 by outputDim, the intercepts in the last row
+}
+
+// fitLinear fits a linearModel to the inputs and outputs by least squares,
+// with a QR factorization of the inputs and a column of ones.
+func fitLinear(inputs, outputs *mat64.Dense) (*linearModel, error) {
+	n, inputDim := inputs.Dims()
+	_, outputDim := outputs.Dims()
+	if n <= inputDim {
+		return nil, fmt.Errorf("least squares with %d samples, need more than the %d inputs", n, inputDim)
+	}
+	x := mat64.NewDense(n, inputDim+1, nil)
+	for i := 0; i < n; i++ {
+		row := x.RowView(i)
+		copy(row, inputs.RowView(i))
+		row[inputDim] = 1
+	}
+	qr := mat64.QR(x)
+	if !qr.IsFullRank() {
+		return nil, errors.New("least squares inputs are linearly dependent")
+	}
+	var coef *mat64.Dense
+	err := mat64.Maybe(func() {
+		coef = qr.Solve(mat64.DenseCopyOf(outputs))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &linearModel{inputDim: inputDim, outputDim: outputDim, coef: coef}, nil
+}
+
+// numParameters returns the number of coefficients of the model.
+func (l *linearModel) numParameters() int {
+	return (l.inputDim + 1) * l.outputDim
+}
+
+func (l *linearModel) InputDim() int  { return l.inputDim }
+func (l *linearModel) OutputDim() int { return l.outputDim }
+
+func (l *linearModel) Predict(input, output []float64) ([]float64, error) {
+	if len(input) != l.inputDim {
+		return nil, errors.New("input dimension mismatch")
+	}
+	if output == nil {
+		output = make([]float64, l.outputDim)
+	} else if len(output) != l.outputDim {
+		return nil, errors.New("output dimension mismatch")
+	}
+	l.predict(input, output)
+	return output, nil
+}
+
+func (l *linearModel) predict(input, output []float64) {
+	copy(output, l.coef.RowView(l.inputDim))
+	for i, v := range input {
+		for j, c := range l.coef.RowView(i) {
+			output[j] += v * c
+		}
+	}
+}
+
+func (l *linearModel) PredictBatch(inputs common.RowMatrix, outputs common.MutableRowMatrix) (common.MutableRowMatrix, error) {
+	// Predict about 100000 multiplies in each goroutine, as nnet does
+	grainSize := int(math.Ceil(100000 / float64(l.numParameters())))
+	return predHelp.BatchPredict(l, inputs, outputs, l.inputDim, l.outputDim, grainSize)
+}
+
+// NewPredictor returns a predictor of the model for predHelp.BatchPredict.
+// The model keeps no state while predicting, so they can share it.
+func (l *linearModel) NewPredictor() predHelp.Predictor {
+	return linearPredictor{l}
+}
+
+type linearPredictor struct {
+	*linearModel
+}
+
+func (p linearPredictor) Predict(input, output []float64) {
+	p.predict(input, output)
+}
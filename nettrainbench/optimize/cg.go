@@ -0,0 +1,87 @@
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/opt"
+)
+
+// CG is the nonlinear conjugate gradient method with the Polak-Ribière+
+// update. The search restarts along the negative gradient when the update
+// does not give a descent direction. The default line search is a bisection
+// with a gradient constant of 0.1, since the method needs the accurate line
+// searches that BFGS does not.
+type CG struct {
+	LinesearchMethod opt.LinesearchMethod
+
+	linesearch *opt.Linesearch
+
+	x        []float64 // location of the last major iteration
+	grad     []float64 // gradient at the last major iteration
+	dir      []float64 // last search direction
+	projGrad float64   // projected gradient along dir at the last major iteration
+}
+
+func (c *CG) Init(loc opt.Location, f *opt.FunctionStats, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	if c.LinesearchMethod == nil {
+		c.LinesearchMethod = &opt.Bisection{GradConst: 0.1}
+	}
+	if c.linesearch == nil {
+		c.linesearch = &opt.Linesearch{}
+	}
+	c.linesearch.Method = c.LinesearchMethod
+	c.linesearch.NextDirectioner = c
+
+	return c.linesearch.Init(loc, f, xNext)
+}
+
+func (c *CG) Iterate(loc opt.Location, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	return c.linesearch.Iterate(loc, xNext)
+}
+
+func (c *CG) InitDirection(loc opt.Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	c.x = resize(c.x, dim)
+	c.grad = resize(c.grad, dim)
+	c.dir = resize(c.dir, dim)
+
+	copy(dir, loc.Gradient)
+	floats.Scale(-1/math.Sqrt(floats.Norm(dir, 2)), dir)
+	c.save(loc, dir)
+	return 1
+}
+
+func (c *CG) NextDirection(loc opt.Location, dir []float64) (stepSize float64) {
+	// The length of the last step along the last direction, used to guess the
+	// next step size as in Nocedal and Wright equation 3.60.
+	lastStep := floats.Distance(loc.X, c.x, 2) / floats.Norm(c.dir, 2)
+
+	gDotG := floats.Dot(c.grad, c.grad)
+	beta := (floats.Dot(loc.Gradient, loc.Gradient) - floats.Dot(loc.Gradient, c.grad)) / gDotG
+	beta = math.Max(beta, 0)
+
+	for i, g := range loc.Gradient {
+		dir[i] = -g + beta*c.dir[i]
+	}
+	projGrad := floats.Dot(dir, loc.Gradient)
+	if projGrad >= 0 {
+		copy(dir, loc.Gradient)
+		floats.Scale(-1, dir)
+		projGrad = floats.Dot(dir, loc.Gradient)
+	}
+	step := 1.0
+	if lastStep > 0 && !math.IsInf(lastStep, 0) && projGrad != 0 {
+		step = lastStep * c.projGrad / projGrad
+	}
+	c.save(loc, dir)
+	return step
+}
+
+// save stores the location and direction of a major iteration.
+func (c *CG) save(loc opt.Location, dir []float64) {
+	copy(c.x, loc.X)
+	copy(c.grad, loc.Gradient)
+	copy(c.dir, dir)
+	c.projGrad = floats.Dot(loc.Gradient, dir)
+}
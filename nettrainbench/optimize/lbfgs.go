@@ -0,0 +1,127 @@
+// Package optimize provides optimization methods for github.com/gonum/opt
+// beyond the BFGS and gradient descent methods that package implements.
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/opt"
+)
+
+// LBFGS is the limited-memory BFGS method. The inverse Hessian is approximated
+// from the Store most recent steps, so the memory is linear in the dimension.
+type LBFGS struct {
+	LinesearchMethod opt.LinesearchMethod
+	Store            int // number of past steps kept, 15 if zero
+
+	linesearch *opt.Linesearch
+
+	x    []float64 // location of the last major iteration
+	grad []float64 // gradient at the last major iteration
+	sNew []float64 // the latest step, before it is accepted into the ring
+	yNew []float64 // the latest gradient difference
+
+	// Ring buffer of the past steps and gradient differences
+	s, y  [][]float64
+	rho   []float64
+	alpha []float64
+	next  int // index of the oldest entry
+	n     int // number of entries
+}
+
+func (l *LBFGS) Init(loc opt.Location, f *opt.FunctionStats, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	if l.LinesearchMethod == nil {
+		l.LinesearchMethod = &opt.Bisection{}
+	}
+	if l.linesearch == nil {
+		l.linesearch = &opt.Linesearch{}
+	}
+	l.linesearch.Method = l.LinesearchMethod
+	l.linesearch.NextDirectioner = l
+
+	return l.linesearch.Init(loc, f, xNext)
+}
+
+func (l *LBFGS) Iterate(loc opt.Location, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	return l.linesearch.Iterate(loc, xNext)
+}
+
+func (l *LBFGS) InitDirection(loc opt.Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	store := l.Store
+	if store == 0 {
+		store = 15
+	}
+	l.x = resize(l.x, dim)
+	copy(l.x, loc.X)
+	l.grad = resize(l.grad, dim)
+	copy(l.grad, loc.Gradient)
+	l.sNew = resize(l.sNew, dim)
+	l.yNew = resize(l.yNew, dim)
+	if len(l.s) != store || (store > 0 && len(l.s[0]) != dim) {
+		l.s = make([][]float64, store)
+		l.y = make([][]float64, store)
+		for i := range l.s {
+			l.s[i] = make([]float64, dim)
+			l.y[i] = make([]float64, dim)
+		}
+		l.rho = make([]float64, store)
+		l.alpha = make([]float64, store)
+	}
+	l.next, l.n = 0, 0
+
+	// Steepest descent scaled as in BFGS.
+	copy(dir, loc.Gradient)
+	floats.Scale(-1/math.Sqrt(floats.Norm(dir, 2)), dir)
+	return 1
+}
+
+func (l *LBFGS) NextDirection(loc opt.Location, dir []float64) (stepSize float64) {
+	store := len(l.s)
+	floats.SubTo(l.sNew, loc.X, l.x)
+	floats.SubTo(l.yNew, loc.Gradient, l.grad)
+	copy(l.x, loc.X)
+	copy(l.grad, loc.Gradient)
+
+	sDotY := floats.Dot(l.sNew, l.yNew)
+	if sDotY > 0 {
+		// Only keep steps that preserve positive definiteness. The pair is
+		// swapped into the slot of the oldest, so a rejected pair leaves the
+		// history intact.
+		l.s[l.next], l.sNew = l.sNew, l.s[l.next]
+		l.y[l.next], l.yNew = l.yNew, l.y[l.next]
+		l.rho[l.next] = 1 / sDotY
+		l.next = (l.next + 1) % store
+		if l.n < store {
+			l.n++
+		}
+	}
+
+	// Two-loop recursion, Nocedal and Wright algorithm 7.4.
+	copy(dir, loc.Gradient)
+	for k := 0; k < l.n; k++ {
+		i := (l.next - 1 - k + store) % store
+		l.alpha[i] = l.rho[i] * floats.Dot(l.s[i], dir)
+		floats.AddScaled(dir, -l.alpha[i], l.y[i])
+	}
+	if l.n > 0 {
+		newest := (l.next - 1 + store) % store
+		yn := l.y[newest]
+		floats.Scale(1/(l.rho[newest]*floats.Dot(yn, yn)), dir)
+	}
+	for k := l.n - 1; k >= 0; k-- {
+		i := (l.next - 1 - k + store) % store
+		beta := l.rho[i] * floats.Dot(l.y[i], dir)
+		floats.AddScaled(dir, l.alpha[i]-beta, l.s[i])
+	}
+	floats.Scale(-1, dir)
+	return 1
+}
+
+func resize(x []float64, dim int) []float64 {
+	if dim > cap(x) {
+		return make([]float64, dim)
+	}
+	return x[:dim]
+}
@@ -0,0 +1,65 @@
+package optimize
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gonum/opt"
+)
+
+var ErrUnknown = errors.New("optimize: unknown method")
+
+// methods holds constructors of the named methods.
+var methods = map[string]func() opt.Method{
+	"bfgs":  func() opt.Method { return &opt.BFGS{} },
+	"lbfgs": func() opt.Method { return &LBFGS{} },
+	"cg":    func() opt.Method { return &CG{} },
+	"gd":    func() opt.Method { return &opt.GradientDescent{} },
+	"sgd": func() opt.Method {
+		return &opt.GradientDescent{NoLinesearch: true, StepSizer: opt.ConstantStepSize{Size: 0.01}}
+	},
+	"nesterov": func() opt.Method { return &Nesterov{} },
+	"adam":     func() opt.Method { return &Adam{} },
+}
+
+// New returns a new method with default settings: bfgs, lbfgs, cg, gd
+// (gradient descent), sgd (gradient descent with a fixed step of 0.01 for
+// mini-batch training), nesterov or adam.
+func New(name string) (opt.Method, error) {
+	m, ok := methods[name]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrUnknown)
+	}
+	return m(), nil
+}
+
+// Names returns the names accepted by New in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetLearningRate sets the fixed step size of a sgd, nesterov or adam method
+// returned by New, and returns false for the methods that search for their
+// steps.
+func SetLearningRate(m opt.Method, rate float64) bool {
+	switch m := m.(type) {
+	case *Nesterov:
+		m.LearningRate = rate
+	case *Adam:
+		m.LearningRate = rate
+	case *opt.GradientDescent:
+		if !m.NoLinesearch {
+			return false
+		}
+		m.StepSizer = opt.ConstantStepSize{Size: rate}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/opt"
+)
+
+// Nesterov is gradient descent with Nesterov momentum and a fixed learning
+// rate. The gradient is evaluated at the look-ahead point x + Momentum·v.
+type Nesterov struct {
+	LearningRate float64 // 0.01 if zero
+	Momentum     float64 // 0.9 if zero
+
+	x, v []float64
+}
+
+func (n *Nesterov) Init(loc opt.Location, f *opt.FunctionStats, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	if n.LearningRate == 0 {
+		n.LearningRate = 0.01
+	}
+	if n.Momentum == 0 {
+		n.Momentum = 0.9
+	}
+	n.x = resize(n.x, len(loc.X))
+	copy(n.x, loc.X)
+	n.v = resize(n.v, len(loc.X))
+	for i := range n.v {
+		n.v[i] = 0
+	}
+	return n.Iterate(loc, xNext)
+}
+
+func (n *Nesterov) Iterate(loc opt.Location, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	for i, g := range loc.Gradient {
+		n.v[i] = n.Momentum*n.v[i] - n.LearningRate*g
+		n.x[i] += n.v[i]
+		xNext[i] = n.x[i] + n.Momentum*n.v[i]
+	}
+	return opt.FunctionAndGradient, opt.Major, nil
+}
+
+// Adam is the adaptive moment estimation method of Kingma and Ba.
+type Adam struct {
+	LearningRate float64 // 0.001 if zero
+	Beta1        float64 // decay of the first moment, 0.9 if zero
+	Beta2        float64 // decay of the second moment, 0.999 if zero
+	Epsilon      float64 // 1e-8 if zero
+
+	m, v []float64
+	t    int
+}
+
+func (a *Adam) Init(loc opt.Location, f *opt.FunctionStats, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	if a.LearningRate == 0 {
+		a.LearningRate = 0.001
+	}
+	if a.Beta1 == 0 {
+		a.Beta1 = 0.9
+	}
+	if a.Beta2 == 0 {
+		a.Beta2 = 0.999
+	}
+	if a.Epsilon == 0 {
+		a.Epsilon = 1e-8
+	}
+	a.m = resize(a.m, len(loc.X))
+	a.v = resize(a.v, len(loc.X))
+	for i := range a.m {
+		a.m[i], a.v[i] = 0, 0
+	}
+	a.t = 0
+	return a.Iterate(loc, xNext)
+}
+
+func (a *Adam) Iterate(loc opt.Location, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	a.t++
+	c1 := 1 - math.Pow(a.Beta1, float64(a.t))
+	c2 := 1 - math.Pow(a.Beta2, float64(a.t))
+	for i, g := range loc.Gradient {
+		a.m[i] = a.Beta1*a.m[i] + (1-a.Beta1)*g
+		a.v[i] = a.Beta2*a.v[i] + (1-a.Beta2)*g*g
+		xNext[i] = loc.X[i] - a.LearningRate*(a.m[i]/c1)/(math.Sqrt(a.v[i]/c2)+a.Epsilon)
+	}
+	return opt.FunctionAndGradient, opt.Major, nil
+}
@@ -0,0 +1,140 @@
+package optimize
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/opt"
+)
+
+// rosenbrock is the extended Rosenbrock function, with minimum 0 at all ones.
+type rosenbrock struct{}
+
+func (rosenbrock) F(x []float64) float64 {
+	return rosenbrock{}.FDf(x, make([]float64, len(x)))
+}
+
+func (rosenbrock) FDf(x, grad []float64) float64 {
+	var f float64
+	for i := range grad {
+		grad[i] = 0
+	}
+	for i := 0; i < len(x)-1; i++ {
+		a, b := 1-x[i], x[i+1]-x[i]*x[i]
+		f += a*a + 100*b*b
+		grad[i] += -2*a - 400*b*x[i]
+		grad[i+1] += 200 * b
+	}
+	return f
+}
+
+// quadratic is a convex function with minimum 0 at zero.
+type quadratic struct{}
+
+func (quadratic) F(x []float64) float64 {
+	return quadratic{}.FDf(x, make([]float64, len(x)))
+}
+
+func (quadratic) FDf(x, grad []float64) float64 {
+	var f float64
+	for i, v := range x {
+		c := float64(i + 1)
+		f += c * v * v
+		grad[i] = 2 * c * v
+	}
+	return f
+}
+
+func TestMethods(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		f     opt.Function
+		tol   float64
+		evals int
+	}{
+		{"lbfgs", rosenbrock{}, 1e-8, 500},
+		{"cg", rosenbrock{}, 1e-8, 2000},
+		{"nesterov", quadratic{}, 1e-8, 2000},
+		{"adam", quadratic{}, 1e-4, 20000},
+	} {
+		m, err := New(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x := []float64{-1.2, 1, -1.2, 1}
+		settings := opt.DefaultSettings()
+		settings.Recorder = nil
+		settings.GradientAbsoluteTolerance = 0
+		settings.FunctionAbsoluteTolerance = test.tol
+		settings.MaximumFunctionEvaluations = test.evals
+		result, err := opt.Minimize(test.f, x, settings, m)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if result.Status != opt.FunctionAbsoluteConvergence {
+			t.Errorf("%s: status %v with f = %v after %d evaluations", test.name, result.Status, result.F, result.NumFunGradEvals)
+		}
+		if math.IsNaN(result.F) {
+			t.Errorf("%s: NaN objective", test.name)
+		}
+	}
+	if _, err := New("newton"); !errors.Is(err, ErrUnknown) || err.Error() != `"newton": optimize: unknown method` {
+		t.Errorf("got error %v for unknown method", err)
+	}
+}
+
+func TestRelativeStop(t *testing.T) {
+	m, err := New("nesterov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := opt.DefaultSettings()
+	settings.Recorder = nil
+	settings.GradientAbsoluteTolerance = 0
+	settings.FunctionAbsoluteTolerance = math.Inf(-1)
+	settings.MaximumFunctionEvaluations = 100000
+	result, err := opt.Minimize(quadratic{}, []float64{1, -1, 1}, settings, &RelativeStop{Method: m, Tolerance: 1e-3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != FunctionRelativeConvergence {
+		t.Errorf("status %v after %d evaluations", result.Status, result.NumFunGradEvals)
+	}
+	if result.NumFunGradEvals >= settings.MaximumFunctionEvaluations {
+		t.Errorf("used the whole budget of %d evaluations", settings.MaximumFunctionEvaluations)
+	}
+}
+
+func TestSetLearningRate(t *testing.T) {
+	for _, name := range Names() {
+		m, err := New(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := name == "sgd" || name == "nesterov" || name == "adam"
+		if got := SetLearningRate(m, 0.1); got != want {
+			t.Errorf("%s: SetLearningRate = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestLBFGSRejectsPair(t *testing.T) {
+	l := &LBFGS{Store: 2}
+	dir := make([]float64, 2)
+	l.InitDirection(opt.Location{X: []float64{0, 0}, Gradient: []float64{1, 1}}, dir)
+	// Two pairs with s equal to y fill the history, keeping the inverse
+	// Hessian the identity.
+	l.NextDirection(opt.Location{X: []float64{1, 0}, Gradient: []float64{2, 1}}, dir)
+	l.NextDirection(opt.Location{X: []float64{1, 1}, Gradient: []float64{2, 2}}, dir)
+	// This step has s·y < 0, so it is rejected and the direction stays that of
+	// the identity.
+	l.NextDirection(opt.Location{X: []float64{2, 1}, Gradient: []float64{1, 2}}, dir)
+	if dir[0] != -1 || dir[1] != -2 {
+		t.Errorf("direction %v after a rejected pair, want [-1 -2]", dir)
+	}
+	if l.n != 2 {
+		t.Errorf("%d pairs kept, want 2", l.n)
+	}
+}
@@ -0,0 +1,60 @@
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/opt"
+)
+
+// FunctionRelativeConvergence is the status of a RelativeStop whose objective
+// stopped decreasing.
+var FunctionRelativeConvergence = opt.NewStatus("FunctionRelativeConvergence", true)
+
+// RelativeStop wraps a method to stop the minimization when the objective at
+// a major iteration changes by no more than Tolerance relative to the previous
+// major iteration, which opt.Settings cannot express.
+type RelativeStop struct {
+	opt.Method
+	Tolerance float64
+
+	f      float64 // objective at the last major iteration
+	status opt.Status
+}
+
+func (r *RelativeStop) Init(loc opt.Location, f *opt.FunctionStats, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	r.f = math.NaN()
+	r.status = opt.NotTerminated
+	evalType, iterType, err := r.Method.Init(loc, f, xNext)
+	r.check(loc, iterType)
+	return evalType, iterType, err
+}
+
+func (r *RelativeStop) Iterate(loc opt.Location, xNext []float64) (opt.EvaluationType, opt.IterationType, error) {
+	evalType, iterType, err := r.Method.Iterate(loc, xNext)
+	r.check(loc, iterType)
+	return evalType, iterType, err
+}
+
+// check compares the objective of loc with that of the previous major
+// iteration if the method made loc a major iteration.
+func (r *RelativeStop) check(loc opt.Location, iterType opt.IterationType) {
+	if iterType != opt.Major {
+		return
+	}
+	if math.Abs(r.f-loc.F) <= r.Tolerance*math.Abs(r.f) {
+		r.status = FunctionRelativeConvergence
+	}
+	r.f = loc.F
+}
+
+// Status returns FunctionRelativeConvergence once the objective has stopped
+// decreasing, and otherwise the status of the wrapped method if it has one.
+func (r *RelativeStop) Status() (opt.Status, error) {
+	if r.status != opt.NotTerminated {
+		return r.status, nil
+	}
+	if s, ok := r.Method.(opt.Statuser); ok {
+		return s.Status()
+	}
+	return opt.NotTerminated, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+
+	"github.com/reggo/reggo/regularize"
+)
+
+// penalty is the elastic net regularizer L1 Σ|w_i| + L2 Σ w_i². The
+// derivative of |w_i| at zero is taken to be zero. It is used in place of
+// regularize.OneNorm, whose derivative is that of the two norm.
+type penalty struct {
+	L1, L2 float64
+}
+
+// newRegularizer returns the regularizer of the case, or nil if it has no
+// penalty. Pure weight decay uses regularize.TwoNorm. GradOptimizable adds the
+// penalty to the summed loss of the samples before dividing by their number,
+// so the strengths are relative to the total loss of each evaluation.
+func newRegularizer(c Case) regularize.Regularizer {
+	switch {
+	case c.L1 == 0 && c.L2 == 0:
+		return nil
+	case c.L1 == 0:
+		return regularize.TwoNorm{Gamma: c.L2}
+	}
+	return penalty{L1: c.L1, L2: c.L2}
+}
+
+func (p penalty) Loss(parameters []float64) float64 {
+	var l1, l2 float64
+	for _, w := range parameters {
+		l1 += math.Abs(w)
+		l2 += w * w
+	}
+	return p.L1*l1 + p.L2*l2
+}
+
+func (p penalty) LossDeriv(parameters, derivative []float64) float64 {
+	for i := range derivative {
+		derivative[i] = 0
+	}
+	return p.LossAddDeriv(parameters, derivative)
+}
+
+func (p penalty) LossAddDeriv(parameters, derivative []float64) float64 {
+	for i, w := range parameters {
+		var sign float64
+		switch {
+		case w > 0:
+			sign = 1
+		case w < 0:
+			sign = -1
+		}
+		derivative[i] += p.L1*sign + 2*p.L2*w
+	}
+	return p.Loss(parameters)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/reggo/reggo/loss"
+	"github.com/reggo/reggo/regularize"
+	"github.com/reggo/reggo/train"
+)
+
+// Phases is the time in seconds spent in each phase of a run. Predict, Deriv
+// and Loss are summed over the objective workers, so they are CPU time rather
+// than wall time, and are only measured with -phases, as is Regularize.
+type Phases struct {
+	Load     float64 `json:"load"`     // reading or generating the data, zero if already loaded
+	Scale    float64 `json:"scale"`    // copying and scaling the training data
+	Setup    float64 `json:"setup"`    // network construction and objective initialization
+	Optimize float64 `json:"optimize"` // the optimizer, including evaluations
+	Eval     float64 `json:"eval"`     // objective and gradient evaluations
+	Predict  float64 `json:"predict,omitempty"`
+	Deriv    float64 `json:"deriv,omitempty"`
+	Loss     float64 `json:"loss,omitempty"`
+
+	Regularize float64 `json:"regularize,omitempty"` // the penalty on the parameters, once per evaluation
+	Wait       float64 `json:"wait,omitempty"`       // the optimizer waiting for streamed batches
+}
+
+func (p Phases) String() string {
+	s := fmt.Sprintf("load %.3gs, scale %.3gs, setup %.3gs, optimize %.3gs (eval %.3gs, optimizer %.3gs)",
+		p.Load, p.Scale, p.Setup, p.Optimize, p.Eval, p.Optimize-p.Eval-p.Wait)
+	if p.Predict != 0 || p.Deriv != 0 || p.Loss != 0 {
+		s += fmt.Sprintf(", worker predict %.3gs, deriv %.3gs, loss %.3gs", p.Predict, p.Deriv, p.Loss)
+	}
+	if p.Regularize != 0 {
+		s += fmt.Sprintf(", regularize %.3gs", p.Regularize)
+	}
+	if p.Wait != 0 {
+		s += fmt.Sprintf(", waiting for batches %.3gs", p.Wait)
+	}
+	return s
+}
+
+// add adds the times of q to p.
+func (p *Phases) add(q Phases) {
+	p.Load += q.Load
+	p.Scale += q.Scale
+	p.Setup += q.Setup
+	p.Optimize += q.Optimize
+	p.Eval += q.Eval
+	p.Predict += q.Predict
+	p.Deriv += q.Deriv
+	p.Loss += q.Loss
+	p.Regularize += q.Regularize
+	p.Wait += q.Wait
+}
+
+// timedObjective times the evaluations of a GradOptimizable.
+type timedObjective struct {
+	*train.GradOptimizable
+	eval time.Duration
+}
+
+func (t *timedObjective) F(x []float64) float64 {
+	start := time.Now()
+	f := t.GradOptimizable.F(x)
+	t.eval += time.Since(start)
+	return f
+}
+
+func (t *timedObjective) FDf(x, grad []float64) float64 {
+	start := time.Now()
+	f := t.GradOptimizable.FDf(x, grad)
+	t.eval += time.Since(start)
+	return f
+}
+
+// workerTimes accumulates the time the objective workers spend in the
+// network, the loss function and the regularizer, in nanoseconds.
+type workerTimes struct {
+	predict, deriv, loss, regularize int64
+}
+
+// timedTrainable wraps a Trainable so that its loss derivers are timed.
+type timedTrainable struct {
+	train.Trainable
+	times *workerTimes
+}
+
+func (t timedTrainable) NewLossDeriver() train.LossDeriver {
+	return timedLossDeriver{t.Trainable.NewLossDeriver(), t.times}
+}
+
+type timedLossDeriver struct {
+	train.LossDeriver
+	times *workerTimes
+}
+
+func (t timedLossDeriver) Predict(parameters, featurizedInput, predOutput []float64) {
+	start := time.Now()
+	t.LossDeriver.Predict(parameters, featurizedInput, predOutput)
+	atomic.AddInt64(&t.times.predict, int64(time.Since(start)))
+}
+
+func (t timedLossDeriver) Deriv(parameters, featurizedInput, predOutput, dLossDPred, dLossDWeight []float64) {
+	start := time.Now()
+	t.LossDeriver.Deriv(parameters, featurizedInput, predOutput, dLossDPred, dLossDWeight)
+	atomic.AddInt64(&t.times.deriv, int64(time.Since(start)))
+}
+
+// timedLosser times a loss function.
+type timedLosser struct {
+	loss.DerivLosser
+	times *workerTimes
+}
+
+func (t timedLosser) LossDeriv(prediction, truth, derivative []float64) float64 {
+	start := time.Now()
+	l := t.DerivLosser.LossDeriv(prediction, truth, derivative)
+	atomic.AddInt64(&t.times.loss, int64(time.Since(start)))
+	return l
+}
+
+// timedRegularizer times a regularizer.
+type timedRegularizer struct {
+	regularize.Regularizer
+	times *workerTimes
+}
+
+func (t timedRegularizer) LossDeriv(parameters, derivative []float64) float64 {
+	start := time.Now()
+	l := t.Regularizer.LossDeriv(parameters, derivative)
+	atomic.AddInt64(&t.times.regularize, int64(time.Since(start)))
+	return l
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Iterate is the objective after a major iteration of the optimizer.
+type Iterate struct {
+	Evals     int     `json:"evals"` // evaluations of the objective or its gradient so far
+	Objective float64 `json:"objective"`
+}
+
+// plotColors are the colors of the lines of a chart, in turn.
+var plotColors = []color.Color{
+	color.RGBA{0x1f, 0x77, 0xb4, 0xff},
+	color.RGBA{0xd6, 0x27, 0x28, 0xff},
+	color.RGBA{0x2c, 0xa0, 0x2c, 0xff},
+	color.RGBA{0xff, 0x7f, 0x0e, 0xff},
+	color.RGBA{0x94, 0x67, 0xbd, 0xff},
+	color.RGBA{0x8c, 0x56, 0x4b, 0xff},
+	color.RGBA{0xe3, 0x77, 0xc2, 0xff},
+	color.RGBA{0x17, 0xbe, 0xcf, 0xff},
+	color.RGBA{0x7f, 0x7f, 0x7f, 0xff},
+	color.RGBA{0xbc, 0xbd, 0x22, 0xff},
+}
+
+// Layout of a chart.
+const (
+	plotWidth  = 7.5 * vg.Inch
+	plotHeight = 4.5 * vg.Inch
+	legendGap  = 10 * vg.Millimeter // between the plot and the legend
+)
+
+// plotFormats are the file formats that charts are written in.
+var plotFormats = []string{"svg", "png"}
+
+// checkPlotFormat returns an error if the format is not one of plotFormats.
+func checkPlotFormat(format string) error {
+	for _, f := range plotFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown plot format %q, want %s", format, strings.Join(plotFormats, " or "))
+}
+
+// chartLine is a line of a chart through its points.
+type chartLine struct {
+	name   string
+	x, y   []float64
+	dashed bool
+}
+
+// chart is a line chart, drawn with gonum/plot.
+type chart struct {
+	title, xLabel, yLabel string
+	logY                  bool // log scale, if the y values are all positive
+	lines                 []chartLine
+}
+
+// plot returns the chart as a gonum/plot and the legend of its lines, which
+// is drawn beside the plot rather than over it.
+func (ch *chart) plot() (*plot.Plot, plot.Legend, error) {
+	p := plot.New()
+	p.Title.Text = ch.title
+	p.X.Label.Text = ch.xLabel
+	p.Y.Label.Text = ch.yLabel
+	logY := ch.logY
+	for _, l := range ch.lines {
+		for _, y := range l.y {
+			if !(y > 0) {
+				logY = false
+			}
+		}
+	}
+	if logY {
+		p.Y.Scale = plot.LogScale{}
+		p.Y.Tick.Marker = plot.LogTicks{Prec: -1}
+		p.Y.Label.Text += " (log scale)"
+	}
+	p.Add(plotter.NewGrid())
+	legend := plot.NewLegend()
+	legend.Top = true
+	for i, l := range ch.lines {
+		xys := make(plotter.XYs, len(l.x))
+		for j := range xys {
+			xys[j].X, xys[j].Y = l.x[j], l.y[j]
+		}
+		line, err := plotter.NewLine(xys)
+		if err != nil {
+			return nil, legend, fmt.Errorf("%s: %v", l.name, err)
+		}
+		line.Color = plotColors[i%len(plotColors)]
+		line.Width = vg.Points(1.5)
+		if l.dashed {
+			line.Dashes = []vg.Length{vg.Points(6), vg.Points(4)}
+		}
+		p.Add(line)
+		legend.Add(l.name, line)
+	}
+	return p, legend, nil
+}
+
+// write draws the chart to w in the format, one of plotFormats.
+func (ch *chart) write(w io.Writer, format string) error {
+	p, legend, err := ch.plot()
+	if err != nil {
+		return err
+	}
+	c, err := draw.NewFormattedCanvas(plotWidth, plotHeight, format)
+	if err != nil {
+		return err
+	}
+	dc := draw.New(c)
+	width := legend.Rectangle(dc).Size().X
+	p.Draw(draw.Crop(dc, 0, -width-legendGap, 0, 0))
+	legend.Draw(draw.Crop(dc, plotWidth-width, 0, 0, 0))
+	_, err = c.WriteTo(w)
+	return err
+}
+
+// convergenceChart returns a chart of the objective against the evaluations
+// of the first run of each case with a recorded convergence, or nil if there
+// are none. The objectives of cases on different data or losses are not
+// comparable, so the chart is meant for comparing optimizers and backends.
+func convergenceChart(records []Record) *chart {
+	ch := &chart{title: "Convergence", xLabel: "evaluations", yLabel: "objective", logY: true}
+	for _, r := range records {
+		if r.Run != 0 || len(r.Convergence) == 0 {
+			continue
+		}
+		l := chartLine{name: r.Name}
+		for _, p := range r.Convergence {
+			l.x = append(l.x, float64(p.Evals))
+			l.y = append(l.y, p.Objective)
+		}
+		ch.lines = append(ch.lines, l)
+	}
+	if ch.lines == nil {
+		return nil
+	}
+	return ch
+}
+
+// scalingChart returns a chart of the speedup against the workers of each
+// case run with several worker counts, grouped as by reportScaling, with the
+// ideal linear speedup, or nil if there are none.
+func scalingChart(records []Record) *chart {
+	ch := &chart{title: "Scaling", xLabel: "workers", yLabel: "speedup"}
+	groups, points := scalingGroups(records)
+	most := 1
+	for _, name := range groups {
+		pts := points[name]
+		speedup := speedups(pts)
+		if len(pts) < 2 || speedup == nil {
+			continue
+		}
+		l := chartLine{name: name, y: speedup}
+		for _, p := range pts {
+			l.x = append(l.x, float64(p.workers))
+			if p.workers > most {
+				most = p.workers
+			}
+		}
+		ch.lines = append(ch.lines, l)
+	}
+	if ch.lines == nil {
+		return nil
+	}
+	ch.lines = append(ch.lines, chartLine{name: "ideal", x: []float64{1, float64(most)}, y: []float64{1, float64(most)}, dashed: true})
+	return ch
+}
+
+// writePlots draws the convergence and scaling charts of the records that
+// have them in the format, one of plotFormats, to convergence and scaling
+// files with its extension in the directory, creating it, and returns the
+// names of the files written.
+func writePlots(dir, format string, records []Record) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, p := range []struct {
+		name  string
+		chart *chart
+	}{
+		{"convergence." + format, convergenceChart(records)},
+		{"scaling." + format, scalingChart(records)},
+	} {
+		if p.chart == nil {
+			continue
+		}
+		filename := filepath.Join(dir, p.name)
+		f, err := os.Create(filename)
+		if err != nil {
+			return written, err
+		}
+		if err := p.chart.write(f, format); err != nil {
+			f.Close()
+			return written, err
+		}
+		if err := f.Close(); err != nil {
+			return written, err
+		}
+		written = append(written, filename)
+	}
+	return written, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+)
+
+// predictTime is the least time spent timing each way of predicting.
+const predictTime = 200 * time.Millisecond
+
+// Throughput is the speed of prediction with a trained network, batched with
+// PredictBatch over all of the rows, which may use several goroutines, and one
+// row at a time with Predict.
+type Throughput struct {
+	Rows          int     `json:"rows"` // rows per batch
+	BatchNsPerRow float64 `json:"batch_ns_per_row"`
+	RowNsPerRow   float64 `json:"row_ns_per_row"`
+}
+
+func (t Throughput) String() string {
+	return fmt.Sprintf("batched %.3g rows/s (%.0f ns/row), single row %.3g rows/s (%.0f ns/row)",
+		1e9/t.BatchNsPerRow, t.BatchNsPerRow, 1e9/t.RowNsPerRow, t.RowNsPerRow)
+}
+
+// measurePrediction times predicting the outputs of the rows of inputs, each
+// way repeating until at least predictTime has passed.
+func measurePrediction(p common.Predictor, inputs *mat64.Dense) (*Throughput, error) {
+	n, _ := inputs.Dims()
+	t := &Throughput{Rows: n}
+	outputs := mat64.NewDense(n, p.OutputDim(), nil)
+	var rows int
+	start := time.Now()
+	for rows == 0 || time.Since(start) < predictTime {
+		if _, err := p.PredictBatch(inputs, outputs); err != nil {
+			return nil, err
+		}
+		rows += n
+	}
+	t.BatchNsPerRow = float64(time.Since(start).Nanoseconds()) / float64(rows)
+
+	output := make([]float64, p.OutputDim())
+	rows = 0
+	start = time.Now()
+	for rows == 0 || time.Since(start) < predictTime {
+		// Check the clock every 64 rows to keep it out of the timing.
+		for i := 0; i < 64; i++ {
+			if _, err := p.Predict(inputs.RowView(rows%n), output); err != nil {
+				return nil, err
+			}
+			rows++
+		}
+	}
+	t.RowNsPerRow = float64(time.Since(start).Nanoseconds()) / float64(rows)
+	return t, nil
+}
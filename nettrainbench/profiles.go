@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+)
+
+// profiles holds the files given to the profiling flags. Empty names disable
+// the profile.
+type profiles struct {
+	cpu, mem, block, mutex, exec string
+}
+
+// start starts the enabled profiles of a run, and returns a function that
+// stops them and writes the files. The memory, block and mutex profiles are
+// cumulative over the life of the process, so with several cases each file
+// also includes the earlier cases.
+func (p profiles) start(c Case, run int, several bool) (stop func() error, err error) {
+	var stops []func() error
+	stop = func() error {
+		var first error
+		for i := len(stops) - 1; i >= 0; i-- {
+			if err := stops[i](); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+	if p.cpu != "" {
+		f, err := os.Create(caseFile(p.cpu, c, run, several))
+		if err != nil {
+			return stop, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+	if p.exec != "" {
+		f, err := os.Create(caseFile(p.exec, c, run, several))
+		if err != nil {
+			return stop, err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+	if p.block != "" {
+		runtime.SetBlockProfileRate(1)
+		stops = append(stops, func() error {
+			runtime.SetBlockProfileRate(0)
+			return writeProfile("block", caseFile(p.block, c, run, several))
+		})
+	}
+	if p.mutex != "" {
+		runtime.SetMutexProfileFraction(1)
+		stops = append(stops, func() error {
+			runtime.SetMutexProfileFraction(0)
+			return writeProfile("mutex", caseFile(p.mutex, c, run, several))
+		})
+	}
+	if p.mem != "" {
+		stops = append(stops, func() error {
+			runtime.GC() // get up-to-date statistics
+			return writeProfile("allocs", caseFile(p.mem, c, run, several))
+		})
+	}
+	return stop, nil
+}
+
+// writeProfile writes the named runtime profile to the file.
+func writeProfile(name, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// caseFile returns the name of an output file of a run, such as a trace or
+// profile. With several runs, the case name and run index are added before
+// the extension of filename.
+func caseFile(filename string, c Case, run int, several bool) string {
+	if !several {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	name := strings.Map(func(r rune) rune {
+		if r == os.PathSeparator || r == ' ' {
+			return '_'
+		}
+		return r
+	}, c.Name)
+	if c.Repeat > 1 {
+		name += "_" + strconv.Itoa(run)
+	}
+	return strings.TrimSuffix(filename, ext) + "_" + name + ext
+}
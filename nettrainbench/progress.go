@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// console is where the human readable output goes, nowhere with -quiet.
+var console io.Writer = os.Stdout
+
+// quiet is set by -quiet, which leaves only the machine readable progress on
+// the standard output.
+var quiet bool
+
+// Statuses of a case in the progress reports.
+const (
+	progressOK      = "ok"      // every run finished
+	progressFailed  = "failed"  // a run did not finish
+	progressSkipped = "skipped" // the suite ran out of time first
+	progressResumed = "resumed" // the records were read from the checkpoint
+)
+
+// progress reports each case of a benchmark of several as they finish, with
+// the time taken and the time left estimated from the mean time of the cases
+// run so far.
+type progress struct {
+	w     io.Writer
+	json  bool // write JSON lines rather than text
+	total int
+	done  int
+	ran   int // of the done cases, those that ran rather than being resumed or skipped
+	start time.Time
+}
+
+// caseProgress is the JSON line of a finished case.
+type caseProgress struct {
+	Case    string  `json:"case"`
+	Status  string  `json:"status"`
+	Done    int     `json:"done"`
+	Total   int     `json:"total"`
+	Seconds float64 `json:"seconds"`     // of the case
+	Elapsed float64 `json:"elapsed"`     // seconds since the first case started
+	ETA     float64 `json:"eta_seconds"` // estimated seconds left
+}
+
+func newProgress(w io.Writer, total int, json bool) *progress {
+	return &progress{w: w, json: json, total: total, start: time.Now()}
+}
+
+// finish reports that the named case finished with the status after
+// running for the duration, if there are several cases or the report is
+// JSON.
+func (p *progress) finish(name, status string, d time.Duration) error {
+	p.done++
+	if status != progressResumed && status != progressSkipped {
+		p.ran++
+	}
+	elapsed := time.Since(p.start)
+	var eta time.Duration
+	if p.ran > 0 {
+		eta = time.Duration(float64(elapsed) / float64(p.ran) * float64(p.total-p.done))
+	}
+	if p.json {
+		return json.NewEncoder(p.w).Encode(caseProgress{
+			Case:    name,
+			Status:  status,
+			Done:    p.done,
+			Total:   p.total,
+			Seconds: d.Seconds(),
+			Elapsed: elapsed.Seconds(),
+			ETA:     eta.Seconds(),
+		})
+	}
+	if p.total < 2 {
+		return nil
+	}
+	_, err := fmt.Fprintf(p.w, "[%d/%d] %s %s in %v; elapsed %v, ETA %v\n",
+		p.done, p.total, name, status, d.Round(time.Millisecond), elapsed.Round(time.Second), eta.Round(time.Second))
+	return err
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runRequest is the body of a POST to /run of a worker started with -listen.
+type runRequest struct {
+	Case   Case     `json:"case"`
+	Seed   int64    `json:"seed"`
+	NCases int      `json:"ncases"` // cases of the whole benchmark, for naming files
+	Flags  []string `json:"flags"`  // of the coordinator, from childFlags
+}
+
+// serveWorker serves HTTP on addr for a coordinator started with -remote,
+// running the case of each POST to /run in a subprocess, one at a time so
+// that cases do not share the machine, and replying with its records as
+// JSON. GET /env replies with the Env of the machine, whose BLAS is chosen by
+// the flags of each run. The data files of the cases are read on the worker,
+// relative to its working directory. Anyone who can reach the address can run
+// cases, so it is for trusted networks, but requests whose flags fail
+// checkWorkerFlags are refused, so they cannot write files on the worker.
+func serveWorker(addr string) error {
+	log.Printf("worker listening on %s", addr)
+	return http.ListenAndServe(addr, workerHandler())
+}
+
+// localFlags are the flags that write files on, or serve from, the machine
+// running a case, which a worker does not take from a coordinator. -plot is
+// not among them: a subprocess only records the convergence it charts.
+var localFlags = map[string]bool{
+	"save":         true,
+	"cpuprofile":   true,
+	"memprofile":   true,
+	"blockprofile": true,
+	"mutexprofile": true,
+	"exectrace":    true,
+	"trace":        true,
+	"report":       true,
+	"serve":        true,
+}
+
+// checkWorkerFlags returns an error unless each of the flags is -name=value
+// of a flag of this program, as from childFlags, that is neither one of the
+// parentFlags, which the worker sets itself, nor one of the localFlags.
+func checkWorkerFlags(flags []string) error {
+	for _, arg := range flags {
+		name, _, ok := strings.Cut(arg, "=")
+		if !ok || !strings.HasPrefix(name, "-") {
+			return fmt.Errorf("flag %q is not -name=value", arg)
+		}
+		name = name[1:]
+		switch {
+		case flag.Lookup(name) == nil:
+			return fmt.Errorf("flag -%s is not defined", name)
+		case parentFlags[name]:
+			return fmt.Errorf("flag -%s is set by the worker", name)
+		case localFlags[name]:
+			return fmt.Errorf("flag -%s writes on the worker", name)
+		}
+	}
+	return nil
+}
+
+// workerHandler returns the handler of serveWorker.
+func workerHandler() http.Handler {
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/env", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, environment())
+	})
+	mux.HandleFunc("/run", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST a run request", http.StatusMethodNotAllowed)
+			return
+		}
+		var req runRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := checkWorkerFlags(req.Flags); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		log.Printf("running %s for %s", req.Case.Name, r.RemoteAddr)
+		records, err := runSubprocess(req.Case, req.Seed, req.NCases, req.Flags)
+		mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, records)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Print(err)
+	}
+}
+
+// remoteResult is the outcome of a case run by a worker.
+type remoteResult struct {
+	c       Case
+	start   time.Time
+	records []Record
+	err     error
+}
+
+// remotePool runs cases on workers started with -listen, each running one
+// case at a time.
+type remotePool struct {
+	jobs    chan remoteResult // cases to run, with their start times
+	results chan remoteResult
+	pending int // cases submitted whose results have not been handled
+}
+
+// workerURL returns the base URL of a worker given as host:port or a URL.
+func workerURL(worker string) string {
+	if strings.Contains(worker, "://") {
+		return strings.TrimSuffix(worker, "/")
+	}
+	return "http://" + worker
+}
+
+// checkWorkers returns the Env of each worker, failing if one cannot be
+// reached.
+func checkWorkers(workers []string) ([]Env, error) {
+	envs := make([]Env, len(workers))
+	for i, worker := range workers {
+		resp, err := http.Get(workerURL(worker) + "/env")
+		if err != nil {
+			return nil, fmt.Errorf("worker %s: %v", worker, err)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&envs[i])
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("worker %s: %v", worker, err)
+		}
+	}
+	return envs, nil
+}
+
+// newRemotePool starts running cases on the workers with the seed and
+// flags, as for runSubprocess.
+func newRemotePool(workers []string, seed int64, nCases int, flags []string) *remotePool {
+	p := &remotePool{jobs: make(chan remoteResult), results: make(chan remoteResult)}
+	for _, worker := range workers {
+		go func(worker string) {
+			for job := range p.jobs {
+				job.records, job.err = runRemote(worker, runRequest{Case: job.c, Seed: seed, NCases: nCases, Flags: flags})
+				for i := range job.records {
+					job.records[i].Host = worker
+				}
+				p.results <- job
+			}
+		}(worker)
+	}
+	return p
+}
+
+// runRemote runs the case of the request on the worker and returns its
+// records.
+func runRemote(worker string, req runRequest) ([]Record, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(workerURL(worker)+"/run", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("worker %s: %v", worker, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("worker %s: %s: %s", worker, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var records []Record
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("worker %s: %v", worker, err)
+	}
+	return records, nil
+}
+
+// submit waits for a worker to take the case, calling handle with the results
+// of the cases that finish meanwhile, so that they are handled by the caller's
+// goroutine.
+func (p *remotePool) submit(c Case, handle func(remoteResult)) {
+	job := remoteResult{c: c}
+	for {
+		job.start = time.Now()
+		select {
+		case p.jobs <- job:
+			p.pending++
+			return
+		case r := <-p.results:
+			p.pending--
+			handle(r)
+		}
+	}
+}
+
+// wait calls handle with the results of the remaining cases and stops the
+// workers.
+func (p *remotePool) wait(handle func(remoteResult)) {
+	close(p.jobs)
+	for ; p.pending > 0; p.pending-- {
+		handle(<-p.results)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/btracey/numcsv"
+	"github.com/gonum/matrix/mat64"
+	"github.com/reggo/reggo/common"
+)
+
+// Env describes the machine and build a benchmark ran on.
+type Env struct {
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	NumCPU     int    `json:"numcpu"`
+	CPU        string `json:"cpu"`
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	GoVersion  string `json:"go"`
+	GitSHA     string `json:"git"`
+	BLAS       string `json:"blas"`
+	Affinity   string `json:"affinity,omitempty"` // processors the benchmark was pinned to
+	Governor   string `json:"governor,omitempty"` // frequency governors of those processors
+	GOGC       string `json:"gogc,omitempty"`     // the GOGC environment variable, of cases without gogc
+
+	// The machine, for comparing results from different ones. Machine is a
+	// hash of the hardware fields and the OS, equal for runs on alike
+	// machines.
+	Cores   int    `json:"cores,omitempty"`   // physical cores
+	NUMA    string `json:"numa,omitempty"`    // processors of each NUMA node, separated by semicolons
+	Memory  uint64 `json:"memory,omitempty"`  // bytes of physical memory
+	OS      string `json:"os,omitempty"`      // distribution and kernel release
+	Threads string `json:"threads,omitempty"` // thread count settings of the C BLAS libraries
+	Machine string `json:"machine,omitempty"`
+}
+
+// Record is the result of running a case.
+type Record struct {
+	Name         string           `json:"name"`
+	Case         Case             `json:"case"`
+	ConfigHash   string           `json:"config_hash"` // of the settings of the case that affect its results
+	Seed         int64            `json:"seed"`
+	Run          int              `json:"run"` // index of the repetition
+	Start        time.Time        `json:"start"`
+	Seconds      float64          `json:"seconds"`               // wall time
+	NsPerOp      int64            `json:"ns_per_op"`             // wall time of one training run
+	NsPerEval    float64          `json:"ns_per_eval,omitempty"` // wall time per evaluation of the objective
+	Loss         float64          `json:"loss"`                  // final training loss, of the scaled outputs
+	Iterations   int              `json:"iterations"`
+	FunEvals     int              `json:"fun_evals"`
+	GradEvals    int              `json:"grad_evals"`
+	FunGradEvals int              `json:"fungrad_evals"`
+	NParams      int              `json:"nparams"`
+	Status       string           `json:"status"`
+	Test         *Metrics         `json:"test,omitempty"` // errors on the held out samples
+	CV           *CrossValidation `json:"cv,omitempty"`   // errors over the folds of a cross-validated case
+	Phases       Phases           `json:"phases"`
+	Memory       *Memory          `json:"memory,omitempty"`       // allocation and garbage collection during the run
+	Profile      []FuncTime       `json:"profile,omitempty"`      // functions with the most CPU time, with -profilesummary
+	Prediction   *Throughput      `json:"prediction,omitempty"`   // speed of the trained network
+	IterSeconds  []float64        `json:"iter_seconds,omitempty"` // wall time of each major iteration, with -iters
+	Convergence  []Iterate        `json:"convergence,omitempty"`  // objective at each major iteration, with -plot
+	Members      []Metrics        `json:"members,omitempty"`      // test errors of each network of an ensemble
+	Error        string           `json:"error,omitempty"`        // why a run with status Failed, Panicked or TimedOut did not finish
+	Host         string           `json:"host,omitempty"`         // worker that ran it with -remote
+	Env          Env              `json:"env"`
+
+	predictor common.Predictor // the trained network or baseline
+	inputs    *mat64.Dense     // its scaled training inputs
+	model     *Model           // the network and scalers, for saving; nil for baselines
+}
+
+// evals returns the number of evaluations of the objective or its gradient.
+func (r *Record) evals() int {
+	return r.FunEvals + r.GradEvals + r.FunGradEvals
+}
+
+// environment returns the current Env. Fields that cannot be determined are
+// left empty.
+func environment() Env {
+	e := Env{
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+		CPU:        cpuModel(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		GoVersion:  runtime.Version(),
+		GitSHA:     gitSHA(),
+		BLAS:       blasBackend,
+		Affinity:   affinity,
+		Governor:   governors(pinned),
+		GOGC:       os.Getenv("GOGC"),
+
+		Cores:   physicalCores(),
+		NUMA:    numaNodes(),
+		Memory:  physicalMemory(),
+		OS:      osRelease(),
+		Threads: blasThreads(),
+	}
+	e.Machine = e.machine()
+	return e
+}
+
+// cpuModel returns the model name of the first processor from /proc/cpuinfo.
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		kv := strings.SplitN(s.Text(), ":", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == "model name" {
+			return strings.TrimSpace(kv[1])
+		}
+	}
+	return ""
+}
+
+// gitSHA returns the commit checked out in the working directory, with a
+// "+dirty" suffix if there are uncommitted changes.
+func gitSHA() string {
+	sha, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	s := strings.TrimSpace(string(sha))
+	if status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err == nil && len(status) > 0 {
+		s += "+dirty"
+	}
+	return s
+}
+
+// writeResults writes the records to the named file, as JSON if the name ends
+// in ".json", in the Go benchmark format read by benchstat if it ends in ".txt"
+// or ".bench", and as CSV otherwise. The CSV has a row per record, with the
+// environment of the first record in comments above the headings. Runs that
+// did not finish are only written to JSON, as the others have no measurements
+// of them.
+func writeResults(filename string, records []Record) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	switch filepath.Ext(filename) {
+	case ".json":
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(records)
+	case ".txt", ".bench":
+		err = writeBenchstat(f, finished(records))
+	default:
+		err = writeResultsCSV(f, finished(records))
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// finished returns the records of the runs that finished.
+func finished(records []Record) []Record {
+	var done []Record
+	for _, r := range records {
+		if r.Error == "" {
+			done = append(done, r)
+		}
+	}
+	return done
+}
+
+// writeBenchstat writes a benchmark line per record, preceded by the
+// configuration lines of the environment of the first record. Repeated runs of
+// a case give repeated lines of the same benchmark.
+func writeBenchstat(w io.Writer, records []Record) error {
+	bw := bufio.NewWriter(w)
+	if len(records) > 0 {
+		e := records[0].Env
+		fmt.Fprintf(bw, "goos: %s\ngoarch: %s\npkg: github.com/btracey/gobench/nettrainbench\n", e.GOOS, e.GOARCH)
+		if e.CPU != "" {
+			fmt.Fprintf(bw, "cpu: %s\n", e.CPU)
+		}
+		fmt.Fprintf(bw, "blas: %s\n", e.BLAS)
+		if e.Machine != "" {
+			fmt.Fprintf(bw, "machine: %s\n", e.Machine)
+		}
+		if e.GitSHA != "" {
+			fmt.Fprintf(bw, "commit: %s\n", e.GitSHA)
+		}
+	}
+	for _, r := range records {
+		fmt.Fprintf(bw, "%s-%d\t1\t%d ns/op\t%g ns/eval\t%g loss\t%d evals",
+			benchmarkName(r.Name), r.Env.GOMAXPROCS, r.NsPerOp, r.NsPerEval, r.Loss, r.evals())
+		switch {
+		case r.Test != nil && r.Test.Classes > 0:
+			fmt.Fprintf(bw, "\t%g accuracy", r.Test.Accuracy)
+		case r.CV != nil && r.CV.Accuracy != nil:
+			fmt.Fprintf(bw, "\t%g accuracy", r.CV.Accuracy.Mean)
+		}
+		if r.Memory != nil {
+			fmt.Fprintf(bw, "\t%d B/op\t%d allocs/op", r.Memory.Bytes, r.Memory.Mallocs)
+		}
+		if r.Prediction != nil {
+			fmt.Fprintf(bw, "\t%g batch-ns/row\t%g row-ns/row", r.Prediction.BatchNsPerRow, r.Prediction.RowNsPerRow)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// benchmarkName returns the case name as a Go benchmark name, with spaces
+// replaced since they end the name.
+func benchmarkName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "Benchmark"
+	}
+	return "Benchmark" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+func writeResultsCSV(f *os.File, records []Record) error {
+	headings := []string{"neurons", "layers", "ndata", "workers", "seconds", "ns/op", "ns/eval", "loss", "fun_evals", "grad_evals", "fungrad_evals", "nparams", "bytes", "mallocs", "num_gc"}
+	data := mat64.NewDense(len(records), len(headings), nil)
+	for i, r := range records {
+		c := r.Case
+		var mem Memory
+		if r.Memory != nil {
+			mem = *r.Memory
+		}
+		for j, v := range []float64{
+			float64(c.Neurons), float64(c.Layers), float64(c.NData), float64(c.Workers),
+			r.Seconds, float64(r.NsPerOp), r.NsPerEval, r.Loss,
+			float64(r.FunEvals), float64(r.GradEvals), float64(r.FunGradEvals), float64(r.NParams),
+			float64(mem.Bytes), float64(mem.Mallocs), float64(mem.NumGC),
+		} {
+			data.Set(i, j, v)
+		}
+	}
+	w := numcsv.NewWriter(f)
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	w.Comment = "# "
+	if len(records) > 0 {
+		e := records[0].Env
+		for _, line := range []string{
+			"cases: " + names(records),
+			fmt.Sprintf("gomaxprocs: %d numcpu: %d", e.GOMAXPROCS, e.NumCPU),
+			"cpu: " + e.CPU,
+			"go: " + e.GoVersion + " " + e.GOOS + "/" + e.GOARCH,
+			"git: " + e.GitSHA,
+			"blas: " + e.BLAS + " " + e.Threads,
+			fmt.Sprintf("machine: %s cores: %d numa: %s memory: %d os: %s", e.Machine, e.Cores, e.NUMA, e.Memory, e.OS),
+		} {
+			if err := w.WriteComment(line); err != nil {
+				return err
+			}
+		}
+	}
+	return w.WriteAll(headings, data)
+}
+
+// names returns the comma separated names of the records.
+func names(records []Record) string {
+	s := make([]string, len(records))
+	for i, r := range records {
+		s[i] = r.Name
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,42 @@
+package main
+
+import "math/rand"
+
+// miniBatch is a train.Sampler that visits the samples in a random order,
+// reshuffling at the start of each epoch. Every batch has BatchSize samples;
+// a batch that spans two epochs takes the remainder from the next one.
+type miniBatch struct {
+	BatchSize int
+	Rand      *rand.Rand // the source of the order
+
+	nSamples int
+	perm     []int
+	next     int // index in perm of the next sample
+	batch    []int
+}
+
+func (m *miniBatch) Init(nSamples int) error {
+	m.nSamples = nSamples
+	m.perm = m.Rand.Perm(nSamples)
+	m.next = 0
+	m.batch = make([]int, m.BatchSize)
+	return nil
+}
+
+func (m *miniBatch) Iterate() []int {
+	for i := range m.batch {
+		if m.next == m.nSamples {
+			m.perm = m.Rand.Perm(m.nSamples)
+			m.next = 0
+		}
+		m.batch[i] = m.perm[m.next]
+		m.next++
+	}
+	return m.batch
+}
+
+// batchesPerEpoch returns the number of batches of size batch that cover n
+// samples.
+func batchesPerEpoch(n, batch int) int {
+	return (n + batch - 1) / batch
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/reggo/reggo/scale"
+)
+
+// Scalings of the inputs or outputs of a case.
+const (
+	scaleNormal = "normal" // mean zero and variance one
+	scaleMinMax = "minmax" // between zero and one
+	scaleNone   = "none"   // left as read
+)
+
+// Scaler is a scaler of one of the scalings. Only the field of its kind is
+// set, so that it can be saved with a Model.
+type Scaler struct {
+	Kind   string        `json:"kind"`
+	Normal *scale.Normal `json:"normal,omitempty"`
+	MinMax *scale.Linear `json:"minmax,omitempty"`
+	None   *scale.None   `json:"none,omitempty"`
+}
+
+// newScaler returns an unfitted scaler of the kind.
+func newScaler(kind string) (*Scaler, error) {
+	s := &Scaler{Kind: kind}
+	switch kind {
+	case scaleNormal:
+		s.Normal = &scale.Normal{}
+	case scaleMinMax:
+		s.MinMax = &scale.Linear{}
+	case scaleNone:
+		s.None = &scale.None{}
+	default:
+		return nil, fmt.Errorf("unknown scaling %q", kind)
+	}
+	return s, nil
+}
+
+// fittedScaler returns a scaler of the kind fit to columns with the mean,
+// standard deviation, minimum and maximum, treating constant columns like
+// SetScale does.
+func fittedScaler(kind string, mean, sigma, min, max []float64) (*Scaler, error) {
+	s, err := newScaler(kind)
+	if err != nil {
+		return nil, err
+	}
+	dim := len(mean)
+	switch kind {
+	case scaleNormal:
+		sd := make([]float64, dim)
+		for j, v := range sigma {
+			sd[j] = v
+			if v == 0 {
+				sd[j] = 1
+			}
+		}
+		*s.Normal = scale.Normal{Mu: append([]float64(nil), mean...), Sigma: sd, Dim: dim, Scaled: true}
+	case scaleMinMax:
+		lo, hi := append([]float64(nil), min...), append([]float64(nil), max...)
+		for j := range lo {
+			if lo[j] == hi[j] {
+				lo[j] -= 0.5
+				hi[j] += 0.5
+			}
+		}
+		*s.MinMax = scale.Linear{Min: lo, Max: hi, Dim: dim, Scaled: true}
+	case scaleNone:
+		*s.None = scale.None{Dim: dim, Scaled: true}
+	}
+	return s, nil
+}
+
+// scaler returns the scaler of the kind.
+func (s *Scaler) scaler() scale.Scaler {
+	switch {
+	case s.Normal != nil:
+		return s.Normal
+	case s.MinMax != nil:
+		return s.MinMax
+	}
+	return s.None
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// scalingWorkers returns the worker counts of a scaling run up to n: the
+// powers of two below n, and n.
+func scalingWorkers(n int) []int {
+	var w []int
+	for i := 1; i < n; i *= 2 {
+		w = append(w, i)
+	}
+	return append(w, n)
+}
+
+// scalingPoint is the total wall time of the runs of a case with a number of
+// workers.
+type scalingPoint struct {
+	workers int
+	sec     float64
+	runs    int
+}
+
+// scalingGroups groups the records by their case name without the workers
+// suffix added by Sweep, in the order of first appearance, with a point for
+// each worker count of a group. speedups returns the speedup of each point of
+// a group relative to its single-worker run, using the mean wall time of
+// repeated runs, or nil if the group has no single-worker run.
+func scalingGroups(records []Record) (groups []string, points map[string][]*scalingPoint) {
+	points = make(map[string][]*scalingPoint)
+	for _, r := range records {
+		name := strings.TrimSuffix(r.Name, fmt.Sprintf("_workers%d", r.Case.Workers))
+		pts, ok := points[name]
+		if !ok {
+			groups = append(groups, name)
+		}
+		var p *scalingPoint
+		for _, q := range pts {
+			if q.workers == r.Case.Workers {
+				p = q
+			}
+		}
+		if p == nil {
+			p = &scalingPoint{workers: r.Case.Workers}
+			points[name] = append(pts, p)
+		}
+		p.sec += r.Seconds
+		p.runs++
+	}
+	return groups, points
+}
+
+func speedups(points []*scalingPoint) []float64 {
+	var base float64
+	for _, p := range points {
+		if p.workers == 1 {
+			base = p.sec / float64(p.runs)
+		}
+	}
+	if base == 0 {
+		return nil
+	}
+	s := make([]float64, len(points))
+	for i, p := range points {
+		s[i] = base / (p.sec / float64(p.runs))
+	}
+	return s
+}
+
+// reportScaling writes the speedup and parallel efficiency of each case
+// relative to its single-worker run, grouped as by scalingGroups.
+func reportScaling(w io.Writer, records []Record) error {
+	groups, points := scalingGroups(records)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "case\tworkers\tseconds\tspeedup\tefficiency\t")
+	for _, name := range groups {
+		speedup := speedups(points[name])
+		for i, p := range points[name] {
+			sec := p.sec / float64(p.runs)
+			if speedup == nil {
+				fmt.Fprintf(tw, "%s\t%d\t%.3g\t-\t-\t\n", name, p.workers, sec)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%.3g\t%.2f\t%.0f%%\t\n", name, p.workers, sec, speedup[i], 100*speedup[i]/float64(p.workers))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/btracey/gobench/nettrainbench/optimize"
+)
+
+// Search is a random search over the hyperparameters of a case. Each of the N
+// configurations draws the dimensions with a range uniformly, the integer
+// ones inclusive of both ends and the rest on a log scale, and the optimizer
+// from Optimizers. Dimensions without a range take the value of the base
+// case, and Rate is only drawn for optimizers with a learning rate.
+type Search struct {
+	N    int   `json:"n"`    // number of configurations
+	Seed int64 `json:"seed"` // of the draws, so that they are the same every run
+
+	Layers     []int     `json:"layers"`     // [min, max]
+	Neurons    []int     `json:"neurons"`    // [min, max]
+	L1         []float64 `json:"l1"`         // [min, max]
+	L2         []float64 `json:"l2"`         // [min, max]
+	Rate       []float64 `json:"rate"`       // [min, max]
+	Optimizers []string  `json:"optimizers"` // to choose from
+}
+
+// parseSearch parses a search of the form
+// "n=20;neurons=5:50;l2=1e-5:1e-2;optimizers=adam,nesterov".
+func parseSearch(s string) (*Search, error) {
+	se := &Search{}
+	for _, dim := range strings.Split(s, ";") {
+		kv := strings.SplitN(dim, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("search: bad dimension %q", dim)
+		}
+		key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		var err error
+		switch key {
+		case "n":
+			se.N, err = strconv.Atoi(val)
+		case "seed":
+			se.Seed, err = strconv.ParseInt(val, 10, 64)
+		case "layers":
+			se.Layers, err = parseIntRange(val)
+		case "neurons":
+			se.Neurons, err = parseIntRange(val)
+		case "l1":
+			se.L1, err = parseRange(val)
+		case "l2":
+			se.L2, err = parseRange(val)
+		case "rate":
+			se.Rate, err = parseRange(val)
+		case "optimizers":
+			se.Optimizers = strings.Split(val, ",")
+		default:
+			return nil, fmt.Errorf("search: unknown dimension %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("search: %s: %v", key, err)
+		}
+	}
+	return se, se.validate()
+}
+
+// parseRange parses a range of the form "min:max".
+func parseRange(s string) ([]float64, error) {
+	mm := strings.Split(s, ":")
+	if len(mm) != 2 {
+		return nil, fmt.Errorf("range %q is not min:max", s)
+	}
+	r := make([]float64, 2)
+	for i, str := range mm {
+		v, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = v
+	}
+	return r, nil
+}
+
+// parseIntRange parses a range of integers of the form "min:max".
+func parseIntRange(s string) ([]int, error) {
+	r, err := parseRange(s)
+	if err != nil {
+		return nil, err
+	}
+	return []int{int(r[0]), int(r[1])}, nil
+}
+
+// validate checks that the ranges are ordered and that those drawn on a log
+// scale are positive.
+func (s *Search) validate() error {
+	if s.N < 1 {
+		return fmt.Errorf("search: n %d is not positive", s.N)
+	}
+	for _, r := range []struct {
+		name string
+		r    []int
+	}{{"layers", s.Layers}, {"neurons", s.Neurons}} {
+		if r.r != nil && (len(r.r) != 2 || r.r[0] < 1 || r.r[1] < r.r[0]) {
+			return fmt.Errorf("search: %s range %v is not [min, max] with 1 <= min <= max", r.name, r.r)
+		}
+	}
+	for _, r := range []struct {
+		name string
+		r    []float64
+	}{{"l1", s.L1}, {"l2", s.L2}, {"rate", s.Rate}} {
+		if r.r != nil && (len(r.r) != 2 || !(r.r[0] > 0) || r.r[1] < r.r[0]) {
+			return fmt.Errorf("search: %s range %v is not [min, max] with 0 < min <= max", r.name, r.r)
+		}
+	}
+	for _, name := range s.Optimizers {
+		if _, err := optimize.New(name); err != nil {
+			return fmt.Errorf("search: %v", err)
+		}
+	}
+	return nil
+}
+
+// cases returns the N configurations drawn from the search, numbered after
+// the base case.
+func (s *Search) cases(base Case) []Case {
+	rnd := rand.New(rand.NewSource(s.Seed))
+	intn := func(r []int, v int) int {
+		if r == nil {
+			return v
+		}
+		return r[0] + rnd.Intn(r[1]-r[0]+1)
+	}
+	logUniform := func(r []float64, v float64) float64 {
+		if r == nil {
+			return v
+		}
+		lo, hi := math.Log(r[0]), math.Log(r[1])
+		return math.Exp(lo + rnd.Float64()*(hi-lo))
+	}
+	cases := make([]Case, s.N)
+	for i := range cases {
+		c := base
+		c.Name = fmt.Sprintf("%s_search%d", base.Name, i)
+		c.Layers = intn(s.Layers, c.Layers)
+		c.Neurons = intn(s.Neurons, c.Neurons)
+		c.L1 = logUniform(s.L1, c.L1)
+		c.L2 = logUniform(s.L2, c.L2)
+		if len(s.Optimizers) > 0 {
+			c.Optimizer = s.Optimizers[rnd.Intn(len(s.Optimizers))]
+		}
+		rate := logUniform(s.Rate, c.Rate)
+		if m, err := optimize.New(c.Optimizer); err == nil && optimize.SetLearningRate(m, rate) {
+			c.Rate = rate
+		}
+		cases[i] = c
+	}
+	return cases
+}
+
+// score returns the error a search ranks records by: the test or
+// cross-validated RMSE, one minus the accuracy of classifiers, or the
+// training loss if nothing was held out.
+func score(r Record) (float64, string) {
+	switch {
+	case r.Test != nil && r.Test.Classes > 0:
+		return 1 - r.Test.Accuracy, "test error rate"
+	case r.Test != nil:
+		return r.Test.RMSE, "test RMSE"
+	case r.CV != nil && r.CV.Accuracy != nil:
+		return 1 - r.CV.Accuracy.Mean, "cv error rate"
+	case r.CV != nil:
+		return r.CV.RMSE.Mean, "cv RMSE"
+	}
+	return r.Loss, "loss"
+}
+
+// rankSearch writes a table of the configurations of a search from best to
+// worst by their mean score over repeated runs.
+func rankSearch(w io.Writer, records []Record) error {
+	type row struct {
+		c          Case
+		runs       int
+		score, sec float64
+	}
+	var rows []*row
+	byName := make(map[string]*row)
+	for _, r := range records {
+		rw, ok := byName[r.Name]
+		if !ok {
+			rw = &row{c: r.Case}
+			byName[r.Name] = rw
+			rows = append(rows, rw)
+		}
+		s, _ := score(r)
+		rw.runs++
+		rw.score += s
+		rw.sec += r.Seconds
+	}
+	for _, rw := range rows {
+		rw.score /= float64(rw.runs)
+		rw.sec /= float64(rw.runs)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].score < rows[j].score })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "score"
+	if len(records) > 0 {
+		_, header = score(records[0])
+	}
+	fmt.Fprintf(tw, "rank\tcase\tlayers\tneurons\tl1\tl2\toptimizer\trate\tseconds\t%s\t\n", header)
+	for i, rw := range rows {
+		c := rw.c
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%.3g\t%.3g\t%s\t%.3g\t%.3g\t%.4g\t\n",
+			i+1, c.Name, c.Layers, c.Neurons, c.L1, c.L2, c.Optimizer, c.Rate, rw.sec, rw.score)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Summary holds statistics of repeated measurements.
+type Summary struct {
+	N    int     `json:"n"`
+	Mean float64 `json:"mean"`
+	Std  float64 `json:"std"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	CI95 float64 `json:"ci95"` // half width of the 95% confidence interval of the mean
+}
+
+// tCrit95 holds the two-sided 95% critical values of the t distribution by
+// degrees of freedom.
+var tCrit95 = []float64{
+	math.NaN(), 12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262,
+	2.228, 2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093,
+	2.086, 2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// summarize returns the statistics of x. The confidence interval uses the t
+// distribution, and is NaN for a single value.
+func summarize(x []float64) Summary {
+	if len(x) == 0 {
+		return Summary{Mean: math.NaN(), Std: math.NaN(), Min: math.NaN(), Max: math.NaN(), CI95: math.NaN()}
+	}
+	s := Summary{N: len(x), Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, v := range x {
+		s.Mean += v
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+	}
+	s.Mean /= float64(len(x))
+	if len(x) == 1 {
+		s.Std, s.CI95 = math.NaN(), math.NaN()
+		return s
+	}
+	for _, v := range x {
+		s.Std += (v - s.Mean) * (v - s.Mean)
+	}
+	s.Std = math.Sqrt(s.Std / float64(len(x)-1))
+	t := 1.96
+	if df := len(x) - 1; df < len(tCrit95) {
+		t = tCrit95[df]
+	}
+	s.CI95 = t * s.Std / math.Sqrt(float64(len(x)))
+	return s
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%.4g ± %.2g (std %.2g, min %.4g, max %.4g, n=%d)", s.Mean, s.CI95, s.Std, s.Min, s.Max, s.N)
+}
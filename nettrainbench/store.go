@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// configHash returns a short hash of the settings of the case that affect its
+// results, leaving out its name and how many times it is run.
+func configHash(c Case) string {
+	c.Name, c.Repeat, c.Warmup = "", 0, 0
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic(err) // a Case always marshals
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))[:12]
+}
+
+// storeKey identifies records of the same benchmark that can be compared:
+// the same case on the same machine at the same commit.
+type storeKey struct {
+	Name, Config, Machine, Git string
+}
+
+func keyOf(r Record) storeKey {
+	return storeKey{r.Name, r.ConfigHash, r.Env.Machine, r.Env.GitSHA}
+}
+
+// appendStore appends the records to the results store, a file of JSON
+// lines with a record on each, creating it if needed.
+func appendStore(filename string, records []Record) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readStore reads the records of the results store matching the query.
+func readStore(filename string, q storeQuery) ([]Record, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []Record
+	dec := json.NewDecoder(f)
+	for line := 1; ; line++ {
+		var r Record
+		if err := dec.Decode(&r); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("store %s record %d: %v", filename, line, err)
+		}
+		if q.match(r) {
+			records = append(records, r)
+		}
+	}
+}
+
+// storeQuery selects records of the store. Empty fields match any record,
+// and Git matches commits it is a prefix of.
+type storeQuery struct {
+	Name, Config, Machine, Git string
+	Since                      time.Time // the earliest start
+}
+
+// parseStoreQuery parses a query of the form
+// "name=FiveNeurons;machine=bd71e3c9a573;git=7a5b9be;since=2024-01-02".
+func parseStoreQuery(s string) (storeQuery, error) {
+	var q storeQuery
+	if strings.TrimSpace(s) == "" {
+		return q, nil
+	}
+	for _, term := range strings.Split(s, ";") {
+		kv := strings.SplitN(term, "=", 2)
+		if len(kv) != 2 {
+			return q, fmt.Errorf("query: bad term %q", term)
+		}
+		v := strings.TrimSpace(kv[1])
+		switch strings.TrimSpace(kv[0]) {
+		case "name":
+			q.Name = v
+		case "config":
+			q.Config = v
+		case "machine":
+			q.Machine = v
+		case "git":
+			q.Git = v
+		case "since":
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return q, fmt.Errorf("query: since %q is not a date like 2006-01-02", v)
+			}
+			q.Since = t
+		default:
+			return q, fmt.Errorf("query: unknown field %q", kv[0])
+		}
+	}
+	return q, nil
+}
+
+func (q storeQuery) match(r Record) bool {
+	return (q.Name == "" || r.Name == q.Name) &&
+		(q.Config == "" || r.ConfigHash == q.Config) &&
+		(q.Machine == "" || r.Env.Machine == q.Machine) &&
+		(q.Git == "" || strings.HasPrefix(r.Env.GitSHA, q.Git)) &&
+		!r.Start.Before(q.Since)
+}
+
+// historyRow is the mean of the records of a storeKey.
+type historyRow struct {
+	key       storeKey
+	first     time.Time // start of the earliest run
+	runs      int
+	nsPerEval float64
+	seconds   float64
+	loss      float64
+}
+
+// history returns the mean of the records of each storeKey, ordered by case,
+// config and machine, then by the start of their first run, which is the
+// order of the commits if they were benchmarked as they were made.
+func history(records []Record) []*historyRow {
+	byKey := make(map[storeKey]*historyRow)
+	var rows []*historyRow
+	for _, r := range records {
+		k := keyOf(r)
+		row, ok := byKey[k]
+		if !ok {
+			row = &historyRow{key: k, first: r.Start}
+			byKey[k] = row
+			rows = append(rows, row)
+		}
+		if r.Start.Before(row.first) {
+			row.first = r.Start
+		}
+		row.runs++
+		row.nsPerEval += r.NsPerEval
+		row.seconds += r.Seconds
+		row.loss += r.Loss
+	}
+	for _, row := range rows {
+		n := float64(row.runs)
+		row.nsPerEval /= n
+		row.seconds /= n
+		row.loss /= n
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].key, rows[j].key
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Config != b.Config {
+			return a.Config < b.Config
+		}
+		if a.Machine != b.Machine {
+			return a.Machine < b.Machine
+		}
+		return rows[i].first.Before(rows[j].first)
+	})
+	return rows
+}
+
+// writeHistory writes the history of the records as a table with the change
+// in ns/eval from the commit before on the same case, config and machine,
+// marking changes beyond the threshold fraction, so that a regression can be
+// traced to the commit that made it.
+func writeHistory(w io.Writer, records []Record, threshold float64) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "case\tconfig\tmachine\tgit\tdate\truns\tseconds\tns/eval\tdelta\tloss\t")
+	var prev *historyRow
+	for _, row := range history(records) {
+		k := row.key
+		delta := ""
+		if prev != nil && prev.key.Name == k.Name && prev.key.Config == k.Config && prev.key.Machine == k.Machine && prev.nsPerEval > 0 {
+			d := row.nsPerEval/prev.nsPerEval - 1
+			delta = fmt.Sprintf("%+.1f%%", 100*d)
+			switch {
+			case d > threshold:
+				delta += " SLOWER"
+			case d < -threshold:
+				delta += " FASTER"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%.4g\t%.4g\t%s\t%.6g\t\n",
+			k.Name, k.Config, k.Machine, shortSHA(k.Git), row.first.Format("2006-01-02 15:04"), row.runs, row.seconds, row.nsPerEval, delta, row.loss)
+		prev = row
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/btracey/numcsv"
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/opt"
+	"github.com/reggo/reggo/loss"
+	"github.com/reggo/reggo/regularize"
+	"github.com/reggo/reggo/scale"
+	"github.com/reggo/reggo/supervised/nnet"
+	"github.com/reggo/reggo/train"
+)
+
+// Streaming modes of a case, which read mini-batches from the data file
+// during training instead of loading it.
+const (
+	streamSequential = "sequential" // in the order of the file
+	streamShuffled   = "shuffled"   // in a new random order each epoch
+)
+
+const (
+	prefetch   = 4    // batches read ahead of the optimizer
+	statsBatch = 4096 // rows read at a time when fitting the scalers
+)
+
+// streamCase trains the case on mini-batches streamed from its data file, so
+// that only prefetch batches are in memory at once. A first pass over the file
+// counts the samples and fits the scalers, unless they come from a loaded
+// model, and a last one evaluates the training loss. Every sample is trained
+// on, so there is no test set.
+func streamCase(c Case, trace *tracer, workerTiming bool) (*Record, error) {
+	switch {
+	case c.Stream != streamSequential && c.Stream != streamShuffled:
+		return nil, fmt.Errorf("unknown stream %q", c.Stream)
+	case c.Synthetic != nil:
+		return nil, errors.New("synthetic data cannot be streamed")
+	case c.BatchSize <= 0:
+		return nil, errors.New("streaming needs a mini-batch size")
+	case c.Folds > 0:
+		return nil, errors.New("streamed cases cannot be cross-validated")
+	case c.Task != regression:
+		return nil, fmt.Errorf("streamed task %s, only regression is supported", c.Task)
+	case c.Weights != "":
+		return nil, errors.New("streamed cases cannot be weighted")
+	case c.Ensemble > 1:
+		return nil, errors.New("streamed cases cannot be ensembles")
+	case c.Method != methodNet:
+		return nil, fmt.Errorf("only networks can be streamed, not method %s", c.Method)
+	}
+	var phases Phases
+	start := time.Now()
+	lap := func() float64 {
+		now := time.Now()
+		d := now.Sub(start).Seconds()
+		start = now
+		return d
+	}
+
+	nTrain, inputDim, inputScaler, outputScaler, err := streamScalers(c)
+	if err != nil {
+		return nil, err
+	}
+	outputDim := c.Outputs
+	if c.model != nil {
+		if c.model.InputDim != inputDim || c.model.OutputDim != outputDim {
+			return nil, fmt.Errorf("loaded model maps %d inputs to %d outputs, data has %d and %d", c.model.InputDim, c.model.OutputDim, inputDim, outputDim)
+		}
+		inputScaler, outputScaler = c.model.InputScaler, c.model.OutputScaler
+	}
+	phases.Scale = lap()
+
+	hiddenActivator, ok := activators[c.Activation]
+	if !ok {
+		return nil, fmt.Errorf("unknown activation %q", c.Activation)
+	}
+	algorithm, err := nnet.NewSimpleTrainer(inputDim, outputDim, c.Layers, c.Neurons, hiddenActivator, nnet.Linear{})
+	if err != nil {
+		return nil, err
+	}
+	randomizeParameters(algorithm, inputDim, c.Layers, c.Neurons, outputDim, c.random())
+	initLoc := algorithm.Parameters(nil)
+	if c.model != nil {
+		if len(c.model.Parameters) != len(initLoc) {
+			return nil, fmt.Errorf("loaded model has %d parameters, network needs %d", len(c.model.Parameters), len(initLoc))
+		}
+		copy(initLoc, c.model.Parameters)
+	}
+
+	var trainable train.Trainable = algorithm
+	var losser loss.DerivLosser = loss.SquaredDistance{}
+	regularizer := newRegularizer(c)
+	times := &workerTimes{}
+	if workerTiming {
+		trainable = timedTrainable{algorithm, times}
+		losser = timedLosser{losser, times}
+		if regularizer != nil {
+			regularizer = timedRegularizer{regularizer, times}
+		}
+	}
+	stream := newBatchStream(c, inputDim, inputScaler, outputScaler)
+	defer stream.Close()
+	objective := newStreamObjective(trainable, losser, regularizer, c.Workers, stream)
+
+	settings := stopSettings(c, trace, nTrain)
+	method, err := newMethod(c)
+	if err != nil {
+		return nil, err
+	}
+	phases.Setup = lap()
+	result, err := opt.Minimize(objective, initLoc, settings, method)
+	if err != nil {
+		return nil, err
+	}
+	phases.Optimize = lap()
+	phases.Eval = objective.eval.Seconds()
+	phases.Wait = objective.wait.Seconds()
+	phases.Predict = time.Duration(times.predict).Seconds()
+	phases.Deriv = time.Duration(times.deriv).Seconds()
+	phases.Loss = time.Duration(times.loss).Seconds()
+	phases.Regularize = time.Duration(times.regularize).Seconds()
+
+	// The optimizer only saw mini-batch losses, so evaluate the loss on all
+	// of the data.
+	c.Stream = streamSequential
+	whole := newBatchStream(c, inputDim, inputScaler, outputScaler)
+	defer whole.Close()
+	total, err := objective.epochLoss(result.X, whole)
+	if err != nil {
+		return nil, err
+	}
+	algorithm.SetParameters(result.X)
+	return &Record{
+		Name:         c.Name,
+		Case:         c,
+		Loss:         total,
+		Iterations:   result.NumMajorIterations,
+		FunEvals:     result.NumFunEvals,
+		GradEvals:    result.NumGradEvals,
+		FunGradEvals: result.NumFunGradEvals,
+		NParams:      len(initLoc),
+		Status:       result.Status.String(),
+		Phases:       phases,
+		predictor:    algorithm.Predictor(),
+		inputs:       objective.first,
+		model: &Model{
+			Case:         c,
+			InputDim:     inputDim,
+			OutputDim:    outputDim,
+			Parameters:   result.X,
+			InputScaler:  inputScaler,
+			OutputScaler: outputScaler,
+		},
+	}, nil
+}
+
+// openBatches opens the data file of the case, returning it and a function
+// yielding its batches of size rows, in a random order if shuffle is not nil.
+func openBatches(c Case, size int, shuffle *rand.Rand) (*os.File, func() (*mat64.Dense, error), error) {
+	f, err := os.Open(c.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := numcsv.NewReader(f)
+	r.Comma = c.Comma
+	r.Shuffle = shuffle
+	if _, err := r.ReadHeading(); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, r.Batches(size), nil
+}
+
+// streamScalers makes a pass over the data file of the case, returning the
+// number of samples and of inputs, and the scalers of the inputs and of the
+// c.Outputs output columns fit to them.
+func streamScalers(c Case) (n, nIn int, inputScaler, outputScaler *Scaler, err error) {
+	f, next, err := openBatches(c, statsBatch, nil)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	defer f.Close()
+	var mean, m2, min, max []float64 // m2 is the sum of squared deviations
+	for {
+		d, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, 0, nil, nil, err
+		}
+		r, nDim := d.Dims()
+		if mean == nil {
+			if nDim <= c.Outputs {
+				return 0, 0, nil, nil, fmt.Errorf("%s has %d columns, need more than the %d outputs", c.Data, nDim, c.Outputs)
+			}
+			mean, m2 = make([]float64, nDim), make([]float64, nDim)
+			min, max = make([]float64, nDim), make([]float64, nDim)
+			for j := range min {
+				min[j], max[j] = math.Inf(1), math.Inf(-1)
+			}
+		}
+		for i := 0; i < r; i++ {
+			n++
+			for j, v := range d.RowView(i) {
+				delta := v - mean[j]
+				mean[j] += delta / float64(n)
+				m2[j] += delta * (v - mean[j])
+				min[j] = math.Min(min[j], v)
+				max[j] = math.Max(max[j], v)
+			}
+		}
+	}
+	if n < 2 {
+		return 0, 0, nil, nil, fmt.Errorf("%d samples in %s, need at least 2", n, c.Data)
+	}
+	sigma := make([]float64, len(mean))
+	for j, s := range m2 {
+		sigma[j] = math.Sqrt(s / float64(n))
+	}
+	nIn = len(mean) - c.Outputs
+	inputScaler, err = fittedScaler(c.InputScaling, mean[:nIn], sigma[:nIn], min[:nIn], max[:nIn])
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	outputScaler, err = fittedScaler(c.OutputScaling, mean[nIn:], sigma[nIn:], min[nIn:], max[nIn:])
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	return n, nIn, inputScaler, outputScaler, nil
+}
+
+// streamBatch is a scaled mini-batch, or the error that ended the stream.
+type streamBatch struct {
+	inputs, outputs *mat64.Dense
+	err             error
+}
+
+// batchStream reads the scaled mini-batches of a case in the background,
+// starting again at the top of the file after the last one, until closed. A
+// sequential stream marks the end of each pass over the file with an empty
+// batch.
+type batchStream struct {
+	c                         Case
+	nIn                       int
+	inputScaler, outputScaler scale.Scaler
+
+	batches chan streamBatch
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// newBatchStream starts streaming the batches of the case. Shuffled streams
+// are seeded from the source of the case, so they follow the seed of the run.
+func newBatchStream(c Case, nIn int, inputScaler, outputScaler *Scaler) *batchStream {
+	s := &batchStream{
+		c:            c,
+		nIn:          nIn,
+		inputScaler:  inputScaler.scaler(),
+		outputScaler: outputScaler.scaler(),
+		batches:      make(chan streamBatch, prefetch),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go s.read(c.random().Int63())
+	return s
+}
+
+func (s *batchStream) read(seed int64) {
+	defer close(s.done)
+	for epoch := int64(0); ; epoch++ {
+		var shuffle *rand.Rand
+		if s.c.Stream == streamShuffled {
+			shuffle = rand.New(rand.NewSource(seed + epoch))
+		}
+		f, next, err := openBatches(s.c, s.c.BatchSize, shuffle)
+		if err != nil {
+			s.send(streamBatch{err: err})
+			return
+		}
+		var rows int
+		for {
+			d, err := next()
+			if err == io.EOF {
+				break
+			}
+			b := streamBatch{err: err}
+			if err == nil {
+				b = s.split(d)
+				r, _ := d.Dims()
+				rows += r
+			}
+			if !s.send(b) || b.err != nil {
+				f.Close()
+				return
+			}
+		}
+		f.Close()
+		if rows == 0 {
+			s.send(streamBatch{err: fmt.Errorf("no samples in %s", s.c.Data)})
+			return
+		}
+		if s.c.Stream == streamSequential && !s.send(streamBatch{}) {
+			return
+		}
+	}
+}
+
+// send sends the batch, returning false if the stream was closed first.
+func (s *batchStream) send(b streamBatch) bool {
+	select {
+	case s.batches <- b:
+		return true
+	case <-s.quit:
+		return false
+	}
+}
+
+// split returns the scaled inputs and outputs of the batch.
+func (s *batchStream) split(d *mat64.Dense) streamBatch {
+	r, nDim := d.Dims()
+	nIn := s.nIn
+	if nDim != nIn+s.c.Outputs {
+		return streamBatch{err: fmt.Errorf("batch of %d columns, want %d", nDim, nIn+s.c.Outputs)}
+	}
+	b := streamBatch{inputs: mat64.NewDense(r, nIn, nil), outputs: mat64.NewDense(r, nDim-nIn, nil)}
+	for i := 0; i < r; i++ {
+		row := d.RowView(i)
+		in, out := b.inputs.RowView(i), b.outputs.RowView(i)
+		copy(in, row[:nIn])
+		copy(out, row[nIn:])
+		if err := s.inputScaler.Scale(in); err != nil {
+			return streamBatch{err: err}
+		}
+		if err := s.outputScaler.Scale(out); err != nil {
+			return streamBatch{err: err}
+		}
+	}
+	return b
+}
+
+// Close stops the stream and waits for the file to be closed.
+func (s *batchStream) Close() {
+	close(s.quit)
+	<-s.done
+}
+
+// streamObjective is the mean loss of the next streamed mini-batch, the
+// counterpart of a GradOptimizable with a mini-batch sampler. The rows of a
+// batch are split between the workers. A read error stops the optimizer
+// through Status.
+type streamObjective struct {
+	trainable   train.Trainable
+	losser      loss.DerivLosser
+	regularizer regularize.Regularizer // nil for none
+	stream      *batchStream
+	workers     []*streamWorker
+	regDeriv    []float64
+
+	first      *mat64.Dense  // scaled inputs of the first batch, for timing prediction
+	eval, wait time.Duration // in evaluations excluding waiting, and waiting for batches
+	err        error
+}
+
+// streamWorker holds the buffers of one worker of a streamObjective.
+type streamWorker struct {
+	deriver                         train.LossDeriver
+	featurizer                      train.Featurizer
+	features, pred, dLossDPred, out []float64
+	dLossDParam, deriv              []float64
+	loss                            float64
+}
+
+func newStreamObjective(t train.Trainable, losser loss.DerivLosser, regularizer regularize.Regularizer, nWorkers int, stream *batchStream) *streamObjective {
+	if nWorkers < 1 {
+		nWorkers = 1
+	}
+	o := &streamObjective{
+		trainable:   t,
+		losser:      losser,
+		regularizer: regularizer,
+		stream:      stream,
+		regDeriv:    make([]float64, t.NumParameters()),
+	}
+	for i := 0; i < nWorkers; i++ {
+		o.workers = append(o.workers, &streamWorker{
+			deriver:     t.NewLossDeriver(),
+			featurizer:  t.NewFeaturizer(),
+			features:    make([]float64, t.NumFeatures()),
+			pred:        make([]float64, t.OutputDim()),
+			dLossDPred:  make([]float64, t.OutputDim()),
+			out:         make([]float64, t.OutputDim()),
+			dLossDParam: make([]float64, t.NumParameters()),
+			deriv:       make([]float64, t.NumParameters()),
+		})
+	}
+	return o
+}
+
+func (o *streamObjective) F(params []float64) float64 {
+	return o.FDf(params, make([]float64, len(params)))
+}
+
+func (o *streamObjective) FDf(params, deriv []float64) float64 {
+	start := time.Now()
+	b := <-o.stream.batches
+	for b.err == nil && b.inputs == nil {
+		b = <-o.stream.batches // the end of an epoch of a sequential stream
+	}
+	o.wait += time.Since(start)
+	start = time.Now()
+	defer func() { o.eval += time.Since(start) }()
+	if b.err != nil {
+		if o.err == nil {
+			o.err = b.err
+		}
+		for i := range deriv {
+			deriv[i] = math.NaN()
+		}
+		return math.NaN()
+	}
+	if o.first == nil {
+		o.first = b.inputs
+	}
+	n, _ := b.inputs.Dims()
+	l := o.batchLoss(params, b, deriv)
+	if o.regularizer != nil {
+		l += o.regularizer.LossDeriv(params, o.regDeriv)
+		floats.Add(deriv, o.regDeriv)
+	}
+	floats.Scale(1/float64(n), deriv)
+	return l / float64(n)
+}
+
+// Status stops the optimizer with the error of the stream if it failed.
+func (o *streamObjective) Status() (opt.Status, error) {
+	if o.err != nil {
+		return opt.Failure, o.err
+	}
+	return opt.NotTerminated, nil
+}
+
+// batchLoss returns the summed loss of the batch and puts its summed
+// derivative into deriv if it is not nil.
+func (o *streamObjective) batchLoss(params []float64, b streamBatch, deriv []float64) float64 {
+	n, _ := b.inputs.Dims()
+	var wg sync.WaitGroup
+	for i, w := range o.workers {
+		lo, hi := i*n/len(o.workers), (i+1)*n/len(o.workers)
+		wg.Add(1)
+		go func(w *streamWorker) {
+			defer wg.Done()
+			w.loss = 0
+			for j := range w.deriv {
+				w.deriv[j] = 0
+			}
+			for k := lo; k < hi; k++ {
+				w.featurizer.Featurize(b.inputs.RowView(k), w.features)
+				w.deriver.Predict(params, w.features, w.pred)
+				b.outputs.Row(w.out, k)
+				w.loss += o.losser.LossDeriv(w.pred, w.out, w.dLossDPred)
+				if deriv != nil {
+					w.deriver.Deriv(params, w.features, w.pred, w.dLossDPred, w.dLossDParam)
+					floats.Add(w.deriv, w.dLossDParam)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	for i := range deriv {
+		deriv[i] = 0
+	}
+	var l float64
+	for _, w := range o.workers {
+		l += w.loss
+		if deriv != nil {
+			floats.Add(deriv, w.deriv)
+		}
+	}
+	return l
+}
+
+// epochLoss returns the mean loss over one pass of the sequential stream,
+// including the penalty on the parameters as a GradOptimizable does.
+func (o *streamObjective) epochLoss(params []float64, s *batchStream) (float64, error) {
+	var l float64
+	var n int
+	for b := range s.batches {
+		if b.err != nil {
+			return 0, b.err
+		}
+		if b.inputs == nil {
+			break
+		}
+		r, _ := b.inputs.Dims()
+		n += r
+		l += o.batchLoss(params, b, nil)
+	}
+	if o.regularizer != nil {
+		l += o.regularizer.Loss(params)
+	}
+	return l / float64(n), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// parentFlags are the flags of the parent process that do not pass to the
+// subprocess running each case, as the parent selects and expands the cases,
+// and seeds and reports on them.
+var parentFlags = map[string]bool{
+	"config":     true,
+	"suite":      true,
+	"sweep":      true,
+	"search":     true,
+	"scaling":    true,
+	"out":        true,
+	"baseline":   true,
+	"threshold":  true,
+	"seed":       true,
+	"subprocess": true,
+	"caseof":     true,
+	"checkpoint": true,
+	"resume":     true,
+	"remote":     true,
+	"listen":     true,
+	"store":      true,
+	"history":    true,
+	"push":       true,
+}
+
+// childFlags returns the flags this process was given apart from the
+// parentFlags, as arguments for a process running one of its cases.
+func childFlags() []string {
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if !parentFlags[f.Name] {
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return args
+}
+
+// runSubprocess runs the case in a new process of this program, with the
+// flags, such as those of childFlags, and returns the records of its runs,
+// including any that did not finish. Each case then starts with a fresh heap
+// and garbage collector and no goroutines left by earlier cases, at the cost
+// of loading its data again. The case is passed as a config of one case, with
+// nCases, the number of cases of the whole benchmark, so that files are named
+// as they are in process. The output of the subprocess goes to this
+// process's.
+func runSubprocess(c Case, seed int64, nCases int, flags []string) ([]Record, error) {
+	dir, err := os.MkdirTemp("", "nettrainbench")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	config, err := json.Marshal(Config{Cases: []Case{c}})
+	if err != nil {
+		return nil, err
+	}
+	configFile := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configFile, config, 0644); err != nil {
+		return nil, err
+	}
+	outFile := filepath.Join(dir, "results.json")
+	args := []string{
+		"-config", configFile,
+		"-out", outFile,
+		"-seed", strconv.FormatInt(seed, 10),
+		"-caseof", strconv.Itoa(nCases),
+	}
+	args = append(args, flags...)
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	runErr := cmd.Run()
+	// The subprocess exits with status 1 if a run did not finish, having
+	// written its records, so only fail without them.
+	f, err := os.Open(outFile)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("subprocess: %v", runErr)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var records []Record
+	if err := json.NewDecoder(f).Decode(&records); err != nil {
+		return nil, fmt.Errorf("subprocess results: %v", err)
+	}
+	return records, nil
+}
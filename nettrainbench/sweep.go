@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sweep is a cartesian grid of cases. Empty dimensions take the value of the
+// base case.
+type Sweep struct {
+	Neurons []int `json:"neurons"`
+	Layers  []int `json:"layers"`
+	NData   []int `json:"ndata"`
+	Workers []int `json:"workers"`
+}
+
+// parseSweep parses a sweep of the form "neurons=5,20;layers=1,2".
+func parseSweep(s string) (*Sweep, error) {
+	sw := &Sweep{}
+	for _, dim := range strings.Split(s, ";") {
+		kv := strings.SplitN(dim, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("sweep: bad dimension %q", dim)
+		}
+		var vals *[]int
+		switch strings.TrimSpace(kv[0]) {
+		case "neurons":
+			vals = &sw.Neurons
+		case "layers":
+			vals = &sw.Layers
+		case "ndata":
+			vals = &sw.NData
+		case "workers":
+			vals = &sw.Workers
+		default:
+			return nil, fmt.Errorf("sweep: unknown dimension %q", kv[0])
+		}
+		for _, str := range strings.Split(kv[1], ",") {
+			v, err := strconv.Atoi(strings.TrimSpace(str))
+			if err != nil {
+				return nil, fmt.Errorf("sweep: %v", err)
+			}
+			*vals = append(*vals, v)
+		}
+	}
+	return sw, nil
+}
+
+// cases returns a case for each cell of the grid, named after the values of
+// the swept dimensions.
+func (s *Sweep) cases(base Case) []Case {
+	cases := []Case{base}
+	expand := func(name string, vals []int, set func(*Case, int)) {
+		if len(vals) == 0 {
+			return
+		}
+		var next []Case
+		for _, c := range cases {
+			for _, v := range vals {
+				c := c
+				set(&c, v)
+				c.Name = fmt.Sprintf("%s_%s%d", c.Name, name, v)
+				next = append(next, c)
+			}
+		}
+		cases = next
+	}
+	expand("neurons", s.Neurons, func(c *Case, v int) { c.Neurons = v })
+	expand("layers", s.Layers, func(c *Case, v int) { c.Layers = v })
+	expand("ndata", s.NData, func(c *Case, v int) { c.NData = v })
+	expand("workers", s.Workers, func(c *Case, v int) { c.Workers = v })
+	return cases
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// profileTop is the number of functions of the CPU profile of each run kept
+// in the results by -profilesummary.
+const profileTop = 10
+
+// FuncTime is the CPU time of a function in a profile.
+type FuncTime struct {
+	Function string  `json:"function"`
+	Flat     float64 `json:"flat"` // seconds in the function itself
+	Cum      float64 `json:"cum"`  // seconds in the function and those it called
+}
+
+// topFunctions reads the CPU profile written by runtime/pprof to the file and
+// returns the n functions with the most cumulative time, most first, as
+// pprof -top -cum lists them.
+func topFunctions(filename string, n int) ([]FuncTime, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil // no samples were taken
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %v", filename, err)
+	}
+	b, err = io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %v", filename, err)
+	}
+	p, err := parseProfile(b)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %v", filename, err)
+	}
+	return p.top(n), nil
+}
+
+// profile is the part of a pprof profile.proto message needed to sum the time
+// of each function.
+type profile struct {
+	samples   []profileSample
+	locations map[uint64][]uint64 // function ids of each location, innermost first
+	functions map[uint64]int64    // string table index of the name of each function
+	strings   []string
+}
+
+type profileSample struct {
+	locations []uint64 // innermost first
+	value     int64    // the last value, nanoseconds of CPU for a CPU profile
+}
+
+// top returns the n functions of the profile with the most cumulative time.
+func (p *profile) top(n int) []FuncTime {
+	flat := make(map[string]int64)
+	cum := make(map[string]int64)
+	for _, s := range p.samples {
+		seen := make(map[string]bool)
+		for i, loc := range s.locations {
+			for j, fn := range p.locations[loc] {
+				name := p.name(fn)
+				if i == 0 && j == 0 {
+					flat[name] += s.value
+				}
+				if !seen[name] { // count recursive calls once
+					seen[name] = true
+					cum[name] += s.value
+				}
+			}
+		}
+	}
+	funcs := make([]FuncTime, 0, len(cum))
+	for name, c := range cum {
+		funcs = append(funcs, FuncTime{Function: name, Flat: float64(flat[name]) / 1e9, Cum: float64(c) / 1e9})
+	}
+	sort.Slice(funcs, func(i, j int) bool {
+		if funcs[i].Cum != funcs[j].Cum {
+			return funcs[i].Cum > funcs[j].Cum
+		}
+		return funcs[i].Function < funcs[j].Function
+	})
+	if len(funcs) > n {
+		funcs = funcs[:n]
+	}
+	return funcs
+}
+
+func (p *profile) name(fn uint64) string {
+	i, ok := p.functions[fn]
+	if !ok || i < 0 || int(i) >= len(p.strings) {
+		return fmt.Sprintf("function %d", fn)
+	}
+	return p.strings[i]
+}
+
+// Field numbers of profile.proto.
+const (
+	profileSampleField   = 2
+	profileLocationField = 4
+	profileFunctionField = 5
+	profileStringField   = 6
+
+	sampleLocationField = 1
+	sampleValueField    = 2
+
+	locationIDField   = 1
+	locationLineField = 4
+	lineFunctionField = 1
+
+	functionIDField   = 1
+	functionNameField = 2
+)
+
+// parseProfile decodes an uncompressed profile.proto message.
+func parseProfile(b []byte) (*profile, error) {
+	p := &profile{locations: make(map[uint64][]uint64), functions: make(map[uint64]int64)}
+	err := eachField(b, func(field int, v uint64, data []byte) error {
+		switch field {
+		case profileSampleField:
+			var s profileSample
+			err := eachField(data, func(field int, v uint64, data []byte) error {
+				switch field {
+				case sampleLocationField:
+					s.locations = appendVarints(s.locations, v, data)
+				case sampleValueField:
+					values := appendVarints(nil, v, data)
+					if len(values) > 0 {
+						s.value = int64(values[len(values)-1])
+					}
+				}
+				return nil
+			})
+			p.samples = append(p.samples, s)
+			return err
+		case profileLocationField:
+			var id uint64
+			var funcs []uint64
+			err := eachField(data, func(field int, v uint64, data []byte) error {
+				switch field {
+				case locationIDField:
+					id = v
+				case locationLineField:
+					return eachField(data, func(field int, v uint64, _ []byte) error {
+						if field == lineFunctionField {
+							funcs = append(funcs, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			p.locations[id] = funcs
+			return err
+		case profileFunctionField:
+			var id uint64
+			var name int64
+			err := eachField(data, func(field int, v uint64, _ []byte) error {
+				switch field {
+				case functionIDField:
+					id = v
+				case functionNameField:
+					name = int64(v)
+				}
+				return nil
+			})
+			p.functions[id] = name
+			return err
+		case profileStringField:
+			p.strings = append(p.strings, string(data))
+		}
+		return nil
+	})
+	return p, err
+}
+
+var errProto = errors.New("malformed protocol buffer")
+
+// eachField calls f with the number and value of each field of the protocol
+// buffer message b: the varint or fixed value, or the bytes of a length
+// delimited field.
+func eachField(b []byte, f func(field int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProto
+		}
+		b = b[n:]
+		var v uint64
+		var data []byte
+		switch key & 7 {
+		case 0: // varint
+			v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errProto
+			}
+			b = b[n:]
+		case 1: // 64 bit
+			if len(b) < 8 {
+				return errProto
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2: // length delimited
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return errProto
+			}
+			data, b = b[n:n+int(l)], b[n+int(l):]
+		case 5: // 32 bit
+			if len(b) < 4 {
+				return errProto
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return errProto
+		}
+		if err := f(int(key>>3), v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarints appends the value of a repeated integer field, which is
+// either the varint v or, if data is not nil, the packed varints of data.
+func appendVarints(s []uint64, v uint64, data []byte) []uint64 {
+	if data == nil {
+		return append(s, v)
+	}
+	for len(data) > 0 {
+		x, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		s, data = append(s, x), data[n:]
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/btracey/numcsv"
+	"github.com/gonum/opt"
+)
+
+// traceHeadings are the columns written by a tracer.
+var traceHeadings = []string{"iteration", "evals", "objective", "gradnorm", "seconds"}
+
+// tracer is an opt.Recorder that writes a row per major iteration of the
+// optimizer to a CSV file, and records the wall time of each iteration, and
+// the convergence if recordConvergence is set.
+type tracer struct {
+	f     *os.File
+	w     *numcsv.Writer
+	start time.Time
+	last  time.Time
+	row   []float64
+
+	iterSeconds []float64
+
+	recordConvergence bool
+	convergence       []Iterate
+}
+
+// newTracer creates the named trace file. If filename is "", the tracer only
+// records the iteration times.
+func newTracer(filename string) (*tracer, error) {
+	if filename == "" {
+		return &tracer{row: make([]float64, len(traceHeadings))}, nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := numcsv.NewWriter(f)
+	w.FloatFmt = 'g'
+	w.Prec = -1
+	return &tracer{f: f, w: w, row: make([]float64, len(traceHeadings))}, nil
+}
+
+func (t *tracer) Init(*opt.FunctionStats) error {
+	t.start = time.Now()
+	t.last = time.Time{}
+	t.convergence = t.convergence[:0]
+	if t.w == nil {
+		return nil
+	}
+	return t.w.WriteHeading(traceHeadings)
+}
+
+func (t *tracer) Record(l opt.Location, eval opt.EvaluationType, iter opt.IterationType, stats *opt.Stats) error {
+	if iter != opt.Major && iter != opt.NoIteration {
+		return nil
+	}
+	now := time.Now()
+	if iter == opt.Major {
+		// The first major iteration is the starting point.
+		if !t.last.IsZero() {
+			t.iterSeconds = append(t.iterSeconds, now.Sub(t.last).Seconds())
+		}
+		t.last = now
+	}
+	evals := stats.NumFunEvals + stats.NumGradEvals + stats.NumFunGradEvals
+	if t.recordConvergence {
+		t.convergence = append(t.convergence, Iterate{Evals: evals, Objective: l.F})
+	}
+	if t.w == nil {
+		return nil
+	}
+	t.row[0] = float64(stats.NumMajorIterations)
+	t.row[1] = float64(evals)
+	t.row[2] = l.F
+	t.row[3] = stats.GradNorm
+	t.row[4] = now.Sub(t.start).Seconds()
+	return t.w.Write(t.row)
+}
+
+// Close flushes and closes the trace file.
+func (t *tracer) Close() error {
+	if t.w == nil {
+		return nil
+	}
+	if err := t.w.Flush(); err != nil {
+		t.f.Close()
+		return err
+	}
+	return t.f.Close()
+}
+
+// recorders is an opt.Recorder that passes each call to all of its elements.
+type recorders []opt.Recorder
+
+func (r recorders) Init(f *opt.FunctionStats) error {
+	for _, rec := range r {
+		if err := rec.Init(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r recorders) Record(l opt.Location, eval opt.EvaluationType, iter opt.IterationType, stats *opt.Stats) error {
+	for _, rec := range r {
+		if err := rec.Record(l, eval, iter, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Marks of a commit in a trend whose times differ significantly from the
+// commit before.
+const (
+	trendSlower = "REGRESSION"
+	trendFaster = "improvement"
+)
+
+// trendPoint is the times of the runs of a benchmark at a commit.
+type trendPoint struct {
+	Git    string
+	First  time.Time // start of the earliest run
+	Values []float64
+	Median float64
+	Change float64 // of the median from the commit before, as a fraction
+	P      float64 // two-sided Mann-Whitney p-value of the change, NaN if not tested
+	Mark   string  // trendSlower or trendFaster if significant, else empty
+
+	ops []float64 // ns/op of the runs, the Values if some have no evaluations
+}
+
+// trendSeries is the points of a benchmark, a case and config on a machine,
+// at its last commits, oldest first.
+type trendSeries struct {
+	Name, Config, Machine string
+	Unit                  string // ns/eval, or ns/op for cases without evaluations
+	Points                []*trendPoint
+}
+
+// regressed returns whether the last commit of the series is a regression.
+func (s *trendSeries) regressed() bool {
+	return len(s.Points) > 1 && s.Points[len(s.Points)-1].Mark == trendSlower
+}
+
+// trends returns the series of each benchmark of the records over its last
+// commits, the commits ordered by the start of their first run. A commit is
+// marked if its times differ from those of the commit before with a
+// Mann-Whitney p-value below alpha and its median by more than the threshold
+// fraction, so that small but consistent differences of noise are not
+// flagged.
+func trends(records []Record, commits int, alpha, threshold float64) []*trendSeries {
+	type seriesKey struct{ name, config, machine string }
+	bySeries := make(map[seriesKey]*trendSeries)
+	byPoint := make(map[storeKey]*trendPoint)
+	var series []*trendSeries
+	for _, r := range records {
+		sk := seriesKey{r.Name, r.ConfigHash, r.Env.Machine}
+		s, ok := bySeries[sk]
+		if !ok {
+			s = &trendSeries{Name: r.Name, Config: r.ConfigHash, Machine: r.Env.Machine, Unit: "ns/eval"}
+			bySeries[sk] = s
+			series = append(series, s)
+		}
+		k := keyOf(r)
+		p, ok := byPoint[k]
+		if !ok {
+			p = &trendPoint{Git: r.Env.GitSHA, First: r.Start}
+			byPoint[k] = p
+			s.Points = append(s.Points, p)
+		}
+		if r.Start.Before(p.First) {
+			p.First = r.Start
+		}
+		if r.NsPerEval == 0 {
+			s.Unit = "ns/op"
+		}
+		p.Values = append(p.Values, r.NsPerEval)
+		p.ops = append(p.ops, float64(r.NsPerOp))
+	}
+	for _, s := range series {
+		if s.Unit == "ns/op" {
+			// Some runs have no evaluations, so compare whole runs.
+			for _, p := range s.Points {
+				p.Values = p.ops
+			}
+		}
+		sort.SliceStable(s.Points, func(i, j int) bool { return s.Points[i].First.Before(s.Points[j].First) })
+		if len(s.Points) > commits {
+			s.Points = s.Points[len(s.Points)-commits:]
+		}
+		for i, p := range s.Points {
+			p.Median = median(p.Values)
+			p.P = math.NaN()
+			if i == 0 {
+				continue
+			}
+			prev := s.Points[i-1]
+			p.Change = p.Median/prev.Median - 1
+			p.P = mannWhitney(prev.Values, p.Values)
+			if p.P < alpha && math.Abs(p.Change) > threshold {
+				p.Mark = trendFaster
+				if p.Change > 0 {
+					p.Mark = trendSlower
+				}
+			}
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		if series[i].Name != series[j].Name {
+			return series[i].Name < series[j].Name
+		}
+		return series[i].Machine < series[j].Machine
+	})
+	return series
+}
+
+// median returns the median of x, which it sorts.
+func median(x []float64) float64 {
+	if len(x) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(x)
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
+
+// exactMannWhitney is the most samples of both sides for which mannWhitney
+// counts the orderings exactly rather than using the normal approximation.
+const exactMannWhitney = 40
+
+// mannWhitney returns the two-sided p-value of the Mann-Whitney U test that x
+// and y are from the same distribution. Without ties and for few samples it
+// is exact, and otherwise from the normal approximation with corrections for
+// ties and continuity. It is 1 if either has no samples.
+func mannWhitney(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type obs struct {
+		v     float64
+		first bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range y {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+	// Sum the ranks of x, averaging over ties.
+	var rankSum, tieTerm float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSum - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+	if !ties && n1+n2 <= exactMannWhitney {
+		// P(U <= u) for the smaller tail, doubled.
+		lo := math.Min(u, float64(n1*n2)-u)
+		counts := uCounts(n1, n2)
+		var total, tail float64
+		for k, c := range counts {
+			total += c
+			if float64(k) <= lo {
+				tail += c
+			}
+		}
+		return math.Min(1, 2*tail/total)
+	}
+	n := float64(n1 + n2)
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// uCounts returns the number of orderings of n1 and n2 samples giving each
+// value of the Mann-Whitney U, by the recurrence of the largest sample being
+// from one side or the other.
+func uCounts(n1, n2 int) []float64 {
+	// c[i][j] holds the counts for i and j samples.
+	c := make([][][]float64, n1+1)
+	for i := range c {
+		c[i] = make([][]float64, n2+1)
+		for j := range c[i] {
+			c[i][j] = make([]float64, i*j+1)
+			if i == 0 || j == 0 {
+				c[i][j][0] = 1
+				continue
+			}
+			// Largest from the first side: it exceeds all j of the second.
+			for u, v := range c[i-1][j] {
+				c[i][j][u+j] += v
+			}
+			for u, v := range c[i][j-1] {
+				c[i][j][u] += v
+			}
+		}
+	}
+	return c[n1][n2]
+}
+
+// writeTrendText writes a table of each series.
+func writeTrendText(w io.Writer, series []*trendSeries) error {
+	for _, s := range series {
+		fmt.Fprintf(w, "%s (config %s, machine %s), median %s\n", s.Name, s.Config, s.Machine, s.Unit)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "git\tdate\truns\tmedian\tchange\tp\t\t")
+		for i, p := range s.Points {
+			change, pValue := "", ""
+			if i > 0 {
+				change, pValue = fmt.Sprintf("%+.1f%%", 100*p.Change), fmt.Sprintf("%.3g", p.P)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%.4g\t%s\t%s\t%s\t\n", shortSHA(p.Git), p.First.Format("2006-01-02 15:04"), len(p.Values), p.Median, change, pValue, p.Mark)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// shortSHA returns the first 12 characters of a commit, keeping a "+dirty"
+// suffix.
+func shortSHA(sha string) string {
+	short := strings.TrimSuffix(sha, "+dirty")
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	if strings.HasSuffix(sha, "+dirty") {
+		short += "+dirty"
+	}
+	return short
+}
+
+var trendHTML = template.Must(template.New("trend").Funcs(template.FuncMap{
+	"short":   shortSHA,
+	"percent": func(f float64) string { return fmt.Sprintf("%+.1f%%", 100*f) },
+	"num":     func(f float64) string { return fmt.Sprintf("%.4g", f) },
+	"date":    func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>nettrainbench trends</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { padding: 2px 8px; text-align: right; border-bottom: 1px solid #ddd; }
+tr.REGRESSION { background: #fbb; }
+tr.improvement { background: #bfb; }
+</style>
+</head>
+<body>
+<h1>nettrainbench trends</h1>
+{{range .}}
+<h2>{{.Name}}</h2>
+<p>config {{.Config}}, machine {{.Machine}}, median {{.Unit}}</p>
+<table>
+<tr><th>git</th><th>date</th><th>runs</th><th>median</th><th>change</th><th>p</th><th></th></tr>
+{{range $i, $p := .Points}}<tr class="{{$p.Mark}}"><td>{{short $p.Git}}</td><td>{{date $p.First}}</td><td>{{len $p.Values}}</td><td>{{num $p.Median}}</td>{{if $i}}<td>{{percent $p.Change}}</td><td>{{num $p.P}}</td>{{else}}<td></td><td></td>{{end}}<td>{{$p.Mark}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// writeTrendHTML writes the series as an HTML page, with the marked commits
+// highlighted.
+func writeTrendHTML(w io.Writer, series []*trendSeries) error {
+	return trendHTML.Execute(w, series)
+}
+
+// writeReport writes the series to the named file, as HTML if it ends in
+// ".html" and text otherwise, and returns the names of those that regressed
+// at their last commit.
+func writeReport(filename string, series []*trendSeries) (regressed []string, err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(filename) == ".html" {
+		err = writeTrendHTML(f, series)
+	} else {
+		err = writeTrendText(f, series)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	for _, s := range series {
+		if s.regressed() {
+			regressed = append(regressed, s.Name+" on "+s.Machine)
+		}
+	}
+	return regressed, f.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Sample weightings of a case.
+const (
+	weightColumn     = "column"     // read from the column before the outputs
+	weightImportance = "importance" // random log-normal importance weights
+)
+
+// firstColumn returns the first column of m and a copy of the rest.
+func firstColumn(m *mat64.Dense) ([]float64, *mat64.Dense) {
+	r, c := m.Dims()
+	col := make([]float64, r)
+	for i := range col {
+		col[i] = m.At(i, 0)
+	}
+	rest := &mat64.Dense{}
+	rest.Submatrix(m, 0, 1, r, c-1)
+	return col, rest
+}
+
+// importanceWeights returns n weights exp(z) with z standard normal, drawn
+// from rnd.
+func importanceWeights(n int, rnd *rand.Rand) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = math.Exp(rnd.NormFloat64())
+	}
+	return w
+}
+
+// normalizeWeights scales the weights to a mean of one, so the weighted loss
+// is comparable to the unweighted one.
+func normalizeWeights(w []float64) error {
+	var sum float64
+	for i, v := range w {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("weight %v of sample %d is not a finite non-negative number", v, i)
+		}
+		sum += v
+	}
+	if sum == 0 {
+		return fmt.Errorf("weights sum to zero")
+	}
+	for i := range w {
+		w[i] *= float64(len(w)) / sum
+	}
+	return nil
+}